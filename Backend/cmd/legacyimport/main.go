@@ -0,0 +1,291 @@
+// Command legacyimport imports the sheets of the legacy yard spreadsheet, saved as CSV,
+// through the legacy import API of a running instance:
+//
+//	go run ./cmd/legacyimport -url https://api.example.com -token $ADMIN_TOKEN \
+//		-mapping mapping.json customers=Customers.csv cabs=Cabs.csv sales=Sales.csv
+//
+// Sheets are imported in dependency order (customers, cabs, accessories, materials,
+// then sales) whatever the order of the arguments. The mapping file holds the column
+// header of each field that is not named after it, by kind:
+//
+//	{"customers": {"legacy_id": "Cust No", "full_name": "Customer Name"}}
+//
+// A sheet whose import stops part way is resumed, up to -retries times, and the command
+// resumes an unfinished run of the same file left by an earlier invocation rather than
+// starting over. It stops at the first sheet that cannot be imported, since the later
+// sheets may refer to its rows.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+)
+
+// config holds the command line options
+type config struct {
+	BaseURL string
+	Token   string
+	Mapping string // Path of the JSON mapping file
+	Retries int
+	DryRun  bool
+	Timeout time.Duration
+	Sheets  map[string]string // CSV file of each kind
+}
+
+// client sends the sheets to the legacy import API
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func main() {
+	defaultURL := os.Getenv("LEGACYIMPORT_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:8080"
+	}
+
+	cfg := config{Sheets: map[string]string{}}
+	flag.StringVar(&cfg.BaseURL, "url", defaultURL, "base URL of the API, without the /api prefix (env LEGACYIMPORT_URL)")
+	flag.StringVar(&cfg.Token, "token", os.Getenv("LEGACYIMPORT_TOKEN"), "admin access token (env LEGACYIMPORT_TOKEN)")
+	flag.StringVar(&cfg.Mapping, "mapping", "", "JSON file of the column header of each field, by kind")
+	flag.IntVar(&cfg.Retries, "retries", 3, "times a sheet whose import stops is resumed")
+	flag.BoolVar(&cfg.DryRun, "dry-run", false, "only report what each sheet would import")
+	flag.DurationVar(&cfg.Timeout, "timeout", 5*time.Minute, "timeout of each request")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: legacyimport [flags] kind=file.csv ...\nKinds: %s\n", strings.Join(models.LegacyImportKinds, ", "))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	for _, arg := range flag.Args() {
+		kind, path, ok := strings.Cut(arg, "=")
+		if !ok || path == "" {
+			fmt.Fprintf(os.Stderr, "legacy import: %q is not kind=file.csv\n", arg)
+			os.Exit(2)
+		}
+		cfg.Sheets[kind] = path
+	}
+
+	if err := run(cfg, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "legacy import failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run imports each sheet of cfg in dependency order, reporting to out. It returns the
+// first sheet that could not be imported.
+func run(cfg config, out io.Writer) error {
+	if cfg.Token == "" {
+		return fmt.Errorf("an admin token is required")
+	}
+	if len(cfg.Sheets) == 0 {
+		return fmt.Errorf("no sheets to import")
+	}
+	for kind := range cfg.Sheets {
+		if !isLegacyKind(kind) {
+			return fmt.Errorf("unknown kind %q; kinds are %s", kind, strings.Join(models.LegacyImportKinds, ", "))
+		}
+	}
+	mappings := map[string]map[string]string{}
+	if cfg.Mapping != "" {
+		data, err := os.ReadFile(cfg.Mapping)
+		if err != nil {
+			return fmt.Errorf("could not read mapping: %w", err)
+		}
+		if err := json.Unmarshal(data, &mappings); err != nil {
+			return fmt.Errorf("mapping must be a JSON object of kinds to field mappings: %w", err)
+		}
+	}
+
+	c := &client{baseURL: strings.TrimRight(cfg.BaseURL, "/"), token: cfg.Token, http: &http.Client{Timeout: cfg.Timeout}}
+	for _, kind := range models.LegacyImportKinds {
+		path, ok := cfg.Sheets[kind]
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+		if err := importSheet(c, cfg, out, kind, filepath.Base(path), data, mappings[kind]); err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// isLegacyKind reports whether kind is a kind of legacy sheet
+func isLegacyKind(kind string) bool {
+	for _, k := range models.LegacyImportKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// importSheet imports a sheet, resuming its run while it stops part way
+func importSheet(c *client, cfg config, out io.Writer, kind, fileName string, data []byte, mapping map[string]string) error {
+	if cfg.DryRun {
+		report, status, err := c.upload("/api/admin/legacy-imports/"+kind+"?dry_run=true", fileName, data, mapping)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusOK {
+			return fmt.Errorf("dry run answered status %d", status)
+		}
+		printReport(out, kind, "dry run", report)
+		return nil
+	}
+
+	// An earlier invocation may have left an unfinished run of the file
+	sum := sha256.Sum256(data)
+	runID, err := c.unfinishedRun(kind, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		var report *api.LegacyImportResponse
+		var status int
+		if runID == "" {
+			report, status, err = c.upload("/api/admin/legacy-imports/"+kind, fileName, data, mapping)
+		} else {
+			report, status, err = c.upload("/api/admin/legacy-imports/"+runID+"/resume", fileName, data, nil)
+		}
+		if err != nil {
+			return err
+		}
+		if report.Run == nil {
+			return fmt.Errorf("import answered status %d", status)
+		}
+		runID = report.Run.ID
+
+		if status == http.StatusOK {
+			printReport(out, kind, "run "+runID+" completed", report)
+			return nil
+		}
+		printReport(out, kind, "run "+runID+" stopped", report)
+		if attempt == cfg.Retries {
+			return fmt.Errorf("run %s stopped: %s", runID, report.Run.Error)
+		}
+		fmt.Fprintf(out, "%s: resuming run %s from row %d of %d\n", kind, runID, report.Run.NextRow+1, report.Run.TotalRows)
+	}
+}
+
+// printReport writes the counts of a run and the rows it rejected
+func printReport(out io.Writer, kind, outcome string, report *api.LegacyImportResponse) {
+	fmt.Fprintf(out, "%s: %s: %d inserted, %d matched, %d skipped, %d rejected\n", kind, outcome,
+		report.Summary[api.LegacyImportInsert], report.Summary[api.LegacyImportMatch],
+		report.Summary[api.LegacyImportSkip], report.Summary[api.LegacyImportReject])
+	for _, row := range report.Rows {
+		if row.Action == api.LegacyImportReject {
+			fmt.Fprintf(out, "  line %d: %s\n", row.Line, strings.Join(row.Errors, "; "))
+		}
+	}
+}
+
+// unfinishedRun returns the ID of an unfinished run of a kind with the file of checksum,
+// or "" when there is none
+func (c *client) unfinishedRun(kind, checksum string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/api/admin/legacy-imports", nil)
+	if err != nil {
+		return "", err
+	}
+	var runs api.LegacyImportRunsResponse
+	status, err := c.send(req, &runs)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("listing runs answered status %d", status)
+	}
+	for _, run := range runs.Runs {
+		if run.Kind == kind && run.Checksum == checksum && run.Status != models.LegacyImportCompleted {
+			return run.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// upload sends a sheet, with its mapping when it has one, and decodes the report. Error
+// statuses without a report are returned as errors.
+func (c *client) upload(path, fileName string, data []byte, mapping map[string]string) (*api.LegacyImportResponse, int, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := part.Write(data); err != nil {
+		return nil, 0, err
+	}
+	if len(mapping) > 0 {
+		encoded, err := json.Marshal(mapping)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := writer.WriteField("mapping", string(encoded)); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, &body)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	var report api.LegacyImportResponse
+	status, err := c.send(req, &report)
+	if err != nil {
+		return nil, status, err
+	}
+	return &report, status, nil
+}
+
+// send authenticates a request and decodes its JSON response into out. Statuses other
+// than 200 and 500 carry an api.ErrorResponse, which is returned as an error; a 500 of
+// the legacy import carries the report of the run that stopped.
+func (c *client) send(req *http.Request, out interface{}) (int, error) {
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("%s %s: could not read response: %w", req.Method, req.URL.Path, err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+		var apiErr api.ErrorResponse
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+			return resp.StatusCode, fmt.Errorf("%s %s: %s", req.Method, req.URL.Path, apiErr.Error)
+		}
+		return resp.StatusCode, fmt.Errorf("%s %s: status %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return resp.StatusCode, fmt.Errorf("%s %s: could not decode response: %w", req.Method, req.URL.Path, err)
+	}
+	return resp.StatusCode, nil
+}
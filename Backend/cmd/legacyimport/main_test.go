@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oop/internal/handlers"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyCustomers fails to create the customer with email failEmail the next failures times
+type flakyCustomers struct {
+	repositories.CustomerRepository
+	failEmail string
+	failures  int
+}
+
+func (r *flakyCustomers) CreateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
+	if customer.Email == r.failEmail && r.failures > 0 {
+		r.failures--
+		return nil, errors.New("connection reset")
+	}
+	return r.CustomerRepository.CreateCustomer(ctx, customer)
+}
+
+// startTestServer serves the legacy import routes on top of an in-memory store with an
+// admin, returning the URL and an admin token
+func startTestServer(t *testing.T, store *memory.Store, customers repositories.CustomerRepository) (string, string) {
+	jwtSecret := []byte("legacyimport-secret")
+	admin := &models.User{Username: "owner", Email: "owner@example.com", Password: "password123", Role: handlers.RoleAdmin}
+	require.NoError(t, store.Users.Create(context.Background(), admin))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": admin.Id, "role": handlers.RoleAdmin, "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString(jwtSecret)
+	require.NoError(t, err)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	handler := handlers.NewLegacyImportHandler(store.LegacyImports, store.Users, customers, store.Cabs, store.Accessories, store.Materials, store.Sales, jwtSecret)
+	handler.Payments = store.Payments
+	handler.Audit = handlers.NewChangeRecorder(store.Logs)
+	handler.Routes(app.Group("/api"), handlers.NewRouteMiddleware(jwtSecret))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return "http://" + ln.Addr().String(), token
+}
+
+// writeSheets writes the customer and sales sheets and the mapping of the customer
+// sheet's columns, returning the config importing them
+func writeSheets(t *testing.T, url, token string) config {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Customers.csv": "Cust No,Customer Name,email\nC-1,Juan Dela Cruz,juan@example.com\nC-2,Maria Santos,maria@example.com\nC-3,,nobody\n",
+		"Sales.csv":     "sale_date,customer,item_type,item,quantity,unit_price\n2019-03-31,C-2,cab,K-1,1,1000\n",
+		"mapping.json":  `{"customers": {"legacy_id": "Cust No", "full_name": "Customer Name"}}`,
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600))
+	}
+	return config{
+		BaseURL: url, Token: token, Mapping: filepath.Join(dir, "mapping.json"), Retries: 1, Timeout: 5 * time.Second,
+		Sheets: map[string]string{models.LegacySales: filepath.Join(dir, "Sales.csv"), models.LegacyCustomers: filepath.Join(dir, "Customers.csv")},
+	}
+}
+
+func TestRunResumesStoppedImports(t *testing.T) {
+	store := memory.NewStore()
+	customers := &flakyCustomers{CustomerRepository: store.Customers, failEmail: "maria@example.com", failures: 1}
+	url, token := startTestServer(t, store, customers)
+	cfg := writeSheets(t, url, token)
+
+	var out bytes.Buffer
+	require.NoError(t, run(cfg, &out), out.String())
+
+	assert.Contains(t, out.String(), "customers: resuming run")
+	assert.Contains(t, out.String(), "completed: 1 inserted, 0 matched, 0 skipped, 1 rejected")
+	assert.Contains(t, out.String(), "  line 4: full_name is required; email \"nobody\" is not an email address")
+	assert.Contains(t, out.String(), "sales: run", "sales are imported after customers")
+	assert.Contains(t, out.String(), "  line 2: no cab was imported with legacy_id \"K-1\"")
+	imported, err := store.Customers.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, imported, 2)
+}
+
+func TestRunResumesEarlierRun(t *testing.T) {
+	store := memory.NewStore()
+	customers := &flakyCustomers{CustomerRepository: store.Customers, failEmail: "maria@example.com", failures: 1}
+	url, token := startTestServer(t, store, customers)
+	cfg := writeSheets(t, url, token)
+	cfg.Retries = 0
+	delete(cfg.Sheets, models.LegacySales)
+
+	var out bytes.Buffer
+	require.Error(t, run(cfg, &out))
+	runs, err := store.LegacyImports.GetRuns()
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, models.LegacyImportFailed, runs[0].Status)
+
+	out.Reset()
+	require.NoError(t, run(cfg, &out), out.String())
+	assert.Contains(t, out.String(), "customers: run "+runs[0].ID+" completed")
+	runs, err = store.LegacyImports.GetRuns()
+	require.NoError(t, err)
+	assert.Len(t, runs, 1, "the earlier run is resumed rather than started over")
+	assert.Equal(t, 2, runs[0].Inserted)
+}
+
+func TestRunRequiresTokenAndKnownKinds(t *testing.T) {
+	assert.EqualError(t, run(config{Sheets: map[string]string{"customers": "c.csv"}}, &bytes.Buffer{}), "an admin token is required")
+	err := run(config{Token: "t", Sheets: map[string]string{"trucks": "t.csv"}}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, `unknown kind "trucks"`)
+}
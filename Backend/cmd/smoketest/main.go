@@ -0,0 +1,304 @@
+// Command smoketest runs a scripted end-to-end flow against a running instance of the API
+// and exits with a non-zero status as soon as a step fails. It is meant for post-deploy checks:
+//
+//	go run ./cmd/smoketest -url https://api.example.com
+//
+// The flow registers a throwaway staff account, logs in, creates a cab and a customer,
+// sells the cab to the customer and fetches the customer's sales report. The sale, customer
+// and cab are deleted afterwards unless -keep is set; the account is left in place since
+// staff cannot delete users.
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config holds the command line options
+type config struct {
+	BaseURL string
+	Timeout time.Duration
+	Keep    bool
+}
+
+// client is a minimal JSON client for the API that sends the bearer token once logged in
+type client struct {
+	baseURL string
+	http    *http.Client
+	token   string
+}
+
+// step is a single named check of the flow
+type step struct {
+	name string
+	run  func() error
+}
+
+func main() {
+	defaultURL := os.Getenv("SMOKETEST_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:8080"
+	}
+
+	var cfg config
+	flag.StringVar(&cfg.BaseURL, "url", defaultURL, "base URL of the deployed API, without the /api prefix (env SMOKETEST_URL)")
+	flag.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "timeout of each request")
+	flag.BoolVar(&cfg.Keep, "keep", false, "keep the created cab, customer and sale instead of deleting them")
+	flag.Parse()
+
+	if err := run(cfg, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "smoke test failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the flow and reports each step to out. It returns the first failure.
+func run(cfg config, out io.Writer) error {
+	c := &client{
+		baseURL: strings.TrimRight(cfg.BaseURL, "/"),
+		http:    &http.Client{Timeout: cfg.Timeout},
+	}
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		return err
+	}
+	password, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	email := "smoketest-" + suffix + "@example.com"
+
+	var (
+		cabID      int
+		customerID string
+		saleID     string
+	)
+	const cabPrice = 1000.0
+
+	steps := []step{
+		{"health", func() error {
+			return c.do(http.MethodGet, "/health", nil, nil, http.StatusOK)
+		}},
+		{"register", func() error {
+			return c.do(http.MethodPost, "/api/users/register", map[string]string{
+				"username": "smoketest_" + suffix,
+				"fullName": "Smoke Test " + suffix,
+				"email":    email,
+				"password": password,
+				"role":     "staff",
+			}, nil, http.StatusCreated)
+		}},
+		{"login", func() error {
+			var resp struct {
+				Token string `json:"token"`
+			}
+			if err := c.do(http.MethodPost, "/api/users/login", map[string]string{"username": email, "password": password}, &resp, http.StatusOK); err != nil {
+				return err
+			}
+			if resp.Token == "" {
+				return fmt.Errorf("login response has no token")
+			}
+			c.token = resp.Token
+			return nil
+		}},
+		{"create cab", func() error {
+			var resp struct {
+				ID int `json:"id"`
+			}
+			if err := c.do(http.MethodPost, "/api/cabs", map[string]interface{}{
+				"name":       "Smoke Test Cab " + suffix,
+				"make":       "Smoke Test",
+				"quantity":   1,
+				"price":      cabPrice,
+				"status":     "In Stock",
+				"unit_color": "White",
+			}, &resp, http.StatusCreated); err != nil {
+				return err
+			}
+			if resp.ID == 0 {
+				return fmt.Errorf("created cab has no ID")
+			}
+			cabID = resp.ID
+			return nil
+		}},
+		{"create customer", func() error {
+			var resp struct {
+				ID string `json:"id"`
+			}
+			if err := c.do(http.MethodPost, "/api/customers", map[string]string{
+				"fullName": "Smoke Test Customer " + suffix,
+				"email":    "smoketest-customer-" + suffix + "@example.com",
+				"phone":    "+639000000000",
+			}, &resp, http.StatusCreated); err != nil {
+				return err
+			}
+			if resp.ID == "" {
+				return fmt.Errorf("created customer has no ID")
+			}
+			customerID = resp.ID
+			return nil
+		}},
+		{"sell cab", func() error {
+			var resp struct {
+				SaleID     string  `json:"saleId"`
+				TotalPrice float64 `json:"totalPrice"`
+			}
+			if err := c.do(http.MethodPost, "/api/cabs/"+strconv.Itoa(cabID)+"/sell", map[string]interface{}{
+				"customerId": customerID,
+				"quantity":   1,
+			}, &resp, http.StatusCreated); err != nil {
+				return err
+			}
+			if resp.SaleID == "" {
+				return fmt.Errorf("sale response has no sale ID")
+			}
+			if resp.TotalPrice != cabPrice {
+				return fmt.Errorf("expected total price %.2f, got %.2f", cabPrice, resp.TotalPrice)
+			}
+			saleID = resp.SaleID
+			return nil
+		}},
+		{"fetch report", func() error {
+			var sales []struct {
+				ID         string
+				TotalPrice float64
+			}
+			if err := c.do(http.MethodGet, "/api/customers/"+customerID+"/sales", nil, &sales, http.StatusOK); err != nil {
+				return err
+			}
+			if len(sales) != 1 || sales[0].ID != saleID {
+				return fmt.Errorf("expected the customer's sales report to list sale %s, got %d sales", saleID, len(sales))
+			}
+
+			var items []struct {
+				ItemType   string
+				MultiCabID string
+			}
+			if err := c.do(http.MethodGet, "/api/sales/"+saleID+"/items", nil, &items, http.StatusOK); err != nil {
+				return err
+			}
+			if len(items) != 1 || items[0].ItemType != "cab" || items[0].MultiCabID != strconv.Itoa(cabID) {
+				return fmt.Errorf("expected sale %s to contain cab %d", saleID, cabID)
+			}
+			return nil
+		}},
+	}
+
+	for _, s := range steps {
+		started := time.Now()
+		if err := s.run(); err != nil {
+			fmt.Fprintf(out, "FAIL %s (%s)\n", s.name, time.Since(started).Round(time.Millisecond))
+			if !cfg.Keep {
+				cleanup(c, out, saleID, customerID, cabID)
+			}
+			return fmt.Errorf("%s: %w", s.name, err)
+		}
+		fmt.Fprintf(out, "PASS %s (%s)\n", s.name, time.Since(started).Round(time.Millisecond))
+	}
+
+	if !cfg.Keep {
+		cleanup(c, out, saleID, customerID, cabID)
+	}
+	return nil
+}
+
+// cleanup deletes whatever the flow created. Failures are reported but do not fail the run,
+// since the deployment itself was already verified.
+func cleanup(c *client, out io.Writer, saleID, customerID string, cabID int) {
+	var paths []string
+	if saleID != "" {
+		paths = append(paths, "/api/sales/"+saleID)
+	}
+	if customerID != "" {
+		paths = append(paths, "/api/customers/"+customerID)
+	}
+	if cabID != 0 {
+		paths = append(paths, "/api/cabs/"+strconv.Itoa(cabID))
+	}
+
+	for _, path := range paths {
+		if err := c.do(http.MethodDelete, path, nil, nil, 0); err != nil {
+			fmt.Fprintf(out, "WARN cleanup of %s failed: %v\n", path, err)
+		}
+	}
+}
+
+// do sends a JSON request and decodes the JSON response into out when it is not nil.
+// A wantStatus of 0 accepts any 2xx status.
+func (c *client) do(method, path string, body, out interface{}, wantStatus int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: could not read response: %w", method, path, err)
+	}
+
+	statusOK := resp.StatusCode == wantStatus
+	if wantStatus == 0 {
+		statusOK = resp.StatusCode >= 200 && resp.StatusCode < 300
+	}
+	if !statusOK {
+		return fmt.Errorf("%s %s: expected status %d, got %d: %s", method, path, wantStatus, resp.StatusCode, truncate(string(respBody), 200))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("%s %s: could not decode response: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// randomHex returns n random bytes, hex-encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate random value: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// truncate shortens s to at most n bytes for error messages
+func truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"oop/internal/app"
+	"oop/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestServer serves the whole API in mock mode, as the server command builds it,
+// and returns its URL and the default tenant's repositories
+func startTestServer(t *testing.T) (string, app.Repositories) {
+	t.Setenv("MOCK_MODE", "true")
+	t.Setenv("FRONTEND_URL", "http://localhost:9000")
+	cfg, err := app.LoadConfig([]byte("smoketest-secret-at-least-32-characters"))
+	require.NoError(t, err)
+	server, err := app.New(cfg)
+	require.NoError(t, err)
+	repos, err := server.Tenants.Get(models.DefaultTenantID)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Server.Listener(ln)
+	t.Cleanup(func() { server.Shutdown(context.Background()) })
+
+	return "http://" + ln.Addr().String(), repos
+}
+
+// counts returns how many cabs, customers and sales the repositories hold
+func counts(t *testing.T, repos app.Repositories) (int, int, int) {
+	cabs, err := repos.Cabs.GetCabs(context.Background(), models.CabFilter{})
+	require.NoError(t, err)
+	customers, err := repos.Customers.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	sales, err := repos.Sales.GetAll(context.Background(), models.SalesFilter{})
+	require.NoError(t, err)
+	return len(cabs), len(customers), len(sales)
+}
+
+func TestRunPasses(t *testing.T) {
+	url, repos := startTestServer(t)
+	cabs, customers, sales := counts(t, repos)
+
+	var out bytes.Buffer
+	err := run(config{BaseURL: url, Timeout: 5 * time.Second}, &out)
+	require.NoError(t, err, out.String())
+
+	for _, name := range []string{"health", "register", "login", "create cab", "create customer", "sell cab", "fetch report"} {
+		assert.Contains(t, out.String(), "PASS "+name)
+	}
+	assert.NotContains(t, out.String(), "WARN")
+
+	// Everything but the account is cleaned up
+	cabsAfter, customersAfter, salesAfter := counts(t, repos)
+	assert.Equal(t, cabs, cabsAfter)
+	assert.Equal(t, customers, customersAfter)
+	assert.Equal(t, sales, salesAfter)
+}
+
+func TestRunKeep(t *testing.T) {
+	url, repos := startTestServer(t)
+	_, _, sales := counts(t, repos)
+
+	var out bytes.Buffer
+	require.NoError(t, run(config{BaseURL: url, Timeout: 5 * time.Second, Keep: true}, &out))
+
+	_, _, salesAfter := counts(t, repos)
+	assert.Equal(t, sales+1, salesAfter)
+}
+
+func TestRunFailsOnUnexpectedStatus(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/health", func(c *fiber.Ctx) error { return c.Status(fiber.StatusServiceUnavailable).SendString("database down") })
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	var out bytes.Buffer
+	err = run(config{BaseURL: "http://" + ln.Addr().String(), Timeout: 5 * time.Second}, &out)
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "health: "))
+	assert.Contains(t, err.Error(), "expected status 200, got 503: database down")
+	assert.Contains(t, out.String(), "FAIL health")
+}
@@ -10,18 +10,9 @@ import (
 	"strings"
 	"time"
 
+	"oop/internal/app"
 	"oop/internal/config"
-	"oop/internal/handlers"
-	"oop/internal/middleware"
-	"oop/internal/repositories"
 
-	_ "oop/docs" // load API docs generated by Swag CLI
-
-	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
-	swagger "github.com/gofiber/swagger" // swagger handler
 	"github.com/joho/godotenv"
 )
 
@@ -41,6 +32,97 @@ var jwtSecret = []byte(getEnv("JWT_SECRET", "7b324dbe6535a315ef300b1c79bc35324c8
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
+// @description Send "Bearer <access token>" from POST /users/login. Routes without a lock in the docs are public.
+// @tag.name Auth
+// @tag.description Single sign-on through the company's identity provider. Public.
+// @tag.name Users
+// @tag.description Accounts, sessions, invites and password resets. Registering, logging in, refreshing, logging out, accepting an invite and resetting a password are public; the rest needs a token.
+// @tag.name Cabs
+// @tag.description Multicabs in stock. Listing and viewing are public; changes need a staff or admin token.
+// @tag.name Accessories
+// @tag.description Accessories in stock. Listing and viewing are public; changes need a staff or admin token.
+// @tag.name Materials
+// @tag.description Materials in stock, for staff and admins.
+// @tag.name Inventory
+// @tag.description Photos, labels, snapshots and exports of the stock. Item photos can be viewed without a token.
+// @tag.name Search
+// @tag.description One search across cabs, accessories, materials and customers, for staff and admins.
+// @tag.name Sales
+// @tag.description Sales, their items, statuses and receipts.
+// @tag.name Receipts
+// @tag.description Official receipt series and the numbers issued to sales.
+// @tag.name Customers
+// @tag.description Customers, their addresses and delivery estimates.
+// @tag.name Registrations
+// @tag.description Registrations of sold cabs with the LTO.
+// @tag.name Insurance
+// @tag.description Insurance policies of sold cabs.
+// @tag.name Registers
+// @tag.description Cash register sessions.
+// @tag.name Deposits
+// @tag.description Bank deposits of register cash.
+// @tag.name Expenses
+// @tag.description Yard expenses and their approval.
+// @tag.name Reports
+// @tag.description Sales, stock and financial reports.
+// @tag.name Analytics
+// @tag.description Breakdowns of sales for dashboards.
+// @tag.name Exports
+// @tag.description Background exports. Downloads are authenticated by their signed link instead of a token.
+// @tag.name Files
+// @tag.description Stored files, downloaded through signed links. Public.
+// @tag.name Tasks
+// @tag.description Follow-ups assigned to staff.
+// @tag.name Announcements
+// @tag.description Notices posted by admins for staff to acknowledge.
+// @tag.name Notifications
+// @tag.description Live notifications for signed-in users.
+// @tag.name Favorites
+// @tag.description Inventory items starred by the signed-in user.
+// @tag.name Change Requests
+// @tag.description Inventory edits proposed for a reviewer to apply.
+// @tag.name Price Changes
+// @tag.description Large price changes waiting for an admin to approve them.
+// @tag.name Anomalies
+// @tag.description Unusual sales and stock movements waiting for an admin to review them.
+// @tag.name Integrity
+// @tag.description Checks for inconsistent records and fixes for the safe ones. Admins only.
+// @tag.name Trash
+// @tag.description Deleted records admins can restore or purge.
+// @tag.name Undo
+// @tag.description Restores records deleted within the undo window.
+// @tag.name Numbering
+// @tag.description Display number formats of records.
+// @tag.name Settings
+// @tag.description Fiscal calendar, document template and other tenant settings.
+// @tag.name Accounting
+// @tag.description Postings to the accounting system. Admins only.
+// @tag.name Integrations
+// @tag.description Signed orders from partner systems and the Google Sheets export. Inbound orders are authenticated by their signature instead of a token.
+// @tag.name Legacy Import
+// @tag.description Sheets of the legacy yard system, imported in resumable batches. Admins only.
+// @tag.name Activity Logs
+// @tag.description Everyone's actions, readable by admins.
+// @tag.name Audit
+// @tag.description Verification of the activity log hash chain. Admins only.
+// @tag.name Features
+// @tag.description Features switched on for the tenant.
+// @tag.name Usage
+// @tag.description Tenant usage against its quotas. Admins only.
+// @tag.name Sandbox
+// @tag.description Training sandbox with sample data.
+// @tag.name Super Admin
+// @tag.description Tenants, managed by super admins outside any tenant.
+// @tag.name Meta
+// @tag.description Machine-readable descriptions of the API: the spec, a Postman collection and the changelog. Public.
+// @tag.name Captcha
+// @tag.description Cloudflare Turnstile verification. Public.
+// @tag.name Workers
+// @tag.description Background jobs and queues of the server and whether they are running. Admins only.
+// @tag.name Logging
+// @tag.description The level the server logs at, raised for a while to diagnose incidents. Admins only.
+// @tag.name Health
+// @tag.description Liveness check. Public.
 
 // Helper function to get environment variable or default value with validation and sanitization
 // Supported environment variables:
@@ -124,214 +206,48 @@ func getEnv(key, fallback string) string {
 	return value
 }
 
-// turnstileMiddleware creates a middleware that verifies Cloudflare Turnstile tokens
-func turnstileMiddleware() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// 1) grab the Turnstile token from the client
-		token := c.FormValue("cf-turnstile-response")
-		if token == "" {
-			log.Printf("Error: Missing Turnstile token in request")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "captcha token missing",
-			})
-		}
-
-		// Log token for debugging (truncate for security)
-		tokenLength := len(token)
-		truncatedToken := ""
-		if tokenLength > 10 {
-			truncatedToken = token[:5] + "..." + token[tokenLength-5:]
-		} else {
-			truncatedToken = token
-		}
-		log.Printf("Debug: Processing Turnstile token: %s", truncatedToken)
-
-		// 2) verify with Cloudflare
-		ok, err := handlers.VerifyTurnstile(token)
-		if err != nil {
-			log.Printf("Error: Turnstile verification failed: %v, token: %s", err, truncatedToken)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		if !ok {
-			log.Printf("Error: Invalid Turnstile captcha with token: %s", truncatedToken)
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "invalid captcha",
-			})
-		}
-
-		log.Printf("Success: Turnstile verification passed for token: %s", truncatedToken)
-		return c.Next()
-	}
-}
-
 func main() {
 	// Load env variables
 	err := godotenv.Load()
 
-	if err != nil {
+	// Mock mode may be enabled from the process environment, in which case no .env file is needed
+	mockMode, mockErr := config.LoadMockMode()
+	if mockErr != nil {
+		log.Fatalf("Failed to load mock mode configuration: %v", mockErr)
+	}
+
+	if err != nil && !mockMode {
 		log.Println("Failed to load environment variables... Retrying...")
 		return
 	}
 
-	if os.Getenv("FRONTEND_URL") == "" {
-		log.Fatalf("FRONTEND_URL is not set. Please set it to a valid frontend URL (e.g., http://localhost:9000).")
+	if mockMode && os.Getenv("FRONTEND_URL") == "" {
+		os.Setenv("FRONTEND_URL", "http://localhost:9000")
 	}
 
-	// Load db config
-	dbClient, err := initDatabase()
+	cfg, err := app.LoadConfig(jwtSecret)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		log.Fatalf("%v", err)
 	}
 
-	// Load and validate Turnstile config
-	if _, err := config.LoadTurnstileConfig(); err != nil {
-		log.Fatalf("Failed to load Turnstile configuration: %v", err)
+	// Build the repositories, services, handlers and jobs, and start the jobs
+	server, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
+	server.LogLevel.Install(os.Stderr) // From here on messages below LOG_LEVEL are dropped
+	server.Start()
 
 	// Create a shutdown channel
 	shutdown := make(chan struct{})
 
 	// Handle graceful shutdown on interrupt signal
-	go handleShutdown(dbClient, shutdown)
-
-	// Initialize Fiber app
-	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			// Default error handling
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			log.Printf("Error: %v", err) // Log the error
-			return c.Status(code).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		},
-	})
-
-	// Add middleware
-	app.Use(logger.New())
-	app.Use(recover.New())
-
-	// Get allowed origins from environment variable or use default for development
-	app.Use(cors.New(cors.Config{
-		AllowOrigins:     os.Getenv("FRONTEND_URL"),     // Restricted to specific origins from environment variable
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS", // Added OPTIONS for preflight
-		AllowCredentials: true,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-	}))
-
-	// Initialize repositories
-	userRepo := repositories.NewUserRepository(dbClient)
-	materialRepo := repositories.NewMaterialRepository(dbClient.DB)
-	accessoryRepo := repositories.NewAccessoryRepository(dbClient.DB)
-	customerRepo := repositories.NewCustomerRepository(dbClient.DB)
-
-	// Initialize cabs repository directly with DB
-	cabsRepo := repositories.NewCabsRepository(dbClient.DB)
-
-	// Initialize sales repository
-	saleRepo := repositories.NewSalesRepository(dbClient.DB)
-
-	// Initialize logs repository
-	logsRepo := repositories.NewLogsRepository(dbClient.DB) // Assuming dbClient.DB is the *sql.DB instance
-
-	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userRepo, jwtSecret)
-	materialHandler := handlers.NewMaterialHandlers(materialRepo, jwtSecret)
-	customerHandler := handlers.NewCustomerHandler(customerRepo, jwtSecret)
-	// Initialize cabs handler
-	cabsHandler := handlers.NewCabsHandlers(cabsRepo)
-	accessoryHandler := handlers.NewAccessoriesHandler(accessoryRepo)
-	// Initialize sales handler
-	saleHandler := handlers.NewSaleHandlers(saleRepo, cabsRepo, accessoryRepo, customerRepo, jwtSecret)
-	// Initialize activity log handler
-	activityLogHandler := handlers.NewActivityLogHandler(logsRepo)
-
-	// --- Route Registration ---
-	api := app.Group("/api") // Base group for API routes
-
-	// Swagger docs route
-	api.Get("/swagger/*", swagger.HandlerDefault) // get /api/swagger/*
-
-	// @Summary Submit Turnstile Captcha
-	// @Description Verifies a Cloudflare Turnstile token.
-	// @Tags Captcha
-	// @Accept x-www-form-urlencoded
-	// @Produce json
-	// @Param cf-turnstile-response formData string true "Cloudflare Turnstile Token"
-	// @Success 200 {object} fiber.Map{"status=ok"}
-	// @Failure 400 {object} fiber.Map{"error=captcha token missing"}
-	// @Failure 403 {object} fiber.Map{"error=invalid captcha"}
-	// @Failure 500 {object} fiber.Map{"error=verification failed"}
-	// @Router /submit [post]
-	app.Post("/submit", turnstileMiddleware(), func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{"status": "ok"})
-	})
-
-	// Public User Routes (register, login)
-	userHandler.RegisterRoutes(api) // This will now only register public routes
-	materialHandler.RegisterMaterialRoutes(api)
-	customerHandler.RegisterCustomerRoutes(api)
-
-	// Register Cabs routes - Detailed Swagger annotations are in cabs_handlers.go
-	api.Get("/cabs", cabsHandler.GetCabs)          // GET /api/cabs
-	api.Get("/cabs/:id", cabsHandler.GetCabByID)   // GET /api/cabs/:id
-	api.Post("/cabs", cabsHandler.AddCab)          // POST /api/cabs
-	api.Put("/cabs/:id", cabsHandler.UpdateCab)    // PUT /api/cabs/:id
-	api.Delete("/cabs/:id", cabsHandler.DeleteCab) // DELETE /api/cabs/:id
-
-	// Register Accessories routes - Detailed Swagger annotations are in accessories_handlers.go
-	api.Get("/accessories", accessoryHandler.GetAllAccessories)      // GET /api/accessories
-	api.Get("/accessories/:id", accessoryHandler.GetAccessoryByID)   // GET /api/accessories/:id
-	api.Post("/accessories", accessoryHandler.CreateAccessory)       // POST /api/accessories
-	api.Put("/accessories/:id", accessoryHandler.UpdateAccessory)    // PUT /api/accessories/:id
-	api.Delete("/accessories/:id", accessoryHandler.DeleteAccessory) // DELETE /api/accessories/:id
-
-	// Register Sale routes - Detailed Swagger annotations are in sales_handlers.go
-	saleHandler.RegisterSaleRoutes(api)
-
-	// Protected User Routes (require JWT)
-	authMiddleware := middleware.JWTMiddleware(jwtSecret)
-	userProtected := api.Group("/users", authMiddleware) // Apply middleware here
-
-	userProtected.Get("/", userHandler.GetAllUsers)
-	userProtected.Get("/:id", userHandler.GetUser)
-	userProtected.Put("/:id", userHandler.UpdateUser)
-	userProtected.Delete("/:id", userHandler.DeleteUser)
-	userProtected.Put("/:id/activate", userHandler.ActivateUser)
-	userProtected.Put("/:id/deactivate", userHandler.DeactivateUser)
-	userProtected.Put("/:id/password", userHandler.UpdatePassword)
-	userProtected.Post("/", userHandler.CreateUser)
-
-	// Protected Activity Log Routes (require JWT)
-	activityLogProtected := api.Group("/activity-logs", authMiddleware)
-	activityLogProtected.Get("/", activityLogHandler.GetActivityLogs)
-	activityLogProtected.Get("/filter", activityLogHandler.GetFilteredActivityLogs)
-	activityLogProtected.Post("/", activityLogHandler.CreateActivityLog)
-
-	// Add a health check endpoint (public)
-	// @Summary Health Check
-	// @Description Checks if the server is running
-	// @Tags Health
-	// @Accept json
-	// @Produce json
-	// @Success 200 {object} fiber.Map
-	// @Router /health [get]
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.Status(fiber.StatusOK).JSON(fiber.Map{
-			"status": "ok",
-			"time":   time.Now().Format(time.RFC3339),
-		})
-	})
+	go handleShutdown(shutdown)
 
 	// Start server in a goroutine so we can listen for shutdown signal
 	go func() {
 		port := getEnv("PORT", "8080")
-		log.Printf("Starting server on :%s", port)
-		if err := app.Listen(":" + port); err != nil {
+		if err := server.Listen(":" + port); err != nil {
 			log.Printf("Server error: %v", err)
 			close(shutdown) // Signal shutdown if server fails
 		}
@@ -341,49 +257,22 @@ func main() {
 	<-shutdown
 	log.Println("Shutting down gracefully...")
 
-	// Shutdown the server with a timeout
+	// Stop serving, then stop the jobs, flush the queues and close the database, with a timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-
-	if err := app.ShutdownWithContext(ctx); err != nil {
-		log.Printf("Error during server shutdown: %v", err)
-	}
+	server.Shutdown(ctx) // Failures are logged
 
 	log.Println("Server shutdown complete")
 }
 
-// initDatabase loads the database configuration, connects to the database,
-// It returns a DatabaseClient pointer and an error if initialization fails.
-func initDatabase() (*repositories.DatabaseClient, error) {
-	dbConfig, err := config.LoadDatabaseConfig()
-	if err != nil {
-		return nil, fmt.Errorf("load database config: %v", err)
-	}
-
-	dbClient, err := repositories.NewDatabaseClient(dbConfig)
-	if err != nil {
-		return nil, fmt.Errorf("connect to database: %v", err)
-	}
-
-	log.Println("Database connection test successful.")
-	return dbClient, nil
-}
-
-// handleShutdown listens for interrupt signals (like Ctrl+C) to gracefully shut down the application.
-// It closes the database connection and signals the main goroutine to shut down.
-func handleShutdown(dbClient *repositories.DatabaseClient, shutdown chan struct{}) {
+// handleShutdown listens for interrupt signals (like Ctrl+C) and signals the main
+// goroutine to shut down the application.
+func handleShutdown(shutdown chan struct{}) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	<-c
 	log.Println("Interrupt received, initiating shutdown...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // Adjust timeout as needed
-	defer cancel()
-
-	if err := dbClient.Close(ctx); err != nil {
-		log.Printf("Error closing database connection: %v", err)
-	}
-
 	// Signal the main goroutine to shut down
 	close(shutdown)
 }
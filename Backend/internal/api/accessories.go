@@ -0,0 +1,13 @@
+package api
+
+import "oop/internal/models"
+
+// AccessoriesListResponse represents a page of accessories
+type AccessoriesListResponse struct {
+	Data       []models.Accessory `json:"data"`
+	Count      int                `json:"count"`
+	Page       int                `json:"page,omitempty"`
+	PageSize   int                `json:"pageSize,omitempty"`
+	Total      int64              `json:"total,omitempty"` // Accessories matching the filters across all pages
+	TotalPages int                `json:"totalPages,omitempty"`
+}
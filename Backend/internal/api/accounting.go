@@ -0,0 +1,16 @@
+package api
+
+import "oop/internal/models"
+
+// AccountingSyncStatusResponse is the response for the accounting sync status.
+type AccountingSyncStatusResponse struct {
+	Enabled     bool                       `json:"enabled"`     // Whether an accounting system is configured
+	MaxAttempts int                        `json:"maxAttempts"` // Attempts after which the sync job stops retrying a failed posting
+	Postings    []models.AccountingPosting `json:"postings"`    // Latest day first
+}
+
+// AccountingPostingResponse is the response for retrying a posting.
+type AccountingPostingResponse struct {
+	Message string                   `json:"message"`
+	Posting models.AccountingPosting `json:"posting"`
+}
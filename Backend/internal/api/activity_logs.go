@@ -0,0 +1,11 @@
+package api
+
+import "oop/internal/models"
+
+// ActivityLogPageResponse is one page of activity logs, newest first.
+type ActivityLogPageResponse struct {
+	Data     []models.ActivityLog `json:"data"`
+	Total    int64                `json:"total"` // Logs matching the filters across all pages
+	Page     int                  `json:"page"`
+	LastPage float64              `json:"last_page"` // 0 when no log matches
+}
@@ -0,0 +1,23 @@
+package api
+
+import "oop/internal/models"
+
+// BasketResponse is the response for the accessories frequently bought together.
+type BasketResponse struct {
+	Pairs []models.BasketPair `json:"pairs"`
+	Count int                 `json:"count"`
+}
+
+// PivotResponse is a cross-tab of sales. Values[i][j] is the measure for Rows[i] and
+// Columns[j], 0 when nothing was sold there.
+type PivotResponse struct {
+	RowDimension    string      `json:"rowDimension"`
+	ColumnDimension string      `json:"columnDimension"`
+	Measure         string      `json:"measure"`
+	StartDate       string      `json:"startDate"`
+	EndDate         string      `json:"endDate"`
+	Rows            []string    `json:"rows"`
+	Columns         []string    `json:"columns"`
+	Values          [][]float64 `json:"values"`
+	Max             float64     `json:"max"` // Largest value, for scaling a heatmap
+}
@@ -0,0 +1,20 @@
+package api
+
+import "oop/internal/models"
+
+// AnnouncementListResponse is the response for listing announcements.
+type AnnouncementListResponse struct {
+	Announcements []models.Announcement `json:"announcements"`
+}
+
+// AnnouncementResponse is the response for creating or updating an announcement.
+type AnnouncementResponse struct {
+	Message      string               `json:"message"`
+	Announcement *models.Announcement `json:"announcement"`
+}
+
+// AnnouncementAcksResponse is the response for listing who acknowledged an announcement.
+type AnnouncementAcksResponse struct {
+	Acknowledgments []models.AnnouncementAck `json:"acknowledgments"`
+	Count           int                      `json:"count"`
+}
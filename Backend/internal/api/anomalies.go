@@ -0,0 +1,28 @@
+package api
+
+import "oop/internal/models"
+
+// AnomalyListResponse is the response for listing the anomaly review queue.
+type AnomalyListResponse struct {
+	Anomalies []models.Anomaly `json:"anomalies"`
+	Count     int              `json:"count"`
+}
+
+// AnomalyScanResponse is the response for scanning a day for anomalies.
+type AnomalyScanResponse struct {
+	Message   string           `json:"message"`
+	Date      string           `json:"date"`
+	Anomalies []models.Anomaly `json:"anomalies"` // Only those not flagged by an earlier scan
+	Count     int              `json:"count"`
+}
+
+// AnomalyReviewRequest is the body for acknowledging or dismissing an anomaly.
+type AnomalyReviewRequest struct {
+	Note string `json:"note"` // Optional reason
+}
+
+// AnomalyResponse is the response for acknowledging or dismissing an anomaly.
+type AnomalyResponse struct {
+	Message string         `json:"message"`
+	Anomaly models.Anomaly `json:"anomaly"`
+}
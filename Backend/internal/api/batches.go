@@ -0,0 +1,64 @@
+package api
+
+import "oop/internal/models"
+
+// CabBatchRequest is the request to create, update and delete cabs at once
+type CabBatchRequest struct {
+	Operations []CabBatchOperation `json:"operations"`
+}
+
+// CabBatchOperation is an operation of a cab batch. Item is the cab to create or the
+// new state of cab ID, as sent to PUT /api/cabs/{id}; deletes only need the ID.
+type CabBatchOperation struct {
+	Op   string           `json:"op" enums:"create,update,delete"`
+	ID   int              `json:"id,omitempty"`
+	Item *models.MultiCab `json:"item,omitempty"`
+}
+
+// AccessoryBatchRequest is the request to create, update and delete accessories at once
+type AccessoryBatchRequest struct {
+	Operations []AccessoryBatchOperation `json:"operations"`
+}
+
+// AccessoryBatchOperation is an operation of an accessory batch. Item holds the fields
+// of the accessory to create, of which name, make and unit_color are required, or
+// those to change of accessory ID, as sent to PUT /api/accessories/{id}; deletes only
+// need the ID.
+type AccessoryBatchOperation struct {
+	Op   string                       `json:"op" enums:"create,update,delete"`
+	ID   int                          `json:"id,omitempty"`
+	Item *models.UpdateAccessoryInput `json:"item,omitempty"`
+}
+
+// MaterialBatchRequest is the request to create, update and delete materials at once
+type MaterialBatchRequest struct {
+	Operations []MaterialBatchOperation `json:"operations"`
+}
+
+// MaterialBatchOperation is an operation of a material batch. Item is the material to
+// create or the new state of material ID, as sent to PUT /api/materials/{id}; deletes
+// only need the ID.
+type MaterialBatchOperation struct {
+	Op   string           `json:"op" enums:"create,update,delete"`
+	ID   int              `json:"id,omitempty"`
+	Item *models.Material `json:"item,omitempty"`
+}
+
+// BatchResult is the outcome of one operation of an inventory batch
+type BatchResult struct {
+	Index int    `json:"index"` // Of the operation in the request
+	Op    string `json:"op"`
+	ID    int    `json:"id,omitempty"` // Of the item; set on created items once applied
+	// Status is what the operation would have answered on its own: 201, 200 or 204
+	// once applied. When the batch is not applied, operations that failed have theirs
+	// with an error and the others have 424.
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResponse is the response of an inventory batch: either every operation was
+// applied, or none was and the results say which failed and why
+type BatchResponse struct {
+	Applied bool          `json:"applied"`
+	Results []BatchResult `json:"results"` // In the order of the operations
+}
@@ -0,0 +1,11 @@
+package api
+
+import "oop/internal/models"
+
+// CabPageResponse is one page of cabs, for lists paged with limit and offset.
+type CabPageResponse struct {
+	Data   []models.MultiCab `json:"data"`
+	Total  int64             `json:"total"` // Cabs matching the filters across all pages
+	Limit  int               `json:"limit"`
+	Offset int               `json:"offset"`
+}
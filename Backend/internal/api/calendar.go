@@ -0,0 +1,10 @@
+package api
+
+import "oop/internal/models"
+
+// CalendarFeedResponse is the response for the caller's iCal feed.
+type CalendarFeedResponse struct {
+	Message string               `json:"message,omitempty"`
+	URL     string               `json:"url,omitempty"` // Subscription URL; only returned when the feed is created
+	Feed    *models.CalendarFeed `json:"feed"`          // Nil until the caller creates a feed
+}
@@ -0,0 +1,28 @@
+package api
+
+import "oop/internal/models"
+
+// ChangeRequestInput is the body for proposing an edit of a cab, accessory or material.
+type ChangeRequestInput struct {
+	EntityType string                 `json:"entityType"` // cab, accessory or material
+	EntityID   int                    `json:"entityId"`
+	Changes    map[string]interface{} `json:"changes"` // New values of the fields to change, named as in the entity's JSON, e.g. {"price": 650000}
+	Reason     string                 `json:"reason"`  // Optional, shown to the reviewer
+}
+
+// ChangeRequestListResponse is the response for listing change requests.
+type ChangeRequestListResponse struct {
+	Requests []models.ChangeRequest `json:"requests"`
+	Count    int                    `json:"count"`
+}
+
+// ChangeRequestReviewRequest is the body for applying or rejecting a change request.
+type ChangeRequestReviewRequest struct {
+	Note string `json:"note"` // Optional, shown to the requester
+}
+
+// ChangeRequestResponse is the response for applying or rejecting a change request.
+type ChangeRequestResponse struct {
+	Message string               `json:"message"`
+	Request models.ChangeRequest `json:"request"`
+}
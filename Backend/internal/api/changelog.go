@@ -0,0 +1,35 @@
+package api
+
+// Kinds of API changes
+const (
+	ChangeAdded      = "added"
+	ChangeChanged    = "changed"
+	ChangeDeprecated = "deprecated"
+	ChangeRemoved    = "removed"
+)
+
+// APIChange is an entry of the API changelog.
+type APIChange struct {
+	Date      string   `json:"date"`                // YYYY-MM-DD the change shipped
+	Kind      string   `json:"kind"`                // added, changed, deprecated or removed
+	Endpoints []string `json:"endpoints,omitempty"` // Affected routes, e.g. "GET /api/tasks/mine"; empty when the change is API-wide
+	Summary   string   `json:"summary"`
+}
+
+// Deprecation is an endpoint that still works but is going away. Responses of the
+// endpoint carry Deprecation and, once a date is set, Sunset headers.
+type Deprecation struct {
+	Method       string `json:"method"`
+	Path         string `json:"path"`                  // Route pattern, e.g. /api/cabs/:id
+	DeprecatedOn string `json:"deprecatedOn"`          // YYYY-MM-DD
+	Sunset       string `json:"sunset,omitempty"`      // YYYY-MM-DD after which the endpoint may be removed
+	Replacement  string `json:"replacement,omitempty"` // Route to use instead, e.g. "GET /api/tasks/mine"
+	Notes        string `json:"notes,omitempty"`
+}
+
+// ChangelogResponse is the response for the API changelog.
+type ChangelogResponse struct {
+	Version      string        `json:"version"`      // API version of the running server
+	Changes      []APIChange   `json:"changes"`      // Newest first
+	Deprecations []Deprecation `json:"deprecations"` // Endpoints still served that are going away
+}
@@ -0,0 +1,41 @@
+package api
+
+// ErrorResponse represents an API error response
+type ErrorResponse struct {
+	Error      string `json:"error" example:"Sale not found"`
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"statusCode" example:"404"`
+	Timestamp  string `json:"timestamp" example:"2026-10-16T08:30:00Z"`
+}
+
+// SuccessResponse represents a successful API response
+type SuccessResponse struct {
+	Success   bool        `json:"success"`
+	Data      interface{} `json:"data,omitempty"`
+	Message   string      `json:"message,omitempty" example:"Logged out"`
+	Timestamp string      `json:"timestamp" example:"2026-10-16T08:30:00Z"`
+}
+
+// MessageResponse is a generic response for actions that only return a message.
+type MessageResponse struct {
+	Message string `json:"message" example:"Deposit deleted"`
+}
+
+// StatusResponse is the response of public checks that only report success.
+type StatusResponse struct {
+	Status string `json:"status" example:"ok"`
+}
+
+// HealthResponse is the response of the health check.
+type HealthResponse struct {
+	Status   string `json:"status" example:"ok" enums:"ok,degraded"`
+	Time     string `json:"time" example:"2026-10-16T08:30:00Z"`             // Server time, RFC 3339
+	Database string `json:"database,omitempty" example:"up" enums:"up,down"` // Omitted in mock mode
+}
+
+// UndoResponse is the response for undoing a delete.
+type UndoResponse struct {
+	Message    string `json:"message" example:"Record restored"`
+	EntityType string `json:"entityType" example:"customer"` // customer, accessory or material
+	EntityID   string `json:"entityId" example:"42"`
+}
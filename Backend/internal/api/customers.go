@@ -0,0 +1,123 @@
+package api
+
+import "oop/internal/models"
+
+// CustomerResponse defines the structure for a single customer response.
+// It omits sensitive or unnecessary fields for client-side display.
+type CustomerResponse struct {
+	ID             string   `json:"id"`
+	FullName       string   `json:"fullName"`
+	Email          string   `json:"email"`
+	Phone          string   `json:"phone"`
+	Address        string   `json:"address,omitempty"` // House number and street
+	Province       string   `json:"province,omitempty"`
+	City           string   `json:"city,omitempty"`
+	Barangay       string   `json:"barangay,omitempty"`
+	Latitude       *float64 `json:"latitude,omitempty"` // Set when the address was geocoded
+	Longitude      *float64 `json:"longitude,omitempty"`
+	DateOfBirth    string   `json:"dateOfBirth,omitempty"` // YYYY-MM-DD
+	DateRegistered string   `json:"dateRegistered"`
+	CreatedAt      string   `json:"createdAt"`
+	UpdatedAt      string   `json:"updatedAt"`
+	DisplayNumber  string   `json:"displayNumber,omitempty"` // Generated from the customer numbering format
+}
+
+// CustomerListResponse defines the structure for a list of customers, or one page of
+// them when the list is paged.
+type CustomerListResponse struct {
+	Customers  []*CustomerResponse `json:"customers"`
+	Page       int                 `json:"page,omitempty"`
+	PageSize   int                 `json:"pageSize,omitempty"`
+	Total      int64               `json:"total,omitempty"` // Customers matching the search across all pages
+	TotalPages int                 `json:"totalPages,omitempty"`
+}
+
+// CustomerDataExport is the full record of personal data stored for a customer
+type CustomerDataExport struct {
+	ExportedAt string                   `json:"exportedAt"`
+	Customer   *CustomerResponse        `json:"customer"`
+	Consent    *models.CustomerConsent  `json:"consent,omitempty"`
+	Sales      []CustomerDataExportSale `json:"sales"`
+}
+
+// CustomerDataExportSale is a sale made to the customer, including its line items
+type CustomerDataExportSale struct {
+	ID         string                       `json:"id"`
+	SoldBy     string                       `json:"soldBy"`
+	SaleDate   string                       `json:"saleDate"`
+	TotalPrice float64                      `json:"totalPrice"`
+	CreatedAt  string                       `json:"createdAt"`
+	Items      []CustomerDataExportSaleItem `json:"items"`
+}
+
+// CustomerDataExportSaleItem is a single line item of an exported sale
+type CustomerDataExportSaleItem struct {
+	ItemRef     string  `json:"itemRef"` // External reference ID of the item, e.g. cab:12
+	ItemType    string  `json:"itemType"`
+	MultiCabID  string  `json:"multiCabId,omitempty"`
+	AccessoryID string  `json:"accessoryId,omitempty"`
+	MaterialID  string  `json:"materialId,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Subtotal    float64 `json:"subtotal"`
+}
+
+// CustomerOccasionResponse is an upcoming birthday or anniversary with the customer to
+// greet, whose contact details are masked like in other customer responses
+type CustomerOccasionResponse struct {
+	models.CustomerOccasion
+	Customer *CustomerResponse `json:"customer"`
+}
+
+// UpcomingOccasionsResponse lists the customers' birthdays or anniversaries falling
+// within Days days from From, soonest first
+type UpcomingOccasionsResponse struct {
+	From      string                     `json:"from"`
+	Days      int                        `json:"days"`
+	Occasion  string                     `json:"occasion"` // birthday, anniversary or all
+	Occasions []CustomerOccasionResponse `json:"occasions"`
+	Count     int                        `json:"count"`
+}
+
+// CustomerOccasionsEvent is pushed to the sales team ahead of the birthdays and
+// anniversaries of customers falling on Date
+type CustomerOccasionsEvent struct {
+	Date      string                    `json:"date"`
+	Occasions []models.CustomerOccasion `json:"occasions"`
+}
+
+// UpdateCustomerConsentRequest sets a customer's marketing consent, do-not-contact flag
+// or both. Flags left out keep their value.
+type UpdateCustomerConsentRequest struct {
+	MarketingConsent *bool  `json:"marketingConsent,omitempty"`
+	DoNotContact     *bool  `json:"doNotContact,omitempty"`
+	Source           string `json:"source"` // How the customer gave or withdrew it, e.g. in_store_form or phone_call
+}
+
+// LocationProvince is a province of the location dataset with its cities and municipalities
+type LocationProvince struct {
+	Name   string   `json:"name"`
+	Cities []string `json:"cities"`
+}
+
+// LocationsResponse lists the provinces customer addresses are validated against
+type LocationsResponse struct {
+	Provinces []LocationProvince `json:"provinces"`
+}
+
+// DeliveryEstimateResponse is the distance and fee of delivering from the delivery
+// origin to a customer or a place
+type DeliveryEstimateResponse struct {
+	CustomerID string  `json:"customerId,omitempty"`
+	Province   string  `json:"province"`
+	City       string  `json:"city"`
+	Barangay   string  `json:"barangay,omitempty"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	// Approximate is set when the destination is the center of its city, as the address
+	// was not geocoded
+	Approximate    bool    `json:"approximate"`
+	StraightLineKm float64 `json:"straightLineKm"`
+	RoadKm         float64 `json:"roadKm"` // Straight-line distance times DELIVERY_ROAD_FACTOR
+	Fee            float64 `json:"fee"`    // DELIVERY_BASE_FEE plus DELIVERY_FEE_PER_KM per road kilometer
+}
@@ -0,0 +1,16 @@
+package api
+
+import "oop/internal/models"
+
+// DepositListResponse is the response for listing bank deposits.
+type DepositListResponse struct {
+	Deposits []models.BankDeposit `json:"deposits"`
+	Count    int                  `json:"count"`
+	Total    float64              `json:"total"` // Sum of the listed amounts
+}
+
+// DepositResponse is the response for recording a bank deposit.
+type DepositResponse struct {
+	Message string              `json:"message"`
+	Deposit *models.BankDeposit `json:"deposit"`
+}
@@ -0,0 +1,23 @@
+package api
+
+import "oop/internal/models"
+
+// NumberFormatListResponse is the response for listing numbering formats.
+type NumberFormatListResponse struct {
+	Formats []models.NumberFormat `json:"formats"`
+	Count   int                   `json:"count"`
+}
+
+// NumberFormatResponse is the response for setting a numbering format.
+type NumberFormatResponse struct {
+	Message string               `json:"message"`
+	Format  *models.NumberFormat `json:"format"`
+	Example string               `json:"example"` // The first number the format would give today
+}
+
+// DisplayNumberLookupResponse lists the records given a display number.
+type DisplayNumberLookupResponse struct {
+	Number  string                 `json:"number"`
+	Matches []models.DisplayNumber `json:"matches"`
+	Count   int                    `json:"count"`
+}
@@ -0,0 +1,4 @@
+// Package api defines the JSON response bodies returned by the HTTP handlers.
+// These types are the contract the frontend client is generated from, so
+// JSON tags and enum values must stay stable; add fields rather than rename them.
+package api
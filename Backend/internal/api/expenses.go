@@ -0,0 +1,27 @@
+package api
+
+import "oop/internal/models"
+
+// ExpenseListResponse is the response for listing expenses.
+type ExpenseListResponse struct {
+	Expenses []models.Expense `json:"expenses"`
+	Count    int              `json:"count"`
+	Total    float64          `json:"total"` // Sum of the listed amounts
+}
+
+// ExpenseResponse is the response for creating, updating or reviewing an expense.
+type ExpenseResponse struct {
+	Message string          `json:"message"`
+	Expense *models.Expense `json:"expense"`
+}
+
+// ExpenseAttachmentResponse is the response for attaching a file to an expense.
+type ExpenseAttachmentResponse struct {
+	Message    string                    `json:"message"`
+	Attachment *models.ExpenseAttachment `json:"attachment"`
+}
+
+// ExpenseCategoriesResponse is the response for listing the expense categories.
+type ExpenseCategoriesResponse struct {
+	Categories []string `json:"categories"`
+}
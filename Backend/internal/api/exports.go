@@ -0,0 +1,16 @@
+package api
+
+import "oop/internal/models"
+
+// ExportJobResponse is the response for requesting or checking on an export.
+type ExportJobResponse struct {
+	Message     string            `json:"message,omitempty"`
+	Job         *models.ExportJob `json:"job"`
+	DownloadURL string            `json:"downloadUrl,omitempty"` // Short-lived link to the file; only once the export is completed
+}
+
+// ExportJobListResponse is the response for listing the caller's exports.
+type ExportJobListResponse struct {
+	Jobs  []models.ExportJob `json:"jobs"`
+	Count int                `json:"count"`
+}
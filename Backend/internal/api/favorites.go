@@ -0,0 +1,20 @@
+package api
+
+import "oop/internal/models"
+
+// FavoriteItem is a starred item with its current details.
+type FavoriteItem struct {
+	models.Favorite
+	Item interface{} `json:"item"` // The cab or accessory; null when it was deleted
+}
+
+// FavoriteListResponse is the response for listing a user's favorites.
+type FavoriteListResponse struct {
+	Favorites []FavoriteItem `json:"favorites"`
+}
+
+// FavoriteResponse is the response for starring an item.
+type FavoriteResponse struct {
+	Message  string           `json:"message"`
+	Favorite *models.Favorite `json:"favorite"`
+}
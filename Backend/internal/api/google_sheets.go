@@ -0,0 +1,12 @@
+package api
+
+import "oop/internal/models"
+
+// GoogleSheetsExportResponse is the response for the Google Sheets export of a tenant.
+type GoogleSheetsExportResponse struct {
+	Message        string                     `json:"message,omitempty"`
+	Enabled        bool                       `json:"enabled"`                  // Whether a service account is configured
+	ServiceAccount string                     `json:"serviceAccount,omitempty"` // Share the spreadsheet with this email as an editor
+	Reports        []string                   `json:"reports"`                  // Reports that can be exported
+	Export         *models.GoogleSheetsExport `json:"export"`                   // Nil until an export is set up
+}
@@ -0,0 +1,37 @@
+package api
+
+import "oop/internal/models"
+
+// InsurancePolicyListResponse is the response for listing insurance policies.
+type InsurancePolicyListResponse struct {
+	Policies []models.InsurancePolicy `json:"policies"`
+	Count    int                      `json:"count"`
+}
+
+// InsurancePolicyResponse is the response for recording or updating an insurance policy.
+type InsurancePolicyResponse struct {
+	Message string                  `json:"message"`
+	Policy  *models.InsurancePolicy `json:"policy"`
+}
+
+// RenewalOpportunity is a policy expiring soon, or lapsed recently, that was not renewed
+type RenewalOpportunity struct {
+	models.InsurancePolicy
+	DaysToExpiry int               `json:"daysToExpiry"` // Negative once the policy lapsed
+	Customer     *CustomerResponse `json:"customer"`
+}
+
+// RenewalOpportunitiesResponse is the insurance renewal report, soonest expiring first
+type RenewalOpportunitiesResponse struct {
+	From          string               `json:"from"` // YYYY-MM-DD
+	Days          int                  `json:"days"`
+	Opportunities []RenewalOpportunity `json:"opportunities"`
+	Count         int                  `json:"count"`
+}
+
+// InsuranceExpiringEvent is pushed to the sales team with the policies expiring on a day
+// that were not renewed
+type InsuranceExpiringEvent struct {
+	ExpiryDate string                   `json:"expiryDate"` // YYYY-MM-DD
+	Policies   []models.InsurancePolicy `json:"policies"`
+}
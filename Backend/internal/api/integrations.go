@@ -0,0 +1,22 @@
+package api
+
+import "oop/internal/models"
+
+// IntegrationListResponse is the response for listing integrations.
+type IntegrationListResponse struct {
+	Integrations []models.Integration `json:"integrations"` // Newest first
+}
+
+// IntegrationSecretResponse is the response for creating an integration or rotating
+// its secret. The secret is not shown again.
+type IntegrationSecretResponse struct {
+	Integration models.Integration `json:"integration"`
+	Secret      string             `json:"secret"`
+}
+
+// InboundOrderResponse is the response for an order posted by an integration.
+type InboundOrderResponse struct {
+	Message   string `json:"message"`
+	SaleID    string `json:"saleId"`
+	Duplicate bool   `json:"duplicate"` // The order was received before; no new sale was created
+}
@@ -0,0 +1,22 @@
+package api
+
+import "oop/internal/models"
+
+// IntegrityCheckResponse is the response for running the data integrity check.
+type IntegrityCheckResponse struct {
+	Message string                 `json:"message"`
+	Report  models.IntegrityReport `json:"report"`
+}
+
+// NegativeStockResponse is the response for listing the items with negative stock.
+type NegativeStockResponse struct {
+	Items []models.NegativeStock `json:"items"`
+	Count int                    `json:"count"`
+}
+
+// NegativeStockRepairResponse is the response for zeroing negative stock.
+type NegativeStockRepairResponse struct {
+	Message  string                 `json:"message"`
+	Repaired []models.NegativeStock `json:"repaired"` // With the quantities they had before
+	Failed   int                    `json:"failed"`
+}
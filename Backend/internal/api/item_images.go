@@ -0,0 +1,34 @@
+package api
+
+import "oop/internal/models"
+
+// ItemImageListResponse is the response for listing the photo gallery of an item.
+type ItemImageListResponse struct {
+	Images []models.ItemImage `json:"images"`
+	Count  int                `json:"count"`
+}
+
+// ItemImageResponse is the response for uploading a photo to a gallery.
+type ItemImageResponse struct {
+	Message    string             `json:"message"`
+	Image      *models.ItemImage  `json:"image"`
+	Warning    string             `json:"warning,omitempty"`    // Set when the same photo is attached to other items
+	Duplicates []models.ItemImage `json:"duplicates,omitempty"` // The same photo in the galleries of other items
+}
+
+// ItemImageOrderRequest is the body for reordering a photo gallery.
+type ItemImageOrderRequest struct {
+	IDs []string `json:"ids"` // Every photo of the gallery, in the new order
+}
+
+// DuplicateItemImageGroup is one photo attached to more than one item.
+type DuplicateItemImageGroup struct {
+	ContentHash string             `json:"contentHash"`
+	Images      []models.ItemImage `json:"images"` // Oldest upload first
+}
+
+// DuplicateItemImagesResponse is the response for listing photos attached to more than one item.
+type DuplicateItemImagesResponse struct {
+	Duplicates []DuplicateItemImageGroup `json:"duplicates"`
+	Count      int                       `json:"count"`
+}
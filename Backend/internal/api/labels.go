@@ -0,0 +1,16 @@
+package api
+
+// InventoryLabelsRequest is the body for printing inventory labels.
+type InventoryLabelsRequest struct {
+	Items    []InventoryLabelItem `json:"items"`
+	Sheet    string               `json:"sheet"`    // letter (Avery 5160, the default) or a4 (Avery L7160)
+	Location string               `json:"location"` // Optional, printed on the labels of items without their own
+}
+
+// InventoryLabelItem is an item to print labels of.
+type InventoryLabelItem struct {
+	Type     string `json:"type"` // cab, accessory or material
+	ID       int    `json:"id"`
+	Copies   int    `json:"copies"`   // Labels to print, 1 when left out
+	Location string `json:"location"` // Optional, e.g. "Yard B, Row 3"
+}
@@ -0,0 +1,38 @@
+package api
+
+import "oop/internal/models"
+
+// LegacyImportAction is what a legacy import does with one row
+type LegacyImportAction string
+
+// Legacy import actions reported per row
+const (
+	LegacyImportInsert LegacyImportAction = "insert" // Imported as a new record
+	LegacyImportMatch  LegacyImportAction = "match"  // Linked to a record that already existed, which is left unchanged
+	LegacyImportSkip   LegacyImportAction = "skip"   // Imported by an earlier run
+	LegacyImportReject LegacyImportAction = "reject"
+)
+
+// LegacyImportRow is the outcome of one row of a legacy spreadsheet.
+type LegacyImportRow struct {
+	Line   int                `json:"line"` // Line of the CSV file, the header being line 1
+	Key    string             `json:"key"`  // Legacy ID of the row, or a hash of its values when the sheet has none
+	Action LegacyImportAction `json:"action"`
+	ID     string             `json:"id,omitempty"`     // Record the row was imported as or linked to; empty on a dry run for new records
+	Errors []string           `json:"errors,omitempty"` // Why the row was rejected
+}
+
+// LegacyImportResponse is the validation report of a legacy import. Rows only cover
+// the rows processed by this request, so a resumed run leaves out the rows its
+// earlier requests got through.
+type LegacyImportResponse struct {
+	DryRun  bool                       `json:"dryRun"`        // Nothing was saved
+	Run     *models.LegacyImportRun    `json:"run,omitempty"` // The run and its progress; absent on a dry run
+	Summary map[LegacyImportAction]int `json:"summary"`
+	Rows    []LegacyImportRow          `json:"rows"`
+}
+
+// LegacyImportRunsResponse lists the legacy import runs, newest first.
+type LegacyImportRunsResponse struct {
+	Runs []models.LegacyImportRun `json:"runs"`
+}
@@ -0,0 +1,7 @@
+package api
+
+// LogLevelRequest is the request to change the level the server logs at for a while
+type LogLevelRequest struct {
+	Level           string `json:"level" example:"debug" enums:"debug,info,warn,error"`
+	DurationMinutes int    `json:"durationMinutes,omitempty" example:"30"` // Until it reverts; LOG_LEVEL_OVERRIDE_MINUTES when left out
+}
@@ -0,0 +1,28 @@
+package api
+
+// MaterialImportAction is what a material import does with one row
+type MaterialImportAction string
+
+// Material import actions reported per row
+const (
+	MaterialImportInsert MaterialImportAction = "insert"
+	MaterialImportUpdate MaterialImportAction = "update"
+	MaterialImportReject MaterialImportAction = "reject"
+)
+
+// MaterialImportRow is the outcome of one row of a material import.
+type MaterialImportRow struct {
+	Line     int                  `json:"line"` // Line of the CSV file, the header being line 1
+	Action   MaterialImportAction `json:"action"`
+	ID       int                  `json:"id,omitempty"` // Material updated, or inserted unless it was a dry run
+	Name     string               `json:"name,omitempty"`
+	Quantity int                  `json:"quantity"`
+	Errors   []string             `json:"errors,omitempty"` // Why the row was rejected
+}
+
+// MaterialImportResponse is the validation report of a material import.
+type MaterialImportResponse struct {
+	DryRun  bool                         `json:"dryRun"` // Nothing was saved
+	Summary map[MaterialImportAction]int `json:"summary"`
+	Rows    []MaterialImportRow          `json:"rows"`
+}
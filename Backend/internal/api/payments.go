@@ -0,0 +1,28 @@
+package api
+
+import "oop/internal/models"
+
+// SalePaymentRequest is the body for recording a payment of a sale.
+type SalePaymentRequest struct {
+	Amount    float64 `json:"amount"`
+	Method    string  `json:"method"`    // cash (default), card, bank_transfer or check
+	Reference string  `json:"reference"` // Optional card slip, transfer or check number
+	Date      string  `json:"date"`      // Optional day the payment was received, YYYY-MM-DD; today by default
+}
+
+// SalePaymentsResponse is the payments of a sale and how much of its total they cover.
+type SalePaymentsResponse struct {
+	SaleID   string               `json:"saleId"`
+	Total    float64              `json:"total"`
+	Paid     float64              `json:"paid"`
+	Balance  float64              `json:"balance"` // Total less paid; negative when overpaid
+	Payments []models.SalePayment `json:"payments"`
+}
+
+// PaymentReconciliationResponse is the response for reconciling payments with sale totals.
+type PaymentReconciliationResponse struct {
+	Message    string                   `json:"message"`
+	Mismatches []models.PaymentMismatch `json:"mismatches"` // Every sale whose payments do not match its total
+	Anomalies  []models.Anomaly         `json:"anomalies"`  // Only those not flagged by an earlier run
+	Count      int                      `json:"count"`      // Number of new anomalies
+}
@@ -0,0 +1,20 @@
+package api
+
+import "oop/internal/models"
+
+// PriceChangeListResponse is the response for listing price changes held for approval.
+type PriceChangeListResponse struct {
+	Changes []models.PriceChange `json:"changes"`
+	Count   int                  `json:"count"`
+}
+
+// PriceChangeReviewRequest is the body for approving or rejecting a price change.
+type PriceChangeReviewRequest struct {
+	Note string `json:"note"` // Optional reason, shown to the requester
+}
+
+// PriceChangeResponse is the response for approving or rejecting a price change.
+type PriceChangeResponse struct {
+	Message string             `json:"message"`
+	Change  models.PriceChange `json:"change"`
+}
@@ -0,0 +1,23 @@
+package api
+
+import "oop/internal/models"
+
+// ReceiptSeriesStatus is an official receipt series with the numbers it has left.
+type ReceiptSeriesStatus struct {
+	models.ReceiptSeries
+	Remaining       int  `json:"remaining"`
+	NearlyExhausted bool `json:"nearlyExhausted"` // Remaining is at or below the warning level
+}
+
+// ReceiptSeriesListResponse is the response for listing official receipt series.
+type ReceiptSeriesListResponse struct {
+	Series []ReceiptSeriesStatus `json:"series"`
+	Count  int                   `json:"count"`
+}
+
+// OfficialReceiptResponse is the response for issuing an OR number to a sale.
+type OfficialReceiptResponse struct {
+	Receipt   *models.OfficialReceipt `json:"receipt"`
+	Remaining int                     `json:"remaining"`         // Numbers left in the series the receipt came from
+	Warning   string                  `json:"warning,omitempty"` // Set when the series is nearly exhausted
+}
@@ -0,0 +1,9 @@
+package api
+
+import "oop/internal/models"
+
+// RecentViewListResponse is the response for listing the records a user recently opened.
+type RecentViewListResponse struct {
+	Views []models.EntityView `json:"views"`
+	Count int                 `json:"count"`
+}
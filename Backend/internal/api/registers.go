@@ -0,0 +1,9 @@
+package api
+
+import "oop/internal/models"
+
+// RegisterSessionResponse is the response for opening or closing a register session.
+type RegisterSessionResponse struct {
+	Message string                  `json:"message"`
+	Session *models.RegisterSession `json:"session"`
+}
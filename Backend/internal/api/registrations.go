@@ -0,0 +1,37 @@
+package api
+
+import "oop/internal/models"
+
+// RegistrationListResponse is the response for listing cab registrations.
+type RegistrationListResponse struct {
+	Registrations []models.CabRegistration `json:"registrations"`
+	Count         int                      `json:"count"`
+	Overdue       int                      `json:"overdue"` // Listed registrations whose OR/CR is not released by the due date
+}
+
+// RegistrationResponse is the response for tracking or updating a cab registration.
+type RegistrationResponse struct {
+	Message      string                  `json:"message"`
+	Registration *models.CabRegistration `json:"registration"`
+}
+
+// OverdueRegistration is a registration whose OR/CR was not released by its due date
+type OverdueRegistration struct {
+	models.CabRegistration
+	DaysOverdue int `json:"daysOverdue"`
+}
+
+// OverdueRegistrationsResponse is the overdue registrations report, most overdue first
+type OverdueRegistrationsResponse struct {
+	AsOf          string                `json:"asOf"` // YYYY-MM-DD
+	Registrations []OverdueRegistration `json:"registrations"`
+	Count         int                   `json:"count"`
+}
+
+// RegistrationsDueEvent is pushed to the back office with the registrations coming due
+// and those already overdue
+type RegistrationsDueEvent struct {
+	Date    string                   `json:"date"` // YYYY-MM-DD
+	DueSoon []models.CabRegistration `json:"dueSoon"`
+	Overdue []OverdueRegistration    `json:"overdue"`
+}
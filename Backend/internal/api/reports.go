@@ -0,0 +1,183 @@
+package api
+
+import (
+	"oop/internal/models"
+	"time"
+)
+
+// ReportPeriod is a week, month, quarter or year of the calendar or of the fiscal
+// calendar. Fiscal labels start with FY and number months P01 to P12 from the start
+// of the fiscal year, e.g. FY2025-Q1 or FY2025-P07; calendar labels look like
+// 2025-W11, 2025-03, 2025-Q1 or 2025.
+type ReportPeriod struct {
+	Label     string `json:"label"`
+	StartDate string `json:"startDate"` // YYYY-MM-DD
+	EndDate   string `json:"endDate"`   // YYYY-MM-DD
+}
+
+// LeaderboardResponse is the response for the staff sales leaderboard.
+type LeaderboardResponse struct {
+	Period    string                    `json:"period"`    // week, month, quarter or year
+	Fiscal    bool                      `json:"fiscal"`    // Whether the period is of the fiscal calendar
+	Label     string                    `json:"label"`     // See ReportPeriod
+	StartDate string                    `json:"startDate"` // First sale date counted, YYYY-MM-DD
+	EndDate   string                    `json:"endDate"`   // Last sale date counted, YYYY-MM-DD
+	Entries   []models.LeaderboardEntry `json:"entries"`
+}
+
+// RevenuePeriod is the sales of one period of the revenue report.
+type RevenuePeriod struct {
+	ReportPeriod
+	SalesTotal float64 `json:"salesTotal"`
+	SalesCount int     `json:"salesCount"`
+}
+
+// RevenueReport is the response for the revenue report: sales grouped by calendar
+// or fiscal period, including periods without sales.
+type RevenueReport struct {
+	GroupBy    string          `json:"groupBy"`   // week, month, quarter or year
+	Fiscal     bool            `json:"fiscal"`    // Whether periods are of the fiscal calendar
+	StartDate  string          `json:"startDate"` // First day of the first period, YYYY-MM-DD
+	EndDate    string          `json:"endDate"`   // Last day of the last period, YYYY-MM-DD
+	Periods    []RevenuePeriod `json:"periods"`   // Oldest first
+	SalesTotal float64         `json:"salesTotal"`
+	SalesCount int             `json:"salesCount"`
+}
+
+// SalesSummaryPeriod is the sales of one day, week or month of the sales summary.
+type SalesSummaryPeriod struct {
+	ReportPeriod
+	SalesCount int     `json:"salesCount"`
+	Revenue    float64 `json:"revenue"`
+	UnitsSold  int     `json:"unitsSold"`
+}
+
+// SalesSummaryResponse is the response for the sales summary: sales grouped by day,
+// week or month, including periods without sales, and the best-selling items.
+type SalesSummaryResponse struct {
+	GroupBy        string               `json:"groupBy"`   // day, week or month
+	StartDate      string               `json:"startDate"` // First day of the first period, YYYY-MM-DD
+	EndDate        string               `json:"endDate"`   // Last day of the last period, YYYY-MM-DD
+	Periods        []SalesSummaryPeriod `json:"periods"`   // Oldest first
+	SalesCount     int                  `json:"salesCount"`
+	Revenue        float64              `json:"revenue"`
+	UnitsSold      int                  `json:"unitsSold"`
+	TopCabs        []models.TopSeller   `json:"topCabs"`        // Most units sold first
+	TopAccessories []models.TopSeller   `json:"topAccessories"` // Most units sold first
+}
+
+// EndOfDayReport is the response for the end-of-day report: the day's sales and the
+// register sessions closed that day with their combined cash count.
+type EndOfDayReport struct {
+	Date          string                     `json:"date"`
+	SalesTotal    float64                    `json:"salesTotal"`
+	SalesCount    int                        `json:"salesCount"`
+	Sessions      []models.RegisterSession   `json:"sessions"`
+	OpeningFloat  float64                    `json:"openingFloat"`  // Sum over the sessions
+	CountedCash   float64                    `json:"countedCash"`   // Sum over the sessions
+	ExpectedCash  float64                    `json:"expectedCash"`  // Sum over the sessions
+	Variance      float64                    `json:"variance"`      // Counted minus expected
+	Denominations []models.DenominationCount `json:"denominations"` // Bills and coins counted in all sessions
+}
+
+// ExpenseCategoryTotal is the approved spending in one expense category.
+type ExpenseCategoryTotal struct {
+	Category string  `json:"category"`
+	Total    float64 `json:"total"`
+	Count    int     `json:"count"`
+}
+
+// MonthlyReport is the response for the monthly report: gross sales, the approved
+// expenses of the month by category and the net result.
+type MonthlyReport struct {
+	Month           string                 `json:"month"`     // YYYY-MM
+	StartDate       string                 `json:"startDate"` // YYYY-MM-DD
+	EndDate         string                 `json:"endDate"`   // YYYY-MM-DD
+	SalesTotal      float64                `json:"salesTotal"`
+	SalesCount      int                    `json:"salesCount"`
+	Expenses        []ExpenseCategoryTotal `json:"expenses"` // Approved expenses, largest category first
+	ExpensesTotal   float64                `json:"expensesTotal"`
+	NetTotal        float64                `json:"netTotal"`        // Sales minus approved expenses
+	PendingExpenses float64                `json:"pendingExpenses"` // Awaiting review, not deducted
+	PendingCount    int                    `json:"pendingCount"`
+}
+
+// UndepositedSession is a closed register session whose cash was not deposited yet.
+type UndepositedSession struct {
+	SessionID      string    `json:"sessionId"`
+	OpenedBy       string    `json:"openedBy"`
+	ClosedAt       time.Time `json:"closedAt"`
+	CashToDeposit  float64   `json:"cashToDeposit"`  // Counted cash minus the opening float
+	DaysSinceClose int       `json:"daysSinceClose"` // Calendar days
+}
+
+// DepositReconciliationReport is the response for the deposit reconciliation report:
+// the closed register sessions whose cash is not in the bank, split by whether they
+// are past the deposit deadline.
+type DepositReconciliationReport struct {
+	Date         string               `json:"date"` // Today, YYYY-MM-DD
+	Days         int                  `json:"days"` // Days allowed between closing a session and depositing its cash
+	Overdue      []UndepositedSession `json:"overdue"`
+	OverdueTotal float64              `json:"overdueTotal"`
+	Pending      []UndepositedSession `json:"pending"` // Still within the allowed days
+	PendingTotal float64              `json:"pendingTotal"`
+}
+
+// TaxTotals sums the sales of a period by tax type. Vatable sales are net of the
+// VAT they include; gross sales include it.
+type TaxTotals struct {
+	Date           string  `json:"date,omitempty"` // Sale date, YYYY-MM-DD; empty for the report total
+	SalesCount     int     `json:"salesCount"`
+	GrossSales     float64 `json:"grossSales"`
+	VatableSales   float64 `json:"vatableSales"`
+	VATAmount      float64 `json:"vatAmount"`
+	ExemptSales    float64 `json:"exemptSales"`
+	ZeroRatedSales float64 `json:"zeroRatedSales"`
+}
+
+// TaxReport is the response for the tax report: the VAT collected and the taxable,
+// exempt and zero-rated sales between two sale dates, per day and in total.
+type TaxReport struct {
+	From    string      `json:"from"`    // First sale date counted, YYYY-MM-DD
+	To      string      `json:"to"`      // Last sale date counted, YYYY-MM-DD
+	VATRate float64     `json:"vatRate"` // Rate included in vatable prices
+	Days    []TaxTotals `json:"days"`    // Days with sales, oldest first
+	Total   TaxTotals   `json:"total"`
+}
+
+// InventoryTypeTotal sums the cabs, accessories or materials of an inventory snapshot
+type InventoryTypeTotal struct {
+	ItemType string  `json:"itemType"` // cab, accessory or material
+	Items    int     `json:"items"`    // Distinct items in stock
+	Quantity int     `json:"quantity"` // Units in stock
+	Value    float64 `json:"value"`
+}
+
+// InventorySnapshotReport is the response for an end-of-month inventory snapshot
+type InventorySnapshotReport struct {
+	models.InventorySnapshot
+	Totals []InventoryTypeTotal `json:"totals"` // Cabs, accessories, then materials
+}
+
+// PossibleDuplicateSalesResponse is the response for the possible duplicate sales
+// report: sales for the same customer on the same day with the same total and items,
+// and the sales already voided as duplicates in the period.
+type PossibleDuplicateSalesResponse struct {
+	StartDate string                      `json:"startDate"` // First sale date checked, YYYY-MM-DD
+	EndDate   string                      `json:"endDate"`   // Last sale date checked, YYYY-MM-DD
+	Groups    []models.DuplicateSaleGroup `json:"groups"`    // Newest sale date first
+	Count     int                         `json:"count"`     // Number of groups
+	Voided    []models.DuplicateSaleVoid  `json:"voided"`    // Most recently voided first
+}
+
+// VoidDuplicateSaleRequest is the body for voiding a sale as a duplicate of another.
+type VoidDuplicateSaleRequest struct {
+	SaleID      string `json:"saleId"`      // Sale to void
+	DuplicateOf string `json:"duplicateOf"` // Sale it duplicates, which is kept
+}
+
+// VoidDuplicateSaleResponse is the response for voiding a sale as a duplicate.
+type VoidDuplicateSaleResponse struct {
+	Message string                   `json:"message"`
+	Void    models.DuplicateSaleVoid `json:"void"`
+}
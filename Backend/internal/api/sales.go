@@ -0,0 +1,30 @@
+package api
+
+import "oop/internal/models"
+
+// SalePageResponse is one page of sales, for lists paged on the server.
+type SalePageResponse struct {
+	Data       []models.Sale `json:"data"`
+	Page       int           `json:"page"`
+	Limit      int           `json:"limit"`
+	Total      int64         `json:"total"`       // Sales matching the filters across all pages
+	TotalPages int64         `json:"total_pages"` // 0 when no sale matches
+}
+
+// SaleRefundRequest is the body for refunding a confirmed or completed sale.
+type SaleRefundRequest struct {
+	Items  []SaleRefundItemRequest `json:"items"`  // Items returned; every item left to return when empty
+	Reason string                  `json:"reason"` // Optional, up to 255 characters
+}
+
+// SaleRefundItemRequest is a number of units of a sale item returned.
+type SaleRefundItemRequest struct {
+	SaleItemID string `json:"saleItemId"`
+	Quantity   int    `json:"quantity"`
+}
+
+// SaleRefundResponse is a refund recorded and the sale it refunded.
+type SaleRefundResponse struct {
+	Refund models.SaleRefund `json:"refund"`
+	Sale   models.Sale       `json:"sale"` // Refunded once every item was returned
+}
@@ -0,0 +1,26 @@
+package api
+
+import "time"
+
+// SandboxStatusResponse describes the training sandbox of the caller's tenant.
+type SandboxStatusResponse struct {
+	TenantID  string     `json:"tenantId"`           // Tenant ID of the sandbox, carried by sandbox session tokens
+	InSandbox bool       `json:"inSandbox"`          // Whether the caller's token is a sandbox session
+	SeededAt  *time.Time `json:"seededAt,omitempty"` // When the sandbox was last seeded; absent until first used
+}
+
+// SandboxSessionResponse is the response for entering the training sandbox. Requests
+// made with the token read and change the sandbox's data only.
+type SandboxSessionResponse struct {
+	Message   string    `json:"message"`
+	TenantID  string    `json:"tenantId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SandboxResetResponse is the response for resetting the training sandbox.
+type SandboxResetResponse struct {
+	Message  string    `json:"message"`
+	TenantID string    `json:"tenantId"`
+	SeededAt time.Time `json:"seededAt"`
+}
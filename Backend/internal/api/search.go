@@ -0,0 +1,39 @@
+package api
+
+import "oop/internal/models"
+
+// SearchResponse is the response of the global search: the first matches of each
+// type of record searched, with how many match in all. Types left out of the search
+// are omitted.
+type SearchResponse struct {
+	Query       string                `json:"query" example:"carry"`
+	Cabs        *CabSearchGroup       `json:"cabs,omitempty"`
+	Accessories *AccessorySearchGroup `json:"accessories,omitempty"`
+	Materials   *MaterialSearchGroup  `json:"materials,omitempty"`
+	Customers   *CustomerSearchGroup  `json:"customers,omitempty"`
+}
+
+// CabSearchGroup is the cabs whose name or make matches a search, newest first
+type CabSearchGroup struct {
+	Items []models.MultiCab `json:"items"`
+	Total int64             `json:"total" example:"3"` // Matches, including those not listed
+}
+
+// AccessorySearchGroup is the accessories whose ID or name matches a search
+type AccessorySearchGroup struct {
+	Items []models.Accessory `json:"items"`
+	Total int64              `json:"total" example:"1"`
+}
+
+// MaterialSearchGroup is the materials whose ID, name, category or supplier matches a search
+type MaterialSearchGroup struct {
+	Items []models.Material `json:"items"`
+	Total int64             `json:"total" example:"0"`
+}
+
+// CustomerSearchGroup is the customers whose name, email or phone matches a search,
+// newest first
+type CustomerSearchGroup struct {
+	Items []*CustomerResponse `json:"items"`
+	Total int64               `json:"total" example:"2"`
+}
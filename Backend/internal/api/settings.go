@@ -0,0 +1,16 @@
+package api
+
+import "oop/internal/models"
+
+// DocumentTemplateResponse is the response for changing the document template or logo.
+type DocumentTemplateResponse struct {
+	Message  string                  `json:"message"`
+	Template models.DocumentTemplate `json:"template"`
+}
+
+// FiscalCalendarResponse is the response for getting or changing the fiscal calendar.
+type FiscalCalendarResponse struct {
+	Message     string                `json:"message,omitempty"`
+	Calendar    models.FiscalCalendar `json:"calendar"`
+	CurrentYear ReportPeriod          `json:"currentYear"` // The fiscal year containing today
+}
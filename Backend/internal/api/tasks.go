@@ -0,0 +1,16 @@
+package api
+
+import "oop/internal/models"
+
+// TaskListResponse is the response for listing tasks.
+type TaskListResponse struct {
+	Tasks   []models.Task `json:"tasks"`
+	Count   int           `json:"count"`
+	Overdue int           `json:"overdue"` // Listed tasks still pending after their due date
+}
+
+// TaskResponse is the response for creating or updating a task.
+type TaskResponse struct {
+	Message string       `json:"message"`
+	Task    *models.Task `json:"task"`
+}
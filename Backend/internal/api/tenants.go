@@ -0,0 +1,49 @@
+package api
+
+import (
+	"oop/internal/models"
+	"time"
+)
+
+// TenantListResponse is the response for listing tenants.
+type TenantListResponse struct {
+	Tenants []models.Tenant `json:"tenants"`
+}
+
+// TenantCreatedResponse is the response for provisioning a tenant with its first admin.
+type TenantCreatedResponse struct {
+	Message string         `json:"message"`
+	Tenant  *models.Tenant `json:"tenant"`
+	Admin   *models.User   `json:"admin"`
+}
+
+// SingleTenantResponse is the response for fetching a single tenant.
+type SingleTenantResponse struct {
+	Tenant *models.Tenant `json:"tenant"`
+}
+
+// TenantActionResponse is for actions like update that return a tenant and a message.
+type TenantActionResponse struct {
+	Message string         `json:"message"`
+	Tenant  *models.Tenant `json:"tenant"`
+}
+
+// TenantUsageResponse is the response for a tenant's usage statistics.
+type TenantUsageResponse struct {
+	Usage *models.TenantUsage `json:"usage"`
+}
+
+// TenantFeaturesResponse lists every known feature flag and whether it is enabled.
+type TenantFeaturesResponse struct {
+	TenantID string          `json:"tenantId"`
+	Features map[string]bool `json:"features"`
+}
+
+// ImpersonationResponse is the response for impersonating a tenant admin. The token
+// is short-lived and its actions are attributed to the super admin in the audit log.
+type ImpersonationResponse struct {
+	Message   string       `json:"message"`
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	User      *models.User `json:"user"`
+}
@@ -0,0 +1,35 @@
+package api
+
+import "oop/internal/models"
+
+// TrashListResponse is the response for listing deleted records.
+type TrashListResponse struct {
+	Items []models.TrashItem `json:"items"` // Most recently deleted first
+	Count int                `json:"count"`
+}
+
+// TrashActionStatus is the outcome of restoring or purging one deleted record
+type TrashActionStatus string
+
+// Outcomes reported per record in a bulk restore or purge response
+const (
+	TrashStatusRestored TrashActionStatus = "restored"
+	TrashStatusPurged   TrashActionStatus = "purged"
+	TrashStatusFailed   TrashActionStatus = "failed"
+)
+
+// TrashActionResult reports the outcome for a single record in a bulk restore or purge.
+type TrashActionResult struct {
+	EntityType string            `json:"entityType"`
+	EntityID   string            `json:"entityId"`
+	Status     TrashActionStatus `json:"status"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// TrashActionResponse is the response for a bulk restore or purge.
+type TrashActionResponse struct {
+	Message   string              `json:"message"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []TrashActionResult `json:"results"`
+}
@@ -0,0 +1,20 @@
+package api
+
+import "oop/internal/models"
+
+// QuotaLimits lists the quotas enforced on every tenant. 0 means unlimited.
+type QuotaLimits struct {
+	TenantRequestsPerMinute int `json:"tenantRequestsPerMinute"`
+	ClientRequestsPerMinute int `json:"clientRequestsPerMinute"`
+	MaxRequestBytes         int `json:"maxRequestBytes"`
+	UploadBytesPerDay       int `json:"uploadBytesPerDay"`
+}
+
+// UsageResponse is the response for a tenant's metered usage.
+type UsageResponse struct {
+	TenantID string               `json:"tenantId"`
+	Requests models.RequestUsage  `json:"requests"`
+	Clients  []models.ClientUsage `json:"clients"`
+	Records  *models.TenantUsage  `json:"records"`
+	Quotas   QuotaLimits          `json:"quotas"`
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"oop/internal/models"
+	"time"
+)
+
+// UserAuthResponse is the response for successful user registration or login.
+type UserAuthResponse struct {
+	Message      string       `json:"message"`
+	User         *models.User `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refreshToken,omitempty"` // Exchanged for a new token through POST /users/refresh
+	ExpiresIn    int          `json:"expiresIn,omitempty"`    // Seconds until the token expires
+}
+
+// TokenRefreshRequest carries the refresh token of a session, to refresh or end it.
+type TokenRefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// TokenRefreshResponse is the response for exchanging a refresh token. The refresh
+// token sent is revoked; the one returned replaces it.
+type TokenRefreshResponse struct {
+	Message      string `json:"message"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"` // Seconds until the token expires
+}
+
+// UserListResponse is the response for listing multiple users.
+type UserListResponse struct {
+	Users []*models.User `json:"users"`
+}
+
+// SingleUserResponse is the response for fetching a single user.
+type SingleUserResponse struct {
+	User *models.User `json:"user"`
+}
+
+// UserActionResponse is for actions like create or update that return a user and a message.
+type UserActionResponse struct {
+	Message string       `json:"message"`
+	User    *models.User `json:"user"`
+}
+
+// InviteStatus is the outcome of a single invitation in a bulk invite request
+type InviteStatus string
+
+// Invite result statuses reported per email in a bulk invite response
+const (
+	InviteStatusInvited InviteStatus = "invited"
+	InviteStatusSkipped InviteStatus = "skipped"
+	InviteStatusFailed  InviteStatus = "failed"
+)
+
+// UserInviteResult reports the outcome of a single invitation in a bulk request.
+type UserInviteResult struct {
+	Email  string       `json:"email"`
+	Status InviteStatus `json:"status"`
+	UserID string       `json:"userId,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// UserInviteResponse is the response for a bulk invite request.
+type UserInviteResponse struct {
+	Message string             `json:"message"`
+	Results []UserInviteResult `json:"results"`
+}
+
+// UserInviteListResponse is the response for listing pending invitations.
+type UserInviteListResponse struct {
+	Invites []models.UserInvite `json:"invites"`
+}
+
+// ProvisionAction is what roster provisioning does for one user
+type ProvisionAction string
+
+// Provisioning actions reported in the roster diff
+const (
+	ProvisionActionCreate        ProvisionAction = "create"
+	ProvisionActionReactivate    ProvisionAction = "reactivate"
+	ProvisionActionDeactivate    ProvisionAction = "deactivate"
+	ProvisionActionUpdateRole    ProvisionAction = "update_role"
+	ProvisionActionUnchanged     ProvisionAction = "unchanged"
+	ProvisionActionInvitePending ProvisionAction = "invite_pending" // Invited and not accepted yet, so left as is
+)
+
+// ProvisionStatus is the state of a single provisioning change
+type ProvisionStatus string
+
+// Provisioning statuses reported once changes are applied
+const (
+	ProvisionStatusPending ProvisionStatus = "pending"
+	ProvisionStatusApplied ProvisionStatus = "applied"
+	ProvisionStatusFailed  ProvisionStatus = "failed"
+)
+
+// ProvisioningChange describes what provisioning does (or did) for one user.
+type ProvisioningChange struct {
+	Email        string          `json:"email"`
+	Action       ProvisionAction `json:"action"`
+	UserID       string          `json:"userId,omitempty"`
+	FullName     string          `json:"fullName,omitempty"`
+	Role         string          `json:"role,omitempty"`
+	PreviousRole string          `json:"previousRole,omitempty"`
+	Status       ProvisionStatus `json:"status"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// ProvisioningResponse is the response for a roster provisioning request.
+type ProvisioningResponse struct {
+	DryRun  bool                    `json:"dryRun"`
+	Summary map[ProvisionAction]int `json:"summary"`
+	Changes []ProvisioningChange    `json:"changes"`
+}
+
+// DormantUser is an account the dormant account policy finds inactive
+type DormantUser struct {
+	models.UserDormancy
+	WillDeactivate bool `json:"willDeactivate"` // False for exempt users and admins
+}
+
+// DormantUsersResponse is the response for previewing the dormant account policy.
+type DormantUsersResponse struct {
+	Days   int           `json:"days"`   // Days without a sign-in after which accounts are deactivated
+	Cutoff time.Time     `json:"cutoff"` // Accounts inactive since before this are dormant
+	Users  []DormantUser `json:"users"`  // Least recently active first
+}
+
+// DormantAccountsEvent is pushed to admins when dormant accounts were deactivated
+type DormantAccountsEvent struct {
+	Days  int                   `json:"days"`
+	Users []models.UserDormancy `json:"users"`
+}
+
+// HeartbeatRequest is the optional body of a heartbeat.
+type HeartbeatRequest struct {
+	Branch string `json:"branch"` // Branch code the user is working at; kept from earlier heartbeats when empty
+}
+
+// OnlineUser is a user active within the online window.
+type OnlineUser struct {
+	models.UserPresence
+	Username string `json:"username"`
+	FullName string `json:"fullName"`
+}
+
+// BranchOnlineCount is the number of users online at a branch; users who never sent a
+// branch are counted under an empty branch.
+type BranchOnlineCount struct {
+	Branch string `json:"branch"`
+	Count  int    `json:"count"`
+}
+
+// OnlineUsersResponse is the response for listing the users currently online.
+type OnlineUsersResponse struct {
+	WindowMinutes int                 `json:"windowMinutes"` // Users seen within this many minutes are online
+	Count         int                 `json:"count"`
+	Branches      []BranchOnlineCount `json:"branches"`
+	Users         []OnlineUser        `json:"users"`
+}
+
+// ShiftSchedulesRequest is the body for replacing the shift schedules; an empty list
+// lifts every shift restriction.
+type ShiftSchedulesRequest struct {
+	Schedules []models.ShiftSchedule `json:"schedules"` // IDs are assigned by the server
+}
+
+// ShiftSchedulesResponse is the response for listing the shift schedules.
+type ShiftSchedulesResponse struct {
+	Schedules []models.ShiftSchedule `json:"schedules"` // By role, branch and start time
+}
+
+// ShiftBranchRequest is the body for assigning a user to a branch's shift schedules.
+type ShiftBranchRequest struct {
+	Branch string `json:"branch"` // Branch code; empty for the schedules of the user's role
+}
+
+// ShiftOverrideRequest is the body for letting a user work outside their shift.
+type ShiftOverrideRequest struct {
+	UserID string `json:"userId"`
+	Hours  int    `json:"hours"`  // How long the override lasts, 1 to 72
+	Reason string `json:"reason"` // Why the user works off-shift, up to 255 characters
+}
+
+// ShiftOverridesResponse is the response for listing the overrides in effect.
+type ShiftOverridesResponse struct {
+	Overrides []models.ShiftOverride `json:"overrides"` // Expiring soonest first
+}
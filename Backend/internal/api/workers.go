@@ -0,0 +1,9 @@
+package api
+
+import "oop/internal/models"
+
+// WorkerListResponse is the response for the status of the server's background workers.
+type WorkerListResponse struct {
+	Workers []models.WorkerStatus `json:"workers"`
+	Count   int                   `json:"count"`
+}
@@ -0,0 +1,470 @@
+// Package app wires the web server from its configuration: the repositories of every
+// tenant, the services they share, the handlers serving each tenant's routes and the
+// background jobs. The server command and the tests build the server through it
+// instead of wiring the parts by hand.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"oop/docs" // load API docs generated by Swag CLI
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/handlers"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+	swagger "github.com/gofiber/swagger" // swagger handler
+)
+
+// App is the server wired from a Config. New builds it without starting any background
+// job; Start starts them, Listen serves requests and Shutdown stops everything, the
+// parts created last first.
+type App struct {
+	Config   Config
+	Server   *fiber.App // Serves /health, /submit and the API routes of every tenant
+	Tenants  *TenantRegistry
+	DB       *repositories.DatabaseClient // Nil in mock mode
+	Workers  *services.Supervisor         // Runs the loops of the jobs and queues, restarting those that crash
+	LogLevel *services.LogLevel           // Level the server logs at once installed, which admins can change for a while
+
+	services tenantAppServices
+	jobs     []func() job // Started by Start
+	closers  []closer     // Stopped by Shutdown, last first
+	started  bool
+}
+
+// job is a background job, stopped by Close once its running task finishes
+type job interface {
+	Close()
+}
+
+// closer stops one part of the app, flushing what it still holds within ctx
+type closer struct {
+	name  string // What failed, for the log: "Error <name>: <err>"
+	close func(ctx context.Context) error
+}
+
+// New connects to the database, or seeds the in-memory repositories in mock mode, and
+// builds the services, workers and routes of the server. Nothing runs in the background
+// until Start, other than the queues waiting for work.
+func New(cfg Config) (_ *App, err error) {
+	a := &App{Config: cfg, Workers: services.NewSupervisor(), LogLevel: services.NewLogLevel(cfg.LogLevel.Level)}
+	defer func() {
+		if err != nil {
+			a.close(context.Background()) // Close the database and queues opened before the failure
+		}
+	}()
+
+	fileStorage, err := services.NewStorage(cfg.Storage, cfg.JWTSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize file storage: %w", err)
+	}
+
+	if cfg.MockMode {
+		// Mock mode: in-memory repositories with fixture data, no database or Turnstile keys required
+		if a.Tenants, err = initMockTenants(); err != nil {
+			return nil, fmt.Errorf("failed to initialize mock data: %w", err)
+		}
+		log.Printf("MOCK_MODE enabled: using in-memory data, changes are lost on restart. Log in as %s / %s or %s / %s",
+			memory.FixtureAdminEmail, memory.FixtureAdminPassword, memory.FixtureStaffEmail, memory.FixtureStaffPassword)
+		if fileStorage != nil {
+			log.Printf("STORAGE_DRIVER is ignored in mock mode: uploaded files are kept in memory")
+		}
+	} else {
+		if a.DB, err = repositories.NewDatabaseClient(cfg.Database); err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		log.Println("Database connection test successful.")
+		a.onClose("closing database connection", a.DB.Close)
+		if fileStorage != nil {
+			a.DB.Files = fileStorage
+			log.Printf("Keeping uploaded files in %s storage", cfg.Storage.Driver)
+		}
+		a.Tenants = initSQLTenants(a.DB)
+
+		// Keep checking the connection so /health reports a database that went away
+		if cfg.Database.HealthInterval > 0 {
+			a.schedule(func() job {
+				return services.NewPeriodicJob(a.Workers, "Database health check", a.checkDatabase, cfg.Database.HealthInterval)
+			})
+		}
+	}
+
+	// Make sure no job or queue is left running once they are all stopped, before
+	// the database is closed
+	a.onClose("waiting for background workers", a.Workers.Wait)
+
+	// Every tenant's training sandbox is kept in memory and seeded with fixtures, in both modes
+	a.Tenants.sandboxes = services.NewSandboxes(a.Tenants.tenants)
+
+	if cfg.OIDC.Enabled() {
+		log.Printf("SSO login enabled for issuer %s", cfg.OIDC.Issuer)
+	}
+	usageMeter := services.NewUsageMeter(cfg.Quotas)
+
+	locations, err := services.LoadPHLocations(cfg.Delivery.LocationsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load location dataset: %w", err)
+	}
+	var geocoder services.Geocoder
+	if cfg.Delivery.Geocoder == config.GeocoderNominatim {
+		geocoder = services.NewNominatimGeocoder(cfg.Delivery.GeocoderURL, cfg.Delivery.GeocoderUserAgent)
+	}
+
+	// Ship activity logs to the SIEM collector, if one is configured
+	var logShipper *services.LogShipper
+	if cfg.SIEM.Enabled() {
+		logShipper = services.NewLogShipper(a.Workers, services.NewLogSink(cfg.SIEM), cfg.SIEM)
+		a.onClose("flushing activity logs to SIEM", logShipper.Close)
+	}
+
+	// Push inventory availability and prices to the marketplace, if one is configured
+	var marketplaceSync *services.MarketplaceSync
+	if cfg.Marketplace.Enabled() {
+		marketplaceSync = services.NewMarketplaceSync(a.Workers, services.NewMarketplaceAdapter(cfg.Marketplace), cfg.Marketplace)
+		a.onClose("pushing inventory changes to the marketplace", marketplaceSync.Close)
+		a.schedule(func() job {
+			return services.NewNightlyJob(a.Workers, "Marketplace full sync", func() error {
+				return a.syncMarketplace(marketplaceSync)
+			}, cfg.Marketplace.FullSyncHour, time.Hour)
+		})
+	}
+
+	// Post daily sales journals and payments to the accounting system, if one is configured
+	accountingExporter := services.NewAccountingExporter(cfg.Accounting)
+	if accountingExporter != nil {
+		a.schedule(func() job {
+			return services.NewPeriodicJob(a.Workers, "Accounting sync job", func() error {
+				return a.syncAccounting(accountingExporter, cfg.Accounting)
+			}, time.Hour)
+		})
+	}
+
+	// Export reports to each tenant's Google Sheets spreadsheet, if a service account is configured
+	sheetsWriter, err := services.NewGoogleSheetsWriter(cfg.Sheets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google service account: %w", err)
+	}
+	if sheetsWriter != nil {
+		a.schedule(func() job {
+			return services.NewPeriodicJob(a.Workers, "Google Sheets export job", func() error {
+				return a.exportGoogleSheets(sheetsWriter, cfg.Sheets)
+			}, cfg.Sheets.Interval)
+		})
+	}
+
+	// Notifications are fanned out per tenant to the clients connected to this instance
+	notificationHub := services.NewNotificationHub()
+
+	// Post big sales and stock-outs to the Slack and Telegram channels that are configured
+	for _, channel := range cfg.Chat.Channels {
+		notifier := services.NewChatNotifier(a.Workers, services.NewChatChannel(channel), channel, cfg.Chat)
+		notificationHub.AddNotifier(notifier)
+		a.onClose("posting chat notifications", notifier.Close)
+	}
+	viewTracker := services.NewViewTracker(a.Workers) // Writes recently viewed records in the background
+	a.onClose("recording recently viewed records", closeFunc(viewTracker.Close))
+	imageVariants := services.NewImageVariantQueue(a.Workers) // Makes the smaller sizes of uploaded photos in the background
+	a.onClose("making photo variants", closeFunc(imageVariants.Close))
+	exportQueue := services.NewExportQueue(a.Workers, cfg.Exports.Workers)
+	a.onClose("building exports", closeFunc(exportQueue.Close))
+
+	a.scheduleJobs(notificationHub)
+
+	a.services = tenantAppServices{
+		mailer:           services.NewMailer(cfg.Mailer),
+		oidcConfig:       cfg.OIDC,
+		permissions:      handlers.NewPermissions(cfg.Permissions),
+		features:         handlers.NewFeatureFlags(a.Tenants.tenants, cfg.JWTSecret),
+		usage:            handlers.NewUsageHandler(usageMeter, a.Tenants.tenants, cfg.JWTSecret),
+		workers:          handlers.NewWorkerHandler(a.Workers, cfg.JWTSecret),
+		logLevel:         handlers.NewLogLevelHandler(a.LogLevel, cfg.LogLevel, cfg.JWTSecret),
+		hub:              notificationHub,
+		views:            viewTracker,
+		imageVariants:    imageVariants,
+		exports:          exportQueue,
+		exportRetention:  cfg.Exports.Retention,
+		files:            fileStorage,
+		fileLinkExpiry:   cfg.Storage.URLExpiry,
+		submissions:      services.NewSubmissionGuard(cfg.DuplicateWindow),
+		undo:             services.NewUndoWindow(cfg.UndoWindow),
+		presence:         services.NewPresence(cfg.OnlineWindow),
+		logins:           services.NewLoginThrottle(cfg.LoginThrottle),
+		sandboxes:        a.Tenants.sandboxes,
+		sessions:         cfg.Sessions,
+		dormantDays:      cfg.DormantDays,
+		priceApproval:    cfg.PriceApprovalPercent,
+		anomalies:        cfg.Anomalies,
+		marketplace:      marketplaceSync,
+		accounting:       accountingExporter,
+		accountingConfig: cfg.Accounting,
+		sheets:           sheetsWriter,
+		sheetsConfig:     cfg.Sheets,
+		bigSaleThreshold: cfg.Chat.BigSaleThreshold,
+		frontendURL:      cfg.FrontendURL,
+		passwordResetTTL: cfg.PasswordResetTTL,
+		locations:        locations,
+		geocoder:         geocoder,
+		delivery:         cfg.Delivery,
+		registrationDays: cfg.Registrations.DueDays,
+	}
+
+	if err := a.buildServer(usageMeter, logShipper); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// scheduleJobs schedules the jobs every server runs on all tenants
+func (a *App) scheduleJobs(hub *services.NotificationHub) {
+	cfg := a.Config
+
+	// Delete the exports of every tenant once they expire, hourly
+	a.schedule(func() job {
+		return services.NewPeriodicJob(a.Workers, "Export purge job", a.purgeExpiredExports, time.Hour)
+	})
+
+	// Snapshot every tenant's inventory shortly after each month ends
+	a.schedule(func() job { return services.NewMonthEndJob(a.Workers, a.snapshotInventories, time.Hour) })
+
+	// Enforce the dormant account policy on every tenant, hourly
+	if cfg.DormantDays > 0 {
+		a.schedule(func() job {
+			return services.NewPeriodicJob(a.Workers, "Dormant account job", func() error {
+				return a.deactivateDormantAccounts(cfg.DormantDays, hub)
+			}, time.Hour)
+		})
+	}
+
+	// Report inconsistent records of every tenant at startup and then periodically.
+	// Nothing is fixed unattended; admins fix what is safe through the admin route.
+	if cfg.IntegrityInterval > 0 {
+		a.schedule(func() job {
+			return services.NewPeriodicJob(a.Workers, "Integrity check job", a.checkIntegrity, cfg.IntegrityInterval)
+		})
+	}
+
+	// Flag the sales of every tenant whose payments do not match their total for
+	// finance to review
+	if cfg.ReconciliationInterval > 0 {
+		a.schedule(func() job {
+			return services.NewPeriodicJob(a.Workers, "Payment reconciliation job", a.reconcilePayments, cfg.ReconciliationInterval)
+		})
+	}
+
+	// Flag the unusual sales and stock movements of every tenant's previous day for
+	// admins to review
+	a.schedule(func() job {
+		return services.NewNightlyJob(a.Workers, "Anomaly scan", func() error {
+			return a.scanAnomalies(cfg.Anomalies)
+		}, cfg.Anomalies.ScanHour, time.Hour)
+	})
+
+	// Notify every tenant's sales team of the customers' birthdays and anniversaries
+	// coming up, for greetings and promotions
+	a.schedule(func() job {
+		return services.NewNightlyJob(a.Workers, "Customer occasion notices", func() error {
+			return a.notifyCustomerOccasions(cfg.Occasions.NoticeDays, hub)
+		}, cfg.Occasions.NotifyHour, time.Hour)
+	})
+
+	// Alert every tenant's back office of the LTO registrations coming due and overdue
+	a.schedule(func() job {
+		return services.NewNightlyJob(a.Workers, "Registration due alerts", func() error {
+			return a.notifyDueRegistrations(cfg.Registrations.AlertDays, hub)
+		}, cfg.Registrations.AlertHour, time.Hour)
+	})
+
+	// Alert every tenant's sales team of the insurance policies expiring that were not renewed
+	a.schedule(func() job {
+		return services.NewNightlyJob(a.Workers, "Insurance expiry alerts", func() error {
+			return a.notifyExpiringPolicies(cfg.Insurance.AlertDays, hub)
+		}, cfg.Insurance.AlertHour, time.Hour)
+	})
+}
+
+// buildServer creates the Fiber app serving the public routes, the routes run outside
+// any tenant and, through the app of the request's tenant, every other API route
+func (a *App) buildServer(usageMeter *services.UsageMeter, logShipper *services.LogShipper) error {
+	cfg := a.Config
+	app := fiber.New(fiber.Config{ErrorHandler: errorHandler})
+
+	// Add middleware
+	app.Use(logger.New())
+	app.Use(recover.New())
+	app.Use(middleware.DebugLog()) // Details every request while the log level is debug
+
+	// Get allowed origins from environment variable or use default for development
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.FrontendURL,               // Restricted to specific origins from environment variable
+		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS", // Added OPTIONS for preflight
+		AllowCredentials: true,
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, If-Unmodified-Since",
+		ExposeHeaders:    "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Undo-Token, X-Undo-Expires-At, X-Price-Change-Id, Deprecation, Sunset, Link", // Let the frontend back off before hitting quotas, offer undo after deletes, track held price changes and detect deprecations
+	}))
+
+	// Keep user management and admin routes to the office network or VPN, if configured
+	restrictAdminRoutes(app, cfg.AdminNetworks)
+
+	// --- Route Registration ---
+	apiGroup := app.Group("/api") // Base group for API routes
+
+	// Mark responses of deprecated endpoints; registered first so it covers every route
+	metaHandler := handlers.NewMetaHandler(docs.SwaggerInfo)
+	apiGroup.Use(metaHandler.DeprecationHeaders())
+
+	// Swagger docs route
+	apiGroup.Get("/swagger/*", swagger.HandlerDefault) // get /api/swagger/*
+	routeMiddleware := handlers.NewRouteMiddleware(cfg.JWTSecret)
+	metaHandler.Routes(apiGroup, routeMiddleware)
+
+	// Super-admin routes run outside any tenant; actions are audited in the default tenant's log
+	defaultRepos, err := a.Tenants.Get(models.DefaultTenantID)
+	if err != nil {
+		return fmt.Errorf("failed to initialize default tenant: %w", err)
+	}
+	tenantHandler := handlers.NewTenantHandler(a.Tenants.tenants, a.Tenants.scope, cfg.JWTSecret)
+	tenantHandler.Audit = handlers.NewChangeRecorder(defaultRepos.Logs)
+	tenantHandler.Routes(apiGroup, routeMiddleware)
+
+	// Download links of the local storage driver are signed per file, so they are served outside any tenant
+	if localStorage, ok := a.services.files.(*services.LocalStorage); ok {
+		handlers.NewStoredFileHandler(localStorage).Routes(apiGroup, routeMiddleware)
+	}
+
+	// @Summary Submit Turnstile Captcha
+	// @Description Verifies a Cloudflare Turnstile token.
+	// @Tags Captcha
+	// @Accept x-www-form-urlencoded
+	// @Produce json
+	// @Param cf-turnstile-response formData string true "Cloudflare Turnstile Token"
+	// @Success 200 {object} api.StatusResponse "Captcha passed"
+	// @Failure 400 {object} api.ErrorResponse "Captcha token missing"
+	// @Failure 403 {object} api.ErrorResponse "Invalid captcha"
+	// @Failure 500 {object} api.ErrorResponse "Verification failed"
+	// @Router /submit [post]
+	captcha := turnstileMiddleware()
+	if cfg.MockMode {
+		captcha = func(c *fiber.Ctx) error { return c.Next() } // No Turnstile keys in mock mode
+	}
+	app.Post("/submit", captcha, func(c *fiber.Ctx) error {
+		return c.JSON(api.StatusResponse{Status: "ok"})
+	})
+
+	// Every other API route is served by the app of the request's tenant
+	tenantApps := middleware.NewTenantApps(func(tenantID string) (*fiber.App, error) {
+		repos, err := a.Tenants.Get(tenantID)
+		if err != nil {
+			return nil, err
+		}
+		if logShipper != nil {
+			repos.Logs = repositories.WithLogForwarding(repos.Logs, logShipper, tenantID)
+		}
+		return a.newTenantApp(repos), nil
+	})
+	a.Tenants.sandboxes.OnReset = func(sandboxID string) {
+		a.Tenants.forget(sandboxID)
+		tenantApps.Forget(sandboxID) // Rebuilt on the next request, bound to the reseeded data
+	}
+	app.Use("/api", middleware.TenantResolver(a.Tenants.sandboxes, cfg.Tenancy, cfg.JWTSecret), middleware.Quota(usageMeter, cfg.JWTSecret), tenantApps.Handler())
+
+	// Add a health check endpoint (public)
+	// @Summary Health Check
+	// @Description Checks if the server is running and, unless in mock mode, whether it can reach the database
+	// @Tags Health
+	// @Accept json
+	// @Produce json
+	// @Success 200 {object} api.HealthResponse "Server is up"
+	// @Failure 503 {object} api.HealthResponse "Server is up but the database is not reachable"
+	// @Router /health [get]
+	app.Get("/health", func(c *fiber.Ctx) error {
+		health := api.HealthResponse{Status: "ok", Time: time.Now().Format(time.RFC3339)}
+		if a.DB == nil {
+			return c.Status(fiber.StatusOK).JSON(health)
+		}
+		health.Database = "up"
+		if !a.DB.Healthy() {
+			health.Status, health.Database = "degraded", "down"
+			return c.Status(fiber.StatusServiceUnavailable).JSON(health)
+		}
+		return c.Status(fiber.StatusOK).JSON(health)
+	})
+
+	a.Server = app
+	return nil
+}
+
+// Start starts the background jobs. Calling it again does nothing.
+func (a *App) Start() {
+	if a.started {
+		return
+	}
+	a.started = true
+	for _, start := range a.jobs {
+		a.onClose("stopping job", closeFunc(start().Close))
+	}
+}
+
+// Listen serves requests on addr, such as ":8080", until Shutdown
+func (a *App) Listen(addr string) error {
+	log.Printf("Starting server on %s", addr)
+	return a.Server.Listen(addr)
+}
+
+// Shutdown stops serving requests, waiting for those in flight, then stops the jobs,
+// flushes the queues and closes the database, within ctx. Failures are logged and
+// returned together.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+	if a.Server != nil {
+		if err := a.Server.ShutdownWithContext(ctx); err != nil {
+			log.Printf("Error during server shutdown: %v", err)
+			errs = append(errs, fmt.Errorf("server shutdown: %w", err))
+		}
+	}
+	return errors.Join(append(errs, a.close(ctx)...)...)
+}
+
+// schedule adds a job for Start to start
+func (a *App) schedule(start func() job) {
+	a.jobs = append(a.jobs, start)
+}
+
+// onClose adds a part for Shutdown to stop
+func (a *App) onClose(name string, close func(ctx context.Context) error) {
+	a.closers = append(a.closers, closer{name: name, close: close})
+}
+
+// close stops the parts of the app, the last added first, and forgets them
+func (a *App) close(ctx context.Context) []error {
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		c := a.closers[i]
+		if err := c.close(ctx); err != nil {
+			log.Printf("Error %s: %v", c.name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+	}
+	a.closers = nil
+	return errs
+}
+
+// closeFunc adapts the Close method of a part that cannot fail
+func closeFunc(close func()) func(ctx context.Context) error {
+	return func(context.Context) error {
+		close()
+		return nil
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockApp builds the app in mock mode from the default configuration
+func newMockApp(t *testing.T) *App {
+	t.Setenv("MOCK_MODE", "true")
+	t.Setenv("FRONTEND_URL", "http://localhost:9000")
+	cfg, err := LoadConfig([]byte("app-test-secret-at-least-32-characters"))
+	require.NoError(t, err)
+
+	a, err := New(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { a.Shutdown(context.Background()) })
+	return a
+}
+
+func TestLoadConfigRequiresFrontendURL(t *testing.T) {
+	t.Setenv("MOCK_MODE", "true")
+	t.Setenv("FRONTEND_URL", "")
+
+	_, err := LoadConfig([]byte("app-test-secret-at-least-32-characters"))
+	assert.ErrorContains(t, err, "FRONTEND_URL is not set")
+}
+
+func TestNewServesTenantRoutes(t *testing.T) {
+	a := newMockApp(t)
+	assert.Nil(t, a.DB, "mock mode needs no database")
+
+	resp, err := a.Server.Test(httptest.NewRequest(http.MethodGet, "/health", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Public cab listing, served by the default tenant's app from its fixtures
+	resp, err = a.Server.Test(httptest.NewRequest(http.MethodGet, "/api/cabs", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var cabs []models.MultiCab
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&cabs))
+
+	repos, err := a.Tenants.Get(models.DefaultTenantID)
+	require.NoError(t, err)
+	stored, err := repos.Cabs.GetCabs(context.Background(), models.CabFilter{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, cabs)
+	assert.Len(t, cabs, len(stored))
+}
+
+func TestHealthReportsDatabaseDown(t *testing.T) {
+	a := newMockApp(t)
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	a.DB = &repositories.DatabaseClient{DB: db}
+
+	health := func() (int, api.HealthResponse) {
+		resp, err := a.Server.Test(httptest.NewRequest(http.MethodGet, "/health", nil))
+		require.NoError(t, err)
+		var body api.HealthResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	assert.Error(t, a.checkDatabase())
+	status, body := health()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "degraded", body.Status)
+	assert.Equal(t, "down", body.Database)
+
+	mock.ExpectPing()
+	require.NoError(t, a.checkDatabase())
+	status, body = health()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "ok", body.Status)
+	assert.Equal(t, "up", body.Database)
+}
+
+func TestShutdownClosesLastAddedFirst(t *testing.T) {
+	a := newMockApp(t)
+	a.Start()
+	a.Start() // Jobs are started once
+
+	var closed []string
+	for _, name := range []string{"database", "queue", "shipper"} {
+		a.onClose(name, func(context.Context) error {
+			closed = append(closed, name)
+			if name == "queue" {
+				return errors.New("flush failed")
+			}
+			return nil
+		})
+	}
+
+	err := a.Shutdown(context.Background())
+	assert.Equal(t, []string{"shipper", "queue", "database"}, closed)
+	assert.ErrorContains(t, err, "queue: flush failed")
+	assert.Empty(t, a.closers, "the jobs, queues and everything else were stopped too")
+}
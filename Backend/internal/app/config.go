@@ -0,0 +1,191 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"oop/internal/config"
+)
+
+// Config is everything the server is built from, loaded from the environment
+type Config struct {
+	MockMode    bool // In-memory repositories seeded with fixtures instead of the database
+	JWTSecret   []byte
+	FrontendURL string
+
+	Database      config.DatabaseConfig // Unused in mock mode
+	Tenancy       config.TenancyConfig
+	Storage       config.StorageConfig
+	Mailer        config.MailerConfig
+	OIDC          config.OIDCConfig
+	Permissions   config.PermissionsConfig
+	Quotas        config.QuotaConfig
+	Sessions      config.SessionConfig
+	LoginThrottle config.LoginThrottleConfig
+	Delivery      config.DeliveryConfig
+	Exports       config.ExportConfig
+	Anomalies     config.AnomalyConfig
+	Occasions     config.CustomerOccasionConfig
+	Registrations config.RegistrationConfig
+	Insurance     config.InsuranceConfig
+	SIEM          config.SIEMConfig
+	Marketplace   config.MarketplaceConfig
+	Accounting    config.AccountingConfig
+	Sheets        config.GoogleSheetsConfig
+	Chat          config.ChatConfig
+	LogLevel      config.LogLevelConfig
+
+	DuplicateWindow        time.Duration // 0 lets forms be submitted twice
+	UndoWindow             time.Duration
+	PasswordResetTTL       time.Duration
+	OnlineWindow           time.Duration
+	DormantDays            int     // 0 disables the dormant account policy
+	PriceApprovalPercent   float64 // 0 disables price change approvals
+	IntegrityInterval      time.Duration
+	ReconciliationInterval time.Duration
+	AdminNetworks          []*net.IPNet // Nil lets administrative routes be reached from anywhere
+}
+
+// LoadConfig loads the configuration of the server signing tokens with jwtSecret.
+// FRONTEND_URL must be set. In mock mode neither the database nor Turnstile needs to
+// be configured.
+func LoadConfig(jwtSecret []byte) (Config, error) {
+	cfg := Config{JWTSecret: jwtSecret, FrontendURL: os.Getenv("FRONTEND_URL")}
+	if cfg.FrontendURL == "" {
+		return Config{}, fmt.Errorf("FRONTEND_URL is not set. Please set it to a valid frontend URL (e.g., http://localhost:9000)")
+	}
+
+	var err error
+	if cfg.MockMode, err = config.LoadMockMode(); err != nil {
+		return Config{}, fmt.Errorf("failed to load mock mode configuration: %w", err)
+	}
+	if !cfg.MockMode {
+		if cfg.Database, err = config.LoadDatabaseConfig(); err != nil {
+			return Config{}, fmt.Errorf("failed to load database configuration: %w", err)
+		}
+		// Turnstile is read per request; it is only checked here
+		if _, err := config.LoadTurnstileConfig(); err != nil {
+			return Config{}, fmt.Errorf("failed to load Turnstile configuration: %w", err)
+		}
+	}
+
+	// Tenancy (subdomain resolution is optional)
+	if cfg.Tenancy, err = config.LoadTenancyConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load tenancy configuration: %w", err)
+	}
+	// File storage (photos and attachments stay in the database unless a driver is set)
+	if cfg.Storage, err = config.LoadStorageConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load storage configuration: %w", err)
+	}
+	// Mailer (SMTP is optional; emails are logged when it is not configured)
+	if cfg.Mailer, err = config.LoadMailerConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load mailer configuration: %w", err)
+	}
+	// SSO (optional; the OIDC routes are only registered when configured)
+	if cfg.OIDC, err = config.LoadOIDCConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load OIDC configuration: %w", err)
+	}
+	// Role permissions (admins hold every permission)
+	if cfg.Permissions, err = config.LoadPermissionsConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load permissions configuration: %w", err)
+	}
+	// Quotas (all disabled unless configured)
+	if cfg.Quotas, err = config.LoadQuotaConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load quota configuration: %w", err)
+	}
+	// Catch forms submitted twice (on by default)
+	if cfg.DuplicateWindow, err = config.LoadDuplicateSubmissionWindow(); err != nil {
+		return Config{}, fmt.Errorf("failed to load duplicate submission configuration: %w", err)
+	}
+	// Let deletes of customers, accessories and materials be undone for a while
+	if cfg.UndoWindow, err = config.LoadUndoWindow(); err != nil {
+		return Config{}, fmt.Errorf("failed to load undo configuration: %w", err)
+	}
+	// Lifetimes of access and refresh tokens
+	if cfg.Sessions, err = config.LoadSessionConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load session configuration: %w", err)
+	}
+	// Lock accounts and block addresses after repeated failed sign-ins
+	if cfg.LoginThrottle, err = config.LoadLoginThrottleConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load login throttle configuration: %w", err)
+	}
+	// How long emailed password reset links stay valid
+	if cfg.PasswordResetTTL, err = config.LoadPasswordResetTTL(); err != nil {
+		return Config{}, fmt.Errorf("failed to load password reset configuration: %w", err)
+	}
+	// Customer address validation, geocoding and delivery estimates
+	if cfg.Delivery, err = config.LoadDeliveryConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load delivery configuration: %w", err)
+	}
+	// Workers and retention of exports built in the background
+	if cfg.Exports, err = config.LoadExportConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load export configuration: %w", err)
+	}
+	// Deactivate accounts nobody signed in to for a while (off by default)
+	if cfg.DormantDays, err = config.LoadDormantAccountDays(); err != nil {
+		return Config{}, fmt.Errorf("failed to load dormant account configuration: %w", err)
+	}
+	// How long after their last request users are listed as online
+	if cfg.OnlineWindow, err = config.LoadOnlineWindow(); err != nil {
+		return Config{}, fmt.Errorf("failed to load online users configuration: %w", err)
+	}
+	// Hold large price changes of cabs and accessories for approval (off by default)
+	if cfg.PriceApprovalPercent, err = config.LoadPriceApprovalThreshold(); err != nil {
+		return Config{}, fmt.Errorf("failed to load price approval configuration: %w", err)
+	}
+	// Check every tenant's data for inconsistent records (daily by default)
+	if cfg.IntegrityInterval, err = config.LoadIntegrityCheckInterval(); err != nil {
+		return Config{}, fmt.Errorf("failed to load integrity check configuration: %w", err)
+	}
+	// Compare every tenant's payments with its sale totals (daily by default)
+	if cfg.ReconciliationInterval, err = config.LoadPaymentReconciliationInterval(); err != nil {
+		return Config{}, fmt.Errorf("failed to load payment reconciliation configuration: %w", err)
+	}
+	// Thresholds of the nightly scan for unusual sales and stock movements
+	if cfg.Anomalies, err = config.LoadAnomalyConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load anomaly scan configuration: %w", err)
+	}
+	// When the sales team hears of customers' upcoming birthdays and anniversaries
+	if cfg.Occasions, err = config.LoadCustomerOccasionConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load customer occasion configuration: %w", err)
+	}
+	// When LTO registrations of sold cabs are due and the back office is alerted of them
+	if cfg.Registrations, err = config.LoadRegistrationConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load registration configuration: %w", err)
+	}
+	// When the sales team is alerted of insurance policies expiring
+	if cfg.Insurance, err = config.LoadInsuranceConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load insurance configuration: %w", err)
+	}
+	// Networks administrative routes can be reached from (any by default)
+	if cfg.AdminNetworks, err = config.LoadAdminIPAllowlist(); err != nil {
+		return Config{}, fmt.Errorf("failed to load admin IP allowlist: %w", err)
+	}
+	// SIEM collector activity logs are shipped to, if any
+	if cfg.SIEM, err = config.LoadSIEMConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load SIEM configuration: %w", err)
+	}
+	// Marketplace inventory availability and prices are pushed to, if any
+	if cfg.Marketplace, err = config.LoadMarketplaceConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load marketplace configuration: %w", err)
+	}
+	// Accounting system daily sales journals and payments are posted to, if any
+	if cfg.Accounting, err = config.LoadAccountingConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load accounting configuration: %w", err)
+	}
+	// Service account reports are exported to Google Sheets with, if any
+	if cfg.Sheets, err = config.LoadGoogleSheetsConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load Google Sheets configuration: %w", err)
+	}
+	// Slack and Telegram channels big sales and stock-outs are posted to
+	if cfg.Chat, err = config.LoadChatConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load chat notification configuration: %w", err)
+	}
+	// Level the server logs at, and how long a level admins set at runtime lasts
+	if cfg.LogLevel, err = config.LoadLogLevelConfig(); err != nil {
+		return Config{}, fmt.Errorf("failed to load log level configuration: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,359 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/handlers"
+	"oop/internal/models"
+	"oop/internal/services"
+)
+
+// checkDatabase pings the database, marking the server degraded while it does not answer
+func (a *App) checkDatabase() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return a.DB.Check(ctx)
+}
+
+// snapshotInventories takes the end-of-month inventory snapshot of every tenant
+// that has none for month yet
+func (a *App) snapshotInventories(month string) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		snapshots := handlers.NewInventorySnapshotHandler(repos.Snapshots, repos.Cabs, repos.Accessories, repos.Materials, a.Config.JWTSecret)
+		taken, err := snapshots.TakeSnapshot(context.Background(), month)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if taken {
+			log.Printf("Took the %s inventory snapshot of tenant %s", month, tenant.ID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deactivateDormantAccounts deactivates the accounts of every tenant nobody signed in
+// to for days days
+func (a *App) deactivateDormantAccounts(days int, hub *services.NotificationHub) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		dormancy := handlers.NewDormantAccountHandler(repos.Users, repos.Dormancy, repos.Logs, days, a.Config.JWTSecret)
+		dormancy.Hub = hub
+		deactivated, err := dormancy.DeactivateDormant(context.Background(), tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+		if len(deactivated) > 0 {
+			log.Printf("Deactivated %d dormant accounts of tenant %s", len(deactivated), tenant.ID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// purgeExpiredExports deletes the expired exports of every tenant
+func (a *App) purgeExpiredExports() error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		deleted, err := repos.Exports.DeleteExpired(time.Now())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d expired exports of tenant %s", deleted, tenant.ID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkIntegrity checks the data of every tenant and logs how many problems were found
+func (a *App) checkIntegrity() error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		report, err := handlers.NewIntegrityHandler(repos.Integrity, a.Config.JWTSecret).Check(false)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if len(report.Issues) == 0 {
+			continue
+		}
+		// Only counts are logged; the admin route lists the records
+		log.Printf("Integrity check of tenant %s found %d problems: %d orphaned sale items, %d sale totals not matching their items, %d negative quantities, %d sales with a missing customer",
+			tenant.ID, len(report.Issues), report.Counts[models.IntegrityOrphanSaleItem], report.Counts[models.IntegrityTotalMismatch],
+			report.Counts[models.IntegrityNegativeQuantity], report.Counts[models.IntegrityMissingCustomer])
+	}
+	return errors.Join(errs...)
+}
+
+// scanAnomalies flags the unusual sales and stock movements of every tenant's
+// previous day. Sources flagged by an earlier run are skipped, so retries are safe.
+func (a *App) scanAnomalies(cfg config.AnomalyConfig) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	yesterday := time.Now().AddDate(0, 0, -1)
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		found, err := handlers.NewAnomalyHandler(repos.Anomalies, cfg, a.Config.JWTSecret).Scan(yesterday)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if len(found) > 0 {
+			log.Printf("Anomaly scan of tenant %s flagged %d anomalies on %s", tenant.ID, len(found), yesterday.Format("2006-01-02"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyCustomerOccasions notifies the users of every tenant of the customers' birthdays
+// and anniversaries falling noticeDays days from today
+func (a *App) notifyCustomerOccasions(noticeDays int, hub *services.NotificationHub) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		customerHandler := handlers.NewCustomerHandler(repos.Customers, a.Config.JWTSecret)
+		customerHandler.Consents = repos.Consents // Only customers who can be contacted are greeted
+		occasions, err := customerHandler.NotifyUpcomingOccasions(context.Background(), tenant.ID, repos.Users, hub, noticeDays)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if len(occasions) > 0 {
+			log.Printf("Notified tenant %s of %d customer birthdays and anniversaries", tenant.ID, len(occasions))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyDueRegistrations alerts every tenant's back office of the LTO registrations due
+// within alertDays days and those overdue
+func (a *App) notifyDueRegistrations(alertDays int, hub *services.NotificationHub) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		registrationHandler := handlers.NewRegistrationHandler(repos.Registrations, repos.Sales, a.Config.JWTSecret)
+		count, err := registrationHandler.NotifyDueRegistrations(context.Background(), tenant.ID, repos.Users, hub, alertDays)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if count > 0 {
+			log.Printf("Alerted tenant %s of %d LTO registrations due or overdue", tenant.ID, count)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// notifyExpiringPolicies alerts every tenant's sales team of the insurance policies
+// expiring alertDays days from now that were not renewed
+func (a *App) notifyExpiringPolicies(alertDays int, hub *services.NotificationHub) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		insuranceHandler := handlers.NewInsuranceHandler(repos.Insurance, repos.Sales, repos.Customers, a.Config.JWTSecret)
+		count, err := insuranceHandler.NotifyExpiringPolicies(context.Background(), tenant.ID, repos.Users, hub, alertDays)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if count > 0 {
+			log.Printf("Alerted tenant %s of %d insurance policies expiring", tenant.ID, count)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reconcilePayments compares the payments of every tenant's sales with their totals
+// and flags the mismatches. Mismatches flagged by an earlier run are skipped.
+func (a *App) reconcilePayments() error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		_, found, err := handlers.NewSalePaymentHandler(repos.Payments, repos.Sales, repos.Anomalies, a.Config.JWTSecret).Reconcile()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		if len(found) > 0 {
+			log.Printf("Payment reconciliation of tenant %s flagged %d sales not matching their payments", tenant.ID, len(found))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncMarketplace pushes the listings of every tenant's cabs and accessories to the
+// marketplace, reconciling it with changes that were not pushed as they happened
+func (a *App) syncMarketplace(marketplace *services.MarketplaceSync) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		cabs, err := repos.Cabs.GetCabs(context.Background(), models.CabFilter{})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: failed to list cabs: %w", tenant.ID, err))
+			continue
+		}
+		accessories, err := repos.Accessories.GetAll(context.Background())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: failed to list accessories: %w", tenant.ID, err))
+			continue
+		}
+
+		listings := make([]services.MarketplaceListing, 0, len(cabs)+len(accessories))
+		for _, cab := range cabs {
+			listings = append(listings, services.CabListing(cab))
+		}
+		for _, accessory := range accessories {
+			listings = append(listings, services.AccessoryListing(accessory))
+		}
+		if err := marketplace.FullSync(tenant.ID, listings); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncAccounting posts the sales journals and payments of every tenant that are due,
+// and retries the ones that failed
+func (a *App) syncAccounting(exporter services.AccountingExporter, cfg config.AccountingConfig) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		accounting := handlers.NewAccountingHandler(repos.Accounting, repos.Sales, exporter, cfg, a.Config.JWTSecret)
+		if err := accounting.Sync(context.Background(), tenant.ID); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// exportGoogleSheets writes the reports of every tenant that set up a Google Sheets
+// export to its spreadsheet
+func (a *App) exportGoogleSheets(writer services.SheetsWriter, cfg config.GoogleSheetsConfig) error {
+	all, err := a.Tenants.tenants.GetAll()
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenant := range all {
+		repos, err := a.Tenants.Get(tenant.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+			continue
+		}
+		sheets := handlers.NewGoogleSheetsHandler(repos.Sheets, repos.Sales, repos.Cabs, repos.Accessories, repos.Materials, writer, cfg, a.Config.JWTSecret)
+		if err := sheets.Export(context.Background()); err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,417 @@
+package app
+
+import (
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/handlers"
+	"oop/internal/middleware"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/recover"
+)
+
+// tenantAppServices are the dependencies every tenant app shares
+type tenantAppServices struct {
+	mailer           services.Mailer
+	oidcConfig       config.OIDCConfig
+	permissions      *handlers.Permissions
+	features         *handlers.FeatureFlags
+	usage            *handlers.UsageHandler
+	workers          *handlers.WorkerHandler
+	logLevel         *handlers.LogLevelHandler
+	hub              *services.NotificationHub
+	views            *services.ViewTracker
+	imageVariants    *services.ImageVariantQueue
+	exports          *services.ExportQueue
+	exportRetention  time.Duration
+	files            services.Storage // Nil when files are kept in the database
+	fileLinkExpiry   time.Duration
+	submissions      *services.SubmissionGuard
+	undo             *services.UndoWindow
+	presence         *services.Presence
+	logins           *services.LoginThrottle
+	sandboxes        *services.Sandboxes
+	sessions         config.SessionConfig
+	dormantDays      int                         // 0 disables the dormant account policy
+	priceApproval    float64                     // Percentage a price may change by without approval; 0 disables approvals
+	anomalies        config.AnomalyConfig        // Thresholds of the nightly anomaly scan
+	marketplace      *services.MarketplaceSync   // Nil unless a marketplace is configured
+	accounting       services.AccountingExporter // Nil unless an accounting system is configured
+	accountingConfig config.AccountingConfig
+	sheets           services.SheetsWriter // Nil unless a Google service account is configured
+	sheetsConfig     config.GoogleSheetsConfig
+	bigSaleThreshold float64 // 0 disables big sale alerts
+	frontendURL      string
+	passwordResetTTL time.Duration
+	registrationDays int // Days after the sale an LTO registration is due by default
+	locations        *services.PHLocations
+	geocoder         services.Geocoder // Nil unless a geocoder is configured
+	delivery         config.DeliveryConfig
+}
+
+// errorHandler reports errors returned by handlers as JSON
+func errorHandler(c *fiber.Ctx, err error) error {
+	// Default error handling
+	code := fiber.StatusInternalServerError
+	if e, ok := err.(*fiber.Error); ok {
+		code = e.Code
+	}
+	log.Printf("Error: %v", err) // Log the error
+	return c.Status(code).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// newTenantApp creates the app that serves the API routes of one tenant. The handlers
+// only see that tenant's repositories, so they never need to filter by tenant themselves.
+func (a *App) newTenantApp(repos Repositories) *fiber.App {
+	svc, jwtSecret := a.services, a.Config.JWTSecret
+	app := fiber.New(fiber.Config{ErrorHandler: errorHandler})
+	app.Use(recover.New())
+	app.Use(handlers.NewAuditTrail(repos.Logs, jwtSecret).Record) // Records every change of a signed-in user in the activity log, refused ones included
+	presenceHandler := handlers.NewPresenceHandler(repos.Users, svc.presence, jwtSecret)
+	app.Use(presenceHandler.Track) // Marks callers online once a route has authenticated them
+	shiftHandler := handlers.NewShiftHandler(repos.Users, repos.Shifts, repos.Logs, jwtSecret)
+	app.Use(shiftHandler.Enforce) // Refuses changes by users outside their shift
+
+	userRepo := repos.Users
+	materialRepo := repos.Materials
+	accessoryRepo := repos.Accessories
+	customerRepo := repos.Customers
+	cabsRepo := repos.Cabs
+	saleRepo := repos.Sales
+	logsRepo := repos.Logs
+	inviteRepo := repos.Invites
+
+	// Initialize handlers
+	userHandler := handlers.NewUserHandler(userRepo, jwtSecret)
+	inviteHandler := handlers.NewUserInviteHandler(userRepo, inviteRepo, svc.mailer, svc.frontendURL, jwtSecret)
+	provisioningHandler := handlers.NewUserProvisioningHandler(userRepo, inviteHandler)
+	materialHandler := handlers.NewMaterialHandlers(materialRepo, jwtSecret)
+	customerHandler := handlers.NewCustomerHandler(customerRepo, jwtSecret)
+	customerHandler.Perms = svc.permissions
+	// Initialize cabs handler
+	cabsHandler := handlers.NewCabsHandlers(cabsRepo)
+	cabsHandler.Perms = svc.permissions
+	accessoryHandler := handlers.NewAccessoriesHandler(accessoryRepo)
+	accessoryHandler.Perms = svc.permissions
+	// Initialize sales handler
+	saleHandler := handlers.NewSaleHandlers(saleRepo, cabsRepo, accessoryRepo, customerRepo, jwtSecret)
+	saleHandler.Perms = svc.permissions
+	customerHandler.Sales = saleRepo // Sales history is included in customer data exports
+	customerHandler.Consents = repos.Consents
+	customerHandler.Locations = svc.locations
+	customerHandler.Geocoder = svc.geocoder
+	deliveryHandler := handlers.NewDeliveryHandler(svc.locations, customerRepo, svc.delivery, jwtSecret)
+	// Initialize activity log handler
+	activityLogHandler := handlers.NewActivityLogHandler(logsRepo)
+	announcementHandler := handlers.NewAnnouncementHandler(repos.Announcements, svc.hub, jwtSecret)
+	notificationHandler := handlers.NewNotificationHandler(svc.hub, jwtSecret)
+	taskHandler := handlers.NewTaskHandler(repos.Tasks, userRepo, customerRepo, saleRepo, cabsRepo, jwtSecret)
+	taskHandler.Hub = svc.hub
+	reportHandler := handlers.NewReportHandler(saleRepo, userRepo, jwtSecret)
+	reportHandler.Registers = repos.Registers
+	reportHandler.Expenses = repos.Expenses
+	reportHandler.Fiscal = repos.Fiscal
+	reportHandler.Registrations = repos.Registrations
+	registrationHandler := handlers.NewRegistrationHandler(repos.Registrations, saleRepo, jwtSecret)
+	registrationHandler.DueDays = svc.registrationDays
+	insuranceHandler := handlers.NewInsuranceHandler(repos.Insurance, saleRepo, customerRepo, jwtSecret)
+	insuranceHandler.Perms = svc.permissions
+	insuranceHandler.Consents = repos.Consents
+	expenseHandler := handlers.NewExpenseHandler(repos.Expenses, jwtSecret)
+	expenseHandler.Files = svc.files
+	expenseHandler.LinkExpiry = svc.fileLinkExpiry
+	cashRegisterHandler := handlers.NewCashRegisterHandler(repos.Registers, saleRepo, jwtSecret)
+	depositHandler := handlers.NewDepositHandler(repos.Deposits, repos.Registers, jwtSecret)
+	analyticsHandler := handlers.NewAnalyticsHandler(saleRepo, accessoryRepo, jwtSecret)
+	documentTemplateHandler := handlers.NewDocumentTemplateHandler(repos.Documents, jwtSecret)
+	fiscalCalendarHandler := handlers.NewFiscalCalendarHandler(repos.Fiscal, jwtSecret)
+	inventorySnapshotHandler := handlers.NewInventorySnapshotHandler(repos.Snapshots, cabsRepo, accessoryRepo, materialRepo, jwtSecret)
+	inventoryLabelHandler := handlers.NewInventoryLabelHandler(cabsRepo, accessoryRepo, materialRepo, jwtSecret)
+	itemImageHandler := handlers.NewItemImageHandler(repos.Images, cabsRepo, accessoryRepo, materialRepo, jwtSecret)
+	cabsHandler.Gallery = itemImageHandler
+	accessoryHandler.Gallery = itemImageHandler
+	materialHandler.Gallery = itemImageHandler
+	itemImageHandler.Variants = svc.imageVariants
+	saleHandler.Documents = repos.Documents
+	receiptSeriesHandler := handlers.NewReceiptSeriesHandler(repos.Receipts, saleRepo, jwtSecret)
+	receiptSeriesHandler.Hub = svc.hub
+	saleHandler.Receipts = repos.Receipts
+	saleHandler.Payments = repos.Payments
+	salePaymentHandler := handlers.NewSalePaymentHandler(repos.Payments, saleRepo, repos.Anomalies, jwtSecret)
+	favoriteHandler := handlers.NewFavoriteHandler(repos.Favorites, cabsRepo, accessoryRepo, jwtSecret)
+	undoHandler := handlers.NewUndoHandler(svc.undo, customerRepo, accessoryRepo, materialRepo, jwtSecret)
+	customerHandler.Undo = undoHandler
+	accessoryHandler.Undo = undoHandler
+	materialHandler.Undo = undoHandler
+	trashHandler := handlers.NewTrashHandler(repos.Trash, jwtSecret)
+	customerHandler.Trash = trashHandler
+	cabsHandler.Trash = trashHandler
+	accessoryHandler.Trash = trashHandler
+	materialHandler.Trash = trashHandler
+	dormantAccountHandler := handlers.NewDormantAccountHandler(userRepo, repos.Dormancy, logsRepo, svc.dormantDays, jwtSecret)
+	dormantAccountHandler.Hub = svc.hub
+	userHandler.Dormancy = dormantAccountHandler
+	sessionHandler := handlers.NewSessionHandler(repos.RefreshTokens, userRepo, svc.sessions, jwtSecret)
+	sessionHandler.Dormancy = dormantAccountHandler
+	userHandler.Sessions = sessionHandler
+	passwordResetHandler := handlers.NewPasswordResetHandler(userRepo, repos.Resets, svc.mailer, svc.frontendURL)
+	passwordResetHandler.TTL = svc.passwordResetTTL
+	passwordResetHandler.Sessions = sessionHandler
+	userHandler.Shifts = shiftHandler
+	userHandler.Throttle = svc.logins
+	sessionHandler.Shifts = shiftHandler
+	cabsHandler.Marketplace = svc.marketplace
+	accountingHandler := handlers.NewAccountingHandler(repos.Accounting, saleRepo, svc.accounting, svc.accountingConfig, jwtSecret)
+	accessoryHandler.Marketplace = svc.marketplace
+	integrationHandler := handlers.NewIntegrationHandler(repos.Integrations, userRepo, customerRepo, saleRepo, cabsRepo, accessoryRepo, jwtSecret)
+	googleSheetsHandler := handlers.NewGoogleSheetsHandler(repos.Sheets, saleRepo, cabsRepo, accessoryRepo, materialRepo, svc.sheets, svc.sheetsConfig, jwtSecret)
+	calendarHandler := handlers.NewCalendarHandler(repos.Calendars, repos.Tasks, userRepo, customerRepo, jwtSecret)
+	integrityHandler := handlers.NewIntegrityHandler(repos.Integrity, jwtSecret)
+	anomalyHandler := handlers.NewAnomalyHandler(repos.Anomalies, svc.anomalies, jwtSecret)
+	priceChangeHandler := handlers.NewPriceChangeHandler(repos.PriceChanges, userRepo, cabsRepo, accessoryRepo, svc.priceApproval, jwtSecret)
+	priceChangeHandler.Hub = svc.hub
+	priceChangeHandler.Marketplace = svc.marketplace
+	cabsHandler.Approvals = priceChangeHandler
+	accessoryHandler.Approvals = priceChangeHandler
+	changeRequestHandler := handlers.NewChangeRequestHandler(repos.Changes, userRepo, cabsRepo, accessoryRepo, materialRepo, jwtSecret)
+	changeRequestHandler.Perms = svc.permissions
+	changeRequestHandler.Hub = svc.hub
+	changeRequestHandler.Marketplace = svc.marketplace
+	exportHandler := handlers.NewExportHandler(repos.Exports, saleRepo, cabsRepo, accessoryRepo, materialRepo, customerRepo, svc.exports, jwtSecret)
+	exportHandler.Perms = svc.permissions
+	exportHandler.Retention = svc.exportRetention
+	exportHandler.Files = svc.files
+	exportHandler.LinkExpiry = svc.fileLinkExpiry
+	exportHandler.Consents = repos.Consents
+	inventoryExportHandler := handlers.NewInventoryExportHandler(repos.Stock, jwtSecret)
+	sandboxHandler := handlers.NewSandboxHandler(userRepo, svc.sandboxes, jwtSecret)
+	legacyImportHandler := handlers.NewLegacyImportHandler(repos.LegacyImports, userRepo, customerRepo, cabsRepo, accessoryRepo, materialRepo, saleRepo, jwtSecret)
+	legacyImportHandler.Payments = repos.Payments
+
+	// Record field-level before/after diffs for updates
+	changeRecorder := handlers.NewChangeRecorder(logsRepo)
+	userHandler.Audit = changeRecorder
+	sessionHandler.Audit = changeRecorder
+	customerHandler.Audit = changeRecorder
+	materialHandler.Audit = changeRecorder
+	cabsHandler.Audit = changeRecorder
+	accessoryHandler.Audit = changeRecorder
+	saleHandler.Audit = changeRecorder
+	announcementHandler.Audit = changeRecorder
+	taskHandler.Audit = changeRecorder
+	registrationHandler.Audit = changeRecorder
+	insuranceHandler.Audit = changeRecorder
+	documentTemplateHandler.Audit = changeRecorder
+	fiscalCalendarHandler.Audit = changeRecorder
+	cashRegisterHandler.Audit = changeRecorder
+	expenseHandler.Audit = changeRecorder
+	depositHandler.Audit = changeRecorder
+	receiptSeriesHandler.Audit = changeRecorder
+	undoHandler.Audit = changeRecorder
+	trashHandler.Audit = changeRecorder
+	dormantAccountHandler.Audit = changeRecorder
+	shiftHandler.Audit = changeRecorder
+	sandboxHandler.Audit = changeRecorder
+	integrationHandler.Audit = changeRecorder
+	accountingHandler.Audit = changeRecorder
+	googleSheetsHandler.Audit = changeRecorder
+	calendarHandler.Audit = changeRecorder
+	integrityHandler.Audit = changeRecorder
+	anomalyHandler.Audit = changeRecorder
+	salePaymentHandler.Audit = changeRecorder
+	priceChangeHandler.Audit = changeRecorder
+	changeRequestHandler.Audit = changeRecorder
+	itemImageHandler.Audit = changeRecorder
+	exportHandler.Audit = changeRecorder
+	legacyImportHandler.Audit = changeRecorder
+	reportHandler.Audit = changeRecorder
+
+	// Notify users who starred a cab or accessory when it changes
+	watchlist := handlers.NewWatchlist(repos.Favorites, svc.hub)
+	cabsHandler.Watch = watchlist
+	accessoryHandler.Watch = watchlist
+	saleHandler.Watch = watchlist
+	priceChangeHandler.Watch = watchlist
+	changeRequestHandler.Watch = watchlist
+
+	// Publish big sales and items running out of stock
+	alerts := handlers.NewAlerts(svc.hub, svc.bigSaleThreshold)
+	saleHandler.Alerts = alerts
+	integrationHandler.Alerts = alerts
+	cabsHandler.Alerts = alerts
+	accessoryHandler.Alerts = alerts
+	materialHandler.Alerts = alerts
+	changeRequestHandler.Alerts = alerts
+
+	// Keep each user's recently viewed cabs, accessories, customers and sales
+	recentViews := handlers.NewRecentViews(repos.Views, svc.views, jwtSecret)
+	cabsHandler.Views = recentViews
+	accessoryHandler.Views = recentViews
+	customerHandler.Views = recentViews
+	saleHandler.Views = recentViews
+
+	// Number new sales, customers and cabs with the formats admins set
+	displayNumbers := handlers.NewDisplayNumbers(repos.Numbers, jwtSecret)
+	displayNumbers.Shifts = repos.Shifts
+	displayNumbers.Audit = changeRecorder
+	saleHandler.Numbers = displayNumbers
+	customerHandler.Numbers = displayNumbers
+	cabsHandler.Numbers = displayNumbers
+
+	// One search across cabs, accessories, materials and customers for the omnibox
+	searchHandler := handlers.NewSearchHandler(cabsRepo, accessoryRepo, materialRepo, customerRepo, jwtSecret)
+	searchHandler.Perms = svc.permissions
+	searchHandler.Numbers = displayNumbers
+
+	auditHandler := handlers.NewAuditHandler(logsRepo, jwtSecret)
+	userHandler.Invites = inviteHandler
+
+	// Middleware the modules choose from for their routes
+	mw := handlers.NewRouteMiddleware(jwtSecret)
+	mw.Dedupe = middleware.DuplicateSubmissions(svc.submissions, jwtSecret) // Answers a create submitted twice with the record created the first time
+	mw.Features = svc.features
+
+	// Modules with /users routes of their own must precede the users module, whose
+	// /users group requires a token and whose /users/:id would match their paths
+	modules := []handlers.Module{
+		sessionHandler,        // Refresh and logout, authenticated by the refresh token
+		inviteHandler,         // Public accept route
+		passwordResetHandler,  // Public forgot and reset password routes
+		svc.features,          // Features of the caller's tenant
+		sandboxHandler,        // Training sandbox
+		materialHandler,       // Detailed Swagger annotations are in materials_handlers.go
+		customerHandler,       // Detailed Swagger annotations are in contacts_handlers.go
+		deliveryHandler,       // Locations and delivery estimates
+		cabsHandler,           // Detailed Swagger annotations are in cabs_handlers.go
+		accessoryHandler,      // Detailed Swagger annotations are in accessories_handlers.go
+		searchHandler,         // Matches of one term across cabs, accessories, materials and customers
+		saleHandler,           // Detailed Swagger annotations are in sales_handlers.go
+		receiptSeriesHandler,  // OR series per branch and the numbers issued to sales
+		undoHandler,           // Restores records deleted within the undo window
+		trashHandler,          // Deleted records admins can restore or purge
+		integrationHandler,    // Signed orders from partner systems, and their admin routes
+		accountingHandler,     // Status and retries of postings to the accounting system
+		googleSheetsHandler,   // Spreadsheet the reports are exported to
+		integrityHandler,      // Checks for inconsistent records and fixes the safe ones
+		priceChangeHandler,    // Large price changes waiting for an admin to approve them
+		anomalyHandler,        // Unusual sales and stock movements waiting for an admin to review them
+		salePaymentHandler,    // Payments of sales and their reconciliation with sale totals
+		changeRequestHandler,  // Inventory edits proposed for a reviewer to apply
+		legacyImportHandler,   // Sheets of the legacy yard system, imported in resumable batches
+		favoriteHandler,       // Starred inventory items
+		recentViews,           // Recently viewed records
+		dormantAccountHandler, // Exemption from the dormant account policy
+		calendarHandler,       // The .ics feed is authenticated by its own token
+		presenceHandler,       // Heartbeats and online users
+		shiftHandler,          // Shift schedules and the shift branch route
+		provisioningHandler,   // HR roster sync, dry run by default
+		userHandler,           // Public register and login routes, then the protected /users group
+		activityLogHandler,    // Everyone's actions are logged, only admins read them
+		auditHandler,          // Verification of the audit log hash chain
+		svc.usage,             // Usage of the caller's tenant against its quotas
+		svc.workers,           // Background jobs and queues of this server
+		svc.logLevel,          // Level this server logs at, raised for a while to diagnose incidents
+		announcementHandler,   // Announcements and the notification stream they are pushed to
+		notificationHandler,
+		taskHandler,              // Follow-up tasks assigned to staff
+		reportHandler,            // Sales reports
+		inventorySnapshotHandler, // End-of-month inventory snapshots
+		registrationHandler,      // LTO registration paperwork of sold cab units
+		insuranceHandler,         // Insurance policies sold with sales and the renewal report
+		displayNumbers,           // Numbering formats and display number lookup
+		inventoryLabelHandler,    // Printable labels for relabeling stock after intake
+		itemImageHandler,         // Photo galleries of cabs, accessories and materials
+		cashRegisterHandler,      // Cash register sessions, counted by denomination at close
+		depositHandler,           // Bank deposits of register cash
+		expenseHandler,           // Yard expenses, approved by admins before they count in the monthly report
+		analyticsHandler,         // Accessories frequently bought together, for add-on suggestions
+		documentTemplateHandler,  // Branding printed on receipts and statements
+		fiscalCalendarHandler,    // Fiscal periods of the reports
+		exportHandler,            // CSV exports built in the background and downloaded through signed links
+		inventoryExportHandler,   // Current stock streamed as CSV while it is read
+	}
+	if svc.oidcConfig.Enabled() {
+		oidcHandler := handlers.NewOIDCHandler(userRepo, services.NewOIDCProvider(svc.oidcConfig), svc.oidcConfig.AllowedDomains, svc.oidcConfig.AutoProvision, svc.frontendURL, jwtSecret)
+		oidcHandler.Dormancy = dormantAccountHandler
+		oidcHandler.Sessions = sessionHandler
+		oidcHandler.Shifts = shiftHandler
+		modules = append(modules, oidcHandler) // Google Workspace SSO
+	}
+	handlers.RegisterModules(app.Group("/api"), mw, modules...)
+
+	return app
+}
+
+// selfServiceUserRoutes are the routes under /api/users that users need wherever they
+// are, so the admin IP allowlist does not apply to them
+var selfServiceUserRoutes = []string{"/login", "/register", "/refresh", "/logout", "/invite/accept", "/forgot-password", "/reset-password"}
+
+// restrictAdminRoutes applies the admin IP allowlist to user management and to the
+// admin and super-admin routes. Users' own favorites and recently viewed records
+// under /api/users/me stay reachable from anywhere.
+func restrictAdminRoutes(app fiber.Router, networks []*net.IPNet) {
+	allowlist := middleware.IPAllowlist(networks)
+	app.Use("/api/admin", allowlist)
+	app.Use("/api/superadmin", allowlist)
+	app.Use("/api/users", func(c *fiber.Ctx) error {
+		path := strings.TrimPrefix(c.Path(), "/api/users")
+		if slices.Contains(selfServiceUserRoutes, path) || strings.HasPrefix(path, "/me/") {
+			return c.Next()
+		}
+		return allowlist(c)
+	})
+}
+
+// turnstileMiddleware creates a middleware that verifies Cloudflare Turnstile tokens
+func turnstileMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// 1) grab the Turnstile token from the client
+		token := c.FormValue("cf-turnstile-response")
+		if token == "" {
+			log.Printf("Error: Missing Turnstile token in request")
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      "captcha token missing",
+				StatusCode: fiber.StatusBadRequest,
+			})
+		}
+
+		// Log token for debugging (truncate for security)
+		tokenLength := len(token)
+		truncatedToken := ""
+		if tokenLength > 10 {
+			truncatedToken = token[:5] + "..." + token[tokenLength-5:]
+		} else {
+			truncatedToken = token
+		}
+		log.Printf("Debug: Processing Turnstile token: %s", truncatedToken)
+
+		// 2) verify with Cloudflare
+		ok, err := handlers.VerifyTurnstile(token)
+		if err != nil {
+			log.Printf("Error: Turnstile verification failed: %v, token: %s", err, truncatedToken)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      err.Error(),
+				StatusCode: fiber.StatusInternalServerError,
+			})
+		}
+		if !ok {
+			log.Printf("Error: Invalid Turnstile captcha with token: %s", truncatedToken)
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+				Error:      "invalid captcha",
+				StatusCode: fiber.StatusForbidden,
+			})
+		}
+
+		log.Printf("Success: Turnstile verification passed for token: %s", truncatedToken)
+		return c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// TestRestrictAdminRoutes tests that the admin IP allowlist guards user management
+// and admin routes but not the routes every user needs
+func TestRestrictAdminRoutes(t *testing.T) {
+	_, office, _ := net.ParseCIDR("10.0.0.0/8")
+	_, testClient, _ := net.ParseCIDR("0.0.0.0/32") // The address app.Test requests come from
+
+	tests := []struct {
+		name     string
+		networks []*net.IPNet
+		method   string
+		path     string
+		want     int
+	}{
+		{"AdminFromOutside", []*net.IPNet{office}, "GET", "/api/admin/trash", fiber.StatusForbidden},
+		{"SuperAdminFromOutside", []*net.IPNet{office}, "GET", "/api/superadmin/tenants", fiber.StatusForbidden},
+		{"UserManagementFromOutside", []*net.IPNet{office}, "PUT", "/api/users/42/activate", fiber.StatusForbidden},
+		{"UserListFromOutside", []*net.IPNet{office}, "GET", "/api/users", fiber.StatusForbidden},
+		{"LoginFromOutside", []*net.IPNet{office}, "POST", "/api/users/login", fiber.StatusOK},
+		{"InviteAcceptFromOutside", []*net.IPNet{office}, "POST", "/api/users/invite/accept", fiber.StatusOK},
+		{"OwnFavoritesFromOutside", []*net.IPNet{office}, "GET", "/api/users/me/favorites", fiber.StatusOK},
+		{"OtherRoutesFromOutside", []*net.IPNet{office}, "GET", "/api/cabs", fiber.StatusOK},
+		{"AdminFromAllowedNetwork", []*net.IPNet{office, testClient}, "GET", "/api/admin/trash", fiber.StatusOK},
+		{"NoAllowlist", nil, "PUT", "/api/users/42/activate", fiber.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			restrictAdminRoutes(app, tt.networks)
+			app.Use(func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+			resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.want, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"sync"
+
+	"oop/internal/handlers"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+)
+
+// Repositories groups the repositories of one tenant, so they can be
+// backed either by MySQL or, in mock mode, by memory.
+type Repositories struct {
+	Users         handlers.UserRepository
+	Materials     repositories.MaterialRepository
+	Accessories   repositories.AccessoryRepository
+	Customers     repositories.CustomerRepository
+	Cabs          repositories.CabsRepository
+	Sales         repositories.SalesRepository
+	Logs          repositories.LogsRepositoryInterface
+	Invites       repositories.UserInviteRepository
+	Announcements repositories.AnnouncementRepository
+	Tasks         repositories.TaskRepository
+	Favorites     repositories.FavoriteRepository
+	Views         repositories.EntityViewRepository
+	Documents     repositories.DocumentTemplateRepository
+	Registers     repositories.RegisterSessionRepository
+	Expenses      repositories.ExpenseRepository
+	Deposits      repositories.DepositRepository
+	Receipts      repositories.ReceiptSeriesRepository
+	Fiscal        repositories.FiscalCalendarRepository
+	Snapshots     repositories.InventorySnapshotRepository
+	Trash         repositories.TrashRepository
+	Dormancy      repositories.UserDormancyRepository
+	Integrations  repositories.IntegrationRepository
+	Accounting    repositories.AccountingPostingRepository
+	Sheets        repositories.GoogleSheetsExportRepository
+	Calendars     repositories.CalendarFeedRepository
+	Integrity     repositories.IntegrityRepository
+	PriceChanges  repositories.PriceChangeRepository
+	Changes       repositories.ChangeRequestRepository
+	Images        repositories.ItemImageRepository
+	RefreshTokens repositories.RefreshTokenRepository
+	Exports       repositories.ExportJobRepository
+	Anomalies     repositories.AnomalyRepository
+	Stock         repositories.StockExportRepository
+	Payments      repositories.SalePaymentRepository
+	Shifts        repositories.ShiftRepository
+	LegacyImports repositories.LegacyImportRepository
+	Resets        repositories.PasswordResetRepository
+	Consents      repositories.CustomerConsentRepository
+	Registrations repositories.CabRegistrationRepository
+	Insurance     repositories.InsurancePolicyRepository
+	Numbers       repositories.DisplayNumberRepository
+}
+
+// TenantRegistry creates the repositories of each tenant on first use and keeps them
+// for the life of the process, so a tenant's app and the super-admin routes share them.
+type TenantRegistry struct {
+	tenants   repositories.TenantRepository
+	create    func(tenantID string) (Repositories, error)
+	sandboxes *services.Sandboxes // Sandbox tenants are served from their in-memory data instead of create
+
+	mu    sync.Mutex
+	repos map[string]Repositories
+}
+
+// Get returns the repositories of a tenant
+func (r *TenantRegistry) Get(tenantID string) (Repositories, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if repos, ok := r.repos[tenantID]; ok {
+		return repos, nil
+	}
+	var repos Repositories
+	if parentID, ok := models.SandboxParent(tenantID); ok {
+		store, err := r.sandboxes.Store(parentID)
+		if err != nil {
+			return Repositories{}, err
+		}
+		repos = storeRepositories(store)
+	} else {
+		var err error
+		if repos, err = r.create(tenantID); err != nil {
+			return Repositories{}, err
+		}
+	}
+	r.repos[tenantID] = repos
+	return repos, nil
+}
+
+// forget drops the repositories of a tenant, so the next Get creates them again
+func (r *TenantRegistry) forget(tenantID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.repos, tenantID)
+}
+
+// scope returns the repositories of a tenant used by the super-admin routes
+func (r *TenantRegistry) scope(tenantID string) (handlers.TenantScope, error) {
+	repos, err := r.Get(tenantID)
+	if err != nil {
+		return handlers.TenantScope{}, err
+	}
+	return handlers.TenantScope{Users: repos.Users, Logs: repos.Logs}, nil
+}
+
+// initSQLTenants creates the tenant registry backed by the database.
+// Every tenant's repositories are scoped through repositories.ForTenant.
+func initSQLTenants(dbClient *repositories.DatabaseClient) *TenantRegistry {
+	return &TenantRegistry{
+		tenants: repositories.NewTenantRepository(dbClient.DB),
+		create: func(tenantID string) (Repositories, error) {
+			return initSQLRepositories(dbClient, tenantID), nil
+		},
+		repos: make(map[string]Repositories),
+	}
+}
+
+// initSQLRepositories creates the repositories of a tenant backed by the database
+func initSQLRepositories(dbClient *repositories.DatabaseClient, tenantID string) Repositories {
+	scoped := repositories.ForTenant(dbClient, tenantID)
+	return Repositories{
+		Users:         scoped.Users,
+		Materials:     scoped.Materials,
+		Accessories:   scoped.Accessories,
+		Customers:     scoped.Customers,
+		Cabs:          scoped.Cabs,
+		Sales:         scoped.Sales,
+		Logs:          scoped.Logs,
+		Invites:       scoped.Invites,
+		Announcements: scoped.Announcements,
+		Tasks:         scoped.Tasks,
+		Favorites:     scoped.Favorites,
+		Views:         scoped.Views,
+		Documents:     scoped.Documents,
+		Registers:     scoped.Registers,
+		Expenses:      scoped.Expenses,
+		Deposits:      scoped.Deposits,
+		Receipts:      scoped.Receipts,
+		Fiscal:        scoped.Fiscal,
+		Snapshots:     scoped.Snapshots,
+		Trash:         scoped.Trash,
+		Dormancy:      scoped.Dormancy,
+		Integrations:  scoped.Integrations,
+		Accounting:    scoped.Accounting,
+		Sheets:        scoped.Sheets,
+		Calendars:     scoped.Calendars,
+		Integrity:     scoped.Integrity,
+		PriceChanges:  scoped.PriceChanges,
+		Changes:       scoped.Changes,
+		Images:        scoped.Images,
+		RefreshTokens: scoped.RefreshTokens,
+		Exports:       scoped.Exports,
+		Anomalies:     scoped.Anomalies,
+		Stock:         scoped.Stock,
+		Payments:      scoped.Payments,
+		Shifts:        scoped.Shifts,
+		LegacyImports: scoped.LegacyImports,
+		Resets:        scoped.Resets,
+		Consents:      scoped.Consents,
+		Registrations: scoped.Registrations,
+		Insurance:     scoped.Insurance,
+		Numbers:       scoped.Numbers,
+	}
+}
+
+// initMockTenants creates the tenant registry for mock mode. The default tenant is
+// seeded with fixture data; tenants created at runtime start empty.
+func initMockTenants() (*TenantRegistry, error) {
+	seeded, err := memory.NewSeededStore()
+	if err != nil {
+		return nil, err
+	}
+
+	tenantRepo := memory.NewTenantRepository()
+	tenantRepo.SetStore(models.DefaultTenantID, seeded)
+
+	return &TenantRegistry{
+		tenants: tenantRepo,
+		create: func(tenantID string) (Repositories, error) {
+			return storeRepositories(tenantRepo.Store(tenantID)), nil
+		},
+		repos: make(map[string]Repositories),
+	}, nil
+}
+
+// storeRepositories exposes an in-memory store as a tenant's repositories
+func storeRepositories(store *memory.Store) Repositories {
+	return Repositories{
+		Users:         store.Users,
+		Materials:     store.Materials,
+		Accessories:   store.Accessories,
+		Customers:     store.Customers,
+		Cabs:          store.Cabs,
+		Sales:         store.Sales,
+		Logs:          store.Logs,
+		Invites:       store.Invites,
+		Announcements: store.Announcements,
+		Tasks:         store.Tasks,
+		Favorites:     store.Favorites,
+		Views:         store.Views,
+		Documents:     store.Documents,
+		Registers:     store.Registers,
+		Expenses:      store.Expenses,
+		Deposits:      store.Deposits,
+		Receipts:      store.Receipts,
+		Fiscal:        store.Fiscal,
+		Snapshots:     store.Snapshots,
+		Trash:         store.Trash,
+		Dormancy:      store.Dormancy,
+		Integrations:  store.Integrations,
+		Accounting:    store.Accounting,
+		Sheets:        store.Sheets,
+		Calendars:     store.Calendars,
+		Integrity:     store.Integrity,
+		PriceChanges:  store.PriceChanges,
+		Changes:       store.Changes,
+		Images:        store.Images,
+		RefreshTokens: store.RefreshTokens,
+		Exports:       store.Exports,
+		Anomalies:     store.Anomalies,
+		Stock:         store.Stock,
+		Payments:      store.Payments,
+		Shifts:        store.Shifts,
+		LegacyImports: store.LegacyImports,
+		Resets:        store.Resets,
+		Consents:      store.Consents,
+		Registrations: store.Registrations,
+		Insurance:     store.Insurance,
+		Numbers:       store.Numbers,
+	}
+}
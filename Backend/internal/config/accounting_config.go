@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// AccountingAccounts are the chart of accounts codes sales journals are posted to
+type AccountingAccounts struct {
+	Cash  string // Debited with the total of the day's sales
+	Sales string // Credited with the sales net of VAT
+	VAT   string // Credited with the output VAT
+}
+
+// AccountingConfig holds the accounting system daily sales journals and payments
+// are posted to. Syncing is off unless a URL is set.
+type AccountingConfig struct {
+	URL         string // Base URL of the accounting API; journals go to /journals and payments to /payments
+	Token       string // Sent as a bearer token when set
+	MaxAttempts int    // Attempts at a posting before the job stops retrying it
+	Accounts    AccountingAccounts
+}
+
+// Enabled reports whether an accounting system is configured
+func (c AccountingConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// LoadAccountingConfig loads the accounting sync configuration from the environment
+func LoadAccountingConfig() (AccountingConfig, error) {
+	cfg := AccountingConfig{
+		URL:         strings.TrimRight(strings.TrimSpace(os.Getenv("ACCOUNTING_API_URL")), "/"),
+		Token:       os.Getenv("ACCOUNTING_API_TOKEN"),
+		MaxAttempts: parseEnvInt("ACCOUNTING_MAX_ATTEMPTS", 5),
+		Accounts: AccountingAccounts{
+			Cash:  parseEnvString("ACCOUNTING_CASH_ACCOUNT", "1000"),
+			Sales: parseEnvString("ACCOUNTING_SALES_ACCOUNT", "4000"),
+			VAT:   parseEnvString("ACCOUNTING_VAT_ACCOUNT", "2100"),
+		},
+	}
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return AccountingConfig{}, fmt.Errorf("ACCOUNTING_API_URL must be an http or https URL, got %q", cfg.URL)
+	}
+	if cfg.MaxAttempts < 1 {
+		return AccountingConfig{}, fmt.Errorf("ACCOUNTING_MAX_ATTEMPTS must be at least 1")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AnomalyConfig holds the thresholds of the nightly scan for unusual sales and stock
+// movements
+type AnomalyConfig struct {
+	ScanHour              int     // Local hour after which the previous day is scanned
+	PriceDeviationPercent float64 // How far from an item's average price a sale is flagged
+	PriceHistoryDays      int     // Days of earlier sales the average price is taken over
+	MinPriceHistory       int     // Earlier sales of an item needed before its prices are compared
+	StockAdjustmentUnits  int     // Smallest change of an item's quantity in one update that is flagged
+	OpenHour              int     // Business hours, local time; sales deleted outside them are flagged
+	CloseHour             int
+}
+
+// LoadAnomalyConfig loads the anomaly scan thresholds from ANOMALY_SCAN_HOUR (default 2),
+// ANOMALY_PRICE_DEVIATION_PERCENT (50), ANOMALY_PRICE_HISTORY_DAYS (90),
+// ANOMALY_MIN_PRICE_HISTORY (3), ANOMALY_STOCK_ADJUSTMENT_UNITS (20) and
+// ANOMALY_BUSINESS_HOURS (8-18, opening and closing hour).
+func LoadAnomalyConfig() (AnomalyConfig, error) {
+	cfg := AnomalyConfig{
+		ScanHour:             parseEnvInt("ANOMALY_SCAN_HOUR", 2),
+		PriceHistoryDays:     parseEnvInt("ANOMALY_PRICE_HISTORY_DAYS", 90),
+		MinPriceHistory:      parseEnvInt("ANOMALY_MIN_PRICE_HISTORY", 3),
+		StockAdjustmentUnits: parseEnvInt("ANOMALY_STOCK_ADJUSTMENT_UNITS", 20),
+	}
+	if cfg.ScanHour < 0 || cfg.ScanHour > 23 {
+		return AnomalyConfig{}, fmt.Errorf("ANOMALY_SCAN_HOUR must be between 0 and 23")
+	}
+
+	percent, err := strconv.ParseFloat(parseEnvString("ANOMALY_PRICE_DEVIATION_PERCENT", "50"), 64)
+	if err != nil || percent <= 0 {
+		return AnomalyConfig{}, fmt.Errorf("ANOMALY_PRICE_DEVIATION_PERCENT must be a positive percentage")
+	}
+	cfg.PriceDeviationPercent = percent
+
+	if cfg.PriceHistoryDays < 1 || cfg.MinPriceHistory < 1 || cfg.StockAdjustmentUnits < 1 {
+		return AnomalyConfig{}, fmt.Errorf("ANOMALY_PRICE_HISTORY_DAYS, ANOMALY_MIN_PRICE_HISTORY and ANOMALY_STOCK_ADJUSTMENT_UNITS must be positive")
+	}
+
+	opening, closing, ok := strings.Cut(parseEnvString("ANOMALY_BUSINESS_HOURS", "8-18"), "-")
+	cfg.OpenHour, err = strconv.Atoi(strings.TrimSpace(opening))
+	if err == nil {
+		cfg.CloseHour, err = strconv.Atoi(strings.TrimSpace(closing))
+	}
+	if !ok || err != nil || cfg.OpenHour < 0 || cfg.CloseHour > 24 || cfg.OpenHour >= cfg.CloseHour {
+		return AnomalyConfig{}, fmt.Errorf("ANOMALY_BUSINESS_HOURS must be an opening and closing hour such as 8-18")
+	}
+	return cfg, nil
+}
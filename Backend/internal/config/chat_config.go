@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Chat channels notifications can be sent to
+const (
+	ChatSlack    = "slack"
+	ChatTelegram = "telegram"
+)
+
+// ChatChannelConfig is a chat channel notifications are posted to, and which of them
+type ChatChannelConfig struct {
+	Kind       string   // ChatSlack or ChatTelegram
+	WebhookURL string   // Slack incoming webhook
+	BotToken   string   // Telegram bot the messages are sent as
+	ChatID     string   // Telegram chat, group or channel the bot posts to
+	Events     []string // Notification types posted, e.g. big_sale and stock_out
+}
+
+// ChatConfig holds the chat channels critical events are posted to. Each channel is
+// off unless its webhook or bot is set.
+type ChatConfig struct {
+	Channels         []ChatChannelConfig
+	BigSaleThreshold float64 // Sales totalling at least this much are reported as big sales; 0 disables them
+	QueueSize        int     // Messages waiting per channel while it is slow or down; newer ones are dropped when full
+	MaxRetries       int     // Attempts after the first before a message is dropped
+}
+
+// LoadChatConfig loads the chat notification configuration from the environment
+func LoadChatConfig() (ChatConfig, error) {
+	cfg := ChatConfig{
+		QueueSize:  parseEnvInt("CHAT_NOTIFY_QUEUE_SIZE", 100),
+		MaxRetries: parseEnvInt("CHAT_NOTIFY_MAX_RETRIES", 3),
+	}
+
+	threshold, err := strconv.ParseFloat(parseEnvString("BIG_SALE_THRESHOLD", "100000"), 64)
+	if err != nil || threshold < 0 {
+		return ChatConfig{}, fmt.Errorf("BIG_SALE_THRESHOLD must be a positive amount, or 0 to disable big sale notifications")
+	}
+	cfg.BigSaleThreshold = threshold
+
+	if webhookURL := strings.TrimSpace(os.Getenv("SLACK_WEBHOOK_URL")); webhookURL != "" {
+		if u, err := url.Parse(webhookURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			return ChatConfig{}, fmt.Errorf("SLACK_WEBHOOK_URL must be an https URL")
+		}
+		cfg.Channels = append(cfg.Channels, ChatChannelConfig{
+			Kind:       ChatSlack,
+			WebhookURL: webhookURL,
+			Events:     parseChatEvents("SLACK_NOTIFY_EVENTS"),
+		})
+	}
+
+	botToken, chatID := strings.TrimSpace(os.Getenv("TELEGRAM_BOT_TOKEN")), strings.TrimSpace(os.Getenv("TELEGRAM_CHAT_ID"))
+	if (botToken == "") != (chatID == "") {
+		return ChatConfig{}, fmt.Errorf("set both TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID, or neither")
+	}
+	if botToken != "" {
+		cfg.Channels = append(cfg.Channels, ChatChannelConfig{
+			Kind:     ChatTelegram,
+			BotToken: botToken,
+			ChatID:   chatID,
+			Events:   parseChatEvents("TELEGRAM_NOTIFY_EVENTS"),
+		})
+	}
+
+	if cfg.QueueSize < 1 || cfg.MaxRetries < 0 {
+		return ChatConfig{}, fmt.Errorf("chat notification queue size must be positive and retries cannot be negative")
+	}
+	return cfg, nil
+}
+
+// parseChatEvents reads a comma-separated list of notification types, big sales and
+// stock-outs by default
+func parseChatEvents(key string) []string {
+	var events []string
+	for _, event := range strings.Split(parseEnvString(key, "big_sale,stock_out"), ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,26 @@
+package config
+
+import "fmt"
+
+// CustomerOccasionConfig holds when the sales team is notified of customers' upcoming
+// birthdays and anniversaries
+type CustomerOccasionConfig struct {
+	NotifyHour int // Local hour after which the day's notification is sent
+	NoticeDays int // Days ahead of an occasion it is notified; 0 notifies on the day
+}
+
+// LoadCustomerOccasionConfig loads the customer occasion notifications from
+// CUSTOMER_OCCASION_HOUR (default 8) and CUSTOMER_OCCASION_NOTICE_DAYS (7).
+func LoadCustomerOccasionConfig() (CustomerOccasionConfig, error) {
+	cfg := CustomerOccasionConfig{
+		NotifyHour: parseEnvInt("CUSTOMER_OCCASION_HOUR", 8),
+		NoticeDays: parseEnvInt("CUSTOMER_OCCASION_NOTICE_DAYS", 7),
+	}
+	if cfg.NotifyHour < 0 || cfg.NotifyHour > 23 {
+		return CustomerOccasionConfig{}, fmt.Errorf("CUSTOMER_OCCASION_HOUR must be between 0 and 23")
+	}
+	if cfg.NoticeDays < 0 || cfg.NoticeDays > 365 {
+		return CustomerOccasionConfig{}, fmt.Errorf("CUSTOMER_OCCASION_NOTICE_DAYS must be between 0 and 365")
+	}
+	return cfg, nil
+}
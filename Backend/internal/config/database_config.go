@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +18,15 @@ type DatabaseConfig struct {
 	Password     string
 	DatabaseName string
 	SSLMode      string
+
+	// ConnectAttempts is how many times the database is pinged at startup before
+	// giving up, waiting ConnectBackoff after the first failure and twice as long
+	// after each next one, up to a minute
+	ConnectAttempts int
+	ConnectBackoff  time.Duration
+	// HealthInterval is how often the connection is checked once the server runs;
+	// 0 disables the check
+	HealthInterval time.Duration
 }
 
 func LoadDatabaseConfig() (DatabaseConfig, error) {
@@ -24,14 +36,27 @@ func LoadDatabaseConfig() (DatabaseConfig, error) {
 		return DatabaseConfig{}, err
 	}
 
-	return DatabaseConfig{
-		Host:         os.Getenv("DB_HOST"),
-		Port:         parseEnvInt("DB_PORT", 3306),
-		Username:     os.Getenv("DB_USERNAME"),
-		Password:     os.Getenv("DB_PASSWORD"),
-		DatabaseName: os.Getenv("DB_NAME"),
-		SSLMode:      os.Getenv("DB_SSLMODE"),
-	}, nil
+	cfg := DatabaseConfig{
+		Host:            os.Getenv("DB_HOST"),
+		Port:            parseEnvInt("DB_PORT", 3306),
+		Username:        os.Getenv("DB_USERNAME"),
+		Password:        os.Getenv("DB_PASSWORD"),
+		DatabaseName:    os.Getenv("DB_NAME"),
+		SSLMode:         os.Getenv("DB_SSLMODE"),
+		ConnectAttempts: parseEnvInt("DB_CONNECT_ATTEMPTS", 10),
+		ConnectBackoff:  time.Duration(parseEnvInt("DB_CONNECT_BACKOFF_SECONDS", 1)) * time.Second,
+		HealthInterval:  time.Duration(parseEnvInt("DB_HEALTH_CHECK_INTERVAL_SECONDS", 15)) * time.Second,
+	}
+	if cfg.ConnectAttempts < 1 {
+		return DatabaseConfig{}, fmt.Errorf("DB_CONNECT_ATTEMPTS must be at least 1")
+	}
+	if cfg.ConnectBackoff < 0 {
+		return DatabaseConfig{}, fmt.Errorf("DB_CONNECT_BACKOFF_SECONDS cannot be negative")
+	}
+	if cfg.HealthInterval < 0 {
+		return DatabaseConfig{}, fmt.Errorf("DB_HEALTH_CHECK_INTERVAL_SECONDS cannot be negative")
+	}
+	return cfg, nil
 }
 
 func parseEnvInt(key string, defaultValue int) int {
@@ -48,3 +73,11 @@ func parseEnvInt(key string, defaultValue int) int {
 
 	return value
 }
+
+// parseEnvString returns the trimmed value of an environment variable, or defaultValue when it is unset or blank
+func parseEnvString(key, defaultValue string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return defaultValue
+}
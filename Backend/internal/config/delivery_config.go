@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Geocoding providers customer addresses can be located with
+const (
+	GeocoderNone      = ""          // Addresses are placed at the center of their city
+	GeocoderNominatim = "nominatim" // OpenStreetMap's Nominatim, or a server with its API
+)
+
+// DeliveryConfig holds the location dataset customer addresses are validated against,
+// the geocoding provider that locates them and how delivery estimates are worked out
+type DeliveryConfig struct {
+	LocationsFile     string // JSON dataset of provinces, cities and barangays; the built-in one when empty
+	Geocoder          string // GeocoderNone or GeocoderNominatim
+	GeocoderURL       string
+	GeocoderUserAgent string // Nominatim's usage policy asks for an identifying user agent
+	// Origin is where deliveries leave from, as latitude and longitude; estimates are
+	// unavailable without it
+	OriginLatitude  float64
+	OriginLongitude float64
+	HasOrigin       bool
+	RoadFactor      float64 // Road distance per straight-line distance
+	BaseFee         float64 // Fee of every delivery
+	FeePerKm        float64 // Fee per kilometer of road
+}
+
+// LoadDeliveryConfig loads the delivery planning configuration from the environment
+func LoadDeliveryConfig() (DeliveryConfig, error) {
+	cfg := DeliveryConfig{
+		LocationsFile:     strings.TrimSpace(os.Getenv("PH_LOCATIONS_FILE")),
+		Geocoder:          strings.ToLower(strings.TrimSpace(os.Getenv("GEOCODER"))),
+		GeocoderURL:       strings.TrimRight(parseEnvString("GEOCODER_URL", "https://nominatim.openstreetmap.org"), "/"),
+		GeocoderUserAgent: parseEnvString("GEOCODER_USER_AGENT", "cortes-surplus-backend"),
+	}
+	if cfg.LocationsFile != "" {
+		if _, err := os.Stat(cfg.LocationsFile); err != nil {
+			return DeliveryConfig{}, fmt.Errorf("PH_LOCATIONS_FILE cannot be read: %w", err)
+		}
+	}
+	switch cfg.Geocoder {
+	case GeocoderNone:
+	case GeocoderNominatim:
+		if u, err := url.Parse(cfg.GeocoderURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return DeliveryConfig{}, fmt.Errorf("GEOCODER_URL must be an http or https URL")
+		}
+	default:
+		return DeliveryConfig{}, fmt.Errorf("GEOCODER must be nominatim, or empty to place addresses at the center of their city")
+	}
+
+	if origin := strings.TrimSpace(os.Getenv("DELIVERY_ORIGIN")); origin != "" {
+		lat, lon, ok := parseCoordinates(origin)
+		if !ok {
+			return DeliveryConfig{}, fmt.Errorf("DELIVERY_ORIGIN must be a latitude and longitude, e.g. 14.5995,120.9842")
+		}
+		cfg.OriginLatitude, cfg.OriginLongitude, cfg.HasOrigin = lat, lon, true
+	}
+
+	var err error
+	if cfg.RoadFactor, err = strconv.ParseFloat(parseEnvString("DELIVERY_ROAD_FACTOR", "1.3"), 64); err != nil || cfg.RoadFactor < 1 {
+		return DeliveryConfig{}, fmt.Errorf("DELIVERY_ROAD_FACTOR must be a number of at least 1")
+	}
+	if cfg.BaseFee, err = strconv.ParseFloat(parseEnvString("DELIVERY_BASE_FEE", "0"), 64); err != nil || cfg.BaseFee < 0 {
+		return DeliveryConfig{}, fmt.Errorf("DELIVERY_BASE_FEE must be a positive amount, or 0")
+	}
+	if cfg.FeePerKm, err = strconv.ParseFloat(parseEnvString("DELIVERY_FEE_PER_KM", "0"), 64); err != nil || cfg.FeePerKm < 0 {
+		return DeliveryConfig{}, fmt.Errorf("DELIVERY_FEE_PER_KM must be a positive amount, or 0")
+	}
+	return cfg, nil
+}
+
+// parseCoordinates parses "latitude,longitude"
+func parseCoordinates(value string) (float64, float64, bool) {
+	lat, lon, found := strings.Cut(value, ",")
+	if !found {
+		return 0, 0, false
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return 0, 0, false
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return 0, 0, false
+	}
+	return latitude, longitude, true
+}
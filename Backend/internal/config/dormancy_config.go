@@ -0,0 +1,13 @@
+package config
+
+import "fmt"
+
+// LoadDormantAccountDays loads after how many days without a sign-in user accounts
+// are deactivated, from DORMANT_ACCOUNT_DAYS. It defaults to 0, which disables the policy.
+func LoadDormantAccountDays() (int, error) {
+	days := parseEnvInt("DORMANT_ACCOUNT_DAYS", 0)
+	if days < 0 {
+		return 0, fmt.Errorf("DORMANT_ACCOUNT_DAYS cannot be negative")
+	}
+	return days, nil
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadDuplicateSubmissionWindow loads how long an identical create request from the
+// same client is treated as an accidental resubmission, from
+// DUPLICATE_SUBMISSION_WINDOW_SECONDS. It defaults to 10 seconds; 0 disables the check.
+func LoadDuplicateSubmissionWindow() (time.Duration, error) {
+	seconds := parseEnvInt("DUPLICATE_SUBMISSION_WINDOW_SECONDS", 10)
+	if seconds < 0 {
+		return 0, fmt.Errorf("DUPLICATE_SUBMISSION_WINDOW_SECONDS cannot be negative")
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
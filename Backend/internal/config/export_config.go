@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExportConfig holds how exports requested through POST /api/exports are run and kept
+type ExportConfig struct {
+	Workers   int           // How many exports are built at the same time
+	Retention time.Duration // How long finished exports can be downloaded before they are deleted
+}
+
+// LoadExportConfig loads the export configuration from EXPORT_WORKERS (default 2)
+// and EXPORT_RETENTION_HOURS (default 24)
+func LoadExportConfig() (ExportConfig, error) {
+	cfg := ExportConfig{
+		Workers:   parseEnvInt("EXPORT_WORKERS", 2),
+		Retention: time.Duration(parseEnvInt("EXPORT_RETENTION_HOURS", 24)) * time.Hour,
+	}
+	if cfg.Workers < 1 || cfg.Workers > 16 {
+		return ExportConfig{}, fmt.Errorf("EXPORT_WORKERS must be between 1 and 16")
+	}
+	if cfg.Retention <= 0 {
+		return ExportConfig{}, fmt.Errorf("EXPORT_RETENTION_HOURS must be positive")
+	}
+	return cfg, nil
+}
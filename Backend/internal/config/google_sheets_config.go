@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// GoogleSheetsConfig holds the Google service account reports are exported to
+// spreadsheets with. Exporting is off unless a credentials file is set; each tenant
+// then picks its spreadsheet and reports through /api/integrations/google-sheets.
+type GoogleSheetsConfig struct {
+	CredentialsFile string        // JSON key of the service account, as downloaded from the Google Cloud console
+	Interval        time.Duration // How often every tenant's reports are exported
+	DailySalesDays  int           // Days covered by the daily sales report, ending today
+}
+
+// Enabled reports whether a service account is configured
+func (c GoogleSheetsConfig) Enabled() bool {
+	return c.CredentialsFile != ""
+}
+
+// LoadGoogleSheetsConfig loads the Google Sheets export configuration from the environment
+func LoadGoogleSheetsConfig() (GoogleSheetsConfig, error) {
+	cfg := GoogleSheetsConfig{
+		CredentialsFile: strings.TrimSpace(os.Getenv("GOOGLE_SHEETS_CREDENTIALS_FILE")),
+		Interval:        time.Duration(parseEnvInt("GOOGLE_SHEETS_EXPORT_INTERVAL_MINUTES", 60)) * time.Minute,
+		DailySalesDays:  parseEnvInt("GOOGLE_SHEETS_DAILY_SALES_DAYS", 31),
+	}
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	if _, err := os.Stat(cfg.CredentialsFile); err != nil {
+		return GoogleSheetsConfig{}, fmt.Errorf("GOOGLE_SHEETS_CREDENTIALS_FILE cannot be read: %w", err)
+	}
+	if cfg.Interval <= 0 {
+		return GoogleSheetsConfig{}, fmt.Errorf("GOOGLE_SHEETS_EXPORT_INTERVAL_MINUTES must be positive")
+	}
+	if cfg.DailySalesDays < 1 || cfg.DailySalesDays > 366 {
+		return GoogleSheetsConfig{}, fmt.Errorf("GOOGLE_SHEETS_DAILY_SALES_DAYS must be between 1 and 366")
+	}
+	return cfg, nil
+}
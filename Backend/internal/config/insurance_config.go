@@ -0,0 +1,25 @@
+package config
+
+import "fmt"
+
+// InsuranceConfig holds when the sales team is alerted of insurance policies expiring
+type InsuranceConfig struct {
+	AlertDays int // Days ahead of its expiry a policy is alerted of; 0 alerts on the day
+	AlertHour int // Local hour after which the day's alert is sent
+}
+
+// LoadInsuranceConfig loads the expiring policy alerts from INSURANCE_ALERT_DAYS
+// (default 30) and INSURANCE_ALERT_HOUR (8).
+func LoadInsuranceConfig() (InsuranceConfig, error) {
+	cfg := InsuranceConfig{
+		AlertDays: parseEnvInt("INSURANCE_ALERT_DAYS", 30),
+		AlertHour: parseEnvInt("INSURANCE_ALERT_HOUR", 8),
+	}
+	if cfg.AlertDays < 0 || cfg.AlertDays > 366 {
+		return InsuranceConfig{}, fmt.Errorf("INSURANCE_ALERT_DAYS must be between 0 and 366")
+	}
+	if cfg.AlertHour < 0 || cfg.AlertHour > 23 {
+		return InsuranceConfig{}, fmt.Errorf("INSURANCE_ALERT_HOUR must be between 0 and 23")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadIntegrityCheckInterval loads how often every tenant's data is checked for
+// inconsistent records, from INTEGRITY_CHECK_INTERVAL_HOURS. It defaults to 24
+// hours; 0 disables the scheduled check, which still runs on request.
+func LoadIntegrityCheckInterval() (time.Duration, error) {
+	hours := parseEnvInt("INTEGRITY_CHECK_INTERVAL_HOURS", 24)
+	if hours < 0 {
+		return 0, fmt.Errorf("INTEGRITY_CHECK_INTERVAL_HOURS cannot be negative")
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// LoadAdminIPAllowlist loads the networks administrative routes can be reached from,
+// from ADMIN_IP_ALLOWLIST: comma-separated CIDR ranges such as 203.0.113.0/24, or single
+// addresses. It defaults to empty, which allows any address.
+func LoadAdminIPAllowlist() ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, entry := range strings.Split(os.Getenv("ADMIN_IP_ALLOWLIST"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("ADMIN_IP_ALLOWLIST: invalid address %q", entry)
+			}
+			if ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("ADMIN_IP_ALLOWLIST: invalid CIDR range %q", entry)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Log levels LOG_LEVEL and the log level endpoint accept
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// LogLevelConfig holds the level the server logs at and how long a level set at
+// runtime through PUT /api/admin/log-level lasts before it reverts
+type LogLevelConfig struct {
+	Level          slog.Level
+	OverrideTTL    time.Duration // When the request does not say
+	MaxOverrideTTL time.Duration
+}
+
+// ParseLogLevel parses debug, info, warn or error, ignoring case
+func ParseLogLevel(name string) (slog.Level, error) {
+	level, ok := logLevels[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, fmt.Errorf("log level must be debug, info, warn or error, got %q", name)
+	}
+	return level, nil
+}
+
+// LoadLogLevelConfig loads the log level configuration from LOG_LEVEL (default info),
+// LOG_LEVEL_OVERRIDE_MINUTES (default 30) and LOG_LEVEL_MAX_OVERRIDE_MINUTES
+// (default 240)
+func LoadLogLevelConfig() (LogLevelConfig, error) {
+	level, err := ParseLogLevel(parseEnvString("LOG_LEVEL", "info"))
+	if err != nil {
+		return LogLevelConfig{}, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	cfg := LogLevelConfig{
+		Level:          level,
+		OverrideTTL:    time.Duration(parseEnvInt("LOG_LEVEL_OVERRIDE_MINUTES", 30)) * time.Minute,
+		MaxOverrideTTL: time.Duration(parseEnvInt("LOG_LEVEL_MAX_OVERRIDE_MINUTES", 240)) * time.Minute,
+	}
+	if cfg.OverrideTTL <= 0 || cfg.MaxOverrideTTL <= 0 {
+		return LogLevelConfig{}, fmt.Errorf("LOG_LEVEL_OVERRIDE_MINUTES and LOG_LEVEL_MAX_OVERRIDE_MINUTES must be positive")
+	}
+	if cfg.OverrideTTL > cfg.MaxOverrideTTL {
+		return LogLevelConfig{}, fmt.Errorf("LOG_LEVEL_OVERRIDE_MINUTES cannot exceed LOG_LEVEL_MAX_OVERRIDE_MINUTES")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginThrottleConfig holds the brute-force protection of sign-ins. A limit of 0
+// disables it.
+type LoginThrottleConfig struct {
+	// MaxFailures is how many failed sign-ins in a row lock an account (423)
+	MaxFailures int
+	// IPMaxFailures is how many failed sign-ins from one address, to any accounts,
+	// block that address (429)
+	IPMaxFailures int
+	// Lockout is how long a locked account or blocked address stays so, and how long
+	// failures are remembered
+	Lockout time.Duration
+}
+
+// LoadLoginThrottleConfig loads the sign-in limits from LOGIN_MAX_FAILURES (default 5),
+// LOGIN_IP_MAX_FAILURES (default 20) and LOGIN_LOCKOUT_MINUTES (default 15)
+func LoadLoginThrottleConfig() (LoginThrottleConfig, error) {
+	cfg := LoginThrottleConfig{
+		MaxFailures:   parseEnvInt("LOGIN_MAX_FAILURES", 5),
+		IPMaxFailures: parseEnvInt("LOGIN_IP_MAX_FAILURES", 20),
+	}
+	if cfg.MaxFailures < 0 || cfg.IPMaxFailures < 0 {
+		return LoginThrottleConfig{}, fmt.Errorf("LOGIN_MAX_FAILURES and LOGIN_IP_MAX_FAILURES cannot be negative")
+	}
+	minutes := parseEnvInt("LOGIN_LOCKOUT_MINUTES", 15)
+	if minutes < 1 {
+		return LoginThrottleConfig{}, fmt.Errorf("LOGIN_LOCKOUT_MINUTES must be at least 1")
+	}
+	cfg.Lockout = time.Duration(minutes) * time.Minute
+	return cfg, nil
+}
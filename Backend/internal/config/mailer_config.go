@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MailerConfig holds the SMTP settings used to send outgoing emails
+type MailerConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Enabled reports whether an SMTP server has been configured.
+// When it returns false, emails are written to the application log instead.
+func (c MailerConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// LoadMailerConfig loads the SMTP configuration from the environment.
+// SMTP is optional; SMTP_FROM is only required once SMTP_HOST is set.
+func LoadMailerConfig() (MailerConfig, error) {
+	cfg := MailerConfig{
+		Host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		Port:     parseEnvInt("SMTP_PORT", 587),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     strings.TrimSpace(os.Getenv("SMTP_FROM")),
+	}
+
+	if cfg.Enabled() && cfg.From == "" {
+		return MailerConfig{}, fmt.Errorf("SMTP_FROM environment variable is required when SMTP_HOST is set")
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// MarketplaceAdapterREST posts listings as JSON to a generic REST endpoint
+const MarketplaceAdapterREST = "rest"
+
+// MarketplaceConfig holds where inventory availability and prices are pushed for
+// sale on an online marketplace. Syncing is off unless a URL is set.
+type MarketplaceConfig struct {
+	Adapter string // How listings are pushed; only MarketplaceAdapterREST for now
+	URL     string // Endpoint receiving the listings
+	Token   string // Sent as a bearer token when set
+
+	Interval     time.Duration // How long changes are collected before they are pushed
+	QueueSize    int           // Items waiting to be pushed; changes to other items are dropped when full
+	MaxRetries   int           // Attempts after the first before a push is dropped
+	FullSyncHour int           // Local hour (0-23) after which every listing is pushed once a day
+}
+
+// Enabled reports whether a marketplace is configured
+func (c MarketplaceConfig) Enabled() bool {
+	return c.URL != ""
+}
+
+// LoadMarketplaceConfig loads the marketplace sync configuration from the environment
+func LoadMarketplaceConfig() (MarketplaceConfig, error) {
+	cfg := MarketplaceConfig{
+		Adapter:      strings.TrimSpace(os.Getenv("MARKETPLACE_SYNC_ADAPTER")),
+		URL:          strings.TrimSpace(os.Getenv("MARKETPLACE_SYNC_URL")),
+		Token:        os.Getenv("MARKETPLACE_SYNC_TOKEN"),
+		Interval:     time.Duration(parseEnvInt("MARKETPLACE_SYNC_INTERVAL_SECONDS", 10)) * time.Second,
+		QueueSize:    parseEnvInt("MARKETPLACE_SYNC_QUEUE_SIZE", 10000),
+		MaxRetries:   parseEnvInt("MARKETPLACE_SYNC_MAX_RETRIES", 5),
+		FullSyncHour: parseEnvInt("MARKETPLACE_FULL_SYNC_HOUR", 2),
+	}
+	if cfg.Adapter == "" {
+		cfg.Adapter = MarketplaceAdapterREST
+	}
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	if cfg.Adapter != MarketplaceAdapterREST {
+		return MarketplaceConfig{}, fmt.Errorf("MARKETPLACE_SYNC_ADAPTER must be %s, got %q", MarketplaceAdapterREST, cfg.Adapter)
+	}
+	if u, err := url.Parse(cfg.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return MarketplaceConfig{}, fmt.Errorf("MARKETPLACE_SYNC_URL must be an http or https URL, got %q", cfg.URL)
+	}
+	if cfg.Interval <= 0 || cfg.QueueSize < 1 || cfg.MaxRetries < 0 {
+		return MarketplaceConfig{}, fmt.Errorf("marketplace sync interval and queue size must be positive and retries cannot be negative")
+	}
+	if cfg.FullSyncHour < 0 || cfg.FullSyncHour > 23 {
+		return MarketplaceConfig{}, fmt.Errorf("MARKETPLACE_FULL_SYNC_HOUR must be between 0 and 23")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// LoadMockMode reports whether MOCK_MODE is enabled. In mock mode the server uses
+// in-memory repositories seeded with fixture data instead of MySQL, and skips
+// Turnstile verification, so the frontend can be developed without any keys.
+func LoadMockMode() (bool, error) {
+	value := os.Getenv("MOCK_MODE")
+	if value == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("MOCK_MODE must be true or false: %w", err)
+	}
+	return enabled, nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultOIDCIssuer is used when OIDC_ISSUER is not set (Google Workspace)
+const DefaultOIDCIssuer = "https://accounts.google.com"
+
+// OIDCConfig holds the settings for the optional OpenID Connect (SSO) login flow
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	// AllowedDomains restricts logins to emails from these domains. Empty allows any verified email.
+	AllowedDomains []string
+	// AutoProvision creates a staff account for unknown emails from an allowed domain.
+	AutoProvision bool
+}
+
+// Enabled reports whether SSO login has been configured.
+func (c OIDCConfig) Enabled() bool {
+	return c.ClientID != ""
+}
+
+// LoadOIDCConfig loads the OIDC configuration from the environment.
+// SSO is optional; the remaining settings are only required once OIDC_CLIENT_ID is set.
+func LoadOIDCConfig() (OIDCConfig, error) {
+	cfg := OIDCConfig{
+		Issuer:       strings.TrimRight(strings.TrimSpace(os.Getenv("OIDC_ISSUER")), "/"),
+		ClientID:     strings.TrimSpace(os.Getenv("OIDC_CLIENT_ID")),
+		ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:  strings.TrimSpace(os.Getenv("OIDC_REDIRECT_URL")),
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = DefaultOIDCIssuer
+	}
+
+	for _, domain := range strings.Split(os.Getenv("OIDC_ALLOWED_DOMAINS"), ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			cfg.AllowedDomains = append(cfg.AllowedDomains, domain)
+		}
+	}
+
+	if value := os.Getenv("OIDC_AUTO_PROVISION"); value != "" {
+		autoProvision, err := strconv.ParseBool(value)
+		if err != nil {
+			return OIDCConfig{}, fmt.Errorf("OIDC_AUTO_PROVISION must be true or false: %w", err)
+		}
+		cfg.AutoProvision = autoProvision
+	}
+
+	if !cfg.Enabled() {
+		return cfg, nil
+	}
+
+	if cfg.ClientSecret == "" {
+		return OIDCConfig{}, fmt.Errorf("OIDC_CLIENT_SECRET environment variable is required when OIDC_CLIENT_ID is set")
+	}
+	if cfg.RedirectURL == "" {
+		return OIDCConfig{}, fmt.Errorf("OIDC_REDIRECT_URL environment variable is required when OIDC_CLIENT_ID is set")
+	}
+	if cfg.AutoProvision && len(cfg.AllowedDomains) == 0 {
+		return OIDCConfig{}, fmt.Errorf("OIDC_ALLOWED_DOMAINS must be set when OIDC_AUTO_PROVISION is enabled")
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadPasswordResetTTL loads how long password reset links stay valid from
+// PASSWORD_RESET_TTL_MINUTES. It defaults to an hour.
+func LoadPasswordResetTTL() (time.Duration, error) {
+	minutes := parseEnvInt("PASSWORD_RESET_TTL_MINUTES", 60)
+	if minutes <= 0 {
+		return 0, fmt.Errorf("PASSWORD_RESET_TTL_MINUTES must be positive")
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PermissionsConfig holds the extra permissions granted to each role.
+// Admins implicitly hold every permission and don't need to be listed.
+type PermissionsConfig struct {
+	RolePermissions map[string][]string
+}
+
+// LoadPermissionsConfig loads role permissions from ROLE_PERMISSIONS, formatted as
+// semicolon-separated "role:permission,permission" pairs, e.g. "staff:customers.pii".
+func LoadPermissionsConfig() (PermissionsConfig, error) {
+	cfg := PermissionsConfig{RolePermissions: map[string][]string{}}
+
+	for _, entry := range strings.Split(os.Getenv("ROLE_PERMISSIONS"), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, permissions, ok := strings.Cut(entry, ":")
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !ok || role == "" {
+			return PermissionsConfig{}, fmt.Errorf("invalid ROLE_PERMISSIONS entry %q, expected role:permission", entry)
+		}
+
+		for _, permission := range strings.Split(permissions, ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				cfg.RolePermissions[role] = append(cfg.RolePermissions[role], permission)
+			}
+		}
+	}
+
+	return cfg, nil
+}
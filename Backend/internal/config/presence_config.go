@@ -0,0 +1,16 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadOnlineWindow loads for how long after their last request or heartbeat users
+// are listed as online, from USER_ONLINE_WINDOW_MINUTES. It defaults to 5 minutes.
+func LoadOnlineWindow() (time.Duration, error) {
+	minutes := parseEnvInt("USER_ONLINE_WINDOW_MINUTES", 5)
+	if minutes < 1 {
+		return 0, fmt.Errorf("USER_ONLINE_WINDOW_MINUTES must be at least 1")
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
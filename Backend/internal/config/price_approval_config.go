@@ -0,0 +1,17 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LoadPriceApprovalThreshold loads by how many percent the price of a cab or
+// accessory may change before an admin has to approve the change, from
+// PRICE_APPROVAL_THRESHOLD_PERCENT. It defaults to 0, which applies every change at once.
+func LoadPriceApprovalThreshold() (float64, error) {
+	percent, err := strconv.ParseFloat(parseEnvString("PRICE_APPROVAL_THRESHOLD_PERCENT", "0"), 64)
+	if err != nil || percent < 0 {
+		return 0, fmt.Errorf("PRICE_APPROVAL_THRESHOLD_PERCENT must be a positive percentage, or 0 to disable price approvals")
+	}
+	return percent, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import "fmt"
+
+// QuotaConfig holds the limits that keep one tenant or integration from starving the
+// shared instance. A limit of 0 disables it, which is the default for all of them.
+type QuotaConfig struct {
+	// TenantRequestsPerMinute caps the API requests of a whole tenant (429 when exceeded)
+	TenantRequestsPerMinute int
+	// ClientRequestsPerMinute caps the API requests of one client: a user or
+	// integration account, or an IP address for requests without a token (429)
+	ClientRequestsPerMinute int
+	// MaxRequestBytes caps the size of a single request body (413)
+	MaxRequestBytes int
+	// UploadBytesPerDay caps the request body bytes a tenant sends per UTC day (413)
+	UploadBytesPerDay int
+}
+
+// LoadQuotaConfig loads the quota limits from the environment
+func LoadQuotaConfig() (QuotaConfig, error) {
+	cfg := QuotaConfig{
+		TenantRequestsPerMinute: parseEnvInt("QUOTA_TENANT_REQUESTS_PER_MINUTE", 0),
+		ClientRequestsPerMinute: parseEnvInt("QUOTA_CLIENT_REQUESTS_PER_MINUTE", 0),
+		MaxRequestBytes:         parseEnvInt("QUOTA_MAX_REQUEST_BYTES", 0),
+		UploadBytesPerDay:       parseEnvInt("QUOTA_UPLOAD_BYTES_PER_DAY", 0),
+	}
+
+	if cfg.TenantRequestsPerMinute < 0 || cfg.ClientRequestsPerMinute < 0 || cfg.MaxRequestBytes < 0 || cfg.UploadBytesPerDay < 0 {
+		return QuotaConfig{}, fmt.Errorf("quota limits cannot be negative")
+	}
+
+	return cfg, nil
+}
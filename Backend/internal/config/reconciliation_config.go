@@ -0,0 +1,18 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadPaymentReconciliationInterval loads how often every tenant's payments are
+// compared with its sale totals, from PAYMENT_RECONCILIATION_INTERVAL_HOURS. It
+// defaults to 24 hours; 0 disables the scheduled reconciliation, which still runs
+// on request.
+func LoadPaymentReconciliationInterval() (time.Duration, error) {
+	hours := parseEnvInt("PAYMENT_RECONCILIATION_INTERVAL_HOURS", 24)
+	if hours < 0 {
+		return 0, fmt.Errorf("PAYMENT_RECONCILIATION_INTERVAL_HOURS cannot be negative")
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
@@ -0,0 +1,32 @@
+package config
+
+import "fmt"
+
+// RegistrationConfig holds when the LTO registration of a sold cab unit is due and
+// when the back office is alerted of registrations coming due
+type RegistrationConfig struct {
+	DueDays   int // Days after the sale date a registration is due by default
+	AlertDays int // Days ahead of its due date a registration is alerted of; 0 alerts on the day
+	AlertHour int // Local hour after which the day's alert is sent
+}
+
+// LoadRegistrationConfig loads the registration due dates and alerts from
+// REGISTRATION_DUE_DAYS (default 30), REGISTRATION_ALERT_DAYS (3) and
+// REGISTRATION_ALERT_HOUR (8).
+func LoadRegistrationConfig() (RegistrationConfig, error) {
+	cfg := RegistrationConfig{
+		DueDays:   parseEnvInt("REGISTRATION_DUE_DAYS", 30),
+		AlertDays: parseEnvInt("REGISTRATION_ALERT_DAYS", 3),
+		AlertHour: parseEnvInt("REGISTRATION_ALERT_HOUR", 8),
+	}
+	if cfg.DueDays < 1 || cfg.DueDays > 365 {
+		return RegistrationConfig{}, fmt.Errorf("REGISTRATION_DUE_DAYS must be between 1 and 365")
+	}
+	if cfg.AlertDays < 0 || cfg.AlertDays > 90 {
+		return RegistrationConfig{}, fmt.Errorf("REGISTRATION_ALERT_DAYS must be between 0 and 90")
+	}
+	if cfg.AlertHour < 0 || cfg.AlertHour > 23 {
+		return RegistrationConfig{}, fmt.Errorf("REGISTRATION_ALERT_HOUR must be between 0 and 23")
+	}
+	return cfg, nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// SessionConfig holds how long the tokens issued on sign-in last
+type SessionConfig struct {
+	AccessTTL  time.Duration // Lifetime of the JWT sent with every request
+	RefreshTTL time.Duration // How long a session can go unused before its refresh token expires
+}
+
+// LoadSessionConfig loads the token lifetimes from ACCESS_TOKEN_TTL_MINUTES and
+// REFRESH_TOKEN_TTL_HOURS. The access token defaults to 72 hours, its lifetime
+// before refresh tokens existed.
+func LoadSessionConfig() (SessionConfig, error) {
+	cfg := SessionConfig{
+		AccessTTL:  time.Duration(parseEnvInt("ACCESS_TOKEN_TTL_MINUTES", 72*60)) * time.Minute,
+		RefreshTTL: time.Duration(parseEnvInt("REFRESH_TOKEN_TTL_HOURS", 30*24)) * time.Hour,
+	}
+	if cfg.AccessTTL <= 0 {
+		return SessionConfig{}, fmt.Errorf("ACCESS_TOKEN_TTL_MINUTES must be positive")
+	}
+	if cfg.RefreshTTL <= cfg.AccessTTL {
+		return SessionConfig{}, fmt.Errorf("REFRESH_TOKEN_TTL_HOURS must be longer than the access token lifetime")
+	}
+	return cfg, nil
+}
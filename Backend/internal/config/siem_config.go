@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SIEMConfig holds where activity log entries are shipped for central security
+// monitoring. Shipping is off unless a syslog address or an HTTP collector is set.
+type SIEMConfig struct {
+	// SyslogNetwork and SyslogAddress locate a syslog collector, e.g. udp and
+	// siem.example.com:514. Entries are sent as RFC 5424 messages with a JSON body.
+	SyslogNetwork string
+	SyslogAddress string
+	// HTTPURL is a collector receiving batches as a JSON array; HTTPToken, when set,
+	// is sent as a bearer token
+	HTTPURL   string
+	HTTPToken string
+
+	BatchSize     int           // Entries sent at most per batch
+	FlushInterval time.Duration // How long entries wait for a batch to fill
+	QueueSize     int           // Entries buffered while the collector is slow or down; newer ones are dropped when full
+	MaxRetries    int           // Attempts after the first before a batch is dropped
+}
+
+// Enabled reports whether a collector is configured
+func (c SIEMConfig) Enabled() bool {
+	return c.SyslogAddress != "" || c.HTTPURL != ""
+}
+
+// LoadSIEMConfig loads the log shipping configuration from the environment.
+// SIEM_SYSLOG_ADDR takes a network and address such as udp://siem.example.com:514.
+func LoadSIEMConfig() (SIEMConfig, error) {
+	cfg := SIEMConfig{
+		HTTPURL:       strings.TrimSpace(os.Getenv("SIEM_HTTP_URL")),
+		HTTPToken:     os.Getenv("SIEM_HTTP_TOKEN"),
+		BatchSize:     parseEnvInt("SIEM_BATCH_SIZE", 100),
+		FlushInterval: time.Duration(parseEnvInt("SIEM_FLUSH_SECONDS", 5)) * time.Second,
+		QueueSize:     parseEnvInt("SIEM_QUEUE_SIZE", 10000),
+		MaxRetries:    parseEnvInt("SIEM_MAX_RETRIES", 5),
+	}
+
+	if syslogAddr := strings.TrimSpace(os.Getenv("SIEM_SYSLOG_ADDR")); syslogAddr != "" {
+		network, address, ok := strings.Cut(syslogAddr, "://")
+		if !ok || (network != "udp" && network != "tcp") || address == "" {
+			return SIEMConfig{}, fmt.Errorf("SIEM_SYSLOG_ADDR must look like udp://host:514 or tcp://host:514, got %q", syslogAddr)
+		}
+		cfg.SyslogNetwork, cfg.SyslogAddress = network, address
+	}
+
+	if cfg.SyslogAddress != "" && cfg.HTTPURL != "" {
+		return SIEMConfig{}, fmt.Errorf("set either SIEM_SYSLOG_ADDR or SIEM_HTTP_URL, not both")
+	}
+	if cfg.HTTPURL != "" {
+		if u, err := url.Parse(cfg.HTTPURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return SIEMConfig{}, fmt.Errorf("SIEM_HTTP_URL must be an http or https URL, got %q", cfg.HTTPURL)
+		}
+	}
+	if cfg.BatchSize < 1 || cfg.FlushInterval <= 0 || cfg.QueueSize < 1 || cfg.MaxRetries < 0 {
+		return SIEMConfig{}, fmt.Errorf("SIEM batch size, flush interval and queue size must be positive and retries cannot be negative")
+	}
+
+	return cfg, nil
+}
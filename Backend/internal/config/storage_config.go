@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Storage drivers keeping the contents of uploaded photos and attachments
+const (
+	StorageDatabase = "database" // In the database rows, as before storage drivers existed
+	StorageLocal    = "local"    // In a directory on the server's disk
+	StorageS3       = "s3"       // In an Amazon S3 bucket or an S3-compatible object store such as MinIO
+	StorageGCS      = "gcs"      // In a Google Cloud Storage bucket, through its XML API with HMAC keys
+)
+
+// StorageConfig holds where uploaded files are kept. Files stay in the database
+// unless STORAGE_DRIVER is set; rows written before a driver was set are still
+// read from the database.
+type StorageConfig struct {
+	Driver string
+
+	// LocalDir is the directory files are written to by the local driver
+	LocalDir string
+	// SigningSecret signs the download links of the local driver; the JWT secret is
+	// used when it is empty
+	SigningSecret string
+
+	// Bucket, Endpoint and Region locate the bucket of the s3 and gcs drivers. The
+	// endpoint defaults to the provider's; with PathStyle the bucket is the first
+	// segment of the path instead of part of the host name.
+	Bucket    string
+	Endpoint  string
+	Region    string
+	PathStyle bool
+	// AccessKey and SecretKey are the HMAC credentials requests are signed with
+	AccessKey string
+	SecretKey string
+
+	URLExpiry time.Duration // How long download links stay valid
+}
+
+// Enabled reports whether files are kept outside the database
+func (c StorageConfig) Enabled() bool {
+	return c.Driver != StorageDatabase
+}
+
+// LoadStorageConfig loads the file storage configuration from the environment
+func LoadStorageConfig() (StorageConfig, error) {
+	cfg := StorageConfig{
+		Driver:        strings.ToLower(parseEnvString("STORAGE_DRIVER", StorageDatabase)),
+		LocalDir:      parseEnvString("STORAGE_LOCAL_DIR", "storage"),
+		SigningSecret: os.Getenv("STORAGE_SIGNING_SECRET"),
+		Bucket:        strings.TrimSpace(os.Getenv("STORAGE_BUCKET")),
+		Endpoint:      strings.TrimRight(strings.TrimSpace(os.Getenv("STORAGE_ENDPOINT")), "/"),
+		Region:        strings.TrimSpace(os.Getenv("STORAGE_REGION")),
+		AccessKey:     strings.TrimSpace(os.Getenv("STORAGE_ACCESS_KEY")),
+		SecretKey:     os.Getenv("STORAGE_SECRET_KEY"),
+		URLExpiry:     time.Duration(parseEnvInt("STORAGE_URL_EXPIRY_SECONDS", 300)) * time.Second,
+	}
+
+	switch cfg.Driver {
+	case StorageDatabase, StorageLocal:
+	case StorageS3:
+		if cfg.Region == "" {
+			cfg.Region = "us-east-1"
+		}
+		// Custom endpoints are usually self-hosted stores, which address buckets by path
+		cfg.PathStyle = cfg.Endpoint != ""
+		if cfg.Endpoint == "" {
+			cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+		}
+	case StorageGCS:
+		if cfg.Region == "" {
+			cfg.Region = "auto"
+		}
+		cfg.PathStyle = true
+		if cfg.Endpoint == "" {
+			cfg.Endpoint = "https://storage.googleapis.com"
+		}
+	default:
+		return StorageConfig{}, fmt.Errorf("STORAGE_DRIVER must be %s, %s, %s or %s, got %q",
+			StorageDatabase, StorageLocal, StorageS3, StorageGCS, cfg.Driver)
+	}
+	if value := strings.TrimSpace(os.Getenv("STORAGE_PATH_STYLE")); value != "" {
+		cfg.PathStyle = value == "true" || value == "1"
+	}
+
+	if cfg.Driver == StorageS3 || cfg.Driver == StorageGCS {
+		if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return StorageConfig{}, fmt.Errorf("STORAGE_BUCKET, STORAGE_ACCESS_KEY and STORAGE_SECRET_KEY are required by the %s storage driver", cfg.Driver)
+		}
+		if u, err := url.Parse(cfg.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return StorageConfig{}, fmt.Errorf("STORAGE_ENDPOINT must be an http or https URL, got %q", cfg.Endpoint)
+		}
+	}
+	if cfg.URLExpiry <= 0 || cfg.URLExpiry > 7*24*time.Hour {
+		return StorageConfig{}, fmt.Errorf("STORAGE_URL_EXPIRY_SECONDS must be positive and at most a week")
+	}
+	return cfg, nil
+}
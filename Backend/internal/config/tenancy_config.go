@@ -0,0 +1,44 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// TenancyConfig holds how requests are mapped to tenants
+type TenancyConfig struct {
+	// BaseDomain enables subdomain resolution: acme.<BaseDomain> is served as the tenant
+	// with slug "acme". Empty disables it, so only the JWT selects a tenant.
+	BaseDomain string
+}
+
+// LoadTenancyConfig loads the tenancy configuration from the environment
+func LoadTenancyConfig() (TenancyConfig, error) {
+	cfg := TenancyConfig{
+		BaseDomain: strings.Trim(strings.ToLower(strings.TrimSpace(os.Getenv("TENANT_BASE_DOMAIN"))), "."),
+	}
+	if strings.ContainsAny(cfg.BaseDomain, "/:") {
+		return TenancyConfig{}, fmt.Errorf("TENANT_BASE_DOMAIN must be a bare domain such as example.com, got %q", cfg.BaseDomain)
+	}
+	return cfg, nil
+}
+
+// TenantSlug returns the tenant slug encoded in a Host header, or "" when the host
+// is the base domain itself, another domain, or subdomain resolution is disabled.
+func (c TenancyConfig) TenantSlug(host string) string {
+	if c.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	slug, ok := strings.CutSuffix(host, "."+c.BaseDomain)
+	if !ok || slug == "" || strings.Contains(slug, ".") {
+		return ""
+	}
+	return slug
+}
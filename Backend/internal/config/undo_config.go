@@ -0,0 +1,17 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoadUndoWindow loads how long a deleted customer, accessory or material can be
+// restored with the undo token returned by the delete, from UNDO_WINDOW_MINUTES. It
+// defaults to 5 minutes; 0 disables undo.
+func LoadUndoWindow() (time.Duration, error) {
+	minutes := parseEnvInt("UNDO_WINDOW_MINUTES", 5)
+	if minutes < 0 {
+		return 0, fmt.Errorf("UNDO_WINDOW_MINUTES cannot be negative")
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
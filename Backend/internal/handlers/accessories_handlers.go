@@ -1,12 +1,17 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
 	"strings"
 	"oop/internal/config"
 
@@ -14,37 +19,19 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// ErrorResponse represents an API error response
-type ErrorResponse struct {
-	Error      string `json:"error"`
-	Message    string `json:"message,omitempty"`
-	StatusCode int    `json:"statusCode"`
-	Timestamp  string `json:"timestamp"`
-}
-
-// SuccessResponse represents a successful API response
-type SuccessResponse struct {
-	Success   bool        `json:"success"`
-	Data      interface{} `json:"data,omitempty"` // Changed to interface{} to be more generic
-	Message   string      `json:"message,omitempty"`
-	Timestamp string      `json:"timestamp"`
-}
-
-// AccessoriesListResponse represents a response with a list of accessories
-// This can be used directly in Swagger docs if GetAllAccessories returns this structure.
-// However, the current GetAllAccessories returns fiber.Map{"data": accessories, "count": len(accessories)}
-// For simplicity with current handler structure, we might define success directly in annotations.
-type AccessoriesListResponse struct {
-	Data       []models.Accessory `json:"data"`
-	Count      int                `json:"count"`
-	Page       int                `json:"page,omitempty"`
-	PageSize   int                `json:"pageSize,omitempty"`
-	TotalPages int                `json:"totalPages,omitempty"`
-}
-
 // AccessoriesHandler handles accessory-related requests
 type AccessoriesHandler struct {
-	Repo repositories.AccessoryRepository
+	Repo        repositories.AccessoryRepository
+	Audit       *ChangeRecorder           // Optional; records field-level changes to the activity log
+	Watch       *Watchlist                // Optional; notifies users who starred an accessory when it changes
+	Views       *RecentViews              // Optional; records the accessory in the caller's recently viewed list
+	Undo        *UndoHandler              // Optional; lets the delete be undone for a while
+	Trash       *TrashHandler             // Optional; records who deleted the accessory
+	Marketplace *services.MarketplaceSync // Optional; pushes the accessory's availability and price to the marketplace
+	Alerts      *Alerts                   // Optional; publishes the accessory running out of stock
+	Perms       *Permissions              // Optional; without it only admins may price accessories outside their price guard
+	Approvals   *PriceChangeHandler       // Optional; holds large price changes for approval
+	Gallery     *ItemImageHandler         // Optional; adds the photo gallery to the accessory's details
 }
 
 // NewAccessoriesHandler creates a new accessories handler
@@ -52,9 +39,22 @@ func NewAccessoriesHandler(repo repositories.AccessoryRepository) *AccessoriesHa
 	return &AccessoriesHandler{Repo: repo}
 }
 
+// Routes registers the accessory routes. Listing and viewing stay public; changes
+// need a staff or admin token.
+func (h *AccessoriesHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/accessories", mw.IncludeDeleted, h.GetAllAccessories)             // GET /api/accessories
+	r.Get("/accessories/:id", h.GetAccessoryByID)                             // GET /api/accessories/:id
+	r.Post("/accessories", mw.Auth, mw.Staff, mw.Dedupe, h.CreateAccessory)   // POST /api/accessories
+	r.Post("/accessories/batch", mw.Auth, mw.Staff, h.BatchAccessories)       // POST /api/accessories/batch
+	r.Put("/accessories/:id", mw.Auth, mw.Staff, h.UpdateAccessory)           // PUT /api/accessories/:id
+	r.Delete("/accessories/:id", mw.Auth, mw.Staff, h.DeleteAccessory)        // DELETE /api/accessories/:id
+	r.Post("/accessories/:id/restore", mw.Auth, mw.Admin, h.RestoreAccessory) // POST /api/accessories/:id/restore
+}
+
 // GetAllAccessories returns all accessories
 // @Summary Get all accessories
-// @Description Get a list of all accessories, with optional filtering.
+// @Description Get a list of all accessories, with optional filtering. With page or limit set, returns one page of the accessories matching the filters, with the total number of matches and pages.
+// @Description Admins can add include_deleted=true, on its own, to list the deleted accessories after the others.
 // @Tags Accessories
 // @Accept json
 // @Produce json
@@ -62,27 +62,46 @@ func NewAccessoriesHandler(repo repositories.AccessoryRepository) *AccessoriesHa
 // @Param status query string false "Filter by status"
 // @Param unit_color query string false "Filter by unit color"
 // @Param search query string false "General search term"
-// @Success 200 {object} AccessoriesListResponse "Successfully retrieved list of accessories"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve accessories"
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Accessories per page (default 10, max 100)"
+// @Param include_deleted query bool false "Also list the deleted accessories, with their deletedAt (admins only)"
+// @Success 200 {object} api.AccessoriesListResponse "Successfully retrieved list of accessories"
+// @Failure 400 {object} api.ErrorResponse "include_deleted combined with filters or paging"
+// @Failure 403 {object} api.ErrorResponse "Only admins can list deleted accessories"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve accessories"
 // @Router /accessories [get]
 func (h *AccessoriesHandler) GetAllAccessories(c *fiber.Ctx) error {
-	// Extract query parameters for filtering
-	filters := make(map[string]interface{})
-	if makeFilter := c.Query("make"); makeFilter != "" {
-		filters["make"] = makeFilter
-	}
-	if statusFilter := c.Query("status"); statusFilter != "" {
-		filters["status"] = statusFilter
+	filter := models.AccessoryFilter{
+		Make:      c.Query("make"),
+		Status:    c.Query("status"),
+		UnitColor: c.Query("unit_color"),
+		Search:    c.Query("search"),
 	}
-	if colorFilter := c.Query("unit_color"); colorFilter != "" {
-		filters["unit_color"] = colorFilter
-	}
-	if searchFilter := c.Query("search"); searchFilter != "" {
-		filters["search"] = searchFilter
+
+	if c.Query("page") != "" || c.Query("limit") != "" {
+		page, limit := pageParams(c)
+		accessories, total, err := h.Repo.GetPaginated(c.Context(), page, limit, filter)
+		if err != nil {
+			log.Printf("Error getting paginated accessories: %v", err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve accessories"})
+		}
+		return c.Status(http.StatusOK).JSON(api.AccessoriesListResponse{
+			Data:       accessories,
+			Count:      len(accessories),
+			Page:       page,
+			PageSize:   limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		})
 	}
 
 	// Call repository to get accessories
 	accessories, err := h.Repo.GetAll(c.Context())
+	if err == nil && includeDeleted(c) {
+		var deleted []models.Accessory
+		deleted, err = h.Repo.GetDeleted(c.Context())
+		accessories = append(accessories, deleted...)
+	}
 	if err != nil {
 		// Log the error internally
 		fmt.Printf("Error fetching accessories: %v\n", err) // Replace with proper logging
@@ -104,9 +123,9 @@ func (h *AccessoriesHandler) GetAllAccessories(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path int true "Accessory ID"
 // @Success 200 {object} models.Accessory "Successfully retrieved accessory"
-// @Failure 400 {object} ErrorResponse "Invalid ID format. ID must be an integer."
-// @Failure 404 {object} ErrorResponse "Accessory not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve accessory"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format. ID must be an integer."
+// @Failure 404 {object} api.ErrorResponse "Accessory not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve accessory"
 // @Router /accessories/{id} [get]
 func (h *AccessoriesHandler) GetAccessoryByID(c *fiber.Ctx) error {
 	// Parse ID from URL path parameter
@@ -127,21 +146,28 @@ func (h *AccessoriesHandler) GetAccessoryByID(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve accessory"})
 	}
 
+	h.Views.Viewed(c, models.ViewEntityAccessory, strconv.Itoa(id))
+	accessory.Images = h.Gallery.Images(models.InventoryAccessory, id)
+	setLastModified(c, accessory.UpdatedAt)
+
 	// Return the accessory as JSON
 	return c.Status(http.StatusOK).JSON(accessory)
 }
 
 // CreateAccessory creates a new accessory
 // @Summary Create a new accessory
-// @Description Add a new accessory to the inventory.
+// @Description Add a new accessory to the inventory. Setting min_price or max_price, the range its price must stay within, requires the prices.override permission.
 // @Tags Accessories
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param accessory_input body models.NewAccessoryInput true "Accessory object to create"
-// @Success 201 {object} SuccessResponse "Accessory created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid JSON format or failed to parse request body"
-// @Failure 422 {object} ErrorResponse "Missing required fields or validation error"
-// @Failure 500 {object} ErrorResponse "Failed to create accessory or failed to retrieve details after creation"
+// @Success 201 {object} api.SuccessResponse "Accessory created successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid JSON format or failed to parse request body"
+// @Failure 403 {object} api.ErrorResponse "Setting a price guard requires the prices.override permission"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero"
+// @Failure 422 {object} api.ErrorResponse "Missing required fields, validation error or price outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to create accessory or failed to retrieve details after creation"
 // @Router /accessories [post]
 func (h *AccessoriesHandler) CreateAccessory(c *fiber.Ctx) error {
 	var input models.NewAccessoryInput
@@ -163,6 +189,12 @@ func (h *AccessoriesHandler) CreateAccessory(c *fiber.Ctx) error {
 		})
 	}
 
+	input.MinPrice, input.MaxPrice = models.PriceBound(input.MinPrice), models.PriceBound(input.MaxPrice)
+	pricing := pricedItem{Name: input.Name, Price: input.Price, MinPrice: input.MinPrice, MaxPrice: input.MaxPrice}
+	if rejected, err := rejectPriceChange(c, h.Perms, nil, pricing); rejected {
+		return err
+	}
+
 	// Handle null or empty image with default image URL
 	if input.Image == "null" || input.Image == "" {
 		input.Image = config.DefaultImageURL
@@ -171,6 +203,9 @@ func (h *AccessoriesHandler) CreateAccessory(c *fiber.Ctx) error {
 	// Create accessory
 	createdAccessoryID, err := h.Repo.Create(c.Context(), input)
 	if err != nil {
+		if conflict, err := rejectNegativeStock(c, err); conflict {
+			return err
+		}
 		// Check for specific repository errors
 		if strings.Contains(err.Error(), "cannot be empty") {
 			return c.Status(http.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
@@ -189,6 +224,7 @@ func (h *AccessoriesHandler) CreateAccessory(c *fiber.Ctx) error {
 	}
 
 	log.Printf("Returning newly created accessory: %+v\n", newlyCreatedAccessory)
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.AccessoryListing(newlyCreatedAccessory))
 
 	// Return success response
 	return c.Status(http.StatusCreated).JSON(fiber.Map{
@@ -200,16 +236,23 @@ func (h *AccessoriesHandler) CreateAccessory(c *fiber.Ctx) error {
 
 // UpdateAccessory updates an existing accessory
 // @Summary Update an existing accessory
-// @Description Update an existing accessory by its ID.
+// @Description Update an existing accessory by its ID. A min_price or max_price of 0 removes that bound; changing them requires the prices.override permission, as does a new price outside them. A price change by more than PRICE_APPROVAL_THRESHOLD_PERCENT is held for an admin to approve: the rest of the update is applied and 202 is returned with the accessory at its old price.
 // @Tags Accessories
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path int true "Accessory ID"
 // @Param accessory_update body models.UpdateAccessoryInput true "Accessory object with updated fields"
-// @Success 200 {object} SuccessResponse "Accessory updated successfully"
-// @Failure 400 {object} ErrorResponse "Invalid ID format or invalid JSON format/parsing error"
-// @Failure 404 {object} ErrorResponse "Accessory not found for update"
-// @Failure 500 {object} ErrorResponse "Failed to update accessory"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.SuccessResponse "Accessory updated successfully"
+// @Success 202 {object} api.SuccessResponse "Accessory updated; its price change is held for approval, with the ID of the change in X-Price-Change-Id"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format or invalid JSON format/parsing error"
+// @Failure 403 {object} api.ErrorResponse "Changing the price guard requires the prices.override permission"
+// @Failure 404 {object} api.ErrorResponse "Accessory not found for update"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero, or a price change is already waiting for approval"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 422 {object} api.ErrorResponse "Price outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to update accessory"
 // @Router /accessories/{id} [put]
 func (h *AccessoriesHandler) UpdateAccessory(c *fiber.Ctx) error {
 	// Parse ID from URL path parameter
@@ -238,9 +281,57 @@ func (h *AccessoriesHandler) UpdateAccessory(c *fiber.Ctx) error {
 		}
 	}
 
+	// Capture the previous state for conditional updates, the price guard, the activity log diff, watchlist notifications and stock-out alerts
+	var before *models.Accessory
+	pricingChanged := input.Price != nil || input.MinPrice != nil || input.MaxPrice != nil
+	if h.Audit.Enabled() || h.Watch.Enabled() || h.Alerts.Enabled() || hasUpdatePrecondition(c) || pricingChanged {
+		if existing, err := h.Repo.GetByID(c.Context(), id); err == nil {
+			if modified, err := rejectIfModified(c, existing.UpdatedAt); modified {
+				return err
+			}
+			before = &existing
+		} else {
+			log.Printf("Error fetching accessory ID %d before update: %v", id, err)
+		}
+	}
+
+	if pricingChanged {
+		var beforePricing *pricedItem
+		var pricing pricedItem
+		if before != nil {
+			pricing = accessoryPricing(*before)
+			current := pricing
+			beforePricing = &current
+		}
+		if input.Name != nil {
+			pricing.Name = *input.Name
+		}
+		if input.Price != nil {
+			pricing.Price = *input.Price
+		}
+		pricing.MinPrice = mergePriceBound(pricing.MinPrice, input.MinPrice)
+		pricing.MaxPrice = mergePriceBound(pricing.MaxPrice, input.MaxPrice)
+		if rejected, err := rejectPriceChange(c, h.Perms, beforePricing, pricing); rejected {
+			return err
+		}
+	}
+
+	// Hold a large price change for approval; the rest of the update is applied now
+	holdPrice := before != nil && input.Price != nil && h.Approvals.needsApproval(before.Price, *input.Price)
+	var requestedPrice float64
+	if holdPrice {
+		if pending, err := h.Approvals.rejectIfPending(c, models.InventoryAccessory, id); pending {
+			return err
+		}
+		requestedPrice, input.Price = *input.Price, nil
+	}
+
 	// Update accessory
 	updatedAccessory, err := h.Repo.Update(c.Context(), id, input)
 	if err != nil {
+		if conflict, err := rejectNegativeStock(c, err); conflict {
+			return err
+		}
 		// Check if the error is 'not found'
 		if strings.Contains(strings.ToLower(err.Error()), "not found") {
 			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("Accessory with ID %d not found for update", id)})
@@ -250,11 +341,32 @@ func (h *AccessoriesHandler) UpdateAccessory(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update accessory"})
 	}
 
+	if before != nil {
+		h.Audit.RecordUpdate(c, AuditEntityAccessory, strconv.Itoa(id), before, updatedAccessory)
+		h.Watch.ItemUpdated(c, models.FavoriteItemAccessory, id, updatedAccessory.Name,
+			itemStock{Price: before.Price, Quantity: before.Quantity},
+			itemStock{Price: updatedAccessory.Price, Quantity: updatedAccessory.Quantity})
+		h.Alerts.StockChanged(c, AuditEntityAccessory, id, updatedAccessory.Name, before.Quantity, updatedAccessory.Quantity)
+	}
+
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.AccessoryListing(updatedAccessory))
+
+	status, message := http.StatusOK, "Accessory updated successfully"
+	if holdPrice {
+		change, err := h.Approvals.hold(c, models.InventoryAccessory, id, updatedAccessory.Name, before.Price, requestedPrice)
+		if err != nil {
+			log.Printf("Error holding the price change of accessory ID %d: %v", id, err)
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Accessory updated, but its price change could not be held for approval"})
+		}
+		status, message = heldForApproval(c, change), "Accessory updated; its price change is waiting for approval"
+	}
+
 	// Return success response with the updated accessory data
-	return c.Status(http.StatusOK).JSON(fiber.Map{
+	setLastModified(c, updatedAccessory.UpdatedAt)
+	return c.Status(status).JSON(fiber.Map{
 		"success": true,
 		"data":    updatedAccessory, // Return the full accessory object
-		"message": "Accessory updated successfully",
+		"message": message,
 	})
 }
 
@@ -264,11 +376,12 @@ func (h *AccessoriesHandler) UpdateAccessory(c *fiber.Ctx) error {
 // @Tags Accessories
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path int true "Accessory ID"
-// @Success 204 "Accessory deleted successfully (No Content)"
-// @Failure 400 {object} ErrorResponse "Invalid ID format. ID must be an integer."
-// @Failure 404 {object} ErrorResponse "Accessory not found for deletion"
-// @Failure 500 {object} ErrorResponse "Failed to delete accessory"
+// @Success 204 "Accessory deleted successfully (No Content). The X-Undo-Token header holds a token for POST /undo/{token}, valid until X-Undo-Expires-At."
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format. ID must be an integer."
+// @Failure 404 {object} api.ErrorResponse "Accessory not found for deletion"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete accessory"
 // @Router /accessories/{id} [delete]
 func (h *AccessoriesHandler) DeleteAccessory(c *fiber.Ctx) error {
 	// Parse ID from URL path parameter
@@ -289,6 +402,190 @@ func (h *AccessoriesHandler) DeleteAccessory(c *fiber.Ctx) error {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete accessory"})
 	}
 
+	h.Trash.Deleted(c, models.TrashAccessory, strconv.Itoa(id))
+	h.Undo.Offer(c, AuditEntityAccessory, strconv.Itoa(id))
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.RemovedListing(services.ListingAccessory, id))
+
 	// Return No Content status for successful deletion
 	return c.SendStatus(http.StatusNoContent)
 }
+
+// BatchAccessories creates, updates and deletes many accessories at once
+// @Summary Create, update and delete accessories in one batch
+// @Description Applies up to 500 operations on accessories in one transaction, so either every one is applied or none is. A create takes the fields of POST /accessories, of which name, make and unit_color are required; an update the fields to change, as PUT /accessories/{id} does, with the same price guard; a delete only needs the ID. An item may be updated or deleted by one operation of a batch. A price change needing approval is refused: make it with PUT /accessories/{id}.
+// @Description Each operation has a result with the status it would have had on its own. When an operation fails, nothing is applied: the response has the status of the first that failed, and the operations that did not fail have 424.
+// @Tags Accessories
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param batch body api.AccessoryBatchRequest true "Operations, applied in order"
+// @Success 200 {object} api.BatchResponse "Every operation applied"
+// @Failure 400 {object} api.ErrorResponse "Invalid JSON, or no or more than 500 operations"
+// @Failure 403 {object} api.BatchResponse "Not applied: an operation changes a price guard without the prices.override permission"
+// @Failure 404 {object} api.BatchResponse "Not applied: an operation's accessory does not exist"
+// @Failure 409 {object} api.BatchResponse "Not applied: an operation takes a quantity below zero or changes a price by more than needs approval"
+// @Failure 422 {object} api.BatchResponse "Not applied: an operation is invalid or its price is outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to apply accessory batch"
+// @Router /accessories/batch [post]
+func (h *AccessoriesHandler) BatchAccessories(c *fiber.Ctx) error {
+	var req api.AccessoryBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid JSON format in request body",
+			StatusCode: http.StatusBadRequest,
+		})
+	}
+	if rejected, err := rejectBatchSize(c, len(req.Operations)); rejected {
+		return err
+	}
+
+	plan := newBatchPlan[models.Accessory]("accessory", len(req.Operations))
+	for i, op := range req.Operations {
+		if !plan.check(i, op.Op, op.ID, op.Item != nil) {
+			continue
+		}
+		if err := h.planAccessory(c, plan, i, op); err != nil {
+			log.Printf("Error checking operation %d of accessory batch: %v", i, err)
+			return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Failed to apply accessory batch",
+				StatusCode: http.StatusInternalServerError,
+			})
+		}
+	}
+	if applied, err := plan.apply(c, h.Repo.ApplyBatch); !applied {
+		return err
+	}
+
+	tenantID := tenantIDFromCtx(c)
+	for i, change := range plan.changes {
+		accessory := change.Item
+		switch change.Op {
+		case models.BatchCreate:
+			h.Marketplace.ItemChanged(tenantID, services.AccessoryListing(accessory))
+		case models.BatchUpdate:
+			before := plan.before[i]
+			h.Audit.RecordUpdate(c, AuditEntityAccessory, strconv.Itoa(accessory.ID), before, accessory)
+			h.Watch.ItemUpdated(c, models.FavoriteItemAccessory, accessory.ID, accessory.Name,
+				itemStock{Price: before.Price, Quantity: before.Quantity},
+				itemStock{Price: accessory.Price, Quantity: accessory.Quantity})
+			h.Alerts.StockChanged(c, AuditEntityAccessory, accessory.ID, accessory.Name, before.Quantity, accessory.Quantity)
+			h.Marketplace.ItemChanged(tenantID, services.AccessoryListing(accessory))
+		case models.BatchDelete:
+			h.Trash.Deleted(c, models.TrashAccessory, strconv.Itoa(change.ID))
+			h.Marketplace.ItemChanged(tenantID, services.RemovedListing(services.ListingAccessory, change.ID))
+		}
+	}
+	return plan.respond(c, h.Audit, AuditEntityAccessory, func(accessory models.Accessory) int { return accessory.ID })
+}
+
+// planAccessory checks operation i of an accessory batch against the accessory it
+// changes, as CreateAccessory and UpdateAccessory would, and adds its change with the
+// accessory's new state to the plan. It only fails when the accessory cannot be read.
+func (h *AccessoriesHandler) planAccessory(c *fiber.Ctx, plan *batchPlan[models.Accessory], i int, op api.AccessoryBatchOperation) error {
+	var before *models.Accessory
+	if op.Op != models.BatchCreate {
+		existing, err := h.Repo.GetByID(c.Context(), op.ID)
+		if err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "not found") {
+				plan.notFound(i)
+				return nil
+			}
+			return err
+		}
+		before = &existing
+	}
+	if op.Op == models.BatchDelete {
+		plan.add(i, op.Op, op.ID, models.Accessory{}, before)
+		return nil
+	}
+
+	input := op.Item
+	var accessory models.Accessory
+	if before != nil {
+		accessory = *before
+	} else if input.Name == nil || *input.Name == "" || input.Make == nil || *input.Make == "" || input.UnitColor == nil || *input.UnitColor == "" {
+		plan.reject(i, http.StatusUnprocessableEntity, "Missing required fields. Required: name, make, unit_color")
+		return nil
+	}
+	if input.Name != nil {
+		accessory.Name = *input.Name
+	}
+	if input.Make != nil {
+		accessory.Make = *input.Make
+	}
+	if input.Quantity != nil {
+		accessory.Quantity = *input.Quantity
+	}
+	if input.Price != nil {
+		accessory.Price = *input.Price
+	}
+	accessory.MinPrice = mergePriceBound(accessory.MinPrice, input.MinPrice)
+	accessory.MaxPrice = mergePriceBound(accessory.MaxPrice, input.MaxPrice)
+	if input.UnitColor != nil {
+		accessory.UnitColor = *input.UnitColor
+	}
+	if input.Image != nil {
+		accessory.Image = *input.Image
+	}
+	if accessory.Image == "null" || accessory.Image == "" {
+		accessory.Image = config.DefaultImageURL
+	}
+	if accessory.Quantity < 0 {
+		plan.reject(i, http.StatusConflict, "Quantity cannot go below zero")
+		return nil
+	}
+
+	var beforePricing *pricedItem
+	if before != nil {
+		pricing := accessoryPricing(*before)
+		beforePricing = &pricing
+	}
+	if status, message := priceChangeError(c, h.Perms, beforePricing, accessoryPricing(accessory)); status != 0 {
+		plan.reject(i, status, message)
+		return nil
+	}
+	if before != nil && h.Approvals.needsApproval(before.Price, accessory.Price) {
+		plan.reject(i, http.StatusConflict, fmt.Sprintf("The price change of accessory %d needs approval; make it with PUT /api/accessories/%d", op.ID, op.ID))
+		return nil
+	}
+
+	plan.add(i, op.Op, op.ID, accessory, before)
+	return nil
+}
+
+// RestoreAccessory restores a deleted accessory
+// @Summary Restore a deleted accessory (Admin)
+// @Description Restores a deleted accessory, with the stock and prices it had when it was deleted.
+// @Tags Accessories
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Accessory ID"
+// @Success 200 {object} models.Accessory "Restored accessory"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format. ID must be an integer."
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Accessory is not deleted, or does not exist"
+// @Failure 500 {object} api.ErrorResponse "Failed to restore accessory"
+// @Router /accessories/{id}/restore [post]
+func (h *AccessoriesHandler) RestoreAccessory(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format. ID must be an integer."})
+	}
+
+	if err := h.Repo.Restore(c.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": fmt.Sprintf("Accessory with ID %d is not deleted", id)})
+		}
+		log.Printf("Error restoring accessory ID %d: %v", id, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to restore accessory"})
+	}
+	h.Audit.RecordAction(c, trashRestore.auditAction, AuditEntityAccessory, strconv.Itoa(id), fmt.Sprintf("Restored deleted accessory %d", id))
+
+	accessory, err := h.Repo.GetByID(c.Context(), id)
+	if err != nil {
+		log.Printf("Error fetching restored accessory ID %d: %v", id, err)
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to retrieve restored accessory"})
+	}
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.AccessoryListing(accessory))
+	return c.Status(http.StatusOK).JSON(accessory)
+}
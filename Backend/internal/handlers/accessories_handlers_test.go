@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"oop/internal/api"
 	"oop/internal/models"
 	"testing"
 	"time"
@@ -29,6 +30,12 @@ func (m *MockAccessoryRepository) GetAll(ctx context.Context) ([]models.Accessor
 	return args.Get(0).([]models.Accessory), args.Error(1)
 }
 
+// GetPaginated mocks the GetPaginated method
+func (m *MockAccessoryRepository) GetPaginated(ctx context.Context, page, limit int, filter models.AccessoryFilter) ([]models.Accessory, int64, error) {
+	args := m.Called(ctx, page, limit, filter)
+	return args.Get(0).([]models.Accessory), args.Get(1).(int64), args.Error(2)
+}
+
 // GetByID mocks the GetByID method
 func (m *MockAccessoryRepository) GetByID(ctx context.Context, id int) (models.Accessory, error) {
 	args := m.Called(ctx, id)
@@ -53,6 +60,21 @@ func (m *MockAccessoryRepository) Delete(ctx context.Context, id int) error {
 	return args.Error(0)
 }
 
+func (m *MockAccessoryRepository) Restore(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAccessoryRepository) GetDeleted(ctx context.Context) ([]models.Accessory, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Accessory), args.Error(1)
+}
+
+func (m *MockAccessoryRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Accessory]) error {
+	args := m.Called(ctx, changes)
+	return args.Error(0)
+}
+
 // Helper function to setup a test Fiber app with the accessories handlers
 func setupTestApp(mockRepo *MockAccessoryRepository) *fiber.App {
 	app := fiber.New()
@@ -156,6 +178,28 @@ func TestGetAllAccessories(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Paginated", func(t *testing.T) {
+		mockRepo := new(MockAccessoryRepository)
+		page := []models.Accessory{{ID: 3, Name: "Side Mirror", Make: models.MakeOEM, Status: models.StatusInStock, UnitColor: models.ColorBlack}}
+		filter := models.AccessoryFilter{Make: string(models.MakeOEM), Search: "mirror"}
+		mockRepo.On("GetPaginated", mock.Anything, 2, 1, filter).Return(page, int64(3), nil)
+
+		app := setupTestApp(mockRepo)
+		resp, body, err := makeRequest(app, "GET", "/api/accessories?page=2&limit=1&make=OEM&search=mirror", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response api.AccessoriesListResponse
+		assert.NoError(t, json.Unmarshal(body, &response))
+		assert.Len(t, response.Data, 1)
+		assert.Equal(t, 1, response.Count)
+		assert.Equal(t, 2, response.Page)
+		assert.Equal(t, 1, response.PageSize)
+		assert.Equal(t, int64(3), response.Total)
+		assert.Equal(t, 3, response.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("Error", func(t *testing.T) {
 		// Create mock repository
 		mockRepo := new(MockAccessoryRepository)
@@ -477,7 +521,8 @@ func TestUpdateAccessory(t *testing.T) {
 			UpdatedAt: now,                 // Updated time
 		}
 
-		// Set expectations
+		// Set expectations; the current accessory is fetched to check the new price against its price guard
+		mockRepo.On("GetByID", mock.Anything, accessoryID).Return(models.Accessory{ID: accessoryID, Name: "Test Accessory", Price: 150.0}, nil)
 		mockRepo.On("Update", mock.Anything, accessoryID, updateInput).Return(updatedAccessory, nil)
 
 		// Setup app and make request
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits of the accounting sync status listing and of a single posting
+const (
+	defaultAccountingPostingLimit = 60
+	maxAccountingPostingLimit     = 500
+	accountingPostTimeout         = 30 * time.Second
+)
+
+// accountingKinds are posted for every day, in this order
+var accountingKinds = []string{models.AccountingSalesJournal, models.AccountingPayments}
+
+// AccountingHandler posts each day's sales journal and payments to the accounting
+// system once the day is over, and reports how the postings went. Failed postings
+// are retried by the accounting sync job until they have been attempted
+// Config.MaxAttempts times, and by admins at any time.
+type AccountingHandler struct {
+	Repo      repositories.AccountingPostingRepository
+	Sales     SaleRepository
+	Exporter  services.AccountingExporter // Nil when no accounting system is configured
+	Config    config.AccountingConfig
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewAccountingHandler creates a new AccountingHandler instance
+func NewAccountingHandler(repo repositories.AccountingPostingRepository, sales SaleRepository, exporter services.AccountingExporter, cfg config.AccountingConfig, jwtSecret []byte) *AccountingHandler {
+	return &AccountingHandler{Repo: repo, Sales: sales, Exporter: exporter, Config: cfg, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes of the accounting sync
+func (h *AccountingHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	accountingGroup := r.Group("/admin/accounting", mw.Auth, mw.Admin)
+	accountingGroup.Get("/sync-status", h.GetSyncStatus)                // GET /api/admin/accounting/sync-status
+	accountingGroup.Post("/postings/:kind/:date/retry", h.RetryPosting) // POST /api/admin/accounting/postings/:kind/:date/retry
+}
+
+// Sync retries the failed postings of the tenant that have attempts left, then posts
+// yesterday's sales journal and payments unless they were posted or attempted already
+func (h *AccountingHandler) Sync(ctx context.Context, tenantID string) error {
+	var errs []error
+
+	retryable, err := h.Repo.GetRetryable(h.Config.MaxAttempts)
+	if err != nil {
+		return err
+	}
+	for _, posting := range retryable {
+		if _, err := h.post(ctx, tenantID, posting.Kind, posting.PostingDate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	yesterday := h.now().AddDate(0, 0, -1).Format("2006-01-02")
+	for _, kind := range accountingKinds {
+		_, err := h.Repo.Get(kind, yesterday)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := h.post(ctx, tenantID, kind, yesterday); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// post posts the sales journal or payments of a day, unless they were posted
+// already, and records the outcome. Days without sales are recorded as posted
+// without calling the accounting system.
+func (h *AccountingHandler) post(ctx context.Context, tenantID, kind, date string) (*models.AccountingPosting, error) {
+	posting, err := h.Repo.Get(kind, date)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		posting = &models.AccountingPosting{Kind: kind, PostingDate: date}
+	}
+	if posting.Status == models.AccountingPosted {
+		return posting, nil
+	}
+
+	sales, err := h.Sales.GetAll(ctx, models.SalesFilter{StartDate: date, EndDate: date})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sales of %s: %w", date, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, accountingPostTimeout)
+	defer cancel()
+	var externalID string
+	switch kind {
+	case models.AccountingSalesJournal:
+		journal := services.NewSalesJournal(tenantID, date, sales, h.Config.Accounts)
+		posting.Amount = journal.Total()
+		if len(sales) > 0 {
+			externalID, err = h.Exporter.PostSalesJournal(ctx, journal)
+		}
+	case models.AccountingPayments:
+		batch := services.NewPaymentBatch(tenantID, date, sales)
+		posting.Amount = batch.Total()
+		if len(sales) > 0 {
+			externalID, err = h.Exporter.PostPayments(ctx, batch)
+		}
+	}
+
+	now := h.now()
+	posting.Attempts++
+	posting.UpdatedAt = now
+	if err != nil {
+		posting.Status = models.AccountingFailed
+		posting.LastError = err.Error()
+	} else {
+		posting.Status = models.AccountingPosted
+		posting.LastError = ""
+		posting.ExternalID = externalID
+		posting.PostedAt = &now
+	}
+	if saveErr := h.Repo.Save(posting); saveErr != nil {
+		return nil, errors.Join(err, saveErr)
+	}
+	if err != nil {
+		return posting, fmt.Errorf("failed to post %s of %s: %w", kind, date, err)
+	}
+	return posting, nil
+}
+
+// GetSyncStatus handles listing postings to the accounting system
+// @Summary Accounting sync status (Admin)
+// @Description Lists the daily sales journals and payments posted to the accounting system, latest day first, with the attempts made and the last error of failed ones. The sync job retries a failed posting until it was attempted maxAttempts times.
+// @Tags Accounting
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Only postings with this status: posted or failed"
+// @Param limit query int false "Maximum number of postings (default 60, max 500)"
+// @Success 200 {object} api.AccountingSyncStatusResponse "Postings"
+// @Failure 400 {object} api.ErrorResponse "Invalid status or limit"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve postings"
+// @Router /admin/accounting/sync-status [get]
+func (h *AccountingHandler) GetSyncStatus(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if status != "" && status != models.AccountingPosted && status != models.AccountingFailed {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "status must be posted or failed", StatusCode: fiber.StatusBadRequest})
+	}
+
+	limit := defaultAccountingPostingLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxAccountingPostingLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("limit must be between 1 and %d", maxAccountingPostingLimit), StatusCode: fiber.StatusBadRequest})
+		}
+		limit = parsed
+	}
+
+	postings, err := h.Repo.List(status, limit)
+	if err != nil {
+		log.Printf("Error listing accounting postings: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve postings", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.AccountingSyncStatusResponse{Enabled: h.Exporter != nil, MaxAttempts: h.Config.MaxAttempts, Postings: postings})
+}
+
+// RetryPosting handles posting a day's sales journal or payments now
+// @Summary Retry an accounting posting (Admin)
+// @Description Posts the sales journal or payments of a day that is over to the accounting system now, whether it failed before, ran out of attempts or was never attempted.
+// @Tags Accounting
+// @Produce json
+// @Security ApiKeyAuth
+// @Param kind path string true "sales_journal or payments"
+// @Param date path string true "Day (YYYY-MM-DD)"
+// @Success 200 {object} api.AccountingPostingResponse "Posted"
+// @Failure 400 {object} api.ErrorResponse "Invalid kind or date"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "Already posted"
+// @Failure 500 {object} api.ErrorResponse "Failed to post"
+// @Failure 502 {object} api.ErrorResponse "The accounting system rejected the posting"
+// @Failure 503 {object} api.ErrorResponse "No accounting system is configured"
+// @Router /admin/accounting/postings/{kind}/{date}/retry [post]
+func (h *AccountingHandler) RetryPosting(c *fiber.Ctx) error {
+	if h.Exporter == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "No accounting system is configured", StatusCode: fiber.StatusServiceUnavailable})
+	}
+
+	kind := strings.Clone(c.Params("kind"))
+	if !slices.Contains(accountingKinds, kind) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "kind must be sales_journal or payments", StatusCode: fiber.StatusBadRequest})
+	}
+	day, err := time.ParseInLocation("2006-01-02", c.Params("date"), time.Local)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date must be in YYYY-MM-DD format", StatusCode: fiber.StatusBadRequest})
+	}
+	date := day.Format("2006-01-02")
+	if date >= h.now().Format("2006-01-02") {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Only days that are over can be posted", StatusCode: fiber.StatusBadRequest})
+	}
+
+	if existing, err := h.Repo.Get(kind, date); err == nil && existing.Status == models.AccountingPosted {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Already posted", StatusCode: fiber.StatusConflict})
+	}
+
+	posting, err := h.post(c.Context(), tenantIDFromCtx(c), kind, date)
+	if posting == nil {
+		log.Printf("Error posting %s of %s: %v", kind, date, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to post", StatusCode: fiber.StatusInternalServerError})
+	}
+	h.Audit.RecordAttempt(c, "RETRY_ACCOUNTING_POSTING", AuditEntityAccounting, kind+"/"+date, fmt.Sprintf("Posted %s of %s to the accounting system", kind, date), err == nil)
+	if err != nil {
+		log.Printf("Error posting %s of %s: %v", kind, date, err)
+		return c.Status(fiber.StatusBadGateway).JSON(api.ErrorResponse{Error: "The accounting system rejected the posting: " + posting.LastError, StatusCode: fiber.StatusBadGateway})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.AccountingPostingResponse{Message: "Posted", Posting: *posting})
+}
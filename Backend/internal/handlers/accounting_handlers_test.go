@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAccountingExporter records postings and fails while down is set
+type fakeAccountingExporter struct {
+	journals []services.SalesJournal
+	payments []services.PaymentBatch
+	down     bool
+}
+
+func (e *fakeAccountingExporter) PostSalesJournal(ctx context.Context, journal services.SalesJournal) (string, error) {
+	if e.down {
+		return "", errors.New("accounting system answered 503 Service Unavailable")
+	}
+	e.journals = append(e.journals, journal)
+	return "JE-" + journal.Date, nil
+}
+
+func (e *fakeAccountingExporter) PostPayments(ctx context.Context, batch services.PaymentBatch) (string, error) {
+	if e.down {
+		return "", errors.New("accounting system answered 503 Service Unavailable")
+	}
+	e.payments = append(e.payments, batch)
+	return "PAY-" + batch.Date, nil
+}
+
+// setupAccountingTestApp registers the accounting routes on an in-memory store with
+// one sale yesterday, evaluated on 2025-03-02
+func setupAccountingTestApp(t *testing.T) (*fiber.App, *memory.Store, *AccountingHandler, *fakeAccountingExporter, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	exporter := &fakeAccountingExporter{}
+	cfg := config.AccountingConfig{URL: "https://books.example.com", MaxAttempts: 2, Accounts: config.AccountingAccounts{Cash: "1000", Sales: "4000", VAT: "2100"}}
+	handler := NewAccountingHandler(store.Accounting, store.Sales, exporter, cfg, jwtSecret)
+	handler.Audit = NewChangeRecorder(store.Logs)
+	handler.now = func() time.Time { return time.Date(2025, 3, 2, 3, 0, 0, 0, time.Local) }
+
+	sale := &models.Sale{CustomerID: "customer-1", SoldBy: "user-1", SaleDate: "2025-03-01", TotalPrice: 1120}
+	sale.ApplyTax()
+	_, err := store.Sales.Create(context.Background(), sale)
+	require.NoError(t, err)
+
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, handler, exporter, jwtSecret
+}
+
+func TestAccountingSyncRetriesFailedPostings(t *testing.T) {
+	_, store, handler, exporter, _ := setupAccountingTestApp(t)
+
+	exporter.down = true
+	assert.Error(t, handler.Sync(context.Background(), models.DefaultTenantID))
+	posting, err := store.Accounting.Get(models.AccountingSalesJournal, "2025-03-01")
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountingFailed, posting.Status)
+	assert.Equal(t, 1, posting.Attempts, "a new day is attempted once per run")
+	assert.Contains(t, posting.LastError, "503")
+
+	exporter.down = false
+	require.NoError(t, handler.Sync(context.Background(), models.DefaultTenantID))
+	posting, err = store.Accounting.Get(models.AccountingSalesJournal, "2025-03-01")
+	require.NoError(t, err)
+	assert.Equal(t, models.AccountingPosted, posting.Status)
+	assert.Equal(t, 2, posting.Attempts)
+	assert.Equal(t, "JE-2025-03-01", posting.ExternalID)
+	assert.Equal(t, 1120.0, posting.Amount)
+	require.Len(t, exporter.journals, 1)
+	require.Len(t, exporter.payments, 1)
+	assert.Equal(t, 1120.0, exporter.payments[0].Total())
+
+	require.NoError(t, handler.Sync(context.Background(), models.DefaultTenantID))
+	assert.Len(t, exporter.journals, 1, "posted days are not posted again")
+}
+
+func TestAccountingSyncStopsRetryingAfterMaxAttempts(t *testing.T) {
+	_, store, handler, exporter, _ := setupAccountingTestApp(t)
+	exporter.down = true
+	for range 2 {
+		assert.Error(t, handler.Sync(context.Background(), models.DefaultTenantID))
+	}
+	posting, err := store.Accounting.Get(models.AccountingPayments, "2025-03-01")
+	require.NoError(t, err)
+	assert.Equal(t, 2, posting.Attempts)
+	assert.NoError(t, handler.Sync(context.Background(), models.DefaultTenantID), "nothing is left to retry")
+}
+
+func TestAccountingRoutes(t *testing.T) {
+	app, store, handler, exporter, jwtSecret := setupAccountingTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	exporter.down = true
+	assert.Error(t, handler.Sync(context.Background(), models.DefaultTenantID))
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/admin/accounting/sync-status", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/accounting/sync-status?status=failed", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status api.AccountingSyncStatusResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.True(t, status.Enabled)
+	assert.Len(t, status.Postings, 2)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/accounting/postings/invoices/2025-03-01/retry", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/accounting/postings/sales_journal/2025-03-02/retry", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "today is not over")
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/accounting/postings/sales_journal/2025-03-01/retry", nil)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+
+	exporter.down = false
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/accounting/postings/sales_journal/2025-03-01/retry", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var retried api.AccountingPostingResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&retried))
+	assert.Equal(t, models.AccountingPosted, retried.Posting.Status)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/accounting/postings/sales_journal/2025-03-01/retry", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "RETRY_ACCOUNTING_POSTING", logs[0].Action)
+
+	handler.Exporter = nil
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/accounting/postings/payments/2025-03-01/retry", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -22,25 +24,39 @@ func NewActivityLogHandler(repo repositories.LogsRepositoryInterface) *ActivityL
 	return &ActivityLogHandler{repo: repo}
 }
 
+// Routes registers the activity log routes. Everyone's actions are logged; only
+// admins read them.
+func (h *ActivityLogHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	logGroup := r.Group("/activity-logs", mw.Auth)
+	logGroup.Get("/", mw.Admin, h.GetActivityLogs)               // GET /api/activity-logs
+	logGroup.Get("/filter", mw.Admin, h.GetFilteredActivityLogs) // GET /api/activity-logs/filter
+	logGroup.Get("/:id", mw.Admin, h.GetActivityLogByID)         // GET /api/activity-logs/:id (must come after /filter)
+	logGroup.Post("/", mw.Staff, h.CreateActivityLog)            // POST /api/activity-logs
+}
+
 // GetActivityLogs godoc
 // @Summary Get paginated activity logs
 // @Description Retrieves a paginated list of activity logs, ordered by timestamp descending.
-// @Tags ActivityLogs
+// @Tags Activity Logs
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param page query int false "Page number for pagination." default(1)
 // @Param limit query int false "Number of logs per page." default(10)
-// @Success 200 {object} map[string]interface{} "{\"data\":[]models.ActivityLog, \"total\":int64, \"page\":int, \"last_page\":float64}"
-// @Failure 400 {object} map[string]string "{\"error\": \"Invalid query parameter(s)\"}"
-// @Failure 500 {object} map[string]string "{\"error\": \"Failed to retrieve activity logs\"}"
-// @Router /api/v1/activity-logs [get]
+// @Success 200 {object} api.ActivityLogPageResponse "Page of activity logs"
+// @Failure 400 {object} api.ErrorResponse "Invalid page or limit"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Admins only"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve activity logs"
+// @Router /activity-logs [get]
 func (h *ActivityLogHandler) GetActivityLogs(c *fiber.Ctx) error {
 	pageStr := c.Query("page", "1")
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
 		if pageStr != "1" { // Only return error if user provided an invalid value
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid page parameter. Must be a positive integer.",
+			return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      "Invalid page parameter. Must be a positive integer.",
+				StatusCode: http.StatusBadRequest,
 			})
 		}
 		page = 1
@@ -50,8 +66,9 @@ func (h *ActivityLogHandler) GetActivityLogs(c *fiber.Ctx) error {
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit < 1 {
 		if limitStr != "10" { // Only return error if user provided an invalid value
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid limit parameter. Must be a positive integer.",
+			return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      "Invalid limit parameter. Must be a positive integer.",
+				StatusCode: http.StatusBadRequest,
 			})
 		}
 		limit = 10
@@ -59,25 +76,27 @@ func (h *ActivityLogHandler) GetActivityLogs(c *fiber.Ctx) error {
 
 	logs, total, err := h.repo.GetLogs(page, limit)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve activity logs",
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve activity logs",
+			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"data":      logs,
-		"total":     total,
-		"page":      page,
-		"last_page": math.Ceil(float64(total) / float64(limit)),
+	return c.Status(http.StatusOK).JSON(api.ActivityLogPageResponse{
+		Data:     logs,
+		Total:    total,
+		Page:     page,
+		LastPage: math.Ceil(float64(total) / float64(limit)),
 	})
 }
 
 // GetFilteredActivityLogs godoc
 // @Summary Get filtered and paginated activity logs
 // @Description Retrieves a list of activity logs based on specified filters and pagination, ordered by timestamp descending.
-// @Tags ActivityLogs
+// @Tags Activity Logs
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param page query int false "Page number for pagination." default(1)
 // @Param limit query int false "Number of logs per page." default(10)
 // @Param user query string false "Filter logs by the user who performed the action (case-insensitive, partial match)."
@@ -85,17 +104,20 @@ func (h *ActivityLogHandler) GetActivityLogs(c *fiber.Ctx) error {
 // @Param status query string false "Filter logs by the status of the action (case-insensitive, partial match)."
 // @Param startDate query string false "Filter logs from this date (YYYY-MM-DD). Includes the entire day."
 // @Param endDate query string false "Filter logs up to this date (YYYY-MM-DD). Includes the entire day."
-// @Success 200 {object} map[string]interface{} "{\"data\":[]models.ActivityLog, \"total\":int64, \"page\":int, \"last_page\":float64}"
-// @Failure 400 {object} map[string]string "{\"error\": \"Invalid query parameter(s), e.g., invalid date format\"}"
-// @Failure 500 {object} map[string]string "{\"error\": \"Failed to retrieve filtered activity logs\"}"
-// @Router /api/v1/activity-logs/filter [get]
+// @Success 200 {object} api.ActivityLogPageResponse "Page of matching activity logs"
+// @Failure 400 {object} api.ErrorResponse "Invalid page, limit or date"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Admins only"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve filtered activity logs"
+// @Router /activity-logs/filter [get]
 func (h *ActivityLogHandler) GetFilteredActivityLogs(c *fiber.Ctx) error {
 	pageStr := c.Query("page", "1")
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
 		if pageStr != "1" { // Only return error if user provided an invalid value
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid page parameter. Must be a positive integer.",
+			return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      "Invalid page parameter. Must be a positive integer.",
+				StatusCode: http.StatusBadRequest,
 			})
 		}
 		page = 1
@@ -105,8 +127,9 @@ func (h *ActivityLogHandler) GetFilteredActivityLogs(c *fiber.Ctx) error {
 	limit, err := strconv.Atoi(limitStr)
 	if err != nil || limit < 1 {
 		if limitStr != "10" { // Only return error if user provided an invalid value
-			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid limit parameter. Must be a positive integer.",
+			return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      "Invalid limit parameter. Must be a positive integer.",
+				StatusCode: http.StatusBadRequest,
 			})
 		}
 		limit = 10
@@ -125,8 +148,9 @@ func (h *ActivityLogHandler) GetFilteredActivityLogs(c *fiber.Ctx) error {
 			// Fall back to YYYY-MM-DD format if ISO parsing fails
 			parsedDate, err = time.Parse("2006-01-02", startDateStr)
 			if err != nil {
-				return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-					"error": fmt.Sprintf("Invalid startDate format: %s. Use ISO format or YYYY-MM-DD.", startDateStr),
+				return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+					Error:      fmt.Sprintf("Invalid startDate format: %s. Use ISO format or YYYY-MM-DD.", startDateStr),
+					StatusCode: http.StatusBadRequest,
 				})
 			}
 		}
@@ -141,8 +165,9 @@ func (h *ActivityLogHandler) GetFilteredActivityLogs(c *fiber.Ctx) error {
 			// Fall back to YYYY-MM-DD format if ISO parsing fails
 			parsedDate, err = time.Parse("2006-01-02", endDateStr)
 			if err != nil {
-				return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-					"error": fmt.Sprintf("Invalid endDate format: %s. Use ISO format or YYYY-MM-DD.", endDateStr),
+				return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+					Error:      fmt.Sprintf("Invalid endDate format: %s. Use ISO format or YYYY-MM-DD.", endDateStr),
+					StatusCode: http.StatusBadRequest,
 				})
 			}
 		}
@@ -153,43 +178,82 @@ func (h *ActivityLogHandler) GetFilteredActivityLogs(c *fiber.Ctx) error {
 
 	logs, total, err := h.repo.GetBasedOnFilter(page, limit, user, action, status, startDate, endDate)
 	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve filtered activity logs",
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve filtered activity logs",
+			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"data":      logs,
-		"total":     total,
-		"page":      page,
-		"last_page": math.Ceil(float64(total) / float64(limit)),
+	return c.Status(http.StatusOK).JSON(api.ActivityLogPageResponse{
+		Data:     logs,
+		Total:    total,
+		Page:     page,
+		LastPage: math.Ceil(float64(total) / float64(limit)),
 	})
 }
 
+// GetActivityLogByID godoc
+// @Summary Get a single activity log
+// @Description Retrieves an activity log entry including the before/after values of the fields it changed. Sensitive values are masked.
+// @Tags Activity Logs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Activity log ID"
+// @Success 200 {object} models.ActivityLog "Activity log with field changes"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Admins only"
+// @Failure 404 {object} api.ErrorResponse "Activity log not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve activity log"
+// @Router /activity-logs/{id} [get]
+func (h *ActivityLogHandler) GetActivityLogByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	logEntry, err := h.repo.GetByID(id)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return c.Status(http.StatusNotFound).JSON(api.ErrorResponse{
+				Error:      "Activity log not found",
+				StatusCode: http.StatusNotFound,
+			})
+		}
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve activity log",
+			StatusCode: http.StatusInternalServerError,
+		})
+	}
+
+	return c.Status(http.StatusOK).JSON(logEntry)
+}
+
 // CreateActivityLog godoc
 // @Summary Create a new activity log
 // @Description Adds a new activity log entry to the system.
-// @Tags ActivityLogs
+// @Tags Activity Logs
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param log body models.ActivityLog true "Activity Log data to create. ID, Timestamp, CreatedAt, UpdatedAt are auto-generated."
 // @Success 201 {object} models.ActivityLog "Successfully created activity log"
-// @Failure 400 {object} map[string]string "{"error": "Invalid request payload or missing required fields"}"
-// @Failure 500 {object} map[string]string "{"error": "Failed to create activity log"}"
-// @Router /api/activity-logs [post]
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload or missing required fields"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Staff or admins only"
+// @Failure 500 {object} api.ErrorResponse "Failed to create activity log"
+// @Router /activity-logs [post]
 func (h *ActivityLogHandler) CreateActivityLog(c *fiber.Ctx) error {
 	logEntry := new(models.ActivityLog)
 
 	if err := c.BodyParser(logEntry); err != nil {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request payload: " + err.Error(),
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid request payload: " + err.Error(),
+			StatusCode: http.StatusBadRequest,
 		})
 	}
 
 	// Basic validation (can be expanded based on model requirements)
 	if logEntry.User == "" || logEntry.Action == "" || logEntry.Status == "" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Missing required fields: user, action, status",
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Missing required fields: user, action, status",
+			StatusCode: http.StatusBadRequest,
 		})
 	}
 
@@ -197,8 +261,9 @@ func (h *ActivityLogHandler) CreateActivityLog(c *fiber.Ctx) error {
 	// or by database defaults if applicable.
 
 	if err := h.repo.Create(logEntry); err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create activity log: " + err.Error(),
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to create activity log: " + err.Error(),
+			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"oop/internal/models"
+	"oop/internal/services"
 	"strings"
 	"testing"
 	"time"
@@ -28,6 +29,22 @@ func (m *MockLogsRepository) Create(log *models.ActivityLog) error {
 	return args.Error(0)
 }
 
+func (m *MockLogsRepository) GetByID(id string) (*models.ActivityLog, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ActivityLog), args.Error(1)
+}
+
+func (m *MockLogsRepository) VerifyChain() (*models.AuditChainReport, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AuditChainReport), args.Error(1)
+}
+
 func (m *MockLogsRepository) GetLogs(page, limit int) ([]models.ActivityLog, int64, error) {
 	args := m.Called(page, limit)
 	return args.Get(0).([]models.ActivityLog), args.Get(1).(int64), args.Error(2)
@@ -45,6 +62,7 @@ func setupAppAndHandler(mockRepo *MockLogsRepository) *fiber.App {
 	activityLogRoutes := api.Group("/activity-logs")
 	activityLogRoutes.Get("/", h.GetActivityLogs)
 	activityLogRoutes.Get("/filter", h.GetFilteredActivityLogs)
+	activityLogRoutes.Get("/:id", h.GetActivityLogByID)
 	activityLogRoutes.Post("/", h.CreateActivityLog)
 	return app
 }
@@ -349,3 +367,82 @@ func TestCreateActivityLogHandler(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 }
+
+func TestGetActivityLogByIDHandler(t *testing.T) {
+	t.Run("SuccessfulRetrieval", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app := setupAppAndHandler(mockRepo)
+
+		expectedLog := &models.ActivityLog{
+			ID:         "log-1",
+			User:       "admin-id",
+			Action:     "UPDATE_CUSTOMER",
+			EntityType: AuditEntityCustomer,
+			EntityID:   "cust-1",
+			Changes:    []models.FieldChange{{Field: "phone", Before: "111", After: "222"}},
+		}
+		mockRepo.On("GetByID", "log-1").Return(expectedLog, nil)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/activity-logs/log-1", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.ActivityLog
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Equal(t, expectedLog.Changes, result.Changes)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app := setupAppAndHandler(mockRepo)
+		mockRepo.On("GetByID", "missing").Return(nil, errors.New("activity log with ID missing not found"))
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/activity-logs/missing", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("FilterRouteStillMatches", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app := setupAppAndHandler(mockRepo)
+		mockRepo.On("GetBasedOnFilter", 1, 10, "", "", "", (*time.Time)(nil), (*time.Time)(nil)).Return([]models.ActivityLog{}, int64(0), nil)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/activity-logs/filter", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+	})
+}
+
+func TestChangeRecorder_RecordUpdate(t *testing.T) {
+	app := fiber.New()
+	mockRepo := new(MockLogsRepository)
+	recorder := NewChangeRecorder(mockRepo)
+
+	before := models.Customer{ID: "cust-1", FullName: "Jane", Phone: "111", UpdatedAt: time.Now().Add(-time.Hour)}
+	after := before
+	after.Phone = "222"
+	after.UpdatedAt = time.Now()
+
+	mockRepo.On("Create", mock.MatchedBy(func(l *models.ActivityLog) bool {
+		return l.User == "admin-id" && l.Action == "UPDATE_CUSTOMER" && l.EntityID == "cust-1" &&
+			len(l.Changes) == 1 && l.Changes[0] == models.FieldChange{Field: "phone", Before: services.MaskedValue, After: services.MaskedValue}
+	})).Return(nil).Once()
+
+	app.Put("/customers/:id", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "admin-id")
+		recorder.RecordUpdate(c, AuditEntityCustomer, c.Params("id"), before, after)
+		// Unchanged records are not logged
+		recorder.RecordUpdate(c, AuditEntityCustomer, c.Params("id"), after, after)
+		// A nil recorder is a no-op
+		var disabled *ChangeRecorder
+		disabled.RecordUpdate(c, AuditEntityCustomer, c.Params("id"), before, after)
+		return c.SendStatus(http.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodPut, "/customers/cust-1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mockRepo.AssertExpectations(t)
+}
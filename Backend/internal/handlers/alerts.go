@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"oop/internal/models"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Critical events pushed to every user of a tenant, which chat notifiers can also
+// post to Slack or Telegram
+const (
+	NotificationBigSale  = "big_sale"
+	NotificationStockOut = "stock_out"
+)
+
+// Alerts publishes critical events: sales totalling at least BigSaleThreshold and
+// items whose last unit is gone. A nil *Alerts is valid and publishes nothing, so
+// handlers work without one.
+type Alerts struct {
+	Hub              *services.NotificationHub
+	BigSaleThreshold float64 // 0 disables big sale alerts
+}
+
+// NewAlerts creates a new Alerts instance
+func NewAlerts(hub *services.NotificationHub, bigSaleThreshold float64) *Alerts {
+	return &Alerts{Hub: hub, BigSaleThreshold: bigSaleThreshold}
+}
+
+// Enabled reports whether events are being published. Handlers use it to skip
+// loading the previous state of an item when no alert could be raised.
+func (a *Alerts) Enabled() bool {
+	return a != nil && a.Hub != nil
+}
+
+// SaleRecorded publishes a big sale when the sale totals at least the threshold
+func (a *Alerts) SaleRecorded(c *fiber.Ctx, sale models.Sale) {
+	if !a.Enabled() || a.BigSaleThreshold <= 0 || sale.TotalPrice < a.BigSaleThreshold {
+		return
+	}
+
+	a.Hub.Publish(tenantIDFromCtx(c), models.Notification{Type: NotificationBigSale, Data: models.BigSaleEvent{
+		SaleID: sale.ID, CustomerID: sale.CustomerID, SoldBy: sale.SoldBy,
+		TotalPrice: sale.TotalPrice, Threshold: a.BigSaleThreshold,
+	}})
+}
+
+// StockChanged publishes a stock-out when an item's quantity dropped to zero
+func (a *Alerts) StockChanged(c *fiber.Ctx, itemType string, itemID int, name string, before, after int) {
+	if !a.Enabled() || before <= 0 || after > 0 {
+		return
+	}
+
+	a.Hub.Publish(tenantIDFromCtx(c), models.Notification{Type: NotificationStockOut, Data: models.StockOutEvent{
+		ItemType: itemType, ItemID: itemID, ItemName: name,
+	}})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAlertsTestApp registers the cab, accessory and sale routes on an in-memory
+// store, with alerts pushing to the returned hub and a big sale threshold of 100,000
+func setupAlertsTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.NotificationHub, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	hub := services.NewNotificationHub()
+	alerts := NewAlerts(hub, 100000)
+
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Alerts = alerts
+	accessories := NewAccessoriesHandler(store.Accessories)
+	accessories.Alerts = alerts
+	sales := NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret)
+	sales.Alerts = alerts
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	apiGroup.Put("/cabs/:id", cabs.UpdateCab)
+	apiGroup.Put("/accessories/:id", accessories.UpdateAccessory)
+	sales.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	return app, store, hub, jwtSecret
+}
+
+// receiveNotifications drains the notifications already delivered to a subscriber
+func receiveNotifications(notifications <-chan models.Notification) []models.Notification {
+	received := []models.Notification{}
+	for {
+		select {
+		case notification := <-notifications:
+			received = append(received, notification)
+		default:
+			return received
+		}
+	}
+}
+
+func TestAlertsBigSale(t *testing.T) {
+	app, _, hub, jwtSecret := setupAlertsTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "admin-1")
+	defer unsubscribe()
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/sales", models.Sale{CustomerID: "customer-1", SoldBy: "staff-1", SaleDate: "2025-03-01", TotalPrice: 99999})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, receiveNotifications(notifications), "below the threshold")
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/sales", models.Sale{CustomerID: "customer-1", SoldBy: "staff-1", SaleDate: "2025-03-01", TotalPrice: 100000})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	received := receiveNotifications(notifications)
+	require.Len(t, received, 1, "every user of the tenant is told")
+	assert.Equal(t, NotificationBigSale, received[0].Type)
+	event := received[0].Data.(models.BigSaleEvent)
+	assert.NotEmpty(t, event.SaleID)
+	assert.Equal(t, 100000.0, event.TotalPrice)
+	assert.Equal(t, "staff-1", event.SoldBy)
+}
+
+func TestAlertsStockOut(t *testing.T) {
+	app, store, hub, jwtSecret := setupAlertsTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	cab := addTestCab(t, store)
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "admin-1")
+	defer unsubscribe()
+
+	update := *cab
+	update.Quantity = 1
+	resp := authedRequest(t, app, token, http.MethodPut, "/api/cabs/"+strconv.Itoa(cab.ID), update)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, receiveNotifications(notifications), "still in stock")
+
+	update.Quantity = 0
+	resp = authedRequest(t, app, token, http.MethodPut, "/api/cabs/"+strconv.Itoa(cab.ID), update)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	received := receiveNotifications(notifications)
+	require.Len(t, received, 1)
+	assert.Equal(t, NotificationStockOut, received[0].Type)
+	assert.Equal(t, models.StockOutEvent{ItemType: AuditEntityCab, ItemID: cab.ID, ItemName: "Unit 42"}, received[0].Data)
+
+	resp = authedRequest(t, app, token, http.MethodPut, "/api/cabs/"+strconv.Itoa(cab.ID), update)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, receiveNotifications(notifications), "only when the last unit goes")
+}
+
+func TestAlertsNilIsNoop(t *testing.T) {
+	var alerts *Alerts
+	assert.False(t, alerts.Enabled())
+	alerts.SaleRecorded(nil, models.Sale{TotalPrice: 1e9})
+	alerts.StockChanged(nil, AuditEntityCab, 1, "Unit 42", 1, 0)
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Defaults and limits of the market basket query
+const (
+	defaultBasketPairs    = 20
+	maxBasketPairs        = 100
+	defaultBasketMinSales = 2
+	defaultPivotMonths    = 12 // Months up to the current one covered by a pivot without dates
+)
+
+// AnalyticsHandler serves what-sold-with-what analytics for the sell screen
+type AnalyticsHandler struct {
+	Sales       repositories.SalesRepository
+	Accessories repositories.AccessoryRepository
+	now         func() time.Time
+	jwtSecret   []byte
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler instance
+func NewAnalyticsHandler(sales repositories.SalesRepository, accessories repositories.AccessoryRepository, jwtSecret []byte) *AnalyticsHandler {
+	return &AnalyticsHandler{Sales: sales, Accessories: accessories, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the analytics routes
+func (h *AnalyticsHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	analyticsGroup := r.Group("/analytics", mw.Auth)
+	analyticsGroup.Get("/basket", h.GetBasket) // GET /api/analytics/basket
+	analyticsGroup.Get("/pivot", h.GetPivot)   // GET /api/analytics/pivot
+}
+
+// GetBasket handles listing accessories frequently bought together
+// @Summary Accessories frequently bought together
+// @Description Lists pairs of accessories sold in the same sale. Support is the share of all sales containing both accessories; confidence is the share of sales with accessoryId that also have pairedAccessoryId. Pairs are ordered by confidence, then support. Pass accessoryId with the accessory in the cart to get add-on suggestions for it.
+// @Tags Analytics
+// @Produce json
+// @Security ApiKeyAuth
+// @Param accessoryId query int false "Only pairs starting from this accessory"
+// @Param minSales query int false "Minimum number of sales with both accessories (default 2)"
+// @Param minConfidence query number false "Minimum confidence between 0 and 1 (default 0)"
+// @Param limit query int false "Number of pairs (default 20, max 100)"
+// @Success 200 {object} api.BasketResponse "Accessory pairs"
+// @Failure 400 {object} api.ErrorResponse "Invalid query parameter"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to analyze sales"
+// @Router /analytics/basket [get]
+func (h *AnalyticsHandler) GetBasket(c *fiber.Ctx) error {
+	accessoryID := 0
+	if raw := c.Query("accessoryId"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "accessoryId must be a positive number", StatusCode: fiber.StatusBadRequest})
+		}
+		accessoryID = parsed
+	}
+
+	minSales := defaultBasketMinSales
+	if raw := c.Query("minSales"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "minSales must be a positive number", StatusCode: fiber.StatusBadRequest})
+		}
+		minSales = parsed
+	}
+
+	minConfidence := 0.0
+	if raw := c.Query("minConfidence"); raw != "" {
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "minConfidence must be between 0 and 1", StatusCode: fiber.StatusBadRequest})
+		}
+		minConfidence = parsed
+	}
+
+	limit := defaultBasketPairs
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxBasketPairs {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "limit must be between 1 and 100", StatusCode: fiber.StatusBadRequest})
+		}
+		limit = parsed
+	}
+
+	pairs, err := h.Sales.GetBasketPairs(c.Context())
+	if err != nil {
+		log.Printf("Error getting accessory basket pairs: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to analyze sales", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Filter in place, keeping the repository's order
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		if len(kept) == limit {
+			break
+		}
+		if (accessoryID != 0 && pair.AccessoryID != accessoryID) || pair.SalesTogether < minSales || pair.Confidence < minConfidence {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+
+	// Look up each accessory once; deleted accessories keep an empty name
+	names := make(map[int]string)
+	name := func(id int) string {
+		if cached, ok := names[id]; ok {
+			return cached
+		}
+		names[id] = ""
+		if accessory, err := h.Accessories.GetByID(c.Context(), id); err == nil {
+			names[id] = accessory.Name
+		}
+		return names[id]
+	}
+	for i := range kept {
+		kept[i].AccessoryName = name(kept[i].AccessoryID)
+		kept[i].PairedName = name(kept[i].PairedAccessoryID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.BasketResponse{Pairs: kept, Count: len(kept)})
+}
+
+// GetPivot handles cross-tabulating sales
+// @Summary Sales cross-tab
+// @Description Totals a measure of the items sold between startDate and endDate by two dimensions, as a matrix for a heatmap. Dimensions are make (of the cab or accessory; empty for materials), item_type, month (YYYY-MM), year, sold_by (user ID) and tax_type; measures are revenue (sum of item subtotals), units and sales (number of distinct sales). Rows and columns are sorted ascending and only those with sales are listed.
+// @Tags Analytics
+// @Produce json
+// @Security ApiKeyAuth
+// @Param rows query string true "Row dimension" Enums(make, item_type, month, year, sold_by, tax_type)
+// @Param cols query string true "Column dimension, other than rows" Enums(make, item_type, month, year, sold_by, tax_type)
+// @Param value query string false "Measure (default revenue)" Enums(revenue, units, sales)
+// @Param startDate query string false "First sale date, YYYY-MM-DD (default the first day of the month 11 months ago)"
+// @Param endDate query string false "Last sale date, YYYY-MM-DD (default today)"
+// @Success 200 {object} api.PivotResponse "Cross-tab"
+// @Failure 400 {object} api.ErrorResponse "Invalid query parameter"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to build pivot"
+// @Router /analytics/pivot [get]
+func (h *AnalyticsHandler) GetPivot(c *fiber.Ctx) error {
+	rows, cols, measure := c.Query("rows"), c.Query("cols"), c.Query("value", models.PivotRevenue)
+	dimensions := strings.Join(models.PivotDimensions, ", ")
+	if !slices.Contains(models.PivotDimensions, rows) || !slices.Contains(models.PivotDimensions, cols) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "rows and cols must each be one of " + dimensions, StatusCode: fiber.StatusBadRequest})
+	}
+	if rows == cols {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "rows and cols must be different dimensions", StatusCode: fiber.StatusBadRequest})
+	}
+	if !slices.Contains(models.PivotMeasures, measure) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "value must be one of " + strings.Join(models.PivotMeasures, ", "), StatusCode: fiber.StatusBadRequest})
+	}
+
+	now := h.now()
+	startDate := time.Date(now.Year(), now.Month()-defaultPivotMonths+1, 1, 0, 0, 0, 0, time.Local).Format(saleDateLayout)
+	endDate := now.Format(saleDateLayout)
+	for name, date := range map[string]*string{"startDate": &startDate, "endDate": &endDate} {
+		if raw := c.Query(name); raw != "" {
+			if _, err := time.Parse(saleDateLayout, raw); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: name + " must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+			}
+			*date = raw
+		}
+	}
+	if startDate > endDate {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "startDate must not be after endDate", StatusCode: fiber.StatusBadRequest})
+	}
+
+	cells, err := h.Sales.GetPivot(c.Context(), rows, cols, measure, startDate, endDate)
+	if err != nil {
+		log.Printf("Error building %s by %s pivot of %s: %v", rows, cols, measure, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build pivot", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	response := api.PivotResponse{
+		RowDimension:    rows,
+		ColumnDimension: cols,
+		Measure:         measure,
+		StartDate:       startDate,
+		EndDate:         endDate,
+		Rows:            []string{},
+		Columns:         []string{},
+		Values:          [][]float64{},
+	}
+	rowIndex, colIndex := make(map[string]int), make(map[string]int)
+	for _, cell := range cells {
+		if _, ok := rowIndex[cell.Row]; !ok {
+			rowIndex[cell.Row] = len(response.Rows)
+			response.Rows = append(response.Rows, cell.Row)
+		}
+		if _, ok := colIndex[cell.Col]; !ok {
+			colIndex[cell.Col] = len(response.Columns)
+			response.Columns = append(response.Columns, cell.Col)
+		}
+	}
+	slices.Sort(response.Columns)
+	for i, col := range response.Columns {
+		colIndex[col] = i
+	}
+	for range response.Rows {
+		response.Values = append(response.Values, make([]float64, len(response.Columns)))
+	}
+	for _, cell := range cells {
+		response.Values[rowIndex[cell.Row]][colIndex[cell.Col]] = cell.Value
+		response.Max = max(response.Max, cell.Value)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBasketTestApp registers the analytics routes on an in-memory store holding
+// three sales of a roof rack: two with seat covers and one with an accessory that
+// has since been deleted
+func setupBasketTestApp(t *testing.T) (*fiber.App, string, int, int) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	ctx := context.Background()
+	rackID, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Roof rack", Make: models.MakeGeneric, Quantity: 5, Price: 1500, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+	coversID, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Seat covers", Make: models.MakeGeneric, Quantity: 5, Price: 800, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+
+	for _, accessories := range [][]int{{rackID, coversID}, {rackID, coversID}, {rackID, 999}} {
+		saleID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "staff-1", SaleDate: "2025-03-03", TotalPrice: 2300})
+		require.NoError(t, err)
+		for _, accessoryID := range accessories {
+			_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: saleID, Item: models.IntRef(models.RefAccessory, accessoryID), Quantity: 1})
+			require.NoError(t, err)
+		}
+	}
+
+	app := fiber.New()
+	NewAnalyticsHandler(store.Sales, store.Accessories, jwtSecret).Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID), rackID, coversID
+}
+
+func TestGetBasket(t *testing.T) {
+	app, token, rackID, coversID := setupBasketTestApp(t)
+	rack, covers := strconv.Itoa(rackID), strconv.Itoa(coversID)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // accessoryId>pairedAccessoryId
+	}{
+		{"Default needs two sales together", "", []string{covers + ">" + rack, rack + ">" + covers}},
+		{"From one accessory", "?accessoryId=" + rack + "&minSales=1", []string{rack + ">" + covers, rack + ">999"}},
+		{"Minimum confidence", "?minSales=1&minConfidence=0.9", []string{covers + ">" + rack, "999>" + rack}},
+		{"Limit", "?minSales=1&limit=1", []string{covers + ">" + rack}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := authedRequest(t, app, token, http.MethodGet, "/api/analytics/basket"+tt.query, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var body api.BasketResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			got := []string{}
+			for _, pair := range body.Pairs {
+				got = append(got, strconv.Itoa(pair.AccessoryID)+">"+strconv.Itoa(pair.PairedAccessoryID))
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, len(tt.want), body.Count)
+		})
+	}
+}
+
+func TestGetBasketNames(t *testing.T) {
+	app, token, rackID, _ := setupBasketTestApp(t)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/analytics/basket?minSales=1&accessoryId="+strconv.Itoa(rackID), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body api.BasketResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Pairs, 2)
+
+	assert.Equal(t, "Roof rack", body.Pairs[0].AccessoryName)
+	assert.Equal(t, "Seat covers", body.Pairs[0].PairedName)
+	assert.InDelta(t, 2.0/3, body.Pairs[0].Confidence, 1e-9)
+	assert.InDelta(t, 2.0/3, body.Pairs[0].Support, 1e-9)
+	assert.Empty(t, body.Pairs[1].PairedName) // Deleted accessory
+}
+
+func TestGetBasketInvalidQuery(t *testing.T) {
+	app, token, _, _ := setupBasketTestApp(t)
+
+	for _, query := range []string{"?accessoryId=abc", "?minSales=0", "?minConfidence=1.5", "?limit=101"} {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/analytics/basket"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestGetPivot(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Quantity: 5})
+	require.NoError(t, err)
+	for _, sale := range []struct {
+		date     string
+		subtotal float64
+	}{{"2025-01-15", 500}, {"2025-03-02", 700}, {"2025-03-20", 300}} {
+		saleID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "staff-1", SaleDate: sale.date, TotalPrice: sale.subtotal})
+		require.NoError(t, err)
+		_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: saleID, Item: models.IntRef(models.RefCab, cab.ID), Quantity: 1, Subtotal: sale.subtotal})
+		require.NoError(t, err)
+	}
+	h := NewAnalyticsHandler(store.Sales, store.Accessories, jwtSecret)
+	h.now = func() time.Time { return time.Date(2025, time.March, 31, 12, 0, 0, 0, time.Local) }
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/analytics/pivot?rows=make&cols=month&value=revenue&startDate=2025-02-01", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body api.PivotResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, api.PivotResponse{
+		RowDimension:    "make",
+		ColumnDimension: "month",
+		Measure:         "revenue",
+		StartDate:       "2025-02-01",
+		EndDate:         "2025-03-31",
+		Rows:            []string{"Suzuki"},
+		Columns:         []string{"2025-03"},
+		Values:          [][]float64{{1000}},
+		Max:             1000,
+	}, body)
+
+	// The default range covers the last twelve months
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/analytics/pivot?rows=month&cols=make&value=sales", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "2024-04-01", body.StartDate)
+	assert.Equal(t, []string{"2025-01", "2025-03"}, body.Rows)
+	assert.Equal(t, [][]float64{{1}, {2}}, body.Values)
+
+	for _, query := range []string{"", "?rows=make", "?rows=make&cols=make", "?rows=customer&cols=month", "?rows=make&cols=month&value=profit", "?rows=make&cols=month&startDate=03/01/2025", "?rows=make&cols=month&startDate=2025-04-01&endDate=2025-03-01"} {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/analytics/pivot"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Notification types pushed when announcements change
+const (
+	NotificationAnnouncement        = "announcement"
+	NotificationAnnouncementDeleted = "announcement_deleted"
+)
+
+// maxAnnouncementTitleLength keeps titles short enough for a banner
+const maxAnnouncementTitleLength = 200
+
+// AnnouncementHandler serves announcements that admins broadcast to staff
+type AnnouncementHandler struct {
+	Repo      repositories.AnnouncementRepository
+	Hub       *services.NotificationHub // Optional; without it changes are not pushed
+	Audit     *ChangeRecorder
+	jwtSecret []byte
+}
+
+// NewAnnouncementHandler creates a new AnnouncementHandler instance
+func NewAnnouncementHandler(repo repositories.AnnouncementRepository, hub *services.NotificationHub, jwtSecret []byte) *AnnouncementHandler {
+	return &AnnouncementHandler{Repo: repo, Hub: hub, jwtSecret: jwtSecret}
+}
+
+// Routes registers the announcement routes
+func (h *AnnouncementHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	announcementGroup := r.Group("/announcements", mw.Auth)
+	announcementGroup.Get("/", h.GetAnnouncements)                                 // GET /api/announcements
+	announcementGroup.Post("/", mw.Admin, h.CreateAnnouncement)                    // POST /api/announcements
+	announcementGroup.Put("/:id", mw.Admin, h.UpdateAnnouncement)                  // PUT /api/announcements/:id
+	announcementGroup.Delete("/:id", mw.Admin, h.DeleteAnnouncement)               // DELETE /api/announcements/:id
+	announcementGroup.Post("/:id/ack", h.AcknowledgeAnnouncement)                  // POST /api/announcements/:id/ack
+	announcementGroup.Get("/:id/acknowledgments", mw.Admin, h.GetAnnouncementAcks) // GET /api/announcements/:id/acknowledgments
+}
+
+// requireAdmin only lets admins through
+var requireAdmin = middleware.RequireRoles(RoleAdmin)
+
+// AnnouncementRequest is the body for creating or replacing an announcement
+type AnnouncementRequest struct {
+	Title    string     `json:"title"`
+	Body     string     `json:"body"`
+	Severity string     `json:"severity"`           // info (default), warning or critical
+	StartsAt *time.Time `json:"startsAt,omitempty"` // Defaults to now
+	EndsAt   *time.Time `json:"endsAt,omitempty"`   // Omit to display until deleted
+}
+
+// toAnnouncement validates the request and applies it to an announcement
+func (input AnnouncementRequest) toAnnouncement(announcement *models.Announcement) error {
+	title := strings.TrimSpace(input.Title)
+	if title == "" || strings.TrimSpace(input.Body) == "" {
+		return errors.New("Title and body are required")
+	}
+	if len(title) > maxAnnouncementTitleLength {
+		return fmt.Errorf("Title cannot be longer than %d characters", maxAnnouncementTitleLength)
+	}
+
+	severity := input.Severity
+	if severity == "" {
+		severity = models.AnnouncementSeverityInfo
+	}
+	switch severity {
+	case models.AnnouncementSeverityInfo, models.AnnouncementSeverityWarning, models.AnnouncementSeverityCritical:
+	default:
+		return errors.New("Severity must be info, warning or critical")
+	}
+
+	startsAt := time.Now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	if input.EndsAt != nil && !input.EndsAt.After(startsAt) {
+		return errors.New("endsAt must be after startsAt")
+	}
+
+	announcement.Title = title
+	announcement.Body = input.Body
+	announcement.Severity = severity
+	announcement.StartsAt = startsAt
+	announcement.EndsAt = input.EndsAt
+	return nil
+}
+
+// publish pushes a notification to the tenant's stream
+func (h *AnnouncementHandler) publish(c *fiber.Ctx, notificationType string, data interface{}) {
+	if h.Hub != nil {
+		h.Hub.Publish(tenantIDFromCtx(c), models.Notification{Type: notificationType, Data: data})
+	}
+}
+
+// loadAnnouncement fetches the announcement named by the :id parameter, writing the
+// error response itself when it fails
+func (h *AnnouncementHandler) loadAnnouncement(c *fiber.Ctx) (*models.Announcement, error) {
+	announcement, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Announcement not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting announcement %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve announcement", StatusCode: fiber.StatusInternalServerError})
+	}
+	return announcement, nil
+}
+
+// GetAnnouncements handles listing announcements
+// @Summary List announcements
+// @Description Returns the announcements currently displayed, newest first, with whether the caller acknowledged each. Admins can pass all=true to include scheduled and expired announcements.
+// @Tags Announcements
+// @Produce json
+// @Security ApiKeyAuth
+// @Param all query bool false "Include scheduled and expired announcements (admin only)"
+// @Success 200 {object} api.AnnouncementListResponse "Announcements"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve announcements"
+// @Router /announcements [get]
+func (h *AnnouncementHandler) GetAnnouncements(c *fiber.Ctx) error {
+	var announcements []models.Announcement
+	var err error
+
+	if c.QueryBool("all") {
+		if requestUserRole, _ := c.Locals("role").(string); requestUserRole != RoleAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+		}
+		announcements, err = h.Repo.GetAll()
+	} else {
+		userID, _ := c.Locals("user_id").(string)
+		announcements, err = h.Repo.GetActive(time.Now(), userID)
+	}
+	if err != nil {
+		log.Printf("Error getting announcements: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve announcements", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.AnnouncementListResponse{Announcements: announcements})
+}
+
+// CreateAnnouncement handles posting an announcement
+// @Summary Post an announcement (Admin)
+// @Description Broadcasts an announcement to all staff. It is listed by GET /announcements between startsAt and endsAt and pushed to connected notification streams right away.
+// @Tags Announcements
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param announcement body AnnouncementRequest true "Announcement"
+// @Success 201 {object} api.AnnouncementResponse "Announcement posted"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to create announcement"
+// @Router /announcements [post]
+func (h *AnnouncementHandler) CreateAnnouncement(c *fiber.Ctx) error {
+	var input AnnouncementRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	announcement := &models.Announcement{}
+	if err := input.toAnnouncement(announcement); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	announcement.CreatedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Repo.Create(announcement); err != nil {
+		log.Printf("Error creating announcement: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create announcement", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_ANNOUNCEMENT", AuditEntityAnnouncement, announcement.ID,
+		fmt.Sprintf("Posted %s announcement %q", announcement.Severity, announcement.Title))
+	h.publish(c, NotificationAnnouncement, announcement)
+
+	return c.Status(fiber.StatusCreated).JSON(api.AnnouncementResponse{Message: "Announcement posted", Announcement: announcement})
+}
+
+// UpdateAnnouncement handles editing an announcement
+// @Summary Update an announcement (Admin)
+// @Description Replaces the content and display window of an announcement. Acknowledgments are kept.
+// @Tags Announcements
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Announcement ID"
+// @Param announcement body AnnouncementRequest true "Announcement"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.AnnouncementResponse "Announcement updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Announcement not found"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update announcement"
+// @Router /announcements/{id} [put]
+func (h *AnnouncementHandler) UpdateAnnouncement(c *fiber.Ctx) error {
+	var input AnnouncementRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	announcement, err := h.loadAnnouncement(c)
+	if announcement == nil {
+		return err
+	}
+	if modified, err := rejectIfModified(c, announcement.UpdatedAt); modified {
+		return err
+	}
+	before := *announcement
+
+	if err := input.toAnnouncement(announcement); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	if err := h.Repo.Update(announcement); err != nil {
+		log.Printf("Error updating announcement %s: %v", announcement.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update announcement", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityAnnouncement, announcement.ID, before, *announcement)
+	h.publish(c, NotificationAnnouncement, announcement)
+
+	setLastModified(c, announcement.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.AnnouncementResponse{Message: "Announcement updated", Announcement: announcement})
+}
+
+// DeleteAnnouncement handles removing an announcement
+// @Summary Delete an announcement (Admin)
+// @Tags Announcements
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Announcement ID"
+// @Success 200 {object} api.MessageResponse "Announcement deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Announcement not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete announcement"
+// @Router /announcements/{id} [delete]
+func (h *AnnouncementHandler) DeleteAnnouncement(c *fiber.Ctx) error {
+	announcement, err := h.loadAnnouncement(c)
+	if announcement == nil {
+		return err
+	}
+
+	if err := h.Repo.Delete(announcement.ID); err != nil {
+		log.Printf("Error deleting announcement %s: %v", announcement.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete announcement", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_ANNOUNCEMENT", AuditEntityAnnouncement, announcement.ID,
+		fmt.Sprintf("Deleted announcement %q", announcement.Title))
+	h.publish(c, NotificationAnnouncementDeleted, fiber.Map{"id": announcement.ID})
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Announcement deleted"})
+}
+
+// AcknowledgeAnnouncement handles a user confirming they read an announcement
+// @Summary Acknowledge an announcement
+// @Description Records that the caller read the announcement. Acknowledging again keeps the first time.
+// @Tags Announcements
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Announcement ID"
+// @Success 200 {object} api.MessageResponse "Announcement acknowledged"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Announcement not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to acknowledge announcement"
+// @Router /announcements/{id}/ack [post]
+func (h *AnnouncementHandler) AcknowledgeAnnouncement(c *fiber.Ctx) error {
+	announcement, err := h.loadAnnouncement(c)
+	if announcement == nil {
+		return err
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.Repo.Acknowledge(announcement.ID, userID); err != nil {
+		log.Printf("Error acknowledging announcement %s for user %s: %v", announcement.ID, userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to acknowledge announcement", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Announcement acknowledged"})
+}
+
+// GetAnnouncementAcks handles listing who acknowledged an announcement
+// @Summary List announcement acknowledgments (Admin)
+// @Tags Announcements
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Announcement ID"
+// @Success 200 {object} api.AnnouncementAcksResponse "Acknowledgments, earliest first"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Announcement not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve acknowledgments"
+// @Router /announcements/{id}/acknowledgments [get]
+func (h *AnnouncementHandler) GetAnnouncementAcks(c *fiber.Ctx) error {
+	announcement, err := h.loadAnnouncement(c)
+	if announcement == nil {
+		return err
+	}
+
+	acks, err := h.Repo.GetAcknowledgments(announcement.ID)
+	if err != nil {
+		log.Printf("Error getting acknowledgments of announcement %s: %v", announcement.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve acknowledgments", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.AnnouncementAcksResponse{Acknowledgments: acks, Count: len(acks)})
+}
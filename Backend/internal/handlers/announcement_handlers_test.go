@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAnnouncementTestApp registers the announcement and notification routes on an in-memory store
+func setupAnnouncementTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.NotificationHub, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	hub := services.NewNotificationHub()
+
+	h := NewAnnouncementHandler(store.Announcements, hub, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	h.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	notifications := NewNotificationHandler(hub, jwtSecret)
+	notifications.Heartbeat = 50 * time.Millisecond // Closed streams are noticed on the next write
+	notifications.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	return app, store, hub, jwtSecret
+}
+
+// authedRequest sends a request with a bearer token and a JSON body when input is not nil
+func authedRequest(t *testing.T, app *fiber.App, token, method, path string, input interface{}) *http.Response {
+	payload := []byte{}
+	if input != nil {
+		payload, _ = json.Marshal(input)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCreateAnnouncement(t *testing.T) {
+	app, store, hub, jwtSecret := setupAnnouncementTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribe()
+
+	resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/announcements",
+		AnnouncementRequest{Title: "Inventory count", Body: "The lot closes at noon on Friday."})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created api.AnnouncementResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.NotEmpty(t, created.Announcement.ID)
+	assert.Equal(t, models.AnnouncementSeverityInfo, created.Announcement.Severity)
+	assert.Equal(t, "admin-1", created.Announcement.CreatedBy)
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, NotificationAnnouncement, notification.Type)
+	default:
+		t.Fatal("expected an announcement notification")
+	}
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.NotEmpty(t, logs)
+	assert.Equal(t, "CREATE_ANNOUNCEMENT", logs[0].Action)
+}
+
+func TestCreateAnnouncementValidation(t *testing.T) {
+	app, _, _, jwtSecret := setupAnnouncementTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/announcements",
+		AnnouncementRequest{Title: "Hi", Body: "Hello"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	invalid := map[string]AnnouncementRequest{
+		"missing body":      {Title: "Hi"},
+		"unknown severity":  {Title: "Hi", Body: "Hello", Severity: "urgent"},
+		"ends before start": {Title: "Hi", Body: "Hello", StartsAt: &now, EndsAt: &earlier},
+	}
+	for name, input := range invalid {
+		resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/announcements", input)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+}
+
+func TestGetAnnouncementsDisplayWindow(t *testing.T) {
+	app, store, _, jwtSecret := setupAnnouncementTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	now := time.Now()
+	later := now.Add(time.Hour)
+	past := now.Add(-2 * time.Hour)
+	ended := now.Add(-time.Hour)
+
+	current := &models.Announcement{Title: "Current", Body: "Now", Severity: models.AnnouncementSeverityInfo, StartsAt: now.Add(-time.Minute)}
+	require.NoError(t, store.Announcements.Create(current))
+	require.NoError(t, store.Announcements.Create(&models.Announcement{Title: "Scheduled", Body: "Soon", Severity: models.AnnouncementSeverityInfo, StartsAt: later}))
+	require.NoError(t, store.Announcements.Create(&models.Announcement{Title: "Expired", Body: "Over", Severity: models.AnnouncementSeverityInfo, StartsAt: past, EndsAt: &ended}))
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/announcements", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.AnnouncementListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Announcements, 1)
+	assert.Equal(t, "Current", list.Announcements[0].Title)
+	assert.False(t, list.Announcements[0].Acknowledged)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/announcements?all=true", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/announcements?all=true", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Len(t, list.Announcements, 3)
+}
+
+func TestAcknowledgeAnnouncement(t *testing.T) {
+	app, store, _, jwtSecret := setupAnnouncementTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	announcement := &models.Announcement{Title: "Policy", Body: "Read me", Severity: models.AnnouncementSeverityWarning, StartsAt: time.Now().Add(-time.Minute)}
+	require.NoError(t, store.Announcements.Create(announcement))
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/announcements/missing/ack", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	for i := 0; i < 2; i++ {
+		resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/announcements/"+announcement.ID+"/ack", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/announcements", nil)
+	var list api.AnnouncementListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Announcements, 1)
+	assert.True(t, list.Announcements[0].Acknowledged)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/announcements/"+announcement.ID+"/acknowledgments", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/announcements/"+announcement.ID+"/acknowledgments", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var acks api.AnnouncementAcksResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&acks))
+	assert.Equal(t, 1, acks.Count)
+	assert.Equal(t, "staff-1", acks.Acknowledgments[0].UserID)
+}
+
+func TestNotificationStream(t *testing.T) {
+	app, _, _, jwtSecret := setupAnnouncementTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	// app.Test waits for the whole body, so the stream is read from a real listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+ln.Addr().String()+"/api/notifications/stream", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": connected\n", line)
+
+	post := authedRequest(t, app, adminToken, http.MethodPost, "/api/announcements",
+		AnnouncementRequest{Title: "Closing early", Body: "The office closes at 3pm today."})
+	require.Equal(t, http.StatusCreated, post.StatusCode)
+
+	received := make(chan string, 1)
+	go func() {
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "event: ") {
+				data, _ := reader.ReadString('\n')
+				received <- line + data
+				return
+			}
+		}
+	}()
+
+	select {
+	case event := <-received:
+		assert.Contains(t, event, "event: announcement\n")
+		assert.Contains(t, event, `"title":"Closing early"`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the announcement event")
+	}
+}
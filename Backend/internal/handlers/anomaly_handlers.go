@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditEntityAnomaly is the entity type of anomalies in the activity log
+const AuditEntityAnomaly = "anomaly"
+
+// maxAnomalyNoteLength matches the width of the note column
+const maxAnomalyNoteLength = 500
+
+// stockUpdateActions are the activity log actions of updates that may change an item's quantity
+var stockUpdateActions = []string{
+	"UPDATE_" + strings.ToUpper(AuditEntityCab),
+	"UPDATE_" + strings.ToUpper(AuditEntityAccessory),
+	"UPDATE_" + strings.ToUpper(AuditEntityMaterial),
+}
+
+// AnomalyHandler flags unusual sales and stock movements of a day, such as sales far
+// from an item's usual price, large stock adjustments and sales deleted after hours,
+// and serves the queue in which admins acknowledge or dismiss them
+type AnomalyHandler struct {
+	Repo      repositories.AnomalyRepository
+	Config    config.AnomalyConfig
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewAnomalyHandler creates a new AnomalyHandler instance
+func NewAnomalyHandler(repo repositories.AnomalyRepository, cfg config.AnomalyConfig, jwtSecret []byte) *AnomalyHandler {
+	return &AnomalyHandler{Repo: repo, Config: cfg, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes of the anomaly review queue
+func (h *AnomalyHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	group := r.Group("/anomalies", mw.Auth, mw.Admin)
+	group.Get("/", h.GetAnomalies)                       // GET /api/anomalies
+	group.Post("/scan", h.ScanAnomalies)                 // POST /api/anomalies/scan
+	group.Post("/:id/acknowledge", h.AcknowledgeAnomaly) // POST /api/anomalies/:id/acknowledge
+	group.Post("/:id/dismiss", h.DismissAnomaly)         // POST /api/anomalies/:id/dismiss
+}
+
+// Scan flags the anomalies of the day containing day and adds them to the review
+// queue. It returns the ones an earlier scan had not flagged yet.
+func (h *AnomalyHandler) Scan(day time.Time) ([]models.Anomaly, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 1)
+
+	found, err := h.priceOutliers(start)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := h.Repo.Activity(stockUpdateActions, start, end)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, h.stockAdjustments(adjustments)...)
+	voids, err := h.Repo.Activity([]string{AuditActionDeleteSale}, start, end)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, h.afterHoursVoids(voids)...)
+
+	return h.Repo.Add(found)
+}
+
+// priceOutliers flags the items sold on day at a unit price further from their
+// average over the previous PriceHistoryDays than PriceDeviationPercent. Items sold
+// fewer than MinPriceHistory times before are not compared.
+func (h *AnomalyHandler) priceOutliers(day time.Time) ([]models.Anomaly, error) {
+	date := day.Format(saleDateLayout)
+	prices, err := h.Repo.SoldPrices(day.AddDate(0, 0, -h.Config.PriceHistoryDays).Format(saleDateLayout), date)
+	if err != nil {
+		return nil, err
+	}
+
+	type history struct {
+		total float64
+		count int
+	}
+	usual := make(map[string]*history)
+	for _, price := range prices {
+		if price.SaleDate >= date {
+			continue
+		}
+		key := price.ItemType + " " + price.ItemID
+		if usual[key] == nil {
+			usual[key] = &history{}
+		}
+		usual[key].total += price.UnitPrice
+		usual[key].count++
+	}
+
+	anomalies := []models.Anomaly{}
+	for _, price := range prices {
+		item := usual[price.ItemType+" "+price.ItemID]
+		if price.SaleDate != date || item == nil || item.count < h.Config.MinPriceHistory || item.total <= 0 {
+			continue
+		}
+		average := item.total / float64(item.count)
+		deviation := (price.UnitPrice - average) / average * 100
+		if math.Abs(deviation) <= h.Config.PriceDeviationPercent {
+			continue
+		}
+		direction := "above"
+		if deviation < 0 {
+			direction = "below"
+		}
+		anomalies = append(anomalies, models.Anomaly{
+			Kind:       models.AnomalyPriceOutlier,
+			SourceID:   price.SaleItemID,
+			EntityType: AuditEntitySale,
+			EntityID:   price.SaleID,
+			Details: fmt.Sprintf("%s %s sold at %.2f, %.0f%% %s its average of %.2f over %d earlier sales",
+				price.ItemType, price.ItemID, price.UnitPrice, math.Abs(deviation), direction, average, item.count),
+			OccurredAt: price.SoldAt,
+		})
+	}
+	return anomalies, nil
+}
+
+// stockAdjustments flags the updates that changed an item's quantity by
+// StockAdjustmentUnits or more
+func (h *AnomalyHandler) stockAdjustments(entries []models.ActivityLog) []models.Anomaly {
+	anomalies := []models.Anomaly{}
+	for _, entry := range entries {
+		for _, change := range entry.Changes {
+			before, beforeOK := change.Before.(float64)
+			after, afterOK := change.After.(float64)
+			if change.Field != "quantity" || !beforeOK || !afterOK || math.Abs(after-before) < float64(h.Config.StockAdjustmentUnits) {
+				continue
+			}
+			anomalies = append(anomalies, models.Anomaly{
+				Kind:       models.AnomalyStockAdjustment,
+				SourceID:   entry.ID,
+				EntityType: entry.EntityType,
+				EntityID:   entry.EntityID,
+				Details:    fmt.Sprintf("Quantity of %s %s changed from %.0f to %.0f (%+.0f) by %s", entry.EntityType, entry.EntityID, before, after, after-before, entry.User),
+				OccurredAt: entry.Timestamp,
+			})
+		}
+	}
+	return anomalies
+}
+
+// afterHoursVoids flags the sales deleted outside business hours
+func (h *AnomalyHandler) afterHoursVoids(entries []models.ActivityLog) []models.Anomaly {
+	anomalies := []models.Anomaly{}
+	for _, entry := range entries {
+		at := entry.Timestamp.Local()
+		if at.Hour() >= h.Config.OpenHour && at.Hour() < h.Config.CloseHour {
+			continue
+		}
+		anomalies = append(anomalies, models.Anomaly{
+			Kind:       models.AnomalyAfterHoursVoid,
+			SourceID:   entry.ID,
+			EntityType: AuditEntitySale,
+			EntityID:   entry.EntityID,
+			Details: fmt.Sprintf("Sale %s deleted at %s by %s, outside business hours (%02d:00-%02d:00)",
+				entry.EntityID, at.Format("15:04"), entry.User, h.Config.OpenHour, h.Config.CloseHour),
+			OccurredAt: entry.Timestamp,
+		})
+	}
+	return anomalies
+}
+
+// GetAnomalies handles listing the anomaly review queue
+// @Summary List anomalies (Admin)
+// @Description Lists the unusual sales and stock movements flagged by the nightly scan and the payment reconciliation, most recent first. Pass status=open for the ones waiting for review.
+// @Tags Anomalies
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "open, acknowledged or dismissed"
+// @Param kind query string false "price_outlier, stock_adjustment, after_hours_void, payment_overage or payment_shortfall"
+// @Success 200 {object} api.AnomalyListResponse "Anomalies"
+// @Failure 400 {object} api.ErrorResponse "Invalid status or kind"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve anomalies"
+// @Router /anomalies [get]
+func (h *AnomalyHandler) GetAnomalies(c *fiber.Ctx) error {
+	status, kind := c.Query("status"), c.Query("kind")
+	switch status {
+	case "", models.AnomalyOpen, models.AnomalyAcknowledged, models.AnomalyDismissed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "status must be open, acknowledged or dismissed", StatusCode: fiber.StatusBadRequest})
+	}
+	switch kind {
+	case "", models.AnomalyPriceOutlier, models.AnomalyStockAdjustment, models.AnomalyAfterHoursVoid,
+		models.AnomalyPaymentOverage, models.AnomalyPaymentShortfall:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "kind must be price_outlier, stock_adjustment, after_hours_void, payment_overage or payment_shortfall", StatusCode: fiber.StatusBadRequest})
+	}
+
+	anomalies, err := h.Repo.GetAll(status, kind)
+	if err != nil {
+		log.Printf("Error listing anomalies: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve anomalies", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.AnomalyListResponse{Anomalies: anomalies, Count: len(anomalies)})
+}
+
+// ScanAnomalies handles scanning a day for anomalies at once
+// @Summary Scan a day for anomalies (Admin)
+// @Description Runs the nightly anomaly scan for a day, by default yesterday. Sources flagged by an earlier scan are not flagged again.
+// @Tags Anomalies
+// @Produce json
+// @Security ApiKeyAuth
+// @Param date query string false "Day to scan, YYYY-MM-DD (default yesterday)"
+// @Success 200 {object} api.AnomalyScanResponse "Newly flagged anomalies"
+// @Failure 400 {object} api.ErrorResponse "Invalid date"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to scan for anomalies"
+// @Router /anomalies/scan [post]
+func (h *AnomalyHandler) ScanAnomalies(c *fiber.Ctx) error {
+	day := h.now().AddDate(0, 0, -1)
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		day = parsed
+	}
+
+	found, err := h.Scan(day)
+	if err != nil {
+		log.Printf("Error scanning %s for anomalies: %v", day.Format(saleDateLayout), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to scan for anomalies", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.AnomalyScanResponse{
+		Message:   fmt.Sprintf("Found %d new anomalies", len(found)),
+		Date:      day.Format(saleDateLayout),
+		Anomalies: found,
+		Count:     len(found),
+	})
+}
+
+// AcknowledgeAnomaly handles acknowledging an anomaly
+// @Summary Acknowledge an anomaly (Admin)
+// @Description Marks an open anomaly as looked into and real, taking it out of the open queue.
+// @Tags Anomalies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Anomaly ID"
+// @Param review body api.AnomalyReviewRequest false "Optional note"
+// @Success 200 {object} api.AnomalyResponse "Anomaly acknowledged"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Anomaly not found"
+// @Failure 409 {object} api.ErrorResponse "Already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to review anomaly"
+// @Router /anomalies/{id}/acknowledge [post]
+func (h *AnomalyHandler) AcknowledgeAnomaly(c *fiber.Ctx) error {
+	return h.review(c, models.AnomalyAcknowledged, "ACKNOWLEDGE_ANOMALY", "Anomaly acknowledged")
+}
+
+// DismissAnomaly handles dismissing an anomaly
+// @Summary Dismiss an anomaly (Admin)
+// @Description Marks an open anomaly as a false alarm, taking it out of the open queue.
+// @Tags Anomalies
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Anomaly ID"
+// @Param review body api.AnomalyReviewRequest false "Optional reason"
+// @Success 200 {object} api.AnomalyResponse "Anomaly dismissed"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Anomaly not found"
+// @Failure 409 {object} api.ErrorResponse "Already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to review anomaly"
+// @Router /anomalies/{id}/dismiss [post]
+func (h *AnomalyHandler) DismissAnomaly(c *fiber.Ctx) error {
+	return h.review(c, models.AnomalyDismissed, "DISMISS_ANOMALY", "Anomaly dismissed")
+}
+
+// review acknowledges or dismisses the open anomaly in the path
+func (h *AnomalyHandler) review(c *fiber.Ctx, status, action, message string) error {
+	var input api.AnomalyReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	note := strings.TrimSpace(input.Note)
+	if utf8.RuneCountInString(note) > maxAnomalyNoteLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("note cannot be longer than %d characters", maxAnomalyNoteLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	id := c.Params("id")
+	reviewedBy, _ := c.Locals("user_id").(string)
+	anomaly, err := h.Repo.Review(id, status, reviewedBy, note)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error reviewing anomaly %s: %v", id, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to review anomaly", StatusCode: fiber.StatusInternalServerError})
+		}
+		// Tell an anomaly that does not exist from one that was already reviewed
+		existing, err := h.Repo.GetByID(id)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Anomaly not found", StatusCode: fiber.StatusNotFound})
+		}
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Anomaly was already " + existing.Status, StatusCode: fiber.StatusConflict})
+	}
+
+	h.Audit.RecordAction(c, action, AuditEntityAnomaly, anomaly.ID, fmt.Sprintf("%s: %s", message, anomaly.Details))
+	return c.Status(fiber.StatusOK).JSON(api.AnomalyResponse{Message: message, Anomaly: *anomaly})
+}
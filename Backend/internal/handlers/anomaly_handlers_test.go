@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAnomalyConfig flags prices 50% off the average of at least 3 earlier sales,
+// quantity changes of 20 or more, and sales deleted outside 8:00-18:00
+var testAnomalyConfig = config.AnomalyConfig{
+	ScanHour:              2,
+	PriceDeviationPercent: 50,
+	PriceHistoryDays:      90,
+	MinPriceHistory:       3,
+	StockAdjustmentUnits:  20,
+	OpenHour:              8,
+	CloseHour:             18,
+}
+
+// setupAnomalyTestApp registers the anomaly routes and the cab update route on an
+// in-memory store
+func setupAnomalyTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	audit := NewChangeRecorder(store.Logs)
+
+	anomalies := NewAnomalyHandler(store.Anomalies, testAnomalyConfig, jwtSecret)
+	anomalies.Audit = audit
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Audit = audit
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	anomalies.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Put("/cabs/:id", middleware.JWTMiddleware(jwtSecret), cabs.UpdateCab)
+	return app, store, jwtSecret
+}
+
+func TestScanAnomalies(t *testing.T) {
+	app, store, jwtSecret := setupAnomalyTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	today := time.Now()
+	date := today.Format(saleDateLayout)
+
+	// Three earlier sales of the cab at 1000, then one today at 300
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 50, Price: 1000})
+	require.NoError(t, err)
+	sell := func(saleDate string, price float64) string {
+		saleID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "staff-1", SaleDate: saleDate, TotalPrice: price})
+		require.NoError(t, err)
+		itemID, err := store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: saleID, Item: models.IntRef(models.RefCab, cab.ID), Quantity: 1, UnitPrice: price, Subtotal: price})
+		require.NoError(t, err)
+		return itemID
+	}
+	for days := 10; days > 7; days-- {
+		sell(today.AddDate(0, 0, -days).Format(saleDateLayout), 1000)
+	}
+	outlierItemID := sell(date, 300)
+	sell(date, 1200) // Within 50% of the average
+
+	// The cab's stock is cut from 50 to 5 by hand
+	cab.Quantity = 5
+	resp := authedRequest(t, app, adminToken, http.MethodPut, fmt.Sprintf("/api/cabs/%d", cab.ID), cab)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// One sale is deleted late at night and another during business hours
+	midnight := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+	for _, at := range []time.Time{midnight.Add(23 * time.Hour), midnight.Add(10 * time.Hour)} {
+		require.NoError(t, store.Logs.Create(&models.ActivityLog{User: "staff-2", Action: AuditActionDeleteSale, Status: "SUCCESS", EntityType: AuditEntitySale, EntityID: "sale-" + at.Format("15"), Timestamp: at}))
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/anomalies/scan?date="+date, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var scan api.AnomalyScanResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&scan))
+	require.Equal(t, 3, scan.Count)
+	byKind := make(map[string]models.Anomaly)
+	for _, anomaly := range scan.Anomalies {
+		byKind[anomaly.Kind] = anomaly
+	}
+	assert.Equal(t, outlierItemID, byKind[models.AnomalyPriceOutlier].SourceID)
+	assert.Contains(t, byKind[models.AnomalyPriceOutlier].Details, "70% below its average of 1000.00")
+	assert.Equal(t, strconv.Itoa(cab.ID), byKind[models.AnomalyStockAdjustment].EntityID)
+	assert.Contains(t, byKind[models.AnomalyStockAdjustment].Details, "from 50 to 5 (-45) by admin-1")
+	assert.Equal(t, "sale-23", byKind[models.AnomalyAfterHoursVoid].EntityID)
+
+	// Scanning the day again flags nothing new
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/anomalies/scan?date="+date, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&scan))
+	assert.Equal(t, 0, scan.Count)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/anomalies?status=open&kind=after_hours_void", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.AnomalyListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 1, list.Count)
+}
+
+func TestReviewAnomaly(t *testing.T) {
+	app, store, jwtSecret := setupAnomalyTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	added, err := store.Anomalies.Add([]models.Anomaly{
+		{Kind: models.AnomalyAfterHoursVoid, SourceID: "log-1", EntityType: AuditEntitySale, EntityID: "sale-1", Details: "Sale sale-1 deleted at 23:00", OccurredAt: time.Now()},
+		{Kind: models.AnomalyAfterHoursVoid, SourceID: "log-2", EntityType: AuditEntitySale, EntityID: "sale-2", Details: "Sale sale-2 deleted at 23:30", OccurredAt: time.Now()},
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/anomalies/"+added[0].ID+"/acknowledge", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/anomalies/"+added[0].ID+"/acknowledge", api.AnomalyReviewRequest{Note: "Customer returned the unit"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var reviewed api.AnomalyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviewed))
+	assert.Equal(t, models.AnomalyAcknowledged, reviewed.Anomaly.Status)
+	assert.Equal(t, "admin-1", reviewed.Anomaly.ReviewedBy)
+	assert.Equal(t, "Customer returned the unit", reviewed.Anomaly.Note)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/anomalies/"+added[0].ID+"/dismiss", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "an anomaly is reviewed once")
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/anomalies/missing/dismiss", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/anomalies/"+added[1].ID+"/dismiss", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/anomalies?status=open", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.AnomalyListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 0, list.Count)
+
+	for _, query := range []string{"?status=closed", "?kind=refund"} {
+		resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/anomalies"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import "oop/internal/api"
+
+// APIChangelog lists the changes integrators need to know about, newest first, and
+// the endpoints that are going away. It is compiled into the binary and served at
+// GET /api/meta/changelog. Add an entry with every change to an endpoint's request,
+// response or behavior; when deprecating an endpoint, add it to Deprecations as well
+// and keep serving it until its sunset date.
+var APIChangelog = Changelog{
+	Changes: []api.APIChange{
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/activity-logs"},
+			Summary: "API_<METHOD> entries are no longer recorded for requests without a valid token or to paths that match no route."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/activity-logs"},
+			Summary: "Field changes mask the values of personal fields (fullName, email, phone, address, barangay, latitude, longitude, dateOfBirth) with ***."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/sales", "PUT /api/sales/{id}/status", "POST /api/admin/payment-reconciliation"},
+			Summary: "Drafts are not paid until they are confirmed, when the status request may carry a downPayment; unpaid drafts and cancelled or refunded sales are not flagged as short."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/reports/leaderboard", "GET /api/reports/end-of-day", "GET /api/reports/monthly", "GET /api/reports/tax", "GET /api/reports/revenue", "GET /api/reports/sales-summary", "GET /api/analytics/pivot", "GET /api/analytics/basket"},
+			Summary: "Only confirmed and completed sales are counted; drafts, cancelled and refunded sales are left out of the totals."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/auth/oidc/callback"},
+			Summary: "A staff account is only created when no account has the email; if the account cannot be looked up the login fails with 500."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/users/provision"},
+			Summary: "Roster members with a pending invite are reported with the invite_pending action and no longer reactivated."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/integrations/inbound"},
+			Summary: "Orders take the cabs and accessories ordered out of stock with the sale, in one transaction; an order for more than is in stock is refused with 409."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/reports/possible-duplicate-sales/void"},
+			Summary: "Voiding a duplicate sale puts the cabs and accessories it took out of stock back; cancelled and refunded sales cannot be voided (409)."},
+		{Date: "2026-10-17", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/admin/log-level", "PUT /api/admin/log-level", "DELETE /api/admin/log-level"},
+			Summary: "Admins change the level the server logs at without restarting it; the change reverts to LOG_LEVEL after the duration given."},
+		{Date: "2026-10-17", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/cabs/batch", "POST /api/accessories/batch", "POST /api/materials/batch"},
+			Summary: "Creates, updates and deletes up to 500 cabs, accessories or materials at once; either every operation is applied or none, with a result per operation."},
+		{Date: "2026-10-17", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/admin/workers"},
+			Summary: "Lists the server's background workers with whether each is running, restarting after a crash or stopped."},
+		{Date: "2026-10-17", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/search"},
+			Summary: "Searches cabs, accessories, materials and customers with one term, returning the first matches of each type with their totals."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"GET /health"},
+			Summary: "Reports the database as up or down; while the server cannot reach it the status is degraded and the response is 503."},
+		{Date: "2026-10-17", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/customers/:id/sales"},
+			Summary: "With include=items every sale lists its items, named after the cab, accessory or material sold."},
+		{Date: "2026-10-17", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/sales/:id/refund", "GET /api/sales/:id/refunds"},
+			Summary: "Confirmed and completed sales are refunded item by item, putting the returned cabs and accessories back in stock if the sale took them out of stock; a sale is refunded once all of its items are returned."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/activity-logs", "GET /api/activity-logs/filter", "GET /api/activity-logs/:id", "POST /api/activity-logs", "POST /submit"},
+			Summary: "Errors of the activity log and captcha routes include statusCode like every other error."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"PUT /api/sales/:id/status"},
+			Summary: "Sales move from draft to confirmed to completed, or are cancelled or refunded once confirmed; cancelling or refunding a confirmed or completed sale puts the cabs and accessories it took out of stock back."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/sales", "PUT /api/sales/:id", "GET /api/sales", "GET /api/sales/:id"},
+			Summary: "Sales include their status and may be created as drafts; GET /api/sales filters by status, and completed, cancelled and refunded sales cannot be edited (409)."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/sales", "POST /api/exports"},
+			Summary: "date_from and date_to filter the sales listed, and sales exports and listings with a date that is not YYYY-MM-DD or ends before it starts are rejected with 400."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/sales", "POST /api/cabs/:id/sell", "POST /api/sales/:id/payments", "GET /api/sales", "GET /api/sales/:id", "PUT /api/sales/:id", "GET /api/customers/:id/sales"},
+			Summary: "Sales may be sold on installment with a downPayment, record installments with a payment date, and include their balance."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/sales/:id/receipt"},
+			Summary: "format=pdf renders the receipt as a PDF on A5 or half-letter paper (paper=a5|half-letter) with the unit prices, the VAT and the total, for printing at the counter."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/sales/:id/items", "GET /api/customers/:id/data-export"},
+			Summary: "Sale items include itemRef, the external reference ID of the item sold such as cab:12, next to the ItemType, MultiCabID, AccessoryID and MaterialID fields they still carry."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/admin/numbering-formats", "PUT /api/admin/numbering-formats/:entityType", "DELETE /api/admin/numbering-formats/:entityType", "GET /api/numbering/lookup"},
+			Summary: "Admin-defined display number formats for sales, customers and cabs, such as SALE-{YYYY}-{seq:5}, and looking a record up by its display number."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/sales", "POST /api/cabs/:id/sell", "GET /api/sales", "GET /api/sales/:id", "POST /api/customers", "GET /api/customers", "GET /api/customers/:id", "POST /api/cabs", "GET /api/cabs", "GET /api/cabs/:id"},
+			Summary: "Sales, customers and cabs include their displayNumber, generated when they are created if their entity type has a numbering format."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/insurance-policies", "POST /api/insurance-policies", "GET /api/insurance-policies/:id", "PUT /api/insurance-policies/:id", "DELETE /api/insurance-policies/:id"},
+			Summary: "Insurance policies sold or arranged with a sale, with provider, policy number, coverage, premium and expiry; renewals name the policy they renew."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/reports/insurance-renewals"},
+			Summary: "The insurance policies expiring within the next days days, or lapsed within 30, that were not renewed, with the customer to call (admin and staff)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/registrations", "POST /api/registrations", "GET /api/registrations/:id", "PUT /api/registrations/:id", "PUT /api/registrations/:id/status"},
+			Summary: "LTO registration paperwork per cab unit sold, from pending to submitted to released with the OR/CR and plate numbers, with a due date REGISTRATION_DUE_DAYS after the sale by default."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/reports/overdue-registrations"},
+			Summary: "The LTO registrations not released by their due date, with the days overdue (admin and staff)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/locations", "GET /api/delivery/estimate"},
+			Summary: "The provinces and cities addresses are validated against, and the distance and fee of delivering from DELIVERY_ORIGIN to a customer or a place."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/customers", "PUT /api/customers/:id", "GET /api/customers", "GET /api/customers/:id", "POST /api/exports"},
+			Summary: "Customers have a province, city and barangay validated against the location dataset (400 when unknown), and latitude and longitude when geocoded; customer exports include them."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/customers/:id/consent", "PUT /api/customers/:id/consent"},
+			Summary: "Customers' marketing consent and do-not-contact flags, each stamped with when, how (source) and by whom it was last set."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/customers/upcoming-birthdays", "POST /api/exports", "GET /api/customers/:id/data-export"},
+			Summary: "Upcoming birthdays, their customer_occasions export and notices only include customers who consented to marketing and did not ask not to be contacted; data exports include the consent."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/users/login"},
+			Summary: "Accounts are locked for LOGIN_LOCKOUT_MINUTES after LOGIN_MAX_FAILURES failed sign-ins (423), and addresses after LOGIN_IP_MAX_FAILURES (429), both with Retry-After."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/users/forgot-password", "POST /api/users/reset-password"},
+			Summary: "Emails a one-time password reset link valid for PASSWORD_RESET_TTL_MINUTES, answering 202 alike for unknown emails, and sets a new password with its token, revoking the user's sessions."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/customers/upcoming-birthdays"},
+			Summary: "Customers' birthdays, or registration anniversaries, within the next days days, soonest first, for greeting and promotion campaigns."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/customers", "PUT /api/customers/:id", "GET /api/customers", "GET /api/customers/:id", "POST /api/exports"},
+			Summary: "Customers have an optional dateOfBirth, YYYY-MM-DD and not in the future, masked to its month and day without customers.pii; exports take the customer_occasions type."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/reports/possible-duplicate-sales", "POST /api/reports/possible-duplicate-sales/void"},
+			Summary: "Flags sales for the same customer, day, total and items as possible double entries, and voids one as a duplicate of another, recording the link between them."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/activity-logs", "GET /api/activity-logs/filter"},
+			Summary: "Every POST, PUT, PATCH and DELETE is recorded as API_<METHOD> with its path, user, entity, status code and the body fields it set; refused requests as FAILED."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"DELETE /api/cabs/:id"},
+			Summary: "Deleted cabs are kept in the trash, where admins can restore or purge them, instead of being removed; sales keep referring to them."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/cabs", "GET /api/accessories", "GET /api/materials", "GET /api/admin/trash"},
+			Summary: "Admins can pass include_deleted=true, without filters or paging, to list the deleted records after the others with their deletedAt; the trash lists cabs too (?type=cab)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/cabs/:id/restore", "POST /api/accessories/:id/restore", "POST /api/materials/:id/restore"},
+			Summary: "Restores a deleted cab, accessory or material and returns it; 404 when it is not deleted (admin)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/admin/legacy-imports", "GET /api/admin/legacy-imports/:id", "POST /api/admin/legacy-imports/:kind", "POST /api/admin/legacy-imports/:id/resume"},
+			Summary: "Imports the CSV sheets of the legacy system with a column mapping and a per-row report, in batches that resume where a run stopped (admin)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/sandbox", "POST /api/sandbox/session", "POST /api/sandbox/reset"},
+			Summary: "Training sandbox per tenant, seeded with fixture data: a session token for practicing apart from real records, and a reset to the fixtures (admin)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/admin/shifts", "PUT /api/admin/shifts", "PUT /api/users/:id/shift-branch", "GET /api/admin/shift-overrides", "POST /api/admin/shift-overrides"},
+			Summary: "Shift schedules per role and branch, and time-limited overrides to work outside them (admin)."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/users/login", "POST /api/users/refresh"},
+			Summary: "Users restricted to shifts get 403 when signing in or refreshing outside them, and so do their changes to any endpoint."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/reports/sales-summary"},
+			Summary: "Sales count, revenue and units sold by day, week or month, with the top-selling cabs and accessories (admin and staff)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/users/me/heartbeat", "GET /api/users/online"},
+			Summary: "Heartbeat with an optional branch, and the users seen within the online window by branch (admin)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/materials/import"},
+			Summary: "Imports a CSV material list, inserting and updating materials, with a per-row validation report and a dry_run mode."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/sales/:id/payments", "POST /api/sales/:id/payments"},
+			Summary: "Payments of a sale with the amount paid and balance; payments never exceed the sale total (409)."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/admin/payment-reconciliation"},
+			Summary: "Compares payments with sale totals and flags the mismatches in the anomaly review queue (admin)."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/sales", "POST /api/cabs/:id/sell"},
+			Summary: "New sales are recorded as paid in full in cash by their seller."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"PUT /api/sales/:id"},
+			Summary: "Returns 409 when the total would drop below the payments already recorded."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/anomalies"},
+			Summary: "New kinds payment_overage and payment_shortfall from the payment reconciliation."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/export/inventory"},
+			Summary: "Current stock of cabs, materials or accessories (?type=) streamed as CSV with name, make, quantity, price and status (admin and staff)."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/accessories"},
+			Summary: "Pass page and limit (default 10, max 100) to get one page of the accessories matching make, status, unit_color and search, with page, pageSize, total and totalPages; without them the full list is returned as before."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/customers"},
+			Summary: "Pass page, limit (default 10, max 100) or search, matching names, emails and phone numbers, to get one page of customers with page, pageSize, total and totalPages; without them the full list is returned as before."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/anomalies", "POST /api/anomalies/scan", "POST /api/anomalies/:id/acknowledge", "POST /api/anomalies/:id/dismiss"},
+			Summary: "Review queue of price outliers, large stock adjustments and after-hours sale deletions found by the nightly scan, with acknowledge and dismiss actions (admin only)."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"DELETE /api/sales/:id"},
+			Summary: "Deleting a sale is recorded in the activity log as DELETE_SALE."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/analytics/pivot"},
+			Summary: "Cross-tab of sales by two dimensions, such as make by month, totalling revenue, units or sales, for the dashboard heatmap."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/cabs"},
+			Summary: "Sort with sort_by and sort_dir, and pass limit (max 100) and offset to get one page of cabs as {data, total, limit, offset}; without them the full list is returned as before."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/sales"},
+			Summary: "Pass page and limit (default 10, max 100) to get one page of sales as {data, page, limit, total, total_pages}; without them the full list is returned as before."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/cabs", "PUT /api/cabs/:id", "DELETE /api/cabs/:id", "POST /api/accessories", "PUT /api/accessories/:id", "DELETE /api/accessories/:id"},
+			Summary: "Creating, updating and deleting cabs and accessories requires an admin or staff token."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"/api/sales", "/api/materials", "/api/users", "/api/activity-logs"},
+			Summary: "Routes are restricted by role: admins and staff only, and reading activity logs admins only; other roles get 403."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/exports", "GET /api/exports", "GET /api/exports/:id", "GET /api/exports/:id/download"},
+			Summary: "CSV exports of sales, inventory and customers built in the background, with progress and a signed download link once completed."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/users/refresh", "POST /api/users/logout"},
+			Summary: "Refresh tokens: exchange one for a new access token and refresh token, or revoke its session on logout."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/users/login", "GET /api/auth/oidc/callback"},
+			Summary: "Login also returns a refreshToken and the expiresIn of the access token, whose lifetime is set by ACCESS_TOKEN_TTL_MINUTES."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/files/*"},
+			Summary: "Signed download links of files kept by the local storage driver."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/expenses/:id/attachments/:attachmentId"},
+			Summary: "Attachments kept in file storage are served by redirecting (302) to a short-lived download link."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/cabs/:id/sell"},
+			Summary: "Sold cabs and accessories are taken out of stock, with their status updated, in the sale's transaction; selling more than is in stock returns 409."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/inventory/images/duplicates"},
+			Summary: "Photos whose file is attached to more than one cab, accessory or material, grouped by content hash."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/inventory/:itemType/:itemId/images"},
+			Summary: "Photos carry a contentHash; uploading a file already attached to another item returns a warning and the duplicates."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/inventory/:itemType/:itemId/images", "GET /api/inventory/:itemType/:itemId/images/:imageId/file"},
+			Summary: "Smaller copies of JPEG and PNG photos are made after upload and listed as variants; the file endpoint takes ?size=thumb, small, medium or original."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/inventory/:itemType/:itemId/images", "GET /api/inventory/:itemType/:itemId/images/:imageId/file", "POST /api/inventory/:itemType/:itemId/images",
+			"PUT /api/inventory/:itemType/:itemId/images/order", "POST /api/inventory/:itemType/:itemId/images/:imageId/primary", "DELETE /api/inventory/:itemType/:itemId/images/:imageId"},
+			Summary: "Photo galleries of cabs, accessories and materials, with ordering and a primary photo."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/cabs/:id", "GET /api/accessories/:id", "GET /api/materials/:id"},
+			Summary: "Responses include the item's photo gallery as images."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/inventory/labels"},
+			Summary: "PDF of printable labels with name, price, location and barcode of cabs, accessories and materials, for Letter or A4 label sheets."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/change-requests", "GET /api/change-requests", "GET /api/change-requests/:id", "POST /api/change-requests/:id/apply", "POST /api/change-requests/:id/reject"},
+			Summary: "Proposed edits of cabs, accessories and materials, applied or rejected by an admin or a role with the inventory.write permission other than the requester."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/price-changes", "POST /api/price-changes/:id/approve", "POST /api/price-changes/:id/reject"},
+			Summary: "Price changes larger than PRICE_APPROVAL_THRESHOLD_PERCENT wait for an admin other than the requester to approve or reject them."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"PUT /api/cabs/:id", "PUT /api/accessories/:id"},
+			Summary: "With PRICE_APPROVAL_THRESHOLD_PERCENT set, a larger price change is held for approval: the rest of the update is applied and 202 is returned with X-Price-Change-Id. A second one while it waits fails with 409."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/cabs", "PUT /api/cabs/:id", "POST /api/accessories", "PUT /api/accessories/:id", "POST /api/cabs/:id/sell", "POST /api/integrations/inbound"},
+			Summary: "Cabs and accessories carry optional min_price and max_price. Without the prices.override permission, a price outside them fails with 422 and changing them with 403."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/admin/negative-stock", "POST /api/admin/negative-stock/repair"},
+			Summary: "Lists the cabs, accessories and materials with a quantity below zero, and sets them to zero with an activity log entry each."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/cabs", "PUT /api/cabs/:id", "POST /api/accessories", "PUT /api/accessories/:id", "POST /api/materials", "PUT /api/materials/:id"},
+			Summary: "A negative quantity fails with 409 Conflict."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/admin/integrity-check"},
+			Summary: "Reports orphaned sale items, sale totals that do not match their items, negative stock and sales whose customer is gone. ?fix=true deletes the orphaned sale items."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/meta/changelog"},
+			Summary: "Changelog of the API and the endpoints that are deprecated. Responses of deprecated endpoints carry Deprecation and Sunset headers."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/users/me/calendar", "GET /api/users/me/calendar", "DELETE /api/users/me/calendar", "GET /api/users/me/calendar.ics"},
+			Summary: "iCal feed of the caller's follow-up tasks, for phone calendars."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded,
+			Summary: "big_sale and stock_out events on the notification stream."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"POST /api/integrations/inbound"},
+			Summary: "Signed orders from partner systems are recorded as sales."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"DELETE /api/customers/:id", "DELETE /api/accessories/:id", "DELETE /api/materials/:id"},
+			Summary: "Deleted records are hidden rather than removed. The response carries X-Undo-Token and X-Undo-Expires-At headers for POST /api/undo/:token."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"PUT /api/customers/:id", "PUT /api/cabs/:id", "PUT /api/accessories/:id", "PUT /api/materials/:id"},
+			Summary: "Responses carry Last-Modified. An If-Unmodified-Since header makes the update fail with 412 when the record changed since."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"POST /api/customers", "POST /api/cabs", "POST /api/accessories", "POST /api/materials"},
+			Summary: "A create request repeated within a few seconds gets the first response with an X-Duplicate-Submission: true header instead of creating another record."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged,
+			Summary: "While per-minute quotas are configured, responses carry X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset, and requests over the quota get 429 with Retry-After."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged,
+			Summary: "Session tokens carry a tenant_id claim and are only accepted by their tenant. Tokens issued before belong to the default tenant."},
+		{Date: "2026-10-16", Kind: api.ChangeChanged, Endpoints: []string{"GET /api/customers", "GET /api/customers/:id"},
+			Summary: "Emails and phone numbers are masked for callers without the customers.pii permission."},
+		{Date: "2026-10-16", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/meta/openapi.json", "GET /api/meta/postman"},
+			Summary: "The Swagger document of the running version, and the same as a Postman collection."},
+	},
+	Deprecations: []api.Deprecation{},
+}
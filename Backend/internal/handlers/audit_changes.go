@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Entity types recorded with field-level changes in the activity log
+const (
+	AuditEntityUser         = "user"
+	AuditEntityCustomer     = "customer"
+	AuditEntityMaterial     = "material"
+	AuditEntityCab          = "cab"
+	AuditEntityAccessory    = "accessory"
+	AuditEntitySale         = "sale"
+	AuditEntitySaleItem     = "sale_item"
+	AuditEntityAnnouncement = "announcement"
+	AuditEntityTask         = "task"
+	AuditEntityDocuments    = "document_template"
+	AuditEntityRegister     = "register_session"
+	AuditEntityExpense      = "expense"
+	AuditEntityDeposit      = "bank_deposit"
+	AuditEntityReceipts     = "receipt_series"
+	AuditEntityFiscal       = "fiscal_calendar"
+	AuditEntityIntegration  = "integration"
+	AuditEntityAccounting   = "accounting_posting"
+	AuditEntityRegistration = "cab_registration"
+	AuditEntityInsurance    = "insurance_policy"
+	AuditEntityNumbering    = "number_format"
+)
+
+// ChangeRecorder writes before/after field diffs of updated records to the activity log.
+// A nil *ChangeRecorder is valid and records nothing, so handlers work without one.
+type ChangeRecorder struct {
+	repo repositories.LogsRepositoryInterface
+}
+
+// NewChangeRecorder creates a new ChangeRecorder instance
+func NewChangeRecorder(repo repositories.LogsRepositoryInterface) *ChangeRecorder {
+	return &ChangeRecorder{repo: repo}
+}
+
+// Enabled reports whether changes are being recorded. Handlers use it to skip
+// loading the previous state of a record when nothing would be logged.
+func (r *ChangeRecorder) Enabled() bool {
+	return r != nil && r.repo != nil
+}
+
+// RecordUpdate logs the fields that differ between before and after. Nothing is
+// logged when no field changed. Failures are logged but never fail the request.
+func (r *ChangeRecorder) RecordUpdate(c *fiber.Ctx, entityType, entityID string, before, after interface{}) {
+	if !r.Enabled() {
+		return
+	}
+
+	changes := services.DiffFields(before, after)
+	if len(changes) == 0 {
+		return
+	}
+
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		userID = "unknown"
+	}
+
+	logEntry := &models.ActivityLog{
+		User:       userID,
+		Action:     "UPDATE_" + strings.ToUpper(entityType),
+		Details:    withImpersonation(c, fmt.Sprintf("Updated %s %s: %s", entityType, entityID, strings.Join(fields, ", "))),
+		Status:     "SUCCESS",
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	}
+	if err := r.repo.Create(logEntry); err != nil {
+		log.Printf("Error recording %s %s changes: %v", entityType, entityID, err)
+	}
+}
+
+// RecordAction logs an event on an entity that has no field diff, such as an export.
+// Failures are logged but never fail the request.
+func (r *ChangeRecorder) RecordAction(c *fiber.Ctx, action, entityType, entityID, details string) {
+	r.RecordAttempt(c, action, entityType, entityID, details, true)
+}
+
+// RecordAttempt logs an event like RecordAction, marking it FAILED when it did not
+// succeed, so rejected attempts show up in the log as well.
+func (r *ChangeRecorder) RecordAttempt(c *fiber.Ctx, action, entityType, entityID, details string, succeeded bool) {
+	if !r.Enabled() {
+		return
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		userID = "unknown"
+	}
+
+	status := "SUCCESS"
+	if !succeeded {
+		status = "FAILED"
+	}
+
+	logEntry := &models.ActivityLog{
+		User:       userID,
+		Action:     action,
+		Details:    withImpersonation(c, details),
+		Status:     status,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+	if err := r.repo.Create(logEntry); err != nil {
+		log.Printf("Error recording %s for %s %s: %v", action, entityType, entityID, err)
+	}
+}
+
+// withImpersonation notes the super admin behind an impersonation token, so actions
+// taken while impersonating a tenant admin can be told apart from the admin's own
+func withImpersonation(c *fiber.Ctx, details string) string {
+	if superAdminID, ok := c.Locals("impersonated_by").(string); ok && superAdminID != "" {
+		return fmt.Sprintf("%s (impersonated by super admin %s)", details, superAdminID)
+	}
+	return details
+}
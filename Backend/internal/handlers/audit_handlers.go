@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditHandler exposes compliance checks over the activity log
+type AuditHandler struct {
+	Repo      repositories.LogsRepositoryInterface
+	jwtSecret []byte
+}
+
+// NewAuditHandler creates a new AuditHandler instance
+func NewAuditHandler(repo repositories.LogsRepositoryInterface, jwtSecret []byte) *AuditHandler {
+	return &AuditHandler{Repo: repo, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin audit routes
+func (h *AuditHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	auditGroup := r.Group("/admin/audit", mw.Auth)
+	auditGroup.Get("/verify", h.VerifyAuditChain) // GET /api/admin/audit/verify
+}
+
+// VerifyAuditChain re-validates the activity log hash chain
+// @Summary Verify the audit log chain (Admin)
+// @Description Recomputes the hash of every activity log entry in order and reports the first entry that was tampered with, if any.
+// @Tags Audit
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AuditChainReport "Verification result"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to verify audit log"
+// @Router /admin/audit/verify [get]
+func (h *AuditHandler) VerifyAuditChain(c *fiber.Ctx) error {
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+
+	report, err := h.Repo.VerifyChain()
+	if err != nil {
+		log.Printf("Error verifying audit log chain: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to verify audit log",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	if !report.Valid {
+		log.Printf("Audit log chain verification failed at entry %s: %s", report.FirstTamperedID, report.Reason)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"oop/internal/models"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAuditTestApp(mockRepo *MockLogsRepository) (*fiber.App, []byte) {
+	jwtSecret := []byte("testsecret")
+	app := fiber.New()
+	NewAuditHandler(mockRepo, jwtSecret).Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, jwtSecret
+}
+
+func auditVerifyRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/audit/verify", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestVerifyAuditChainHandler(t *testing.T) {
+	t.Run("Valid chain", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app, secret := setupAuditTestApp(mockRepo)
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		mockRepo.On("VerifyChain").Return(&models.AuditChainReport{Valid: true, CheckedEntries: 3}, nil)
+
+		resp, err := app.Test(auditVerifyRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var report models.AuditChainReport
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		assert.True(t, report.Valid)
+		assert.Equal(t, 3, report.CheckedEntries)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Tampered chain", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app, secret := setupAuditTestApp(mockRepo)
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		mockRepo.On("VerifyChain").Return(&models.AuditChainReport{Valid: false, CheckedEntries: 2, FirstTamperedID: "log-2", Reason: "entry contents do not match its hash"}, nil)
+
+		resp, err := app.Test(auditVerifyRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var report models.AuditChainReport
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		assert.False(t, report.Valid)
+		assert.Equal(t, "log-2", report.FirstTamperedID)
+	})
+
+	t.Run("Forbidden for staff", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app, secret := setupAuditTestApp(mockRepo)
+		token, _ := createCustomerTestToken(secret, "staff-id", RoleStaff)
+
+		resp, err := app.Test(auditVerifyRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		mockRepo.AssertNotCalled(t, "VerifyChain")
+	})
+
+	t.Run("Requires authentication", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app, _ := setupAuditTestApp(mockRepo)
+
+		resp, err := app.Test(auditVerifyRequest(""))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo := new(MockLogsRepository)
+		app, secret := setupAuditTestApp(mockRepo)
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		mockRepo.On("VerifyChain").Return(nil, errors.New("db error"))
+
+		resp, err := app.Test(auditVerifyRequest(token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditTrailSkippedPaths are changes that are not worth an entry of their own: activity
+// logs posted by clients are entries already, and heartbeats change nothing.
+var auditTrailSkippedPaths = map[string]bool{
+	"/api/activity-logs":      true,
+	"/api/users/me/heartbeat": true,
+}
+
+// AuditTrail records every request of a signed-in user that changes data in the
+// activity log, whether or not its handler records it too, so the trail does not
+// depend on clients posting logs. Entries hold the method, path, user, entity and status code, with the fields
+// the request body set; never their values, which may be passwords or personal data.
+type AuditTrail struct {
+	repo      repositories.LogsRepositoryInterface
+	jwtSecret []byte
+}
+
+// NewAuditTrail creates a new AuditTrail instance
+func NewAuditTrail(repo repositories.LogsRepositoryInterface, jwtSecret []byte) *AuditTrail {
+	return &AuditTrail{repo: repo, jwtSecret: jwtSecret}
+}
+
+// Record is middleware writing an API_<METHOD> entry for each POST, PUT, PATCH and
+// DELETE once it is answered, FAILED when it was answered with an error status.
+// Requests that matched no route, or were made without a valid token, are not
+// recorded: anybody could flood the log with them.
+func (a *AuditTrail) Record(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return c.Next()
+	}
+	if auditTrailSkippedPaths[strings.TrimSuffix(c.Path(), "/")] {
+		return c.Next()
+	}
+
+	err := c.Next()
+	status := c.Response().StatusCode()
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		// The router fails paths that match no route with these; handlers answer them themselves
+		if fiberErr.Code == fiber.StatusNotFound || fiberErr.Code == fiber.StatusMethodNotAllowed {
+			return err
+		}
+	}
+	if err != nil {
+		// The error handler sets the status after the middleware returns
+		status = fiber.StatusInternalServerError
+		if fiberErr != nil {
+			status = fiberErr.Code
+		}
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		userID = middleware.BearerUserID(c, a.jwtSecret)
+	}
+	if userID != "" {
+		a.record(c, userID, status)
+	}
+	return err
+}
+
+// record writes the entry of an answered request. Failures are logged but never fail
+// the request.
+func (a *AuditTrail) record(c *fiber.Ctx, userID string, status int) {
+	entityType, entityID := auditedEntity(c)
+
+	details := fmt.Sprintf("%s %s answered %d", c.Method(), c.Path(), status)
+	if fields := bodyFields(c); len(fields) > 0 {
+		details += "; fields: " + strings.Join(fields, ", ")
+	}
+	outcome := "SUCCESS"
+	if status >= fiber.StatusBadRequest {
+		outcome = "FAILED"
+	}
+
+	logEntry := &models.ActivityLog{
+		User:       strings.Clone(userID),
+		Action:     "API_" + c.Method(),
+		Details:    withImpersonation(c, details),
+		Status:     outcome,
+		EntityType: entityType,
+		EntityID:   entityID,
+	}
+	if err := a.repo.Create(logEntry); err != nil {
+		log.Printf("Error recording %s %s in the audit trail: %v", c.Method(), c.Path(), err)
+	}
+}
+
+// auditedEntity returns the entity a request is about, the first segment of its path
+// after /api (and /admin), and the ID of the record when the route has one. The
+// values are copied, as Fiber reuses the memory of a request once it is answered.
+func auditedEntity(c *fiber.Ctx) (string, string) {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(c.Path(), "/api"), "/"), "/")
+	if len(segments) > 1 && segments[0] == "admin" {
+		segments = segments[1:]
+	}
+
+	entityID := ""
+	for _, param := range c.Route().Params {
+		if param == "id" {
+			entityID = strings.Clone(c.Params("id"))
+		}
+	}
+	return strings.Clone(segments[0]), entityID
+}
+
+// bodyFields returns the sorted top-level fields of a JSON object body; other bodies,
+// such as file uploads, have none
+func bodyFields(c *fiber.Ctx) []string {
+	if !strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationJSON) {
+		return nil
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return nil
+	}
+	fields := make([]string, 0, len(body))
+	for field := range body {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
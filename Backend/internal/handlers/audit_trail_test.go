@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditTrailRecordsChanges(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	cabs := NewCabsHandlers(store.Cabs)
+	auth := middleware.JWTMiddleware(jwtSecret)
+
+	app := fiber.New()
+	app.Use(NewAuditTrail(store.Logs, jwtSecret).Record)
+	app.Get("/api/cabs", cabs.GetCabs)
+	app.Post("/api/cabs", auth, cabs.AddCab)
+	app.Delete("/api/cabs/:id", auth, requireAdmin, cabs.DeleteCab)
+	app.Post("/api/users/me/heartbeat", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/cabs", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/users/me/heartbeat", nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	logs, total, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total, "reads and heartbeats are not recorded")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/cabs", map[string]interface{}{
+		"name": "Scrum Wagon", "make": "Mazda", "unit_color": "White", "quantity": 2, "price": 185000, "status": "In Stock",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodDelete, "/api/cabs/1", nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodDelete, "/api/cabs/1", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/no-such-route", map[string]interface{}{"name": "x"})
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	logs, total, err = store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, total, "requests without a token and to unknown paths are not recorded")
+
+	created := logs[1]
+	assert.Equal(t, "API_POST", created.Action)
+	assert.Equal(t, "staff-1", created.User)
+	assert.Equal(t, "SUCCESS", created.Status)
+	assert.Equal(t, "cabs", created.EntityType)
+	assert.Equal(t, "POST /api/cabs answered 201; fields: make, name, price, quantity, status, unit_color", created.Details)
+
+	refused := logs[0]
+	assert.Equal(t, "API_DELETE", refused.Action)
+	assert.Equal(t, "staff-1", refused.User)
+	assert.Equal(t, "FAILED", refused.Status)
+	assert.Equal(t, strconv.Itoa(1), refused.EntityID)
+	assert.Equal(t, "DELETE /api/cabs/1 answered 403", refused.Details)
+}
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
 	"strings"
 	"oop/internal/config"
 
@@ -14,7 +20,17 @@ import (
 
 // CabsHandlers struct holds dependencies specifically for cab-related handlers.
 type CabsHandlers struct {
-	Repo repositories.CabsRepository
+	Repo        repositories.CabsRepository
+	Audit       *ChangeRecorder           // Optional; records field-level changes to the activity log
+	Watch       *Watchlist                // Optional; notifies users who starred a cab when it changes
+	Views       *RecentViews              // Optional; records the cab in the caller's recently viewed list
+	Marketplace *services.MarketplaceSync // Optional; pushes the cab's availability and price to the marketplace
+	Alerts      *Alerts                   // Optional; publishes the cab running out of stock
+	Perms       *Permissions              // Optional; without it only admins may price cabs outside their price guard
+	Approvals   *PriceChangeHandler       // Optional; holds large price changes for approval
+	Gallery     *ItemImageHandler         // Optional; adds the photo gallery to the cab's details
+	Trash       *TrashHandler             // Optional; records who deleted the cab
+	Numbers     *DisplayNumbers           // Optional; gives new cabs a display number
 }
 
 // NewCabsHandlers creates a new CabsHandlers struct.
@@ -22,9 +38,23 @@ func NewCabsHandlers(repo repositories.CabsRepository) *CabsHandlers {
 	return &CabsHandlers{Repo: repo}
 }
 
+// Routes registers the cab routes. Listing and viewing stay public; changes need a
+// staff or admin token.
+func (h *CabsHandlers) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/cabs", mw.IncludeDeleted, h.GetCabs)                 // GET /api/cabs
+	r.Get("/cabs/:id", h.GetCabByID)                             // GET /api/cabs/:id
+	r.Post("/cabs", mw.Auth, mw.Staff, mw.Dedupe, h.AddCab)      // POST /api/cabs
+	r.Post("/cabs/batch", mw.Auth, mw.Staff, h.BatchCabs)        // POST /api/cabs/batch
+	r.Put("/cabs/:id", mw.Auth, mw.Staff, h.UpdateCab)           // PUT /api/cabs/:id
+	r.Delete("/cabs/:id", mw.Auth, mw.Staff, h.DeleteCab)        // DELETE /api/cabs/:id
+	r.Post("/cabs/:id/restore", mw.Auth, mw.Admin, h.RestoreCab) // POST /api/cabs/:id/restore
+}
+
 // GetCabs handles requests to retrieve a list of cabs, applying filters.
 // @Summary Get all cabs
 // @Description Get a list of all cabs, with optional filtering by make, status, unit color, or a general search term.
+// @Description When limit or offset is set, returns an api.CabPageResponse with the total count instead of a plain array.
+// @Description Admins can add include_deleted=true, on its own, to list the deleted cabs after the others.
 // @Tags Cabs
 // @Accept json
 // @Produce json
@@ -32,37 +62,112 @@ func NewCabsHandlers(repo repositories.CabsRepository) *CabsHandlers {
 // @Param status query string false "Filter by status (e.g., Available, Maintenance)"
 // @Param unit_color query string false "Filter by unit color (e.g., Red)"
 // @Param search query string false "General search term for various fields"
+// @Param sort_by query string false "Field to sort by; newest first when omitted" Enums(id, name, make, quantity, price, status, unit_color, created_at, updated_at)
+// @Param sort_dir query string false "Sort direction, asc by default" Enums(asc, desc)
+// @Param limit query int false "Maximum number of cabs to return (max 100); returns a page with the total when set"
+// @Param offset query int false "Number of cabs to skip; returns a page with the total when set"
+// @Param include_deleted query bool false "Also list the deleted cabs, with their deletedAt (admins only)"
 // @Success 200 {array} models.MultiCab "Successfully retrieved list of cabs"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve cabs"
+// @Failure 400 {object} api.ErrorResponse "Invalid sort or paging parameters"
+// @Failure 403 {object} api.ErrorResponse "Only admins can list deleted cabs"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve cabs"
 // @Router /cabs [get]
 func (h *CabsHandlers) GetCabs(c *fiber.Ctx) error {
-	// Extract query parameters for filtering
-	filters := make(map[string]interface{})
-	if makeFilter := c.Query("make"); makeFilter != "" {
-		filters["make"] = makeFilter
+	if includeDeleted(c) {
+		return h.getCabsWithDeleted(c)
 	}
-	if statusFilter := c.Query("status"); statusFilter != "" {
-		filters["status"] = statusFilter
+
+	filter := models.CabFilter{
+		Make:      c.Query("make"),
+		Status:    c.Query("status"),
+		UnitColor: c.Query("unit_color"),
+		Search:    c.Query("search"),
+		SortBy:    c.Query("sort_by"),
+		SortDir:   strings.ToLower(c.Query("sort_dir")),
 	}
-	if unitColorFilter := c.Query("unit_color"); unitColorFilter != "" {
-		filters["unit_color"] = unitColorFilter
+	if err := filter.Validate(); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      err.Error(),
+			StatusCode: http.StatusBadRequest,
+		})
 	}
-	if searchFilter := c.Query("search"); searchFilter != "" {
-		filters["search"] = searchFilter
+
+	// Paging is opt-in so existing clients keep getting the full list
+	paged := c.Query("limit") != "" || c.Query("offset") != ""
+	limit, err := queryCount(c, "limit", maxPageLimit)
+	if err != nil || limit == 0 {
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "limit must be a positive number",
+			StatusCode: http.StatusBadRequest,
+		})
+	}
+	offset, err := queryCount(c, "offset", 0)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "offset must be zero or a positive number",
+			StatusCode: http.StatusBadRequest,
+		})
+	}
+	if paged {
+		filter.Limit = min(limit, maxPageLimit)
+		filter.Offset = offset
 	}
 
 	// Call repository to get cabs with filters
-	cabs, err := h.Repo.GetCabs(filters)
+	cabs, err := h.Repo.GetCabs(c.Context(), filter)
 	if err != nil {
 		// Log the error internally
 		fmt.Printf("Error fetching cabs: %v\n", err) // Replace with proper logging
-		return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to retrieve cabs",
 			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
+	if paged {
+		total, err := h.Repo.CountCabs(c.Context(), filter)
+		if err != nil {
+			log.Printf("Error counting cabs: %v", err)
+			return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Failed to retrieve cabs",
+				StatusCode: http.StatusInternalServerError,
+			})
+		}
+		if cabs == nil {
+			cabs = []models.MultiCab{}
+		}
+		h.Numbers.numberCabs(cabs)
+		return c.Status(http.StatusOK).JSON(api.CabPageResponse{
+			Data:   cabs,
+			Total:  total,
+			Limit:  filter.Limit,
+			Offset: offset,
+		})
+	}
+
 	// Return the list of cabs as JSON
+	h.Numbers.numberCabs(cabs)
+	return c.Status(http.StatusOK).JSON(cabs)
+}
+
+// getCabsWithDeleted lists every cab, the deleted ones last
+func (h *CabsHandlers) getCabsWithDeleted(c *fiber.Ctx) error {
+	cabs, err := h.Repo.GetCabs(c.Context(), models.CabFilter{})
+	if err == nil {
+		var deleted []models.MultiCab
+		deleted, err = h.Repo.GetDeletedCabs(c.Context())
+		cabs = append(cabs, deleted...)
+	}
+	if err != nil {
+		log.Printf("Error fetching cabs with the deleted ones: %v", err)
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve cabs",
+			StatusCode: http.StatusInternalServerError,
+		})
+	}
+	if cabs == nil {
+		cabs = []models.MultiCab{}
+	}
 	return c.Status(http.StatusOK).JSON(cabs)
 }
 
@@ -74,53 +179,61 @@ func (h *CabsHandlers) GetCabs(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path int true "Cab ID"
 // @Success 200 {object} models.MultiCab "Successfully retrieved cab"
-// @Failure 400 {object} ErrorResponse "Invalid ID format. ID must be an integer."
-// @Failure 404 {object} ErrorResponse "Cab not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve cab"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format. ID must be an integer."
+// @Failure 404 {object} api.ErrorResponse "Cab not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve cab"
 // @Router /cabs/{id} [get]
 func (h *CabsHandlers) GetCabByID(c *fiber.Ctx) error {
 	// Parse ID from URL path parameter
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid ID format. ID must be an integer.",
 			StatusCode: http.StatusBadRequest,
 		})
 	}
 
 	// Call repository to get cab by ID
-	cab, err := h.Repo.GetCabByID(id)
+	cab, err := h.Repo.GetCabByID(c.Context(), id)
 	if err != nil {
 		// Check if the error is 'not found'
 		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+			return c.Status(http.StatusNotFound).JSON(api.ErrorResponse{
 				Error:      fmt.Sprintf("Cab with ID %d not found", id),
 				StatusCode: http.StatusNotFound,
 			})
 		}
 		// Handle other potential repository errors
 		fmt.Printf("Error fetching cab by ID %d: %v\n", id, err) // Replace with proper logging
-		return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to retrieve cab",
 			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
+	h.Views.Viewed(c, models.ViewEntityCab, strconv.Itoa(id))
+	cab.Images = h.Gallery.Images(models.InventoryCab, id)
+	cab.DisplayNumber = h.Numbers.Number(models.NumberedCab, strconv.Itoa(id))
+
 	// Return the cab as JSON
+	setLastModified(c, cab.UpdatedAt)
 	return c.Status(http.StatusOK).JSON(cab)
 }
 
 // AddCab handles requests to add a new cab to the inventory.
 // @Summary Add a new cab
-// @Description Add a new cab to the inventory.
+// @Description Add a new cab to the inventory. Setting min_price or max_price, the range its price must stay within, requires the prices.override permission.
 // @Tags Cabs
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param cab body models.MultiCab true "Cab object to add. ID is auto-generated and should be omitted."
 // @Success 201 {object} models.MultiCab "Cab added successfully"
-// @Failure 400 {object} ErrorResponse "Invalid JSON format or failed to parse request body"
-// @Failure 422 {object} ErrorResponse "Missing required fields or validation error"
-// @Failure 500 {object} ErrorResponse "Failed to add new cab"
+// @Failure 400 {object} api.ErrorResponse "Invalid JSON format or failed to parse request body"
+// @Failure 403 {object} api.ErrorResponse "Setting a price guard requires the prices.override permission"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero"
+// @Failure 422 {object} api.ErrorResponse "Missing required fields, validation error or price outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to add new cab"
 // @Router /cabs [post]
 func (h *CabsHandlers) AddCab(c *fiber.Ctx) error {
 	var cab models.MultiCab
@@ -129,13 +242,13 @@ func (h *CabsHandlers) AddCab(c *fiber.Ctx) error {
 	if err := c.BodyParser(&cab); err != nil {
 		// Check for specific JSON parsing errors
 		if _, ok := err.(*json.SyntaxError); ok || err == fiber.ErrUnprocessableEntity {
-			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+			return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 				Error:      "Invalid JSON format in request body",
 				StatusCode: http.StatusBadRequest,
 			})
 		}
 		// Handle other potential BodyParser errors
-		return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      fmt.Sprintf("Failed to parse request body: %v", err),
 			StatusCode: http.StatusBadRequest,
 		})
@@ -143,59 +256,77 @@ func (h *CabsHandlers) AddCab(c *fiber.Ctx) error {
 
 	// Perform basic validation (consider using a validation library for complex cases)
 	if cab.Name == "" || cab.Make == "" || cab.UnitColor == "" || cab.Status == "" {
-		return c.Status(http.StatusUnprocessableEntity).JSON(ErrorResponse{
+		return c.Status(http.StatusUnprocessableEntity).JSON(api.ErrorResponse{
 			Error:      "Missing required fields. Required: name, make, unit_color, status",
 			StatusCode: http.StatusUnprocessableEntity,
 		})
 	}
 	// ID should not be provided by the client for Add operations
 	cab.ID = 0
-	
+
+	cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
+	if rejected, err := rejectPriceChange(c, h.Perms, nil, cabPricing(cab)); rejected {
+		return err
+	}
+
 	// Handle empty or null image with default image URL
 	if cab.Image == "null" || cab.Image == "" {
 		cab.Image = config.DefaultImageURL
 	}
 
 	// Call repository to add the new cab
-	addedCab, err := h.Repo.AddCab(cab)
+	addedCab, err := h.Repo.AddCab(c.Context(), cab)
 	if err != nil {
+		if conflict, err := rejectNegativeStock(c, err); conflict {
+			return err
+		}
 		// Check for specific repository errors (e.g., validation error from repo)
 		if strings.Contains(err.Error(), "cannot be empty") { // Example check
-			return c.Status(http.StatusUnprocessableEntity).JSON(ErrorResponse{
+			return c.Status(http.StatusUnprocessableEntity).JSON(api.ErrorResponse{
 				Error:      err.Error(),
 				StatusCode: http.StatusUnprocessableEntity,
 			})
 		}
 		// Handle other potential repository errors
 		fmt.Printf("Error adding cab: %v\n", err) // Replace with proper logging
-		return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to add new cab",
 			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.CabListing(*addedCab))
+	addedCab.DisplayNumber = h.Numbers.Assign(c, models.NumberedCab, strconv.Itoa(addedCab.ID))
+
 	// Return the newly added cab with generated ID and timestamps
 	return c.Status(http.StatusCreated).JSON(addedCab)
 }
 
 // UpdateCab handles requests to update an existing cab.
 // @Summary Update an existing cab
-// @Description Update an existing cab by its ID. The ID in the path is authoritative.
+// @Description Update an existing cab by its ID. The ID in the path is authoritative. Leaving out min_price or max_price keeps the current bound and 0 removes it; changing them requires the prices.override permission, as does a new price outside them. A price change by more than PRICE_APPROVAL_THRESHOLD_PERCENT is held for an admin to approve: the rest of the update is applied and 202 is returned with the cab at its old price.
 // @Tags Cabs
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path int true "Cab ID"
 // @Param cab_update body models.MultiCab true "Cab object with updated fields. ID in body is ignored."
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
 // @Success 200 {object} models.MultiCab "Cab updated successfully"
-// @Failure 400 {object} ErrorResponse "Invalid ID format or invalid JSON format/parsing error"
-// @Failure 404 {object} ErrorResponse "Cab not found for update"
-// @Failure 500 {object} ErrorResponse "Failed to update cab"
+// @Success 202 {object} models.MultiCab "Cab updated; its price change is held for approval, with the ID of the change in X-Price-Change-Id"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format or invalid JSON format/parsing error"
+// @Failure 403 {object} api.ErrorResponse "Changing the price guard requires the prices.override permission"
+// @Failure 404 {object} api.ErrorResponse "Cab not found for update"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero, or a price change is already waiting for approval"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 422 {object} api.ErrorResponse "Price outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to update cab"
 // @Router /cabs/{id} [put]
 func (h *CabsHandlers) UpdateCab(c *fiber.Ctx) error {
 	// Parse ID from URL path parameter
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid ID format. ID must be an integer.",
 			StatusCode: http.StatusBadRequest,
 		})
@@ -206,13 +337,13 @@ func (h *CabsHandlers) UpdateCab(c *fiber.Ctx) error {
 	if err := c.BodyParser(&updatedCabData); err != nil {
 		// Check for specific JSON parsing errors
 		if _, ok := err.(*json.SyntaxError); ok || err == fiber.ErrUnprocessableEntity {
-			return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+			return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 				Error:      "Invalid JSON format in request body",
 				StatusCode: http.StatusBadRequest,
 			})
 		}
 		// Handle other potential BodyParser errors
-		return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      fmt.Sprintf("Failed to parse request body: %v", err),
 			StatusCode: http.StatusBadRequest,
 		})
@@ -220,74 +351,326 @@ func (h *CabsHandlers) UpdateCab(c *fiber.Ctx) error {
 
 	// ID from payload is usually ignored in PUT, path parameter 'id' is authoritative
 	updatedCabData.ID = id
-	
+
 	// Handle empty or null image with default image URL
 	if updatedCabData.Image == "null" || updatedCabData.Image == "" {
 		updatedCabData.Image = config.DefaultImageURL
 	}
 
+	// Capture the previous state for conditional updates, the price guard, the activity log diff, watchlist notifications and stock-out alerts
+	before, err := h.Repo.GetCabByID(c.Context(), id)
+	if err != nil {
+		log.Printf("Error fetching cab ID %d before update: %v", id, err)
+	} else if modified, err := rejectIfModified(c, before.UpdatedAt); modified {
+		return err
+	}
+
+	var beforePricing *pricedItem
+	if before != nil {
+		updatedCabData.MinPrice = mergePriceBound(before.MinPrice, updatedCabData.MinPrice)
+		updatedCabData.MaxPrice = mergePriceBound(before.MaxPrice, updatedCabData.MaxPrice)
+		pricing := cabPricing(*before)
+		beforePricing = &pricing
+	} else {
+		updatedCabData.MinPrice, updatedCabData.MaxPrice = models.PriceBound(updatedCabData.MinPrice), models.PriceBound(updatedCabData.MaxPrice)
+	}
+	if rejected, err := rejectPriceChange(c, h.Perms, beforePricing, cabPricing(updatedCabData)); rejected {
+		return err
+	}
+
+	// Hold a large price change for approval; the rest of the update is applied now
+	holdPrice := before != nil && h.Approvals.needsApproval(before.Price, updatedCabData.Price)
+	var requestedPrice float64
+	if holdPrice {
+		if pending, err := h.Approvals.rejectIfPending(c, models.InventoryCab, id); pending {
+			return err
+		}
+		requestedPrice, updatedCabData.Price = updatedCabData.Price, before.Price
+	}
+
 	// Call repository to update the cab
-	resultCab, err := h.Repo.UpdateCab(id, updatedCabData)
+	resultCab, err := h.Repo.UpdateCab(c.Context(), id, updatedCabData)
 	if err != nil {
+		if conflict, err := rejectNegativeStock(c, err); conflict {
+			return err
+		}
 		// Check if the error is 'not found'
 		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+			return c.Status(http.StatusNotFound).JSON(api.ErrorResponse{
 				Error:      fmt.Sprintf("Cab with ID %d not found for update", id),
 				StatusCode: http.StatusNotFound,
 			})
 		}
 		// Handle other potential repository errors
 		fmt.Printf("Error updating cab ID %d: %v\n", id, err) // Replace with proper logging
-		return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to update cab",
 			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
+	if before != nil {
+		h.Audit.RecordUpdate(c, AuditEntityCab, strconv.Itoa(id), before, resultCab)
+		h.Watch.ItemUpdated(c, models.FavoriteItemCab, id, resultCab.Name,
+			itemStock{Price: before.Price, Quantity: before.Quantity},
+			itemStock{Price: resultCab.Price, Quantity: resultCab.Quantity})
+		h.Alerts.StockChanged(c, AuditEntityCab, id, resultCab.Name, before.Quantity, resultCab.Quantity)
+	}
+
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.CabListing(*resultCab))
+
+	status := http.StatusOK
+	if holdPrice {
+		change, err := h.Approvals.hold(c, models.InventoryCab, id, resultCab.Name, before.Price, requestedPrice)
+		if err != nil {
+			log.Printf("Error holding the price change of cab ID %d: %v", id, err)
+			return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Cab updated, but its price change could not be held for approval",
+				StatusCode: http.StatusInternalServerError,
+			})
+		}
+		status = heldForApproval(c, change)
+	}
+
 	// Return the updated cab data
-	return c.Status(http.StatusOK).JSON(resultCab)
+	resultCab.DisplayNumber = h.Numbers.Number(models.NumberedCab, strconv.Itoa(id))
+	setLastModified(c, resultCab.UpdatedAt)
+	return c.Status(status).JSON(resultCab)
 }
 
 // DeleteCab handles requests to delete a cab by its ID.
 // @Summary Delete a cab
-// @Description Delete a cab by its ID.
+// @Description Delete a cab by its ID. The cab is kept in the trash, where admins can restore it.
 // @Tags Cabs
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
 // @Param id path int true "Cab ID"
 // @Success 204 "Cab deleted successfully (No Content)"
-// @Failure 400 {object} ErrorResponse "Invalid ID format. ID must be an integer."
-// @Failure 404 {object} ErrorResponse "Cab not found for deletion"
-// @Failure 500 {object} ErrorResponse "Failed to delete cab"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format. ID must be an integer."
+// @Failure 404 {object} api.ErrorResponse "Cab not found for deletion"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete cab"
 // @Router /cabs/{id} [delete]
 func (h *CabsHandlers) DeleteCab(c *fiber.Ctx) error {
 	// Parse ID from URL path parameter
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(http.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid ID format. ID must be an integer.",
 			StatusCode: http.StatusBadRequest,
 		})
 	}
 
 	// Call repository to delete the cab
-	err = h.Repo.DeleteCab(id)
+	err = h.Repo.DeleteCab(c.Context(), id)
 	if err != nil {
 		// Check if the error is 'not found'
 		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			return c.Status(http.StatusNotFound).JSON(ErrorResponse{
+			return c.Status(http.StatusNotFound).JSON(api.ErrorResponse{
 				Error:      fmt.Sprintf("Cab with ID %d not found for deletion", id),
 				StatusCode: http.StatusNotFound,
 			})
 		}
 		// Handle other potential repository errors
 		fmt.Printf("Error deleting cab ID %d: %v\n", id, err) // Replace with proper logging
-		return c.Status(http.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to delete cab",
 			StatusCode: http.StatusInternalServerError,
 		})
 	}
 
+	h.Trash.Deleted(c, models.TrashCab, strconv.Itoa(id))
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.RemovedListing(services.ListingCab, id))
+
 	// Return No Content status for successful deletion
 	return c.SendStatus(http.StatusNoContent)
 }
+
+// BatchCabs handles creating, updating and deleting many cabs at once.
+// @Summary Create, update and delete cabs in one batch
+// @Description Applies up to 500 operations on cabs in one transaction, so either every one is applied or none is. A create takes the cab as POST /cabs does and an update the cab as PUT /cabs/{id} does, with the same price guard; a delete only needs the ID. An item may be updated or deleted by one operation of a batch. A price change needing approval is refused: make it with PUT /cabs/{id}.
+// @Description Each operation has a result with the status it would have had on its own. When an operation fails, nothing is applied: the response has the status of the first that failed, and the operations that did not fail have 424.
+// @Tags Cabs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param batch body api.CabBatchRequest true "Operations, applied in order"
+// @Success 200 {object} api.BatchResponse "Every operation applied"
+// @Failure 400 {object} api.ErrorResponse "Invalid JSON, or no or more than 500 operations"
+// @Failure 403 {object} api.BatchResponse "Not applied: an operation changes a price guard without the prices.override permission"
+// @Failure 404 {object} api.BatchResponse "Not applied: an operation's cab does not exist"
+// @Failure 409 {object} api.BatchResponse "Not applied: an operation takes a quantity below zero or changes a price by more than needs approval"
+// @Failure 422 {object} api.BatchResponse "Not applied: an operation is invalid or its price is outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to apply cab batch"
+// @Router /cabs/batch [post]
+func (h *CabsHandlers) BatchCabs(c *fiber.Ctx) error {
+	var req api.CabBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid JSON format in request body",
+			StatusCode: http.StatusBadRequest,
+		})
+	}
+	if rejected, err := rejectBatchSize(c, len(req.Operations)); rejected {
+		return err
+	}
+
+	plan := newBatchPlan[models.MultiCab]("cab", len(req.Operations))
+	for i, op := range req.Operations {
+		if !plan.check(i, op.Op, op.ID, op.Item != nil) {
+			continue
+		}
+		if err := h.planCab(c, plan, i, op); err != nil {
+			log.Printf("Error checking operation %d of cab batch: %v", i, err)
+			return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Failed to apply cab batch",
+				StatusCode: http.StatusInternalServerError,
+			})
+		}
+	}
+	if applied, err := plan.apply(c, h.Repo.ApplyBatch); !applied {
+		return err
+	}
+
+	tenantID := tenantIDFromCtx(c)
+	for i, change := range plan.changes {
+		cab := change.Item
+		switch change.Op {
+		case models.BatchCreate:
+			h.Numbers.Assign(c, models.NumberedCab, strconv.Itoa(cab.ID))
+			h.Marketplace.ItemChanged(tenantID, services.CabListing(cab))
+		case models.BatchUpdate:
+			before := plan.before[i]
+			h.Audit.RecordUpdate(c, AuditEntityCab, strconv.Itoa(cab.ID), before, &cab)
+			h.Watch.ItemUpdated(c, models.FavoriteItemCab, cab.ID, cab.Name,
+				itemStock{Price: before.Price, Quantity: before.Quantity},
+				itemStock{Price: cab.Price, Quantity: cab.Quantity})
+			h.Alerts.StockChanged(c, AuditEntityCab, cab.ID, cab.Name, before.Quantity, cab.Quantity)
+			h.Marketplace.ItemChanged(tenantID, services.CabListing(cab))
+		case models.BatchDelete:
+			h.Trash.Deleted(c, models.TrashCab, strconv.Itoa(change.ID))
+			h.Marketplace.ItemChanged(tenantID, services.RemovedListing(services.ListingCab, change.ID))
+		}
+	}
+	return plan.respond(c, h.Audit, AuditEntityCab, func(cab models.MultiCab) int { return cab.ID })
+}
+
+// planCab checks operation i of a cab batch against the cab it changes, as AddCab and
+// UpdateCab would, and adds its change to the plan. It only fails when the cab cannot
+// be read.
+func (h *CabsHandlers) planCab(c *fiber.Ctx, plan *batchPlan[models.MultiCab], i int, op api.CabBatchOperation) error {
+	var before *models.MultiCab
+	if op.Op != models.BatchCreate {
+		existing, err := h.Repo.GetCabByID(c.Context(), op.ID)
+		if err != nil {
+			if strings.Contains(strings.ToLower(err.Error()), "not found") {
+				plan.notFound(i)
+				return nil
+			}
+			return err
+		}
+		before = existing
+	}
+	if op.Op == models.BatchDelete {
+		plan.add(i, op.Op, op.ID, models.MultiCab{}, before)
+		return nil
+	}
+
+	cab := *op.Item
+	if cab.Name == "" || cab.Make == "" || cab.UnitColor == "" || cab.Status == "" {
+		plan.reject(i, http.StatusUnprocessableEntity, "Missing required fields. Required: name, make, unit_color, status")
+		return nil
+	}
+	if cab.Quantity < 0 {
+		plan.reject(i, http.StatusConflict, "Quantity cannot go below zero")
+		return nil
+	}
+	if cab.Image == "null" || cab.Image == "" {
+		cab.Image = config.DefaultImageURL
+	}
+
+	cab.ID = op.ID
+	var beforePricing *pricedItem
+	if before != nil {
+		cab.MinPrice = mergePriceBound(before.MinPrice, cab.MinPrice)
+		cab.MaxPrice = mergePriceBound(before.MaxPrice, cab.MaxPrice)
+		pricing := cabPricing(*before)
+		beforePricing = &pricing
+	} else {
+		cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
+	}
+	if status, message := priceChangeError(c, h.Perms, beforePricing, cabPricing(cab)); status != 0 {
+		plan.reject(i, status, message)
+		return nil
+	}
+	if before != nil && h.Approvals.needsApproval(before.Price, cab.Price) {
+		plan.reject(i, http.StatusConflict, fmt.Sprintf("The price change of cab %d needs approval; make it with PUT /api/cabs/%d", op.ID, op.ID))
+		return nil
+	}
+
+	plan.add(i, op.Op, op.ID, cab, before)
+	return nil
+}
+
+// RestoreCab handles requests to restore a deleted cab.
+// @Summary Restore a deleted cab (Admin)
+// @Description Restores a deleted cab, with the stock and prices it had when it was deleted.
+// @Tags Cabs
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Cab ID"
+// @Success 200 {object} models.MultiCab "Restored cab"
+// @Failure 400 {object} api.ErrorResponse "Invalid ID format. ID must be an integer."
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Cab is not deleted, or does not exist"
+// @Failure 500 {object} api.ErrorResponse "Failed to restore cab"
+// @Router /cabs/{id}/restore [post]
+func (h *CabsHandlers) RestoreCab(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid ID format. ID must be an integer.",
+			StatusCode: http.StatusBadRequest,
+		})
+	}
+
+	if err := h.Repo.RestoreCab(c.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(http.StatusNotFound).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Cab with ID %d is not deleted", id),
+				StatusCode: http.StatusNotFound,
+			})
+		}
+		log.Printf("Error restoring cab ID %d: %v", id, err)
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to restore cab",
+			StatusCode: http.StatusInternalServerError,
+		})
+	}
+	h.Audit.RecordAction(c, trashRestore.auditAction, AuditEntityCab, strconv.Itoa(id), fmt.Sprintf("Restored deleted cab %d", id))
+
+	cab, err := h.Repo.GetCabByID(c.Context(), id)
+	if err != nil {
+		log.Printf("Error fetching restored cab ID %d: %v", id, err)
+		return c.Status(http.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve restored cab",
+			StatusCode: http.StatusInternalServerError,
+		})
+	}
+	h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.CabListing(*cab))
+	return c.Status(http.StatusOK).JSON(cab)
+}
+
+// queryCount parses a non-negative whole number query parameter, returning fallback when it is
+// missing
+func queryCount(c *fiber.Ctx, name string, fallback int) (int, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be zero or a positive number", name)
+	}
+	return n, nil
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
 	"testing"
@@ -19,49 +21,81 @@ import (
 // MockCabsRepository is a mock implementation of CabsRepository for testing handlers.
 // It allows setting expectations on function calls.
 type MockCabsRepository struct {
-	GetCabsFn    func(filters map[string]interface{}) ([]models.MultiCab, error)
+	GetCabsFn    func(filter models.CabFilter) ([]models.MultiCab, error)
+	CountCabsFn  func(filter models.CabFilter) (int64, error)
 	GetCabByIDFn func(id int) (*models.MultiCab, error)
 	AddCabFn     func(cab models.MultiCab) (*models.MultiCab, error)
 	UpdateCabFn  func(id int, cab models.MultiCab) (*models.MultiCab, error)
 	DeleteCabFn  func(id int) error
+	RestoreCabFn func(id int) error
+	GetDeletedFn func() ([]models.MultiCab, error)
+	ApplyBatchFn func(changes []models.BatchChange[models.MultiCab]) error
 }
 
 // Implement the CabsRepository interface for the mock
-func (m *MockCabsRepository) GetCabs(filters map[string]interface{}) ([]models.MultiCab, error) {
+func (m *MockCabsRepository) GetCabs(ctx context.Context, filter models.CabFilter) ([]models.MultiCab, error) {
 	if m.GetCabsFn != nil {
-		return m.GetCabsFn(filters)
+		return m.GetCabsFn(filter)
 	}
 	return nil, fmt.Errorf("mock GetCabsFn not implemented")
 }
 
-func (m *MockCabsRepository) GetCabByID(id int) (*models.MultiCab, error) {
+func (m *MockCabsRepository) CountCabs(ctx context.Context, filter models.CabFilter) (int64, error) {
+	if m.CountCabsFn != nil {
+		return m.CountCabsFn(filter)
+	}
+	return 0, fmt.Errorf("mock CountCabsFn not implemented")
+}
+
+func (m *MockCabsRepository) GetCabByID(ctx context.Context, id int) (*models.MultiCab, error) {
 	if m.GetCabByIDFn != nil {
 		return m.GetCabByIDFn(id)
 	}
 	return nil, fmt.Errorf("mock GetCabByIDFn not implemented")
 }
 
-func (m *MockCabsRepository) AddCab(cab models.MultiCab) (*models.MultiCab, error) {
+func (m *MockCabsRepository) AddCab(ctx context.Context, cab models.MultiCab) (*models.MultiCab, error) {
 	if m.AddCabFn != nil {
 		return m.AddCabFn(cab)
 	}
 	return nil, fmt.Errorf("mock AddCabFn not implemented")
 }
 
-func (m *MockCabsRepository) UpdateCab(id int, cab models.MultiCab) (*models.MultiCab, error) {
+func (m *MockCabsRepository) UpdateCab(ctx context.Context, id int, cab models.MultiCab) (*models.MultiCab, error) {
 	if m.UpdateCabFn != nil {
 		return m.UpdateCabFn(id, cab)
 	}
 	return nil, fmt.Errorf("mock UpdateCabFn not implemented")
 }
 
-func (m *MockCabsRepository) DeleteCab(id int) error {
+func (m *MockCabsRepository) DeleteCab(ctx context.Context, id int) error {
 	if m.DeleteCabFn != nil {
 		return m.DeleteCabFn(id)
 	}
 	return fmt.Errorf("mock DeleteCabFn not implemented")
 }
 
+func (m *MockCabsRepository) RestoreCab(ctx context.Context, id int) error {
+	if m.RestoreCabFn != nil {
+		return m.RestoreCabFn(id)
+	}
+	return fmt.Errorf("mock RestoreCabFn not implemented")
+}
+
+func (m *MockCabsRepository) GetDeletedCabs(ctx context.Context) ([]models.MultiCab, error) {
+	if m.GetDeletedFn != nil {
+		return m.GetDeletedFn()
+	}
+	return nil, fmt.Errorf("mock GetDeletedFn not implemented")
+}
+
+func (m *MockCabsRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.MultiCab]) error {
+	if m.ApplyBatchFn != nil {
+		return m.ApplyBatchFn(changes)
+	}
+	return fmt.Errorf("mock ApplyBatchFn not implemented")
+}
+
 // Helper to setup Fiber app with handlers using a provided (mock) repository
 func setupAppWithMockRepo(repo repositories.CabsRepository) *fiber.App {
 	h := NewCabsHandlers(repo)
@@ -85,8 +119,8 @@ func TestGetCabs_Handler_NoFilters(t *testing.T) {
 		{ID: 2, Name: "Cab 2"},
 	}
 
-	mockRepo.GetCabsFn = func(filters map[string]interface{}) ([]models.MultiCab, error) {
-		assert.Empty(t, filters, "Expected empty filters map for no-filter request")
+	mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+		assert.Equal(t, models.CabFilter{}, filter, "Expected an empty filter for no-filter request")
 		return expectedCabs, nil
 	}
 
@@ -110,8 +144,8 @@ func TestGetCabs_Handler_WithFilters(t *testing.T) {
 	// Test filter by make
 	t.Run("Filter by Make", func(t *testing.T) {
 		expectedCabsMake := []models.MultiCab{{ID: 3, Name: "Porsche 1", Make: "Porsche"}}
-		mockRepo.GetCabsFn = func(filters map[string]interface{}) ([]models.MultiCab, error) {
-			assert.Equal(t, map[string]interface{}{"make": "Porsche"}, filters, "Expected 'make' filter")
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			assert.Equal(t, models.CabFilter{Make: "Porsche"}, filter, "Expected 'make' filter")
 			return expectedCabsMake, nil
 		}
 		reqMake := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?make=Porsche", nil)
@@ -127,8 +161,8 @@ func TestGetCabs_Handler_WithFilters(t *testing.T) {
 	// Test filter by status
 	t.Run("Filter by Status", func(t *testing.T) {
 		expectedCabsStatus := []models.MultiCab{{ID: 4, Name: "Cab 4", Status: "Available"}}
-		mockRepo.GetCabsFn = func(filters map[string]interface{}) ([]models.MultiCab, error) {
-			assert.Equal(t, map[string]interface{}{"status": "Available"}, filters, "Expected 'status' filter")
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			assert.Equal(t, models.CabFilter{Status: "Available"}, filter, "Expected 'status' filter")
 			return expectedCabsStatus, nil
 		}
 		reqStatus := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?status=Available", nil)
@@ -144,8 +178,8 @@ func TestGetCabs_Handler_WithFilters(t *testing.T) {
 	// Test filter by search term
 	t.Run("Filter by Search", func(t *testing.T) {
 		expectedCabsSearch := []models.MultiCab{{ID: 6, Name: "Navara"}}
-		mockRepo.GetCabsFn = func(filters map[string]interface{}) ([]models.MultiCab, error) {
-			assert.Equal(t, map[string]interface{}{"search": "Navara"}, filters, "Expected 'search' filter")
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			assert.Equal(t, models.CabFilter{Search: "Navara"}, filter, "Expected 'search' filter")
 			return expectedCabsSearch, nil
 		}
 		reqSearch := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?search=Navara", nil)
@@ -161,9 +195,9 @@ func TestGetCabs_Handler_WithFilters(t *testing.T) {
 	// Test combined filters
 	t.Run("Combined Filters", func(t *testing.T) {
 		expectedCabsCombined := []models.MultiCab{{ID: 5, Name: "Porsche 2", Make: "Porsche", Status: "In Stock"}}
-		mockRepo.GetCabsFn = func(filters map[string]interface{}) ([]models.MultiCab, error) {
-			expectedFilters := map[string]interface{}{"make": "Porsche", "status": "In Stock"}
-			assert.Equal(t, expectedFilters, filters, "Expected combined filters")
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			expectedFilter := models.CabFilter{Make: "Porsche", Status: "In Stock"}
+			assert.Equal(t, expectedFilter, filter, "Expected combined filters")
 			return expectedCabsCombined, nil
 		}
 		reqCombined := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?make=Porsche&status=In%20Stock", nil)
@@ -178,7 +212,7 @@ func TestGetCabs_Handler_WithFilters(t *testing.T) {
 
 	// Test repository error
 	t.Run("Repository Error", func(t *testing.T) {
-		mockRepo.GetCabsFn = func(filters map[string]interface{}) ([]models.MultiCab, error) {
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
 			return nil, fmt.Errorf("internal database error")
 		}
 		reqErr := httptest.NewRequest(http.MethodGet, "/api/v1/cabs", nil)
@@ -192,6 +226,63 @@ func TestGetCabs_Handler_WithFilters(t *testing.T) {
 	})
 }
 
+func TestGetCabs_Handler_Paging(t *testing.T) {
+	mockRepo := &MockCabsRepository{}
+	app := setupAppWithMockRepo(mockRepo)
+
+	t.Run("Sorted Page With Total", func(t *testing.T) {
+		expectedCabs := []models.MultiCab{{ID: 7, Name: "Cab 7", Price: 100}}
+		expectedFilter := models.CabFilter{Make: "Suzuki", SortBy: "price", SortDir: "desc", Limit: 1, Offset: 2}
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			assert.Equal(t, expectedFilter, filter)
+			return expectedCabs, nil
+		}
+		mockRepo.CountCabsFn = func(filter models.CabFilter) (int64, error) {
+			assert.Equal(t, "Suzuki", filter.Make)
+			return 5, nil
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?make=Suzuki&sort_by=price&sort_dir=DESC&limit=1&offset=2", nil)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page api.CabPageResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		assert.Equal(t, api.CabPageResponse{Data: expectedCabs, Total: 5, Limit: 1, Offset: 2}, page)
+		resp.Body.Close()
+	})
+
+	t.Run("Limit Is Capped", func(t *testing.T) {
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			assert.Equal(t, maxPageLimit, filter.Limit)
+			return nil, nil
+		}
+		mockRepo.CountCabsFn = func(filter models.CabFilter) (int64, error) { return 0, nil }
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?limit=1000", nil)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var page api.CabPageResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+		assert.NotNil(t, page.Data)
+		assert.Equal(t, maxPageLimit, page.Limit)
+		resp.Body.Close()
+	})
+
+	t.Run("Invalid Parameters", func(t *testing.T) {
+		mockRepo.GetCabsFn = func(filter models.CabFilter) ([]models.MultiCab, error) {
+			t.Error("GetCabs should not be called for invalid parameters")
+			return nil, nil
+		}
+		for _, query := range []string{"sort_by=image", "sort_dir=up", "limit=0", "limit=abc", "offset=-1"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/cabs?"+query, nil)
+			resp, err := app.Test(req, -1)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+			resp.Body.Close()
+		}
+	})
+}
+
 func TestGetCabByID_Handler_Exists(t *testing.T) {
 	mockRepo := &MockCabsRepository{}
 	app := setupAppWithMockRepo(mockRepo)
@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// calendarRefresh is how often subscribed calendars are asked to fetch the feed again
+const calendarRefresh = time.Hour
+
+// calendarEventLength is how long a task's due date is shown for in the calendar
+const calendarEventLength = 30 * time.Minute
+
+// CalendarHandler serves each user's follow-up tasks as an iCal feed their phone
+// calendar can subscribe to. Calendar apps cannot send a session token, so the feed
+// URL carries a token of its own, which the user can replace or revoke.
+type CalendarHandler struct {
+	Feeds     repositories.CalendarFeedRepository
+	Tasks     repositories.TaskRepository
+	Users     UserRepository
+	Customers repositories.CustomerRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewCalendarHandler creates a new CalendarHandler instance. Customers are used to
+// name the customer a follow-up is about.
+func NewCalendarHandler(feeds repositories.CalendarFeedRepository, tasks repositories.TaskRepository, users UserRepository, customers repositories.CustomerRepository, jwtSecret []byte) *CalendarHandler {
+	return &CalendarHandler{Feeds: feeds, Tasks: tasks, Users: users, Customers: customers, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the calendar feed routes. The module must be registered before
+// the users module, whose /users group requires a token, so the feed is matched first.
+func (h *CalendarHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/users/me/calendar.ics", h.GetFeed)              // GET /api/users/me/calendar.ics?token=... (token-authenticated)
+	r.Get("/users/me/calendar", mw.Auth, h.GetSubscription) // GET /api/users/me/calendar
+	r.Post("/users/me/calendar", mw.Auth, h.CreateFeed)     // POST /api/users/me/calendar
+	r.Delete("/users/me/calendar", mw.Auth, h.DeleteFeed)   // DELETE /api/users/me/calendar
+}
+
+// GetSubscription handles getting the caller's calendar feed
+// @Summary Get my calendar feed
+// @Description Returns whether the caller has an iCal feed and when a calendar last fetched it. The feed URL is only shown when the feed is created.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.CalendarFeedResponse "Calendar feed, nil when there is none"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve the calendar feed"
+// @Router /users/me/calendar [get]
+func (h *CalendarHandler) GetSubscription(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	feed, err := h.Feeds.GetByUser(userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting calendar feed of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve the calendar feed", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.CalendarFeedResponse{Feed: feed})
+}
+
+// CreateFeed handles creating the caller's calendar feed
+// @Summary Create my calendar feed
+// @Description Creates an iCal feed of the caller's pending follow-up tasks that have a due date, and returns its URL to subscribe to from a phone calendar. Creating it again replaces the URL, so calendars subscribed to the old one stop updating.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} api.CalendarFeedResponse "Calendar feed created"
+// @Failure 500 {object} api.ErrorResponse "Failed to create the calendar feed"
+// @Router /users/me/calendar [post]
+func (h *CalendarHandler) CreateFeed(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("Error generating calendar feed token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create the calendar feed", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Hashed like invite tokens, so a leaked database does not expose feed URLs
+	feed := &models.CalendarFeed{UserID: userID, TokenHash: hashInviteToken(token), CreatedAt: h.now()}
+	if err := h.Feeds.Save(feed); err != nil {
+		log.Printf("Error saving calendar feed of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create the calendar feed", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_CALENDAR_FEED", AuditEntityUser, userID, "Created a calendar feed URL, replacing any previous one")
+	return c.Status(fiber.StatusCreated).JSON(api.CalendarFeedResponse{
+		Message: "Calendar feed created. Subscribe to the URL from your calendar app; it is not shown again.",
+		URL:     c.BaseURL() + "/api/users/me/calendar.ics?token=" + url.QueryEscape(token),
+		Feed:    feed,
+	})
+}
+
+// DeleteFeed handles revoking the caller's calendar feed
+// @Summary Delete my calendar feed
+// @Description Revokes the caller's iCal feed URL. Subscribed calendars stop updating.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.SuccessResponse "Calendar feed deleted"
+// @Failure 404 {object} api.ErrorResponse "No calendar feed"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete the calendar feed"
+// @Router /users/me/calendar [delete]
+func (h *CalendarHandler) DeleteFeed(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.Feeds.Delete(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No calendar feed", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error deleting calendar feed of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete the calendar feed", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_CALENDAR_FEED", AuditEntityUser, userID, "Revoked the calendar feed URL")
+	return c.Status(fiber.StatusOK).JSON(api.SuccessResponse{Message: "Calendar feed deleted"})
+}
+
+// GetFeed handles fetching a calendar feed
+// @Summary Get a calendar feed
+// @Description iCal feed of a user's open and in-progress follow-up tasks that have a due date, each shown at its due time. Authenticated by the token of the feed URL rather than a session token, so phone calendars can subscribe to it.
+// @Tags Users
+// @Produce text/calendar
+// @Param token query string true "Token of the feed URL"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} api.ErrorResponse "Unknown or revoked feed"
+// @Failure 500 {object} api.ErrorResponse "Failed to build the calendar feed"
+// @Router /users/me/calendar.ics [get]
+func (h *CalendarHandler) GetFeed(c *fiber.Ctx) error {
+	notFound := api.ErrorResponse{Error: "Calendar feed not found", StatusCode: fiber.StatusNotFound}
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusNotFound).JSON(notFound)
+	}
+	feed, err := h.Feeds.GetByTokenHash(hashInviteToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(notFound)
+		}
+		log.Printf("Error getting calendar feed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build the calendar feed", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Deactivated and deleted users lose their feed along with their account
+	user, err := h.Users.GetByID(c.Context(), feed.UserID)
+	if err != nil || !user.IsActive {
+		return c.Status(fiber.StatusNotFound).JSON(notFound)
+	}
+
+	tasks, err := h.Tasks.GetAll(models.TaskFilter{
+		AssigneeID: feed.UserID,
+		Statuses:   []string{models.TaskStatusOpen, models.TaskStatusInProgress},
+	})
+	if err != nil {
+		log.Printf("Error getting tasks for the calendar feed of user %s: %v", feed.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build the calendar feed", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	events := []services.CalendarEvent{}
+	for _, task := range tasks {
+		if task.DueDate == nil {
+			continue
+		}
+		events = append(events, services.CalendarEvent{
+			UID:         "task-" + task.ID + "@" + c.Hostname(),
+			Summary:     task.Title,
+			Description: h.taskDescription(c.Context(), task),
+			Start:       *task.DueDate,
+			Duration:    calendarEventLength,
+			Updated:     task.UpdatedAt,
+		})
+	}
+
+	if err := h.Feeds.RecordFetch(feed.UserID, h.now()); err != nil {
+		log.Printf("Error recording calendar feed fetch of user %s: %v", feed.UserID, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="calendar.ics"`)
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Status(fiber.StatusOK).SendString(services.RenderICal("Follow-ups", calendarRefresh, events))
+}
+
+// taskDescription describes a task and what it is about
+func (h *CalendarHandler) taskDescription(ctx context.Context, task models.Task) string {
+	var lines []string
+	if task.Description != "" {
+		lines = append(lines, task.Description)
+	}
+	switch task.EntityType {
+	case models.TaskEntityCustomer:
+		if customer, err := h.Customers.GetCustomerByID(ctx, task.EntityID); err == nil {
+			line := "Customer: " + customer.FullName
+			if customer.Phone != "" {
+				line += ", " + customer.Phone
+			}
+			lines = append(lines, line)
+		} else {
+			lines = append(lines, "Customer: "+task.EntityID)
+		}
+	case models.TaskEntitySale:
+		lines = append(lines, "Sale: "+task.EntityID)
+	case models.TaskEntityCab:
+		lines = append(lines, "Cab: #"+task.EntityID)
+	}
+	return strings.Join(lines, "\n")
+}
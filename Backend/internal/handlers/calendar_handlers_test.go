@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCalendarTestApp registers the calendar routes on an in-memory store with a staff user
+func setupCalendarTestApp(t *testing.T) (*fiber.App, *memory.Store, *models.User, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	staff := &models.User{Username: "clerk", Email: "clerk@example.com", Password: "password123", Role: RoleStaff}
+	require.NoError(t, store.Users.Create(context.Background(), staff))
+
+	h := NewCalendarHandler(store.Calendars, store.Tasks, store.Users, store.Customers, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, staff, createTenantTestToken(jwtSecret, staff.Id, RoleStaff, models.DefaultTenantID)
+}
+
+// fetchCalendar fetches a feed URL without a session token, as a calendar app would
+func fetchCalendar(t *testing.T, app *fiber.App, feedURL string) (*http.Response, string) {
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, feedURL, nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func createCalendarFeed(t *testing.T, app *fiber.App, token string) string {
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/users/me/calendar", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.CalendarFeedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.Contains(t, created.URL, "/api/users/me/calendar.ics?token=")
+	return created.URL[strings.Index(created.URL, "/api/"):]
+}
+
+func TestCalendarFeed(t *testing.T) {
+	app, store, staff, token := setupCalendarTestApp(t)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Phone: "09171234567"})
+	require.NoError(t, err)
+
+	due := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	for _, task := range []*models.Task{
+		{Title: "Call about balance", Description: "Second reminder", AssigneeID: staff.Id, DueDate: &due, Status: models.TaskStatusOpen, EntityType: models.TaskEntityCustomer, EntityID: customer.ID},
+		{Title: "No due date", AssigneeID: staff.Id, Status: models.TaskStatusOpen},
+		{Title: "Already done", AssigneeID: staff.Id, DueDate: &due, Status: models.TaskStatusDone},
+		{Title: "Someone else's", AssigneeID: "staff-2", DueDate: &due, Status: models.TaskStatusOpen},
+	} {
+		require.NoError(t, store.Tasks.Create(task))
+	}
+
+	feedURL := createCalendarFeed(t, app, token)
+	resp, body := fetchCalendar(t, app, feedURL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, 1, strings.Count(body, "BEGIN:VEVENT"), "only the caller's pending tasks with a due date")
+	assert.Contains(t, body, "SUMMARY:Call about balance\r\n")
+	assert.Contains(t, body, "DTSTART:20250303T090000Z\r\n")
+	assert.Contains(t, body, `DESCRIPTION:Second reminder\nCustomer: Juan Dela Cruz\, 09171234567`)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/users/me/calendar", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status api.CalendarFeedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	require.NotNil(t, status.Feed)
+	assert.NotNil(t, status.Feed.LastFetchedAt)
+	assert.Empty(t, status.URL, "the URL is only shown when created")
+}
+
+func TestCalendarFeedRevocation(t *testing.T) {
+	app, store, staff, token := setupCalendarTestApp(t)
+
+	resp, _ := fetchCalendar(t, app, "/api/users/me/calendar.ics")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "no token")
+	resp, _ = fetchCalendar(t, app, "/api/users/me/calendar.ics?token=guess")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	oldURL := createCalendarFeed(t, app, token)
+	newURL := createCalendarFeed(t, app, token)
+	resp, _ = fetchCalendar(t, app, oldURL)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "creating a feed again replaces the URL")
+	resp, _ = fetchCalendar(t, app, newURL)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), staff.Id))
+	resp, _ = fetchCalendar(t, app, newURL)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "deactivated users lose their feed")
+	require.NoError(t, store.Users.ActivateUser(context.Background(), staff.Id))
+
+	resp = authedRequest(t, app, token, http.MethodDelete, "/api/users/me/calendar", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = fetchCalendar(t, app, newURL)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodDelete, "/api/users/me/calendar", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionInventoryWrite allows reviewing the change requests of cabs, accessories
+// and materials. Users without it propose their edits as change requests.
+const PermissionInventoryWrite = "inventory.write"
+
+// Notifications of change requests: reviewers are told a request was submitted, and
+// the requester is told how it was decided
+const (
+	NotificationChangeRequestSubmitted = "change_request_submitted"
+	NotificationChangeRequestReviewed  = "change_request_reviewed"
+)
+
+// AuditEntityChangeRequest is the entity type of change requests in the activity log
+const AuditEntityChangeRequest = "change_request"
+
+// maxChangeRequestTextLength matches the width of the reason and note columns
+const maxChangeRequestTextLength = 500
+
+// changeRequestFields are the fields a change request may change, per entity type.
+// Price guards are left out: changing them needs the prices.override permission.
+var changeRequestFields = map[string]map[string]bool{
+	models.InventoryCab:       {"name": true, "make": true, "quantity": true, "price": true, "status": true, "unit_color": true, "image": true},
+	models.InventoryAccessory: {"name": true, "make": true, "quantity": true, "price": true, "unit_color": true, "image": true},
+	models.InventoryMaterial:  {"name": true, "category": true, "supplier": true, "quantity": true, "status": true, "image": true},
+}
+
+// ChangeRequestHandler lets users without PermissionInventoryWrite propose edits of
+// cabs, accessories and materials, which a reviewer other than the requester applies
+// or rejects. Nothing changes until a request is applied.
+type ChangeRequestHandler struct {
+	Repo        repositories.ChangeRequestRepository
+	Users       UserRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Perms       *Permissions              // Optional; without it only admins review change requests
+	Hub         *services.NotificationHub // Optional; without it nobody is notified
+	Audit       *ChangeRecorder
+	Watch       *Watchlist                // Optional; notifies users who starred an edited cab or accessory
+	Alerts      *Alerts                   // Optional; publishes items an applied request took out of stock
+	Marketplace *services.MarketplaceSync // Optional; pushes edited cabs and accessories to the marketplace
+	jwtSecret   []byte
+}
+
+// NewChangeRequestHandler creates a new ChangeRequestHandler instance. The inventory
+// repositories are used to apply requests.
+func NewChangeRequestHandler(repo repositories.ChangeRequestRepository, users UserRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, jwtSecret []byte) *ChangeRequestHandler {
+	return &ChangeRequestHandler{Repo: repo, Users: users, Cabs: cabs, Accessories: accessories, Materials: materials, jwtSecret: jwtSecret}
+}
+
+// Routes registers the change request routes
+func (h *ChangeRequestHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	group := r.Group("/change-requests", mw.Auth)
+	group.Get("/", h.GetChangeRequests)              // GET /api/change-requests
+	group.Get("/:id", h.GetChangeRequest)            // GET /api/change-requests/:id
+	group.Post("/", h.CreateChangeRequest)           // POST /api/change-requests
+	group.Post("/:id/apply", h.ApplyChangeRequest)   // POST /api/change-requests/:id/apply
+	group.Post("/:id/reject", h.RejectChangeRequest) // POST /api/change-requests/:id/reject
+}
+
+// canReview reports whether the caller may apply and reject change requests
+func (h *ChangeRequestHandler) canReview(c *fiber.Ctx) bool {
+	return h.Perms.Allowed(c, PermissionInventoryWrite)
+}
+
+// changeRequestEntity is a cab, accessory or material a change request edits
+type changeRequestEntity struct {
+	Name   string
+	Record interface{} // *models.MultiCab, *models.Accessory or *models.Material
+}
+
+// loadEntity returns the current state of the entity a change request edits, or an
+// error wrapping sql.ErrNoRows when it does not exist
+func (h *ChangeRequestHandler) loadEntity(c *fiber.Ctx, entityType string, id int) (*changeRequestEntity, error) {
+	switch entityType {
+	case models.InventoryCab:
+		cab, err := h.Cabs.GetCabByID(c.Context(), id)
+		if err != nil {
+			return nil, notFoundAsNoRows(err)
+		}
+		return &changeRequestEntity{Name: cab.Name, Record: cab}, nil
+	case models.InventoryAccessory:
+		accessory, err := h.Accessories.GetByID(c.Context(), id)
+		if err != nil {
+			return nil, notFoundAsNoRows(err)
+		}
+		return &changeRequestEntity{Name: accessory.Name, Record: &accessory}, nil
+	case models.InventoryMaterial:
+		material, err := h.Materials.GetByID(c.Context(), id)
+		if err != nil {
+			return nil, notFoundAsNoRows(err)
+		}
+		if material == nil {
+			return nil, sql.ErrNoRows
+		}
+		return &changeRequestEntity{Name: material.Name, Record: material}, nil
+	}
+	return nil, fmt.Errorf("unknown entity type %q", entityType)
+}
+
+// notFoundAsNoRows wraps the "not found" errors of the inventory repositories, which
+// do not all wrap sql.ErrNoRows, in sql.ErrNoRows
+func notFoundAsNoRows(err error) error {
+	if !errors.Is(err, sql.ErrNoRows) && strings.Contains(strings.ToLower(err.Error()), "not found") {
+		return fmt.Errorf("%w: %v", sql.ErrNoRows, err)
+	}
+	return err
+}
+
+// overlayFields returns a copy of record, a pointer to a cab, accessory or material,
+// with the given JSON fields set to new values. It fails when a value does not fit
+// its field, such as text for the quantity.
+func overlayFields(record interface{}, values map[string]interface{}) (interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for field, value := range values {
+		fields[field] = value
+	}
+	if data, err = json.Marshal(fields); err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	switch record.(type) {
+	case *models.MultiCab:
+		result = &models.MultiCab{}
+	case *models.Accessory:
+		result = &models.Accessory{}
+	case *models.Material:
+		result = &models.Material{}
+	default:
+		return nil, fmt.Errorf("unsupported record %T", record)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// quantityOf returns the quantity of a cab, accessory or material
+func quantityOf(record interface{}) int {
+	switch record := record.(type) {
+	case *models.MultiCab:
+		return record.Quantity
+	case *models.Accessory:
+		return record.Quantity
+	case *models.Material:
+		return record.Quantity
+	}
+	return 0
+}
+
+// changeRequestValues returns the values of the changes of a request, before or after
+func changeRequestValues(changes []models.FieldChange, after bool) map[string]interface{} {
+	values := make(map[string]interface{}, len(changes))
+	for _, change := range changes {
+		if after {
+			values[change.Field] = change.After
+		} else {
+			values[change.Field] = change.Before
+		}
+	}
+	return values
+}
+
+// changedFields lists the fields of changes
+func changedFields(changes []models.FieldChange) string {
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	return strings.Join(fields, ", ")
+}
+
+// CreateChangeRequest handles proposing an edit
+// @Summary Propose an inventory edit
+// @Description Proposes changing fields of a cab, accessory or material for a reviewer to apply. The request records the current value of each field next to the proposed one. Anyone signed in may propose; reviewers, admins and roles with the inventory.write permission, are notified.
+// @Tags Change Requests
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body api.ChangeRequestInput true "Entity and the new values of its fields"
+// @Success 201 {object} models.ChangeRequest "Change request submitted"
+// @Failure 400 {object} api.ErrorResponse "Invalid entity type, field or value, or nothing would change"
+// @Failure 404 {object} api.ErrorResponse "Entity not found"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero"
+// @Failure 500 {object} api.ErrorResponse "Failed to submit change request"
+// @Router /change-requests [post]
+func (h *ChangeRequestHandler) CreateChangeRequest(c *fiber.Ctx) error {
+	var input api.ChangeRequestInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	fields, ok := changeRequestFields[input.EntityType]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "entityType must be cab, accessory or material", StatusCode: fiber.StatusBadRequest})
+	}
+	if len(input.Changes) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "changes must name at least one field", StatusCode: fiber.StatusBadRequest})
+	}
+	for field, value := range input.Changes {
+		if !fields[field] {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("%s cannot be changed through a change request of a %s", field, input.EntityType),
+				StatusCode: fiber.StatusBadRequest,
+			})
+		}
+		if text, ok := value.(string); ok && field != "image" && strings.TrimSpace(text) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: field + " cannot be empty", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if utf8.RuneCountInString(reason) > maxChangeRequestTextLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("reason cannot be longer than %d characters", maxChangeRequestTextLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	entity, err := h.loadEntity(c, input.EntityType, input.EntityID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("%s %d not found", input.EntityType, input.EntityID),
+				StatusCode: fiber.StatusNotFound,
+			})
+		}
+		log.Printf("Error fetching %s %d for a change request: %v", input.EntityType, input.EntityID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to submit change request", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	proposed, err := overlayFields(entity.Record, input.Changes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid value in changes: " + err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	if quantityOf(proposed) < 0 {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Quantity cannot go below zero", StatusCode: fiber.StatusConflict})
+	}
+	changes := services.DiffFields(entity.Record, proposed)
+	if len(changes) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The changes match the current values", StatusCode: fiber.StatusBadRequest})
+	}
+
+	requestedBy, _ := c.Locals("user_id").(string)
+	request := &models.ChangeRequest{
+		EntityType:  input.EntityType,
+		EntityID:    input.EntityID,
+		EntityName:  entity.Name,
+		Changes:     changes,
+		Reason:      reason,
+		RequestedBy: requestedBy,
+	}
+	if err := h.Repo.Create(request); err != nil {
+		log.Printf("Error creating change request: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to submit change request", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_CHANGE_REQUEST", AuditEntityChangeRequest, request.ID,
+		fmt.Sprintf("Proposed changing %s of %s %s", changedFields(changes), input.EntityType, entity.Name))
+	h.notifyReviewers(c.Context(), tenantIDFromCtx(c), *request)
+	return c.Status(fiber.StatusCreated).JSON(request)
+}
+
+// GetChangeRequests handles listing change requests
+// @Summary List change requests
+// @Description Lists change requests, most recently requested first. Reviewers see every request; other users see their own.
+// @Tags Change Requests
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "pending, applied or rejected"
+// @Success 200 {object} api.ChangeRequestListResponse "Change requests"
+// @Failure 400 {object} api.ErrorResponse "Invalid status"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve change requests"
+// @Router /change-requests [get]
+func (h *ChangeRequestHandler) GetChangeRequests(c *fiber.Ctx) error {
+	filter := models.ChangeRequestFilter{Status: c.Query("status")}
+	switch filter.Status {
+	case "", models.ChangeRequestPending, models.ChangeRequestApplied, models.ChangeRequestRejected:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "status must be pending, applied or rejected", StatusCode: fiber.StatusBadRequest})
+	}
+	if !h.canReview(c) {
+		filter.RequestedBy, _ = c.Locals("user_id").(string)
+	}
+
+	requests, err := h.Repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error listing change requests: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve change requests", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ChangeRequestListResponse{Requests: requests, Count: len(requests)})
+}
+
+// GetChangeRequest handles retrieving a change request
+// @Summary Get a change request
+// @Description Returns a change request to a reviewer or to the user who submitted it.
+// @Tags Change Requests
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Change request ID"
+// @Success 200 {object} models.ChangeRequest "Change request"
+// @Failure 404 {object} api.ErrorResponse "Change request not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve change request"
+// @Router /change-requests/{id} [get]
+func (h *ChangeRequestHandler) GetChangeRequest(c *fiber.Ctx) error {
+	request, err := h.Repo.GetByID(c.Params("id"))
+	userID, _ := c.Locals("user_id").(string)
+	if err == nil && !h.canReview(c) && request.RequestedBy != userID {
+		err = sql.ErrNoRows // Other users' requests are not revealed
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Change request not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error fetching change request %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve change request", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(request)
+}
+
+// ApplyChangeRequest handles applying a change request
+// @Summary Apply a change request
+// @Description Applies the proposed values of a pending request. Fields another edit changed since the request was submitted are not overwritten: the request is refused with 409 and should be rejected. Requires the inventory.write permission, and a reviewer other than the requester.
+// @Tags Change Requests
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Change request ID"
+// @Param review body api.ChangeRequestReviewRequest false "Optional note"
+// @Success 200 {object} api.ChangeRequestResponse "Change request applied"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied, or the caller submitted the request"
+// @Failure 404 {object} api.ErrorResponse "Change request not found"
+// @Failure 409 {object} api.ErrorResponse "Already reviewed, or the entity changed or is gone"
+// @Failure 500 {object} api.ErrorResponse "Failed to apply change request"
+// @Router /change-requests/{id}/apply [post]
+func (h *ChangeRequestHandler) ApplyChangeRequest(c *fiber.Ctx) error {
+	request, note, done, err := h.loadForReview(c)
+	if done {
+		return err
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if request.RequestedBy == userID {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "A change request must be applied by someone other than who submitted it", StatusCode: fiber.StatusForbidden})
+	}
+
+	entity, err := h.loadEntity(c, request.EntityType, request.EntityID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("%s %d no longer exists; reject the request instead", request.EntityType, request.EntityID),
+				StatusCode: fiber.StatusConflict,
+			})
+		}
+		log.Printf("Error fetching %s %d to apply change request %s: %v", request.EntityType, request.EntityID, request.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to apply change request", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Fields edited since the request was submitted no longer hold the values it replaces
+	submitted, err := overlayFields(entity.Record, changeRequestValues(request.Changes, false))
+	if err != nil {
+		log.Printf("Error comparing change request %s with %s %d: %v", request.ID, request.EntityType, request.EntityID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to apply change request", StatusCode: fiber.StatusInternalServerError})
+	}
+	if stale := services.DiffFields(entity.Record, submitted); len(stale) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("%s of %s was changed since the request was submitted; reject it instead", changedFields(stale), entity.Name),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+	proposed, err := overlayFields(entity.Record, changeRequestValues(request.Changes, true))
+	if err != nil {
+		log.Printf("Error applying change request %s: %v", request.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to apply change request", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Marking the request applied first means a concurrent review cannot also apply it
+	applied, done, err := h.review(c, request.ID, models.ChangeRequestApplied, note)
+	if done {
+		return err
+	}
+	if err := h.apply(c, *applied, entity.Record, proposed); err != nil {
+		log.Printf("Error applying change request %s: %v", applied.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "The change request was approved but could not be applied", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "APPLY_CHANGE_REQUEST", AuditEntityChangeRequest, applied.ID,
+		fmt.Sprintf("Applied the change of %s of %s %s requested by %s", changedFields(applied.Changes), applied.EntityType, applied.EntityName, applied.RequestedBy))
+	h.notifyRequester(tenantIDFromCtx(c), *applied)
+	return c.Status(fiber.StatusOK).JSON(api.ChangeRequestResponse{Message: "Change request applied", Request: *applied})
+}
+
+// RejectChangeRequest handles rejecting a change request
+// @Summary Reject a change request
+// @Description Rejects a pending request; nothing is changed. Requires the inventory.write permission.
+// @Tags Change Requests
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Change request ID"
+// @Param review body api.ChangeRequestReviewRequest false "Optional reason"
+// @Success 200 {object} api.ChangeRequestResponse "Change request rejected"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Change request not found"
+// @Failure 409 {object} api.ErrorResponse "Already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to reject change request"
+// @Router /change-requests/{id}/reject [post]
+func (h *ChangeRequestHandler) RejectChangeRequest(c *fiber.Ctx) error {
+	request, note, done, err := h.loadForReview(c)
+	if done {
+		return err
+	}
+
+	rejected, done, err := h.review(c, request.ID, models.ChangeRequestRejected, note)
+	if done {
+		return err
+	}
+
+	h.Audit.RecordAction(c, "REJECT_CHANGE_REQUEST", AuditEntityChangeRequest, rejected.ID,
+		fmt.Sprintf("Rejected the change of %s of %s %s requested by %s", changedFields(rejected.Changes), rejected.EntityType, rejected.EntityName, rejected.RequestedBy))
+	h.notifyRequester(tenantIDFromCtx(c), *rejected)
+	return c.Status(fiber.StatusOK).JSON(api.ChangeRequestResponse{Message: "Change request rejected", Request: *rejected})
+}
+
+// loadForReview checks the caller may review, parses the review note and loads the
+// pending request. It reports whether it wrote the response, in which case the
+// handler returns err.
+func (h *ChangeRequestHandler) loadForReview(c *fiber.Ctx) (*models.ChangeRequest, string, bool, error) {
+	if !h.canReview(c) {
+		return nil, "", true, c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+			Error:      "Reviewing change requests requires the " + PermissionInventoryWrite + " permission",
+			StatusCode: fiber.StatusForbidden,
+		})
+	}
+
+	var input api.ChangeRequestReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return nil, "", true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	note := strings.TrimSpace(input.Note)
+	if utf8.RuneCountInString(note) > maxChangeRequestTextLength {
+		return nil, "", true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("note cannot be longer than %d characters", maxChangeRequestTextLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	request, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", true, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Change request not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error fetching change request %s: %v", c.Params("id"), err)
+		return nil, "", true, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve change request", StatusCode: fiber.StatusInternalServerError})
+	}
+	if request.Status != models.ChangeRequestPending {
+		return nil, "", true, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "The change request was already " + request.Status, StatusCode: fiber.StatusConflict})
+	}
+	return request, note, false, nil
+}
+
+// review records the decision on a pending request. It reports whether it wrote the
+// response, in which case the handler returns err.
+func (h *ChangeRequestHandler) review(c *fiber.Ctx, id, status, note string) (*models.ChangeRequest, bool, error) {
+	reviewedBy, _ := c.Locals("user_id").(string)
+	request, err := h.Repo.Review(id, status, reviewedBy, note)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, true, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "The change request was already reviewed", StatusCode: fiber.StatusConflict})
+		}
+		log.Printf("Error reviewing change request %s: %v", id, err)
+		return nil, true, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to review change request", StatusCode: fiber.StatusInternalServerError})
+	}
+	return request, false, nil
+}
+
+// apply saves the proposed state of the entity of a request, recording the update
+// and notifying watchers, alerts and the marketplace like a direct edit
+func (h *ChangeRequestHandler) apply(c *fiber.Ctx, request models.ChangeRequest, current, proposed interface{}) error {
+	id := strconv.Itoa(request.EntityID)
+	switch before := current.(type) {
+	case *models.MultiCab:
+		updated, err := h.Cabs.UpdateCab(c.Context(), request.EntityID, *proposed.(*models.MultiCab))
+		if err != nil {
+			return err
+		}
+		h.Audit.RecordUpdate(c, AuditEntityCab, id, before, updated)
+		h.Watch.ItemUpdated(c, models.FavoriteItemCab, request.EntityID, updated.Name,
+			itemStock{Price: before.Price, Quantity: before.Quantity},
+			itemStock{Price: updated.Price, Quantity: updated.Quantity})
+		h.Alerts.StockChanged(c, AuditEntityCab, request.EntityID, updated.Name, before.Quantity, updated.Quantity)
+		h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.CabListing(*updated))
+	case *models.Accessory:
+		// The update input has the same JSON fields as the accessory
+		data, err := json.Marshal(changeRequestValues(request.Changes, true))
+		if err != nil {
+			return err
+		}
+		var input models.UpdateAccessoryInput
+		if err := json.Unmarshal(data, &input); err != nil {
+			return err
+		}
+		updated, err := h.Accessories.Update(c.Context(), request.EntityID, input)
+		if err != nil {
+			return err
+		}
+		h.Audit.RecordUpdate(c, AuditEntityAccessory, id, before, updated)
+		h.Watch.ItemUpdated(c, models.FavoriteItemAccessory, request.EntityID, updated.Name,
+			itemStock{Price: before.Price, Quantity: before.Quantity},
+			itemStock{Price: updated.Price, Quantity: updated.Quantity})
+		h.Alerts.StockChanged(c, AuditEntityAccessory, request.EntityID, updated.Name, before.Quantity, updated.Quantity)
+		h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.AccessoryListing(updated))
+	case *models.Material:
+		material := proposed.(*models.Material)
+		if err := h.Materials.Update(c.Context(), material); err != nil {
+			return err
+		}
+		h.Audit.RecordUpdate(c, AuditEntityMaterial, id, before, material)
+		h.Alerts.StockChanged(c, AuditEntityMaterial, request.EntityID, material.Name, before.Quantity, material.Quantity)
+	default:
+		return fmt.Errorf("unsupported record %T", current)
+	}
+	return nil
+}
+
+// notifyReviewers pushes a submitted request to the active users who may review it
+func (h *ChangeRequestHandler) notifyReviewers(ctx context.Context, tenantID string, request models.ChangeRequest) {
+	reviewer := func(user *models.User) bool {
+		return user.Id != request.RequestedBy && h.Perms.Has(user.Role, PermissionInventoryWrite)
+	}
+	notification := models.Notification{Type: NotificationChangeRequestSubmitted, Data: request}
+	if err := notifyUsers(ctx, h.Users, h.Hub, tenantID, reviewer, notification); err != nil {
+		log.Printf("Error notifying reviewers of change request %s: %v", request.ID, err)
+	}
+}
+
+// notifyRequester pushes the decision on a request to whoever submitted it
+func (h *ChangeRequestHandler) notifyRequester(tenantID string, request models.ChangeRequest) {
+	if h.Hub == nil || request.RequestedBy == "" {
+		return
+	}
+	h.Hub.Publish(tenantID, models.Notification{Type: NotificationChangeRequestReviewed, Data: request, Recipients: []string{request.RequestedBy}})
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupChangeRequestTestApp registers the change request routes on an in-memory store
+// with an admin and a manager granted inventory.write
+func setupChangeRequestTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.NotificationHub, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "admin-1", Username: "admin-1", Email: "admin-1@example.com", Password: "secret", Role: RoleAdmin, IsActive: true}))
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "manager-1", Username: "manager-1", Email: "manager-1@example.com", Password: "secret", Role: "manager", IsActive: true}))
+	hub := services.NewNotificationHub()
+
+	handler := NewChangeRequestHandler(store.Changes, store.Users, store.Cabs, store.Accessories, store.Materials, jwtSecret)
+	handler.Perms = NewPermissions(config.PermissionsConfig{RolePermissions: map[string][]string{"manager": {PermissionInventoryWrite}}})
+	handler.Hub = hub
+	handler.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, hub, jwtSecret
+}
+
+func TestApplyChangeRequest(t *testing.T) {
+	app, store, hub, jwtSecret := setupChangeRequestTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	managerToken := createTenantTestToken(jwtSecret, "manager-1", "manager", models.DefaultTenantID)
+	reviewers, unsubscribe := hub.Subscribe(models.DefaultTenantID, "manager-1")
+	defer unsubscribe()
+	requester, unsubscribeRequester := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribeRequester()
+
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Blue", Status: "Available", Quantity: 3, Price: 700000})
+	require.NoError(t, err)
+	propose := func(changes map[string]interface{}) *http.Response {
+		return authedRequest(t, app, staffToken, http.MethodPost, "/api/change-requests",
+			map[string]interface{}{"entityType": "cab", "entityId": cab.ID, "changes": changes, "reason": "Counted 5 in the yard"})
+	}
+
+	assert.Equal(t, http.StatusBadRequest, propose(map[string]interface{}{"min_price": 1}).StatusCode, "price guards are not proposed")
+	assert.Equal(t, http.StatusBadRequest, propose(map[string]interface{}{"quantity": "five"}).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, propose(map[string]interface{}{"quantity": 3}).StatusCode, "nothing would change")
+	assert.Equal(t, http.StatusConflict, propose(map[string]interface{}{"quantity": -1}).StatusCode)
+
+	resp := propose(map[string]interface{}{"quantity": 5, "status": "Available"})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var request models.ChangeRequest
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&request))
+	require.Len(t, request.Changes, 1, "unchanged fields are left out")
+	assert.Equal(t, "quantity", request.Changes[0].Field)
+	assert.Equal(t, models.ChangeRequestPending, request.Status)
+
+	select {
+	case notification := <-reviewers:
+		assert.Equal(t, NotificationChangeRequestSubmitted, notification.Type)
+	case <-time.After(time.Second):
+		t.Fatal("reviewers were not notified")
+	}
+
+	saved, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved.Quantity, "nothing changes before review")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/change-requests/"+request.ID+"/apply", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "staff cannot review")
+
+	resp = authedRequest(t, app, managerToken, http.MethodGet, "/api/change-requests?status=pending", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ChangeRequestListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 1, list.Count)
+
+	resp = authedRequest(t, app, managerToken, http.MethodPost, "/api/change-requests/"+request.ID+"/apply", map[string]string{"note": "Matches the yard count"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var applied api.ChangeRequestResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&applied))
+	assert.Equal(t, models.ChangeRequestApplied, applied.Request.Status)
+	assert.Equal(t, "manager-1", applied.Request.ReviewedBy)
+
+	saved, err = store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, saved.Quantity)
+	assert.Equal(t, "Blue", saved.UnitColor, "other fields are kept")
+
+	select {
+	case notification := <-requester:
+		assert.Equal(t, NotificationChangeRequestReviewed, notification.Type)
+	case <-time.After(time.Second):
+		t.Fatal("the requester was not notified")
+	}
+
+	resp = authedRequest(t, app, managerToken, http.MethodPost, "/api/change-requests/"+request.ID+"/reject", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "already applied")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	var actions []string
+	for _, entry := range logs {
+		actions = append(actions, entry.Action)
+	}
+	assert.Contains(t, actions, "CREATE_CHANGE_REQUEST")
+	assert.Contains(t, actions, "APPLY_CHANGE_REQUEST")
+}
+
+func TestReviewMaterialChangeRequest(t *testing.T) {
+	app, store, _, jwtSecret := setupChangeRequestTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	otherStaffToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Plywood", Category: "Wood", Supplier: "Acme", Quantity: 10, Status: "In Stock"})
+	require.NoError(t, err)
+	propose := func(token string, changes map[string]interface{}) models.ChangeRequest {
+		resp := authedRequest(t, app, token, http.MethodPost, "/api/change-requests",
+			map[string]interface{}{"entityType": "material", "entityId": materialID, "changes": changes})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var request models.ChangeRequest
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&request))
+		return request
+	}
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/change-requests",
+		map[string]interface{}{"entityType": "material", "entityId": 999, "changes": map[string]interface{}{"quantity": 1}})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	first := propose(staffToken, map[string]interface{}{"quantity": 12})
+	second := propose(otherStaffToken, map[string]interface{}{"quantity": 8, "supplier": "Globex"})
+
+	resp = authedRequest(t, app, otherStaffToken, http.MethodGet, "/api/change-requests/"+first.ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "other users' requests are hidden")
+	resp = authedRequest(t, app, otherStaffToken, http.MethodGet, "/api/change-requests", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ChangeRequestListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, second.ID, list.Requests[0].ID)
+
+	adminRequest := propose(adminToken, map[string]interface{}{"category": "Lumber"})
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/change-requests/"+adminRequest.ID+"/apply", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "requesters cannot apply their own request")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/change-requests/"+first.ID+"/apply", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/change-requests/"+second.ID+"/apply", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the quantity changed since the request was submitted")
+	material, err := store.Materials.GetByID(context.Background(), materialID)
+	require.NoError(t, err)
+	assert.Equal(t, 12, material.Quantity)
+	assert.Equal(t, "Acme", material.Supplier, "a stale request changes nothing")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/change-requests/"+second.ID+"/reject", map[string]string{"note": "Recount"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rejected api.ChangeRequestResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rejected))
+	assert.Equal(t, models.ChangeRequestRejected, rejected.Request.Status)
+	assert.Equal(t, "Recount", rejected.Request.Note)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, fmt.Sprintf("/api/change-requests/%s/apply", "missing"), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -1,40 +1,32 @@
 package handlers
 
 import (
+	"context"
 	"log"
-	"oop/internal/middleware"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
-// CustomerResponse defines the structure for a single customer response.
-// It omits sensitive or unnecessary fields for client-side display.
-type CustomerResponse struct {
-	ID             string `json:"id"`
-	FullName       string `json:"fullName"`
-	Email          string `json:"email"`
-	Phone          string `json:"phone"`
-	Address        string `json:"address,omitempty"`
-	DateRegistered string `json:"dateRegistered"`
-	CreatedAt      string `json:"createdAt"`
-	UpdatedAt      string `json:"updatedAt"`
-}
-
-// CustomerListResponse defines the structure for a list of customers.
-type CustomerListResponse struct {
-	Customers []*CustomerResponse `json:"customers"`
-}
-
 // CreateCustomerRequest defines the expected payload for creating a new customer.
 type CreateCustomerRequest struct {
 	FullName string `json:"fullName" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Phone    string `json:"phone" validate:"required,e164"` // e164 format for phone numbers
 	Address  string `json:"address,omitempty" validate:"max=255"`
+	// Province, City and Barangay must be in the location dataset; province and city
+	// are given together
+	Province string `json:"province,omitempty"`
+	City     string `json:"city,omitempty"`
+	Barangay string `json:"barangay,omitempty"`
+	// DateOfBirth is formatted as YYYY-MM-DD and must not be in the future
+	DateOfBirth string `json:"dateOfBirth,omitempty"`
 }
 
 // UpdateCustomerRequest defines the expected payload for updating an existing customer.
@@ -46,12 +38,30 @@ type UpdateCustomerRequest struct {
 	Email    string `json:"email,omitempty" validate:"omitempty,email"`
 	Phone    string `json:"phone,omitempty" validate:"omitempty,e164"`
 	Address  string `json:"address,omitempty" validate:"omitempty,max=255"`
+	Province string `json:"province,omitempty"`
+	City     string `json:"city,omitempty"`
+	Barangay string `json:"barangay,omitempty"`
+	// DateOfBirth is formatted as YYYY-MM-DD and must not be in the future
+	DateOfBirth string `json:"dateOfBirth,omitempty"`
 }
 
 // CustomerHandler holds the repository and JWT secret.
 type CustomerHandler struct {
 	Repo      repositories.CustomerRepository
 	jwtSecret []byte
+	Audit     *ChangeRecorder // Optional; records field-level changes to the activity log
+	Sales     SaleRepository  // Optional; includes purchase history in data exports
+	Perms     *Permissions    // Optional; without it only admins see unmasked contact details
+	Views     *RecentViews    // Optional; records the customer in the caller's recently viewed list
+	Undo      *UndoHandler    // Optional; lets deletes be undone for a while
+	Trash     *TrashHandler   // Optional; records who deleted the customer
+	// Consents holds the marketing consent and do-not-contact flags. Campaigns only reach
+	// customers who consented, so without it they reach nobody.
+	Consents  repositories.CustomerConsentRepository
+	Locations *services.PHLocations // Optional; validates provinces, cities and barangays
+	Geocoder  services.Geocoder     // Optional; locates addresses for delivery estimates
+	Numbers   *DisplayNumbers       // Optional; gives new customers a display number
+	now       func() time.Time
 }
 
 // NewCustomerHandler creates a new CustomerHandler instance.
@@ -59,36 +69,104 @@ func NewCustomerHandler(repo repositories.CustomerRepository, jwtSecret []byte)
 	return &CustomerHandler{
 		Repo:      repo,
 		jwtSecret: jwtSecret,
+		now:       time.Now,
 	}
 }
 
-// toCustomerResponse converts a models.Customer to a CustomerResponse.
-func toCustomerResponse(customer *models.Customer) *CustomerResponse {
+// toCustomerResponse converts a models.Customer to a api.CustomerResponse.
+func toCustomerResponse(customer *models.Customer) *api.CustomerResponse {
 	if customer == nil {
 		return nil
 	}
-	return &CustomerResponse{
+	return &api.CustomerResponse{
 		ID:             customer.ID,
 		FullName:       customer.FullName,
 		Email:          customer.Email,
 		Phone:          customer.Phone,
 		Address:        customer.Address,
+		Province:       customer.Province,
+		City:           customer.City,
+		Barangay:       customer.Barangay,
+		Latitude:       customer.Latitude,
+		Longitude:      customer.Longitude,
+		DateOfBirth:    customer.DateOfBirth,
 		DateRegistered: customer.DateRegistered.Format(time.RFC3339),
 		CreatedAt:      customer.CreatedAt.Format(time.RFC3339),
 		UpdatedAt:      customer.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
-// RegisterCustomerRoutes sets up the routes for customer operations.
-func (h *CustomerHandler) RegisterCustomerRoutes(r fiber.Router) {
-	authRequired := middleware.JWTMiddleware(h.jwtSecret)
-	customerGroup := r.Group("/customers", authRequired)
+// geocodeTimeout bounds how long saving a customer waits for the geocoder
+const geocodeTimeout = 5 * time.Second
+
+// locateAddress validates the province, city and barangay of a customer against the
+// location dataset, spelling them as it does, and geocodes the address when a geocoder
+// is configured. Addresses the geocoder cannot locate are saved without coordinates.
+// It returns why the address is invalid, or "" when it is valid.
+func (h *CustomerHandler) locateAddress(ctx context.Context, customer *models.Customer) string {
+	customer.Province = strings.TrimSpace(customer.Province)
+	customer.City = strings.TrimSpace(customer.City)
+	customer.Barangay = strings.TrimSpace(customer.Barangay)
+	customer.Latitude, customer.Longitude = nil, nil
+	if customer.Province == "" && customer.City == "" && customer.Barangay == "" {
+		return ""
+	}
+	if customer.Province == "" || customer.City == "" {
+		return "province and city are required with any part of the structured address"
+	}
+
+	if h.Locations != nil {
+		place, err := h.Locations.Resolve(customer.Province, customer.City, customer.Barangay)
+		if err != nil {
+			return err.Error()
+		}
+		customer.Province, customer.City, customer.Barangay = place.Province, place.City, place.Barangay
+	}
+
+	if h.Geocoder != nil {
+		ctx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+		defer cancel()
+		query := services.GeocodeQuery{Street: customer.Address, Barangay: customer.Barangay, City: customer.City, Province: customer.Province}
+		latitude, longitude, err := h.Geocoder.Geocode(ctx, query)
+		if err != nil {
+			log.Printf("Could not geocode the address of customer %s: %v", customer.ID, err)
+			return ""
+		}
+		customer.Latitude, customer.Longitude = &latitude, &longitude
+	}
+	return ""
+}
+
+// validateDateOfBirth returns why a date of birth is invalid, or "" when it is valid or
+// not given
+func validateDateOfBirth(date string) string {
+	if date == "" {
+		return ""
+	}
+	born, err := time.ParseInLocation(saleDateLayout, date, time.Local)
+	if err != nil {
+		return "dateOfBirth must be formatted as YYYY-MM-DD"
+	}
+	if born.After(time.Now()) {
+		return "dateOfBirth must not be in the future"
+	}
+	return ""
+}
+
+// Routes sets up the routes for customer operations.
+func (h *CustomerHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	customerGroup := r.Group("/customers", mw.Auth)
 
-	customerGroup.Post("/", h.CreateCustomer)
+	customerGroup.Post("/", mw.Dedupe, h.CreateCustomer)
 	customerGroup.Get("/", h.GetAllCustomers)
+	customerGroup.Get("/upcoming-birthdays", h.GetUpcomingBirthdays)
 	customerGroup.Get("/:id", h.GetCustomer)
 	customerGroup.Put("/:id", h.UpdateCustomer)
 	customerGroup.Delete("/:id", h.DeleteCustomer)
+	customerGroup.Get("/:id/data-export", h.ExportCustomerData)
+	customerGroup.Post("/:id/anonymize", h.AnonymizeCustomer)
+	customerGroup.Get("/:id/consent", h.GetCustomerConsent)
+	customerGroup.Put("/:id/consent", h.UpdateCustomerConsent)
 }
 
 // CreateCustomer handles the creation of a new customer.
@@ -99,61 +177,104 @@ func (h *CustomerHandler) RegisterCustomerRoutes(r fiber.Router) {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param customer body CreateCustomerRequest true "Customer information"
-// @Success 201 {object} CustomerResponse "Customer created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or validation error"
-// @Failure 500 {object} ErrorResponse "Failed to create customer"
+// @Success 201 {object} api.CustomerResponse "Customer created successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload or validation error"
+// @Failure 500 {object} api.ErrorResponse "Failed to create customer"
 // @Router /customers [post]
 func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 	var req CreateCustomerRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request payload", StatusCode: fiber.StatusBadRequest})
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request payload", StatusCode: fiber.StatusBadRequest})
 	}
 
 	// Basic validation (you might want to use a validation library for more complex scenarios)
 	if req.FullName == "" || req.Email == "" || req.Phone == "" {
 		log.Println("CreateCustomer: Validation failed - missing required fields")
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "FullName, email, and phone are required", StatusCode: fiber.StatusBadRequest})
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "FullName, email, and phone are required", StatusCode: fiber.StatusBadRequest})
+	}
+	if msg := validateDateOfBirth(req.DateOfBirth); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
 	}
 
 	customer := &models.Customer{
-		ID:       uuid.New().String(), // Repository will also generate if empty, but good to have it here too.
-		FullName: req.FullName,
-		Email:    req.Email,
-		Phone:    req.Phone,
-		Address:  req.Address,
+		ID:          uuid.New().String(), // Repository will also generate if empty, but good to have it here too.
+		FullName:    req.FullName,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Address:     req.Address,
+		Province:    req.Province,
+		City:        req.City,
+		Barangay:    req.Barangay,
+		DateOfBirth: req.DateOfBirth,
+	}
+	if msg := h.locateAddress(c.Context(), customer); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
 	}
 
-	createdCustomer, err := h.Repo.CreateCustomer(customer)
+	createdCustomer, err := h.Repo.CreateCustomer(c.Context(), customer)
 	if err != nil {
 		log.Printf("Error creating customer: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to create customer", StatusCode: fiber.StatusInternalServerError})
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create customer", StatusCode: fiber.StatusInternalServerError})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(toCustomerResponse(createdCustomer))
+	response := h.Perms.MaskCustomer(c, toCustomerResponse(createdCustomer))
+	response.DisplayNumber = h.Numbers.Assign(c, models.NumberedCustomer, createdCustomer.ID)
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 // GetAllCustomers handles retrieving all customers.
 // @Summary Get all customers
-// @Description Retrieves a list of all customers.
+// @Description Retrieves a list of all customers. With page, limit or search set, returns one page of the customers whose name, email or phone contains the search term, newest first, with the total number of matches and pages. Emails and phone numbers are masked for callers without the customers.pii permission.
 // @Tags Customers
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} CustomerListResponse "Successfully retrieved list of customers"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve customers"
+// @Param search query string false "Search names, emails and phone numbers"
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Customers per page (default 10, max 100)"
+// @Success 200 {object} api.CustomerListResponse "Successfully retrieved list of customers"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve customers"
 // @Router /customers [get]
 func (h *CustomerHandler) GetAllCustomers(c *fiber.Ctx) error {
-	customers, err := h.Repo.GetAllCustomers()
+	if c.Query("page") != "" || c.Query("limit") != "" || c.Query("search") != "" {
+		page, limit := pageParams(c)
+		customers, total, err := h.Repo.GetPaginated(c.Context(), page, limit, c.Query("search"))
+		if err != nil {
+			log.Printf("Error getting paginated customers: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve customers", StatusCode: fiber.StatusInternalServerError})
+		}
+		return c.Status(fiber.StatusOK).JSON(api.CustomerListResponse{
+			Customers:  h.maskCustomers(c, customers),
+			Page:       page,
+			PageSize:   limit,
+			Total:      total,
+			TotalPages: int((total + int64(limit) - 1) / int64(limit)),
+		})
+	}
+
+	customers, err := h.Repo.GetAllCustomers(c.Context())
 	if err != nil {
 		log.Printf("Error getting all customers: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to retrieve customers", StatusCode: fiber.StatusInternalServerError})
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve customers", StatusCode: fiber.StatusInternalServerError})
 	}
 
-	customerResponses := make([]*CustomerResponse, len(customers))
+	return c.Status(fiber.StatusOK).JSON(api.CustomerListResponse{Customers: h.maskCustomers(c, customers)})
+}
+
+// maskCustomers converts customers to responses with their display numbers, masking
+// their contact details for callers without the customers.pii permission
+func (h *CustomerHandler) maskCustomers(c *fiber.Ctx, customers []*models.Customer) []*api.CustomerResponse {
+	ids := make([]string, len(customers))
 	for i, cust := range customers {
-		customerResponses[i] = toCustomerResponse(cust)
+		ids[i] = cust.ID
 	}
+	numbers := h.Numbers.Numbers(models.NumberedCustomer, ids)
 
-	return c.Status(fiber.StatusOK).JSON(CustomerListResponse{Customers: customerResponses})
+	customerResponses := make([]*api.CustomerResponse, len(customers))
+	for i, cust := range customers {
+		customerResponses[i] = h.Perms.MaskCustomer(c, toCustomerResponse(cust))
+		customerResponses[i].DisplayNumber = numbers[cust.ID]
+	}
+	return customerResponses
 }
 
 // GetCustomer handles retrieving a single customer by ID.
@@ -163,29 +284,33 @@ func (h *CustomerHandler) GetAllCustomers(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "Customer ID (UUID format)"
-// @Success 200 {object} CustomerResponse "Successfully retrieved customer"
-// @Failure 400 {object} ErrorResponse "Invalid Customer ID format"
-// @Failure 404 {object} ErrorResponse "Customer not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve customer"
+// @Success 200 {object} api.CustomerResponse "Successfully retrieved customer"
+// @Failure 400 {object} api.ErrorResponse "Invalid Customer ID format"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve customer"
 // @Router /customers/{id} [get]
 func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if _, err := uuid.Parse(id); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
 	}
 
-	customer, err := h.Repo.GetCustomerByID(id)
+	customer, err := h.Repo.GetCustomerByID(c.Context(), id)
 	if err != nil {
 		// Differentiate between not found and other errors if repo returns specific errors
 		log.Printf("Error getting customer by ID %s: %v", id, err)
 		// For now, assume any error from repo.GetCustomerByID for a non-existent ID might be caught by specific error string check
 		if err.Error() == "customer with ID "+id+" not found" { // This is fragile; better to use custom error types or errors.Is
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to retrieve customer", StatusCode: fiber.StatusInternalServerError})
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve customer", StatusCode: fiber.StatusInternalServerError})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(toCustomerResponse(customer))
+	h.Views.Viewed(c, models.ViewEntityCustomer, id)
+	setLastModified(c, customer.UpdatedAt)
+	response := h.Perms.MaskCustomer(c, toCustomerResponse(customer))
+	response.DisplayNumber = h.Numbers.Number(models.NumberedCustomer, id)
+	return c.Status(fiber.StatusOK).JSON(response)
 }
 
 // UpdateCustomer handles updating an existing customer.
@@ -197,30 +322,39 @@ func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "Customer ID (UUID format)"
 // @Param customer body UpdateCustomerRequest true "Customer information to update"
-// @Success 200 {object} CustomerResponse "Customer updated successfully"
-// @Failure 400 {object} ErrorResponse "Invalid Customer ID format or invalid request payload"
-// @Failure 404 {object} ErrorResponse "Customer not found"
-// @Failure 500 {object} ErrorResponse "Failed to update customer"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.CustomerResponse "Customer updated successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid Customer ID format or invalid request payload"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update customer"
 // @Router /customers/{id} [put]
 func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if _, err := uuid.Parse(id); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
 	}
 
 	var req UpdateCustomerRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid request payload", StatusCode: fiber.StatusBadRequest})
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request payload", StatusCode: fiber.StatusBadRequest})
 	}
 
 	// TODO: Add validation for req struct
+	if msg := validateDateOfBirth(req.DateOfBirth); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
+	}
 
-	existingCustomer, err := h.Repo.GetCustomerByID(id)
+	existingCustomer, err := h.Repo.GetCustomerByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error finding customer %s for update: %v", id, err)
 		// Differentiate error types if possible
-		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+	}
+	if modified, err := rejectIfModified(c, existingCustomer.UpdatedAt); modified {
+		return err
 	}
+	before := *existingCustomer
 
 	// Apply updates from request if fields are provided
 	if req.FullName != "" {
@@ -235,15 +369,37 @@ func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
 	if req.Address != "" {
 		existingCustomer.Address = req.Address
 	}
+	if req.DateOfBirth != "" {
+		existingCustomer.DateOfBirth = req.DateOfBirth
+	}
+	if req.Province != "" {
+		existingCustomer.Province = req.Province
+	}
+	if req.City != "" {
+		existingCustomer.City = req.City
+	}
+	if req.Barangay != "" {
+		existingCustomer.Barangay = req.Barangay
+	}
+	if req.Address != "" || req.Province != "" || req.City != "" || req.Barangay != "" {
+		if msg := h.locateAddress(c.Context(), existingCustomer); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
+		}
+	}
 	// Note: ID, DateRegistered, CreatedAt should not be changed here. UpdatedAt is handled by the repo.
 
-	updatedCustomer, err := h.Repo.UpdateCustomer(existingCustomer)
+	updatedCustomer, err := h.Repo.UpdateCustomer(c.Context(), existingCustomer)
 	if err != nil {
 		log.Printf("Error updating customer %s: %v", id, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to update customer", StatusCode: fiber.StatusInternalServerError})
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update customer", StatusCode: fiber.StatusInternalServerError})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(toCustomerResponse(updatedCustomer))
+	h.Audit.RecordUpdate(c, AuditEntityCustomer, id, before, updatedCustomer)
+	setLastModified(c, updatedCustomer.UpdatedAt)
+
+	response := h.Perms.MaskCustomer(c, toCustomerResponse(updatedCustomer))
+	response.DisplayNumber = h.Numbers.Number(models.NumberedCustomer, id)
+	return c.Status(fiber.StatusOK).JSON(response)
 }
 
 // DeleteCustomer handles deleting a customer by ID.
@@ -253,26 +409,28 @@ func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "Customer ID (UUID format)"
-// @Success 204 "Customer deleted successfully (No Content)"
-// @Failure 400 {object} ErrorResponse "Invalid Customer ID format"
-// @Failure 404 {object} ErrorResponse "Customer not found"
-// @Failure 500 {object} ErrorResponse "Failed to delete customer"
+// @Success 204 "Customer deleted successfully (No Content). The X-Undo-Token header holds a token for POST /undo/{token}, valid until X-Undo-Expires-At."
+// @Failure 400 {object} api.ErrorResponse "Invalid Customer ID format"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete customer"
 // @Router /customers/{id} [delete]
 func (h *CustomerHandler) DeleteCustomer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if _, err := uuid.Parse(id); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
 	}
 
-	err := h.Repo.DeleteCustomer(id)
+	err := h.Repo.DeleteCustomer(c.Context(), id)
 	if err != nil {
 		log.Printf("Error deleting customer %s: %v", id, err)
 		// Check if the error indicates "not found"
 		if err.Error() == "customer with ID "+id+" not found for deletion" { // Fragile check
-			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to delete customer", StatusCode: fiber.StatusInternalServerError})
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete customer", StatusCode: fiber.StatusInternalServerError})
 	}
 
+	h.Trash.Deleted(c, models.TrashCustomer, id)
+	h.Undo.Offer(c, AuditEntityCustomer, id)
 	return c.SendStatus(fiber.StatusNoContent)
 }
@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
+	"oop/internal/services"
 	"testing"
 	"time"
 
@@ -20,11 +23,13 @@ import (
 )
 
 // MockCustomerRepository is a mock type for the CustomerRepository interface
+// The context is not recorded with the calls: handlers pass the request's fasthttp
+// context, which is reused once the request ends.
 type MockCustomerRepository struct {
 	mock.Mock
 }
 
-func (m *MockCustomerRepository) CreateCustomer(customer *models.Customer) (*models.Customer, error) {
+func (m *MockCustomerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
 	args := m.Called(customer)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -32,7 +37,7 @@ func (m *MockCustomerRepository) CreateCustomer(customer *models.Customer) (*mod
 	return args.Get(0).(*models.Customer), args.Error(1)
 }
 
-func (m *MockCustomerRepository) GetCustomerByID(id string) (*models.Customer, error) {
+func (m *MockCustomerRepository) GetCustomerByID(ctx context.Context, id string) (*models.Customer, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -40,7 +45,7 @@ func (m *MockCustomerRepository) GetCustomerByID(id string) (*models.Customer, e
 	return args.Get(0).(*models.Customer), args.Error(1)
 }
 
-func (m *MockCustomerRepository) GetAllCustomers() ([]*models.Customer, error) {
+func (m *MockCustomerRepository) GetAllCustomers(ctx context.Context) ([]*models.Customer, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -48,7 +53,15 @@ func (m *MockCustomerRepository) GetAllCustomers() ([]*models.Customer, error) {
 	return args.Get(0).([]*models.Customer), args.Error(1)
 }
 
-func (m *MockCustomerRepository) UpdateCustomer(customer *models.Customer) (*models.Customer, error) {
+func (m *MockCustomerRepository) GetPaginated(ctx context.Context, page, limit int, search string) ([]*models.Customer, int64, error) {
+	args := m.Called(page, limit, search)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]*models.Customer), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockCustomerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
 	args := m.Called(customer)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -56,12 +69,17 @@ func (m *MockCustomerRepository) UpdateCustomer(customer *models.Customer) (*mod
 	return args.Get(0).(*models.Customer), args.Error(1)
 }
 
-func (m *MockCustomerRepository) DeleteCustomer(id string) error {
+func (m *MockCustomerRepository) DeleteCustomer(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCustomerRepository) RestoreCustomer(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockCustomerRepository) GetCustomerByEmail(email string) (*models.Customer, error) {
+func (m *MockCustomerRepository) GetCustomerByEmail(ctx context.Context, email string) (*models.Customer, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -85,18 +103,18 @@ func setupCustomerTestApp(repo repositories.CustomerRepository, jwtSecret []byte
 	h := NewCustomerHandler(repo, jwtSecret)
 	// Assuming routes are registered under /api like in other tests
 	// If your actual routes are different, adjust this group path.
-	// Based on RegisterCustomerRoutes, it seems it's r.Group("/customers", authRequired)
+	// Based on Routes, it seems it's r.Group("/customers", authRequired)
 	// So if 'r' is app.Group("/api"), then it would be /api/customers
-	// For simplicity, let's assume the main app will group it under /api and pass that to RegisterCustomerRoutes
-	// Or, the RegisterCustomerRoutes gets the app itself.
+	// For simplicity, let's assume the main app will group it under /api and pass that to Routes
+	// Or, the Routes gets the app itself.
 	// Let's follow the material_handlers_test.go pattern
 	apiGroup := app.Group("/api") // If your routes are not prefixed with /api, adjust this.
-	h.RegisterCustomerRoutes(apiGroup)
+	h.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
 	return app
 }
 
-// Helper to compare CustomerResponse, ignoring time-sensitive fields if necessary
-// For CustomerResponse, all time fields are strings, so direct comparison should work if mocks are consistent.
+// Helper to compare api.CustomerResponse, ignoring time-sensitive fields if necessary
+// For api.CustomerResponse, all time fields are strings, so direct comparison should work if mocks are consistent.
 
 func TestCreateCustomerHandler(t *testing.T) {
 	mockRepo := new(MockCustomerRepository)
@@ -146,7 +164,7 @@ func TestCreateCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-		var actualResponse CustomerResponse
+		var actualResponse api.CustomerResponse
 		err = json.NewDecoder(resp.Body).Decode(&actualResponse)
 		assert.NoError(t, err)
 
@@ -172,7 +190,7 @@ func TestCreateCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Invalid request payload", errResp.Error)
@@ -190,7 +208,7 @@ func TestCreateCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "FullName, email, and phone are required", errResp.Error)
@@ -209,7 +227,7 @@ func TestCreateCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Failed to create customer", errResp.Error)
@@ -238,7 +256,7 @@ func TestGetAllCustomersHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var listResponse CustomerListResponse
+		var listResponse api.CustomerListResponse
 		err = json.NewDecoder(resp.Body).Decode(&listResponse)
 		assert.NoError(t, err)
 
@@ -253,6 +271,26 @@ func TestGetAllCustomersHandler(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Paginated Search", func(t *testing.T) {
+		mockRepo.On("GetPaginated", 1, 1, "cust").Return(expectedCustomersModel[:1], int64(2), nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/customers?limit=1&search=cust", nil)
+		req.Header.Set("Authorization", "Bearer "+testToken)
+
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var listResponse api.CustomerListResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&listResponse))
+		assert.Len(t, listResponse.Customers, 1)
+		assert.Equal(t, 1, listResponse.Page)
+		assert.Equal(t, 1, listResponse.PageSize)
+		assert.Equal(t, int64(2), listResponse.Total)
+		assert.Equal(t, 2, listResponse.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("Repository Error", func(t *testing.T) {
 		mockRepo.On("GetAllCustomers").Return(nil, errors.New("db error fetching all")).Once()
 
@@ -263,7 +301,7 @@ func TestGetAllCustomersHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Failed to retrieve customers", errResp.Error)
@@ -298,7 +336,7 @@ func TestGetCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var actualResponse CustomerResponse
+		var actualResponse api.CustomerResponse
 		err = json.NewDecoder(resp.Body).Decode(&actualResponse)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedCustomerModel.ID, actualResponse.ID)
@@ -316,7 +354,7 @@ func TestGetCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Invalid Customer ID format", errResp.Error)
@@ -337,7 +375,7 @@ func TestGetCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Customer not found", errResp.Error)
@@ -355,7 +393,7 @@ func TestGetCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Failed to retrieve customer", errResp.Error)
@@ -425,7 +463,7 @@ func TestUpdateCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var actualResponse CustomerResponse
+		var actualResponse api.CustomerResponse
 		err = json.NewDecoder(resp.Body).Decode(&actualResponse)
 		assert.NoError(t, err)
 		assert.Equal(t, customerID, actualResponse.ID)
@@ -471,7 +509,7 @@ func TestUpdateCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var actualResponse CustomerResponse
+		var actualResponse api.CustomerResponse
 		err = json.NewDecoder(resp.Body).Decode(&actualResponse)
 		assert.NoError(t, err)
 		assert.Equal(t, customerID, actualResponse.ID)
@@ -541,6 +579,42 @@ func TestUpdateCustomerHandler(t *testing.T) {
 	})
 }
 
+func TestUpdateCustomerHandler_RecordsChanges(t *testing.T) {
+	mockRepo := new(MockCustomerRepository)
+	mockLogs := new(MockLogsRepository)
+	jwtSecret := []byte("testsecret")
+	testToken, _ := createCustomerTestToken(jwtSecret, "user-id-123", "admin")
+
+	app := fiber.New()
+	h := NewCustomerHandler(mockRepo, jwtSecret)
+	h.Audit = NewChangeRecorder(mockLogs)
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+
+	customerID := uuid.New().String()
+	existing := &models.Customer{ID: customerID, FullName: "Original Name", Phone: "+1000000000"}
+	updated := *existing
+	updated.Phone = "+2000000000"
+
+	mockRepo.On("GetCustomerByID", customerID).Return(existing, nil).Once()
+	mockRepo.On("UpdateCustomer", mock.AnythingOfType("*models.Customer")).Return(&updated, nil).Once()
+	mockLogs.On("Create", mock.MatchedBy(func(l *models.ActivityLog) bool {
+		return l.User == "user-id-123" && l.EntityType == AuditEntityCustomer && l.EntityID == customerID &&
+			len(l.Changes) == 1 && l.Changes[0].Field == "phone" &&
+			l.Changes[0].Before == services.MaskedValue && l.Changes[0].After == services.MaskedValue
+	})).Return(nil).Once()
+
+	bodyBytes, _ := json.Marshal(UpdateCustomerRequest{Phone: "+2000000000"})
+	req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/customers/%s", customerID), bytes.NewReader(bodyBytes))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+testToken)
+
+	resp, err := app.Test(req, -1)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	mockRepo.AssertExpectations(t)
+	mockLogs.AssertExpectations(t)
+}
+
 func TestDeleteCustomerHandler(t *testing.T) {
 	mockRepo := new(MockCustomerRepository)
 	jwtSecret := []byte("testsecret")
@@ -573,7 +647,7 @@ func TestDeleteCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Invalid Customer ID format", errResp.Error)
@@ -593,7 +667,7 @@ func TestDeleteCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Customer not found", errResp.Error)
@@ -612,7 +686,7 @@ func TestDeleteCustomerHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 
-		var errResp ErrorResponse
+		var errResp api.ErrorResponse
 		err = json.NewDecoder(resp.Body).Decode(&errResp)
 		assert.NoError(t, err)
 		assert.Equal(t, "Failed to delete customer", errResp.Error)
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxConsentSourceLength is the longest source a consent change can be given
+const maxConsentSourceLength = 100
+
+// AuditActionUpdateConsent is the activity log action of changing a customer's consent
+const AuditActionUpdateConsent = "UPDATE_CUSTOMER_CONSENT"
+
+// contactableCustomers leaves out the customers campaigns must not reach: those who did
+// not consent to marketing and those who asked not to be contacted. Without a consent
+// repository nobody has consented.
+func contactableCustomers(consents repositories.CustomerConsentRepository, customers []*models.Customer) ([]*models.Customer, error) {
+	contactable := []*models.Customer{}
+	if consents == nil {
+		return contactable, nil
+	}
+	all, err := consents.GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer consents: %w", err)
+	}
+	allowed := make(map[string]bool, len(all))
+	for _, consent := range all {
+		allowed[consent.CustomerID] = consent.MarketingConsent && !consent.DoNotContact
+	}
+	for _, customer := range customers {
+		if allowed[customer.ID] {
+			contactable = append(contactable, customer)
+		}
+	}
+	return contactable, nil
+}
+
+// loadConsentCustomer validates the ID and loads the customer whose consent is read or
+// changed. It writes the error response itself and returns a nil customer when the
+// request must stop.
+func (h *CustomerHandler) loadConsentCustomer(c *fiber.Ctx) (*models.Customer, error) {
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
+	}
+	if h.Consents == nil {
+		return nil, c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "Customer consent is not configured", StatusCode: fiber.StatusServiceUnavailable})
+	}
+
+	customer, err := h.Repo.GetCustomerByID(c.Context(), id)
+	if err != nil {
+		log.Printf("Error getting customer by ID %s: %v", id, err)
+		if err.Error() == "customer with ID "+id+" not found" {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+		}
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve customer", StatusCode: fiber.StatusInternalServerError})
+	}
+	return customer, nil
+}
+
+// GetCustomerConsent handles reading a customer's consent
+// @Summary Get a customer's consent
+// @Description Returns whether the customer consented to marketing and whether they asked not to be contacted, with when and how each was last set. Customers without recorded flags have not consented.
+// @Tags Customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID (UUID format)"
+// @Success 200 {object} models.CustomerConsent "Consent of the customer"
+// @Failure 400 {object} api.ErrorResponse "Invalid Customer ID format"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve consent"
+// @Router /customers/{id}/consent [get]
+func (h *CustomerHandler) GetCustomerConsent(c *fiber.Ctx) error {
+	customer, err := h.loadConsentCustomer(c)
+	if customer == nil {
+		return err
+	}
+
+	consent, err := h.Consents.Get(customer.ID)
+	if err != nil {
+		log.Printf("Error getting consent of customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve consent", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(consent)
+}
+
+// UpdateCustomerConsent handles setting a customer's consent
+// @Summary Set a customer's consent
+// @Description Sets whether the customer consents to marketing, whether they asked not to be contacted, or both, stamping each flag set with the time and source. Flags left out keep their value. Campaigns, such as the upcoming birthdays list, its export and notices, only reach customers who consented and did not ask not to be contacted. Changes are recorded in the activity log.
+// @Tags Customers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID (UUID format)"
+// @Param consent body api.UpdateCustomerConsentRequest true "Flags to set and their source"
+// @Success 200 {object} models.CustomerConsent "Consent updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload, no flag or missing source"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update consent"
+// @Router /customers/{id}/consent [put]
+func (h *CustomerHandler) UpdateCustomerConsent(c *fiber.Ctx) error {
+	var req api.UpdateCustomerConsentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request payload", StatusCode: fiber.StatusBadRequest})
+	}
+	if req.MarketingConsent == nil && req.DoNotContact == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "marketingConsent or doNotContact is required", StatusCode: fiber.StatusBadRequest})
+	}
+	source := strings.TrimSpace(req.Source)
+	if source == "" || len(source) > maxConsentSourceLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("source is required, up to %d characters", maxConsentSourceLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	customer, err := h.loadConsentCustomer(c)
+	if customer == nil {
+		return err
+	}
+	consent, err := h.Consents.Get(customer.ID)
+	if err != nil {
+		log.Printf("Error getting consent of customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update consent", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	now := h.now()
+	var changes []string
+	if req.MarketingConsent != nil {
+		consent.MarketingConsent = *req.MarketingConsent
+		consent.MarketingConsentAt = &now
+		consent.MarketingConsentSource = source
+		changes = append(changes, fmt.Sprintf("marketing consent to %t", consent.MarketingConsent))
+	}
+	if req.DoNotContact != nil {
+		consent.DoNotContact = *req.DoNotContact
+		consent.DoNotContactAt = &now
+		consent.DoNotContactSource = source
+		changes = append(changes, fmt.Sprintf("do not contact to %t", consent.DoNotContact))
+	}
+	consent.UpdatedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Consents.Save(consent); err != nil {
+		log.Printf("Error saving consent of customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update consent", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, AuditActionUpdateConsent, AuditEntityCustomer, customer.ID,
+		fmt.Sprintf("Set %s (source: %s)", strings.Join(changes, " and "), source))
+	return c.Status(fiber.StatusOK).JSON(consent)
+}
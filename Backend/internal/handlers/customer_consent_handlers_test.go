@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerConsent(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewCustomerHandler(store.Customers, jwtSecret)
+	h.Consents = store.Consents
+	h.Audit = NewChangeRecorder(store.Logs)
+	now := time.Date(2025, time.March, 12, 9, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Maria Santos", Email: "maria@example.com", Phone: "+639171234567"})
+	require.NoError(t, err)
+	path := "/api/customers/" + customer.ID + "/consent"
+	decode := func(resp *http.Response) models.CustomerConsent {
+		var consent models.CustomerConsent
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&consent))
+		return consent
+	}
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	consent := decode(resp)
+	assert.False(t, consent.MarketingConsent, "customers have not consented until recorded")
+	assert.Nil(t, consent.MarketingConsentAt)
+
+	yes, no := true, false
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, api.UpdateCustomerConsentRequest{Source: "in_store_form"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a flag is required")
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, api.UpdateCustomerConsentRequest{MarketingConsent: &yes, Source: "  "})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "the source is required")
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/customers/"+uuid.NewString()+"/consent", api.UpdateCustomerConsentRequest{MarketingConsent: &yes, Source: "in_store_form"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, api.UpdateCustomerConsentRequest{MarketingConsent: &yes, DoNotContact: &no, Source: "in_store_form"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	consent = decode(resp)
+	assert.True(t, consent.MarketingConsent)
+	require.NotNil(t, consent.MarketingConsentAt)
+	assert.True(t, now.Equal(*consent.MarketingConsentAt))
+	assert.Equal(t, "in_store_form", consent.MarketingConsentSource)
+	assert.Equal(t, "staff-1", consent.UpdatedBy)
+
+	now = now.Add(24 * time.Hour)
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, api.UpdateCustomerConsentRequest{DoNotContact: &yes, Source: "phone_call"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	consent = decode(resp)
+	assert.True(t, consent.MarketingConsent, "flags left out keep their value")
+	assert.Equal(t, "in_store_form", consent.MarketingConsentSource)
+	assert.True(t, consent.DoNotContact)
+	assert.True(t, now.Equal(*consent.DoNotContactAt))
+	assert.Equal(t, "phone_call", consent.DoNotContactSource)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, AuditActionUpdateConsent, logs[0].Action)
+	assert.Equal(t, customer.ID, logs[0].EntityID)
+	assert.Equal(t, "Set do not contact to true (source: phone_call)", logs[0].Details)
+
+	contactable, err := contactableCustomers(store.Consents, []*models.Customer{customer})
+	require.NoError(t, err)
+	assert.Empty(t, contactable, "asking not to be contacted overrides consent")
+	contactable, err = contactableCustomers(nil, []*models.Customer{customer})
+	require.NoError(t, err)
+	assert.Empty(t, contactable)
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationCustomerOccasions is pushed to the sales team ahead of customers'
+// birthdays and anniversaries
+const NotificationCustomerOccasions = "customer_occasions"
+
+// Days of upcoming birthdays and anniversaries listed by default, and at most
+const (
+	defaultCustomerOccasionDays = 30
+	maxCustomerOccasionDays     = 366
+)
+
+// occasionAll asks for both birthdays and anniversaries
+const occasionAll = "all"
+
+// parseCustomerOccasionFilters validates the days and occasion of a list of upcoming
+// birthdays and anniversaries, defaulting to the birthdays of the next 30 days. The
+// occasion is returned as services.CustomerOccasions takes it, "" for both. A message is
+// returned when they are invalid.
+func parseCustomerOccasionFilters(rawDays, rawOccasion string) (int, string, string) {
+	days := defaultCustomerOccasionDays
+	if rawDays != "" {
+		parsed, err := strconv.Atoi(rawDays)
+		if err != nil || parsed < 1 || parsed > maxCustomerOccasionDays {
+			return 0, "", fmt.Sprintf("days must be between 1 and %d", maxCustomerOccasionDays)
+		}
+		days = parsed
+	}
+
+	switch rawOccasion {
+	case "", models.OccasionBirthday:
+		return days, models.OccasionBirthday, ""
+	case models.OccasionAnniversary:
+		return days, models.OccasionAnniversary, ""
+	case occasionAll:
+		return days, "", ""
+	}
+	return 0, "", "occasion must be birthday, anniversary or all"
+}
+
+// upcomingCustomerOccasions returns the occasions of customers falling within days days
+// from from, leaving out anonymized customers, whose personal data was erased
+func upcomingCustomerOccasions(customers []*models.Customer, from time.Time, days int, occasion string) []models.CustomerOccasion {
+	greeted := make([]*models.Customer, 0, len(customers))
+	for _, customer := range customers {
+		if !isAnonymizedCustomer(customer) {
+			greeted = append(greeted, customer)
+		}
+	}
+	return services.CustomerOccasions(greeted, from, days, occasion)
+}
+
+// GetUpcomingBirthdays handles listing upcoming customer birthdays and anniversaries
+// @Summary List upcoming customer birthdays and anniversaries
+// @Description Lists the customers whose birthday, or anniversary of registering, falls within the next days days, today included, soonest first, for greeting and promotion campaigns. Customers born on February 29 are listed on February 28 in other years. Only customers who consented to marketing and did not ask not to be contacted are listed; anonymized customers are left out too. Contact details and dates of birth are masked for callers without the customers.pii permission.
+// @Tags Customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "Days ahead, from 1 to 366 (default 30)"
+// @Param occasion query string false "birthday (default), anniversary or all"
+// @Success 200 {object} api.UpcomingOccasionsResponse "Upcoming birthdays and anniversaries"
+// @Failure 400 {object} api.ErrorResponse "Invalid days or occasion"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve upcoming birthdays"
+// @Router /customers/upcoming-birthdays [get]
+func (h *CustomerHandler) GetUpcomingBirthdays(c *fiber.Ctx) error {
+	days, occasion, msg := parseCustomerOccasionFilters(c.Query("days"), c.Query("occasion"))
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
+	}
+
+	customers, err := h.Repo.GetAllCustomers(c.Context())
+	if err != nil {
+		log.Printf("Error getting customers for upcoming birthdays: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve upcoming birthdays", StatusCode: fiber.StatusInternalServerError})
+	}
+	customers, err = contactableCustomers(h.Consents, customers)
+	if err != nil {
+		log.Printf("Error checking the consent of customers for upcoming birthdays: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve upcoming birthdays", StatusCode: fiber.StatusInternalServerError})
+	}
+	byID := make(map[string]*models.Customer, len(customers))
+	for _, customer := range customers {
+		byID[customer.ID] = customer
+	}
+
+	from := h.now()
+	occasions := upcomingCustomerOccasions(customers, from, days, occasion)
+	responses := make([]api.CustomerOccasionResponse, len(occasions))
+	for i, found := range occasions {
+		responses[i] = api.CustomerOccasionResponse{CustomerOccasion: found, Customer: h.Perms.MaskCustomer(c, toCustomerResponse(byID[found.CustomerID]))}
+	}
+	if occasion == "" {
+		occasion = occasionAll
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.UpcomingOccasionsResponse{
+		From:      from.Format(saleDateLayout),
+		Days:      days,
+		Occasion:  occasion,
+		Occasions: responses,
+		Count:     len(responses),
+	})
+}
+
+// NotifyUpcomingOccasions pushes the birthdays and anniversaries of customers who can be
+// contacted falling daysAhead days from now to the active users of the tenant, so the
+// sales team can prepare greetings. It returns the occasions notified of.
+func (h *CustomerHandler) NotifyUpcomingOccasions(ctx context.Context, tenantID string, users UserRepository, hub *services.NotificationHub, daysAhead int) ([]models.CustomerOccasion, error) {
+	customers, err := h.Repo.GetAllCustomers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+	if customers, err = contactableCustomers(h.Consents, customers); err != nil {
+		return nil, err
+	}
+	day := h.now().AddDate(0, 0, daysAhead)
+	occasions := upcomingCustomerOccasions(customers, day, 1, "")
+	if len(occasions) == 0 || hub == nil {
+		return occasions, nil
+	}
+
+	everyone := func(*models.User) bool { return true }
+	notification := models.Notification{
+		Type: NotificationCustomerOccasions,
+		Data: api.CustomerOccasionsEvent{Date: day.Format(saleDateLayout), Occasions: occasions},
+	}
+	if err := notifyUsers(ctx, users, hub, tenantID, everyone, notification); err != nil {
+		return nil, err
+	}
+	return occasions, nil
+}
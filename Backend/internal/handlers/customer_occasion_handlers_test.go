@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpcomingBirthdays(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewCustomerHandler(store.Customers, jwtSecret)
+	h.Consents = store.Consents
+	h.now = func() time.Time { return time.Date(2025, time.March, 12, 9, 0, 0, 0, time.Local) }
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	yes, no := true, false
+	for _, customer := range []struct {
+		CreateCustomerRequest
+		consent api.UpdateCustomerConsentRequest
+	}{
+		{CreateCustomerRequest{FullName: "Maria Santos", Email: "maria@example.com", Phone: "+639171234567", DateOfBirth: "1990-03-14"}, api.UpdateCustomerConsentRequest{MarketingConsent: &yes}},
+		{CreateCustomerRequest{FullName: "Ana Reyes", Email: "ana@example.com", Phone: "+639181234567", DateOfBirth: "1975-03-12"}, api.UpdateCustomerConsentRequest{MarketingConsent: &yes, DoNotContact: &no}},
+		{CreateCustomerRequest{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "+639191234567", DateOfBirth: "1988-05-01"}, api.UpdateCustomerConsentRequest{MarketingConsent: &yes}},
+		{CreateCustomerRequest{FullName: "No Birthday", Email: "none@example.com", Phone: "+639201234567"}, api.UpdateCustomerConsentRequest{MarketingConsent: &yes}},
+		{CreateCustomerRequest{FullName: "Never Asked", Email: "never@example.com", Phone: "+639231234567", DateOfBirth: "1985-03-13"}, api.UpdateCustomerConsentRequest{}},
+		{CreateCustomerRequest{FullName: "Opted Out", Email: "out@example.com", Phone: "+639241234567", DateOfBirth: "1980-03-13"}, api.UpdateCustomerConsentRequest{MarketingConsent: &yes, DoNotContact: &yes}},
+	} {
+		resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/customers", customer.CreateCustomerRequest)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created api.CustomerResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		if customer.consent.MarketingConsent != nil {
+			customer.consent.Source = "in_store_form"
+			resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/customers/"+created.ID+"/consent", customer.consent)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	}
+	resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/customers", CreateCustomerRequest{FullName: "Unborn", Email: "unborn@example.com", Phone: "+639211234567", DateOfBirth: "2999-01-01"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "dates of birth cannot be in the future")
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/customers", CreateCustomerRequest{FullName: "Typo", Email: "typo@example.com", Phone: "+639221234567", DateOfBirth: "14/03/1990"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/customers/upcoming-birthdays?days=7", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var upcoming api.UpcomingOccasionsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&upcoming))
+	assert.Equal(t, "2025-03-12", upcoming.From)
+	assert.Equal(t, models.OccasionBirthday, upcoming.Occasion)
+	require.Equal(t, 2, upcoming.Count, "birthdays after the window, and of customers who did not consent or asked not to be contacted, are left out")
+	assert.Equal(t, "Ana Reyes", upcoming.Occasions[0].FullName)
+	assert.Equal(t, 0, upcoming.Occasions[0].DaysAway)
+	assert.Equal(t, 50, upcoming.Occasions[0].Years)
+	assert.Equal(t, "2025-03-14", upcoming.Occasions[1].Date)
+	assert.Equal(t, "maria@example.com", upcoming.Occasions[1].Customer.Email)
+	assert.Equal(t, "1990-03-14", upcoming.Occasions[1].Customer.DateOfBirth)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/customers/upcoming-birthdays?days=7&occasion=all", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	upcoming = api.UpcomingOccasionsResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&upcoming))
+	assert.Equal(t, "all", upcoming.Occasion)
+	require.Equal(t, 2, upcoming.Count, "customers registered this year have no anniversary yet")
+	assert.Equal(t, "m•••@example.com", upcoming.Occasions[1].Customer.Email, "staff without the PII permission get masked contact details")
+	assert.Equal(t, "•••-03-14", upcoming.Occasions[1].Customer.DateOfBirth)
+
+	for _, query := range []string{"days=0", "days=367", "occasion=wedding"} {
+		resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/customers/upcoming-birthdays?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	// The sales team is told a week ahead
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "staff-1", Username: "staff", Email: "staff@example.com", Password: "secret123", Role: RoleStaff, IsActive: true}))
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "staff-2", Username: "former", Email: "former@example.com", Password: "secret123", Role: RoleStaff}))
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), "staff-2"))
+	hub := services.NewNotificationHub()
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribe()
+	inactive, unsubscribeInactive := hub.Subscribe(models.DefaultTenantID, "staff-2")
+	defer unsubscribeInactive()
+
+	h.now = func() time.Time { return time.Date(2025, time.March, 7, 9, 0, 0, 0, time.Local) }
+	occasions, err := h.NotifyUpcomingOccasions(context.Background(), models.DefaultTenantID, store.Users, hub, 7)
+	require.NoError(t, err)
+	require.Len(t, occasions, 1)
+	received := receiveNotifications(notifications)
+	require.Len(t, received, 1)
+	assert.Equal(t, NotificationCustomerOccasions, received[0].Type)
+	event := received[0].Data.(api.CustomerOccasionsEvent)
+	assert.Equal(t, "2025-03-14", event.Date)
+	assert.Equal(t, "Maria Santos", event.Occasions[0].FullName)
+	assert.Empty(t, receiveNotifications(inactive), "inactive users are not told")
+}
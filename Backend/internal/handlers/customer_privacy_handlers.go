@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// anonymizedEmailDomain marks customers whose personal data has been erased.
+// The reserved .invalid TLD guarantees the placeholder address is never deliverable.
+const anonymizedEmailDomain = "@anonymized.invalid"
+
+// isAnonymizedCustomer reports whether a customer's personal data was already erased
+func isAnonymizedCustomer(customer *models.Customer) bool {
+	return strings.HasSuffix(customer.Email, anonymizedEmailDomain)
+}
+
+// loadCustomerForPrivacyRequest validates the ID and role, then loads the customer.
+// It writes the error response itself and returns a nil customer when the request must stop.
+func (h *CustomerHandler) loadCustomerForPrivacyRequest(c *fiber.Ctx) (*models.Customer, error) {
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin {
+		return nil, c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
+	}
+
+	customer, err := h.Repo.GetCustomerByID(c.Context(), id)
+	if err != nil {
+		log.Printf("Error getting customer by ID %s: %v", id, err)
+		if err.Error() == "customer with ID "+id+" not found" {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+		}
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve customer", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return customer, nil
+}
+
+// buildCustomerDataExport gathers the customer record and their full sales history
+func (h *CustomerHandler) buildCustomerDataExport(ctx context.Context, customer *models.Customer) (*api.CustomerDataExport, error) {
+	export := &api.CustomerDataExport{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Customer:   toCustomerResponse(customer),
+		Sales:      []api.CustomerDataExportSale{},
+	}
+	if h.Consents != nil {
+		consent, err := h.Consents.Get(customer.ID)
+		if err != nil {
+			return nil, fmt.Errorf("could not load consent: %w", err)
+		}
+		export.Consent = consent
+	}
+	if h.Sales == nil {
+		return export, nil
+	}
+
+	sales, err := h.Sales.GetCustomerSales(ctx, customer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not load sales: %w", err)
+	}
+
+	for _, sale := range sales {
+		items, err := h.Sales.GetSaleItems(ctx, sale.ID)
+		if err != nil {
+			return nil, fmt.Errorf("could not load items for sale %s: %w", sale.ID, err)
+		}
+
+		exportSale := api.CustomerDataExportSale{
+			ID:         sale.ID,
+			SoldBy:     sale.SoldBy,
+			SaleDate:   sale.SaleDate,
+			TotalPrice: sale.TotalPrice,
+			CreatedAt:  sale.CreatedAt.Format(time.RFC3339),
+			Items:      make([]api.CustomerDataExportSaleItem, 0, len(items)),
+		}
+		for _, item := range items {
+			multiCabID, accessoryID, materialID := models.SaleItemColumns(item.Item)
+			exportSale.Items = append(exportSale.Items, api.CustomerDataExportSaleItem{
+				ItemRef:     string(item.Item),
+				ItemType:    item.ItemType(),
+				MultiCabID:  multiCabID,
+				AccessoryID: accessoryID,
+				MaterialID:  materialID,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+				Subtotal:    item.Subtotal,
+			})
+		}
+		export.Sales = append(export.Sales, exportSale)
+	}
+
+	return export, nil
+}
+
+// zipCustomerDataExport packages an export as customer.json and sales.json
+func zipCustomerDataExport(export *api.CustomerDataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"customer.json", struct {
+			ExportedAt string                `json:"exportedAt"`
+			Customer   *api.CustomerResponse `json:"customer"`
+		}{export.ExportedAt, export.Customer}},
+		{"sales.json", export.Sales},
+	}
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportCustomerData handles exporting all personal data stored for a customer.
+// @Summary Export customer data (Admin)
+// @Description Returns all personal data, the marketing consent and do-not-contact flags and the sales history of a customer, as JSON or as a ZIP archive with format=zip.
+// @Tags Customers
+// @Produce json
+// @Produce application/zip
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID (UUID format)"
+// @Param format query string false "Export format: json (default) or zip"
+// @Success 200 {object} api.CustomerDataExport "Customer data export"
+// @Failure 400 {object} api.ErrorResponse "Invalid Customer ID format or export format"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to export customer data"
+// @Router /customers/{id}/data-export [get]
+func (h *CustomerHandler) ExportCustomerData(c *fiber.Ctx) error {
+	format := strings.ToLower(c.Query("format", "json"))
+	if format != "json" && format != "zip" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid export format, expected json or zip", StatusCode: fiber.StatusBadRequest})
+	}
+
+	customer, err := h.loadCustomerForPrivacyRequest(c)
+	if customer == nil {
+		return err
+	}
+
+	export, err := h.buildCustomerDataExport(c.Context(), customer)
+	if err != nil {
+		log.Printf("Error exporting data for customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to export customer data", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "EXPORT_CUSTOMER_DATA", AuditEntityCustomer, customer.ID,
+		fmt.Sprintf("Exported personal data of customer %s as %s (%d sales)", customer.ID, format, len(export.Sales)))
+
+	if format == "json" {
+		return c.Status(fiber.StatusOK).JSON(export)
+	}
+
+	archive, err := zipCustomerDataExport(export)
+	if err != nil {
+		log.Printf("Error packaging data export for customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to export customer data", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="customer-%s-export.zip"`, customer.ID))
+	return c.Status(fiber.StatusOK).Send(archive)
+}
+
+// AnonymizeCustomer handles irreversibly erasing a customer's personal data.
+// @Summary Anonymize a customer (Admin)
+// @Description Replaces the name, email, phone and address of a customer with placeholders and clears the date of birth. Sales stay linked to the record so sales statistics are unaffected. This cannot be undone.
+// @Tags Customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Customer ID (UUID format)"
+// @Success 200 {object} api.CustomerResponse "Customer anonymized successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid Customer ID format"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 409 {object} api.ErrorResponse "Customer is already anonymized"
+// @Failure 500 {object} api.ErrorResponse "Failed to anonymize customer"
+// @Router /customers/{id}/anonymize [post]
+func (h *CustomerHandler) AnonymizeCustomer(c *fiber.Ctx) error {
+	customer, err := h.loadCustomerForPrivacyRequest(c)
+	if customer == nil {
+		return err
+	}
+
+	if isAnonymizedCustomer(customer) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Customer is already anonymized", StatusCode: fiber.StatusConflict})
+	}
+
+	// The placeholder is random rather than derived from the old values, so it can't be reversed.
+	// The customer ID keeps the email unique without revealing anything about the person.
+	pseudonym, err := generateToken()
+	if err != nil {
+		log.Printf("Error generating pseudonym for customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to anonymize customer", StatusCode: fiber.StatusInternalServerError})
+	}
+	customer.FullName = "Anonymized Customer " + pseudonym[:8]
+	customer.Email = "customer-" + customer.ID + anonymizedEmailDomain
+	customer.Phone = ""
+	customer.Address = ""
+	// The province and city are kept for regional reports; anything finer locates the person
+	customer.Barangay = ""
+	customer.Latitude, customer.Longitude = nil, nil
+	customer.DateOfBirth = ""
+
+	anonymized, err := h.Repo.UpdateCustomer(c.Context(), customer)
+	if err != nil {
+		log.Printf("Error anonymizing customer %s: %v", customer.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to anonymize customer", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Only the action is logged; recording the old values would copy the erased data into the audit log.
+	// Diffs of earlier updates mask personal fields too, except those chained before the masking.
+	h.Audit.RecordAction(c, "ANONYMIZE_CUSTOMER", AuditEntityCustomer, customer.ID,
+		fmt.Sprintf("Anonymized customer %s: fullName, email, phone, address, barangay, coordinates, dateOfBirth", customer.ID))
+
+	return c.Status(fiber.StatusOK).JSON(toCustomerResponse(anonymized))
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"oop/internal/api"
+	"oop/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const privacyTestCustomerID = "5f0c6f4e-8d4b-4c4a-9a53-2b7f1f3f9c10"
+
+func setupCustomerPrivacyTest() (*fiber.App, *MockCustomerRepository, *MockSaleRepository, *MockLogsRepository, []byte) {
+	jwtSecret := []byte("testsecret")
+	customerRepo := new(MockCustomerRepository)
+	saleRepo := new(MockSaleRepository)
+	logsRepo := new(MockLogsRepository)
+
+	h := NewCustomerHandler(customerRepo, jwtSecret)
+	h.Sales = saleRepo
+	h.Audit = NewChangeRecorder(logsRepo)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, customerRepo, saleRepo, logsRepo, jwtSecret
+}
+
+func privacyTestCustomer() *models.Customer {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &models.Customer{
+		ID:             privacyTestCustomerID,
+		FullName:       "Juan Dela Cruz",
+		Email:          "juan@example.com",
+		Phone:          "+639171234567",
+		Address:        "123 Rizal St",
+		DateRegistered: now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func privacyRequest(method, target, token string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+func TestExportCustomerDataHandler(t *testing.T) {
+	target := "/api/customers/" + privacyTestCustomerID + "/data-export"
+	sales := []models.Sale{{ID: "sale-1", CustomerID: privacyTestCustomerID, SoldBy: "staff-1", SaleDate: "2025-01-03", TotalPrice: 1500}}
+	items := []models.SaleItem{{ID: "item-1", SaleID: "sale-1", Item: models.IntRef(models.RefAccessory, 7), Quantity: 3, UnitPrice: 500, Subtotal: 1500}}
+
+	t.Run("JSON export", func(t *testing.T) {
+		app, customerRepo, saleRepo, logsRepo, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(privacyTestCustomer(), nil)
+		saleRepo.On("GetCustomerSales", privacyTestCustomerID).Return(sales, nil)
+		saleRepo.On("GetSaleItems", "sale-1").Return(items, nil)
+		logsRepo.On("Create", mock.MatchedBy(func(l *models.ActivityLog) bool {
+			return l.Action == "EXPORT_CUSTOMER_DATA" && l.User == "admin-id" && l.EntityID == privacyTestCustomerID
+		})).Return(nil)
+
+		resp, err := app.Test(privacyRequest(http.MethodGet, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var export api.CustomerDataExport
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&export))
+		assert.Equal(t, "juan@example.com", export.Customer.Email)
+		assert.Equal(t, "123 Rizal St", export.Customer.Address)
+		if assert.Len(t, export.Sales, 1) {
+			assert.Equal(t, 1500.0, export.Sales[0].TotalPrice)
+			assert.Len(t, export.Sales[0].Items, 1)
+		}
+		customerRepo.AssertExpectations(t)
+		saleRepo.AssertExpectations(t)
+		logsRepo.AssertExpectations(t)
+	})
+
+	t.Run("ZIP export", func(t *testing.T) {
+		app, customerRepo, saleRepo, logsRepo, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(privacyTestCustomer(), nil)
+		saleRepo.On("GetCustomerSales", privacyTestCustomerID).Return(sales, nil)
+		saleRepo.On("GetSaleItems", "sale-1").Return(items, nil)
+		logsRepo.On("Create", mock.Anything).Return(nil)
+
+		resp, err := app.Test(privacyRequest(http.MethodGet, target+"?format=zip", token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "customer-"+privacyTestCustomerID+"-export.zip")
+
+		body, _ := io.ReadAll(resp.Body)
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		assert.NoError(t, err)
+		names := []string{}
+		for _, f := range archive.File {
+			names = append(names, f.Name)
+		}
+		assert.ElementsMatch(t, []string{"customer.json", "sales.json"}, names)
+	})
+
+	t.Run("Invalid format", func(t *testing.T) {
+		app, customerRepo, _, _, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+
+		resp, err := app.Test(privacyRequest(http.MethodGet, target+"?format=xml", token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		customerRepo.AssertNotCalled(t, "GetCustomerByID", mock.Anything)
+	})
+
+	t.Run("Forbidden for staff", func(t *testing.T) {
+		app, customerRepo, _, _, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "staff-id", RoleStaff)
+
+		resp, err := app.Test(privacyRequest(http.MethodGet, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		customerRepo.AssertNotCalled(t, "GetCustomerByID", mock.Anything)
+	})
+
+	t.Run("Customer not found", func(t *testing.T) {
+		app, customerRepo, _, _, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(nil, errors.New("customer with ID "+privacyTestCustomerID+" not found"))
+
+		resp, err := app.Test(privacyRequest(http.MethodGet, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Sales lookup fails", func(t *testing.T) {
+		app, customerRepo, saleRepo, logsRepo, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(privacyTestCustomer(), nil)
+		saleRepo.On("GetCustomerSales", privacyTestCustomerID).Return(nil, errors.New("db error"))
+
+		resp, err := app.Test(privacyRequest(http.MethodGet, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		logsRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestAnonymizeCustomerHandler(t *testing.T) {
+	target := "/api/customers/" + privacyTestCustomerID + "/anonymize"
+
+	t.Run("Success", func(t *testing.T) {
+		app, customerRepo, saleRepo, logsRepo, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(privacyTestCustomer(), nil)
+		customerRepo.On("UpdateCustomer", mock.MatchedBy(func(c *models.Customer) bool {
+			return c.ID == privacyTestCustomerID &&
+				strings.HasPrefix(c.FullName, "Anonymized Customer ") &&
+				c.Email == "customer-"+privacyTestCustomerID+"@anonymized.invalid" &&
+				c.Phone == "" && c.Address == ""
+		})).Return(&models.Customer{
+			ID:       privacyTestCustomerID,
+			FullName: "Anonymized Customer abcd1234",
+			Email:    "customer-" + privacyTestCustomerID + "@anonymized.invalid",
+		}, nil)
+		logsRepo.On("Create", mock.MatchedBy(func(l *models.ActivityLog) bool {
+			// The erased values must not be copied into the audit log
+			return l.Action == "ANONYMIZE_CUSTOMER" && len(l.Changes) == 0 && !strings.Contains(l.Details, "juan")
+		})).Return(nil)
+
+		resp, err := app.Test(privacyRequest(http.MethodPost, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body api.CustomerResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.NotContains(t, body.FullName, "Juan")
+		assert.Empty(t, body.Phone)
+		customerRepo.AssertExpectations(t)
+		logsRepo.AssertExpectations(t)
+		// Sales are left untouched so statistics stay intact
+		saleRepo.AssertNotCalled(t, "Update", mock.Anything)
+		saleRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("Already anonymized", func(t *testing.T) {
+		app, customerRepo, _, _, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customer := privacyTestCustomer()
+		customer.Email = "customer-" + privacyTestCustomerID + "@anonymized.invalid"
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(customer, nil)
+
+		resp, err := app.Test(privacyRequest(http.MethodPost, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		customerRepo.AssertNotCalled(t, "UpdateCustomer", mock.Anything)
+	})
+
+	t.Run("Forbidden for staff", func(t *testing.T) {
+		app, customerRepo, _, _, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "staff-id", RoleStaff)
+
+		resp, err := app.Test(privacyRequest(http.MethodPost, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		customerRepo.AssertNotCalled(t, "GetCustomerByID", mock.Anything)
+	})
+
+	t.Run("Invalid ID", func(t *testing.T) {
+		app, _, _, _, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+
+		resp, err := app.Test(privacyRequest(http.MethodPost, "/api/customers/not-a-uuid/anonymize", token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Update fails", func(t *testing.T) {
+		app, customerRepo, _, logsRepo, secret := setupCustomerPrivacyTest()
+		token, _ := createCustomerTestToken(secret, "admin-id", RoleAdmin)
+		customerRepo.On("GetCustomerByID", privacyTestCustomerID).Return(privacyTestCustomer(), nil)
+		customerRepo.On("UpdateCustomer", mock.Anything).Return(nil, errors.New("db error"))
+
+		resp, err := app.Test(privacyRequest(http.MethodPost, target, token))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		logsRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
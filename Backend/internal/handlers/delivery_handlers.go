@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/repositories"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DeliveryHandler lists the locations customer addresses are validated against and
+// estimates delivery distances and fees for quotes
+type DeliveryHandler struct {
+	Locations *services.PHLocations
+	Customers repositories.CustomerRepository
+	config    config.DeliveryConfig
+	jwtSecret []byte
+}
+
+// NewDeliveryHandler creates a new DeliveryHandler instance
+func NewDeliveryHandler(locations *services.PHLocations, customers repositories.CustomerRepository, cfg config.DeliveryConfig, jwtSecret []byte) *DeliveryHandler {
+	return &DeliveryHandler{
+		Locations: locations,
+		Customers: customers,
+		config:    cfg,
+		jwtSecret: jwtSecret,
+	}
+}
+
+// Routes registers the location and delivery estimate routes
+func (h *DeliveryHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/locations", mw.Auth, h.GetLocations)                // GET /api/locations
+	r.Get("/delivery/estimate", mw.Auth, h.GetDeliveryEstimate) // GET /api/delivery/estimate
+}
+
+// GetLocations lists the provinces and cities of the location dataset
+// @Summary List provinces and cities
+// @Description Lists the provinces, ordered by name, and their cities and municipalities that customer addresses are validated against.
+// @Tags Customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.LocationsResponse "Provinces and cities"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 503 {object} api.ErrorResponse "No location dataset is configured"
+// @Router /locations [get]
+func (h *DeliveryHandler) GetLocations(c *fiber.Ctx) error {
+	if h.Locations == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "No location dataset is configured", StatusCode: fiber.StatusServiceUnavailable})
+	}
+	provinces := h.Locations.Provinces()
+	response := api.LocationsResponse{Provinces: make([]api.LocationProvince, len(provinces))}
+	for i, province := range provinces {
+		cities := make([]string, len(province.Cities))
+		for j, city := range province.Cities {
+			cities[j] = city.Name
+		}
+		response.Provinces[i] = api.LocationProvince{Name: province.Name, Cities: cities}
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// GetDeliveryEstimate estimates the distance and fee of delivering to a customer or a place
+// @Summary Estimate a delivery
+// @Description Estimates the distance from DELIVERY_ORIGIN to a customer's address, or to a province, city and optional barangay, and the fee of delivering there. Customers whose address was geocoded are measured to their coordinates; otherwise the center of the city is used and the estimate is marked approximate. Road distance is the straight-line distance times DELIVERY_ROAD_FACTOR.
+// @Tags Customers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param customerId query string false "Customer to deliver to"
+// @Param province query string false "Province to deliver to, without customerId"
+// @Param city query string false "City or municipality to deliver to, without customerId"
+// @Param barangay query string false "Barangay to deliver to"
+// @Success 200 {object} api.DeliveryEstimateResponse "Delivery estimate"
+// @Failure 400 {object} api.ErrorResponse "Missing or unknown destination"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve customer"
+// @Failure 503 {object} api.ErrorResponse "Delivery estimates are not configured"
+// @Router /delivery/estimate [get]
+func (h *DeliveryHandler) GetDeliveryEstimate(c *fiber.Ctx) error {
+	if !h.config.HasOrigin || h.Locations == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "Delivery estimates are not configured; set DELIVERY_ORIGIN", StatusCode: fiber.StatusServiceUnavailable})
+	}
+
+	response := api.DeliveryEstimateResponse{
+		Province: c.Query("province"),
+		City:     c.Query("city"),
+		Barangay: c.Query("barangay"),
+	}
+	var latitude, longitude *float64
+	if id := c.Query("customerId"); id != "" {
+		if _, err := uuid.Parse(id); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid Customer ID format", StatusCode: fiber.StatusBadRequest})
+		}
+		customer, err := h.Customers.GetCustomerByID(c.Context(), id)
+		if err != nil {
+			log.Printf("Error getting customer %s for a delivery estimate: %v", id, err)
+			if err.Error() == "customer with ID "+id+" not found" {
+				return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Customer not found", StatusCode: fiber.StatusNotFound})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve customer", StatusCode: fiber.StatusInternalServerError})
+		}
+		response.CustomerID = customer.ID
+		response.Province, response.City, response.Barangay = customer.Province, customer.City, customer.Barangay
+		latitude, longitude = customer.Latitude, customer.Longitude
+	}
+	if response.Province == "" || response.City == "" {
+		msg := "customerId, or province and city, are required"
+		if response.CustomerID != "" {
+			msg = "the customer's address has no province and city"
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
+	}
+
+	place, err := h.Locations.Resolve(response.Province, response.City, response.Barangay)
+	if errors.Is(err, services.ErrUnknownLocation) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	if err != nil {
+		log.Printf("Error resolving delivery destination: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to estimate delivery", StatusCode: fiber.StatusInternalServerError})
+	}
+	response.Province, response.City, response.Barangay = place.Province, place.City, place.Barangay
+	if latitude != nil && longitude != nil {
+		response.Latitude, response.Longitude = *latitude, *longitude
+	} else {
+		response.Latitude, response.Longitude, response.Approximate = place.Latitude, place.Longitude, true
+	}
+
+	estimate := services.EstimateDelivery(h.config, response.Latitude, response.Longitude)
+	response.StraightLineKm, response.RoadKm, response.Fee = estimate.StraightLineKm, estimate.RoadKm, estimate.Fee
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubGeocoder places every address in Makati, or fails for streets it was told to
+type stubGeocoder struct {
+	queries []services.GeocodeQuery
+	fail    string
+}
+
+func (g *stubGeocoder) Geocode(_ context.Context, query services.GeocodeQuery) (float64, float64, error) {
+	g.queries = append(g.queries, query)
+	if query.Street == g.fail {
+		return 0, 0, errors.New("no match")
+	}
+	return 14.5547, 121.0244, nil
+}
+
+func TestCustomerAddressesAndDeliveryEstimates(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	locations, err := services.LoadPHLocations("")
+	require.NoError(t, err)
+	geocoder := &stubGeocoder{fail: "Unknown Street"}
+
+	h := NewCustomerHandler(store.Customers, jwtSecret)
+	h.Locations = locations
+	h.Geocoder = geocoder
+	cfg := config.DeliveryConfig{OriginLatitude: 14.5995, OriginLongitude: 120.9842, HasOrigin: true, RoadFactor: 1.3, BaseFee: 500, FeePerKm: 20}
+	delivery := NewDeliveryHandler(locations, store.Customers, cfg, jwtSecret)
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	delivery.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/customers", CreateCustomerRequest{
+		FullName: "Maria Santos", Email: "maria@example.com", Phone: "+639171234567",
+		Address: "12 Ayala Ave", Province: "NCR", City: "makati city", Barangay: "Bel-Air",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var geocoded api.CustomerResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&geocoded))
+	assert.Equal(t, "Metro Manila", geocoded.Province, "names are spelled as in the dataset")
+	assert.Equal(t, "Makati", geocoded.City)
+	require.NotNil(t, geocoded.Latitude)
+	assert.Equal(t, 14.5547, *geocoded.Latitude)
+	assert.Equal(t, services.GeocodeQuery{Street: "12 Ayala Ave", Barangay: "Bel-Air", City: "Makati", Province: "Metro Manila"}, geocoder.queries[0])
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/customers", CreateCustomerRequest{
+		FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "+639181234567",
+		Address: "Unknown Street", Province: "Benguet", City: "Baguio",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "addresses the geocoder cannot locate are still saved")
+	var unlocated api.CustomerResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&unlocated))
+	assert.Nil(t, unlocated.Latitude)
+
+	for _, invalid := range []CreateCustomerRequest{
+		{FullName: "Ana Reyes", Email: "ana@example.com", Phone: "+639191234567", Province: "Atlantis", City: "Makati"},
+		{FullName: "Ana Reyes", Email: "ana@example.com", Phone: "+639191234567", Province: "Cavite", City: "Makati"},
+		{FullName: "Ana Reyes", Email: "ana@example.com", Phone: "+639191234567", City: "Makati"},
+	} {
+		resp = authedRequest(t, app, token, http.MethodPost, "/api/customers", invalid)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, invalid.Province+"/"+invalid.City)
+	}
+
+	resp = authedRequest(t, app, token, http.MethodPut, "/api/customers/"+unlocated.ID, UpdateCustomerRequest{Province: "Metro Manila", City: "Unknown"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Estimates use the geocoded coordinates, or else the center of the city
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/delivery/estimate?customerId="+unlocated.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var estimate api.DeliveryEstimateResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&estimate))
+	assert.True(t, estimate.Approximate)
+	assert.Equal(t, 266.2, estimate.RoadKm)
+	assert.Equal(t, 5824.0, estimate.Fee)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/delivery/estimate?customerId="+geocoded.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	estimate = api.DeliveryEstimateResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&estimate))
+	assert.False(t, estimate.Approximate)
+	assert.Equal(t, geocoded.ID, estimate.CustomerID)
+	assert.Equal(t, "Bel-Air", estimate.Barangay)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/delivery/estimate?province=cavite&city=bacoor", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	estimate = api.DeliveryEstimateResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&estimate))
+	assert.Equal(t, "Bacoor", estimate.City)
+	assert.True(t, estimate.Approximate)
+
+	for query, status := range map[string]int{
+		"":                                http.StatusBadRequest,
+		"?province=Cavite&city=Makati":    http.StatusBadRequest,
+		"?customerId=not-a-uuid":          http.StatusBadRequest,
+		"?customerId=" + uuid.NewString(): http.StatusNotFound,
+	} {
+		resp = authedRequest(t, app, token, http.MethodGet, "/api/delivery/estimate"+query, nil)
+		assert.Equal(t, status, resp.StatusCode, query)
+	}
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/locations", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed api.LocationsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	assert.NotEmpty(t, listed.Provinces)
+	assert.Contains(t, listed.Provinces[0].Cities, "Batangas City")
+
+	delivery.config.HasOrigin = false
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/delivery/estimate?province=Cavite&city=Bacoor", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DepositHandler records bank deposits of register cash. Each deposit covers one or
+// more closed register sessions, and is compared with the cash those sessions took in.
+type DepositHandler struct {
+	Repo      repositories.DepositRepository
+	Registers repositories.RegisterSessionRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewDepositHandler creates a new DepositHandler instance
+func NewDepositHandler(repo repositories.DepositRepository, registers repositories.RegisterSessionRepository, jwtSecret []byte) *DepositHandler {
+	return &DepositHandler{Repo: repo, Registers: registers, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the bank deposit routes
+func (h *DepositHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	depositGroup := r.Group("/deposits", mw.Auth)
+	depositGroup.Get("/", h.GetDeposits)                   // GET /api/deposits
+	depositGroup.Get("/:id", h.GetDeposit)                 // GET /api/deposits/:id
+	depositGroup.Post("/", h.CreateDeposit)                // POST /api/deposits
+	depositGroup.Delete("/:id", mw.Admin, h.DeleteDeposit) // DELETE /api/deposits/:id
+}
+
+// DepositRequest is the body for recording a bank deposit
+type DepositRequest struct {
+	Bank        string   `json:"bank"`        // Up to 100 characters
+	Reference   string   `json:"reference"`   // Deposit slip or transaction number, up to 100 characters
+	Amount      float64  `json:"amount"`      // Amount deposited, in pesos
+	DepositDate string   `json:"depositDate"` // YYYY-MM-DD; today when empty
+	SessionIDs  []string `json:"sessionIds"`  // Closed register sessions whose cash was deposited
+	Notes       string   `json:"notes"`       // Up to 1000 characters
+}
+
+// validate trims the fields, checks them and fills in the default date
+func (r *DepositRequest) validate(today time.Time) error {
+	r.Bank = strings.TrimSpace(r.Bank)
+	r.Reference = strings.TrimSpace(r.Reference)
+	r.DepositDate = strings.TrimSpace(r.DepositDate)
+	r.Notes = strings.TrimSpace(r.Notes)
+
+	if r.Bank == "" || r.Reference == "" {
+		return fmt.Errorf("bank and reference are required")
+	}
+	if utf8.RuneCountInString(r.Bank) > 100 || utf8.RuneCountInString(r.Reference) > 100 {
+		return fmt.Errorf("bank and reference must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(r.Notes) > 1000 {
+		return fmt.Errorf("notes must be at most 1000 characters")
+	}
+	if r.Amount <= 0 || math.IsInf(r.Amount, 0) || math.IsNaN(r.Amount) {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+	r.Amount = float64(toCentavos(r.Amount)) / 100
+
+	if len(r.SessionIDs) == 0 {
+		return fmt.Errorf("sessionIds must name at least one register session")
+	}
+	seen := make(map[string]bool, len(r.SessionIDs))
+	for _, id := range r.SessionIDs {
+		if seen[id] {
+			return fmt.Errorf("register session %s is listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	if r.DepositDate == "" {
+		r.DepositDate = today.Format(saleDateLayout)
+	}
+	date, err := time.ParseInLocation(saleDateLayout, r.DepositDate, today.Location())
+	if err != nil {
+		return fmt.Errorf("depositDate must be formatted as YYYY-MM-DD")
+	}
+	if date.After(today) {
+		return fmt.Errorf("depositDate cannot be in the future")
+	}
+	return nil
+}
+
+// GetDeposits handles listing bank deposits
+// @Summary List bank deposits
+// @Description Lists the bank deposits of the tenant, latest first. Pass sessionId to find the deposit covering a register session.
+// @Tags Deposits
+// @Produce json
+// @Security ApiKeyAuth
+// @Param startDate query string false "First deposit date, YYYY-MM-DD"
+// @Param endDate query string false "Last deposit date, YYYY-MM-DD"
+// @Param sessionId query string false "Only the deposit covering this register session"
+// @Success 200 {object} api.DepositListResponse "Deposits"
+// @Failure 400 {object} api.ErrorResponse "Invalid date"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve deposits"
+// @Router /deposits [get]
+func (h *DepositHandler) GetDeposits(c *fiber.Ctx) error {
+	filter := models.DepositFilter{
+		StartDate: c.Query("startDate"),
+		EndDate:   c.Query("endDate"),
+		SessionID: c.Query("sessionId"),
+	}
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if _, err := time.Parse(saleDateLayout, date); date != "" && err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Dates must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+
+	deposits, err := h.Repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting bank deposits: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve deposits", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	var total int64
+	for _, deposit := range deposits {
+		total += toCentavos(deposit.Amount)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.DepositListResponse{Deposits: deposits, Count: len(deposits), Total: float64(total) / 100})
+}
+
+// GetDeposit handles getting a bank deposit
+// @Summary Get a bank deposit
+// @Tags Deposits
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Deposit ID"
+// @Success 200 {object} models.BankDeposit "Deposit"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Deposit not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve deposit"
+// @Router /deposits/{id} [get]
+func (h *DepositHandler) GetDeposit(c *fiber.Ctx) error {
+	deposit, err := h.loadDeposit(c)
+	if deposit == nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(deposit)
+}
+
+// loadDeposit fetches the deposit named by the :id parameter, writing the error
+// response itself when it fails
+func (h *DepositHandler) loadDeposit(c *fiber.Ctx) (*models.BankDeposit, error) {
+	deposit, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Deposit not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting bank deposit %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve deposit", StatusCode: fiber.StatusInternalServerError})
+	}
+	return deposit, nil
+}
+
+// CreateDeposit handles recording a bank deposit
+// @Summary Record a bank deposit
+// @Description Records register cash deposited at the bank, covering one or more closed register sessions. Cashiers can deposit their own sessions; admins any. The expected amount is the cash the sessions took in, their counted cash minus the opening floats, and the difference is negative when the deposit is short.
+// @Tags Deposits
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param deposit body DepositRequest true "Deposit"
+// @Success 201 {object} api.DepositResponse "Deposit recorded"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or unknown session"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "Session still open or already deposited"
+// @Failure 500 {object} api.ErrorResponse "Failed to record deposit"
+// @Router /deposits [post]
+func (h *DepositHandler) CreateDeposit(c *fiber.Ctx) error {
+	var input DepositRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(h.now()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	sessions, err := h.loadDepositSessions(c, input.SessionIDs)
+	if sessions == nil {
+		return err
+	}
+
+	var expected int64
+	for _, session := range sessions {
+		expected += toCentavos(session.CashToDeposit())
+	}
+	deposit := &models.BankDeposit{
+		Bank:           input.Bank,
+		Reference:      input.Reference,
+		Amount:         input.Amount,
+		DepositDate:    input.DepositDate,
+		SessionIDs:     input.SessionIDs,
+		ExpectedAmount: float64(expected) / 100,
+		Difference:     float64(toCentavos(input.Amount)-expected) / 100,
+		Notes:          input.Notes,
+	}
+	deposit.RecordedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Repo.Create(deposit); err != nil {
+		log.Printf("Error recording bank deposit: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to record deposit", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_DEPOSIT", AuditEntityDeposit, deposit.ID,
+		fmt.Sprintf("Deposited %.2f at %s (ref. %s) for %d register session(s); difference %.2f",
+			deposit.Amount, deposit.Bank, deposit.Reference, len(deposit.SessionIDs), deposit.Difference))
+
+	return c.Status(fiber.StatusCreated).JSON(api.DepositResponse{Message: "Deposit recorded", Deposit: deposit})
+}
+
+// loadDepositSessions fetches the sessions of a deposit and checks they can be
+// deposited: the caller may access them, and they are closed and not deposited yet.
+// On failure it writes the error response and returns nil sessions.
+func (h *DepositHandler) loadDepositSessions(c *fiber.Ctx, ids []string) ([]models.RegisterSession, error) {
+	sessions := make([]models.RegisterSession, 0, len(ids))
+	for _, id := range ids {
+		session, err := h.Registers.GetByID(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("Register session %s not found", id), StatusCode: fiber.StatusBadRequest})
+		}
+		if err != nil {
+			log.Printf("Error getting register session %s for a deposit: %v", id, err)
+			return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to record deposit", StatusCode: fiber.StatusInternalServerError})
+		}
+		if !canAccessRegister(c, session) {
+			return nil, c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+		}
+		if session.Status != models.RegisterSessionClosed {
+			return nil, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("Register session %s is still open", id), StatusCode: fiber.StatusConflict})
+		}
+		sessions = append(sessions, *session)
+	}
+
+	deposited, err := h.Repo.DepositedSessions(ids)
+	if err != nil {
+		log.Printf("Error checking deposited register sessions: %v", err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to record deposit", StatusCode: fiber.StatusInternalServerError})
+	}
+	for _, id := range ids {
+		if depositID, ok := deposited[id]; ok {
+			return nil, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Register session %s was already deposited in deposit %s", id, depositID),
+				StatusCode: fiber.StatusConflict,
+			})
+		}
+	}
+	return sessions, nil
+}
+
+// DeleteDeposit handles removing a bank deposit
+// @Summary Delete a bank deposit (Admin)
+// @Description Removes a deposit recorded in error. Its register sessions become undeposited again and can be recorded in another deposit.
+// @Tags Deposits
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Deposit ID"
+// @Success 200 {object} api.MessageResponse "Deposit deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Deposit not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete deposit"
+// @Router /deposits/{id} [delete]
+func (h *DepositHandler) DeleteDeposit(c *fiber.Ctx) error {
+	deposit, err := h.loadDeposit(c)
+	if deposit == nil {
+		return err
+	}
+
+	if err := h.Repo.Delete(deposit.ID); err != nil {
+		log.Printf("Error deleting bank deposit %s: %v", deposit.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete deposit", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_DEPOSIT", AuditEntityDeposit, deposit.ID,
+		fmt.Sprintf("Deleted the %.2f deposit at %s (ref. %s)", deposit.Amount, deposit.Bank, deposit.Reference))
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Deposit deleted"})
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDepositTestApp registers the deposit routes on an in-memory store, with the
+// clock fixed to 12 March 2025
+func setupDepositTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewDepositHandler(store.Deposits, store.Registers, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+	h.now = func() time.Time { return time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local) }
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+// addClosedSession stores a register session of openedBy closed at closedAt
+func addClosedSession(t *testing.T, store *memory.Store, openedBy string, closedAt time.Time, openingFloat, countedCash float64) *models.RegisterSession {
+	t.Helper()
+	session := &models.RegisterSession{OpenedBy: openedBy, OpenedAt: closedAt.Add(-8 * time.Hour), OpeningFloat: openingFloat}
+	require.NoError(t, store.Registers.Open(session))
+	session.ClosedBy = openedBy
+	session.ClosedAt = &closedAt
+	session.CountedCash = countedCash
+	require.NoError(t, store.Registers.Close(session))
+	return session
+}
+
+func TestCreateDeposit(t *testing.T) {
+	app, store, jwtSecret := setupDepositTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	closedAt := time.Date(2025, 3, 11, 18, 0, 0, 0, time.Local)
+	monday := addClosedSession(t, store, "staff-1", closedAt.AddDate(0, 0, -1), 2000, 12000)
+	tuesday := addClosedSession(t, store, "staff-1", closedAt, 2000, 7500.50)
+	open := &models.RegisterSession{OpenedBy: "staff-1", OpenedAt: closedAt.Add(14 * time.Hour)}
+	require.NoError(t, store.Registers.Open(open))
+
+	request := func(sessionIDs ...string) DepositRequest {
+		return DepositRequest{Bank: "BDO", Reference: "DS-0042", Amount: 15500, SessionIDs: sessionIDs}
+	}
+	for name, input := range map[string]DepositRequest{
+		"no bank":          {Reference: "DS-0042", Amount: 100, SessionIDs: []string{monday.ID}},
+		"no sessions":      {Bank: "BDO", Reference: "DS-0042", Amount: 100},
+		"repeated session": request(monday.ID, monday.ID),
+		"unknown session":  request("missing"),
+		"future date":      {Bank: "BDO", Reference: "DS-0042", Amount: 100, DepositDate: "2025-03-13", SessionIDs: []string{monday.ID}},
+	} {
+		resp := authedRequest(t, app, token, http.MethodPost, "/api/deposits", input)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/deposits", request(monday.ID, open.ID))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "open sessions cannot be deposited")
+
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	resp = authedRequest(t, app, otherToken, http.MethodPost, "/api/deposits", request(monday.ID))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "cashiers deposit their own sessions")
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/deposits", request(monday.ID, tuesday.ID))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.DepositResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, "2025-03-12", created.Deposit.DepositDate, "defaults to today")
+	assert.Equal(t, 15500.50, created.Deposit.ExpectedAmount, "counted cash minus the opening floats")
+	assert.Equal(t, -0.50, created.Deposit.Difference)
+	assert.Equal(t, "staff-1", created.Deposit.RecordedBy)
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/deposits", request(tuesday.ID))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "a session is deposited once")
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/deposits?sessionId="+tuesday.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.DepositListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, created.Deposit.ID, list.Deposits[0].ID)
+	assert.Equal(t, 15500.0, list.Total)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "CREATE_DEPOSIT", logs[0].Action)
+}
+
+func TestDeleteDeposit(t *testing.T) {
+	app, store, jwtSecret := setupDepositTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	session := addClosedSession(t, store, "staff-1", time.Date(2025, 3, 11, 18, 0, 0, 0, time.Local), 1000, 6000)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/deposits", DepositRequest{Bank: "BPI", Reference: "123", Amount: 5000, SessionIDs: []string{session.ID}})
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "admins deposit any session")
+	var created api.DepositResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	path := "/api/deposits/" + created.Deposit.ID
+
+	resp = authedRequest(t, app, staffToken, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/deposits", DepositRequest{Bank: "BPI", Reference: "124", Amount: 5000, SessionIDs: []string{session.ID}})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "the session can be deposited again")
+}
@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultNumberBranch fills the {branch} placeholder for users without a shift branch
+const defaultNumberBranch = "MAIN"
+
+// DisplayNumbers lets admins set how the display numbers of sales, customers and cabs
+// are formatted, e.g. SALE-{YYYY}-{seq:5}, and gives new records their number. The
+// numbers are shown alongside the internal IDs. A nil *DisplayNumbers is valid and
+// numbers nothing, so the sales, customer and cab handlers work without one.
+type DisplayNumbers struct {
+	Repo      repositories.DisplayNumberRepository
+	Shifts    repositories.ShiftRepository // Optional; fills {branch} with the caller's shift branch
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewDisplayNumbers creates a new DisplayNumbers instance
+func NewDisplayNumbers(repo repositories.DisplayNumberRepository, jwtSecret []byte) *DisplayNumbers {
+	return &DisplayNumbers{Repo: repo, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the numbering format and display number routes
+func (n *DisplayNumbers) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/admin/numbering-formats", mw.Auth, mw.Admin, n.GetFormats)                  // GET /api/admin/numbering-formats
+	r.Put("/admin/numbering-formats/:entityType", mw.Auth, mw.Admin, n.SetFormat)       // PUT /api/admin/numbering-formats/:entityType
+	r.Delete("/admin/numbering-formats/:entityType", mw.Auth, mw.Admin, n.DeleteFormat) // DELETE /api/admin/numbering-formats/:entityType
+	r.Get("/numbering/lookup", mw.Auth, n.LookupNumber)                                 // GET /api/numbering/lookup
+}
+
+// Assign gives a new record the next number of its entity type's format, returning
+// "" when the type has no format. Failures are logged but never fail the request.
+func (n *DisplayNumbers) Assign(c *fiber.Ctx, entityType, entityID string) string {
+	if n == nil || n.Repo == nil {
+		return ""
+	}
+
+	format, err := n.Repo.GetFormat(entityType)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting %s number format: %v", entityType, err)
+		}
+		return ""
+	}
+
+	scope := format.Scope(n.now(), n.branch(c))
+	assigned, err := n.Repo.Assign(entityType, entityID, scope)
+	if err != nil {
+		log.Printf("Error assigning a display number to %s %s: %v", entityType, entityID, err)
+		return ""
+	}
+	return assigned.Number
+}
+
+// branch returns the shift branch of the caller, which fills the {branch} placeholder
+func (n *DisplayNumbers) branch(c *fiber.Ctx) string {
+	userID, _ := c.Locals("user_id").(string)
+	if n.Shifts == nil || userID == "" {
+		return defaultNumberBranch
+	}
+	branch, err := n.Shifts.GetBranch(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return defaultNumberBranch
+		}
+		log.Printf("Error getting the shift branch of user %s: %v", userID, err)
+		return defaultNumberBranch
+	}
+	if branch = normalizeBranch(branch); branch == "" {
+		return defaultNumberBranch
+	}
+	return branch
+}
+
+// Numbers returns the display numbers of records by ID. Records without one, and all
+// records when the numbers cannot be read, are left out.
+func (n *DisplayNumbers) Numbers(entityType string, entityIDs []string) map[string]string {
+	if n == nil || n.Repo == nil || len(entityIDs) == 0 {
+		return nil
+	}
+	numbers, err := n.Repo.GetNumbers(entityType, entityIDs)
+	if err != nil {
+		log.Printf("Error getting %s display numbers: %v", entityType, err)
+		return nil
+	}
+	return numbers
+}
+
+// Number returns the display number of a record, "" when it has none
+func (n *DisplayNumbers) Number(entityType, entityID string) string {
+	return n.Numbers(entityType, []string{entityID})[entityID]
+}
+
+// numberSales fills in the display numbers of a list of sales
+func (n *DisplayNumbers) numberSales(sales []models.Sale) {
+	ids := make([]string, 0, len(sales))
+	for _, sale := range sales {
+		ids = append(ids, sale.ID)
+	}
+	numbers := n.Numbers(models.NumberedSale, ids)
+	for i := range sales {
+		sales[i].DisplayNumber = numbers[sales[i].ID]
+	}
+}
+
+// numberCabs fills in the display numbers of a list of cabs
+func (n *DisplayNumbers) numberCabs(cabs []models.MultiCab) {
+	ids := make([]string, 0, len(cabs))
+	for _, cab := range cabs {
+		ids = append(ids, strconv.Itoa(cab.ID))
+	}
+	numbers := n.Numbers(models.NumberedCab, ids)
+	for i := range cabs {
+		cabs[i].DisplayNumber = numbers[strconv.Itoa(cabs[i].ID)]
+	}
+}
+
+// NumberFormatRequest is the body for setting a numbering format
+type NumberFormatRequest struct {
+	// Placeholders: {YYYY}, {YY}, {MM}, {DD}, {branch} and exactly one {seq} or {seq:N},
+	// N being the width numbers are padded to
+	Format string `json:"format"`
+}
+
+// numberedEntity reads and checks the entity type of a numbering format route
+func numberedEntity(c *fiber.Ctx) (string, error) {
+	entityType := strings.ToLower(c.Params("entityType"))
+	if !models.ValidNumberedEntity(entityType) {
+		return "", c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Entity type must be one of sale, customer, cab", StatusCode: fiber.StatusBadRequest})
+	}
+	return entityType, nil
+}
+
+// GetFormats handles listing the numbering formats
+// @Summary List numbering formats (Admin)
+// @Description Lists the display number format of each entity type that has one. Records of the other types get no display number.
+// @Tags Numbering
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.NumberFormatListResponse "Numbering formats"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve numbering formats"
+// @Router /admin/numbering-formats [get]
+func (n *DisplayNumbers) GetFormats(c *fiber.Ctx) error {
+	formats, err := n.Repo.GetFormats()
+	if err != nil {
+		log.Printf("Error getting number formats: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve numbering formats", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NumberFormatListResponse{Formats: formats, Count: len(formats)})
+}
+
+// SetFormat handles setting the numbering format of an entity type
+// @Summary Set a numbering format (Admin)
+// @Description Sets how the display numbers of new sales, customers or cabs are generated, e.g. SALE-{YYYY}-{seq:5} or CAB-{branch}-{seq}. Each combination of the date and branch placeholders counts from 1, so a yearly format restarts each year. {branch} is the shift branch of the user creating the record, MAIN when they have none. Records numbered before keep their numbers.
+// @Tags Numbering
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entityType path string true "sale, customer or cab"
+// @Param format body NumberFormatRequest true "Format"
+// @Success 200 {object} api.NumberFormatResponse "Numbering format set"
+// @Failure 400 {object} api.ErrorResponse "Invalid entity type or format"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to save numbering format"
+// @Router /admin/numbering-formats/{entityType} [put]
+func (n *DisplayNumbers) SetFormat(c *fiber.Ctx) error {
+	entityType, err := numberedEntity(c)
+	if entityType == "" {
+		return err
+	}
+	var req NumberFormatRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	req.Format = strings.TrimSpace(req.Format)
+	if err := models.ValidateNumberFormat(req.Format); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	var before *models.NumberFormat
+	if existing, err := n.Repo.GetFormat(entityType); err == nil {
+		before = existing
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting %s number format: %v", entityType, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to save numbering format", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	format := &models.NumberFormat{EntityType: entityType, Format: req.Format, UpdatedBy: userID}
+	if err := n.Repo.SaveFormat(format); err != nil {
+		log.Printf("Error saving %s number format: %v", entityType, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to save numbering format", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	if before == nil {
+		n.Audit.RecordAction(c, "CREATE_NUMBER_FORMAT", AuditEntityNumbering, entityType, fmt.Sprintf("Set the %s number format to %s", entityType, format.Format))
+	} else {
+		n.Audit.RecordUpdate(c, AuditEntityNumbering, entityType, before, format)
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NumberFormatResponse{
+		Message: "Numbering format set",
+		Format:  format,
+		Example: models.FormatSequence(format.Scope(n.now(), n.branch(c)), 1),
+	})
+}
+
+// DeleteFormat handles removing the numbering format of an entity type
+// @Summary Delete a numbering format (Admin)
+// @Description Stops numbering new records of the entity type. Records numbered before keep their numbers, and setting a format again continues its sequences.
+// @Tags Numbering
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entityType path string true "sale, customer or cab"
+// @Success 200 {object} api.MessageResponse "Numbering format deleted"
+// @Failure 400 {object} api.ErrorResponse "Invalid entity type"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Numbering format not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete numbering format"
+// @Router /admin/numbering-formats/{entityType} [delete]
+func (n *DisplayNumbers) DeleteFormat(c *fiber.Ctx) error {
+	entityType, err := numberedEntity(c)
+	if entityType == "" {
+		return err
+	}
+	if err := n.Repo.DeleteFormat(entityType); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Numbering format not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error deleting %s number format: %v", entityType, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete numbering format", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	n.Audit.RecordAction(c, "DELETE_NUMBER_FORMAT", AuditEntityNumbering, entityType, fmt.Sprintf("Deleted the %s number format", entityType))
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Numbering format deleted"})
+}
+
+// LookupNumber handles finding the records given a display number
+// @Summary Look up a display number
+// @Description Finds the sale, customer or cab given a display number, so a number read off a receipt or a unit leads to its record.
+// @Tags Numbering
+// @Produce json
+// @Security ApiKeyAuth
+// @Param number query string true "Display number"
+// @Success 200 {object} api.DisplayNumberLookupResponse "Matching records"
+// @Failure 400 {object} api.ErrorResponse "Number is required"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to look up display number"
+// @Router /numbering/lookup [get]
+func (n *DisplayNumbers) LookupNumber(c *fiber.Ctx) error {
+	number := strings.TrimSpace(c.Query("number"))
+	if number == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Number is required", StatusCode: fiber.StatusBadRequest})
+	}
+	matches, err := n.Repo.Find(number)
+	if err != nil {
+		log.Printf("Error looking up display number %s: %v", number, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to look up display number", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.DisplayNumberLookupResponse{Number: number, Matches: matches, Count: len(matches)})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayNumbers(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "staff-1", Username: "staff", Email: "staff@example.com", Password: "secret123", Role: RoleStaff, IsActive: true}))
+
+	numbers := NewDisplayNumbers(store.Numbers, jwtSecret)
+	numbers.Shifts = store.Shifts
+	numbers.Audit = NewChangeRecorder(store.Logs)
+	numbers.now = func() time.Time { return time.Date(2026, time.October, 16, 9, 0, 0, 0, time.Local) }
+	customers := NewCustomerHandler(store.Customers, jwtSecret)
+	customers.Numbers = numbers
+	app := fiber.New()
+	numbers.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	customers.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	customerCount := 0
+	createCustomer := func(name string) *api.CustomerResponse {
+		customerCount++
+		email := fmt.Sprintf("customer%d@example.com", customerCount)
+		resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/customers", CreateCustomerRequest{FullName: name, Email: email, Phone: "+639171234567"})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var created api.CustomerResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+		return &created
+	}
+	assert.Empty(t, createCustomer("Before Numbering").DisplayNumber, "customers are not numbered without a format")
+
+	path := "/api/admin/numbering-formats/customer"
+	resp := authedRequest(t, app, staffToken, http.MethodPut, path, NumberFormatRequest{Format: "CUST-{YYYY}-{seq:4}"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "only admins set formats")
+	for _, invalid := range []string{"", "CUST", "CUST-{seq}-{seq}", "CUST-{year}-{seq}", "CUST-{seq:0}", "CUST-{seq", "CUST}-{seq}"} {
+		resp = authedRequest(t, app, adminToken, http.MethodPut, path, NumberFormatRequest{Format: invalid})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, invalid)
+	}
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/admin/numbering-formats/invoice", NumberFormatRequest{Format: "INV-{seq}"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "only sales, customers and cabs are numbered")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, path, NumberFormatRequest{Format: "CUST-{YYYY}-{seq:4}"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var set api.NumberFormatResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&set))
+	assert.Equal(t, "CUST-2026-0001", set.Example)
+
+	// Numbers follow each other, and are listed with the customers
+	first := createCustomer("Maria Santos")
+	second := createCustomer("Juan Dela Cruz")
+	assert.Equal(t, "CUST-2026-0001", first.DisplayNumber)
+	assert.Equal(t, "CUST-2026-0002", second.DisplayNumber)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/customers", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed api.CustomerListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	listedNumbers := map[string]string{}
+	for _, customer := range listed.Customers {
+		listedNumbers[customer.ID] = customer.DisplayNumber
+	}
+	assert.Equal(t, "CUST-2026-0002", listedNumbers[second.ID])
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/customers/"+first.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var fetched api.CustomerResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&fetched))
+	assert.Equal(t, "CUST-2026-0001", fetched.DisplayNumber)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/numbering/lookup?number="+url.QueryEscape("CUST-2026-0002"), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var lookup api.DisplayNumberLookupResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&lookup))
+	require.Equal(t, 1, lookup.Count)
+	assert.Equal(t, second.ID, lookup.Matches[0].EntityID)
+
+	// Each branch counts on its own
+	require.NoError(t, store.Shifts.SetBranch("staff-1", "MNL"))
+	resp = authedRequest(t, app, adminToken, http.MethodPut, path, NumberFormatRequest{Format: "C-{branch}-{seq}"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "C-MNL-1", createCustomer("Ana Reyes").DisplayNumber)
+	assert.Equal(t, "C-MNL-2", createCustomer("Jose Rizal").DisplayNumber)
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	assert.NotEmpty(t, logs, "format changes are recorded in the activity log")
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/numbering-formats", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var formats api.NumberFormatListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&formats))
+	require.Equal(t, 1, formats.Count)
+	assert.Equal(t, "C-{branch}-{seq}", formats.Formats[0].Format)
+
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Empty(t, createCustomer("After Numbering").DisplayNumber)
+}
+
+func TestDisplayNumbersForSalesAndCabs(t *testing.T) {
+	store := memory.NewStore()
+	numbers := NewDisplayNumbers(store.Numbers, []byte("testsecret"))
+	require.NoError(t, store.Numbers.SaveFormat(&models.NumberFormat{EntityType: models.NumberedCab, Format: "CAB-{branch}-{seq:3}"}))
+
+	app := fiber.New()
+	app.Post("/cabs/:id", func(c *fiber.Ctx) error {
+		return c.SendString(numbers.Assign(c, models.NumberedCab, c.Params("id")))
+	})
+	resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/cabs/7", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "CAB-MAIN-001", string(body), "callers without a shift branch number as MAIN")
+
+	cabs := []models.MultiCab{{ID: 3}, {ID: 7}}
+	numbers.numberCabs(cabs)
+	assert.Empty(t, cabs[0].DisplayNumber)
+	assert.Equal(t, "CAB-MAIN-001", cabs[1].DisplayNumber)
+
+	sales := []models.Sale{{ID: "sale-1"}}
+	numbers.numberSales(sales)
+	assert.Empty(t, sales[0].DisplayNumber, "sales are not numbered without a format")
+
+	var none *DisplayNumbers
+	none.numberSales(sales)
+	assert.Empty(t, none.Number(models.NumberedSale, "sale-1"), "a nil DisplayNumbers numbers nothing")
+}
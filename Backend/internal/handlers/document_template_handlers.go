@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxLogoSize is the largest logo image accepted, in bytes
+const maxLogoSize = 512 * 1024
+
+// Logo image types accepted, detected from the file contents
+var logoContentTypes = map[string]bool{"image/png": true, "image/jpeg": true}
+
+// DocumentTemplateHandler manages the company logo, address, header, footer and
+// terms printed on receipts and statements. Renderers read the template through
+// the repository.
+type DocumentTemplateHandler struct {
+	Repo      repositories.DocumentTemplateRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewDocumentTemplateHandler creates a new DocumentTemplateHandler instance
+func NewDocumentTemplateHandler(repo repositories.DocumentTemplateRepository, jwtSecret []byte) *DocumentTemplateHandler {
+	return &DocumentTemplateHandler{Repo: repo, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the document template routes
+func (h *DocumentTemplateHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	documentGroup := r.Group("/settings/documents", mw.Auth)
+	documentGroup.Get("/", h.GetDocumentTemplate)                 // GET /api/settings/documents
+	documentGroup.Put("/", mw.Admin, h.UpdateDocumentTemplate)    // PUT /api/settings/documents
+	documentGroup.Get("/logo", h.GetDocumentLogo)                 // GET /api/settings/documents/logo
+	documentGroup.Put("/logo", mw.Admin, h.UploadDocumentLogo)    // PUT /api/settings/documents/logo
+	documentGroup.Delete("/logo", mw.Admin, h.DeleteDocumentLogo) // DELETE /api/settings/documents/logo
+}
+
+// DocumentTemplateRequest is the body for replacing the text of the document template
+type DocumentTemplateRequest struct {
+	CompanyName string `json:"companyName"` // Up to 200 characters
+	Address     string `json:"address"`     // Up to 500 characters
+	HeaderText  string `json:"headerText"`  // Up to 1000 characters
+	FooterText  string `json:"footerText"`  // Up to 1000 characters
+	Terms       string `json:"terms"`       // Up to 4000 characters
+}
+
+// validate trims the fields and checks they fit the template columns
+func (r *DocumentTemplateRequest) validate() error {
+	for _, field := range []struct {
+		name  string
+		value *string
+		max   int
+	}{
+		{"companyName", &r.CompanyName, 200},
+		{"address", &r.Address, 500},
+		{"headerText", &r.HeaderText, 1000},
+		{"footerText", &r.FooterText, 1000},
+		{"terms", &r.Terms, 4000},
+	} {
+		*field.value = strings.TrimSpace(*field.value)
+		if utf8.RuneCountInString(*field.value) > field.max {
+			return fmt.Errorf("%s must be at most %d characters", field.name, field.max)
+		}
+	}
+	return nil
+}
+
+// GetDocumentTemplate handles getting the document template
+// @Summary Get the document template
+// @Description Returns the company details, header, footer and terms printed on receipts and statements. Fields are empty until an admin saves the template.
+// @Tags Settings
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.DocumentTemplate "Document template"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve document template"
+// @Router /settings/documents [get]
+func (h *DocumentTemplateHandler) GetDocumentTemplate(c *fiber.Ctx) error {
+	template, err := h.Repo.Get()
+	if err != nil {
+		log.Printf("Error getting document template: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve document template", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(template)
+}
+
+// UpdateDocumentTemplate handles replacing the text of the document template
+// @Summary Update the document template (Admin)
+// @Description Replaces the company details, header, footer and terms printed on receipts and statements. The logo is kept; use PUT /settings/documents/logo to change it.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param template body DocumentTemplateRequest true "Template text"
+// @Success 200 {object} api.DocumentTemplateResponse "Template updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to update document template"
+// @Router /settings/documents [put]
+func (h *DocumentTemplateHandler) UpdateDocumentTemplate(c *fiber.Ctx) error {
+	var input DocumentTemplateRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	before, err := h.Repo.Get()
+	if err != nil {
+		log.Printf("Error getting document template: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update document template", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	now := h.now()
+	template := before
+	template.CompanyName = input.CompanyName
+	template.Address = input.Address
+	template.HeaderText = input.HeaderText
+	template.FooterText = input.FooterText
+	template.Terms = input.Terms
+	template.UpdatedBy, _ = c.Locals("user_id").(string)
+	template.UpdatedAt = &now
+	if err := h.Repo.Save(template); err != nil {
+		log.Printf("Error saving document template: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update document template", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityDocuments, "documents", documentTemplateText(before), documentTemplateText(template))
+
+	return c.Status(fiber.StatusOK).JSON(api.DocumentTemplateResponse{Message: "Document template updated", Template: template})
+}
+
+// documentTemplateText is the part of a template compared in the activity log
+func documentTemplateText(template models.DocumentTemplate) DocumentTemplateRequest {
+	return DocumentTemplateRequest{
+		CompanyName: template.CompanyName,
+		Address:     template.Address,
+		HeaderText:  template.HeaderText,
+		FooterText:  template.FooterText,
+		Terms:       template.Terms,
+	}
+}
+
+// GetDocumentLogo handles getting the logo image
+// @Summary Get the document logo
+// @Description Returns the logo image printed on receipts and statements.
+// @Tags Settings
+// @Produce png,jpeg
+// @Security ApiKeyAuth
+// @Success 200 {file} binary "Logo image"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "No logo uploaded"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve logo"
+// @Router /settings/documents/logo [get]
+func (h *DocumentTemplateHandler) GetDocumentLogo(c *fiber.Ctx) error {
+	logo, err := h.Repo.GetLogo()
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No logo uploaded", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error getting document logo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve logo", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	c.Set(fiber.HeaderContentType, logo.ContentType)
+	return c.Status(fiber.StatusOK).Send(logo.Data)
+}
+
+// UploadDocumentLogo handles replacing the logo image
+// @Summary Upload the document logo (Admin)
+// @Description Replaces the logo printed on receipts and statements with a PNG or JPEG image of up to 512 KB, sent in the multipart "logo" field.
+// @Tags Settings
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param logo formData file true "PNG or JPEG image"
+// @Success 200 {object} api.DocumentTemplateResponse "Logo uploaded"
+// @Failure 400 {object} api.ErrorResponse "Missing, oversized or unsupported image"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to upload logo"
+// @Router /settings/documents/logo [put]
+func (h *DocumentTemplateHandler) UploadDocumentLogo(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("logo")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "A logo image is required in the \"logo\" field", StatusCode: fiber.StatusBadRequest})
+	}
+	if fileHeader.Size > maxLogoSize {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The logo must be at most 512 KB", StatusCode: fiber.StatusBadRequest})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error opening uploaded logo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload logo", StatusCode: fiber.StatusInternalServerError})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxLogoSize+1))
+	if err != nil {
+		log.Printf("Error reading uploaded logo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload logo", StatusCode: fiber.StatusInternalServerError})
+	}
+	if len(data) > maxLogoSize {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The logo must be at most 512 KB", StatusCode: fiber.StatusBadRequest})
+	}
+
+	// The declared content type is not trusted; the image is sniffed instead
+	contentType := http.DetectContentType(data)
+	if !logoContentTypes[contentType] {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The logo must be a PNG or JPEG image", StatusCode: fiber.StatusBadRequest})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.Repo.SaveLogo(models.DocumentLogo{ContentType: contentType, Data: data}, userID, h.now()); err != nil {
+		log.Printf("Error saving document logo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload logo", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "UPDATE_DOCUMENT_LOGO", AuditEntityDocuments, "documents",
+		fmt.Sprintf("Uploaded a %d byte %s document logo", len(data), contentType))
+
+	return h.respondWithTemplate(c, "Logo uploaded")
+}
+
+// DeleteDocumentLogo handles removing the logo image
+// @Summary Delete the document logo (Admin)
+// @Description Removes the logo, so documents are printed without one.
+// @Tags Settings
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.DocumentTemplateResponse "Logo removed"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete logo"
+// @Router /settings/documents/logo [delete]
+func (h *DocumentTemplateHandler) DeleteDocumentLogo(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.Repo.DeleteLogo(userID, h.now()); err != nil {
+		log.Printf("Error deleting document logo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete logo", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_DOCUMENT_LOGO", AuditEntityDocuments, "documents", "Removed the document logo")
+
+	return h.respondWithTemplate(c, "Logo removed")
+}
+
+// respondWithTemplate writes the current template after a logo change
+func (h *DocumentTemplateHandler) respondWithTemplate(c *fiber.Ctx, message string) error {
+	template, err := h.Repo.Get()
+	if err != nil {
+		log.Printf("Error getting document template: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve document template", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.DocumentTemplateResponse{Message: message, Template: template})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDocumentTemplateTestApp registers the document template routes on an in-memory store
+func setupDocumentTemplateTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewDocumentTemplateHandler(store.Documents, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+// uploadLogo sends data in the multipart "logo" field
+func uploadLogo(t *testing.T, app *fiber.App, token string, data []byte) *http.Response {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("logo", "logo.png")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPut, "/api/settings/documents/logo", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+// testPNG encodes a small PNG image
+func testPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestUpdateDocumentTemplate(t *testing.T) {
+	app, store, jwtSecret := setupDocumentTemplateTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	input := DocumentTemplateRequest{CompanyName: "  Surplus Motors ", Address: "Cebu City", FooterText: "Thank you!", Terms: "No returns after 7 days"}
+	resp := authedRequest(t, app, staffToken, http.MethodPut, "/api/settings/documents", input)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/settings/documents", input)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated api.DocumentTemplateResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	assert.Equal(t, "Surplus Motors", updated.Template.CompanyName)
+	assert.Equal(t, "admin-1", updated.Template.UpdatedBy)
+
+	// Staff can read the template, as documents are printed from the sell screen
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/settings/documents", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var template models.DocumentTemplate
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&template))
+	assert.Equal(t, "No returns after 7 days", template.Terms)
+	assert.False(t, template.HasLogo)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "UPDATE_DOCUMENT_TEMPLATE", logs[0].Action)
+	assert.Equal(t, AuditEntityDocuments, logs[0].EntityType)
+}
+
+func TestUpdateDocumentTemplateTooLong(t *testing.T) {
+	app, _, jwtSecret := setupDocumentTemplateTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPut, "/api/settings/documents", DocumentTemplateRequest{HeaderText: strings.Repeat("ñ", 1001)})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestDocumentLogo(t *testing.T) {
+	app, _, jwtSecret := setupDocumentTemplateTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/settings/documents/logo", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	logo := testPNG(t)
+	resp = uploadLogo(t, app, staffToken, logo)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = uploadLogo(t, app, adminToken, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "only PNG and JPEG images are accepted")
+	resp = uploadLogo(t, app, adminToken, append(logo, make([]byte, maxLogoSize)...))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "oversized logos are rejected")
+
+	resp = uploadLogo(t, app, adminToken, logo)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var uploaded api.DocumentTemplateResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&uploaded))
+	assert.True(t, uploaded.Template.HasLogo)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/settings/documents/logo", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	served, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, logo, served)
+
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/settings/documents/logo", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/settings/documents/logo", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationDormantAccounts is pushed to admins when dormant accounts were deactivated
+const NotificationDormantAccounts = "dormant_accounts_deactivated"
+
+// maxDormantAccountDays bounds the days a preview can be asked for
+const maxDormantAccountDays = 3650
+
+// DormantAccountHandler enforces the dormant account policy: accounts nobody signed in
+// to for Days days are deactivated, unless they are exempt or belong to an admin, so
+// a tenant can never lock itself out. It also tracks the sign-ins the policy goes by.
+type DormantAccountHandler struct {
+	Users     UserRepository
+	Repo      repositories.UserDormancyRepository
+	Logs      repositories.LogsRepositoryInterface // Deactivations are recorded here, as they have no request
+	Hub       *services.NotificationHub            // Optional; without it admins are not notified
+	Audit     *ChangeRecorder
+	Days      int // 0 disables deactivation
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewDormantAccountHandler creates a new DormantAccountHandler instance
+func NewDormantAccountHandler(users UserRepository, repo repositories.UserDormancyRepository, logs repositories.LogsRepositoryInterface, days int, jwtSecret []byte) *DormantAccountHandler {
+	return &DormantAccountHandler{Users: users, Repo: repo, Logs: logs, Days: days, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes of the dormant account policy
+func (h *DormantAccountHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/admin/dormant-users", mw.Auth, mw.Admin, h.GetDormantUsers)         // GET /api/admin/dormant-users
+	r.Put("/users/:id/dormancy-exempt", mw.Auth, mw.Admin, h.SetDormancyExempt) // PUT /api/users/:id/dormancy-exempt
+}
+
+// RecordLogin stores that a user just signed in. A nil handler records nothing.
+func (h *DormantAccountHandler) RecordLogin(userID string) {
+	if h == nil || h.Repo == nil {
+		return
+	}
+	if err := h.Repo.RecordLogin(userID, h.now()); err != nil {
+		log.Printf("Error recording login of user %s: %v", userID, err)
+	}
+}
+
+// Reactivated stores that a user was just reactivated, so the policy does not
+// deactivate them again before they had the chance to sign in. A nil handler
+// records nothing.
+func (h *DormantAccountHandler) Reactivated(userID string) {
+	if h == nil || h.Repo == nil {
+		return
+	}
+	if err := h.Repo.RecordReactivation(userID, h.now()); err != nil {
+		log.Printf("Error recording reactivation of user %s: %v", userID, err)
+	}
+}
+
+// dormancyProtected reports whether the policy leaves an account active however long it was unused
+func dormancyProtected(user models.UserDormancy) bool {
+	return user.Exempt || user.Role == RoleAdmin || user.Role == RoleSuperAdmin
+}
+
+// DeactivateDormant deactivates the accounts of the tenant nobody signed in to for
+// Days days, recording each one in the activity log and notifying the admins. It
+// returns the accounts that were deactivated.
+func (h *DormantAccountHandler) DeactivateDormant(ctx context.Context, tenantID string) ([]models.UserDormancy, error) {
+	if h.Days <= 0 {
+		return nil, nil
+	}
+
+	dormant, err := h.Repo.FindDormant(h.now().AddDate(0, 0, -h.Days))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	deactivated := []models.UserDormancy{}
+	for _, user := range dormant {
+		if dormancyProtected(user) {
+			continue
+		}
+		if err := h.Users.DeactivateUser(ctx, user.UserID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deactivate user %s: %w", user.UserID, err))
+			continue
+		}
+		deactivated = append(deactivated, user)
+
+		logEntry := &models.ActivityLog{
+			User:       "system",
+			Action:     "DEACTIVATE_DORMANT_USER",
+			Details:    fmt.Sprintf("Deactivated %s after %d days without signing in (last active %s)", user.Username, h.Days, user.LastActiveAt().Format("2006-01-02")),
+			Status:     "SUCCESS",
+			EntityType: AuditEntityUser,
+			EntityID:   user.UserID,
+		}
+		if err := h.Logs.Create(logEntry); err != nil {
+			log.Printf("Error recording deactivation of dormant user %s: %v", user.UserID, err)
+		}
+	}
+
+	if len(deactivated) > 0 {
+		h.notifyAdmins(ctx, tenantID, deactivated)
+	}
+	return deactivated, errors.Join(errs...)
+}
+
+// notifyAdmins pushes the deactivated accounts to the active admins of the tenant
+func (h *DormantAccountHandler) notifyAdmins(ctx context.Context, tenantID string, deactivated []models.UserDormancy) {
+	notification := models.Notification{Type: NotificationDormantAccounts, Data: api.DormantAccountsEvent{Days: h.Days, Users: deactivated}}
+	if err := notifyUsers(ctx, h.Users, h.Hub, tenantID, isAdmin, notification); err != nil {
+		log.Printf("Error notifying admins of dormant accounts: %v", err)
+	}
+}
+
+// GetDormantUsers handles previewing the dormant account policy
+// @Summary List dormant user accounts (Admin)
+// @Description Lists the active accounts nobody signed in to for DORMANT_ACCOUNT_DAYS days, least recently active first, and whether the policy will deactivate them. Exempt accounts and admins are never deactivated. Pass days to preview another threshold.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "Days without a sign-in (defaults to DORMANT_ACCOUNT_DAYS)"
+// @Success 200 {object} api.DormantUsersResponse "Dormant accounts"
+// @Failure 400 {object} api.ErrorResponse "Invalid days, or the policy is disabled and no days were given"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve dormant users"
+// @Router /admin/dormant-users [get]
+func (h *DormantAccountHandler) GetDormantUsers(c *fiber.Ctx) error {
+	days := h.Days
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxDormantAccountDays {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("days must be between 1 and %d", maxDormantAccountDays), StatusCode: fiber.StatusBadRequest})
+		}
+		days = parsed
+	}
+	if days <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The dormant account policy is disabled; pass days to preview it", StatusCode: fiber.StatusBadRequest})
+	}
+
+	cutoff := h.now().AddDate(0, 0, -days)
+	dormant, err := h.Repo.FindDormant(cutoff)
+	if err != nil {
+		log.Printf("Error finding dormant users: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve dormant users", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	users := make([]api.DormantUser, 0, len(dormant))
+	for _, user := range dormant {
+		users = append(users, api.DormantUser{UserDormancy: user, WillDeactivate: !dormancyProtected(user)})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.DormantUsersResponse{Days: days, Cutoff: cutoff, Users: users})
+}
+
+// DormancyExemptRequest is the body for exempting a user from the dormant account policy
+type DormancyExemptRequest struct {
+	Exempt *bool `json:"exempt"`
+}
+
+// SetDormancyExempt handles exempting a user from the dormant account policy
+// @Summary Set dormant account exemption (Admin)
+// @Description Sets whether a user is exempt from the dormant account policy. Exempt accounts are never deactivated for inactivity, such as accounts used only at audits.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Param exemption body DormancyExemptRequest true "Whether the user is exempt"
+// @Success 200 {object} api.MessageResponse "Exemption updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "User not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update exemption"
+// @Router /users/{id}/dormancy-exempt [put]
+func (h *DormantAccountHandler) SetDormancyExempt(c *fiber.Ctx) error {
+	id := strings.Clone(c.Params("id"))
+
+	var input DormancyExemptRequest
+	if err := c.BodyParser(&input); err != nil || input.Exempt == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "exempt is required", StatusCode: fiber.StatusBadRequest})
+	}
+
+	if err := h.Repo.SetExempt(id, *input.Exempt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "User not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error updating dormancy exemption of user %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update exemption", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	if *input.Exempt {
+		h.Audit.RecordAction(c, "SET_DORMANCY_EXEMPT", AuditEntityUser, id, "Exempted user from the dormant account policy")
+		return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "User is exempt from the dormant account policy"})
+	}
+	h.Audit.RecordAction(c, "CLEAR_DORMANCY_EXEMPT", AuditEntityUser, id, "Removed the dormant account policy exemption of user")
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "User is no longer exempt from the dormant account policy"})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDormancyTestApp registers the dormant account and user activation routes on an
+// in-memory store, with a 30 day policy evaluated 60 days from now
+func setupDormancyTestApp(t *testing.T) (*fiber.App, *memory.Store, *DormantAccountHandler, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	dormancy := NewDormantAccountHandler(store.Users, store.Dormancy, store.Logs, 30, jwtSecret)
+	dormancy.Audit = NewChangeRecorder(store.Logs)
+	later := time.Now().AddDate(0, 0, 60)
+	dormancy.now = func() time.Time { return later }
+	users := NewUserHandler(store.Users, jwtSecret)
+	users.Dormancy = dormancy
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	dormancy.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Put("/users/:id/activate", users.ActivateUser)
+	return app, store, dormancy, jwtSecret
+}
+
+func createDormancyTestUser(t *testing.T, store *memory.Store, username, role string) *models.User {
+	user := &models.User{Username: username, Email: username + "@example.com", Password: "secret", Role: role, IsActive: true}
+	require.NoError(t, store.Users.Create(context.Background(), user))
+	return user
+}
+
+func TestDeactivateDormantAccounts(t *testing.T) {
+	_, store, dormancy, _ := setupDormancyTestApp(t)
+	hub := services.NewNotificationHub()
+	dormancy.Hub = hub
+	dormant := createDormancyTestUser(t, store, "dormant", RoleStaff)
+	exempt := createDormancyTestUser(t, store, "exempt", RoleStaff)
+	admin := createDormancyTestUser(t, store, "admin", RoleAdmin)
+	recent := createDormancyTestUser(t, store, "recent", RoleStaff)
+	require.NoError(t, store.Dormancy.SetExempt(exempt.Id, true))
+	require.NoError(t, store.Dormancy.RecordLogin(recent.Id, dormancy.now().AddDate(0, 0, -1)))
+
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, admin.Id)
+	defer unsubscribe()
+	bystander, unsubscribeBystander := hub.Subscribe(models.DefaultTenantID, recent.Id)
+	defer unsubscribeBystander()
+
+	deactivated, err := dormancy.DeactivateDormant(context.Background(), models.DefaultTenantID)
+	require.NoError(t, err)
+	require.Len(t, deactivated, 1, "exempt users, admins and recent logins stay active")
+	assert.Equal(t, dormant.Id, deactivated[0].UserID)
+
+	for _, user := range []*models.User{dormant, exempt, admin, recent} {
+		current, err := store.Users.GetByID(context.Background(), user.Id)
+		require.NoError(t, err)
+		assert.Equal(t, user.Id != dormant.Id, current.IsActive, user.Username)
+	}
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "DEACTIVATE_DORMANT_USER", logs[0].Action)
+	assert.Equal(t, "system", logs[0].User)
+	assert.Equal(t, dormant.Id, logs[0].EntityID)
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, NotificationDormantAccounts, notification.Type)
+		event, ok := notification.Data.(api.DormantAccountsEvent)
+		require.True(t, ok)
+		assert.Equal(t, 30, event.Days)
+		require.Len(t, event.Users, 1)
+	case <-time.After(time.Second):
+		t.Fatal("admins were not notified")
+	}
+	select {
+	case <-bystander:
+		t.Fatal("only admins are notified")
+	default:
+	}
+
+	deactivated, err = dormancy.DeactivateDormant(context.Background(), models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Empty(t, deactivated, "inactive accounts are not deactivated twice")
+
+	dormancy.Days = 0
+	require.NoError(t, store.Users.ActivateUser(context.Background(), dormant.Id))
+	deactivated, err = dormancy.DeactivateDormant(context.Background(), models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Empty(t, deactivated, "the policy is disabled")
+}
+
+func TestReactivatedAccountsAreNotDormant(t *testing.T) {
+	app, store, dormancy, jwtSecret := setupDormancyTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	user := createDormancyTestUser(t, store, "returning", RoleStaff)
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), user.Id))
+
+	later := dormancy.now()
+	dormancy.now = func() time.Time { return later.AddDate(0, 0, -1) }
+	resp := authedRequest(t, app, adminToken, http.MethodPut, "/api/users/"+user.Id+"/activate", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	dormancy.now = func() time.Time { return later }
+	deactivated, err := dormancy.DeactivateDormant(context.Background(), models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Empty(t, deactivated, "reactivating restarts the count of days")
+}
+
+func TestDormantUsersRoutes(t *testing.T) {
+	app, store, _, jwtSecret := setupDormancyTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	staff := createDormancyTestUser(t, store, "staff", RoleStaff)
+	createDormancyTestUser(t, store, "admin", RoleAdmin)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/admin/dormant-users", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/dormant-users?days=0", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/users/"+staff.Id+"/dormancy-exempt", map[string]bool{"exempt": true})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/users/missing/dormancy-exempt", map[string]bool{"exempt": true})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/users/"+staff.Id+"/dormancy-exempt", map[string]string{})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/users/"+staff.Id+"/dormancy-exempt", map[string]bool{"exempt": false})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/dormant-users", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var preview api.DormantUsersResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+	assert.Equal(t, 30, preview.Days)
+	require.Len(t, preview.Users, 2)
+	for _, user := range preview.Users {
+		assert.False(t, user.WillDeactivate, "%s is exempt or an admin", user.Username)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/dormant-users?days=90", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&preview))
+	assert.Empty(t, preview.Users, "nobody was created 90 days ago")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "SET_DORMANCY_EXEMPT", logs[0].Action)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Days checked for duplicate sales by default, and at most
+const (
+	defaultDuplicateSaleDays = 30
+	maxDuplicateSaleDays     = 366
+)
+
+// AuditActionVoidDuplicateSale is the activity log action of voiding a sale as a duplicate
+const AuditActionVoidDuplicateSale = "VOID_DUPLICATE_SALE"
+
+// GetPossibleDuplicateSales handles the possible duplicate sales report
+// @Summary Possible duplicate sales
+// @Description Groups the sales for the same customer on the same day with the same total and items, likely entered twice. The first sale of each group is the oldest, presumed to be the original. Sales already voided as duplicates in the period are listed too.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "First sale date, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last sale date, YYYY-MM-DD (default today)"
+// @Success 200 {object} api.PossibleDuplicateSalesResponse "Possible duplicate sales"
+// @Failure 400 {object} api.ErrorResponse "Invalid period"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to find possible duplicate sales"
+// @Router /reports/possible-duplicate-sales [get]
+func (h *ReportHandler) GetPossibleDuplicateSales(c *fiber.Ctx) error {
+	to := h.now()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "to must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, 1-defaultDuplicateSaleDays)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "from must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		from = parsed
+	}
+	if calendarDaysBetween(from, to) < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "from must not be after to", StatusCode: fiber.StatusBadRequest})
+	}
+	if calendarDaysBetween(from, to) >= maxDuplicateSaleDays {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("the report covers at most %d days", maxDuplicateSaleDays), StatusCode: fiber.StatusBadRequest})
+	}
+	fromDate, toDate := from.Format(saleDateLayout), to.Format(saleDateLayout)
+
+	groups, err := h.Sales.GetPossibleDuplicates(c.Context(), fromDate, toDate)
+	if err != nil {
+		log.Printf("Error finding possible duplicate sales from %s to %s: %v", fromDate, toDate, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to find possible duplicate sales", StatusCode: fiber.StatusInternalServerError})
+	}
+	voided, err := h.Sales.GetDuplicateVoids(c.Context(), fromDate, toDate)
+	if err != nil {
+		log.Printf("Error listing sales voided as duplicates from %s to %s: %v", fromDate, toDate, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to find possible duplicate sales", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.PossibleDuplicateSalesResponse{
+		StartDate: fromDate,
+		EndDate:   toDate,
+		Groups:    groups,
+		Count:     len(groups),
+		Voided:    voided,
+	})
+}
+
+// VoidDuplicateSale handles voiding a sale as a duplicate of another
+// @Summary Void a duplicate sale
+// @Description Deletes a sale and its items as a duplicate of another sale for the same customer on the same day with the same total and items, which is kept. The void is recorded with both sale IDs and listed by the possible duplicate sales report. Payments of the voided sale are kept, and the cabs and accessories it took out of stock go back in stock. Cancelled and refunded sales cannot be voided, nor voided as duplicates of.
+// @Tags Reports
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param void body api.VoidDuplicateSaleRequest true "Sale to void and the sale it duplicates"
+// @Success 200 {object} api.VoidDuplicateSaleResponse "Sale voided"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 409 {object} api.ErrorResponse "The sales are not duplicates, or either was cancelled or refunded"
+// @Failure 500 {object} api.ErrorResponse "Failed to void sale"
+// @Router /reports/possible-duplicate-sales/void [post]
+func (h *ReportHandler) VoidDuplicateSale(c *fiber.Ctx) error {
+	var input api.VoidDuplicateSaleRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	input.SaleID = strings.TrimSpace(input.SaleID)
+	input.DuplicateOf = strings.TrimSpace(input.DuplicateOf)
+	if input.SaleID == "" || input.DuplicateOf == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "saleId and duplicateOf are required", StatusCode: fiber.StatusBadRequest})
+	}
+	userID, _ := c.Locals("user_id").(string)
+
+	void, err := h.Sales.VoidDuplicate(c.Context(), input.SaleID, input.DuplicateOf, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+	}
+	if errors.Is(err, repositories.ErrSaleTransition) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Cancelled and refunded sales cannot be voided as duplicates", StatusCode: fiber.StatusConflict})
+	}
+	if errors.Is(err, repositories.ErrNotDuplicateSale) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("sale %s is not a duplicate of sale %s: the customer, date, total or items differ", input.SaleID, input.DuplicateOf),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+	if err != nil {
+		log.Printf("Error voiding sale %s as a duplicate of sale %s: %v", input.SaleID, input.DuplicateOf, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to void sale", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, AuditActionVoidDuplicateSale, AuditEntitySale, void.SaleID,
+		fmt.Sprintf("Voided sale %s of %.2f sold by %s as a duplicate of sale %s", void.SaleID, void.TotalPrice, void.SoldBy, void.DuplicateOf))
+	return c.Status(fiber.StatusOK).JSON(api.VoidDuplicateSaleResponse{Message: "Sale voided as a duplicate", Void: *void})
+}
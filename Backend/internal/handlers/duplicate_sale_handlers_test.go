@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPossibleDuplicateSales(t *testing.T) {
+	app, store, token := setupReportTestApp(t)
+	addItemSale := func(date string, accessoryID string) string {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "staff-2", SaleDate: date, TotalPrice: 450})
+		require.NoError(t, err)
+		_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: id, Item: models.NewRef(models.RefAccessory, accessoryID), Quantity: 1, UnitPrice: 450, Subtotal: 450})
+		require.NoError(t, err)
+		return id
+	}
+	original := addItemSale("2025-03-10", "4")
+	duplicate := addItemSale("2025-03-10", "4")
+	addItemSale("2025-03-10", "5") // Other items
+	addItemSale("2025-01-10", "4") // Before the default range
+	addItemSale("2025-01-10", "4")
+
+	for _, query := range []string{"?from=2025-03-12&to=2025-03-01", "?from=2024-01-01", "?to=March"} {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/possible-duplicate-sales"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/possible-duplicate-sales", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.PossibleDuplicateSalesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, "2025-02-11", report.StartDate, "defaults to the last 30 days")
+	assert.Equal(t, "2025-03-12", report.EndDate)
+	require.Equal(t, 1, report.Count)
+	require.Len(t, report.Groups[0].Sales, 2)
+	assert.Equal(t, original, report.Groups[0].Sales[0].ID)
+	assert.Equal(t, duplicate, report.Groups[0].Sales[1].ID)
+	assert.Empty(t, report.Voided)
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/reports/possible-duplicate-sales/void", map[string]string{"saleId": duplicate})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/reports/possible-duplicate-sales/void", map[string]string{"saleId": "sale-99", "duplicateOf": original})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/reports/possible-duplicate-sales/void", map[string]string{"saleId": original, "duplicateOf": original})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/reports/possible-duplicate-sales/void", map[string]string{"saleId": duplicate, "duplicateOf": original})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var voided api.VoidDuplicateSaleResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&voided))
+	assert.Equal(t, duplicate, voided.Void.SaleID)
+	assert.Equal(t, original, voided.Void.DuplicateOf)
+	assert.Equal(t, "staff-1", voided.Void.VoidedBy)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/reports/possible-duplicate-sales", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report = api.PossibleDuplicateSalesResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Zero(t, report.Count)
+	assert.Empty(t, report.Groups)
+	require.Len(t, report.Voided, 1)
+	assert.Equal(t, duplicate, report.Voided[0].SaleID)
+
+	cancelled := addItemSale("2025-03-10", "4")
+	require.NoError(t, store.Sales.SetStatus(context.Background(), cancelled, models.SaleCancelled))
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/reports/possible-duplicate-sales/void", map[string]string{"saleId": cancelled, "duplicateOf": original})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "cancelled sales cannot be voided")
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDuplicateSubmissionTestApp guards the customer and cab create routes the way
+// the server does
+func setupDuplicateSubmissionTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	dedupe := middleware.DuplicateSubmissions(services.NewSubmissionGuard(10*time.Second), jwtSecret)
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	apiGroup.Post("/customers", dedupe)
+	NewCustomerHandler(store.Customers, jwtSecret).Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Post("/cabs", dedupe, NewCabsHandlers(store.Cabs).AddCab)
+	return app, store, jwtSecret
+}
+
+func TestDuplicateCustomerSubmission(t *testing.T) {
+	app, store, jwtSecret := setupDuplicateSubmissionTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	input := CreateCustomerRequest{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "+639171234567"}
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/customers", input)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var first api.CustomerResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&first))
+	assert.Empty(t, resp.Header.Get(middleware.HeaderDuplicateSubmission))
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/customers", input)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var second api.CustomerResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&second))
+	assert.Equal(t, first.ID, second.ID, "the customer created first is returned")
+	assert.Equal(t, "true", resp.Header.Get(middleware.HeaderDuplicateSubmission))
+
+	customers, err := store.Customers.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, customers, 1)
+
+	// A different payload creates a record
+	input.Email = "juan.delacruz@example.com"
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/customers", input)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(middleware.HeaderDuplicateSubmission))
+}
+
+func TestDuplicateSubmissionAfterRejection(t *testing.T) {
+	app, store, jwtSecret := setupDuplicateSubmissionTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	cab := models.MultiCab{Name: "Unit 7", Make: "Suzuki", UnitColor: "White", Quantity: 1, Price: 250000, Status: "Available"}
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/cabs", cab)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/cabs", cab)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get(middleware.HeaderDuplicateSubmission))
+
+	// The same form submitted by another user creates a record
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	resp = authedRequest(t, app, otherToken, http.MethodPost, "/api/cabs", cab)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(middleware.HeaderDuplicateSubmission))
+
+	cabs, err := store.Cabs.GetCabs(context.Background(), models.CabFilter{})
+	require.NoError(t, err)
+	assert.Len(t, cabs, 2)
+
+	// Rejected requests are not replayed
+	invalid := models.MultiCab{Name: "Unit 8"}
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/cabs", invalid)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/cabs", invalid)
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(middleware.HeaderDuplicateSubmission))
+}
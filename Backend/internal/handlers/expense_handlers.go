@@ -0,0 +1,640 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxExpenseAttachmentSize is the largest receipt or invoice accepted, in bytes. It
+// stays under the server's 4 MB request body limit.
+const maxExpenseAttachmentSize = 2 * 1024 * 1024
+
+// Attachment types accepted, detected from the file contents
+var expenseAttachmentTypes = map[string]bool{"application/pdf": true, "image/png": true, "image/jpeg": true}
+
+// ExpenseHandler serves yard expenses. Staff submit expenses with their receipts and
+// an admin approves or rejects them; only approved expenses count in reports.
+type ExpenseHandler struct {
+	Repo  repositories.ExpenseRepository
+	Audit *ChangeRecorder
+	// Files, when set, serves attachments kept in file storage through short-lived
+	// links valid for LinkExpiry instead of through the API
+	Files      services.Storage
+	LinkExpiry time.Duration
+	now        func() time.Time
+	jwtSecret  []byte
+}
+
+// NewExpenseHandler creates a new ExpenseHandler instance
+func NewExpenseHandler(repo repositories.ExpenseRepository, jwtSecret []byte) *ExpenseHandler {
+	return &ExpenseHandler{Repo: repo, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the expense routes
+func (h *ExpenseHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	expenseGroup := r.Group("/expenses", mw.Auth)
+	expenseGroup.Get("/", h.GetExpenses)                                             // GET /api/expenses
+	expenseGroup.Get("/categories", h.GetExpenseCategories)                          // GET /api/expenses/categories (must precede /:id)
+	expenseGroup.Get("/:id", h.GetExpense)                                           // GET /api/expenses/:id
+	expenseGroup.Post("/", h.CreateExpense)                                          // POST /api/expenses
+	expenseGroup.Put("/:id", h.UpdateExpense)                                        // PUT /api/expenses/:id
+	expenseGroup.Delete("/:id", h.DeleteExpense)                                     // DELETE /api/expenses/:id
+	expenseGroup.Post("/:id/approve", mw.Admin, h.ApproveExpense)                    // POST /api/expenses/:id/approve
+	expenseGroup.Post("/:id/reject", mw.Admin, h.RejectExpense)                      // POST /api/expenses/:id/reject
+	expenseGroup.Post("/:id/attachments", h.AddExpenseAttachment)                    // POST /api/expenses/:id/attachments
+	expenseGroup.Get("/:id/attachments/:attachmentId", h.GetExpenseAttachment)       // GET /api/expenses/:id/attachments/:attachmentId
+	expenseGroup.Delete("/:id/attachments/:attachmentId", h.DeleteExpenseAttachment) // DELETE /api/expenses/:id/attachments/:attachmentId
+}
+
+// ExpenseRequest is the body for submitting or replacing an expense
+type ExpenseRequest struct {
+	Category    string  `json:"category"`    // fuel, repairs, utilities, supplies, rent, salaries or other
+	Description string  `json:"description"` // Up to 500 characters
+	Vendor      string  `json:"vendor"`      // Up to 200 characters
+	Amount      float64 `json:"amount"`
+	ExpenseDate string  `json:"expenseDate"` // YYYY-MM-DD; today when empty
+}
+
+// ExpenseReviewRequest is the body for approving or rejecting an expense
+type ExpenseReviewRequest struct {
+	Note string `json:"note"` // Required when rejecting; up to 500 characters
+}
+
+// validate trims the fields, checks them and fills in the default date
+func (r *ExpenseRequest) validate(today time.Time) error {
+	r.Category = strings.TrimSpace(r.Category)
+	r.Description = strings.TrimSpace(r.Description)
+	r.Vendor = strings.TrimSpace(r.Vendor)
+	r.ExpenseDate = strings.TrimSpace(r.ExpenseDate)
+
+	if !models.ValidExpenseCategory(r.Category) {
+		return fmt.Errorf("category must be one of %s", strings.Join(models.ExpenseCategories, ", "))
+	}
+	if r.Description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if utf8.RuneCountInString(r.Description) > 500 {
+		return fmt.Errorf("description must be at most 500 characters")
+	}
+	if utf8.RuneCountInString(r.Vendor) > 200 {
+		return fmt.Errorf("vendor must be at most 200 characters")
+	}
+	if r.Amount <= 0 || math.IsInf(r.Amount, 0) || math.IsNaN(r.Amount) {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+	r.Amount = float64(toCentavos(r.Amount)) / 100
+
+	if r.ExpenseDate == "" {
+		r.ExpenseDate = today.Format(saleDateLayout)
+	}
+	date, err := time.ParseInLocation(saleDateLayout, r.ExpenseDate, today.Location())
+	if err != nil {
+		return fmt.Errorf("expenseDate must be formatted as YYYY-MM-DD")
+	}
+	if date.After(today) {
+		return fmt.Errorf("expenseDate cannot be in the future")
+	}
+	return nil
+}
+
+// loadExpense fetches the expense named by the :id parameter, writing the error
+// response itself when it fails
+func (h *ExpenseHandler) loadExpense(c *fiber.Ctx) (*models.Expense, error) {
+	expense, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Expense not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting expense %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve expense", StatusCode: fiber.StatusInternalServerError})
+	}
+	return expense, nil
+}
+
+// checkCanChangeExpense writes the error response when the caller may not change an expense.
+// Admins can change any expense; the submitter only while it is pending review.
+func checkCanChangeExpense(c *fiber.Ctx, expense *models.Expense) (bool, error) {
+	requestUserID, _ := c.Locals("user_id").(string)
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole == RoleAdmin {
+		return true, nil
+	}
+	if requestUserID != expense.SubmittedBy {
+		return false, c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+	if expense.Status != models.ExpensePending {
+		return false, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("The expense was already %s and can only be changed by an admin", expense.Status),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+	return true, nil
+}
+
+// GetExpenses handles listing expenses
+// @Summary List expenses
+// @Description Lists the expenses of the tenant, latest first, with the total of the listed amounts. Attachments are not included; get an expense to see them.
+// @Tags Expenses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param category query string false "Only expenses of this category"
+// @Param status query string false "pending, approved or rejected"
+// @Param submittedBy query string false "Only expenses submitted by this user"
+// @Param startDate query string false "First expense date, YYYY-MM-DD"
+// @Param endDate query string false "Last expense date, YYYY-MM-DD"
+// @Success 200 {object} api.ExpenseListResponse "Expenses"
+// @Failure 400 {object} api.ErrorResponse "Invalid filter"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve expenses"
+// @Router /expenses [get]
+func (h *ExpenseHandler) GetExpenses(c *fiber.Ctx) error {
+	filter := models.ExpenseFilter{
+		Category:    c.Query("category"),
+		Status:      c.Query("status"),
+		SubmittedBy: c.Query("submittedBy"),
+		StartDate:   c.Query("startDate"),
+		EndDate:     c.Query("endDate"),
+	}
+	if filter.Category != "" && !models.ValidExpenseCategory(filter.Category) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Unknown expense category", StatusCode: fiber.StatusBadRequest})
+	}
+	switch filter.Status {
+	case "", models.ExpensePending, models.ExpenseApproved, models.ExpenseRejected:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "status must be pending, approved or rejected", StatusCode: fiber.StatusBadRequest})
+	}
+	for _, date := range []string{filter.StartDate, filter.EndDate} {
+		if _, err := time.Parse(saleDateLayout, date); date != "" && err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Dates must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+
+	expenses, err := h.Repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting expenses: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve expenses", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	var total int64
+	for _, expense := range expenses {
+		total += toCentavos(expense.Amount)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.ExpenseListResponse{Expenses: expenses, Count: len(expenses), Total: float64(total) / 100})
+}
+
+// GetExpenseCategories handles listing the expense categories
+// @Summary List expense categories
+// @Tags Expenses
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.ExpenseCategoriesResponse "Categories"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Router /expenses/categories [get]
+func (h *ExpenseHandler) GetExpenseCategories(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(api.ExpenseCategoriesResponse{Categories: models.ExpenseCategories})
+}
+
+// GetExpense handles getting an expense
+// @Summary Get an expense
+// @Description Returns an expense with the list of its attachments.
+// @Tags Expenses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Success 200 {object} models.Expense "Expense"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Expense not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve expense"
+// @Router /expenses/{id} [get]
+func (h *ExpenseHandler) GetExpense(c *fiber.Ctx) error {
+	expense, err := h.loadExpense(c)
+	if expense == nil {
+		return err
+	}
+	setLastModified(c, expense.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(expense)
+}
+
+// CreateExpense handles submitting an expense
+// @Summary Submit an expense
+// @Description Records an expense pending review by an admin. Attach receipts with POST /expenses/{id}/attachments.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param expense body ExpenseRequest true "Expense"
+// @Success 201 {object} api.ExpenseResponse "Expense submitted"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to create expense"
+// @Router /expenses [post]
+func (h *ExpenseHandler) CreateExpense(c *fiber.Ctx) error {
+	var input ExpenseRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(h.now()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	expense := &models.Expense{
+		Category:    input.Category,
+		Description: input.Description,
+		Vendor:      input.Vendor,
+		Amount:      input.Amount,
+		ExpenseDate: input.ExpenseDate,
+		Status:      models.ExpensePending,
+	}
+	expense.SubmittedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Repo.Create(expense); err != nil {
+		log.Printf("Error creating expense: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create expense", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_EXPENSE", AuditEntityExpense, expense.ID,
+		fmt.Sprintf("Submitted a %.2f %s expense: %s", expense.Amount, expense.Category, expense.Description))
+
+	return c.Status(fiber.StatusCreated).JSON(api.ExpenseResponse{Message: "Expense submitted", Expense: expense})
+}
+
+// UpdateExpense handles editing an expense
+// @Summary Update an expense
+// @Description Replaces the category, description, vendor, amount and date of a pending expense. The submitter can edit their own expenses; admins can edit any.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Param expense body ExpenseRequest true "Expense"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.ExpenseResponse "Expense updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Expense not found"
+// @Failure 409 {object} api.ErrorResponse "Expense already reviewed"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update expense"
+// @Router /expenses/{id} [put]
+func (h *ExpenseHandler) UpdateExpense(c *fiber.Ctx) error {
+	var input ExpenseRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(h.now()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	expense, err := h.loadExpense(c)
+	if expense == nil {
+		return err
+	}
+	if ok, err := checkCanChangeExpense(c, expense); !ok {
+		return err
+	}
+	if expense.Status != models.ExpensePending {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Only pending expenses can be edited", StatusCode: fiber.StatusConflict})
+	}
+	if modified, err := rejectIfModified(c, expense.UpdatedAt); modified {
+		return err
+	}
+	before := *expense
+
+	expense.Category = input.Category
+	expense.Description = input.Description
+	expense.Vendor = input.Vendor
+	expense.Amount = input.Amount
+	expense.ExpenseDate = input.ExpenseDate
+
+	if err := h.Repo.Update(expense); err != nil {
+		log.Printf("Error updating expense %s: %v", expense.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update expense", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityExpense, expense.ID, before, *expense)
+
+	setLastModified(c, expense.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.ExpenseResponse{Message: "Expense updated", Expense: expense})
+}
+
+// DeleteExpense handles removing an expense
+// @Summary Delete an expense
+// @Description Removes an expense and its attachments. The submitter can delete their own expenses while they are pending; admins can delete any.
+// @Tags Expenses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Success 200 {object} api.MessageResponse "Expense deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Expense not found"
+// @Failure 409 {object} api.ErrorResponse "Expense already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete expense"
+// @Router /expenses/{id} [delete]
+func (h *ExpenseHandler) DeleteExpense(c *fiber.Ctx) error {
+	expense, err := h.loadExpense(c)
+	if expense == nil {
+		return err
+	}
+	if ok, err := checkCanChangeExpense(c, expense); !ok {
+		return err
+	}
+
+	if err := h.Repo.Delete(expense.ID); err != nil {
+		log.Printf("Error deleting expense %s: %v", expense.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete expense", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_EXPENSE", AuditEntityExpense, expense.ID,
+		fmt.Sprintf("Deleted the %s %.2f %s expense: %s", expense.Status, expense.Amount, expense.Category, expense.Description))
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Expense deleted"})
+}
+
+// ApproveExpense handles approving an expense
+// @Summary Approve an expense (Admin)
+// @Description Approves a pending expense, so it is deducted in the monthly report.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Param review body ExpenseReviewRequest false "Optional note"
+// @Success 200 {object} api.ExpenseResponse "Expense approved"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Expense not found"
+// @Failure 409 {object} api.ErrorResponse "Expense already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to review expense"
+// @Router /expenses/{id}/approve [post]
+func (h *ExpenseHandler) ApproveExpense(c *fiber.Ctx) error {
+	return h.reviewExpense(c, models.ExpenseApproved)
+}
+
+// RejectExpense handles rejecting an expense
+// @Summary Reject an expense (Admin)
+// @Description Rejects a pending expense with a note explaining why. Rejected expenses are not counted in reports.
+// @Tags Expenses
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Param review body ExpenseReviewRequest true "Reason for rejecting"
+// @Success 200 {object} api.ExpenseResponse "Expense rejected"
+// @Failure 400 {object} api.ErrorResponse "Missing note"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Expense not found"
+// @Failure 409 {object} api.ErrorResponse "Expense already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to review expense"
+// @Router /expenses/{id}/reject [post]
+func (h *ExpenseHandler) RejectExpense(c *fiber.Ctx) error {
+	return h.reviewExpense(c, models.ExpenseRejected)
+}
+
+// reviewExpense moves a pending expense to approved or rejected
+func (h *ExpenseHandler) reviewExpense(c *fiber.Ctx, status string) error {
+	var input ExpenseReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	input.Note = strings.TrimSpace(input.Note)
+	if status == models.ExpenseRejected && input.Note == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "A note explaining the rejection is required", StatusCode: fiber.StatusBadRequest})
+	}
+	if utf8.RuneCountInString(input.Note) > 500 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "note must be at most 500 characters", StatusCode: fiber.StatusBadRequest})
+	}
+
+	expense, err := h.loadExpense(c)
+	if expense == nil {
+		return err
+	}
+	if expense.Status != models.ExpensePending {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("The expense was already %s", expense.Status),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+	before := *expense
+
+	now := h.now()
+	expense.Status = status
+	expense.ReviewedBy, _ = c.Locals("user_id").(string)
+	expense.ReviewedAt = &now
+	expense.ReviewNote = input.Note
+
+	if err := h.Repo.Update(expense); err != nil {
+		log.Printf("Error reviewing expense %s: %v", expense.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to review expense", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityExpense, expense.ID, before, *expense)
+
+	message := "Expense approved"
+	if status == models.ExpenseRejected {
+		message = "Expense rejected"
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ExpenseResponse{Message: message, Expense: expense})
+}
+
+// AddExpenseAttachment handles attaching a receipt or invoice to an expense
+// @Summary Attach a file to an expense
+// @Description Attaches a PDF, PNG or JPEG file of up to 2 MB, sent in the multipart "file" field. The submitter and admins can attach files at any time, so receipts can be added after review.
+// @Tags Expenses
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Param file formData file true "PDF, PNG or JPEG file"
+// @Success 201 {object} api.ExpenseAttachmentResponse "File attached"
+// @Failure 400 {object} api.ErrorResponse "Missing, oversized or unsupported file"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Expense not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to attach file"
+// @Router /expenses/{id}/attachments [post]
+func (h *ExpenseHandler) AddExpenseAttachment(c *fiber.Ctx) error {
+	expense, err := h.loadExpense(c)
+	if expense == nil {
+		return err
+	}
+	requestUserID, _ := c.Locals("user_id").(string)
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin && requestUserID != expense.SubmittedBy {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "A file is required in the \"file\" field", StatusCode: fiber.StatusBadRequest})
+	}
+	if fileHeader.Size > maxExpenseAttachmentSize {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Attachments must be at most 2 MB", StatusCode: fiber.StatusBadRequest})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error opening uploaded expense attachment: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to attach file", StatusCode: fiber.StatusInternalServerError})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxExpenseAttachmentSize+1))
+	if err != nil {
+		log.Printf("Error reading uploaded expense attachment: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to attach file", StatusCode: fiber.StatusInternalServerError})
+	}
+	if len(data) > maxExpenseAttachmentSize {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Attachments must be at most 2 MB", StatusCode: fiber.StatusBadRequest})
+	}
+
+	// The declared content type is not trusted; the file is sniffed instead
+	contentType := http.DetectContentType(data)
+	if !expenseAttachmentTypes[contentType] {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Attachments must be PDF, PNG or JPEG files", StatusCode: fiber.StatusBadRequest})
+	}
+
+	attachment := &models.ExpenseAttachment{
+		ExpenseID:   expense.ID,
+		FileName:    attachmentFileName(fileHeader.Filename),
+		ContentType: contentType,
+		UploadedBy:  requestUserID,
+		UploadedAt:  h.now(),
+	}
+	if err := h.Repo.AddAttachment(attachment, data); err != nil {
+		log.Printf("Error saving attachment of expense %s: %v", expense.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to attach file", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "ADD_EXPENSE_ATTACHMENT", AuditEntityExpense, expense.ID,
+		fmt.Sprintf("Attached %s (%d bytes) to the expense", attachment.FileName, attachment.Size))
+
+	return c.Status(fiber.StatusCreated).JSON(api.ExpenseAttachmentResponse{Message: "File attached", Attachment: attachment})
+}
+
+// attachmentFileName keeps the base name of an uploaded file, replacing characters
+// that cannot be sent back in a Content-Disposition header
+func attachmentFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' || r == '"' || r == '/' {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	if name == "" || name == "." {
+		return "attachment"
+	}
+	return name
+}
+
+// GetExpenseAttachment handles downloading an attachment
+// @Summary Download an expense attachment
+// @Description Attachments kept in file storage are served by redirecting to a short-lived download link; the others are sent in the response.
+// @Tags Expenses
+// @Produce application/pdf,png,jpeg
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {file} binary "Attached file"
+// @Success 302 "Redirect to a download link"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Attachment not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve attachment"
+// @Router /expenses/{id}/attachments/{attachmentId} [get]
+func (h *ExpenseHandler) GetExpenseAttachment(c *fiber.Ctx) error {
+	if h.Files != nil {
+		if link, err := h.attachmentLink(c.Params("id"), c.Params("attachmentId")); err != nil {
+			log.Printf("Error signing a link to attachment %s of expense %s: %v", c.Params("attachmentId"), c.Params("id"), err)
+		} else if link != "" {
+			return c.Redirect(link, fiber.StatusFound)
+		}
+	}
+
+	attachment, data, err := h.Repo.GetAttachment(c.Params("id"), c.Params("attachmentId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Attachment not found", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error getting attachment %s of expense %s: %v", c.Params("attachmentId"), c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve attachment", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+attachment.FileName+`"`)
+	return c.Status(fiber.StatusOK).Send(data)
+}
+
+// attachmentLink returns a download link of an attachment kept in file storage, or
+// an empty link when it is kept in the database or does not exist
+func (h *ExpenseHandler) attachmentLink(expenseID, id string) (string, error) {
+	expense, err := h.Repo.GetByID(expenseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, attachment := range expense.Attachments {
+		if attachment.ID == id && attachment.StorageKey != "" {
+			return h.Files.SignedURL(attachment.StorageKey, attachment.FileName, h.LinkExpiry)
+		}
+	}
+	return "", nil
+}
+
+// DeleteExpenseAttachment handles removing an attachment
+// @Summary Delete an expense attachment
+// @Description Removes a file from an expense. The submitter can remove files while the expense is pending; admins at any time.
+// @Tags Expenses
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Expense ID"
+// @Param attachmentId path string true "Attachment ID"
+// @Success 200 {object} api.MessageResponse "Attachment deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Attachment not found"
+// @Failure 409 {object} api.ErrorResponse "Expense already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete attachment"
+// @Router /expenses/{id}/attachments/{attachmentId} [delete]
+func (h *ExpenseHandler) DeleteExpenseAttachment(c *fiber.Ctx) error {
+	expense, err := h.loadExpense(c)
+	if expense == nil {
+		return err
+	}
+	if ok, err := checkCanChangeExpense(c, expense); !ok {
+		return err
+	}
+
+	err = h.Repo.DeleteAttachment(expense.ID, c.Params("attachmentId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Attachment not found", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error deleting attachment %s of expense %s: %v", c.Params("attachmentId"), expense.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete attachment", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_EXPENSE_ATTACHMENT", AuditEntityExpense, expense.ID,
+		fmt.Sprintf("Removed attachment %s from the expense", c.Params("attachmentId")))
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Attachment deleted"})
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupExpenseTestApp registers the expense routes on an in-memory store, with the
+// clock fixed to 12 March 2025
+func setupExpenseTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewExpenseHandler(store.Expenses, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+	h.now = func() time.Time { return time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local) }
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+// submitExpense creates an expense with the token's user and returns it
+func submitExpense(t *testing.T, app *fiber.App, token string, input ExpenseRequest) *models.Expense {
+	t.Helper()
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/expenses", input)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.ExpenseResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created.Expense
+}
+
+// uploadExpenseAttachment sends data in the multipart "file" field
+func uploadExpenseAttachment(t *testing.T, app *fiber.App, token, expenseID, fileName string, data []byte) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/expenses/"+expenseID+"/attachments", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCreateExpense(t *testing.T) {
+	app, store, jwtSecret := setupExpenseTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	for name, input := range map[string]ExpenseRequest{
+		"unknown category": {Category: "snacks", Description: "Merienda", Amount: 300},
+		"no description":   {Category: models.ExpenseFuel, Amount: 300},
+		"zero amount":      {Category: models.ExpenseFuel, Description: "Diesel"},
+		"bad date":         {Category: models.ExpenseFuel, Description: "Diesel", Amount: 300, ExpenseDate: "12/03/2025"},
+		"future date":      {Category: models.ExpenseFuel, Description: "Diesel", Amount: 300, ExpenseDate: "2025-03-13"},
+	} {
+		resp := authedRequest(t, app, token, http.MethodPost, "/api/expenses", input)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	expense := submitExpense(t, app, token, ExpenseRequest{Category: models.ExpenseFuel, Description: " Diesel for the tow truck ", Amount: 2500.456})
+	assert.Equal(t, models.ExpensePending, expense.Status)
+	assert.Equal(t, "staff-1", expense.SubmittedBy)
+	assert.Equal(t, "Diesel for the tow truck", expense.Description)
+	assert.Equal(t, 2500.46, expense.Amount, "rounded to centavos")
+	assert.Equal(t, "2025-03-12", expense.ExpenseDate, "defaults to today")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "CREATE_EXPENSE", logs[0].Action)
+}
+
+func TestGetExpenses(t *testing.T) {
+	app, _, jwtSecret := setupExpenseTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	submitExpense(t, app, token, ExpenseRequest{Category: models.ExpenseFuel, Description: "Diesel", Amount: 1500, ExpenseDate: "2025-03-01"})
+	submitExpense(t, app, token, ExpenseRequest{Category: models.ExpenseUtilities, Description: "Electricity", Amount: 4200.50, ExpenseDate: "2025-03-10"})
+	submitExpense(t, app, token, ExpenseRequest{Category: models.ExpenseFuel, Description: "Gasoline", Amount: 800, ExpenseDate: "2025-02-20"})
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/expenses?startDate=2025-03-01&endDate=2025-03-31", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ExpenseListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 2, list.Count)
+	assert.Equal(t, "Electricity", list.Expenses[0].Description, "latest first")
+	assert.Equal(t, 5700.50, list.Total)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/expenses?category=fuel", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 2, list.Count)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/expenses?status=paid", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodGet, "/api/expenses", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestUpdateExpensePermissions(t *testing.T) {
+	app, _, jwtSecret := setupExpenseTestApp(t)
+	ownerToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	expense := submitExpense(t, app, ownerToken, ExpenseRequest{Category: models.ExpenseRepairs, Description: "Welding", Amount: 3000})
+	path := "/api/expenses/" + expense.ID
+	update := ExpenseRequest{Category: models.ExpenseRepairs, Description: "Welding the hoist", Amount: 3500, ExpenseDate: "2025-03-11"}
+
+	resp := authedRequest(t, app, otherToken, http.MethodPut, path, update)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, ownerToken, http.MethodPut, path, update)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated api.ExpenseResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	assert.Equal(t, 3500.0, updated.Expense.Amount)
+	assert.Equal(t, "2025-03-11", updated.Expense.ExpenseDate)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, path+"/approve", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, ownerToken, http.MethodPut, path, update)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "reviewed expenses cannot be edited")
+	resp = authedRequest(t, app, ownerToken, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "only admins delete reviewed expenses")
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestReviewExpense(t *testing.T) {
+	app, _, jwtSecret := setupExpenseTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	expense := submitExpense(t, app, staffToken, ExpenseRequest{Category: models.ExpenseSupplies, Description: "Paint", Amount: 950})
+	path := "/api/expenses/" + expense.ID
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, path+"/approve", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, path+"/reject", ExpenseReviewRequest{})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "rejecting needs a note")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, path+"/reject", ExpenseReviewRequest{Note: "Personal purchase"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var reviewed api.ExpenseResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reviewed))
+	assert.Equal(t, models.ExpenseRejected, reviewed.Expense.Status)
+	assert.Equal(t, "admin-1", reviewed.Expense.ReviewedBy)
+	assert.Equal(t, "Personal purchase", reviewed.Expense.ReviewNote)
+	require.NotNil(t, reviewed.Expense.ReviewedAt)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, path+"/approve", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "expenses are reviewed once")
+}
+
+func TestExpenseAttachments(t *testing.T) {
+	app, _, jwtSecret := setupExpenseTestApp(t)
+	ownerToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	expense := submitExpense(t, app, ownerToken, ExpenseRequest{Category: models.ExpenseFuel, Description: "Diesel", Amount: 1500})
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n%%EOF\n")
+
+	resp := uploadExpenseAttachment(t, app, otherToken, expense.ID, "receipt.pdf", pdf)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = uploadExpenseAttachment(t, app, ownerToken, expense.ID, "notes.txt", []byte("not a receipt"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = uploadExpenseAttachment(t, app, ownerToken, expense.ID, "big.pdf", append(append([]byte{}, pdf...), make([]byte, maxExpenseAttachmentSize)...))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = uploadExpenseAttachment(t, app, ownerToken, expense.ID, `C:\scans\gas "receipt".pdf`, pdf)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var attached api.ExpenseAttachmentResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&attached))
+	assert.Equal(t, "gas _receipt_.pdf", attached.Attachment.FileName)
+	assert.Equal(t, "application/pdf", attached.Attachment.ContentType)
+	assert.Equal(t, len(pdf), attached.Attachment.Size)
+
+	resp = authedRequest(t, app, otherToken, http.MethodGet, "/api/expenses/"+expense.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got models.Expense
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.Len(t, got.Attachments, 1)
+
+	attachmentPath := "/api/expenses/" + expense.ID + "/attachments/" + attached.Attachment.ID
+	resp = authedRequest(t, app, otherToken, http.MethodGet, attachmentPath, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="gas _receipt_.pdf"`, resp.Header.Get("Content-Disposition"))
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, data)
+
+	resp = authedRequest(t, app, otherToken, http.MethodDelete, attachmentPath, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, ownerToken, http.MethodDelete, attachmentPath, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, ownerToken, http.MethodGet, attachmentPath, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
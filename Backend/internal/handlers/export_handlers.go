@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AuditEntityExport is the entity type of exports in the activity log
+const AuditEntityExport = "export"
+
+// Defaults used when no export configuration is set
+const (
+	defaultExportRetention  = 24 * time.Hour
+	defaultExportLinkExpiry = 15 * time.Minute
+)
+
+// ExportHandler builds CSV exports of sales, inventory and customers in the
+// background. Callers queue an export, poll it for progress and download the file
+// through a signed link once it is completed. Exports are deleted after Retention.
+type ExportHandler struct {
+	Repo        repositories.ExportJobRepository
+	Sales       repositories.SalesRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Customers   repositories.CustomerRepository
+	Consents    repositories.CustomerConsentRepository // Campaign exports only list customers who consented
+	Queue       *services.ExportQueue
+	Perms       *Permissions
+	Audit       *ChangeRecorder
+	Retention   time.Duration
+	// Files, when set, serves exports kept in file storage through its own links
+	// instead of through the API. Links are valid for LinkExpiry either way.
+	Files      services.Storage
+	LinkExpiry time.Duration
+	now        func() time.Time
+	jwtSecret  []byte
+}
+
+// NewExportHandler creates a new ExportHandler instance
+func NewExportHandler(repo repositories.ExportJobRepository, sales repositories.SalesRepository, cabs repositories.CabsRepository,
+	accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, customers repositories.CustomerRepository,
+	queue *services.ExportQueue, jwtSecret []byte) *ExportHandler {
+	return &ExportHandler{
+		Repo: repo, Sales: sales, Cabs: cabs, Accessories: accessories, Materials: materials, Customers: customers, Queue: queue,
+		Retention: defaultExportRetention, LinkExpiry: defaultExportLinkExpiry, now: time.Now, jwtSecret: jwtSecret,
+	}
+}
+
+// Routes registers the export routes. The download route is
+// authenticated by its signature, so links work when opened in a new tab.
+func (h *ExportHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/exports/:id/download", h.DownloadExport) // GET /api/exports/:id/download?expires=...&signature=... (signed link)
+
+	exportGroup := r.Group("/exports", mw.Auth)
+	exportGroup.Post("/", h.CreateExport) // POST /api/exports
+	exportGroup.Get("/", h.GetExports)    // GET /api/exports
+	exportGroup.Get("/:id", h.GetExport)  // GET /api/exports/:id
+}
+
+// ExportRequest is the body for requesting an export
+type ExportRequest struct {
+	Type    string            `json:"type"`    // sales, cabs, accessories, materials, customers or customer_occasions
+	Filters map[string]string `json:"filters"` // Filters of the kind of export, such as start_date and end_date for sales
+}
+
+// CreateExport handles queueing an export
+// @Summary Request an export
+// @Description Queues a CSV export of sales, cabs, accessories, materials, customers or customer_occasions, the customers' upcoming birthdays and anniversaries, and returns at once. Poll GET /exports/{id} until it is completed, then download it through the link returned. Sales accept the customer_id, sold_by, start_date and end_date filters; cabs make, unit_color, status and search; materials search, category, supplier and status; customer_occasions days and occasion, like GET /customers/upcoming-birthdays. Customer contact details are masked unless the caller may see them. Exports are deleted after EXPORT_RETENTION_HOURS.
+// @Tags Exports
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ExportRequest true "Kind of export and its filters"
+// @Success 202 {object} api.ExportJobResponse "Export queued"
+// @Failure 400 {object} api.ErrorResponse "Invalid type or filter"
+// @Failure 500 {object} api.ErrorResponse "Failed to queue export"
+// @Failure 503 {object} api.ErrorResponse "Too many exports are running"
+// @Router /exports [post]
+func (h *ExportHandler) CreateExport(c *fiber.Ctx) error {
+	var input ExportRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	allowed, ok := models.ExportFilters[input.Type]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "type must be sales, cabs, accessories, materials, customers or customer_occasions", StatusCode: fiber.StatusBadRequest})
+	}
+	filters := make(map[string]string)
+	for name, value := range input.Filters {
+		if !slices.Contains(allowed, name) {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("%s exports cannot be filtered by %s", input.Type, name), StatusCode: fiber.StatusBadRequest})
+		}
+		if value != "" {
+			filters[name] = value
+		}
+	}
+	switch input.Type {
+	case models.ExportSales:
+		if err := salesExportFilter(filters).Validate(); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+		}
+	case models.ExportCustomerOccasions:
+		if _, _, msg := parseCustomerOccasionFilters(filters["days"], filters["occasion"]); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: msg, StatusCode: fiber.StatusBadRequest})
+		}
+	}
+
+	now := h.now()
+	userID, _ := c.Locals("user_id").(string)
+	job := models.ExportJob{
+		ID:          uuid.New().String(),
+		Type:        input.Type,
+		Filters:     filters,
+		Status:      models.ExportStatusQueued,
+		RequestedBy: userID,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(h.Retention),
+	}
+	if err := h.Repo.Create(&job); err != nil {
+		log.Printf("Error creating %s export: %v", job.Type, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to queue export", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Decided now, as the export runs after the request is gone
+	maskPII := (job.Type == models.ExportCustomers || job.Type == models.ExportCustomerOccasions) && !h.Perms.Allowed(c, PermissionCustomersPII)
+	if !h.Queue.Queue(func() { h.run(context.Background(), job, maskPII) }) {
+		if err := h.Repo.Fail(job.ID, "Too many exports are running", h.now()); err != nil {
+			log.Printf("Error failing export %s: %v", job.ID, err)
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "Too many exports are running, try again later", StatusCode: fiber.StatusServiceUnavailable})
+	}
+
+	h.Audit.RecordAction(c, "REQUEST_EXPORT", AuditEntityExport, job.ID, fmt.Sprintf("Requested a %s export", job.Type))
+
+	return c.Status(fiber.StatusAccepted).JSON(api.ExportJobResponse{Message: "Export queued", Job: &job})
+}
+
+// GetExports handles listing the caller's exports
+// @Summary List my exports
+// @Description Returns the exports the caller requested that have not been deleted yet, newest first.
+// @Tags Exports
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.ExportJobListResponse "Exports"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve exports"
+// @Router /exports [get]
+func (h *ExportHandler) GetExports(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	jobs, err := h.Repo.GetByUser(userID)
+	if err != nil {
+		log.Printf("Error listing exports of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve exports", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ExportJobListResponse{Jobs: jobs, Count: len(jobs)})
+}
+
+// GetExport handles checking on an export
+// @Summary Get an export
+// @Description Returns the status and progress of an export. Once it is completed the response carries a download link valid for STORAGE_URL_EXPIRY_SECONDS; fetch the export again for a fresh link. Only the requester and admins can see an export.
+// @Tags Exports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Export ID"
+// @Success 200 {object} api.ExportJobResponse "Export"
+// @Failure 404 {object} api.ErrorResponse "Export not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve export"
+// @Router /exports/{id} [get]
+func (h *ExportHandler) GetExport(c *fiber.Ctx) error {
+	job, err := h.Repo.GetByID(c.Params("id"))
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(string)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && job.RequestedBy != userID && role != RoleAdmin) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Export not found", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error getting export %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve export", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	response := api.ExportJobResponse{Job: job}
+	if job.Status == models.ExportStatusCompleted {
+		link, err := h.downloadLink(tenantIDFromCtx(c), job)
+		if err != nil {
+			log.Printf("Error signing the download link of export %s: %v", job.ID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve export", StatusCode: fiber.StatusInternalServerError})
+		}
+		response.DownloadURL = link
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// DownloadExport handles downloading an export through a signed link
+// @Summary Download an export
+// @Description Serves the CSV file of a completed export through the link returned by GET /exports/{id}. Exports kept in file storage are served from there instead.
+// @Tags Exports
+// @Produce text/csv
+// @Param id path string true "Export ID"
+// @Param expires query int true "Expiry of the link as a Unix time"
+// @Param signature query string true "Signature of the link"
+// @Success 200 {file} binary "CSV file"
+// @Failure 403 {object} api.ErrorResponse "Download link is invalid or has expired"
+// @Failure 404 {object} api.ErrorResponse "Export not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve export"
+// @Router /exports/{id}/download [get]
+func (h *ExportHandler) DownloadExport(c *fiber.Ctx) error {
+	id := c.Params("id")
+	expires := c.Query("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || h.now().Unix() > unix ||
+		!hmac.Equal([]byte(c.Query("signature")), []byte(h.signature(tenantIDFromCtx(c), id, expires))) {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Download link is invalid or has expired", StatusCode: fiber.StatusForbidden})
+	}
+
+	job, err := h.Repo.GetByID(id)
+	if err == nil && !h.now().Before(job.ExpiresAt) {
+		err = fmt.Errorf("export expired: %w", sql.ErrNoRows) // Not purged yet
+	}
+	if err == nil {
+		var data []byte
+		if data, err = h.Repo.GetFile(id); err == nil {
+			c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+			c.Set(fiber.HeaderContentDisposition, services.ContentDisposition(job.FileName))
+			c.Set(fiber.HeaderCacheControl, "private, no-store")
+			return c.Status(fiber.StatusOK).Send(data)
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Export not found", StatusCode: fiber.StatusNotFound})
+	}
+	log.Printf("Error getting the file of export %s: %v", id, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve export", StatusCode: fiber.StatusInternalServerError})
+}
+
+// downloadLink returns a link to the file of a completed export: a link of the file
+// storage when the file is kept there, or else a signed link to DownloadExport
+func (h *ExportHandler) downloadLink(tenantID string, job *models.ExportJob) (string, error) {
+	if h.Files != nil && job.StorageKey != "" {
+		return h.Files.SignedURL(job.StorageKey, job.FileName, h.LinkExpiry)
+	}
+	expires := strconv.FormatInt(h.now().Add(h.LinkExpiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {h.signature(tenantID, job.ID, expires)}}
+	return "/api/exports/" + url.PathEscape(job.ID) + "/download?" + query.Encode(), nil
+}
+
+// signature signs a download link of an export of the tenant, so a link only works
+// for the tenant it was handed out by
+func (h *ExportHandler) signature(tenantID, id, expires string) string {
+	mac := hmac.New(sha256.New, h.jwtSecret)
+	mac.Write([]byte("export\n" + tenantID + "\n" + id + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// run builds the file of a queued export and records its progress as it goes
+func (h *ExportHandler) run(ctx context.Context, job models.ExportJob, maskPII bool) {
+	if err := h.Repo.Start(job.ID, h.now()); err != nil {
+		log.Printf("Error starting export %s: %v", job.ID, err)
+		return
+	}
+
+	rows, err := h.exportRows(ctx, job, maskPII)
+	if err != nil {
+		h.fail(job, err)
+		return
+	}
+	// Reading the data is the first tenth of the work; writing the rows is the rest
+	total := len(rows) - 1
+	h.progress(job, 10, 0)
+	data, err := services.WriteExportCSV(rows, func(written int) {
+		if total > 0 {
+			h.progress(job, 10+85*written/total, written)
+		}
+	})
+	if err != nil {
+		h.fail(job, err)
+		return
+	}
+
+	completed := h.now()
+	job.Rows = total
+	job.FileName = fmt.Sprintf("%s-export-%s.csv", job.Type, completed.Format("20060102-150405"))
+	job.CompletedAt = &completed
+	job.ExpiresAt = completed.Add(h.Retention)
+	if err := h.Repo.Complete(&job, data); err != nil {
+		h.fail(job, err)
+	}
+}
+
+func (h *ExportHandler) progress(job models.ExportJob, progress, rows int) {
+	if err := h.Repo.UpdateProgress(job.ID, progress, rows); err != nil {
+		log.Printf("Error recording the progress of export %s: %v", job.ID, err)
+	}
+}
+
+// fail records why an export failed; the details stay in the log
+func (h *ExportHandler) fail(job models.ExportJob, cause error) {
+	log.Printf("Export %s of %s failed: %v", job.ID, job.Type, cause)
+	if err := h.Repo.Fail(job.ID, "Failed to build the export", h.now()); err != nil {
+		log.Printf("Error failing export %s: %v", job.ID, err)
+	}
+}
+
+// salesExportFilter reads the filters of a sales export, named like those of models.ExportFilters
+func salesExportFilter(f map[string]string) models.SalesFilter {
+	return models.SalesFilter{CustomerID: f["customer_id"], SoldBy: f["sold_by"], StartDate: f["start_date"], EndDate: f["end_date"]}
+}
+
+// exportRows reads the data of an export with its filters
+func (h *ExportHandler) exportRows(ctx context.Context, job models.ExportJob, maskPII bool) ([][]string, error) {
+	f := job.Filters
+	switch job.Type {
+	case models.ExportSales:
+		sales, err := h.Sales.GetAll(ctx, salesExportFilter(f))
+		if err != nil {
+			return nil, err
+		}
+		return services.SalesExport(sales), nil
+	case models.ExportCabs:
+		cabs, err := h.Cabs.GetCabs(ctx, models.CabFilter{
+			Make: f["make"], UnitColor: f["unit_color"], Status: f["status"], Search: f["search"],
+		})
+		if err != nil {
+			return nil, err
+		}
+		return services.CabsExport(cabs), nil
+	case models.ExportAccessories:
+		accessories, err := h.Accessories.GetAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return services.AccessoriesExport(accessories), nil
+	case models.ExportMaterials:
+		materials, err := h.Materials.GetAll(ctx, models.MaterialFilter{
+			Search: f["search"], Category: f["category"], Supplier: f["supplier"], Status: f["status"],
+		})
+		if err != nil {
+			return nil, err
+		}
+		return services.MaterialsExport(materials), nil
+	case models.ExportCustomers:
+		customers, err := h.Customers.GetAllCustomers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(customers, func(i, j int) bool { return customers[i].FullName < customers[j].FullName })
+		return services.CustomersExport(customers, maskPII), nil
+	case models.ExportCustomerOccasions:
+		days, occasion, msg := parseCustomerOccasionFilters(job.Filters["days"], job.Filters["occasion"])
+		if msg != "" {
+			return nil, errors.New(msg)
+		}
+		customers, err := h.Customers.GetAllCustomers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if customers, err = contactableCustomers(h.Consents, customers); err != nil {
+			return nil, err
+		}
+		byID := make(map[string]*models.Customer, len(customers))
+		for _, customer := range customers {
+			byID[customer.ID] = customer
+		}
+		return services.CustomerOccasionsExport(upcomingCustomerOccasions(customers, h.now(), days, occasion), byID, maskPII), nil
+	}
+	return nil, fmt.Errorf("unknown export type %q", job.Type)
+}
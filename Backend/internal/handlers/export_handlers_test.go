@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupExportTestApp registers the export routes on an in-memory store with one
+// customer and a queue with a single worker
+func setupExportTestApp(t *testing.T) (*fiber.App, *memory.Store, *ExportHandler, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	_, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "09171234567", DateRegistered: time.Now()})
+	require.NoError(t, err)
+
+	queue := services.NewExportQueue(nil, 1)
+	t.Cleanup(queue.Close)
+	h := NewExportHandler(store.Exports, store.Sales, store.Cabs, store.Accessories, store.Materials, store.Customers, queue, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, h, jwtSecret
+}
+
+// waitForExport polls an export until it has finished
+func waitForExport(t *testing.T, app *fiber.App, token, id string) api.ExportJobResponse {
+	var response api.ExportJobResponse
+	require.Eventually(t, func() bool {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/exports/"+id, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return response.Job.Status == models.ExportStatusCompleted || response.Job.Status == models.ExportStatusFailed
+	}, 5*time.Second, 10*time.Millisecond)
+	return response
+}
+
+func TestCreateExportAndDownload(t *testing.T) {
+	app, _, _, jwtSecret := setupExportTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/exports", ExportRequest{Type: models.ExportCustomers})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var queued api.ExportJobResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&queued))
+	assert.Equal(t, models.ExportStatusQueued, queued.Job.Status)
+
+	done := waitForExport(t, app, staffToken, queued.Job.ID)
+	require.Equal(t, models.ExportStatusCompleted, done.Job.Status)
+	assert.Equal(t, 100, done.Job.Progress)
+	assert.Equal(t, 1, done.Job.Rows)
+	require.NotEmpty(t, done.DownloadURL)
+
+	// The signed link works without a token
+	resp = authedRequest(t, app, "", http.MethodGet, done.DownloadURL, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), "text/csv")
+	body, _ := io.ReadAll(resp.Body)
+	assert.True(t, strings.HasPrefix(string(body), "id,full_name,email,phone,address,barangay,city,province,date_of_birth,date_registered\n"))
+	assert.Contains(t, string(body), "Juan Dela Cruz")
+	assert.NotContains(t, string(body), "juan@example.com", "staff without the PII permission get masked contact details")
+
+	resp = authedRequest(t, app, "", http.MethodGet, strings.Replace(done.DownloadURL, "signature=", "signature=0", 1), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Only the requester and admins see an export
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	resp = authedRequest(t, app, otherToken, http.MethodGet, "/api/exports/"+queued.Job.ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/exports/"+queued.Job.ID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/exports", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ExportJobListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 1, list.Count)
+}
+
+func TestCreateExportValidation(t *testing.T) {
+	app, _, _, jwtSecret := setupExportTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/exports", ExportRequest{Type: "invoices"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/exports", ExportRequest{Type: models.ExportSales, Filters: map[string]string{"make": "Suzuki"}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "filters of another kind of export are refused")
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/exports", ExportRequest{Type: models.ExportSales})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestDownloadExpiredExport(t *testing.T) {
+	app, _, h, jwtSecret := setupExportTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/exports", ExportRequest{Type: models.ExportCabs})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var queued api.ExportJobResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&queued))
+	done := waitForExport(t, app, token, queued.Job.ID)
+	require.Equal(t, models.ExportStatusCompleted, done.Job.Status)
+
+	h.now = func() time.Time { return time.Now().Add(h.Retention + time.Minute) }
+	resp = authedRequest(t, app, "", http.MethodGet, done.DownloadURL, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the link expired long before the export")
+}
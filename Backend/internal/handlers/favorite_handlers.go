@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FavoriteHandler serves the inventory items the caller starred
+type FavoriteHandler struct {
+	Repo        repositories.FavoriteRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	jwtSecret   []byte
+}
+
+// NewFavoriteHandler creates a new FavoriteHandler instance
+func NewFavoriteHandler(repo repositories.FavoriteRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, jwtSecret []byte) *FavoriteHandler {
+	return &FavoriteHandler{Repo: repo, Cabs: cabs, Accessories: accessories, jwtSecret: jwtSecret}
+}
+
+// Routes registers the favorite routes
+func (h *FavoriteHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	favoriteGroup := r.Group("/users/me/favorites", mw.Auth)
+	favoriteGroup.Get("/", h.GetFavorites)                       // GET /api/users/me/favorites
+	favoriteGroup.Post("/", h.SaveFavorite)                      // POST /api/users/me/favorites
+	favoriteGroup.Delete("/:itemType/:itemId", h.RemoveFavorite) // DELETE /api/users/me/favorites/:itemType/:itemId
+}
+
+// FavoriteRequest is the body for starring an item
+type FavoriteRequest struct {
+	ItemType string `json:"itemType"` // cab or accessory
+	ItemID   int    `json:"itemId"`
+	Notify   *bool  `json:"notify,omitempty"` // Defaults to true
+}
+
+// loadItem fetches a starred item. It returns nil without an error when the item no longer exists.
+func (h *FavoriteHandler) loadItem(c *fiber.Ctx, itemType string, itemID int) (interface{}, error) {
+	var item interface{}
+	var err error
+	switch itemType {
+	case models.FavoriteItemCab:
+		item, err = h.Cabs.GetCabByID(c.Context(), itemID)
+	case models.FavoriteItemAccessory:
+		var accessory models.Accessory
+		if accessory, err = h.Accessories.GetByID(c.Context(), itemID); err == nil {
+			item = accessory
+		}
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "not found") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetFavorites handles listing the caller's starred items
+// @Summary List my favorites
+// @Description Lists the cabs and accessories the caller starred, most recent first, with their current details. Items deleted since they were starred have no item.
+// @Tags Favorites
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.FavoriteListResponse "Favorites"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve favorites"
+// @Router /users/me/favorites [get]
+func (h *FavoriteHandler) GetFavorites(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	favorites, err := h.Repo.GetByUser(userID)
+	if err != nil {
+		log.Printf("Error getting favorites of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve favorites", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	items := make([]api.FavoriteItem, 0, len(favorites))
+	for _, favorite := range favorites {
+		item, err := h.loadItem(c, favorite.ItemType, favorite.ItemID)
+		if err != nil {
+			log.Printf("Error getting %s %d: %v", favorite.ItemType, favorite.ItemID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve favorites", StatusCode: fiber.StatusInternalServerError})
+		}
+		items = append(items, api.FavoriteItem{Favorite: favorite, Item: item})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.FavoriteListResponse{Favorites: items})
+}
+
+// SaveFavorite handles starring an item
+// @Summary Star an item
+// @Description Stars a cab or accessory. With notify on (the default), the caller is sent a watchlist notification over the notification stream when the item's price changes, it is restocked or it is sold. Starring an item again updates notify.
+// @Tags Favorites
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param favorite body FavoriteRequest true "Item to star"
+// @Success 200 {object} api.FavoriteResponse "Favorite saved"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 404 {object} api.ErrorResponse "Item not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to save favorite"
+// @Router /users/me/favorites [post]
+func (h *FavoriteHandler) SaveFavorite(c *fiber.Ctx) error {
+	var input FavoriteRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if input.ItemType != models.FavoriteItemCab && input.ItemType != models.FavoriteItemAccessory {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "itemType must be cab or accessory", StatusCode: fiber.StatusBadRequest})
+	}
+
+	item, err := h.loadItem(c, input.ItemType, input.ItemID)
+	if err != nil {
+		log.Printf("Error getting %s %d: %v", input.ItemType, input.ItemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to save favorite", StatusCode: fiber.StatusInternalServerError})
+	}
+	if item == nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Item not found", StatusCode: fiber.StatusNotFound})
+	}
+
+	favorite := &models.Favorite{ItemType: input.ItemType, ItemID: input.ItemID, Notify: true}
+	favorite.UserID, _ = c.Locals("user_id").(string)
+	if input.Notify != nil {
+		favorite.Notify = *input.Notify
+	}
+
+	if err := h.Repo.Save(favorite); err != nil {
+		log.Printf("Error saving favorite of user %s: %v", favorite.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to save favorite", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.FavoriteResponse{Message: "Favorite saved", Favorite: favorite})
+}
+
+// RemoveFavorite handles unstarring an item
+// @Summary Unstar an item
+// @Tags Favorites
+// @Produce json
+// @Security ApiKeyAuth
+// @Param itemType path string true "cab or accessory"
+// @Param itemId path int true "Item ID"
+// @Success 200 {object} api.MessageResponse "Favorite removed"
+// @Failure 400 {object} api.ErrorResponse "Invalid item ID"
+// @Failure 404 {object} api.ErrorResponse "Favorite not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to remove favorite"
+// @Router /users/me/favorites/{itemType}/{itemId} [delete]
+func (h *FavoriteHandler) RemoveFavorite(c *fiber.Ctx) error {
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid item ID", StatusCode: fiber.StatusBadRequest})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.Repo.Remove(userID, c.Params("itemType"), itemID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Favorite not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error removing favorite of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to remove favorite", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Favorite removed"})
+}
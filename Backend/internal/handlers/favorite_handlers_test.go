@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupFavoriteTestApp registers the favorite, cab, accessory and sale routes on an
+// in-memory store, with a watchlist pushing to the returned hub
+func setupFavoriteTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.NotificationHub, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	hub := services.NewNotificationHub()
+	watchlist := NewWatchlist(store.Favorites, hub)
+
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Watch = watchlist
+	accessories := NewAccessoriesHandler(store.Accessories)
+	accessories.Watch = watchlist
+	sales := NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret)
+	sales.Watch = watchlist
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	NewFavoriteHandler(store.Favorites, store.Cabs, store.Accessories, jwtSecret).Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Put("/cabs/:id", cabs.UpdateCab)
+	apiGroup.Put("/accessories/:id", accessories.UpdateAccessory)
+	sales.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	return app, store, hub, jwtSecret
+}
+
+func addTestCab(t *testing.T, store *memory.Store) *models.MultiCab {
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Unit 42", Make: "Suzuki", UnitColor: "White", Quantity: 2, Price: 250000})
+	require.NoError(t, err)
+	return cab
+}
+
+// receiveWatchlistEvents drains the notifications already delivered to a subscriber
+func receiveWatchlistEvents(notifications <-chan models.Notification) []models.WatchlistEvent {
+	events := []models.WatchlistEvent{}
+	for {
+		select {
+		case notification := <-notifications:
+			if notification.Type == NotificationWatchlist {
+				events = append(events, notification.Data.(models.WatchlistEvent))
+			}
+		default:
+			return events
+		}
+	}
+}
+
+func TestFavorites(t *testing.T) {
+	app, store, _, jwtSecret := setupFavoriteTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	cab := addTestCab(t, store)
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/users/me/favorites", FavoriteRequest{ItemType: models.FavoriteItemCab, ItemID: cab.ID})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var saved api.FavoriteResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&saved))
+	assert.True(t, saved.Favorite.Notify, "notifications are on by default")
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/users/me/favorites", FavoriteRequest{ItemType: models.FavoriteItemCab, ItemID: 999})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/users/me/favorites", FavoriteRequest{ItemType: "material", ItemID: 1})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/users/me/favorites", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list struct {
+		Favorites []struct {
+			models.Favorite
+			Item models.MultiCab `json:"item"`
+		} `json:"favorites"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Favorites, 1)
+	assert.Equal(t, "Unit 42", list.Favorites[0].Item.Name)
+
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	resp = authedRequest(t, app, otherToken, http.MethodDelete, "/api/users/me/favorites/cab/"+strconv.Itoa(cab.ID), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "favorites are per user")
+
+	resp = authedRequest(t, app, token, http.MethodDelete, "/api/users/me/favorites/cab/"+strconv.Itoa(cab.ID), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	favorites, err := store.Favorites.GetByUser("staff-1")
+	require.NoError(t, err)
+	assert.Empty(t, favorites)
+}
+
+func TestWatchlistNotifications(t *testing.T) {
+	app, store, hub, jwtSecret := setupFavoriteTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	cab := addTestCab(t, store)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Roof rack", Make: "Generic", Quantity: 5, Price: 1500, UnitColor: "Black"})
+	require.NoError(t, err)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Favorites.Save(&models.Favorite{UserID: "staff-1", ItemType: models.FavoriteItemCab, ItemID: cab.ID, Notify: true}))
+	require.NoError(t, store.Favorites.Save(&models.Favorite{UserID: "staff-1", ItemType: models.FavoriteItemAccessory, ItemID: accessoryID, Notify: true}))
+	require.NoError(t, store.Favorites.Save(&models.Favorite{UserID: "staff-3", ItemType: models.FavoriteItemCab, ItemID: cab.ID, Notify: false}))
+
+	watcher, unsubscribe := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribe()
+	muted, unsubscribeMuted := hub.Subscribe(models.DefaultTenantID, "staff-3")
+	defer unsubscribeMuted()
+	bystander, unsubscribeBystander := hub.Subscribe(models.DefaultTenantID, "staff-2")
+	defer unsubscribeBystander()
+
+	t.Run("PriceChangeAndRestock", func(t *testing.T) {
+		update := *cab
+		update.Price = 240000
+		update.Quantity = 4
+		resp := authedRequest(t, app, token, http.MethodPut, "/api/cabs/"+strconv.Itoa(cab.ID), update)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		events := receiveWatchlistEvents(watcher)
+		require.Len(t, events, 2)
+		assert.Equal(t, models.WatchEventPriceChanged, events[0].Event)
+		assert.Equal(t, 250000.0, events[0].PreviousPrice)
+		assert.Equal(t, 240000.0, events[0].Price)
+		assert.Equal(t, models.WatchEventRestocked, events[1].Event)
+		assert.Equal(t, 2, events[1].PreviousQuantity)
+		assert.Equal(t, 4, events[1].Quantity)
+	})
+
+	t.Run("NoChange", func(t *testing.T) {
+		current, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+		require.NoError(t, err)
+		resp := authedRequest(t, app, token, http.MethodPut, "/api/cabs/"+strconv.Itoa(cab.ID), current)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, receiveWatchlistEvents(watcher))
+	})
+
+	t.Run("AccessoryPriceChange", func(t *testing.T) {
+		price := 1200.0
+		resp := authedRequest(t, app, token, http.MethodPut, "/api/accessories/"+strconv.Itoa(accessoryID), models.UpdateAccessoryInput{Price: &price})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		events := receiveWatchlistEvents(watcher)
+		require.Len(t, events, 1)
+		assert.Equal(t, models.FavoriteItemAccessory, events[0].ItemType)
+		assert.Equal(t, models.WatchEventPriceChanged, events[0].Event)
+	})
+
+	t.Run("Sold", func(t *testing.T) {
+		sale := models.CabSalePayload{CustomerID: customer.ID, Quantity: 1, Accessories: []models.AccessoryForSale{{ID: accessoryID, Quantity: 2}}}
+		resp := authedRequest(t, app, token, http.MethodPost, "/api/cabs/"+strconv.Itoa(cab.ID)+"/sell", sale)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		events := receiveWatchlistEvents(watcher)
+		require.Len(t, events, 2)
+		assert.Equal(t, models.WatchEventSold, events[0].Event)
+		assert.Equal(t, models.FavoriteItemCab, events[0].ItemType)
+		assert.Equal(t, 1, events[0].Quantity)
+		assert.Equal(t, models.FavoriteItemAccessory, events[1].ItemType)
+		assert.Equal(t, 2, events[1].Quantity)
+	})
+
+	assert.Empty(t, receiveWatchlistEvents(muted), "watchers with notifications off are skipped")
+	assert.Empty(t, receiveWatchlistEvents(bystander), "users who did not star the item are skipped")
+}
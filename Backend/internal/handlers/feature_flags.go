@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Features that super admins can switch per tenant
+const (
+	FeatureSSO              = "sso"               // OIDC login routes
+	FeatureUserProvisioning = "user_provisioning" // HR roster sync
+	FeatureSandbox          = "sandbox"           // Entering the training sandbox
+)
+
+// featureDefaults holds every known feature and whether it is enabled for tenants
+// that have no stored flag for it
+var featureDefaults = map[string]bool{
+	FeatureSSO:              true,
+	FeatureUserProvisioning: true,
+	FeatureSandbox:          true,
+}
+
+// knownFeatures returns the names of all features, sorted
+func knownFeatures() []string {
+	names := make([]string, 0, len(featureDefaults))
+	for name := range featureDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FeatureStore loads the feature flags stored for a tenant
+type FeatureStore interface {
+	GetFeatures(tenantID string) (map[string]bool, error)
+}
+
+// FeatureFlags resolves the features of the request's tenant
+type FeatureFlags struct {
+	Store     FeatureStore
+	jwtSecret []byte
+}
+
+// NewFeatureFlags creates a new FeatureFlags instance
+func NewFeatureFlags(store FeatureStore, jwtSecret []byte) *FeatureFlags {
+	return &FeatureFlags{Store: store, jwtSecret: jwtSecret}
+}
+
+// resolveFeatures applies the stored flags of a tenant over the defaults
+func resolveFeatures(store FeatureStore, tenantID string) (map[string]bool, error) {
+	stored, err := store.GetFeatures(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	features := make(map[string]bool, len(featureDefaults))
+	for name, enabled := range featureDefaults {
+		if value, ok := stored[name]; ok {
+			enabled = value
+		}
+		features[name] = enabled
+	}
+	return features, nil
+}
+
+// Routes registers the route tenants use to read their features
+func (f *FeatureFlags) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/features", mw.Auth, f.GetFeatures) // GET /api/features
+}
+
+// Require creates a middleware that rejects requests when the tenant has the feature disabled
+func (f *FeatureFlags) Require(feature string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		features, err := resolveFeatures(f.Store, tenantIDFromCtx(c))
+		if err != nil {
+			log.Printf("Error loading features of tenant %s: %v", tenantIDFromCtx(c), err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to load tenant features", StatusCode: fiber.StatusInternalServerError})
+		}
+		if !features[feature] {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "This feature is not enabled for your organization", StatusCode: fiber.StatusForbidden})
+		}
+		return c.Next()
+	}
+}
+
+// GetFeatures handles reading the features of the caller's tenant
+// @Summary Get tenant features
+// @Description Returns every known feature and whether it is enabled for the caller's tenant, so clients can hide disabled functionality.
+// @Tags Features
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.TenantFeaturesResponse "Feature flags"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to load tenant features"
+// @Router /features [get]
+func (f *FeatureFlags) GetFeatures(c *fiber.Ctx) error {
+	tenantID := tenantIDFromCtx(c)
+	features, err := resolveFeatures(f.Store, tenantID)
+	if err != nil {
+		log.Printf("Error loading features of tenant %s: %v", tenantID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to load tenant features", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.TenantFeaturesResponse{TenantID: tenantID, Features: features})
+}
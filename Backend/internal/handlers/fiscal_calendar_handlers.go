@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FiscalCalendarHandler manages the month the tenant's fiscal year starts in,
+// which reports use for fiscal periods
+type FiscalCalendarHandler struct {
+	Repo      repositories.FiscalCalendarRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewFiscalCalendarHandler creates a new FiscalCalendarHandler instance
+func NewFiscalCalendarHandler(repo repositories.FiscalCalendarRepository, jwtSecret []byte) *FiscalCalendarHandler {
+	return &FiscalCalendarHandler{Repo: repo, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the fiscal calendar routes
+func (h *FiscalCalendarHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	fiscalGroup := r.Group("/settings/fiscal-calendar", mw.Auth)
+	fiscalGroup.Get("/", h.GetFiscalCalendar)              // GET /api/settings/fiscal-calendar
+	fiscalGroup.Put("/", mw.Admin, h.UpdateFiscalCalendar) // PUT /api/settings/fiscal-calendar
+}
+
+// FiscalCalendarRequest is the body for changing the fiscal calendar
+type FiscalCalendarRequest struct {
+	StartMonth    int  `json:"startMonth"`    // 1 (January) to 12
+	NamedAfterEnd bool `json:"namedAfterEnd"` // Name fiscal years after the calendar year they end in
+}
+
+func (r FiscalCalendarRequest) validate() error {
+	if r.StartMonth < 1 || r.StartMonth > 12 {
+		return errors.New("startMonth must be between 1 and 12")
+	}
+	return nil
+}
+
+// fiscalCalendarResponse describes a calendar and the fiscal year containing today
+func (h *FiscalCalendarHandler) fiscalCalendarResponse(message string, calendar models.FiscalCalendar) api.FiscalCalendarResponse {
+	return api.FiscalCalendarResponse{Message: message, Calendar: calendar, CurrentYear: reportPeriod(PeriodYear, &calendar, h.now())}
+}
+
+// GetFiscalCalendar handles getting the fiscal calendar
+// @Summary Get the fiscal calendar
+// @Description Returns the month the fiscal year starts in and the current fiscal year. The fiscal year is the calendar year until an admin changes it.
+// @Tags Settings
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.FiscalCalendarResponse "Fiscal calendar"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve fiscal calendar"
+// @Router /settings/fiscal-calendar [get]
+func (h *FiscalCalendarHandler) GetFiscalCalendar(c *fiber.Ctx) error {
+	calendar, err := h.Repo.Get()
+	if err != nil {
+		log.Printf("Error getting fiscal calendar: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve fiscal calendar", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(h.fiscalCalendarResponse("", calendar))
+}
+
+// UpdateFiscalCalendar handles changing the fiscal calendar
+// @Summary Update the fiscal calendar (Admin)
+// @Description Sets the month the fiscal year starts in and whether fiscal years are named after the calendar year they start or end in. Reports asked for fiscal periods group by the new calendar from then on.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param calendar body FiscalCalendarRequest true "Fiscal calendar"
+// @Success 200 {object} api.FiscalCalendarResponse "Fiscal calendar updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to update fiscal calendar"
+// @Router /settings/fiscal-calendar [put]
+func (h *FiscalCalendarHandler) UpdateFiscalCalendar(c *fiber.Ctx) error {
+	var input FiscalCalendarRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	before, err := h.Repo.Get()
+	if err != nil {
+		log.Printf("Error getting fiscal calendar: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update fiscal calendar", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	now := h.now()
+	calendar := models.FiscalCalendar{StartMonth: input.StartMonth, NamedAfterEnd: input.NamedAfterEnd, UpdatedAt: &now}
+	calendar.UpdatedBy, _ = c.Locals("user_id").(string)
+	if err := h.Repo.Save(calendar); err != nil {
+		log.Printf("Error saving fiscal calendar: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update fiscal calendar", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityFiscal, "fiscal_calendar",
+		FiscalCalendarRequest{StartMonth: before.StartMonth, NamedAfterEnd: before.NamedAfterEnd}, input)
+
+	return c.Status(fiber.StatusOK).JSON(h.fiscalCalendarResponse("Fiscal calendar updated", calendar))
+}
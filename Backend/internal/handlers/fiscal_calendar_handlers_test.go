@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupFiscalCalendarTestApp registers the fiscal calendar routes on an in-memory
+// store, with the clock fixed to 12 March 2025
+func setupFiscalCalendarTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewFiscalCalendarHandler(store.Fiscal, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+	h.now = func() time.Time { return time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local) }
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+func TestFiscalCalendar(t *testing.T) {
+	app, store, jwtSecret := setupFiscalCalendarTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/settings/fiscal-calendar", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body api.FiscalCalendarResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 1, body.Calendar.StartMonth, "the calendar year by default")
+	assert.Equal(t, api.ReportPeriod{Label: "FY2025", StartDate: "2025-01-01", EndDate: "2025-12-31"}, body.CurrentYear)
+
+	input := FiscalCalendarRequest{StartMonth: 7, NamedAfterEnd: true}
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/settings/fiscal-calendar", input)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for _, month := range []int{0, 13} {
+		resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/settings/fiscal-calendar", FiscalCalendarRequest{StartMonth: month})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, month)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/settings/fiscal-calendar", input)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body = api.FiscalCalendarResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "admin-1", body.Calendar.UpdatedBy)
+	assert.Equal(t, api.ReportPeriod{Label: "FY2025", StartDate: "2024-07-01", EndDate: "2025-06-30"}, body.CurrentYear)
+
+	saved, err := store.Fiscal.Get()
+	require.NoError(t, err)
+	assert.Equal(t, 7, saved.StartMonth)
+	assert.True(t, saved.NamedAfterEnd)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, AuditEntityFiscal, logs[0].EntityType)
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// googleSheetsExportTimeout limits writing all reports of a tenant
+const googleSheetsExportTimeout = 2 * time.Minute
+
+var (
+	// spreadsheetURLPattern finds the ID in a link to a spreadsheet, e.g.
+	// https://docs.google.com/spreadsheets/d/{id}/edit#gid=0
+	spreadsheetURLPattern = regexp.MustCompile(`/spreadsheets/d/([A-Za-z0-9_-]+)`)
+	spreadsheetIDPattern  = regexp.MustCompile(`^[A-Za-z0-9_-]{20,100}$`)
+)
+
+// GoogleSheetsHandler manages the Google Sheets spreadsheet a tenant's reports are
+// exported to, and exports them. The export job writes every report to its own
+// tab on a schedule, so managers always find the latest figures in the spreadsheet.
+type GoogleSheetsHandler struct {
+	Repo        repositories.GoogleSheetsExportRepository
+	Sales       SaleRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Writer      services.SheetsWriter // Nil when no service account is configured
+	Config      config.GoogleSheetsConfig
+	Audit       *ChangeRecorder
+	now         func() time.Time
+	jwtSecret   []byte
+}
+
+// NewGoogleSheetsHandler creates a new GoogleSheetsHandler instance
+func NewGoogleSheetsHandler(repo repositories.GoogleSheetsExportRepository, sales SaleRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, writer services.SheetsWriter, cfg config.GoogleSheetsConfig, jwtSecret []byte) *GoogleSheetsHandler {
+	return &GoogleSheetsHandler{Repo: repo, Sales: sales, Cabs: cabs, Accessories: accessories, Materials: materials, Writer: writer, Config: cfg, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes of the Google Sheets export
+func (h *GoogleSheetsHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	sheetsGroup := r.Group("/integrations/google-sheets", mw.Auth, mw.Admin)
+	sheetsGroup.Get("/", h.GetExport)        // GET /api/integrations/google-sheets
+	sheetsGroup.Put("/", h.UpdateExport)     // PUT /api/integrations/google-sheets
+	sheetsGroup.Delete("/", h.DeleteExport)  // DELETE /api/integrations/google-sheets
+	sheetsGroup.Post("/export", h.ExportNow) // POST /api/integrations/google-sheets/export
+}
+
+// GoogleSheetsExportRequest is the body for setting up the Google Sheets export
+type GoogleSheetsExportRequest struct {
+	Spreadsheet string   `json:"spreadsheet"` // ID or link of the spreadsheet
+	Reports     []string `json:"reports"`     // daily_sales and/or low_stock
+}
+
+// spreadsheetID returns the ID of the spreadsheet the request names
+func (r GoogleSheetsExportRequest) spreadsheetID() (string, error) {
+	id := strings.TrimSpace(r.Spreadsheet)
+	if match := spreadsheetURLPattern.FindStringSubmatch(id); match != nil {
+		id = match[1]
+	}
+	if !spreadsheetIDPattern.MatchString(id) {
+		return "", errors.New("spreadsheet must be the ID or link of a Google Sheets spreadsheet")
+	}
+	return id, nil
+}
+
+func (r GoogleSheetsExportRequest) validate() error {
+	if len(r.Reports) == 0 {
+		return errors.New("reports must name at least one report")
+	}
+	for _, report := range r.Reports {
+		if !slices.Contains(models.SheetsReports, report) {
+			return fmt.Errorf("unknown report %q, expected one of %s", report, strings.Join(models.SheetsReports, ", "))
+		}
+	}
+	return nil
+}
+
+// exportResponse describes the export of the tenant, nil when none is set up
+func (h *GoogleSheetsHandler) exportResponse(message string, export *models.GoogleSheetsExport) api.GoogleSheetsExportResponse {
+	response := api.GoogleSheetsExportResponse{Message: message, Enabled: h.Writer != nil, Reports: models.SheetsReports, Export: export}
+	if h.Writer != nil {
+		response.ServiceAccount = h.Writer.ServiceAccount()
+	}
+	return response
+}
+
+// Export writes the tenant's reports to its spreadsheet and records how it went. It
+// does nothing when the tenant has not set up an export.
+func (h *GoogleSheetsHandler) Export(ctx context.Context) error {
+	export, err := h.Repo.Get()
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	exportErr := h.writeReports(ctx, export)
+	lastError := ""
+	if exportErr != nil {
+		lastError = exportErr.Error()
+	}
+	if err := h.Repo.RecordExport(h.now(), lastError); err != nil {
+		return errors.Join(exportErr, err)
+	}
+	return exportErr
+}
+
+// writeReports builds each report of the export and writes it to its tab
+func (h *GoogleSheetsHandler) writeReports(ctx context.Context, export *models.GoogleSheetsExport) error {
+	ctx, cancel := context.WithTimeout(ctx, googleSheetsExportTimeout)
+	defer cancel()
+
+	for _, report := range export.Reports {
+		var rows [][]interface{}
+		switch report {
+		case models.SheetsReportDailySales:
+			today := h.now()
+			first := today.AddDate(0, 0, 1-h.Config.DailySalesDays)
+			sales, err := h.Sales.GetAll(ctx, models.SalesFilter{StartDate: first.Format("2006-01-02"), EndDate: today.Format("2006-01-02")})
+			if err != nil {
+				return fmt.Errorf("failed to list sales: %w", err)
+			}
+			rows = services.DailySalesSheet(sales, first, today)
+		case models.SheetsReportLowStock:
+			cabs, err := h.Cabs.GetCabs(ctx, models.CabFilter{})
+			if err != nil {
+				return fmt.Errorf("failed to list cabs: %w", err)
+			}
+			accessories, err := h.Accessories.GetAll(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list accessories: %w", err)
+			}
+			materials, err := h.Materials.GetAll(ctx, models.MaterialFilter{})
+			if err != nil {
+				return fmt.Errorf("failed to list materials: %w", err)
+			}
+			rows = services.LowStockSheet(cabs, accessories, materials)
+		default:
+			continue
+		}
+
+		tab := services.SheetsReportTabs[report]
+		if err := h.Writer.WriteSheet(ctx, export.SpreadsheetID, tab, rows); err != nil {
+			return fmt.Errorf("failed to write %s: %w", tab, err)
+		}
+	}
+	return nil
+}
+
+// GetExport handles getting the Google Sheets export
+// @Summary Get the Google Sheets export (Admin)
+// @Description Returns the spreadsheet reports are exported to, which reports, and how the last export went. Share the spreadsheet with the service account as an editor before setting it up.
+// @Tags Integrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.GoogleSheetsExportResponse "Google Sheets export"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve the Google Sheets export"
+// @Router /integrations/google-sheets [get]
+func (h *GoogleSheetsHandler) GetExport(c *fiber.Ctx) error {
+	export, err := h.Repo.Get()
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting google sheets export: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve the Google Sheets export", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(h.exportResponse("", export))
+}
+
+// UpdateExport handles setting up the Google Sheets export
+// @Summary Set up the Google Sheets export (Admin)
+// @Description Sets the spreadsheet reports are exported to and which reports: daily_sales (the sales of each recent day) and low_stock (items low on or out of stock). Each report is written to its own tab by the export job, replacing what was there.
+// @Tags Integrations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param export body GoogleSheetsExportRequest true "Spreadsheet and reports"
+// @Success 200 {object} api.GoogleSheetsExportResponse "Google Sheets export updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to update the Google Sheets export"
+// @Failure 503 {object} api.ErrorResponse "No Google service account is configured"
+// @Router /integrations/google-sheets [put]
+func (h *GoogleSheetsHandler) UpdateExport(c *fiber.Ctx) error {
+	if h.Writer == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "No Google service account is configured", StatusCode: fiber.StatusServiceUnavailable})
+	}
+
+	var input GoogleSheetsExportRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	spreadsheetID, err := input.spreadsheetID()
+	if err == nil {
+		err = input.validate()
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	export := &models.GoogleSheetsExport{SpreadsheetID: spreadsheetID, Reports: slices.Compact(slices.Sorted(slices.Values(input.Reports))), UpdatedAt: h.now()}
+	export.UpdatedBy, _ = c.Locals("user_id").(string)
+	if err := h.Repo.Save(export); err != nil {
+		log.Printf("Error saving google sheets export: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update the Google Sheets export", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "UPDATE_GOOGLE_SHEETS_EXPORT", AuditEntityIntegration, "google-sheets",
+		fmt.Sprintf("Exporting %s to spreadsheet %s", strings.Join(export.Reports, ", "), spreadsheetID))
+
+	saved, err := h.Repo.Get()
+	if err != nil {
+		saved = export
+	}
+	return c.Status(fiber.StatusOK).JSON(h.exportResponse("Google Sheets export updated", saved))
+}
+
+// DeleteExport handles stopping the Google Sheets export
+// @Summary Stop the Google Sheets export (Admin)
+// @Description Stops exporting reports. The spreadsheet keeps what was last written to it.
+// @Tags Integrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.SuccessResponse "Google Sheets export stopped"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "No Google Sheets export is set up"
+// @Failure 500 {object} api.ErrorResponse "Failed to stop the Google Sheets export"
+// @Router /integrations/google-sheets [delete]
+func (h *GoogleSheetsHandler) DeleteExport(c *fiber.Ctx) error {
+	if err := h.Repo.Delete(); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No Google Sheets export is set up", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error deleting google sheets export: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to stop the Google Sheets export", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_GOOGLE_SHEETS_EXPORT", AuditEntityIntegration, "google-sheets", "Stopped exporting reports to Google Sheets")
+	return c.Status(fiber.StatusOK).JSON(api.SuccessResponse{Message: "Google Sheets export stopped"})
+}
+
+// ExportNow handles exporting the reports right away
+// @Summary Export to Google Sheets now (Admin)
+// @Description Writes the reports to the spreadsheet now instead of waiting for the export job, e.g. to check the spreadsheet was shared with the service account.
+// @Tags Integrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.GoogleSheetsExportResponse "Exported"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "No Google Sheets export is set up"
+// @Failure 500 {object} api.ErrorResponse "Failed to export"
+// @Failure 502 {object} api.ErrorResponse "Google Sheets rejected the export"
+// @Failure 503 {object} api.ErrorResponse "No Google service account is configured"
+// @Router /integrations/google-sheets/export [post]
+func (h *GoogleSheetsHandler) ExportNow(c *fiber.Ctx) error {
+	if h.Writer == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "No Google service account is configured", StatusCode: fiber.StatusServiceUnavailable})
+	}
+	if _, err := h.Repo.Get(); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No Google Sheets export is set up", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting google sheets export: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to export", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	exportErr := h.Export(c.Context())
+	export, err := h.Repo.Get()
+	if err != nil {
+		log.Printf("Error getting google sheets export: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to export", StatusCode: fiber.StatusInternalServerError})
+	}
+	h.Audit.RecordAttempt(c, "EXPORT_GOOGLE_SHEETS", AuditEntityIntegration, "google-sheets",
+		fmt.Sprintf("Exported %s to spreadsheet %s", strings.Join(export.Reports, ", "), export.SpreadsheetID), exportErr == nil)
+	if exportErr != nil {
+		log.Printf("Error exporting to google sheets: %v", exportErr)
+		return c.Status(fiber.StatusBadGateway).JSON(api.ErrorResponse{Error: "Google Sheets rejected the export: " + export.LastError, StatusCode: fiber.StatusBadGateway})
+	}
+	return c.Status(fiber.StatusOK).JSON(h.exportResponse("Exported", export))
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSheetsWriter keeps the rows written to each tab and fails while denied is set
+type fakeSheetsWriter struct {
+	tabs   map[string][][]interface{}
+	denied bool
+}
+
+func (w *fakeSheetsWriter) ServiceAccount() string {
+	return "reports@project.iam.gserviceaccount.com"
+}
+
+func (w *fakeSheetsWriter) WriteSheet(ctx context.Context, spreadsheetID, tab string, rows [][]interface{}) error {
+	if w.denied {
+		return errors.New("google sheets answered 403 Forbidden: The caller does not have permission")
+	}
+	w.tabs[spreadsheetID+"/"+tab] = rows
+	return nil
+}
+
+const testSpreadsheetID = "1AbCdEfGhIjKlMnOpQrStUvWxYz0123456789"
+
+// setupGoogleSheetsTestApp registers the Google Sheets routes on an in-memory store,
+// evaluated on 2025-03-02
+func setupGoogleSheetsTestApp(t *testing.T) (*fiber.App, *memory.Store, *GoogleSheetsHandler, *fakeSheetsWriter, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	writer := &fakeSheetsWriter{tabs: make(map[string][][]interface{})}
+	cfg := config.GoogleSheetsConfig{CredentialsFile: "key.json", Interval: time.Hour, DailySalesDays: 7}
+	handler := NewGoogleSheetsHandler(store.Sheets, store.Sales, store.Cabs, store.Accessories, store.Materials, writer, cfg, jwtSecret)
+	handler.Audit = NewChangeRecorder(store.Logs)
+	handler.now = func() time.Time { return time.Date(2025, 3, 2, 9, 0, 0, 0, time.Local) }
+
+	_, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "customer-1", SoldBy: "user-1", SaleDate: "2025-03-01", TotalPrice: 1120})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, handler, writer, jwtSecret
+}
+
+func TestGoogleSheetsExportSetupAndExport(t *testing.T) {
+	app, store, handler, writer, jwtSecret := setupGoogleSheetsTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	require.NoError(t, handler.Export(context.Background()), "nothing is exported until an export is set up")
+	assert.Empty(t, writer.tabs)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPut, "/api/integrations/google-sheets", GoogleSheetsExportRequest{
+		Spreadsheet: "https://docs.google.com/spreadsheets/d/" + testSpreadsheetID + "/edit#gid=0",
+		Reports:     []string{models.SheetsReportLowStock, models.SheetsReportDailySales},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated api.GoogleSheetsExportResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	assert.Equal(t, "reports@project.iam.gserviceaccount.com", updated.ServiceAccount)
+	require.NotNil(t, updated.Export)
+	assert.Equal(t, testSpreadsheetID, updated.Export.SpreadsheetID, "the ID is taken from the link")
+
+	require.NoError(t, handler.Export(context.Background()))
+	dailySales := writer.tabs[testSpreadsheetID+"/Daily Sales"]
+	require.Len(t, dailySales, 8, "a header and the last 7 days")
+	assert.Equal(t, []interface{}{"2025-03-01", 1, 1120.0, 0.0, 1120.0}, dailySales[6])
+	assert.Contains(t, writer.tabs, testSpreadsheetID+"/Low Stock")
+
+	export, err := store.Sheets.Get()
+	require.NoError(t, err)
+	require.NotNil(t, export.LastExportAt)
+	assert.Empty(t, export.LastError)
+}
+
+func TestGoogleSheetsExportNowReportsFailures(t *testing.T) {
+	app, store, _, writer, jwtSecret := setupGoogleSheetsTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/integrations/google-sheets/export", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "no export is set up")
+
+	require.NoError(t, store.Sheets.Save(&models.GoogleSheetsExport{SpreadsheetID: testSpreadsheetID, Reports: []string{models.SheetsReportLowStock}}))
+	writer.denied = true
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/integrations/google-sheets/export", nil)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	export, err := store.Sheets.Get()
+	require.NoError(t, err)
+	assert.Contains(t, export.LastError, "does not have permission")
+
+	writer.denied = false
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/integrations/google-sheets/export", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	export, err = store.Sheets.Get()
+	require.NoError(t, err)
+	assert.Empty(t, export.LastError, "cleared by a successful export")
+
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/integrations/google-sheets", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/integrations/google-sheets", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGoogleSheetsExportValidation(t *testing.T) {
+	app, _, handler, _, jwtSecret := setupGoogleSheetsTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/integrations/google-sheets", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	for name, body := range map[string]GoogleSheetsExportRequest{
+		"Invalid spreadsheet": {Spreadsheet: "my sheet", Reports: []string{models.SheetsReportLowStock}},
+		"No reports":          {Spreadsheet: testSpreadsheetID},
+		"Unknown report":      {Spreadsheet: testSpreadsheetID, Reports: []string{"payroll"}},
+	} {
+		resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/integrations/google-sheets", body)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	handler.Writer = nil
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/integrations/google-sheets", GoogleSheetsExportRequest{Spreadsheet: testSpreadsheetID, Reports: models.SheetsReports})
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/integrations/google-sheets", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status api.GoogleSheetsExportResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.False(t, status.Enabled)
+	assert.Nil(t, status.Export)
+}
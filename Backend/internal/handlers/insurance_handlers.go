@@ -0,0 +1,575 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationInsuranceExpiring is pushed to the sales team ahead of the expiry of
+// insurance policies that were not renewed
+const NotificationInsuranceExpiring = "insurance_expiring"
+
+// Days ahead the renewal report looks by default and at most, and how long after
+// lapsing a policy is still listed as an opportunity
+const (
+	defaultRenewalDays = 60
+	maxRenewalDays     = 366
+	renewalLapsedDays  = 30
+)
+
+// Longest providers and policy numbers, matching the widths of their columns
+const (
+	maxInsuranceProviderLength = 100
+	maxPolicyNumberLength      = 50
+)
+
+// InsuranceHandler records the insurance policies sold or arranged with sales, and
+// reports the policies coming up for renewal
+type InsuranceHandler struct {
+	Repo      repositories.InsurancePolicyRepository
+	Sales     repositories.SalesRepository
+	Customers repositories.CustomerRepository
+	Audit     *ChangeRecorder // Optional; records changes to the activity log
+	Perms     *Permissions    // Optional; without it only admins see unmasked contact details
+	// Consents holds the do-not-contact flags; customers who asked not to be contacted
+	// are left out of the renewal report. Without it nobody is left out.
+	Consents  repositories.CustomerConsentRepository
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewInsuranceHandler creates a new InsuranceHandler instance. Policies are recorded
+// for the customer of the sale they are linked to.
+func NewInsuranceHandler(repo repositories.InsurancePolicyRepository, sales repositories.SalesRepository, customers repositories.CustomerRepository, jwtSecret []byte) *InsuranceHandler {
+	return &InsuranceHandler{Repo: repo, Sales: sales, Customers: customers, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the insurance policy routes and the renewal report
+// (admin and staff)
+func (h *InsuranceHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	policyGroup := r.Group("/insurance-policies", mw.Auth, mw.Staff)
+	policyGroup.Get("/", h.GetPolicies)                  // GET /api/insurance-policies
+	policyGroup.Get("/:id", h.GetPolicy)                 // GET /api/insurance-policies/:id
+	policyGroup.Post("/", h.CreatePolicy)                // POST /api/insurance-policies
+	policyGroup.Put("/:id", h.UpdatePolicy)              // PUT /api/insurance-policies/:id
+	policyGroup.Delete("/:id", mw.Admin, h.DeletePolicy) // DELETE /api/insurance-policies/:id
+
+	r.Get("/reports/insurance-renewals", mw.Auth, mw.Staff, h.GetRenewalOpportunities) // GET /api/reports/insurance-renewals
+}
+
+// CreateInsurancePolicyRequest is the body for recording an insurance policy. A renewal
+// names the policy it renews and is linked to that policy's sale unless saleId is given.
+type CreateInsurancePolicyRequest struct {
+	SaleID       string  `json:"saleId,omitempty"`
+	RenewalOf    string  `json:"renewalOf,omitempty"`
+	Provider     string  `json:"provider"`
+	PolicyNumber string  `json:"policyNumber"`
+	Coverage     string  `json:"coverage"` // ctpl, comprehensive or other
+	Premium      float64 `json:"premium"`
+	StartDate    string  `json:"startDate,omitempty"` // YYYY-MM-DD; the sale date, or the expiry of the renewed policy, by default
+	ExpiryDate   string  `json:"expiryDate"`          // YYYY-MM-DD
+	Notes        string  `json:"notes,omitempty"`
+}
+
+// UpdateInsurancePolicyRequest is the body for replacing the details of a policy
+type UpdateInsurancePolicyRequest struct {
+	Provider     string  `json:"provider"`
+	PolicyNumber string  `json:"policyNumber"`
+	Coverage     string  `json:"coverage"`
+	Premium      float64 `json:"premium"`
+	StartDate    string  `json:"startDate"`  // YYYY-MM-DD
+	ExpiryDate   string  `json:"expiryDate"` // YYYY-MM-DD
+	Notes        string  `json:"notes"`
+}
+
+// insuranceInputError is a validation failure reported to the client as 400
+type insuranceInputError struct{ message string }
+
+func (e insuranceInputError) Error() string { return e.message }
+
+// insuranceError writes the response for a validation or lookup error
+func insuranceError(c *fiber.Ctx, err error, action string) error {
+	var inputErr insuranceInputError
+	if errors.As(err, &inputErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: inputErr.message, StatusCode: fiber.StatusBadRequest})
+	}
+	log.Printf("Error validating insurance policy: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to " + action, StatusCode: fiber.StatusInternalServerError})
+}
+
+// setPolicyDetails validates the details of a policy and sets them. An empty start date
+// is left for the caller to default.
+func setPolicyDetails(policy *models.InsurancePolicy, provider, policyNumber, coverage string, premium float64, startDate, expiryDate, notes string) error {
+	provider, policyNumber = strings.TrimSpace(provider), strings.ToUpper(strings.TrimSpace(policyNumber))
+	if provider == "" || policyNumber == "" {
+		return insuranceInputError{"provider and policyNumber are required"}
+	}
+	if len(provider) > maxInsuranceProviderLength {
+		return insuranceInputError{fmt.Sprintf("provider cannot be longer than %d characters", maxInsuranceProviderLength)}
+	}
+	if len(policyNumber) > maxPolicyNumberLength {
+		return insuranceInputError{fmt.Sprintf("policyNumber cannot be longer than %d characters", maxPolicyNumberLength)}
+	}
+	if !models.ValidInsuranceCoverage(coverage) {
+		return insuranceInputError{"coverage must be ctpl, comprehensive or other"}
+	}
+	if premium < 0 {
+		return insuranceInputError{"premium cannot be negative"}
+	}
+	start, err := parseInsuranceDate("startDate", startDate)
+	if err != nil {
+		return err
+	}
+	expiry, err := parseInsuranceDate("expiryDate", expiryDate)
+	if err != nil {
+		return err
+	}
+	if expiry == "" {
+		return insuranceInputError{"expiryDate is required"}
+	}
+	if start != "" && expiry <= start {
+		return insuranceInputError{"expiryDate must be after startDate"}
+	}
+
+	policy.Provider = provider
+	policy.PolicyNumber = policyNumber
+	policy.Coverage = coverage
+	policy.Premium = premium
+	policy.StartDate = start
+	policy.ExpiryDate = expiry
+	policy.Notes = strings.TrimSpace(notes)
+	return nil
+}
+
+// parseInsuranceDate validates a YYYY-MM-DD date of a field, returning "" unchanged
+func parseInsuranceDate(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := time.ParseInLocation(saleDateLayout, value, time.Local); err != nil {
+		return "", insuranceInputError{field + " must be formatted as YYYY-MM-DD"}
+	}
+	return value, nil
+}
+
+// duplicatePolicy returns the other policy of the provider with the same number, if any
+func (h *InsuranceHandler) duplicatePolicy(policy *models.InsurancePolicy) (*models.InsurancePolicy, error) {
+	existing, err := h.Repo.GetAll(models.InsurancePolicyFilter{PolicyNumber: policy.PolicyNumber})
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		if existing[i].ID != policy.ID && strings.EqualFold(existing[i].Provider, policy.Provider) {
+			return &existing[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// renewalOf returns the policy renewing the policy with the ID given, if any
+func (h *InsuranceHandler) renewalOf(policyID string) (*models.InsurancePolicy, error) {
+	renewals, err := h.Repo.GetAll(models.InsurancePolicyFilter{RenewalsOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	for i := range renewals {
+		if renewals[i].RenewalOf == policyID {
+			return &renewals[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// loadPolicy fetches the policy named by the :id parameter, writing the error response
+// itself when it fails
+func (h *InsuranceHandler) loadPolicy(c *fiber.Ctx) (*models.InsurancePolicy, error) {
+	policy, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Insurance policy not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting insurance policy %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve insurance policy", StatusCode: fiber.StatusInternalServerError})
+	}
+	return policy, nil
+}
+
+// GetPolicies handles listing insurance policies
+// @Summary List insurance policies
+// @Description Lists the insurance policies sold or arranged with sales, soonest expiring first. Filter by sale or customer, or list only those expiring within the next days.
+// @Tags Insurance
+// @Produce json
+// @Security ApiKeyAuth
+// @Param saleId query string false "Only policies linked to this sale"
+// @Param customerId query string false "Only policies of this customer"
+// @Param expiringWithin query int false "Only policies expiring within this many days, today included"
+// @Success 200 {object} api.InsurancePolicyListResponse "Insurance policies"
+// @Failure 400 {object} api.ErrorResponse "Invalid expiringWithin"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve insurance policies"
+// @Router /insurance-policies [get]
+func (h *InsuranceHandler) GetPolicies(c *fiber.Ctx) error {
+	filter := models.InsurancePolicyFilter{SaleID: c.Query("saleId"), CustomerID: c.Query("customerId")}
+	if raw := c.Query("expiringWithin"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 0 || days > maxRenewalDays {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("expiringWithin must be between 0 and %d", maxRenewalDays), StatusCode: fiber.StatusBadRequest})
+		}
+		now := h.now()
+		filter.ExpiresFrom = now.Format(saleDateLayout)
+		filter.ExpiresBefore = now.AddDate(0, 0, days+1).Format(saleDateLayout)
+	}
+
+	policies, err := h.Repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting insurance policies: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve insurance policies", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.InsurancePolicyListResponse{Policies: policies, Count: len(policies)})
+}
+
+// GetPolicy handles getting an insurance policy
+// @Summary Get an insurance policy
+// @Tags Insurance
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Policy ID"
+// @Success 200 {object} models.InsurancePolicy "Insurance policy"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Insurance policy not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve insurance policy"
+// @Router /insurance-policies/{id} [get]
+func (h *InsuranceHandler) GetPolicy(c *fiber.Ctx) error {
+	policy, err := h.loadPolicy(c)
+	if policy == nil {
+		return err
+	}
+	setLastModified(c, policy.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(policy)
+}
+
+// CreatePolicy handles recording an insurance policy
+// @Summary Record an insurance policy
+// @Description Records an insurance policy sold or arranged with a sale, for the sale's customer. A renewal names the policy it renews; it is linked to the same sale unless saleId is given, starts when the renewed policy expires by default, and takes the renewed policy out of the renewal report. Each policy can be renewed once.
+// @Tags Insurance
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param policy body CreateInsurancePolicyRequest true "Insurance policy"
+// @Success 201 {object} api.InsurancePolicyResponse "Insurance policy recorded"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body, or unknown sale or renewed policy"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "The provider's policy number is already recorded, or the policy was already renewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to record insurance policy"
+// @Router /insurance-policies [post]
+func (h *InsuranceHandler) CreatePolicy(c *fiber.Ctx) error {
+	var input CreateInsurancePolicyRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	input.SaleID, input.RenewalOf = strings.TrimSpace(input.SaleID), strings.TrimSpace(input.RenewalOf)
+	if input.SaleID == "" && input.RenewalOf == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "saleId or renewalOf is required", StatusCode: fiber.StatusBadRequest})
+	}
+	policy := &models.InsurancePolicy{RenewalOf: input.RenewalOf}
+	if err := setPolicyDetails(policy, input.Provider, input.PolicyNumber, input.Coverage, input.Premium, input.StartDate, input.ExpiryDate, input.Notes); err != nil {
+		return insuranceError(c, err, "record insurance policy")
+	}
+
+	if input.RenewalOf != "" {
+		renewed, err := h.Repo.GetByID(input.RenewalOf)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Renewed policy not found", StatusCode: fiber.StatusBadRequest})
+			}
+			return insuranceError(c, err, "record insurance policy")
+		}
+		renewal, err := h.renewalOf(renewed.ID)
+		if err != nil {
+			return insuranceError(c, err, "record insurance policy")
+		}
+		if renewal != nil {
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("Policy %s was already renewed by policy %s", renewed.PolicyNumber, renewal.PolicyNumber), StatusCode: fiber.StatusConflict})
+		}
+		if input.SaleID == "" {
+			input.SaleID = renewed.SaleID
+		}
+		if policy.StartDate == "" {
+			policy.StartDate = renewed.ExpiryDate
+		}
+		policy.CustomerID = renewed.CustomerID
+	}
+
+	sale, err := h.Sales.GetByID(c.Context(), input.SaleID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusBadRequest})
+		}
+		return insuranceError(c, fmt.Errorf("failed to get sale %s: %w", input.SaleID, err), "record insurance policy")
+	}
+	if policy.CustomerID != "" && policy.CustomerID != sale.CustomerID {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "A renewal must be linked to a sale of the same customer", StatusCode: fiber.StatusBadRequest})
+	}
+	policy.SaleID = sale.ID
+	policy.CustomerID = sale.CustomerID
+	if policy.StartDate == "" {
+		policy.StartDate = sale.SaleDate
+	}
+	if policy.ExpiryDate <= policy.StartDate {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "expiryDate must be after startDate", StatusCode: fiber.StatusBadRequest})
+	}
+
+	duplicate, err := h.duplicatePolicy(policy)
+	if err != nil {
+		return insuranceError(c, err, "record insurance policy")
+	}
+	if duplicate != nil {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("%s policy %s is already recorded", duplicate.Provider, duplicate.PolicyNumber), StatusCode: fiber.StatusConflict})
+	}
+
+	policy.CreatedBy, _ = c.Locals("user_id").(string)
+	if err := h.Repo.Create(policy); err != nil {
+		log.Printf("Error creating insurance policy: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to record insurance policy", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_INSURANCE_POLICY", AuditEntityInsurance, policy.ID,
+		fmt.Sprintf("Recorded %s policy %s of sale %s, expiring %s", policy.Provider, policy.PolicyNumber, policy.SaleID, policy.ExpiryDate))
+	return c.Status(fiber.StatusCreated).JSON(api.InsurancePolicyResponse{Message: "Insurance policy recorded", Policy: policy})
+}
+
+// UpdatePolicy handles editing the details of an insurance policy
+// @Summary Update an insurance policy
+// @Description Replaces the provider, policy number, coverage, premium, dates and notes of a policy. Its sale, customer and the policy it renews cannot change.
+// @Tags Insurance
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Policy ID"
+// @Param policy body UpdateInsurancePolicyRequest true "Policy details"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.InsurancePolicyResponse "Insurance policy updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Insurance policy not found"
+// @Failure 409 {object} api.ErrorResponse "The provider's policy number is already recorded"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update insurance policy"
+// @Router /insurance-policies/{id} [put]
+func (h *InsuranceHandler) UpdatePolicy(c *fiber.Ctx) error {
+	var input UpdateInsurancePolicyRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if strings.TrimSpace(input.StartDate) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "startDate is required", StatusCode: fiber.StatusBadRequest})
+	}
+
+	policy, err := h.loadPolicy(c)
+	if policy == nil {
+		return err
+	}
+	if modified, err := rejectIfModified(c, policy.UpdatedAt); modified {
+		return err
+	}
+	before := *policy
+
+	if err := setPolicyDetails(policy, input.Provider, input.PolicyNumber, input.Coverage, input.Premium, input.StartDate, input.ExpiryDate, input.Notes); err != nil {
+		return insuranceError(c, err, "update insurance policy")
+	}
+	duplicate, err := h.duplicatePolicy(policy)
+	if err != nil {
+		return insuranceError(c, err, "update insurance policy")
+	}
+	if duplicate != nil {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("%s policy %s is already recorded", duplicate.Provider, duplicate.PolicyNumber), StatusCode: fiber.StatusConflict})
+	}
+
+	if err := h.Repo.Update(policy); err != nil {
+		log.Printf("Error updating insurance policy %s: %v", policy.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update insurance policy", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityInsurance, policy.ID, before, *policy)
+	setLastModified(c, policy.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.InsurancePolicyResponse{Message: "Insurance policy updated", Policy: policy})
+}
+
+// DeletePolicy handles removing an insurance policy
+// @Summary Delete an insurance policy (Admin)
+// @Description Removes a policy recorded by mistake. Policies that were renewed cannot be deleted; delete the renewal first.
+// @Tags Insurance
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Policy ID"
+// @Success 200 {object} api.MessageResponse "Insurance policy deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Insurance policy not found"
+// @Failure 409 {object} api.ErrorResponse "The policy was renewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete insurance policy"
+// @Router /insurance-policies/{id} [delete]
+func (h *InsuranceHandler) DeletePolicy(c *fiber.Ctx) error {
+	policy, err := h.loadPolicy(c)
+	if policy == nil {
+		return err
+	}
+	renewal, err := h.renewalOf(policy.ID)
+	if err != nil {
+		return insuranceError(c, err, "delete insurance policy")
+	}
+	if renewal != nil {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("Policy %s was renewed by policy %s; delete the renewal first", policy.PolicyNumber, renewal.PolicyNumber), StatusCode: fiber.StatusConflict})
+	}
+
+	if err := h.Repo.Delete(policy.ID); err != nil {
+		log.Printf("Error deleting insurance policy %s: %v", policy.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete insurance policy", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_INSURANCE_POLICY", AuditEntityInsurance, policy.ID, fmt.Sprintf("Deleted %s policy %s", policy.Provider, policy.PolicyNumber))
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Insurance policy deleted"})
+}
+
+// unrenewedPolicies returns the policies expiring from from up to before before that
+// were not renewed, soonest expiring first
+func (h *InsuranceHandler) unrenewedPolicies(from, before string) ([]models.InsurancePolicy, error) {
+	expiring, err := h.Repo.GetAll(models.InsurancePolicyFilter{ExpiresFrom: from, ExpiresBefore: before})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expiring insurance policies: %w", err)
+	}
+	renewals, err := h.Repo.GetAll(models.InsurancePolicyFilter{RenewalsOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list insurance renewals: %w", err)
+	}
+	renewed := make(map[string]bool, len(renewals))
+	for _, renewal := range renewals {
+		renewed[renewal.RenewalOf] = true
+	}
+	policies := []models.InsurancePolicy{}
+	for _, policy := range expiring {
+		if !renewed[policy.ID] {
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// GetRenewalOpportunities handles the insurance renewal report
+// @Summary Insurance renewal opportunities
+// @Description Lists the insurance policies expiring within the next days days, or lapsed within the last 30, that were not renewed, soonest expiring first, with the customer to call. Customers who asked not to be contacted and anonymized customers are left out. Contact details are masked for callers without the customers.pii permission.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "Days ahead, from 1 to 366 (default 60)"
+// @Success 200 {object} api.RenewalOpportunitiesResponse "Renewal opportunities"
+// @Failure 400 {object} api.ErrorResponse "Invalid days"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve renewal opportunities"
+// @Router /reports/insurance-renewals [get]
+func (h *InsuranceHandler) GetRenewalOpportunities(c *fiber.Ctx) error {
+	days := defaultRenewalDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxRenewalDays {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("days must be between 1 and %d", maxRenewalDays), StatusCode: fiber.StatusBadRequest})
+		}
+		days = parsed
+	}
+
+	now := h.now()
+	today, err := time.ParseInLocation(saleDateLayout, now.Format(saleDateLayout), time.Local)
+	if err != nil {
+		return insuranceError(c, err, "retrieve renewal opportunities")
+	}
+	policies, err := h.unrenewedPolicies(today.AddDate(0, 0, -renewalLapsedDays).Format(saleDateLayout), today.AddDate(0, 0, days+1).Format(saleDateLayout))
+	if err != nil {
+		return insuranceError(c, err, "retrieve renewal opportunities")
+	}
+	customers, err := h.Customers.GetAllCustomers(c.Context())
+	if err != nil {
+		return insuranceError(c, fmt.Errorf("failed to list customers: %w", err), "retrieve renewal opportunities")
+	}
+	doNotContact := map[string]bool{}
+	if h.Consents != nil {
+		consents, err := h.Consents.GetAll()
+		if err != nil {
+			return insuranceError(c, fmt.Errorf("failed to list customer consents: %w", err), "retrieve renewal opportunities")
+		}
+		for _, consent := range consents {
+			doNotContact[consent.CustomerID] = consent.DoNotContact
+		}
+	}
+	byID := make(map[string]*models.Customer, len(customers))
+	for _, customer := range customers {
+		if !isAnonymizedCustomer(customer) && !doNotContact[customer.ID] {
+			byID[customer.ID] = customer
+		}
+	}
+
+	opportunities := []api.RenewalOpportunity{}
+	for _, policy := range policies {
+		customer, ok := byID[policy.CustomerID]
+		if !ok {
+			continue
+		}
+		expiry, err := time.ParseInLocation(saleDateLayout, policy.ExpiryDate, time.Local)
+		if err != nil {
+			continue
+		}
+		opportunities = append(opportunities, api.RenewalOpportunity{
+			InsurancePolicy: policy,
+			DaysToExpiry:    calendarDaysBetween(today, expiry),
+			Customer:        h.Perms.MaskCustomer(c, toCustomerResponse(customer)),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.RenewalOpportunitiesResponse{
+		From:          today.Format(saleDateLayout),
+		Days:          days,
+		Opportunities: opportunities,
+		Count:         len(opportunities),
+	})
+}
+
+// NotifyExpiringPolicies pushes the policies expiring daysAhead days from now that were
+// not renewed to the tenant's active admins and staff, so the sales team can offer the
+// renewal. It returns how many policies were notified of.
+func (h *InsuranceHandler) NotifyExpiringPolicies(ctx context.Context, tenantID string, users UserRepository, hub *services.NotificationHub, daysAhead int) (int, error) {
+	day := h.now().AddDate(0, 0, daysAhead)
+	policies, err := h.unrenewedPolicies(day.Format(saleDateLayout), day.AddDate(0, 0, 1).Format(saleDateLayout))
+	if err != nil {
+		return 0, err
+	}
+	if len(policies) == 0 || hub == nil {
+		return len(policies), nil
+	}
+
+	notification := models.Notification{
+		Type: NotificationInsuranceExpiring,
+		Data: api.InsuranceExpiringEvent{ExpiryDate: day.Format(saleDateLayout), Policies: policies},
+	}
+	if err := notifyUsers(ctx, users, hub, tenantID, isBackOffice, notification); err != nil {
+		return 0, err
+	}
+	return len(policies), nil
+}
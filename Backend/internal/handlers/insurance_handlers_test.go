@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsurancePolicies(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	cab := addTestCab(t, store)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "09171234567"})
+	require.NoError(t, err)
+	sale, err := store.Sales.SellCab(context.Background(), cab.ID, customer.ID, 1, "staff-1", "", nil)
+	require.NoError(t, err)
+	sold, err := time.ParseInLocation(saleDateLayout, sale.SaleDate, time.Local)
+	require.NoError(t, err)
+
+	h := NewInsuranceHandler(store.Insurance, store.Sales, store.Customers, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+	h.Consents = store.Consents
+	h.now = func() time.Time { return sold }
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	expiry := sold.AddDate(1, 0, 0).Format(saleDateLayout)
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/insurance-policies", CreateInsurancePolicyRequest{
+		SaleID: sale.ID, Provider: "Malayan Insurance", PolicyNumber: "ctpl-0001", Coverage: models.InsuranceCTPL, Premium: 1200, ExpiryDate: expiry,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.InsurancePolicyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	policy := created.Policy
+	assert.Equal(t, customer.ID, policy.CustomerID)
+	assert.Equal(t, sale.SaleDate, policy.StartDate, "starts on the sale date by default")
+	assert.Equal(t, "CTPL-0001", policy.PolicyNumber)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/insurance-policies", CreateInsurancePolicyRequest{
+		SaleID: sale.ID, Provider: "malayan insurance", PolicyNumber: "CTPL-0001", Coverage: models.InsuranceCTPL, ExpiryDate: expiry,
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the provider's policy number is already recorded")
+	for _, invalid := range []CreateInsurancePolicyRequest{
+		{Provider: "Malayan Insurance", PolicyNumber: "CTPL-0002", Coverage: models.InsuranceCTPL, ExpiryDate: expiry},
+		{SaleID: "missing", Provider: "Malayan Insurance", PolicyNumber: "CTPL-0002", Coverage: models.InsuranceCTPL, ExpiryDate: expiry},
+		{SaleID: sale.ID, Provider: "Malayan Insurance", PolicyNumber: "CTPL-0002", Coverage: "life", ExpiryDate: expiry},
+		{SaleID: sale.ID, Provider: "Malayan Insurance", PolicyNumber: "CTPL-0002", Coverage: models.InsuranceCTPL, ExpiryDate: sale.SaleDate},
+		{SaleID: sale.ID, Provider: "Malayan Insurance", PolicyNumber: "CTPL-0002", Coverage: models.InsuranceCTPL, Premium: -1, ExpiryDate: expiry},
+	} {
+		resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/insurance-policies", invalid)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, invalid)
+	}
+
+	// A comprehensive policy expiring in 20 days shows up for renewal
+	soon := sold.AddDate(0, 0, 20).Format(saleDateLayout)
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/insurance-policies", CreateInsurancePolicyRequest{
+		SaleID: sale.ID, Provider: "FPG Insurance", PolicyNumber: "COMP-77", Coverage: models.InsuranceComprehensive, Premium: 8500,
+		StartDate: sold.AddDate(-1, 0, 20).Format(saleDateLayout), ExpiryDate: soon,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	created = api.InsurancePolicyResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	expiring := created.Policy
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/insurance-renewals?days=30", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.RenewalOpportunitiesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, 1, report.Count, "the CTPL policy expires after the window")
+	assert.Equal(t, expiring.ID, report.Opportunities[0].ID)
+	assert.Equal(t, 20, report.Opportunities[0].DaysToExpiry)
+	assert.NotEqual(t, customer.Phone, report.Opportunities[0].Customer.Phone, "contact details are masked without customers.pii")
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/insurance-renewals?days=0", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Still listed a week after lapsing, unless the customer asked not to be contacted
+	h.now = func() time.Time { return sold.AddDate(0, 0, 27) }
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/insurance-renewals?days=30", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report = api.RenewalOpportunitiesResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, 1, report.Count)
+	assert.Equal(t, -7, report.Opportunities[0].DaysToExpiry)
+	assert.Equal(t, customer.Phone, report.Opportunities[0].Customer.Phone)
+
+	require.NoError(t, store.Consents.Save(&models.CustomerConsent{CustomerID: customer.ID, DoNotContact: true}))
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/insurance-renewals?days=30", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report = api.RenewalOpportunitiesResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Zero(t, report.Count)
+	require.NoError(t, store.Consents.Save(&models.CustomerConsent{CustomerID: customer.ID}))
+
+	// Renewing takes the policy out of the report
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/insurance-policies", CreateInsurancePolicyRequest{
+		RenewalOf: expiring.ID, Provider: "FPG Insurance", PolicyNumber: "COMP-78", Coverage: models.InsuranceComprehensive, Premium: 8900,
+		ExpiryDate: sold.AddDate(1, 0, 20).Format(saleDateLayout),
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	created = api.InsurancePolicyResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	renewal := created.Policy
+	assert.Equal(t, sale.ID, renewal.SaleID)
+	assert.Equal(t, soon, renewal.StartDate, "starts when the renewed policy expires")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/insurance-policies", CreateInsurancePolicyRequest{
+		RenewalOf: expiring.ID, Provider: "FPG Insurance", PolicyNumber: "COMP-79", Coverage: models.InsuranceComprehensive, ExpiryDate: expiry,
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "each policy is renewed once")
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/insurance-renewals?days=30", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report = api.RenewalOpportunitiesResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Zero(t, report.Count)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/insurance-policies?customerId="+customer.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed api.InsurancePolicyListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Equal(t, 3, listed.Count)
+	assert.Equal(t, expiring.ID, listed.Policies[0].ID, "soonest expiring first")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/insurance-policies/"+renewal.ID, UpdateInsurancePolicyRequest{
+		Provider: "FPG Insurance", PolicyNumber: "COMP-80", Coverage: models.InsuranceComprehensive, Premium: 9100,
+		StartDate: renewal.StartDate, ExpiryDate: renewal.ExpiryDate, Notes: "Premium adjusted for the new accessories",
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/insurance-policies/"+renewal.ID, UpdateInsurancePolicyRequest{
+		Provider: "Malayan Insurance", PolicyNumber: "CTPL-0001", Coverage: models.InsuranceCTPL, StartDate: renewal.StartDate, ExpiryDate: renewal.ExpiryDate,
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodDelete, "/api/insurance-policies/"+renewal.ID, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/insurance-policies/"+expiring.ID, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "delete the renewal first")
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/insurance-policies/"+renewal.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/insurance-policies/"+renewal.ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	assert.NotEmpty(t, logs, "changes are recorded in the activity log")
+}
+
+func TestNotifyExpiringPolicies(t *testing.T) {
+	store := memory.NewStore()
+	today := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.Local)
+	renewed := models.InsurancePolicy{SaleID: "sale-1", Provider: "FPG Insurance", PolicyNumber: "COMP-1", Coverage: models.InsuranceComprehensive, ExpiryDate: "2026-11-15"}
+	require.NoError(t, store.Insurance.Create(&renewed))
+	for _, policy := range []models.InsurancePolicy{
+		{SaleID: "sale-1", Provider: "FPG Insurance", PolicyNumber: "COMP-2", Coverage: models.InsuranceComprehensive, ExpiryDate: "2027-11-15", RenewalOf: renewed.ID},
+		{SaleID: "sale-2", Provider: "Malayan Insurance", PolicyNumber: "CTPL-1", Coverage: models.InsuranceCTPL, ExpiryDate: "2026-11-15"},
+		{SaleID: "sale-3", Provider: "Malayan Insurance", PolicyNumber: "CTPL-2", Coverage: models.InsuranceCTPL, ExpiryDate: "2026-11-16"},
+	} {
+		require.NoError(t, store.Insurance.Create(&policy))
+	}
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "staff-1", Username: "staff", Email: "staff@example.com", Password: "secret123", Role: RoleStaff, IsActive: true}))
+	hub := services.NewNotificationHub()
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribe()
+
+	h := NewInsuranceHandler(store.Insurance, store.Sales, store.Customers, []byte("testsecret"))
+	h.now = func() time.Time { return today }
+	count, err := h.NotifyExpiringPolicies(context.Background(), models.DefaultTenantID, store.Users, hub, 30)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	received := receiveNotifications(notifications)
+	require.Len(t, received, 1)
+	assert.Equal(t, NotificationInsuranceExpiring, received[0].Type)
+	event := received[0].Data.(api.InsuranceExpiringEvent)
+	assert.Equal(t, "2026-11-15", event.ExpiryDate)
+	require.Len(t, event.Policies, 1, "renewed policies and those expiring on other days are left out")
+	assert.Equal(t, "sale-2", event.Policies[0].SaleID)
+}
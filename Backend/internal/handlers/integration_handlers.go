@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers a partner system signs its requests to the inbound endpoint with
+const (
+	HeaderIntegrationID      = "X-Integration-Id"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp" // Unix seconds
+	HeaderSignatureNonce     = "X-Signature-Nonce"     // Unique per request
+	HeaderSignature          = "X-Signature"           // See services.SignRequest
+)
+
+// Limits of inbound orders
+const (
+	maxIntegrationNonceLength = 100
+	maxInboundOrderItems      = 50
+	maxIntegrationNameLength  = 100
+)
+
+// InboundOrderTypeOrder is the only kind of payload the inbound endpoint converts, into a sale
+const InboundOrderTypeOrder = "order"
+
+// IntegrationHandler manages the partner systems, such as online marketplaces, that
+// post orders to the inbound endpoint, and converts their orders into sales. Every
+// inbound request is signed with the integration's secret; its nonce is only
+// accepted once, and the order's external ID only creates one sale.
+type IntegrationHandler struct {
+	Repo        repositories.IntegrationRepository
+	Users       UserRepository
+	Customers   repositories.CustomerRepository
+	Sales       SaleRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Audit       *ChangeRecorder
+	Alerts      *Alerts
+	now         func() time.Time
+	jwtSecret   []byte
+}
+
+// NewIntegrationHandler creates a new IntegrationHandler instance
+func NewIntegrationHandler(repo repositories.IntegrationRepository, users UserRepository, customers repositories.CustomerRepository, sales SaleRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, jwtSecret []byte) *IntegrationHandler {
+	return &IntegrationHandler{Repo: repo, Users: users, Customers: customers, Sales: sales, Cabs: cabs, Accessories: accessories, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the inbound endpoint and the admin routes that manage integrations
+func (h *IntegrationHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/integrations/inbound", h.ReceiveInbound) // POST /api/integrations/inbound (signed, no JWT)
+
+	adminGroup := r.Group("/admin/integrations", mw.Auth, mw.Admin)
+	adminGroup.Get("/", h.GetIntegrations)             // GET /api/admin/integrations
+	adminGroup.Post("/", h.CreateIntegration)          // POST /api/admin/integrations
+	adminGroup.Post("/:id/secret", h.RotateSecret)     // POST /api/admin/integrations/:id/secret
+	adminGroup.Delete("/:id", h.DeactivateIntegration) // DELETE /api/admin/integrations/:id
+}
+
+// IntegrationRequest is the body for registering an integration
+type IntegrationRequest struct {
+	Name   string `json:"name"`
+	SoldBy string `json:"soldBy"` // User the sales are recorded under; defaults to you
+}
+
+// GetIntegrations handles listing integrations
+// @Summary List integrations (Admin)
+// @Description Lists the partner systems that can post orders to the inbound endpoint, newest first. Secrets are not shown.
+// @Tags Integrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.IntegrationListResponse "Integrations"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve integrations"
+// @Router /admin/integrations [get]
+func (h *IntegrationHandler) GetIntegrations(c *fiber.Ctx) error {
+	integrations, err := h.Repo.GetAll()
+	if err != nil {
+		log.Printf("Error listing integrations: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve integrations", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.IntegrationListResponse{Integrations: integrations})
+}
+
+// CreateIntegration handles registering an integration
+// @Summary Register an integration (Admin)
+// @Description Registers a partner system and returns the secret it signs its requests with. The secret is only shown once.
+// @Tags Integrations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param integration body IntegrationRequest true "Integration"
+// @Success 201 {object} api.IntegrationSecretResponse "Integration registered"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or sales user"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to register integration"
+// @Router /admin/integrations [post]
+func (h *IntegrationHandler) CreateIntegration(c *fiber.Ctx) error {
+	var input IntegrationRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > maxIntegrationNameLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("name is required and at most %d characters", maxIntegrationNameLength), StatusCode: fiber.StatusBadRequest})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if input.SoldBy == "" {
+		input.SoldBy = userID
+	}
+	if user, err := h.Users.GetByID(c.Context(), input.SoldBy); err != nil || !user.IsActive {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "soldBy must be an active user", StatusCode: fiber.StatusBadRequest})
+	}
+
+	secret, err := services.NewSigningSecret()
+	if err != nil {
+		log.Printf("Error generating integration secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to register integration", StatusCode: fiber.StatusInternalServerError})
+	}
+	integration := &models.Integration{Name: input.Name, Secret: secret, SoldBy: input.SoldBy, CreatedBy: userID}
+	if err := h.Repo.Create(integration); err != nil {
+		log.Printf("Error creating integration: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to register integration", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_INTEGRATION", AuditEntityIntegration, integration.ID, fmt.Sprintf("Registered integration %s", integration.Name))
+	return c.Status(fiber.StatusCreated).JSON(api.IntegrationSecretResponse{Integration: *integration, Secret: secret})
+}
+
+// RotateSecret handles replacing the secret of an integration
+// @Summary Rotate an integration secret (Admin)
+// @Description Replaces the secret an integration signs its requests with. Requests signed with the old secret are rejected right away.
+// @Tags Integrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Integration ID"
+// @Success 200 {object} api.IntegrationSecretResponse "New secret"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Integration not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to rotate secret"
+// @Router /admin/integrations/{id}/secret [post]
+func (h *IntegrationHandler) RotateSecret(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	secret, err := services.NewSigningSecret()
+	if err != nil {
+		log.Printf("Error generating integration secret: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to rotate secret", StatusCode: fiber.StatusInternalServerError})
+	}
+	if err := h.Repo.SetSecret(id, secret); err != nil {
+		return h.integrationError(c, id, "rotate secret", err)
+	}
+	integration, err := h.Repo.GetByID(id)
+	if err != nil {
+		return h.integrationError(c, id, "rotate secret", err)
+	}
+
+	h.Audit.RecordAction(c, "ROTATE_INTEGRATION_SECRET", AuditEntityIntegration, id, fmt.Sprintf("Rotated the secret of integration %s", integration.Name))
+	return c.Status(fiber.StatusOK).JSON(api.IntegrationSecretResponse{Integration: *integration, Secret: secret})
+}
+
+// DeactivateIntegration handles deactivating an integration
+// @Summary Deactivate an integration (Admin)
+// @Description Stops accepting requests from an integration. The sales it created are kept.
+// @Tags Integrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Integration ID"
+// @Success 200 {object} api.MessageResponse "Integration deactivated"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Integration not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to deactivate integration"
+// @Router /admin/integrations/{id} [delete]
+func (h *IntegrationHandler) DeactivateIntegration(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := h.Repo.Deactivate(id); err != nil {
+		return h.integrationError(c, id, "deactivate integration", err)
+	}
+
+	h.Audit.RecordAction(c, "DEACTIVATE_INTEGRATION", AuditEntityIntegration, id, "Deactivated integration")
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Integration deactivated"})
+}
+
+// integrationError answers a failed change to an integration
+func (h *IntegrationHandler) integrationError(c *fiber.Ctx, id, action string, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Integration not found", StatusCode: fiber.StatusNotFound})
+	}
+	log.Printf("Error trying to %s %s: %v", action, id, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to " + action, StatusCode: fiber.StatusInternalServerError})
+}
+
+// InboundCustomer is the buyer of an inbound order. An existing customer with the
+// same email is reused.
+type InboundCustomer struct {
+	FullName string `json:"fullName"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Address  string `json:"address"`
+}
+
+// InboundOrderItem is a cab or accessory of an inbound order
+type InboundOrderItem struct {
+	ItemType  string   `json:"itemType"` // cab or accessory
+	ItemID    int      `json:"itemId"`
+	Quantity  int      `json:"quantity"`
+	UnitPrice *float64 `json:"unitPrice"` // Defaults to the item's current price
+}
+
+// InboundOrder is the payload a partner system posts to the inbound endpoint
+type InboundOrder struct {
+	Type       string             `json:"type"`       // Only "order" is supported; defaults to it
+	ExternalID string             `json:"externalId"` // The partner's order ID; an order is only converted once
+	OrderDate  string             `json:"orderDate"`  // YYYY-MM-DD; defaults to today
+	TaxType    string             `json:"taxType"`    // vatable (default), exempt or zero_rated
+	Customer   InboundCustomer    `json:"customer"`
+	Items      []InboundOrderItem `json:"items"`
+}
+
+func (o InboundOrder) validate() error {
+	if o.Type != "" && o.Type != InboundOrderTypeOrder {
+		return fmt.Errorf("type %q is not supported; only orders are converted into sales", o.Type)
+	}
+	if strings.TrimSpace(o.ExternalID) == "" || len(o.ExternalID) > 100 {
+		return errors.New("externalId is required and at most 100 characters")
+	}
+	if o.OrderDate != "" {
+		if _, err := time.Parse("2006-01-02", o.OrderDate); err != nil {
+			return errors.New("orderDate must be a date in YYYY-MM-DD format")
+		}
+	}
+	if o.TaxType != "" && !models.ValidTaxType(o.TaxType) {
+		return errors.New("taxType must be vatable, exempt or zero_rated")
+	}
+	if strings.TrimSpace(o.Customer.FullName) == "" || strings.TrimSpace(o.Customer.Email) == "" {
+		return errors.New("customer.fullName and customer.email are required")
+	}
+	if len(o.Items) == 0 || len(o.Items) > maxInboundOrderItems {
+		return fmt.Errorf("an order has between 1 and %d items", maxInboundOrderItems)
+	}
+	for _, item := range o.Items {
+		if item.ItemType != AuditEntityCab && item.ItemType != AuditEntityAccessory {
+			return errors.New("itemType must be cab or accessory")
+		}
+		if item.Quantity < 1 {
+			return errors.New("quantity must be at least 1")
+		}
+		if item.UnitPrice != nil && *item.UnitPrice < 0 {
+			return errors.New("unitPrice cannot be negative")
+		}
+	}
+	return nil
+}
+
+// ReceiveInbound handles an order posted by a partner system
+// @Summary Receive a signed order from an integration
+// @Description Converts an order from a partner system, such as an online marketplace, into a sale. The request carries the integration ID, a Unix timestamp within 5 minutes of the server clock and a unique nonce in headers, and is signed with the hex HMAC-SHA256 of "timestamp.nonce.body" keyed with the integration secret. A nonce is accepted once; an order resent with the same externalId returns the sale created the first time. The cabs and accessories ordered are taken out of stock with the sale, and an order for more than is in stock is refused.
+// @Tags Integrations
+// @Accept json
+// @Produce json
+// @Param X-Integration-Id header string true "Integration ID"
+// @Param X-Signature-Timestamp header string true "Unix seconds"
+// @Param X-Signature-Nonce header string true "Unique per request, up to 100 characters"
+// @Param X-Signature header string true "Hex HMAC-SHA256, optionally prefixed with sha256="
+// @Param order body InboundOrder true "Order"
+// @Success 200 {object} api.InboundOrderResponse "Order was received before"
+// @Success 201 {object} api.InboundOrderResponse "Sale created"
+// @Failure 400 {object} api.ErrorResponse "Invalid payload"
+// @Failure 401 {object} api.ErrorResponse "Unknown integration or invalid signature"
+// @Failure 409 {object} api.ErrorResponse "Nonce was already used, or not enough stock for the order"
+// @Failure 422 {object} api.ErrorResponse "Unknown item or price outside its price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to process order"
+// @Router /integrations/inbound [post]
+func (h *IntegrationHandler) ReceiveInbound(c *fiber.Ctx) error {
+	integration, status, reason := h.authenticate(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(api.ErrorResponse{Error: reason, StatusCode: status})
+	}
+	// The integration is the caller, for the activity log
+	c.Locals("user_id", "integration:"+integration.ID)
+
+	var order InboundOrder
+	if err := json.Unmarshal(c.Body(), &order); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := order.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	order.ExternalID = strings.TrimSpace(order.ExternalID)
+
+	if saleID, err := h.Repo.GetOrderSale(integration.ID, order.ExternalID); err == nil {
+		return c.Status(fiber.StatusOK).JSON(api.InboundOrderResponse{Message: "Order was already received", SaleID: saleID, Duplicate: true})
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error looking up order %s of integration %s: %v", order.ExternalID, integration.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to process order", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	items, total, err := h.priceItems(c.Context(), order.Items)
+	if err != nil {
+		var outsideGuard *priceGuardError
+		if strings.Contains(err.Error(), "not found") || errors.As(err, &outsideGuard) {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusUnprocessableEntity})
+		}
+		log.Printf("Error pricing order %s of integration %s: %v", order.ExternalID, integration.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to process order", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	sale, err := h.createSale(c.Context(), integration, order, items, total)
+	if errors.Is(err, repositories.ErrNegativeStock) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Not enough stock for the order: " + err.Error(), StatusCode: fiber.StatusConflict})
+	}
+	if err != nil {
+		log.Printf("Error converting order %s of integration %s: %v", order.ExternalID, integration.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to process order", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Alerts.SaleRecorded(c, *sale)
+	h.Audit.RecordAction(c, "CREATE_SALE_FROM_INTEGRATION", AuditEntitySale, sale.ID, fmt.Sprintf("Converted order %s from integration %s into a sale", order.ExternalID, integration.Name))
+	return c.Status(fiber.StatusCreated).JSON(api.InboundOrderResponse{Message: "Sale created", SaleID: sale.ID})
+}
+
+// authenticate checks the integration, signature and nonce of an inbound request. It
+// returns the integration with 200, or the status and reason to reject the request with.
+func (h *IntegrationHandler) authenticate(c *fiber.Ctx) (*models.Integration, int, string) {
+	const unauthorized = "Invalid integration or signature"
+
+	integrationID := c.Get(HeaderIntegrationID)
+	nonce := c.Get(HeaderSignatureNonce)
+	if integrationID == "" || nonce == "" || len(nonce) > maxIntegrationNonceLength {
+		return nil, fiber.StatusUnauthorized, unauthorized
+	}
+
+	integration, err := h.Repo.GetByID(integrationID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting integration %s: %v", integrationID, err)
+			return nil, fiber.StatusInternalServerError, "Failed to process order"
+		}
+		return nil, fiber.StatusUnauthorized, unauthorized
+	}
+	if !integration.Active {
+		return nil, fiber.StatusUnauthorized, unauthorized
+	}
+
+	now := h.now()
+	if err := services.VerifyRequest(integration.Secret, c.Get(HeaderSignatureTimestamp), nonce, c.Get(HeaderSignature), c.Body(), now); err != nil {
+		log.Printf("Rejected inbound request of integration %s: %v", integration.ID, err)
+		h.Audit.RecordAttempt(c, "INBOUND_REQUEST_REJECTED", AuditEntityIntegration, integration.ID, fmt.Sprintf("Rejected request from integration %s: %v", integration.Name, err), false)
+		return nil, fiber.StatusUnauthorized, unauthorized
+	}
+
+	fresh, err := h.Repo.UseNonce(integration.ID, strings.Clone(nonce), now, now.Add(-2*services.SignatureTolerance))
+	if err != nil {
+		log.Printf("Error recording nonce of integration %s: %v", integration.ID, err)
+		return nil, fiber.StatusInternalServerError, "Failed to process order"
+	}
+	if !fresh {
+		h.Audit.RecordAttempt(c, "INBOUND_REQUEST_REJECTED", AuditEntityIntegration, integration.ID, fmt.Sprintf("Rejected replayed request from integration %s", integration.Name), false)
+		return nil, fiber.StatusConflict, "Nonce was already used"
+	}
+	return integration, fiber.StatusOK, ""
+}
+
+// priceItems turns the items of an order into sale items, at their price in the order
+// or the item's current price, and returns their total. A price outside the item's
+// price guard is a *priceGuardError.
+func (h *IntegrationHandler) priceItems(ctx context.Context, orderItems []InboundOrderItem) ([]models.SaleItem, float64, error) {
+	items := make([]models.SaleItem, 0, len(orderItems))
+	total := 0.0
+	for _, orderItem := range orderItems {
+		item := models.SaleItem{Quantity: orderItem.Quantity}
+		var pricing pricedItem
+		switch orderItem.ItemType {
+		case AuditEntityCab:
+			cab, err := h.Cabs.GetCabByID(ctx, orderItem.ItemID)
+			if err != nil {
+				return nil, 0, err
+			}
+			item.Item = cab.Ref()
+			pricing = cabPricing(*cab)
+		case AuditEntityAccessory:
+			accessory, err := h.Accessories.GetByID(ctx, orderItem.ItemID)
+			if err != nil {
+				return nil, 0, err
+			}
+			item.Item = accessory.Ref()
+			pricing = accessoryPricing(accessory)
+		}
+		if orderItem.UnitPrice != nil {
+			pricing.Price = *orderItem.UnitPrice
+		}
+		if err := checkPriceGuard(pricing); err != nil {
+			return nil, 0, err
+		}
+		price := pricing.Price
+		item.UnitPrice = price
+		item.Subtotal = price * float64(orderItem.Quantity)
+		total += item.Subtotal
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+// createSale records an order as a sale of the integration's sales user, to the
+// customer with the buyer's email, creating that customer when there is none. The
+// sale and its items are recorded and taken out of stock in one transaction, which
+// fails with repositories.ErrNegativeStock when fewer units are in stock than ordered.
+func (h *IntegrationHandler) createSale(ctx context.Context, integration *models.Integration, order InboundOrder, items []models.SaleItem, total float64) (*models.Sale, error) {
+	email := strings.TrimSpace(order.Customer.Email)
+	customer, err := h.Customers.GetCustomerByEmail(ctx, email)
+	if err != nil {
+		if !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+		customer, err = h.Customers.CreateCustomer(ctx, &models.Customer{
+			FullName:       strings.TrimSpace(order.Customer.FullName),
+			Email:          email,
+			Phone:          strings.TrimSpace(order.Customer.Phone),
+			Address:        strings.TrimSpace(order.Customer.Address),
+			DateRegistered: h.now(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create customer: %w", err)
+		}
+	}
+
+	saleDate := order.OrderDate
+	if saleDate == "" {
+		saleDate = h.now().Format("2006-01-02")
+	}
+	sale := &models.Sale{
+		CustomerID: customer.ID,
+		SoldBy:     integration.SoldBy,
+		SaleDate:   saleDate,
+		TotalPrice: total,
+		TaxType:    order.TaxType,
+		CreatedAt:  h.now(),
+		UpdatedAt:  h.now(),
+	}
+	sale.ApplyTax()
+	if err := h.Sales.Sell(ctx, sale, items, true); err != nil {
+		return nil, fmt.Errorf("failed to record sale: %w", err)
+	}
+
+	if err := h.Repo.SaveOrder(integration.ID, order.ExternalID, sale.ID); err != nil {
+		// Call the sale off so a resent order does not sell the items twice
+		if cancelErr := h.Sales.SetStatus(ctx, sale.ID, models.SaleCancelled); cancelErr != nil {
+			log.Printf("Error cancelling sale %s of order %s whose link could not be saved: %v", sale.ID, order.ExternalID, cancelErr)
+		}
+		return nil, err
+	}
+	return sale, nil
+}
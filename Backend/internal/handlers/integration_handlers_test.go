@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupIntegrationTestApp registers the integration routes on an in-memory store with
+// one integration, a cab and an accessory, and returns the integration's secret
+func setupIntegrationTestApp(t *testing.T) (*fiber.App, *memory.Store, *models.Integration, *models.MultiCab, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	handler := NewIntegrationHandler(store.Integrations, store.Users, store.Customers, store.Sales, store.Cabs, store.Accessories, jwtSecret)
+	handler.Audit = NewChangeRecorder(store.Logs)
+
+	seller := &models.User{Username: "seller", Email: "seller@example.com", Password: "secret", Role: RoleStaff, IsActive: true}
+	require.NoError(t, store.Users.Create(context.Background(), seller))
+	integration := &models.Integration{Name: "Marketplace", Secret: "integration-secret", SoldBy: seller.Id}
+	require.NoError(t, store.Integrations.Create(integration))
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Scrum", Make: "Suzuki", Quantity: 3, Price: 250000, Status: "In Stock", UnitColor: "White"})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, integration, cab, jwtSecret
+}
+
+// signedRequest posts an order to the inbound endpoint, signed with secret
+func signedRequest(t *testing.T, app *fiber.App, integrationID, secret, nonce string, timestamp time.Time, order interface{}) *http.Response {
+	body, err := json.Marshal(order)
+	require.NoError(t, err)
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/integrations/inbound", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderIntegrationID, integrationID)
+	req.Header.Set(HeaderSignatureTimestamp, ts)
+	req.Header.Set(HeaderSignatureNonce, nonce)
+	req.Header.Set(HeaderSignature, "sha256="+services.SignRequest(secret, ts, nonce, body))
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func testInboundOrder(cabID int) InboundOrder {
+	return InboundOrder{
+		ExternalID: "MP-1001",
+		OrderDate:  "2026-10-01",
+		Customer:   InboundCustomer{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "09171234567"},
+		Items:      []InboundOrderItem{{ItemType: "cab", ItemID: cabID, Quantity: 2}},
+	}
+}
+
+func TestReceiveInboundOrder(t *testing.T) {
+	app, store, integration, cab, _ := setupIntegrationTestApp(t)
+	order := testInboundOrder(cab.ID)
+
+	resp := signedRequest(t, app, integration.ID, integration.Secret, "nonce-1", time.Now(), order)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.InboundOrderResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.False(t, created.Duplicate)
+
+	sale, err := store.Sales.GetByID(context.Background(), created.SaleID)
+	require.NoError(t, err)
+	assert.Equal(t, integration.SoldBy, sale.SoldBy)
+	assert.Equal(t, "2026-10-01", sale.SaleDate)
+	assert.Equal(t, 500000.0, sale.TotalPrice, "items default to their current price")
+	items, err := store.Sales.GetSaleItems(context.Background(), sale.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, cab.Ref(), items[0].Item)
+	stocked, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stocked.Quantity, "the cabs ordered are taken out of stock")
+
+	customer, err := store.Customers.GetCustomerByEmail(context.Background(), "juan@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, customer.ID, sale.CustomerID)
+
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-2", time.Now(), order)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var duplicate api.InboundOrderResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&duplicate))
+	assert.True(t, duplicate.Duplicate)
+	assert.Equal(t, created.SaleID, duplicate.SaleID, "an order is only converted once")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "CREATE_SALE_FROM_INTEGRATION", logs[0].Action)
+	assert.Equal(t, "integration:"+integration.ID, logs[0].User)
+}
+
+func TestReceiveInboundRejectsUnsignedAndReplayed(t *testing.T) {
+	app, store, integration, cab, _ := setupIntegrationTestApp(t)
+	order := testInboundOrder(cab.ID)
+
+	resp := signedRequest(t, app, integration.ID, "wrong-secret", "nonce-1", time.Now(), order)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-1", time.Now().Add(-time.Hour), order)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "stale timestamps are rejected")
+	resp = signedRequest(t, app, "unknown", integration.Secret, "nonce-1", time.Now(), order)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-1", time.Now(), order)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "rejected requests do not use up their nonce")
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-1", time.Now(), order)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	require.NoError(t, store.Integrations.Deactivate(integration.ID))
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-2", time.Now(), order)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestReceiveInboundValidatesOrder(t *testing.T) {
+	app, store, integration, cab, _ := setupIntegrationTestApp(t)
+
+	quotation := testInboundOrder(cab.ID)
+	quotation.Type = "quotation"
+	resp := signedRequest(t, app, integration.ID, integration.Secret, "nonce-1", time.Now(), quotation)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	noItems := testInboundOrder(cab.ID)
+	noItems.Items = nil
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-2", time.Now(), noItems)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	unknownItem := testInboundOrder(cab.ID + 100)
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-3", time.Now(), unknownItem)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	tooMany := testInboundOrder(cab.ID)
+	tooMany.Items[0].Quantity = 4
+	resp = signedRequest(t, app, integration.ID, integration.Secret, "nonce-4", time.Now(), tooMany)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "only three cabs are in stock")
+	stocked, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stocked.Quantity)
+
+	sales, err := store.Sales.GetAll(context.Background(), models.SalesFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, sales)
+}
+
+func TestIntegrationAdminRoutes(t *testing.T) {
+	app, store, integration, _, jwtSecret := setupIntegrationTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, integration.SoldBy, RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/admin/integrations", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/integrations", IntegrationRequest{Name: "Shop", SoldBy: "missing"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/integrations", IntegrationRequest{Name: "Shop"})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.IntegrationSecretResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.NotEmpty(t, created.Secret)
+	assert.Equal(t, integration.SoldBy, created.Integration.SoldBy, "sales default to the admin who registered it")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/integrations/"+created.Integration.ID+"/secret", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rotated api.IntegrationSecretResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rotated))
+	assert.NotEqual(t, created.Secret, rotated.Secret)
+
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/admin/integrations/missing", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/admin/integrations/"+created.Integration.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/integrations", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.IntegrationListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Integrations, 2)
+	assert.Empty(t, list.Integrations[0].Secret, "secrets are never listed")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	assert.Len(t, logs, 3)
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IntegrityHandler checks a tenant's data for records that are inconsistent with each
+// other, such as left behind by a failed write or edited directly in the database.
+// Only orphaned sale items are repaired: the other problems need someone to decide
+// which side is right, as receipts or accounting postings may already show a total.
+type IntegrityHandler struct {
+	Repo      repositories.IntegrityRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewIntegrityHandler creates a new IntegrityHandler instance
+func NewIntegrityHandler(repo repositories.IntegrityRepository, jwtSecret []byte) *IntegrityHandler {
+	return &IntegrityHandler{Repo: repo, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes of the integrity check and of
+// the negative stock repair
+func (h *IntegrityHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/admin/integrity-check", mw.Auth, mw.Admin, h.RunIntegrityCheck)         // POST /api/admin/integrity-check
+	r.Get("/admin/negative-stock", mw.Auth, mw.Admin, h.GetNegativeStock)            // GET /api/admin/negative-stock
+	r.Post("/admin/negative-stock/repair", mw.Auth, mw.Admin, h.RepairNegativeStock) // POST /api/admin/negative-stock/repair
+}
+
+// RunIntegrityCheck handles running the data integrity check
+// @Summary Check data integrity
+// @Description Looks for sale items whose sale is gone, sales whose total is not the sum of their items, negative stock, and sales whose customer is gone or deleted. With fix=true the orphaned sale items are deleted; the other problems are only reported. Admin only.
+// @Tags Integrity
+// @Produce json
+// @Security ApiKeyAuth
+// @Param fix query bool false "Delete orphaned sale items"
+// @Success 200 {object} api.IntegrityCheckResponse "Integrity report"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Admin access required"
+// @Failure 500 {object} api.ErrorResponse "Failed to check data integrity"
+// @Router /admin/integrity-check [post]
+func (h *IntegrityHandler) RunIntegrityCheck(c *fiber.Ctx) error {
+	fix := c.QueryBool("fix")
+	report, err := h.Check(fix)
+	if err != nil {
+		log.Printf("Error checking data integrity: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to check data integrity", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Fixed {
+			h.Audit.RecordAction(c, "DELETE_ORPHAN_SALE_ITEM", AuditEntitySaleItem, issue.EntityID, issue.Details)
+		}
+	}
+
+	message := fmt.Sprintf("Found %d problems", len(report.Issues))
+	if fix {
+		message += fmt.Sprintf(", fixed %d", report.Fixed)
+	}
+	return c.Status(fiber.StatusOK).JSON(api.IntegrityCheckResponse{Message: message, Report: *report})
+}
+
+// Check runs every integrity check of the tenant. With fix, orphaned sale items are
+// deleted and marked fixed in the report.
+func (h *IntegrityHandler) Check(fix bool) (*models.IntegrityReport, error) {
+	report := &models.IntegrityReport{
+		CheckedAt: h.now(),
+		Counts: map[string]int{
+			models.IntegrityOrphanSaleItem:   0,
+			models.IntegrityTotalMismatch:    0,
+			models.IntegrityNegativeQuantity: 0,
+			models.IntegrityMissingCustomer:  0,
+		},
+		Issues: []models.IntegrityIssue{},
+	}
+	add := func(issue models.IntegrityIssue) {
+		report.Counts[issue.Kind]++
+		report.Issues = append(report.Issues, issue)
+	}
+
+	orphans, err := h.Repo.OrphanSaleItems()
+	if err != nil {
+		return nil, err
+	}
+	orphanIDs := make([]string, 0, len(orphans))
+	for _, item := range orphans {
+		orphanIDs = append(orphanIDs, item.ID)
+		add(models.IntegrityIssue{
+			Kind:       models.IntegrityOrphanSaleItem,
+			EntityType: AuditEntitySaleItem,
+			EntityID:   item.ID,
+			Details:    fmt.Sprintf("Item of sale %s, which does not exist: %d %s for %.2f", item.SaleID, item.Quantity, item.ItemType(), item.Subtotal),
+			Fixable:    true,
+		})
+	}
+	if fix && len(orphanIDs) > 0 {
+		if err := h.fixOrphans(report, orphanIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	mismatches, err := h.Repo.SaleTotalMismatches()
+	if err != nil {
+		return nil, err
+	}
+	for _, sale := range mismatches {
+		add(models.IntegrityIssue{
+			Kind:       models.IntegrityTotalMismatch,
+			EntityType: AuditEntitySale,
+			EntityID:   sale.SaleID,
+			Details:    fmt.Sprintf("Total is %.2f but its items add up to %.2f", sale.TotalPrice, sale.ItemsTotal),
+		})
+	}
+
+	stock, err := h.Repo.NegativeStock()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range stock {
+		add(models.IntegrityIssue{
+			Kind:       models.IntegrityNegativeQuantity,
+			EntityType: item.ItemType,
+			EntityID:   fmt.Sprint(item.ItemID),
+			Details:    fmt.Sprintf("%s has a quantity of %d", item.Name, item.Quantity),
+		})
+	}
+
+	refs, err := h.Repo.SalesWithMissingCustomers()
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		details := fmt.Sprintf("Customer %s does not exist", ref.CustomerID)
+		if ref.Deleted {
+			details = fmt.Sprintf("Customer %s was deleted and can be restored from the trash", ref.CustomerID)
+		}
+		add(models.IntegrityIssue{
+			Kind:       models.IntegrityMissingCustomer,
+			EntityType: AuditEntitySale,
+			EntityID:   ref.SaleID,
+			Details:    details,
+		})
+	}
+	return report, nil
+}
+
+// fixOrphans deletes the orphaned sale items and marks the ones that are no longer
+// orphaned as fixed. Items whose sale appeared in the meantime are kept.
+func (h *IntegrityHandler) fixOrphans(report *models.IntegrityReport, ids []string) error {
+	if _, err := h.Repo.DeleteOrphanSaleItems(ids); err != nil {
+		return err
+	}
+	remaining, err := h.Repo.OrphanSaleItems()
+	if err != nil {
+		return err
+	}
+	left := make(map[string]bool, len(remaining))
+	for _, item := range remaining {
+		left[item.ID] = true
+	}
+	for i := range report.Issues {
+		issue := &report.Issues[i]
+		if issue.Kind == models.IntegrityOrphanSaleItem && !left[issue.EntityID] {
+			issue.Fixed = true
+			report.Fixed++
+		}
+	}
+	return nil
+}
+
+// GetNegativeStock handles listing the items with negative stock
+// @Summary List negative stock
+// @Description Lists the cabs, accessories and materials whose quantity is below zero, including deleted accessories and materials. Such rows predate the checks that now refuse them. Admin only.
+// @Tags Integrity
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.NegativeStockResponse "Items with negative stock"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Admin access required"
+// @Failure 500 {object} api.ErrorResponse "Failed to list negative stock"
+// @Router /admin/negative-stock [get]
+func (h *IntegrityHandler) GetNegativeStock(c *fiber.Ctx) error {
+	stock, err := h.Repo.NegativeStock()
+	if err != nil {
+		log.Printf("Error listing negative stock: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to list negative stock", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.NegativeStockResponse{Items: stock, Count: len(stock)})
+}
+
+// RepairNegativeStock handles zeroing negative stock
+// @Summary Repair negative stock
+// @Description Sets every quantity below zero to zero and records each correction in the activity log. Count the items afterwards to set their real quantity. Admin only.
+// @Tags Integrity
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.NegativeStockRepairResponse "Items whose quantity was set to zero"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Admin access required"
+// @Failure 500 {object} api.ErrorResponse "Failed to list negative stock"
+// @Router /admin/negative-stock/repair [post]
+func (h *IntegrityHandler) RepairNegativeStock(c *fiber.Ctx) error {
+	stock, err := h.Repo.NegativeStock()
+	if err != nil {
+		log.Printf("Error listing negative stock: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to list negative stock", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	response := api.NegativeStockRepairResponse{Repaired: []models.NegativeStock{}}
+	for _, item := range stock {
+		if err := h.Repo.ZeroNegativeStock(item.ItemType, item.ItemID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue // Corrected by someone else in the meantime
+			}
+			log.Printf("Error zeroing the quantity of %s %d: %v", item.ItemType, item.ItemID, err)
+			response.Failed++
+			continue
+		}
+		response.Repaired = append(response.Repaired, item)
+		h.Audit.RecordAction(c, "REPAIR_NEGATIVE_STOCK", item.ItemType, fmt.Sprint(item.ItemID),
+			fmt.Sprintf("Corrected the quantity of %s from %d to 0", item.Name, item.Quantity))
+	}
+
+	response.Message = fmt.Sprintf("Set %d quantities to zero", len(response.Repaired))
+	if response.Failed > 0 {
+		response.Message += fmt.Sprintf(", %d failed", response.Failed)
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// legacyStock adds rows with negative stock, which the repositories no longer let
+// anyone write, to an in-memory integrity repository
+type legacyStock struct {
+	repositories.IntegrityRepository
+	stock []models.NegativeStock
+}
+
+func (r *legacyStock) NegativeStock() ([]models.NegativeStock, error) {
+	return append([]models.NegativeStock{}, r.stock...), nil
+}
+
+func (r *legacyStock) ZeroNegativeStock(itemType string, itemID int) error {
+	for i, item := range r.stock {
+		if item.ItemType == itemType && item.ItemID == itemID {
+			r.stock = append(r.stock[:i], r.stock[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %d has no negative quantity: %w", itemType, itemID, sql.ErrNoRows)
+}
+
+// setupIntegrityTestApp registers the integrity routes on an in-memory store holding
+// an orphaned sale item, a sale whose total is off and a material below zero
+func setupIntegrityTestApp(t *testing.T) (*fiber.App, *memory.Store, *legacyStock, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	legacy := &legacyStock{IntegrityRepository: store.Integrity, stock: []models.NegativeStock{
+		{ItemType: models.InventoryMaterial, ItemID: 7, Name: "Paint", Quantity: -2},
+	}}
+	integrity := NewIntegrityHandler(legacy, jwtSecret)
+	integrity.Audit = NewChangeRecorder(store.Logs)
+
+	saleID, err := store.Sales.Create(context.Background(), &models.Sale{TotalPrice: 500})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: saleID, Item: models.IntRef(models.RefCab, 1), Quantity: 1, UnitPrice: 400, Subtotal: 400})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: "sale-gone", Item: models.IntRef(models.RefCab, 1), Quantity: 1, Subtotal: 100})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	integrity.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, legacy, jwtSecret
+}
+
+func TestIntegrityCheckReports(t *testing.T) {
+	app, store, _, jwtSecret := setupIntegrityTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/admin/integrity-check", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/integrity-check", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result api.IntegrityCheckResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, map[string]int{
+		models.IntegrityOrphanSaleItem:   1,
+		models.IntegrityTotalMismatch:    1,
+		models.IntegrityNegativeQuantity: 1,
+		models.IntegrityMissingCustomer:  0,
+	}, result.Report.Counts)
+	require.Len(t, result.Report.Issues, 3)
+	assert.True(t, result.Report.Issues[0].Fixable)
+	assert.False(t, result.Report.Issues[0].Fixed, "nothing is fixed unless asked")
+	assert.False(t, result.Report.Issues[1].Fixable, "sale totals are only reported")
+	assert.Equal(t, "Total is 500.00 but its items add up to 400.00", result.Report.Issues[1].Details)
+
+	orphans, err := store.Integrity.OrphanSaleItems()
+	require.NoError(t, err)
+	assert.Len(t, orphans, 1)
+}
+
+func TestIntegrityCheckFixesOrphanedSaleItems(t *testing.T) {
+	app, store, legacy, jwtSecret := setupIntegrityTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/integrity-check?fix=true", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var result api.IntegrityCheckResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "Found 3 problems, fixed 1", result.Message)
+	assert.Equal(t, 1, result.Report.Fixed)
+	assert.True(t, result.Report.Issues[0].Fixed)
+
+	orphans, err := store.Integrity.OrphanSaleItems()
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+	assert.Len(t, legacy.stock, 1, "negative stock is left alone")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "DELETE_ORPHAN_SALE_ITEM", logs[0].Action)
+	assert.Equal(t, AuditEntitySaleItem, logs[0].EntityType)
+}
+
+func TestRepairNegativeStock(t *testing.T) {
+	app, store, legacy, jwtSecret := setupIntegrityTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/admin/negative-stock/repair", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/negative-stock", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.NegativeStockResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 1, list.Count)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/negative-stock/repair", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var repaired api.NegativeStockRepairResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&repaired))
+	assert.Equal(t, "Set 1 quantities to zero", repaired.Message)
+	require.Len(t, repaired.Repaired, 1)
+	assert.Equal(t, -2, repaired.Repaired[0].Quantity, "reported with the quantity it had")
+	assert.Empty(t, legacy.stock)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "REPAIR_NEGATIVE_STOCK", logs[0].Action)
+	assert.Equal(t, AuditEntityMaterial, logs[0].EntityType)
+	assert.Equal(t, "7", logs[0].EntityID)
+	assert.Equal(t, "Corrected the quantity of Paint from -2 to 0", logs[0].Details)
+}
+
+func TestNegativeQuantityIsConflict(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	materials := NewMaterialHandlers(store.Materials, jwtSecret)
+	app := fiber.New()
+	materials.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Category: "Paint", Supplier: "Boysen", Quantity: 3, Status: "Available"})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/materials",
+		map[string]interface{}{"name": "Primer", "category": "Paint", "supplier": "Boysen", "quantity": -1, "status": "Available"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPut, fmt.Sprintf("/api/materials/%d", materialID),
+		map[string]interface{}{"name": "Paint", "category": "Paint", "supplier": "Boysen", "quantity": -1, "status": "Available"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	material, err := store.Materials.GetByID(context.Background(), materialID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, material.Quantity)
+}
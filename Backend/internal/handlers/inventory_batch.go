@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxBatchOperations caps the operations of one cab, accessory or material batch
+const maxBatchOperations = 500
+
+// AuditActionBatchInventory is the activity log action of a cab, accessory or
+// material batch
+const AuditActionBatchInventory = "BATCH_INVENTORY"
+
+// batchPlan is a cab, accessory or material batch being checked before it is applied:
+// the change of each operation, the item it updates or deletes as it was, and its
+// result. The batch is only applied when no operation failed its checks.
+type batchPlan[T any] struct {
+	itemType string
+	changes  []models.BatchChange[T]
+	before   []*T
+	results  []api.BatchResult
+	targets  map[int]int // Operation updating or deleting each item
+	failed   bool
+}
+
+// rejectBatchSize writes 400 when a batch has no operations or more than
+// maxBatchOperations. It reports whether it wrote the response, in which case the
+// handler returns err.
+func rejectBatchSize(c *fiber.Ctx, operations int) (bool, error) {
+	if operations > 0 && operations <= maxBatchOperations {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+		Error:      fmt.Sprintf("A batch must have between 1 and %d operations", maxBatchOperations),
+		StatusCode: fiber.StatusBadRequest,
+	})
+}
+
+func newBatchPlan[T any](itemType string, operations int) *batchPlan[T] {
+	return &batchPlan[T]{
+		itemType: itemType,
+		changes:  make([]models.BatchChange[T], operations),
+		before:   make([]*T, operations),
+		results:  make([]api.BatchResult, operations),
+		targets:  make(map[int]int),
+	}
+}
+
+// check checks the form of operation i: a create without an ID, an update or delete
+// with one, an item for creates and updates, and no item updated or deleted by an
+// earlier operation. It reports whether the operation passed, recording why not.
+func (p *batchPlan[T]) check(i int, op string, id int, hasItem bool) bool {
+	p.results[i] = api.BatchResult{Index: i, Op: op, ID: id}
+	switch {
+	case op != models.BatchCreate && op != models.BatchUpdate && op != models.BatchDelete:
+		p.reject(i, fiber.StatusUnprocessableEntity, "op must be create, update or delete")
+	case op == models.BatchCreate && id != 0:
+		p.reject(i, fiber.StatusUnprocessableEntity, "id must be left out of a create")
+	case op != models.BatchCreate && id <= 0:
+		p.reject(i, fiber.StatusUnprocessableEntity, "id is required")
+	case op != models.BatchDelete && !hasItem:
+		p.reject(i, fiber.StatusUnprocessableEntity, "item is required")
+	default:
+		if op == models.BatchCreate {
+			return true
+		}
+		if first, ok := p.targets[id]; ok {
+			p.reject(i, fiber.StatusUnprocessableEntity, fmt.Sprintf("Same %s as operation %d", p.itemType, first))
+			return false
+		}
+		p.targets[id] = i
+		return true
+	}
+	return false
+}
+
+// reject records why operation i fails
+func (p *batchPlan[T]) reject(i, status int, message string) {
+	p.results[i].Status = status
+	p.results[i].Error = message
+	p.failed = true
+}
+
+// notFound records that the item of operation i does not exist
+func (p *batchPlan[T]) notFound(i int) {
+	p.reject(i, fiber.StatusNotFound, fmt.Sprintf("No %s with ID %d", p.itemType, p.results[i].ID))
+}
+
+// add records the change of operation i once it passed its checks, with the item it
+// updates or deletes as it was
+func (p *batchPlan[T]) add(i int, op string, id int, item T, before *T) {
+	p.changes[i] = models.BatchChange[T]{Op: op, ID: id, Item: item}
+	p.before[i] = before
+}
+
+// apply applies the batch when every operation passed its checks. Otherwise, or when
+// an operation fails while applying, it writes the response of a batch that was not
+// applied: failed operations have their status and error and the others 424, and the
+// response has the status of the first that failed. It reports whether the batch was
+// applied; when not, the handler returns err.
+func (p *batchPlan[T]) apply(c *fiber.Ctx, apply func(context.Context, []models.BatchChange[T]) error) (bool, error) {
+	if !p.failed {
+		err := apply(c.Context(), p.changes)
+		if err == nil {
+			return true, nil
+		}
+
+		var batchErr *repositories.BatchError
+		switch {
+		case errors.As(err, &batchErr) && errors.Is(err, repositories.ErrNegativeStock):
+			p.reject(batchErr.Index, fiber.StatusConflict, "Quantity cannot go below zero")
+		case errors.As(err, &batchErr) && errors.Is(err, sql.ErrNoRows):
+			p.notFound(batchErr.Index)
+		default:
+			log.Printf("Error applying %s batch of %d operations: %v", p.itemType, len(p.changes), err)
+			return false, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Failed to apply %s batch", p.itemType),
+				StatusCode: fiber.StatusInternalServerError,
+			})
+		}
+	}
+
+	status := 0
+	for i := range p.results {
+		if p.results[i].Error == "" {
+			p.results[i].Status = fiber.StatusFailedDependency
+		} else if status == 0 {
+			status = p.results[i].Status
+		}
+	}
+	return false, c.Status(status).JSON(api.BatchResponse{Applied: false, Results: p.results})
+}
+
+// respond writes the response of an applied batch, with the status each operation
+// would have had on its own and the IDs of created items, and records the batch in
+// the activity log
+func (p *batchPlan[T]) respond(c *fiber.Ctx, audit *ChangeRecorder, auditEntity string, itemID func(T) int) error {
+	counts := make(map[string]int)
+	for i, change := range p.changes {
+		result := &p.results[i]
+		switch change.Op {
+		case models.BatchCreate:
+			result.Status, result.ID = fiber.StatusCreated, itemID(change.Item)
+		case models.BatchUpdate:
+			result.Status = fiber.StatusOK
+		case models.BatchDelete:
+			result.Status = fiber.StatusNoContent
+		}
+		counts[change.Op]++
+	}
+
+	audit.RecordAction(c, AuditActionBatchInventory, auditEntity, "",
+		fmt.Sprintf("Applied %s batch: %d created, %d updated, %d deleted",
+			p.itemType, counts[models.BatchCreate], counts[models.BatchUpdate], counts[models.BatchDelete]))
+	return c.Status(fiber.StatusOK).JSON(api.BatchResponse{Applied: true, Results: p.results})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBatchTestApp registers the cab, accessory and material routes on an in-memory
+// store holding one of each
+func setupBatchTestApp(t *testing.T) (*fiber.App, *memory.Store, string, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	ctx := context.Background()
+	_, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 4, Price: 500})
+	require.NoError(t, err)
+	_, err = store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Roof rack", Make: models.MakeGeneric, Quantity: 8, Price: 100, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+	_, err = store.Materials.Create(ctx, &models.Material{Name: "Paint", Category: "Finishing", Supplier: "Boysen", Quantity: 3, Status: "In Stock"})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	mw := NewRouteMiddleware(jwtSecret)
+	NewCabsHandlers(store.Cabs).Routes(apiGroup, mw)
+	NewAccessoriesHandler(store.Accessories).Routes(apiGroup, mw)
+	NewMaterialHandlers(store.Materials, jwtSecret).Routes(apiGroup, mw)
+	return app, store, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID), createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+}
+
+func batchRequest(t *testing.T, app *fiber.App, token, path string, operations interface{}) (int, api.BatchResponse) {
+	resp := authedRequest(t, app, token, http.MethodPost, path, map[string]interface{}{"operations": operations})
+	var body api.BatchResponse
+	if resp.StatusCode != http.StatusBadRequest && resp.StatusCode != http.StatusInternalServerError {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body
+}
+
+func TestBatchCabs(t *testing.T) {
+	t.Run("Applies every operation", func(t *testing.T) {
+		app, store, staffToken, _ := setupBatchTestApp(t)
+		status, body := batchRequest(t, app, staffToken, "/api/cabs/batch", []api.CabBatchOperation{
+			{Op: models.BatchCreate, Item: &models.MultiCab{Name: "Every", Make: "Suzuki", UnitColor: "Red", Status: "In Stock", Quantity: 2, Price: 600}},
+			{Op: models.BatchUpdate, ID: 1, Item: &models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 9, Price: 500}},
+		})
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, body.Applied)
+		require.Len(t, body.Results, 2)
+		assert.Equal(t, http.StatusCreated, body.Results[0].Status)
+		assert.Equal(t, 2, body.Results[0].ID)
+		assert.Equal(t, http.StatusOK, body.Results[1].Status)
+
+		cab, err := store.Cabs.GetCabByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 9, cab.Quantity)
+
+		status, body = batchRequest(t, app, staffToken, "/api/cabs/batch", []api.CabBatchOperation{{Op: models.BatchDelete, ID: 2}})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, http.StatusNoContent, body.Results[0].Status)
+		_, err = store.Cabs.GetCabByID(context.Background(), 2)
+		assert.Error(t, err)
+	})
+
+	t.Run("Applies nothing when an operation fails", func(t *testing.T) {
+		app, store, staffToken, _ := setupBatchTestApp(t)
+		status, body := batchRequest(t, app, staffToken, "/api/cabs/batch", []api.CabBatchOperation{
+			{Op: models.BatchUpdate, ID: 1, Item: &models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 9, Price: 500}},
+			{Op: models.BatchDelete, ID: 42},
+			{Op: models.BatchCreate, Item: &models.MultiCab{Name: "Every"}},
+		})
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.False(t, body.Applied)
+		require.Len(t, body.Results, 3)
+		assert.Equal(t, http.StatusFailedDependency, body.Results[0].Status)
+		assert.Equal(t, http.StatusNotFound, body.Results[1].Status)
+		assert.Equal(t, http.StatusUnprocessableEntity, body.Results[2].Status)
+		assert.NotEmpty(t, body.Results[2].Error)
+
+		cab, err := store.Cabs.GetCabByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, 4, cab.Quantity)
+	})
+
+	t.Run("Price guard needs prices.override", func(t *testing.T) {
+		app, _, staffToken, adminToken := setupBatchTestApp(t)
+		guarded := func() []api.CabBatchOperation {
+			minPrice := 400.0
+			return []api.CabBatchOperation{{Op: models.BatchUpdate, ID: 1, Item: &models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 4, Price: 500, MinPrice: &minPrice}}}
+		}
+		status, body := batchRequest(t, app, staffToken, "/api/cabs/batch", guarded())
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.False(t, body.Applied)
+
+		status, _ = batchRequest(t, app, adminToken, "/api/cabs/batch", guarded())
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	for name, operations := range map[string]interface{}{"No operations": []api.CabBatchOperation{}, "Too many operations": make([]api.CabBatchOperation, maxBatchOperations+1)} {
+		t.Run(name, func(t *testing.T) {
+			app, _, staffToken, _ := setupBatchTestApp(t)
+			status, _ := batchRequest(t, app, staffToken, "/api/cabs/batch", operations)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	}
+}
+
+func TestBatchAccessories(t *testing.T) {
+	app, store, staffToken, _ := setupBatchTestApp(t)
+	quantity, color := 1, models.ColorBlack
+
+	t.Run("Updates only the fields sent", func(t *testing.T) {
+		status, body := batchRequest(t, app, staffToken, "/api/accessories/batch", []api.AccessoryBatchOperation{
+			{Op: models.BatchUpdate, ID: 1, Item: &models.UpdateAccessoryInput{Quantity: &quantity}},
+		})
+		require.Equal(t, http.StatusOK, status)
+		assert.True(t, body.Applied)
+
+		accessory, err := store.Accessories.GetByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Roof rack", accessory.Name)
+		assert.Equal(t, 1, accessory.Quantity)
+		assert.Equal(t, models.StatusLowStock, accessory.Status)
+	})
+
+	t.Run("Creates need a name, make and color", func(t *testing.T) {
+		status, body := batchRequest(t, app, staffToken, "/api/accessories/batch", []api.AccessoryBatchOperation{
+			{Op: models.BatchCreate, Item: &models.UpdateAccessoryInput{Quantity: &quantity, UnitColor: &color}},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.False(t, body.Applied)
+	})
+
+	t.Run("Needs a staff token", func(t *testing.T) {
+		resp := authedRequest(t, app, "", http.MethodPost, "/api/accessories/batch", map[string]interface{}{"operations": []api.AccessoryBatchOperation{{Op: models.BatchDelete, ID: 1}}})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestBatchMaterials(t *testing.T) {
+	app, store, staffToken, _ := setupBatchTestApp(t)
+
+	t.Run("Each item changed once", func(t *testing.T) {
+		status, body := batchRequest(t, app, staffToken, "/api/materials/batch", []api.MaterialBatchOperation{
+			{Op: models.BatchUpdate, ID: 1, Item: &models.Material{Name: "Paint", Category: "Finishing", Supplier: "Boysen", Quantity: 5, Status: "In Stock"}},
+			{Op: models.BatchDelete, ID: 1},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, status)
+		assert.Equal(t, "Same material as operation 0", body.Results[1].Error)
+	})
+
+	t.Run("Negative quantity", func(t *testing.T) {
+		status, body := batchRequest(t, app, staffToken, "/api/materials/batch", []api.MaterialBatchOperation{
+			{Op: models.BatchCreate, Item: &models.Material{Name: "Primer", Category: "Finishing", Supplier: "Boysen", Quantity: -1, Status: "In Stock"}},
+		})
+		assert.Equal(t, http.StatusConflict, status)
+		assert.False(t, body.Applied)
+	})
+
+	t.Run("Creates and deletes", func(t *testing.T) {
+		status, body := batchRequest(t, app, staffToken, "/api/materials/batch", []api.MaterialBatchOperation{
+			{Op: models.BatchCreate, Item: &models.Material{Name: "Primer", Category: "Finishing", Supplier: "Boysen", Quantity: 6, Status: "In Stock"}},
+			{Op: models.BatchDelete, ID: 1},
+		})
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 2, body.Results[0].ID)
+
+		material, err := store.Materials.GetByID(context.Background(), 2)
+		require.NoError(t, err)
+		require.NotNil(t, material)
+		assert.Equal(t, "Primer", material.Name)
+		material, err = store.Materials.GetByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Nil(t, material)
+	})
+}
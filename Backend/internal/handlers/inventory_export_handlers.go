@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// stockFlushEvery is how many rows of a stock export are written between flushes to
+// the client
+const stockFlushEvery = 200
+
+// InventoryExportHandler streams the current stock of the inventory as CSV
+type InventoryExportHandler struct {
+	Repo      repositories.StockExportRepository
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewInventoryExportHandler creates a new inventory export handler
+func NewInventoryExportHandler(repo repositories.StockExportRepository, jwtSecret []byte) *InventoryExportHandler {
+	return &InventoryExportHandler{Repo: repo, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the inventory export route
+func (h *InventoryExportHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/export/inventory", mw.Auth, mw.Staff, h.ExportStock) // GET /api/export/inventory?type=cabs
+}
+
+// ExportStock handles streaming the stock of an inventory table
+// @Summary Export inventory stock
+// @Description Streams the current stock of cabs, materials or accessories as CSV, with the name, make, quantity, price and status of each item ordered by ID. Materials have no make or price. Rows are sent as they are read, so a large inventory starts downloading at once; should reading fail partway, the file ends early and the error is logged.
+// @Tags Exports
+// @Produce text/csv
+// @Security ApiKeyAuth
+// @Param type query string true "cabs, materials or accessories"
+// @Success 200 {file} binary "CSV file"
+// @Failure 400 {object} api.ErrorResponse "Invalid inventory type"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Forbidden"
+// @Router /export/inventory [get]
+func (h *InventoryExportHandler) ExportStock(c *fiber.Ctx) error {
+	itemType := c.Query("type")
+	if itemType != models.ExportCabs && itemType != models.ExportMaterials && itemType != models.ExportAccessories {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "type must be cabs, materials or accessories", StatusCode: fiber.StatusBadRequest})
+	}
+
+	tenantID := tenantIDFromCtx(c)
+	fileName := fmt.Sprintf("%s-stock-%s.csv", itemType, h.now().Format(saleDateLayout))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, services.ContentDisposition(fileName))
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	c.Set("X-Accel-Buffering", "no") // Disable response buffering in nginx
+
+	repo := h.Repo
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		cw := csv.NewWriter(w)
+		written := 0
+		err := cw.Write(services.StockExportHeader)
+		if err == nil {
+			err = repo.EachStock(itemType, func(row models.StockRow) error {
+				if err := cw.Write(services.StockExportRow(row)); err != nil {
+					return err
+				}
+				written++
+				if written%stockFlushEvery == 0 {
+					// A failed flush means the client went away
+					cw.Flush()
+					if err := cw.Error(); err != nil {
+						return err
+					}
+					return w.Flush()
+				}
+				return nil
+			})
+		}
+		cw.Flush()
+		if err == nil {
+			err = cw.Error()
+		}
+		if err != nil {
+			log.Printf("Error streaming %s stock of tenant %s after %d rows: %v", itemType, tenantID, written, err)
+		}
+	})
+	return nil
+}
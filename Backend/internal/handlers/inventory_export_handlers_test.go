@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportInventoryStock(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	_, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry, Mini Truck", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 3, Price: 250000})
+	require.NoError(t, err)
+	_, err = store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Scrum", Make: "Mazda", UnitColor: "Blue", Status: "Out of Stock", Quantity: 0, Price: 180000.5})
+	require.NoError(t, err)
+	_, err = store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Category: "Finishing", Supplier: "Boysen", Quantity: 12, Status: "In Stock"})
+	require.NoError(t, err)
+	_, err = store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 6, Price: 1500, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+
+	h := NewInventoryExportHandler(store.Stock, jwtSecret)
+	h.now = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/export/inventory?type=cabs", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentType), "text/csv")
+	assert.Contains(t, resp.Header.Get(fiber.HeaderContentDisposition), "cabs-stock-2026-10-16.csv")
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "name,make,quantity,price,status\n"+
+		"\"Carry, Mini Truck\",Suzuki,3,250000.00,In Stock\n"+
+		"Scrum,Mazda,0,180000.50,Out of Stock\n", string(body))
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/export/inventory?type=materials", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "name,make,quantity,price,status\nPaint,,12,,In Stock\n", string(body), "materials have no make or price")
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/export/inventory?type=accessories", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ = io.ReadAll(resp.Body)
+	assert.True(t, strings.HasSuffix(string(body), "Roof Rack,OEM,6,1500.00,Available\n"), string(body))
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/export/inventory?type=customers", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodGet, "/api/export/inventory?type=cabs", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	viewerToken := createTenantTestToken(jwtSecret, "viewer-1", "viewer", models.DefaultTenantID)
+	resp = authedRequest(t, app, viewerToken, http.MethodGet, "/api/export/inventory?type=cabs", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
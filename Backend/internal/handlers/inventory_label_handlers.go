@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxLabelsPerRequest keeps a print job to ten Letter sheets
+const maxLabelsPerRequest = 300
+
+// maxLabelLocationLength keeps locations short enough to print
+const maxLabelLocationLength = 60
+
+// InventoryLabelHandler prints labels of cabs, accessories and materials
+type InventoryLabelHandler struct {
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	jwtSecret   []byte
+}
+
+// NewInventoryLabelHandler creates a new InventoryLabelHandler instance
+func NewInventoryLabelHandler(cabs repositories.CabsRepository, accessories repositories.AccessoryRepository,
+	materials repositories.MaterialRepository, jwtSecret []byte) *InventoryLabelHandler {
+	return &InventoryLabelHandler{Cabs: cabs, Accessories: accessories, Materials: materials, jwtSecret: jwtSecret}
+}
+
+// Routes registers the inventory label routes
+func (h *InventoryLabelHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/inventory/labels", mw.Auth, h.PrintInventoryLabels) // POST /api/inventory/labels
+}
+
+// loadLabel returns the label of an inventory item, or an error wrapping
+// sql.ErrNoRows when it does not exist
+func (h *InventoryLabelHandler) loadLabel(c *fiber.Ctx, item api.InventoryLabelItem) (services.InventoryLabel, error) {
+	label := services.InventoryLabel{Barcode: services.LabelBarcode(item.Type, item.ID), Location: item.Location}
+	switch item.Type {
+	case models.InventoryCab:
+		cab, err := h.Cabs.GetCabByID(c.Context(), item.ID)
+		if err != nil {
+			return label, notFoundAsNoRows(err)
+		}
+		label.Name, label.Price = cab.Name, &cab.Price
+	case models.InventoryAccessory:
+		accessory, err := h.Accessories.GetByID(c.Context(), item.ID)
+		if err != nil {
+			return label, notFoundAsNoRows(err)
+		}
+		label.Name, label.Price = accessory.Name, &accessory.Price
+	case models.InventoryMaterial:
+		material, err := h.Materials.GetByID(c.Context(), item.ID)
+		if err != nil {
+			return label, notFoundAsNoRows(err)
+		}
+		if material == nil {
+			return label, sql.ErrNoRows
+		}
+		label.Name = material.Name
+	default:
+		return label, fmt.Errorf("unknown item type %q", item.Type)
+	}
+	return label, nil
+}
+
+// PrintInventoryLabels handles printing labels
+// @Summary Print inventory labels
+// @Description Returns a PDF of labels for the given cabs, accessories and materials, laid out for Letter (Avery 5160, 30 per sheet) or A4 (Avery L7160, 21 per sheet) label sheets. Each label has the item's name, price, location and a Code 39 barcode of its type and ID, e.g. CAB-12. Up to 300 labels per request.
+// @Tags Inventory
+// @Accept json
+// @Produce application/pdf
+// @Security ApiKeyAuth
+// @Param request body api.InventoryLabelsRequest true "Items to print labels of"
+// @Success 200 {file} binary "PDF of the labels"
+// @Failure 400 {object} api.ErrorResponse "Invalid items, copies, location or sheet"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Item not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to print labels"
+// @Router /inventory/labels [post]
+func (h *InventoryLabelHandler) PrintInventoryLabels(c *fiber.Ctx) error {
+	var input api.InventoryLabelsRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	if input.Sheet == "" {
+		input.Sheet = services.LabelSheetLetter
+	}
+	sheet, ok := services.LabelSheets[input.Sheet]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "sheet must be letter or a4", StatusCode: fiber.StatusBadRequest})
+	}
+	if len(input.Items) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "items must list at least one item", StatusCode: fiber.StatusBadRequest})
+	}
+
+	total := 0
+	for i, item := range input.Items {
+		switch item.Type {
+		case models.InventoryCab, models.InventoryAccessory, models.InventoryMaterial:
+		default:
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "type must be cab, accessory or material", StatusCode: fiber.StatusBadRequest})
+		}
+		if item.Copies == 0 {
+			input.Items[i].Copies = 1
+		}
+		if item.Copies < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "copies cannot be negative", StatusCode: fiber.StatusBadRequest})
+		}
+		input.Items[i].Location = strings.TrimSpace(item.Location)
+		if input.Items[i].Location == "" {
+			input.Items[i].Location = strings.TrimSpace(input.Location)
+		}
+		if len([]rune(input.Items[i].Location)) > maxLabelLocationLength {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("location cannot be longer than %d characters", maxLabelLocationLength),
+				StatusCode: fiber.StatusBadRequest,
+			})
+		}
+		total += input.Items[i].Copies
+	}
+	if total > maxLabelsPerRequest {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("at most %d labels can be printed at once", maxLabelsPerRequest),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	labels := make([]services.InventoryLabel, 0, total)
+	for _, item := range input.Items {
+		label, err := h.loadLabel(c, item)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
+					Error:      fmt.Sprintf("%s %d not found", item.Type, item.ID),
+					StatusCode: fiber.StatusNotFound,
+				})
+			}
+			log.Printf("Error fetching %s %d for labels: %v", item.Type, item.ID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to print labels", StatusCode: fiber.StatusInternalServerError})
+		}
+		for copy := 0; copy < item.Copies; copy++ {
+			labels = append(labels, label)
+		}
+	}
+
+	pdf, err := services.RenderLabelsPDF(sheet, labels)
+	if err != nil {
+		log.Printf("Error rendering inventory labels: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to print labels", StatusCode: fiber.StatusInternalServerError})
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="inventory-labels.pdf"`)
+	return c.Status(fiber.StatusOK).Send(pdf)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintInventoryLabels(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	handler := NewInventoryLabelHandler(store.Cabs, store.Accessories, store.Materials, jwtSecret)
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Blue", Status: "Available", Quantity: 3, Price: 700000})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Roof Rack", Make: "OEM", UnitColor: "Black", Quantity: 5, Price: 4500})
+	require.NoError(t, err)
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Plywood", Category: "Wood", Supplier: "Acme", Quantity: 10, Status: "In Stock"})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/inventory/labels", map[string]interface{}{
+		"location": "Yard A",
+		"items": []map[string]interface{}{
+			{"type": "cab", "id": cab.ID, "location": "Yard B"},
+			{"type": "accessory", "id": accessoryID, "copies": 5},
+			{"type": "material", "id": materialID},
+		},
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/pdf", resp.Header.Get(fiber.HeaderContentType))
+	pdf, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.Equal(t, 1, bytes.Count(pdf, []byte("(Yard B) Tj")))
+	assert.Equal(t, 6, bytes.Count(pdf, []byte("(Yard A) Tj")), "items without a location get the default")
+	assert.Equal(t, 5, bytes.Count(pdf, []byte("(ACC-1) Tj")))
+
+	tests := []struct {
+		name   string
+		body   map[string]interface{}
+		status int
+	}{
+		{"no items", map[string]interface{}{"items": []interface{}{}}, http.StatusBadRequest},
+		{"unknown sheet", map[string]interface{}{"sheet": "legal", "items": []map[string]interface{}{{"type": "cab", "id": cab.ID}}}, http.StatusBadRequest},
+		{"unknown type", map[string]interface{}{"items": []map[string]interface{}{{"type": "sale", "id": 1}}}, http.StatusBadRequest},
+		{"too many labels", map[string]interface{}{"items": []map[string]interface{}{{"type": "cab", "id": cab.ID, "copies": 301}}}, http.StatusBadRequest},
+		{"missing item", map[string]interface{}{"items": []map[string]interface{}{{"type": "material", "id": 999}}}, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := authedRequest(t, app, token, http.MethodPost, "/api/inventory/labels", tt.body)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/inventory/labels", map[string]interface{}{"items": []map[string]interface{}{{"type": "cab", "id": cab.ID}}})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// snapshotMonthLayout is the format of snapshot months
+const snapshotMonthLayout = "2006-01"
+
+// InventorySnapshotHandler serves the end-of-month inventory snapshots, and takes
+// them for the month-end job
+type InventorySnapshotHandler struct {
+	Snapshots   repositories.InventorySnapshotRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	now         func() time.Time
+	jwtSecret   []byte
+}
+
+// NewInventorySnapshotHandler creates a new InventorySnapshotHandler instance
+func NewInventorySnapshotHandler(snapshots repositories.InventorySnapshotRepository, cabs repositories.CabsRepository,
+	accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, jwtSecret []byte) *InventorySnapshotHandler {
+	return &InventorySnapshotHandler{Snapshots: snapshots, Cabs: cabs, Accessories: accessories, Materials: materials, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the inventory snapshot routes
+func (h *InventorySnapshotHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/reports/inventory-snapshot", mw.Auth, mw.Admin, h.GetInventorySnapshot) // GET /api/reports/inventory-snapshot
+}
+
+// PreviousMonth is the month before the one containing now, formatted YYYY-MM
+func PreviousMonth(now time.Time) string {
+	return now.AddDate(0, 0, -now.Day()).Format(snapshotMonthLayout)
+}
+
+// TakeSnapshot records the stock on hand now as the snapshot of month, unless one
+// was taken already. It reports whether a snapshot was taken.
+func (h *InventorySnapshotHandler) TakeSnapshot(ctx context.Context, month string) (bool, error) {
+	if _, err := h.Snapshots.Get(month); err == nil {
+		return false, nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	snapshot := &models.InventorySnapshot{Month: month, TakenAt: h.now(), Items: []models.InventorySnapshotItem{}}
+	add := func(itemType string, id int, name string, quantity int, price float64) {
+		if quantity == 0 {
+			return
+		}
+		snapshot.Items = append(snapshot.Items, models.InventorySnapshotItem{
+			ItemType:  itemType,
+			ItemID:    id,
+			Name:      name,
+			Quantity:  quantity,
+			UnitPrice: price,
+			Value:     float64(toCentavos(price)*int64(quantity)) / 100,
+		})
+	}
+
+	cabs, err := h.Cabs.GetCabs(ctx, models.CabFilter{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get cabs: %w", err)
+	}
+	for _, cab := range cabs {
+		add(models.InventoryCab, cab.ID, cab.Name, cab.Quantity, cab.Price)
+	}
+	accessories, err := h.Accessories.GetAll(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to get accessories: %w", err)
+	}
+	for _, accessory := range accessories {
+		add(models.InventoryAccessory, accessory.ID, accessory.Name, accessory.Quantity, accessory.Price)
+	}
+	materials, err := h.Materials.GetAll(ctx, models.MaterialFilter{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get materials: %w", err)
+	}
+	for _, material := range materials {
+		add(models.InventoryMaterial, material.ID, material.Name, material.Quantity, 0)
+	}
+
+	var value int64
+	for _, item := range snapshot.Items {
+		snapshot.TotalQuantity += item.Quantity
+		value += toCentavos(item.Value)
+	}
+	snapshot.TotalValue = float64(value) / 100
+
+	if err := h.Snapshots.Save(snapshot); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetInventorySnapshot handles getting an end-of-month inventory snapshot
+// @Summary End-of-month inventory snapshot (Admin)
+// @Description Returns the cabs, accessories and materials in stock at the end of a month, with their quantities and value at the price they had then, and totals by type. Snapshots are taken by a job shortly after each month ends, so months before the job ran have none. Materials have no price and are valued at zero.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param month query string false "Month, YYYY-MM (default last month)"
+// @Success 200 {object} api.InventorySnapshotReport "Inventory snapshot"
+// @Failure 400 {object} api.ErrorResponse "Invalid month"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "No snapshot for the month"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve inventory snapshot"
+// @Router /reports/inventory-snapshot [get]
+func (h *InventorySnapshotHandler) GetInventorySnapshot(c *fiber.Ctx) error {
+	month := PreviousMonth(h.now())
+	if raw := c.Query("month"); raw != "" {
+		parsed, err := time.ParseInLocation(snapshotMonthLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "month must be formatted as YYYY-MM", StatusCode: fiber.StatusBadRequest})
+		}
+		month = parsed.Format(snapshotMonthLayout)
+	}
+
+	snapshot, err := h.Snapshots.Get(month)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No inventory snapshot for " + month, StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting inventory snapshot of %s: %v", month, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve inventory snapshot", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	report := api.InventorySnapshotReport{InventorySnapshot: *snapshot, Totals: []api.InventoryTypeTotal{}}
+	for _, itemType := range []string{models.InventoryCab, models.InventoryAccessory, models.InventoryMaterial} {
+		total := api.InventoryTypeTotal{ItemType: itemType}
+		var value int64
+		for _, item := range snapshot.Items {
+			if item.ItemType == itemType {
+				total.Items++
+				total.Quantity += item.Quantity
+				value += toCentavos(item.Value)
+			}
+		}
+		total.Value = float64(value) / 100
+		report.Totals = append(report.Totals, total)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
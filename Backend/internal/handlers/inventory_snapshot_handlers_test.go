@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupInventorySnapshotTestApp registers the inventory snapshot route on an
+// in-memory store without fixtures, with the clock fixed to 1 April 2025
+func setupInventorySnapshotTestApp(t *testing.T) (*fiber.App, *memory.Store, *InventorySnapshotHandler) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewInventorySnapshotHandler(store.Snapshots, store.Cabs, store.Accessories, store.Materials, jwtSecret)
+	h.now = func() time.Time { return time.Date(2025, 4, 1, 0, 30, 0, 0, time.Local) }
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, h
+}
+
+func TestInventorySnapshot(t *testing.T) {
+	app, store, h := setupInventorySnapshotTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken([]byte("testsecret"), "staff-1", RoleStaff, models.DefaultTenantID)
+
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Scrum", Make: "Suzuki", Quantity: 3, Price: 150000.50, UnitColor: "White"})
+	require.NoError(t, err)
+	_, err = store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", Quantity: 0, Price: 90000, UnitColor: "Red"})
+	require.NoError(t, err)
+	_, err = store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side mirror", Make: "Generic", Quantity: 10, Price: 450.25, UnitColor: "Black"})
+	require.NoError(t, err)
+	_, err = store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Category: "Finishing", Supplier: "Boysen", Quantity: 7, Status: "In Stock"})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/inventory-snapshot", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "no snapshot taken yet")
+
+	taken, err := h.TakeSnapshot(context.Background(), PreviousMonth(h.now()))
+	require.NoError(t, err)
+	assert.True(t, taken)
+
+	// Stock moves after the month ended; the snapshot keeps the month-end position
+	cab.Quantity = 1
+	_, err = store.Cabs.UpdateCab(context.Background(), cab.ID, *cab)
+	require.NoError(t, err)
+	taken, err = h.TakeSnapshot(context.Background(), "2025-03")
+	require.NoError(t, err)
+	assert.False(t, taken, "a month is snapshotted once")
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/inventory-snapshot", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/inventory-snapshot?month=March", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/inventory-snapshot?month=2025-03", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.InventorySnapshotReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, "2025-03", report.Month)
+	require.Len(t, report.Items, 3, "items out of stock are left out")
+	assert.Equal(t, models.InventorySnapshotItem{ItemType: models.InventoryCab, ItemID: cab.ID, Name: "Scrum", Quantity: 3, UnitPrice: 150000.50, Value: 450001.50}, report.Items[1])
+	assert.Equal(t, 20, report.TotalQuantity)
+	assert.Equal(t, 454504.00, report.TotalValue)
+	assert.Equal(t, []api.InventoryTypeTotal{
+		{ItemType: models.InventoryCab, Items: 1, Quantity: 3, Value: 450001.50},
+		{ItemType: models.InventoryAccessory, Items: 1, Quantity: 10, Value: 4502.50},
+		{ItemType: models.InventoryMaterial, Items: 1, Quantity: 7},
+	}, report.Totals)
+}
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxItemImageSize is the largest photo that can be uploaded to a gallery
+const maxItemImageSize = 5 * 1024 * 1024
+
+// maxItemImages is the most photos a gallery holds
+const maxItemImages = 20
+
+// itemImageTypes are the content types of photos that can be uploaded
+var itemImageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true}
+
+// ItemImageHandler serves the photo galleries of cabs, accessories and materials
+type ItemImageHandler struct {
+	Repo        repositories.ItemImageRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Audit       *ChangeRecorder
+	Variants    *services.ImageVariantQueue // Makes the smaller sizes of uploaded photos; photos are served in their original size when nil
+	jwtSecret   []byte
+}
+
+// NewItemImageHandler creates a new ItemImageHandler instance. The inventory
+// repositories are used to check the item of a gallery exists.
+func NewItemImageHandler(repo repositories.ItemImageRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository,
+	materials repositories.MaterialRepository, jwtSecret []byte) *ItemImageHandler {
+	return &ItemImageHandler{Repo: repo, Cabs: cabs, Accessories: accessories, Materials: materials, jwtSecret: jwtSecret}
+}
+
+// Routes registers the photo gallery routes. Galleries and photos are
+// public like the cab and accessory listings, so pages can show them without a token.
+func (h *ItemImageHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/inventory/images/duplicates", mw.Auth, h.GetDuplicateItemImages) // GET /api/inventory/images/duplicates
+
+	gallery := r.Group("/inventory/:itemType/:itemId/images")
+	gallery.Get("/", h.GetItemImages)                                 // GET /api/inventory/:itemType/:itemId/images
+	gallery.Get("/:imageId/file", h.GetItemImageFile)                 // GET /api/inventory/:itemType/:itemId/images/:imageId/file
+	gallery.Post("/", mw.Auth, h.AddItemImage)                        // POST /api/inventory/:itemType/:itemId/images
+	gallery.Put("/order", mw.Auth, h.ReorderItemImages)               // PUT /api/inventory/:itemType/:itemId/images/order
+	gallery.Post("/:imageId/primary", mw.Auth, h.SetPrimaryItemImage) // POST /api/inventory/:itemType/:itemId/images/:imageId/primary
+	gallery.Delete("/:imageId", mw.Auth, h.DeleteItemImage)           // DELETE /api/inventory/:itemType/:itemId/images/:imageId
+}
+
+// Images returns the gallery of an item for its detail response, or nil when the
+// handler is not configured or the gallery cannot be read
+func (h *ItemImageHandler) Images(itemType string, itemID int) []models.ItemImage {
+	if h == nil {
+		return nil
+	}
+	images, err := h.Repo.GetAll(itemType, itemID)
+	if err != nil {
+		log.Printf("Error loading the gallery of %s %d: %v", itemType, itemID, err)
+		return nil
+	}
+	return withImageURLs(images)
+}
+
+// itemImageURL is where the file of a photo is served
+func itemImageURL(image models.ItemImage) string {
+	return fmt.Sprintf("/api/inventory/%s/%d/images/%s/file", image.ItemType, image.ItemID, image.ID)
+}
+
+// withImageURLs fills in the URL of each photo and of its variants
+func withImageURLs(images []models.ItemImage) []models.ItemImage {
+	for i := range images {
+		images[i].URL = itemImageURL(images[i])
+		for j := range images[i].Variants {
+			images[i].Variants[j].URL = images[i].URL + "?size=" + images[i].Variants[j].Size
+		}
+	}
+	return images
+}
+
+// galleryItem parses the item of a gallery from the path. It reports whether it
+// wrote the response, in which case the handler returns err.
+func (h *ItemImageHandler) galleryItem(c *fiber.Ctx) (string, int, bool, error) {
+	itemType := c.Params("itemType")
+	switch itemType {
+	case models.InventoryCab, models.InventoryAccessory, models.InventoryMaterial:
+	default:
+		return "", 0, true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Item type must be cab, accessory or material", StatusCode: fiber.StatusBadRequest})
+	}
+	itemID, err := c.ParamsInt("itemId")
+	if err != nil {
+		return "", 0, true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid item ID", StatusCode: fiber.StatusBadRequest})
+	}
+	return itemType, itemID, false, nil
+}
+
+// itemName returns the name of an inventory item, or an error wrapping sql.ErrNoRows
+// when it does not exist
+func (h *ItemImageHandler) itemName(c *fiber.Ctx, itemType string, itemID int) (string, error) {
+	switch itemType {
+	case models.InventoryCab:
+		cab, err := h.Cabs.GetCabByID(c.Context(), itemID)
+		if err != nil {
+			return "", notFoundAsNoRows(err)
+		}
+		return cab.Name, nil
+	case models.InventoryAccessory:
+		accessory, err := h.Accessories.GetByID(c.Context(), itemID)
+		if err != nil {
+			return "", notFoundAsNoRows(err)
+		}
+		return accessory.Name, nil
+	case models.InventoryMaterial:
+		material, err := h.Materials.GetByID(c.Context(), itemID)
+		if err != nil {
+			return "", notFoundAsNoRows(err)
+		}
+		if material == nil {
+			return "", sql.ErrNoRows
+		}
+		return material.Name, nil
+	}
+	return "", fmt.Errorf("unknown item type %q", itemType)
+}
+
+// GetItemImages handles listing the photo gallery of an item
+// @Summary List the photos of an item
+// @Description Returns the photo gallery of a cab, accessory or material in display order. Exactly one photo is primary, the one to show first.
+// @Tags Inventory
+// @Produce json
+// @Param itemType path string true "cab, accessory or material"
+// @Param itemId path int true "Item ID"
+// @Success 200 {object} api.ItemImageListResponse "Photo gallery"
+// @Failure 400 {object} api.ErrorResponse "Invalid item type or ID"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve photos"
+// @Router /inventory/{itemType}/{itemId}/images [get]
+func (h *ItemImageHandler) GetItemImages(c *fiber.Ctx) error {
+	itemType, itemID, done, err := h.galleryItem(c)
+	if done {
+		return err
+	}
+
+	images, err := h.Repo.GetAll(itemType, itemID)
+	if err != nil {
+		log.Printf("Error listing the gallery of %s %d: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve photos", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ItemImageListResponse{Images: withImageURLs(images), Count: len(images)})
+}
+
+// GetItemImageFile handles downloading a photo
+// @Summary Download a photo of an item
+// @Description Serves a photo in the size asked for: thumb (160px), small (480px), medium (1024px) or original, the longest side in pixels. The original is served when the photo is no larger than that size or its smaller copy has not been made yet.
+// @Tags Inventory
+// @Produce jpeg,png,webp
+// @Param itemType path string true "cab, accessory or material"
+// @Param itemId path int true "Item ID"
+// @Param imageId path string true "Photo ID"
+// @Param size query string false "thumb, small, medium or original (default)"
+// @Success 200 {file} binary "Photo"
+// @Failure 400 {object} api.ErrorResponse "Invalid item type, ID or size"
+// @Failure 404 {object} api.ErrorResponse "Photo not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve photo"
+// @Router /inventory/{itemType}/{itemId}/images/{imageId}/file [get]
+func (h *ItemImageHandler) GetItemImageFile(c *fiber.Ctx) error {
+	itemType, itemID, done, err := h.galleryItem(c)
+	if done {
+		return err
+	}
+	size := c.Query("size", services.ImageSizeOriginal)
+	if _, ok := services.ImageVariantSizes[size]; !ok && size != services.ImageSizeOriginal {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Size must be thumb, small, medium or original", StatusCode: fiber.StatusBadRequest})
+	}
+
+	// A photo never changes once uploaded, so browsers may keep it
+	cacheControl := "public, max-age=86400"
+	if size != services.ImageSizeOriginal {
+		variant, data, err := h.Repo.GetVariant(itemType, itemID, c.Params("imageId"), size)
+		if err == nil {
+			c.Set(fiber.HeaderContentType, variant.ContentType)
+			c.Set(fiber.HeaderCacheControl, cacheControl)
+			return c.Status(fiber.StatusOK).Send(data)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting the %s variant of photo %s: %v", size, c.Params("imageId"), err)
+		}
+		// Until its variant is made, or when it is small already, the photo is served as
+		// it is, and only briefly cached so the variant is picked up once made
+		cacheControl = "public, max-age=60"
+	}
+
+	image, data, err := h.Repo.GetFile(itemType, itemID, c.Params("imageId"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Photo not found", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error getting photo %s of %s %d: %v", c.Params("imageId"), itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve photo", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	c.Set(fiber.HeaderContentType, image.ContentType)
+	c.Set(fiber.HeaderCacheControl, cacheControl)
+	return c.Status(fiber.StatusOK).Send(data)
+}
+
+// AddItemImage handles uploading a photo to a gallery
+// @Summary Upload a photo of an item
+// @Description Adds a JPEG, PNG or WebP photo of up to 5 MB, sent in the multipart "file" field, at the end of the item's gallery. The first photo of a gallery becomes primary. A gallery holds up to 20 photos. Smaller copies of JPEG and PNG photos are made in the background and listed in the photo's variants once ready. When the same file is attached to other items, it is still added and they are returned as duplicates with a warning.
+// @Tags Inventory
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param itemType path string true "cab, accessory or material"
+// @Param itemId path int true "Item ID"
+// @Param file formData file true "JPEG, PNG or WebP photo"
+// @Success 201 {object} api.ItemImageResponse "Photo uploaded"
+// @Failure 400 {object} api.ErrorResponse "Missing, oversized or unsupported file, or the gallery is full"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Item not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to upload photo"
+// @Router /inventory/{itemType}/{itemId}/images [post]
+func (h *ItemImageHandler) AddItemImage(c *fiber.Ctx) error {
+	itemType, itemID, done, err := h.galleryItem(c)
+	if done {
+		return err
+	}
+
+	name, err := h.itemName(c, itemType, itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: fmt.Sprintf("%s %d not found", itemType, itemID), StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error fetching %s %d for a photo upload: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload photo", StatusCode: fiber.StatusInternalServerError})
+	}
+	images, err := h.Repo.GetAll(itemType, itemID)
+	if err != nil {
+		log.Printf("Error listing the gallery of %s %d: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload photo", StatusCode: fiber.StatusInternalServerError})
+	}
+	if len(images) >= maxItemImages {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("A gallery holds at most %d photos", maxItemImages),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "A file is required in the \"file\" field", StatusCode: fiber.StatusBadRequest})
+	}
+	if fileHeader.Size > maxItemImageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Photos must be at most 5 MB", StatusCode: fiber.StatusBadRequest})
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Printf("Error opening uploaded photo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload photo", StatusCode: fiber.StatusInternalServerError})
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxItemImageSize+1))
+	if err != nil {
+		log.Printf("Error reading uploaded photo: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload photo", StatusCode: fiber.StatusInternalServerError})
+	}
+	if len(data) > maxItemImageSize {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Photos must be at most 5 MB", StatusCode: fiber.StatusBadRequest})
+	}
+
+	// The declared content type is not trusted; the file is sniffed instead
+	contentType := http.DetectContentType(data)
+	if !itemImageTypes[contentType] {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Photos must be JPEG, PNG or WebP files", StatusCode: fiber.StatusBadRequest})
+	}
+
+	uploadedBy, _ := c.Locals("user_id").(string)
+	image := &models.ItemImage{
+		ItemType:    itemType,
+		ItemID:      itemID,
+		FileName:    attachmentFileName(fileHeader.Filename),
+		ContentType: contentType,
+		UploadedBy:  uploadedBy,
+		UploadedAt:  time.Now(),
+	}
+	if err := h.Repo.Add(image, data); err != nil {
+		log.Printf("Error saving photo of %s %d: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to upload photo", StatusCode: fiber.StatusInternalServerError})
+	}
+	image.URL = itemImageURL(*image)
+	// WebP photos cannot be decoded to be resized, so they are only served as uploaded
+	if contentType != "image/webp" {
+		h.Variants.Queue(h.Repo, *image, data)
+	}
+
+	h.Audit.RecordAction(c, "ADD_ITEM_IMAGE", itemType, strconv.Itoa(itemID),
+		fmt.Sprintf("Added photo %s to the gallery of %s", image.FileName, name))
+
+	response := api.ItemImageResponse{Message: "Photo uploaded", Image: image}
+	response.Duplicates = h.duplicatesOf(*image)
+	if len(response.Duplicates) > 0 {
+		response.Warning = fmt.Sprintf("The same photo is attached to %d other item(s); check it was not added to the wrong listing", countItems(response.Duplicates))
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// duplicatesOf returns the copies of a photo in the galleries of other items. A
+// failed lookup only loses the warning, so it is logged rather than returned.
+func (h *ItemImageHandler) duplicatesOf(image models.ItemImage) []models.ItemImage {
+	matches, err := h.Repo.FindByHash(image.ContentHash)
+	if err != nil {
+		log.Printf("Error looking for copies of photo %s: %v", image.ID, err)
+		return nil
+	}
+	duplicates := []models.ItemImage{}
+	for _, match := range matches {
+		if match.ItemType != image.ItemType || match.ItemID != image.ItemID {
+			duplicates = append(duplicates, match)
+		}
+	}
+	return withImageURLs(duplicates)
+}
+
+// countItems returns how many different items the photos belong to
+func countItems(images []models.ItemImage) int {
+	items := map[string]bool{}
+	for _, image := range images {
+		items[fmt.Sprintf("%s/%d", image.ItemType, image.ItemID)] = true
+	}
+	return len(items)
+}
+
+// GetDuplicateItemImages handles listing photos attached to more than one item
+// @Summary List photos attached to more than one item
+// @Description Groups the gallery photos whose files are identical but belong to different cabs, accessories or materials, oldest upload first, to catch photos copied to the wrong listing. Photos are compared by SHA-256 of their file, so a re-saved or resized copy is not matched.
+// @Tags Inventory
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.DuplicateItemImagesResponse "Photos by file"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve duplicate photos"
+// @Router /inventory/images/duplicates [get]
+func (h *ItemImageHandler) GetDuplicateItemImages(c *fiber.Ctx) error {
+	images, err := h.Repo.GetDuplicates()
+	if err != nil {
+		log.Printf("Error listing duplicate photos: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve duplicate photos", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	groups := []api.DuplicateItemImageGroup{}
+	for _, image := range withImageURLs(images) {
+		if len(groups) == 0 || groups[len(groups)-1].ContentHash != image.ContentHash {
+			groups = append(groups, api.DuplicateItemImageGroup{ContentHash: image.ContentHash})
+		}
+		last := &groups[len(groups)-1]
+		last.Images = append(last.Images, image)
+	}
+	return c.Status(fiber.StatusOK).JSON(api.DuplicateItemImagesResponse{Duplicates: groups, Count: len(groups)})
+}
+
+// ReorderItemImages handles reordering a gallery
+// @Summary Reorder the photos of an item
+// @Description Puts the photos of a gallery in the given order, which must list each of them once.
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param itemType path string true "cab, accessory or material"
+// @Param itemId path int true "Item ID"
+// @Param order body api.ItemImageOrderRequest true "Photo IDs in the new order"
+// @Success 200 {object} api.ItemImageListResponse "Reordered gallery"
+// @Failure 400 {object} api.ErrorResponse "The order does not list each photo once"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to reorder photos"
+// @Router /inventory/{itemType}/{itemId}/images/order [put]
+func (h *ItemImageHandler) ReorderItemImages(c *fiber.Ctx) error {
+	itemType, itemID, done, err := h.galleryItem(c)
+	if done {
+		return err
+	}
+
+	var input api.ItemImageOrderRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := h.Repo.Reorder(itemType, itemID, input.IDs); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "ids must list each photo of the gallery once", StatusCode: fiber.StatusBadRequest})
+		}
+		log.Printf("Error reordering the gallery of %s %d: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to reorder photos", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	images, err := h.Repo.GetAll(itemType, itemID)
+	if err != nil {
+		log.Printf("Error listing the gallery of %s %d: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to reorder photos", StatusCode: fiber.StatusInternalServerError})
+	}
+	h.Audit.RecordAction(c, "REORDER_ITEM_IMAGES", itemType, strconv.Itoa(itemID), "Reordered the photo gallery")
+	return c.Status(fiber.StatusOK).JSON(api.ItemImageListResponse{Images: withImageURLs(images), Count: len(images)})
+}
+
+// SetPrimaryItemImage handles choosing the primary photo of a gallery
+// @Summary Set the primary photo of an item
+// @Tags Inventory
+// @Produce json
+// @Security ApiKeyAuth
+// @Param itemType path string true "cab, accessory or material"
+// @Param itemId path int true "Item ID"
+// @Param imageId path string true "Photo ID"
+// @Success 200 {object} api.MessageResponse "Primary photo set"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Photo not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to set primary photo"
+// @Router /inventory/{itemType}/{itemId}/images/{imageId}/primary [post]
+func (h *ItemImageHandler) SetPrimaryItemImage(c *fiber.Ctx) error {
+	itemType, itemID, done, err := h.galleryItem(c)
+	if done {
+		return err
+	}
+
+	if err := h.Repo.SetPrimary(itemType, itemID, c.Params("imageId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Photo not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error setting the primary photo of %s %d: %v", itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to set primary photo", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "SET_PRIMARY_ITEM_IMAGE", itemType, strconv.Itoa(itemID), "Set photo "+c.Params("imageId")+" as the primary photo")
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Primary photo set"})
+}
+
+// DeleteItemImage handles removing a photo from a gallery
+// @Summary Delete a photo of an item
+// @Description Removes a photo from a gallery. When it was the primary photo, the first remaining photo becomes primary.
+// @Tags Inventory
+// @Produce json
+// @Security ApiKeyAuth
+// @Param itemType path string true "cab, accessory or material"
+// @Param itemId path int true "Item ID"
+// @Param imageId path string true "Photo ID"
+// @Success 200 {object} api.MessageResponse "Photo deleted"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Photo not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete photo"
+// @Router /inventory/{itemType}/{itemId}/images/{imageId} [delete]
+func (h *ItemImageHandler) DeleteItemImage(c *fiber.Ctx) error {
+	itemType, itemID, done, err := h.galleryItem(c)
+	if done {
+		return err
+	}
+
+	if err := h.Repo.Delete(itemType, itemID, c.Params("imageId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Photo not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error deleting photo %s of %s %d: %v", c.Params("imageId"), itemType, itemID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete photo", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_ITEM_IMAGE", itemType, strconv.Itoa(itemID), "Deleted photo "+c.Params("imageId")+" from the gallery")
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Photo deleted"})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadItemImage sends data in the multipart "file" field to the gallery at path
+func uploadItemImage(t *testing.T, app *fiber.App, token, path, fileName string, data []byte) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestItemImageGallery(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	gallery := NewItemImageHandler(store.Images, store.Cabs, store.Accessories, store.Materials, jwtSecret)
+	gallery.Audit = NewChangeRecorder(store.Logs)
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Gallery = gallery
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	gallery.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Get("/cabs/:id", cabs.GetCabByID)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Blue", Status: "Available", Quantity: 3, Price: 700000})
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/inventory/cab/%d/images", cab.ID)
+
+	resp := uploadItemImage(t, app, token, path, "notes.txt", []byte("not a photo"))
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = uploadItemImage(t, app, token, "/api/inventory/cab/999/images", "front.png", testPNG(t))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = uploadItemImage(t, app, "", path, "front.png", testPNG(t))
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	var ids []string
+	for _, name := range []string{"front.png", "side.png"} {
+		resp := uploadItemImage(t, app, token, path, name, testPNG(t))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var uploaded api.ItemImageResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&uploaded))
+		assert.Equal(t, "image/png", uploaded.Image.ContentType)
+		ids = append(ids, uploaded.Image.ID)
+	}
+
+	resp = authedRequest(t, app, token, http.MethodPut, path+"/order", api.ItemImageOrderRequest{IDs: []string{ids[1]}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "every photo must be listed")
+	resp = authedRequest(t, app, token, http.MethodPut, path+"/order", api.ItemImageOrderRequest{IDs: []string{ids[1], ids[0]}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, path+"/"+ids[1]+"/primary", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The gallery is part of the cab's details
+	resp = authedRequest(t, app, "", http.MethodGet, fmt.Sprintf("/api/cabs/%d", cab.ID), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var detail models.MultiCab
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&detail))
+	require.Len(t, detail.Images, 2)
+	assert.Equal(t, ids[1], detail.Images[0].ID)
+	assert.True(t, detail.Images[0].IsPrimary)
+	assert.False(t, detail.Images[1].IsPrimary)
+
+	resp = authedRequest(t, app, "", http.MethodGet, detail.Images[0].URL, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "photos are public")
+	assert.Equal(t, "image/png", resp.Header.Get(fiber.HeaderContentType))
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, testPNG(t), data)
+
+	resp = authedRequest(t, app, token, http.MethodDelete, path+"/"+ids[1], nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ItemImageListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.True(t, list.Images[0].IsPrimary, "the remaining photo becomes primary")
+
+	resp = authedRequest(t, app, token, http.MethodDelete, path+"/"+ids[1], nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodGet, "/api/inventory/sale/1/images", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestItemImageVariants(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	gallery := NewItemImageHandler(store.Images, store.Cabs, store.Accessories, store.Materials, jwtSecret)
+	gallery.Variants = services.NewImageVariantQueue(nil)
+	app := fiber.New()
+	gallery.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Roof rack", Make: "Universal", Quantity: 2, Price: 4500, UnitColor: "Black"})
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/inventory/accessory/%d/images", accessoryID)
+
+	photo := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	for x := 0; x < 800; x++ {
+		photo.Set(x, x*600/800, color.RGBA{G: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, photo, nil))
+	resp := uploadItemImage(t, app, token, path, "rack.jpg", buf.Bytes())
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var uploaded api.ItemImageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&uploaded))
+
+	// Until its variants are made, a photo is served in its original size
+	resp = authedRequest(t, app, "", http.MethodGet, uploaded.Image.URL+"?size=huge", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	gallery.Variants.Close()
+
+	resp = authedRequest(t, app, "", http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ItemImageListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Len(t, list.Images[0].Variants, 2, "the photo is smaller than the medium size")
+	thumb := list.Images[0].Variants[0]
+	assert.Equal(t, services.ImageSizeThumb, thumb.Size)
+	assert.Equal(t, 160, thumb.Width)
+	assert.Equal(t, 120, thumb.Height)
+
+	resp = authedRequest(t, app, "", http.MethodGet, thumb.URL, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "image/jpeg", resp.Header.Get(fiber.HeaderContentType))
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	config, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 160, config.Width)
+
+	resp = authedRequest(t, app, "", http.MethodGet, uploaded.Image.URL+"?size=medium", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	data, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), data, "photos smaller than a size are served as uploaded")
+}
+
+func TestDuplicateItemImages(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	gallery := NewItemImageHandler(store.Images, store.Cabs, store.Accessories, store.Materials, jwtSecret)
+	app := fiber.New()
+	gallery.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	var paths []string
+	for _, name := range []string{"RX-7", "RX-8"} {
+		cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: name, Make: "Mazda", UnitColor: "Blue", Status: "Available", Quantity: 1, Price: 700000})
+		require.NoError(t, err)
+		paths = append(paths, fmt.Sprintf("/api/inventory/cab/%d/images", cab.ID))
+	}
+
+	resp := uploadItemImage(t, app, token, paths[0], "front.png", testPNG(t))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var first api.ItemImageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&first))
+	assert.Empty(t, first.Warning)
+	assert.Len(t, first.Image.ContentHash, 64)
+
+	// The same photo on another cab is kept, with a warning
+	resp = uploadItemImage(t, app, token, paths[1], "copy.png", testPNG(t))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var copied api.ItemImageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&copied))
+	assert.NotEmpty(t, copied.Warning)
+	require.Len(t, copied.Duplicates, 1)
+	assert.Equal(t, first.Image.ID, copied.Duplicates[0].ID)
+	assert.Equal(t, first.Image.URL, copied.Duplicates[0].URL)
+
+	resp = authedRequest(t, app, "", http.MethodGet, "/api/inventory/images/duplicates", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/inventory/images/duplicates", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.DuplicateItemImagesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, 1, report.Count)
+	require.Len(t, report.Duplicates[0].Images, 2)
+	assert.Equal(t, first.Image.ID, report.Duplicates[0].Images[0].ID)
+}
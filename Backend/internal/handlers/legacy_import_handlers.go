@@ -0,0 +1,914 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditEntityLegacyImport is the activity log entity of the legacy import runs
+const AuditEntityLegacyImport = "legacy_import"
+
+// AuditActionImportLegacy is the activity log action of importing a legacy spreadsheet
+const AuditActionImportLegacy = "IMPORT_LEGACY_DATA"
+
+// Limits of legacy imports
+const (
+	maxLegacyImportRows     = 20000
+	defaultLegacyImportSize = 100 // Rows imported between two saves of a run's progress
+)
+
+// legacyKeyField is the optional field of every kind holding the key of a row in the
+// legacy system
+const legacyKeyField = "legacy_id"
+
+// legacyDateLayouts are the sale date formats accepted, as the legacy sheets were
+// typed by hand
+var legacyDateLayouts = []string{"2006-01-02", "2006/01/02", "01/02/2006", "1/2/2006"}
+
+// legacyField is a field read from a legacy spreadsheet
+type legacyField struct {
+	name     string
+	required bool
+}
+
+// legacyImportFields are the fields of each kind of legacy spreadsheet, besides legacy_id
+var legacyImportFields = map[string][]legacyField{
+	models.LegacyCustomers: {{"full_name", true}, {"email", true}, {"phone", false}, {"address", false}},
+	models.LegacyCabs: {{"name", true}, {"make", true}, {"unit_color", true}, {"quantity", true}, {"price", true},
+		{"status", false}},
+	models.LegacyAccessories: {{"name", true}, {"make", true}, {"unit_color", true}, {"quantity", true}, {"price", true}},
+	models.LegacyMaterials: {{"name", true}, {"category", true}, {"supplier", true}, {"quantity", true},
+		{"status", false}},
+	models.LegacySales: {{"sale_date", true}, {"customer", true}, {"item_type", true}, {"item", true}, {"quantity", true},
+		{"unit_price", true}, {"sold_by", false}, {"tax_type", false}},
+}
+
+// legacySaleItemKinds are the kinds of legacy records a historical sale's item_type refers to
+var legacySaleItemKinds = map[string]string{
+	AuditEntityCab:       models.LegacyCabs,
+	AuditEntityAccessory: models.LegacyAccessories,
+	AuditEntityMaterial:  models.LegacyMaterials,
+}
+
+// LegacyImportHandler imports the spreadsheets of the legacy yard system: customers,
+// cabs, accessories, materials and historical sales, in that order. Columns are found
+// through a mapping of field names to the sheet's headers. Rows are imported in
+// batches and each run's progress is saved, so an import that stopped is resumed with
+// the same file. Every imported row is recorded under its legacy key, which makes
+// importing a file again skip the rows already imported, and lets historical sales
+// refer to customers and items by their legacy keys.
+type LegacyImportHandler struct {
+	Repo        repositories.LegacyImportRepository
+	Users       UserRepository
+	Customers   repositories.CustomerRepository
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Sales       SaleRepository
+	Payments    repositories.SalePaymentRepository // Optional; records historical sales as paid in full, like new sales
+	Audit       *ChangeRecorder
+	batchSize   int
+	now         func() time.Time
+	jwtSecret   []byte
+
+	mu      sync.Mutex
+	running map[string]bool // Kinds being imported; one import per kind at a time
+}
+
+// NewLegacyImportHandler creates a new LegacyImportHandler instance
+func NewLegacyImportHandler(repo repositories.LegacyImportRepository, users UserRepository, customers repositories.CustomerRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, sales SaleRepository, jwtSecret []byte) *LegacyImportHandler {
+	return &LegacyImportHandler{Repo: repo, Users: users, Customers: customers, Cabs: cabs, Accessories: accessories, Materials: materials, Sales: sales,
+		batchSize: defaultLegacyImportSize, now: time.Now, jwtSecret: jwtSecret, running: make(map[string]bool)}
+}
+
+// Routes registers the admin routes of the legacy import
+func (h *LegacyImportHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	adminGroup := r.Group("/admin/legacy-imports", mw.Auth, mw.Admin)
+	adminGroup.Get("/", h.GetRuns)              // GET /api/admin/legacy-imports
+	adminGroup.Get("/:id", h.GetRun)            // GET /api/admin/legacy-imports/:id
+	adminGroup.Post("/:id/resume", h.ResumeRun) // POST /api/admin/legacy-imports/:id/resume
+	adminGroup.Post("/:kind", h.StartImport)    // POST /api/admin/legacy-imports/:kind
+}
+
+// normalizeLegacyColumn makes "Full Name", "full-name" and "FULL_NAME" the same header
+func normalizeLegacyColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+// legacyMapping returns the column header of every field of a kind: the field's own
+// name unless overrides maps it to another header
+func legacyMapping(kind string, overrides map[string]string) (map[string]string, error) {
+	fields := append([]legacyField{{legacyKeyField, false}}, legacyImportFields[kind]...)
+	mapping := make(map[string]string, len(fields))
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		mapping[field.name] = field.name
+		names[i] = field.name
+	}
+	for field, column := range overrides {
+		if _, ok := mapping[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q for %s; fields are %s", field, kind, strings.Join(names, ", "))
+		}
+		if strings.TrimSpace(column) == "" {
+			return nil, fmt.Errorf("column of field %s cannot be empty", field)
+		}
+		mapping[field] = strings.TrimSpace(column)
+	}
+	return mapping, nil
+}
+
+// legacyRow is a data row of a legacy spreadsheet, by field
+type legacyRow struct {
+	line   int
+	key    string
+	values map[string]string
+}
+
+// parseLegacyImport reads a legacy spreadsheet saved as CSV, finding the column of
+// each field through mapping. Blank rows are skipped. A row's key is its legacy_id,
+// or a hash of its values when the sheet has none, so the same row always gets the
+// same key.
+func parseLegacyImport(r io.Reader, kind string, mapping map[string]string) ([]legacyRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s sheet is empty", kind)
+		}
+		return nil, fmt.Errorf("failed to read %s sheet header: %w", kind, err)
+	}
+	headers := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := headers[normalizeLegacyColumn(name)]; !ok {
+			headers[normalizeLegacyColumn(name)] = i
+		}
+	}
+
+	fields := append([]legacyField{{legacyKeyField, false}}, legacyImportFields[kind]...)
+	columns := make(map[string]int, len(fields))
+	for _, field := range fields {
+		i, ok := headers[normalizeLegacyColumn(mapping[field.name])]
+		if !ok {
+			if field.required {
+				return nil, fmt.Errorf("%s sheet has no %q column for %s", kind, mapping[field.name], field.name)
+			}
+			continue
+		}
+		columns[field.name] = i
+	}
+
+	rows := []legacyRow{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s sheet: %w", kind, err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) == maxLegacyImportRows {
+			return nil, fmt.Errorf("%s sheet exceeds the maximum of %d rows", kind, maxLegacyImportRows)
+		}
+
+		row := legacyRow{line: line, values: make(map[string]string, len(fields))}
+		hash := sha256.New()
+		for _, field := range fields {
+			if i, ok := columns[field.name]; ok && i < len(record) {
+				row.values[field.name] = strings.TrimSpace(record[i])
+			}
+			hash.Write([]byte(row.values[field.name] + "\x1f"))
+		}
+		row.key = row.values[legacyKeyField]
+		if row.key == "" {
+			row.key = "row-" + hex.EncodeToString(hash.Sum(nil))[:16]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseLegacyQuantity reads a whole, non-negative number such as "1,200"
+func parseLegacyQuantity(value string) (int, error) {
+	quantity, err := strconv.Atoi(strings.ReplaceAll(value, ",", ""))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a whole number", value)
+	}
+	if quantity < 0 {
+		return 0, fmt.Errorf("%q cannot be negative", value)
+	}
+	return quantity, nil
+}
+
+// parseLegacyAmount reads a non-negative peso amount such as "₱185,000.00" or "PHP 450"
+func parseLegacyAmount(value string) (float64, error) {
+	cleaned := strings.NewReplacer(",", "", "₱", "", "PHP", "", "php", "", " ", "").Replace(value)
+	amount, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not an amount", value)
+	}
+	if amount < 0 {
+		return 0, fmt.Errorf("%q cannot be negative", value)
+	}
+	return amount, nil
+}
+
+// parseLegacyDate reads a sale date in one of the legacyDateLayouts
+func parseLegacyDate(value string) (time.Time, error) {
+	for _, layout := range legacyDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("sale_date %q is not a date such as 2019-03-31 or 03/31/2019", value)
+}
+
+// legacyEntry is a planned row of a legacy import: its report row, and for rows to
+// insert, how to create their record
+type legacyEntry struct {
+	row    api.LegacyImportRow
+	insert func() (string, error)
+}
+
+// legacyPlan is a row checked against the records that already exist: the record it
+// matches, or how to insert it, and why it cannot be imported
+type legacyPlan struct {
+	target  string // Identifies the record the row stands for, to find repeated rows; empty when rows cannot repeat
+	matchID string
+	insert  func() (string, error)
+	errors  []string
+}
+
+// planLegacyImport decides what to do with each row: skip the rows recorded as
+// imported before, link rows to the records they match, insert the others, and
+// reject the rows that are invalid or repeat an earlier row
+func (h *LegacyImportHandler) planLegacyImport(c *fiber.Ctx, kind string, rows []legacyRow) ([]legacyEntry, error) {
+	records, err := h.Repo.GetRecords(kind)
+	if err != nil {
+		return nil, err
+	}
+	var planRow func(row legacyRow) legacyPlan
+	switch kind {
+	case models.LegacyCustomers:
+		planRow, err = h.customerPlanner(c.Context())
+	case models.LegacyCabs:
+		planRow, err = h.cabPlanner(c.Context())
+	case models.LegacyAccessories:
+		planRow, err = h.accessoryPlanner(c.Context())
+	case models.LegacyMaterials:
+		planRow, err = h.materialPlanner(c.Context())
+	case models.LegacySales:
+		planRow, err = h.salePlanner(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]legacyEntry, 0, len(rows))
+	keys := make(map[string]int)    // Line of the first row with each key
+	targets := make(map[string]int) // Line of the first row standing for each record
+	for _, row := range rows {
+		entry := legacyEntry{row: api.LegacyImportRow{Line: row.line, Key: row.key}}
+		if first, ok := keys[row.key]; ok {
+			entry.row.Action = api.LegacyImportReject
+			entry.row.Errors = []string{fmt.Sprintf("same %s as line %d", legacyKeyField, first)}
+			entries = append(entries, entry)
+			continue
+		}
+		keys[row.key] = row.line
+		if id, ok := records[row.key]; ok {
+			entry.row.Action, entry.row.ID = api.LegacyImportSkip, id
+			entries = append(entries, entry)
+			continue
+		}
+
+		plan := planRow(row)
+		if len(plan.errors) == 0 && plan.target != "" {
+			if first, ok := targets[plan.target]; ok {
+				plan.errors = append(plan.errors, fmt.Sprintf("same %s as line %d", strings.TrimSuffix(kind, "s"), first))
+			} else {
+				targets[plan.target] = row.line
+			}
+		}
+		switch {
+		case len(plan.errors) > 0:
+			entry.row.Action, entry.row.Errors = api.LegacyImportReject, plan.errors
+		case plan.matchID != "":
+			entry.row.Action, entry.row.ID = api.LegacyImportMatch, plan.matchID
+		default:
+			entry.row.Action, entry.insert = api.LegacyImportInsert, plan.insert
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// customerPlanner plans customer rows, matching existing customers by email
+func (h *LegacyImportHandler) customerPlanner(ctx context.Context) (func(legacyRow) legacyPlan, error) {
+	existing, err := h.Customers.GetAllCustomers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]string, len(existing))
+	for _, customer := range existing {
+		byEmail[strings.ToLower(customer.Email)] = customer.ID
+	}
+
+	return func(row legacyRow) legacyPlan {
+		var plan legacyPlan
+		customer := models.Customer{
+			FullName: row.values["full_name"],
+			Email:    row.values["email"],
+			Phone:    row.values["phone"],
+			Address:  row.values["address"],
+		}
+		if customer.FullName == "" {
+			plan.errors = append(plan.errors, "full_name is required")
+		}
+		if !strings.Contains(customer.Email, "@") {
+			plan.errors = append(plan.errors, fmt.Sprintf("email %q is not an email address", customer.Email))
+		}
+		plan.target = "email:" + strings.ToLower(customer.Email)
+		plan.matchID = byEmail[strings.ToLower(customer.Email)]
+		plan.insert = func() (string, error) {
+			created, err := h.Customers.CreateCustomer(ctx, &customer)
+			if err != nil {
+				return "", err
+			}
+			return created.ID, nil
+		}
+		return plan
+	}, nil
+}
+
+// legacyStockFields reads the quantity, price and status of an inventory row. Price
+// is only read when withPrice is set, status only when the kind has one.
+func legacyStockFields(row legacyRow, plan *legacyPlan, withPrice bool) (quantity int, price float64, status string) {
+	var err error
+	if quantity, err = parseLegacyQuantity(row.values["quantity"]); err != nil {
+		plan.errors = append(plan.errors, "quantity "+err.Error())
+	}
+	if withPrice {
+		if price, err = parseLegacyAmount(row.values["price"]); err != nil {
+			plan.errors = append(plan.errors, "price "+err.Error())
+		}
+	}
+	status = row.values["status"]
+	switch models.AccessoryStatus(status) {
+	case "":
+		status = materialStatusFor(quantity)
+	case models.StatusInStock, models.StatusLowStock, models.StatusOutOfStock:
+	default:
+		plan.errors = append(plan.errors, fmt.Sprintf("status %q must be In Stock, Low Stock or Out of Stock", status))
+	}
+	return quantity, price, status
+}
+
+// requireLegacyFields rejects a row missing any of the fields
+func requireLegacyFields(row legacyRow, plan *legacyPlan, fields ...string) {
+	for _, field := range fields {
+		if row.values[field] == "" {
+			plan.errors = append(plan.errors, field+" is required")
+		}
+	}
+}
+
+// legacyMatch returns the single ID in matches, or records that the row is ambiguous
+func legacyMatch(plan *legacyPlan, matches []string, what string) {
+	switch {
+	case len(matches) > 1:
+		plan.errors = append(plan.errors, fmt.Sprintf("%d records match %s; enter the item once and give its legacy_id", len(matches), what))
+	case len(matches) == 1:
+		plan.matchID = matches[0]
+	}
+}
+
+// cabPlanner plans cab rows, matching existing cabs by name and make
+func (h *LegacyImportHandler) cabPlanner(ctx context.Context) (func(legacyRow) legacyPlan, error) {
+	existing, err := h.Cabs.GetCabs(ctx, models.CabFilter{})
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]string, len(existing))
+	for _, cab := range existing {
+		key := strings.ToLower(cab.Name + "\x1f" + cab.Make)
+		byName[key] = append(byName[key], strconv.Itoa(cab.ID))
+	}
+
+	return func(row legacyRow) legacyPlan {
+		var plan legacyPlan
+		requireLegacyFields(row, &plan, "name", "make", "unit_color")
+		quantity, price, status := legacyStockFields(row, &plan, true)
+		cab := models.MultiCab{Name: row.values["name"], Make: row.values["make"], UnitColor: row.values["unit_color"],
+			Quantity: quantity, Price: price, Status: status, Image: config.DefaultImageURL}
+		plan.target = strings.ToLower(cab.Name + "\x1f" + cab.Make)
+		legacyMatch(&plan, byName[plan.target], fmt.Sprintf("cab %s %s", cab.Make, cab.Name))
+		plan.insert = func() (string, error) {
+			created, err := h.Cabs.AddCab(ctx, cab)
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(created.ID), nil
+		}
+		return plan
+	}, nil
+}
+
+// accessoryPlanner plans accessory rows, matching existing accessories by name and make
+func (h *LegacyImportHandler) accessoryPlanner(ctx context.Context) (func(legacyRow) legacyPlan, error) {
+	existing, err := h.Accessories.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]string, len(existing))
+	for _, accessory := range existing {
+		key := strings.ToLower(accessory.Name + "\x1f" + string(accessory.Make))
+		byName[key] = append(byName[key], strconv.Itoa(accessory.ID))
+	}
+
+	return func(row legacyRow) legacyPlan {
+		var plan legacyPlan
+		requireLegacyFields(row, &plan, "name", "make", "unit_color")
+		quantity, price, _ := legacyStockFields(row, &plan, true)
+		input := models.NewAccessoryInput{Name: row.values["name"], Make: models.AccessoryMake(row.values["make"]),
+			UnitColor: models.AccessoryColor(row.values["unit_color"]), Quantity: quantity, Price: price, Image: config.DefaultImageURL}
+		plan.target = strings.ToLower(input.Name + "\x1f" + string(input.Make))
+		legacyMatch(&plan, byName[plan.target], fmt.Sprintf("accessory %s %s", input.Make, input.Name))
+		plan.insert = func() (string, error) {
+			id, err := h.Accessories.Create(ctx, input)
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(id), nil
+		}
+		return plan
+	}, nil
+}
+
+// materialPlanner plans material rows, matching existing materials by name
+func (h *LegacyImportHandler) materialPlanner(ctx context.Context) (func(legacyRow) legacyPlan, error) {
+	existing, err := h.Materials.GetAll(ctx, models.MaterialFilter{})
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]string, len(existing))
+	for _, material := range existing {
+		key := strings.ToLower(material.Name)
+		byName[key] = append(byName[key], strconv.Itoa(material.ID))
+	}
+
+	return func(row legacyRow) legacyPlan {
+		var plan legacyPlan
+		requireLegacyFields(row, &plan, "name", "category", "supplier")
+		quantity, _, status := legacyStockFields(row, &plan, false)
+		material := models.Material{Name: row.values["name"], Category: row.values["category"], Supplier: row.values["supplier"],
+			Quantity: quantity, Status: status}
+		plan.target = strings.ToLower(material.Name)
+		legacyMatch(&plan, byName[plan.target], fmt.Sprintf("material %s", material.Name))
+		plan.insert = func() (string, error) {
+			id, err := h.Materials.Create(ctx, &material)
+			if err != nil {
+				return "", err
+			}
+			return strconv.Itoa(id), nil
+		}
+		return plan
+	}, nil
+}
+
+// salePlanner plans historical sale rows. Each row is a sale of one item, whose
+// customer and item are found by the legacy keys they were imported under. Sales
+// are recorded as paid in full and leave stock unchanged, as the items were sold
+// before the legacy inventory counts were taken.
+func (h *LegacyImportHandler) salePlanner(c *fiber.Ctx) (func(legacyRow) legacyPlan, error) {
+	customers, err := h.Repo.GetRecords(models.LegacyCustomers)
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]map[string]string, len(legacySaleItemKinds))
+	for itemType, kind := range legacySaleItemKinds {
+		if items[itemType], err = h.Repo.GetRecords(kind); err != nil {
+			return nil, err
+		}
+	}
+	users, err := h.Users.GetAll(c.Context())
+	if err != nil {
+		return nil, err
+	}
+	sellers := make(map[string]string, 2*len(users))
+	for _, user := range users {
+		sellers[strings.ToLower(user.Email)] = user.Id
+		sellers[strings.ToLower(user.Username)] = user.Id
+	}
+	importer, _ := c.Locals("user_id").(string)
+	importer = strings.Clone(importer)
+	today := h.now().Format("2006-01-02")
+	ctx := c.Context()
+
+	return func(row legacyRow) legacyPlan {
+		var plan legacyPlan
+		sale := models.Sale{SoldBy: importer, TaxType: strings.ToLower(row.values["tax_type"])}
+		var item models.SaleItem
+		itemType := strings.ToLower(row.values["item_type"])
+
+		if date, err := parseLegacyDate(row.values["sale_date"]); err != nil {
+			plan.errors = append(plan.errors, err.Error())
+		} else if sale.SaleDate = date.Format("2006-01-02"); sale.SaleDate > today {
+			plan.errors = append(plan.errors, fmt.Sprintf("sale_date %s is in the future", sale.SaleDate))
+		}
+		if sale.CustomerID = customers[row.values["customer"]]; sale.CustomerID == "" {
+			plan.errors = append(plan.errors, fmt.Sprintf("no customer was imported with legacy_id %q", row.values["customer"]))
+		}
+		itemID := ""
+		if records, ok := items[itemType]; !ok {
+			plan.errors = append(plan.errors, fmt.Sprintf("item_type %q must be cab, accessory or material", row.values["item_type"]))
+		} else if itemID = records[row.values["item"]]; itemID == "" {
+			plan.errors = append(plan.errors, fmt.Sprintf("no %s was imported with legacy_id %q", itemType, row.values["item"]))
+		} else {
+			item.Item = models.NewRef(itemType, itemID)
+		}
+		var err error
+		if item.Quantity, err = parseLegacyQuantity(row.values["quantity"]); err != nil {
+			plan.errors = append(plan.errors, "quantity "+err.Error())
+		} else if item.Quantity == 0 {
+			plan.errors = append(plan.errors, "quantity must be at least 1")
+		}
+		if item.UnitPrice, err = parseLegacyAmount(row.values["unit_price"]); err != nil {
+			plan.errors = append(plan.errors, "unit_price "+err.Error())
+		}
+		if seller := row.values["sold_by"]; seller != "" {
+			if sale.SoldBy = sellers[strings.ToLower(seller)]; sale.SoldBy == "" {
+				plan.errors = append(plan.errors, fmt.Sprintf("sold_by %q is not the email or username of a user", seller))
+			}
+		}
+		if sale.TaxType != "" && !models.ValidTaxType(sale.TaxType) {
+			plan.errors = append(plan.errors, "tax_type must be vatable, exempt or zero_rated")
+		}
+
+		item.Subtotal = item.UnitPrice * float64(item.Quantity)
+		sale.TotalPrice = item.Subtotal
+		plan.insert = func() (string, error) {
+			return h.createSale(ctx, sale, item)
+		}
+		return plan
+	}, nil
+}
+
+// createSale records a historical sale with its item in one transaction, leaving
+// stock unchanged, then its payment in full. The sale is deleted again when the
+// payment cannot be recorded, so a failed row leaves nothing behind.
+func (h *LegacyImportHandler) createSale(ctx context.Context, sale models.Sale, item models.SaleItem) (string, error) {
+	sale.ApplyTax()
+	if err := h.Sales.Sell(ctx, &sale, []models.SaleItem{item}, false); err != nil {
+		return "", fmt.Errorf("failed to record sale: %w", err)
+	}
+	if h.Payments != nil && sale.TotalPrice > 0 {
+		payment := &models.SalePayment{SaleID: sale.ID, Amount: sale.TotalPrice, Method: models.PaymentCash, ReceivedBy: sale.SoldBy}
+		if err := h.Payments.Add(payment); err != nil {
+			if deleteErr := h.Sales.Delete(ctx, sale.ID); deleteErr != nil {
+				log.Printf("Error deleting sale %s of a legacy row whose payment failed: %v", sale.ID, deleteErr)
+			}
+			return "", fmt.Errorf("failed to record the payment of sale %s: %w", sale.ID, err)
+		}
+	}
+	return sale.ID, nil
+}
+
+// legacyUpload is a legacy spreadsheet uploaded to import
+type legacyUpload struct {
+	fileName string
+	checksum string
+	data     []byte
+	mapping  map[string]string // Column overrides sent with the file
+}
+
+// readLegacyUpload reads a legacy spreadsheet from a multipart "file" field, with an
+// optional "mapping" field of JSON column overrides, or from a raw text/csv body
+func readLegacyUpload(c *fiber.Ctx) (*legacyUpload, error) {
+	upload := &legacyUpload{}
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		if strings.EqualFold(filepath.Ext(fileHeader.Filename), ".xlsx") || strings.EqualFold(filepath.Ext(fileHeader.Filename), ".xls") {
+			return nil, fmt.Errorf("Excel files are not supported; save each sheet as CSV")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open uploaded sheet: %w", err)
+		}
+		defer file.Close()
+		if upload.data, err = io.ReadAll(file); err != nil {
+			return nil, fmt.Errorf("failed to read uploaded sheet: %w", err)
+		}
+		upload.fileName = filepath.Base(fileHeader.Filename)
+		if mapping := c.FormValue("mapping"); mapping != "" {
+			if err := json.Unmarshal([]byte(mapping), &upload.mapping); err != nil {
+				return nil, fmt.Errorf("mapping must be a JSON object of field names to column headers")
+			}
+		}
+	} else {
+		upload.data = append([]byte(nil), c.Body()...)
+	}
+	if len(upload.data) == 0 {
+		return nil, fmt.Errorf("a CSV sheet is required")
+	}
+	sum := sha256.Sum256(upload.data)
+	upload.checksum = hex.EncodeToString(sum[:])
+	return upload, nil
+}
+
+// claim marks a kind as being imported, returning false when it already is
+func (h *LegacyImportHandler) claim(kind string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running[kind] {
+		return false
+	}
+	h.running[kind] = true
+	return true
+}
+
+// release marks a kind as no longer being imported
+func (h *LegacyImportHandler) release(kind string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.running, kind)
+}
+
+// process imports the rows of a run from its NextRow on, saving its progress after
+// every batch. When a row fails, the run is saved as failed with NextRow at that
+// row, so resuming starts there. It returns the report rows it got through.
+func (h *LegacyImportHandler) process(run *models.LegacyImportRun, entries []legacyEntry) ([]api.LegacyImportRow, error) {
+	rows := []api.LegacyImportRow{}
+	for start := run.NextRow; start < len(entries); start += h.batchSize {
+		end := min(start+h.batchSize, len(entries))
+		for i := start; i < end; i++ {
+			entry := &entries[i]
+			var err error
+			switch entry.row.Action {
+			case api.LegacyImportReject:
+				run.Rejected++
+			case api.LegacyImportSkip:
+				run.Skipped++
+			case api.LegacyImportMatch:
+				if err = h.Repo.AddRecord(run.Kind, entry.row.Key, entry.row.ID); err == nil {
+					run.Matched++
+				}
+			case api.LegacyImportInsert:
+				if entry.row.ID, err = entry.insert(); err == nil {
+					if err = h.Repo.AddRecord(run.Kind, entry.row.Key, entry.row.ID); err == nil {
+						run.Inserted++
+					}
+				}
+			}
+			if err != nil {
+				log.Printf("Error importing line %d of legacy %s run %s: %v", entry.row.Line, run.Kind, run.ID, err)
+				run.Status, run.NextRow = models.LegacyImportFailed, i
+				run.Error = fmt.Sprintf("Failed to import line %d; resume the run to retry from there", entry.row.Line)
+				if updateErr := h.Repo.UpdateRun(run); updateErr != nil {
+					log.Printf("Error saving legacy import run %s: %v", run.ID, updateErr)
+				}
+				return rows, err
+			}
+			rows = append(rows, entry.row)
+		}
+
+		run.NextRow = end
+		if run.NextRow == len(entries) {
+			run.Status = models.LegacyImportCompleted
+		}
+		if err := h.Repo.UpdateRun(run); err != nil {
+			return rows, fmt.Errorf("failed to save progress of legacy import run %s: %w", run.ID, err)
+		}
+	}
+	if run.Status != models.LegacyImportCompleted {
+		run.Status = models.LegacyImportCompleted // A run of a sheet without rows, resumed
+		if err := h.Repo.UpdateRun(run); err != nil {
+			return rows, fmt.Errorf("failed to save progress of legacy import run %s: %w", run.ID, err)
+		}
+	}
+	return rows, nil
+}
+
+// runImport plans the rows of an uploaded sheet and imports them into run, answering
+// with the report
+func (h *LegacyImportHandler) runImport(c *fiber.Ctx, run *models.LegacyImportRun, upload *legacyUpload, dryRun bool) error {
+	lines, err := parseLegacyImport(strings.NewReader(string(upload.data)), run.Kind, run.Mapping)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	if len(lines) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Sheet contains no rows", StatusCode: fiber.StatusBadRequest})
+	}
+	if run.ID != "" && len(lines) != run.TotalRows {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "The file differs from the one the run started with", StatusCode: fiber.StatusConflict})
+	}
+
+	entries, err := h.planLegacyImport(c, run.Kind, lines)
+	if err != nil {
+		log.Printf("Error planning legacy %s import: %v", run.Kind, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to import legacy data", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	response := api.LegacyImportResponse{DryRun: dryRun, Summary: map[api.LegacyImportAction]int{
+		api.LegacyImportInsert: 0, api.LegacyImportMatch: 0, api.LegacyImportSkip: 0, api.LegacyImportReject: 0,
+	}}
+	if dryRun {
+		response.Rows = make([]api.LegacyImportRow, len(entries))
+		for i, entry := range entries {
+			response.Rows[i] = entry.row
+			response.Summary[entry.row.Action]++
+		}
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+
+	if run.ID == "" {
+		run.TotalRows = len(entries)
+		if err := h.Repo.CreateRun(run); err != nil {
+			log.Printf("Error creating legacy %s import run: %v", run.Kind, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to import legacy data", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+
+	rows, importErr := h.process(run, entries)
+	response.Run, response.Rows = run, rows
+	for _, row := range rows {
+		response.Summary[row.Action]++
+	}
+	details := fmt.Sprintf("Imported legacy %s from %s: %d inserted, %d matched, %d skipped, %d rejected",
+		run.Kind, run.FileName, response.Summary[api.LegacyImportInsert], response.Summary[api.LegacyImportMatch],
+		response.Summary[api.LegacyImportSkip], response.Summary[api.LegacyImportReject])
+	if importErr != nil {
+		h.Audit.RecordAttempt(c, AuditActionImportLegacy, AuditEntityLegacyImport, run.ID, details+"; "+run.Error, false)
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	h.Audit.RecordAction(c, AuditActionImportLegacy, AuditEntityLegacyImport, run.ID, details)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// StartImport handles importing a legacy spreadsheet
+// @Summary Import a legacy spreadsheet (Admin)
+// @Description Imports one sheet of the legacy yard system saved as CSV: customers, cabs, accessories, materials or sales, in that order. Columns are found by field name, or by the headers given in mapping, e.g. {"full_name": "Customer Name"}; every kind has an optional legacy_id column. Rows matching an existing customer (by email), cab or accessory (by name and make) or material (by name) are linked to it rather than inserted. Each sales row is a paid sale of one item to a customer, referring to both by the legacy_id they were imported with. Rows are imported in batches and the run's progress is saved; when a row fails the run stops with 500 and is resumed with the same file. Rows imported before are skipped. Pass dry_run=true to only get the report.
+// @Tags Legacy Import
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param kind path string true "customers, cabs, accessories, materials or sales"
+// @Param file formData file false "CSV sheet"
+// @Param mapping formData string false "JSON object of field names to column headers"
+// @Param dry_run query bool false "Only report what would be imported (default false)"
+// @Success 200 {object} api.LegacyImportResponse "Run and its rows"
+// @Failure 400 {object} api.ErrorResponse "Invalid kind, mapping or sheet"
+// @Failure 409 {object} api.ErrorResponse "An import of the kind is running, or an unfinished run has the same file"
+// @Failure 500 {object} api.LegacyImportResponse "The run stopped at a row and can be resumed"
+// @Router /admin/legacy-imports/{kind} [post]
+func (h *LegacyImportHandler) StartImport(c *fiber.Ctx) error {
+	kind := strings.Clone(c.Params("kind"))
+	if _, ok := legacyImportFields[kind]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "kind must be one of " + strings.Join(models.LegacyImportKinds, ", "), StatusCode: fiber.StatusBadRequest})
+	}
+	dryRun := c.QueryBool("dry_run", false)
+	upload, err := readLegacyUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	mapping, err := legacyMapping(kind, upload.mapping)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	if !h.claim(kind) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("An import of %s is already running", kind), StatusCode: fiber.StatusConflict})
+	}
+	defer h.release(kind)
+
+	if !dryRun {
+		runs, err := h.Repo.GetRuns()
+		if err != nil {
+			log.Printf("Error getting legacy import runs: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to import legacy data", StatusCode: fiber.StatusInternalServerError})
+		}
+		for _, run := range runs {
+			if run.Kind == kind && run.Checksum == upload.checksum && run.Status != models.LegacyImportCompleted {
+				return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("Run %s has not finished importing this file; resume it instead", run.ID), StatusCode: fiber.StatusConflict})
+			}
+		}
+	}
+
+	startedBy, _ := c.Locals("user_id").(string)
+	run := &models.LegacyImportRun{Kind: kind, FileName: upload.fileName, Checksum: upload.checksum, Mapping: mapping,
+		Status: models.LegacyImportRunning, StartedBy: strings.Clone(startedBy)}
+	return h.runImport(c, run, upload, dryRun)
+}
+
+// ResumeRun handles resuming a legacy import that stopped
+// @Summary Resume a legacy import (Admin)
+// @Description Continues a run that stopped from the row it stopped at, with the column mapping it started with. The file must be the one the run started with.
+// @Tags Legacy Import
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Run ID"
+// @Param file formData file false "The CSV sheet the run started with"
+// @Success 200 {object} api.LegacyImportResponse "Run and the rows imported now"
+// @Failure 400 {object} api.ErrorResponse "Invalid sheet"
+// @Failure 404 {object} api.ErrorResponse "Run not found"
+// @Failure 409 {object} api.ErrorResponse "Run is completed or running, or the file differs"
+// @Failure 500 {object} api.LegacyImportResponse "The run stopped at a row again"
+// @Router /admin/legacy-imports/{id}/resume [post]
+func (h *LegacyImportHandler) ResumeRun(c *fiber.Ctx) error {
+	run, err := h.Repo.GetRun(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Run not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting legacy import run %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to import legacy data", StatusCode: fiber.StatusInternalServerError})
+	}
+	if run.Status == models.LegacyImportCompleted {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Run is already completed", StatusCode: fiber.StatusConflict})
+	}
+	upload, err := readLegacyUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	if upload.checksum != run.Checksum {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "The file differs from the one the run started with", StatusCode: fiber.StatusConflict})
+	}
+
+	if !h.claim(run.Kind) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: fmt.Sprintf("An import of %s is already running", run.Kind), StatusCode: fiber.StatusConflict})
+	}
+	defer h.release(run.Kind)
+
+	run.Status, run.Error = models.LegacyImportRunning, ""
+	return h.runImport(c, run, upload, false)
+}
+
+// GetRuns handles listing the legacy import runs
+// @Summary List legacy imports (Admin)
+// @Description Lists the runs of the legacy import with their progress, newest first.
+// @Tags Legacy Import
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.LegacyImportRunsResponse "Runs"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve runs"
+// @Router /admin/legacy-imports [get]
+func (h *LegacyImportHandler) GetRuns(c *fiber.Ctx) error {
+	runs, err := h.Repo.GetRuns()
+	if err != nil {
+		log.Printf("Error getting legacy import runs: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve runs", StatusCode: fiber.StatusInternalServerError})
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].CreatedAt.After(runs[j].CreatedAt) })
+	return c.Status(fiber.StatusOK).JSON(api.LegacyImportRunsResponse{Runs: runs})
+}
+
+// GetRun handles getting a legacy import run
+// @Summary Get a legacy import (Admin)
+// @Description Returns a run of the legacy import with its progress.
+// @Tags Legacy Import
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Run ID"
+// @Success 200 {object} models.LegacyImportRun "Run"
+// @Failure 404 {object} api.ErrorResponse "Run not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve run"
+// @Router /admin/legacy-imports/{id} [get]
+func (h *LegacyImportHandler) GetRun(c *fiber.Ctx) error {
+	run, err := h.Repo.GetRun(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Run not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting legacy import run %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve run", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(run)
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyCustomers fails to create the customer with email failEmail while failing is set
+type flakyCustomers struct {
+	repositories.CustomerRepository
+	failEmail string
+	failing   bool
+}
+
+func (r *flakyCustomers) CreateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
+	if r.failing && customer.Email == r.failEmail {
+		return nil, errors.New("connection reset")
+	}
+	return r.CustomerRepository.CreateCustomer(ctx, customer)
+}
+
+// setupLegacyImportTestApp registers the legacy import routes on an in-memory store
+// holding an admin and the customer Existing Buyer, importing two rows per batch
+func setupLegacyImportTestApp(t *testing.T) (*fiber.App, *memory.Store, *flakyCustomers, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	admin := &models.User{Username: "owner", Email: "owner@example.com", Password: "password123", Role: RoleAdmin}
+	require.NoError(t, store.Users.Create(context.Background(), admin))
+	_, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Existing Buyer", Email: "buyer@example.com"})
+	require.NoError(t, err)
+
+	customers := &flakyCustomers{CustomerRepository: store.Customers}
+	handler := NewLegacyImportHandler(store.LegacyImports, store.Users, customers, store.Cabs, store.Accessories, store.Materials, store.Sales, jwtSecret)
+	handler.Payments = store.Payments
+	handler.Audit = NewChangeRecorder(store.Logs)
+	handler.batchSize = 2
+	handler.now = func() time.Time { return time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC) }
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, customers, createTenantTestToken(jwtSecret, admin.Id, RoleAdmin, models.DefaultTenantID)
+}
+
+// uploadLegacySheet sends a legacy sheet in the multipart "file" field, with mapping
+// when it is set
+func uploadLegacySheet(t *testing.T, app *fiber.App, token, path, data, mapping string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "sheet.csv")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(data))
+	require.NoError(t, err)
+	if mapping != "" {
+		require.NoError(t, writer.WriteField("mapping", mapping))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func decodeLegacyImport(t *testing.T, resp *http.Response) api.LegacyImportResponse {
+	t.Helper()
+	var report api.LegacyImportResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	return report
+}
+
+const testLegacyCustomers = `Cust No,Customer Name,E-mail,Contact
+C-1,Juan Dela Cruz,juan@example.com,0917 555 0101
+C-2,Existing Buyer,BUYER@example.com,
+C-3,,nobody,
+C-1,Juan Again,juan2@example.com,
+C-4,Maria Santos,maria@example.com,0918 555 0102
+`
+
+const testLegacyCustomerMapping = `{"legacy_id": "Cust No", "full_name": "Customer Name", "email": "E-mail", "phone": "Contact"}`
+
+func TestImportLegacyCustomers(t *testing.T) {
+	t.Run("Dry run", func(t *testing.T) {
+		app, store, _, token := setupLegacyImportTestApp(t)
+
+		resp := uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers?dry_run=true", testLegacyCustomers, testLegacyCustomerMapping)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		report := decodeLegacyImport(t, resp)
+		assert.True(t, report.DryRun)
+		assert.Nil(t, report.Run)
+		require.Len(t, report.Rows, 5)
+		assert.Equal(t, api.LegacyImportInsert, report.Rows[0].Action)
+		assert.Equal(t, 2, report.Rows[0].Line)
+		assert.Equal(t, api.LegacyImportMatch, report.Rows[1].Action, "customers are matched by email")
+		assert.Equal(t, api.LegacyImportReject, report.Rows[2].Action)
+		assert.Len(t, report.Rows[2].Errors, 2)
+		assert.Equal(t, []string{"same legacy_id as line 2"}, report.Rows[3].Errors)
+		assert.Equal(t, map[api.LegacyImportAction]int{"insert": 2, "match": 1, "skip": 0, "reject": 2}, report.Summary)
+
+		customers, err := store.Customers.GetAllCustomers(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, customers, 1, "a dry run changes nothing")
+	})
+
+	t.Run("Import and import again", func(t *testing.T) {
+		app, store, _, token := setupLegacyImportTestApp(t)
+
+		resp := uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, testLegacyCustomerMapping)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		report := decodeLegacyImport(t, resp)
+		require.NotNil(t, report.Run)
+		assert.Equal(t, models.LegacyImportCompleted, report.Run.Status)
+		assert.Equal(t, 5, report.Run.NextRow)
+		assert.Equal(t, 2, report.Run.Inserted)
+		assert.Equal(t, 1, report.Run.Matched)
+		assert.Equal(t, "Cust No", report.Run.Mapping["legacy_id"])
+
+		customers, err := store.Customers.GetAllCustomers(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, customers, 3)
+
+		// The run is finished, so the same file is imported anew and every row is skipped
+		resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, testLegacyCustomerMapping)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		report = decodeLegacyImport(t, resp)
+		assert.Equal(t, 3, report.Run.Skipped)
+		assert.Equal(t, 0, report.Run.Inserted)
+
+		logs, _, err := store.Logs.GetLogs(1, 10)
+		require.NoError(t, err)
+		require.Len(t, logs, 2)
+		assert.Equal(t, AuditActionImportLegacy, logs[0].Action)
+	})
+
+	t.Run("Invalid requests", func(t *testing.T) {
+		app, _, _, token := setupLegacyImportTestApp(t)
+
+		resp := uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/trucks", testLegacyCustomers, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, `{"nickname": "Alias"}`)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "unknown field")
+		resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "no full_name column")
+
+		staffToken := createTenantTestToken([]byte("testsecret"), "staff-1", RoleStaff, models.DefaultTenantID)
+		resp = uploadLegacySheet(t, app, staffToken, "/api/admin/legacy-imports/customers", testLegacyCustomers, testLegacyCustomerMapping)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestResumeLegacyImport(t *testing.T) {
+	app, store, customers, token := setupLegacyImportTestApp(t)
+	customers.failEmail, customers.failing = "maria@example.com", true
+
+	resp := uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, testLegacyCustomerMapping)
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	report := decodeLegacyImport(t, resp)
+	require.NotNil(t, report.Run)
+	assert.Equal(t, models.LegacyImportFailed, report.Run.Status)
+	assert.Equal(t, 4, report.Run.NextRow)
+	assert.Equal(t, "Failed to import line 6; resume the run to retry from there", report.Run.Error)
+	assert.Len(t, report.Rows, 4)
+
+	// The unfinished run keeps its file from being started over
+	resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, testLegacyCustomerMapping)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/"+report.Run.ID+"/resume", "Cust No\nC-9\n", "")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "another file")
+
+	customers.failing = false
+	resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/"+report.Run.ID+"/resume", testLegacyCustomers, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resumed := decodeLegacyImport(t, resp)
+	assert.Equal(t, models.LegacyImportCompleted, resumed.Run.Status)
+	assert.Empty(t, resumed.Run.Error)
+	assert.Equal(t, 2, resumed.Run.Inserted)
+	assert.Equal(t, 1, resumed.Run.Matched)
+	assert.Equal(t, 2, resumed.Run.Rejected)
+	require.Len(t, resumed.Rows, 1, "only the rows left are imported")
+	assert.Equal(t, 6, resumed.Rows[0].Line)
+
+	all, err := store.Customers.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/admin/legacy-imports/"+report.Run.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var run models.LegacyImportRun
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&run))
+	assert.Equal(t, models.LegacyImportCompleted, run.Status)
+
+	resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/"+report.Run.ID+"/resume", testLegacyCustomers, "")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "completed")
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/admin/legacy-imports/missing", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestImportLegacySales(t *testing.T) {
+	app, store, _, token := setupLegacyImportTestApp(t)
+
+	resp := uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/customers", testLegacyCustomers, testLegacyCustomerMapping)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/cabs", `legacy_id,name,make,unit_color,quantity,price
+K-1,Multicab Van,Suzuki,White,2,"₱185,000.00"
+K-2,Pickup,Suzuki,Red,1,abc
+`, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	cabs := decodeLegacyImport(t, resp)
+	assert.Equal(t, 1, cabs.Run.Inserted)
+	assert.Equal(t, 1, cabs.Run.Rejected)
+
+	resp = uploadLegacySheet(t, app, token, "/api/admin/legacy-imports/sales", `Sale Date,customer,item_type,item,quantity,unit_price,sold_by
+03/31/2019,C-1,cab,K-1,1,"180,000"
+2019-04-02,C-4,cab,K-2,1,1000
+2019-04-03,C-1,truck,K-1,1,1000
+2030-01-01,C-1,cab,K-1,1,1000
+2019-04-05,C-2,cab,K-1,2,"PHP 170,000",owner
+`, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report := decodeLegacyImport(t, resp)
+	assert.Equal(t, 2, report.Run.Inserted)
+	assert.Equal(t, 3, report.Run.Rejected)
+	assert.Equal(t, []string{`no cab was imported with legacy_id "K-2"`}, report.Rows[1].Errors)
+	assert.Equal(t, []string{"sale_date 2030-01-01 is in the future"}, report.Rows[3].Errors)
+
+	sale, err := store.Sales.GetByID(context.Background(), report.Rows[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "2019-03-31", sale.SaleDate)
+	assert.Equal(t, 180000.0, sale.TotalPrice)
+	items, err := store.Sales.GetSaleItems(context.Background(), sale.ID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "cab", items[0].ItemType())
+	paid, err := store.Payments.Paid(sale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 180000.0, paid, "historical sales are paid in full")
+
+	cab, err := store.Cabs.GetCabByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cab.Quantity, "historical sales leave stock unchanged")
+}
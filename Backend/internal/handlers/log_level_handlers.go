@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LogLevelHandler lets admins change the level the server logs at for a while,
+// such as to debug logging while diagnosing an incident. The level is the server's,
+// shared by every tenant.
+type LogLevelHandler struct {
+	Levels    *services.LogLevel
+	Config    config.LogLevelConfig
+	jwtSecret []byte
+}
+
+// NewLogLevelHandler creates a new LogLevelHandler instance
+func NewLogLevelHandler(levels *services.LogLevel, cfg config.LogLevelConfig, jwtSecret []byte) *LogLevelHandler {
+	return &LogLevelHandler{Levels: levels, Config: cfg, jwtSecret: jwtSecret}
+}
+
+// Routes registers the log level routes
+func (h *LogLevelHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/admin/log-level", mw.Auth, mw.Admin, h.GetLogLevel)      // GET /api/admin/log-level
+	r.Put("/admin/log-level", mw.Auth, mw.Admin, h.SetLogLevel)      // PUT /api/admin/log-level
+	r.Delete("/admin/log-level", mw.Auth, mw.Admin, h.ResetLogLevel) // DELETE /api/admin/log-level
+}
+
+// GetLogLevel handles reading the log level
+// @Summary Get the log level
+// @Description Returns the level this server logs at, the level LOG_LEVEL sets, and when a level an admin set reverts.
+// @Tags Logging
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.LogLevelStatus "Log level"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Forbidden"
+// @Router /admin/log-level [get]
+func (h *LogLevelHandler) GetLogLevel(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.Levels.Status())
+}
+
+// SetLogLevel handles changing the log level for a while
+// @Summary Change the log level for a while
+// @Description Changes the level this server logs at without restarting it, replacing any earlier change. It reverts to the level LOG_LEVEL sets after durationMinutes, LOG_LEVEL_OVERRIDE_MINUTES by default and at most LOG_LEVEL_MAX_OVERRIDE_MINUTES. Only the instance serving the request changes, and a restart reverts it.
+// @Tags Logging
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body api.LogLevelRequest true "Level and how long it lasts"
+// @Success 200 {object} models.LogLevelStatus "Log level"
+// @Failure 400 {object} api.ErrorResponse "Unknown level or duration out of range"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Forbidden"
+// @Router /admin/log-level [put]
+func (h *LogLevelHandler) SetLogLevel(c *fiber.Ctx) error {
+	var input api.LogLevelRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	level, err := config.ParseLogLevel(input.Level)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "level must be debug, info, warn or error", StatusCode: fiber.StatusBadRequest})
+	}
+
+	ttl := h.Config.OverrideTTL
+	if input.DurationMinutes != 0 {
+		ttl = time.Duration(input.DurationMinutes) * time.Minute
+		if ttl < 0 || ttl > h.Config.MaxOverrideTTL {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("durationMinutes must be between 1 and %d", int(h.Config.MaxOverrideTTL/time.Minute)),
+				StatusCode: fiber.StatusBadRequest,
+			})
+		}
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	h.Levels.Set(level, ttl, userID)
+	return c.Status(fiber.StatusOK).JSON(h.Levels.Status())
+}
+
+// ResetLogLevel handles reverting the log level now
+// @Summary Revert the log level
+// @Description Reverts this server to the level LOG_LEVEL sets before a change made with PUT /api/admin/log-level is due to revert.
+// @Tags Logging
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.LogLevelStatus "Log level"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Forbidden"
+// @Router /admin/log-level [delete]
+func (h *LogLevelHandler) ResetLogLevel(c *fiber.Ctx) error {
+	h.Levels.Reset()
+	return c.Status(fiber.StatusOK).JSON(h.Levels.Status())
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevel(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	levels := services.NewLogLevel(slog.LevelInfo)
+	defer levels.Close()
+
+	app := fiber.New()
+	NewLogLevelHandler(levels, config.LogLevelConfig{Level: slog.LevelInfo, OverrideTTL: 30 * time.Minute, MaxOverrideTTL: 4 * time.Hour}, jwtSecret).
+		Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	request := func(method string, body interface{}) (int, models.LogLevelStatus) {
+		resp := authedRequest(t, app, adminToken, method, "/api/admin/log-level", body)
+		var status models.LogLevelStatus
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+		}
+		return resp.StatusCode, status
+	}
+
+	t.Run("Set with the default duration", func(t *testing.T) {
+		before := time.Now()
+		code, status := request(http.MethodPut, api.LogLevelRequest{Level: "DEBUG"})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "debug", status.Level)
+		assert.Equal(t, "info", status.DefaultLevel)
+		assert.Equal(t, "admin-1", status.ChangedBy)
+		require.NotNil(t, status.RevertsAt)
+		assert.WithinDuration(t, before.Add(30*time.Minute), *status.RevertsAt, 5*time.Second)
+
+		code, status = request(http.MethodGet, nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "debug", status.Level)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		code, status := request(http.MethodDelete, nil)
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "info", status.Level)
+		assert.Nil(t, status.RevertsAt)
+	})
+
+	for name, body := range map[string]api.LogLevelRequest{
+		"Unknown level":     {Level: "trace"},
+		"Negative duration": {Level: "debug", DurationMinutes: -1},
+		"Too long":          {Level: "debug", DurationMinutes: 241},
+	} {
+		t.Run(name, func(t *testing.T) {
+			code, _ := request(http.MethodPut, body)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Equal(t, "info", levels.Status().Level)
+		})
+	}
+
+	t.Run("Staff forbidden", func(t *testing.T) {
+		resp := authedRequest(t, app, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID), http.MethodPut, "/api/admin/log-level", api.LogLevelRequest{Level: "debug"})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditActionImportMaterials is the activity log action of a material import
+const AuditActionImportMaterials = "IMPORT_MATERIALS"
+
+// maxMaterialImportRows caps the number of rows accepted in a single material import
+const maxMaterialImportRows = 5000
+
+// materialImportLine is a row of a material import, with the material it describes
+// and why it cannot be imported
+type materialImportLine struct {
+	line     int
+	material models.Material
+	errors   []string
+}
+
+// parseMaterialImport reads a CSV material list with a name, category, supplier and
+// quantity header, and optional id and status columns. Blank rows are skipped;
+// invalid rows are returned with their errors rather than failing the import.
+func parseMaterialImport(r io.Reader) ([]materialImportLine, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("material list is empty")
+		}
+		return nil, fmt.Errorf("failed to read material list header: %w", err)
+	}
+
+	columns := map[string]int{"id": -1, "name": -1, "category": -1, "supplier": -1, "quantity": -1, "status": -1}
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if key == "qty" {
+			key = "quantity"
+		}
+		if _, ok := columns[key]; ok {
+			columns[key] = i
+		}
+	}
+	for _, column := range []string{"name", "category", "supplier", "quantity"} {
+		if columns[column] < 0 {
+			return nil, fmt.Errorf("material list header must include name, category, supplier and quantity columns")
+		}
+	}
+
+	field := func(record []string, column string) string {
+		i := columns[column]
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	lines := []materialImportLine{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read material list: %w", err)
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		line, _ := reader.FieldPos(0) // Counts the blank lines the reader skips
+		if len(lines) == maxMaterialImportRows {
+			return nil, fmt.Errorf("material list exceeds the maximum of %d rows", maxMaterialImportRows)
+		}
+
+		entry := materialImportLine{line: line, material: models.Material{
+			Name:     field(record, "name"),
+			Category: field(record, "category"),
+			Supplier: field(record, "supplier"),
+			Status:   field(record, "status"),
+		}}
+		if id := field(record, "id"); id != "" {
+			if entry.material.ID, err = strconv.Atoi(id); err != nil || entry.material.ID <= 0 {
+				entry.errors = append(entry.errors, fmt.Sprintf("invalid id %q", id))
+			}
+		}
+		for _, column := range []string{"name", "category", "supplier"} {
+			if field(record, column) == "" {
+				entry.errors = append(entry.errors, column+" is required")
+			}
+		}
+		quantity := field(record, "quantity")
+		if entry.material.Quantity, err = strconv.Atoi(quantity); err != nil {
+			entry.errors = append(entry.errors, fmt.Sprintf("quantity %q is not a whole number", quantity))
+		} else if entry.material.Quantity < 0 {
+			entry.errors = append(entry.errors, "quantity cannot be negative")
+		}
+		switch models.AccessoryStatus(entry.material.Status) {
+		case "":
+			entry.material.Status = materialStatusFor(entry.material.Quantity)
+		case models.StatusInStock, models.StatusLowStock, models.StatusOutOfStock:
+		default:
+			entry.errors = append(entry.errors, fmt.Sprintf("status %q must be In Stock, Low Stock or Out of Stock", entry.material.Status))
+		}
+		lines = append(lines, entry)
+	}
+
+	return lines, nil
+}
+
+// materialStatusFor is the status of an imported material that has none
+func materialStatusFor(quantity int) string {
+	switch {
+	case quantity <= 0:
+		return string(models.StatusOutOfStock)
+	case quantity <= repositories.LowStockQuantity:
+		return string(models.StatusLowStock)
+	default:
+		return string(models.StatusInStock)
+	}
+}
+
+// planMaterialImport decides which rows insert and which update an existing
+// material, matched by id or else by name, and rejects rows that are invalid,
+// ambiguous or repeat an earlier row. It returns the report rows and the materials
+// to import, in file order.
+func planMaterialImport(lines []materialImportLine, existing []models.Material) ([]api.MaterialImportRow, []models.Material, map[int]models.Material) {
+	byID := make(map[int]models.Material, len(existing))
+	byName := make(map[string][]models.Material, len(existing))
+	for _, material := range existing {
+		byID[material.ID] = material
+		key := strings.ToLower(material.Name)
+		byName[key] = append(byName[key], material)
+	}
+
+	rows := make([]api.MaterialImportRow, 0, len(lines))
+	materials := []models.Material{}
+	before := make(map[int]models.Material)
+	seen := make(map[string]int) // Line of the first row for each target
+	for _, entry := range lines {
+		row := api.MaterialImportRow{Line: entry.line, Name: entry.material.Name, Quantity: entry.material.Quantity, Errors: entry.errors}
+		material := entry.material
+
+		if len(row.Errors) == 0 {
+			if material.ID != 0 {
+				if _, ok := byID[material.ID]; !ok {
+					row.Errors = append(row.Errors, fmt.Sprintf("no material with id %d", material.ID))
+				}
+			} else if matches := byName[strings.ToLower(material.Name)]; len(matches) > 1 {
+				row.Errors = append(row.Errors, fmt.Sprintf("%d materials are named %q; give the id of the one to update", len(matches), material.Name))
+			} else if len(matches) == 1 {
+				material.ID = matches[0].ID
+			}
+		}
+		if len(row.Errors) == 0 {
+			target := "name:" + strings.ToLower(material.Name)
+			if material.ID != 0 {
+				target = "id:" + strconv.Itoa(material.ID)
+			}
+			if first, ok := seen[target]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("same material as line %d", first))
+			} else {
+				seen[target] = entry.line
+			}
+		}
+
+		switch {
+		case len(row.Errors) > 0:
+			row.Action = api.MaterialImportReject
+		case material.ID != 0:
+			row.Action = api.MaterialImportUpdate
+			row.ID = material.ID
+			before[material.ID] = byID[material.ID]
+			materials = append(materials, material)
+		default:
+			row.Action = api.MaterialImportInsert
+			materials = append(materials, material)
+		}
+		rows = append(rows, row)
+	}
+	return rows, materials, before
+}
+
+// readMaterialUpload reads the material list from a multipart "file" field or a raw
+// text/csv body
+func readMaterialUpload(c *fiber.Ctx) ([]materialImportLine, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		if strings.EqualFold(filepath.Ext(fileHeader.Filename), ".xlsx") {
+			return nil, fmt.Errorf("XLSX files are not supported; save the sheet as CSV")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open uploaded material list: %w", err)
+		}
+		defer file.Close()
+		return parseMaterialImport(file)
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return nil, fmt.Errorf("a CSV material list is required")
+	}
+	return parseMaterialImport(strings.NewReader(string(body)))
+}
+
+// ImportMaterialsHandler handles importing a material list kept in a spreadsheet
+// @Summary Import materials from CSV
+// @Description Imports a CSV material list with name, category, supplier and quantity columns, and optional id and status columns. Rows update the material with their id, or else the one with their name, and insert a new material otherwise. Invalid rows are rejected and reported while the others are imported together. A missing status follows the quantity. Pass dry_run=true to only get the report.
+// @Tags Materials
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file false "CSV material list"
+// @Param dry_run query bool false "Only report what would be imported (default false)"
+// @Success 200 {object} api.MaterialImportResponse "Inserted, updated and rejected rows"
+// @Failure 400 {object} api.ErrorResponse "Invalid material list"
+// @Failure 500 {object} api.ErrorResponse "Failed to import materials"
+// @Router /materials/import [post]
+func (h *MaterialHandlers) ImportMaterialsHandler(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dry_run", false)
+
+	lines, err := readMaterialUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	if len(lines) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Material list contains no rows", StatusCode: fiber.StatusBadRequest})
+	}
+
+	existing, err := h.Repo.GetAll(c.Context(), models.MaterialFilter{})
+	if err != nil {
+		log.Printf("Error getting materials for import: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to import materials", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	rows, materials, before := planMaterialImport(lines, existing)
+	summary := map[api.MaterialImportAction]int{api.MaterialImportInsert: 0, api.MaterialImportUpdate: 0, api.MaterialImportReject: 0}
+	for _, row := range rows {
+		summary[row.Action]++
+	}
+
+	if !dryRun && len(materials) > 0 {
+		if err := h.Repo.BulkImport(c.Context(), materials); err != nil {
+			log.Printf("Error importing %d materials: %v", len(materials), err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to import materials", StatusCode: fiber.StatusInternalServerError})
+		}
+
+		// Materials are in the order of the rows that were not rejected
+		next := 0
+		for i := range rows {
+			if rows[i].Action == api.MaterialImportReject {
+				continue
+			}
+			material := materials[next]
+			next++
+			rows[i].ID = material.ID
+			if previous, ok := before[material.ID]; ok && rows[i].Action == api.MaterialImportUpdate {
+				material.Image, material.CreatedAt, material.UpdatedAt = previous.Image, previous.CreatedAt, previous.UpdatedAt
+				h.Audit.RecordUpdate(c, AuditEntityMaterial, strconv.Itoa(material.ID), previous, material)
+				h.Alerts.StockChanged(c, AuditEntityMaterial, material.ID, material.Name, previous.Quantity, material.Quantity)
+			}
+		}
+		h.Audit.RecordAction(c, AuditActionImportMaterials, AuditEntityMaterial, "",
+			fmt.Sprintf("Imported materials: %d inserted, %d updated, %d rejected",
+				summary[api.MaterialImportInsert], summary[api.MaterialImportUpdate], summary[api.MaterialImportReject]))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.MaterialImportResponse{DryRun: dryRun, Summary: summary, Rows: rows})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMaterialImportTestApp registers the material routes on an in-memory store
+// holding Plywood and two sheets both named Steel Sheet
+func setupMaterialImportTestApp(t *testing.T) (*fiber.App, *memory.Store, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	for _, material := range []models.Material{
+		{Name: "Plywood", Category: "Lumber", Supplier: "Wood Works", Quantity: 5, Status: "Low Stock"},
+		{Name: "Steel Sheet", Category: "Building", Supplier: "Steel Co.", Quantity: 40, Status: "In Stock"},
+		{Name: "Steel Sheet", Category: "Building", Supplier: "Metal Mart", Quantity: 10, Status: "In Stock"},
+	} {
+		material := material
+		_, err := store.Materials.Create(context.Background(), &material)
+		require.NoError(t, err)
+	}
+
+	materials := NewMaterialHandlers(store.Materials, jwtSecret)
+	materials.Audit = NewChangeRecorder(store.Logs)
+	app := fiber.New()
+	materials.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+}
+
+// uploadMaterialList sends a material list in the multipart "file" field
+func uploadMaterialList(t *testing.T, app *fiber.App, token, query, fileName, data string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/materials/import"+query, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+const testMaterialList = `Name,Category,Supplier,Qty,Status
+Rivets,Hardware,Steel Co.,100,
+plywood,Lumber,Timber Inc.,25,In Stock
+Steel Sheet,Building,Steel Co.,30,In Stock
+Paint,Finishing,,-2,Wet
+Rivets,Hardware,Steel Co.,50,
+
+Washers,Hardware,Steel Co.,2,
+`
+
+func TestImportMaterials(t *testing.T) {
+	t.Run("Dry run", func(t *testing.T) {
+		app, store, token := setupMaterialImportTestApp(t)
+
+		resp := uploadMaterialList(t, app, token, "?dry_run=true", "materials.csv", testMaterialList)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var report api.MaterialImportResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		assert.True(t, report.DryRun)
+		assert.Equal(t, map[api.MaterialImportAction]int{api.MaterialImportInsert: 2, api.MaterialImportUpdate: 1, api.MaterialImportReject: 3}, report.Summary)
+		require.Len(t, report.Rows, 6)
+
+		assert.Equal(t, api.MaterialImportRow{Line: 2, Action: api.MaterialImportInsert, Name: "Rivets", Quantity: 100}, report.Rows[0])
+		assert.Equal(t, api.MaterialImportUpdate, report.Rows[1].Action, "matched by name regardless of case")
+		assert.Equal(t, 1, report.Rows[1].ID)
+		assert.Contains(t, report.Rows[2].Errors[0], "2 materials are named")
+		assert.Len(t, report.Rows[3].Errors, 3, "supplier, quantity and status")
+		assert.Equal(t, []string{"same material as line 2"}, report.Rows[4].Errors)
+		assert.Equal(t, 8, report.Rows[5].Line, "blank lines are skipped")
+
+		all, err := store.Materials.GetAll(context.Background(), models.MaterialFilter{})
+		require.NoError(t, err)
+		assert.Len(t, all, 3, "nothing is saved")
+	})
+
+	t.Run("Import", func(t *testing.T) {
+		app, store, token := setupMaterialImportTestApp(t)
+
+		resp := uploadMaterialList(t, app, token, "", "materials.csv", testMaterialList)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var report api.MaterialImportResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		assert.False(t, report.DryRun)
+		require.NotZero(t, report.Rows[0].ID)
+
+		rivets, err := store.Materials.GetByID(context.Background(), report.Rows[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "In Stock", rivets.Status, "status follows the quantity")
+		washers, err := store.Materials.GetByID(context.Background(), report.Rows[5].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Low Stock", washers.Status)
+		plywood, err := store.Materials.GetByID(context.Background(), 1)
+		require.NoError(t, err)
+		assert.Equal(t, "plywood", plywood.Name)
+		assert.Equal(t, 25, plywood.Quantity)
+
+		logs, _, err := store.Logs.GetLogs(1, 10)
+		require.NoError(t, err)
+		actions := []string{}
+		for _, entry := range logs {
+			actions = append(actions, entry.Action)
+		}
+		assert.ElementsMatch(t, []string{"UPDATE_MATERIAL", AuditActionImportMaterials}, actions)
+	})
+
+	t.Run("Update by id", func(t *testing.T) {
+		app, store, token := setupMaterialImportTestApp(t)
+
+		resp := authedCSVRequest(t, app, token, "id,name,category,supplier,quantity\n3,Steel Sheet,Building,Metal Mart,12\n42,Ghost,Building,Metal Mart,1\n")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var report api.MaterialImportResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+		assert.Equal(t, api.MaterialImportUpdate, report.Rows[0].Action)
+		assert.Equal(t, []string{"no material with id 42"}, report.Rows[1].Errors)
+
+		sheet, err := store.Materials.GetByID(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, 12, sheet.Quantity)
+	})
+
+	t.Run("Invalid upload", func(t *testing.T) {
+		app, _, token := setupMaterialImportTestApp(t)
+
+		for name, resp := range map[string]*http.Response{
+			"missing columns": authedCSVRequest(t, app, token, "name,quantity\nRivets,5\n"),
+			"header only":     authedCSVRequest(t, app, token, "name,category,supplier,quantity\n"),
+			"xlsx":            uploadMaterialList(t, app, token, "", "materials.xlsx", "PK"),
+		} {
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		}
+	})
+}
+
+// authedCSVRequest posts a material list as a raw text/csv body
+func authedCSVRequest(t *testing.T, app *fiber.App, token, data string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/materials/import", strings.NewReader(data))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
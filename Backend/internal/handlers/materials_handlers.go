@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"log"
 	"strconv"
 
-	"oop/internal/middleware"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
 	"oop/internal/config"
@@ -16,6 +19,11 @@ import (
 type MaterialHandlers struct {
 	Repo      repositories.MaterialRepository
 	jwtSecret []byte
+	Audit     *ChangeRecorder   // Optional; records field-level changes to the activity log
+	Undo      *UndoHandler      // Optional; lets deletes be undone for a while
+	Trash     *TrashHandler     // Optional; records who deleted the material
+	Alerts    *Alerts           // Optional; publishes the material running out of stock
+	Gallery   *ItemImageHandler // Optional; adds the photo gallery to the material's details
 }
 
 // NewMaterialHandlers creates a new instance of MaterialHandlers
@@ -26,25 +34,25 @@ func NewMaterialHandlers(repo repositories.MaterialRepository, jwtSecret []byte)
 	}
 }
 
-// RegisterMaterialRoutes sets up the routes for material operations within the provided Fiber router
-func (h *MaterialHandlers) RegisterMaterialRoutes(r fiber.Router) {
-	// Define middleware - Use the imported middleware package and the injected jwtSecret
-	authRequired := middleware.JWTMiddleware(h.jwtSecret)
-
+// Routes sets up the routes for material operations within the provided Fiber router
+func (h *MaterialHandlers) Routes(r fiber.Router, mw RouteMiddleware) {
 	// Group routes under '/materials'
-	materialsGroup := r.Group("/materials", authRequired)
-
-	materialsGroup.Get("/", h.GetMaterialsHandler)                   // GET /api/materials?params...
-	materialsGroup.Get("/paginated", h.GetPaginatedMaterialsHandler) // GET /api/materials/paginated?page=1&limit=10
-	materialsGroup.Get("/:id", h.GetMaterialHandler)                 // GET /api/materials/{id}
-	materialsGroup.Post("/", h.CreateMaterialHandler)                // POST /api/materials
-	materialsGroup.Put("/:id", h.UpdateMaterialHandler)              // PUT /api/materials/{id}
-	materialsGroup.Delete("/:id", h.DeleteMaterialHandler)           // DELETE /api/materials/{id}
+	materialsGroup := r.Group("/materials", mw.Auth, mw.Staff)
+
+	materialsGroup.Get("/", mw.IncludeDeleted, h.GetMaterialsHandler)       // GET /api/materials?params...
+	materialsGroup.Get("/paginated", h.GetPaginatedMaterialsHandler)        // GET /api/materials/paginated?page=1&limit=10
+	materialsGroup.Get("/:id", h.GetMaterialHandler)                        // GET /api/materials/{id}
+	materialsGroup.Post("/", mw.Dedupe, h.CreateMaterialHandler)            // POST /api/materials
+	materialsGroup.Post("/import", h.ImportMaterialsHandler)                // POST /api/materials/import?dry_run=true
+	materialsGroup.Post("/batch", h.BatchMaterialsHandler)                  // POST /api/materials/batch
+	materialsGroup.Put("/:id", h.UpdateMaterialHandler)                     // PUT /api/materials/{id}
+	materialsGroup.Delete("/:id", h.DeleteMaterialHandler)                  // DELETE /api/materials/{id}
+	materialsGroup.Post("/:id/restore", mw.Admin, h.RestoreMaterialHandler) // POST /api/materials/{id}/restore
 }
 
 // GetMaterialsHandler handles requests to retrieve multiple materials with filtering
 // @Summary Get all materials
-// @Description Retrieves a list of materials, with optional filtering.
+// @Description Retrieves a list of materials, with optional filtering. Admins can add include_deleted=true, on its own, to list the deleted materials after the others.
 // @Tags Materials
 // @Produce json
 // @Security ApiKeyAuth
@@ -52,20 +60,22 @@ func (h *MaterialHandlers) RegisterMaterialRoutes(r fiber.Router) {
 // @Param category query string false "Filter by category"
 // @Param supplier query string false "Filter by supplier"
 // @Param status query string false "Filter by status (e.g., In Stock, Low Stock)"
+// @Param include_deleted query bool false "Also list the deleted materials, with their deletedAt (admins only)"
 // @Success 200 {array} models.Material "Successfully retrieved list of materials"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve materials"
+// @Failure 400 {object} api.ErrorResponse "include_deleted combined with filters"
+// @Failure 403 {object} api.ErrorResponse "Only admins can list deleted materials"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve materials"
 // @Router /materials [get]
 func (h *MaterialHandlers) GetMaterialsHandler(c *fiber.Ctx) error {
-	// Extract query parameters for filtering
-	searchTerm := c.Query("search")
-	category := c.Query("category")
-	supplier := c.Query("supplier")
-	status := c.Query("status")
-
-	materials, err := h.Repo.GetAll(searchTerm, category, supplier, status)
+	materials, err := h.Repo.GetAll(c.Context(), materialFilter(c))
+	if err == nil && includeDeleted(c) {
+		var deleted []models.Material
+		deleted, err = h.Repo.GetDeleted(c.Context())
+		materials = append(materials, deleted...)
+	}
 	if err != nil {
 		log.Printf("Error getting materials: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to retrieve materials",
 			StatusCode: fiber.StatusInternalServerError,
 		})
@@ -74,6 +84,17 @@ func (h *MaterialHandlers) GetMaterialsHandler(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(materials)
 }
 
+// materialFilter reads the search, category, supplier and status query parameters
+// of the material listings
+func materialFilter(c *fiber.Ctx) models.MaterialFilter {
+	return models.MaterialFilter{
+		Search:   c.Query("search"),
+		Category: c.Query("category"),
+		Supplier: c.Query("supplier"),
+		Status:   c.Query("status"),
+	}
+}
+
 // GetMaterialHandler handles requests to retrieve a single material by ID
 // @Summary Get material by ID
 // @Description Retrieves a single material by its ID.
@@ -82,35 +103,37 @@ func (h *MaterialHandlers) GetMaterialsHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path int true "Material ID"
 // @Success 200 {object} models.Material "Successfully retrieved material"
-// @Failure 400 {object} ErrorResponse "Invalid Material ID format"
-// @Failure 404 {object} ErrorResponse "Material not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve material"
+// @Failure 400 {object} api.ErrorResponse "Invalid Material ID format"
+// @Failure 404 {object} api.ErrorResponse "Material not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve material"
 // @Router /materials/{id} [get]
 func (h *MaterialHandlers) GetMaterialHandler(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid Material ID format",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
 
-	material, err := h.Repo.GetByID(id)
+	material, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error getting material by ID %d: %v", id, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to retrieve material",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 	if material == nil {
-		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
 			Error:      "Material not found",
 			StatusCode: fiber.StatusNotFound,
 		})
 	}
 
+	material.Images = h.Gallery.Images(models.InventoryMaterial, id)
+	setLastModified(c, material.UpdatedAt)
 	return c.Status(fiber.StatusOK).JSON(material)
 }
 
@@ -123,14 +146,15 @@ func (h *MaterialHandlers) GetMaterialHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param material body models.Material true "Material object to create"
 // @Success 201 {object} models.Material "Material created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or missing required fields"
-// @Failure 500 {object} ErrorResponse "Failed to create material"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload or missing required fields"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero"
+// @Failure 500 {object} api.ErrorResponse "Failed to create material"
 // @Router /materials [post]
 func (h *MaterialHandlers) CreateMaterialHandler(c *fiber.Ctx) error {
 	var newMaterial models.Material
 	if err := c.BodyParser(&newMaterial); err != nil {
 		log.Printf("Error decoding create material request: %v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request payload",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -138,7 +162,7 @@ func (h *MaterialHandlers) CreateMaterialHandler(c *fiber.Ctx) error {
 
 	// Basic validation (could be expanded)
 	if newMaterial.Name == "" || newMaterial.Category == "" || newMaterial.Supplier == "" || newMaterial.Status == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Missing required material fields",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -149,17 +173,20 @@ func (h *MaterialHandlers) CreateMaterialHandler(c *fiber.Ctx) error {
 		newMaterial.Image = config.DefaultImageURL
 	}
 
-	id, err := h.Repo.Create(&newMaterial)
+	id, err := h.Repo.Create(c.Context(), &newMaterial)
 	if err != nil {
+		if conflict, err := rejectNegativeStock(c, err); conflict {
+			return err
+		}
 		log.Printf("Error creating material: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to create material",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
 	// Optionally fetch the full created object to get timestamps
-	createdMaterial, err := h.Repo.GetByID(id)
+	createdMaterial, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil || createdMaterial == nil {
 		log.Printf("Error fetching created material ID %d after creation: %v", id, err)
 		// Respond with the ID even if fetch fails, as creation succeeded
@@ -180,16 +207,19 @@ func (h *MaterialHandlers) CreateMaterialHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path int true "Material ID"
 // @Param material body models.Material true "Material object with updated fields"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
 // @Success 200 {object} models.Material "Material updated successfully"
 // @Success 204 "Material updated, but fetch failed (No Content)"
-// @Failure 400 {object} ErrorResponse "Invalid Material ID format or invalid request payload or missing required fields"
-// @Failure 500 {object} ErrorResponse "Failed to update material"
+// @Failure 400 {object} api.ErrorResponse "Invalid Material ID format or invalid request payload or missing required fields"
+// @Failure 409 {object} api.ErrorResponse "Quantity cannot go below zero"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update material"
 // @Router /materials/{id} [put]
 func (h *MaterialHandlers) UpdateMaterialHandler(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid Material ID format",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -198,7 +228,7 @@ func (h *MaterialHandlers) UpdateMaterialHandler(c *fiber.Ctx) error {
 	var updatedMaterial models.Material
 	if err := c.BodyParser(&updatedMaterial); err != nil {
 		log.Printf("Error decoding update material request for ID %d: %v", id, err)
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request payload",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -209,7 +239,7 @@ func (h *MaterialHandlers) UpdateMaterialHandler(c *fiber.Ctx) error {
 
 	// Basic validation
 	if updatedMaterial.Name == "" || updatedMaterial.Category == "" || updatedMaterial.Supplier == "" || updatedMaterial.Status == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Missing required material fields",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -220,24 +250,45 @@ func (h *MaterialHandlers) UpdateMaterialHandler(c *fiber.Ctx) error {
 		updatedMaterial.Image = config.DefaultImageURL
 	}
 
-	err = h.Repo.Update(&updatedMaterial)
+	// Capture the previous state for conditional updates, the activity log diff and stock-out alerts
+	var before *models.Material
+	if h.Audit.Enabled() || h.Alerts.Enabled() || hasUpdatePrecondition(c) {
+		if before, err = h.Repo.GetByID(c.Context(), id); err != nil {
+			log.Printf("Error fetching material ID %d before update: %v", id, err)
+		} else if before != nil {
+			if modified, err := rejectIfModified(c, before.UpdatedAt); modified {
+				return err
+			}
+		}
+	}
+
+	err = h.Repo.Update(c.Context(), &updatedMaterial)
 	if err != nil {
+		if conflict, err := rejectNegativeStock(c, err); conflict {
+			return err
+		}
 		log.Printf("Error updating material ID %d: %v", id, err)
 		// Could check for specific errors like 'not found' if the repo layer provides them
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to update material",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
 	// Fetch the updated object to return the latest state including UpdatedAt
-	finalMaterial, err := h.Repo.GetByID(id)
+	finalMaterial, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil || finalMaterial == nil {
 		log.Printf("Error fetching updated material ID %d after update: %v", id, err)
 		// Update succeeded, but fetch failed. Return No Content.
 		return c.SendStatus(fiber.StatusNoContent)
 	}
 
+	if before != nil {
+		h.Audit.RecordUpdate(c, AuditEntityMaterial, idStr, before, finalMaterial)
+		h.Alerts.StockChanged(c, AuditEntityMaterial, id, finalMaterial.Name, before.Quantity, finalMaterial.Quantity)
+	}
+
+	setLastModified(c, finalMaterial.UpdatedAt)
 	return c.Status(fiber.StatusOK).JSON(finalMaterial)
 }
 
@@ -248,38 +299,209 @@ func (h *MaterialHandlers) UpdateMaterialHandler(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path int true "Material ID"
-// @Success 204 "Material deleted successfully (No Content)"
-// @Failure 400 {object} ErrorResponse "Invalid Material ID format"
-// @Failure 500 {object} ErrorResponse "Failed to delete material"
+// @Success 204 "Material deleted successfully (No Content). The X-Undo-Token header holds a token for POST /undo/{token}, valid until X-Undo-Expires-At."
+// @Failure 400 {object} api.ErrorResponse "Invalid Material ID format"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete material"
 // @Router /materials/{id} [delete]
 func (h *MaterialHandlers) DeleteMaterialHandler(c *fiber.Ctx) error {
 	idStr := c.Params("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid Material ID format",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
 
-	err = h.Repo.Delete(id)
+	err = h.Repo.Delete(c.Context(), id)
 	if err != nil {
 		log.Printf("Error deleting material ID %d: %v", id, err)
 		// Could check for specific errors like 'not found'
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to delete material",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
+	h.Trash.Deleted(c, models.TrashMaterial, strconv.Itoa(id))
+	h.Undo.Offer(c, AuditEntityMaterial, strconv.Itoa(id))
 	return c.SendStatus(fiber.StatusNoContent) // Standard response for successful deletion
 }
 
+// BatchMaterialsHandler handles creating, updating and deleting many materials at once
+// @Summary Create, update and delete materials in one batch
+// @Description Applies up to 500 operations on materials in one transaction, so either every one is applied or none is. A create takes the material as POST /materials does and an update the material as PUT /materials/{id} does, both with a name, category, supplier and status; a delete only needs the ID. An item may be updated or deleted by one operation of a batch.
+// @Description Each operation has a result with the status it would have had on its own. When an operation fails, nothing is applied: the response has the status of the first that failed, and the operations that did not fail have 424.
+// @Tags Materials
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param batch body api.MaterialBatchRequest true "Operations, applied in order"
+// @Success 200 {object} api.BatchResponse "Every operation applied"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload, or no or more than 500 operations"
+// @Failure 404 {object} api.BatchResponse "Not applied: an operation's material does not exist"
+// @Failure 409 {object} api.BatchResponse "Not applied: an operation takes a quantity below zero"
+// @Failure 422 {object} api.BatchResponse "Not applied: an operation is invalid"
+// @Failure 500 {object} api.ErrorResponse "Failed to apply material batch"
+// @Router /materials/batch [post]
+func (h *MaterialHandlers) BatchMaterialsHandler(c *fiber.Ctx) error {
+	var req api.MaterialBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.Printf("Error decoding material batch request: %v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid request payload",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+	if rejected, err := rejectBatchSize(c, len(req.Operations)); rejected {
+		return err
+	}
+
+	plan := newBatchPlan[models.Material]("material", len(req.Operations))
+	for i, op := range req.Operations {
+		if !plan.check(i, op.Op, op.ID, op.Item != nil) {
+			continue
+		}
+		if err := h.planMaterial(c, plan, i, op); err != nil {
+			log.Printf("Error checking operation %d of material batch: %v", i, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Failed to apply material batch",
+				StatusCode: fiber.StatusInternalServerError,
+			})
+		}
+	}
+	if applied, err := plan.apply(c, h.Repo.ApplyBatch); !applied {
+		return err
+	}
+
+	for i, change := range plan.changes {
+		material := change.Item
+		switch change.Op {
+		case models.BatchUpdate:
+			before := plan.before[i]
+			h.Audit.RecordUpdate(c, AuditEntityMaterial, strconv.Itoa(material.ID), before, &material)
+			h.Alerts.StockChanged(c, AuditEntityMaterial, material.ID, material.Name, before.Quantity, material.Quantity)
+		case models.BatchDelete:
+			h.Trash.Deleted(c, models.TrashMaterial, strconv.Itoa(change.ID))
+		}
+	}
+	return plan.respond(c, h.Audit, AuditEntityMaterial, func(material models.Material) int { return material.ID })
+}
+
+// planMaterial checks operation i of a material batch against the material it
+// changes, as CreateMaterialHandler and UpdateMaterialHandler would, and adds its
+// change to the plan. It only fails when the material cannot be read.
+func (h *MaterialHandlers) planMaterial(c *fiber.Ctx, plan *batchPlan[models.Material], i int, op api.MaterialBatchOperation) error {
+	var before *models.Material
+	if op.Op != models.BatchCreate {
+		existing, err := h.Repo.GetByID(c.Context(), op.ID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			plan.notFound(i)
+			return nil
+		}
+		before = existing
+	}
+	if op.Op == models.BatchDelete {
+		plan.add(i, op.Op, op.ID, models.Material{}, before)
+		return nil
+	}
+
+	material := *op.Item
+	if material.Name == "" || material.Category == "" || material.Supplier == "" || material.Status == "" {
+		plan.reject(i, fiber.StatusUnprocessableEntity, "Missing required material fields")
+		return nil
+	}
+	if material.Quantity < 0 {
+		plan.reject(i, fiber.StatusConflict, "Quantity cannot go below zero")
+		return nil
+	}
+	if material.Image == "null" || material.Image == "" {
+		material.Image = config.DefaultImageURL
+	}
+	material.ID = op.ID
+
+	plan.add(i, op.Op, op.ID, material, before)
+	return nil
+}
+
+// RestoreMaterialHandler handles requests to restore a deleted material
+// @Summary Restore a deleted material (Admin)
+// @Description Restores a deleted material, with the stock it had when it was deleted.
+// @Tags Materials
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Material ID"
+// @Success 200 {object} models.Material "Restored material"
+// @Failure 400 {object} api.ErrorResponse "Invalid Material ID format"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Material is not deleted, or does not exist"
+// @Failure 500 {object} api.ErrorResponse "Failed to restore material"
+// @Router /materials/{id}/restore [post]
+func (h *MaterialHandlers) RestoreMaterialHandler(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid Material ID format",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	if err := h.Repo.Restore(c.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Material with ID %d is not deleted", id),
+				StatusCode: fiber.StatusNotFound,
+			})
+		}
+		log.Printf("Error restoring material ID %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to restore material",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	h.Audit.RecordAction(c, trashRestore.auditAction, AuditEntityMaterial, strconv.Itoa(id), fmt.Sprintf("Restored deleted material %d", id))
+
+	material, err := h.Repo.GetByID(c.Context(), id)
+	if err != nil {
+		log.Printf("Error getting restored material ID %d: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve restored material",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(material)
+}
+
 // Maximum number of items that can be requested per page
 const maxPageLimit = 100
 
 // GetPaginatedMaterialsHandler handles requests to retrieve paginated materials
 func (h *MaterialHandlers) GetPaginatedMaterialsHandler(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+
+	materials, total, err := h.Repo.GetPaginated(c.Context(), page, limit, materialFilter(c))
+	if err != nil {
+		log.Printf("Error getting paginated materials: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to retrieve materials",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"materials":  materials,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+		"totalPages": (total + int64(limit) - 1) / int64(limit),
+	})
+}
+
+// pageParams reads the page and limit query parameters, falling back to page 1 and
+// 10 items per page and capping the limit at maxPageLimit
+func pageParams(c *fiber.Ctx) (int, int) {
 	pageStr := c.Query("page", "1")
 	limitStr := c.Query("limit", "10")
 
@@ -310,24 +532,5 @@ func (h *MaterialHandlers) GetPaginatedMaterialsHandler(c *fiber.Ctx) error {
 		limit = maxPageLimit
 	}
 
-	searchTerm := c.Query("search")
-	category := c.Query("category")
-	supplier := c.Query("supplier")
-	status := c.Query("status")
-
-	materials, total, err := h.Repo.GetPaginated(page, limit, searchTerm, category, supplier, status)
-	if err != nil {
-		log.Printf("Error getting paginated materials: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve materials",
-		})
-	}
-
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"materials":  materials,
-		"total":      total,
-		"page":       page,
-		"limit":      limit,
-		"totalPages": (total + int64(limit) - 1) / int64(limit),
-	})
+	return page, limit
 }
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -20,25 +21,27 @@ import (
 )
 
 // MockMaterialRepository is a mock type for the MaterialRepository interface
+// The context is not recorded with the calls: handlers pass the request's fasthttp
+// context, which is reused once the request ends.
 type MockMaterialRepository struct {
 	mock.Mock
 }
 
 // GetPaginated implements repositories.MaterialRepository.
-func (m *MockMaterialRepository) GetPaginated(page int, limit int, searchTerm string, category string, supplier string, status string) ([]models.Material, int64, error) {
-	args := m.Called(page, limit, searchTerm, category, supplier, status)
+func (m *MockMaterialRepository) GetPaginated(ctx context.Context, page int, limit int, filter models.MaterialFilter) ([]models.Material, int64, error) {
+	args := m.Called(page, limit, filter)
 	if args.Get(0) == nil {
 		return nil, 0, args.Error(2)
 	}
 	return args.Get(0).([]models.Material), args.Get(1).(int64), args.Error(2)
 }
 
-func (m *MockMaterialRepository) GetAll(searchTerm string, category string, supplier string, status string) ([]models.Material, error) {
-	args := m.Called(searchTerm, category, supplier, status)
+func (m *MockMaterialRepository) GetAll(ctx context.Context, filter models.MaterialFilter) ([]models.Material, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]models.Material), args.Error(1)
 }
 
-func (m *MockMaterialRepository) GetByID(id int) (*models.Material, error) {
+func (m *MockMaterialRepository) GetByID(ctx context.Context, id int) (*models.Material, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -46,21 +49,41 @@ func (m *MockMaterialRepository) GetByID(id int) (*models.Material, error) {
 	return args.Get(0).(*models.Material), args.Error(1)
 }
 
-func (m *MockMaterialRepository) Create(material *models.Material) (int, error) {
+func (m *MockMaterialRepository) Create(ctx context.Context, material *models.Material) (int, error) {
 	args := m.Called(material)
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockMaterialRepository) Update(material *models.Material) error {
+func (m *MockMaterialRepository) Update(ctx context.Context, material *models.Material) error {
 	args := m.Called(material)
 	return args.Error(0)
 }
 
-func (m *MockMaterialRepository) Delete(id int) error {
+func (m *MockMaterialRepository) Delete(ctx context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockMaterialRepository) Restore(ctx context.Context, id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockMaterialRepository) GetDeleted(ctx context.Context) ([]models.Material, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Material), args.Error(1)
+}
+
+func (m *MockMaterialRepository) BulkImport(ctx context.Context, materials []models.Material) error {
+	args := m.Called(materials)
+	return args.Error(0)
+}
+
+func (m *MockMaterialRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Material]) error {
+	args := m.Called(changes)
+	return args.Error(0)
+}
+
 // Helper function to create a test JWT token
 func createTestToken(secret []byte, userID uint, userRole string) (string, error) {
 	claims := jwt.MapClaims{
@@ -76,7 +99,7 @@ func setupMaterialTestApp(repo repositories.MaterialRepository, jwtSecret []byte
 	app := fiber.New()
 	h := NewMaterialHandlers(repo, jwtSecret)
 	api := app.Group("/api") // Match the main setup
-	h.RegisterMaterialRoutes(api)
+	h.Routes(api, NewRouteMiddleware(jwtSecret))
 	return app
 }
 
@@ -94,7 +117,7 @@ func TestGetMaterialsHandler(t *testing.T) {
 	}
 
 	t.Run("Success - No Filters", func(t *testing.T) {
-		mockRepo.On("GetAll", "", "", "", "").Return(expectedMaterials, nil).Once()
+		mockRepo.On("GetAll", models.MaterialFilter{}).Return(expectedMaterials, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/materials", nil)
 		req.Header.Set("Authorization", "Bearer "+testToken)
@@ -115,7 +138,7 @@ func TestGetMaterialsHandler(t *testing.T) {
 
 	t.Run("Success - With Filters", func(t *testing.T) {
 		filteredMaterials := []models.Material{expectedMaterials[0]}
-		mockRepo.On("GetAll", "search", "C1", "S1", "Active").Return(filteredMaterials, nil).Once()
+		mockRepo.On("GetAll", models.MaterialFilter{Search: "search", Category: "C1", Supplier: "S1", Status: "Active"}).Return(filteredMaterials, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/materials?search=search&category=C1&supplier=S1&status=Active", nil)
 		req.Header.Set("Authorization", "Bearer "+testToken)
@@ -135,7 +158,7 @@ func TestGetMaterialsHandler(t *testing.T) {
 	})
 
 	t.Run("Repository Error", func(t *testing.T) {
-		mockRepo.On("GetAll", "", "", "", "").Return([]models.Material{}, errors.New("db error")).Once()
+		mockRepo.On("GetAll", models.MaterialFilter{}).Return([]models.Material{}, errors.New("db error")).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/materials", nil)
 		req.Header.Set("Authorization", "Bearer "+testToken)
@@ -478,7 +501,7 @@ func TestGetPaginatedMaterialsHandler(t *testing.T) {
 	}
 
 	t.Run("Success - Default Pagination", func(t *testing.T) {
-		mockRepo.On("GetPaginated", 1, 10, "", "", "", "").Return(expectedMaterials, int64(2), nil).Once()
+		mockRepo.On("GetPaginated", 1, 10, models.MaterialFilter{}).Return(expectedMaterials, int64(2), nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/materials/paginated", nil)
 		req.Header.Set("Authorization", "Bearer "+testToken)
@@ -501,7 +524,7 @@ func TestGetPaginatedMaterialsHandler(t *testing.T) {
 	})
 
 	t.Run("Success - With Filters and Custom Pagination", func(t *testing.T) {
-		mockRepo.On("GetPaginated", 2, 5, "search", "C1", "S1", "Active").Return(expectedMaterials[:1], int64(1), nil).Once()
+		mockRepo.On("GetPaginated", 2, 5, models.MaterialFilter{Search: "search", Category: "C1", Supplier: "S1", Status: "Active"}).Return(expectedMaterials[:1], int64(1), nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/materials/paginated?page=2&limit=5&search=search&category=C1&supplier=S1&status=Active", nil)
 		req.Header.Set("Authorization", "Bearer "+testToken)
@@ -523,7 +546,7 @@ func TestGetPaginatedMaterialsHandler(t *testing.T) {
 	})
 
 	t.Run("Repository Error", func(t *testing.T) {
-		mockRepo.On("GetPaginated", 1, 10, "", "", "", "").Return([]models.Material{}, int64(0), errors.New("db error")).Once()
+		mockRepo.On("GetPaginated", 1, 10, models.MaterialFilter{}).Return([]models.Material{}, int64(0), errors.New("db error")).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/materials/paginated", nil)
 		req.Header.Set("Authorization", "Bearer "+testToken)
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureCustomerID is the seeded customer who bought the seeded cab sale
+const fixtureCustomerID = "0b8f6c1e-3f1a-4d2b-9c6e-1a2b3c4d5e01"
+
+// setupMemoryStoreTestApp registers the customer and sale routes on top of a seeded
+// in-memory store, so flows spanning several repositories can be tested without mocks.
+func setupMemoryStoreTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	store, err := memory.NewSeededStore()
+	require.NoError(t, err)
+
+	jwtSecret := []byte("testsecret")
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+
+	customerHandler := NewCustomerHandler(store.Customers, jwtSecret)
+	customerHandler.Sales = store.Sales
+	customerHandler.Audit = NewChangeRecorder(store.Logs)
+	customerHandler.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+
+	saleHandler := NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret)
+	saleHandler.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+
+	return app, store, jwtSecret
+}
+
+func TestSellCabWithMemoryStore(t *testing.T) {
+	app, store, jwtSecret := setupMemoryStoreTestApp(t)
+	token, _ := createCustomerTestToken(jwtSecret, "staff-1", RoleStaff)
+
+	payload, _ := json.Marshal(models.CabSalePayload{
+		CustomerID:  fixtureCustomerID,
+		Quantity:    2,
+		Accessories: []models.AccessoryForSale{{ID: 2, Quantity: 1}},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/cabs/1/sell", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var sold struct {
+		SaleID     string  `json:"saleId"`
+		TotalPrice float64 `json:"totalPrice"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sold))
+	assert.Equal(t, 2*185000.0+1200.0, sold.TotalPrice)
+
+	items, err := store.Sales.GetSaleItems(context.Background(), sold.SaleID)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	// The sold units are taken out of stock, and more than are left cannot be sold
+	cab, err := store.Cabs.GetCabByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cab.Quantity)
+	payload, _ = json.Marshal(models.CabSalePayload{CustomerID: fixtureCustomerID, Quantity: 4})
+	req = httptest.NewRequest(http.MethodPost, "/api/cabs/1/sell", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/customers/"+fixtureCustomerID+"/sales", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var sales []models.Sale
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sales))
+	if assert.Len(t, sales, 2, "the seeded sale and the new one") {
+		assert.Equal(t, "staff-1", sales[0].SoldBy)
+	}
+}
+
+func TestCustomerPrivacyWithMemoryStore(t *testing.T) {
+	app, store, jwtSecret := setupMemoryStoreTestApp(t)
+	token, _ := createCustomerTestToken(jwtSecret, "admin-1", RoleAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/customers/"+fixtureCustomerID+"/data-export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var export api.CustomerDataExport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&export))
+	assert.Equal(t, "Juan Dela Cruz", export.Customer.FullName)
+	if assert.Len(t, export.Sales, 1) {
+		assert.Len(t, export.Sales[0].Items, 2)
+	}
+
+	for _, wantStatus := range []int{http.StatusOK, http.StatusConflict} {
+		req = httptest.NewRequest(http.MethodPost, "/api/customers/"+fixtureCustomerID+"/anonymize", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, wantStatus, resp.StatusCode)
+	}
+
+	customer, err := store.Customers.GetCustomerByID(context.Background(), fixtureCustomerID)
+	require.NoError(t, err)
+	assert.Empty(t, customer.Phone)
+	assert.True(t, isAnonymizedCustomer(customer))
+
+	logs, total, err := store.Logs.GetBasedOnFilter(1, 10, "", "CUSTOMER", "", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, entry := range logs {
+		assert.NotContains(t, entry.Details, "Juan", "audit entries must not copy personal data")
+	}
+}
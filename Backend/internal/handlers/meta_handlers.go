@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"oop/internal/api"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers announcing that an endpoint is deprecated (RFC 9745) and when it goes away (RFC 8594)
+const (
+	HeaderDeprecation = "Deprecation"
+	HeaderSunset      = "Sunset"
+)
+
+// changelogDateLayout is how dates are written in the changelog
+const changelogDateLayout = "2006-01-02"
+
+// APISpec provides the Swagger document generated by swag at build time
+type APISpec interface {
+	ReadDoc() string
+}
+
+// Changelog is the record of API changes and deprecated endpoints served by MetaHandler
+type Changelog struct {
+	Changes      []api.APIChange // Newest first
+	Deprecations []api.Deprecation
+}
+
+// MetaHandler serves machine-readable descriptions of the API
+type MetaHandler struct {
+	Spec      APISpec
+	Changelog Changelog
+}
+
+// NewMetaHandler creates a new MetaHandler instance serving APIChangelog
+func NewMetaHandler(spec APISpec) *MetaHandler {
+	return &MetaHandler{Spec: spec, Changelog: APIChangelog}
+}
+
+// Routes registers the public API description routes
+func (h *MetaHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	metaGroup := r.Group("/meta")
+	metaGroup.Get("/openapi.json", h.GetOpenAPISpec)  // GET /api/meta/openapi.json
+	metaGroup.Get("/postman", h.GetPostmanCollection) // GET /api/meta/postman
+	metaGroup.Get("/changelog", h.GetChangelog)       // GET /api/meta/changelog
+}
+
+// GetOpenAPISpec returns the API spec of the running version
+// @Summary Get the API spec
+// @Description Returns the Swagger 2.0 document of the running API version, as generated at build time.
+// @Tags Meta
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Swagger 2.0 document"
+// @Router /meta/openapi.json [get]
+func (h *MetaHandler) GetOpenAPISpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Status(fiber.StatusOK).SendString(h.Spec.ReadDoc())
+}
+
+// GetPostmanCollection converts the API spec into a Postman collection
+// @Summary Get a Postman collection
+// @Description Returns a Postman v2.1 collection with one folder per tag. Requests target the {{baseUrl}} variable and authenticate with the {{token}} variable.
+// @Tags Meta
+// @Produce json
+// @Success 200 {object} services.PostmanCollection "Postman collection"
+// @Failure 500 {object} api.ErrorResponse "Failed to build Postman collection"
+// @Router /meta/postman [get]
+func (h *MetaHandler) GetPostmanCollection(c *fiber.Ctx) error {
+	collection, err := services.BuildPostmanCollection([]byte(h.Spec.ReadDoc()), c.BaseURL())
+	if err != nil {
+		log.Printf("Error building Postman collection: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to build Postman collection",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	filename := "api.postman_collection.json"
+	if collection.Info.Version != "" {
+		filename = "api-v" + collection.Info.Version + ".postman_collection.json"
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+
+	return c.Status(fiber.StatusOK).JSON(collection)
+}
+
+// GetChangelog returns the API changelog and the deprecated endpoints
+// @Summary Get the API changelog
+// @Description Lists the changes to the API, newest first, and the endpoints that are deprecated with their sunset dates and replacements. Pass since=YYYY-MM-DD to only get the changes made after that day. Deprecations are always listed in full.
+// @Tags Meta
+// @Produce json
+// @Param since query string false "Only changes after this date (YYYY-MM-DD)"
+// @Success 200 {object} api.ChangelogResponse "API changelog"
+// @Failure 400 {object} api.ErrorResponse "Invalid since date"
+// @Router /meta/changelog [get]
+func (h *MetaHandler) GetChangelog(c *fiber.Ctx) error {
+	changes := h.Changelog.Changes
+	if since := c.Query("since"); since != "" {
+		if _, err := time.Parse(changelogDateLayout, since); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      "since must be a date in YYYY-MM-DD format",
+				StatusCode: fiber.StatusBadRequest,
+			})
+		}
+		changes = []api.APIChange{}
+		for _, change := range h.Changelog.Changes {
+			if change.Date > since { // Dates in the same layout compare in calendar order
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	var spec struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal([]byte(h.Spec.ReadDoc()), &spec); err != nil {
+		log.Printf("Error reading API version from spec: %v", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.ChangelogResponse{
+		Version:      spec.Info.Version,
+		Changes:      changes,
+		Deprecations: h.Changelog.Deprecations,
+	})
+}
+
+// DeprecationHeaders creates a middleware that marks the responses of deprecated
+// endpoints with Deprecation and Sunset headers, and links to the changelog for
+// the details, so clients can detect the deprecation without polling the changelog.
+func (h *MetaHandler) DeprecationHeaders() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, deprecation := range h.Changelog.Deprecations {
+			if deprecation.Method != c.Method() || !matchRoutePattern(deprecation.Path, c.Path()) {
+				continue
+			}
+			if deprecatedOn, err := time.Parse(changelogDateLayout, deprecation.DeprecatedOn); err == nil {
+				c.Set(HeaderDeprecation, fmt.Sprintf("@%d", deprecatedOn.Unix()))
+			}
+			if sunset, err := time.Parse(changelogDateLayout, deprecation.Sunset); err == nil {
+				c.Set(HeaderSunset, sunset.UTC().Format(http.TimeFormat))
+			}
+			c.Append(fiber.HeaderLink, `</api/meta/changelog>; rel="deprecation"; type="application/json"`)
+			break
+		}
+		return c.Next()
+	}
+}
+
+// matchRoutePattern reports whether a request path matches a route pattern whose
+// :param segments match any one segment, e.g. /api/cabs/:id matches /api/cabs/7
+func matchRoutePattern(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
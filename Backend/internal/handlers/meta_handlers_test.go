@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"oop/docs"
+	"oop/internal/api"
+	"oop/internal/services"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubAPISpec string
+
+func (s stubAPISpec) ReadDoc() string { return string(s) }
+
+const testAPISpec = `{
+	"swagger": "2.0",
+	"info": {"title": "Test API", "version": "2.1"},
+	"host": "localhost:8080",
+	"basePath": "/api",
+	"paths": {
+		"/customers/{id}": {
+			"put": {
+				"summary": "Update an existing customer",
+				"tags": ["Customers"],
+				"security": [{"ApiKeyAuth": []}],
+				"parameters": [
+					{"name": "id", "in": "path", "required": true},
+					{"name": "customer", "in": "body", "required": true, "schema": {"$ref": "#/definitions/handlers.UpdateCustomerRequest"}}
+				]
+			}
+		},
+		"/users/login": {
+			"post": {"summary": "Login", "tags": ["Users"]}
+		},
+		"/sales": {
+			"get": {
+				"summary": "Get all sales",
+				"tags": ["Sales"],
+				"parameters": [{"name": "customer_id", "in": "query"}]
+			}
+		}
+	},
+	"definitions": {
+		"handlers.UpdateCustomerRequest": {
+			"type": "object",
+			"properties": {"fullName": {"type": "string"}, "age": {"type": "integer"}}
+		}
+	}
+}`
+
+func setupMetaTestApp(spec APISpec) *fiber.App {
+	app := fiber.New()
+	NewMetaHandler(spec).Routes(app.Group("/api"), RouteMiddleware{})
+	return app
+}
+
+func TestGetOpenAPISpecHandler(t *testing.T) {
+	app := setupMetaTestApp(stubAPISpec(testAPISpec))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/meta/openapi.json", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.JSONEq(t, testAPISpec, string(body))
+}
+
+func TestGetPostmanCollectionHandler(t *testing.T) {
+	t.Run("Converts the spec", func(t *testing.T) {
+		app := setupMetaTestApp(stubAPISpec(testAPISpec))
+
+		req := httptest.NewRequest(http.MethodGet, "/api/meta/postman", nil)
+		req.Host = "api.example.com"
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "api-v2.1.postman_collection.json")
+
+		var collection services.PostmanCollection
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&collection))
+		assert.Equal(t, "Test API", collection.Info.Name)
+		assert.Equal(t, services.PostmanSchema, collection.Info.Schema)
+		assert.Equal(t, "http://api.example.com/api", collection.Variable[0].Value)
+
+		folders := []string{}
+		for _, folder := range collection.Item {
+			folders = append(folders, folder.Name)
+		}
+		assert.Equal(t, []string{"Customers", "Sales", "Users"}, folders)
+
+		update := collection.Item[0].Item[0].Request
+		assert.Equal(t, "PUT", update.Method)
+		assert.Equal(t, "{{baseUrl}}/customers/:id", update.URL.Raw)
+		assert.Equal(t, "id", update.URL.Variable[0].Key)
+		assert.Contains(t, update.Header, services.PostmanVariable{Key: "Authorization", Value: "Bearer {{token}}"})
+		if assert.NotNil(t, update.Body) {
+			assert.JSONEq(t, `{"fullName": "", "age": 0}`, update.Body.Raw)
+		}
+
+		sales := collection.Item[1].Item[0].Request
+		assert.True(t, sales.URL.Query[0].Disabled)
+		assert.Empty(t, collection.Item[2].Item[0].Request.Header)
+	})
+
+	t.Run("Converts the generated spec", func(t *testing.T) {
+		app := setupMetaTestApp(docs.SwaggerInfo)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/meta/postman", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var collection services.PostmanCollection
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&collection))
+		assert.NotEmpty(t, collection.Item)
+	})
+
+	t.Run("Invalid spec", func(t *testing.T) {
+		app := setupMetaTestApp(stubAPISpec("not json"))
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/meta/postman", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+}
+
+func TestGetChangelogHandler(t *testing.T) {
+	handler := NewMetaHandler(stubAPISpec(testAPISpec))
+	handler.Changelog = Changelog{
+		Changes: []api.APIChange{
+			{Date: "2025-03-01", Kind: api.ChangeDeprecated, Endpoints: []string{"GET /api/reports/old"}, Summary: "Use /api/reports/new"},
+			{Date: "2025-01-15", Kind: api.ChangeAdded, Endpoints: []string{"GET /api/reports/new"}, Summary: "New report"},
+		},
+		Deprecations: []api.Deprecation{{Method: http.MethodGet, Path: "/api/reports/old", DeprecatedOn: "2025-03-01", Sunset: "2025-09-01"}},
+	}
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), RouteMiddleware{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/meta/changelog", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var changelog api.ChangelogResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&changelog))
+	assert.Equal(t, "2.1", changelog.Version)
+	assert.Len(t, changelog.Changes, 2)
+	assert.Len(t, changelog.Deprecations, 1)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/meta/changelog?since=2025-01-15", nil))
+	require.NoError(t, err)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&changelog))
+	require.Len(t, changelog.Changes, 1, "only changes after the date")
+	assert.Equal(t, api.ChangeDeprecated, changelog.Changes[0].Kind)
+	assert.Len(t, changelog.Deprecations, 1, "deprecations are always listed")
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/api/meta/changelog?since=March", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	handler := NewMetaHandler(stubAPISpec(testAPISpec))
+	handler.Changelog.Deprecations = []api.Deprecation{
+		{Method: http.MethodGet, Path: "/api/cabs/:id/history", DeprecatedOn: "2025-03-01", Sunset: "2025-09-01"},
+		{Method: http.MethodDelete, Path: "/api/cabs/:id", DeprecatedOn: "2025-03-01"},
+	}
+	app := fiber.New()
+	app.Use(handler.DeprecationHeaders())
+	app.All("/*", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/cabs/7/history", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "@1740787200", resp.Header.Get(HeaderDeprecation))
+	assert.Equal(t, "Mon, 01 Sep 2025 00:00:00 GMT", resp.Header.Get(HeaderSunset))
+	assert.Contains(t, resp.Header.Get("Link"), `</api/meta/changelog>; rel="deprecation"`)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/api/cabs/7", nil))
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Header.Get(HeaderDeprecation))
+	assert.Empty(t, resp.Header.Get(HeaderSunset), "no sunset date set yet")
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/cabs/7", nil),
+		httptest.NewRequest(http.MethodGet, "/api/cabs/7/history/1", nil),
+		httptest.NewRequest(http.MethodPut, "/api/cabs/7/history", nil),
+	} {
+		resp, err = app.Test(req)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Header.Get(HeaderDeprecation), req.Method+" "+req.URL.Path)
+	}
+}
+
+// TestAPIChangelogEntries keeps the changelog compiled into the binary well-formed
+func TestAPIChangelogEntries(t *testing.T) {
+	kinds := map[string]bool{api.ChangeAdded: true, api.ChangeChanged: true, api.ChangeDeprecated: true, api.ChangeRemoved: true}
+	previous := "9999-12-31"
+	for _, change := range APIChangelog.Changes {
+		_, err := time.Parse(changelogDateLayout, change.Date)
+		assert.NoError(t, err, change.Summary)
+		assert.True(t, kinds[change.Kind], "unknown kind %q", change.Kind)
+		assert.LessOrEqual(t, change.Date, previous, "changes are listed newest first")
+		assert.NotEmpty(t, change.Summary)
+		previous = change.Date
+	}
+	for _, deprecation := range APIChangelog.Deprecations {
+		assert.True(t, strings.HasPrefix(deprecation.Path, "/api/"), deprecation.Path)
+		_, err := time.Parse(changelogDateLayout, deprecation.DeprecatedOn)
+		assert.NoError(t, err, deprecation.Path)
+		if deprecation.Sunset != "" {
+			_, err = time.Parse(changelogDateLayout, deprecation.Sunset)
+			assert.NoError(t, err, deprecation.Path)
+		}
+	}
+}
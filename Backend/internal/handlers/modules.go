@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"oop/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Module is a part of the API whose handler registers its own routes. Modules put
+// the shared middleware in front of their routes rather than building their own, so
+// that every route authenticates callers and checks their roles the same way.
+type Module interface {
+	Routes(r fiber.Router, mw RouteMiddleware)
+}
+
+// RouteMiddleware is the middleware modules choose from for their routes, built once
+// per app
+type RouteMiddleware struct {
+	Auth           fiber.Handler // Requires a valid access token
+	Staff          fiber.Handler // Lets admins and staff through; runs after Auth
+	Admin          fiber.Handler // Lets admins through; runs after Auth
+	IncludeDeleted fiber.Handler // Lets admins list deleted records with ?include_deleted=true
+	Dedupe         fiber.Handler // Answers a create submitted twice with the response to the first
+	Features       *FeatureFlags // Switches routes off per tenant; nil leaves every feature on
+}
+
+// NewRouteMiddleware creates the middleware of routes authenticated with jwtSecret.
+// Duplicate submissions are let through until Dedupe is set.
+func NewRouteMiddleware(jwtSecret []byte) RouteMiddleware {
+	return RouteMiddleware{
+		Auth:           middleware.JWTMiddleware(jwtSecret),
+		Staff:          middleware.RequireRoles(RoleAdmin, RoleStaff),
+		Admin:          requireAdmin,
+		IncludeDeleted: IncludeDeleted(jwtSecret),
+		Dedupe:         func(c *fiber.Ctx) error { return c.Next() },
+	}
+}
+
+// Feature returns a middleware that rejects requests when the tenant has feature
+// switched off
+func (mw RouteMiddleware) Feature(feature string) fiber.Handler {
+	if mw.Features == nil {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	return mw.Features.Require(feature)
+}
+
+// RegisterModules registers the routes of modules on r in order. Order matters where
+// the paths of two modules overlap: literal paths such as /users/online must be
+// registered before parameters such as /users/:id.
+func RegisterModules(r fiber.Router, mw RouteMiddleware, modules ...Module) {
+	for _, module := range modules {
+		module.Routes(r, mw)
+	}
+}
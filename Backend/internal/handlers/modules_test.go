@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"oop/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubModule registers one GET route behind the middleware it picks
+type stubModule struct {
+	path       string
+	middleware func(mw RouteMiddleware) []fiber.Handler
+}
+
+func (m stubModule) Routes(r fiber.Router, mw RouteMiddleware) {
+	handlers := append(m.middleware(mw), func(c *fiber.Ctx) error { return c.SendString(m.path) })
+	r.Get(m.path, handlers...)
+}
+
+func TestRegisterModules(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	app := fiber.New()
+	RegisterModules(app.Group("/api"), NewRouteMiddleware(jwtSecret),
+		stubModule{path: "/public", middleware: func(mw RouteMiddleware) []fiber.Handler { return nil }},
+		stubModule{path: "/staff", middleware: func(mw RouteMiddleware) []fiber.Handler { return []fiber.Handler{mw.Auth, mw.Staff} }},
+		stubModule{path: "/admin", middleware: func(mw RouteMiddleware) []fiber.Handler { return []fiber.Handler{mw.Auth, mw.Admin} }},
+		stubModule{path: "/feature", middleware: func(mw RouteMiddleware) []fiber.Handler { return []fiber.Handler{mw.Feature(FeatureSandbox)} }},
+	)
+	staff := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	admin := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"public route needs no token", "/api/public", "", http.StatusOK},
+		{"staff route requires a token", "/api/staff", "", http.StatusUnauthorized},
+		{"staff route lets staff through", "/api/staff", staff, http.StatusOK},
+		{"admin route refuses staff", "/api/admin", staff, http.StatusForbidden},
+		{"admin route lets admins through", "/api/admin", admin, http.StatusOK},
+		{"features are on without feature flags", "/api/feature", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := authedRequest(t, app, tt.token, http.MethodGet, tt.path, nil)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
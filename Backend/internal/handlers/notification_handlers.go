@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"oop/internal/models"
+	"oop/internal/services"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notificationHeartbeat keeps idle streams open through proxies that close silent connections
+const notificationHeartbeat = 25 * time.Second
+
+// NotificationHandler serves the notification stream of a tenant
+type NotificationHandler struct {
+	Hub       *services.NotificationHub
+	Heartbeat time.Duration
+	jwtSecret []byte
+}
+
+// NewNotificationHandler creates a new NotificationHandler instance
+func NewNotificationHandler(hub *services.NotificationHub, jwtSecret []byte) *NotificationHandler {
+	return &NotificationHandler{Hub: hub, Heartbeat: notificationHeartbeat, jwtSecret: jwtSecret}
+}
+
+// Routes registers the notification stream route
+func (h *NotificationHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/notifications/stream", mw.Auth, h.Stream) // GET /api/notifications/stream
+}
+
+// Stream handles the notification stream
+// @Summary Stream notifications
+// @Description Server-Sent Events stream of the notifications for the caller in their tenant. Each event is named after the notification type (e.g. "announcement") and carries JSON data. Comment lines are sent periodically to keep the connection open. Notifications published while disconnected are not replayed, so load the current state (e.g. GET /announcements) after connecting. Send the token in the Authorization header; use a fetch-based SSE client, as EventSource cannot set headers.
+// @Tags Notifications
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Router /notifications/stream [get]
+func (h *NotificationHandler) Stream(c *fiber.Ctx) error {
+	tenantID := tenantIDFromCtx(c)
+	userID, _ := c.Locals("user_id").(string)
+	notifications, unsubscribe := h.Hub.Subscribe(tenantID, userID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Disable response buffering in nginx
+
+	heartbeat := h.Heartbeat
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		fmt.Fprint(w, ": connected\n\n")
+		for {
+			// A failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			select {
+			case notification, ok := <-notifications:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(notification.Data)
+				if err != nil {
+					log.Printf("Error encoding %s notification for tenant %s: %v", notification.Type, tenantID, err)
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", notification.Type, data)
+			case <-ticker.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+		}
+	})
+
+	return nil
+}
+
+// notifyUsers pushes a notification to the active users of the tenant that match. It
+// publishes nothing when no user matches, as a notification without recipients goes
+// to every user.
+func notifyUsers(ctx context.Context, users UserRepository, hub *services.NotificationHub, tenantID string, match func(*models.User) bool, notification models.Notification) error {
+	if hub == nil || users == nil {
+		return nil
+	}
+
+	all, err := users.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list users to notify: %w", err)
+	}
+	var recipients []string
+	for _, user := range all {
+		if user.IsActive && match(user) {
+			recipients = append(recipients, user.Id)
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+	sort.Strings(recipients)
+
+	notification.Recipients = recipients
+	hub.Publish(tenantID, notification)
+	return nil
+}
+
+// isAdmin matches the admins of a tenant
+func isAdmin(user *models.User) bool {
+	return user.Role == RoleAdmin
+}
+
+// isBackOffice matches the admins and staff of a tenant
+func isBackOffice(user *models.User) bool {
+	return user.Role == RoleAdmin || user.Role == RoleStaff
+}
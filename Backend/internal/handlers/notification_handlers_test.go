@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyUsers(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "staff-1", Username: "staff", Email: "staff@example.com", Password: "secret123", Role: RoleStaff, IsActive: true}))
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "admin-1", Username: "admin", Email: "admin@example.com", Password: "secret123", Role: RoleAdmin}))
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), "admin-1"))
+	hub := services.NewNotificationHub()
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribe()
+	adminNotifications, unsubscribeAdmin := hub.Subscribe(models.DefaultTenantID, "admin-1")
+	defer unsubscribeAdmin()
+
+	// The only admin is deactivated, so nobody is notified rather than everyone
+	notification := models.Notification{Type: NotificationDormantAccounts}
+	require.NoError(t, notifyUsers(context.Background(), store.Users, hub, models.DefaultTenantID, isAdmin, notification))
+	assert.Empty(t, receiveNotifications(notifications))
+	assert.Empty(t, receiveNotifications(adminNotifications))
+
+	require.NoError(t, notifyUsers(context.Background(), store.Users, hub, models.DefaultTenantID, isBackOffice, notification))
+	received := receiveNotifications(notifications)
+	require.Len(t, received, 1)
+	assert.Equal(t, []string{"staff-1"}, received[0].Recipients)
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// oidcStateCookie holds the anti-CSRF state between the login redirect and the callback
+const oidcStateCookie = "oidc_state"
+
+// oidcStateTTL is how long a user has to complete the provider login
+const oidcStateTTL = 10 * time.Minute
+
+// OIDCHandler handles the optional single sign-on login flow
+type OIDCHandler struct {
+	userRepo       UserRepository
+	provider       services.OIDCProvider
+	allowedDomains []string
+	autoProvision  bool
+	frontendURL    string
+	jwtSecret      []byte
+	Dormancy       *DormantAccountHandler // Optional; records sign-ins for the dormant account policy
+	Sessions       *SessionHandler        // Optional; issues refresh tokens alongside the access token
+	Shifts         *ShiftHandler          // Optional; refuses sign-ins outside the user's shift
+}
+
+// NewOIDCHandler creates a new OIDCHandler instance
+func NewOIDCHandler(userRepo UserRepository, provider services.OIDCProvider, allowedDomains []string, autoProvision bool, frontendURL string, jwtSecret []byte) *OIDCHandler {
+	return &OIDCHandler{
+		userRepo:       userRepo,
+		provider:       provider,
+		allowedDomains: allowedDomains,
+		autoProvision:  autoProvision,
+		frontendURL:    strings.TrimRight(frontendURL, "/"),
+		jwtSecret:      jwtSecret,
+	}
+}
+
+// Routes registers the public SSO login routes, which super admins can switch off
+// per tenant
+func (h *OIDCHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	authGroup := r.Group("/auth/oidc", mw.Feature(FeatureSSO))
+	authGroup.Get("/login", h.Login)       // GET /api/auth/oidc/login
+	authGroup.Get("/callback", h.Callback) // GET /api/auth/oidc/callback
+}
+
+// Login redirects the browser to the identity provider
+// @Summary Start SSO login
+// @Description Redirects to the configured OpenID Connect provider (e.g. Google Workspace).
+// @Tags Auth
+// @Success 302 "Redirect to the identity provider"
+// @Failure 502 {object} api.ErrorResponse "Identity provider unavailable"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *fiber.Ctx) error {
+	state, err := generateToken()
+	if err != nil {
+		log.Printf("Error generating OIDC state: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to start login",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	authURL, err := h.provider.AuthCodeURL(c.Context(), state)
+	if err != nil {
+		log.Printf("Error building OIDC authorization URL: %v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(api.ErrorResponse{
+			Error:      "Identity provider unavailable",
+			StatusCode: fiber.StatusBadGateway,
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc",
+		Expires:  time.Now().Add(oidcStateTTL),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: fiber.CookieSameSiteLaxMode, // Lax so the cookie survives the provider's top-level redirect back
+	})
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// Callback completes the SSO login and issues the same JWT as password login
+// @Summary Complete SSO login
+// @Description Exchanges the authorization code, maps the verified email to a user account (optionally creating a staff account when no account has the email) and issues a JWT. Redirects to FRONTEND_URL/oidc/callback#token=... when a frontend URL is configured, otherwise responds with JSON.
+// @Tags Auth
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} api.UserAuthResponse "Login successful"
+// @Success 302 "Redirect to the frontend with the token"
+// @Failure 400 {object} api.ErrorResponse "Invalid login state"
+// @Failure 401 {object} api.ErrorResponse "Login failed"
+// @Failure 403 {object} api.ErrorResponse "Account not allowed or inactive"
+// @Failure 500 {object} api.ErrorResponse "Internal server error"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	expectedState := c.Cookies(oidcStateCookie)
+	// Clear the state cookie so it cannot be replayed
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Path:     "/api/auth/oidc",
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+	})
+
+	state := c.Query("state")
+	if expectedState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(expectedState)) != 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid login state",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		log.Printf("OIDC provider returned error: %s", providerErr)
+		return c.Status(fiber.StatusUnauthorized).JSON(api.ErrorResponse{
+			Error:      "Login failed",
+			StatusCode: fiber.StatusUnauthorized,
+		})
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Authorization code is required",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	identity, err := h.provider.Exchange(c.Context(), code)
+	if err != nil {
+		log.Printf("OIDC code exchange failed: %v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(api.ErrorResponse{
+			Error:      "Login failed",
+			StatusCode: fiber.StatusUnauthorized,
+		})
+	}
+
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" || !identity.EmailVerified {
+		log.Printf("OIDC login rejected - unverified email for subject %s", identity.Subject)
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+			Error:      "Email address is not verified",
+			StatusCode: fiber.StatusForbidden,
+		})
+	}
+	if !h.domainAllowed(email, identity.HostedDomain) {
+		log.Printf("OIDC login rejected - domain not allowed for %s", email)
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+			Error:      "Account not allowed",
+			StatusCode: fiber.StatusForbidden,
+		})
+	}
+
+	user, err := h.userRepo.GetByEmail(c.Context(), email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting user %s for OIDC login: %v", email, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Internal server error",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	if user == nil {
+		if !h.autoProvision {
+			log.Printf("OIDC login rejected - no account for %s: %v", email, err)
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+				Error:      "No account exists for this email",
+				StatusCode: fiber.StatusForbidden,
+			})
+		}
+		user, err = h.provisionUser(c.Context(), email, identity.Name)
+		if err != nil {
+			log.Printf("OIDC auto-provisioning failed for %s: %v", email, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Failed to create account",
+				StatusCode: fiber.StatusInternalServerError,
+			})
+		}
+	}
+
+	if !user.IsActive {
+		log.Printf("OIDC login attempt for inactive user: %s", email)
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+			Error:      "Account is inactive",
+			StatusCode: fiber.StatusForbidden,
+		})
+	}
+
+	if err := h.Shifts.CheckLogin(user); err != nil {
+		if errors.Is(err, ErrOutsideShift) {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+				Error:      "Signing in is not allowed outside your scheduled shift",
+				StatusCode: fiber.StatusForbidden,
+			})
+		}
+		log.Printf("Error checking shift of user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to check shift schedule",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	tokenString, err := generateJWT(user, tenantIDFromCtx(c), h.jwtSecret, h.Sessions.AccessTTL())
+	if err != nil {
+		log.Printf("Error signing JWT token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to generate authentication token",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	refreshToken, err := h.Sessions.Start(user)
+	if err != nil {
+		log.Printf("Error starting session for user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to generate authentication token",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	h.Dormancy.RecordLogin(user.Id)
+
+	if h.frontendURL != "" {
+		// The fragment is never sent to servers, keeping the tokens out of access logs
+		fragment := "token=" + url.QueryEscape(tokenString)
+		if refreshToken != "" {
+			fragment += "&refreshToken=" + url.QueryEscape(refreshToken)
+		}
+		return c.Redirect(fmt.Sprintf("%s/oidc/callback#%s", h.frontendURL, fragment), fiber.StatusFound)
+	}
+
+	user.Password = ""
+	return c.Status(fiber.StatusOK).JSON(api.UserAuthResponse{
+		Message:      "Login successful",
+		User:         user,
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.Sessions.AccessTTL().Seconds()),
+	})
+}
+
+// domainAllowed checks the email (and Workspace hosted domain, when present) against the allowlist
+func (h *OIDCHandler) domainAllowed(email, hostedDomain string) bool {
+	if len(h.allowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range h.allowedDomains {
+		if domain == allowed && (hostedDomain == "" || strings.EqualFold(hostedDomain, allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// provisionUser creates a staff account for a first-time SSO user
+func (h *OIDCHandler) provisionUser(ctx context.Context, email, name string) (*models.User, error) {
+	// SSO users never see this password; they can set one later via the usual password update
+	password, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	fullName := strings.TrimSpace(name)
+	if fullName == "" {
+		fullName = email
+	}
+
+	user := &models.User{
+		Id:       uuid.New().String(),
+		Username: email, // Email is unique, so it is a safe initial username
+		FullName: fullName,
+		Email:    email,
+		Password: password,
+		Role:     RoleStaff,
+	}
+	if err := h.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	log.Printf("OIDC auto-provisioned staff account %s for %s", user.Id, email)
+	return user, nil
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeOIDCProvider returns a fixed identity instead of contacting an identity provider
+type fakeOIDCProvider struct {
+	identity *services.OIDCIdentity
+	err      error
+}
+
+func (p *fakeOIDCProvider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	return "https://idp.example.com/auth?state=" + state, nil
+}
+
+func (p *fakeOIDCProvider) Exchange(ctx context.Context, code string) (*services.OIDCIdentity, error) {
+	return p.identity, p.err
+}
+
+func setupOIDCTest(provider services.OIDCProvider, autoProvision bool, frontendURL string) (*fiber.App, *MockUserRepository) {
+	app := fiber.New()
+	userRepo := new(MockUserRepository)
+	handler := NewOIDCHandler(userRepo, provider, []string{"example.com"}, autoProvision, frontendURL, []byte("dummy_secret_for_test"))
+	handler.Routes(app.Group("/api"), NewRouteMiddleware([]byte("dummy_secret_for_test")))
+	return app, userRepo
+}
+
+func oidcCallbackRequest(state, cookieState string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?code=abc&state="+state, nil)
+	if cookieState != "" {
+		req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: cookieState})
+	}
+	return req
+}
+
+func TestOIDCHandler_Login(t *testing.T) {
+	t.Run("Redirects with state cookie", func(t *testing.T) {
+		app, _ := setupOIDCTest(&fakeOIDCProvider{}, false, "")
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+		var stateCookie *http.Cookie
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == oidcStateCookie {
+				stateCookie = cookie
+			}
+		}
+		if assert.NotNil(t, stateCookie) {
+			assert.True(t, stateCookie.HttpOnly)
+			assert.True(t, strings.HasSuffix(resp.Header.Get("Location"), "state="+stateCookie.Value))
+		}
+	})
+
+	t.Run("Provider unavailable", func(t *testing.T) {
+		app, _ := setupOIDCTest(&fakeOIDCProvider{err: errors.New("discovery failed")}, false, "")
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	})
+}
+
+func TestOIDCHandler_Callback(t *testing.T) {
+	verified := &services.OIDCIdentity{Subject: "sub-1", Email: "Jane@Example.com", EmailVerified: true, Name: "Jane Doe", HostedDomain: "example.com"}
+
+	t.Run("Existing user receives JWT", func(t *testing.T) {
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: verified}, false, "")
+		userRepo.On("GetByEmail", "jane@example.com").Return(&models.User{Id: "user-1", Email: "jane@example.com", Role: RoleStaff, IsActive: true, Password: "hash"}, nil)
+
+		resp, err := app.Test(oidcCallbackRequest("state-1", "state-1"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var result api.UserAuthResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.NotEmpty(t, result.Token)
+		assert.Empty(t, result.User.Password)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Redirects to frontend with token", func(t *testing.T) {
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: verified}, false, "http://localhost:9000/")
+		userRepo.On("GetByEmail", "jane@example.com").Return(&models.User{Id: "user-1", Email: "jane@example.com", Role: RoleStaff, IsActive: true}, nil)
+
+		resp, err := app.Test(oidcCallbackRequest("state-1", "state-1"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.True(t, strings.HasPrefix(resp.Header.Get("Location"), "http://localhost:9000/oidc/callback#token="))
+	})
+
+	t.Run("State mismatch", func(t *testing.T) {
+		app, _ := setupOIDCTest(&fakeOIDCProvider{identity: verified}, false, "")
+
+		resp, err := app.Test(oidcCallbackRequest("state-1", "other"))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp, err = app.Test(oidcCallbackRequest("state-1", ""))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Unverified email", func(t *testing.T) {
+		identity := *verified
+		identity.EmailVerified = false
+		app, _ := setupOIDCTest(&fakeOIDCProvider{identity: &identity}, false, "")
+
+		resp, err := app.Test(oidcCallbackRequest("s", "s"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Domain not allowed", func(t *testing.T) {
+		identity := &services.OIDCIdentity{Email: "jane@gmail.com", EmailVerified: true}
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: identity}, true, "")
+
+		resp, err := app.Test(oidcCallbackRequest("s", "s"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything)
+	})
+
+	t.Run("Unknown user without auto-provisioning", func(t *testing.T) {
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: verified}, false, "")
+		userRepo.On("GetByEmail", "jane@example.com").Return(nil, fmt.Errorf("user not found: %w", sql.ErrNoRows))
+
+		resp, err := app.Test(oidcCallbackRequest("s", "s"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Unknown user is auto-provisioned as staff", func(t *testing.T) {
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: verified}, true, "")
+		userRepo.On("GetByEmail", "jane@example.com").Return(nil, fmt.Errorf("user not found: %w", sql.ErrNoRows))
+		userRepo.On("Create", mock.MatchedBy(func(u *models.User) bool {
+			return u.Email == "jane@example.com" && u.Role == RoleStaff && u.FullName == "Jane Doe"
+		})).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.User).IsActive = true
+		}).Return(nil)
+
+		resp, err := app.Test(oidcCallbackRequest("s", "s"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		userRepo.AssertExpectations(t)
+	})
+
+	t.Run("Lookup failure is not auto-provisioned", func(t *testing.T) {
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: verified}, true, "")
+		userRepo.On("GetByEmail", "jane@example.com").Return(nil, errors.New("connection refused"))
+
+		resp, err := app.Test(oidcCallbackRequest("s", "s"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		userRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Inactive user", func(t *testing.T) {
+		app, userRepo := setupOIDCTest(&fakeOIDCProvider{identity: verified}, false, "")
+		userRepo.On("GetByEmail", "jane@example.com").Return(&models.User{Id: "user-1", Email: "jane@example.com", IsActive: false}, nil)
+
+		resp, err := app.Test(oidcCallbackRequest("s", "s"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultPasswordResetTTL is how long a reset link stays valid unless configured
+const defaultPasswordResetTTL = time.Hour
+
+// passwordResetCooldown is how long after a reset link was sent another is refused,
+// so the endpoint cannot be used to flood someone's inbox
+const passwordResetCooldown = time.Minute
+
+// forgotPasswordMessage answers every forgot password request alike, so the endpoint
+// does not reveal which emails have accounts
+const forgotPasswordMessage = "If an active account uses this email, a password reset link has been sent to it."
+
+// PasswordResetHandler handles resetting forgotten passwords through emailed one-time links
+type PasswordResetHandler struct {
+	userRepo    UserRepository
+	Repo        repositories.PasswordResetRepository
+	mailer      services.Mailer
+	frontendURL string
+	TTL         time.Duration   // How long a reset link stays valid
+	Sessions    *SessionHandler // Optional; ends the user's sessions once the password is reset
+	now         func() time.Time
+}
+
+// NewPasswordResetHandler creates a new PasswordResetHandler instance
+func NewPasswordResetHandler(userRepo UserRepository, repo repositories.PasswordResetRepository, mailer services.Mailer, frontendURL string) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		userRepo:    userRepo,
+		Repo:        repo,
+		mailer:      mailer,
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		TTL:         defaultPasswordResetTTL,
+		now:         time.Now,
+	}
+}
+
+// Routes registers the public password reset routes. The module must be registered
+// before the users module, like the invite routes.
+func (h *PasswordResetHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	userGroup := r.Group("/users")
+	userGroup.Post("/forgot-password", h.ForgotPassword) // POST /api/users/forgot-password (public)
+	userGroup.Post("/reset-password", h.ResetPassword)   // POST /api/users/reset-password (public)
+}
+
+// ForgotPassword emails a one-time password reset link to the account with the email
+// @Summary Request a password reset link
+// @Description Emails a one-time link to reset the password of the active account with the email, valid for PASSWORD_RESET_TTL_MINUTES. Requesting a new link spends the earlier ones, and another link is not sent within a minute of the last. The answer is the same whether or not an account uses the email.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Email of the account"
+// @Success 202 {object} api.MessageResponse "Reset link sent if the account exists"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing email"
+// @Router /users/forgot-password [post]
+func (h *PasswordResetHandler) ForgotPassword(c *fiber.Ctx) error {
+	var input models.ForgotPasswordRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid request body",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+	email := strings.TrimSpace(input.Email)
+	if email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Email is required",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	h.sendResetLink(c.Context(), email)
+	return c.Status(fiber.StatusAccepted).JSON(api.MessageResponse{Message: forgotPasswordMessage})
+}
+
+// sendResetLink creates a reset token for the active account with the email and mails
+// its link. Failures are only logged, as the caller is answered alike either way.
+func (h *PasswordResetHandler) sendResetLink(ctx context.Context, email string) {
+	user, err := h.userRepo.GetByEmail(ctx, email)
+	if err != nil || !user.IsActive {
+		return
+	}
+
+	now := h.now()
+	last, err := h.Repo.LastCreatedAt(user.Id)
+	if err != nil {
+		log.Printf("Error checking the last password reset of user %s: %v", user.Id, err)
+		return
+	}
+	if now.Sub(last) < passwordResetCooldown {
+		log.Printf("Password reset of user %s requested again within %s; no link sent", user.Id, passwordResetCooldown)
+		return
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("Error generating password reset token: %v", err)
+		return
+	}
+	reset := &models.PasswordResetToken{
+		ID:        uuid.New().String(),
+		UserID:    user.Id,
+		TokenHash: hashInviteToken(token),
+		ExpiresAt: now.Add(h.TTL),
+		CreatedAt: now,
+	}
+	if err := h.Repo.Create(reset); err != nil {
+		log.Printf("Error creating password reset token for user %s: %v", user.Id, err)
+		return
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", h.frontendURL, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Hello %s,\n\nSomeone asked to reset the password of your Cortes Surplus account. If it was you, set a new password using the link below. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you did not ask for it, you can ignore this email; your password stays the same.\n",
+		user.FullName, int(h.TTL.Minutes()), link,
+	)
+	if err := h.mailer.Send(user.Email, "Reset your Cortes Surplus password", body); err != nil {
+		log.Printf("Error sending password reset email to user %s: %v", user.Id, err)
+	}
+}
+
+// ResetPassword sets a new password using the token of a reset link
+// @Summary Reset a forgotten password
+// @Description Sets a new password for the account of a reset link's token and ends all of its sessions. The token can only be used once.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param reset body models.ResetPasswordRequest true "Reset token and new password"
+// @Success 200 {object} api.MessageResponse "Password reset"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing fields"
+// @Failure 404 {object} api.ErrorResponse "Invalid or expired reset link"
+// @Failure 500 {object} api.ErrorResponse "Failed to reset password"
+// @Router /users/reset-password [post]
+func (h *PasswordResetHandler) ResetPassword(c *fiber.Ctx) error {
+	var input models.ResetPasswordRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid request body",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+	if input.Token == "" || input.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Token and password are required",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	invalid := api.ErrorResponse{Error: "Invalid or expired reset link", StatusCode: fiber.StatusNotFound}
+	now := h.now()
+	reset, err := h.Repo.GetByTokenHash(hashInviteToken(input.Token))
+	if err != nil || reset.UsedAt != nil || now.After(reset.ExpiresAt) {
+		return c.Status(fiber.StatusNotFound).JSON(invalid)
+	}
+	user, err := h.userRepo.GetByID(c.Context(), reset.UserID)
+	if err != nil || !user.IsActive {
+		return c.Status(fiber.StatusNotFound).JSON(invalid)
+	}
+
+	// Claim the token first so it can never be used twice concurrently
+	if err := h.Repo.Use(reset.ID, now); err != nil {
+		log.Printf("ResetPassword: failed to use reset token %s: %v", reset.ID, err)
+		return c.Status(fiber.StatusNotFound).JSON(invalid)
+	}
+
+	if err := h.userRepo.UpdatePassword(c.Context(), user.Id, input.Password); err != nil {
+		log.Printf("ResetPassword: failed to set password for user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to reset password",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	// Whoever knew the old password can no longer refresh a session
+	h.Sessions.RevokeUser(user.Id)
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{
+		Message: "Password reset. You can now log in with your new password.",
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resetLinkMailer keeps the emails sent, to follow their reset links
+type resetLinkMailer struct {
+	bodies []string
+}
+
+func (m *resetLinkMailer) Send(to, subject, body string) error {
+	m.bodies = append(m.bodies, body)
+	return nil
+}
+
+var resetLinkToken = regexp.MustCompile(`/reset-password\?token=(\S+)`)
+
+// resetToken returns the token of the reset link in the last email sent
+func (m *resetLinkMailer) resetToken(t *testing.T) string {
+	require.NotEmpty(t, m.bodies)
+	match := resetLinkToken.FindStringSubmatch(m.bodies[len(m.bodies)-1])
+	require.Len(t, match, 2)
+	token, err := url.QueryUnescape(match[1])
+	require.NoError(t, err)
+	return token
+}
+
+func TestPasswordReset(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "user-1", Username: "jane", FullName: "Jane Doe", Email: "jane@example.com", Password: "oldpassword", Role: RoleStaff, IsActive: true}))
+	mailer := &resetLinkMailer{}
+	now := time.Date(2025, time.March, 12, 9, 0, 0, 0, time.UTC)
+	h := NewPasswordResetHandler(store.Users, store.Resets, mailer, "http://localhost:9000/")
+	h.now = func() time.Time { return now }
+	app := fiber.New()
+	h.Routes(app.Group("/api"), RouteMiddleware{})
+
+	resp := authedRequest(t, app, "", http.MethodPost, "/api/users/forgot-password", models.ForgotPasswordRequest{Email: "nobody@example.com"})
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode, "unknown emails are answered alike")
+	assert.Empty(t, mailer.bodies)
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/forgot-password", models.ForgotPasswordRequest{Email: " jane@example.com "})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Len(t, mailer.bodies, 1)
+	assert.Contains(t, mailer.bodies[0], "http://localhost:9000/reset-password?token=")
+	first := mailer.resetToken(t)
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/forgot-password", models.ForgotPasswordRequest{Email: "jane@example.com"})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Len(t, mailer.bodies, 1, "no other link within a minute")
+
+	now = now.Add(2 * time.Minute)
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/forgot-password", models.ForgotPasswordRequest{Email: "jane@example.com"})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Len(t, mailer.bodies, 2)
+	second := mailer.resetToken(t)
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/reset-password", models.ResetPasswordRequest{Token: first, Password: "newpassword"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "a newer link spends the earlier ones")
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/reset-password", models.ResetPasswordRequest{Token: second})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/reset-password", models.ResetPasswordRequest{Token: second, Password: "newpassword"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err := store.Users.VerifyPassword(context.Background(), "jane@example.com", "newpassword")
+	assert.NoError(t, err)
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/reset-password", models.ResetPasswordRequest{Token: second, Password: "otherpassword"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "links work once")
+
+	now = now.Add(2 * time.Minute)
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/forgot-password", models.ForgotPasswordRequest{Email: "jane@example.com"})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	expired := mailer.resetToken(t)
+	now = now.Add(h.TTL + time.Second)
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/reset-password", models.ResetPasswordRequest{Token: expired, Password: "otherpassword"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "links expire")
+
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), "user-1"))
+	now = now.Add(2 * time.Minute)
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/forgot-password", models.ForgotPasswordRequest{Email: "jane@example.com"})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Len(t, mailer.bodies, 3, "inactive accounts get no link")
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"oop/internal/api"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Conditional updates let clients that do not track record versions, such as scripts
+// and integrations, avoid overwriting changes they have not seen. Detail and update
+// responses send the record's update time as Last-Modified; a client echoes it in
+// If-Unmodified-Since on its next PUT, which is rejected with 412 Precondition Failed
+// when the record changed in between. The check runs before the write rather than in
+// the same statement, so two updates racing within the same moment can both pass.
+
+// unmodifiedSince returns the date of the request's If-Unmodified-Since header. As
+// RFC 9110 requires, a missing or invalid date means the update is unconditional.
+func unmodifiedSince(c *fiber.Ctx) (time.Time, bool) {
+	header := c.Get(fiber.HeaderIfUnmodifiedSince)
+	if header == "" {
+		return time.Time{}, false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return since, true
+}
+
+// hasUpdatePrecondition reports whether an update is conditional. Handlers that only
+// load the stored record for auditing use it to know the record is needed anyway.
+func hasUpdatePrecondition(c *fiber.Ctx) bool {
+	_, ok := unmodifiedSince(c)
+	return ok
+}
+
+// rejectIfModified writes 412 Precondition Failed when a record last updated at
+// updatedAt changed after the request's If-Unmodified-Since date. It reports whether
+// it wrote the response, in which case the handler returns err.
+func rejectIfModified(c *fiber.Ctx, updatedAt time.Time) (bool, error) {
+	since, ok := unmodifiedSince(c)
+	if !ok || !updatedAt.Truncate(time.Second).After(since) { // HTTP dates have second precision
+		return false, nil
+	}
+
+	setLastModified(c, updatedAt)
+	return true, c.Status(fiber.StatusPreconditionFailed).JSON(api.ErrorResponse{
+		Error:      "The record was modified after " + since.UTC().Format(http.TimeFormat) + "; reload it and try again",
+		StatusCode: fiber.StatusPreconditionFailed,
+	})
+}
+
+// setLastModified sends the time a record was last updated
+func setLastModified(c *fiber.Ctx, updatedAt time.Time) {
+	if !updatedAt.IsZero() {
+		c.Set(fiber.HeaderLastModified, updatedAt.UTC().Format(http.TimeFormat))
+	}
+}
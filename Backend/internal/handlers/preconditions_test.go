@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPreconditionTestApp registers the customer and cab routes on an in-memory store,
+// without an audit recorder, so cabs are only loaded before an update for the check
+func setupPreconditionTestApp(t *testing.T) (*fiber.App, *memory.Store, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	cabs := NewCabsHandlers(store.Cabs)
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	NewCustomerHandler(store.Customers, jwtSecret).Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Get("/cabs/:id", cabs.GetCabByID)
+	apiGroup.Put("/cabs/:id", cabs.UpdateCab)
+	return app, store, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+}
+
+// conditionalPut sends an update with an If-Unmodified-Since header
+func conditionalPut(t *testing.T, app *fiber.App, token, path, since string, input interface{}) *http.Response {
+	payload, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPut, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(fiber.HeaderIfUnmodifiedSince, since)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestConditionalCustomerUpdate(t *testing.T) {
+	app, store, token := setupPreconditionTestApp(t)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	path := "/api/customers/" + customer.ID
+
+	resp := authedRequest(t, app, token, http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	lastModified := resp.Header.Get(fiber.HeaderLastModified)
+	require.NotEmpty(t, lastModified)
+
+	stale := customer.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	resp = conditionalPut(t, app, token, path, stale, UpdateCustomerRequest{FullName: "Stale Write"})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.Equal(t, lastModified, resp.Header.Get(fiber.HeaderLastModified), "the current version is reported")
+	unchanged, err := store.Customers.GetCustomerByID(context.Background(), customer.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Juan Dela Cruz", unchanged.FullName)
+
+	resp = conditionalPut(t, app, token, path, lastModified, UpdateCustomerRequest{FullName: "Juan Santos"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "the date read from Last-Modified passes")
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderLastModified))
+
+	resp = conditionalPut(t, app, token, path, "yesterday", UpdateCustomerRequest{FullName: "Juan Reyes"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "invalid dates are ignored")
+}
+
+func TestConditionalCabUpdate(t *testing.T) {
+	app, store, token := setupPreconditionTestApp(t)
+	cab := addTestCab(t, store)
+	path := "/api/cabs/" + strconv.Itoa(cab.ID)
+	update := *cab
+	update.Status = "Available"
+	update.Price = 240000
+
+	stale := cab.UpdatedAt.Add(-time.Hour).UTC().Format(http.TimeFormat)
+	resp := conditionalPut(t, app, token, path, stale, update)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	current, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 250000.0, current.Price)
+
+	resp = conditionalPut(t, app, token, path, time.Now().UTC().Format(http.TimeFormat), update)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = conditionalPut(t, app, token, "/api/cabs/999", stale, update)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "missing records are still reported as missing")
+}
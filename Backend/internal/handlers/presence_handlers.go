@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PresenceHandler tracks when users were last active, from their authenticated
+// requests and from heartbeats that also tell at which branch they work, and lists
+// who is online. A nil Presence tracks nothing.
+type PresenceHandler struct {
+	Users     UserRepository
+	Presence  *services.Presence
+	jwtSecret []byte
+}
+
+// NewPresenceHandler creates a new PresenceHandler instance
+func NewPresenceHandler(users UserRepository, presence *services.Presence, jwtSecret []byte) *PresenceHandler {
+	return &PresenceHandler{Users: users, Presence: presence, jwtSecret: jwtSecret}
+}
+
+// Routes registers the heartbeat and online users routes. The module must be
+// registered before the users module, whose /users/:id would match them.
+func (h *PresenceHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/users/me/heartbeat", mw.Auth, h.Heartbeat)         // POST /api/users/me/heartbeat
+	r.Get("/users/online", mw.Auth, mw.Admin, h.GetOnlineUsers) // GET /api/users/online
+}
+
+// Track is middleware marking the caller as active once a route has authenticated them.
+// Requests without a valid token are not tracked.
+func (h *PresenceHandler) Track(c *fiber.Ctx) error {
+	err := c.Next()
+	h.seen(c, "")
+	return err
+}
+
+// seen marks the authenticated caller as active, at branch unless it is empty. The
+// values are copied, as Fiber reuses the memory of a request once it is answered.
+func (h *PresenceHandler) seen(c *fiber.Ctx, branch string) {
+	userID, _ := c.Locals("user_id").(string)
+	if h.Presence == nil || userID == "" {
+		return
+	}
+	role, _ := c.Locals("role").(string)
+	h.Presence.Seen(strings.Clone(tenantIDFromCtx(c)), strings.Clone(userID), strings.Clone(role), branch)
+}
+
+// Heartbeat handles a client telling that its user is still active
+// @Summary Send a heartbeat
+// @Description Marks the caller as online. Clients send it every minute or so while open, as users reading a screen make no requests. Any authenticated request marks the caller as online as well; only heartbeats carry the branch.
+// @Tags Users
+// @Accept json
+// @Security ApiKeyAuth
+// @Param heartbeat body api.HeartbeatRequest false "Branch the user is working at"
+// @Success 204 "Recorded"
+// @Failure 400 {object} api.ErrorResponse "Invalid branch"
+// @Router /users/me/heartbeat [post]
+func (h *PresenceHandler) Heartbeat(c *fiber.Ctx) error {
+	var input api.HeartbeatRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	branch := normalizeBranch(input.Branch)
+	if utf8.RuneCountInString(branch) > 50 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "branch must be at most 50 characters", StatusCode: fiber.StatusBadRequest})
+	}
+
+	h.seen(c, strings.Clone(branch))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetOnlineUsers handles listing the users currently online
+// @Summary List online users (Admin)
+// @Description Lists the users who made a request or sent a heartbeat within the last USER_ONLINE_WINDOW_MINUTES (default 5), most recently seen first, with the branch of their last heartbeat and the number online at each branch. Presence is kept per server instance and starts empty after a restart.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param branch query string false "Only users at this branch"
+// @Success 200 {object} api.OnlineUsersResponse "Online users"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve users"
+// @Router /users/online [get]
+func (h *PresenceHandler) GetOnlineUsers(c *fiber.Ctx) error {
+	response := api.OnlineUsersResponse{Branches: []api.BranchOnlineCount{}, Users: []api.OnlineUser{}}
+	if h.Presence == nil {
+		return c.Status(fiber.StatusOK).JSON(response)
+	}
+	response.WindowMinutes = int(h.Presence.Window().Minutes())
+
+	// Track only sees this request once it is answered, but the caller is online too
+	h.seen(c, "")
+
+	online := h.Presence.Online(tenantIDFromCtx(c))
+	if len(online) > 0 {
+		users, err := h.Users.GetAll(c.Context())
+		if err != nil {
+			log.Printf("Error getting users for the online list: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve users", StatusCode: fiber.StatusInternalServerError})
+		}
+		usersByID := make(map[string]*models.User, len(users))
+		for _, user := range users {
+			usersByID[user.Id] = user
+		}
+
+		branch, filtered := normalizeBranch(c.Query("branch")), c.Query("branch") != ""
+		counts := make(map[string]int)
+		for _, presence := range online {
+			user, ok := usersByID[presence.UserID]
+			if !ok {
+				continue // Deleted since they were seen
+			}
+			counts[presence.Branch]++
+			if filtered && presence.Branch != branch {
+				continue
+			}
+			response.Users = append(response.Users, api.OnlineUser{UserPresence: presence, Username: user.Username, FullName: user.FullName})
+		}
+		for branch, count := range counts {
+			response.Branches = append(response.Branches, api.BranchOnlineCount{Branch: branch, Count: count})
+		}
+		sort.Slice(response.Branches, func(i, j int) bool { return response.Branches[i].Branch < response.Branches[j].Branch })
+	}
+
+	response.Count = len(response.Users)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPresenceTestApp registers the presence routes, behind the tracking middleware,
+// and an authenticated route to show any request marks the caller online
+func setupPresenceTestApp(t *testing.T) (*fiber.App, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	for _, user := range []*models.User{
+		{Id: "admin-1", Username: "admin", FullName: "Ana Admin", Email: "admin@example.com", Password: "secret", Role: RoleAdmin, IsActive: true},
+		{Id: "staff-1", Username: "staff1", FullName: "Sam Staff", Email: "staff1@example.com", Password: "secret", Role: RoleStaff, IsActive: true},
+		{Id: "staff-2", Username: "staff2", FullName: "Lee Staff", Email: "staff2@example.com", Password: "secret", Role: RoleStaff, IsActive: true},
+	} {
+		require.NoError(t, store.Users.Create(context.Background(), user))
+	}
+
+	presence := NewPresenceHandler(store.Users, services.NewPresence(5*time.Minute), jwtSecret)
+	app := fiber.New()
+	app.Use(presence.Track)
+	apiGroup := app.Group("/api")
+	presence.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Get("/ping", middleware.JWTMiddleware(jwtSecret), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	return app, jwtSecret
+}
+
+func TestOnlineUsers(t *testing.T) {
+	app, jwtSecret := setupPresenceTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	otherTenantToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, "other")
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/users/me/heartbeat", api.HeartbeatRequest{Branch: " mnl "})
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/ping", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, otherTenantToken, http.MethodGet, "/api/ping", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, "invalid", http.MethodGet, "/api/ping", nil)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/users/online", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/users/online", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body api.OnlineUsersResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, 5, body.WindowMinutes)
+	require.Equal(t, 2, body.Count, "the admin and staff-1; staff-2 belongs to another tenant")
+	assert.Equal(t, "admin-1", body.Users[0].UserID, "the admin's own request came last")
+	assert.Equal(t, "staff-1", body.Users[1].UserID)
+	assert.Equal(t, "Sam Staff", body.Users[1].FullName)
+	assert.Equal(t, "MNL", body.Users[1].Branch, "kept from the heartbeat")
+	assert.Equal(t, []api.BranchOnlineCount{{Branch: "", Count: 1}, {Branch: "MNL", Count: 1}}, body.Branches)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/users/online?branch=mnl", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, 1, body.Count)
+	assert.Equal(t, "staff-1", body.Users[0].UserID)
+	assert.Len(t, body.Branches, 2, "branch counts are not filtered")
+}
+
+func TestHeartbeatRejectsLongBranch(t *testing.T) {
+	app, jwtSecret := setupPresenceTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	long := make([]byte, 51)
+	for i := range long {
+		long[i] = 'B'
+	}
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/users/me/heartbeat", api.HeartbeatRequest{Branch: string(long)})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/users/me/heartbeat", nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode, "the body is optional")
+}
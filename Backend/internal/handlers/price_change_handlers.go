@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Notifications of price changes held for approval: admins are told a change is
+// waiting, and the requester is told how it was decided
+const (
+	NotificationPriceChangePending  = "price_change_pending"
+	NotificationPriceChangeReviewed = "price_change_reviewed"
+)
+
+// AuditEntityPriceChange is the entity type of price changes in the activity log
+const AuditEntityPriceChange = "price_change"
+
+// maxPriceChangeNoteLength matches the width of the note column
+const maxPriceChangeNoteLength = 500
+
+// PriceChangeHandler holds changes of the price of a cab or accessory by more than
+// ThresholdPercent until an admin other than the requester approves them; until then
+// the item keeps its old price. A nil *PriceChangeHandler is valid and holds nothing,
+// so the cab and accessory handlers work without one.
+type PriceChangeHandler struct {
+	Repo             repositories.PriceChangeRepository
+	Users            UserRepository
+	Cabs             repositories.CabsRepository
+	Accessories      repositories.AccessoryRepository
+	ThresholdPercent float64                   // 0 applies every change at once
+	Hub              *services.NotificationHub // Optional; without it nobody is notified
+	Audit            *ChangeRecorder
+	Watch            *Watchlist                // Optional; notifies users who starred the item of the approved price
+	Marketplace      *services.MarketplaceSync // Optional; pushes the approved price to the marketplace
+	jwtSecret        []byte
+}
+
+// NewPriceChangeHandler creates a new PriceChangeHandler instance. The cab and
+// accessory repositories are used to apply approved changes.
+func NewPriceChangeHandler(repo repositories.PriceChangeRepository, users UserRepository, cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, thresholdPercent float64, jwtSecret []byte) *PriceChangeHandler {
+	return &PriceChangeHandler{Repo: repo, Users: users, Cabs: cabs, Accessories: accessories, ThresholdPercent: thresholdPercent, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes reviewing held price changes
+func (h *PriceChangeHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	group := r.Group("/price-changes", mw.Auth, mw.Admin)
+	group.Get("/", h.GetPriceChanges)                // GET /api/price-changes
+	group.Post("/:id/approve", h.ApprovePriceChange) // POST /api/price-changes/:id/approve
+	group.Post("/:id/reject", h.RejectPriceChange)   // POST /api/price-changes/:id/reject
+}
+
+// Enabled reports whether large price changes are held for approval
+func (h *PriceChangeHandler) Enabled() bool {
+	return h != nil && h.Repo != nil && h.ThresholdPercent > 0
+}
+
+// needsApproval reports whether changing a price from oldPrice to newPrice is held for
+// approval. Items without a price yet are priced at once.
+func (h *PriceChangeHandler) needsApproval(oldPrice, newPrice float64) bool {
+	if !h.Enabled() || oldPrice <= 0 || oldPrice == newPrice {
+		return false
+	}
+	return models.PriceChange{OldPrice: oldPrice, NewPrice: newPrice}.ChangePercent() > h.ThresholdPercent
+}
+
+// rejectIfPending writes 409 when the item already has a price change waiting for
+// approval, so changes are reviewed one at a time. It reports whether it wrote the
+// response, in which case the handler returns err.
+func (h *PriceChangeHandler) rejectIfPending(c *fiber.Ctx, itemType string, itemID int) (bool, error) {
+	pending, err := h.Repo.GetPending(itemType, itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		log.Printf("Error checking pending price changes of %s %d: %v", itemType, itemID, err)
+		return true, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to check pending price changes", StatusCode: fiber.StatusInternalServerError})
+	}
+	return true, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+		Error:      fmt.Sprintf("The price of %s is already waiting for approval of a change to %.2f", pending.ItemName, pending.NewPrice),
+		StatusCode: fiber.StatusConflict,
+	})
+}
+
+// hold stores a change of an item's price for approval and notifies the admins. The
+// caller sets the status of its response with heldForApproval.
+func (h *PriceChangeHandler) hold(c *fiber.Ctx, itemType string, itemID int, name string, oldPrice, newPrice float64) (*models.PriceChange, error) {
+	requestedBy, _ := c.Locals("user_id").(string)
+	if requestedBy == "" {
+		requestedBy = "unknown"
+	}
+
+	change := &models.PriceChange{ItemType: itemType, ItemID: itemID, ItemName: name, OldPrice: oldPrice, NewPrice: newPrice, RequestedBy: requestedBy}
+	if err := h.Repo.Create(change); err != nil {
+		return nil, err
+	}
+
+	h.Audit.RecordAction(c, "REQUEST_PRICE_CHANGE", itemType, strconv.Itoa(itemID),
+		fmt.Sprintf("Requested changing the price of %s from %.2f to %.2f, held for approval", name, oldPrice, newPrice))
+	h.notifyAdmins(c.Context(), tenantIDFromCtx(c), *change)
+	return change, nil
+}
+
+// heldForApproval returns the status of an update whose price change was held, 202,
+// and sets X-Price-Change-Id to the ID of the change. The rest of the update was applied.
+func heldForApproval(c *fiber.Ctx, change *models.PriceChange) int {
+	c.Set("X-Price-Change-Id", change.ID)
+	return fiber.StatusAccepted
+}
+
+// notifyAdmins pushes a held price change to the active admins of the tenant
+func (h *PriceChangeHandler) notifyAdmins(ctx context.Context, tenantID string, change models.PriceChange) {
+	notification := models.Notification{Type: NotificationPriceChangePending, Data: change}
+	if err := notifyUsers(ctx, h.Users, h.Hub, tenantID, isAdmin, notification); err != nil {
+		log.Printf("Error notifying admins of price change %s: %v", change.ID, err)
+	}
+}
+
+// notifyRequester pushes the decision on a price change to whoever requested it
+func (h *PriceChangeHandler) notifyRequester(tenantID string, change models.PriceChange) {
+	if h.Hub == nil || change.RequestedBy == "" || change.RequestedBy == "unknown" {
+		return
+	}
+	h.Hub.Publish(tenantID, models.Notification{Type: NotificationPriceChangeReviewed, Data: change, Recipients: []string{change.RequestedBy}})
+}
+
+// GetPriceChanges handles listing price changes
+// @Summary List price changes (Admin)
+// @Description Lists the changes of cab and accessory prices by more than PRICE_APPROVAL_THRESHOLD_PERCENT, most recently requested first. Pass status=pending for the ones waiting for approval.
+// @Tags Price Changes
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "pending, approved or rejected"
+// @Success 200 {object} api.PriceChangeListResponse "Price changes"
+// @Failure 400 {object} api.ErrorResponse "Invalid status"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve price changes"
+// @Router /price-changes [get]
+func (h *PriceChangeHandler) GetPriceChanges(c *fiber.Ctx) error {
+	status := c.Query("status")
+	switch status {
+	case "", models.PriceChangePending, models.PriceChangeApproved, models.PriceChangeRejected:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "status must be pending, approved or rejected", StatusCode: fiber.StatusBadRequest})
+	}
+
+	changes, err := h.Repo.GetAll(status)
+	if err != nil {
+		log.Printf("Error listing price changes: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve price changes", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.PriceChangeListResponse{Changes: changes, Count: len(changes)})
+}
+
+// ApprovePriceChange handles approving a held price change
+// @Summary Approve a price change (Admin)
+// @Description Applies a held price change to its cab or accessory. Changes are approved by an admin other than the one who requested them. The requester is notified.
+// @Tags Price Changes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Price change ID"
+// @Param review body api.PriceChangeReviewRequest false "Optional note"
+// @Success 200 {object} api.PriceChangeResponse "Price change approved and applied"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied, or the caller requested the change"
+// @Failure 404 {object} api.ErrorResponse "Price change not found"
+// @Failure 409 {object} api.ErrorResponse "Already reviewed, or the item no longer exists"
+// @Failure 500 {object} api.ErrorResponse "Failed to approve price change"
+// @Router /price-changes/{id}/approve [post]
+func (h *PriceChangeHandler) ApprovePriceChange(c *fiber.Ctx) error {
+	change, note, done, err := h.loadForReview(c)
+	if done {
+		return err
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if change.RequestedBy == userID {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "A price change must be approved by someone other than who requested it", StatusCode: fiber.StatusForbidden})
+	}
+	if !h.itemExists(c, change) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("%s %d no longer exists; reject the change instead", change.ItemType, change.ItemID),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+
+	// Marking the change approved first means a concurrent review cannot also apply it
+	approved, done, err := h.review(c, change.ID, models.PriceChangeApproved, note)
+	if done {
+		return err
+	}
+	if err := h.apply(c, *approved); err != nil {
+		log.Printf("Error applying approved price change %s: %v", approved.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "The price change was approved but could not be applied", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "APPROVE_PRICE_CHANGE", AuditEntityPriceChange, approved.ID,
+		fmt.Sprintf("Approved changing the price of %s from %.2f to %.2f, requested by %s", approved.ItemName, approved.OldPrice, approved.NewPrice, approved.RequestedBy))
+	h.notifyRequester(tenantIDFromCtx(c), *approved)
+	return c.Status(fiber.StatusOK).JSON(api.PriceChangeResponse{Message: "Price change approved", Change: *approved})
+}
+
+// RejectPriceChange handles rejecting a held price change
+// @Summary Reject a price change (Admin)
+// @Description Rejects a held price change; the cab or accessory keeps its price. The requester is notified.
+// @Tags Price Changes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Price change ID"
+// @Param review body api.PriceChangeReviewRequest false "Optional reason"
+// @Success 200 {object} api.PriceChangeResponse "Price change rejected"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Price change not found"
+// @Failure 409 {object} api.ErrorResponse "Already reviewed"
+// @Failure 500 {object} api.ErrorResponse "Failed to reject price change"
+// @Router /price-changes/{id}/reject [post]
+func (h *PriceChangeHandler) RejectPriceChange(c *fiber.Ctx) error {
+	change, note, done, err := h.loadForReview(c)
+	if done {
+		return err
+	}
+
+	rejected, done, err := h.review(c, change.ID, models.PriceChangeRejected, note)
+	if done {
+		return err
+	}
+
+	h.Audit.RecordAction(c, "REJECT_PRICE_CHANGE", AuditEntityPriceChange, rejected.ID,
+		fmt.Sprintf("Rejected changing the price of %s from %.2f to %.2f, requested by %s", rejected.ItemName, rejected.OldPrice, rejected.NewPrice, rejected.RequestedBy))
+	h.notifyRequester(tenantIDFromCtx(c), *rejected)
+	return c.Status(fiber.StatusOK).JSON(api.PriceChangeResponse{Message: "Price change rejected", Change: *rejected})
+}
+
+// loadForReview parses the review note and loads the pending change. It reports
+// whether it wrote the response, in which case the handler returns err.
+func (h *PriceChangeHandler) loadForReview(c *fiber.Ctx) (*models.PriceChange, string, bool, error) {
+	var input api.PriceChangeReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return nil, "", true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	note := strings.TrimSpace(input.Note)
+	if utf8.RuneCountInString(note) > maxPriceChangeNoteLength {
+		return nil, "", true, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("note cannot be longer than %d characters", maxPriceChangeNoteLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	change, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", true, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Price change not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error fetching price change %s: %v", c.Params("id"), err)
+		return nil, "", true, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve price change", StatusCode: fiber.StatusInternalServerError})
+	}
+	if change.Status != models.PriceChangePending {
+		return nil, "", true, priceChangeReviewed(c, change.Status)
+	}
+	return change, note, false, nil
+}
+
+// review records the decision on a pending change. It reports whether it wrote the
+// response, in which case the handler returns err.
+func (h *PriceChangeHandler) review(c *fiber.Ctx, id, status, note string) (*models.PriceChange, bool, error) {
+	reviewedBy, _ := c.Locals("user_id").(string)
+	change, err := h.Repo.Review(id, status, reviewedBy, note)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, true, priceChangeReviewed(c, "")
+		}
+		log.Printf("Error reviewing price change %s: %v", id, err)
+		return nil, true, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to review price change", StatusCode: fiber.StatusInternalServerError})
+	}
+	return change, false, nil
+}
+
+// priceChangeReviewed writes the 409 for a change that is no longer pending
+func priceChangeReviewed(c *fiber.Ctx, status string) error {
+	message := "The price change was already reviewed"
+	if status != "" {
+		message = "The price change was already " + status
+	}
+	return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: message, StatusCode: fiber.StatusConflict})
+}
+
+// itemExists reports whether the cab or accessory of a change is still there
+func (h *PriceChangeHandler) itemExists(c *fiber.Ctx, change *models.PriceChange) bool {
+	switch change.ItemType {
+	case models.InventoryCab:
+		_, err := h.Cabs.GetCabByID(c.Context(), change.ItemID)
+		return err == nil
+	case models.InventoryAccessory:
+		_, err := h.Accessories.GetByID(c.Context(), change.ItemID)
+		return err == nil
+	}
+	return false
+}
+
+// apply sets the approved price on the cab or accessory, recording the update and
+// notifying its watchers and the marketplace like any other price change
+func (h *PriceChangeHandler) apply(c *fiber.Ctx, change models.PriceChange) error {
+	id := strconv.Itoa(change.ItemID)
+	switch change.ItemType {
+	case models.InventoryCab:
+		before, err := h.Cabs.GetCabByID(c.Context(), change.ItemID)
+		if err != nil {
+			return err
+		}
+		cab := *before
+		cab.Price = change.NewPrice
+		updated, err := h.Cabs.UpdateCab(c.Context(), change.ItemID, cab)
+		if err != nil {
+			return err
+		}
+		h.Audit.RecordUpdate(c, AuditEntityCab, id, before, updated)
+		h.Watch.ItemUpdated(c, models.FavoriteItemCab, change.ItemID, updated.Name,
+			itemStock{Price: before.Price, Quantity: before.Quantity},
+			itemStock{Price: updated.Price, Quantity: updated.Quantity})
+		h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.CabListing(*updated))
+	case models.InventoryAccessory:
+		before, err := h.Accessories.GetByID(c.Context(), change.ItemID)
+		if err != nil {
+			return err
+		}
+		price := change.NewPrice
+		updated, err := h.Accessories.Update(c.Context(), change.ItemID, models.UpdateAccessoryInput{Price: &price})
+		if err != nil {
+			return err
+		}
+		h.Audit.RecordUpdate(c, AuditEntityAccessory, id, before, updated)
+		h.Watch.ItemUpdated(c, models.FavoriteItemAccessory, change.ItemID, updated.Name,
+			itemStock{Price: before.Price, Quantity: before.Quantity},
+			itemStock{Price: updated.Price, Quantity: updated.Quantity})
+		h.Marketplace.ItemChanged(tenantIDFromCtx(c), services.AccessoryListing(updated))
+	default:
+		return fmt.Errorf("unknown item type %q", change.ItemType)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPriceChangeTestApp registers the cab, accessory and price change routes on an
+// in-memory store with two admins, holding price changes of more than 20%
+func setupPriceChangeTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.NotificationHub, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	for _, id := range []string{"admin-1", "admin-2"} {
+		require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: id, Username: id, Email: id + "@example.com", Password: "secret", Role: RoleAdmin, IsActive: true}))
+	}
+	hub := services.NewNotificationHub()
+
+	approvals := NewPriceChangeHandler(store.PriceChanges, store.Users, store.Cabs, store.Accessories, 20, jwtSecret)
+	approvals.Hub = hub
+	approvals.Audit = NewChangeRecorder(store.Logs)
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Approvals = approvals
+	accessories := NewAccessoriesHandler(store.Accessories)
+	accessories.Approvals = approvals
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	approvals.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	protected := apiGroup.Group("", middleware.JWTMiddleware(jwtSecret))
+	protected.Put("/cabs/:id", cabs.UpdateCab)
+	protected.Put("/accessories/:id", accessories.UpdateAccessory)
+	return app, store, hub, jwtSecret
+}
+
+func TestHoldCabPriceChange(t *testing.T) {
+	app, store, hub, jwtSecret := setupPriceChangeTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	otherAdminToken := createTenantTestToken(jwtSecret, "admin-2", RoleAdmin, models.DefaultTenantID)
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "admin-1")
+	defer unsubscribe()
+
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Blue", Status: "Available", Quantity: 3, Price: 700000})
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/cabs/%d", cab.ID)
+	body := func(price float64, quantity int) map[string]interface{} {
+		return map[string]interface{}{"name": "RX-7", "make": "Mazda", "unit_color": "Blue", "status": "Available", "quantity": quantity, "price": price}
+	}
+
+	resp := authedRequest(t, app, staffToken, http.MethodPut, path, body(750000, 3))
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "within the threshold")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, body(75000, 5))
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	changeID := resp.Header.Get("X-Price-Change-Id")
+	require.NotEmpty(t, changeID)
+	var updated models.MultiCab
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	assert.Equal(t, 750000.0, updated.Price, "the price is held")
+	assert.Equal(t, 5, updated.Quantity, "the rest of the update is applied")
+
+	select {
+	case notification := <-notifications:
+		assert.Equal(t, NotificationPriceChangePending, notification.Type)
+		change, ok := notification.Data.(models.PriceChange)
+		require.True(t, ok)
+		assert.Equal(t, 75000.0, change.NewPrice)
+	case <-time.After(time.Second):
+		t.Fatal("admins were not notified")
+	}
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, body(1000000, 5))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "one change waits at a time")
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/price-changes", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/price-changes?status=pending", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.PriceChangeListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, "staff-1", list.Changes[0].RequestedBy)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/price-changes/"+changeID+"/approve", map[string]string{"note": "Checked with the supplier"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	saved, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 75000.0, saved.Price)
+
+	resp = authedRequest(t, app, otherAdminToken, http.MethodPost, "/api/price-changes/"+changeID+"/reject", nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "already approved")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	var actions []string
+	for _, entry := range logs {
+		actions = append(actions, entry.Action)
+	}
+	assert.Contains(t, actions, "REQUEST_PRICE_CHANGE")
+	assert.Contains(t, actions, "APPROVE_PRICE_CHANGE")
+}
+
+func TestReviewAccessoryPriceChange(t *testing.T) {
+	app, store, _, jwtSecret := setupPriceChangeTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	otherAdminToken := createTenantTestToken(jwtSecret, "admin-2", RoleAdmin, models.DefaultTenantID)
+
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Roof Rack", Make: "OEM", UnitColor: "Black", Quantity: 5, Price: 4500})
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/accessories/%d", accessoryID)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPut, path, map[string]interface{}{"price": 9000})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode, "admins' changes are held too")
+	changeID := resp.Header.Get("X-Price-Change-Id")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/price-changes/"+changeID+"/approve", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "requesters cannot approve their own change")
+
+	resp = authedRequest(t, app, otherAdminToken, http.MethodPost, "/api/price-changes/"+changeID+"/reject", map[string]string{"note": "Typo"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var rejected api.PriceChangeResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&rejected))
+	assert.Equal(t, models.PriceChangeRejected, rejected.Change.Status)
+	assert.Equal(t, "Typo", rejected.Change.Note)
+
+	saved, err := store.Accessories.GetByID(context.Background(), accessoryID)
+	require.NoError(t, err)
+	assert.Equal(t, 4500.0, saved.Price, "a rejected change is not applied")
+
+	resp = authedRequest(t, app, otherAdminToken, http.MethodPost, "/api/price-changes/missing/approve", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"oop/internal/api"
+	"oop/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionPricesOverride allows pricing and selling cabs and accessories outside
+// their price guard, and changing the guard itself
+const PermissionPricesOverride = "prices.override"
+
+// pricedItem is the price of a cab or accessory and the guard it must stay within
+type pricedItem struct {
+	Name     string
+	Price    float64
+	MinPrice *float64
+	MaxPrice *float64
+}
+
+func cabPricing(cab models.MultiCab) pricedItem {
+	return pricedItem{Name: cab.Name, Price: cab.Price, MinPrice: cab.MinPrice, MaxPrice: cab.MaxPrice}
+}
+
+func accessoryPricing(accessory models.Accessory) pricedItem {
+	return pricedItem{Name: accessory.Name, Price: accessory.Price, MinPrice: accessory.MinPrice, MaxPrice: accessory.MaxPrice}
+}
+
+// priceGuardError is the error for a price outside the guard of its item
+type priceGuardError struct {
+	item pricedItem
+}
+
+func (e *priceGuardError) Error() string {
+	if e.item.MinPrice != nil && e.item.Price < *e.item.MinPrice {
+		return fmt.Sprintf("Price %.2f of %s is below its minimum price of %.2f", e.item.Price, e.item.Name, *e.item.MinPrice)
+	}
+	return fmt.Sprintf("Price %.2f of %s is above its maximum price of %.2f", e.item.Price, e.item.Name, *e.item.MaxPrice)
+}
+
+// checkPriceGuard returns a *priceGuardError when the price of item is outside its guard
+func checkPriceGuard(item pricedItem) error {
+	if (item.MinPrice != nil && item.Price < *item.MinPrice) || (item.MaxPrice != nil && item.Price > *item.MaxPrice) {
+		return &priceGuardError{item: item}
+	}
+	return nil
+}
+
+// mergePriceBound returns the bound of a price guard after a request that sets it to
+// requested: the current bound when the request leaves it out, and none when it is 0
+func mergePriceBound(current, requested *float64) *float64 {
+	if requested == nil {
+		return current
+	}
+	return models.PriceBound(requested)
+}
+
+// samePriceBound reports whether two bounds of a price guard are the same
+func samePriceBound(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// rejectPriceChange writes the response refusing a change of an item from before to
+// after, as decided by priceChangeError. before is nil for new items. It reports
+// whether it wrote the response, in which case the handler returns err.
+func rejectPriceChange(c *fiber.Ctx, perms *Permissions, before *pricedItem, after pricedItem) (bool, error) {
+	status, message := priceChangeError(c, perms, before, after)
+	if status == 0 {
+		return false, nil
+	}
+	return true, c.Status(status).JSON(api.ErrorResponse{Error: message, StatusCode: status})
+}
+
+// priceChangeError returns the status and error refusing a change of an item from
+// before to after, or 0: 422 for a guard whose minimum is above its maximum, and for
+// callers without PermissionPricesOverride, 403 for changing the guard and 422 for
+// changing the price to one outside it. before is nil for new items.
+func priceChangeError(c *fiber.Ctx, perms *Permissions, before *pricedItem, after pricedItem) (int, string) {
+	if after.MinPrice != nil && after.MaxPrice != nil && *after.MinPrice > *after.MaxPrice {
+		return fiber.StatusUnprocessableEntity, "min_price cannot be above max_price"
+	}
+	if perms.Allowed(c, PermissionPricesOverride) {
+		return 0, ""
+	}
+
+	var current pricedItem
+	if before != nil {
+		current = *before
+	}
+	if !samePriceBound(current.MinPrice, after.MinPrice) || !samePriceBound(current.MaxPrice, after.MaxPrice) {
+		return fiber.StatusForbidden, "Changing min_price or max_price requires the " + PermissionPricesOverride + " permission"
+	}
+	// Prices set before the guard are kept until someone changes them
+	if before != nil && before.Price == after.Price {
+		return 0, ""
+	}
+	if err := checkPriceGuard(after); err != nil {
+		return fiber.StatusUnprocessableEntity, err.Error()
+	}
+	return 0, ""
+}
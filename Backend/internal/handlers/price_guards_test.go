@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"oop/internal/config"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPriceGuardTestApp registers the cab, accessory and sale routes on an in-memory
+// store holding a cab that may only be priced between 500,000 and 900,000. Managers
+// hold the prices.override permission.
+func setupPriceGuardTestApp(t *testing.T) (*fiber.App, *memory.Store, int, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	perms := NewPermissions(config.PermissionsConfig{RolePermissions: map[string][]string{"manager": {PermissionPricesOverride}}})
+
+	minPrice, maxPrice := 500000.0, 900000.0
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Blue", Status: "Available",
+		Quantity: 3, Price: 700000, MinPrice: &minPrice, MaxPrice: &maxPrice})
+	require.NoError(t, err)
+
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Perms = perms
+	accessories := NewAccessoriesHandler(store.Accessories)
+	accessories.Perms = perms
+	sales := NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret)
+	sales.Perms = perms
+
+	app := fiber.New()
+	api := app.Group("/api")
+	sales.Routes(api, NewRouteMiddleware(jwtSecret))
+	protected := api.Group("", middleware.JWTMiddleware(jwtSecret))
+	protected.Post("/cabs", cabs.AddCab)
+	protected.Put("/cabs/:id", cabs.UpdateCab)
+	protected.Post("/accessories", accessories.CreateAccessory)
+	protected.Put("/accessories/:id", accessories.UpdateAccessory)
+	return app, store, cab.ID, jwtSecret
+}
+
+func TestCabPriceGuard(t *testing.T) {
+	app, store, cabID, jwtSecret := setupPriceGuardTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	managerToken := createTenantTestToken(jwtSecret, "manager-1", "manager", models.DefaultTenantID)
+	path := fmt.Sprintf("/api/cabs/%d", cabID)
+	cab := func(price float64, extra map[string]interface{}) map[string]interface{} {
+		body := map[string]interface{}{"name": "RX-7", "make": "Mazda", "unit_color": "Blue", "status": "Available", "quantity": 3, "price": price}
+		for key, value := range extra {
+			body[key] = value
+		}
+		return body
+	}
+
+	resp := authedRequest(t, app, staffToken, http.MethodPut, path, cab(70, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "fat-fingered price")
+	var failure struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&failure))
+	assert.Equal(t, "Price 70.00 of RX-7 is below its minimum price of 500000.00", failure.Error)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, cab(650000, nil))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated models.MultiCab
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	require.NotNil(t, updated.MinPrice, "a guard left out of the request is kept")
+	assert.Equal(t, 500000.0, *updated.MinPrice)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, cab(650000, map[string]interface{}{"min_price": 0}))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "staff cannot remove the guard")
+
+	resp = authedRequest(t, app, managerToken, http.MethodPut, path, cab(450000, nil))
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "override permission")
+	resp = authedRequest(t, app, managerToken, http.MethodPut, path, cab(450000, map[string]interface{}{"min_price": 600000, "max_price": 550000}))
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode, "minimum above maximum")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/cabs", cab(100000, map[string]interface{}{"name": "Scrum", "max_price": 200000}))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/cabs", cab(100000, map[string]interface{}{"name": "Scrum"}))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "no guard to check")
+
+	saved, err := store.Cabs.GetCabByID(context.Background(), cabID)
+	require.NoError(t, err)
+	assert.Equal(t, 450000.0, saved.Price)
+	require.NotNil(t, saved.MaxPrice)
+	assert.Equal(t, 900000.0, *saved.MaxPrice)
+}
+
+func TestAccessoryPriceGuard(t *testing.T) {
+	app, store, _, jwtSecret := setupPriceGuardTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/accessories",
+		map[string]interface{}{"name": "Roof Rack", "make": "OEM", "unit_color": "Black", "quantity": 5, "price": 4500, "min_price": 4000, "max_price": 6000})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created struct {
+		Data models.Accessory `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	path := fmt.Sprintf("/api/accessories/%d", created.Data.ID)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, map[string]interface{}{"price": 60000})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, map[string]interface{}{"max_price": 0})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, map[string]interface{}{"price": 5000, "quantity": 4})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPut, path, map[string]interface{}{"max_price": 0})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	accessory, err := store.Accessories.GetByID(context.Background(), created.Data.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5000.0, accessory.Price)
+	require.NotNil(t, accessory.MinPrice)
+	assert.Equal(t, 4000.0, *accessory.MinPrice)
+	assert.Nil(t, accessory.MaxPrice)
+}
+
+func TestSellCabOutsidePriceGuard(t *testing.T) {
+	app, store, _, jwtSecret := setupPriceGuardTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	managerToken := createTenantTestToken(jwtSecret, "manager-1", "manager", models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	// Priced before the guard was set
+	minPrice := 500000.0
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Status: "Available",
+		Quantity: 2, Price: 70, MinPrice: &minPrice})
+	require.NoError(t, err)
+	path := fmt.Sprintf("/api/cabs/%d/sell", cab.ID)
+	sale := models.CabSalePayload{CustomerID: "customer-1", Quantity: 1}
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, path, sale)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	sales, err := store.Sales.GetAll(context.Background(), models.SalesFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, sales)
+
+	resp = authedRequest(t, app, managerToken, http.MethodPost, path, sale)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "only admins and staff may sell")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, path, sale)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "admins hold prices.override")
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Receipt output formats
+const (
+	ReceiptFormatESCPOS = "escpos"
+	ReceiptFormatText   = "text"
+	ReceiptFormatPDF    = "pdf"
+)
+
+// GetSaleReceiptHandler handles printing a sale's receipt on 58mm thermal printers or,
+// as a PDF, on the counter's printer
+// @Summary Get a sale receipt
+// @Description Renders the receipt of a sale with the company details, header, footer and terms of the document template. format=escpos returns an ESC/POS byte stream for 58mm thermal paper (32 characters per line), including the logo and a paper cut, to send to the printer as is; format=text returns plain text for the same paper; format=pdf returns a PDF on A5 or half-letter paper with the unit prices and the VAT of the sale.
+// @Tags Sales
+// @Produce plain,octet-stream,application/pdf
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Param format query string false "escpos, pdf or text (default)"
+// @Param paper query string false "Paper of PDF receipts: a5 (default) or half-letter"
+// @Success 200 {string} string "Receipt"
+// @Failure 400 {object} api.ErrorResponse "Invalid format or paper"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to render receipt"
+// @Router /sales/{id}/receipt [get]
+func (h *SaleHandlers) GetSaleReceiptHandler(c *fiber.Ctx) error {
+	format := c.Query("format", ReceiptFormatText)
+	if format != ReceiptFormatESCPOS && format != ReceiptFormatText && format != ReceiptFormatPDF {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "format must be escpos, pdf or text", StatusCode: fiber.StatusBadRequest})
+	}
+	page, ok := services.ReceiptPages[c.Query("paper", services.ReceiptPageA5)]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "paper must be a5 or half-letter", StatusCode: fiber.StatusBadRequest})
+	}
+
+	id := c.Params("id")
+	sale, err := h.Repo.GetByID(c.Context(), id)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Printf("Error getting sale by ID %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve sale", StatusCode: fiber.StatusInternalServerError})
+	}
+	if sale == nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+	}
+
+	items, err := h.Repo.GetSaleItems(c.Context(), id)
+	if err != nil {
+		log.Printf("Error getting items for sale ID %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to render receipt", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	receipt := services.Receipt{SaleID: sale.ID, SaleDate: sale.SaleDate, Total: sale.TotalPrice, TaxType: sale.TaxType, VAT: sale.VATAmount}
+	if h.Receipts != nil {
+		if issued, err := h.Receipts.GetIssued(sale.ID); err == nil {
+			receipt.ORNumber = issued.ORNumber
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting official receipt of sale %s: %v", id, err)
+		}
+	}
+	if custRepo, ok := h.CustRepo.(repositories.CustomerRepository); ok {
+		if customer, err := custRepo.GetCustomerByID(c.Context(), sale.CustomerID); err == nil && customer != nil {
+			receipt.Customer = customer.FullName
+		}
+	}
+	for _, item := range items {
+		receipt.Lines = append(receipt.Lines, services.ReceiptLine{
+			Description: h.saleItemDescription(c, item.Item),
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Subtotal:    item.Subtotal,
+		})
+	}
+
+	// Receipts print without branding when no template was saved or it cannot be read
+	if h.Documents != nil {
+		if template, err := h.Documents.Get(); err != nil {
+			log.Printf("Error getting document template for receipt of sale %s: %v", id, err)
+		} else {
+			receipt.Template = template
+			if template.HasLogo && format == ReceiptFormatESCPOS {
+				if logo, err := h.Documents.GetLogo(); err == nil {
+					receipt.Logo = logo.Data
+				}
+			}
+		}
+	}
+
+	switch format {
+	case ReceiptFormatPDF:
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, sale.ID))
+		return c.Status(fiber.StatusOK).Send(services.RenderPDFReceipt(page, receipt))
+	case ReceiptFormatESCPOS:
+		c.Set(fiber.HeaderContentType, fiber.MIMEOctetStream)
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="receipt-%s.bin"`, sale.ID))
+		return c.Status(fiber.StatusOK).Send(services.RenderESCPOSReceipt(receipt))
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	return c.Status(fiber.StatusOK).SendString(services.RenderTextReceipt(receipt))
+}
+
+// saleItemDescription names a sale item after the cab or accessory sold, falling
+// back to its type and ID when the record is gone
+func (h *SaleHandlers) saleItemDescription(c *fiber.Ctx, item models.Ref) string {
+	switch item.Type() {
+	case models.RefCab:
+		if cabRepo, ok := h.CabRepo.(repositories.CabsRepository); ok {
+			if cab, err := cabRepo.GetCabByID(c.Context(), item.IntID()); err == nil && cab != nil {
+				return cab.Name
+			}
+		}
+		return "Cab #" + item.ID()
+	case models.RefAccessory:
+		if accRepo, ok := h.AccRepo.(repositories.AccessoryRepository); ok {
+			if accessory, err := accRepo.GetByID(c.Context(), item.IntID()); err == nil {
+				return accessory.Name
+			}
+		}
+		return "Accessory #" + item.ID()
+	case models.RefMaterial:
+		return "Material #" + item.ID()
+	}
+	return item.Type()
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupReceiptTestApp registers the sale routes on an in-memory store holding one
+// sale of a cab with a roof rack, and returns that sale's ID
+func setupReceiptTestApp(t *testing.T) (*fiber.App, *memory.Store, string, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	cab := addTestCab(t, store)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Roof rack", Make: models.MakeGeneric, Quantity: 5, Price: 1500, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	sale, err := store.Sales.SellCab(context.Background(), cab.ID, customer.ID, 1, "staff-1", "", []models.AccessoryForSale{{ID: accessoryID, Name: "Roof rack", Price: 1500, Quantity: 2}})
+	require.NoError(t, err)
+
+	h := NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret)
+	h.Documents = store.Documents
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID), sale.ID
+}
+
+func TestGetSaleReceiptText(t *testing.T) {
+	app, store, token, saleID := setupReceiptTestApp(t)
+	require.NoError(t, store.Documents.Save(models.DocumentTemplate{CompanyName: "Surplus Motors", FooterText: "Thank you!"}))
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleID+"/receipt", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMETextPlainCharsetUTF8, resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+	assert.True(t, strings.HasPrefix(text, "         SURPLUS MOTORS\n"))
+	assert.Contains(t, text, "Customer: Juan Dela Cruz\n")
+	assert.Contains(t, text, "Unit 42\n  1 x 250,000.00      250,000.00\n")
+	assert.Contains(t, text, "Roof rack\n  2 x 1,500.00          3,000.00\n")
+	assert.Contains(t, text, "TOTAL                 253,000.00\n")
+	assert.True(t, strings.HasSuffix(text, "Thank you!\n"))
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		assert.LessOrEqual(t, len([]rune(line)), 32, line)
+	}
+}
+
+func TestGetSaleReceiptESCPOS(t *testing.T) {
+	app, _, token, saleID := setupReceiptTestApp(t)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleID+"/receipt?format=escpos", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEOctetStream, resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="receipt-`+saleID+`.bin"`, resp.Header.Get("Content-Disposition"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1b, '@'}, body[:2], "starts by initializing the printer")
+	assert.Contains(t, string(body), "TOTAL                 253,000.00\n")
+}
+
+func TestGetSaleReceiptPDF(t *testing.T) {
+	app, store, token, saleID := setupReceiptTestApp(t)
+	require.NoError(t, store.Documents.Save(models.DocumentTemplate{CompanyName: "Surplus Motors", FooterText: "Thank you!"}))
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleID+"/receipt?format=pdf&paper=half-letter", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `inline; filename="receipt-`+saleID+`.pdf"`, resp.Header.Get("Content-Disposition"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	document := string(body)
+	assert.True(t, strings.HasPrefix(document, "%PDF-1.4\n"))
+	assert.Contains(t, document, "/MediaBox [0 0 396.00 612.00]")
+	for _, text := range []string{"SURPLUS MOTORS", "Juan Dela Cruz", "Unit 42", "250,000.00", "Roof rack", "1,500.00", "3,000.00",
+		"VATable sales", "225,892.86", "27,107.14", "253,000.00", "Thank you!"} {
+		assert.Contains(t, document, "("+text+") Tj", text)
+	}
+}
+
+func TestGetSaleReceiptErrors(t *testing.T) {
+	app, _, token, saleID := setupReceiptTestApp(t)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleID+"/receipt?format=html", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleID+"/receipt?format=pdf&paper=legal", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/sales/missing/receipt", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodGet, "/api/sales/"+saleID+"/receipt", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationReceiptSeriesLow is pushed when an official receipt series reaches its
+// warning level, and again when it runs out
+const NotificationReceiptSeriesLow = "receipt_series_low"
+
+// defaultReceiptWarnRemaining is the warning level of a series when none is given
+const defaultReceiptWarnRemaining = 50
+
+// maxReceiptNumber is the largest OR number a series can go up to
+const maxReceiptNumber = 999999999
+
+// ReceiptSeriesHandler manages the official receipt (OR) series registered for each
+// branch and issues their numbers to sales when they are paid
+type ReceiptSeriesHandler struct {
+	Repo      repositories.ReceiptSeriesRepository
+	Sales     repositories.SalesRepository
+	Hub       *services.NotificationHub // Optional; without it low series are not pushed
+	Audit     *ChangeRecorder
+	jwtSecret []byte
+}
+
+// NewReceiptSeriesHandler creates a new ReceiptSeriesHandler instance
+func NewReceiptSeriesHandler(repo repositories.ReceiptSeriesRepository, sales repositories.SalesRepository, jwtSecret []byte) *ReceiptSeriesHandler {
+	return &ReceiptSeriesHandler{Repo: repo, Sales: sales, jwtSecret: jwtSecret}
+}
+
+// Routes registers the receipt series and official receipt routes
+func (h *ReceiptSeriesHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	seriesGroup := r.Group("/receipt-series", mw.Auth)
+	seriesGroup.Get("/", h.GetReceiptSeries)                       // GET /api/receipt-series
+	seriesGroup.Get("/:id", h.GetReceiptSeriesByID)                // GET /api/receipt-series/:id
+	seriesGroup.Post("/", mw.Admin, h.CreateReceiptSeries)         // POST /api/receipt-series
+	seriesGroup.Put("/:id", mw.Admin, h.UpdateReceiptSeries)       // PUT /api/receipt-series/:id
+	r.Get("/sales/:id/official-receipt", mw.Auth, h.GetIssued)     // GET /api/sales/:id/official-receipt
+	r.Post("/sales/:id/official-receipt", mw.Auth, h.IssueForSale) // POST /api/sales/:id/official-receipt
+}
+
+// normalizeBranch trims a branch code and upper-cases it, so "mnl" and "MNL " name the same branch
+func normalizeBranch(branch string) string {
+	return strings.ToUpper(strings.TrimSpace(branch))
+}
+
+// receiptSeriesStatus reports the numbers a series has left
+func receiptSeriesStatus(series models.ReceiptSeries) api.ReceiptSeriesStatus {
+	return api.ReceiptSeriesStatus{ReceiptSeries: series, Remaining: series.Remaining(), NearlyExhausted: series.NearlyExhausted()}
+}
+
+// ReceiptSeriesRequest is the body for registering an official receipt series
+type ReceiptSeriesRequest struct {
+	Branch        string `json:"branch"`        // Branch code, up to 50 characters
+	Prefix        string `json:"prefix"`        // Printed before the number, up to 20 characters
+	StartNumber   int    `json:"startNumber"`   // First number of the booklet, at least 1
+	EndNumber     int    `json:"endNumber"`     // Last number of the booklet
+	WarnRemaining *int   `json:"warnRemaining"` // Warn when this many numbers are left; 50 when omitted
+}
+
+// validate trims the fields, checks them and fills in the default warning level
+func (r *ReceiptSeriesRequest) validate() error {
+	r.Branch = normalizeBranch(r.Branch)
+	r.Prefix = strings.TrimSpace(r.Prefix)
+
+	if r.Branch == "" {
+		return fmt.Errorf("branch is required")
+	}
+	if utf8.RuneCountInString(r.Branch) > 50 || utf8.RuneCountInString(r.Prefix) > 20 {
+		return fmt.Errorf("branch must be at most 50 characters and prefix at most 20")
+	}
+	if r.StartNumber < 1 || r.EndNumber < r.StartNumber || r.EndNumber > maxReceiptNumber {
+		return fmt.Errorf("startNumber must be at least 1 and endNumber between startNumber and %d", maxReceiptNumber)
+	}
+	if r.WarnRemaining == nil {
+		warn := defaultReceiptWarnRemaining
+		r.WarnRemaining = &warn
+	}
+	if *r.WarnRemaining < 0 {
+		return fmt.Errorf("warnRemaining cannot be negative")
+	}
+	return nil
+}
+
+// ReceiptSeriesUpdateRequest is the body for updating an official receipt series.
+// The branch and number range are fixed once registered.
+type ReceiptSeriesUpdateRequest struct {
+	Active        *bool `json:"active"`        // Inactive series issue no numbers
+	WarnRemaining *int  `json:"warnRemaining"` // Warn when this many numbers are left
+}
+
+// IssueReceiptRequest is the body for issuing an OR number to a sale
+type IssueReceiptRequest struct {
+	Branch string `json:"branch"` // Branch taking the payment
+}
+
+// GetReceiptSeries handles listing official receipt series
+// @Summary List official receipt series
+// @Description Lists the OR series of every branch, or of one, with the numbers each has left and whether it is nearly exhausted.
+// @Tags Receipts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param branch query string false "Branch code"
+// @Success 200 {object} api.ReceiptSeriesListResponse "Series"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve receipt series"
+// @Router /receipt-series [get]
+func (h *ReceiptSeriesHandler) GetReceiptSeries(c *fiber.Ctx) error {
+	all, err := h.Repo.GetAll(normalizeBranch(c.Query("branch")))
+	if err != nil {
+		log.Printf("Error getting receipt series: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve receipt series", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	statuses := make([]api.ReceiptSeriesStatus, 0, len(all))
+	for _, series := range all {
+		statuses = append(statuses, receiptSeriesStatus(series))
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ReceiptSeriesListResponse{Series: statuses, Count: len(statuses)})
+}
+
+// GetReceiptSeriesByID handles getting an official receipt series
+// @Summary Get an official receipt series
+// @Tags Receipts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Series ID"
+// @Success 200 {object} api.ReceiptSeriesStatus "Series"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Receipt series not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve receipt series"
+// @Router /receipt-series/{id} [get]
+func (h *ReceiptSeriesHandler) GetReceiptSeriesByID(c *fiber.Ctx) error {
+	series, err := h.loadReceiptSeries(c)
+	if series == nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(receiptSeriesStatus(*series))
+}
+
+// loadReceiptSeries fetches the series named by the :id parameter, writing the error
+// response itself when it fails
+func (h *ReceiptSeriesHandler) loadReceiptSeries(c *fiber.Ctx) (*models.ReceiptSeries, error) {
+	series, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Receipt series not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting receipt series %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve receipt series", StatusCode: fiber.StatusInternalServerError})
+	}
+	return series, nil
+}
+
+// CreateReceiptSeries handles registering an official receipt series
+// @Summary Register an official receipt series (Admin)
+// @Description Registers a booklet of OR numbers for a branch. Its numbers are issued in order once the series with lower start numbers of the branch run out. Series with the same prefix cannot share numbers.
+// @Tags Receipts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param series body ReceiptSeriesRequest true "Series"
+// @Success 201 {object} api.ReceiptSeriesStatus "Series registered"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "Overlaps a registered series"
+// @Failure 500 {object} api.ErrorResponse "Failed to register receipt series"
+// @Router /receipt-series [post]
+func (h *ReceiptSeriesHandler) CreateReceiptSeries(c *fiber.Ctx) error {
+	var input ReceiptSeriesRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	series := &models.ReceiptSeries{
+		Branch:        input.Branch,
+		Prefix:        input.Prefix,
+		StartNumber:   input.StartNumber,
+		EndNumber:     input.EndNumber,
+		WarnRemaining: *input.WarnRemaining,
+		Active:        true,
+	}
+	series.CreatedBy, _ = c.Locals("user_id").(string)
+
+	existing, err := h.Repo.GetAll("")
+	if err != nil {
+		log.Printf("Error getting receipt series to check for overlaps: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to register receipt series", StatusCode: fiber.StatusInternalServerError})
+	}
+	for _, other := range existing {
+		if series.Overlaps(other) {
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Numbers %s to %s are already registered for branch %s", other.FormatNumber(other.StartNumber), other.FormatNumber(other.EndNumber), other.Branch),
+				StatusCode: fiber.StatusConflict,
+			})
+		}
+	}
+
+	if err := h.Repo.Create(series); err != nil {
+		log.Printf("Error registering receipt series: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to register receipt series", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_RECEIPT_SERIES", AuditEntityReceipts, series.ID,
+		fmt.Sprintf("Registered OR numbers %s to %s for branch %s", series.FormatNumber(series.StartNumber), series.FormatNumber(series.EndNumber), series.Branch))
+
+	return c.Status(fiber.StatusCreated).JSON(receiptSeriesStatus(*series))
+}
+
+// UpdateReceiptSeries handles updating an official receipt series
+// @Summary Update an official receipt series (Admin)
+// @Description Activates or retires a series, or changes its warning level. Retired series issue no more numbers.
+// @Tags Receipts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Series ID"
+// @Param series body ReceiptSeriesUpdateRequest true "Fields to change"
+// @Success 200 {object} api.ReceiptSeriesStatus "Series updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Receipt series not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update receipt series"
+// @Router /receipt-series/{id} [put]
+func (h *ReceiptSeriesHandler) UpdateReceiptSeries(c *fiber.Ctx) error {
+	var input ReceiptSeriesUpdateRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if input.WarnRemaining != nil && *input.WarnRemaining < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "warnRemaining cannot be negative", StatusCode: fiber.StatusBadRequest})
+	}
+
+	series, err := h.loadReceiptSeries(c)
+	if series == nil {
+		return err
+	}
+	before := *series
+	if input.Active != nil {
+		series.Active = *input.Active
+	}
+	if input.WarnRemaining != nil {
+		series.WarnRemaining = *input.WarnRemaining
+	}
+
+	if err := h.Repo.Update(series); err != nil {
+		log.Printf("Error updating receipt series %s: %v", series.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update receipt series", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityReceipts, series.ID, before, *series)
+
+	return c.Status(fiber.StatusOK).JSON(receiptSeriesStatus(*series))
+}
+
+// GetIssued handles getting the OR number of a sale
+// @Summary Get the official receipt of a sale
+// @Tags Receipts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Success 200 {object} models.OfficialReceipt "Official receipt"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "No OR number issued to the sale"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve official receipt"
+// @Router /sales/{id}/official-receipt [get]
+func (h *ReceiptSeriesHandler) GetIssued(c *fiber.Ctx) error {
+	receipt, err := h.Repo.GetIssued(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No official receipt issued to this sale", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting official receipt of sale %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve official receipt", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(receipt)
+}
+
+// IssueForSale handles issuing an OR number to a sale
+// @Summary Issue an official receipt for a sale
+// @Description Allocates the next OR number of the branch to a sale when it is paid, from the branch's active series with the lowest start number that has numbers left. A sale gets one number: issuing again returns it with status 200. The response warns when the series is nearly exhausted, and a receipt_series_low notification is pushed when it reaches its warning level and when it runs out.
+// @Tags Receipts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Param branch body IssueReceiptRequest true "Branch taking the payment"
+// @Success 200 {object} api.OfficialReceiptResponse "Already issued"
+// @Success 201 {object} api.OfficialReceiptResponse "Issued"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 409 {object} api.ErrorResponse "No series of the branch has numbers left"
+// @Failure 500 {object} api.ErrorResponse "Failed to issue official receipt"
+// @Router /sales/{id}/official-receipt [post]
+func (h *ReceiptSeriesHandler) IssueForSale(c *fiber.Ctx) error {
+	var input IssueReceiptRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	branch := normalizeBranch(input.Branch)
+	if branch == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "branch is required", StatusCode: fiber.StatusBadRequest})
+	}
+
+	saleID := c.Params("id")
+	sale, err := h.Sales.GetByID(c.Context(), saleID)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Printf("Error getting sale by ID %s: %v", saleID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve sale", StatusCode: fiber.StatusInternalServerError})
+	}
+	if sale == nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+	}
+
+	issued, err := h.Repo.GetIssued(sale.ID)
+	if err == nil {
+		return h.respondIssued(c, issued)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting official receipt of sale %s: %v", sale.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to issue official receipt", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	receipt, series, err := h.Repo.Issue(branch, sale.ID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Branch %s has no active official receipt series with numbers left", branch),
+				StatusCode: fiber.StatusConflict,
+			})
+		}
+		log.Printf("Error issuing official receipt to sale %s: %v", sale.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to issue official receipt", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "ISSUE_OFFICIAL_RECEIPT", AuditEntitySale, sale.ID,
+		fmt.Sprintf("Issued OR %s for branch %s", receipt.ORNumber, receipt.Branch))
+	if remaining := series.Remaining(); remaining == series.WarnRemaining || remaining == 0 {
+		h.notifyLow(c, *series)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(officialReceiptResponse(receipt, *series))
+}
+
+// respondIssued answers with a receipt issued earlier and the current state of its series
+func (h *ReceiptSeriesHandler) respondIssued(c *fiber.Ctx, receipt *models.OfficialReceipt) error {
+	series, err := h.Repo.GetByID(receipt.SeriesID)
+	if err != nil {
+		log.Printf("Error getting receipt series %s: %v", receipt.SeriesID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to issue official receipt", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(officialReceiptResponse(receipt, *series))
+}
+
+// officialReceiptResponse reports an issued receipt with the numbers left in its series
+func officialReceiptResponse(receipt *models.OfficialReceipt, series models.ReceiptSeries) api.OfficialReceiptResponse {
+	response := api.OfficialReceiptResponse{Receipt: receipt, Remaining: series.Remaining()}
+	switch {
+	case response.Remaining == 0:
+		response.Warning = fmt.Sprintf("OR series %s of branch %s is used up; register the next booklet", series.FormatNumber(series.StartNumber), series.Branch)
+	case series.NearlyExhausted():
+		response.Warning = fmt.Sprintf("Only %d OR numbers left in series %s of branch %s", response.Remaining, series.FormatNumber(series.StartNumber), series.Branch)
+	}
+	return response
+}
+
+// notifyLow pushes a receipt_series_low notification to the tenant
+func (h *ReceiptSeriesHandler) notifyLow(c *fiber.Ctx, series models.ReceiptSeries) {
+	if h.Hub != nil {
+		h.Hub.Publish(tenantIDFromCtx(c), models.Notification{Type: NotificationReceiptSeriesLow, Data: receiptSeriesStatus(series)})
+	}
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupReceiptSeriesTestApp registers the receipt series routes on an in-memory store
+func setupReceiptSeriesTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewReceiptSeriesHandler(store.Receipts, store.Sales, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+func TestCreateReceiptSeries(t *testing.T) {
+	app, _, jwtSecret := setupReceiptSeriesTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	series := ReceiptSeriesRequest{Branch: " mnl", Prefix: "A-", StartNumber: 1001, EndNumber: 1500}
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/receipt-series", series)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for name, input := range map[string]ReceiptSeriesRequest{
+		"no branch":      {StartNumber: 1, EndNumber: 10},
+		"reversed range": {Branch: "MNL", StartNumber: 10, EndNumber: 1},
+		"zero start":     {Branch: "MNL", StartNumber: 0, EndNumber: 10},
+	} {
+		resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/receipt-series", input)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/receipt-series", series)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.ReceiptSeriesStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, "MNL", created.Branch)
+	assert.Equal(t, 1001, created.NextNumber)
+	assert.Equal(t, 500, created.Remaining)
+	assert.Equal(t, defaultReceiptWarnRemaining, created.WarnRemaining)
+
+	overlapping := ReceiptSeriesRequest{Branch: "CEB", Prefix: "A-", StartNumber: 1500, EndNumber: 2000}
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/receipt-series", overlapping)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "registered numbers are unique per prefix")
+	overlapping.Prefix = "B-"
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/receipt-series", overlapping)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/receipt-series?branch=mnl", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.ReceiptSeriesListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 1, list.Count)
+	assert.Equal(t, created.ID, list.Series[0].ID)
+}
+
+func TestIssueOfficialReceipt(t *testing.T) {
+	app, store, jwtSecret := setupReceiptSeriesTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	series := &models.ReceiptSeries{Branch: "MNL", Prefix: "OR-", StartNumber: 1, EndNumber: 3, WarnRemaining: 1, Active: true}
+	require.NoError(t, store.Receipts.Create(series))
+
+	var saleIDs []string
+	for i := 0; i < 4; i++ {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "staff-1", SaleDate: "2025-03-12", TotalPrice: 1000})
+		require.NoError(t, err)
+		saleIDs = append(saleIDs, id)
+	}
+	issue := func(saleID, branch string) *http.Response {
+		return authedRequest(t, app, token, http.MethodPost, "/api/sales/"+saleID+"/official-receipt", IssueReceiptRequest{Branch: branch})
+	}
+
+	assert.Equal(t, http.StatusNotFound, issue("missing", "MNL").StatusCode)
+	assert.Equal(t, http.StatusBadRequest, issue(saleIDs[0], "").StatusCode)
+	assert.Equal(t, http.StatusConflict, issue(saleIDs[0], "CEB").StatusCode, "branch without a series")
+
+	resp := issue(saleIDs[0], "mnl")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var issued api.OfficialReceiptResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&issued))
+	assert.Equal(t, "OR-1", issued.Receipt.ORNumber)
+	assert.Equal(t, 2, issued.Remaining)
+	assert.Empty(t, issued.Warning)
+
+	resp = issue(saleIDs[0], "MNL")
+	require.Equal(t, http.StatusOK, resp.StatusCode, "issuing again returns the same number")
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&issued))
+	assert.Equal(t, "OR-1", issued.Receipt.ORNumber)
+
+	resp = issue(saleIDs[1], "MNL")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&issued))
+	assert.Equal(t, "OR-2", issued.Receipt.ORNumber)
+	assert.Contains(t, issued.Warning, "Only 1 OR numbers left")
+
+	resp = issue(saleIDs[2], "MNL")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&issued))
+	assert.Contains(t, issued.Warning, "used up")
+	assert.Equal(t, http.StatusConflict, issue(saleIDs[3], "MNL").StatusCode, "series exhausted")
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleIDs[1]+"/official-receipt", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"orNumber":"OR-2"`)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/sales/"+saleIDs[3]+"/official-receipt", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpdateReceiptSeries(t *testing.T) {
+	app, store, jwtSecret := setupReceiptSeriesTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	series := &models.ReceiptSeries{Branch: "MNL", StartNumber: 1, EndNumber: 100, WarnRemaining: 10, Active: true}
+	require.NoError(t, store.Receipts.Create(series))
+
+	active := false
+	resp := authedRequest(t, app, adminToken, http.MethodPut, "/api/receipt-series/"+series.ID, ReceiptSeriesUpdateRequest{Active: &active})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var updated api.ReceiptSeriesStatus
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&updated))
+	assert.False(t, updated.Active)
+	assert.Equal(t, 10, updated.WarnRemaining, "unchanged")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/receipt-series/missing", ReceiptSeriesUpdateRequest{Active: &active})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, AuditEntityReceipts, logs[0].EntityType)
+}
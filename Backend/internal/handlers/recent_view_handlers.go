@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits of the recently viewed list
+const (
+	defaultRecentViews = 20
+	maxRecentViews     = 100
+)
+
+// RecentViews records the cabs, accessories, customers and sales users open and
+// serves each user's recently viewed list, so it follows them across devices. Views
+// are written in the background by Tracker. A nil *RecentViews is valid and records
+// nothing, so the detail handlers work without one.
+type RecentViews struct {
+	Repo      repositories.EntityViewRepository
+	Tracker   *services.ViewTracker
+	jwtSecret []byte
+}
+
+// NewRecentViews creates a new RecentViews instance
+func NewRecentViews(repo repositories.EntityViewRepository, tracker *services.ViewTracker, jwtSecret []byte) *RecentViews {
+	return &RecentViews{Repo: repo, Tracker: tracker, jwtSecret: jwtSecret}
+}
+
+// Routes registers the recently viewed routes
+func (v *RecentViews) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/users/me/recent", mw.Auth, v.GetRecentViews) // GET /api/users/me/recent
+}
+
+// Viewed records that the caller opened a record. Callers without a valid token,
+// which can reach the public cab and accessory routes, are not tracked.
+func (v *RecentViews) Viewed(c *fiber.Ctx, entityType, entityID string) {
+	if v == nil || v.Repo == nil || v.Tracker == nil {
+		return
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		userID = middleware.BearerUserID(c, v.jwtSecret)
+	}
+	if userID == "" {
+		return
+	}
+
+	// The view is written after the request ends, when Fiber reuses the memory that
+	// route params point into, so the IDs are copied
+	v.Tracker.Track(v.Repo, models.EntityView{
+		UserID:     strings.Clone(userID),
+		EntityType: entityType,
+		EntityID:   strings.Clone(entityID),
+		ViewedAt:   time.Now(),
+	})
+}
+
+// validViewEntity reports whether entityType is tracked
+func validViewEntity(entityType string) bool {
+	switch entityType {
+	case models.ViewEntityCab, models.ViewEntityAccessory, models.ViewEntityCustomer, models.ViewEntitySale:
+		return true
+	}
+	return false
+}
+
+// GetRecentViews handles listing the records the caller recently opened
+// @Summary List my recently viewed records
+// @Description Lists the cabs, accessories, customers and sales the caller opened, most recent first, once per record. Views are recorded in the background, so a record opened a moment ago may not be listed yet.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "Only list this type: cab, accessory, customer or sale"
+// @Param limit query int false "Number of records (default 20, max 100)"
+// @Success 200 {object} api.RecentViewListResponse "Recently viewed records"
+// @Failure 400 {object} api.ErrorResponse "Invalid type or limit"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve recently viewed records"
+// @Router /users/me/recent [get]
+func (v *RecentViews) GetRecentViews(c *fiber.Ctx) error {
+	entityType := c.Query("type")
+	if entityType != "" && !validViewEntity(entityType) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "type must be cab, accessory, customer or sale", StatusCode: fiber.StatusBadRequest})
+	}
+
+	limit := defaultRecentViews
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxRecentViews {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "limit must be between 1 and 100", StatusCode: fiber.StatusBadRequest})
+		}
+		limit = parsed
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	views, err := v.Repo.GetRecent(userID, entityType, limit)
+	if err != nil {
+		log.Printf("Error getting recent views of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve recently viewed records", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.RecentViewListResponse{Views: views, Count: len(views)})
+}
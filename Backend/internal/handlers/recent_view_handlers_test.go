@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRecentViewTestApp registers the recently viewed, cab and customer routes on an
+// in-memory store. Closing the returned tracker flushes the views recorded so far.
+func setupRecentViewTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.ViewTracker, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	tracker := services.NewViewTracker(nil)
+	t.Cleanup(tracker.Close)
+	recentViews := NewRecentViews(store.Views, tracker, jwtSecret)
+
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Views = recentViews
+	customers := NewCustomerHandler(store.Customers, jwtSecret)
+	customers.Views = recentViews
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	recentViews.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Get("/cabs/:id", cabs.GetCabByID)
+	customers.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	return app, store, tracker, jwtSecret
+}
+
+func TestRecentViews(t *testing.T) {
+	app, store, tracker, jwtSecret := setupRecentViewTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	cab := addTestCab(t, store)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+
+	cabPath := "/api/cabs/" + strconv.Itoa(cab.ID)
+	resp := authedRequest(t, app, token, http.MethodGet, cabPath, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/customers/"+customer.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, cabPath, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Not recorded: anonymous views of public routes, other users' views and missing records
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, cabPath, nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	authedRequest(t, app, otherToken, http.MethodGet, cabPath, nil)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/cabs/999", nil)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	tracker.Close()
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/users/me/recent", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var recent api.RecentViewListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&recent))
+	require.Equal(t, 2, recent.Count, "views of the same record are listed once")
+	assert.Equal(t, models.ViewEntityCab, recent.Views[0].EntityType, "most recent first")
+	assert.Equal(t, strconv.Itoa(cab.ID), recent.Views[0].EntityID)
+	assert.Equal(t, models.ViewEntityCustomer, recent.Views[1].EntityType)
+	assert.Equal(t, customer.ID, recent.Views[1].EntityID)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/users/me/recent?type=customer&limit=5", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&recent))
+	require.Equal(t, 1, recent.Count)
+	assert.Equal(t, customer.ID, recent.Views[0].EntityID)
+}
+
+func TestRecentViewsValidation(t *testing.T) {
+	app, _, _, jwtSecret := setupRecentViewTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/users/me/recent?type=material", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/users/me/recent?limit=0", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/users/me/recent?limit=101", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/users/me/recent", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// CashRegisterHandler runs cash register sessions: a cashier opens the drawer with
+// a float and counts it by denomination when closing it. The expected cash is the
+// float plus the cashier's sales during the session; sales have no payment method
+// yet, so every sale is counted as cash.
+type CashRegisterHandler struct {
+	Repo      repositories.RegisterSessionRepository
+	Sales     repositories.SalesRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewCashRegisterHandler creates a new CashRegisterHandler instance
+func NewCashRegisterHandler(repo repositories.RegisterSessionRepository, sales repositories.SalesRepository, jwtSecret []byte) *CashRegisterHandler {
+	return &CashRegisterHandler{Repo: repo, Sales: sales, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the cash register routes
+func (h *CashRegisterHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	registerGroup := r.Group("/registers/sessions", mw.Auth)
+	registerGroup.Post("/", h.OpenRegister)             // POST /api/registers/sessions
+	registerGroup.Get("/current", h.GetCurrentRegister) // GET /api/registers/sessions/current (must precede /:id)
+	registerGroup.Get("/:id", h.GetRegister)            // GET /api/registers/sessions/:id
+	registerGroup.Post("/:id/close", h.CloseRegister)   // POST /api/registers/sessions/:id/close
+}
+
+// OpenRegisterRequest is the body for opening a register session
+type OpenRegisterRequest struct {
+	OpeningFloat float64 `json:"openingFloat"` // Cash in the drawer, in pesos
+}
+
+// CloseRegisterRequest is the body for closing a register session
+type CloseRegisterRequest struct {
+	Denominations []models.DenominationCount `json:"denominations"` // Bills and coins counted; subtotals are ignored
+	Notes         string                     `json:"notes"`         // Up to 1000 characters
+}
+
+// toCentavos converts a peso amount to whole centavos
+func toCentavos(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// countCash validates a denomination breakdown and returns it with subtotals and its total in centavos
+func countCash(denominations []models.DenominationCount) ([]models.DenominationCount, int64, error) {
+	if len(denominations) == 0 {
+		return nil, 0, fmt.Errorf("denominations are required")
+	}
+
+	counted := make([]models.DenominationCount, 0, len(denominations))
+	seen := make(map[string]bool)
+	var total int64
+	for _, denomination := range denominations {
+		centavos, ok := denomination.Centavos()
+		if !ok {
+			return nil, 0, fmt.Errorf("%v is not a peso %s", denomination.Value, denomination.Kind)
+		}
+		key := fmt.Sprintf("%s:%d", denomination.Kind, centavos)
+		if seen[key] {
+			return nil, 0, fmt.Errorf("the %v %s is listed more than once", denomination.Value, denomination.Kind)
+		}
+		seen[key] = true
+		if denomination.Count < 0 {
+			return nil, 0, fmt.Errorf("count of the %v %s cannot be negative", denomination.Value, denomination.Kind)
+		}
+
+		subtotal := centavos * int64(denomination.Count)
+		total += subtotal
+		counted = append(counted, models.DenominationCount{
+			Kind:     denomination.Kind,
+			Value:    float64(centavos) / 100,
+			Count:    denomination.Count,
+			Subtotal: float64(subtotal) / 100,
+		})
+	}
+	return counted, total, nil
+}
+
+// canAccessRegister reports whether the caller opened the session or is an admin
+func canAccessRegister(c *fiber.Ctx, session *models.RegisterSession) bool {
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(string)
+	return session.OpenedBy == userID || role == RoleAdmin
+}
+
+// loadRegister fetches the session in the id route parameter. On failure it
+// writes the error response and returns a nil session.
+func (h *CashRegisterHandler) loadRegister(c *fiber.Ctx) (*models.RegisterSession, error) {
+	id := c.Params("id")
+	session, err := h.Repo.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Register session not found", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error getting register session %s: %v", id, err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve register session", StatusCode: fiber.StatusInternalServerError})
+	}
+	if !canAccessRegister(c, session) {
+		return nil, c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+	return session, nil
+}
+
+// OpenRegister handles opening a register session
+// @Summary Open the cash register
+// @Description Starts a register session for the caller with the cash already in the drawer. A cashier has at most one open session.
+// @Tags Registers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param session body OpenRegisterRequest true "Opening float"
+// @Success 201 {object} api.RegisterSessionResponse "Register opened"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 409 {object} api.ErrorResponse "A register session is already open"
+// @Failure 500 {object} api.ErrorResponse "Failed to open register"
+// @Router /registers/sessions [post]
+func (h *CashRegisterHandler) OpenRegister(c *fiber.Ctx) error {
+	var input OpenRegisterRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if input.OpeningFloat < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "openingFloat cannot be negative", StatusCode: fiber.StatusBadRequest})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if _, err := h.Repo.GetOpen(userID); err == nil {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "You already have an open register session", StatusCode: fiber.StatusConflict})
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting open register session of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to open register", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	session := &models.RegisterSession{
+		OpenedBy:     userID,
+		OpenedAt:     h.now(),
+		OpeningFloat: float64(toCentavos(input.OpeningFloat)) / 100,
+	}
+	if err := h.Repo.Open(session); err != nil {
+		log.Printf("Error opening register session: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to open register", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "OPEN_REGISTER", AuditEntityRegister, session.ID,
+		fmt.Sprintf("Opened the register with a float of %.2f", session.OpeningFloat))
+
+	return c.Status(fiber.StatusCreated).JSON(api.RegisterSessionResponse{Message: "Register opened", Session: session})
+}
+
+// GetCurrentRegister handles getting the caller's open register session
+// @Summary Get my open register session
+// @Tags Registers
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RegisterSession "Open session"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "No open register session"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve register session"
+// @Router /registers/sessions/current [get]
+func (h *CashRegisterHandler) GetCurrentRegister(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	session, err := h.Repo.GetOpen(userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "No open register session", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error getting open register session of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve register session", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(session)
+}
+
+// GetRegister handles getting a register session
+// @Summary Get a register session
+// @Description Returns a session with its denomination count once closed. Cashiers see their own sessions; admins see every session.
+// @Tags Registers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} models.RegisterSession "Register session"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Register session not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve register session"
+// @Router /registers/sessions/{id} [get]
+func (h *CashRegisterHandler) GetRegister(c *fiber.Ctx) error {
+	session, err := h.loadRegister(c)
+	if session == nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(session)
+}
+
+// CloseRegister handles counting the drawer and closing a register session
+// @Summary Close the cash register
+// @Description Closes a session with the number of bills and coins of each value in the drawer. The server computes the counted cash from the breakdown, the expected cash (opening float plus the cashier's sales during the session) and the variance. Bills are 1000, 500, 200, 100, 50 and 20; coins are 20, 10, 5, 1, 0.25, 0.10, 0.05 and 0.01.
+// @Tags Registers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param count body CloseRegisterRequest true "Denomination breakdown"
+// @Success 200 {object} api.RegisterSessionResponse "Register closed"
+// @Failure 400 {object} api.ErrorResponse "Invalid denominations"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Register session not found"
+// @Failure 409 {object} api.ErrorResponse "Register session already closed"
+// @Failure 500 {object} api.ErrorResponse "Failed to close register"
+// @Router /registers/sessions/{id}/close [post]
+func (h *CashRegisterHandler) CloseRegister(c *fiber.Ctx) error {
+	var input CloseRegisterRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	denominations, counted, err := countCash(input.Denominations)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	input.Notes = strings.TrimSpace(input.Notes)
+	if utf8.RuneCountInString(input.Notes) > 1000 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "notes must be at most 1000 characters", StatusCode: fiber.StatusBadRequest})
+	}
+
+	session, err := h.loadRegister(c)
+	if session == nil {
+		return err
+	}
+	if session.Status != models.RegisterSessionOpen {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Register session already closed", StatusCode: fiber.StatusConflict})
+	}
+
+	closedAt := h.now()
+	salesTotal, salesCount, err := h.sessionSales(c.Context(), session, closedAt)
+	if err != nil {
+		log.Printf("Error getting sales of register session %s: %v", session.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to close register", StatusCode: fiber.StatusInternalServerError})
+	}
+	expected := toCentavos(session.OpeningFloat) + salesTotal
+
+	session.ClosedBy, _ = c.Locals("user_id").(string)
+	session.ClosedAt = &closedAt
+	session.Denominations = denominations
+	session.CountedCash = float64(counted) / 100
+	session.ExpectedCash = float64(expected) / 100
+	session.Variance = float64(counted-expected) / 100
+	session.SalesTotal = float64(salesTotal) / 100
+	session.SalesCount = salesCount
+	session.Notes = input.Notes
+	if err := h.Repo.Close(session); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Register session already closed", StatusCode: fiber.StatusConflict})
+		}
+		log.Printf("Error closing register session %s: %v", session.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to close register", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CLOSE_REGISTER", AuditEntityRegister, session.ID,
+		fmt.Sprintf("Closed the register with %.2f counted against %.2f expected (variance %.2f)", session.CountedCash, session.ExpectedCash, session.Variance))
+
+	return c.Status(fiber.StatusOK).JSON(api.RegisterSessionResponse{Message: "Register closed", Session: session})
+}
+
+// sessionSales totals, in centavos, the confirmed and completed sales the cashier
+// recorded between opening the session and closedAt
+func (h *CashRegisterHandler) sessionSales(ctx context.Context, session *models.RegisterSession, closedAt time.Time) (int64, int, error) {
+	sales, err := h.Sales.GetAll(ctx, models.SalesFilter{
+		SoldBy:    session.OpenedBy,
+		StartDate: session.OpenedAt.Format(saleDateLayout),
+		EndDate:   closedAt.Format(saleDateLayout),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var total int64
+	count := 0
+	for _, sale := range sales {
+		if sale.CreatedAt.Before(session.OpenedAt) || sale.CreatedAt.After(closedAt) || !sale.Counted() {
+			continue
+		}
+		total += toCentavos(sale.TotalPrice)
+		count++
+	}
+	return total, count, nil
+}
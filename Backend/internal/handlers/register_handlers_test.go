@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupRegisterTestApp registers the cash register routes on an in-memory store
+func setupRegisterTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewCashRegisterHandler(store.Registers, store.Sales, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+// openRegister opens a session for the token's user and returns it
+func openRegister(t *testing.T, app *fiber.App, token string, openingFloat float64) *models.RegisterSession {
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/registers/sessions", OpenRegisterRequest{OpeningFloat: openingFloat})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var opened api.RegisterSessionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&opened))
+	return opened.Session
+}
+
+func TestOpenRegister(t *testing.T) {
+	app, _, jwtSecret := setupRegisterTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/registers/sessions/current", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/registers/sessions", OpenRegisterRequest{OpeningFloat: -1})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	session := openRegister(t, app, token, 2000)
+	assert.Equal(t, models.RegisterSessionOpen, session.Status)
+	assert.Equal(t, "staff-1", session.OpenedBy)
+	assert.Equal(t, 2000.0, session.OpeningFloat)
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/registers/sessions", OpenRegisterRequest{OpeningFloat: 500})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "one open session per cashier")
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/registers/sessions/current", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var current models.RegisterSession
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&current))
+	assert.Equal(t, session.ID, current.ID)
+}
+
+func TestCloseRegister(t *testing.T) {
+	app, store, jwtSecret := setupRegisterTestApp(t)
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	session := openRegister(t, app, token, 2000)
+
+	today := time.Now().Format("2006-01-02")
+	for _, sale := range []models.Sale{
+		{CustomerID: "c-1", SoldBy: "staff-1", SaleDate: today, TotalPrice: 4500},
+		{CustomerID: "c-2", SoldBy: "staff-1", SaleDate: today, TotalPrice: 1250.50},
+		{CustomerID: "c-3", SoldBy: "staff-2", SaleDate: today, TotalPrice: 9999}, // Another cashier
+	} {
+		sale := sale
+		_, err := store.Sales.Create(context.Background(), &sale)
+		require.NoError(t, err)
+	}
+
+	closePath := "/api/registers/sessions/" + session.ID + "/close"
+	count := CloseRegisterRequest{
+		Denominations: []models.DenominationCount{
+			{Kind: models.CashBill, Value: 1000, Count: 7},
+			{Kind: models.CashBill, Value: 500, Count: 1, Subtotal: 99999}, // Subtotals are recomputed
+			{Kind: models.CashCoin, Value: 20, Count: 12},
+			{Kind: models.CashCoin, Value: 0.25, Count: 2},
+		},
+		Notes: "Short by 10 pesos",
+	}
+
+	for name, invalid := range map[string][]models.DenominationCount{
+		"empty":          {},
+		"unknown value":  {{Kind: models.CashBill, Value: 25, Count: 1}},
+		"unknown kind":   {{Kind: "check", Value: 1000, Count: 1}},
+		"listed twice":   {{Kind: models.CashCoin, Value: 5, Count: 1}, {Kind: models.CashCoin, Value: 5, Count: 2}},
+		"negative count": {{Kind: models.CashCoin, Value: 1, Count: -1}},
+	} {
+		resp := authedRequest(t, app, token, http.MethodPost, closePath, CloseRegisterRequest{Denominations: invalid})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	resp := authedRequest(t, app, otherToken, http.MethodPost, closePath, count)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "only the cashier or an admin closes a session")
+
+	resp = authedRequest(t, app, token, http.MethodPost, closePath, count)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var closed api.RegisterSessionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&closed))
+	assert.Equal(t, models.RegisterSessionClosed, closed.Session.Status)
+	assert.Equal(t, 7740.50, closed.Session.CountedCash)
+	assert.Equal(t, 7750.50, closed.Session.ExpectedCash)
+	assert.Equal(t, -10.0, closed.Session.Variance)
+	assert.Equal(t, 5750.50, closed.Session.SalesTotal)
+	assert.Equal(t, 2, closed.Session.SalesCount)
+	assert.Equal(t, 500.0, closed.Session.Denominations[1].Subtotal)
+
+	resp = authedRequest(t, app, token, http.MethodPost, closePath, count)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// The count is stored with the session
+	stored, err := store.Registers.GetByID(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, closed.Session.Denominations, stored.Denominations)
+	assert.Equal(t, "Short by 10 pesos", stored.Notes)
+
+	resp = authedRequest(t, app, otherToken, http.MethodGet, "/api/registers/sessions/"+session.ID, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/registers/sessions/"+session.ID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	actions := []string{}
+	for _, entry := range logs {
+		actions = append(actions, entry.Action)
+	}
+	assert.ElementsMatch(t, []string{"OPEN_REGISTER", "CLOSE_REGISTER"}, actions)
+}
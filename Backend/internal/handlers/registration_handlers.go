@@ -0,0 +1,563 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationRegistrationsDue is pushed to the back office with the LTO registrations
+// coming due and those overdue
+const NotificationRegistrationsDue = "registrations_due"
+
+// defaultRegistrationDueDays is how long after the sale a registration is due unless configured
+const defaultRegistrationDueDays = 30
+
+// Longest OR, CR and plate numbers, matching the widths of their columns
+const (
+	maxRegistrationNumberLength = 50
+	maxPlateNumberLength        = 20
+)
+
+// RegistrationHandler tracks the LTO registration paperwork of sold cab units, from
+// submitting the documents to the release of the OR/CR
+type RegistrationHandler struct {
+	Repo      repositories.CabRegistrationRepository
+	Sales     repositories.SalesRepository
+	Audit     *ChangeRecorder // Optional; records changes to the activity log
+	DueDays   int             // Days after the sale date a registration is due by default
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewRegistrationHandler creates a new RegistrationHandler instance. The sales
+// repository is used to check the cab units registrations are tracked for.
+func NewRegistrationHandler(repo repositories.CabRegistrationRepository, sales repositories.SalesRepository, jwtSecret []byte) *RegistrationHandler {
+	return &RegistrationHandler{Repo: repo, Sales: sales, DueDays: defaultRegistrationDueDays, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the cab registration routes (admin and staff)
+func (h *RegistrationHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	registrationGroup := r.Group("/registrations", mw.Auth, mw.Staff)
+	registrationGroup.Get("/", h.GetRegistrations)                   // GET /api/registrations
+	registrationGroup.Get("/:id", h.GetRegistration)                 // GET /api/registrations/:id
+	registrationGroup.Post("/", h.CreateRegistration)                // POST /api/registrations
+	registrationGroup.Put("/:id", h.UpdateRegistration)              // PUT /api/registrations/:id
+	registrationGroup.Put("/:id/status", h.UpdateRegistrationStatus) // PUT /api/registrations/:id/status
+}
+
+// CreateRegistrationRequest is the body for tracking the registration of a sold cab unit
+type CreateRegistrationRequest struct {
+	SaleID  string `json:"saleId"`
+	CabID   int    `json:"cabId"`
+	DueDate string `json:"dueDate,omitempty"` // YYYY-MM-DD; REGISTRATION_DUE_DAYS after the sale date by default
+	Notes   string `json:"notes,omitempty"`
+}
+
+// UpdateRegistrationRequest is the body for replacing the details of a registration
+type UpdateRegistrationRequest struct {
+	DueDate     string `json:"dueDate"` // YYYY-MM-DD
+	ORNumber    string `json:"orNumber"`
+	CRNumber    string `json:"crNumber"`
+	PlateNumber string `json:"plateNumber"`
+	Notes       string `json:"notes"`
+}
+
+// RegistrationStatusRequest is the body for moving a registration along the pipeline.
+// The OR and CR numbers are required to release a registration unless already recorded.
+type RegistrationStatusRequest struct {
+	Status      string `json:"status"`
+	Date        string `json:"date,omitempty"` // YYYY-MM-DD the documents were submitted or the OR/CR released; today by default
+	ORNumber    string `json:"orNumber,omitempty"`
+	CRNumber    string `json:"crNumber,omitempty"`
+	PlateNumber string `json:"plateNumber,omitempty"`
+}
+
+// registrationInputError is a validation failure reported to the client as 400
+type registrationInputError struct{ message string }
+
+func (e registrationInputError) Error() string { return e.message }
+
+// registrationError writes the response for a validation or lookup error
+func registrationError(c *fiber.Ctx, err error, action string) error {
+	var inputErr registrationInputError
+	if errors.As(err, &inputErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: inputErr.message, StatusCode: fiber.StatusBadRequest})
+	}
+	log.Printf("Error validating cab registration: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to " + action, StatusCode: fiber.StatusInternalServerError})
+}
+
+// today returns the current date as YYYY-MM-DD
+func (h *RegistrationHandler) today() string {
+	return h.now().Format(saleDateLayout)
+}
+
+// parseRegistrationDate validates a YYYY-MM-DD date of a field, returning "" unchanged
+func parseRegistrationDate(field, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := time.ParseInLocation(saleDateLayout, value, time.Local); err != nil {
+		return "", registrationInputError{field + " must be formatted as YYYY-MM-DD"}
+	}
+	return value, nil
+}
+
+// setRegistrationNumbers validates and sets the numbers given, keeping the others
+func setRegistrationNumbers(registration *models.CabRegistration, orNumber, crNumber, plateNumber string) error {
+	orNumber, crNumber, plateNumber = strings.TrimSpace(orNumber), strings.TrimSpace(crNumber), strings.ToUpper(strings.TrimSpace(plateNumber))
+	if len(orNumber) > maxRegistrationNumberLength || len(crNumber) > maxRegistrationNumberLength {
+		return registrationInputError{fmt.Sprintf("orNumber and crNumber cannot be longer than %d characters", maxRegistrationNumberLength)}
+	}
+	if len(plateNumber) > maxPlateNumberLength {
+		return registrationInputError{fmt.Sprintf("plateNumber cannot be longer than %d characters", maxPlateNumberLength)}
+	}
+	if orNumber != "" {
+		registration.ORNumber = orNumber
+	}
+	if crNumber != "" {
+		registration.CRNumber = crNumber
+	}
+	if plateNumber != "" {
+		registration.PlateNumber = plateNumber
+	}
+	return nil
+}
+
+// loadRegistration fetches the registration named by the :id parameter, writing the
+// error response itself when it fails
+func (h *RegistrationHandler) loadRegistration(c *fiber.Ctx) (*models.CabRegistration, error) {
+	registration, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Registration not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting cab registration %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve registration", StatusCode: fiber.StatusInternalServerError})
+	}
+	return registration, nil
+}
+
+// parseRegistrationStatuses reads the comma-separated status query parameter. "all" and
+// an empty value both mean no status filter.
+func parseRegistrationStatuses(value string) ([]string, error) {
+	if value == "" || value == "all" {
+		return nil, nil
+	}
+	statuses := strings.Split(value, ",")
+	for i, status := range statuses {
+		statuses[i] = strings.TrimSpace(status)
+		if !models.ValidRegistrationStatus(statuses[i]) {
+			return nil, fmt.Errorf("Unknown registration status %q", statuses[i])
+		}
+	}
+	return statuses, nil
+}
+
+// overdueRegistrations returns the registrations whose OR/CR was not released by the
+// day before today, most overdue first
+func overdueRegistrations(repo repositories.CabRegistrationRepository, today time.Time) ([]api.OverdueRegistration, error) {
+	registrations, err := repo.GetAll(models.RegistrationFilter{
+		Statuses:  []string{models.RegistrationPending, models.RegistrationSubmitted},
+		DueBefore: today.Format(saleDateLayout),
+	})
+	if err != nil {
+		return nil, err
+	}
+	overdue := make([]api.OverdueRegistration, 0, len(registrations))
+	for _, registration := range registrations {
+		due, err := time.ParseInLocation(saleDateLayout, registration.DueDate, time.Local)
+		if err != nil {
+			continue
+		}
+		overdue = append(overdue, api.OverdueRegistration{CabRegistration: registration, DaysOverdue: calendarDaysBetween(due, today)})
+	}
+	return overdue, nil
+}
+
+// GetOverdueRegistrations handles the overdue registrations report
+// @Summary Overdue cab registrations
+// @Description Lists the registrations of sold cab units whose OR/CR was not released by the due date, most overdue first, with how many days overdue each is.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.OverdueRegistrationsResponse "Overdue registrations"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve overdue registrations"
+// @Failure 503 {object} api.ErrorResponse "Registration tracking is not configured"
+// @Router /reports/overdue-registrations [get]
+func (h *ReportHandler) GetOverdueRegistrations(c *fiber.Ctx) error {
+	if h.Registrations == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(api.ErrorResponse{Error: "Registration tracking is not configured", StatusCode: fiber.StatusServiceUnavailable})
+	}
+	today := h.now()
+	overdue, err := overdueRegistrations(h.Registrations, today)
+	if err != nil {
+		log.Printf("Error listing overdue registrations: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve overdue registrations", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.OverdueRegistrationsResponse{AsOf: today.Format(saleDateLayout), Registrations: overdue, Count: len(overdue)})
+}
+
+// GetRegistrations handles listing cab registrations
+// @Summary List cab registrations
+// @Description Lists the LTO registrations of sold cab units, soonest due first. Filter by status, sale or cab, or list only those whose OR/CR is not released by the due date.
+// @Tags Registrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Comma-separated statuses (pending, submitted, released, cancelled); all by default"
+// @Param saleId query string false "Only registrations of this sale"
+// @Param cabId query int false "Only registrations of this cab"
+// @Param overdue query bool false "Only pending and submitted registrations past their due date"
+// @Success 200 {object} api.RegistrationListResponse "Registrations"
+// @Failure 400 {object} api.ErrorResponse "Unknown status or invalid cabId"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve registrations"
+// @Router /registrations [get]
+func (h *RegistrationHandler) GetRegistrations(c *fiber.Ctx) error {
+	statuses, err := parseRegistrationStatuses(c.Query("status"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	filter := models.RegistrationFilter{Statuses: statuses, SaleID: c.Query("saleId")}
+	if raw := c.Query("cabId"); raw != "" {
+		if filter.CabID, err = strconv.Atoi(raw); err != nil || filter.CabID <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "cabId must be a positive number", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	today := h.today()
+	if c.QueryBool("overdue") {
+		filter.Statuses = []string{models.RegistrationPending, models.RegistrationSubmitted}
+		filter.DueBefore = today
+	}
+
+	registrations, err := h.Repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting cab registrations: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve registrations", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	overdue := 0
+	for _, registration := range registrations {
+		if registration.Overdue(today) {
+			overdue++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.RegistrationListResponse{Registrations: registrations, Count: len(registrations), Overdue: overdue})
+}
+
+// GetRegistration handles getting a cab registration
+// @Summary Get a cab registration
+// @Tags Registrations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Registration ID"
+// @Success 200 {object} models.CabRegistration "Registration"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Registration not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve registration"
+// @Router /registrations/{id} [get]
+func (h *RegistrationHandler) GetRegistration(c *fiber.Ctx) error {
+	registration, err := h.loadRegistration(c)
+	if registration == nil {
+		return err
+	}
+	setLastModified(c, registration.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(registration)
+}
+
+// CreateRegistration handles tracking the registration of a sold cab unit
+// @Summary Track a cab registration
+// @Description Starts tracking the LTO registration of one unit of a cab sold in a sale, as pending. Each unit sold is tracked separately; cancelled registrations do not count towards the units.
+// @Tags Registrations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param registration body CreateRegistrationRequest true "Sale and cab"
+// @Success 201 {object} api.RegistrationResponse "Registration tracked"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body, or the cab is not in the sale"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "Every unit sold is already tracked"
+// @Failure 500 {object} api.ErrorResponse "Failed to track registration"
+// @Router /registrations [post]
+func (h *RegistrationHandler) CreateRegistration(c *fiber.Ctx) error {
+	var input CreateRegistrationRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	input.SaleID = strings.TrimSpace(input.SaleID)
+	if input.SaleID == "" || input.CabID <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "saleId and cabId are required", StatusCode: fiber.StatusBadRequest})
+	}
+	dueDate, err := parseRegistrationDate("dueDate", input.DueDate)
+	if err != nil {
+		return registrationError(c, err, "track registration")
+	}
+
+	sale, units, err := h.soldUnits(c.Context(), input.SaleID, input.CabID)
+	if err != nil {
+		return registrationError(c, err, "track registration")
+	}
+	tracked, err := h.Repo.GetAll(models.RegistrationFilter{
+		Statuses: []string{models.RegistrationPending, models.RegistrationSubmitted, models.RegistrationReleased},
+		SaleID:   sale.ID,
+		CabID:    input.CabID,
+	})
+	if err != nil {
+		return registrationError(c, err, "track registration")
+	}
+	if len(tracked) >= units {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("the registrations of all %d units of cab %d sold in sale %s are already tracked", units, input.CabID, sale.ID),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+
+	if dueDate == "" {
+		sold, err := time.ParseInLocation(saleDateLayout, sale.SaleDate, time.Local)
+		if err != nil {
+			sold = h.now()
+		}
+		dueDate = sold.AddDate(0, 0, h.DueDays).Format(saleDateLayout)
+	}
+	userID, _ := c.Locals("user_id").(string)
+	registration := &models.CabRegistration{
+		SaleID:     sale.ID,
+		CabID:      input.CabID,
+		CustomerID: sale.CustomerID,
+		Status:     models.RegistrationPending,
+		DueDate:    dueDate,
+		Notes:      strings.TrimSpace(input.Notes),
+		UpdatedBy:  userID,
+	}
+	if err := h.Repo.Create(registration); err != nil {
+		log.Printf("Error creating cab registration: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to track registration", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_REGISTRATION", AuditEntityRegistration, registration.ID,
+		fmt.Sprintf("Tracking the registration of cab %d sold in sale %s, due %s", registration.CabID, registration.SaleID, registration.DueDate))
+	return c.Status(fiber.StatusCreated).JSON(api.RegistrationResponse{Message: "Registration tracked", Registration: registration})
+}
+
+// soldUnits returns the sale and how many units of the cab it sold
+func (h *RegistrationHandler) soldUnits(ctx context.Context, saleID string, cabID int) (*models.Sale, int, error) {
+	sale, err := h.Sales.GetByID(ctx, saleID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, 0, registrationInputError{"Sale not found"}
+		}
+		return nil, 0, fmt.Errorf("failed to get sale %s: %w", saleID, err)
+	}
+	items, err := h.Sales.GetSaleItems(ctx, sale.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get the items of sale %s: %w", sale.ID, err)
+	}
+	units := 0
+	for _, item := range items {
+		if item.Item == models.IntRef(models.RefCab, cabID) {
+			units += item.Quantity
+		}
+	}
+	if units == 0 {
+		return nil, 0, registrationInputError{fmt.Sprintf("Cab %d was not sold in sale %s", cabID, sale.ID)}
+	}
+	return sale, units, nil
+}
+
+// UpdateRegistration handles editing the details of a cab registration
+// @Summary Update a cab registration
+// @Description Replaces the due date, OR, CR and plate numbers and notes of a registration. Use PUT /registrations/{id}/status to move it along the pipeline.
+// @Tags Registrations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Registration ID"
+// @Param registration body UpdateRegistrationRequest true "Registration details"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.RegistrationResponse "Registration updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Registration not found"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update registration"
+// @Router /registrations/{id} [put]
+func (h *RegistrationHandler) UpdateRegistration(c *fiber.Ctx) error {
+	var input UpdateRegistrationRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	dueDate, err := parseRegistrationDate("dueDate", input.DueDate)
+	if err != nil {
+		return registrationError(c, err, "update registration")
+	}
+	if dueDate == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "dueDate is required", StatusCode: fiber.StatusBadRequest})
+	}
+
+	registration, err := h.loadRegistration(c)
+	if registration == nil {
+		return err
+	}
+	if modified, err := rejectIfModified(c, registration.UpdatedAt); modified {
+		return err
+	}
+	before := *registration
+
+	registration.DueDate = dueDate
+	registration.ORNumber, registration.CRNumber, registration.PlateNumber = "", "", ""
+	if err := setRegistrationNumbers(registration, input.ORNumber, input.CRNumber, input.PlateNumber); err != nil {
+		return registrationError(c, err, "update registration")
+	}
+	if registration.Status == models.RegistrationReleased && (registration.ORNumber == "" || registration.CRNumber == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Released registrations keep their OR and CR numbers", StatusCode: fiber.StatusBadRequest})
+	}
+	registration.Notes = strings.TrimSpace(input.Notes)
+	registration.UpdatedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Repo.Update(registration); err != nil {
+		log.Printf("Error updating cab registration %s: %v", registration.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update registration", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityRegistration, registration.ID, before, *registration)
+	setLastModified(c, registration.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.RegistrationResponse{Message: "Registration updated", Registration: registration})
+}
+
+// UpdateRegistrationStatus handles moving a cab registration along the pipeline
+// @Summary Change the status of a cab registration
+// @Description Moves a registration along the pipeline: pending registrations are submitted once the documents are with the LTO, and submitted ones released with the OR and CR numbers, or sent back to pending when the LTO returns the documents. Registrations that are not released can be cancelled, and cancelled ones reopened as pending. Released registrations are final.
+// @Tags Registrations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Registration ID"
+// @Param status body RegistrationStatusRequest true "New status"
+// @Success 200 {object} api.RegistrationResponse "Registration status updated"
+// @Failure 400 {object} api.ErrorResponse "Unknown status, invalid date or missing OR/CR numbers"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Registration not found"
+// @Failure 409 {object} api.ErrorResponse "Transition not allowed"
+// @Failure 500 {object} api.ErrorResponse "Failed to update registration"
+// @Router /registrations/{id}/status [put]
+func (h *RegistrationHandler) UpdateRegistrationStatus(c *fiber.Ctx) error {
+	var input RegistrationStatusRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if !models.ValidRegistrationStatus(input.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Status must be pending, submitted, released or cancelled", StatusCode: fiber.StatusBadRequest})
+	}
+	date, err := parseRegistrationDate("date", input.Date)
+	if err != nil {
+		return registrationError(c, err, "update registration")
+	}
+	today := h.today()
+	if date == "" {
+		date = today
+	}
+	if date > today {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date cannot be in the future", StatusCode: fiber.StatusBadRequest})
+	}
+
+	registration, err := h.loadRegistration(c)
+	if registration == nil {
+		return err
+	}
+	if !registration.CanTransition(input.Status) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("A %s registration cannot be moved to %s", registration.Status, input.Status),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+
+	before := *registration
+	if err := setRegistrationNumbers(registration, input.ORNumber, input.CRNumber, input.PlateNumber); err != nil {
+		return registrationError(c, err, "update registration")
+	}
+	switch input.Status {
+	case models.RegistrationPending:
+		// The documents are back with the yard, to be submitted again
+		registration.SubmittedDate = ""
+	case models.RegistrationSubmitted:
+		registration.SubmittedDate = date
+	case models.RegistrationReleased:
+		if registration.ORNumber == "" || registration.CRNumber == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "orNumber and crNumber are required to release a registration", StatusCode: fiber.StatusBadRequest})
+		}
+		if date < registration.SubmittedDate {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The OR/CR cannot be released before the documents were submitted", StatusCode: fiber.StatusBadRequest})
+		}
+		registration.ReleasedDate = date
+	}
+	registration.Status = input.Status
+	registration.UpdatedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Repo.Update(registration); err != nil {
+		log.Printf("Error updating status of cab registration %s: %v", registration.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update registration", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityRegistration, registration.ID, before, *registration)
+	return c.Status(fiber.StatusOK).JSON(api.RegistrationResponse{Message: "Registration status updated", Registration: registration})
+}
+
+// NotifyDueRegistrations pushes the registrations due within alertDays days, and those
+// already overdue, to the tenant's active admins and staff, so the back office can
+// follow them up with the LTO. It returns how many registrations were notified of.
+func (h *RegistrationHandler) NotifyDueRegistrations(ctx context.Context, tenantID string, users UserRepository, hub *services.NotificationHub, alertDays int) (int, error) {
+	now := h.now()
+	overdue, err := overdueRegistrations(h.Repo, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list overdue registrations: %w", err)
+	}
+	today := now.Format(saleDateLayout)
+	upcoming, err := h.Repo.GetAll(models.RegistrationFilter{
+		Statuses:  []string{models.RegistrationPending, models.RegistrationSubmitted},
+		DueBefore: now.AddDate(0, 0, alertDays+1).Format(saleDateLayout),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list registrations coming due: %w", err)
+	}
+	dueSoon := []models.CabRegistration{}
+	for _, registration := range upcoming {
+		if registration.DueDate >= today {
+			dueSoon = append(dueSoon, registration)
+		}
+	}
+	count := len(dueSoon) + len(overdue)
+	if count == 0 || hub == nil {
+		return count, nil
+	}
+
+	notification := models.Notification{
+		Type: NotificationRegistrationsDue,
+		Data: api.RegistrationsDueEvent{Date: today, DueSoon: dueSoon, Overdue: overdue},
+	}
+	if err := notifyUsers(ctx, users, hub, tenantID, isBackOffice, notification); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
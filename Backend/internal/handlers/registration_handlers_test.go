@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCabRegistrations(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	cab := addTestCab(t, store)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	sale, err := store.Sales.SellCab(context.Background(), cab.ID, customer.ID, 2, "staff-1", "", nil)
+	require.NoError(t, err)
+	sold, err := time.ParseInLocation(saleDateLayout, sale.SaleDate, time.Local)
+	require.NoError(t, err)
+
+	h := NewRegistrationHandler(store.Registrations, store.Sales, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+	h.now = func() time.Time { return sold }
+	reports := NewReportHandler(store.Sales, store.Users, jwtSecret)
+	reports.Registrations = store.Registrations
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	reports.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	// Each of the two units sold is tracked
+	resp := authedRequest(t, app, token, http.MethodPost, "/api/registrations", CreateRegistrationRequest{SaleID: sale.ID, CabID: cab.ID})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created api.RegistrationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	first := created.Registration
+	assert.Equal(t, models.RegistrationPending, first.Status)
+	assert.Equal(t, customer.ID, first.CustomerID)
+	assert.Equal(t, sold.AddDate(0, 0, defaultRegistrationDueDays).Format(saleDateLayout), first.DueDate, "due 30 days after the sale by default")
+
+	dueEarly := sold.AddDate(0, 0, 5).Format(saleDateLayout)
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/registrations", CreateRegistrationRequest{SaleID: sale.ID, CabID: cab.ID, DueDate: dueEarly})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	created = api.RegistrationResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	second := created.Registration
+	assert.Equal(t, dueEarly, second.DueDate)
+
+	resp = authedRequest(t, app, token, http.MethodPost, "/api/registrations", CreateRegistrationRequest{SaleID: sale.ID, CabID: cab.ID})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "both units sold are already tracked")
+	for _, invalid := range []CreateRegistrationRequest{
+		{SaleID: sale.ID},
+		{SaleID: "missing", CabID: cab.ID},
+		{SaleID: sale.ID, CabID: cab.ID + 1},
+		{SaleID: sale.ID, CabID: cab.ID, DueDate: "next month"},
+	} {
+		resp = authedRequest(t, app, token, http.MethodPost, "/api/registrations", invalid)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, invalid)
+	}
+
+	// The pipeline: submitted, then released with the OR/CR
+	path := "/api/registrations/" + first.ID + "/status"
+	resp = authedRequest(t, app, token, http.MethodPut, path, RegistrationStatusRequest{Status: models.RegistrationReleased, ORNumber: "OR-1", CRNumber: "CR-1"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the documents must be submitted first")
+	resp = authedRequest(t, app, token, http.MethodPut, path, RegistrationStatusRequest{Status: models.RegistrationSubmitted})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodPut, path, RegistrationStatusRequest{Status: models.RegistrationReleased})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "the OR and CR numbers are required")
+	resp = authedRequest(t, app, token, http.MethodPut, path, RegistrationStatusRequest{Status: models.RegistrationReleased, Date: sold.AddDate(0, 0, 1).Format(saleDateLayout), ORNumber: "OR-1", CRNumber: "CR-1"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "dates cannot be in the future")
+	resp = authedRequest(t, app, token, http.MethodPut, path, RegistrationStatusRequest{Status: models.RegistrationReleased, ORNumber: "OR-1", CRNumber: "CR-1", PlateNumber: "nbc 1234"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var released api.RegistrationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&released))
+	assert.Equal(t, models.RegistrationReleased, released.Registration.Status)
+	assert.Equal(t, sale.SaleDate, released.Registration.SubmittedDate)
+	assert.Equal(t, sale.SaleDate, released.Registration.ReleasedDate)
+	assert.Equal(t, "NBC 1234", released.Registration.PlateNumber)
+	resp = authedRequest(t, app, token, http.MethodPut, path, RegistrationStatusRequest{Status: models.RegistrationCancelled})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "released registrations are final")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	assert.NotEmpty(t, logs, "changes are recorded in the activity log")
+
+	// A week later the second unit is overdue
+	h.now = func() time.Time { return sold.AddDate(0, 0, 7) }
+	reports.now = h.now
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/registrations?overdue=true", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed api.RegistrationListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Equal(t, 1, listed.Count)
+	assert.Equal(t, second.ID, listed.Registrations[0].ID)
+	assert.Equal(t, 1, listed.Overdue)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/registrations?cabId="+strconv.Itoa(cab.ID)+"&status=pending,released", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	listed = api.RegistrationListResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	assert.Equal(t, 2, listed.Count)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/registrations?status=lost", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/reports/overdue-registrations", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.OverdueRegistrationsResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, 1, report.Count)
+	assert.Equal(t, 2, report.Registrations[0].DaysOverdue)
+	assert.Equal(t, second.ID, report.Registrations[0].ID)
+
+	// Moving the due date clears it from the report
+	resp = authedRequest(t, app, token, http.MethodPut, "/api/registrations/"+second.ID, UpdateRegistrationRequest{DueDate: sold.AddDate(0, 0, 9).Format(saleDateLayout), Notes: "LTO office closed for a week"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/reports/overdue-registrations", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report = api.OverdueRegistrationsResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Zero(t, report.Count)
+}
+
+func TestNotifyDueRegistrations(t *testing.T) {
+	store := memory.NewStore()
+	today := time.Date(2026, time.October, 16, 9, 0, 0, 0, time.Local)
+	for _, registration := range []models.CabRegistration{
+		{SaleID: "sale-1", CabID: 1, Status: models.RegistrationPending, DueDate: "2026-10-10"},
+		{SaleID: "sale-2", CabID: 1, Status: models.RegistrationSubmitted, DueDate: "2026-10-19"},
+		{SaleID: "sale-3", CabID: 1, Status: models.RegistrationPending, DueDate: "2026-10-20"},
+		{SaleID: "sale-4", CabID: 1, Status: models.RegistrationReleased, DueDate: "2026-10-01"},
+	} {
+		require.NoError(t, store.Registrations.Create(&registration))
+	}
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "staff-1", Username: "staff", Email: "staff@example.com", Password: "secret123", Role: RoleStaff, IsActive: true}))
+	hub := services.NewNotificationHub()
+	notifications, unsubscribe := hub.Subscribe(models.DefaultTenantID, "staff-1")
+	defer unsubscribe()
+
+	h := NewRegistrationHandler(store.Registrations, store.Sales, []byte("testsecret"))
+	h.now = func() time.Time { return today }
+	count, err := h.NotifyDueRegistrations(context.Background(), models.DefaultTenantID, store.Users, hub, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	received := receiveNotifications(notifications)
+	require.Len(t, received, 1)
+	assert.Equal(t, NotificationRegistrationsDue, received[0].Type)
+	event := received[0].Data.(api.RegistrationsDueEvent)
+	require.Len(t, event.Overdue, 1)
+	assert.Equal(t, "sale-1", event.Overdue[0].SaleID)
+	assert.Equal(t, 6, event.Overdue[0].DaysOverdue)
+	require.Len(t, event.DueSoon, 1, "registrations due after the alert window and released ones are left out")
+	assert.Equal(t, "sale-2", event.DueSoon[0].SaleID)
+}
@@ -0,0 +1,821 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Report periods. Weeks run Monday to Sunday; months, quarters and years follow
+// the calendar, or the fiscal calendar when a report asks for fiscal periods.
+const (
+	PeriodWeek    = "week"
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+	PeriodYear    = "year"
+)
+
+// validReportPeriods are the periods reports group by
+var validReportPeriods = map[string]bool{PeriodWeek: true, PeriodMonth: true, PeriodQuarter: true, PeriodYear: true}
+
+// Days allowed by default, and at most, between closing a register session and
+// depositing its cash
+const (
+	defaultDepositDays = 2
+	maxDepositDays     = 365
+)
+
+// saleDateLayout is the format sale dates are stored in
+const saleDateLayout = "2006-01-02"
+
+// maxTaxReportDays is the longest period a tax report covers, a year
+const maxTaxReportDays = 366
+
+// maxRevenueReportDays is the longest period a revenue report covers, about ten years
+const maxRevenueReportDays = 3660
+
+// Defaults and limits of the sales summary: the days covered by default, the longest
+// period grouped by day, and the number of top-selling cabs and accessories
+const (
+	defaultSummaryDays    = 30
+	maxDailySummaryDays   = 366
+	defaultSummarySellers = 5
+	maxSummarySellers     = 50
+)
+
+// ReportHandler serves sales reports
+type ReportHandler struct {
+	Sales         repositories.SalesRepository
+	Users         UserRepository
+	Registers     repositories.RegisterSessionRepository // Optional; adds register counts to the end-of-day report
+	Expenses      repositories.ExpenseRepository         // Optional; deducts approved expenses in the monthly report
+	Fiscal        repositories.FiscalCalendarRepository  // Optional; fiscal periods follow the calendar year without it
+	Audit         *ChangeRecorder                        // Optional; records voids of duplicate sales
+	Registrations repositories.CabRegistrationRepository // Optional; the overdue registrations report is unavailable without it
+	now           func() time.Time
+	jwtSecret     []byte
+}
+
+// NewReportHandler creates a new ReportHandler instance
+func NewReportHandler(sales repositories.SalesRepository, users UserRepository, jwtSecret []byte) *ReportHandler {
+	return &ReportHandler{Sales: sales, Users: users, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the report routes
+func (h *ReportHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	reportGroup := r.Group("/reports", mw.Auth)
+	reportGroup.Get("/leaderboard", h.GetLeaderboard)                                   // GET /api/reports/leaderboard
+	reportGroup.Get("/end-of-day", h.GetEndOfDay)                                       // GET /api/reports/end-of-day
+	reportGroup.Get("/monthly", mw.Admin, h.GetMonthly)                                 // GET /api/reports/monthly
+	reportGroup.Get("/deposit-reconciliation", mw.Admin, h.GetDepositReconciliation)    // GET /api/reports/deposit-reconciliation
+	reportGroup.Get("/tax", mw.Admin, h.GetTaxReport)                                   // GET /api/reports/tax
+	reportGroup.Get("/revenue", mw.Admin, h.GetRevenueReport)                           // GET /api/reports/revenue
+	reportGroup.Get("/sales-summary", mw.Staff, h.GetSalesSummary)                      // GET /api/reports/sales-summary
+	reportGroup.Get("/possible-duplicate-sales", mw.Staff, h.GetPossibleDuplicateSales) // GET /api/reports/possible-duplicate-sales
+	reportGroup.Post("/possible-duplicate-sales/void", mw.Staff, h.VoidDuplicateSale)   // POST /api/reports/possible-duplicate-sales/void
+	reportGroup.Get("/overdue-registrations", mw.Staff, h.GetOverdueRegistrations)      // GET /api/reports/overdue-registrations
+}
+
+// periodRange returns the first and last day of the week (Monday to Sunday) or
+// month containing day
+func periodRange(period string, day time.Time) (time.Time, time.Time) {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	if period == PeriodMonth {
+		start := day.AddDate(0, 0, 1-day.Day())
+		return start, start.AddDate(0, 1, -1)
+	}
+	start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return start, start.AddDate(0, 0, 6)
+}
+
+// monthsBetween counts the month boundaries between two days
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// reportPeriod returns the week, month, quarter or year containing day. Without a
+// fiscal calendar months, quarters and years are the calendar's; with one they are
+// counted from the start of the fiscal year. Weeks are always Monday to Sunday.
+func reportPeriod(period string, fiscal *models.FiscalCalendar, day time.Time) api.ReportPeriod {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	if period == PeriodWeek {
+		start, end := periodRange(PeriodWeek, day)
+		year, week := start.ISOWeek()
+		return api.ReportPeriod{Label: fmt.Sprintf("%d-W%02d", year, week), StartDate: start.Format(saleDateLayout), EndDate: end.Format(saleDateLayout)}
+	}
+
+	calendar := models.FiscalCalendar{}
+	if fiscal != nil {
+		calendar = *fiscal
+	}
+	months := 12
+	switch period {
+	case PeriodMonth:
+		months = 1
+	case PeriodQuarter:
+		months = 3
+	}
+	yearStart := calendar.YearStart(day)
+	index := monthsBetween(yearStart, day) / months
+	start := yearStart.AddDate(0, index*months, 0)
+	end := start.AddDate(0, months, -1)
+
+	var label string
+	if fiscal != nil {
+		label = fmt.Sprintf("FY%d", calendar.YearName(yearStart))
+		switch period {
+		case PeriodMonth:
+			label += fmt.Sprintf("-P%02d", index+1)
+		case PeriodQuarter:
+			label += fmt.Sprintf("-Q%d", index+1)
+		}
+	} else {
+		switch period {
+		case PeriodMonth:
+			label = start.Format("2006-01")
+		case PeriodQuarter:
+			label = fmt.Sprintf("%d-Q%d", start.Year(), index+1)
+		default:
+			label = strconv.Itoa(start.Year())
+		}
+	}
+	return api.ReportPeriod{Label: label, StartDate: start.Format(saleDateLayout), EndDate: end.Format(saleDateLayout)}
+}
+
+// loadFiscalCalendar returns the tenant's fiscal calendar for reports that asked
+// for fiscal periods with fiscal=true, and nil for calendar periods. When ok is
+// false the error response, with failure as its message for server errors, has
+// been written.
+func (h *ReportHandler) loadFiscalCalendar(c *fiber.Ctx, failure string) (calendar *models.FiscalCalendar, ok bool, err error) {
+	fiscal, err := strconv.ParseBool(c.Query("fiscal", "false"))
+	if err != nil {
+		return nil, false, c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "fiscal must be true or false", StatusCode: fiber.StatusBadRequest})
+	}
+	if !fiscal {
+		return nil, true, nil
+	}
+	if h.Fiscal == nil {
+		return &models.FiscalCalendar{StartMonth: int(time.January)}, true, nil
+	}
+	saved, err := h.Fiscal.Get()
+	if err != nil {
+		log.Printf("Error getting fiscal calendar: %v", err)
+		return nil, false, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: failure, StatusCode: fiber.StatusInternalServerError})
+	}
+	return &saved, true, nil
+}
+
+// GetLeaderboard handles ranking staff by sales
+// @Summary Staff sales leaderboard
+// @Description Ranks the staff who made sales in the week (Monday to Sunday), month, quarter or year containing date by revenue. With fiscal=true months, quarters and years follow the fiscal calendar in settings. Ties are broken by units sold, then number of sales, then user ID, so every staff member has a distinct rank.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param period query string false "week (default), month, quarter or year"
+// @Param fiscal query bool false "Use fiscal rather than calendar periods"
+// @Param date query string false "A day in the period, YYYY-MM-DD (default today)"
+// @Success 200 {object} api.LeaderboardResponse "Leaderboard"
+// @Failure 400 {object} api.ErrorResponse "Invalid period or date"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to build leaderboard"
+// @Router /reports/leaderboard [get]
+func (h *ReportHandler) GetLeaderboard(c *fiber.Ctx) error {
+	period := c.Query("period", PeriodWeek)
+	if !validReportPeriods[period] {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "period must be week, month, quarter or year", StatusCode: fiber.StatusBadRequest})
+	}
+
+	day := h.now()
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		day = parsed
+	}
+	fiscal, ok, err := h.loadFiscalCalendar(c, "Failed to build leaderboard")
+	if !ok {
+		return err
+	}
+	span := reportPeriod(period, fiscal, day)
+
+	entries, err := h.Sales.GetLeaderboard(c.Context(), span.StartDate, span.EndDate)
+	if err != nil {
+		log.Printf("Error building %s leaderboard: %v", period, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build leaderboard", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	for i := range entries {
+		if user, err := h.Users.GetByID(c.Context(), entries[i].UserID); err == nil {
+			entries[i].FullName = user.FullName
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.LeaderboardResponse{
+		Period:    period,
+		Fiscal:    fiscal != nil,
+		Label:     span.Label,
+		StartDate: span.StartDate,
+		EndDate:   span.EndDate,
+		Entries:   entries,
+	})
+}
+
+// GetEndOfDay handles the end-of-day report
+// @Summary End-of-day report
+// @Description Totals the sales recorded on date and the register sessions closed that day, with the bills and coins counted in them combined by value.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param date query string false "Day to report on, YYYY-MM-DD (default today)"
+// @Success 200 {object} api.EndOfDayReport "End-of-day report"
+// @Failure 400 {object} api.ErrorResponse "Invalid date"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to build end-of-day report"
+// @Router /reports/end-of-day [get]
+func (h *ReportHandler) GetEndOfDay(c *fiber.Ctx) error {
+	now := h.now()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		day = parsed
+	}
+	date := day.Format(saleDateLayout)
+
+	sales, err := h.Sales.GetAll(c.Context(), models.SalesFilter{StartDate: date, EndDate: date})
+	if err != nil {
+		log.Printf("Error getting sales of %s for the end-of-day report: %v", date, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build end-of-day report", StatusCode: fiber.StatusInternalServerError})
+	}
+	var salesTotal int64
+	for _, sale := range sales {
+		if sale.Counted() {
+			salesTotal += toCentavos(sale.TotalPrice)
+		}
+	}
+
+	sessions := []models.RegisterSession{}
+	if h.Registers != nil {
+		sessions, err = h.Registers.GetClosedBetween(day, day.AddDate(0, 0, 1))
+		if err != nil {
+			log.Printf("Error getting register sessions of %s for the end-of-day report: %v", date, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build end-of-day report", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+
+	var openingFloat, counted, expected int64
+	pieces := make(map[models.DenominationCount]int)
+	for _, session := range sessions {
+		openingFloat += toCentavos(session.OpeningFloat)
+		counted += toCentavos(session.CountedCash)
+		expected += toCentavos(session.ExpectedCash)
+		for _, denomination := range session.Denominations {
+			pieces[models.DenominationCount{Kind: denomination.Kind, Value: denomination.Value}] += denomination.Count
+		}
+	}
+
+	denominations := make([]models.DenominationCount, 0, len(pieces))
+	for denomination, count := range pieces {
+		denomination.Count = count
+		denomination.Subtotal = float64(toCentavos(denomination.Value)*int64(count)) / 100
+		denominations = append(denominations, denomination)
+	}
+	// Bills before coins, largest value first
+	sort.Slice(denominations, func(i, j int) bool {
+		if denominations[i].Kind != denominations[j].Kind {
+			return denominations[i].Kind == models.CashBill
+		}
+		return denominations[i].Value > denominations[j].Value
+	})
+
+	return c.Status(fiber.StatusOK).JSON(api.EndOfDayReport{
+		Date:          date,
+		SalesTotal:    float64(salesTotal) / 100,
+		SalesCount:    len(sales),
+		Sessions:      sessions,
+		OpeningFloat:  float64(openingFloat) / 100,
+		CountedCash:   float64(counted) / 100,
+		ExpectedCash:  float64(expected) / 100,
+		Variance:      float64(counted-expected) / 100,
+		Denominations: denominations,
+	})
+}
+
+// GetMonthly handles the monthly report
+// @Summary Monthly report (Admin)
+// @Description Totals the sales of a month and the approved expenses dated in it by category, and the net result of sales minus expenses. Expenses still pending review are reported separately and not deducted.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param month query string false "Month to report on, YYYY-MM (default this month)"
+// @Success 200 {object} api.MonthlyReport "Monthly report"
+// @Failure 400 {object} api.ErrorResponse "Invalid month"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to build monthly report"
+// @Router /reports/monthly [get]
+func (h *ReportHandler) GetMonthly(c *fiber.Ctx) error {
+	day := h.now()
+	if raw := c.Query("month"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01", raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "month must be formatted as YYYY-MM", StatusCode: fiber.StatusBadRequest})
+		}
+		day = parsed
+	}
+	start, end := periodRange(PeriodMonth, day)
+	startDate, endDate := start.Format(saleDateLayout), end.Format(saleDateLayout)
+
+	sales, err := h.Sales.GetAll(c.Context(), models.SalesFilter{StartDate: startDate, EndDate: endDate})
+	if err != nil {
+		log.Printf("Error getting sales of %s for the monthly report: %v", start.Format("2006-01"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build monthly report", StatusCode: fiber.StatusInternalServerError})
+	}
+	var salesTotal int64
+	for _, sale := range sales {
+		if sale.Counted() {
+			salesTotal += toCentavos(sale.TotalPrice)
+		}
+	}
+
+	expenses := []models.Expense{}
+	if h.Expenses != nil {
+		expenses, err = h.Expenses.GetAll(models.ExpenseFilter{StartDate: startDate, EndDate: endDate})
+		if err != nil {
+			log.Printf("Error getting expenses of %s for the monthly report: %v", start.Format("2006-01"), err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build monthly report", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+
+	var expensesTotal, pendingTotal int64
+	pendingCount := 0
+	spent := make(map[string]int64) // Centavos by category
+	counts := make(map[string]int)
+	for _, expense := range expenses {
+		switch expense.Status {
+		case models.ExpenseApproved:
+			expensesTotal += toCentavos(expense.Amount)
+			spent[expense.Category] += toCentavos(expense.Amount)
+			counts[expense.Category]++
+		case models.ExpensePending:
+			pendingTotal += toCentavos(expense.Amount)
+			pendingCount++
+		}
+	}
+
+	categories := make([]api.ExpenseCategoryTotal, 0, len(spent))
+	for category, cents := range spent {
+		categories = append(categories, api.ExpenseCategoryTotal{Category: category, Total: float64(cents) / 100, Count: counts[category]})
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Total != categories[j].Total {
+			return categories[i].Total > categories[j].Total
+		}
+		return categories[i].Category < categories[j].Category
+	})
+
+	return c.Status(fiber.StatusOK).JSON(api.MonthlyReport{
+		Month:           start.Format("2006-01"),
+		StartDate:       startDate,
+		EndDate:         endDate,
+		SalesTotal:      float64(salesTotal) / 100,
+		SalesCount:      len(sales),
+		Expenses:        categories,
+		ExpensesTotal:   float64(expensesTotal) / 100,
+		NetTotal:        float64(salesTotal-expensesTotal) / 100,
+		PendingExpenses: float64(pendingTotal) / 100,
+		PendingCount:    pendingCount,
+	})
+}
+
+// calendarDaysBetween counts the midnights between two times in to's location
+func calendarDaysBetween(from, to time.Time) int {
+	from = from.In(to.Location())
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// GetDepositReconciliation handles the deposit reconciliation report
+// @Summary Deposit reconciliation report (Admin)
+// @Description Lists the closed register sessions whose cash is not covered by a bank deposit. Sessions closed more than days calendar days ago are overdue; the rest are pending. Sessions that took in no cash beyond their opening float are left out.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param days query int false "Days allowed to deposit a session's cash (default 2)"
+// @Success 200 {object} api.DepositReconciliationReport "Reconciliation report"
+// @Failure 400 {object} api.ErrorResponse "Invalid days"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to build deposit reconciliation report"
+// @Router /reports/deposit-reconciliation [get]
+func (h *ReportHandler) GetDepositReconciliation(c *fiber.Ctx) error {
+	days := defaultDepositDays
+	if raw := c.Query("days"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > maxDepositDays {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "days must be between 0 and 365", StatusCode: fiber.StatusBadRequest})
+		}
+		days = parsed
+	}
+
+	now := h.now()
+	sessions := []models.RegisterSession{}
+	if h.Registers != nil {
+		var err error
+		sessions, err = h.Registers.GetUndeposited(now)
+		if err != nil {
+			log.Printf("Error getting undeposited register sessions: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build deposit reconciliation report", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+
+	report := api.DepositReconciliationReport{
+		Date:    now.Format(saleDateLayout),
+		Days:    days,
+		Overdue: []api.UndepositedSession{},
+		Pending: []api.UndepositedSession{},
+	}
+	var overdueTotal, pendingTotal int64
+	for _, session := range sessions {
+		if session.CashToDeposit() <= 0 {
+			continue // Nothing left the drawer for the bank
+		}
+		undeposited := api.UndepositedSession{
+			SessionID:      session.ID,
+			OpenedBy:       session.OpenedBy,
+			ClosedAt:       *session.ClosedAt,
+			CashToDeposit:  session.CashToDeposit(),
+			DaysSinceClose: calendarDaysBetween(*session.ClosedAt, now),
+		}
+		if undeposited.DaysSinceClose > days {
+			report.Overdue = append(report.Overdue, undeposited)
+			overdueTotal += toCentavos(undeposited.CashToDeposit)
+		} else {
+			report.Pending = append(report.Pending, undeposited)
+			pendingTotal += toCentavos(undeposited.CashToDeposit)
+		}
+	}
+	report.OverdueTotal = float64(overdueTotal) / 100
+	report.PendingTotal = float64(pendingTotal) / 100
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// taxTally sums sales by tax type in centavos
+type taxTally struct {
+	count                                 int
+	gross, vatable, vat, exempt, zeroRate int64
+}
+
+// add counts a sale. Sales without a tax type predate tax tracking and were all vatable.
+func (t *taxTally) add(sale models.Sale) {
+	if sale.TaxType == "" {
+		sale.ApplyTax()
+	}
+	total := toCentavos(sale.TotalPrice)
+	t.count++
+	t.gross += total
+	switch sale.TaxType {
+	case models.TaxExempt:
+		t.exempt += total
+	case models.TaxZeroRated:
+		t.zeroRate += total
+	default:
+		vat := toCentavos(sale.VATAmount)
+		t.vat += vat
+		t.vatable += total - vat
+	}
+}
+
+func (t taxTally) totals(date string) api.TaxTotals {
+	return api.TaxTotals{
+		Date:           date,
+		SalesCount:     t.count,
+		GrossSales:     float64(t.gross) / 100,
+		VatableSales:   float64(t.vatable) / 100,
+		VATAmount:      float64(t.vat) / 100,
+		ExemptSales:    float64(t.exempt) / 100,
+		ZeroRatedSales: float64(t.zeroRate) / 100,
+	}
+}
+
+// GetTaxReport handles the tax report
+// @Summary Tax report (Admin)
+// @Description Sums the confirmed and completed sales between two sale dates by tax type for BIR filing: vatable sales net of VAT, the VAT collected, and exempt and zero-rated sales, per day and in total. Sales recorded before tax types were tracked count as vatable. With format=csv the report is downloaded as a CSV file with a row per day and a total row.
+// @Tags Reports
+// @Produce json,text/csv
+// @Security ApiKeyAuth
+// @Param from query string false "First sale date, YYYY-MM-DD (default the first of this month)"
+// @Param to query string false "Last sale date, YYYY-MM-DD (default today)"
+// @Param format query string false "csv to download the report as CSV"
+// @Success 200 {object} api.TaxReport "Tax report"
+// @Failure 400 {object} api.ErrorResponse "Invalid period or format"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to build tax report"
+// @Router /reports/tax [get]
+func (h *ReportHandler) GetTaxReport(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "csv" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "format must be json or csv", StatusCode: fiber.StatusBadRequest})
+	}
+
+	today := h.now()
+	monthStart, _ := periodRange(PeriodMonth, today)
+	from, to := monthStart, today
+	for param, date := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := c.Query(param); raw != "" {
+			parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: param + " must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+			}
+			*date = parsed
+		}
+	}
+	if calendarDaysBetween(from, to) < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "from must not be after to", StatusCode: fiber.StatusBadRequest})
+	}
+	if calendarDaysBetween(from, to) >= maxTaxReportDays {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("the report covers at most %d days", maxTaxReportDays), StatusCode: fiber.StatusBadRequest})
+	}
+	fromDate, toDate := from.Format(saleDateLayout), to.Format(saleDateLayout)
+
+	sales, err := h.Sales.GetAll(c.Context(), models.SalesFilter{StartDate: fromDate, EndDate: toDate})
+	if err != nil {
+		log.Printf("Error getting sales from %s to %s for the tax report: %v", fromDate, toDate, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build tax report", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	var total taxTally
+	byDay := make(map[string]*taxTally)
+	for _, sale := range sales {
+		if !sale.Counted() {
+			continue // Drafts, cancelled and refunded sales owe no tax
+		}
+		day, ok := byDay[sale.SaleDate]
+		if !ok {
+			day = &taxTally{}
+			byDay[sale.SaleDate] = day
+		}
+		day.add(sale)
+		total.add(sale)
+	}
+
+	report := api.TaxReport{From: fromDate, To: toDate, VATRate: models.VATRate, Days: make([]api.TaxTotals, 0, len(byDay)), Total: total.totals("")}
+	for date, day := range byDay {
+		report.Days = append(report.Days, day.totals(date))
+	}
+	sort.Slice(report.Days, func(i, j int) bool { return report.Days[i].Date < report.Days[j].Date })
+
+	if format == "csv" {
+		body, err := taxReportCSV(report)
+		if err != nil {
+			log.Printf("Error writing the tax report from %s to %s as CSV: %v", fromDate, toDate, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build tax report", StatusCode: fiber.StatusInternalServerError})
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Attachment(fmt.Sprintf("tax-report-%s-to-%s.csv", fromDate, toDate))
+		return c.Status(fiber.StatusOK).Send(body)
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// taxReportCSV writes a row per day of the tax report, then the total row
+func taxReportCSV(report api.TaxReport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	money := func(amount float64) string { return strconv.FormatFloat(amount, 'f', 2, 64) }
+	row := func(totals api.TaxTotals) []string {
+		return []string{totals.Date, strconv.Itoa(totals.SalesCount), money(totals.GrossSales), money(totals.VatableSales),
+			money(totals.VATAmount), money(totals.ExemptSales), money(totals.ZeroRatedSales)}
+	}
+
+	rows := [][]string{{"date", "sales", "gross_sales", "vatable_sales", "vat", "exempt_sales", "zero_rated_sales"}}
+	for _, day := range report.Days {
+		rows = append(rows, row(day))
+	}
+	total := report.Total
+	total.Date = "TOTAL"
+	rows = append(rows, row(total))
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GetRevenueReport handles the revenue report
+// @Summary Revenue report (Admin)
+// @Description Totals sales by week, month, quarter or year between two dates, widened to whole periods, including periods without sales. With fiscal=true months, quarters and years follow the fiscal calendar in settings, labelled like FY2025-P07, FY2025-Q3 or FY2025.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param groupBy query string false "week, month (default), quarter or year"
+// @Param fiscal query bool false "Use fiscal rather than calendar periods"
+// @Param from query string false "A day in the first period, YYYY-MM-DD (default the start of this year)"
+// @Param to query string false "A day in the last period, YYYY-MM-DD (default today)"
+// @Success 200 {object} api.RevenueReport "Revenue report"
+// @Failure 400 {object} api.ErrorResponse "Invalid grouping or period"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to build revenue report"
+// @Router /reports/revenue [get]
+func (h *ReportHandler) GetRevenueReport(c *fiber.Ctx) error {
+	groupBy := c.Query("groupBy", PeriodMonth)
+	if !validReportPeriods[groupBy] {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "groupBy must be week, month, quarter or year", StatusCode: fiber.StatusBadRequest})
+	}
+	fiscal, ok, err := h.loadFiscalCalendar(c, "Failed to build revenue report")
+	if !ok {
+		return err
+	}
+
+	today := h.now()
+	yearStart, _ := time.ParseInLocation(saleDateLayout, reportPeriod(PeriodYear, fiscal, today).StartDate, time.Local)
+	from, to := yearStart, today
+	for param, date := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := c.Query(param); raw != "" {
+			parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: param + " must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+			}
+			*date = parsed
+		}
+	}
+	if calendarDaysBetween(from, to) < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "from must not be after to", StatusCode: fiber.StatusBadRequest})
+	}
+	if calendarDaysBetween(from, to) >= maxRevenueReportDays {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("the report covers at most %d days", maxRevenueReportDays), StatusCode: fiber.StatusBadRequest})
+	}
+
+	report := api.RevenueReport{GroupBy: groupBy, Fiscal: fiscal != nil, Periods: []api.RevenuePeriod{}}
+	index := make(map[string]int) // Periods by label
+	last := reportPeriod(groupBy, fiscal, to)
+	for day := from; ; {
+		period := reportPeriod(groupBy, fiscal, day)
+		index[period.Label] = len(report.Periods)
+		report.Periods = append(report.Periods, api.RevenuePeriod{ReportPeriod: period})
+		if period.Label == last.Label {
+			break
+		}
+		end, _ := time.ParseInLocation(saleDateLayout, period.EndDate, time.Local)
+		day = end.AddDate(0, 0, 1)
+	}
+	report.StartDate = report.Periods[0].StartDate
+	report.EndDate = last.EndDate
+
+	sales, err := h.Sales.GetAll(c.Context(), models.SalesFilter{StartDate: report.StartDate, EndDate: report.EndDate})
+	if err != nil {
+		log.Printf("Error getting sales from %s to %s for the revenue report: %v", report.StartDate, report.EndDate, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build revenue report", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	totals := make([]int64, len(report.Periods)) // Centavos by period
+	var salesTotal int64
+	for _, sale := range sales {
+		if !sale.Counted() {
+			continue
+		}
+		day, err := time.ParseInLocation(saleDateLayout, sale.SaleDate, time.Local)
+		if err != nil {
+			log.Printf("Skipping sale %s with invalid sale date %q in the revenue report", sale.ID, sale.SaleDate)
+			continue
+		}
+		i, ok := index[reportPeriod(groupBy, fiscal, day).Label]
+		if !ok {
+			continue
+		}
+		totals[i] += toCentavos(sale.TotalPrice)
+		report.Periods[i].SalesCount++
+		salesTotal += toCentavos(sale.TotalPrice)
+		report.SalesCount++
+	}
+	for i := range report.Periods {
+		report.Periods[i].SalesTotal = float64(totals[i]) / 100
+	}
+	report.SalesTotal = float64(salesTotal) / 100
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// summaryPeriod returns the day, week or month containing day
+func summaryPeriod(groupBy string, day time.Time) api.ReportPeriod {
+	if groupBy == models.SummaryDay {
+		date := day.Format(saleDateLayout)
+		return api.ReportPeriod{Label: date, StartDate: date, EndDate: date}
+	}
+	return reportPeriod(groupBy, nil, day)
+}
+
+// GetSalesSummary handles the sales summary of the dashboard
+// @Summary Sales summary
+// @Description Totals the number of sales, revenue and units sold by day, week (Monday to Sunday) or month between two dates, widened to whole periods, including periods without sales, and ranks the cabs and accessories that sold the most units over the whole range. Grouping by day covers at most 366 days.
+// @Tags Reports
+// @Produce json
+// @Security ApiKeyAuth
+// @Param groupBy query string false "day (default), week or month"
+// @Param from query string false "A day in the first period, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "A day in the last period, YYYY-MM-DD (default today)"
+// @Param top query int false "Number of top cabs and of top accessories (default 5, max 50)"
+// @Success 200 {object} api.SalesSummaryResponse "Sales summary"
+// @Failure 400 {object} api.ErrorResponse "Invalid grouping, period or top"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to build sales summary"
+// @Router /reports/sales-summary [get]
+func (h *ReportHandler) GetSalesSummary(c *fiber.Ctx) error {
+	groupBy := c.Query("groupBy", models.SummaryDay)
+	if groupBy != models.SummaryDay && groupBy != models.SummaryWeek && groupBy != models.SummaryMonth {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "groupBy must be day, week or month", StatusCode: fiber.StatusBadRequest})
+	}
+	top := defaultSummarySellers
+	if raw := c.Query("top"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxSummarySellers {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("top must be between 1 and %d", maxSummarySellers), StatusCode: fiber.StatusBadRequest})
+		}
+		top = parsed
+	}
+
+	to := h.now()
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "to must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, 1-defaultSummaryDays)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation(saleDateLayout, raw, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "from must be formatted as YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		from = parsed
+	}
+	maxDays := maxRevenueReportDays
+	if groupBy == models.SummaryDay {
+		maxDays = maxDailySummaryDays
+	}
+	if calendarDaysBetween(from, to) < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "from must not be after to", StatusCode: fiber.StatusBadRequest})
+	}
+	if calendarDaysBetween(from, to) >= maxDays {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("grouped by %s the summary covers at most %d days", groupBy, maxDays), StatusCode: fiber.StatusBadRequest})
+	}
+
+	response := api.SalesSummaryResponse{GroupBy: groupBy, Periods: []api.SalesSummaryPeriod{}}
+	index := make(map[string]int) // Periods by start date
+	last := summaryPeriod(groupBy, to)
+	for day := from; ; {
+		period := summaryPeriod(groupBy, day)
+		index[period.StartDate] = len(response.Periods)
+		response.Periods = append(response.Periods, api.SalesSummaryPeriod{ReportPeriod: period})
+		if period.Label == last.Label {
+			break
+		}
+		end, _ := time.ParseInLocation(saleDateLayout, period.EndDate, time.Local)
+		day = end.AddDate(0, 0, 1)
+	}
+	response.StartDate = response.Periods[0].StartDate
+	response.EndDate = last.EndDate
+
+	summary, err := h.Sales.GetSalesSummary(c.Context(), groupBy, response.StartDate, response.EndDate, top)
+	if err != nil {
+		log.Printf("Error summarizing sales from %s to %s: %v", response.StartDate, response.EndDate, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to build sales summary", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	var revenue int64 // Centavos
+	for _, totals := range summary.Periods {
+		i, ok := index[totals.StartDate]
+		if !ok {
+			continue
+		}
+		response.Periods[i].SalesCount = totals.SalesCount
+		response.Periods[i].Revenue = totals.Revenue
+		response.Periods[i].UnitsSold = totals.UnitsSold
+		response.SalesCount += totals.SalesCount
+		response.UnitsSold += totals.UnitsSold
+		revenue += toCentavos(totals.Revenue)
+	}
+	response.Revenue = float64(revenue) / 100
+	response.TopCabs = summary.TopCabs
+	response.TopAccessories = summary.TopAccessories
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
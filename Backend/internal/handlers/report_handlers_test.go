@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupReportTestApp registers the report routes on an in-memory store with
+// the clock fixed to Wednesday 2025-03-12
+func setupReportTestApp(t *testing.T) (*fiber.App, *memory.Store, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	h := NewReportHandler(store.Sales, store.Users, jwtSecret)
+	h.Registers = store.Registers
+	h.Expenses = store.Expenses
+	h.Fiscal = store.Fiscal
+	h.now = func() time.Time { return time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local) }
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, store, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+}
+
+func addTestSale(t *testing.T, store *memory.Store, soldBy, date string, total float64) {
+	t.Helper()
+	_, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: soldBy, SaleDate: date, TotalPrice: total})
+	require.NoError(t, err)
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	app, store, token := setupReportTestApp(t)
+	seller := &models.User{FullName: "Maria Santos", Username: "maria", Email: "maria@example.com", Password: "password123", Role: RoleStaff, IsActive: true}
+	require.NoError(t, store.Users.Create(context.Background(), seller))
+
+	addTestSale(t, store, seller.Id, "2025-03-10", 400)
+	addTestSale(t, store, "gone-user", "2025-03-16", 900)
+	addTestSale(t, store, seller.Id, "2025-03-01", 1000) // Earlier week, same month
+	addTestSale(t, store, "gone-user", "2025-02-28", 5000)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantStart string
+		wantEnd   string
+		wantUsers []string
+	}{
+		{"Default is the current week", "", "2025-03-10", "2025-03-16", []string{"gone-user", seller.Id}},
+		{"Month", "?period=month", "2025-03-01", "2025-03-31", []string{seller.Id, "gone-user"}},
+		{"Anchored on a date", "?period=month&date=2025-02-14", "2025-02-01", "2025-02-28", []string{"gone-user"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/leaderboard"+tt.query, nil)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var body api.LeaderboardResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantStart, body.StartDate)
+			assert.Equal(t, tt.wantEnd, body.EndDate)
+			users := []string{}
+			for i, entry := range body.Entries {
+				assert.Equal(t, i+1, entry.Rank)
+				users = append(users, entry.UserID)
+				if entry.UserID == seller.Id {
+					assert.Equal(t, "Maria Santos", entry.FullName)
+				} else {
+					assert.Empty(t, entry.FullName)
+				}
+			}
+			assert.Equal(t, tt.wantUsers, users)
+		})
+	}
+}
+
+func TestGetLeaderboardInvalidQuery(t *testing.T) {
+	app, _, token := setupReportTestApp(t)
+
+	for _, query := range []string{"?period=decade", "?date=12-03-2025", "?fiscal=maybe"} {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/leaderboard"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp := authedRequest(t, app, "", http.MethodGet, "/api/reports/leaderboard", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestGetLeaderboardFiscalPeriods(t *testing.T) {
+	app, store, token := setupReportTestApp(t)
+	addTestSale(t, store, "staff-1", "2024-07-01", 100)
+	addTestSale(t, store, "staff-2", "2025-01-15", 200)
+
+	check := func(query, wantLabel, wantStart, wantEnd string, wantEntries int) {
+		t.Helper()
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/leaderboard"+query, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode, query)
+		var body api.LeaderboardResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, wantLabel, body.Label, query)
+		assert.Equal(t, wantStart, body.StartDate, query)
+		assert.Equal(t, wantEnd, body.EndDate, query)
+		assert.Len(t, body.Entries, wantEntries, query)
+	}
+
+	check("?period=week", "2025-W11", "2025-03-10", "2025-03-16", 0)
+	check("?period=quarter", "2025-Q1", "2025-01-01", "2025-03-31", 1)
+	check("?period=year&fiscal=true", "FY2025", "2025-01-01", "2025-12-31", 1) // No fiscal calendar saved yet
+
+	require.NoError(t, store.Fiscal.Save(models.FiscalCalendar{StartMonth: 7, NamedAfterEnd: true}))
+	check("?period=year", "2025", "2025-01-01", "2025-12-31", 1)
+	check("?period=year&fiscal=true", "FY2025", "2024-07-01", "2025-06-30", 2)
+	check("?period=quarter&fiscal=true", "FY2025-Q3", "2025-01-01", "2025-03-31", 1)
+	check("?period=month&fiscal=true", "FY2025-P09", "2025-03-01", "2025-03-31", 0)
+	check("?period=quarter&fiscal=true&date=2024-06-30", "FY2024-Q4", "2024-04-01", "2024-06-30", 0)
+}
+
+func TestGetEndOfDay(t *testing.T) {
+	app, store, token := setupReportTestApp(t)
+	addTestSale(t, store, "staff-1", "2025-03-12", 4500)
+	addTestSale(t, store, "staff-2", "2025-03-12", 1500)
+	addTestSale(t, store, "staff-1", "2025-03-11", 9000)
+
+	closeSession := func(openedBy string, closedAt time.Time, counted, expected float64, denominations []models.DenominationCount) {
+		session := &models.RegisterSession{OpenedBy: openedBy, OpenedAt: closedAt.Add(-8 * time.Hour), OpeningFloat: 1000}
+		require.NoError(t, store.Registers.Open(session))
+		session.ClosedAt = &closedAt
+		session.CountedCash = counted
+		session.ExpectedCash = expected
+		session.Variance = counted - expected
+		session.Denominations = denominations
+		require.NoError(t, store.Registers.Close(session))
+	}
+	closeSession("staff-1", time.Date(2025, 3, 12, 18, 0, 0, 0, time.Local), 5500, 5500, []models.DenominationCount{
+		{Kind: models.CashBill, Value: 1000, Count: 5, Subtotal: 5000},
+		{Kind: models.CashCoin, Value: 20, Count: 25, Subtotal: 500},
+	})
+	closeSession("staff-2", time.Date(2025, 3, 12, 20, 0, 0, 0, time.Local), 2480, 2500, []models.DenominationCount{
+		{Kind: models.CashBill, Value: 1000, Count: 2, Subtotal: 2000},
+		{Kind: models.CashBill, Value: 20, Count: 24, Subtotal: 480},
+	})
+	closeSession("staff-1", time.Date(2025, 3, 11, 18, 0, 0, 0, time.Local), 10000, 10000, nil)
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/end-of-day", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.EndOfDayReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+	assert.Equal(t, "2025-03-12", report.Date)
+	assert.Equal(t, 6000.0, report.SalesTotal)
+	assert.Equal(t, 2, report.SalesCount)
+	require.Len(t, report.Sessions, 2)
+	assert.Equal(t, "staff-1", report.Sessions[0].OpenedBy)
+	assert.Equal(t, 2000.0, report.OpeningFloat)
+	assert.Equal(t, 7980.0, report.CountedCash)
+	assert.Equal(t, 8000.0, report.ExpectedCash)
+	assert.Equal(t, -20.0, report.Variance)
+	assert.Equal(t, []models.DenominationCount{
+		{Kind: models.CashBill, Value: 1000, Count: 7, Subtotal: 7000},
+		{Kind: models.CashBill, Value: 20, Count: 24, Subtotal: 480},
+		{Kind: models.CashCoin, Value: 20, Count: 25, Subtotal: 500},
+	}, report.Denominations)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/reports/end-of-day?date=2025-03-11", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, 9000.0, report.SalesTotal)
+	assert.Len(t, report.Sessions, 1)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/reports/end-of-day?date=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetMonthlyReport(t *testing.T) {
+	app, store, staffToken := setupReportTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	addTestSale(t, store, "staff-1", "2025-03-01", 150000)
+	addTestSale(t, store, "staff-1", "2025-03-31", 40000.50)
+	addTestSale(t, store, "staff-1", "2025-04-01", 99999) // Next month
+	for _, expense := range []models.Expense{
+		{Category: models.ExpenseFuel, Description: "Diesel", Amount: 2500, ExpenseDate: "2025-03-03", Status: models.ExpenseApproved},
+		{Category: models.ExpenseFuel, Description: "Diesel", Amount: 1800.25, ExpenseDate: "2025-03-17", Status: models.ExpenseApproved},
+		{Category: models.ExpenseUtilities, Description: "Electricity", Amount: 6200, ExpenseDate: "2025-03-20", Status: models.ExpenseApproved},
+		{Category: models.ExpenseRepairs, Description: "Hoist", Amount: 12000, ExpenseDate: "2025-03-21", Status: models.ExpensePending},
+		{Category: models.ExpenseSupplies, Description: "Snacks", Amount: 500, ExpenseDate: "2025-03-22", Status: models.ExpenseRejected},
+		{Category: models.ExpenseRent, Description: "April rent", Amount: 20000, ExpenseDate: "2025-04-01", Status: models.ExpenseApproved},
+	} {
+		expense := expense
+		require.NoError(t, store.Expenses.Create(&expense))
+	}
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/monthly", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/monthly?month=March", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/monthly", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.MonthlyReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+
+	assert.Equal(t, "2025-03", report.Month, "defaults to this month")
+	assert.Equal(t, "2025-03-01", report.StartDate)
+	assert.Equal(t, "2025-03-31", report.EndDate)
+	assert.Equal(t, 190000.50, report.SalesTotal)
+	assert.Equal(t, 2, report.SalesCount)
+	assert.Equal(t, []api.ExpenseCategoryTotal{
+		{Category: models.ExpenseUtilities, Total: 6200, Count: 1},
+		{Category: models.ExpenseFuel, Total: 4300.25, Count: 2},
+	}, report.Expenses)
+	assert.Equal(t, 10500.25, report.ExpensesTotal)
+	assert.Equal(t, 179500.25, report.NetTotal)
+	assert.Equal(t, 12000.0, report.PendingExpenses)
+	assert.Equal(t, 1, report.PendingCount)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/monthly?month=2025-04", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, 99999.0, report.SalesTotal)
+	assert.Equal(t, 79999.0, report.NetTotal)
+}
+
+func TestGetDepositReconciliation(t *testing.T) {
+	app, store, staffToken := setupReportTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	// The report clock is 12 March 2025 at 15:00
+	old := addClosedSession(t, store, "staff-1", time.Date(2025, 3, 9, 18, 0, 0, 0, time.Local), 2000, 9000)
+	recent := addClosedSession(t, store, "staff-2", time.Date(2025, 3, 10, 18, 0, 0, 0, time.Local), 2000, 4500.25)
+	addClosedSession(t, store, "staff-2", time.Date(2025, 3, 8, 18, 0, 0, 0, time.Local), 2000, 2000) // No cash taken in
+	deposited := addClosedSession(t, store, "staff-1", time.Date(2025, 3, 1, 18, 0, 0, 0, time.Local), 0, 3000)
+	require.NoError(t, store.Deposits.Create(&models.BankDeposit{Bank: "BDO", Reference: "1", Amount: 3000, DepositDate: "2025-03-02", SessionIDs: []string{deposited.ID}}))
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/deposit-reconciliation", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/deposit-reconciliation?days=-1", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/deposit-reconciliation", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.DepositReconciliationReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, "2025-03-12", report.Date)
+	assert.Equal(t, 2, report.Days)
+	require.Len(t, report.Overdue, 1)
+	assert.Equal(t, old.ID, report.Overdue[0].SessionID)
+	assert.Equal(t, 3, report.Overdue[0].DaysSinceClose)
+	assert.Equal(t, 7000.0, report.OverdueTotal)
+	require.Len(t, report.Pending, 1)
+	assert.Equal(t, recent.ID, report.Pending[0].SessionID)
+	assert.Equal(t, 2500.25, report.PendingTotal)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/deposit-reconciliation?days=1", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Len(t, report.Overdue, 2)
+	assert.Empty(t, report.Pending)
+}
+
+func TestGetTaxReport(t *testing.T) {
+	app, store, staffToken := setupReportTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	for _, sale := range []models.Sale{
+		{SaleDate: "2025-03-03", TotalPrice: 112000, TaxType: models.TaxVatable},
+		{SaleDate: "2025-03-03", TotalPrice: 5000, TaxType: models.TaxExempt},
+		{SaleDate: "2025-03-10", TotalPrice: 30000, TaxType: models.TaxZeroRated},
+		{SaleDate: "2025-03-10", TotalPrice: 560},   // Recorded before tax types
+		{SaleDate: "2025-02-28", TotalPrice: 99999}, // Before the period
+		{SaleDate: "2025-03-03", TotalPrice: 88000, TaxType: models.TaxVatable, Status: models.SaleDraft},
+		{SaleDate: "2025-03-10", TotalPrice: 77000, TaxType: models.TaxVatable, Status: models.SaleCancelled},
+	} {
+		sale := sale
+		sale.CustomerID, sale.SoldBy = "c-1", "staff-1"
+		if sale.TaxType != "" {
+			sale.ApplyTax()
+		}
+		_, err := store.Sales.Create(context.Background(), &sale)
+		require.NoError(t, err)
+	}
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/tax", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for _, query := range []string{"?from=March", "?from=2025-03-12&to=2025-03-01", "?from=2024-01-01&to=2025-03-01", "?format=xlsx"} {
+		resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/tax"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/tax", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.TaxReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.Equal(t, "2025-03-01", report.From, "defaults to this month")
+	assert.Equal(t, "2025-03-12", report.To)
+	require.Len(t, report.Days, 2)
+	assert.Equal(t, api.TaxTotals{Date: "2025-03-03", SalesCount: 2, GrossSales: 117000, VatableSales: 100000, VATAmount: 12000, ExemptSales: 5000}, report.Days[0])
+	assert.Equal(t, api.TaxTotals{SalesCount: 4, GrossSales: 147560, VatableSales: 100500, VATAmount: 12060, ExemptSales: 5000, ZeroRatedSales: 30000}, report.Total)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/tax?from=2025-03-10&to=2025-03-10&format=csv", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "tax-report-2025-03-10-to-2025-03-10.csv")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "date,sales,gross_sales,vatable_sales,vat,exempt_sales,zero_rated_sales\n"+
+		"2025-03-10,2,30560.00,500.00,60.00,0.00,30000.00\n"+
+		"TOTAL,2,30560.00,500.00,60.00,0.00,30000.00\n", string(body))
+}
+
+func TestGetRevenueReport(t *testing.T) {
+	app, store, staffToken := setupReportTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), "admin-1", RoleAdmin, models.DefaultTenantID)
+	require.NoError(t, store.Fiscal.Save(models.FiscalCalendar{StartMonth: 7, NamedAfterEnd: true}))
+	addTestSale(t, store, "staff-1", "2024-06-30", 700) // Last fiscal year
+	addTestSale(t, store, "staff-1", "2024-07-01", 1000)
+	addTestSale(t, store, "staff-1", "2024-08-15", 250.50)
+	addTestSale(t, store, "staff-2", "2025-03-12", 300)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/reports/revenue", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for _, query := range []string{"?groupBy=day", "?fiscal=yes", "?from=2025-03-12&to=2025-03-01", "?from=2010-01-01", "?to=March"} {
+		resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/revenue"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/revenue?groupBy=quarter&fiscal=true", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var report api.RevenueReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.True(t, report.Fiscal)
+	assert.Equal(t, "2024-07-01", report.StartDate, "defaults to this fiscal year")
+	assert.Equal(t, "2025-03-31", report.EndDate)
+	assert.Equal(t, []api.RevenuePeriod{
+		{ReportPeriod: api.ReportPeriod{Label: "FY2025-Q1", StartDate: "2024-07-01", EndDate: "2024-09-30"}, SalesTotal: 1250.50, SalesCount: 2},
+		{ReportPeriod: api.ReportPeriod{Label: "FY2025-Q2", StartDate: "2024-10-01", EndDate: "2024-12-31"}},
+		{ReportPeriod: api.ReportPeriod{Label: "FY2025-Q3", StartDate: "2025-01-01", EndDate: "2025-03-31"}, SalesTotal: 300, SalesCount: 1},
+	}, report.Periods)
+	assert.Equal(t, 1550.50, report.SalesTotal)
+	assert.Equal(t, 3, report.SalesCount)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/reports/revenue?groupBy=year&from=2024-06-01", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	report = api.RevenueReport{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Len(t, report.Periods, 2)
+	assert.Equal(t, "2024", report.Periods[0].Label)
+	assert.Equal(t, 1950.50, report.Periods[0].SalesTotal)
+	assert.Equal(t, "2025", report.Periods[1].Label)
+	assert.Equal(t, "2025-12-31", report.EndDate)
+}
+
+func TestGetSalesSummary(t *testing.T) {
+	app, store, token := setupReportTestApp(t)
+	addTestSale(t, store, "staff-1", "2025-02-10", 999) // Before the default range
+	addTestSale(t, store, "staff-1", "2025-02-11", 100.10)
+	addTestSale(t, store, "staff-1", "2025-03-10", 200.20)
+	addTestSale(t, store, "staff-2", "2025-03-12", 300)
+
+	for _, query := range []string{"?groupBy=year", "?top=0", "?top=51", "?from=2025-03-12&to=2025-03-01", "?from=2024-01-01", "?to=March"} {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/sales-summary"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/reports/sales-summary", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var summary api.SalesSummaryResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&summary))
+	assert.Equal(t, models.SummaryDay, summary.GroupBy)
+	assert.Equal(t, "2025-02-11", summary.StartDate, "defaults to the last 30 days")
+	assert.Equal(t, "2025-03-12", summary.EndDate)
+	require.Len(t, summary.Periods, 30)
+	assert.Equal(t, 100.10, summary.Periods[0].Revenue)
+	assert.Equal(t, 0, summary.Periods[1].SalesCount, "days without sales are included")
+	assert.Equal(t, 3, summary.SalesCount)
+	assert.Equal(t, 600.30, summary.Revenue)
+	assert.Empty(t, summary.TopCabs)
+
+	resp = authedRequest(t, app, token, http.MethodGet, "/api/reports/sales-summary?groupBy=week&from=2025-03-01&to=2025-03-12", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	summary = api.SalesSummaryResponse{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&summary))
+	assert.Equal(t, []api.SalesSummaryPeriod{
+		{ReportPeriod: api.ReportPeriod{Label: "2025-W09", StartDate: "2025-02-24", EndDate: "2025-03-02"}},
+		{ReportPeriod: api.ReportPeriod{Label: "2025-W10", StartDate: "2025-03-03", EndDate: "2025-03-09"}},
+		{ReportPeriod: api.ReportPeriod{Label: "2025-W11", StartDate: "2025-03-10", EndDate: "2025-03-16"}, SalesCount: 2, Revenue: 500.20},
+	}, summary.Periods)
+	assert.Equal(t, 500.20, summary.Revenue)
+}
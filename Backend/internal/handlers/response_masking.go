@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionCustomersPII allows seeing customer email addresses and phone numbers unmasked
+const PermissionCustomersPII = "customers.pii"
+
+// Permissions resolves the permissions held by the caller's role.
+// Admins hold every permission. A nil *Permissions grants other roles nothing.
+type Permissions struct {
+	roles map[string]map[string]bool
+}
+
+// NewPermissions creates a Permissions instance from the configured role grants
+func NewPermissions(cfg config.PermissionsConfig) *Permissions {
+	p := &Permissions{roles: make(map[string]map[string]bool)}
+	for role, permissions := range cfg.RolePermissions {
+		p.roles[role] = make(map[string]bool)
+		for _, permission := range permissions {
+			p.roles[role][permission] = true
+		}
+	}
+	return p
+}
+
+// Has reports whether role holds permission
+func (p *Permissions) Has(role, permission string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	return p != nil && p.roles[role][permission]
+}
+
+// Allowed reports whether the authenticated caller holds permission
+func (p *Permissions) Allowed(c *fiber.Ctx, permission string) bool {
+	role, _ := c.Locals("role").(string)
+	return p.Has(role, permission)
+}
+
+// MaskCustomer hides the contact details of a customer from callers without
+// PermissionCustomersPII. The response is modified in place and returned.
+func (p *Permissions) MaskCustomer(c *fiber.Ctx, customer *api.CustomerResponse) *api.CustomerResponse {
+	if customer == nil || p.Allowed(c, PermissionCustomersPII) {
+		return customer
+	}
+	customer.Email = services.MaskEmail(customer.Email)
+	customer.Phone = services.MaskPhone(customer.Phone)
+	customer.DateOfBirth = services.MaskDateOfBirth(customer.DateOfBirth)
+	return customer
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermissionsHas(t *testing.T) {
+	perms := NewPermissions(config.PermissionsConfig{RolePermissions: map[string][]string{
+		"staff": {PermissionCustomersPII},
+	}})
+
+	assert.True(t, perms.Has(RoleAdmin, PermissionCustomersPII))
+	assert.True(t, perms.Has(RoleStaff, PermissionCustomersPII))
+	assert.False(t, perms.Has("viewer", PermissionCustomersPII))
+	assert.False(t, perms.Has(RoleStaff, "customers.delete"))
+
+	var none *Permissions
+	assert.True(t, none.Has(RoleAdmin, PermissionCustomersPII))
+	assert.False(t, none.Has(RoleStaff, PermissionCustomersPII))
+}
+
+func TestCustomerResponsesMaskedByPermission(t *testing.T) {
+	customer := &models.Customer{
+		ID:        "5f0c6f4e-8d4b-4c4a-9a53-2b7f1f3f9c10",
+		FullName:  "Juan Dela Cruz",
+		Email:     "juan@example.com",
+		Phone:     "+639171234567",
+		Address:   "123 Rizal St",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	tests := []struct {
+		name      string
+		role      string
+		perms     *Permissions
+		wantEmail string
+		wantPhone string
+	}{
+		{"Admin sees contact details", RoleAdmin, nil, "juan@example.com", "+639171234567"},
+		{"Staff sees masked contact details", RoleStaff, nil, "j•••@example.com", "+63•••4567"},
+		{"Staff with permission sees contact details", RoleStaff,
+			NewPermissions(config.PermissionsConfig{RolePermissions: map[string][]string{"staff": {PermissionCustomersPII}}}),
+			"juan@example.com", "+639171234567"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtSecret := []byte("testsecret")
+			mockRepo := new(MockCustomerRepository)
+			h := NewCustomerHandler(mockRepo, jwtSecret)
+			h.Perms = tt.perms
+			app := fiber.New()
+			h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+
+			copied := *customer
+			mockRepo.On("GetAllCustomers").Return([]*models.Customer{&copied}, nil)
+			token, _ := createCustomerTestToken(jwtSecret, "user-id-123", tt.role)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/customers", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			resp, err := app.Test(req)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var body api.CustomerListResponse
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if assert.Len(t, body.Customers, 1) {
+				assert.Equal(t, tt.wantEmail, body.Customers[0].Email)
+				assert.Equal(t, tt.wantPhone, body.Customers[0].Phone)
+				assert.Equal(t, "Juan Dela Cruz", body.Customers[0].FullName)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditActionAddPayment is the activity log action of recording a payment of a sale
+const AuditActionAddPayment = "ADD_SALE_PAYMENT"
+
+// maxPaymentReferenceLength matches the width of the reference column
+const maxPaymentReferenceLength = 100
+
+// SalePaymentHandler records the payments of sales, refusing those that would exceed
+// a sale's total, and reconciles payments with sale totals, flagging the sales where
+// they do not match in the anomaly review queue for finance
+type SalePaymentHandler struct {
+	Repo      repositories.SalePaymentRepository
+	Sales     repositories.SalesRepository
+	Anomalies repositories.AnomalyRepository
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewSalePaymentHandler creates a new SalePaymentHandler instance
+func NewSalePaymentHandler(repo repositories.SalePaymentRepository, sales repositories.SalesRepository, anomalies repositories.AnomalyRepository, jwtSecret []byte) *SalePaymentHandler {
+	return &SalePaymentHandler{Repo: repo, Sales: sales, Anomalies: anomalies, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the payment routes of sales and the admin
+// reconciliation route
+func (h *SalePaymentHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/sales/:id/payments", mw.Auth, mw.Staff, h.GetPayments)                  // GET /api/sales/:id/payments
+	r.Post("/sales/:id/payments", mw.Auth, mw.Staff, h.AddPayment)                  // POST /api/sales/:id/payments
+	r.Post("/admin/payment-reconciliation", mw.Auth, mw.Admin, h.ReconcilePayments) // POST /api/admin/payment-reconciliation
+}
+
+// Reconcile compares the payments of every sale with its total and adds the sales
+// where they differ to the anomaly review queue. It returns all mismatches and the
+// anomalies an earlier run had not flagged yet; a mismatch is flagged again only when
+// its amounts change.
+func (h *SalePaymentHandler) Reconcile() ([]models.PaymentMismatch, []models.Anomaly, error) {
+	mismatches, err := h.Repo.Mismatches()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := h.now()
+	found := make([]models.Anomaly, 0, len(mismatches))
+	for _, mismatch := range mismatches {
+		anomaly := models.Anomaly{
+			SourceID:   fmt.Sprintf("%s:%.2f:%.2f", mismatch.SaleID, mismatch.TotalPrice, mismatch.Paid),
+			EntityType: AuditEntitySale,
+			EntityID:   mismatch.SaleID,
+			OccurredAt: now,
+		}
+		if mismatch.Paid > mismatch.TotalPrice {
+			anomaly.Kind = models.AnomalyPaymentOverage
+			anomaly.Details = fmt.Sprintf("Sale %s of %s was paid %.2f, %.2f more than its total of %.2f",
+				mismatch.SaleID, mismatch.SaleDate, mismatch.Paid, mismatch.Paid-mismatch.TotalPrice, mismatch.TotalPrice)
+		} else {
+			anomaly.Kind = models.AnomalyPaymentShortfall
+			anomaly.Details = fmt.Sprintf("Sale %s of %s was paid %.2f, %.2f short of its total of %.2f",
+				mismatch.SaleID, mismatch.SaleDate, mismatch.Paid, mismatch.TotalPrice-mismatch.Paid, mismatch.TotalPrice)
+		}
+		found = append(found, anomaly)
+	}
+
+	added, err := h.Anomalies.Add(found)
+	return mismatches, added, err
+}
+
+// GetPayments handles listing the payments of a sale
+// @Summary List the payments of a sale
+// @Description Lists the payments of a sale, oldest first, with how much of its total they cover.
+// @Tags Sales
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Success 200 {object} api.SalePaymentsResponse "Payments"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve payments"
+// @Router /sales/{id}/payments [get]
+func (h *SalePaymentHandler) GetPayments(c *fiber.Ctx) error {
+	sale, err := h.Sales.GetByID(c.Context(), c.Params("id"))
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Printf("Error getting sale by ID %s: %v", c.Params("id"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve payments", StatusCode: fiber.StatusInternalServerError})
+	}
+	if sale == nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+	}
+	return h.respondPayments(c, fiber.StatusOK, sale)
+}
+
+// AddPayment handles recording a payment of a sale
+// @Summary Record a payment of a sale
+// @Description Records money received for a sale, such as an installment of a sale sold with a down payment, on the given date or today. Payments may never add up to more than the sale total, so a payment larger than the balance is refused.
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Param payment body api.SalePaymentRequest true "Payment"
+// @Success 201 {object} api.SalePaymentsResponse "Payments of the sale, including the new one"
+// @Failure 400 {object} api.ErrorResponse "Invalid amount, method, reference or date"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 409 {object} api.ErrorResponse "Payment exceeds the balance"
+// @Failure 500 {object} api.ErrorResponse "Failed to record payment"
+// @Router /sales/{id}/payments [post]
+func (h *SalePaymentHandler) AddPayment(c *fiber.Ctx) error {
+	var input api.SalePaymentRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	amount := math.Round(input.Amount*100) / 100
+	if amount <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "amount must be at least 0.01", StatusCode: fiber.StatusBadRequest})
+	}
+	if input.Method == "" {
+		input.Method = models.PaymentCash
+	} else if !models.ValidPaymentMethod(input.Method) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "method must be cash, card, bank_transfer or check", StatusCode: fiber.StatusBadRequest})
+	}
+	reference := strings.TrimSpace(input.Reference)
+	if utf8.RuneCountInString(reference) > maxPaymentReferenceLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("reference cannot be longer than %d characters", maxPaymentReferenceLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	saleID := c.Params("id")
+	receivedBy, _ := c.Locals("user_id").(string)
+	payment := &models.SalePayment{SaleID: saleID, Amount: amount, Method: input.Method, Reference: reference, ReceivedBy: receivedBy}
+
+	// An installment received earlier may be recorded late, but not before the sale
+	// nor in the future
+	if input.Date != "" {
+		day, err := time.ParseInLocation("2006-01-02", input.Date, time.Local)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date must be YYYY-MM-DD", StatusCode: fiber.StatusBadRequest})
+		}
+		now := h.now()
+		if day.After(now) {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date cannot be in the future", StatusCode: fiber.StatusBadRequest})
+		}
+		sale, err := h.Sales.GetByID(c.Context(), saleID)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			log.Printf("Error getting sale by ID %s: %v", saleID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to record payment", StatusCode: fiber.StatusInternalServerError})
+		}
+		if sale == nil {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+		}
+		if len(sale.SaleDate) >= 10 && input.Date < sale.SaleDate[:10] {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "date cannot be before the sale date", StatusCode: fiber.StatusBadRequest})
+		}
+		// Payments of today keep the time they were recorded at
+		if day.Format("2006-01-02") != now.Format("2006-01-02") {
+			payment.ReceivedAt = day
+		}
+	}
+	if err := h.Repo.Add(payment); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+		case errors.Is(err, repositories.ErrPaymentExceedsTotal):
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Payment exceeds the balance of the sale", StatusCode: fiber.StatusConflict})
+		}
+		log.Printf("Error recording payment of sale %s: %v", saleID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to record payment", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, AuditActionAddPayment, AuditEntitySale, saleID,
+		fmt.Sprintf("Recorded %s payment of %.2f for sale %s", payment.Method, payment.Amount, saleID))
+
+	sale, err := h.Sales.GetByID(c.Context(), saleID)
+	if err != nil || sale == nil {
+		log.Printf("Error getting sale by ID %s: %v", saleID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve payments", StatusCode: fiber.StatusInternalServerError})
+	}
+	return h.respondPayments(c, fiber.StatusCreated, sale)
+}
+
+// ReconcilePayments handles reconciling payments with sale totals on request
+// @Summary Reconcile payments with sale totals (Admin)
+// @Description Compares the payments of every sale with its total, as the scheduled reconciliation does, and adds the sales paid more or less than their total to the anomaly review queue.
+// @Tags Anomalies
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.PaymentReconciliationResponse "Mismatches and new anomalies"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to reconcile payments"
+// @Router /admin/payment-reconciliation [post]
+func (h *SalePaymentHandler) ReconcilePayments(c *fiber.Ctx) error {
+	mismatches, added, err := h.Reconcile()
+	if err != nil {
+		log.Printf("Error reconciling payments: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to reconcile payments", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.PaymentReconciliationResponse{
+		Message:    fmt.Sprintf("%d sales do not match their payments", len(mismatches)),
+		Mismatches: mismatches,
+		Anomalies:  added,
+		Count:      len(added),
+	})
+}
+
+// respondPayments sends the payments of a sale with how much of its total they cover
+func (h *SalePaymentHandler) respondPayments(c *fiber.Ctx, status int, sale *models.Sale) error {
+	payments, err := h.Repo.GetBySale(sale.ID)
+	if err != nil {
+		log.Printf("Error getting payments of sale %s: %v", sale.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve payments", StatusCode: fiber.StatusInternalServerError})
+	}
+	paid := 0.0
+	for _, payment := range payments {
+		paid += payment.Amount
+	}
+	paid = math.Round(paid*100) / 100
+	return c.Status(status).JSON(api.SalePaymentsResponse{
+		SaleID:   sale.ID,
+		Total:    sale.TotalPrice,
+		Paid:     paid,
+		Balance:  math.Round((sale.TotalPrice-paid)*100) / 100,
+		Payments: payments,
+	})
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSalePaymentTestApp registers the payment routes, the anomaly list and the sale
+// update route on an in-memory store
+func setupSalePaymentTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	audit := NewChangeRecorder(store.Logs)
+
+	payments := NewSalePaymentHandler(store.Payments, store.Sales, store.Anomalies, jwtSecret)
+	payments.Audit = audit
+	sales := NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret)
+	sales.Payments = store.Payments
+	sales.Audit = audit
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	payments.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	NewAnomalyHandler(store.Anomalies, testAnomalyConfig, jwtSecret).Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Post("/sales", middleware.JWTMiddleware(jwtSecret), sales.CreateSaleHandler)
+	apiGroup.Get("/sales/:id", middleware.JWTMiddleware(jwtSecret), sales.GetSaleByIDHandler)
+	apiGroup.Put("/sales/:id", middleware.JWTMiddleware(jwtSecret), sales.UpdateSaleHandler)
+	apiGroup.Put("/sales/:id/status", middleware.JWTMiddleware(jwtSecret), sales.UpdateSaleStatusHandler)
+	return app, store, jwtSecret
+}
+
+func TestAddSalePayment(t *testing.T) {
+	app, store, jwtSecret := setupSalePaymentTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	saleID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c1", SoldBy: "staff-1", SaleDate: "2026-10-01", TotalPrice: 1000})
+	require.NoError(t, err)
+
+	t.Run("Invalid input", func(t *testing.T) {
+		for _, input := range []api.SalePaymentRequest{
+			{Amount: 0},
+			{Amount: 0.001},
+			{Amount: 100, Method: "barter"},
+			{Amount: 100, Reference: string(make([]byte, maxPaymentReferenceLength+1))},
+		} {
+			resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/"+saleID+"/payments", input)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "%+v", input)
+		}
+	})
+
+	t.Run("Partial payments up to the total", func(t *testing.T) {
+		resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/"+saleID+"/payments",
+			api.SalePaymentRequest{Amount: 600})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var body api.SalePaymentsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, 600.0, body.Paid)
+		assert.Equal(t, 400.0, body.Balance)
+		require.Len(t, body.Payments, 1)
+		assert.Equal(t, models.PaymentCash, body.Payments[0].Method, "cash by default")
+		assert.Equal(t, "staff-1", body.Payments[0].ReceivedBy)
+
+		resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/"+saleID+"/payments",
+			api.SalePaymentRequest{Amount: 400.01, Method: models.PaymentCard})
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/"+saleID+"/payments",
+			api.SalePaymentRequest{Amount: 400, Method: models.PaymentCard, Reference: "slip-7"})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/sales/"+saleID+"/payments", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, 1000.0, body.Paid)
+		assert.Equal(t, 0.0, body.Balance)
+		require.Len(t, body.Payments, 2)
+		assert.Equal(t, "slip-7", body.Payments[1].Reference)
+	})
+
+	t.Run("Total cannot drop below paid", func(t *testing.T) {
+		sale := models.Sale{CustomerID: "c1", SoldBy: "staff-1", SaleDate: "2026-10-01", TotalPrice: 900}
+		resp := authedRequest(t, app, staffToken, http.MethodPut, "/api/sales/"+saleID, sale)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Sale not found", func(t *testing.T) {
+		resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/missing/payments", api.SalePaymentRequest{Amount: 1})
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/sales/missing/payments", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestInstallmentSale(t *testing.T) {
+	app, _, jwtSecret := setupSalePaymentTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	downPayment := -1.0
+	sale := models.Sale{CustomerID: "c1", SoldBy: "staff-1", SaleDate: "2026-01-10", TotalPrice: 1000, DownPayment: &downPayment}
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales", sale)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	downPayment = 300
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/sales", sale)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.Sale
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	require.NotNil(t, created.Balance)
+	assert.Equal(t, 700.0, *created.Balance)
+
+	t.Run("Payment dates", func(t *testing.T) {
+		for _, date := range []string{"10/01/2026", "2026-01-09", time.Now().AddDate(0, 0, 2).Format("2006-01-02")} {
+			resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/"+created.ID+"/payments", api.SalePaymentRequest{Amount: 100, Date: date})
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, date)
+		}
+
+		resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales/"+created.ID+"/payments", api.SalePaymentRequest{Amount: 200, Date: "2026-02-10"})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var body api.SalePaymentsResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, 500.0, body.Balance)
+		require.Len(t, body.Payments, 2)
+		assert.Equal(t, "2026-02-10", body.Payments[0].ReceivedAt.Format("2006-01-02"), "oldest first")
+	})
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/sales/"+created.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got models.Sale
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	require.NotNil(t, got.Balance)
+	assert.Equal(t, 500.0, *got.Balance)
+
+	// The balance of an installment sale is not a shortfall
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/payment-reconciliation", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var reconciled api.PaymentReconciliationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reconciled))
+	assert.Empty(t, reconciled.Mismatches)
+}
+
+func TestDraftSalePayment(t *testing.T) {
+	app, store, jwtSecret := setupSalePaymentTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	sale := models.Sale{CustomerID: "c1", SoldBy: "staff-1", SaleDate: "2026-10-01", TotalPrice: 1000, Status: models.SaleDraft}
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sales", sale)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.Sale
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	payments, err := store.Payments.GetBySale(created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, payments, "a draft is not paid")
+
+	// An unpaid draft is not a shortfall
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/payment-reconciliation", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var reconciled api.PaymentReconciliationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reconciled))
+	assert.Empty(t, reconciled.Mismatches)
+
+	downPayment := -1.0
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/sales/"+created.ID+"/status", SaleStatusRequest{Status: models.SaleConfirmed, DownPayment: &downPayment})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	downPayment = 400
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/sales/"+created.ID+"/status", SaleStatusRequest{Status: models.SaleConfirmed, DownPayment: &downPayment})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var confirmed models.Sale
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&confirmed))
+	require.NotNil(t, confirmed.Balance)
+	assert.Equal(t, 600.0, *confirmed.Balance)
+
+	payments, err = store.Payments.GetBySale(created.ID)
+	require.NoError(t, err)
+	require.Len(t, payments, 1)
+	assert.Equal(t, 400.0, payments[0].Amount)
+}
+
+func TestReconcilePayments(t *testing.T) {
+	app, store, jwtSecret := setupSalePaymentTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	paidID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c1", SoldBy: "staff-1", SaleDate: "2026-10-01", TotalPrice: 1000})
+	require.NoError(t, err)
+	require.NoError(t, store.Payments.Add(&models.SalePayment{SaleID: paidID, Amount: 1000, Method: models.PaymentCash}))
+	unpaidID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c1", SoldBy: "staff-1", SaleDate: "2026-10-02", TotalPrice: 500})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/admin/payment-reconciliation", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/payment-reconciliation", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body api.PaymentReconciliationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Mismatches, 1)
+	require.Equal(t, 1, body.Count)
+	assert.Equal(t, models.AnomalyPaymentShortfall, body.Anomalies[0].Kind)
+	assert.Equal(t, unpaidID, body.Anomalies[0].EntityID)
+	assert.Equal(t, AuditEntitySale, body.Anomalies[0].EntityType)
+
+	// The same mismatch is flagged once
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/payment-reconciliation", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Len(t, body.Mismatches, 1)
+	assert.Equal(t, 0, body.Count)
+
+	// A total lowered behind the application's back shows up as an overage
+	sale, err := store.Sales.GetByID(context.Background(), paidID)
+	require.NoError(t, err)
+	sale.TotalPrice = 800
+	require.NoError(t, store.Sales.Update(context.Background(), sale))
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/payment-reconciliation", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Equal(t, 1, body.Count)
+	assert.Equal(t, models.AnomalyPaymentOverage, body.Anomalies[0].Kind)
+	assert.Equal(t, paidID, body.Anomalies[0].EntityID)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/anomalies?kind="+models.AnomalyPaymentOverage, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.AnomalyListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Len(t, list.Anomalies, 1)
+}
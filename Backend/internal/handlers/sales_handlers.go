@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
-	"oop/internal/middleware"
+	"oop/internal/api"
 	"oop/internal/models"
 	"oop/internal/repositories"
 
@@ -16,31 +21,60 @@ import (
 
 // SaleRepository defines the interface for sales data operations
 type SaleRepository interface {
-	// GetAll retrieves all sales, with optional filtering
-	GetAll(filters map[string]interface{}) ([]models.Sale, error)
+	// GetAll retrieves all sales matching the filter
+	GetAll(ctx context.Context, filter models.SalesFilter) ([]models.Sale, error)
+
+	// GetPaginated retrieves one page of the filtered sales and the total number of matches
+	GetPaginated(ctx context.Context, page, limit int, filter models.SalesFilter) ([]models.Sale, int64, error)
 
 	// GetByID retrieves a sale by its ID
-	GetByID(id string) (*models.Sale, error)
+	GetByID(ctx context.Context, id string) (*models.Sale, error)
 
 	// GetSaleItems retrieves all items for a specific sale
-	GetSaleItems(saleID string) ([]models.SaleItem, error)
+	GetSaleItems(ctx context.Context, saleID string) ([]models.SaleItem, error)
 
 	// GetCustomerSales retrieves all sales for a specific customer
-	GetCustomerSales(customerID string) ([]models.Sale, error)
+	GetCustomerSales(ctx context.Context, customerID string) ([]models.Sale, error)
+
+	// GetCustomerSaleItems retrieves the items of every sale of a customer with the
+	// names of the items sold
+	GetCustomerSaleItems(ctx context.Context, customerID string) ([]models.SaleItem, error)
 
 	// Create creates a new sale record
-	Create(sale *models.Sale) (string, error)
+	Create(ctx context.Context, sale *models.Sale) (string, error)
 
 	// CreateSaleItem adds a new item to a sale
-	CreateSaleItem(item *models.SaleItem) (string, error)
+	CreateSaleItem(ctx context.Context, item *models.SaleItem) (string, error)
+
+	// SellCab records a cab sale with its accessories and takes them out of stock in one transaction
+	SellCab(ctx context.Context, cabID int, customerID string, quantity int, soldBy, taxType string, accessories []models.AccessoryForSale) (*models.Sale, error)
+
+	// Sell records a sale with its items, taking them out of stock with takeStock, in one transaction
+	Sell(ctx context.Context, sale *models.Sale, items []models.SaleItem, takeStock bool) error
 
 	// Update updates an existing sale
-	Update(sale *models.Sale) error
+	Update(ctx context.Context, sale *models.Sale) error
 
 	// Delete deletes a sale and its associated items
-	Delete(id string) error
+	Delete(ctx context.Context, id string) error
+
+	// SetStatus moves a sale along its workflow, putting the cabs and accessories it took out of stock back when it is cancelled or refunded
+	SetStatus(ctx context.Context, id, status string) error
+
+	// Refund records the return of items of a confirmed or completed sale and puts
+	// them back in stock when the sale took them out of stock
+	Refund(ctx context.Context, refund *models.SaleRefund) error
+
+	// GetRefunds retrieves the refunds of a sale, oldest first
+	GetRefunds(ctx context.Context, saleID string) ([]models.SaleRefund, error)
 }
 
+// AuditActionRefundSale is the activity log action of refunding items of a sale
+const AuditActionRefundSale = "REFUND_SALE"
+
+// maxRefundReasonLength matches the width of the refund reason column
+const maxRefundReasonLength = 255
+
 // SaleHandlers holds the repository dependency and JWT secret
 type SaleHandlers struct {
 	Repo      SaleRepository
@@ -48,6 +82,15 @@ type SaleHandlers struct {
 	AccRepo   interface{} // Generic interface for accessory repository
 	CustRepo  interface{} // Generic interface for customer repository
 	jwtSecret []byte
+	Audit     *ChangeRecorder                         // Optional; records field-level changes to the activity log
+	Watch     *Watchlist                              // Optional; notifies users who starred a sold cab or accessory
+	Views     *RecentViews                            // Optional; records the sale in the caller's recently viewed list
+	Documents repositories.DocumentTemplateRepository // Optional; branding printed on receipts
+	Receipts  repositories.ReceiptSeriesRepository    // Optional; OR numbers printed on receipts
+	Alerts    *Alerts                                 // Optional; publishes big sales
+	Perms     *Permissions                            // Optional; without it only admins may sell items priced outside their price guard
+	Payments  repositories.SalePaymentRepository      // Optional; records the payment of new sales and keeps totals from dropping below what was paid
+	Numbers   *DisplayNumbers                         // Optional; gives new sales a display number
 }
 
 // NewSaleHandlers creates a new instance of SaleHandlers
@@ -67,63 +110,87 @@ func NewSaleHandlers(
 	}
 }
 
-// RegisterSaleRoutes sets up the routes for sale operations within the provided Fiber router
-func (h *SaleHandlers) RegisterSaleRoutes(r fiber.Router) {
+// Routes sets up the routes for sale operations within the provided Fiber router
+func (h *SaleHandlers) Routes(r fiber.Router, mw RouteMiddleware) {
 	// Define middleware - Use the imported middleware package and the injected jwtSecret
-	authRequired := middleware.JWTMiddleware(h.jwtSecret)
-
 	// Group routes under '/sales'
-	salesGroup := r.Group("/sales", authRequired)
+	salesGroup := r.Group("/sales", mw.Auth, mw.Staff)
 
 	// Sales endpoints
-	salesGroup.Get("/", h.GetSalesHandler)              // GET /api/sales
-	salesGroup.Get("/:id", h.GetSaleByIDHandler)        // GET /api/sales/{id}
-	salesGroup.Get("/:id/items", h.GetSaleItemsHandler) // GET /api/sales/{id}/items
-	salesGroup.Post("/", h.CreateSaleHandler)           // POST /api/sales
-	salesGroup.Put("/:id", h.UpdateSaleHandler)         // PUT /api/sales/{id}
-	salesGroup.Delete("/:id", h.DeleteSaleHandler)      // DELETE /api/sales/{id}
+	salesGroup.Get("/", h.GetSalesHandler)                   // GET /api/sales
+	salesGroup.Get("/:id", h.GetSaleByIDHandler)             // GET /api/sales/{id}
+	salesGroup.Get("/:id/items", h.GetSaleItemsHandler)      // GET /api/sales/{id}/items
+	salesGroup.Get("/:id/receipt", h.GetSaleReceiptHandler)  // GET /api/sales/{id}/receipt
+	salesGroup.Post("/", h.CreateSaleHandler)                // POST /api/sales
+	salesGroup.Put("/:id", h.UpdateSaleHandler)              // PUT /api/sales/{id}
+	salesGroup.Put("/:id/status", h.UpdateSaleStatusHandler) // PUT /api/sales/{id}/status
+	salesGroup.Post("/:id/refund", h.RefundSaleHandler)      // POST /api/sales/{id}/refund
+	salesGroup.Get("/:id/refunds", h.GetSaleRefundsHandler)  // GET /api/sales/{id}/refunds
+	salesGroup.Delete("/:id", h.DeleteSaleHandler)           // DELETE /api/sales/{id}
 
 	// Customer sales endpoints
-	r.Get("/customers/:id/sales", authRequired, h.GetCustomerSalesHandler) // GET /api/customers/{id}/sales
+	r.Get("/customers/:id/sales", mw.Auth, mw.Staff, h.GetCustomerSalesHandler) // GET /api/customers/{id}/sales
 
 	// Cab sales endpoint
-	r.Post("/cabs/:id/sell", authRequired, h.SellCabHandler) // POST /api/cabs/{id}/sell
+	r.Post("/cabs/:id/sell", mw.Auth, mw.Staff, h.SellCabHandler) // POST /api/cabs/{id}/sell
 }
 
 // GetSalesHandler handles requests to retrieve all sales with optional filtering
 // @Summary Get all sales
-// @Description Retrieves a list of sales, with optional filtering.
+// @Description Retrieves a list of sales, newest first, with optional filtering. With page or limit set, returns one page of them in an envelope with the total number of sales and pages instead of a plain list.
 // @Tags Sales
 // @Produce json
 // @Security ApiKeyAuth
 // @Param customer_id query string false "Filter by customer ID"
 // @Param sold_by query string false "Filter by seller ID"
-// @Param date_from query string false "Filter by sale date (from)"
-// @Param date_to query string false "Filter by sale date (to)"
+// @Param status query string false "Filter by status: draft, confirmed, completed, cancelled or refunded"
+// @Param date_from query string false "First sale date, YYYY-MM-DD"
+// @Param date_to query string false "Last sale date, YYYY-MM-DD"
+// @Param page query int false "Page number, from 1"
+// @Param limit query int false "Sales per page (default 10, max 100)"
 // @Success 200 {array} models.Sale "Successfully retrieved list of sales"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve sales"
+// @Success 200 {object} api.SalePageResponse "One page of sales, when page or limit is set"
+// @Failure 400 {object} api.ErrorResponse "Invalid status or date filter"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve sales"
 // @Router /sales [get]
 func (h *SaleHandlers) GetSalesHandler(c *fiber.Ctx) error {
 	// Extract query parameters for filtering
-	filters := make(map[string]interface{})
-
-	if customerID := c.Query("customer_id"); customerID != "" {
-		filters["customer_id"] = customerID
+	filter := models.SalesFilter{
+		CustomerID: c.Query("customer_id"),
+		SoldBy:     c.Query("sold_by"),
+		Status:     c.Query("status"),
+		StartDate:  c.Query("date_from"),
+		EndDate:    c.Query("date_to"),
 	}
-
-	if soldBy := c.Query("sold_by"); soldBy != "" {
-		filters["sold_by"] = soldBy
-	}
-
-	if dateFrom := c.Query("date_from"); dateFrom != "" {
-		filters["date_from"] = dateFrom
+	if err := filter.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       err.Error(),
+			"status_code": fiber.StatusBadRequest,
+		})
 	}
 
-	if dateTo := c.Query("date_to"); dateTo != "" {
-		filters["date_to"] = dateTo
+	if c.Query("page") != "" || c.Query("limit") != "" {
+		page, limit := pageParams(c)
+		sales, total, err := h.Repo.GetPaginated(c.Context(), page, limit, filter)
+		if err != nil {
+			log.Printf("Error getting paginated sales: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":       "Failed to retrieve sales",
+				"status_code": fiber.StatusInternalServerError,
+			})
+		}
+		h.Numbers.numberSales(sales)
+		h.setBalances(c, sales)
+		return c.Status(fiber.StatusOK).JSON(api.SalePageResponse{
+			Data:       sales,
+			Page:       page,
+			Limit:      limit,
+			Total:      total,
+			TotalPages: (total + int64(limit) - 1) / int64(limit),
+		})
 	}
 
-	sales, err := h.Repo.GetAll(filters)
+	sales, err := h.Repo.GetAll(c.Context(), filter)
 	if err != nil {
 		log.Printf("Error getting sales: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -132,6 +199,8 @@ func (h *SaleHandlers) GetSalesHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Numbers.numberSales(sales)
+	h.setBalances(c, sales)
 	return c.Status(fiber.StatusOK).JSON(sales)
 }
 
@@ -143,8 +212,8 @@ func (h *SaleHandlers) GetSalesHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "Sale ID"
 // @Success 200 {object} models.Sale "Successfully retrieved sale"
-// @Failure 404 {object} ErrorResponse "Sale not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve sale"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve sale"
 // @Router /sales/{id} [get]
 func (h *SaleHandlers) GetSaleByIDHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -155,7 +224,7 @@ func (h *SaleHandlers) GetSaleByIDHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	sale, err := h.Repo.GetByID(id)
+	sale, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error getting sale by ID %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -171,6 +240,10 @@ func (h *SaleHandlers) GetSaleByIDHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Views.Viewed(c, models.ViewEntitySale, id)
+	sale.DisplayNumber = h.Numbers.Number(models.NumberedSale, id)
+	h.setBalance(c, sale)
+	setLastModified(c, sale.UpdatedAt)
 	return c.Status(fiber.StatusOK).JSON(sale)
 }
 
@@ -182,8 +255,8 @@ func (h *SaleHandlers) GetSaleByIDHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "Sale ID"
 // @Success 200 {array} models.SaleItem "Successfully retrieved sale items"
-// @Failure 404 {object} ErrorResponse "Sale not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve sale items"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve sale items"
 // @Router /sales/{id}/items [get]
 func (h *SaleHandlers) GetSaleItemsHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -195,7 +268,7 @@ func (h *SaleHandlers) GetSaleItemsHandler(c *fiber.Ctx) error {
 	}
 
 	// First check if the sale exists
-	sale, err := h.Repo.GetByID(id)
+	sale, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error checking sale existence by ID %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -212,7 +285,7 @@ func (h *SaleHandlers) GetSaleItemsHandler(c *fiber.Ctx) error {
 	}
 
 	// Get the sale items
-	items, err := h.Repo.GetSaleItems(id)
+	items, err := h.Repo.GetSaleItems(c.Context(), id)
 	if err != nil {
 		log.Printf("Error getting items for sale ID %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -226,15 +299,15 @@ func (h *SaleHandlers) GetSaleItemsHandler(c *fiber.Ctx) error {
 
 // CreateSaleHandler handles requests to create a new sale
 // @Summary Create a new sale
-// @Description Creates a new sale record.
+// @Description Creates a new sale record, paid in full or, with downPayment, paid that much now and the balance in installments recorded with POST /sales/{id}/payments. Drafts are not paid until they are confirmed with PUT /sales/{id}/status.
 // @Tags Sales
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param sale body models.Sale true "Sale object to create"
 // @Success 201 {object} models.Sale "Sale created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or missing required fields"
-// @Failure 500 {object} ErrorResponse "Failed to create sale"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload or missing required fields"
+// @Failure 500 {object} api.ErrorResponse "Failed to create sale"
 // @Router /sales [post]
 func (h *SaleHandlers) CreateSaleHandler(c *fiber.Ctx) error {
 	var newSale models.Sale
@@ -253,13 +326,33 @@ func (h *SaleHandlers) CreateSaleHandler(c *fiber.Ctx) error {
 			"status_code": fiber.StatusBadRequest,
 		})
 	}
+	if newSale.TaxType != "" && !models.ValidTaxType(newSale.TaxType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "TaxType must be vatable, exempt or zero_rated",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
+	if newSale.Status != "" && newSale.Status != models.SaleDraft && newSale.Status != models.SaleConfirmed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "Status must be draft or confirmed",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
+	newSale.ApplyTax()
+	newSale.Balance = nil
+	if newSale.DownPayment != nil && *newSale.DownPayment < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "downPayment cannot be negative",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
 
 	// Set timestamps
 	newSale.CreatedAt = time.Now()
 	newSale.UpdatedAt = time.Now()
 
 	// Create the sale
-	saleID, err := h.Repo.Create(&newSale)
+	saleID, err := h.Repo.Create(c.Context(), &newSale)
 	if err != nil {
 		log.Printf("Error creating sale: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -270,6 +363,12 @@ func (h *SaleHandlers) CreateSaleHandler(c *fiber.Ctx) error {
 
 	// Set the ID in the response
 	newSale.ID = saleID
+	newSale.DisplayNumber = h.Numbers.Assign(c, models.NumberedSale, saleID)
+	if newSale.CurrentStatus() == models.SaleConfirmed {
+		h.recordPayment(c, newSale, newSale.DownPayment)
+	}
+	h.setBalance(c, &newSale)
+	h.Alerts.SaleRecorded(c, newSale)
 
 	return c.Status(fiber.StatusCreated).JSON(newSale)
 }
@@ -283,10 +382,13 @@ func (h *SaleHandlers) CreateSaleHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "Sale ID"
 // @Param sale body models.Sale true "Sale object with updated fields"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
 // @Success 200 {object} models.Sale "Sale updated successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or missing required fields"
-// @Failure 404 {object} ErrorResponse "Sale not found"
-// @Failure 500 {object} ErrorResponse "Failed to update sale"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload or missing required fields"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 409 {object} api.ErrorResponse "Sale is completed, cancelled or refunded, or the total is less than the payments already recorded"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update sale"
 // @Router /sales/{id} [put]
 func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -298,7 +400,7 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 	}
 
 	// Check if the sale exists
-	existingSale, err := h.Repo.GetByID(id)
+	existingSale, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error checking sale existence by ID %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -313,6 +415,15 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 			"status_code": fiber.StatusNotFound,
 		})
 	}
+	if !existingSale.Editable() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":       fmt.Sprintf("A %s sale cannot be edited", existingSale.CurrentStatus()),
+			"status_code": fiber.StatusConflict,
+		})
+	}
+	if modified, err := rejectIfModified(c, existingSale.UpdatedAt); modified {
+		return err
+	}
 
 	// Parse the updated sale
 	var updatedSale models.Sale
@@ -324,8 +435,12 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Ensure the ID from the path is used
+	// Ensure the ID from the path is used; a down payment is only taken on creation,
+	// and the status only changes through PUT /sales/{id}/status
 	updatedSale.ID = id
+	updatedSale.Balance = nil
+	updatedSale.DownPayment = nil
+	updatedSale.Status = existingSale.Status
 
 	// Basic validation
 	if updatedSale.CustomerID == "" || updatedSale.SoldBy == "" || updatedSale.SaleDate == "" {
@@ -334,6 +449,33 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 			"status_code": fiber.StatusBadRequest,
 		})
 	}
+	if updatedSale.TaxType == "" {
+		updatedSale.TaxType = existingSale.TaxType
+	} else if !models.ValidTaxType(updatedSale.TaxType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "TaxType must be vatable, exempt or zero_rated",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
+	updatedSale.ApplyTax()
+
+	// Payments may never exceed the total, so it cannot drop below what was paid
+	if h.Payments != nil {
+		paid, err := h.Payments.Paid(id)
+		if err != nil {
+			log.Printf("Error getting payments of sale %s: %v", id, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":       "Failed to update sale",
+				"status_code": fiber.StatusInternalServerError,
+			})
+		}
+		if updatedSale.TotalPrice < paid-repositories.PaymentTolerance {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":       fmt.Sprintf("Total cannot be less than the %.2f already paid", paid),
+				"status_code": fiber.StatusConflict,
+			})
+		}
+	}
 
 	// Update timestamp
 	updatedSale.UpdatedAt = time.Now()
@@ -342,7 +484,7 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 	updatedSale.CreatedAt = existingSale.CreatedAt
 
 	// Update the sale
-	err = h.Repo.Update(&updatedSale)
+	err = h.Repo.Update(c.Context(), &updatedSale)
 	if err != nil {
 		log.Printf("Error updating sale: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -351,9 +493,254 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Audit.RecordUpdate(c, AuditEntitySale, id, existingSale, updatedSale)
+
+	updatedSale.DisplayNumber = h.Numbers.Number(models.NumberedSale, id)
+	h.setBalance(c, &updatedSale)
+	setLastModified(c, updatedSale.UpdatedAt)
 	return c.Status(fiber.StatusOK).JSON(updatedSale)
 }
 
+// SaleStatusRequest is the body for moving a sale along its workflow.
+type SaleStatusRequest struct {
+	Status      string   `json:"status" example:"completed" enums:"draft,confirmed,completed,cancelled,refunded"`
+	DownPayment *float64 `json:"downPayment,omitempty"` // Paid when a draft is confirmed; paid in full when left out
+}
+
+// UpdateSaleStatusHandler handles moving a sale along its workflow
+// @Summary Change the status of a sale
+// @Description Moves a sale along its workflow: drafts are confirmed or cancelled, confirmed sales completed, cancelled or refunded, and completed sales refunded. Cancelling or refunding a confirmed or completed sale puts the cabs and accessories it took out of stock back; sales recorded without taking stock leave it alone. Confirming a draft records its payment, in full or, with downPayment, that much now and the balance in installments. Completed sales can no longer be edited, and cancelled and refunded sales are final.
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Param status body SaleStatusRequest true "New status"
+// @Success 200 {object} models.Sale "Sale status updated"
+// @Failure 400 {object} api.ErrorResponse "Unknown status"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 409 {object} api.ErrorResponse "Transition not allowed"
+// @Failure 500 {object} api.ErrorResponse "Failed to update sale status"
+// @Router /sales/{id}/status [put]
+func (h *SaleHandlers) UpdateSaleStatusHandler(c *fiber.Ctx) error {
+	var input SaleStatusRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if !models.ValidSaleStatus(input.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Status must be draft, confirmed, completed, cancelled or refunded", StatusCode: fiber.StatusBadRequest})
+	}
+	if input.DownPayment != nil && *input.DownPayment < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "downPayment cannot be negative", StatusCode: fiber.StatusBadRequest})
+	}
+
+	id := c.Params("id")
+	sale, err := h.Repo.GetByID(c.Context(), id)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Printf("Error getting sale by ID %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve sale", StatusCode: fiber.StatusInternalServerError})
+	}
+	if sale == nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+	}
+	transitionNotAllowed := func() error {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("A %s sale cannot be moved to %s", sale.CurrentStatus(), input.Status),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+	if !sale.CanTransition(input.Status) {
+		return transitionNotAllowed()
+	}
+
+	if err := h.Repo.SetStatus(c.Context(), id, input.Status); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrSaleTransition):
+			// Another request moved the sale first
+			return transitionNotAllowed()
+		case errors.Is(err, sql.ErrNoRows):
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error updating status of sale %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update sale status", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	before := *sale
+	sale.Status = input.Status
+	sale.UpdatedAt = time.Now()
+	h.Audit.RecordUpdate(c, AuditEntitySale, id, before, *sale)
+	if input.Status == models.SaleConfirmed {
+		h.recordPayment(c, *sale, input.DownPayment) // Drafts are paid once confirmed
+	}
+
+	sale.DisplayNumber = h.Numbers.Number(models.NumberedSale, id)
+	h.setBalance(c, sale)
+	setLastModified(c, sale.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(sale)
+}
+
+// RefundSaleHandler handles returning items of a confirmed or completed sale
+// @Summary Refund a sale
+// @Description Records the return of the given units of items of a confirmed or completed sale, or of every item left to return when no items are given, and puts the cabs and accessories returned back in stock in one transaction if the sale took them out of stock. The refund is worth the returned units at the price they were sold at. A sale is refunded once all of its items were returned; until then it keeps its status and can be refunded again. The sale total and payments are left as they are.
+// @Tags Sales
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Param refund body api.SaleRefundRequest true "Items returned"
+// @Success 201 {object} api.SaleRefundResponse "Refund recorded"
+// @Failure 400 {object} api.ErrorResponse "Invalid items or reason"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 409 {object} api.ErrorResponse "Sale neither confirmed nor completed, or more returned than is left to return"
+// @Failure 500 {object} api.ErrorResponse "Failed to refund sale"
+// @Router /sales/{id}/refund [post]
+func (h *SaleHandlers) RefundSaleHandler(c *fiber.Ctx) error {
+	var input api.SaleRefundRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+		}
+	}
+	reason := strings.TrimSpace(input.Reason)
+	if utf8.RuneCountInString(reason) > maxRefundReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("reason cannot be longer than %d characters", maxRefundReasonLength),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	id := c.Params("id")
+	refundedBy, _ := c.Locals("user_id").(string)
+	refund := &models.SaleRefund{SaleID: id, Reason: reason, RefundedBy: refundedBy}
+	seen := make(map[string]bool, len(input.Items))
+	for _, item := range input.Items {
+		if item.SaleItemID == "" || item.Quantity <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Every item needs a saleItemId and a quantity of at least 1", StatusCode: fiber.StatusBadRequest})
+		}
+		if seen[item.SaleItemID] {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("Item %s is listed more than once", item.SaleItemID), StatusCode: fiber.StatusBadRequest})
+		}
+		seen[item.SaleItemID] = true
+		refund.Items = append(refund.Items, models.SaleRefundItem{SaleItemID: item.SaleItemID, Quantity: item.Quantity})
+	}
+
+	if err := h.Repo.Refund(c.Context(), refund); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+		case errors.Is(err, repositories.ErrSaleTransition):
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Only confirmed and completed sales can be refunded", StatusCode: fiber.StatusConflict})
+		case errors.Is(err, repositories.ErrRefundQuantity):
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "More is returned than is left to return of the sale", StatusCode: fiber.StatusConflict})
+		}
+		log.Printf("Error refunding sale %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to refund sale", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, AuditActionRefundSale, AuditEntitySale, id,
+		fmt.Sprintf("Refunded %.2f for %d item(s) of sale %s", refund.Amount, len(refund.Items), id))
+
+	sale, err := h.Repo.GetByID(c.Context(), id)
+	if err != nil || sale == nil {
+		log.Printf("Error getting sale by ID %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve sale", StatusCode: fiber.StatusInternalServerError})
+	}
+	sale.DisplayNumber = h.Numbers.Number(models.NumberedSale, id)
+	h.setBalance(c, sale)
+	return c.Status(fiber.StatusCreated).JSON(api.SaleRefundResponse{Refund: *refund, Sale: *sale})
+}
+
+// GetSaleRefundsHandler handles listing the refunds of a sale
+// @Summary Get the refunds of a sale
+// @Description Retrieves the refunds of a sale with the items each returned, oldest first.
+// @Tags Sales
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Sale ID"
+// @Success 200 {array} models.SaleRefund "Refunds of the sale"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve refunds"
+// @Router /sales/{id}/refunds [get]
+func (h *SaleHandlers) GetSaleRefundsHandler(c *fiber.Ctx) error {
+	id := c.Params("id")
+	sale, err := h.Repo.GetByID(c.Context(), id)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		log.Printf("Error getting sale by ID %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve refunds", StatusCode: fiber.StatusInternalServerError})
+	}
+	if sale == nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Sale not found", StatusCode: fiber.StatusNotFound})
+	}
+	refunds, err := h.Repo.GetRefunds(c.Context(), id)
+	if err != nil {
+		log.Printf("Error getting refunds of sale %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve refunds", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(refunds)
+}
+
+// recordPayment records that a new sale was paid in full by its seller or, given a
+// down payment less than its total, that the down payment was received and the rest
+// will be paid in installments. A failure is only logged: the sale stands, and the
+// payment reconciliation flags it.
+func (h *SaleHandlers) recordPayment(c *fiber.Ctx, sale models.Sale, downPayment *float64) {
+	if h.Payments == nil || sale.TotalPrice <= 0 {
+		return
+	}
+	amount := sale.TotalPrice
+	if downPayment != nil && *downPayment < sale.TotalPrice-repositories.PaymentTolerance {
+		if err := h.Payments.MarkInstallment(sale.ID); err != nil {
+			log.Printf("Error marking sale %s in tenant %s as paid in installments: %v", sale.ID, tenantIDFromCtx(c), err)
+		}
+		amount = math.Round(*downPayment*100) / 100
+	}
+	if amount <= 0 {
+		return
+	}
+	payment := &models.SalePayment{SaleID: sale.ID, Amount: amount, Method: models.PaymentCash, ReceivedBy: sale.SoldBy}
+	if err := h.Payments.Add(payment); err != nil {
+		log.Printf("Error recording the payment of sale %s in tenant %s: %v", sale.ID, tenantIDFromCtx(c), err)
+	}
+}
+
+// setBalance fills in the balance of a sale from its payments, when payments are
+// tracked. The sale is sent without it when they cannot be read.
+func (h *SaleHandlers) setBalance(c *fiber.Ctx, sale *models.Sale) {
+	if h.Payments == nil {
+		return
+	}
+	paid, err := h.Payments.Paid(sale.ID)
+	if err != nil {
+		log.Printf("Error getting payments of sale %s in tenant %s: %v", sale.ID, tenantIDFromCtx(c), err)
+		return
+	}
+	balance := math.Round((sale.TotalPrice-paid)*100) / 100
+	sale.Balance = &balance
+}
+
+// setBalances fills in the balances of sales from their payments, read at once
+func (h *SaleHandlers) setBalances(c *fiber.Ctx, sales []models.Sale) {
+	if h.Payments == nil || len(sales) == 0 {
+		return
+	}
+	ids := make([]string, len(sales))
+	for i, sale := range sales {
+		ids[i] = sale.ID
+	}
+	paid, err := h.Payments.PaidBySales(ids)
+	if err != nil {
+		log.Printf("Error getting payments of sales in tenant %s: %v", tenantIDFromCtx(c), err)
+		return
+	}
+	for i := range sales {
+		balance := math.Round((sales[i].TotalPrice-paid[sales[i].ID])*100) / 100
+		sales[i].Balance = &balance
+	}
+}
+
+// AuditActionDeleteSale is the activity log action of deleting a sale
+const AuditActionDeleteSale = "DELETE_SALE"
+
 // DeleteSaleHandler handles requests to delete a sale
 // @Summary Delete a sale
 // @Description Deletes a sale by its ID.
@@ -362,8 +749,8 @@ func (h *SaleHandlers) UpdateSaleHandler(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "Sale ID"
 // @Success 204 "Sale deleted successfully (No Content)"
-// @Failure 404 {object} ErrorResponse "Sale not found"
-// @Failure 500 {object} ErrorResponse "Failed to delete sale"
+// @Failure 404 {object} api.ErrorResponse "Sale not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete sale"
 // @Router /sales/{id} [delete]
 func (h *SaleHandlers) DeleteSaleHandler(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -375,7 +762,7 @@ func (h *SaleHandlers) DeleteSaleHandler(c *fiber.Ctx) error {
 	}
 
 	// Check if the sale exists
-	existingSale, err := h.Repo.GetByID(id)
+	existingSale, err := h.Repo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error checking sale existence by ID %s: %v", id, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -392,7 +779,7 @@ func (h *SaleHandlers) DeleteSaleHandler(c *fiber.Ctx) error {
 	}
 
 	// Delete the sale
-	err = h.Repo.Delete(id)
+	err = h.Repo.Delete(c.Context(), id)
 	if err != nil {
 		log.Printf("Error deleting sale: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -401,12 +788,14 @@ func (h *SaleHandlers) DeleteSaleHandler(c *fiber.Ctx) error {
 		})
 	}
 
+	h.Audit.RecordAction(c, AuditActionDeleteSale, AuditEntitySale, id,
+		fmt.Sprintf("Deleted sale %s of %.2f sold by %s", id, existingSale.TotalPrice, existingSale.SoldBy))
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
 
 // SellCabHandler handles requests to sell a cab with optional accessories
 // @Summary Sell a cab
-// @Description Sells a cab with optional accessories.
+// @Description Sells a cab with optional accessories, taking them out of stock in the same transaction. A cab sold down to 2 or fewer units becomes Low Stock, and to none Out of Stock; accessories get the status of their remaining quantity. A cab or accessory priced outside its min_price and max_price is refused unless the caller holds the prices.override permission. With downPayment, only that much is recorded as paid and the balance is paid in installments.
 // @Tags Sales
 // @Accept json
 // @Produce json
@@ -414,9 +803,11 @@ func (h *SaleHandlers) DeleteSaleHandler(c *fiber.Ctx) error {
 // @Param id path int true "Cab ID"
 // @Param sale body models.CabSalePayload true "Sale details"
 // @Success 201 {object} models.CabSale "Cab sold successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request payload or missing required fields"
-// @Failure 404 {object} ErrorResponse "Cab not found"
-// @Failure 500 {object} ErrorResponse "Failed to process sale"
+// @Failure 400 {object} api.ErrorResponse "Invalid request payload or missing required fields"
+// @Failure 404 {object} api.ErrorResponse "Cab not found"
+// @Failure 409 {object} api.ErrorResponse "Requested quantity exceeds available stock"
+// @Failure 422 {object} api.ErrorResponse "Price outside the price guard"
+// @Failure 500 {object} api.ErrorResponse "Failed to process sale"
 // @Router /cabs/{id}/sell [post]
 func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 	// Parse the cab ID from the URL
@@ -446,6 +837,18 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 			"status_code": fiber.StatusBadRequest,
 		})
 	}
+	if salePayload.TaxType != "" && !models.ValidTaxType(salePayload.TaxType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "taxType must be vatable, exempt or zero_rated",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
+	if salePayload.DownPayment != nil && *salePayload.DownPayment < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "downPayment cannot be negative",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
 
 	// Get user ID from JWT token for the SoldBy field
 	userID := c.Locals("user_id")
@@ -453,19 +856,7 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 		userID = "system" // Fallback if user ID is not available
 	}
 
-	// Create a new sale record
-	newSale := models.Sale{
-		CustomerID: salePayload.CustomerID,
-		SoldBy:     fmt.Sprintf("%v", userID),
-		SaleDate:   time.Now().Format("2006-01-02"),
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-
-	// Calculate total price (cab price * quantity + accessories price)
-	var totalPrice float64
-
-	// Get cab price from repository and calculate total
+	// Get the cab to check its price
 	cabRepo, ok := h.CabRepo.(repositories.CabsRepository)
 	if !ok {
 		log.Println("Cab repository not initialized correctly")
@@ -475,7 +866,7 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	cab, err := cabRepo.GetCabByID(cabID)
+	cab, err := cabRepo.GetCabByID(c.Context(), cabID)
 	if err != nil {
 		log.Printf("Error getting cab by ID %d: %v", cabID, err)
 		// Check if the error is due to the cab not being found
@@ -491,11 +882,15 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	// Calculate cab item price
-	cabItemPrice := cab.Price * float64(salePayload.Quantity)
-	totalPrice += cabItemPrice
+	// A price typed wrong, such as 70 for 700,000, must not reach a sale
+	override := h.Perms.Allowed(c, PermissionPricesOverride)
+	if err := checkPriceGuard(cabPricing(*cab)); err != nil && !override {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":       err.Error(),
+			"status_code": fiber.StatusUnprocessableEntity,
+		})
+	}
 
-	// Calculate accessories total price and create accessory sale items
 	accRepo, ok := h.AccRepo.(repositories.AccessoryRepository)
 	if !ok {
 		log.Println("Accessory repository not initialized correctly")
@@ -505,8 +900,8 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	var accessorySaleItems []models.SaleItem // To store successfully created accessory sale items for response
-
+	// The accessories are sold at their current prices, not those sent by the client
+	var accessories []models.AccessoryForSale
 	for _, accessoryForSale := range salePayload.Accessories {
 		accessory, err := accRepo.GetByID(c.Context(), accessoryForSale.ID)
 		if err != nil {
@@ -514,67 +909,45 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 			// Continue to the next accessory if not found or other error
 			continue
 		}
-
-		// Calculate accessory item price
-		accessoryItemPrice := accessory.Price * float64(accessoryForSale.Quantity)
-		totalPrice += accessoryItemPrice
-
-		// Create sale item for the accessory
-		accessorySaleItem := models.SaleItem{
-			SaleID:      "", // Will be set after the main sale is created
-			ItemType:    "accessory",
-			AccessoryID: strconv.Itoa(accessory.ID), // Convert int to string for SaleItem struct
-			Quantity:    accessoryForSale.Quantity,
-			UnitPrice:   accessory.Price,
-			Subtotal:    accessoryItemPrice,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
+		if err := checkPriceGuard(accessoryPricing(accessory)); err != nil && !override {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error":       err.Error(),
+				"status_code": fiber.StatusUnprocessableEntity,
+			})
 		}
-		accessorySaleItems = append(accessorySaleItems, accessorySaleItem)
+		accessories = append(accessories, models.AccessoryForSale{
+			ID:        accessory.ID,
+			Name:      accessory.Name,
+			Price:     accessory.Price,
+			Quantity:  accessoryForSale.Quantity,
+			UnitPrice: accessory.Price,
+		})
 	}
 
-	// Set the total price
-	newSale.TotalPrice = totalPrice
-
-	// Create the main sale record
-	saleID, err := h.Repo.Create(&newSale)
+	// The sale, its items and the stock deduction are one transaction, so a sale is
+	// never recorded without its cab and accessories being taken out of stock
+	newSale, err := h.Repo.SellCab(c.Context(), cabID, salePayload.CustomerID, salePayload.Quantity, fmt.Sprintf("%v", userID), salePayload.TaxType, accessories)
 	if err != nil {
-		log.Printf("Error creating sale: %v", err)
+		if errors.Is(err, repositories.ErrNegativeStock) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":       "Requested quantity exceeds available stock",
+				"status_code": fiber.StatusConflict,
+			})
+		}
+		log.Printf("Error selling cab %d: %v", cabID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":       "Failed to create sale",
 			"status_code": fiber.StatusInternalServerError,
 		})
 	}
+	saleID := newSale.ID
+	totalPrice := newSale.TotalPrice
+	newSale.DisplayNumber = h.Numbers.Assign(c, models.NumberedSale, saleID)
 
-	// Create sale item for the cab
-	cabSaleItem := models.SaleItem{
-		SaleID:     saleID,
-		ItemType:   "cab",
-		MultiCabID: strconv.Itoa(cab.ID), // Convert int to string for SaleItem struct
-		Quantity:   salePayload.Quantity,
-		UnitPrice:  cab.Price,
-		Subtotal:   cabItemPrice,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
-
-	_, err = h.Repo.CreateSaleItem(&cabSaleItem)
-	if err != nil {
-		log.Printf("Error creating cab sale item for sale ID %s: %v", saleID, err)
-		// Decide how to handle this error - potentially delete the main sale and other items?
-		// For now, just log and continue, but this might leave inconsistent data.
-		// A transaction would be better here.
-	}
-
-	// Create sale items for the accessories
-	for _, accessorySaleItem := range accessorySaleItems {
-		accessorySaleItem.SaleID = saleID // Set the sale ID
-		_, err := h.Repo.CreateSaleItem(&accessorySaleItem)
-		if err != nil {
-			log.Printf("Error creating accessory sale item for sale ID %s and accessory ID %s: %v", saleID, accessorySaleItem.AccessoryID, err)
-			// Log the error and continue. Again, a transaction would be better.
-		}
-	}
+	h.recordPayment(c, *newSale, salePayload.DownPayment)
+	h.setBalance(c, newSale)
+	h.Alerts.SaleRecorded(c, *newSale)
+	h.Watch.ItemSold(c, models.FavoriteItemCab, cab.ID, cab.Name, cab.Price, salePayload.Quantity)
 
 	// Prepare the accessories list for the response, including details from the fetched accessories
 	responseAccessories := []map[string]interface{}{}
@@ -586,6 +959,7 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 			log.Printf("Error getting accessory by ID %d for response: %v. Skipping.", accessoryForSale.ID, err)
 			continue
 		}
+		h.Watch.ItemSold(c, models.FavoriteItemAccessory, accessory.ID, accessory.Name, accessory.Price, accessoryForSale.Quantity)
 		responseAccessories = append(responseAccessories, map[string]interface{}{
 			"id":        accessory.ID,
 			"name":      accessory.Name,
@@ -596,29 +970,36 @@ func (h *SaleHandlers) SellCabHandler(c *fiber.Ctx) error {
 	}
 
 	// Return the sale details
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"success":     true,
-		"message":     "Cab sold successfully",
-		"cabId":       cabID,
-		"customerId":  salePayload.CustomerID,
-		"quantity":    salePayload.Quantity,
-		"accessories": responseAccessories, // Use the prepared accessories list
-		"totalPrice":  totalPrice,
-		"saleDate":    newSale.SaleDate,
-		"saleId":      saleID,
-	})
+	response := fiber.Map{
+		"success":       true,
+		"message":       "Cab sold successfully",
+		"cabId":         cabID,
+		"customerId":    salePayload.CustomerID,
+		"quantity":      salePayload.Quantity,
+		"accessories":   responseAccessories, // Use the prepared accessories list
+		"totalPrice":    totalPrice,
+		"saleDate":      newSale.SaleDate,
+		"saleId":        saleID,
+		"displayNumber": newSale.DisplayNumber,
+	}
+	if newSale.Balance != nil {
+		response["balance"] = *newSale.Balance
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
 }
 
 // GetCustomerSalesHandler handles requests to retrieve all sales for a specific customer
 // @Summary Get customer sales
-// @Description Retrieves all sales for a specific customer.
+// @Description Retrieves all sales for a specific customer. With include=items every sale lists its items, named after the cab, accessory or material sold.
 // @Tags Sales
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "Customer ID"
+// @Param include query string false "Also list the items of each sale" Enums(items)
 // @Success 200 {array} models.Sale "Successfully retrieved customer sales"
-// @Failure 404 {object} ErrorResponse "Customer not found"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve customer sales"
+// @Failure 400 {object} api.ErrorResponse "Unknown include"
+// @Failure 404 {object} api.ErrorResponse "Customer not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve customer sales"
 // @Router /customers/{id}/sales [get]
 func (h *SaleHandlers) GetCustomerSalesHandler(c *fiber.Ctx) error {
 	customerID := c.Params("id")
@@ -628,9 +1009,16 @@ func (h *SaleHandlers) GetCustomerSalesHandler(c *fiber.Ctx) error {
 			"status_code": fiber.StatusBadRequest,
 		})
 	}
+	include := c.Query("include")
+	if include != "" && include != "items" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":       "include must be items",
+			"status_code": fiber.StatusBadRequest,
+		})
+	}
 
 	// Get the customer sales
-	sales, err := h.Repo.GetCustomerSales(customerID)
+	sales, err := h.Repo.GetCustomerSales(c.Context(), customerID)
 	if err != nil {
 		log.Printf("Error getting sales for customer ID %s: %v", customerID, err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -644,5 +1032,26 @@ func (h *SaleHandlers) GetCustomerSalesHandler(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).JSON([]models.Sale{})
 	}
 
+	if include == "items" {
+		// Every item of the customer is fetched at once rather than sale by sale
+		items, err := h.Repo.GetCustomerSaleItems(c.Context(), customerID)
+		if err != nil {
+			log.Printf("Error getting sale items for customer ID %s: %v", customerID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":       "Failed to retrieve customer sales",
+				"status_code": fiber.StatusInternalServerError,
+			})
+		}
+		bySale := make(map[string][]models.SaleItem)
+		for _, item := range items {
+			bySale[item.SaleID] = append(bySale[item.SaleID], item)
+		}
+		for i := range sales {
+			sales[i].Items = bySale[sales[i].ID]
+		}
+	}
+
+	h.Numbers.numberSales(sales)
+	h.setBalances(c, sales)
 	return c.Status(fiber.StatusOK).JSON(sales)
 }
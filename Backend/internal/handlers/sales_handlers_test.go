@@ -3,11 +3,15 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"oop/internal/api"
 	"oop/internal/models" // Assuming models are in this path
+	"oop/internal/repositories"
 	"strconv"
 	"strings"
 	"testing"
@@ -19,11 +23,13 @@ import (
 )
 
 // MockSaleRepository is a mock implementation of SaleRepository
+// The context is not recorded with the calls: handlers pass the request's fasthttp
+// context, which is reused once the request ends.
 type MockSaleRepository struct {
 	mock.Mock
 }
 
-func (m *MockSaleRepository) GetAll(filters map[string]interface{}) ([]models.Sale, error) {
+func (m *MockSaleRepository) GetAll(ctx context.Context, filters models.SalesFilter) ([]models.Sale, error) {
 	args := m.Called(filters)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -31,7 +37,23 @@ func (m *MockSaleRepository) GetAll(filters map[string]interface{}) ([]models.Sa
 	return args.Get(0).([]models.Sale), args.Error(1)
 }
 
-func (m *MockSaleRepository) GetByID(id string) (*models.Sale, error) {
+func (m *MockSaleRepository) GetCustomerSaleItems(ctx context.Context, customerID string) ([]models.SaleItem, error) {
+	args := m.Called(customerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SaleItem), args.Error(1)
+}
+
+func (m *MockSaleRepository) GetPaginated(ctx context.Context, page, limit int, filters models.SalesFilter) ([]models.Sale, int64, error) {
+	args := m.Called(page, limit, filters)
+	if args.Get(0) == nil {
+		return nil, 0, args.Error(2)
+	}
+	return args.Get(0).([]models.Sale), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSaleRepository) GetByID(ctx context.Context, id string) (*models.Sale, error) {
 	args := m.Called(id)
 	// Handle the case where Get(0) might be nil for a *models.Sale
 	if ret := args.Get(0); ret != nil {
@@ -40,7 +62,7 @@ func (m *MockSaleRepository) GetByID(id string) (*models.Sale, error) {
 	return nil, args.Error(1)
 }
 
-func (m *MockSaleRepository) GetSaleItems(saleID string) ([]models.SaleItem, error) {
+func (m *MockSaleRepository) GetSaleItems(ctx context.Context, saleID string) ([]models.SaleItem, error) {
 	args := m.Called(saleID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -48,7 +70,7 @@ func (m *MockSaleRepository) GetSaleItems(saleID string) ([]models.SaleItem, err
 	return args.Get(0).([]models.SaleItem), args.Error(1)
 }
 
-func (m *MockSaleRepository) GetCustomerSales(customerID string) ([]models.Sale, error) {
+func (m *MockSaleRepository) GetCustomerSales(ctx context.Context, customerID string) ([]models.Sale, error) {
 	args := m.Called(customerID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -56,43 +78,68 @@ func (m *MockSaleRepository) GetCustomerSales(customerID string) ([]models.Sale,
 	return args.Get(0).([]models.Sale), args.Error(1)
 }
 
-func (m *MockSaleRepository) Create(sale *models.Sale) (string, error) {
+func (m *MockSaleRepository) Create(ctx context.Context, sale *models.Sale) (string, error) {
 	args := m.Called(sale)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockSaleRepository) CreateSaleItem(item *models.SaleItem) (string, error) {
+func (m *MockSaleRepository) CreateSaleItem(ctx context.Context, item *models.SaleItem) (string, error) {
 	args := m.Called(item)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockSaleRepository) Update(sale *models.Sale) error {
+func (m *MockSaleRepository) Update(ctx context.Context, sale *models.Sale) error {
 	args := m.Called(sale)
 	return args.Error(0)
 }
 
-func (m *MockSaleRepository) Delete(id string) error {
+func (m *MockSaleRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockSaleRepository) SellCab(cabID int, customerID string, quantity int, soldBy string, accessories []models.AccessoryForSale) (*models.Sale, error) {
-	args := m.Called(cabID, customerID, quantity, soldBy, accessories)
+func (m *MockSaleRepository) SetStatus(ctx context.Context, id, status string) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockSaleRepository) Refund(ctx context.Context, refund *models.SaleRefund) error {
+	args := m.Called(refund)
+	return args.Error(0)
+}
+
+func (m *MockSaleRepository) GetRefunds(ctx context.Context, saleID string) ([]models.SaleRefund, error) {
+	args := m.Called(saleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SaleRefund), args.Error(1)
+}
+
+func (m *MockSaleRepository) SellCab(ctx context.Context, cabID int, customerID string, quantity int, soldBy, taxType string, accessories []models.AccessoryForSale) (*models.Sale, error) {
+	args := m.Called(cabID, customerID, quantity, soldBy, taxType, accessories)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Sale), args.Error(1)
 }
 
+func (m *MockSaleRepository) Sell(ctx context.Context, sale *models.Sale, items []models.SaleItem, takeStock bool) error {
+	args := m.Called(sale, items, takeStock)
+	return args.Error(0)
+}
+
 // SaleRepository interface is already defined in the main code
 // We're using it here for the mock implementation
 
 // MockCabsRepositoryForSales is a mock implementation of repositories.CabsRepository for sales tests
+// The context is not recorded with the calls: handlers pass the request's fasthttp
+// context, which is reused once the request ends.
 type MockCabsRepositoryForSales struct {
 	mock.Mock
 }
 
-func (m *MockCabsRepositoryForSales) GetCabs(filters map[string]interface{}) ([]models.MultiCab, error) {
+func (m *MockCabsRepositoryForSales) GetCabs(ctx context.Context, filters models.CabFilter) ([]models.MultiCab, error) {
 	args := m.Called(filters)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -100,7 +147,12 @@ func (m *MockCabsRepositoryForSales) GetCabs(filters map[string]interface{}) ([]
 	return args.Get(0).([]models.MultiCab), args.Error(1)
 }
 
-func (m *MockCabsRepositoryForSales) GetCabByID(id int) (*models.MultiCab, error) {
+func (m *MockCabsRepositoryForSales) CountCabs(ctx context.Context, filters models.CabFilter) (int64, error) {
+	args := m.Called(filters)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCabsRepositoryForSales) GetCabByID(ctx context.Context, id int) (*models.MultiCab, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -108,7 +160,7 @@ func (m *MockCabsRepositoryForSales) GetCabByID(id int) (*models.MultiCab, error
 	return args.Get(0).(*models.MultiCab), args.Error(1)
 }
 
-func (m *MockCabsRepositoryForSales) AddCab(cab models.MultiCab) (*models.MultiCab, error) {
+func (m *MockCabsRepositoryForSales) AddCab(ctx context.Context, cab models.MultiCab) (*models.MultiCab, error) {
 	args := m.Called(cab)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -116,7 +168,7 @@ func (m *MockCabsRepositoryForSales) AddCab(cab models.MultiCab) (*models.MultiC
 	return args.Get(0).(*models.MultiCab), args.Error(1)
 }
 
-func (m *MockCabsRepositoryForSales) UpdateCab(id int, cab models.MultiCab) (*models.MultiCab, error) {
+func (m *MockCabsRepositoryForSales) UpdateCab(ctx context.Context, id int, cab models.MultiCab) (*models.MultiCab, error) {
 	args := m.Called(id, cab)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -124,11 +176,26 @@ func (m *MockCabsRepositoryForSales) UpdateCab(id int, cab models.MultiCab) (*mo
 	return args.Get(0).(*models.MultiCab), args.Error(1)
 }
 
-func (m *MockCabsRepositoryForSales) DeleteCab(id int) error {
+func (m *MockCabsRepositoryForSales) DeleteCab(ctx context.Context, id int) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
+func (m *MockCabsRepositoryForSales) RestoreCab(ctx context.Context, id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCabsRepositoryForSales) GetDeletedCabs(ctx context.Context) ([]models.MultiCab, error) {
+	args := m.Called()
+	return args.Get(0).([]models.MultiCab), args.Error(1)
+}
+
+func (m *MockCabsRepositoryForSales) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.MultiCab]) error {
+	args := m.Called(changes)
+	return args.Error(0)
+}
+
 // MockAccessoryRepositoryForSales is a mock implementation of repositories.AccessoryRepository for sales tests
 type MockAccessoryRepositoryForSales struct {
 	mock.Mock
@@ -142,6 +209,11 @@ func (m *MockAccessoryRepositoryForSales) GetAll(ctx context.Context) ([]models.
 	return args.Get(0).([]models.Accessory), args.Error(1)
 }
 
+func (m *MockAccessoryRepositoryForSales) GetPaginated(ctx context.Context, page, limit int, filters models.AccessoryFilter) ([]models.Accessory, int64, error) {
+	args := m.Called(ctx, page, limit, filters)
+	return args.Get(0).([]models.Accessory), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockAccessoryRepositoryForSales) GetByID(ctx context.Context, id int) (models.Accessory, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(models.Accessory), args.Error(1)
@@ -162,6 +234,21 @@ func (m *MockAccessoryRepositoryForSales) Delete(ctx context.Context, id int) er
 	return args.Error(0)
 }
 
+func (m *MockAccessoryRepositoryForSales) Restore(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAccessoryRepositoryForSales) GetDeleted(ctx context.Context) ([]models.Accessory, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Accessory), args.Error(1)
+}
+
+func (m *MockAccessoryRepositoryForSales) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Accessory]) error {
+	args := m.Called(ctx, changes)
+	return args.Error(0)
+}
+
 // Helper function to create a test Fiber app and SaleHandlers
 // It also includes a mock middleware to simulate authentication
 func setupSaleTestApp(mockRepo *MockSaleRepository, t *testing.T) (*fiber.App, *SaleHandlers) {
@@ -196,7 +283,7 @@ func setupSaleTestApp(mockRepo *MockSaleRepository, t *testing.T) (*fiber.App, *
 		return c.Next()
 	}
 
-	// Register routes (mirroring RegisterSaleRoutes but with mock middleware)
+	// Register routes (mirroring SaleHandlers.Routes but with mock middleware)
 	// Group routes under '/api/sales' (assuming an /api prefix for tests)
 	salesGroup := app.Group("/api/sales", authMiddleware)
 	salesGroup.Get("/", handlers.GetSalesHandler)
@@ -204,6 +291,9 @@ func setupSaleTestApp(mockRepo *MockSaleRepository, t *testing.T) (*fiber.App, *
 	salesGroup.Get("/:id/items", handlers.GetSaleItemsHandler)
 	salesGroup.Post("/", handlers.CreateSaleHandler)
 	salesGroup.Put("/:id", handlers.UpdateSaleHandler)
+	salesGroup.Put("/:id/status", handlers.UpdateSaleStatusHandler)
+	salesGroup.Post("/:id/refund", handlers.RefundSaleHandler)
+	salesGroup.Get("/:id/refunds", handlers.GetSaleRefundsHandler)
 	salesGroup.Delete("/:id", handlers.DeleteSaleHandler)
 
 	app.Get("/api/customers/:id/sales", authMiddleware, handlers.GetCustomerSalesHandler)
@@ -220,7 +310,7 @@ func TestGetSalesHandler(t *testing.T) {
 
 	t.Run("success - no filters", func(t *testing.T) {
 		expectedSales := []models.Sale{{ID: "1", CustomerID: "cust1", SaleDate: "2023-01-01"}}
-		mockRepo.On("GetAll", map[string]interface{}{}).Return(expectedSales, nil).Once()
+		mockRepo.On("GetAll", models.SalesFilter{}).Return(expectedSales, nil).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/sales", nil)
 		resp, err := app.Test(req, -1) // -1 for no timeout
@@ -235,7 +325,7 @@ func TestGetSalesHandler(t *testing.T) {
 	})
 
 	t.Run("success - with filters", func(t *testing.T) {
-		filters := map[string]interface{}{"customer_id": "cust1", "date_from": "2023-01-01"}
+		filters := models.SalesFilter{CustomerID: "cust1", StartDate: "2023-01-01"}
 		expectedSales := []models.Sale{{ID: "1", CustomerID: "cust1", SaleDate: "2023-01-01"}}
 		mockRepo.On("GetAll", filters).Return(expectedSales, nil).Once()
 
@@ -251,8 +341,17 @@ func TestGetSalesHandler(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("failure - invalid date filters", func(t *testing.T) {
+		for _, query := range []string{"date_from=01/02/2023", "date_from=2023-02-01&date_to=2023-01-01"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/sales?"+query, nil)
+			resp, err := app.Test(req, -1)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+		}
+	})
+
 	t.Run("failure - repository error", func(t *testing.T) {
-		mockRepo.On("GetAll", map[string]interface{}{}).Return(nil, errors.New("db error")).Once()
+		mockRepo.On("GetAll", models.SalesFilter{}).Return(nil, errors.New("db error")).Once()
 
 		req := httptest.NewRequest(http.MethodGet, "/api/sales", nil)
 		resp, err := app.Test(req, -1)
@@ -265,6 +364,42 @@ func TestGetSalesHandler(t *testing.T) {
 		assert.Equal(t, "Failed to retrieve sales", errResp["error"])
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("success - paginated", func(t *testing.T) {
+		filters := models.SalesFilter{SoldBy: "user1"}
+		pageSales := []models.Sale{{ID: "3", SoldBy: "user1"}, {ID: "4", SoldBy: "user1"}}
+		mockRepo.On("GetPaginated", 2, 2, filters).Return(pageSales, int64(5), nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sales?sold_by=user1&page=2&limit=2", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var page api.SalePageResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		assert.NoError(t, err)
+		assert.Equal(t, pageSales, page.Data)
+		assert.Equal(t, 2, page.Page)
+		assert.Equal(t, int64(5), page.Total)
+		assert.Equal(t, int64(3), page.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("success - paginated with default page and capped limit", func(t *testing.T) {
+		mockRepo.On("GetPaginated", 1, maxPageLimit, models.SalesFilter{}).Return([]models.Sale{}, int64(0), nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sales?limit=1000", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var page api.SalePageResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		assert.NoError(t, err)
+		assert.Empty(t, page.Data)
+		assert.Equal(t, int64(0), page.TotalPages)
+		mockRepo.AssertExpectations(t)
+	})
 }
 
 // TestGetSaleByIDHandler
@@ -332,7 +467,7 @@ func TestGetSaleItemsHandler(t *testing.T) {
 
 	t.Run("success", func(t *testing.T) {
 		existingSale := &models.Sale{ID: saleID, SaleDate: "2023-01-01"}
-		expectedItems := []models.SaleItem{{ID: "item1", SaleID: saleID, MultiCabID: "prod1", Quantity: 1, UnitPrice: 10.0}}
+		expectedItems := []models.SaleItem{{ID: "item1", SaleID: saleID, Item: models.IntRef(models.RefCab, 1), Quantity: 1, UnitPrice: 10.0}}
 
 		mockRepo.On("GetByID", saleID).Return(existingSale, nil).Once()
 		mockRepo.On("GetSaleItems", saleID).Return(expectedItems, nil).Once()
@@ -349,6 +484,35 @@ func TestGetSaleItemsHandler(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("items keep the legacy fields", func(t *testing.T) {
+		existingSale := &models.Sale{ID: saleID, SaleDate: "2023-01-01"}
+		expectedItems := []models.SaleItem{{ID: "item1", SaleID: saleID, Item: models.IntRef(models.RefAccessory, 7), Quantity: 2}}
+
+		mockRepo.On("GetByID", saleID).Return(existingSale, nil).Once()
+		mockRepo.On("GetSaleItems", saleID).Return(expectedItems, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/sales/"+saleID+"/items", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var items []map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&items)
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, "accessory:7", items[0]["itemRef"])
+		assert.Equal(t, "accessory", items[0]["ItemType"])
+		assert.Equal(t, "7", items[0]["AccessoryID"])
+		assert.Equal(t, "", items[0]["MultiCabID"])
+
+		// Items sent without an itemRef are read from the per-type fields
+		var legacy models.SaleItem
+		assert.NoError(t, json.Unmarshal([]byte(`{"ItemType":"material","MaterialID":"3","Quantity":4}`), &legacy))
+		assert.Equal(t, models.IntRef(models.RefMaterial, 3), legacy.Item)
+		assert.Equal(t, 4, legacy.Quantity)
+		mockRepo.AssertExpectations(t)
+	})
+
 	t.Run("sale not found", func(t *testing.T) {
 		mockRepo.On("GetByID", saleID).Return(nil, nil).Once() // Sale not found
 
@@ -417,6 +581,8 @@ func TestCreateSaleHandler(t *testing.T) {
 				s.SoldBy == saleInput.SoldBy &&
 				s.SaleDate == saleInput.SaleDate &&
 				s.TotalPrice == saleInput.TotalPrice &&
+				s.TaxType == models.TaxVatable &&
+				s.VATAmount == 10.71 &&
 				!s.CreatedAt.IsZero() &&
 				!s.UpdatedAt.IsZero()
 		})).Return(expectedSaleID, nil).Once()
@@ -466,6 +632,16 @@ func TestCreateSaleHandler(t *testing.T) {
 		assert.Equal(t, "Missing required sale fields", errResp["error"])
 	})
 
+	t.Run("invalid payload - unknown tax type", func(t *testing.T) {
+		saleInput := models.Sale{CustomerID: "cust1", SoldBy: "user1", SaleDate: "2023-01-01", TotalPrice: 100.0, TaxType: "vat"}
+		payload, _ := json.Marshal(saleInput)
+		req := httptest.NewRequest(http.MethodPost, "/api/sales", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("repository error", func(t *testing.T) {
 		saleInput := models.Sale{
 			CustomerID: "cust1",
@@ -605,6 +781,192 @@ func TestUpdateSaleHandler(t *testing.T) {
 		assert.Equal(t, "Failed to update sale", errResp["error"])
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("completed sale", func(t *testing.T) {
+		existingSale := &models.Sale{ID: saleID, CustomerID: "oldCust", Status: models.SaleCompleted, CreatedAt: originalCreatedAt}
+		mockRepo.On("GetByID", saleID).Return(existingSale, nil).Once()
+
+		payload, _ := json.Marshal(models.Sale{CustomerID: "newCust", SoldBy: "newUser", SaleDate: "2023-02-02", TotalPrice: 200.0})
+		req := httptest.NewRequest(http.MethodPut, "/api/sales/"+saleID, bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var errResp map[string]interface{}
+		err = json.NewDecoder(resp.Body).Decode(&errResp)
+		assert.NoError(t, err)
+		assert.Equal(t, "A completed sale cannot be edited", errResp["error"])
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+// TestUpdateSaleStatusHandler
+func TestUpdateSaleStatusHandler(t *testing.T) {
+	t.Parallel()
+	mockRepo := new(MockSaleRepository)
+	app, _ := setupSaleTestApp(mockRepo, t)
+	saleID := "saleToMove"
+
+	putStatus := func(status string) *http.Response {
+		payload, _ := json.Marshal(SaleStatusRequest{Status: status})
+		req := httptest.NewRequest(http.MethodPut, "/api/sales/"+saleID+"/status", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.On("GetByID", saleID).Return(&models.Sale{ID: saleID, Status: models.SaleConfirmed}, nil).Once()
+		mockRepo.On("SetStatus", saleID, models.SaleCancelled).Return(nil).Once()
+
+		resp := putStatus(models.SaleCancelled)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var sale models.Sale
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&sale))
+		assert.Equal(t, models.SaleCancelled, sale.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		resp := putStatus("shipped")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("transition not allowed", func(t *testing.T) {
+		mockRepo.On("GetByID", saleID).Return(&models.Sale{ID: saleID, Status: models.SaleRefunded}, nil).Once()
+
+		resp := putStatus(models.SaleConfirmed)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var errResp api.ErrorResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, "A refunded sale cannot be moved to confirmed", errResp.Error)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("moved by another request first", func(t *testing.T) {
+		mockRepo.On("GetByID", saleID).Return(&models.Sale{ID: saleID}, nil).Once()
+		mockRepo.On("SetStatus", saleID, models.SaleCompleted).Return(repositories.ErrSaleTransition).Once()
+
+		resp := putStatus(models.SaleCompleted)
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("sale not found", func(t *testing.T) {
+		mockRepo.On("GetByID", saleID).Return(nil, fmt.Errorf("sale with ID %s not found", saleID)).Once()
+
+		resp := putStatus(models.SaleCompleted)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestRefundSaleHandler(t *testing.T) {
+	t.Parallel()
+	mockRepo := new(MockSaleRepository)
+	app, _ := setupSaleTestApp(mockRepo, t)
+	saleID := "saleToRefund"
+
+	postRefund := func(body interface{}) *http.Response {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/sales/"+saleID+"/refund", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.On("Refund", mock.MatchedBy(func(refund *models.SaleRefund) bool {
+			return refund.SaleID == saleID && refund.RefundedBy == "test_user_id" && refund.Reason == "Damaged" &&
+				len(refund.Items) == 1 && refund.Items[0] == models.SaleRefundItem{SaleItemID: "item_1", Quantity: 2}
+		})).Run(func(args mock.Arguments) {
+			refund := args.Get(0).(*models.SaleRefund)
+			refund.ID = "refund_1"
+			refund.Items[0].Amount = 200
+			refund.Amount = 200
+		}).Return(nil).Once()
+		mockRepo.On("GetByID", saleID).Return(&models.Sale{ID: saleID, Status: models.SaleCompleted}, nil).Once()
+
+		resp := postRefund(api.SaleRefundRequest{
+			Items:  []api.SaleRefundItemRequest{{SaleItemID: "item_1", Quantity: 2}},
+			Reason: "  Damaged ",
+		})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		var body api.SaleRefundResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "refund_1", body.Refund.ID)
+		assert.Equal(t, 200.0, body.Refund.Amount)
+		assert.Equal(t, models.SaleCompleted, body.Sale.Status)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid items", func(t *testing.T) {
+		for _, items := range [][]api.SaleRefundItemRequest{
+			{{SaleItemID: "item_1", Quantity: 0}},
+			{{Quantity: 1}},
+			{{SaleItemID: "item_1", Quantity: 1}, {SaleItemID: "item_1", Quantity: 1}},
+		} {
+			resp := postRefund(api.SaleRefundRequest{Items: items})
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("reason too long", func(t *testing.T) {
+		resp := postRefund(api.SaleRefundRequest{Reason: strings.Repeat("a", maxRefundReasonLength+1)})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			err    error
+			status int
+		}{
+			{"sale not found", fmt.Errorf("sale with ID %s not found: %w", saleID, sql.ErrNoRows), http.StatusNotFound},
+			{"sale not completed", repositories.ErrSaleTransition, http.StatusConflict},
+			{"more than is left", repositories.ErrRefundQuantity, http.StatusConflict},
+			{"database error", errors.New("db down"), http.StatusInternalServerError},
+		}
+		for _, tt := range tests {
+			mockRepo.On("Refund", mock.Anything).Return(tt.err).Once()
+			resp := postRefund(api.SaleRefundRequest{})
+			assert.Equal(t, tt.status, resp.StatusCode, tt.name)
+		}
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestGetSaleRefundsHandler(t *testing.T) {
+	t.Parallel()
+	mockRepo := new(MockSaleRepository)
+	app, _ := setupSaleTestApp(mockRepo, t)
+	saleID := "refundedSale"
+
+	t.Run("success", func(t *testing.T) {
+		refunds := []models.SaleRefund{{ID: "refund_1", SaleID: saleID, Amount: 100}}
+		mockRepo.On("GetByID", saleID).Return(&models.Sale{ID: saleID}, nil).Once()
+		mockRepo.On("GetRefunds", saleID).Return(refunds, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/sales/"+saleID+"/refunds", nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var body []models.SaleRefund
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, refunds, body)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("sale not found", func(t *testing.T) {
+		mockRepo.On("GetByID", saleID).Return(nil, fmt.Errorf("sale with ID %s not found", saleID)).Once()
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/sales/"+saleID+"/refunds", nil), -1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		mockRepo.AssertExpectations(t)
+	})
 }
 
 // TestDeleteSaleHandler
@@ -686,22 +1048,10 @@ func TestSellCabHandler(t *testing.T) {
 		expectedSaleID := "newSaleFromCab"
 		expectedSaleDate := time.Now().Format("2006-01-02")
 
-		// Mock the sale repository Create method
-		mockRepo.On("Create", mock.MatchedBy(func(s *models.Sale) bool {
-			return s.CustomerID == salePayload.CustomerID &&
-				s.SoldBy == "test_user_id" && // From mock middleware
-				s.SaleDate == expectedSaleDate &&
-				!s.CreatedAt.IsZero() &&
-				!s.UpdatedAt.IsZero()
-		})).Return(expectedSaleID, nil).Once()
-
-		// Mock the sale repository CreateSaleItem method for the cab
-		mockRepo.On("CreateSaleItem", mock.AnythingOfType("*models.SaleItem")).Return("item1", nil).Once()
-
-		// Mock the sale repository CreateSaleItem method for each accessory
-		for range salePayload.Accessories {
-			mockRepo.On("CreateSaleItem", mock.AnythingOfType("*models.SaleItem")).Return("item2", nil).Once()
-		}
+		// Mock the sale repository SellCab method; the accessories are sold at their stored prices
+		mockRepo.On("SellCab", cabID, salePayload.CustomerID, 1, "test_user_id", "", mock.MatchedBy(func(accessories []models.AccessoryForSale) bool {
+			return len(accessories) == 2 && accessories[0].Price == 10.0 && accessories[1].Price == 20.0
+		})).Return(&models.Sale{ID: expectedSaleID, SaleDate: expectedSaleDate, TotalPrice: 5030.0}, nil).Once()
 
 		// Mock the cab repository GetCabByID method
 		mockCabRepo := handlers.CabRepo.(*MockCabsRepositoryForSales)
@@ -748,6 +1098,7 @@ func TestSellCabHandler(t *testing.T) {
 		assert.Equal(t, float64(salePayload.Quantity), respBody["quantity"])
 		assert.Equal(t, expectedSaleID, respBody["saleId"])
 		assert.Equal(t, expectedSaleDate, respBody["saleDate"])
+		assert.Equal(t, 5030.0, respBody["totalPrice"])
 
 		// Check accessories are returned in the response
 		respAccessories, ok := respBody["accessories"].([]interface{})
@@ -850,8 +1201,8 @@ func TestSellCabHandler(t *testing.T) {
 			Price: 5000.0,
 		}, nil).Once()
 		
-		// Mock the sale repository Create method to return an error
-		mockRepoLocal.On("Create", mock.AnythingOfType("*models.Sale")).Return("", errors.New("db error creating sale")).Once()
+		// Mock the sale repository SellCab method to return an error
+		mockRepoLocal.On("SellCab", cabID, "cust123", 1, "test_user_id", "", mock.Anything).Return(nil, errors.New("db error creating sale")).Once()
 
 		payload, _ := json.Marshal(salePayload)
 		req := httptest.NewRequest(http.MethodPost, "/api/cabs/"+strconv.Itoa(cabID)+"/sell", bytes.NewBuffer(payload))
@@ -870,6 +1221,28 @@ func TestSellCabHandler(t *testing.T) {
 		assert.Equal(t, "Failed to create sale", errResp["error"])
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("insufficient stock", func(t *testing.T) {
+		mockRepoLocal := new(MockSaleRepository)
+		appLocal, handlersLocal := setupSaleTestApp(mockRepoLocal, t)
+
+		mockCabRepoLocal := handlersLocal.CabRepo.(*MockCabsRepositoryForSales)
+		mockCabRepoLocal.On("GetCabByID", cabID).Return(&models.MultiCab{ID: cabID, Name: "Test Cab", Price: 5000.0, Quantity: 1}, nil).Once()
+		mockRepoLocal.On("SellCab", cabID, "cust123", 2, "test_user_id", "", mock.Anything).
+			Return(nil, fmt.Errorf("cab %d has fewer than 2 in stock: %w", cabID, repositories.ErrNegativeStock)).Once()
+
+		payload, _ := json.Marshal(models.CabSalePayload{CustomerID: "cust123", Quantity: 2})
+		req := httptest.NewRequest(http.MethodPost, "/api/cabs/"+strconv.Itoa(cabID)+"/sell", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := appLocal.Test(req, -1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var errResp map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		assert.Equal(t, "Requested quantity exceeds available stock", errResp["error"])
+		mockRepoLocal.AssertExpectations(t)
+	})
 }
 
 // TestGetCustomerSalesHandler
@@ -913,6 +1286,38 @@ func TestGetCustomerSalesHandler(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("success - with items", func(t *testing.T) {
+		mockRepo.On("GetCustomerSales", customerID).Return([]models.Sale{
+			{ID: "sale1", CustomerID: customerID, SaleDate: "2023-01-01"},
+			{ID: "sale2", CustomerID: customerID, SaleDate: "2023-01-02"},
+		}, nil).Once()
+		mockRepo.On("GetCustomerSaleItems", customerID).Return([]models.SaleItem{
+			{ID: "item1", SaleID: "sale1", Item: models.IntRef(models.RefCab, 3), Quantity: 1, Subtotal: 500, Name: "Carry"},
+			{ID: "item2", SaleID: "sale1", Item: models.IntRef(models.RefAccessory, 7), Quantity: 2, Subtotal: 40, Name: "Roof Rack"},
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/customers/"+customerID+"/sales?include=items", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var sales []models.Sale
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&sales))
+		assert.Len(t, sales, 2)
+		assert.Len(t, sales[0].Items, 2)
+		assert.Equal(t, "Carry", sales[0].Items[0].Name)
+		assert.Equal(t, "Roof Rack", sales[0].Items[1].Name)
+		assert.Empty(t, sales[1].Items)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown include", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/customers/"+customerID+"/sales?include=payments", nil)
+		resp, err := app.Test(req, -1)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
 	t.Run("repository error", func(t *testing.T) {
 		mockRepo.On("GetCustomerSales", customerID).Return(nil, errors.New("db error")).Once()
 
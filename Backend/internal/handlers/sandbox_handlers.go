@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sandboxSessionTTL is how long a token issued to enter the training sandbox stays valid
+const sandboxSessionTTL = 8 * time.Hour
+
+// SandboxHandler lets users practice in their tenant's training sandbox, which is
+// seeded with the fixture data and kept apart from the tenant's records. Users enter
+// it with a sandbox session token; admins reset it to the fixtures, from the tenant or
+// from inside the sandbox.
+type SandboxHandler struct {
+	Users     UserRepository
+	Sandboxes *services.Sandboxes
+	Audit     *ChangeRecorder
+	jwtSecret []byte
+}
+
+// NewSandboxHandler creates a new SandboxHandler instance
+func NewSandboxHandler(users UserRepository, sandboxes *services.Sandboxes, jwtSecret []byte) *SandboxHandler {
+	return &SandboxHandler{Users: users, Sandboxes: sandboxes, jwtSecret: jwtSecret}
+}
+
+// Routes registers the sandbox routes. Super admins can switch entering the sandbox
+// off per tenant.
+func (h *SandboxHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/sandbox", mw.Auth, h.GetSandbox)                                        // GET /api/sandbox
+	r.Post("/sandbox/session", mw.Feature(FeatureSandbox), mw.Auth, h.EnterSandbox) // POST /api/sandbox/session
+	r.Post("/sandbox/reset", mw.Auth, mw.Admin, h.ResetSandbox)                     // POST /api/sandbox/reset
+}
+
+// sandboxOwner returns the tenant whose sandbox the request concerns, and whether the
+// request is made from inside that sandbox
+func sandboxOwner(c *fiber.Ctx) (string, bool) {
+	tenantID := tenantIDFromCtx(c)
+	if parentID, ok := models.SandboxParent(tenantID); ok {
+		return parentID, true
+	}
+	return tenantID, false
+}
+
+// GetSandbox handles describing the training sandbox
+// @Summary Get the training sandbox
+// @Description Returns the tenant ID of the caller's training sandbox, whether the caller is in it, and when it was last seeded. Sandboxes are kept in memory per server instance and seeded on first use.
+// @Tags Sandbox
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.SandboxStatusResponse "Sandbox status"
+// @Router /sandbox [get]
+func (h *SandboxHandler) GetSandbox(c *fiber.Ctx) error {
+	tenantID, inSandbox := sandboxOwner(c)
+	response := api.SandboxStatusResponse{TenantID: models.SandboxTenantID(tenantID), InSandbox: inSandbox}
+	if seededAt, ok := h.Sandboxes.SeededAt(tenantID); ok {
+		response.SeededAt = &seededAt
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// EnterSandbox handles issuing a sandbox session token
+// @Summary Enter the training sandbox
+// @Description Gives the caller an account in their tenant's training sandbox, with the same ID and role, and returns a token for it. Requests made with the token read and change only the sandbox's data, which starts as the demo fixtures. The token lasts 8 hours and does not replace the caller's session.
+// @Tags Sandbox
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} api.SandboxSessionResponse "Sandbox session"
+// @Failure 400 {object} api.ErrorResponse "Already in the sandbox"
+// @Failure 403 {object} api.ErrorResponse "Account is deactivated"
+// @Failure 404 {object} api.ErrorResponse "User not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to enter the sandbox"
+// @Router /sandbox/session [post]
+func (h *SandboxHandler) EnterSandbox(c *fiber.Ctx) error {
+	tenantID, inSandbox := sandboxOwner(c)
+	if inSandbox {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Already in the sandbox", StatusCode: fiber.StatusBadRequest})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	user, err := h.Users.GetByID(c.Context(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "User not found", StatusCode: fiber.StatusNotFound})
+	}
+	if !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Account is deactivated", StatusCode: fiber.StatusForbidden})
+	}
+
+	if err := h.Sandboxes.Enter(tenantID, user); err != nil {
+		log.Printf("Error entering sandbox of tenant %s for user %s: %v", tenantID, user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to enter the sandbox", StatusCode: fiber.StatusInternalServerError})
+	}
+	sandboxID := models.SandboxTenantID(tenantID)
+	token, err := generateJWT(user, sandboxID, h.jwtSecret, sandboxSessionTTL)
+	if err != nil {
+		log.Printf("Error generating sandbox token for user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to enter the sandbox", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "ENTER_SANDBOX", AuditEntityTenant, strings.Clone(tenantID), "Entered the training sandbox")
+	return c.Status(fiber.StatusCreated).JSON(api.SandboxSessionResponse{
+		Message:   "Entered the training sandbox",
+		TenantID:  sandboxID,
+		Token:     token,
+		ExpiresAt: time.Now().Add(sandboxSessionTTL),
+	})
+}
+
+// ResetSandbox handles reseeding the training sandbox
+// @Summary Reset the training sandbox (Admin)
+// @Description Replaces every record in the tenant's training sandbox with the demo fixtures. Users who entered the sandbox keep their accounts and sessions. Works from the tenant or from inside the sandbox.
+// @Tags Sandbox
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.SandboxResetResponse "Sandbox reset"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to reset the sandbox"
+// @Router /sandbox/reset [post]
+func (h *SandboxHandler) ResetSandbox(c *fiber.Ctx) error {
+	tenantID, _ := sandboxOwner(c)
+	seededAt, err := h.Sandboxes.Reset(tenantID)
+	if err != nil {
+		log.Printf("Error resetting sandbox of tenant %s: %v", tenantID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to reset the sandbox", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "RESET_SANDBOX", AuditEntityTenant, strings.Clone(tenantID),
+		fmt.Sprintf("Reset the training sandbox to the fixtures at %s", seededAt.Format(time.RFC3339)))
+	return c.Status(fiber.StatusOK).JSON(api.SandboxResetResponse{
+		Message:  "Sandbox reset",
+		TenantID: models.SandboxTenantID(tenantID),
+		SeededAt: seededAt,
+	})
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSandboxTestApp serves the default tenant from an empty in-memory store with an
+// admin and a staff user, and its sandbox from the fixtures, the way main does: the
+// tenant is resolved from the token and each tenant has its own app. Besides the
+// sandbox routes, every app can list and add customers.
+func setupSandboxTestApp(t *testing.T) (*fiber.App, *memory.Store, *services.Sandboxes, *models.User, *models.User) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	admin := &models.User{Username: "owner", Email: "owner@example.com", Password: "password123", Role: RoleAdmin}
+	require.NoError(t, store.Users.Create(context.Background(), admin))
+	staff := &models.User{Username: "trainee", Email: "staff@example.com", Password: "password123", Role: RoleStaff}
+	require.NoError(t, store.Users.Create(context.Background(), staff))
+
+	sandboxes := services.NewSandboxes(memory.NewTenantRepository())
+	apps := middleware.NewTenantApps(func(tenantID string) (*fiber.App, error) {
+		tenantStore := store
+		if parentID, ok := models.SandboxParent(tenantID); ok {
+			var err error
+			if tenantStore, err = sandboxes.Store(parentID); err != nil {
+				return nil, err
+			}
+		}
+
+		handler := NewSandboxHandler(tenantStore.Users, sandboxes, jwtSecret)
+		handler.Audit = NewChangeRecorder(tenantStore.Logs)
+		app := fiber.New()
+		apiGroup := app.Group("/api")
+		handler.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+		apiGroup.Get("/customers", middleware.JWTMiddleware(jwtSecret), func(c *fiber.Ctx) error {
+			customers, err := tenantStore.Customers.GetAllCustomers(c.Context())
+			if err != nil {
+				return err
+			}
+			return c.JSON(fiber.Map{"count": len(customers)})
+		})
+		apiGroup.Post("/customers", middleware.JWTMiddleware(jwtSecret), func(c *fiber.Ctx) error {
+			customer, err := tenantStore.Customers.CreateCustomer(c.Context(), &models.Customer{FullName: "Practice Customer", Email: "practice@example.com"})
+			if err != nil {
+				return err
+			}
+			return c.Status(fiber.StatusCreated).JSON(customer)
+		})
+		return app, nil
+	})
+	sandboxes.OnReset = apps.Forget
+
+	app := fiber.New()
+	app.Use("/api", middleware.TenantResolver(sandboxes, config.TenancyConfig{}, jwtSecret), apps.Handler())
+	return app, store, sandboxes, admin, staff
+}
+
+// countCustomers lists the customers of the tenant of token
+func countCustomers(t *testing.T, app *fiber.App, token string) int {
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/customers", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Count int `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body.Count
+}
+
+func TestEnterSandbox(t *testing.T) {
+	app, store, sandboxes, _, staff := setupSandboxTestApp(t)
+	staffToken := createTenantTestToken([]byte("testsecret"), staff.Id, RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/sandbox", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var status api.SandboxStatusResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.Equal(t, "sandbox-default", status.TenantID)
+	assert.False(t, status.InSandbox)
+	assert.Nil(t, status.SeededAt)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/sandbox/session", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var session api.SandboxSessionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&session))
+	assert.Equal(t, "sandbox-default", session.TenantID)
+	require.NotEmpty(t, session.Token)
+
+	// The sandbox starts with the fixtures, and practice stays out of the real data
+	assert.Equal(t, 3, countCustomers(t, app, session.Token))
+	resp = authedRequest(t, app, session.Token, http.MethodPost, "/api/customers", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, 4, countCustomers(t, app, session.Token))
+	assert.Equal(t, 0, countCustomers(t, app, staffToken))
+
+	sandboxStore, err := sandboxes.Store(models.DefaultTenantID)
+	require.NoError(t, err)
+	mirrored, err := sandboxStore.Users.GetByID(context.Background(), staff.Id)
+	require.NoError(t, err)
+	assert.Equal(t, RoleStaff, mirrored.Role)
+	assert.Equal(t, staff.Id+"@sandbox.invalid", mirrored.Email, "the fixture staff account has the same email")
+
+	resp = authedRequest(t, app, session.Token, http.MethodGet, "/api/sandbox", nil)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&status))
+	assert.True(t, status.InSandbox)
+	assert.NotNil(t, status.SeededAt)
+
+	resp = authedRequest(t, app, session.Token, http.MethodPost, "/api/sandbox/session", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "ENTER_SANDBOX", logs[0].Action)
+
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), staff.Id))
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/sandbox/session", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestResetSandbox(t *testing.T) {
+	app, store, _, admin, staff := setupSandboxTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), admin.Id, RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken([]byte("testsecret"), staff.Id, RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/sandbox/session", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var session api.SandboxSessionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&session))
+	resp = authedRequest(t, app, session.Token, http.MethodPost, "/api/customers", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.Equal(t, 4, countCustomers(t, app, session.Token))
+
+	resp = authedRequest(t, app, session.Token, http.MethodPost, "/api/sandbox/reset", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/sandbox/reset", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var reset api.SandboxResetResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&reset))
+	assert.Equal(t, "sandbox-default", reset.TenantID)
+	assert.False(t, reset.SeededAt.IsZero())
+
+	// The trainee's session survives the reset and sees the fixtures again
+	assert.Equal(t, 3, countCustomers(t, app, session.Token))
+	assert.Equal(t, 0, countCustomers(t, app, staffToken))
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, "RESET_SANDBOX", logs[0].Action)
+
+	// Admins can reset from inside the sandbox too
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/sandbox/session", nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var adminSession api.SandboxSessionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&adminSession))
+	resp = authedRequest(t, app, adminSession.Token, http.MethodPost, "/api/sandbox/reset", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits of the global search
+const (
+	defaultSearchLimit = 5 // Matches listed per type of record
+	maxSearchLimit     = 20
+	maxSearchLength    = 100 // Characters of the search term
+)
+
+// Types of record the global search covers, in the order they are searched
+const (
+	SearchCabs        = "cabs"
+	SearchAccessories = "accessories"
+	SearchMaterials   = "materials"
+	SearchCustomers   = "customers"
+)
+
+var searchTypes = []string{SearchCabs, SearchAccessories, SearchMaterials, SearchCustomers}
+
+// SearchHandler searches cabs, accessories, materials and customers at once, for the
+// omnibox of the frontend. Each type is matched the way its own listing's search is.
+type SearchHandler struct {
+	Cabs        repositories.CabsRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Customers   repositories.CustomerRepository
+	Perms       *Permissions    // Optional; without it customers' contact details are masked for staff
+	Numbers     *DisplayNumbers // Optional; adds the display numbers of cabs and customers
+	jwtSecret   []byte
+}
+
+// NewSearchHandler creates a new SearchHandler instance
+func NewSearchHandler(cabs repositories.CabsRepository, accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, customers repositories.CustomerRepository, jwtSecret []byte) *SearchHandler {
+	return &SearchHandler{Cabs: cabs, Accessories: accessories, Materials: materials, Customers: customers, jwtSecret: jwtSecret}
+}
+
+// Routes registers the search route
+func (h *SearchHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/search", mw.Auth, mw.Staff, h.Search) // GET /api/search
+}
+
+// Search handles searching every type of record at once
+// @Summary Search cabs, accessories, materials and customers
+// @Description Searches every type of record with one term, returning the first matches of each type with how many match in all. Cabs match by name or make, accessories by ID or name, materials by ID, name, category or supplier, and customers by name, email or phone, regardless of case. Customers' contact details are masked for callers without the customers.pii permission.
+// @Tags Search
+// @Produce json
+// @Security ApiKeyAuth
+// @Param q query string true "Search term, at most 100 characters"
+// @Param limit query int false "Matches listed per type (default 5, max 20)"
+// @Param types query string false "Comma-separated types to search: cabs, accessories, materials, customers (default all)"
+// @Success 200 {object} api.SearchResponse "Matches by type"
+// @Failure 400 {object} api.ErrorResponse "Missing or too long search term, invalid limit or unknown type"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Forbidden"
+// @Failure 500 {object} api.ErrorResponse "Failed to search"
+// @Router /search [get]
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "q is required", StatusCode: fiber.StatusBadRequest})
+	}
+	if utf8.RuneCountInString(query) > maxSearchLength {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "q must be at most 100 characters", StatusCode: fiber.StatusBadRequest})
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "limit must be between 1 and 20", StatusCode: fiber.StatusBadRequest})
+		}
+		limit = parsed
+	}
+
+	types := searchTypes
+	if raw := c.Query("types"); raw != "" {
+		types = nil
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(searchTypes, t) {
+				return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "types must be cabs, accessories, materials or customers", StatusCode: fiber.StatusBadRequest})
+			}
+			types = append(types, t)
+		}
+	}
+
+	response := api.SearchResponse{Query: query}
+	for _, t := range types {
+		if err := h.searchType(c, t, query, limit, &response); err != nil {
+			log.Printf("Error searching %s for %q: %v", t, query, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to search", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// searchType fills in the group of one type of record of the response with the first
+// limit matches of query and their total
+func (h *SearchHandler) searchType(c *fiber.Ctx, searchType, query string, limit int, response *api.SearchResponse) error {
+	switch searchType {
+	case SearchCabs:
+		filter := models.CabFilter{Search: query}
+		total, err := h.Cabs.CountCabs(c.Context(), filter)
+		if err != nil {
+			return err
+		}
+		filter.Limit = limit
+		cabs, err := h.Cabs.GetCabs(c.Context(), filter)
+		if err != nil {
+			return err
+		}
+		h.Numbers.numberCabs(cabs)
+		response.Cabs = &api.CabSearchGroup{Items: nonNil(cabs), Total: total}
+	case SearchAccessories:
+		accessories, total, err := h.Accessories.GetPaginated(c.Context(), 1, limit, models.AccessoryFilter{Search: query})
+		if err != nil {
+			return err
+		}
+		response.Accessories = &api.AccessorySearchGroup{Items: nonNil(accessories), Total: total}
+	case SearchMaterials:
+		materials, total, err := h.Materials.GetPaginated(c.Context(), 1, limit, models.MaterialFilter{Search: query})
+		if err != nil {
+			return err
+		}
+		response.Materials = &api.MaterialSearchGroup{Items: nonNil(materials), Total: total}
+	case SearchCustomers:
+		customers, total, err := h.Customers.GetPaginated(c.Context(), 1, limit, query)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, len(customers))
+		for i, customer := range customers {
+			ids[i] = customer.ID
+		}
+		numbers := h.Numbers.Numbers(models.NumberedCustomer, ids)
+		items := make([]*api.CustomerResponse, len(customers))
+		for i, customer := range customers {
+			items[i] = h.Perms.MaskCustomer(c, toCustomerResponse(customer))
+			items[i].DisplayNumber = numbers[customer.ID]
+		}
+		response.Customers = &api.CustomerSearchGroup{Items: items, Total: total}
+	}
+	return nil
+}
+
+// nonNil returns items, or an empty list when there are none, so groups without
+// matches are listed as [] rather than null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
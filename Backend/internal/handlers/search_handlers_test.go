@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSearchTestApp registers the search route on an in-memory store holding two
+// Carry cabs, a Carry roof rack, a paint material and a customer named Carrie
+func setupSearchTestApp(t *testing.T) (*fiber.App, string, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	ctx := context.Background()
+	for _, name := range []string{"Carry Truck", "Carry Van"} {
+		_, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: name, Make: "Suzuki", UnitColor: "White", Quantity: 1, Price: 500})
+		require.NoError(t, err)
+	}
+	_, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Carry roof rack", Make: models.MakeGeneric, Quantity: 2, Price: 100, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+	_, err = store.Materials.Create(ctx, &models.Material{Name: "Paint", Category: "Finishing", Supplier: "Boysen", Quantity: 3})
+	require.NoError(t, err)
+	_, err = store.Customers.CreateCustomer(ctx, &models.Customer{FullName: "Carrie Santos", Email: "carrie@example.com", Phone: "+639171234567"})
+	require.NoError(t, err)
+
+	app := fiber.New()
+	NewSearchHandler(store.Cabs, store.Accessories, store.Materials, store.Customers, jwtSecret).Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID), createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+}
+
+func TestSearch(t *testing.T) {
+	app, staffToken, adminToken := setupSearchTestApp(t)
+
+	search := func(token, query string) (int, api.SearchResponse) {
+		resp := authedRequest(t, app, token, http.MethodGet, "/api/search"+query, nil)
+		var body api.SearchResponse
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp.StatusCode, body
+	}
+
+	t.Run("Matches every type", func(t *testing.T) {
+		status, body := search(adminToken, "?q=carr")
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "carr", body.Query)
+		require.NotNil(t, body.Cabs)
+		assert.Len(t, body.Cabs.Items, 2)
+		assert.Equal(t, int64(2), body.Cabs.Total)
+		require.NotNil(t, body.Accessories)
+		assert.Equal(t, int64(1), body.Accessories.Total)
+		require.NotNil(t, body.Materials)
+		assert.Empty(t, body.Materials.Items)
+		assert.Equal(t, int64(0), body.Materials.Total)
+		require.NotNil(t, body.Customers)
+		require.Len(t, body.Customers.Items, 1)
+		assert.Equal(t, "carrie@example.com", body.Customers.Items[0].Email)
+	})
+
+	t.Run("Limit per type keeps the total", func(t *testing.T) {
+		status, body := search(adminToken, "?q=carry&limit=1")
+		require.Equal(t, http.StatusOK, status)
+		assert.Len(t, body.Cabs.Items, 1)
+		assert.Equal(t, int64(2), body.Cabs.Total)
+	})
+
+	t.Run("Only the types asked for", func(t *testing.T) {
+		status, body := search(adminToken, "?q=paint&types=materials,%20customers")
+		require.Equal(t, http.StatusOK, status)
+		assert.Nil(t, body.Cabs)
+		assert.Nil(t, body.Accessories)
+		require.NotNil(t, body.Materials)
+		assert.Equal(t, "Paint", body.Materials.Items[0].Name)
+		require.NotNil(t, body.Customers)
+		assert.Empty(t, body.Customers.Items)
+	})
+
+	t.Run("Customer contact details masked for staff", func(t *testing.T) {
+		status, body := search(staffToken, "?q=carrie&types=customers")
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, body.Customers.Items, 1)
+		assert.NotEqual(t, "carrie@example.com", body.Customers.Items[0].Email)
+	})
+
+	for _, query := range []string{"", "?q=%20%20", "?q=carry&limit=0", "?q=carry&limit=21", "?q=carry&types=sales"} {
+		t.Run("Bad request "+query, func(t *testing.T) {
+			status, _ := search(adminToken, query)
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+	}
+
+	t.Run("Needs a token", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/api/search?q=carry", nil))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
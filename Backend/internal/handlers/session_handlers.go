@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// defaultAccessTTL is how long access tokens last when no session handler is configured
+const defaultAccessTTL = 72 * time.Hour
+
+// SessionHandler issues the refresh tokens that keep users signed in while their
+// access tokens stay short-lived. Each exchange revokes the token sent and returns a
+// new one; a revoked token coming back means it was stolen or replayed, so the whole
+// session is ended.
+type SessionHandler struct {
+	Tokens    repositories.RefreshTokenRepository
+	Users     UserRepository
+	Config    config.SessionConfig
+	Audit     *ChangeRecorder
+	Dormancy  *DormantAccountHandler // Optional; a refresh counts as a sign-in for the dormant account policy
+	Shifts    *ShiftHandler          // Optional; like sign-ins, refreshes are refused outside the user's shift
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewSessionHandler creates a new SessionHandler instance
+func NewSessionHandler(tokens repositories.RefreshTokenRepository, users UserRepository, cfg config.SessionConfig, jwtSecret []byte) *SessionHandler {
+	return &SessionHandler{Tokens: tokens, Users: users, Config: cfg, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the refresh and logout routes. Both are public, as the access
+// token may have expired, so the module must be registered before the users module.
+func (h *SessionHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/users/refresh", h.Refresh) // POST /api/users/refresh
+	r.Post("/users/logout", h.Logout)   // POST /api/users/logout
+}
+
+// AccessTTL is how long the access tokens issued on sign-in last
+func (h *SessionHandler) AccessTTL() time.Duration {
+	if h == nil {
+		return defaultAccessTTL
+	}
+	return h.Config.AccessTTL
+}
+
+// Start begins a session for a user who just signed in and returns its refresh
+// token. A nil handler returns no token, leaving the access token as the only one.
+func (h *SessionHandler) Start(user *models.User) (string, error) {
+	if h == nil {
+		return "", nil
+	}
+	refreshToken, token, err := h.newRefreshToken(user.Id, uuid.New().String())
+	if err != nil {
+		return "", err
+	}
+	if err := h.Tokens.Create(token); err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+// RevokeUser ends every session of a user, such as after their password changed. A
+// nil handler does nothing.
+func (h *SessionHandler) RevokeUser(userID string) {
+	if h == nil {
+		return
+	}
+	if err := h.Tokens.RevokeUser(userID, h.now()); err != nil {
+		log.Printf("Error revoking refresh tokens of user %s: %v", userID, err)
+	}
+}
+
+// newRefreshToken generates a refresh token of the session family; only its hash
+// is kept in the returned record
+func (h *SessionHandler) newRefreshToken(userID, familyID string) (string, *models.RefreshToken, error) {
+	refreshToken, err := generateToken()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	now := h.now()
+	return refreshToken, &models.RefreshToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashInviteToken(refreshToken),
+		ExpiresAt: now.Add(h.Config.RefreshTTL),
+		CreatedAt: now,
+	}, nil
+}
+
+// Refresh handles exchanging a refresh token for new tokens
+// @Summary Refresh the access token
+// @Description Exchanges a refresh token from login or an earlier refresh for a new access token and a new refresh token. The refresh token sent is revoked; sending it again ends the session. Sessions unused for longer than REFRESH_TOKEN_TTL_HOURS expire.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body api.TokenRefreshRequest true "Refresh token"
+// @Success 200 {object} api.TokenRefreshResponse "Tokens refreshed"
+// @Failure 400 {object} api.ErrorResponse "Refresh token is required"
+// @Failure 401 {object} api.ErrorResponse "Refresh token is invalid, expired or revoked"
+// @Failure 403 {object} api.ErrorResponse "Account is inactive or outside the user's shift"
+// @Failure 500 {object} api.ErrorResponse "Failed to refresh the session"
+// @Router /users/refresh [post]
+func (h *SessionHandler) Refresh(c *fiber.Ctx) error {
+	var input api.TokenRefreshRequest
+	if err := c.BodyParser(&input); err != nil || input.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Refresh token is required", StatusCode: fiber.StatusBadRequest})
+	}
+	invalid := api.ErrorResponse{Error: "Refresh token is invalid, expired or revoked", StatusCode: fiber.StatusUnauthorized}
+
+	current, err := h.Tokens.GetByTokenHash(hashInviteToken(input.RefreshToken))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusUnauthorized).JSON(invalid)
+		}
+		log.Printf("Error getting refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to refresh the session", StatusCode: fiber.StatusInternalServerError})
+	}
+	if current.RevokedAt != nil {
+		// Tokens revoked by a logout simply stop working; an exchanged one coming back was copied
+		if current.ReplacedBy != "" {
+			h.endReusedSession(c, current)
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(invalid)
+	}
+	if !h.now().Before(current.ExpiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(invalid)
+	}
+
+	user, err := h.Users.GetByID(c.Context(), current.UserID)
+	if err != nil {
+		log.Printf("Refresh rejected - user %s not found: %v", current.UserID, err)
+		return c.Status(fiber.StatusUnauthorized).JSON(invalid)
+	}
+	if !user.IsActive {
+		if err := h.Tokens.RevokeFamily(current.FamilyID, h.now()); err != nil {
+			log.Printf("Error revoking refresh tokens of inactive user %s: %v", user.Id, err)
+		}
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Account is inactive", StatusCode: fiber.StatusForbidden})
+	}
+	if err := h.Shifts.CheckLogin(user); err != nil {
+		if errors.Is(err, ErrOutsideShift) {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Sessions cannot be refreshed outside your scheduled shift", StatusCode: fiber.StatusForbidden})
+		}
+		log.Printf("Error checking shift of user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to refresh the session", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	refreshToken, next, err := h.newRefreshToken(user.Id, current.FamilyID)
+	if err != nil {
+		log.Printf("Error refreshing session of user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to refresh the session", StatusCode: fiber.StatusInternalServerError})
+	}
+	if err := h.Tokens.Rotate(current.ID, next, h.now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Another request exchanged the same token first
+			h.endReusedSession(c, current)
+			return c.Status(fiber.StatusUnauthorized).JSON(invalid)
+		}
+		log.Printf("Error rotating refresh token of user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to refresh the session", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	tokenString, err := generateJWT(user, tenantIDFromCtx(c), h.jwtSecret, h.Config.AccessTTL)
+	if err != nil {
+		log.Printf("Error signing JWT token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to refresh the session", StatusCode: fiber.StatusInternalServerError})
+	}
+	h.Dormancy.RecordLogin(user.Id)
+
+	return c.Status(fiber.StatusOK).JSON(api.TokenRefreshResponse{
+		Message:      "Session refreshed",
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.Config.AccessTTL.Seconds()),
+	})
+}
+
+// endReusedSession revokes the session of a refresh token that was already exchanged
+func (h *SessionHandler) endReusedSession(c *fiber.Ctx, token *models.RefreshToken) {
+	log.Printf("Revoked refresh token of user %s reused; ending session %s", token.UserID, token.FamilyID)
+	if err := h.Tokens.RevokeFamily(token.FamilyID, h.now()); err != nil {
+		log.Printf("Error revoking session %s: %v", token.FamilyID, err)
+	}
+	h.Audit.RecordAttempt(c, "REFRESH_TOKEN_REUSED", AuditEntityUser, token.UserID,
+		fmt.Sprintf("A revoked refresh token of user %s was reused; the session was ended", token.UserID), false)
+}
+
+// Logout handles ending a session
+// @Summary Log out
+// @Description Revokes the refresh token and every token rotated from the same sign-in, so the session can no longer be refreshed. Access tokens already issued stay valid until they expire. Unknown or revoked tokens are not an error.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body api.TokenRefreshRequest true "Refresh token"
+// @Success 200 {object} api.SuccessResponse "Logged out"
+// @Failure 400 {object} api.ErrorResponse "Refresh token is required"
+// @Failure 500 {object} api.ErrorResponse "Failed to log out"
+// @Router /users/logout [post]
+func (h *SessionHandler) Logout(c *fiber.Ctx) error {
+	var input api.TokenRefreshRequest
+	if err := c.BodyParser(&input); err != nil || input.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Refresh token is required", StatusCode: fiber.StatusBadRequest})
+	}
+
+	token, err := h.Tokens.GetByTokenHash(hashInviteToken(input.RefreshToken))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error getting refresh token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to log out", StatusCode: fiber.StatusInternalServerError})
+	}
+	if token != nil {
+		if err := h.Tokens.RevokeFamily(token.FamilyID, h.now()); err != nil {
+			log.Printf("Error revoking session %s: %v", token.FamilyID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to log out", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(api.SuccessResponse{Message: "Logged out"})
+}
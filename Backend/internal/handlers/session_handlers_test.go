@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupSessionTestApp registers the login, refresh and logout routes on an in-memory
+// store with a staff user
+func setupSessionTestApp(t *testing.T) (*fiber.App, *memory.Store, *SessionHandler, *models.User) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	staff := &models.User{Username: "clerk", Email: "clerk@example.com", Password: "password123", Role: RoleStaff}
+	require.NoError(t, store.Users.Create(context.Background(), staff))
+
+	sessions := NewSessionHandler(store.RefreshTokens, store.Users, config.SessionConfig{AccessTTL: 15 * time.Minute, RefreshTTL: 24 * time.Hour}, jwtSecret)
+	sessions.Audit = NewChangeRecorder(store.Logs)
+	users := NewUserHandler(store.Users, jwtSecret)
+	users.Sessions = sessions
+
+	app := fiber.New()
+	RegisterModules(app.Group("/api"), NewRouteMiddleware(jwtSecret), sessions, users)
+	return app, store, sessions, staff
+}
+
+func signIn(t *testing.T, app *fiber.App) api.UserAuthResponse {
+	resp := authedRequest(t, app, "", http.MethodPost, "/api/users/login", map[string]string{"username": "clerk", "password": "password123"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var auth api.UserAuthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&auth))
+	return auth
+}
+
+func refreshSession(t *testing.T, app *fiber.App, refreshToken string) (*http.Response, api.TokenRefreshResponse) {
+	resp := authedRequest(t, app, "", http.MethodPost, "/api/users/refresh", api.TokenRefreshRequest{RefreshToken: refreshToken})
+	var refreshed api.TokenRefreshResponse
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&refreshed))
+	}
+	return resp, refreshed
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	app, _, _, _ := setupSessionTestApp(t)
+
+	auth := signIn(t, app)
+	require.NotEmpty(t, auth.RefreshToken)
+	assert.Equal(t, 15*60, auth.ExpiresIn)
+
+	resp, refreshed := refreshSession(t, app, auth.RefreshToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, refreshed.Token)
+	assert.NotEqual(t, auth.RefreshToken, refreshed.RefreshToken)
+	assert.Equal(t, 15*60, refreshed.ExpiresIn)
+
+	resp, again := refreshSession(t, app, refreshed.RefreshToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "the new token can be exchanged in turn")
+	assert.NotEmpty(t, again.RefreshToken)
+
+	resp, _ = refreshSession(t, app, "not-a-token")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = refreshSession(t, app, "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRefreshTokenReuseEndsSession(t *testing.T) {
+	app, store, _, _ := setupSessionTestApp(t)
+
+	stolen := signIn(t, app)
+	other := signIn(t, app)
+	resp, rotated := refreshSession(t, app, stolen.RefreshToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Replaying the exchanged token revokes the token it was exchanged for too
+	resp, _ = refreshSession(t, app, stolen.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp, _ = refreshSession(t, app, rotated.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, _ = refreshSession(t, app, other.RefreshToken)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "other sessions of the user are untouched")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "REFRESH_TOKEN_REUSED", logs[0].Action)
+}
+
+func TestRefreshRejectsExpiredTokensAndInactiveUsers(t *testing.T) {
+	app, store, sessions, staff := setupSessionTestApp(t)
+
+	auth := signIn(t, app)
+	sessions.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	resp, _ := refreshSession(t, app, auth.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	sessions.now = time.Now
+	auth = signIn(t, app)
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), staff.Id))
+	resp, _ = refreshSession(t, app, auth.RefreshToken)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	require.NoError(t, store.Users.ActivateUser(context.Background(), staff.Id))
+	resp, _ = refreshSession(t, app, auth.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "the session ended when the account was found inactive")
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	app, _, sessions, staff := setupSessionTestApp(t)
+
+	auth := signIn(t, app)
+	resp, rotated := refreshSession(t, app, auth.RefreshToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/logout", api.TokenRefreshRequest{RefreshToken: rotated.RefreshToken})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = refreshSession(t, app, rotated.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = authedRequest(t, app, "", http.MethodPost, "/api/users/logout", api.TokenRefreshRequest{RefreshToken: rotated.RefreshToken})
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "logging out twice is not an error")
+
+	// Changing the password ends every session
+	auth = signIn(t, app)
+	sessions.RevokeUser(staff.Id)
+	resp, _ = refreshSession(t, app, auth.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditEntityShifts is the activity log entity of the shift schedules
+const AuditEntityShifts = "shift_schedule"
+
+// Limits of the shift schedules and overrides
+const (
+	maxShiftSchedules     = 100
+	maxShiftOverrideHours = 72
+)
+
+// ErrOutsideShift is returned when a user may not sign in or make changes at this time
+var ErrOutsideShift = errors.New("outside the user's scheduled shift")
+
+// shiftExemptPaths are the writes allowed outside a shift: ending a session and
+// telling the server the user is still there
+var shiftExemptPaths = map[string]bool{
+	"/api/users/logout":       true,
+	"/api/users/me/heartbeat": true,
+}
+
+// ShiftHandler restricts users to their scheduled shifts: outside them they cannot
+// sign in or make changes, unless an admin granted them an override. Schedules are set
+// per role, and per branch for the users assigned to one. Admins are never restricted,
+// so a tenant cannot lock itself out, and a tenant without schedules is not restricted.
+type ShiftHandler struct {
+	Users     UserRepository
+	Repo      repositories.ShiftRepository
+	Logs      repositories.LogsRepositoryInterface // Off-shift sign-ins and changes are recorded here, as they may have no authenticated user yet
+	Audit     *ChangeRecorder
+	now       func() time.Time
+	jwtSecret []byte
+}
+
+// NewShiftHandler creates a new ShiftHandler instance
+func NewShiftHandler(users UserRepository, repo repositories.ShiftRepository, logs repositories.LogsRepositoryInterface, jwtSecret []byte) *ShiftHandler {
+	return &ShiftHandler{Users: users, Repo: repo, Logs: logs, now: time.Now, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin routes of the shift schedules. The module must be
+// registered before the users module, like the other modules with /users/:id routes.
+func (h *ShiftHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/admin/shifts", mw.Auth, mw.Admin, h.GetSchedules)             // GET /api/admin/shifts
+	r.Put("/admin/shifts", mw.Auth, mw.Admin, h.ReplaceSchedules)         // PUT /api/admin/shifts
+	r.Get("/admin/shift-overrides", mw.Auth, mw.Admin, h.GetOverrides)    // GET /api/admin/shift-overrides
+	r.Post("/admin/shift-overrides", mw.Auth, mw.Admin, h.GrantOverride)  // POST /api/admin/shift-overrides
+	r.Put("/users/:id/shift-branch", mw.Auth, mw.Admin, h.SetShiftBranch) // PUT /api/users/:id/shift-branch
+}
+
+// access checks whether a user may work now. It returns the override they work under
+// when outside their shift, or ErrOutsideShift when they have none.
+func (h *ShiftHandler) access(userID, role string) (*models.ShiftOverride, error) {
+	if role == RoleAdmin || role == RoleSuperAdmin {
+		return nil, nil
+	}
+	schedules, err := h.Repo.GetSchedules()
+	if err != nil {
+		return nil, err
+	}
+	var roleSchedules []models.ShiftSchedule
+	for _, schedule := range schedules {
+		if schedule.Role == role {
+			roleSchedules = append(roleSchedules, schedule)
+		}
+	}
+	if len(roleSchedules) == 0 {
+		return nil, nil
+	}
+
+	branch, err := h.Repo.GetBranch(userID)
+	if err != nil {
+		return nil, err
+	}
+	applicable := shiftSchedulesFor(roleSchedules, branch)
+	if len(applicable) == 0 {
+		return nil, nil
+	}
+	now := h.now()
+	for _, schedule := range applicable {
+		if schedule.Covers(now) {
+			return nil, nil
+		}
+	}
+
+	override, err := h.Repo.ActiveOverride(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if override == nil {
+		return nil, ErrOutsideShift
+	}
+	return override, nil
+}
+
+// shiftSchedulesFor picks the schedules of a branch from those of a role, or the ones
+// without a branch when the branch has none
+func shiftSchedulesFor(schedules []models.ShiftSchedule, branch string) []models.ShiftSchedule {
+	var forBranch, forRole []models.ShiftSchedule
+	for _, schedule := range schedules {
+		switch schedule.Branch {
+		case "":
+			forRole = append(forRole, schedule)
+		case branch:
+			forBranch = append(forBranch, schedule)
+		}
+	}
+	if len(forBranch) > 0 {
+		return forBranch
+	}
+	return forRole
+}
+
+// record writes an off-shift sign-in or change to the activity log
+func (h *ShiftHandler) record(userID, action, details string, succeeded bool) {
+	status := "SUCCESS"
+	if !succeeded {
+		status = "FAILED"
+	}
+	logEntry := &models.ActivityLog{User: userID, Action: action, Details: details, Status: status, EntityType: AuditEntityUser, EntityID: userID}
+	if err := h.Logs.Create(logEntry); err != nil {
+		log.Printf("Error recording %s of user %s: %v", action, userID, err)
+	}
+}
+
+// CheckLogin checks whether a user may sign in now, returning ErrOutsideShift when
+// they may not. Sign-ins outside a shift are logged, whether an override allowed them
+// or not. A nil handler lets every user sign in.
+func (h *ShiftHandler) CheckLogin(user *models.User) error {
+	if h == nil || h.Repo == nil {
+		return nil
+	}
+	override, err := h.access(user.Id, user.Role)
+	if errors.Is(err, ErrOutsideShift) {
+		log.Printf("Rejected sign-in of user %s outside their shift", user.Id)
+		h.record(user.Id, "OFF_SHIFT_LOGIN", fmt.Sprintf("Refused sign-in of %s outside their shift", user.Username), false)
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check the shift of user %s: %w", user.Id, err)
+	}
+	if override != nil {
+		h.record(user.Id, "OFF_SHIFT_LOGIN", fmt.Sprintf("%s signed in outside their shift under an override granted by %s until %s: %s",
+			user.Username, override.GrantedBy, override.ExpiresAt.Format(time.RFC3339), override.Reason), true)
+	}
+	return nil
+}
+
+// Enforce is middleware refusing changes by users outside their shift with 403.
+// Reads, requests without a valid token and signing out are let through; routes
+// still authenticate the caller themselves.
+func (h *ShiftHandler) Enforce(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return c.Next()
+	}
+	if shiftExemptPaths[c.Path()] {
+		return c.Next()
+	}
+	userID := middleware.BearerUserID(c, h.jwtSecret)
+	if userID == "" {
+		return c.Next()
+	}
+
+	_, err := h.access(userID, middleware.BearerRole(c, h.jwtSecret))
+	if errors.Is(err, ErrOutsideShift) {
+		log.Printf("Rejected %s %s by user %s outside their shift", c.Method(), c.Path(), userID)
+		h.record(strings.Clone(userID), "OFF_SHIFT_CHANGE", fmt.Sprintf("Refused %s %s outside the user's shift", c.Method(), c.Path()), false)
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Changes cannot be made outside your scheduled shift", StatusCode: fiber.StatusForbidden})
+	}
+	if err != nil {
+		log.Printf("Error checking the shift of user %s: %v", userID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to check shift schedule", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Next()
+}
+
+// GetSchedules handles listing the shift schedules
+// @Summary List shift schedules (Admin)
+// @Description Lists the shifts during which users of each role, at each branch, may sign in and make changes. Roles without schedules are not restricted.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.ShiftSchedulesResponse "Shift schedules"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve shift schedules"
+// @Router /admin/shifts [get]
+func (h *ShiftHandler) GetSchedules(c *fiber.Ctx) error {
+	schedules, err := h.Repo.GetSchedules()
+	if err != nil {
+		log.Printf("Error getting shift schedules: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve shift schedules", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ShiftSchedulesResponse{Schedules: schedules})
+}
+
+// validateShiftSchedule normalizes a schedule, returning why it is invalid
+func validateShiftSchedule(schedule *models.ShiftSchedule) string {
+	schedule.Role = strings.TrimSpace(schedule.Role)
+	if schedule.Role == "" {
+		return "role is required"
+	}
+	if schedule.Role == RoleAdmin || schedule.Role == RoleSuperAdmin {
+		return "admins cannot be restricted to shifts"
+	}
+	schedule.Branch = normalizeBranch(schedule.Branch)
+	if utf8.RuneCountInString(schedule.Branch) > 50 {
+		return "branch must be at most 50 characters"
+	}
+
+	seen := make(map[int]bool)
+	days := []int{}
+	for _, day := range schedule.Days {
+		if day < 1 || day > 7 {
+			return "days must be between 1 (Monday) and 7 (Sunday)"
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+	schedule.Days = days
+
+	for _, t := range []*string{&schedule.Start, &schedule.End} {
+		parsed, err := time.Parse(models.ShiftTimeLayout, strings.TrimSpace(*t))
+		if err != nil {
+			return "start and end must be formatted as HH:MM"
+		}
+		*t = parsed.Format(models.ShiftTimeLayout)
+	}
+	return ""
+}
+
+// ReplaceSchedules handles setting the shift schedules
+// @Summary Replace shift schedules (Admin)
+// @Description Replaces every shift schedule. A schedule lets the users of a role sign in and make changes from start to end (HH:MM, server time) on the given ISO weekdays (1 Monday to 7 Sunday, every day when empty); a shift ending at or before its start runs past midnight. Schedules with a branch apply to the users assigned to it, instead of those without one. An empty list lifts every restriction.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param schedules body api.ShiftSchedulesRequest true "Shift schedules"
+// @Success 200 {object} api.ShiftSchedulesResponse "Shift schedules replaced"
+// @Failure 400 {object} api.ErrorResponse "Invalid schedule"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to save shift schedules"
+// @Router /admin/shifts [put]
+func (h *ShiftHandler) ReplaceSchedules(c *fiber.Ctx) error {
+	var input api.ShiftSchedulesRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if len(input.Schedules) > maxShiftSchedules {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("at most %d schedules are allowed", maxShiftSchedules), StatusCode: fiber.StatusBadRequest})
+	}
+	for i := range input.Schedules {
+		if problem := validateShiftSchedule(&input.Schedules[i]); problem != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("schedule %d: %s", i+1, problem), StatusCode: fiber.StatusBadRequest})
+		}
+	}
+
+	if err := h.Repo.ReplaceSchedules(input.Schedules); err != nil {
+		log.Printf("Error replacing shift schedules: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to save shift schedules", StatusCode: fiber.StatusInternalServerError})
+	}
+	schedules, err := h.Repo.GetSchedules()
+	if err != nil {
+		log.Printf("Error getting shift schedules: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve shift schedules", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "UPDATE_SHIFT_SCHEDULES", AuditEntityShifts, "", fmt.Sprintf("Set %d shift schedules", len(schedules)))
+	return c.Status(fiber.StatusOK).JSON(api.ShiftSchedulesResponse{Schedules: schedules})
+}
+
+// SetShiftBranch handles assigning a user to the shift schedules of a branch
+// @Summary Set shift branch (Admin)
+// @Description Sets the branch whose shift schedules apply to a user. Users without a branch, or whose branch has no schedules, follow the schedules of their role without a branch.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Param branch body api.ShiftBranchRequest true "Branch code, empty to clear"
+// @Success 200 {object} api.MessageResponse "Shift branch updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid branch"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "User not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update shift branch"
+// @Router /users/{id}/shift-branch [put]
+func (h *ShiftHandler) SetShiftBranch(c *fiber.Ctx) error {
+	id := strings.Clone(c.Params("id"))
+
+	var input api.ShiftBranchRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	branch := normalizeBranch(input.Branch)
+	if utf8.RuneCountInString(branch) > 50 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "branch must be at most 50 characters", StatusCode: fiber.StatusBadRequest})
+	}
+
+	if err := h.Repo.SetBranch(id, branch); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "User not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error updating shift branch of user %s: %v", id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update shift branch", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	if branch == "" {
+		h.Audit.RecordAction(c, "SET_SHIFT_BRANCH", AuditEntityUser, id, "Cleared the shift branch of user")
+		return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "User follows the shift schedules of their role"})
+	}
+	h.Audit.RecordAction(c, "SET_SHIFT_BRANCH", AuditEntityUser, id, fmt.Sprintf("Assigned user to the shifts of branch %s", branch))
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: fmt.Sprintf("User follows the shift schedules of branch %s", branch)})
+}
+
+// GetOverrides handles listing the shift overrides in effect
+// @Summary List shift overrides (Admin)
+// @Description Lists the overrides letting users work outside their shift that have not expired yet.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.ShiftOverridesResponse "Shift overrides in effect"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve shift overrides"
+// @Router /admin/shift-overrides [get]
+func (h *ShiftHandler) GetOverrides(c *fiber.Ctx) error {
+	overrides, err := h.Repo.ActiveOverrides(h.now())
+	if err != nil {
+		log.Printf("Error getting shift overrides: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve shift overrides", StatusCode: fiber.StatusInternalServerError})
+	}
+	return c.Status(fiber.StatusOK).JSON(api.ShiftOverridesResponse{Overrides: overrides})
+}
+
+// GrantOverride handles letting a user work outside their shift
+// @Summary Grant a shift override (Admin)
+// @Description Lets a user sign in and make changes outside their shift for the given hours. The grant, and every sign-in outside a shift, is recorded in the activity log.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param override body api.ShiftOverrideRequest true "User, duration and reason"
+// @Success 201 {object} models.ShiftOverride "Override granted"
+// @Failure 400 {object} api.ErrorResponse "Invalid override"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "User not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to grant shift override"
+// @Router /admin/shift-overrides [post]
+func (h *ShiftHandler) GrantOverride(c *fiber.Ctx) error {
+	var input api.ShiftOverrideRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	switch {
+	case input.UserID == "":
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "userId is required", StatusCode: fiber.StatusBadRequest})
+	case input.Hours < 1 || input.Hours > maxShiftOverrideHours:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("hours must be between 1 and %d", maxShiftOverrideHours), StatusCode: fiber.StatusBadRequest})
+	case input.Reason == "" || utf8.RuneCountInString(input.Reason) > 255:
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "reason is required and must be at most 255 characters", StatusCode: fiber.StatusBadRequest})
+	}
+	if _, err := h.Users.GetByID(c.Context(), input.UserID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "User not found", StatusCode: fiber.StatusNotFound})
+	}
+
+	grantedBy, _ := c.Locals("user_id").(string)
+	override := models.ShiftOverride{
+		UserID:    input.UserID,
+		Reason:    input.Reason,
+		GrantedBy: strings.Clone(grantedBy),
+		ExpiresAt: h.now().Add(time.Duration(input.Hours) * time.Hour),
+	}
+	if err := h.Repo.AddOverride(&override); err != nil {
+		log.Printf("Error granting shift override to user %s: %v", input.UserID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to grant shift override", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "GRANT_SHIFT_OVERRIDE", AuditEntityUser, override.UserID,
+		fmt.Sprintf("Allowed user to work outside their shift until %s: %s", override.ExpiresAt.Format(time.RFC3339), override.Reason))
+	return c.Status(fiber.StatusCreated).JSON(override)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupShiftTestApp registers the login and shift routes, behind the shift middleware,
+// and a route to read and one to change with, on an in-memory store with a staff user.
+// The clock is fixed to Wednesday 2025-03-12 20:00.
+func setupShiftTestApp(t *testing.T) (*fiber.App, *memory.Store, *models.User) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	staff := &models.User{Username: "clerk", Email: "clerk@example.com", Password: "password123", Role: RoleStaff, IsActive: true}
+	require.NoError(t, store.Users.Create(context.Background(), staff))
+
+	shifts := NewShiftHandler(store.Users, store.Shifts, store.Logs, jwtSecret)
+	shifts.Audit = NewChangeRecorder(store.Logs)
+	shifts.now = func() time.Time { return time.Date(2025, 3, 12, 20, 0, 0, 0, time.Local) }
+	users := NewUserHandler(store.Users, jwtSecret)
+	users.Shifts = shifts
+
+	app := fiber.New()
+	app.Use(shifts.Enforce)
+	apiGroup := app.Group("/api")
+	users.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	shifts.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	apiGroup.Get("/ping", middleware.JWTMiddleware(jwtSecret), ok)
+	apiGroup.Post("/ping", middleware.JWTMiddleware(jwtSecret), ok)
+	return app, store, staff
+}
+
+func TestReplaceShiftSchedules(t *testing.T) {
+	app, _, _ := setupShiftTestApp(t)
+	adminToken := createTenantTestToken([]byte("testsecret"), "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken([]byte("testsecret"), "staff-1", RoleStaff, models.DefaultTenantID)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/admin/shifts", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for name, schedule := range map[string]models.ShiftSchedule{
+		"NoRole":  {Start: "08:00", End: "17:00"},
+		"Admin":   {Role: RoleAdmin, Start: "08:00", End: "17:00"},
+		"BadDay":  {Role: RoleStaff, Days: []int{0}, Start: "08:00", End: "17:00"},
+		"BadTime": {Role: RoleStaff, Start: "8am", End: "17:00"},
+	} {
+		resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/admin/shifts", api.ShiftSchedulesRequest{Schedules: []models.ShiftSchedule{schedule}})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/admin/shifts", api.ShiftSchedulesRequest{Schedules: []models.ShiftSchedule{
+		{Role: RoleStaff, Branch: " cebu ", Days: []int{5, 1, 5}, Start: "22:00", End: "06:00"},
+		{Role: RoleStaff, Start: "8:00", End: "17:00"},
+	}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var saved api.ShiftSchedulesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&saved))
+	require.Len(t, saved.Schedules, 2)
+	assert.Equal(t, "", saved.Schedules[0].Branch)
+	assert.Equal(t, "08:00", saved.Schedules[0].Start)
+	assert.Equal(t, []int{}, saved.Schedules[0].Days)
+	assert.Equal(t, "CEBU", saved.Schedules[1].Branch)
+	assert.Equal(t, []int{1, 5}, saved.Schedules[1].Days)
+	assert.NotEmpty(t, saved.Schedules[1].ID)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/shifts", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed api.ShiftSchedulesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	assert.Equal(t, saved, listed)
+}
+
+func TestShiftRestrictions(t *testing.T) {
+	app, store, staff := setupShiftTestApp(t)
+	jwtSecret := []byte("testsecret")
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, staff.Id, RoleStaff, models.DefaultTenantID)
+	login := func() int {
+		resp := authedRequest(t, app, "", http.MethodPost, "/api/users/login", map[string]string{"username": "clerk", "password": "password123"})
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, login(), "tenants without schedules are not restricted")
+	require.NoError(t, store.Shifts.ReplaceSchedules([]models.ShiftSchedule{
+		{Role: RoleStaff, Days: []int{1, 2, 3, 4, 5, 6}, Start: "08:00", End: "18:00"},
+		{Role: RoleStaff, Branch: "CEBU", Days: []int{3}, Start: "18:00", End: "02:00"},
+	}))
+
+	assert.Equal(t, http.StatusForbidden, login())
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/ping", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/ping", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "reads are not restricted")
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/ping", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "admins are never restricted")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/users/"+staff.Id+"/shift-branch", api.ShiftBranchRequest{Branch: "cebu"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, login(), "the branch's evening shift applies")
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/users/missing/shift-branch", api.ShiftBranchRequest{Branch: "cebu"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/users/"+staff.Id+"/shift-branch", api.ShiftBranchRequest{})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/shift-overrides", api.ShiftOverrideRequest{UserID: staff.Id, Hours: 100, Reason: "Inventory count"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/shift-overrides", api.ShiftOverrideRequest{UserID: staff.Id, Hours: 2, Reason: "Inventory count"})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, http.StatusOK, login())
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/ping", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/shift-overrides", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var overrides api.ShiftOverridesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&overrides))
+	require.Len(t, overrides.Overrides, 1)
+	assert.Equal(t, "admin-1", overrides.Overrides[0].GrantedBy)
+
+	logs, _, err := store.Logs.GetLogs(1, 20)
+	require.NoError(t, err)
+	actions := make(map[string][]string)
+	for _, entry := range logs {
+		actions[entry.Action] = append(actions[entry.Action], entry.Status)
+	}
+	assert.ElementsMatch(t, []string{"FAILED", "SUCCESS"}, actions["OFF_SHIFT_LOGIN"])
+	assert.Equal(t, []string{"FAILED"}, actions["OFF_SHIFT_CHANGE"])
+	assert.Len(t, actions["GRANT_SHIFT_OVERRIDE"], 1)
+}
+
+func TestShiftScheduleCovers(t *testing.T) {
+	weekdays := models.ShiftSchedule{Days: []int{1, 2, 3, 4, 5}, Start: "08:00", End: "17:00"}
+	overnight := models.ShiftSchedule{Days: []int{5}, Start: "22:00", End: "06:00"}
+	at := func(day, hour, minute int) time.Time { return time.Date(2025, 3, day, hour, minute, 0, 0, time.Local) } // March 2025 starts on a Saturday
+
+	assert.True(t, weekdays.Covers(at(3, 8, 0)), "Monday at the start")
+	assert.False(t, weekdays.Covers(at(3, 17, 0)), "the end is exclusive")
+	assert.False(t, weekdays.Covers(at(8, 9, 0)), "Saturday")
+	assert.True(t, overnight.Covers(at(7, 23, 0)), "Friday night")
+	assert.True(t, overnight.Covers(at(8, 5, 59)), "Saturday morning, of the shift started Friday")
+	assert.False(t, overnight.Covers(at(7, 5, 0)), "Friday morning belongs to Thursday")
+	assert.True(t, models.ShiftSchedule{Start: "00:00", End: "00:00"}.Covers(at(9, 12, 0)), "a shift ending at its start lasts all day")
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"errors"
+	"oop/internal/api"
+	"oop/internal/repositories"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// rejectNegativeStock writes 409 Conflict when a change was refused because it would
+// take the quantity of a cab, accessory or material below zero. It reports whether it
+// wrote the response, in which case the handler returns err.
+func rejectNegativeStock(c *fiber.Ctx, err error) (bool, error) {
+	if !errors.Is(err, repositories.ErrNegativeStock) {
+		return false, nil
+	}
+	return true, c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+		Error:      "Quantity cannot go below zero",
+		StatusCode: fiber.StatusConflict,
+	})
+}
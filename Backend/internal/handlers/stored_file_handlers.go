@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"oop/internal/api"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StoredFileHandler serves the download links handed out by the local storage
+// driver. Links are signed rather than token-authenticated, so they work when opened
+// in a new tab; the object stores serve their own links.
+type StoredFileHandler struct {
+	Storage *services.LocalStorage
+}
+
+// NewStoredFileHandler creates a new StoredFileHandler instance
+func NewStoredFileHandler(storage *services.LocalStorage) *StoredFileHandler {
+	return &StoredFileHandler{Storage: storage}
+}
+
+// Routes registers the download route of the local storage driver.
+// Keys start with the tenant, so the route is registered outside any tenant.
+func (h *StoredFileHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/files/*", h.GetStoredFile) // GET /api/files/<key>?expires=...&signature=... (signed link)
+}
+
+// GetStoredFile handles downloading a file through a signed link
+// @Summary Download a stored file
+// @Description Serves a file kept by the local storage driver through a link handed out by another endpoint, such as an expense attachment download. Links expire after STORAGE_URL_EXPIRY_SECONDS.
+// @Tags Files
+// @Produce application/octet-stream
+// @Param key path string true "Storage key"
+// @Param expires query int true "Expiry of the link as a Unix time"
+// @Param signature query string true "Signature of the link"
+// @Param name query string false "Name the file is downloaded as"
+// @Success 200 {file} binary "Stored file"
+// @Failure 403 {object} api.ErrorResponse "Download link is invalid or has expired"
+// @Failure 404 {object} api.ErrorResponse "File not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve file"
+// @Router /files/{key} [get]
+func (h *StoredFileHandler) GetStoredFile(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "File not found", StatusCode: fiber.StatusNotFound})
+	}
+	name := c.Query("name")
+	if err := h.Storage.Verify(key, name, c.Query("expires"), c.Query("signature")); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Download link is invalid or has expired", StatusCode: fiber.StatusForbidden})
+	}
+
+	data, err := h.Storage.Get(c.Context(), key)
+	if errors.Is(err, services.ErrObjectNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "File not found", StatusCode: fiber.StatusNotFound})
+	}
+	if err != nil {
+		log.Printf("Error reading stored file %s: %v", key, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve file", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	// Uploads are checked against their contents, so the type is sniffed the same way
+	c.Set(fiber.HeaderContentType, http.DetectContentType(data))
+	if name != "" {
+		c.Set(fiber.HeaderContentDisposition, services.ContentDisposition(name))
+	}
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Status(fiber.StatusOK).Send(data)
+}
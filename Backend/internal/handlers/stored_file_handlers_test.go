@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseAttachmentDownloadLink(t *testing.T) {
+	_, store, jwtSecret := setupExpenseTestApp(t)
+	files, err := services.NewLocalStorage(t.TempDir(), services.LocalFilesPath, jwtSecret)
+	require.NoError(t, err)
+	expenses := NewExpenseHandler(store.Expenses, jwtSecret)
+	expenses.Files, expenses.LinkExpiry = files, time.Minute
+	app := fiber.New()
+	expenses.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	NewStoredFileHandler(files).Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	token := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	expense := submitExpense(t, app, token, ExpenseRequest{Category: "fuel", Description: "Diesel", Amount: 1500, ExpenseDate: "2025-03-10"})
+	pdf := []byte("%PDF-1.4\n%receipt")
+	key := models.DefaultTenantID + "/expense-attachments/att-1"
+	require.NoError(t, files.Put(context.Background(), key, "application/pdf", pdf))
+	require.NoError(t, store.Expenses.AddAttachment(&models.ExpenseAttachment{ID: "att-1", ExpenseID: expense.ID, FileName: "receipt.pdf",
+		ContentType: "application/pdf", StorageKey: key, UploadedBy: "staff-1"}, nil))
+
+	// Attachments in file storage are served through a signed link
+	resp := authedRequest(t, app, token, http.MethodGet, "/api/expenses/"+expense.ID+"/attachments/att-1", nil)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	link := resp.Header.Get(fiber.HeaderLocation)
+	assert.True(t, strings.HasPrefix(link, services.LocalFilesPath+"/"+key+"?"))
+
+	resp = authedRequest(t, app, "", http.MethodGet, link, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "links need no token")
+	assert.Equal(t, "application/pdf", resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, `attachment; filename="receipt.pdf"`, resp.Header.Get(fiber.HeaderContentDisposition))
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, pdf, data)
+
+	resp = authedRequest(t, app, "", http.MethodGet, strings.Replace(link, "name=receipt.pdf", "name=other.pdf", 1), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, "", http.MethodGet, services.LocalFilesPath+"/"+key, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// Attachments kept in the database are still sent in the response
+	resp = uploadExpenseAttachment(t, app, token, expense.ID, "photo.png", testPNG(t))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	updated, err := store.Expenses.GetByID(expense.ID)
+	require.NoError(t, err)
+	require.Len(t, updated.Attachments, 2)
+	for _, attachment := range updated.Attachments {
+		if attachment.ID != "att-1" {
+			resp = authedRequest(t, app, token, http.MethodGet, "/api/expenses/"+expense.ID+"/attachments/"+attachment.ID, nil)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationTaskAssigned is pushed when a task is assigned to a user
+const NotificationTaskAssigned = "task_assigned"
+
+// maxTaskTitleLength matches the width of the title column
+const maxTaskTitleLength = 200
+
+// TaskHandler serves follow-up tasks that admins assign to staff
+type TaskHandler struct {
+	Repo      repositories.TaskRepository
+	Users     UserRepository
+	Customers repositories.CustomerRepository
+	Sales     repositories.SalesRepository
+	Cabs      repositories.CabsRepository
+	Hub       *services.NotificationHub // Optional; without it assignments are not pushed
+	Audit     *ChangeRecorder
+	jwtSecret []byte
+}
+
+// NewTaskHandler creates a new TaskHandler instance. The customer, sale and cab
+// repositories are used to check the entities tasks are linked to.
+func NewTaskHandler(repo repositories.TaskRepository, users UserRepository, customers repositories.CustomerRepository, sales repositories.SalesRepository, cabs repositories.CabsRepository, jwtSecret []byte) *TaskHandler {
+	return &TaskHandler{Repo: repo, Users: users, Customers: customers, Sales: sales, Cabs: cabs, jwtSecret: jwtSecret}
+}
+
+// Routes registers the task routes
+func (h *TaskHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	taskGroup := r.Group("/tasks", mw.Auth)
+	taskGroup.Get("/", h.GetTasks)                   // GET /api/tasks
+	taskGroup.Get("/mine", h.GetMyTasks)             // GET /api/tasks/mine (must precede /:id)
+	taskGroup.Get("/:id", h.GetTask)                 // GET /api/tasks/:id
+	taskGroup.Post("/", mw.Admin, h.CreateTask)      // POST /api/tasks
+	taskGroup.Put("/:id", mw.Admin, h.UpdateTask)    // PUT /api/tasks/:id
+	taskGroup.Put("/:id/status", h.UpdateTaskStatus) // PUT /api/tasks/:id/status
+	taskGroup.Delete("/:id", mw.Admin, h.DeleteTask) // DELETE /api/tasks/:id
+}
+
+// TaskRequest is the body for creating or replacing a task
+type TaskRequest struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	AssigneeID  string     `json:"assigneeId"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	EntityType  string     `json:"entityType,omitempty"` // customer, sale or cab
+	EntityID    string     `json:"entityId,omitempty"`
+}
+
+// TaskStatusRequest is the body for moving a task to another status
+type TaskStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// taskInputError is a validation failure reported to the client as 400
+type taskInputError struct{ message string }
+
+func (e taskInputError) Error() string { return e.message }
+
+// applyTaskRequest validates the request, including that the assignee and the linked
+// entity exist, and applies it to a task
+func (h *TaskHandler) applyTaskRequest(ctx context.Context, input TaskRequest, task *models.Task) error {
+	title := strings.TrimSpace(input.Title)
+	if title == "" || input.AssigneeID == "" {
+		return taskInputError{"Title and assigneeId are required"}
+	}
+	if len(title) > maxTaskTitleLength {
+		return taskInputError{fmt.Sprintf("Title cannot be longer than %d characters", maxTaskTitleLength)}
+	}
+
+	assignee, err := h.Users.GetByID(ctx, input.AssigneeID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return taskInputError{"Assignee not found"}
+		}
+		return fmt.Errorf("failed to get assignee: %w", err)
+	}
+	if !assignee.IsActive {
+		return taskInputError{"Tasks cannot be assigned to deactivated users"}
+	}
+
+	if (input.EntityType == "") != (input.EntityID == "") {
+		return taskInputError{"entityType and entityId must be given together"}
+	}
+	if input.EntityType != "" {
+		if err := h.checkLinkedEntity(ctx, input.EntityType, input.EntityID); err != nil {
+			return err
+		}
+	}
+
+	task.Title = title
+	task.Description = strings.TrimSpace(input.Description)
+	task.AssigneeID = input.AssigneeID
+	task.DueDate = input.DueDate
+	task.EntityType = input.EntityType
+	task.EntityID = input.EntityID
+	return nil
+}
+
+// checkLinkedEntity makes sure the entity a task is linked to exists in the tenant
+func (h *TaskHandler) checkLinkedEntity(ctx context.Context, entityType, entityID string) error {
+	var err error
+	switch entityType {
+	case models.TaskEntityCustomer:
+		_, err = h.Customers.GetCustomerByID(ctx, entityID)
+	case models.TaskEntitySale:
+		_, err = h.Sales.GetByID(ctx, entityID)
+	case models.TaskEntityCab:
+		cabID, convErr := strconv.Atoi(entityID)
+		if convErr != nil {
+			return taskInputError{"entityId of a cab must be a number"}
+		}
+		_, err = h.Cabs.GetCabByID(ctx, cabID)
+	default:
+		return taskInputError{"entityType must be customer, sale or cab"}
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return taskInputError{fmt.Sprintf("Linked %s not found", entityType)}
+		}
+		return fmt.Errorf("failed to get linked %s: %w", entityType, err)
+	}
+	return nil
+}
+
+// taskError writes the response for an error of applyTaskRequest
+func taskError(c *fiber.Ctx, err error) error {
+	var inputErr taskInputError
+	if errors.As(err, &inputErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: inputErr.message, StatusCode: fiber.StatusBadRequest})
+	}
+	log.Printf("Error validating task: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to validate task", StatusCode: fiber.StatusInternalServerError})
+}
+
+// loadTask fetches the task named by the :id parameter, writing the error response
+// itself when it fails
+func (h *TaskHandler) loadTask(c *fiber.Ctx) (*models.Task, error) {
+	task, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Task not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting task %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve task", StatusCode: fiber.StatusInternalServerError})
+	}
+	return task, nil
+}
+
+// parseTaskStatuses reads the comma-separated status query parameter. "all" and an
+// empty value both mean no status filter.
+func parseTaskStatuses(value string) ([]string, error) {
+	if value == "" || value == "all" {
+		return nil, nil
+	}
+	statuses := strings.Split(value, ",")
+	for i, status := range statuses {
+		statuses[i] = strings.TrimSpace(status)
+		if !models.ValidTaskStatus(statuses[i]) {
+			return nil, fmt.Errorf("Unknown task status %q", statuses[i])
+		}
+	}
+	return statuses, nil
+}
+
+// listTasks writes the tasks matching the filter, applying the status and overdue query parameters
+func (h *TaskHandler) listTasks(c *fiber.Ctx, filter models.TaskFilter, defaultStatuses []string) error {
+	statuses, err := parseTaskStatuses(c.Query("status"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+	if c.Query("status") == "" {
+		statuses = defaultStatuses
+	}
+	filter.Statuses = statuses
+
+	now := time.Now()
+	if c.QueryBool("overdue") {
+		filter.DueBefore = &now
+		filter.Statuses = []string{models.TaskStatusOpen, models.TaskStatusInProgress}
+	}
+
+	tasks, err := h.Repo.GetAll(filter)
+	if err != nil {
+		log.Printf("Error getting tasks: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve tasks", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	overdue := 0
+	for _, task := range tasks {
+		if task.Overdue(now) {
+			overdue++
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.TaskListResponse{Tasks: tasks, Count: len(tasks), Overdue: overdue})
+}
+
+// GetTasks handles listing tasks
+// @Summary List tasks
+// @Description Lists the tasks of the tenant, soonest due first. Filter by assignee, status or linked entity, e.g. entityType=customer&entityId=... for the follow-ups about one customer.
+// @Tags Tasks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param assigneeId query string false "Only tasks assigned to this user"
+// @Param status query string false "Comma-separated statuses (open, in_progress, done, cancelled); all by default"
+// @Param entityType query string false "Linked entity type (customer, sale or cab)"
+// @Param entityId query string false "Linked entity ID"
+// @Param overdue query bool false "Only pending tasks past their due date"
+// @Success 200 {object} api.TaskListResponse "Tasks"
+// @Failure 400 {object} api.ErrorResponse "Unknown task status"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve tasks"
+// @Router /tasks [get]
+func (h *TaskHandler) GetTasks(c *fiber.Ctx) error {
+	filter := models.TaskFilter{
+		AssigneeID: c.Query("assigneeId"),
+		EntityType: c.Query("entityType"),
+		EntityID:   c.Query("entityId"),
+	}
+	return h.listTasks(c, filter, nil)
+}
+
+// GetMyTasks handles listing the caller's tasks
+// @Summary List my tasks
+// @Description Lists the tasks assigned to the caller, soonest due first. Only open and in-progress tasks are listed unless status is given.
+// @Tags Tasks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "Comma-separated statuses, or all; open,in_progress by default"
+// @Param overdue query bool false "Only pending tasks past their due date"
+// @Success 200 {object} api.TaskListResponse "Tasks"
+// @Failure 400 {object} api.ErrorResponse "Unknown task status"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve tasks"
+// @Router /tasks/mine [get]
+func (h *TaskHandler) GetMyTasks(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	return h.listTasks(c, models.TaskFilter{AssigneeID: userID}, []string{models.TaskStatusOpen, models.TaskStatusInProgress})
+}
+
+// GetTask handles getting a task
+// @Summary Get a task
+// @Tags Tasks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} models.Task "Task"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 404 {object} api.ErrorResponse "Task not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve task"
+// @Router /tasks/{id} [get]
+func (h *TaskHandler) GetTask(c *fiber.Ctx) error {
+	task, err := h.loadTask(c)
+	if task == nil {
+		return err
+	}
+	setLastModified(c, task.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(task)
+}
+
+// CreateTask handles assigning a task
+// @Summary Assign a task (Admin)
+// @Description Creates an open task for an active user, optionally linked to a customer, sale or cab. The assignee is notified over the notification stream.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param task body TaskRequest true "Task"
+// @Success 201 {object} api.TaskResponse "Task created"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to create task"
+// @Router /tasks [post]
+func (h *TaskHandler) CreateTask(c *fiber.Ctx) error {
+	var input TaskRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	task := &models.Task{Status: models.TaskStatusOpen}
+	if err := h.applyTaskRequest(c.Context(), input, task); err != nil {
+		return taskError(c, err)
+	}
+	task.CreatedBy, _ = c.Locals("user_id").(string)
+
+	if err := h.Repo.Create(task); err != nil {
+		log.Printf("Error creating task: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create task", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "CREATE_TASK", AuditEntityTask, task.ID,
+		fmt.Sprintf("Assigned task %q to user %s", task.Title, task.AssigneeID))
+	h.notifyAssignee(c, task)
+
+	return c.Status(fiber.StatusCreated).JSON(api.TaskResponse{Message: "Task created", Task: task})
+}
+
+// UpdateTask handles editing a task
+// @Summary Update a task (Admin)
+// @Description Replaces the title, description, assignee, due date and link of a task. Use PUT /tasks/{id}/status to change its status.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Param task body TaskRequest true "Task"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.TaskResponse "Task updated"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Task not found"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update task"
+// @Router /tasks/{id} [put]
+func (h *TaskHandler) UpdateTask(c *fiber.Ctx) error {
+	var input TaskRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	task, err := h.loadTask(c)
+	if task == nil {
+		return err
+	}
+	if modified, err := rejectIfModified(c, task.UpdatedAt); modified {
+		return err
+	}
+	before := *task
+
+	if err := h.applyTaskRequest(c.Context(), input, task); err != nil {
+		return taskError(c, err)
+	}
+
+	if err := h.Repo.Update(task); err != nil {
+		log.Printf("Error updating task %s: %v", task.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update task", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityTask, task.ID, before, *task)
+	if task.AssigneeID != before.AssigneeID {
+		h.notifyAssignee(c, task)
+	}
+
+	setLastModified(c, task.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.TaskResponse{Message: "Task updated", Task: task})
+}
+
+// UpdateTaskStatus handles moving a task to another status
+// @Summary Change the status of a task
+// @Description Moves a task along its workflow: open and in_progress tasks can move to each other, done or cancelled; done and cancelled tasks can only be reopened. Only the assignee and admins can change the status.
+// @Tags Tasks
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Param status body TaskStatusRequest true "New status"
+// @Success 200 {object} api.TaskResponse "Task status updated"
+// @Failure 400 {object} api.ErrorResponse "Unknown task status"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Task not found"
+// @Failure 409 {object} api.ErrorResponse "Transition not allowed"
+// @Failure 500 {object} api.ErrorResponse "Failed to update task"
+// @Router /tasks/{id}/status [put]
+func (h *TaskHandler) UpdateTaskStatus(c *fiber.Ctx) error {
+	var input TaskStatusRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if !models.ValidTaskStatus(input.Status) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Status must be open, in_progress, done or cancelled", StatusCode: fiber.StatusBadRequest})
+	}
+
+	task, err := h.loadTask(c)
+	if task == nil {
+		return err
+	}
+
+	requestUserID, _ := c.Locals("user_id").(string)
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin && requestUserID != task.AssigneeID {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+
+	if !task.CanTransition(input.Status) {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("A %s task cannot be moved to %s", task.Status, input.Status),
+			StatusCode: fiber.StatusConflict,
+		})
+	}
+
+	before := *task
+	task.Status = input.Status
+	task.CompletedAt = nil
+	if task.Status == models.TaskStatusDone {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+
+	if err := h.Repo.Update(task); err != nil {
+		log.Printf("Error updating status of task %s: %v", task.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update task", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityTask, task.ID, before, *task)
+
+	return c.Status(fiber.StatusOK).JSON(api.TaskResponse{Message: "Task status updated", Task: task})
+}
+
+// DeleteTask handles removing a task
+// @Summary Delete a task (Admin)
+// @Tags Tasks
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} api.MessageResponse "Task deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Task not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete task"
+// @Router /tasks/{id} [delete]
+func (h *TaskHandler) DeleteTask(c *fiber.Ctx) error {
+	task, err := h.loadTask(c)
+	if task == nil {
+		return err
+	}
+
+	if err := h.Repo.Delete(task.ID); err != nil {
+		log.Printf("Error deleting task %s: %v", task.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete task", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_TASK", AuditEntityTask, task.ID, fmt.Sprintf("Deleted task %q", task.Title))
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Task deleted"})
+}
+
+// notifyAssignee pushes a task_assigned notification to the assignee
+func (h *TaskHandler) notifyAssignee(c *fiber.Ctx, task *models.Task) {
+	if h.Hub != nil {
+		h.Hub.Publish(tenantIDFromCtx(c), models.Notification{
+			Type:       NotificationTaskAssigned,
+			Data:       task,
+			Recipients: []string{task.AssigneeID},
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTaskTestApp registers the task routes on an in-memory store with an admin and a staff user
+func setupTaskTestApp(t *testing.T) (*fiber.App, *memory.Store, string, string) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+
+	admin := &models.User{Username: "manager", Email: "manager@example.com", Password: "password123", Role: RoleAdmin}
+	staff := &models.User{Username: "clerk", Email: "clerk@example.com", Password: "password123", Role: RoleStaff}
+	require.NoError(t, store.Users.Create(context.Background(), admin))
+	require.NoError(t, store.Users.Create(context.Background(), staff))
+
+	h := NewTaskHandler(store.Tasks, store.Users, store.Customers, store.Sales, store.Cabs, jwtSecret)
+	h.Audit = NewChangeRecorder(store.Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+
+	adminToken := createTenantTestToken(jwtSecret, admin.Id, RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, staff.Id, RoleStaff, models.DefaultTenantID)
+	return app, store, adminToken, staffToken
+}
+
+func staffUserID(t *testing.T, store *memory.Store) string {
+	staff, err := store.Users.GetByUsername(context.Background(), "clerk")
+	require.NoError(t, err)
+	return staff.Id
+}
+
+func decodeTask(t *testing.T, resp *http.Response) *models.Task {
+	var body api.TaskResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	return body.Task
+}
+
+func TestCreateTask(t *testing.T) {
+	app, store, adminToken, staffToken := setupTaskTestApp(t)
+	assigneeID := staffUserID(t, store)
+
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Unit 42", Make: "Suzuki", UnitColor: "White", Quantity: 1})
+	require.NoError(t, err)
+
+	t.Run("LinkedToCustomer", func(t *testing.T) {
+		due := time.Now().Add(24 * time.Hour)
+		resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/tasks", TaskRequest{
+			Title: "Call about balance", AssigneeID: assigneeID, DueDate: &due,
+			EntityType: models.TaskEntityCustomer, EntityID: customer.ID,
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		task := decodeTask(t, resp)
+		assert.Equal(t, models.TaskStatusOpen, task.Status)
+		assert.Equal(t, customer.ID, task.EntityID)
+		assert.NotEmpty(t, task.CreatedBy)
+	})
+
+	t.Run("LinkedToCab", func(t *testing.T) {
+		resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/tasks", TaskRequest{
+			Title: "Inspect unit", AssigneeID: assigneeID, EntityType: models.TaskEntityCab, EntityID: strconv.Itoa(cab.ID),
+		})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		invalid := map[string]TaskRequest{
+			"missing title":     {AssigneeID: assigneeID},
+			"unknown assignee":  {Title: "Call", AssigneeID: "missing"},
+			"missing entity id": {Title: "Call", AssigneeID: assigneeID, EntityType: models.TaskEntitySale},
+			"unknown entity":    {Title: "Call", AssigneeID: assigneeID, EntityType: "invoice", EntityID: "1"},
+			"missing customer":  {Title: "Call", AssigneeID: assigneeID, EntityType: models.TaskEntityCustomer, EntityID: "missing"},
+			"missing sale":      {Title: "Call", AssigneeID: assigneeID, EntityType: models.TaskEntitySale, EntityID: "missing"},
+			"non-numeric cab":   {Title: "Call", AssigneeID: assigneeID, EntityType: models.TaskEntityCab, EntityID: "abc"},
+		}
+		for name, input := range invalid {
+			resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/tasks", input)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+		}
+	})
+
+	t.Run("DeactivatedAssignee", func(t *testing.T) {
+		require.NoError(t, store.Users.DeactivateUser(context.Background(), assigneeID))
+		defer store.Users.ActivateUser(context.Background(), assigneeID)
+
+		resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/tasks", TaskRequest{Title: "Call", AssigneeID: assigneeID})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("StaffForbidden", func(t *testing.T) {
+		resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/tasks", TaskRequest{Title: "Call", AssigneeID: assigneeID})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestGetMyTasks(t *testing.T) {
+	app, store, adminToken, staffToken := setupTaskTestApp(t)
+	assigneeID := staffUserID(t, store)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	nextWeek := time.Now().Add(7 * 24 * time.Hour)
+
+	require.NoError(t, store.Tasks.Create(&models.Task{Title: "Later", AssigneeID: assigneeID, Status: models.TaskStatusOpen, DueDate: &nextWeek}))
+	require.NoError(t, store.Tasks.Create(&models.Task{Title: "Late", AssigneeID: assigneeID, Status: models.TaskStatusInProgress, DueDate: &yesterday}))
+	require.NoError(t, store.Tasks.Create(&models.Task{Title: "Finished", AssigneeID: assigneeID, Status: models.TaskStatusDone}))
+	require.NoError(t, store.Tasks.Create(&models.Task{Title: "Someone else's", AssigneeID: "other-user", Status: models.TaskStatusOpen}))
+
+	list := func(token, path string) api.TaskListResponse {
+		resp := authedRequest(t, app, token, http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var body api.TaskListResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	mine := list(staffToken, "/api/tasks/mine")
+	require.Equal(t, 2, mine.Count, "done tasks are hidden by default")
+	assert.Equal(t, "Late", mine.Tasks[0].Title, "soonest due first")
+	assert.Equal(t, 1, mine.Overdue)
+
+	assert.Equal(t, 3, list(staffToken, "/api/tasks/mine?status=all").Count)
+	assert.Equal(t, 1, list(staffToken, "/api/tasks/mine?overdue=true").Count)
+	assert.Equal(t, 4, list(adminToken, "/api/tasks").Count)
+	assert.Equal(t, 3, list(adminToken, "/api/tasks?assigneeId="+assigneeID).Count)
+
+	resp := authedRequest(t, app, staffToken, http.MethodGet, "/api/tasks/mine?status=waiting", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestUpdateTaskStatus(t *testing.T) {
+	app, store, adminToken, staffToken := setupTaskTestApp(t)
+	assigneeID := staffUserID(t, store)
+
+	task := &models.Task{Title: "Inspect unit", AssigneeID: assigneeID, Status: models.TaskStatusOpen}
+	require.NoError(t, store.Tasks.Create(task))
+	othersTask := &models.Task{Title: "Not yours", AssigneeID: "other-user", Status: models.TaskStatusOpen}
+	require.NoError(t, store.Tasks.Create(othersTask))
+	path := "/api/tasks/" + task.ID + "/status"
+
+	resp := authedRequest(t, app, staffToken, http.MethodPut, path, TaskStatusRequest{Status: models.TaskStatusDone})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	done := decodeTask(t, resp)
+	assert.Equal(t, models.TaskStatusDone, done.Status)
+	require.NotNil(t, done.CompletedAt)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, TaskStatusRequest{Status: models.TaskStatusCancelled})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "done tasks can only be reopened")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, TaskStatusRequest{Status: models.TaskStatusOpen})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, decodeTask(t, resp).CompletedAt)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, path, TaskStatusRequest{Status: "waiting"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPut, "/api/tasks/"+othersTask.ID+"/status", TaskStatusRequest{Status: models.TaskStatusDone})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/tasks/"+othersTask.ID+"/status", TaskStatusRequest{Status: models.TaskStatusCancelled})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPut, "/api/tasks/missing/status", TaskStatusRequest{Status: models.TaskStatusDone})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpdateAndDeleteTask(t *testing.T) {
+	app, store, adminToken, _ := setupTaskTestApp(t)
+	assigneeID := staffUserID(t, store)
+
+	task := &models.Task{Title: "Call", AssigneeID: assigneeID, Status: models.TaskStatusInProgress}
+	require.NoError(t, store.Tasks.Create(task))
+
+	resp := authedRequest(t, app, adminToken, http.MethodPut, "/api/tasks/"+task.ID, TaskRequest{Title: "Call back", AssigneeID: assigneeID, Description: "After 2pm"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	updated := decodeTask(t, resp)
+	assert.Equal(t, "Call back", updated.Title)
+	assert.Equal(t, models.TaskStatusInProgress, updated.Status, "editing keeps the status")
+
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/tasks/"+task.ID, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/tasks/"+task.ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
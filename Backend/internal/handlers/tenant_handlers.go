@@ -0,0 +1,570 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// AuditEntityTenant marks activity log entries about tenants
+const AuditEntityTenant = "tenant"
+
+// errSuperAdminRole is returned when a request tries to grant the superadmin role
+const errSuperAdminRole = "Permission denied: the superadmin role cannot be assigned"
+
+// impersonationTTL is how long a token issued to impersonate a tenant admin stays valid
+const impersonationTTL = 30 * time.Minute
+
+// tenantSlugPattern matches slugs that are valid DNS labels, so every tenant can be served on a subdomain
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,61}[a-z0-9]$`)
+
+// tenantIDFromCtx returns the tenant the request was resolved to, or the default tenant
+func tenantIDFromCtx(c *fiber.Ctx) string {
+	if tenantID, ok := c.Locals("tenant_id").(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return models.DefaultTenantID
+}
+
+// TenantScope holds the repositories of one tenant that the super-admin routes use
+type TenantScope struct {
+	Users UserRepository
+	Logs  repositories.LogsRepositoryInterface
+}
+
+// TenantScopes returns the repositories of a tenant
+type TenantScopes func(tenantID string) (TenantScope, error)
+
+// TenantHandler serves the super-admin console: tenant administration, usage,
+// feature flags and impersonation. Every request is recorded in Audit, which
+// should be the default tenant's activity log.
+type TenantHandler struct {
+	Repo      repositories.TenantRepository
+	Scopes    TenantScopes
+	Audit     *ChangeRecorder
+	jwtSecret []byte
+}
+
+// NewTenantHandler creates a new TenantHandler instance
+func NewTenantHandler(repo repositories.TenantRepository, scopes TenantScopes, jwtSecret []byte) *TenantHandler {
+	return &TenantHandler{Repo: repo, Scopes: scopes, jwtSecret: jwtSecret}
+}
+
+// Routes registers the super-admin routes. They are served outside
+// any tenant, so they must not be mounted behind the tenant dispatcher.
+func (h *TenantHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	// Requests are audited before the role check so that denied attempts are logged too
+	superAdminGroup := r.Group("/superadmin", mw.Auth, h.auditRequest, requireSuperAdmin)
+	superAdminGroup.Get("/tenants", h.GetTenants)                              // GET /api/superadmin/tenants
+	superAdminGroup.Post("/tenants", h.CreateTenant)                           // POST /api/superadmin/tenants
+	superAdminGroup.Get("/tenants/:id", h.GetTenant)                           // GET /api/superadmin/tenants/:id
+	superAdminGroup.Put("/tenants/:id", h.UpdateTenant)                        // PUT /api/superadmin/tenants/:id
+	superAdminGroup.Delete("/tenants/:id", h.DeleteTenant)                     // DELETE /api/superadmin/tenants/:id
+	superAdminGroup.Get("/tenants/:id/usage", h.GetTenantUsage)                // GET /api/superadmin/tenants/:id/usage
+	superAdminGroup.Get("/tenants/:id/features", h.GetTenantFeatures)          // GET /api/superadmin/tenants/:id/features
+	superAdminGroup.Put("/tenants/:id/features", h.UpdateTenantFeatures)       // PUT /api/superadmin/tenants/:id/features
+	superAdminGroup.Post("/tenants/:id/impersonate", h.ImpersonateTenantAdmin) // POST /api/superadmin/tenants/:id/impersonate
+}
+
+// auditRequest records every super-admin request, including reads and rejected
+// attempts, with the response status. Changes are additionally logged in detail by
+// the handlers that make them.
+func (h *TenantHandler) auditRequest(c *fiber.Ctx) error {
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if fiberErr, ok := err.(*fiber.Error); ok {
+		status = fiberErr.Code
+	}
+	h.Audit.RecordAttempt(c, "SUPERADMIN_REQUEST", AuditEntityTenant, "",
+		fmt.Sprintf("%s %s -> %d", c.Method(), c.OriginalURL(), status), err == nil && status < fiber.StatusBadRequest)
+	return err
+}
+
+// requireSuperAdmin only lets superadmins of the default tenant through
+func requireSuperAdmin(c *fiber.Ctx) error {
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleSuperAdmin || tenantIDFromCtx(c) != models.DefaultTenantID {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+	return c.Next()
+}
+
+// CreateTenantRequest is the body of a tenant provisioning request
+type CreateTenantRequest struct {
+	Slug          string `json:"slug"`
+	Name          string `json:"name"`
+	AdminFullName string `json:"adminFullName"`
+	AdminUsername string `json:"adminUsername"`
+	AdminEmail    string `json:"adminEmail"`
+	AdminPassword string `json:"adminPassword"`
+}
+
+// GetTenants handles listing all tenants
+// @Summary List tenants (Super Admin)
+// @Description Returns every tenant, oldest first. The default tenant is always listed.
+// @Tags Super Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.TenantListResponse "List of tenants"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve tenants"
+// @Router /superadmin/tenants [get]
+func (h *TenantHandler) GetTenants(c *fiber.Ctx) error {
+	tenants, err := h.Repo.GetAll()
+	if err != nil {
+		log.Printf("Error getting tenants: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve tenants", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.TenantListResponse{Tenants: tenants})
+}
+
+// CreateTenant handles provisioning a tenant together with its first admin account
+// @Summary Create a tenant (Super Admin)
+// @Description Creates a tenant and its first admin. The tenant is served on <slug>.<TENANT_BASE_DOMAIN> when subdomains are enabled, and to every user that logs in with an account of the tenant.
+// @Tags Super Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param tenant body CreateTenantRequest true "Tenant and initial admin"
+// @Success 201 {object} api.TenantCreatedResponse "Tenant created successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body, slug or missing fields"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "Slug already in use"
+// @Failure 500 {object} api.ErrorResponse "Failed to create tenant"
+// @Router /superadmin/tenants [post]
+func (h *TenantHandler) CreateTenant(c *fiber.Ctx) error {
+	var input CreateTenantRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	if input.Name == "" || input.AdminFullName == "" || input.AdminEmail == "" || input.AdminPassword == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Name, adminFullName, adminEmail, and adminPassword are required",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+	if !tenantSlugPattern.MatchString(input.Slug) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Slug must be 3-63 lowercase letters, digits or hyphens, and cannot start or end with a hyphen",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	_, err := h.Repo.GetBySlug(input.Slug)
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Slug already in use", StatusCode: fiber.StatusConflict})
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error checking tenant slug %s: %v", input.Slug, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create tenant", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	tenant := &models.Tenant{Slug: input.Slug, Name: input.Name, IsActive: true}
+	if err := h.Repo.Create(tenant); err != nil {
+		log.Printf("Error creating tenant %s: %v", input.Slug, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to create tenant", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	admin := &models.User{
+		Id:       uuid.New().String(),
+		Username: input.AdminUsername,
+		FullName: input.AdminFullName,
+		Email:    input.AdminEmail,
+		Password: input.AdminPassword, // Will be hashed in the repository
+		Role:     RoleAdmin,
+		IsActive: true,
+	}
+	scope, err := h.Scopes(tenant.ID)
+	if err == nil {
+		err = scope.Users.Create(c.Context(), admin)
+	}
+	if err != nil {
+		// The tenant exists but nobody can log in to it yet; report it so the admin can be added by hand
+		log.Printf("Error creating initial admin of tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Tenant created but the initial admin could not be created",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	admin.Password = ""
+
+	h.Audit.RecordAction(c, "CREATE_TENANT", AuditEntityTenant, tenant.ID,
+		fmt.Sprintf("Created tenant %s (%s) with admin %s", tenant.Slug, tenant.Name, admin.Email))
+
+	return c.Status(fiber.StatusCreated).JSON(api.TenantCreatedResponse{
+		Message: "Tenant created successfully",
+		Tenant:  tenant,
+		Admin:   admin,
+	})
+}
+
+// loadTenant fetches the tenant named by the :id parameter, writing the error
+// response itself when it fails
+func (h *TenantHandler) loadTenant(c *fiber.Ctx) (*models.Tenant, error) {
+	tenant, err := h.Repo.GetByID(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Tenant not found", StatusCode: fiber.StatusNotFound})
+		}
+		log.Printf("Error getting tenant %s: %v", c.Params("id"), err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve tenant", StatusCode: fiber.StatusInternalServerError})
+	}
+	return tenant, nil
+}
+
+// GetTenant handles fetching a single tenant
+// @Summary Get a tenant (Super Admin)
+// @Tags Super Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} api.SingleTenantResponse "Tenant"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Tenant not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve tenant"
+// @Router /superadmin/tenants/{id} [get]
+func (h *TenantHandler) GetTenant(c *fiber.Ctx) error {
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.SingleTenantResponse{Tenant: tenant})
+}
+
+// UpdateTenantRequest is the body of a tenant update. Omitted fields are left unchanged.
+type UpdateTenantRequest struct {
+	Name     *string `json:"name"`
+	IsActive *bool   `json:"isActive"`
+}
+
+// UpdateTenant handles renaming, suspending and reactivating a tenant
+// @Summary Update a tenant (Super Admin)
+// @Description Renames a tenant or changes whether it is active. Requests to an inactive tenant are rejected, which suspends it without deleting data. The default tenant cannot be deactivated.
+// @Tags Super Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Param tenant body UpdateTenantRequest true "Fields to change"
+// @Success 200 {object} api.TenantActionResponse "Tenant updated successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Tenant not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update tenant"
+// @Router /superadmin/tenants/{id} [put]
+func (h *TenantHandler) UpdateTenant(c *fiber.Ctx) error {
+	var input UpdateTenantRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+	before := *tenant
+
+	if input.Name != nil {
+		if strings.TrimSpace(*input.Name) == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Name cannot be empty", StatusCode: fiber.StatusBadRequest})
+		}
+		tenant.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.IsActive != nil {
+		if !*input.IsActive && tenant.ID == models.DefaultTenantID {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The default tenant cannot be deactivated", StatusCode: fiber.StatusBadRequest})
+		}
+		tenant.IsActive = *input.IsActive
+	}
+
+	if err := h.Repo.Update(tenant); err != nil {
+		log.Printf("Error updating tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update tenant", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityTenant, tenant.ID, before, *tenant)
+
+	return c.Status(fiber.StatusOK).JSON(api.TenantActionResponse{Message: "Tenant updated successfully", Tenant: tenant})
+}
+
+// DeleteTenant handles removing a tenant
+// @Summary Delete a tenant (Super Admin)
+// @Description Removes a deactivated tenant and its feature flags. The tenant's records stay in the database but can no longer be reached. The default tenant cannot be deleted.
+// @Tags Super Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} api.MessageResponse "Tenant deleted successfully"
+// @Failure 400 {object} api.ErrorResponse "The default tenant cannot be deleted"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Tenant not found"
+// @Failure 409 {object} api.ErrorResponse "Tenant is still active"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete tenant"
+// @Router /superadmin/tenants/{id} [delete]
+func (h *TenantHandler) DeleteTenant(c *fiber.Ctx) error {
+	if c.Params("id") == models.DefaultTenantID {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "The default tenant cannot be deleted", StatusCode: fiber.StatusBadRequest})
+	}
+
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+	if tenant.IsActive {
+		// Deactivating first gives a window to notice the mistake before access is gone for good
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Deactivate the tenant before deleting it", StatusCode: fiber.StatusConflict})
+	}
+
+	if err := h.Repo.Delete(tenant.ID); err != nil {
+		log.Printf("Error deleting tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to delete tenant", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordAction(c, "DELETE_TENANT", AuditEntityTenant, tenant.ID,
+		fmt.Sprintf("Deleted tenant %s (%s)", tenant.Slug, tenant.Name))
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{Message: "Tenant deleted successfully"})
+}
+
+// GetTenantUsage handles reporting how much a tenant uses the system
+// @Summary Get tenant usage (Super Admin)
+// @Description Counts the users, customers, inventory and sales of a tenant.
+// @Tags Super Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} api.TenantUsageResponse "Usage statistics"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Tenant not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve tenant usage"
+// @Router /superadmin/tenants/{id}/usage [get]
+func (h *TenantHandler) GetTenantUsage(c *fiber.Ctx) error {
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+
+	usage, err := h.Repo.GetUsage(tenant.ID)
+	if err != nil {
+		log.Printf("Error getting usage of tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve tenant usage", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.TenantUsageResponse{Usage: usage})
+}
+
+// GetTenantFeatures handles listing the feature flags of a tenant
+// @Summary Get tenant features (Super Admin)
+// @Description Returns every known feature and whether it is enabled for the tenant, with defaults applied.
+// @Tags Super Admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Success 200 {object} api.TenantFeaturesResponse "Feature flags"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Tenant not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to load tenant features"
+// @Router /superadmin/tenants/{id}/features [get]
+func (h *TenantHandler) GetTenantFeatures(c *fiber.Ctx) error {
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+
+	features, err := resolveFeatures(h.Repo, tenant.ID)
+	if err != nil {
+		log.Printf("Error loading features of tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to load tenant features", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.TenantFeaturesResponse{TenantID: tenant.ID, Features: features})
+}
+
+// UpdateTenantFeaturesRequest is the body of a feature flag update
+type UpdateTenantFeaturesRequest struct {
+	Features map[string]bool `json:"features"`
+}
+
+// UpdateTenantFeatures handles switching features of a tenant on or off
+// @Summary Update tenant features (Super Admin)
+// @Description Enables or disables the listed features for a tenant. Features not listed keep their value. Takes effect on the tenant's next request.
+// @Tags Super Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Param features body UpdateTenantFeaturesRequest true "Features to change"
+// @Success 200 {object} api.TenantFeaturesResponse "Updated feature flags"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or unknown feature"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Tenant not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update tenant features"
+// @Router /superadmin/tenants/{id}/features [put]
+func (h *TenantHandler) UpdateTenantFeatures(c *fiber.Ctx) error {
+	var input UpdateTenantFeaturesRequest
+	if err := c.BodyParser(&input); err != nil || len(input.Features) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body: features are required", StatusCode: fiber.StatusBadRequest})
+	}
+	for name := range input.Features {
+		if _, ok := featureDefaults[name]; !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+				Error:      fmt.Sprintf("Unknown feature %q; known features are %s", name, strings.Join(knownFeatures(), ", ")),
+				StatusCode: fiber.StatusBadRequest,
+			})
+		}
+	}
+
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+
+	before, err := resolveFeatures(h.Repo, tenant.ID)
+	if err != nil {
+		log.Printf("Error loading features of tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update tenant features", StatusCode: fiber.StatusInternalServerError})
+	}
+	for name, enabled := range input.Features {
+		if err := h.Repo.SetFeature(tenant.ID, name, enabled); err != nil {
+			log.Printf("Error setting feature %s of tenant %s: %v", name, tenant.ID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update tenant features", StatusCode: fiber.StatusInternalServerError})
+		}
+	}
+	after, err := resolveFeatures(h.Repo, tenant.ID)
+	if err != nil {
+		log.Printf("Error loading features of tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to load tenant features", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	h.Audit.RecordUpdate(c, AuditEntityTenant, tenant.ID, before, after)
+
+	return c.Status(fiber.StatusOK).JSON(api.TenantFeaturesResponse{TenantID: tenant.ID, Features: after})
+}
+
+// ImpersonateRequest selects the admin to impersonate. Without a user ID the first
+// active admin of the tenant is used.
+type ImpersonateRequest struct {
+	UserID string `json:"userId"`
+	Reason string `json:"reason"`
+}
+
+// ImpersonateTenantAdmin handles issuing a token that acts as a tenant admin
+// @Summary Impersonate a tenant admin (Super Admin)
+// @Description Issues a 30-minute token for an active admin of the tenant, e.g. to reproduce a support issue. A reason is required. The impersonation is logged in both the platform and the tenant's activity log, and every change made with the token names the super admin.
+// @Tags Super Admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Tenant ID"
+// @Param request body ImpersonateRequest true "Admin to impersonate and reason"
+// @Success 200 {object} api.ImpersonationResponse "Impersonation token"
+// @Failure 400 {object} api.ErrorResponse "Missing reason, or the user is not an active admin"
+// @Failure 403 {object} api.ErrorResponse "Permission denied or tenant inactive"
+// @Failure 404 {object} api.ErrorResponse "Tenant or admin not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to impersonate tenant admin"
+// @Router /superadmin/tenants/{id}/impersonate [post]
+func (h *TenantHandler) ImpersonateTenantAdmin(c *fiber.Ctx) error {
+	var input ImpersonateRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if strings.TrimSpace(input.Reason) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "A reason is required to impersonate a tenant admin", StatusCode: fiber.StatusBadRequest})
+	}
+
+	tenant, err := h.loadTenant(c)
+	if tenant == nil {
+		return err
+	}
+	if !tenant.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Tenant is inactive", StatusCode: fiber.StatusForbidden})
+	}
+
+	scope, err := h.Scopes(tenant.ID)
+	if err != nil {
+		log.Printf("Error loading repositories of tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to impersonate tenant admin", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	admin, status, message := findTenantAdmin(c.Context(), scope.Users, input.UserID)
+	if admin == nil {
+		if status == fiber.StatusInternalServerError {
+			log.Printf("Error finding admin of tenant %s: %s", tenant.ID, message)
+			message = "Failed to impersonate tenant admin"
+		}
+		return c.Status(status).JSON(api.ErrorResponse{Error: message, StatusCode: status})
+	}
+
+	superAdminID, _ := c.Locals("user_id").(string)
+	expiresAt := time.Now().Add(impersonationTTL)
+	claims := jwt.MapClaims{
+		"user_id":         admin.Id,
+		"email":           admin.Email,
+		"role":            admin.Role,
+		"tenant_id":       tenant.ID,
+		"impersonated_by": superAdminID,
+		"exp":             expiresAt.Unix(),
+		"iat":             time.Now().Unix(),
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(h.jwtSecret)
+	if err != nil {
+		log.Printf("Error signing impersonation token for tenant %s: %v", tenant.ID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to impersonate tenant admin", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	details := fmt.Sprintf("Super admin %s impersonated %s of tenant %s until %s: %s",
+		superAdminID, admin.Email, tenant.Slug, expiresAt.UTC().Format(time.RFC3339), input.Reason)
+	h.Audit.RecordAction(c, "IMPERSONATE_TENANT_ADMIN", AuditEntityTenant, tenant.ID, details)
+	// The tenant's own log shows the impersonation too, so its admins can see who acted as them
+	NewChangeRecorder(scope.Logs).RecordAction(c, "IMPERSONATE_TENANT_ADMIN", AuditEntityUser, admin.Id, details)
+
+	admin.Password = ""
+	return c.Status(fiber.StatusOK).JSON(api.ImpersonationResponse{
+		Message:   "Impersonation token issued",
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      admin,
+	})
+}
+
+// findTenantAdmin returns the active admin to impersonate, or the status and message
+// to respond with when there is none
+func findTenantAdmin(ctx context.Context, users UserRepository, userID string) (*models.User, int, string) {
+	if userID != "" {
+		user, err := users.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fiber.StatusNotFound, "User not found in this tenant"
+		}
+		if user.Role != RoleAdmin || !user.IsActive {
+			return nil, fiber.StatusBadRequest, "Only active admins can be impersonated"
+		}
+		return user, 0, ""
+	}
+
+	all, err := users.GetAll(ctx)
+	if err != nil {
+		return nil, fiber.StatusInternalServerError, err.Error()
+	}
+	for _, user := range all {
+		if user.Role == RoleAdmin && user.IsActive {
+			return user, 0, ""
+		}
+	}
+	return nil, fiber.StatusNotFound, "The tenant has no active admin"
+}
@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTenantTestToken creates a token for a user of the given tenant
+func createTenantTestToken(secret []byte, userID, userRole, tenantID string) string {
+	claims := jwt.MapClaims{
+		"user_id":   userID,
+		"role":      userRole,
+		"tenant_id": tenantID,
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	return token
+}
+
+// setupTenantTestApp registers the super-admin routes on in-memory tenants, each
+// tenant getting its own store the first time it is used.
+func setupTenantTestApp(t *testing.T) (*fiber.App, *memory.TenantRepository, []byte) {
+	jwtSecret := []byte("testsecret")
+	tenants := memory.NewTenantRepository()
+	scopes := func(tenantID string) (TenantScope, error) {
+		store := tenants.Store(tenantID)
+		return TenantScope{Users: store.Users, Logs: store.Logs}, nil
+	}
+
+	h := NewTenantHandler(tenants, scopes, jwtSecret)
+	h.Audit = NewChangeRecorder(tenants.Store(models.DefaultTenantID).Logs)
+
+	app := fiber.New()
+	h.Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+	return app, tenants, jwtSecret
+}
+
+// superAdminRequest sends a request to the super-admin routes with a JSON body when input is not nil
+func superAdminRequest(t *testing.T, app *fiber.App, token, method, path string, input interface{}) *http.Response {
+	var body *bytes.Reader
+	if input != nil {
+		payload, _ := json.Marshal(input)
+		body = bytes.NewReader(payload)
+	} else {
+		body = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func postTenant(t *testing.T, app *fiber.App, token string, input CreateTenantRequest) *http.Response {
+	return superAdminRequest(t, app, token, http.MethodPost, "/api/superadmin/tenants", input)
+}
+
+func validTenantRequest() CreateTenantRequest {
+	return CreateTenantRequest{
+		Slug:          "acme",
+		Name:          "Acme Motors",
+		AdminFullName: "Acme Admin",
+		AdminUsername: "acme-admin",
+		AdminEmail:    "admin@acme.example.com",
+		AdminPassword: "password123",
+	}
+}
+
+func TestCreateTenant(t *testing.T) {
+	app, tenants, jwtSecret := setupTenantTestApp(t)
+	token := createTenantTestToken(jwtSecret, "root-1", RoleSuperAdmin, models.DefaultTenantID)
+
+	resp := postTenant(t, app, token, validTenantRequest())
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created api.TenantCreatedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, "acme", created.Tenant.Slug)
+	assert.True(t, created.Tenant.IsActive)
+	assert.Empty(t, created.Admin.Password)
+
+	// The admin belongs to the new tenant only
+	admin, err := tenants.Store(created.Tenant.ID).Users.GetByEmail(context.Background(), "admin@acme.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, RoleAdmin, admin.Role)
+	_, err = tenants.Store(models.DefaultTenantID).Users.GetByEmail(context.Background(), "admin@acme.example.com")
+	assert.Error(t, err)
+
+	logs, _, err := tenants.Store(models.DefaultTenantID).Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	actions := make([]string, 0, len(logs))
+	for _, entry := range logs {
+		actions = append(actions, entry.Action)
+	}
+	assert.ElementsMatch(t, []string{"CREATE_TENANT", "SUPERADMIN_REQUEST"}, actions)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/superadmin/tenants", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var list api.TenantListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Len(t, list.Tenants, 2)
+}
+
+func TestCreateTenantValidation(t *testing.T) {
+	app, _, jwtSecret := setupTenantTestApp(t)
+	token := createTenantTestToken(jwtSecret, "root-1", RoleSuperAdmin, models.DefaultTenantID)
+
+	require.Equal(t, http.StatusCreated, postTenant(t, app, token, validTenantRequest()).StatusCode)
+
+	tests := []struct {
+		name   string
+		modify func(*CreateTenantRequest)
+		status int
+	}{
+		{"Duplicate slug", func(r *CreateTenantRequest) {}, http.StatusConflict},
+		{"Invalid slug", func(r *CreateTenantRequest) { r.Slug = "Acme_Motors" }, http.StatusBadRequest},
+		{"Slug ends with hyphen", func(r *CreateTenantRequest) { r.Slug = "acme-" }, http.StatusBadRequest},
+		{"Missing admin email", func(r *CreateTenantRequest) { r.Slug = "other"; r.AdminEmail = "" }, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			input := validTenantRequest()
+			tc.modify(&input)
+			assert.Equal(t, tc.status, postTenant(t, app, token, input).StatusCode)
+		})
+	}
+}
+
+func TestSuperAdminRoutesForbidden(t *testing.T) {
+	app, tenants, jwtSecret := setupTenantTestApp(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"Tenant admin", createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)},
+		{"Superadmin of another tenant", createTenantTestToken(jwtSecret, "root-2", RoleSuperAdmin, "acme")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/superadmin/tenants", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		})
+	}
+
+	// Denied attempts are audited as well
+	logs, _, err := tenants.Store(models.DefaultTenantID).Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, len(tests))
+	for _, entry := range logs {
+		assert.Equal(t, "FAILED", entry.Status)
+	}
+}
+
+func TestRegisterRejectsSuperAdminRole(t *testing.T) {
+	store := memory.NewStore()
+	app := fiber.New()
+	NewUserHandler(store.Users, []byte("testsecret")).Routes(app.Group("/api"), NewRouteMiddleware([]byte("testsecret")))
+
+	body, _ := json.Marshal(fiber.Map{
+		"username": "root", "fullName": "Root", "email": "root@example.com",
+		"password": "password123", "role": RoleSuperAdmin,
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/users/register", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+// createTestTenant provisions the acme tenant and returns its ID
+func createTestTenant(t *testing.T, app *fiber.App, token string) string {
+	resp := postTenant(t, app, token, validTenantRequest())
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created api.TenantCreatedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created.Tenant.ID
+}
+
+func TestUpdateAndDeleteTenant(t *testing.T) {
+	app, tenants, jwtSecret := setupTenantTestApp(t)
+	token := createTenantTestToken(jwtSecret, "root-1", RoleSuperAdmin, models.DefaultTenantID)
+	tenantID := createTestTenant(t, app, token)
+	path := "/api/superadmin/tenants/" + tenantID
+
+	resp := superAdminRequest(t, app, token, http.MethodDelete, path, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "active tenants cannot be deleted")
+
+	resp = superAdminRequest(t, app, token, http.MethodPut, path, fiber.Map{"name": "Acme Trading", "isActive": false})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	tenant, err := tenants.GetByID(tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Trading", tenant.Name)
+	assert.False(t, tenant.IsActive)
+
+	resp = superAdminRequest(t, app, token, http.MethodDelete, path, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = superAdminRequest(t, app, token, http.MethodGet, path, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	defaultPath := "/api/superadmin/tenants/" + models.DefaultTenantID
+	resp = superAdminRequest(t, app, token, http.MethodPut, defaultPath, fiber.Map{"isActive": false})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = superAdminRequest(t, app, token, http.MethodDelete, defaultPath, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestGetTenantUsage(t *testing.T) {
+	app, _, jwtSecret := setupTenantTestApp(t)
+	token := createTenantTestToken(jwtSecret, "root-1", RoleSuperAdmin, models.DefaultTenantID)
+	tenantID := createTestTenant(t, app, token)
+
+	resp := superAdminRequest(t, app, token, http.MethodGet, "/api/superadmin/tenants/"+tenantID+"/usage", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body api.TenantUsageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, tenantID, body.Usage.TenantID)
+	assert.Equal(t, 1, body.Usage.Users, "the initial admin")
+	assert.Equal(t, 1, body.Usage.ActiveUsers)
+	assert.Zero(t, body.Usage.Sales)
+	assert.Nil(t, body.Usage.LastSaleAt)
+}
+
+func TestUpdateTenantFeatures(t *testing.T) {
+	app, tenants, jwtSecret := setupTenantTestApp(t)
+	token := createTenantTestToken(jwtSecret, "root-1", RoleSuperAdmin, models.DefaultTenantID)
+	tenantID := createTestTenant(t, app, token)
+	path := "/api/superadmin/tenants/" + tenantID + "/features"
+
+	resp := superAdminRequest(t, app, token, http.MethodPut, path, fiber.Map{"features": fiber.Map{"teleport": true}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = superAdminRequest(t, app, token, http.MethodPut, path, fiber.Map{"features": fiber.Map{FeatureSSO: false}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body api.TenantFeaturesResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.False(t, body.Features[FeatureSSO])
+	assert.True(t, body.Features[FeatureUserProvisioning], "unlisted features keep their default")
+
+	// The gate only blocks the tenant that has the feature disabled
+	features := NewFeatureFlags(tenants, jwtSecret)
+	gated := fiber.New()
+	gated.Use(func(c *fiber.Ctx) error {
+		c.Locals("tenant_id", c.Get("X-Tenant"))
+		return c.Next()
+	})
+	gated.Get("/sso", features.Require(FeatureSSO), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	for tenant, status := range map[string]int{tenantID: http.StatusForbidden, models.DefaultTenantID: http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/sso", nil)
+		req.Header.Set("X-Tenant", tenant)
+		resp, err := gated.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, status, resp.StatusCode, tenant)
+	}
+}
+
+func TestImpersonateTenantAdmin(t *testing.T) {
+	app, tenants, jwtSecret := setupTenantTestApp(t)
+	token := createTenantTestToken(jwtSecret, "root-1", RoleSuperAdmin, models.DefaultTenantID)
+	tenantID := createTestTenant(t, app, token)
+	path := "/api/superadmin/tenants/" + tenantID + "/impersonate"
+
+	resp := superAdminRequest(t, app, token, http.MethodPost, path, fiber.Map{})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "a reason is required")
+
+	resp = superAdminRequest(t, app, token, http.MethodPost, path, fiber.Map{"reason": "Ticket 42: sales report totals"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body api.ImpersonationResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "admin@acme.example.com", body.User.Email)
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(body.Token, claims, func(*jwt.Token) (interface{}, error) { return jwtSecret, nil })
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, claims["tenant_id"])
+	assert.Equal(t, RoleAdmin, claims["role"])
+	assert.Equal(t, "root-1", claims["impersonated_by"])
+
+	// The tenant's own log records who acted as its admin
+	logs, _, err := tenants.Store(tenantID).Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	if assert.Len(t, logs, 1) {
+		assert.Equal(t, "IMPERSONATE_TENANT_ADMIN", logs[0].Action)
+		assert.Contains(t, logs[0].Details, "Ticket 42")
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Limits of the trash listing and of bulk restore and purge requests
+const (
+	defaultTrashLimit       = 100
+	maxTrashLimit           = 500
+	maxTrashItemsPerRequest = 100
+)
+
+// errNotInTrash is reported for records that are not deleted, or do not exist
+const errNotInTrash = "Record is not in the trash"
+
+// includeDeletedParam is the query parameter, and the Locals key, with which admins
+// list the deleted cabs, accessories or materials along with the others
+const includeDeletedParam = "include_deleted"
+
+// TrashHandler lists the customers, cabs, accessories and materials that were deleted
+// and lets admins restore or purge them in bulk
+type TrashHandler struct {
+	Repo      repositories.TrashRepository
+	Audit     *ChangeRecorder
+	jwtSecret []byte
+}
+
+// NewTrashHandler creates a new TrashHandler instance
+func NewTrashHandler(repo repositories.TrashRepository, jwtSecret []byte) *TrashHandler {
+	return &TrashHandler{Repo: repo, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin trash routes
+func (h *TrashHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	trashGroup := r.Group("/admin/trash", mw.Auth, mw.Admin)
+	trashGroup.Get("/", h.GetTrash)             // GET /api/admin/trash
+	trashGroup.Post("/restore", h.RestoreTrash) // POST /api/admin/trash/restore
+	trashGroup.Post("/purge", h.PurgeTrash)     // POST /api/admin/trash/purge
+}
+
+// Deleted records who deleted a record that was just soft-deleted. A nil handler
+// records nothing.
+func (h *TrashHandler) Deleted(c *fiber.Ctx, entityType, entityID string) {
+	if h == nil || h.Repo == nil {
+		return
+	}
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.Repo.SetDeletedBy(entityType, entityID, userID); err != nil {
+		log.Printf("Error recording who deleted %s %s: %v", entityType, entityID, err)
+	}
+}
+
+// IncludeDeleted lets admins list the deleted records of a list route along with the
+// others with ?include_deleted=true; anyone else gets 403. Filters and paging apply to
+// the live records only, so they cannot be combined with it.
+func IncludeDeleted(jwtSecret []byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !c.QueryBool(includeDeletedParam) {
+			return c.Next()
+		}
+		if middleware.BearerRole(c, jwtSecret) != RoleAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Only admins can list deleted records", StatusCode: fiber.StatusForbidden})
+		}
+		if len(c.Queries()) > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "include_deleted cannot be combined with filters or paging", StatusCode: fiber.StatusBadRequest})
+		}
+		c.Locals(includeDeletedParam, true)
+		return c.Next()
+	}
+}
+
+// includeDeleted reports whether an admin asked for the deleted records too
+func includeDeleted(c *fiber.Ctx) bool {
+	include, _ := c.Locals(includeDeletedParam).(bool)
+	return include
+}
+
+// validTrashType reports whether records of a type are kept in the trash
+func validTrashType(entityType string) bool {
+	switch entityType {
+	case models.TrashCustomer, models.TrashCab, models.TrashAccessory, models.TrashMaterial:
+		return true
+	}
+	return false
+}
+
+// TrashItemRef identifies a deleted record
+type TrashItemRef struct {
+	EntityType string `json:"entityType"` // customer, cab, accessory or material
+	EntityID   string `json:"entityId"`
+}
+
+// TrashActionRequest is the body for restoring or purging deleted records
+type TrashActionRequest struct {
+	Items []TrashItemRef `json:"items"`
+}
+
+func (r TrashActionRequest) validate() error {
+	if len(r.Items) == 0 {
+		return errors.New("At least one item is required")
+	}
+	if len(r.Items) > maxTrashItemsPerRequest {
+		return fmt.Errorf("A maximum of %d items can be processed per request", maxTrashItemsPerRequest)
+	}
+	for _, item := range r.Items {
+		if !validTrashType(item.EntityType) {
+			return fmt.Errorf("entityType must be %s, %s, %s or %s", models.TrashCustomer, models.TrashCab, models.TrashAccessory, models.TrashMaterial)
+		}
+		if strings.TrimSpace(item.EntityID) == "" {
+			return errors.New("entityId is required")
+		}
+	}
+	return nil
+}
+
+// GetTrash handles listing deleted records
+// @Summary List deleted records (Admin)
+// @Description Lists the deleted customers, cabs, accessories and materials that can still be restored or purged, most recently deleted first, with who deleted them and when.
+// @Tags Trash
+// @Produce json
+// @Security ApiKeyAuth
+// @Param type query string false "Only records of this type: customer, cab, accessory or material"
+// @Param limit query int false "Maximum number of records (default 100, max 500)"
+// @Success 200 {object} api.TrashListResponse "Deleted records"
+// @Failure 400 {object} api.ErrorResponse "Invalid type or limit"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve trash"
+// @Router /admin/trash [get]
+func (h *TrashHandler) GetTrash(c *fiber.Ctx) error {
+	entityType := c.Query("type")
+	if entityType != "" && !validTrashType(entityType) {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "type must be customer, cab, accessory or material", StatusCode: fiber.StatusBadRequest})
+	}
+
+	limit := defaultTrashLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxTrashLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: fmt.Sprintf("limit must be between 1 and %d", maxTrashLimit), StatusCode: fiber.StatusBadRequest})
+		}
+		limit = parsed
+	}
+
+	items, err := h.Repo.List(entityType, limit)
+	if err != nil {
+		log.Printf("Error listing trash: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve trash", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.TrashListResponse{Items: items, Count: len(items)})
+}
+
+// RestoreTrash handles restoring deleted records in bulk
+// @Summary Restore deleted records (Admin)
+// @Description Restores up to 100 deleted customers, cabs, accessories or materials. Each record is restored on its own; the results report which ones failed.
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param items body TrashActionRequest true "Records to restore"
+// @Success 200 {object} api.TrashActionResponse "Per-record results"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Router /admin/trash/restore [post]
+func (h *TrashHandler) RestoreTrash(c *fiber.Ctx) error {
+	return h.processTrash(c, trashRestore, h.Repo.Restore)
+}
+
+// PurgeTrash handles removing deleted records for good
+// @Summary Purge deleted records (Admin)
+// @Description Permanently removes up to 100 deleted customers, cabs, accessories or materials. Records still referenced, such as customers with sales, are reported as failed and stay in the trash.
+// @Tags Trash
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param items body TrashActionRequest true "Records to purge"
+// @Success 200 {object} api.TrashActionResponse "Per-record results"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Router /admin/trash/purge [post]
+func (h *TrashHandler) PurgeTrash(c *fiber.Ctx) error {
+	return h.processTrash(c, trashPurge, h.Repo.Purge)
+}
+
+// trashAction describes a bulk restore or purge
+type trashAction struct {
+	name        string // For log messages
+	done        api.TrashActionStatus
+	auditAction string
+	failure     string // Reported for records the action failed on
+}
+
+var (
+	trashRestore = trashAction{name: "restore", done: api.TrashStatusRestored, auditAction: "RESTORE_DELETED", failure: "Record could not be restored"}
+	trashPurge   = trashAction{name: "purge", done: api.TrashStatusPurged, auditAction: "PURGE_DELETED", failure: "Record could not be purged; it may still be referenced by other records"}
+)
+
+// processTrash applies a restore or purge to every record in the request body
+func (h *TrashHandler) processTrash(c *fiber.Ctx, action trashAction, apply func(entityType, entityID string) error) error {
+	var input TrashActionRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: "Invalid request body", StatusCode: fiber.StatusBadRequest})
+	}
+	if err := input.validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{Error: err.Error(), StatusCode: fiber.StatusBadRequest})
+	}
+
+	response := api.TrashActionResponse{Results: make([]api.TrashActionResult, 0, len(input.Items))}
+	for _, item := range input.Items {
+		id := strings.TrimSpace(item.EntityID)
+		result := api.TrashActionResult{EntityType: item.EntityType, EntityID: id, Status: action.done}
+		if err := apply(item.EntityType, id); err != nil {
+			result.Status = api.TrashStatusFailed
+			result.Error = action.failure
+			if errors.Is(err, sql.ErrNoRows) {
+				result.Error = errNotInTrash
+			} else {
+				log.Printf("Error trying to %s %s %s: %v", action.name, item.EntityType, id, err)
+			}
+			response.Failed++
+		} else {
+			response.Succeeded++
+			h.Audit.RecordAction(c, action.auditAction, item.EntityType, id, fmt.Sprintf("%s %s %s from the trash", action.done, item.EntityType, id))
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	response.Message = fmt.Sprintf("%d of %d records %s", response.Succeeded, len(input.Items), action.done)
+	return c.Status(fiber.StatusOK).JSON(response)
+}
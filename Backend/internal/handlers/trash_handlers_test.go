@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupTrashTestApp registers the customer, material and trash routes on an in-memory store
+func setupTrashTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	trash := NewTrashHandler(store.Trash, jwtSecret)
+	trash.Audit = NewChangeRecorder(store.Logs)
+	customers := NewCustomerHandler(store.Customers, jwtSecret)
+	customers.Trash = trash
+	materials := NewMaterialHandlers(store.Materials, jwtSecret)
+	materials.Trash = trash
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	customers.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	materials.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	trash.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+func TestTrashListsDeletedRecords(t *testing.T) {
+	app, store, jwtSecret := setupTrashTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Quantity: 3})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, staffToken, http.MethodDelete, "/api/customers/"+customer.ID, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodDelete, "/api/materials/"+strconv.Itoa(materialID), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/admin/trash", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	for _, query := range []string{"?type=sale", "?limit=0", "?limit=501"} {
+		resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/trash"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/trash", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var list api.TrashListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	require.Equal(t, 2, list.Count)
+	assert.Equal(t, models.TrashMaterial, list.Items[0].EntityType)
+	assert.Equal(t, "admin-1", list.Items[0].DeletedBy)
+	assert.Equal(t, "Juan Dela Cruz", list.Items[1].Name)
+	assert.Equal(t, "staff-1", list.Items[1].DeletedBy)
+	assert.False(t, list.Items[1].DeletedAt.IsZero())
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/admin/trash?type=customer", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, 1, list.Count)
+}
+
+func TestTrashBulkRestoreAndPurge(t *testing.T) {
+	app, store, jwtSecret := setupTrashTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	require.NoError(t, store.Customers.DeleteCustomer(context.Background(), customer.ID))
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side Mirror", Make: "Toyota", Quantity: 2, Price: 500, UnitColor: "Black"})
+	require.NoError(t, err)
+	require.NoError(t, store.Accessories.Delete(context.Background(), accessoryID))
+	accessory := TrashItemRef{EntityType: models.TrashAccessory, EntityID: strconv.Itoa(accessoryID)}
+
+	for name, input := range map[string]TrashActionRequest{
+		"no items":     {},
+		"unknown type": {Items: []TrashItemRef{{EntityType: "sale", EntityID: "1"}}},
+		"no id":        {Items: []TrashItemRef{{EntityType: models.TrashCustomer}}},
+	} {
+		resp := authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/trash/restore", input)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, name)
+	}
+	resp := authedRequest(t, app, staffToken, http.MethodPost, "/api/admin/trash/purge", TrashActionRequest{Items: []TrashItemRef{accessory}})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/trash/restore", TrashActionRequest{Items: []TrashItemRef{
+		{EntityType: models.TrashCustomer, EntityID: customer.ID},
+		{EntityType: models.TrashMaterial, EntityID: "99"},
+	}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var restored api.TrashActionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&restored))
+	assert.Equal(t, 1, restored.Succeeded)
+	assert.Equal(t, 1, restored.Failed)
+	assert.Equal(t, api.TrashStatusRestored, restored.Results[0].Status)
+	assert.Equal(t, api.TrashStatusFailed, restored.Results[1].Status)
+	assert.Equal(t, errNotInTrash, restored.Results[1].Error)
+	_, err = store.Customers.GetCustomerByID(context.Background(), customer.ID)
+	assert.NoError(t, err)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/admin/trash/purge", TrashActionRequest{Items: []TrashItemRef{accessory}})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var purged api.TrashActionResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&purged))
+	assert.Equal(t, 1, purged.Succeeded)
+	assert.Equal(t, api.TrashStatusPurged, purged.Results[0].Status)
+	items, err := store.Trash.List("", 10)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	actions := []string{logs[0].Action, logs[1].Action}
+	assert.ElementsMatch(t, []string{"RESTORE_DELETED", "PURGE_DELETED"}, actions)
+}
+
+func TestSoftDeletedInventoryListedAndRestored(t *testing.T) {
+	app, store, jwtSecret := setupTrashTestApp(t)
+	trash := NewTrashHandler(store.Trash, jwtSecret)
+	cabs := NewCabsHandlers(store.Cabs)
+	cabs.Trash = trash
+	cabs.Audit = NewChangeRecorder(store.Logs)
+	accessories := NewAccessoriesHandler(store.Accessories)
+	auth := middleware.JWTMiddleware(jwtSecret)
+	app.Get("/api/cabs", IncludeDeleted(jwtSecret), cabs.GetCabs)
+	app.Delete("/api/cabs/:id", auth, cabs.DeleteCab)
+	app.Post("/api/cabs/:id/restore", auth, requireAdmin, cabs.RestoreCab)
+	app.Get("/api/accessories", IncludeDeleted(jwtSecret), accessories.GetAllAccessories)
+	app.Post("/api/accessories/:id/restore", auth, requireAdmin, accessories.RestoreAccessory)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	kept, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Kept", Make: "Suzuki", Quantity: 1, Price: 1000, Status: "In Stock", UnitColor: "Red"})
+	require.NoError(t, err)
+	retired, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Retired", Make: "Suzuki", Quantity: 2, Price: 2000, Status: "In Stock", UnitColor: "White"})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side Mirror", Make: "Toyota", Quantity: 2, Price: 500, UnitColor: "Black"})
+	require.NoError(t, err)
+	require.NoError(t, store.Accessories.Delete(context.Background(), accessoryID))
+
+	resp := authedRequest(t, app, staffToken, http.MethodDelete, "/api/cabs/"+strconv.Itoa(retired.ID), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	items, err := store.Trash.List(models.TrashCab, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "staff-1", items[0].DeletedBy)
+
+	resp = authedRequest(t, app, "", http.MethodGet, "/api/cabs", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var listed []models.MultiCab
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Len(t, listed, 1, "deleted cabs are left out of the list")
+	assert.Equal(t, kept.ID, listed[0].ID)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/cabs?include_deleted=true", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/cabs?include_deleted=true&make=Suzuki", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/cabs?include_deleted=true", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&listed))
+	require.Len(t, listed, 2)
+	assert.Nil(t, listed[0].DeletedAt)
+	assert.Equal(t, retired.ID, listed[1].ID)
+	assert.NotNil(t, listed[1].DeletedAt)
+
+	resp = authedRequest(t, app, adminToken, http.MethodGet, "/api/accessories?include_deleted=true", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var accessoryList struct {
+		Data  []models.Accessory `json:"data"`
+		Count int                `json:"count"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accessoryList))
+	require.Equal(t, 1, accessoryList.Count)
+	assert.NotNil(t, accessoryList.Data[0].DeletedAt)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/cabs/"+strconv.Itoa(retired.ID)+"/restore", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/cabs/"+strconv.Itoa(kept.ID)+"/restore", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "only deleted cabs can be restored")
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/cabs/"+strconv.Itoa(retired.ID)+"/restore", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var restored models.MultiCab
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&restored))
+	assert.Equal(t, 2, restored.Quantity)
+	assert.Nil(t, restored.DeletedAt)
+	_, err = store.Cabs.GetCabByID(context.Background(), retired.ID)
+	assert.NoError(t, err)
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/accessories/"+strconv.Itoa(accessoryID)+"/restore", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/materials/99/restore", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	items, err = store.Trash.List("", 10)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "RESTORE_DELETED", logs[0].Action)
+}
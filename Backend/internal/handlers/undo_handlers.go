@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"oop/internal/api"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Headers a delete is answered with when it can be undone
+const (
+	HeaderUndoToken     = "X-Undo-Token"
+	HeaderUndoExpiresAt = "X-Undo-Expires-At"
+)
+
+// UndoHandler lets users restore a customer, accessory or material they deleted by
+// mistake. Deletes of those records answer with an undo token, which restores the
+// record until the undo window has passed.
+type UndoHandler struct {
+	Window      *services.UndoWindow
+	Customers   repositories.CustomerRepository
+	Accessories repositories.AccessoryRepository
+	Materials   repositories.MaterialRepository
+	Audit       *ChangeRecorder
+	jwtSecret   []byte
+}
+
+// NewUndoHandler creates a new UndoHandler instance
+func NewUndoHandler(window *services.UndoWindow, customers repositories.CustomerRepository, accessories repositories.AccessoryRepository, materials repositories.MaterialRepository, jwtSecret []byte) *UndoHandler {
+	return &UndoHandler{Window: window, Customers: customers, Accessories: accessories, Materials: materials, jwtSecret: jwtSecret}
+}
+
+// Routes registers the undo route
+func (h *UndoHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/undo/:token", mw.Auth, h.Undo) // POST /api/undo/:token
+}
+
+// Offer issues an undo token for a record that was just deleted and adds it to the
+// response headers. A nil handler, or one with undo disabled, offers nothing.
+func (h *UndoHandler) Offer(c *fiber.Ctx, entityType, entityID string) {
+	if h == nil || !h.Window.Enabled() {
+		return
+	}
+
+	// The ticket outlives the request, so it must not keep strings backed by its buffers
+	userID, _ := c.Locals("user_id").(string)
+	token, ticket, err := h.Window.Issue(services.UndoTicket{
+		TenantID:   strings.Clone(tenantIDFromCtx(c)),
+		EntityType: entityType,
+		EntityID:   strings.Clone(entityID),
+		DeletedBy:  strings.Clone(userID),
+	})
+	if err != nil {
+		log.Printf("Error issuing undo token for %s %s: %v", entityType, entityID, err)
+		return
+	}
+	c.Set(HeaderUndoToken, token)
+	c.Set(HeaderUndoExpiresAt, ticket.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// Undo handles restoring a deleted record
+// @Summary Undo a delete
+// @Description Restores the customer, accessory or material deleted by the request that returned the token in its X-Undo-Token header. Tokens are valid for UNDO_WINDOW_MINUTES (5 by default) and can be used once, by the user who deleted the record or an admin.
+// @Tags Undo
+// @Produce json
+// @Security ApiKeyAuth
+// @Param token path string true "Undo token"
+// @Success 200 {object} api.UndoResponse "Record restored"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "Undo token not found or expired"
+// @Failure 409 {object} api.ErrorResponse "Record is no longer deleted"
+// @Failure 500 {object} api.ErrorResponse "Failed to restore record"
+// @Router /undo/{token} [post]
+func (h *UndoHandler) Undo(c *fiber.Ctx) error {
+	token := c.Params("token")
+	ticket, ok := h.Window.Get(token)
+	if !ok || ticket.TenantID != tenantIDFromCtx(c) {
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{Error: "Undo token not found or expired", StatusCode: fiber.StatusNotFound})
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(string)
+	if ticket.DeletedBy != "" && ticket.DeletedBy != userID && role != RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied: only the user who deleted the record or an admin can undo it", StatusCode: fiber.StatusForbidden})
+	}
+
+	if err := h.restore(c.Context(), ticket); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.Window.Revoke(token)
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{Error: "Record is no longer deleted", StatusCode: fiber.StatusConflict})
+		}
+		log.Printf("Error restoring %s %s: %v", ticket.EntityType, ticket.EntityID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to restore record", StatusCode: fiber.StatusInternalServerError})
+	}
+	h.Window.Revoke(token)
+
+	h.Audit.RecordAction(c, "UNDO_DELETE", ticket.EntityType, ticket.EntityID, fmt.Sprintf("Restored deleted %s %s", ticket.EntityType, ticket.EntityID))
+	return c.Status(fiber.StatusOK).JSON(api.UndoResponse{Message: "Record restored", EntityType: ticket.EntityType, EntityID: ticket.EntityID})
+}
+
+// restore clears the deletion of the record a ticket was issued for
+func (h *UndoHandler) restore(ctx context.Context, ticket services.UndoTicket) error {
+	if ticket.EntityType == AuditEntityCustomer {
+		return h.Customers.RestoreCustomer(ctx, ticket.EntityID)
+	}
+
+	id, err := strconv.Atoi(ticket.EntityID)
+	if err != nil {
+		return fmt.Errorf("invalid %s ID %q: %w", ticket.EntityType, ticket.EntityID, err)
+	}
+	switch ticket.EntityType {
+	case AuditEntityAccessory:
+		return h.Accessories.Restore(ctx, id)
+	case AuditEntityMaterial:
+		return h.Materials.Restore(ctx, id)
+	default:
+		return fmt.Errorf("cannot restore %s records", ticket.EntityType)
+	}
+}
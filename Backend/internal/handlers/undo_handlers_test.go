@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupUndoTestApp registers the customer, accessory, material and undo routes on an
+// in-memory store, with a 5 minute undo window
+func setupUndoTestApp(t *testing.T) (*fiber.App, *memory.Store, []byte) {
+	jwtSecret := []byte("testsecret")
+	store := memory.NewStore()
+	undo := NewUndoHandler(services.NewUndoWindow(5*time.Minute), store.Customers, store.Accessories, store.Materials, jwtSecret)
+	undo.Audit = NewChangeRecorder(store.Logs)
+	customers := NewCustomerHandler(store.Customers, jwtSecret)
+	customers.Undo = undo
+	accessories := NewAccessoriesHandler(store.Accessories)
+	accessories.Undo = undo
+	materials := NewMaterialHandlers(store.Materials, jwtSecret)
+	materials.Undo = undo
+
+	app := fiber.New()
+	apiGroup := app.Group("/api")
+	customers.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	materials.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Delete("/accessories/:id", accessories.DeleteAccessory)
+	undo.Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	return app, store, jwtSecret
+}
+
+func TestUndoDeleteCustomer(t *testing.T) {
+	app, store, jwtSecret := setupUndoTestApp(t)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	otherToken := createTenantTestToken(jwtSecret, "staff-2", RoleStaff, models.DefaultTenantID)
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, staffToken, http.MethodDelete, "/api/customers/"+customer.ID, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	token := resp.Header.Get(HeaderUndoToken)
+	require.NotEmpty(t, token)
+	expiresAt, err := time.Parse(time.RFC3339, resp.Header.Get(HeaderUndoExpiresAt))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Minute)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/customers/"+customer.ID, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = authedRequest(t, app, otherToken, http.MethodPost, "/api/undo/"+token, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "only the user who deleted it or an admin")
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/undo/"+token, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var undone api.UndoResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&undone))
+	assert.Equal(t, AuditEntityCustomer, undone.EntityType)
+	assert.Equal(t, customer.ID, undone.EntityID)
+
+	resp = authedRequest(t, app, staffToken, http.MethodGet, "/api/customers/"+customer.ID, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/undo/"+token, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "a token is used once")
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "UNDO_DELETE", logs[0].Action)
+}
+
+func TestUndoDeleteInventory(t *testing.T) {
+	app, store, jwtSecret := setupUndoTestApp(t)
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side Mirror", Make: "Toyota", Quantity: 4, Price: 1500, UnitColor: "Black"})
+	require.NoError(t, err)
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Steel Sheet", Category: "Metal", Supplier: "ACME", Quantity: 10, Status: "In Stock"})
+	require.NoError(t, err)
+
+	resp := authedRequest(t, app, staffToken, http.MethodDelete, "/api/materials/"+strconv.Itoa(materialID), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	materialToken := resp.Header.Get(HeaderUndoToken)
+	resp = authedRequest(t, app, staffToken, http.MethodDelete, "/api/accessories/"+strconv.Itoa(accessoryID), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	accessoryToken := resp.Header.Get(HeaderUndoToken)
+	require.NotEmpty(t, materialToken)
+	require.NotEmpty(t, accessoryToken)
+
+	material, err := store.Materials.GetByID(context.Background(), materialID)
+	require.NoError(t, err)
+	assert.Nil(t, material, "deleted materials are hidden")
+
+	resp = authedRequest(t, app, adminToken, http.MethodPost, "/api/undo/"+materialToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode, "admins undo anyone's deletes")
+	material, err = store.Materials.GetByID(context.Background(), materialID)
+	require.NoError(t, err)
+	assert.NotNil(t, material)
+
+	resp = authedRequest(t, app, staffToken, http.MethodPost, "/api/undo/"+accessoryToken, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	accessory, err := store.Accessories.GetByID(context.Background(), accessoryID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, accessory.Quantity)
+
+	otherTenant := createTenantTestToken(jwtSecret, "admin-2", RoleAdmin, "tenant-2")
+	resp = authedRequest(t, app, staffToken, http.MethodDelete, "/api/accessories/"+strconv.Itoa(accessoryID), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = authedRequest(t, app, otherTenant, http.MethodPost, "/api/undo/"+resp.Header.Get(HeaderUndoToken), nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "tokens only work in the tenant that issued them")
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RecordCounter counts the records a tenant holds
+type RecordCounter interface {
+	GetUsage(tenantID string) (*models.TenantUsage, error)
+}
+
+// UsageHandler reports a tenant's metered traffic and record counts to its admins
+type UsageHandler struct {
+	Meter     *services.UsageMeter
+	Records   RecordCounter
+	jwtSecret []byte
+}
+
+// NewUsageHandler creates a new UsageHandler instance
+func NewUsageHandler(meter *services.UsageMeter, records RecordCounter, jwtSecret []byte) *UsageHandler {
+	return &UsageHandler{Meter: meter, Records: records, jwtSecret: jwtSecret}
+}
+
+// Routes registers the admin usage route
+func (h *UsageHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/admin/usage", mw.Auth, h.GetUsage) // GET /api/admin/usage
+}
+
+// GetUsage handles reporting the caller's tenant usage
+// @Summary Get tenant usage and quotas (Admin)
+// @Description Returns the API requests and uploaded bytes of the caller's tenant, broken down per client (user or integration account, or IP address without a token), together with record counts and the enforced quotas. Traffic is counted since the server started; daily counters cover the current UTC day.
+// @Tags Usage
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.UsageResponse "Usage"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve usage"
+// @Router /admin/usage [get]
+func (h *UsageHandler) GetUsage(c *fiber.Ctx) error {
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+
+	tenantID := tenantIDFromCtx(c)
+	records, err := h.Records.GetUsage(tenantID)
+	if err != nil {
+		log.Printf("Error counting records of tenant %s: %v", tenantID, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to retrieve usage", StatusCode: fiber.StatusInternalServerError})
+	}
+
+	requests, clients := h.Meter.Usage(tenantID)
+	quotas := h.Meter.Quotas()
+
+	return c.Status(fiber.StatusOK).JSON(api.UsageResponse{
+		TenantID: tenantID,
+		Requests: requests,
+		Clients:  clients,
+		Records:  records,
+		Quotas: api.QuotaLimits{
+			TenantRequestsPerMinute: quotas.TenantRequestsPerMinute,
+			ClientRequestsPerMinute: quotas.ClientRequestsPerMinute,
+			MaxRequestBytes:         quotas.MaxRequestBytes,
+			UploadBytesPerDay:       quotas.UploadBytesPerDay,
+		},
+	})
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/config"
+	"oop/internal/middleware"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupUsageTestApp meters every request of the default tenant in front of the usage route
+func setupUsageTestApp(t *testing.T, quotas config.QuotaConfig) (*fiber.App, []byte) {
+	jwtSecret := []byte("testsecret")
+	tenants := memory.NewTenantRepository()
+	store, err := memory.NewSeededStore()
+	require.NoError(t, err)
+	tenants.SetStore(models.DefaultTenantID, store)
+
+	meter := services.NewUsageMeter(quotas)
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("tenant_id", models.DefaultTenantID)
+		return c.Next()
+	}, middleware.Quota(meter, jwtSecret))
+
+	apiGroup := app.Group("/api")
+	NewUsageHandler(meter, tenants, jwtSecret).Routes(apiGroup, NewRouteMiddleware(jwtSecret))
+	apiGroup.Post("/echo", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+	return app, jwtSecret
+}
+
+func TestGetUsage(t *testing.T) {
+	app, jwtSecret := setupUsageTestApp(t, config.QuotaConfig{MaxRequestBytes: 64})
+	adminToken := createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/echo", bytes.NewReader([]byte(`{"note":"ok"}`)))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/echo", bytes.NewReader(bytes.Repeat([]byte("x"), 65)))
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/usage", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var usage api.UsageResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&usage))
+	assert.Equal(t, int64(2), usage.Requests.RequestsToday, "the echo and the usage request")
+	assert.Equal(t, int64(1), usage.Requests.RejectedToday)
+	assert.Equal(t, 64, usage.Quotas.MaxRequestBytes)
+	assert.Equal(t, 2, usage.Records.Users)
+	if assert.Len(t, usage.Clients, 2) {
+		assert.Equal(t, "user:admin-1", usage.Clients[0].Client)
+	}
+}
+
+func TestGetUsageRequiresAdmin(t *testing.T) {
+	app, jwtSecret := setupUsageTestApp(t, config.QuotaConfig{})
+	staffToken := createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/usage", nil)
+	req.Header.Set("Authorization", "Bearer "+staffToken)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
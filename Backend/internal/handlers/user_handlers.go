@@ -1,10 +1,18 @@
 package handlers
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"errors"
+	"fmt"
 	"log"
+	"math"
+	"oop/internal/api"
 	"oop/internal/models"
+	"oop/internal/services"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,60 +24,41 @@ import (
 const (
 	RoleAdmin = "admin"
 	RoleStaff = "staff"
+	// RoleSuperAdmin operates the platform across tenants. It is only valid in the default
+	// tenant and can't be granted through the API.
+	RoleSuperAdmin = "superadmin"
 )
 
-// UserAuthResponse is the response for successful user registration or login.
-type UserAuthResponse struct {
-	Message string       `json:"message"`
-	User    *models.User `json:"user"`
-	Token   string       `json:"token"`
-}
-
-// UserListResponse is the response for listing multiple users.
-type UserListResponse struct {
-	Users []*models.User `json:"users"`
-}
-
-// SingleUserResponse is the response for fetching a single user.
-type SingleUserResponse struct {
-	User *models.User `json:"user"`
-}
-
-// UserActionResponse is for actions like create or update that return a user and a message.
-type UserActionResponse struct {
-	Message string       `json:"message"`
-	User    *models.User `json:"user"`
-}
-
-// MessageResponse is a generic response for actions that only return a message.
-type MessageResponse struct {
-	Message string `json:"message"`
-}
-
 // UserRepository defines the interface for user repository operations
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id string) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByUsername(username string) (*models.User, error)
-	Update(user *models.User) error
-	UpdatePassword(userID string, newPassword string) error
-	Delete(id string) error
-	GetAll() ([]*models.User, error)
-	VerifyPassword(identifier, password string) (*models.User, error)
-	ActivateUser(id string) error
-	DeactivateUser(id string) error
-	EmailExists(email string) (bool, error)
-	UsernameExists(username string) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, userID string, newPassword string) error
+	Delete(ctx context.Context, id string) error
+	GetAll(ctx context.Context) ([]*models.User, error)
+	VerifyPassword(ctx context.Context, identifier, password string) (*models.User, error)
+	ActivateUser(ctx context.Context, id string) error
+	DeactivateUser(ctx context.Context, id string) error
+	EmailExists(ctx context.Context, email string) (bool, error)
+	UsernameExists(ctx context.Context, username string) (bool, error)
 	// FindByEmailOrUsernameConstantTime attempts to find a user by email or username
 	// in a way that takes a consistent amount of time.
-	FindByEmailOrUsernameConstantTime(identifier string) (*models.User, error)
+	FindByEmailOrUsernameConstantTime(ctx context.Context, identifier string) (*models.User, error)
 }
 
 // UserHandler handles HTTP requests related to users
 type UserHandler struct {
 	userRepo  UserRepository
 	jwtSecret []byte
+	Audit     *ChangeRecorder         // Optional; records field-level changes to the activity log
+	Dormancy  *DormantAccountHandler  // Optional; records sign-ins and reactivations for the dormant account policy
+	Sessions  *SessionHandler         // Optional; issues refresh tokens on login and ends sessions on password changes
+	Shifts    *ShiftHandler           // Optional; refuses sign-ins outside the user's shift
+	Throttle  *services.LoginThrottle // Optional; locks accounts and blocks addresses after failed sign-ins
+	Invites   *UserInviteHandler      // Optional; lists the pending invites on GET /users?status=invited
 }
 
 // NewUserHandler creates a new UserHandler instance
@@ -80,16 +69,28 @@ func NewUserHandler(userRepo UserRepository, jwtSecret []byte) *UserHandler {
 	}
 }
 
-// RegisterRoutes registers only the public user-related routes (register, login)
-// Protected routes are registered in main.go with middleware.
-func (h *UserHandler) RegisterRoutes(router fiber.Router) {
-	// Base path is assumed to be '/api' from main.go
-	userGroup := router.Group("/users")
-
+// Routes registers the public register and login routes, then the /users group that
+// requires a token. The group's token check covers every /users route registered
+// after it, so modules with public or their own /users routes must come first.
+func (h *UserHandler) Routes(r fiber.Router, mw RouteMiddleware) {
 	// Public routes
-	userGroup.Post("/register", h.Register)
-	userGroup.Post("/login", h.Login)
-
+	r.Post("/users/register", h.Register) // POST /api/users/register
+	r.Post("/users/login", h.Login)       // POST /api/users/login
+
+	userGroup := r.Group("/users", mw.Auth)
+
+	listUsers := []fiber.Handler{mw.Staff, h.GetAllUsers}
+	if h.Invites != nil {
+		listUsers = []fiber.Handler{mw.Staff, h.Invites.ListInvitedUsers, h.GetAllUsers} // ?status=invited lists pending invites
+	}
+	userGroup.Get("/", listUsers...)                             // GET /api/users
+	userGroup.Get("/:id", mw.Staff, h.GetUser)                   // GET /api/users/:id
+	userGroup.Put("/:id", mw.Staff, h.UpdateUser)                // PUT /api/users/:id
+	userGroup.Delete("/:id", mw.Staff, h.DeleteUser)             // DELETE /api/users/:id
+	userGroup.Put("/:id/activate", mw.Staff, h.ActivateUser)     // PUT /api/users/:id/activate
+	userGroup.Put("/:id/deactivate", mw.Staff, h.DeactivateUser) // PUT /api/users/:id/deactivate
+	userGroup.Put("/:id/password", h.UpdatePassword)             // PUT /api/users/:id/password, any role for their own password; admins for anyone's
+	userGroup.Post("/", mw.Staff, h.CreateUser)                  // POST /api/users
 }
 
 // generateToken creates a secure random token (used for registration maybe)
@@ -102,6 +103,24 @@ func generateToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
+// generateJWT creates the signed access token issued on login, valid for ttl.
+// The tenant claim pins the session to the tenant the user belongs to.
+func generateJWT(user *models.User, tenantID string, secret []byte, ttl time.Duration) (string, error) {
+	// Create the claims
+	claims := jwt.MapClaims{
+		"user_id":   user.Id,
+		"email":     user.Email,
+		"role":      user.Role,
+		"tenant_id": tenantID,
+		"exp":       time.Now().Add(ttl).Unix(), // Token expiry
+		"iat":       time.Now().Unix(),          // Issued at
+	}
+
+	// Create token and generate encoded token string
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(secret)
+}
+
 // Register handles user registration
 // @Summary Register a new user
 // @Description Creates a new user account.
@@ -109,10 +128,11 @@ func generateToken() (string, error) {
 // @Accept json
 // @Produce json
 // @Param user body models.UserCreateRequest true "User Registration Information"
-// @Success 201 {object} UserAuthResponse "User registered successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request body or missing fields"
-// @Failure 409 {object} ErrorResponse "Email already in use"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Success 201 {object} api.UserAuthResponse "User registered successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing fields"
+// @Failure 403 {object} api.ErrorResponse "The superadmin role cannot be assigned"
+// @Failure 409 {object} api.ErrorResponse "Email already in use"
+// @Failure 500 {object} api.ErrorResponse "Internal server error"
 // @Router /users/register [post]
 func (h *UserHandler) Register(c *fiber.Ctx) error {
 	var input struct {
@@ -124,7 +144,7 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request body",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -132,24 +152,27 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 
 	// Validate input
 	if input.Username == "" || input.Name == "" || input.Email == "" || input.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Username, name, email, and password are required",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
+	if input.Role == RoleSuperAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: errSuperAdminRole, StatusCode: fiber.StatusForbidden})
+	}
 
 	// Check if email already exists
-	exists, err := h.userRepo.EmailExists(input.Email)
+	exists, err := h.userRepo.EmailExists(c.Context(), input.Email)
 	if err != nil {
 		log.Printf("Error checking email existence: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Internal server error",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
 	if exists {
-		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
 			Error:      "Email already in use",
 			StatusCode: fiber.StatusConflict,
 		})
@@ -159,7 +182,7 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 	token, err := generateToken()
 	if err != nil {
 		log.Printf("Error generating token: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to generate authentication token",
 			StatusCode: fiber.StatusInternalServerError,
 		})
@@ -167,17 +190,17 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 
 	// Create user
 	// Check if username already exists
-	exists, err = h.userRepo.UsernameExists(input.Username)
+	exists, err = h.userRepo.UsernameExists(c.Context(), input.Username)
 	if err != nil {
 		log.Printf("Error checking username existence: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Internal server error",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
 	if exists {
-		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
 			Error:      "Username already in use",
 			StatusCode: fiber.StatusConflict,
 		})
@@ -192,9 +215,9 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 		Role:     input.Role,
 	}
 
-	if err := h.userRepo.Create(user); err != nil {
+	if err := h.userRepo.Create(c.Context(), user); err != nil {
 		log.Printf("Error creating user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to create user",
 			StatusCode: fiber.StatusInternalServerError,
 		})
@@ -203,7 +226,7 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 	// Don't return the password hash
 	user.Password = ""
 
-	return c.Status(fiber.StatusCreated).JSON(UserAuthResponse{
+	return c.Status(fiber.StatusCreated).JSON(api.UserAuthResponse{
 		Message: "User registered successfully",
 		User:    user,
 		Token:   token,
@@ -212,16 +235,18 @@ func (h *UserHandler) Register(c *fiber.Ctx) error {
 
 // Login handles user authentication and JWT generation
 // @Summary Log in an existing user
-// @Description Authenticates a user and returns a JWT token.
+// @Description Authenticates a user and returns a JWT access token, valid for ACCESS_TOKEN_TTL_MINUTES, and a refresh token to exchange for a new one through POST /users/refresh.
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param credentials body models.UserLoginRequest true "User Login Credentials"
-// @Success 200 {object} UserAuthResponse "Login successful"
-// @Failure 400 {object} ErrorResponse "Invalid request body or missing fields"
-// @Failure 401 {object} ErrorResponse "Invalid credentials"
-// @Failure 403 {object} ErrorResponse "Account is inactive"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Success 200 {object} api.UserAuthResponse "Login successful"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing fields"
+// @Failure 401 {object} api.ErrorResponse "Invalid credentials"
+// @Failure 403 {object} api.ErrorResponse "Account is inactive or outside the user's shift"
+// @Failure 423 {object} api.ErrorResponse "Account locked after too many failed sign-ins; see Retry-After"
+// @Failure 429 {object} api.ErrorResponse "Too many failed sign-ins from this address; see Retry-After"
+// @Failure 500 {object} api.ErrorResponse "Internal server error"
 // @Router /users/login [post]
 func (h *UserHandler) Login(c *fiber.Ctx) error {
 	var input struct {
@@ -230,7 +255,7 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request body",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -238,17 +263,40 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 
 	// Validate input
 	if input.Username == "" || input.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Username or Email and password are required",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
 
+	tenantID := tenantIDFromCtx(c)
+	if wait := h.Throttle.AddressBlocked(tenantID, c.IP()); wait > 0 {
+		return c.Status(fiber.StatusTooManyRequests).JSON(api.ErrorResponse{
+			Error:      "Too many failed sign-ins from this address, try again in " + setRetryAfter(c, wait),
+			StatusCode: fiber.StatusTooManyRequests,
+		})
+	}
+
 	// Find user by email or username using the constant time function
-	user, err := h.userRepo.FindByEmailOrUsernameConstantTime(input.Username)
+	user, err := h.userRepo.FindByEmailOrUsernameConstantTime(c.Context(), input.Username)
+
+	// Failures count against the account, or against the identifier when no account
+	// uses it, so locking out does not reveal which accounts exist
+	account := "login:" + strings.ToLower(strings.TrimSpace(input.Username))
+	if err == nil {
+		account = user.Id
+	}
+	if wait := h.Throttle.AccountLocked(tenantID, account); wait > 0 {
+		return c.Status(fiber.StatusLocked).JSON(api.ErrorResponse{
+			Error:      "Account locked after too many failed sign-ins, try again in " + setRetryAfter(c, wait),
+			StatusCode: fiber.StatusLocked,
+		})
+	}
+
 	if err != nil {
 		log.Printf("Login failed - user not found for identifier %s: %v", input.Username, err)
-		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+		h.failLogin(c, tenantID, account)
+		return c.Status(fiber.StatusUnauthorized).JSON(api.ErrorResponse{
 			Error:      "Invalid credentials",
 			StatusCode: fiber.StatusUnauthorized,
 		})
@@ -257,7 +305,7 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 	// Check if user is active before verifying password
 	if !user.IsActive {
 		log.Printf("Login attempt for inactive user: %s", input.Username)
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
 			Error:      "Account is inactive",
 			StatusCode: fiber.StatusForbidden,
 		})
@@ -269,61 +317,97 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password))
 	if err != nil {
 		log.Printf("Login failed - invalid password for identifier %s: %v", input.Username, err)
+		h.failLogin(c, tenantID, account)
 		// Return a generic error message to avoid revealing which part failed
-		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+		return c.Status(fiber.StatusUnauthorized).JSON(api.ErrorResponse{
 			Error:      "Invalid credentials",
 			StatusCode: fiber.StatusUnauthorized,
 		})
 	}
 
-	// Create the claims
-	claims := jwt.MapClaims{
-		"user_id": user.Id,
-		"email":   user.Email,
-		"role":    user.Role,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Token expires in 72 hours
-		"iat":     time.Now().Unix(),                     // Issued at
+	if err := h.Shifts.CheckLogin(user); err != nil {
+		if errors.Is(err, ErrOutsideShift) {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
+				Error:      "Signing in is not allowed outside your scheduled shift",
+				StatusCode: fiber.StatusForbidden,
+			})
+		}
+		log.Printf("Error checking shift of user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to check shift schedule",
+			StatusCode: fiber.StatusInternalServerError,
+		})
 	}
 
-	// Create token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// Generate encoded token string
-	tokenString, err := token.SignedString(h.jwtSecret) // Use the injected secret
+	tokenString, err := generateJWT(user, tenantIDFromCtx(c), h.jwtSecret, h.Sessions.AccessTTL()) // Use the injected secret
 	if err != nil {
 		log.Printf("Error signing JWT token: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to generate authentication token",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
+	refreshToken, err := h.Sessions.Start(user)
+	if err != nil {
+		log.Printf("Error starting session for user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to generate authentication token",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	h.Dormancy.RecordLogin(user.Id)
+	h.Throttle.Succeed(tenantID, account)
 
 	// Optionally update the token in the database (Consider if needed for session invalidation)
 	// if err := h.userRepo.UpdateToken(user.Id, tokenString); err != nil {
 	// 	log.Printf("Error updating JWT token in DB: %v", err)
 	// 	// Decide if this should be a fatal error for login
-	// 	return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Error: "Failed to update session", StatusCode: fiber.StatusInternalServerError})
+	// 	return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{Error: "Failed to update session", StatusCode: fiber.StatusInternalServerError})
 	// }
 
 	// Don't return the password hash
 	user.Password = ""
 
 	// Return user info and the JWT
-	return c.Status(fiber.StatusOK).JSON(UserAuthResponse{
-		Message: "Login successful",
-		User:    user,
-		Token:   tokenString,
+	return c.Status(fiber.StatusOK).JSON(api.UserAuthResponse{
+		Message:      "Login successful",
+		User:         user,
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(h.Sessions.AccessTTL().Seconds()),
 	})
 }
 
+// failLogin counts a failed sign-in, recording in the activity log when it locked the account
+func (h *UserHandler) failLogin(c *fiber.Ctx, tenantID, account string) {
+	lockout := h.Throttle.Fail(tenantID, account, c.IP())
+	if lockout == 0 {
+		return
+	}
+	log.Printf("Login locked for %s after too many failed sign-ins from %s", account, c.IP())
+	h.Audit.RecordAction(c, "ACCOUNT_LOCKED", AuditEntityUser, account,
+		fmt.Sprintf("Locked sign-ins for %s after too many failed attempts, the last from %s", lockout, c.IP()))
+}
+
+// setRetryAfter sets the Retry-After header to wait, rounded up to seconds, and
+// returns wait in minutes for the error message
+func setRetryAfter(c *fiber.Ctx, wait time.Duration) string {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	minutes := int(math.Ceil(wait.Minutes()))
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
 // GetAllUsers returns a list of all users
 // @Summary Get all users
 // @Description Retrieves a list of all registered users. This is a protected route.
 // @Tags Users
 // @Produce json
 // @Security ApiKeyAuth
-// @Success 200 {object} UserListResponse "Successfully retrieved list of users"
-// @Failure 500 {object} ErrorResponse "Failed to retrieve users"
+// @Success 200 {object} api.UserListResponse "Successfully retrieved list of users"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve users"
 // @Router /users [get]
 func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 	// Access user info from middleware if needed:
@@ -331,10 +415,10 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 	// userRole := c.Locals("role")
 	// log.Printf("GetAllUsers called by user %s with role %s", userID, userRole)
 
-	users, err := h.userRepo.GetAll()
+	users, err := h.userRepo.GetAll(c.Context())
 	if err != nil {
 		log.Printf("Error getting users: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to retrieve users",
 			StatusCode: fiber.StatusInternalServerError,
 		})
@@ -345,7 +429,7 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 		user.Password = ""
 	}
 
-	return c.Status(fiber.StatusOK).JSON(UserListResponse{
+	return c.Status(fiber.StatusOK).JSON(api.UserListResponse{
 		Users: users,
 	})
 }
@@ -357,9 +441,9 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "User ID"
-// @Success 200 {object} SingleUserResponse "Successfully retrieved user"
-// @Failure 403 {object} ErrorResponse "Permission denied"
-// @Failure 404 {object} ErrorResponse "User not found"
+// @Success 200 {object} api.SingleUserResponse "Successfully retrieved user"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "User not found"
 // @Router /users/{id} [get]
 func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -367,13 +451,13 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	// requestedUserID := c.Locals("user_id")
 	// requestedUserRole := c.Locals("role")
 	// if requestedUserID != id && requestedUserRole != "admin" { // Example policy
-	// 	return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	// 	return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
 	// }
 
-	user, err := h.userRepo.GetByID(id)
+	user, err := h.userRepo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
 			Error:      "User not found",
 			StatusCode: fiber.StatusNotFound,
 		})
@@ -382,7 +466,8 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 	// Don't return the password hash
 	user.Password = ""
 
-	return c.Status(fiber.StatusOK).JSON(SingleUserResponse{
+	setLastModified(c, user.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.SingleUserResponse{
 		User: user,
 	})
 }
@@ -396,36 +481,30 @@ func (h *UserHandler) GetUser(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "User ID"
 // @Param user_update body models.UserUpdateRequest true "User Update Information"
-// @Success 200 {object} UserActionResponse "User updated successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request body"
-// @Failure 403 {object} ErrorResponse "Permission denied"
-// @Failure 404 {object} ErrorResponse "User not found"
-// @Failure 500 {object} ErrorResponse "Failed to update user"
+// @Param If-Unmodified-Since header string false "Only update if the record was not modified after this HTTP date, its Last-Modified"
+// @Success 200 {object} api.UserActionResponse "User updated successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "User not found"
+// @Failure 412 {object} api.ErrorResponse "Modified after If-Unmodified-Since"
+// @Failure 500 {object} api.ErrorResponse "Failed to update user"
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	id := c.Params("id")
-	// Check if the current user is admin or staff
-	roleValue := c.Locals("role")
-	requestUserRole, ok := roleValue.(string)
-	if !ok || requestUserRole == "" {
-		// Log the issue for debugging
-		log.Printf("UpdateUser: 'role' not found or not a string in context locals for user ID %s. Value: %v", id, roleValue)
-		// Return forbidden, as the role couldn't be determined or is invalid
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Permission denied: Unable to verify user role", StatusCode: fiber.StatusForbidden})
-	}
-	if requestUserRole != RoleAdmin && requestUserRole != RoleStaff { // Now check the validated role
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
-	}
 
 	// Get existing user
-	existingUser, err := h.userRepo.GetByID(id)
+	existingUser, err := h.userRepo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
 			Error:      "User not found",
 			StatusCode: fiber.StatusNotFound,
 		})
 	}
+	if modified, err := rejectIfModified(c, existingUser.UpdatedAt); modified {
+		return err
+	}
+	before := *existingUser
 
 	// Parse request body
 	var input struct {
@@ -437,7 +516,7 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request body",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -451,6 +530,9 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	if input.Email != "" {
 		existingUser.Email = input.Email
 	}
+	if input.Role == RoleSuperAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: errSuperAdminRole, StatusCode: fiber.StatusForbidden})
+	}
 	if input.Role != "" {
 		// Log role changes
 		if input.Role != existingUser.Role {
@@ -470,17 +552,17 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	// Update username if provided
 	if input.Username != "" && input.Username != existingUser.Username {
 		// Check if the new username already exists
-		exists, err := h.userRepo.UsernameExists(input.Username)
+		exists, err := h.userRepo.UsernameExists(c.Context(), input.Username)
 		if err != nil {
 			log.Printf("Error checking username existence: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 				Error:      "Internal server error",
 				StatusCode: fiber.StatusInternalServerError,
 			})
 		}
 
 		if exists {
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
 				Error:      "Username already in use",
 				StatusCode: fiber.StatusConflict,
 			})
@@ -495,18 +577,24 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 	existingUser.UpdatedAt = time.Now()
 
 	// Save changes
-	if err := h.userRepo.Update(existingUser); err != nil {
+	if err := h.userRepo.Update(c.Context(), existingUser); err != nil {
 		log.Printf("Error updating user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to update user",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
+	h.Audit.RecordUpdate(c, AuditEntityUser, id, before, existingUser)
+	if existingUser.IsActive && !before.IsActive {
+		h.Dormancy.Reactivated(existingUser.Id)
+	}
+
 	// Don't return the password hash
 	existingUser.Password = ""
 
-	return c.Status(fiber.StatusOK).JSON(UserActionResponse{
+	setLastModified(c, existingUser.UpdatedAt)
+	return c.Status(fiber.StatusOK).JSON(api.UserActionResponse{
 		Message: "User updated successfully",
 		User:    existingUser,
 	})
@@ -519,22 +607,22 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "User ID"
-// @Success 200 {object} MessageResponse "User deleted successfully"
-// @Failure 500 {object} ErrorResponse "Failed to delete user"
+// @Success 200 {object} api.MessageResponse "User deleted successfully"
+// @Failure 500 {object} api.ErrorResponse "Failed to delete user"
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	// Optional: Check permissions
 
-	if err := h.userRepo.Delete(id); err != nil {
+	if err := h.userRepo.Delete(c.Context(), id); err != nil {
 		log.Printf("Error deleting user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to delete user",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(MessageResponse{
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{
 		Message: "User deleted successfully",
 	})
 }
@@ -546,21 +634,22 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "User ID"
-// @Success 200 {object} MessageResponse "User activated successfully"
-// @Failure 500 {object} ErrorResponse "Failed to activate user"
+// @Success 200 {object} api.MessageResponse "User activated successfully"
+// @Failure 500 {object} api.ErrorResponse "Failed to activate user"
 // @Router /users/{id}/activate [put]
 func (h *UserHandler) ActivateUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	if err := h.userRepo.ActivateUser(id); err != nil {
+	if err := h.userRepo.ActivateUser(c.Context(), id); err != nil {
 		log.Printf("Error activating user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to activate user",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
+	h.Dormancy.Reactivated(strings.Clone(id))
 
-	return c.Status(fiber.StatusOK).JSON(MessageResponse{
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{
 		Message: "User activated successfully",
 	})
 }
@@ -573,24 +662,13 @@ func (h *UserHandler) ActivateUser(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param user body models.UserCreateRequest true "User Creation Information"
-// @Success 201 {object} UserActionResponse "User created successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request body or missing fields"
-// @Failure 403 {object} ErrorResponse "Permission denied"
-// @Failure 409 {object} ErrorResponse "Email already in use"
-// @Failure 500 {object} ErrorResponse "Internal server error or failed to create user"
-// @Router /users [post] // Note: This matches the route in main.go for creating users by admin/staff
+// @Success 201 {object} api.UserActionResponse "User created successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing fields"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 409 {object} api.ErrorResponse "Email already in use"
+// @Failure 500 {object} api.ErrorResponse "Internal server error or failed to create user"
+// @Router /users [post]
 func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
-	roleValue := c.Locals("role")
-	requestUserRole, ok := roleValue.(string)
-
-	if !ok || requestUserRole == "" {
-		log.Printf("CreateUser: 'role' not found or not a string in context locals. Value: %v", roleValue)
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Permission denied: Unable to verify user role", StatusCode: fiber.StatusForbidden})
-	}
-	if requestUserRole != RoleAdmin && requestUserRole != RoleStaff {
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
-	}
-
 	// Parse request body
 	var input struct {
 		FullName string `json:"fullName"`
@@ -601,7 +679,7 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request body",
 			StatusCode: fiber.StatusBadRequest,
 		})
@@ -609,24 +687,27 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 
 	// Validate input
 	if input.FullName == "" || input.Email == "" || input.Password == "" || input.Role == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Full name, email, password, and role are required",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
+	if input.Role == RoleSuperAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: errSuperAdminRole, StatusCode: fiber.StatusForbidden})
+	}
 
 	// Check if email already exists
-	exists, err := h.userRepo.EmailExists(input.Email)
+	exists, err := h.userRepo.EmailExists(c.Context(), input.Email)
 	if err != nil {
 		log.Printf("Error checking email existence: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Internal server error",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
 	if exists {
-		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+		return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
 			Error:      "Email already in use",
 			StatusCode: fiber.StatusConflict,
 		})
@@ -634,17 +715,17 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 
 	// Check if username already exists if provided
 	if input.Username != "" {
-		exists, err = h.userRepo.UsernameExists(input.Username)
+		exists, err = h.userRepo.UsernameExists(c.Context(), input.Username)
 		if err != nil {
 			log.Printf("Error checking username existence: %v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 				Error:      "Internal server error",
 				StatusCode: fiber.StatusInternalServerError,
 			})
 		}
 
 		if exists {
-			return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
 				Error:      "Username already in use",
 				StatusCode: fiber.StatusConflict,
 			})
@@ -662,9 +743,9 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 		IsActive: true,
 	}
 
-	if err := h.userRepo.Create(user); err != nil {
+	if err := h.userRepo.Create(c.Context(), user); err != nil {
 		log.Printf("Error creating user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to create user",
 			StatusCode: fiber.StatusInternalServerError,
 		})
@@ -673,7 +754,7 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 	// Don't return the password hash
 	user.Password = ""
 
-	return c.Status(fiber.StatusCreated).JSON(UserActionResponse{
+	return c.Status(fiber.StatusCreated).JSON(api.UserActionResponse{
 		Message: "User created successfully",
 		User:    user,
 	})
@@ -686,22 +767,22 @@ func (h *UserHandler) CreateUser(c *fiber.Ctx) error {
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "User ID"
-// @Success 200 {object} MessageResponse "User deactivated successfully"
-// @Failure 500 {object} ErrorResponse "Failed to deactivate user"
+// @Success 200 {object} api.MessageResponse "User deactivated successfully"
+// @Failure 500 {object} api.ErrorResponse "Failed to deactivate user"
 // @Router /users/{id}/deactivate [put]
 func (h *UserHandler) DeactivateUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	// Optional: Check permissions
 
-	if err := h.userRepo.DeactivateUser(id); err != nil {
+	if err := h.userRepo.DeactivateUser(c.Context(), id); err != nil {
 		log.Printf("Error deactivating user: %v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to deactivate user",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(MessageResponse{
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{
 		Message: "User deactivated successfully",
 	})
 }
@@ -715,11 +796,11 @@ func (h *UserHandler) DeactivateUser(c *fiber.Ctx) error {
 // @Security ApiKeyAuth
 // @Param id path string true "User ID"
 // @Param password_update body models.UserPasswordUpdateRequest true "Password Update Information"
-// @Success 200 {object} MessageResponse "Password updated successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request body or missing fields"
-// @Failure 403 {object} ErrorResponse "Permission denied"
-// @Failure 404 {object} ErrorResponse "User not found"
-// @Failure 500 {object} ErrorResponse "Failed to update password"
+// @Success 200 {object} api.MessageResponse "Password updated successfully"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing fields"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 404 {object} api.ErrorResponse "User not found"
+// @Failure 500 {object} api.ErrorResponse "Failed to update password"
 // @Router /users/{id}/password [put]
 func (h *UserHandler) UpdatePassword(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -728,7 +809,7 @@ func (h *UserHandler) UpdatePassword(c *fiber.Ctx) error {
 	requestUserRole := c.Locals("role").(string)
 
 	if requestUserID != id && requestUserRole != RoleAdmin {
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
 	}
 
 	var input struct {
@@ -737,24 +818,24 @@ func (h *UserHandler) UpdatePassword(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "Invalid request body",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
 
 	if input.NewPassword == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
 			Error:      "New password is required",
 			StatusCode: fiber.StatusBadRequest,
 		})
 	}
 
 	// Check if user exists before trying to update password
-	user, err := h.userRepo.GetByID(id)
+	user, err := h.userRepo.GetByID(c.Context(), id)
 	if err != nil {
 		log.Printf("UpdatePassword: User not found with ID %s: %v", id, err)
-		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
 			Error:      "User not found",
 			StatusCode: fiber.StatusNotFound,
 		})
@@ -763,31 +844,33 @@ func (h *UserHandler) UpdatePassword(c *fiber.Ctx) error {
 	// IsActive check
 	if !user.IsActive {
 		log.Printf("UpdatePassword: Attempt to update password for inactive user %s", id)
-		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{
 			Error:      "Account is inactive",
 			StatusCode: fiber.StatusForbidden,
 		})
 	}
 
 	// Verify current password
-	_, err = h.userRepo.VerifyPassword(user.Email, input.CurrentPassword)
+	_, err = h.userRepo.VerifyPassword(c.Context(), user.Email, input.CurrentPassword)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+		return c.Status(fiber.StatusUnauthorized).JSON(api.ErrorResponse{
 			Error:      "Current password is incorrect",
 			StatusCode: fiber.StatusUnauthorized,
 		})
 	}
 
 	// Update password
-	if err := h.userRepo.UpdatePassword(id, input.NewPassword); err != nil {
+	if err := h.userRepo.UpdatePassword(c.Context(), id, input.NewPassword); err != nil {
 		log.Printf("Error updating password for user %s: %v", id, err)
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
 			Error:      "Failed to update password",
 			StatusCode: fiber.StatusInternalServerError,
 		})
 	}
+	// Sessions signed in with the old password can no longer be refreshed
+	h.Sessions.RevokeUser(id)
 
-	return c.Status(fiber.StatusOK).JSON(MessageResponse{
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{
 		Message: "Password updated successfully",
 	})
 }
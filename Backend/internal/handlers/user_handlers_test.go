@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"oop/internal/config"
 	"oop/internal/models"
+	"oop/internal/repositories/memory"
+	"oop/internal/services"
 	"testing"
 	"time"
 
@@ -14,20 +18,23 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // MockUserRepository is a mock implementation of the UserRepository
+// The context is not recorded with the calls: handlers pass the request's fasthttp
+// context, which is reused once the request ends.
 type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *models.User) error {
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByID(id string) (*models.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -35,7 +42,7 @@ func (m *MockUserRepository) GetByID(id string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -43,22 +50,22 @@ func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Update(user *models.User) error {
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) UpdatePassword(userID string, newPassword string) error {
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID string, newPassword string) error {
 	args := m.Called(userID, newPassword)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id string) error {
+func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetAll() ([]*models.User, error) {
+func (m *MockUserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
 	args := m.Called()
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -66,7 +73,7 @@ func (m *MockUserRepository) GetAll() ([]*models.User, error) {
 	return args.Get(0).([]*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByUsername(username string) (*models.User, error) {
+func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	args := m.Called(username)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -74,12 +81,12 @@ func (m *MockUserRepository) GetByUsername(username string) (*models.User, error
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UsernameExists(username string) (bool, error) {
+func (m *MockUserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
 	args := m.Called(username)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockUserRepository) VerifyPassword(identifier, password string) (*models.User, error) {
+func (m *MockUserRepository) VerifyPassword(ctx context.Context, identifier, password string) (*models.User, error) {
 	args := m.Called(identifier, password)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -87,22 +94,22 @@ func (m *MockUserRepository) VerifyPassword(identifier, password string) (*model
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) ActivateUser(id string) error {
+func (m *MockUserRepository) ActivateUser(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) DeactivateUser(id string) error {
+func (m *MockUserRepository) DeactivateUser(ctx context.Context, id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) EmailExists(email string) (bool, error) {
+func (m *MockUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 	args := m.Called(email)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockUserRepository) FindByEmailOrUsernameConstantTime(identifier string) (*models.User, error) {
+func (m *MockUserRepository) FindByEmailOrUsernameConstantTime(ctx context.Context, identifier string) (*models.User, error) {
 	args := m.Called(identifier)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -1039,3 +1046,48 @@ func TestUserHandler_UpdatePassword_IncorrectCurrentPassword(t *testing.T) {
 	// Verify expectations
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUserHandler_Login_Lockout(t *testing.T) {
+	store := memory.NewStore()
+	require.NoError(t, store.Users.Create(context.Background(), &models.User{Id: "user-1", Username: "clerk", Email: "clerk@example.com", Password: "password123", Role: RoleStaff}))
+	handler := NewUserHandler(store.Users, []byte("testsecret"))
+	handler.Audit = NewChangeRecorder(store.Logs)
+	handler.Throttle = services.NewLoginThrottle(config.LoginThrottleConfig{MaxFailures: 3, IPMaxFailures: 10, Lockout: 15 * time.Minute})
+	app := fiber.New()
+	handler.Routes(app.Group("/api"), NewRouteMiddleware([]byte("testsecret")))
+	login := func(username, password string) *http.Response {
+		return authedRequest(t, app, "", http.MethodPost, "/api/users/login", map[string]string{"username": username, "password": password})
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("clerk", "wrong").StatusCode)
+	assert.Equal(t, http.StatusOK, login("clerk", "password123").StatusCode, "signing in forgets earlier failures")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login("clerk@example.com", "wrong").StatusCode, "failures count whichever identifier is used")
+	}
+	resp := login("clerk", "password123")
+	require.Equal(t, http.StatusLocked, resp.StatusCode, "even the right password is refused while locked")
+	assert.Equal(t, "900", resp.Header.Get(fiber.HeaderRetryAfter))
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "Account locked after too many failed sign-ins, try again in 15 minutes", body["error"])
+
+	logs, _, err := store.Logs.GetLogs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "ACCOUNT_LOCKED", logs[0].Action)
+	assert.Equal(t, "user-1", logs[0].EntityID)
+
+	// Unknown identifiers are locked alike
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login("ghost", "wrong").StatusCode)
+	}
+	assert.Equal(t, http.StatusLocked, login("Ghost", "wrong").StatusCode)
+
+	// The address has now failed 10 times, whatever the accounts
+	for i := 0; i < 3; i++ {
+		login(uuid.NewString(), "wrong")
+	}
+	resp = login("another", "wrong")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(fiber.HeaderRetryAfter))
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// inviteTTL is how long a one-time setup link stays valid
+const inviteTTL = 7 * 24 * time.Hour
+
+// maxInvitesPerRequest caps the size of a single bulk invite request
+const maxInvitesPerRequest = 100
+
+// UserInviteHandler handles the bulk invite and invite acceptance flow
+type UserInviteHandler struct {
+	userRepo    UserRepository
+	inviteRepo  repositories.UserInviteRepository
+	mailer      services.Mailer
+	frontendURL string
+	jwtSecret   []byte
+}
+
+// NewUserInviteHandler creates a new UserInviteHandler instance
+func NewUserInviteHandler(userRepo UserRepository, inviteRepo repositories.UserInviteRepository, mailer services.Mailer, frontendURL string, jwtSecret []byte) *UserInviteHandler {
+	return &UserInviteHandler{
+		userRepo:    userRepo,
+		inviteRepo:  inviteRepo,
+		mailer:      mailer,
+		frontendURL: strings.TrimRight(frontendURL, "/"),
+		jwtSecret:   jwtSecret,
+	}
+}
+
+// Routes registers the invite routes. The module must be registered before the users
+// module, whose /users group requires a token, so the public accept route is matched first.
+func (h *UserInviteHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	userGroup := r.Group("/users")
+	userGroup.Post("/invite/accept", h.AcceptInvite)  // POST /api/users/invite/accept (public)
+	userGroup.Post("/invite", mw.Auth, h.InviteUsers) // POST /api/users/invite
+}
+
+// hashInviteToken returns the hex-encoded SHA-256 hash of a setup token
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// InviteUsers creates pending accounts and emails a one-time setup link to each address
+// @Summary Invite users in bulk (Admin)
+// @Description Creates inactive accounts for the given emails and sends each one a one-time setup link. Existing emails are skipped.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param invites body models.UserInviteRequest true "Emails and roles to invite"
+// @Success 200 {object} api.UserInviteResponse "Per-email invite results"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Router /users/invite [post]
+func (h *UserInviteHandler) InviteUsers(c *fiber.Ctx) error {
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+	invitedBy, _ := c.Locals("user_id").(string)
+
+	var input models.UserInviteRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid request body",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	if len(input.Invites) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "At least one invite is required",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+	if len(input.Invites) > maxInvitesPerRequest {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      fmt.Sprintf("A maximum of %d invites can be sent per request", maxInvitesPerRequest),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	results := make([]api.UserInviteResult, 0, len(input.Invites))
+	seen := make(map[string]bool)
+	for _, entry := range input.Invites {
+		email := strings.ToLower(strings.TrimSpace(entry.Email))
+		if seen[email] {
+			results = append(results, api.UserInviteResult{Email: email, Status: api.InviteStatusSkipped, Error: "Duplicate email in request"})
+			continue
+		}
+		seen[email] = true
+		results = append(results, h.inviteOne(c.Context(), email, entry, invitedBy))
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.UserInviteResponse{
+		Message: "Invites processed",
+		Results: results,
+	})
+}
+
+// inviteOne creates a single pending account and sends its setup email
+func (h *UserInviteHandler) inviteOne(ctx context.Context, email string, entry models.UserInviteEntry, invitedBy string) api.UserInviteResult {
+	result := api.UserInviteResult{Email: email}
+
+	if _, err := mail.ParseAddress(email); err != nil || email == "" {
+		result.Status = api.InviteStatusFailed
+		result.Error = "Invalid email address"
+		return result
+	}
+	if entry.Role != RoleAdmin && entry.Role != RoleStaff {
+		result.Status = api.InviteStatusFailed
+		result.Error = "Role must be admin or staff"
+		return result
+	}
+
+	exists, err := h.userRepo.EmailExists(ctx, email)
+	if err != nil {
+		log.Printf("Error checking email existence for invite %s: %v", email, err)
+		result.Status = api.InviteStatusFailed
+		result.Error = "Internal server error"
+		return result
+	}
+	if exists {
+		result.Status = api.InviteStatusSkipped
+		result.Error = "Email already in use"
+		return result
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		log.Printf("Error generating invite token: %v", err)
+		result.Status = api.InviteStatusFailed
+		result.Error = "Failed to generate invite token"
+		return result
+	}
+	// The placeholder password is never shared; the invitee sets their own when accepting.
+	placeholderPassword, err := generateToken()
+	if err != nil {
+		log.Printf("Error generating placeholder password: %v", err)
+		result.Status = api.InviteStatusFailed
+		result.Error = "Failed to generate invite token"
+		return result
+	}
+
+	fullName := strings.TrimSpace(entry.FullName)
+	if fullName == "" {
+		fullName = email
+	}
+
+	user := &models.User{
+		Id:       uuid.New().String(),
+		Username: email, // Email is unique, so it is a safe initial username
+		FullName: fullName,
+		Email:    email,
+		Password: placeholderPassword,
+		Role:     entry.Role,
+	}
+	if err := h.userRepo.Create(ctx, user); err != nil {
+		log.Printf("Error creating invited user %s: %v", email, err)
+		result.Status = api.InviteStatusFailed
+		result.Error = "Failed to create user"
+		return result
+	}
+	// Pending accounts cannot log in until the invite is accepted, so an account that
+	// cannot be deactivated is removed and the invite fails
+	if err := h.userRepo.DeactivateUser(ctx, user.Id); err != nil {
+		log.Printf("Error deactivating invited user %s: %v", user.Id, err)
+		if err := h.userRepo.Delete(ctx, user.Id); err != nil {
+			log.Printf("Error deleting invited user %s left active: %v", user.Id, err)
+		}
+		result.Status = api.InviteStatusFailed
+		result.Error = "Failed to create user"
+		return result
+	}
+
+	invite := &models.UserInvite{
+		UserID:    user.Id,
+		Email:     email,
+		Role:      entry.Role,
+		TokenHash: hashInviteToken(token),
+		InvitedBy: invitedBy,
+		ExpiresAt: time.Now().Add(inviteTTL),
+	}
+	if err := h.inviteRepo.Create(invite); err != nil {
+		log.Printf("Error creating invite for %s: %v", email, err)
+		result.Status = api.InviteStatusFailed
+		result.Error = "Failed to create invite"
+		return result
+	}
+
+	link := fmt.Sprintf("%s/accept-invite?token=%s", h.frontendURL, url.QueryEscape(token))
+	body := fmt.Sprintf(
+		"Hello %s,\n\nYou have been invited to the Cortes Surplus Inventory Management System as %s.\n\nSet up your account using the link below. It expires on %s.\n\n%s\n",
+		fullName, entry.Role, invite.ExpiresAt.Format("January 2, 2006"), link,
+	)
+	if err := h.mailer.Send(email, "You're invited to Cortes Surplus", body); err != nil {
+		// The invite exists and can be re-sent, so report it without failing the account creation
+		log.Printf("Error sending invite email to %s: %v", email, err)
+		result.Error = "Invite created but email could not be sent"
+	}
+
+	result.Status = api.InviteStatusInvited
+	result.UserID = user.Id
+	return result
+}
+
+// ListInvitedUsers responds with pending invitations when called with ?status=invited,
+// otherwise it passes the request on to the next handler (the regular user list).
+// @Summary List invited users (Admin)
+// @Description Lists invitations that have not been accepted yet. Used via GET /users?status=invited.
+// @Tags Users
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string true "Must be 'invited'"
+// @Success 200 {object} api.UserInviteListResponse "Pending invitations"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve invites"
+// @Router /users?status=invited [get]
+func (h *UserInviteHandler) ListInvitedUsers(c *fiber.Ctx) error {
+	if c.Query("status") != string(api.InviteStatusInvited) {
+		return c.Next()
+	}
+
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+
+	invites, err := h.inviteRepo.GetPending()
+	if err != nil {
+		log.Printf("Error getting pending invites: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve invites",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.UserInviteListResponse{Invites: invites})
+}
+
+// AcceptInvite activates a pending account using its one-time setup token
+// @Summary Accept an invitation
+// @Description Sets the password for an invited account and activates it. The token can only be used once.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param accept body models.UserInviteAcceptRequest true "Setup token and new password"
+// @Success 200 {object} api.MessageResponse "Invitation accepted"
+// @Failure 400 {object} api.ErrorResponse "Invalid request body or missing fields"
+// @Failure 404 {object} api.ErrorResponse "Invalid or expired invitation"
+// @Failure 409 {object} api.ErrorResponse "Username already in use"
+// @Failure 500 {object} api.ErrorResponse "Failed to accept invitation"
+// @Router /users/invite/accept [post]
+func (h *UserInviteHandler) AcceptInvite(c *fiber.Ctx) error {
+	var input models.UserInviteAcceptRequest
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Invalid request body",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	if input.Token == "" || input.Password == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Token and password are required",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	invite, err := h.inviteRepo.GetByTokenHash(hashInviteToken(input.Token))
+	if err != nil || invite.AcceptedAt != nil || time.Now().After(invite.ExpiresAt) {
+		if err != nil {
+			log.Printf("AcceptInvite: invite lookup failed: %v", err)
+		}
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
+			Error:      "Invalid or expired invitation",
+			StatusCode: fiber.StatusNotFound,
+		})
+	}
+
+	user, err := h.userRepo.GetByID(c.Context(), invite.UserID)
+	if err != nil {
+		log.Printf("AcceptInvite: user %s for invite %s not found: %v", invite.UserID, invite.ID, err)
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
+			Error:      "Invalid or expired invitation",
+			StatusCode: fiber.StatusNotFound,
+		})
+	}
+
+	if input.Username != "" && input.Username != user.Username {
+		exists, err := h.userRepo.UsernameExists(c.Context(), input.Username)
+		if err != nil {
+			log.Printf("Error checking username existence: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+				Error:      "Internal server error",
+				StatusCode: fiber.StatusInternalServerError,
+			})
+		}
+		if exists {
+			return c.Status(fiber.StatusConflict).JSON(api.ErrorResponse{
+				Error:      "Username already in use",
+				StatusCode: fiber.StatusConflict,
+			})
+		}
+		user.Username = input.Username
+	}
+	if input.FullName != "" {
+		user.FullName = input.FullName
+	}
+
+	// Claim the invite first so a token can never be used twice concurrently
+	if err := h.inviteRepo.MarkAccepted(invite.ID); err != nil {
+		log.Printf("AcceptInvite: failed to mark invite %s accepted: %v", invite.ID, err)
+		return c.Status(fiber.StatusNotFound).JSON(api.ErrorResponse{
+			Error:      "Invalid or expired invitation",
+			StatusCode: fiber.StatusNotFound,
+		})
+	}
+
+	user.IsActive = true
+	if err := h.userRepo.Update(c.Context(), user); err != nil {
+		log.Printf("AcceptInvite: failed to update user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to accept invitation",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	if err := h.userRepo.UpdatePassword(c.Context(), user.Id, input.Password); err != nil {
+		log.Printf("AcceptInvite: failed to set password for user %s: %v", user.Id, err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to accept invitation",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.MessageResponse{
+		Message: "Invitation accepted. You can now log in.",
+	})
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"oop/internal/api"
+	"oop/internal/models"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserInviteRepository is a mock implementation of the UserInviteRepository
+type MockUserInviteRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserInviteRepository) Create(invite *models.UserInvite) error {
+	args := m.Called(invite)
+	return args.Error(0)
+}
+
+func (m *MockUserInviteRepository) GetPending() ([]models.UserInvite, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserInvite), args.Error(1)
+}
+
+func (m *MockUserInviteRepository) GetByTokenHash(tokenHash string) (*models.UserInvite, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserInvite), args.Error(1)
+}
+
+func (m *MockUserInviteRepository) MarkAccepted(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// recordingMailer captures sent emails instead of delivering them
+type recordingMailer struct {
+	sent []string
+	err  error
+}
+
+func (m *recordingMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, to)
+	return m.err
+}
+
+func setupInviteTest(role string) (*fiber.App, *MockUserRepository, *MockUserInviteRepository, *recordingMailer) {
+	app := fiber.New()
+	userRepo := new(MockUserRepository)
+	inviteRepo := new(MockUserInviteRepository)
+	mailer := &recordingMailer{}
+	handler := NewUserInviteHandler(userRepo, inviteRepo, mailer, "http://localhost:9000/", []byte("dummy_secret_for_test"))
+
+	setLocals := func(c *fiber.Ctx) error {
+		c.Locals("user_id", "admin-id")
+		c.Locals("role", role)
+		return c.Next()
+	}
+
+	app.Post("/users/invite", setLocals, handler.InviteUsers)
+	app.Post("/users/invite/accept", handler.AcceptInvite)
+	app.Get("/users", setLocals, handler.ListInvitedUsers, func(c *fiber.Ctx) error {
+		return c.Status(fiber.StatusOK).JSON(api.UserListResponse{Users: []*models.User{}})
+	})
+	return app, userRepo, inviteRepo, mailer
+}
+
+func postJSON(app *fiber.App, url string, body interface{}) (*http.Response, error) {
+	jsonBody, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	return app.Test(req)
+}
+
+func TestUserInviteHandler_InviteUsers(t *testing.T) {
+	t.Run("Success with mixed results", func(t *testing.T) {
+		app, userRepo, inviteRepo, mailer := setupInviteTest(RoleAdmin)
+
+		userRepo.On("EmailExists", "new@example.com").Return(false, nil)
+		userRepo.On("EmailExists", "taken@example.com").Return(true, nil)
+		userRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+		userRepo.On("DeactivateUser", mock.AnythingOfType("string")).Return(nil)
+		inviteRepo.On("Create", mock.MatchedBy(func(invite *models.UserInvite) bool {
+			return invite.Email == "new@example.com" && invite.Role == RoleStaff && invite.InvitedBy == "admin-id" && len(invite.TokenHash) == 64
+		})).Return(nil)
+
+		resp, err := postJSON(app, "/users/invite", models.UserInviteRequest{Invites: []models.UserInviteEntry{
+			{Email: "New@Example.com", Role: RoleStaff},
+			{Email: "taken@example.com", Role: RoleStaff},
+			{Email: "not-an-email", Role: RoleStaff},
+			{Email: "new@example.com", Role: RoleStaff},
+			{Email: "other@example.com", Role: "superuser"},
+		}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result api.UserInviteResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Len(t, result.Results, 5)
+		assert.Equal(t, api.InviteStatusInvited, result.Results[0].Status)
+		assert.NotEmpty(t, result.Results[0].UserID)
+		assert.Equal(t, api.InviteStatusSkipped, result.Results[1].Status)
+		assert.Equal(t, api.InviteStatusFailed, result.Results[2].Status)
+		assert.Equal(t, api.InviteStatusSkipped, result.Results[3].Status)
+		assert.Equal(t, api.InviteStatusFailed, result.Results[4].Status)
+		assert.Equal(t, []string{"new@example.com"}, mailer.sent)
+
+		userRepo.AssertExpectations(t)
+		inviteRepo.AssertExpectations(t)
+	})
+
+	t.Run("Account that cannot be deactivated is removed", func(t *testing.T) {
+		app, userRepo, inviteRepo, mailer := setupInviteTest(RoleAdmin)
+
+		userRepo.On("EmailExists", "new@example.com").Return(false, nil)
+		userRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+		userRepo.On("DeactivateUser", mock.AnythingOfType("string")).Return(errors.New("connection reset"))
+		userRepo.On("Delete", mock.AnythingOfType("string")).Return(nil)
+
+		resp, err := postJSON(app, "/users/invite", models.UserInviteRequest{Invites: []models.UserInviteEntry{
+			{Email: "new@example.com", Role: RoleStaff},
+		}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result api.UserInviteResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		require.Len(t, result.Results, 1)
+		assert.Equal(t, api.InviteStatusFailed, result.Results[0].Status)
+		assert.Empty(t, mailer.sent)
+		userRepo.AssertExpectations(t)
+		inviteRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("Forbidden for staff", func(t *testing.T) {
+		app, _, _, _ := setupInviteTest(RoleStaff)
+
+		resp, err := postJSON(app, "/users/invite", models.UserInviteRequest{Invites: []models.UserInviteEntry{{Email: "a@example.com", Role: RoleStaff}}})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Empty invite list", func(t *testing.T) {
+		app, _, _, _ := setupInviteTest(RoleAdmin)
+
+		resp, err := postJSON(app, "/users/invite", models.UserInviteRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestUserInviteHandler_ListInvitedUsers(t *testing.T) {
+	t.Run("Returns pending invites", func(t *testing.T) {
+		app, _, inviteRepo, _ := setupInviteTest(RoleAdmin)
+		inviteRepo.On("GetPending").Return([]models.UserInvite{{ID: "inv-1", Email: "jane@example.com"}}, nil)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/users?status=invited", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var result api.UserInviteListResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Len(t, result.Invites, 1)
+		inviteRepo.AssertExpectations(t)
+	})
+
+	t.Run("Falls through without status filter", func(t *testing.T) {
+		app, _, inviteRepo, _ := setupInviteTest(RoleAdmin)
+
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/users", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var result map[string]interface{}
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.Contains(t, result, "users")
+		inviteRepo.AssertNotCalled(t, "GetPending")
+	})
+}
+
+func TestUserInviteHandler_AcceptInvite(t *testing.T) {
+	token := "setup-token"
+
+	t.Run("Success", func(t *testing.T) {
+		app, userRepo, inviteRepo, _ := setupInviteTest("")
+		invite := &models.UserInvite{ID: "inv-1", UserID: "user-1", ExpiresAt: time.Now().Add(time.Hour)}
+		user := &models.User{Id: "user-1", Username: "jane@example.com", Email: "jane@example.com"}
+
+		inviteRepo.On("GetByTokenHash", hashInviteToken(token)).Return(invite, nil)
+		userRepo.On("GetByID", "user-1").Return(user, nil)
+		userRepo.On("UsernameExists", "janedoe").Return(false, nil)
+		inviteRepo.On("MarkAccepted", "inv-1").Return(nil)
+		userRepo.On("Update", mock.MatchedBy(func(u *models.User) bool {
+			return u.IsActive && u.Username == "janedoe"
+		})).Return(nil)
+		userRepo.On("UpdatePassword", "user-1", "newpassword").Return(nil)
+
+		resp, err := postJSON(app, "/users/invite/accept", models.UserInviteAcceptRequest{Token: token, Password: "newpassword", Username: "janedoe"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		userRepo.AssertExpectations(t)
+		inviteRepo.AssertExpectations(t)
+	})
+
+	t.Run("Expired invite", func(t *testing.T) {
+		app, _, inviteRepo, _ := setupInviteTest("")
+		invite := &models.UserInvite{ID: "inv-1", UserID: "user-1", ExpiresAt: time.Now().Add(-time.Hour)}
+		inviteRepo.On("GetByTokenHash", hashInviteToken(token)).Return(invite, nil)
+
+		resp, err := postJSON(app, "/users/invite/accept", models.UserInviteAcceptRequest{Token: token, Password: "newpassword"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Unknown token", func(t *testing.T) {
+		app, _, inviteRepo, _ := setupInviteTest("")
+		inviteRepo.On("GetByTokenHash", hashInviteToken(token)).Return(nil, errors.New("invite not found"))
+
+		resp, err := postJSON(app, "/users/invite/accept", models.UserInviteAcceptRequest{Token: token, Password: "newpassword"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Missing password", func(t *testing.T) {
+		app, _, _, _ := setupInviteTest("")
+
+		resp, err := postJSON(app, "/users/invite/accept", models.UserInviteAcceptRequest{Token: token})
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"oop/internal/api"
+	"oop/internal/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxRosterRows caps the number of users accepted in a single roster upload
+const maxRosterRows = 1000
+
+// RosterEntry is a single row of an HR roster upload.
+type RosterEntry struct {
+	Email    string `json:"email"`
+	FullName string `json:"fullName"`
+	Role     string `json:"role"`
+}
+
+// UserProvisioningHandler syncs user accounts with an externally maintained staff roster
+type UserProvisioningHandler struct {
+	userRepo UserRepository
+	inviter  *UserInviteHandler
+}
+
+// NewUserProvisioningHandler creates a new UserProvisioningHandler instance.
+// New roster members are onboarded through the invite flow so they set their own password.
+func NewUserProvisioningHandler(userRepo UserRepository, inviter *UserInviteHandler) *UserProvisioningHandler {
+	return &UserProvisioningHandler{
+		userRepo: userRepo,
+		inviter:  inviter,
+	}
+}
+
+// Routes registers the HR roster sync route, which super admins can switch off per
+// tenant. The module must be registered before the users module, whose /users group
+// already requires a token.
+func (h *UserProvisioningHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Post("/users/provision", mw.Auth, mw.Staff, mw.Feature(FeatureUserProvisioning), h.ProvisionUsers) // POST /api/users/provision, dry run by default
+}
+
+// parseRoster reads a CSV roster with an email, full_name and role header.
+// Only the email column is required; role defaults to staff.
+func parseRoster(r io.Reader) ([]RosterEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("roster is empty")
+		}
+		return nil, fmt.Errorf("failed to read roster header: %w", err)
+	}
+
+	columns := map[string]int{"email": -1, "full_name": -1, "role": -1}
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if key == "fullname" || key == "name" {
+			key = "full_name"
+		}
+		if _, ok := columns[key]; ok {
+			columns[key] = i
+		}
+	}
+	if columns["email"] < 0 {
+		return nil, fmt.Errorf("roster header must include an email column")
+	}
+
+	field := func(record []string, column string) string {
+		i := columns[column]
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	entries := []RosterEntry{}
+	seen := make(map[string]bool)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("failed to read roster line %d: %w", line, err)
+		}
+
+		email := strings.ToLower(field(record, "email"))
+		if email == "" {
+			continue
+		}
+		if seen[email] {
+			return nil, fmt.Errorf("duplicate email %s on roster line %d", email, line)
+		}
+		seen[email] = true
+
+		role := strings.ToLower(field(record, "role"))
+		if role == "" {
+			role = RoleStaff
+		}
+		if role != RoleAdmin && role != RoleStaff {
+			return nil, fmt.Errorf("invalid role %q on roster line %d", role, line)
+		}
+
+		entries = append(entries, RosterEntry{Email: email, FullName: field(record, "full_name"), Role: role})
+		if len(entries) > maxRosterRows {
+			return nil, fmt.Errorf("roster exceeds the maximum of %d users", maxRosterRows)
+		}
+	}
+
+	return entries, nil
+}
+
+// diffRoster compares the roster with the current users and returns the changes needed
+// to make them match. The requesting user is never deactivated to avoid a lockout, and
+// users in invited, the IDs of those with a pending invite, are reported but left
+// inactive, so they still set their own password when accepting.
+func diffRoster(roster []RosterEntry, users []*models.User, invited map[string]bool, requestUserID string) []api.ProvisioningChange {
+	usersByEmail := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		usersByEmail[strings.ToLower(user.Email)] = user
+	}
+
+	changes := make([]api.ProvisioningChange, 0, len(roster))
+	inRoster := make(map[string]bool, len(roster))
+	for _, entry := range roster {
+		inRoster[entry.Email] = true
+		change := api.ProvisioningChange{
+			Email:    entry.Email,
+			FullName: entry.FullName,
+			Role:     entry.Role,
+			Status:   api.ProvisionStatusPending,
+		}
+
+		user, exists := usersByEmail[entry.Email]
+		switch {
+		case !exists:
+			change.Action = api.ProvisionActionCreate
+		case invited[user.Id]:
+			change.Action = api.ProvisionActionInvitePending
+			change.UserID = user.Id
+			change.Status = ""
+		case !user.IsActive:
+			change.Action = api.ProvisionActionReactivate
+			change.UserID = user.Id
+			if user.Role != entry.Role {
+				change.PreviousRole = user.Role
+			}
+		case user.Role != entry.Role:
+			change.Action = api.ProvisionActionUpdateRole
+			change.UserID = user.Id
+			change.PreviousRole = user.Role
+		default:
+			change.Action = api.ProvisionActionUnchanged
+			change.UserID = user.Id
+			change.Status = ""
+		}
+		changes = append(changes, change)
+	}
+
+	for _, user := range users {
+		email := strings.ToLower(user.Email)
+		if inRoster[email] || !user.IsActive || user.Id == requestUserID {
+			continue
+		}
+		changes = append(changes, api.ProvisioningChange{
+			Email:    email,
+			Action:   api.ProvisionActionDeactivate,
+			UserID:   user.Id,
+			FullName: user.FullName,
+			Role:     user.Role,
+			Status:   api.ProvisionStatusPending,
+		})
+	}
+
+	return changes
+}
+
+// readRosterUpload reads the roster from a multipart "file" field or a raw text/csv body
+func readRosterUpload(c *fiber.Ctx) ([]RosterEntry, error) {
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open uploaded roster: %w", err)
+		}
+		defer file.Close()
+		return parseRoster(file)
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		return nil, fmt.Errorf("a CSV roster is required")
+	}
+	return parseRoster(strings.NewReader(string(body)))
+}
+
+// ProvisionUsers syncs user accounts with an uploaded HR roster
+// @Summary Provision users from an HR roster (Admin)
+// @Description Compares a CSV roster (email, full_name, role) with existing users. New emails are invited, missing active users are deactivated, inactive roster members are reactivated and role changes are applied. Roster members with a pending invite are reported as invite_pending and left as they are. Runs as a dry run unless dry_run=false.
+// @Tags Users
+// @Accept multipart/form-data,text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file false "CSV roster"
+// @Param dry_run query bool false "Only report the diff without applying it (default true)"
+// @Success 200 {object} api.ProvisioningResponse "Roster diff and per-user results"
+// @Failure 400 {object} api.ErrorResponse "Invalid roster"
+// @Failure 403 {object} api.ErrorResponse "Permission denied"
+// @Failure 500 {object} api.ErrorResponse "Failed to retrieve users or invites"
+// @Router /users/provision [post]
+func (h *UserProvisioningHandler) ProvisionUsers(c *fiber.Ctx) error {
+	requestUserRole, _ := c.Locals("role").(string)
+	if requestUserRole != RoleAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+	}
+	requestUserID, _ := c.Locals("user_id").(string)
+	dryRun := c.QueryBool("dry_run", true)
+
+	roster, err := readRosterUpload(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      err.Error(),
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+	if len(roster) == 0 {
+		// An empty roster would deactivate everyone, which is never what HR intends
+		return c.Status(fiber.StatusBadRequest).JSON(api.ErrorResponse{
+			Error:      "Roster contains no users",
+			StatusCode: fiber.StatusBadRequest,
+		})
+	}
+
+	users, err := h.userRepo.GetAll(c.Context())
+	if err != nil {
+		log.Printf("Error getting users for provisioning: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve users",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+
+	pending, err := h.inviter.inviteRepo.GetPending()
+	if err != nil {
+		log.Printf("Error getting pending invites for provisioning: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(api.ErrorResponse{
+			Error:      "Failed to retrieve invites",
+			StatusCode: fiber.StatusInternalServerError,
+		})
+	}
+	invited := make(map[string]bool, len(pending))
+	for _, invite := range pending {
+		invited[invite.UserID] = true
+	}
+
+	changes := diffRoster(roster, users, invited, requestUserID)
+	if !dryRun {
+		usersByID := make(map[string]*models.User, len(users))
+		for _, user := range users {
+			usersByID[user.Id] = user
+		}
+		for i := range changes {
+			h.applyChange(c.Context(), &changes[i], usersByID[changes[i].UserID], requestUserID)
+		}
+		log.Printf("User %s applied roster provisioning with %d entries", requestUserID, len(roster))
+	}
+
+	summary := make(map[api.ProvisionAction]int)
+	for _, change := range changes {
+		summary[change.Action]++
+	}
+
+	return c.Status(fiber.StatusOK).JSON(api.ProvisioningResponse{
+		DryRun:  dryRun,
+		Summary: summary,
+		Changes: changes,
+	})
+}
+
+// applyChange performs a single provisioning change and records its outcome
+func (h *UserProvisioningHandler) applyChange(ctx context.Context, change *api.ProvisioningChange, user *models.User, requestUserID string) {
+	var err error
+	switch change.Action {
+	case api.ProvisionActionCreate:
+		result := h.inviter.inviteOne(ctx, change.Email, models.UserInviteEntry{
+			Email:    change.Email,
+			FullName: change.FullName,
+			Role:     change.Role,
+		}, requestUserID)
+		change.UserID = result.UserID
+		if result.Status != api.InviteStatusInvited {
+			err = errors.New(result.Error)
+		} else if result.Error != "" {
+			change.Error = result.Error
+		}
+	case api.ProvisionActionReactivate:
+		if change.PreviousRole != "" {
+			err = h.updateRole(ctx, user, change.Role)
+		}
+		if err == nil {
+			err = h.userRepo.ActivateUser(ctx, change.UserID)
+		}
+	case api.ProvisionActionUpdateRole:
+		err = h.updateRole(ctx, user, change.Role)
+	case api.ProvisionActionDeactivate:
+		err = h.userRepo.DeactivateUser(ctx, change.UserID)
+	default:
+		return
+	}
+
+	if err != nil {
+		log.Printf("Provisioning %s for %s failed: %v", change.Action, change.Email, err)
+		change.Status = api.ProvisionStatusFailed
+		change.Error = err.Error()
+		return
+	}
+	change.Status = api.ProvisionStatusApplied
+}
+
+// updateRole persists a role change for an existing user
+func (h *UserProvisioningHandler) updateRole(ctx context.Context, user *models.User, role string) error {
+	if user == nil {
+		return fmt.Errorf("user not found")
+	}
+	user.Role = role
+	return h.userRepo.Update(ctx, user)
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"oop/internal/api"
+	"oop/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testRoster = "email,full_name,role\n" +
+	"new@example.com,New Hire,staff\n" +
+	"STAFF@example.com,Staff Member,admin\n" +
+	"returning@example.com,Returning User,staff\n" +
+	"same@example.com,Same User,staff\n" +
+	"pending@example.com,Pending Invitee,admin\n"
+
+func setupProvisioningTest(role string) (*fiber.App, *MockUserRepository, *MockUserInviteRepository, *recordingMailer) {
+	app := fiber.New()
+	userRepo := new(MockUserRepository)
+	inviteRepo := new(MockUserInviteRepository)
+	mailer := &recordingMailer{}
+	inviter := NewUserInviteHandler(userRepo, inviteRepo, mailer, "http://localhost:9000", []byte("dummy_secret_for_test"))
+	handler := NewUserProvisioningHandler(userRepo, inviter)
+
+	app.Post("/users/provision", func(c *fiber.Ctx) error {
+		c.Locals("user_id", "admin-id")
+		c.Locals("role", role)
+		return c.Next()
+	}, handler.ProvisionUsers)
+	return app, userRepo, inviteRepo, mailer
+}
+
+func provisioningUsers() []*models.User {
+	return []*models.User{
+		{Id: "admin-id", Email: "admin@example.com", Role: RoleAdmin, IsActive: true},
+		{Id: "staff-id", Email: "staff@example.com", Role: RoleStaff, IsActive: true},
+		{Id: "returning-id", Email: "returning@example.com", Role: RoleStaff, IsActive: false},
+		{Id: "same-id", Email: "same@example.com", Role: RoleStaff, IsActive: true},
+		{Id: "leaver-id", Email: "leaver@example.com", Role: RoleStaff, IsActive: true},
+		{Id: "pending-id", Email: "pending@example.com", Role: RoleStaff, IsActive: false},
+	}
+}
+
+func provisioningInvites() []models.UserInvite {
+	return []models.UserInvite{{ID: "invite-1", UserID: "pending-id", Email: "pending@example.com", Role: RoleStaff}}
+}
+
+func postRoster(app *fiber.App, url, roster string) (*http.Response, error) {
+	req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(roster))
+	req.Header.Set("Content-Type", "text/csv")
+	return app.Test(req)
+}
+
+func changesByEmail(changes []api.ProvisioningChange) map[string]api.ProvisioningChange {
+	byEmail := make(map[string]api.ProvisioningChange, len(changes))
+	for _, change := range changes {
+		byEmail[change.Email] = change
+	}
+	return byEmail
+}
+
+func TestUserProvisioningHandler_ProvisionUsers(t *testing.T) {
+	t.Run("Dry run reports diff without changes", func(t *testing.T) {
+		app, userRepo, inviteRepo, mailer := setupProvisioningTest(RoleAdmin)
+		userRepo.On("GetAll").Return(provisioningUsers(), nil)
+		inviteRepo.On("GetPending").Return(provisioningInvites(), nil)
+
+		resp, err := postRoster(app, "/users/provision", testRoster)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result api.ProvisioningResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.True(t, result.DryRun)
+
+		changes := changesByEmail(result.Changes)
+		assert.Equal(t, api.ProvisionActionCreate, changes["new@example.com"].Action)
+		assert.Equal(t, api.ProvisionActionUpdateRole, changes["staff@example.com"].Action)
+		assert.Equal(t, RoleStaff, changes["staff@example.com"].PreviousRole)
+		assert.Equal(t, api.ProvisionActionReactivate, changes["returning@example.com"].Action)
+		assert.Equal(t, api.ProvisionActionUnchanged, changes["same@example.com"].Action)
+		assert.Equal(t, api.ProvisionActionInvitePending, changes["pending@example.com"].Action)
+		assert.Empty(t, changes["pending@example.com"].Status)
+		assert.Equal(t, api.ProvisionActionDeactivate, changes["leaver@example.com"].Action)
+		assert.NotContains(t, changes, "admin@example.com", "requesting admin must never be deactivated")
+		assert.Equal(t, api.ProvisionStatusPending, changes["leaver@example.com"].Status)
+		assert.Equal(t, 1, result.Summary[api.ProvisionActionDeactivate])
+
+		assert.Empty(t, mailer.sent)
+		userRepo.AssertNotCalled(t, "DeactivateUser", mock.Anything)
+		userRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Apply performs changes", func(t *testing.T) {
+		app, userRepo, inviteRepo, mailer := setupProvisioningTest(RoleAdmin)
+		userRepo.On("GetAll").Return(provisioningUsers(), nil)
+		inviteRepo.On("GetPending").Return(provisioningInvites(), nil)
+		userRepo.On("EmailExists", "new@example.com").Return(false, nil)
+		userRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+		userRepo.On("DeactivateUser", mock.AnythingOfType("string")).Return(nil)
+		inviteRepo.On("Create", mock.AnythingOfType("*models.UserInvite")).Return(nil)
+		userRepo.On("Update", mock.MatchedBy(func(u *models.User) bool {
+			return u.Id == "staff-id" && u.Role == RoleAdmin
+		})).Return(nil)
+		userRepo.On("ActivateUser", "returning-id").Return(nil)
+
+		resp, err := postRoster(app, "/users/provision?dry_run=false", testRoster)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result api.ProvisioningResponse
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		assert.False(t, result.DryRun)
+
+		changes := changesByEmail(result.Changes)
+		for _, email := range []string{"new@example.com", "staff@example.com", "returning@example.com", "leaver@example.com"} {
+			assert.Equal(t, api.ProvisionStatusApplied, changes[email].Status, email)
+		}
+		assert.NotEmpty(t, changes["new@example.com"].UserID)
+		assert.Equal(t, []string{"new@example.com"}, mailer.sent)
+		userRepo.AssertCalled(t, "DeactivateUser", "leaver-id")
+		userRepo.AssertNotCalled(t, "DeactivateUser", "admin-id")
+		userRepo.AssertNotCalled(t, "ActivateUser", "pending-id")
+		userRepo.AssertExpectations(t)
+		inviteRepo.AssertExpectations(t)
+	})
+
+	t.Run("Forbidden for staff", func(t *testing.T) {
+		app, _, _, _ := setupProvisioningTest(RoleStaff)
+
+		resp, err := postRoster(app, "/users/provision", testRoster)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Invalid roster", func(t *testing.T) {
+		app, _, _, _ := setupProvisioningTest(RoleAdmin)
+
+		tests := []string{
+			"",
+			"name,role\nJane,staff\n",
+			"email,role\njane@example.com,manager\n",
+			"email\njane@example.com\nJANE@example.com\n",
+			"email,role\n",
+		}
+		for _, roster := range tests {
+			resp, err := postRoster(app, "/users/provision", roster)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, roster)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// NotificationWatchlist is pushed to the users watching an inventory item when it changes
+const NotificationWatchlist = "watchlist"
+
+// Watchlist notifies the users who starred an inventory item when its price changes,
+// it is restocked or it is sold. A nil *Watchlist is valid and notifies nobody, so
+// inventory handlers work without one.
+type Watchlist struct {
+	Favorites repositories.FavoriteRepository
+	Hub       *services.NotificationHub
+}
+
+// NewWatchlist creates a new Watchlist instance
+func NewWatchlist(favorites repositories.FavoriteRepository, hub *services.NotificationHub) *Watchlist {
+	return &Watchlist{Favorites: favorites, Hub: hub}
+}
+
+// Enabled reports whether changes are being pushed. Handlers use it to skip loading
+// the previous state of an item when nobody would be notified.
+func (w *Watchlist) Enabled() bool {
+	return w != nil && w.Favorites != nil && w.Hub != nil
+}
+
+// itemStock is the part of an inventory item its watchers are notified about
+type itemStock struct {
+	Price    float64
+	Quantity int
+}
+
+// ItemUpdated compares an item before and after an update, notifying its watchers
+// of a price change and of a restock when the quantity went up
+func (w *Watchlist) ItemUpdated(c *fiber.Ctx, itemType string, itemID int, name string, before, after itemStock) {
+	if !w.Enabled() {
+		return
+	}
+
+	if after.Price != before.Price {
+		w.notify(c, models.WatchlistEvent{
+			Event: models.WatchEventPriceChanged, ItemType: itemType, ItemID: itemID, ItemName: name,
+			Price: after.Price, PreviousPrice: before.Price, Quantity: after.Quantity,
+		})
+	}
+	if after.Quantity > before.Quantity {
+		w.notify(c, models.WatchlistEvent{
+			Event: models.WatchEventRestocked, ItemType: itemType, ItemID: itemID, ItemName: name,
+			Price: after.Price, Quantity: after.Quantity, PreviousQuantity: before.Quantity,
+		})
+	}
+}
+
+// ItemSold notifies the watchers of an item that units of it were sold
+func (w *Watchlist) ItemSold(c *fiber.Ctx, itemType string, itemID int, name string, price float64, quantity int) {
+	if !w.Enabled() {
+		return
+	}
+
+	w.notify(c, models.WatchlistEvent{
+		Event: models.WatchEventSold, ItemType: itemType, ItemID: itemID, ItemName: name,
+		Price: price, Quantity: quantity,
+	})
+}
+
+func (w *Watchlist) notify(c *fiber.Ctx, event models.WatchlistEvent) {
+	watchers, err := w.Favorites.GetWatchers(event.ItemType, event.ItemID)
+	if err != nil {
+		log.Printf("Error getting watchers of %s %d: %v", event.ItemType, event.ItemID, err)
+		return
+	}
+	if len(watchers) == 0 {
+		return
+	}
+
+	w.Hub.Publish(tenantIDFromCtx(c), models.Notification{Type: NotificationWatchlist, Data: event, Recipients: watchers})
+}
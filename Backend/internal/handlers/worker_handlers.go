@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"oop/internal/api"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WorkerHandler serves the status of the background workers of the server, which
+// are shared by every tenant
+type WorkerHandler struct {
+	Workers   *services.Supervisor
+	jwtSecret []byte
+}
+
+// NewWorkerHandler creates a new WorkerHandler instance
+func NewWorkerHandler(workers *services.Supervisor, jwtSecret []byte) *WorkerHandler {
+	return &WorkerHandler{Workers: workers, jwtSecret: jwtSecret}
+}
+
+// Routes registers the worker status route
+func (h *WorkerHandler) Routes(r fiber.Router, mw RouteMiddleware) {
+	r.Get("/admin/workers", mw.Auth, mw.Admin, h.GetWorkers) // GET /api/admin/workers
+}
+
+// GetWorkers handles listing the background workers
+// @Summary List background workers
+// @Description Lists the jobs and queues running in the background of this server, in the order they were started, with whether each is running, restarting after a crash or stopped, and how often it crashed.
+// @Tags Workers
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} api.WorkerListResponse "Background workers"
+// @Failure 401 {object} api.ErrorResponse "Unauthorized"
+// @Failure 403 {object} api.ErrorResponse "Forbidden"
+// @Router /admin/workers [get]
+func (h *WorkerHandler) GetWorkers(c *fiber.Ctx) error {
+	workers := h.Workers.Workers()
+	return c.Status(fiber.StatusOK).JSON(api.WorkerListResponse{Workers: workers, Count: len(workers)})
+}
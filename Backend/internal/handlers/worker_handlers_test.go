@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"oop/internal/api"
+	"oop/internal/models"
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWorkers(t *testing.T) {
+	jwtSecret := []byte("testsecret")
+	workers := services.NewSupervisor()
+	stop := make(chan struct{})
+	done := workers.Go("Test worker", stop, func() { <-stop })
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	app := fiber.New()
+	NewWorkerHandler(workers, jwtSecret).Routes(app.Group("/api"), NewRouteMiddleware(jwtSecret))
+
+	t.Run("Admin", func(t *testing.T) {
+		resp := authedRequest(t, app, createTenantTestToken(jwtSecret, "admin-1", RoleAdmin, models.DefaultTenantID), http.MethodGet, "/api/admin/workers", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body api.WorkerListResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Equal(t, 1, body.Count)
+		assert.Equal(t, "Test worker", body.Workers[0].Name)
+		assert.Equal(t, models.WorkerRunning, body.Workers[0].State)
+	})
+
+	t.Run("Staff forbidden", func(t *testing.T) {
+		resp := authedRequest(t, app, createTenantTestToken(jwtSecret, "staff-1", RoleStaff, models.DefaultTenantID), http.MethodGet, "/api/admin/workers", nil)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+}
@@ -62,10 +62,20 @@ func JWTMiddleware(secret []byte) fiber.Handler {
 				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims (exp)"})
 			}
 
+			// A session only grants access to the tenant it was issued for
+			tenantID := claimTenantID(claims)
+			if resolved, ok := c.Locals("tenant_id").(string); ok && resolved != "" && resolved != tenantID {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Token was issued for another tenant"})
+			}
+
 			// Store user info in locals for downstream handlers
+			c.Locals("tenant_id", tenantID)
 			c.Locals("user_id", claims["user_id"])
 			c.Locals("email", claims["email"])
 			c.Locals("role", claims["role"])
+			if impersonatedBy, ok := claims["impersonated_by"].(string); ok {
+				c.Locals("impersonated_by", impersonatedBy) // Set on tokens a super admin issued to act as a tenant admin
+			}
 			return c.Next()
 		}
 
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DebugLog creates a middleware that logs each request at debug with its status, how
+// long it took, and the tenant and user it was served for once they are known. It
+// does nothing unless the server logs at debug, such as while an admin has switched
+// it there to diagnose an incident.
+func DebugLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !slog.Default().Enabled(c.Context(), slog.LevelDebug) {
+			return c.Next()
+		}
+
+		started := time.Now()
+		err := c.Next()
+		tenantID, _ := c.Locals("tenant_id").(string)
+		userID, _ := c.Locals("user_id").(string)
+		slog.DebugContext(c.Context(), "Request served",
+			"method", c.Method(),
+			"path", c.Path(),
+			"status", c.Response().StatusCode(),
+			"took", time.Since(started),
+			"tenant", tenantID,
+			"user", userID,
+			"error", err,
+		)
+		return err
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderDuplicateSubmission marks a response replayed for a duplicate request
+const HeaderDuplicateSubmission = "X-Duplicate-Submission"
+
+// DuplicateSubmissions creates a middleware for create endpoints that answers an
+// identical request from the same client within the guard's window with the response
+// of the first one, so a form submitted twice creates one record. Requests are
+// fingerprinted by tenant, client, method, path and a hash of the body; clients are
+// told apart by the user of a valid bearer token, or by IP address without one. Only
+// successful responses are replayed, so a rejected request can be corrected and sent
+// again right away. Replayed responses carry the X-Duplicate-Submission header.
+func DuplicateSubmissions(guard *services.SubmissionGuard, secret []byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !guard.Enabled() {
+			return c.Next()
+		}
+
+		fingerprint := submissionFingerprint(c, secret)
+		if previous, ok := guard.Begin(fingerprint); ok {
+			c.Set(HeaderDuplicateSubmission, "true")
+			c.Set(fiber.HeaderContentType, previous.ContentType)
+			return c.Status(previous.Status).Send(previous.Body)
+		}
+
+		var response *services.SubmittedResponse
+		defer func() { guard.Finish(fingerprint, response) }() // Also releases waiting duplicates if a handler panics
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if status := c.Response().StatusCode(); status >= fiber.StatusOK && status < fiber.StatusMultipleChoices {
+			response = &services.SubmittedResponse{
+				Status:      status,
+				ContentType: string(c.Response().Header.ContentType()),
+				Body:        append([]byte(nil), c.Response().Body()...), // The response buffer is reused after the request
+			}
+		}
+		return nil
+	}
+}
+
+// submissionFingerprint identifies a request by who sent what to which endpoint
+func submissionFingerprint(c *fiber.Ctx, secret []byte) string {
+	tenantID, _ := c.Locals("tenant_id").(string)
+	client := "ip:" + c.IP()
+	if userID := BearerUserID(c, secret); userID != "" {
+		client = "user:" + userID
+	}
+
+	body := sha256.Sum256(c.Body())
+	return strings.Join([]string{tenantID, client, c.Method(), strings.TrimSuffix(c.Path(), "/"), hex.EncodeToString(body[:])}, "|")
+}
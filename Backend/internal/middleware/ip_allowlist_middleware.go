@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"log"
+	"net"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IPAllowlist creates a middleware that only lets requests through from the given
+// networks, such as the office network or VPN, rejecting any other with 403. The
+// address checked is the one the request connects from. An empty allowlist lets every
+// request through.
+func IPAllowlist(networks []*net.IPNet) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if len(networks) == 0 {
+			return c.Next()
+		}
+
+		if ip := net.ParseIP(c.IP()); ip != nil {
+			for _, network := range networks {
+				if network.Contains(ip) {
+					return c.Next()
+				}
+			}
+		}
+
+		log.Printf("Rejected %s %s from %s: address is not in the admin IP allowlist", c.Method(), c.Path(), c.IP())
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "This route cannot be reached from your network"})
+	}
+}
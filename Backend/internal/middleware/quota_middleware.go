@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+
+	"oop/internal/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Rate limit headers sent with every response while a per-minute quota is configured
+const (
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"     // Requests allowed per minute
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining" // Requests left this minute
+	HeaderRateLimitReset     = "X-RateLimit-Reset"     // Seconds until the allowance is restored
+)
+
+// Quota creates a middleware that meters every request of the tenant stored by
+// TenantResolver and rejects it once a quota is used up: 429 with Retry-After when
+// the tenant or client sent too many requests this minute, 413 when the body is too
+// large or the tenant's daily upload allowance is spent. Clients are told apart by the
+// user of a valid bearer token, so each integration account is metered on its own,
+// and by IP address otherwise. While a per-minute quota is configured, every response
+// carries the X-RateLimit headers of the tighter of the tenant and client limits, so
+// clients can slow down before they are rejected.
+func Quota(meter *services.UsageMeter, secret []byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID, _ := c.Locals("tenant_id").(string)
+
+		client := "ip:" + c.IP()
+		if userID := BearerUserID(c, secret); userID != "" {
+			client = "user:" + userID
+		}
+
+		verdict, retryAfter := meter.Admit(tenantID, client, len(c.Body()))
+		if limit, ok := meter.RateLimit(tenantID, client); ok {
+			c.Set(HeaderRateLimitLimit, strconv.Itoa(limit.Limit))
+			c.Set(HeaderRateLimitRemaining, strconv.Itoa(limit.Remaining))
+			c.Set(HeaderRateLimitReset, strconv.Itoa(int(math.Ceil(limit.ResetIn.Seconds()))))
+		}
+
+		switch verdict {
+		case services.QuotaRateLimited:
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": "Request quota exceeded, try again later"})
+		case services.QuotaTooLarge:
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Request body exceeds the size limit"})
+		case services.QuotaUploadsExhausted:
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "Daily upload quota exceeded"})
+		}
+
+		return c.Next()
+	}
+}
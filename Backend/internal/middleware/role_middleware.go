@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"slices"
+
+	"oop/internal/api"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRoles creates a middleware that only lets through callers whose role is one
+// of roles, rejecting any other with 403. It reads the role JWTMiddleware stores, so
+// it must run after it.
+func RequireRoles(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if role == "" || !slices.Contains(roles, role) {
+			return c.Status(fiber.StatusForbidden).JSON(api.ErrorResponse{Error: "Permission denied", StatusCode: fiber.StatusForbidden})
+		}
+		return c.Next()
+	}
+}
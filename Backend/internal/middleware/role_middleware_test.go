@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequireRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   interface{}
+		status int
+	}{
+		{"Admin", "admin", fiber.StatusOK},
+		{"Staff", "staff", fiber.StatusOK},
+		{"OtherRole", "viewer", fiber.StatusForbidden},
+		{"NoRole", nil, fiber.StatusForbidden},
+		{"RoleNotAString", 1, fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				c.Locals("role", tt.role) // Set by JWTMiddleware in the app
+				return c.Next()
+			}, RequireRoles("admin", "staff"), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"oop/internal/config"
+	"oop/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TenantLookup finds tenants by ID or by the subdomain they are served on
+type TenantLookup interface {
+	GetByID(id string) (*models.Tenant, error)
+	GetBySlug(slug string) (*models.Tenant, error)
+}
+
+// TenantResolver creates a middleware that determines the tenant of each request and
+// stores its ID in c.Locals("tenant_id"). A valid session token decides first, then the
+// subdomain when cfg.BaseDomain is set; any other request belongs to the default tenant.
+// A token presented on another tenant's subdomain is rejected, unless it was issued for
+// the sandbox of that tenant, as are unknown and inactive tenants.
+func TenantResolver(tenants TenantLookup, cfg config.TenancyConfig, secret []byte) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var tenant *models.Tenant
+		var err error
+
+		tokenTenantID := tenantIDFromToken(c, secret)
+		if slug := cfg.TenantSlug(c.Hostname()); slug != "" {
+			tenant, err = tenants.GetBySlug(slug)
+			if err == nil && tokenTenantID != "" && tokenTenantID == models.SandboxTenantID(tenant.ID) {
+				tenant, err = tenants.GetByID(tokenTenantID)
+			} else if err == nil && tokenTenantID != "" && tokenTenantID != tenant.ID {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Token was issued for another tenant"})
+			}
+		} else {
+			tenantID := tokenTenantID
+			if tenantID == "" {
+				tenantID = models.DefaultTenantID
+			}
+			tenant, err = tenants.GetByID(tenantID)
+		}
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tenant not found"})
+			}
+			log.Printf("Error resolving tenant: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to resolve tenant"})
+		}
+		if !tenant.IsActive {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Tenant is inactive"})
+		}
+
+		c.Locals("tenant_id", tenant.ID)
+		return c.Next()
+	}
+}
+
+// tenantIDFromToken returns the tenant claim of a valid bearer token. Tokens issued
+// before tenants existed carry no claim and belong to the default tenant. Missing or
+// invalid tokens yield "" and are left for JWTMiddleware to reject on protected routes.
+func tenantIDFromToken(c *fiber.Ctx, secret []byte) string {
+	claims := bearerClaims(c, secret)
+	if claims == nil {
+		return ""
+	}
+	return claimTenantID(claims)
+}
+
+// bearerClaims returns the claims of a valid bearer token, or nil. It never rejects
+// a request; that is left to JWTMiddleware on protected routes.
+func bearerClaims(c *fiber.Ctx, secret []byte) jwt.MapClaims {
+	tokenString, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "unexpected signing method")
+		}
+		return secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	return claims
+}
+
+// BearerUserID returns the user of a valid bearer token, or "" when the request has
+// none. Public routes use it to tell signed-in callers apart without requiring a token.
+func BearerUserID(c *fiber.Ctx, secret []byte) string {
+	claims := bearerClaims(c, secret)
+	if claims == nil {
+		return ""
+	}
+	userID, _ := claims["user_id"].(string)
+	return userID
+}
+
+// BearerRole returns the role in a valid bearer token, or "" when the request has none
+func BearerRole(c *fiber.Ctx, secret []byte) string {
+	claims := bearerClaims(c, secret)
+	if claims == nil {
+		return ""
+	}
+	role, _ := claims["role"].(string)
+	return role
+}
+
+// claimTenantID reads the tenant claim, defaulting to the default tenant
+func claimTenantID(claims jwt.MapClaims) string {
+	if tenantID, ok := claims["tenant_id"].(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return models.DefaultTenantID
+}
+
+// TenantApps serves each tenant from its own Fiber app. An app is built by build the
+// first time its tenant is seen, with handlers bound to that tenant's repositories,
+// and reused afterwards.
+type TenantApps struct {
+	build func(tenantID string) (*fiber.App, error)
+
+	mu   sync.Mutex
+	apps map[string]func(c *fiber.Ctx)
+}
+
+// NewTenantApps creates a dispatcher that builds tenant apps with build
+func NewTenantApps(build func(tenantID string) (*fiber.App, error)) *TenantApps {
+	return &TenantApps{build: build, apps: make(map[string]func(c *fiber.Ctx))}
+}
+
+// Handler passes each request to the app of the tenant stored by TenantResolver.
+// Locals set so far, such as the tenant ID, remain visible to the tenant app.
+func (t *TenantApps) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tenantID, _ := c.Locals("tenant_id").(string)
+		if tenantID == "" {
+			tenantID = models.DefaultTenantID
+		}
+
+		serve, err := t.app(tenantID)
+		if err != nil {
+			log.Printf("Error building app for tenant %s: %v", tenantID, err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load tenant"})
+		}
+		serve(c)
+		return nil
+	}
+}
+
+// app returns the cached app of a tenant, building it on first use
+func (t *TenantApps) app(tenantID string) (func(c *fiber.Ctx), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if serve, ok := t.apps[tenantID]; ok {
+		return serve, nil
+	}
+
+	app, err := t.build(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	handler := app.Handler()
+	serve := func(c *fiber.Ctx) { handler(c.Context()) }
+	t.apps[tenantID] = serve
+	return serve, nil
+}
+
+// Forget drops the app of a tenant, so the next request builds it again, e.g. after
+// the data it was bound to was replaced
+func (t *TenantApps) Forget(tenantID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.apps, tenantID)
+}
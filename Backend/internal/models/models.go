@@ -1,6 +1,12 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -21,25 +27,314 @@ type Customer struct {
 	FullName       string    `json:"fullName"`
 	Email          string    `json:"email"`
 	Phone          string    `json:"phone"`
-	Address        string    `json:"address"`
+	Address        string    `json:"address"` // House number and street
+	Province       string    `json:"province"`
+	City           string    `json:"city"` // City or municipality
+	Barangay       string    `json:"barangay"`
+	Latitude       *float64  `json:"latitude,omitempty"` // Nil unless the address was geocoded
+	Longitude      *float64  `json:"longitude,omitempty"`
+	DateOfBirth    string    `json:"dateOfBirth"` // YYYY-MM-DD, empty when unknown
 	DateRegistered time.Time `json:"dateRegistered"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
 }
 
+// Occasions customers can be greeted on
+const (
+	OccasionBirthday    = "birthday"
+	OccasionAnniversary = "anniversary" // Of the customer's registration
+)
+
+// CustomerOccasion is an upcoming birthday or registration anniversary of a customer
+type CustomerOccasion struct {
+	CustomerID string `json:"customerId"`
+	FullName   string `json:"fullName"`
+	Occasion   string `json:"occasion"`
+	Date       string `json:"date"`     // YYYY-MM-DD the occasion next falls on
+	DaysAway   int    `json:"daysAway"` // 0 when it is today
+	Years      int    `json:"years"`    // Age turned, or years since registering
+}
+
+// CustomerConsent is whether a customer agreed to marketing and whether they asked not
+// to be contacted at all, with when and how each was last set. Campaigns only reach
+// customers who consented and did not ask not to be contacted.
+type CustomerConsent struct {
+	CustomerID             string     `json:"customerId"`
+	MarketingConsent       bool       `json:"marketingConsent"`
+	MarketingConsentAt     *time.Time `json:"marketingConsentAt,omitempty"`     // Nil until consent is first given or withdrawn
+	MarketingConsentSource string     `json:"marketingConsentSource,omitempty"` // How consent was given or withdrawn, e.g. in_store_form
+	DoNotContact           bool       `json:"doNotContact"`
+	DoNotContactAt         *time.Time `json:"doNotContactAt,omitempty"`
+	DoNotContactSource     string     `json:"doNotContactSource,omitempty"`
+	UpdatedBy              string     `json:"updatedBy,omitempty"`
+}
+
 type Sale struct {
 	ID         string
 	CustomerID string
 	SoldBy     string
 	SaleDate   string
 	TotalPrice float64
+	TaxType    string    // How the sale is taxed, one of the Tax* constants
+	VATAmount  float64   // VAT included in TotalPrice; zero unless TaxType is TaxVatable
 	CreatedAt  time.Time `json:"createdAt"`
 	UpdatedAt  time.Time `json:"updatedAt"`
+	// DisplayNumber is generated from the sale numbering format when the sale is
+	// recorded; empty when no format was set then
+	DisplayNumber string `json:"displayNumber,omitempty"`
+	// Balance is the part of TotalPrice not paid yet, computed from the payments of
+	// the sale; omitted when payments are not tracked
+	Balance *float64 `json:"balance,omitempty"`
+	// DownPayment is the amount paid when a sale is created, the rest being paid in
+	// installments. Only read on creation; a sale without it is paid in full.
+	DownPayment *float64 `json:"downPayment,omitempty"`
+	// Status is where the sale is in its workflow, one of the Sale* statuses. Sales
+	// are confirmed when recorded unless saved as drafts.
+	Status string `json:"status,omitempty" enums:"draft,confirmed,completed,cancelled,refunded"`
+	// StockTaken is whether recording the sale took its cabs and accessories out of
+	// stock, so calling it off puts them back. Sales recorded without their items,
+	// such as drafts, take nothing.
+	StockTaken bool `json:"-"`
+	// Items are the items of the sale, only listed with the sale when asked for and
+	// omitted when it has none
+	Items []SaleItem `json:"items,omitempty"`
+}
+
+// Statuses of a sale. Drafts have not taken anything out of stock; cancelling or
+// refunding a confirmed or completed sale that took stock puts its cabs and
+// accessories back.
+const (
+	SaleDraft     = "draft"
+	SaleConfirmed = "confirmed"
+	SaleCompleted = "completed" // Paid and handed over; no longer editable
+	SaleCancelled = "cancelled"
+	SaleRefunded  = "refunded"
+)
+
+// saleTransitions lists the statuses each sale status can move to. Confirmed sales
+// are refunded when the items were handed over before the sale was completed;
+// completed sales can only be refunded, and cancelled and refunded sales are final.
+var saleTransitions = map[string][]string{
+	SaleDraft:     {SaleConfirmed, SaleCancelled},
+	SaleConfirmed: {SaleCompleted, SaleCancelled, SaleRefunded},
+	SaleCompleted: {SaleRefunded},
+	SaleCancelled: {},
+	SaleRefunded:  {},
+}
+
+// ValidSaleStatus reports whether status is a known sale status
+func ValidSaleStatus(status string) bool {
+	_, ok := saleTransitions[status]
+	return ok
+}
+
+// CurrentStatus is the status of the sale, confirmed when it has none like the sales
+// recorded before sales had statuses
+func (s Sale) CurrentStatus() string {
+	if s.Status == "" {
+		return SaleConfirmed
+	}
+	return s.Status
+}
+
+// CanTransition reports whether the sale may move to status
+func (s Sale) CanTransition(status string) bool {
+	return slices.Contains(saleTransitions[s.CurrentStatus()], status)
+}
+
+// Final reports whether the sale was cancelled or refunded, after which its status
+// cannot change
+func (s Sale) Final() bool {
+	return len(saleTransitions[s.CurrentStatus()]) == 0
+}
+
+// Counted reports whether the sale counts towards revenue, taxes and the other
+// reports: drafts were never made, and cancelled and refunded sales were undone
+func (s Sale) Counted() bool {
+	status := s.CurrentStatus()
+	return status == SaleConfirmed || status == SaleCompleted
+}
+
+// Editable reports whether the sale may still be changed
+func (s Sale) Editable() bool {
+	status := s.CurrentStatus()
+	return status == SaleDraft || status == SaleConfirmed
+}
+
+// RestocksOn reports whether moving the sale to status puts its items back in stock,
+// which only a sale that took them out of stock does
+func (s Sale) RestocksOn(status string) bool {
+	taken := s.StockTaken && (s.CurrentStatus() == SaleConfirmed || s.CurrentStatus() == SaleCompleted)
+	return taken && (status == SaleCancelled || status == SaleRefunded)
+}
+
+// SaleRefund is a return of some or all of the items of a confirmed or completed
+// sale. The items returned are put back in stock if the sale took them out of stock;
+// once every item is returned the sale is refunded.
+type SaleRefund struct {
+	ID         string           `json:"id"`
+	SaleID     string           `json:"saleId"`
+	Items      []SaleRefundItem `json:"items"`
+	Amount     float64          `json:"amount"` // Sum of the amounts of the items
+	Reason     string           `json:"reason,omitempty"`
+	RefundedBy string           `json:"refundedBy"`
+	RefundedAt time.Time        `json:"refundedAt"`
+}
+
+// SaleRefundItem is the quantity of one item of a sale returned in a refund
+type SaleRefundItem struct {
+	SaleItemID string  `json:"saleItemId"`
+	Item       Ref     `json:"itemRef"`
+	Quantity   int     `json:"quantity"`
+	Amount     float64 `json:"amount"` // Quantity at the unit price the item was sold at
+}
+
+// SalesFilter narrows a sales listing. Empty fields do not filter.
+type SalesFilter struct {
+	CustomerID string
+	SoldBy     string
+	Status     string // One of the Sale* statuses
+	StartDate  string // First sale date included, YYYY-MM-DD
+	EndDate    string // Last sale date included, YYYY-MM-DD
+}
+
+// Validate reports the first field of the filter a listing cannot use
+func (f SalesFilter) Validate() error {
+	if f.Status != "" && !ValidSaleStatus(f.Status) {
+		return fmt.Errorf("status must be draft, confirmed, completed, cancelled or refunded")
+	}
+	for _, date := range []string{f.StartDate, f.EndDate} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return fmt.Errorf("dates must be formatted as YYYY-MM-DD")
+		}
+	}
+	if f.StartDate != "" && f.EndDate != "" && f.EndDate < f.StartDate {
+		return fmt.Errorf("the end date cannot be before the start date")
+	}
+	return nil
+}
+
+// Tax types of a sale for BIR filing. Vatable prices include VAT; exempt and
+// zero-rated sales carry none.
+const (
+	TaxVatable   = "vatable"
+	TaxExempt    = "exempt"
+	TaxZeroRated = "zero_rated"
+)
+
+// VATRate is the value-added tax rate included in the price of vatable sales
+const VATRate = 0.12
+
+// ValidTaxType reports whether taxType is a known tax type
+func ValidTaxType(taxType string) bool {
+	return taxType == TaxVatable || taxType == TaxExempt || taxType == TaxZeroRated
+}
+
+// PriceBound returns a copy of a bound of a price guard, or nil when it is unset or
+// not above zero, as such a bound is not checked
+func PriceBound(bound *float64) *float64 {
+	if bound == nil || *bound <= 0 {
+		return nil
+	}
+	value := *bound
+	return &value
+}
+
+// ApplyTax defaults the tax type of the sale to vatable and records the VAT
+// included in its total price
+func (s *Sale) ApplyTax() {
+	if s.TaxType == "" {
+		s.TaxType = TaxVatable
+	}
+	s.VATAmount = 0
+	if s.TaxType == TaxVatable {
+		s.VATAmount = math.Round(s.TotalPrice*VATRate/(1+VATRate)*100) / 100
+	}
+}
+
+// Entity types of external reference IDs
+const (
+	RefCab       = "cab"
+	RefAccessory = "accessory"
+	RefMaterial  = "material"
+	RefSale      = "sale"
+	RefCustomer  = "customer"
+)
+
+// Ref is the external reference ID of a record: its entity type and ID joined by a
+// colon, e.g. "cab:12" or "sale:3f2a...". Cabs, accessories and materials have int
+// IDs while sales and customers have UUIDs; a Ref names either the same way.
+type Ref string
+
+// NewRef returns the reference to the record of the entity type with the ID
+func NewRef(entityType, id string) Ref {
+	return Ref(entityType + ":" + id)
+}
+
+// IntRef returns the reference to the cab, accessory or material with the ID
+func IntRef(entityType string, id int) Ref {
+	return NewRef(entityType, strconv.Itoa(id))
+}
+
+// Type is the entity type of the referenced record, empty for the zero Ref
+func (r Ref) Type() string {
+	entityType, _, _ := strings.Cut(string(r), ":")
+	return entityType
+}
+
+// ID is the ID of the referenced record
+func (r Ref) ID() string {
+	_, id, _ := strings.Cut(string(r), ":")
+	return id
 }
 
+// IntID is the ID of the referenced cab, accessory or material, 0 when it is not an int
+func (r Ref) IntID() int {
+	id, _ := strconv.Atoi(r.ID())
+	return id
+}
+
+// Ref is the external reference ID of the customer
+func (c Customer) Ref() Ref { return NewRef(RefCustomer, c.ID) }
+
+// Ref is the external reference ID of the sale
+func (s Sale) Ref() Ref { return NewRef(RefSale, s.ID) }
+
+// Ref is the external reference ID of the accessory
+func (a Accessory) Ref() Ref { return IntRef(RefAccessory, a.ID) }
+
+// Ref is the external reference ID of the material
+func (m Material) Ref() Ref { return IntRef(RefMaterial, m.ID) }
+
+// Ref is the external reference ID of the cab
+func (c MultiCab) Ref() Ref { return IntRef(RefCab, c.ID) }
+
+// SaleItem is a cab, accessory or material sold in a sale, referenced by Item
 type SaleItem struct {
+	ID        string
+	SaleID    string
+	Item      Ref
+	Quantity  int
+	UnitPrice float64
+	Subtotal  float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// Name is the name of the cab, accessory or material sold, only looked up when
+	// items are listed with the sales of a customer
+	Name string
+}
+
+// ItemType is the type of the item sold: cab, accessory or material
+func (i SaleItem) ItemType() string {
+	return i.Item.Type()
+}
+
+// saleItemJSON is the JSON form of a SaleItem. It keeps the item type and the
+// per-type ID fields clients used before items carried an itemRef.
+type saleItemJSON struct {
 	ID          string
 	SaleID      string
+	ItemRef     Ref `json:"itemRef"`
 	ItemType    string
 	MultiCabID  string
 	AccessoryID string
@@ -49,6 +344,65 @@ type SaleItem struct {
 	Subtotal    float64
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+	Name        string    `json:"name,omitempty"`
+}
+
+// SaleItemRef returns the reference to the item sold from the columns sale items
+// were stored with: the item type and the ID of a cab, accessory or material
+func SaleItemRef(itemType, multiCabID, accessoryID, materialID string) Ref {
+	switch itemType {
+	case RefCab:
+		return NewRef(itemType, multiCabID)
+	case RefAccessory:
+		return NewRef(itemType, accessoryID)
+	case RefMaterial:
+		return NewRef(itemType, materialID)
+	}
+	return ""
+}
+
+// SaleItemColumns splits the reference to the item sold into the per-type ID
+// columns sale items are stored with, leaving the other types empty
+func SaleItemColumns(item Ref) (multiCabID, accessoryID, materialID string) {
+	switch item.Type() {
+	case RefCab:
+		multiCabID = item.ID()
+	case RefAccessory:
+		accessoryID = item.ID()
+	case RefMaterial:
+		materialID = item.ID()
+	}
+	return multiCabID, accessoryID, materialID
+}
+
+// MarshalJSON writes the item with both its itemRef and the legacy per-type fields
+func (i SaleItem) MarshalJSON() ([]byte, error) {
+	multiCabID, accessoryID, materialID := SaleItemColumns(i.Item)
+	return json.Marshal(saleItemJSON{
+		ID: i.ID, SaleID: i.SaleID, ItemRef: i.Item, ItemType: i.Item.Type(),
+		MultiCabID: multiCabID, AccessoryID: accessoryID, MaterialID: materialID,
+		Quantity: i.Quantity, UnitPrice: i.UnitPrice, Subtotal: i.Subtotal,
+		CreatedAt: i.CreatedAt, UpdatedAt: i.UpdatedAt, Name: i.Name,
+	})
+}
+
+// UnmarshalJSON reads an item referenced by itemRef or, as older clients send it,
+// by its item type and per-type ID field
+func (i *SaleItem) UnmarshalJSON(data []byte) error {
+	var v saleItemJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	item := v.ItemRef
+	if item == "" {
+		item = SaleItemRef(v.ItemType, v.MultiCabID, v.AccessoryID, v.MaterialID)
+	}
+	*i = SaleItem{
+		ID: v.ID, SaleID: v.SaleID, Item: item,
+		Quantity: v.Quantity, UnitPrice: v.UnitPrice, Subtotal: v.Subtotal,
+		CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt, Name: v.Name,
+	}
+	return nil
 }
 
 type StockTransaction struct {
@@ -100,16 +454,20 @@ const (
 
 // Accessory represents an accessory item in the inventory
 type Accessory struct {
-	ID        int             `json:"id"`         // Unique identifier
-	Name      string          `json:"name"`       // Name of the accessory
-	Make      AccessoryMake   `json:"make"`       // Manufacturer/brand of the accessory
-	Quantity  int             `json:"quantity"`   // Number of units available
-	Price     float64         `json:"price"`      // Price in PHP
-	Status    AccessoryStatus `json:"status"`     // Inventory status
-	UnitColor AccessoryColor  `json:"unit_color"` // Color of the accessory
-	Image     string          `json:"image"`      // URL or base64 string of the image
-	CreatedAt time.Time       `json:"createdAt"`  // Timestamp of creation
-	UpdatedAt time.Time       `json:"updatedAt"`  // Timestamp of last update
+	ID        int             `json:"id"`                  // Unique identifier
+	Name      string          `json:"name"`                // Name of the accessory
+	Make      AccessoryMake   `json:"make"`                // Manufacturer/brand of the accessory
+	Quantity  int             `json:"quantity"`            // Number of units available
+	Price     float64         `json:"price"`               // Price in PHP
+	MinPrice  *float64        `json:"min_price,omitempty"` // Lowest price it may be sold at, unchecked when nil
+	MaxPrice  *float64        `json:"max_price,omitempty"` // Highest price it may be sold at, unchecked when nil
+	Status    AccessoryStatus `json:"status"`              // Inventory status
+	UnitColor AccessoryColor  `json:"unit_color"`          // Color of the accessory
+	Image     string          `json:"image"`               // URL or base64 string of the image
+	Images    []ItemImage     `json:"images,omitempty"`    // Photo gallery; only in detail responses
+	CreatedAt time.Time       `json:"createdAt"`           // Timestamp of creation
+	UpdatedAt time.Time       `json:"updatedAt"`           // Timestamp of last update
+	DeletedAt *time.Time      `json:"deletedAt,omitempty"` // Set on deleted accessories, listed only with include_deleted
 }
 
 // NewAccessoryInput represents data required to create a new accessory
@@ -118,6 +476,8 @@ type NewAccessoryInput struct {
 	Make      AccessoryMake  `json:"make" validate:"required"`
 	Quantity  int            `json:"quantity" validate:"required,min=0"`
 	Price     float64        `json:"price" validate:"required,min=0"`
+	MinPrice  *float64       `json:"min_price"`
+	MaxPrice  *float64       `json:"max_price"`
 	UnitColor AccessoryColor `json:"unit_color" validate:"required"`
 	Image     string         `json:"image"`
 }
@@ -128,6 +488,8 @@ type UpdateAccessoryInput struct {
 	Make      *AccessoryMake  `json:"make"`
 	Quantity  *int            `json:"quantity" validate:"omitempty,min=0"`
 	Price     *float64        `json:"price" validate:"omitempty,min=0"`
+	MinPrice  *float64        `json:"min_price"` // 0 removes the bound
+	MaxPrice  *float64        `json:"max_price"` // 0 removes the bound
 	UnitColor *AccessoryColor `json:"unit_color"`
 	Image     *string         `json:"image"`
 }
@@ -143,15 +505,25 @@ type MultiCabAccessory struct {
 }
 
 type Material struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Category  string    `json:"category"`
-	Supplier  string    `json:"supplier"`
-	Quantity  int       `json:"quantity"`
-	Status    string    `json:"status"`
-	Image     string    `json:"image"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	Category  string      `json:"category"`
+	Supplier  string      `json:"supplier"`
+	Quantity  int         `json:"quantity"`
+	Status    string      `json:"status"`
+	Image     string      `json:"image"`
+	Images    []ItemImage `json:"images,omitempty"` // Photo gallery; only in detail responses
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	DeletedAt *time.Time  `json:"deletedAt,omitempty"` // Set on deleted materials, listed only with include_deleted
+}
+
+// UserPresence is when a user was last active and at which branch
+type UserPresence struct {
+	UserID     string    `json:"userId"`
+	Role       string    `json:"role"`
+	Branch     string    `json:"branch,omitempty"` // Branch code sent with the user's last heartbeat that had one
+	LastSeenAt time.Time `json:"lastSeenAt"`
 }
 
 type MultiCabMaterial struct {
@@ -166,16 +538,72 @@ type MultiCabMaterial struct {
 
 // MultiCab defines the structure for cab data, aligning with frontend needs.
 type MultiCab struct {
-	ID        int       `json:"id"`         // Unique identifier
-	Name      string    `json:"name"`       // Name of the cab model (e.g., RX-7)
-	Make      string    `json:"make"`       // Manufacturer (e.g., Mazda)
-	Quantity  int       `json:"quantity"`   // Number of units available
-	Price     float64   `json:"price"`      // Price in PHP
-	Status    string    `json:"status"`     // Inventory status (e.g., In Stock, Low Stock)
-	UnitColor string    `json:"unit_color"` // Color of the cab unit
-	Image     string    `json:"image"`      // URL or base64 string of the image
-	CreatedAt time.Time `json:"createdAt"`  // Timestamp of creation
-	UpdatedAt time.Time `json:"updatedAt"`  // Timestamp of last update
+	ID        int         `json:"id"`                  // Unique identifier
+	Name      string      `json:"name"`                // Name of the cab model (e.g., RX-7)
+	Make      string      `json:"make"`                // Manufacturer (e.g., Mazda)
+	Quantity  int         `json:"quantity"`            // Number of units available
+	Price     float64     `json:"price"`               // Price in PHP
+	MinPrice  *float64    `json:"min_price,omitempty"` // Lowest price it may be sold at, unchecked when nil
+	MaxPrice  *float64    `json:"max_price,omitempty"` // Highest price it may be sold at, unchecked when nil
+	Status    string      `json:"status"`              // Inventory status (e.g., In Stock, Low Stock)
+	UnitColor string      `json:"unit_color"`          // Color of the cab unit
+	Image     string      `json:"image"`               // URL or base64 string of the image
+	Images    []ItemImage `json:"images,omitempty"`    // Photo gallery; only in detail responses
+	CreatedAt time.Time   `json:"createdAt"`           // Timestamp of creation
+	UpdatedAt time.Time   `json:"updatedAt"`           // Timestamp of last update
+	DeletedAt *time.Time  `json:"deletedAt,omitempty"` // Set on deleted cabs, listed only with include_deleted
+	// DisplayNumber is generated from the cab numbering format when the cab is added;
+	// empty when no format was set then
+	DisplayNumber string `json:"displayNumber,omitempty"`
+}
+
+// CabSortFields are the columns the cab listing may be sorted by
+var CabSortFields = []string{"id", "name", "make", "quantity", "price", "status", "unit_color", "created_at", "updated_at"}
+
+// CabFilter narrows a cab listing. Empty fields do not filter.
+type CabFilter struct {
+	Make      string
+	UnitColor string
+	Status    string
+	Search    string // Part of the name or make
+	SortBy    string // One of CabSortFields; newest first when empty
+	SortDir   string // asc (default) or desc
+	Limit     int    // At most this many cabs when positive
+	Offset    int    // Cabs skipped before the first one listed, with Limit
+}
+
+// Validate reports the first field of the filter a listing cannot use
+func (f CabFilter) Validate() error {
+	if f.SortBy != "" && !slices.Contains(CabSortFields, f.SortBy) {
+		return fmt.Errorf("sort_by must be one of %s", strings.Join(CabSortFields, ", "))
+	}
+	if f.SortDir != "" && !strings.EqualFold(f.SortDir, "asc") && !strings.EqualFold(f.SortDir, "desc") {
+		return fmt.Errorf("sort_dir must be asc or desc")
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("limit must be a positive number")
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("offset must be zero or a positive number")
+	}
+	return nil
+}
+
+// AccessoryFilter narrows an accessory listing. Empty fields do not filter.
+type AccessoryFilter struct {
+	Make      string
+	Status    string
+	UnitColor string
+	Search    string // An accessory ID when numeric, otherwise part of the name
+}
+
+// MaterialFilter narrows a material listing. Empty fields do not filter; the others
+// match regardless of case.
+type MaterialFilter struct {
+	Search   string // A material ID when numeric, otherwise part of the name, category or supplier
+	Category string
+	Supplier string
+	Status   string
 }
 
 // AccessoryForSale represents an accessory included in a cab sale
@@ -192,6 +620,8 @@ type CabSalePayload struct {
 	CustomerID  string             `json:"customerId" validate:"required"`     // ID of the customer making the purchase
 	Quantity    int                `json:"quantity" validate:"required,min=1"` // Number of cabs being sold
 	Accessories []AccessoryForSale `json:"accessories"`                        // Optional accessories included in the sale
+	TaxType     string             `json:"taxType"`                            // Optional tax type of the sale, vatable by default
+	DownPayment *float64           `json:"downPayment"`                        // Optional amount paid now, the balance being paid in installments; paid in full by default
 }
 
 // CabSale represents a completed cab sale transaction
@@ -205,13 +635,1527 @@ type CabSale struct {
 }
 
 type ActivityLog struct {
+	ID             string        `json:"id"`
+	Timestamp      time.Time     `json:"timestamp"`
+	User           string        `json:"user" example:"johndoe"`
+	Action         string        `json:"action" example:"UPDATE_SALE"`
+	Details        string        `json:"details" example:"Updated sale 42: status"`
+	Status         string        `json:"status" example:"SUCCESS" enums:"SUCCESS,FAILED"`
+	IsSystemAction bool          `json:"isSystemAction"`
+	EntityType     string        `json:"entityType,omitempty"`
+	EntityID       string        `json:"entityId,omitempty"`
+	Changes        []FieldChange `json:"changes,omitempty"`  // Only loaded when fetching a single log
+	Hash           string        `json:"hash,omitempty"`     // SHA-256 over this entry and PrevHash
+	PrevHash       string        `json:"prevHash,omitempty"` // Hash of the previous entry in the chain
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+}
+
+// AuditChainReport is the result of re-validating the activity log hash chain.
+type AuditChainReport struct {
+	Valid            bool       `json:"valid"`
+	CheckedEntries   int        `json:"checkedEntries"`
+	UnchainedEntries int        `json:"unchainedEntries"` // Entries written before hashing was enabled
+	FirstTamperedID  string     `json:"firstTamperedId,omitempty"`
+	FirstTamperedAt  *time.Time `json:"firstTamperedAt,omitempty"`
+	Reason           string     `json:"reason,omitempty"`
+}
+
+// FieldChange records the before and after value of a single changed field.
+// Sensitive values are masked before they are stored.
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// UserInvite tracks a pending account created through the bulk invite flow.
+// The setup token itself is never stored, only its SHA-256 hash.
+type UserInvite struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	TokenHash  string     `json:"-"`
+	InvitedBy  string     `json:"invitedBy"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// DefaultTenantID is the tenant that owns all data created before multi-tenancy,
+// and the tenant used by requests that don't identify one.
+const DefaultTenantID = "default"
+
+// Tenant is a company hosted on this instance. Every other table carries a
+// tenant_id, and a tenant's users only ever see rows with their tenant's ID.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Slug      string    `json:"slug"` // Subdomain the tenant is served on, e.g. "acme" for acme.example.com
+	Name      string    `json:"name"`
+	IsActive  bool      `json:"isActive"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// sandboxTenantPrefix starts the ID of every tenant's training sandbox
+const sandboxTenantPrefix = "sandbox-"
+
+// SandboxTenantID returns the ID of a tenant's training sandbox: a tenant of its own,
+// whose data is kept in memory apart from the tenant's and can be reset to fixtures.
+func SandboxTenantID(tenantID string) string {
+	return sandboxTenantPrefix + tenantID
+}
+
+// SandboxParent returns the tenant a sandbox belongs to, or false when tenantID is
+// not a sandbox
+func SandboxParent(tenantID string) (string, bool) {
+	return strings.CutPrefix(tenantID, sandboxTenantPrefix)
+}
+
+// RequestUsage is the API traffic metered for a tenant or one of its clients since the
+// server started. The daily counters cover the current UTC day.
+type RequestUsage struct {
+	RequestsToday    int64 `json:"requestsToday"`
+	RequestsTotal    int64 `json:"requestsTotal"`
+	UploadBytesToday int64 `json:"uploadBytesToday"`
+	UploadBytesTotal int64 `json:"uploadBytesTotal"`
+	RejectedToday    int64 `json:"rejectedToday"` // Requests refused by a quota
+}
+
+// ClientUsage is the API traffic of one client of a tenant
+type ClientUsage struct {
+	Client string `json:"client"` // "user:<id>" for token holders, "ip:<address>" otherwise
+	RequestUsage
+	LastSeenAt time.Time `json:"lastSeenAt"`
+}
+
+// TenantUsage summarizes how much of the system a tenant uses
+type TenantUsage struct {
+	TenantID    string     `json:"tenantId"`
+	Users       int        `json:"users"`
+	ActiveUsers int        `json:"activeUsers"`
+	Customers   int        `json:"customers"`
+	Cabs        int        `json:"cabs"`
+	Accessories int        `json:"accessories"`
+	Materials   int        `json:"materials"`
+	Sales       int        `json:"sales"`
+	SalesTotal  float64    `json:"salesTotal"`
+	LastSaleAt  *time.Time `json:"lastSaleAt,omitempty"` // Nil when the tenant has no sales
+}
+
+// Announcement severities, which clients use to style the banner
+const (
+	AnnouncementSeverityInfo     = "info"
+	AnnouncementSeverityWarning  = "warning"
+	AnnouncementSeverityCritical = "critical"
+)
+
+// Announcement is a message from admins to all staff of a tenant, such as a
+// maintenance window or a price list update. It is displayed from StartsAt until
+// EndsAt, or indefinitely when EndsAt is nil.
+type Announcement struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Severity  string     `json:"severity"`
+	StartsAt  time.Time  `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt,omitempty"`
+	CreatedBy string     `json:"createdBy"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	// Acknowledged reports whether the requesting user acknowledged it; only set when listing active announcements
+	Acknowledged bool `json:"acknowledged"`
+}
+
+// ActiveAt reports whether the announcement is displayed at t
+func (a Announcement) ActiveAt(t time.Time) bool {
+	return !a.StartsAt.After(t) && (a.EndsAt == nil || a.EndsAt.After(t))
+}
+
+// AnnouncementAck records that a user has read an announcement
+type AnnouncementAck struct {
+	AnnouncementID string    `json:"announcementId"`
+	UserID         string    `json:"userId"`
+	AcknowledgedAt time.Time `json:"acknowledgedAt"`
+}
+
+// Notification is an event pushed to the clients of a tenant over the notification stream
+type Notification struct {
+	Type       string      `json:"type"` // Sent as the SSE event name, e.g. "announcement"
+	Data       interface{} `json:"data"`
+	Recipients []string    `json:"-"` // User IDs to notify; empty notifies every user of the tenant
+}
+
+// SentTo reports whether the notification is delivered to a user
+func (n Notification) SentTo(userID string) bool {
+	if len(n.Recipients) == 0 {
+		return true
+	}
+	for _, recipient := range n.Recipients {
+		if recipient == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Task statuses
+const (
+	TaskStatusOpen       = "open"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusDone       = "done"
+	TaskStatusCancelled  = "cancelled"
+)
+
+// Entities a task can be linked to
+const (
+	TaskEntityCustomer = "customer"
+	TaskEntitySale     = "sale"
+	TaskEntityCab      = "cab"
+)
+
+// taskTransitions lists the statuses each status can move to. Finished tasks can be
+// reopened but not moved straight to another finished status.
+var taskTransitions = map[string][]string{
+	TaskStatusOpen:       {TaskStatusInProgress, TaskStatusDone, TaskStatusCancelled},
+	TaskStatusInProgress: {TaskStatusOpen, TaskStatusDone, TaskStatusCancelled},
+	TaskStatusDone:       {TaskStatusOpen},
+	TaskStatusCancelled:  {TaskStatusOpen},
+}
+
+// Task is a follow-up assigned to a user, such as calling a customer about a balance
+// or inspecting a cab unit. It can be linked to the customer, sale or cab it is about.
+type Task struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	AssigneeID  string     `json:"assigneeId"`
+	CreatedBy   string     `json:"createdBy"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Status      string     `json:"status"`
+	EntityType  string     `json:"entityType,omitempty"` // customer, sale or cab; empty when not linked
+	EntityID    string     `json:"entityId,omitempty"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"` // Set while the task is done
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// ValidTaskStatus reports whether status is a known task status
+func ValidTaskStatus(status string) bool {
+	_, ok := taskTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether the task may move to status
+func (t Task) CanTransition(status string) bool {
+	for _, next := range taskTransitions[t.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Overdue reports whether the task is still pending after its due date
+func (t Task) Overdue(now time.Time) bool {
+	pending := t.Status == TaskStatusOpen || t.Status == TaskStatusInProgress
+	return pending && t.DueDate != nil && t.DueDate.Before(now)
+}
+
+// TaskFilter narrows a task listing. Empty fields do not filter.
+type TaskFilter struct {
+	AssigneeID string
+	Statuses   []string
+	EntityType string
+	EntityID   string
+	DueBefore  *time.Time // Only tasks due before this time
+}
+
+// Inventory item types that can be starred
+const (
+	FavoriteItemCab       = "cab"
+	FavoriteItemAccessory = "accessory"
+)
+
+// Changes to starred items that users are notified about
+const (
+	WatchEventPriceChanged = "price_changed"
+	WatchEventSold         = "sold"
+	WatchEventRestocked    = "restocked"
+)
+
+// Favorite is an inventory item a user starred to track it
+type Favorite struct {
+	UserID    string    `json:"userId"`
+	ItemType  string    `json:"itemType"` // cab or accessory
+	ItemID    int       `json:"itemId"`
+	Notify    bool      `json:"notify"` // Whether the user is notified when the item changes
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WatchlistEvent describes a change to a starred item, pushed to the users watching it
+type WatchlistEvent struct {
+	Event            string  `json:"event"` // price_changed, sold or restocked
+	ItemType         string  `json:"itemType"`
+	ItemID           int     `json:"itemId"`
+	ItemName         string  `json:"itemName"`
+	Price            float64 `json:"price"`
+	PreviousPrice    float64 `json:"previousPrice,omitempty"` // Set for price_changed
+	Quantity         int     `json:"quantity"`                // Units in stock, or units sold for sold
+	PreviousQuantity int     `json:"previousQuantity,omitempty"`
+}
+
+// BigSaleEvent is pushed to the users of a tenant when a sale totals at least the big sale threshold
+type BigSaleEvent struct {
+	SaleID     string  `json:"saleId"`
+	CustomerID string  `json:"customerId"`
+	SoldBy     string  `json:"soldBy"`
+	TotalPrice float64 `json:"totalPrice"`
+	Threshold  float64 `json:"threshold"`
+}
+
+// StockOutEvent is pushed to the users of a tenant when the last unit of an item is gone
+type StockOutEvent struct {
+	ItemType string `json:"itemType"` // cab, accessory or material
+	ItemID   int    `json:"itemId"`
+	ItemName string `json:"itemName"`
+}
+
+// Entity types whose views are recorded for the recently viewed list
+const (
+	ViewEntityCab       = "cab"
+	ViewEntityAccessory = "accessory"
+	ViewEntityCustomer  = "customer"
+	ViewEntitySale      = "sale"
+)
+
+// EntityView is the last time a user opened a record
+type EntityView struct {
+	UserID     string    `json:"userId"`
+	EntityType string    `json:"entityType"` // cab, accessory, customer or sale
+	EntityID   string    `json:"entityId"`
+	ViewedAt   time.Time `json:"viewedAt"`
+}
+
+// LeaderboardEntry is one staff member's sales over a period
+type LeaderboardEntry struct {
+	Rank       int     `json:"rank"`
+	UserID     string  `json:"userId"`
+	FullName   string  `json:"fullName"` // Empty when the user no longer exists
+	Revenue    float64 `json:"revenue"`
+	UnitsSold  int     `json:"unitsSold"` // Units across all items of the sales
+	SalesCount int     `json:"salesCount"`
+}
+
+// BasketPair is how often an accessory is bought together with another. Support is the
+// share of all sales containing both, and confidence is the share of sales containing
+// AccessoryID that also contain PairedAccessoryID.
+type BasketPair struct {
+	AccessoryID       int     `json:"accessoryId"`
+	AccessoryName     string  `json:"accessoryName"` // Empty when the accessory no longer exists
+	PairedAccessoryID int     `json:"pairedAccessoryId"`
+	PairedName        string  `json:"pairedName"` // Empty when the accessory no longer exists
+	SalesTogether     int     `json:"salesTogether"`
+	Support           float64 `json:"support"`
+	Confidence        float64 `json:"confidence"`
+}
+
+// Dimensions a sales pivot can be broken down by
+const (
+	PivotMake     = "make"      // Make of the cab or accessory sold; empty for materials and deleted cabs
+	PivotItemType = "item_type" // cab, accessory or material
+	PivotMonth    = "month"     // Month of the sale, YYYY-MM
+	PivotYear     = "year"      // Year of the sale, YYYY
+	PivotSoldBy   = "sold_by"   // User ID of the seller
+	PivotTaxType  = "tax_type"  // One of the Tax* constants
+)
+
+// Measures a sales pivot can total
+const (
+	PivotRevenue = "revenue" // Sum of the subtotals of the items sold
+	PivotUnits   = "units"   // Units sold
+	PivotSales   = "sales"   // Number of distinct sales
+)
+
+// PivotDimensions and PivotMeasures are the values allowed in a sales pivot
+var (
+	PivotDimensions = []string{PivotMake, PivotItemType, PivotMonth, PivotYear, PivotSoldBy, PivotTaxType}
+	PivotMeasures   = []string{PivotRevenue, PivotUnits, PivotSales}
+)
+
+// PivotCell is a measure totalled over the sale items in one row and column of a pivot
+type PivotCell struct {
+	Row   string
+	Col   string
+	Value float64
+}
+
+// Groupings of the sales summary. Weeks run Monday to Sunday.
+const (
+	SummaryDay   = "day"
+	SummaryWeek  = "week"
+	SummaryMonth = "month"
+)
+
+// SalesSummaryPeriod is the sales of one day, week or month of a sales summary
+type SalesSummaryPeriod struct {
+	StartDate  string  `json:"startDate"` // First day of the period, YYYY-MM-DD
+	SalesCount int     `json:"salesCount"`
+	Revenue    float64 `json:"revenue"`
+	UnitsSold  int     `json:"unitsSold"` // Units across all items of the sales
+}
+
+// TopSeller is a cab or accessory ranked by the units of it sold
+type TopSeller struct {
+	ItemID    int     `json:"itemId"`
+	Name      string  `json:"name"` // Empty when the item no longer exists
+	Make      string  `json:"make"`
+	UnitsSold int     `json:"unitsSold"`
+	Revenue   float64 `json:"revenue"` // Subtotal of its sale items
+}
+
+// SalesSummary is the sales between two dates grouped by period, with the cabs and
+// accessories that sold the most units
+type SalesSummary struct {
+	Periods        []SalesSummaryPeriod // Only periods with sales, oldest first
+	TopCabs        []TopSeller
+	TopAccessories []TopSeller
+}
+
+// DuplicateSaleItem is an item shared by the sales of a DuplicateSaleGroup
+type DuplicateSaleItem struct {
+	ItemType  string  `json:"itemType"` // cab, accessory or material
+	ItemID    string  `json:"itemId"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+}
+
+// DuplicateSaleGroup is the sales of a customer on one day with the same items and
+// total, likely one sale entered more than once
+type DuplicateSaleGroup struct {
+	CustomerID string              `json:"customerId"`
+	SaleDate   string              `json:"saleDate"`
+	TotalPrice float64             `json:"totalPrice"` // Of each sale
+	Items      []DuplicateSaleItem `json:"items"`
+	Sales      []Sale              `json:"sales"` // Oldest first, the presumed original
+}
+
+// DuplicateSaleVoid records a sale voided as a duplicate of another, which was kept.
+// The voided sale and its items are deleted; its details are kept here.
+type DuplicateSaleVoid struct {
+	SaleID      string    `json:"saleId"`
+	DuplicateOf string    `json:"duplicateOf"`
+	CustomerID  string    `json:"customerId"`
+	SaleDate    string    `json:"saleDate"`
+	TotalPrice  float64   `json:"totalPrice"`
+	SoldBy      string    `json:"soldBy"`
+	VoidedBy    string    `json:"voidedBy"`
+	VoidedAt    time.Time `json:"voidedAt"`
+}
+
+// DocumentTemplate is the branding printed on a tenant's receipts and statements
+type DocumentTemplate struct {
+	CompanyName string     `json:"companyName"`
+	Address     string     `json:"address"`
+	HeaderText  string     `json:"headerText"`          // Printed under the company details
+	FooterText  string     `json:"footerText"`          // Printed at the bottom of every page
+	Terms       string     `json:"terms"`               // Terms and conditions printed after the totals
+	HasLogo     bool       `json:"hasLogo"`             // The logo is served separately
+	UpdatedBy   string     `json:"updatedBy,omitempty"` // Empty until the template is first saved
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+}
+
+// DocumentLogo is the company logo image printed on documents
+type DocumentLogo struct {
+	ContentType string
+	Data        []byte
+}
+
+// Register session statuses
+const (
+	RegisterSessionOpen   = "open"
+	RegisterSessionClosed = "closed"
+)
+
+// Kinds of cash counted when closing a register
+const (
+	CashBill = "bill"
+	CashCoin = "coin"
+)
+
+// cashDenominations are the peso bills and coins in circulation, in centavos
+var cashDenominations = map[string][]int64{
+	CashBill: {100000, 50000, 20000, 10000, 5000, 2000},
+	CashCoin: {2000, 1000, 500, 100, 25, 10, 5, 1},
+}
+
+// DenominationCount is the number of bills or coins of one value counted in a register
+type DenominationCount struct {
+	Kind     string  `json:"kind"`     // bill or coin
+	Value    float64 `json:"value"`    // Face value in pesos, e.g. 1000 or 0.25
+	Count    int     `json:"count"`    // Number of pieces
+	Subtotal float64 `json:"subtotal"` // Value times count, computed by the server
+}
+
+// Centavos returns the face value in centavos, or false when it is not a peso
+// bill or coin of that kind
+func (d DenominationCount) Centavos() (int64, bool) {
+	centavos := int64(math.Round(d.Value * 100))
+	if math.Abs(d.Value*100-float64(centavos)) > 1e-6 {
+		return 0, false
+	}
+	for _, value := range cashDenominations[d.Kind] {
+		if value == centavos {
+			return centavos, true
+		}
+	}
+	return 0, false
+}
+
+// RegisterSession is the time a cashier runs the cash register, from opening it
+// with a float to counting the drawer when closing it
+type RegisterSession struct {
+	ID            string              `json:"id"`
+	OpenedBy      string              `json:"openedBy"`
+	OpenedAt      time.Time           `json:"openedAt"`
+	OpeningFloat  float64             `json:"openingFloat"` // Cash in the drawer when opened
+	Status        string              `json:"status"`
+	ClosedBy      string              `json:"closedBy,omitempty"`
+	ClosedAt      *time.Time          `json:"closedAt,omitempty"`
+	Denominations []DenominationCount `json:"denominations,omitempty"` // Cash counted at close
+	CountedCash   float64             `json:"countedCash"`             // Total of the denominations
+	ExpectedCash  float64             `json:"expectedCash"`            // Opening float plus sales during the session
+	Variance      float64             `json:"variance"`                // Counted minus expected; negative when short
+	SalesTotal    float64             `json:"salesTotal"`
+	SalesCount    int                 `json:"salesCount"`
+	Notes         string              `json:"notes,omitempty"`
+}
+
+// Expense categories
+const (
+	ExpenseFuel      = "fuel"
+	ExpenseRepairs   = "repairs"
+	ExpenseUtilities = "utilities"
+	ExpenseSupplies  = "supplies"
+	ExpenseRent      = "rent"
+	ExpenseSalaries  = "salaries"
+	ExpenseOther     = "other"
+)
+
+// ExpenseCategories lists the expense categories in display order
+var ExpenseCategories = []string{ExpenseFuel, ExpenseRepairs, ExpenseUtilities, ExpenseSupplies, ExpenseRent, ExpenseSalaries, ExpenseOther}
+
+// ValidExpenseCategory reports whether category is a known expense category
+func ValidExpenseCategory(category string) bool {
+	for _, known := range ExpenseCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Expense statuses. Expenses are submitted pending and reviewed once by an admin;
+// only approved expenses are deducted in reports.
+const (
+	ExpensePending  = "pending"
+	ExpenseApproved = "approved"
+	ExpenseRejected = "rejected"
+)
+
+// Expense is money spent running the yard, such as fuel, repairs or utilities
+type Expense struct {
+	ID          string              `json:"id"`
+	Category    string              `json:"category"`
+	Description string              `json:"description"`
+	Vendor      string              `json:"vendor,omitempty"`
+	Amount      float64             `json:"amount"`
+	ExpenseDate string              `json:"expenseDate"` // YYYY-MM-DD, like sale dates
+	Status      string              `json:"status"`
+	SubmittedBy string              `json:"submittedBy"`
+	ReviewedBy  string              `json:"reviewedBy,omitempty"`
+	ReviewedAt  *time.Time          `json:"reviewedAt,omitempty"`
+	ReviewNote  string              `json:"reviewNote,omitempty"`  // Reason given when approving or rejecting
+	Attachments []ExpenseAttachment `json:"attachments,omitempty"` // Receipts and invoices; not loaded in listings
+	CreatedAt   time.Time           `json:"createdAt"`
+	UpdatedAt   time.Time           `json:"updatedAt"`
+}
+
+// ExpenseAttachment describes a receipt or invoice file attached to an expense.
+// The file itself is served separately.
+type ExpenseAttachment struct {
+	ID          string    `json:"id"`
+	ExpenseID   string    `json:"expenseId"`
+	FileName    string    `json:"fileName"`
+	ContentType string    `json:"contentType"`
+	Size        int       `json:"size"` // In bytes
+	StorageKey  string    `json:"-"`    // Where the contents are kept in file storage; empty when they are in the database
+	UploadedBy  string    `json:"uploadedBy"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+}
+
+// ExpenseFilter narrows an expense listing. Empty fields do not filter.
+type ExpenseFilter struct {
+	Category    string
+	Status      string
+	SubmittedBy string
+	StartDate   string // First expense date included, YYYY-MM-DD
+	EndDate     string // Last expense date included, YYYY-MM-DD
+}
+
+// BankDeposit is register cash taken to the bank, covering one or more closed
+// register sessions. Each session is deposited at most once.
+type BankDeposit struct {
 	ID             string    `json:"id"`
-	Timestamp      time.Time `json:"timestamp"`
-	User           string    `json:"user"`
-	Action         string    `json:"action"`
-	Details        string    `json:"details"`
-	Status         string    `json:"status"`
-	IsSystemAction bool      `json:"isSystemAction"`
+	Bank           string    `json:"bank"`
+	Reference      string    `json:"reference"` // Deposit slip or transaction number
+	Amount         float64   `json:"amount"`
+	DepositDate    string    `json:"depositDate"` // YYYY-MM-DD
+	SessionIDs     []string  `json:"sessionIds"`
+	ExpectedAmount float64   `json:"expectedAmount"` // Cash taken in by the sessions: counted cash minus opening floats
+	Difference     float64   `json:"difference"`     // Amount minus expected; negative when short
+	RecordedBy     string    `json:"recordedBy"`
+	Notes          string    `json:"notes,omitempty"`
 	CreatedAt      time.Time `json:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// CashToDeposit is the cash a closed session took in, which leaves the drawer for
+// the bank; the opening float stays behind
+func (s RegisterSession) CashToDeposit() float64 {
+	return math.Round((s.CountedCash-s.OpeningFloat)*100) / 100
+}
+
+// DepositFilter narrows a deposit listing. Empty fields do not filter.
+type DepositFilter struct {
+	StartDate string // First deposit date included, YYYY-MM-DD
+	EndDate   string // Last deposit date included, YYYY-MM-DD
+	SessionID string // Only the deposit covering this register session
+}
+
+// ReceiptSeries is a booklet of official receipt (OR) numbers registered with the
+// BIR for a branch. Its numbers are issued in order, from StartNumber to EndNumber.
+type ReceiptSeries struct {
+	ID            string    `json:"id"`
+	Branch        string    `json:"branch"` // Branch code the series is registered for
+	Prefix        string    `json:"prefix"` // Printed before the number, e.g. "MNL-"
+	StartNumber   int       `json:"startNumber"`
+	EndNumber     int       `json:"endNumber"`
+	NextNumber    int       `json:"nextNumber"`    // Next number to issue; EndNumber+1 once exhausted
+	WarnRemaining int       `json:"warnRemaining"` // Warn when this many numbers or fewer are left
+	Active        bool      `json:"active"`        // Inactive series issue no numbers
+	CreatedBy     string    `json:"createdBy"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Remaining is the count of numbers the series has left to issue
+func (s ReceiptSeries) Remaining() int {
+	if s.NextNumber > s.EndNumber {
+		return 0
+	}
+	return s.EndNumber - s.NextNumber + 1
+}
+
+// NearlyExhausted reports whether the series is down to its warning level
+func (s ReceiptSeries) NearlyExhausted() bool {
+	return s.Remaining() <= s.WarnRemaining
+}
+
+// Overlaps reports whether two series share a prefix and any number
+func (s ReceiptSeries) Overlaps(other ReceiptSeries) bool {
+	return s.Prefix == other.Prefix && s.StartNumber <= other.EndNumber && other.StartNumber <= s.EndNumber
+}
+
+// FormatNumber prints an OR number of the series: its prefix and the number padded
+// with zeros to the width of the end number, e.g. MNL-000123
+func (s ReceiptSeries) FormatNumber(number int) string {
+	width := len(strconv.Itoa(s.EndNumber))
+	return fmt.Sprintf("%s%0*d", s.Prefix, width, number)
+}
+
+// OfficialReceipt is an OR number issued to a sale. A sale gets at most one.
+type OfficialReceipt struct {
+	SaleID   string    `json:"saleId"`
+	SeriesID string    `json:"seriesId"`
+	Branch   string    `json:"branch"`
+	Number   int       `json:"number"`
+	ORNumber string    `json:"orNumber"` // Number formatted with the series prefix
+	IssuedBy string    `json:"issuedBy"`
+	IssuedAt time.Time `json:"issuedAt"`
+}
+
+// FiscalCalendar is a tenant's fiscal year, which starts on the first day of
+// StartMonth. Until one is saved the fiscal year is the calendar year.
+type FiscalCalendar struct {
+	StartMonth    int        `json:"startMonth"`          // 1 (January) to 12
+	NamedAfterEnd bool       `json:"namedAfterEnd"`       // Whether a fiscal year is named after the calendar year it ends in rather than starts in
+	UpdatedBy     string     `json:"updatedBy,omitempty"` // Empty until the calendar is first saved
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+// FirstMonth is the month the fiscal year starts in, January when none is set
+func (f FiscalCalendar) FirstMonth() time.Month {
+	if f.StartMonth < 1 || f.StartMonth > 12 {
+		return time.January
+	}
+	return time.Month(f.StartMonth)
+}
+
+// YearStart returns the first day of the fiscal year containing day
+func (f FiscalCalendar) YearStart(day time.Time) time.Time {
+	start := time.Date(day.Year(), f.FirstMonth(), 1, 0, 0, 0, 0, day.Location())
+	if start.After(day) {
+		start = start.AddDate(-1, 0, 0)
+	}
+	return start
+}
+
+// YearName returns the number a fiscal year starting on yearStart is named by, e.g.
+// 2025 for July 2024 to June 2025 when years are named after their end
+func (f FiscalCalendar) YearName(yearStart time.Time) int {
+	if f.NamedAfterEnd && f.FirstMonth() != time.January {
+		return yearStart.Year() + 1
+	}
+	return yearStart.Year()
+}
+
+// Kinds of stock counted in inventory snapshots
+const (
+	InventoryCab       = "cab"
+	InventoryAccessory = "accessory"
+	InventoryMaterial  = "material"
+)
+
+// InventorySnapshotItem is the stock of one cab, accessory or material at the end of
+// a month. Materials have no price, so they are counted but not valued.
+type InventorySnapshotItem struct {
+	ItemType  string  `json:"itemType"` // cab, accessory or material
+	ItemID    int     `json:"itemId"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+	Value     float64 `json:"value"` // Quantity times UnitPrice
+}
+
+// InventorySnapshot is the inventory of a tenant as it stood at the end of a month
+type InventorySnapshot struct {
+	Month         string                  `json:"month"`   // YYYY-MM
+	TakenAt       time.Time               `json:"takenAt"` // When the month-end job ran, shortly after the month ended
+	TotalQuantity int                     `json:"totalQuantity"`
+	TotalValue    float64                 `json:"totalValue"`
+	Items         []InventorySnapshotItem `json:"items"` // By type, then item ID
+}
+
+// Kinds of records that are soft-deleted and kept in the trash
+const (
+	TrashCustomer  = "customer"
+	TrashCab       = "cab"
+	TrashAccessory = "accessory"
+	TrashMaterial  = "material"
+)
+
+// TrashItem is a soft-deleted record that can still be restored or purged
+type TrashItem struct {
+	EntityType string    `json:"entityType"` // customer, cab, accessory or material
+	EntityID   string    `json:"entityId"`
+	Name       string    `json:"name"`
+	DeletedAt  time.Time `json:"deletedAt"`
+	DeletedBy  string    `json:"deletedBy,omitempty"` // Empty when the delete was not made by a signed-in user
+}
+
+// UserDormancy is the sign-in activity of a user, which the dormant account policy
+// deactivates accounts by
+type UserDormancy struct {
+	UserID        string     `json:"userId"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	Role          string     `json:"role"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	LastLoginAt   *time.Time `json:"lastLoginAt,omitempty"`   // Nil for users who never signed in
+	ReactivatedAt *time.Time `json:"reactivatedAt,omitempty"` // When an admin last reactivated the account
+	Exempt        bool       `json:"exempt"`                  // Never deactivated for inactivity
+}
+
+// LastActiveAt is when the user last signed in or was reactivated, or when the
+// account was created
+func (d UserDormancy) LastActiveAt() time.Time {
+	last := d.CreatedAt
+	for _, at := range []*time.Time{d.LastLoginAt, d.ReactivatedAt} {
+		if at != nil && at.After(last) {
+			last = *at
+		}
+	}
+	return last
+}
+
+// ShiftTimeLayout is the layout of the start and end times of shifts
+const ShiftTimeLayout = "15:04"
+
+// ShiftSchedule is a shift during which the users of a role may sign in and make
+// changes. Schedules for a branch apply to the users assigned to it, and those without
+// a branch to the other users of the role.
+type ShiftSchedule struct {
+	ID     string `json:"id"`
+	Role   string `json:"role"`
+	Branch string `json:"branch"` // Empty for the users of the role without a schedule of their branch
+	Days   []int  `json:"days"`   // ISO weekdays the shift starts on, 1 Monday to 7 Sunday; empty for every day
+	Start  string `json:"start"`  // HH:MM, server time
+	End    string `json:"end"`    // HH:MM; at or before Start for shifts ending the next day
+}
+
+// Covers reports whether at falls within a shift of the schedule. A shift ending at
+// or before its start time runs past midnight, counting as the day it started on.
+func (s ShiftSchedule) Covers(at time.Time) bool {
+	start, err := time.Parse(ShiftTimeLayout, s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(ShiftTimeLayout, s.End)
+	if err != nil {
+		return false
+	}
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	minute := at.Hour()*60 + at.Minute()
+
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute && s.startsOn(at.Weekday())
+	}
+	if minute >= startMinute {
+		return s.startsOn(at.Weekday())
+	}
+	return minute < endMinute && s.startsOn(at.AddDate(0, 0, -1).Weekday())
+}
+
+// startsOn reports whether the shift starts on a day of the week
+func (s ShiftSchedule) startsOn(day time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	iso := int(day)
+	if day == time.Sunday {
+		iso = 7
+	}
+	for _, d := range s.Days {
+		if d == iso {
+			return true
+		}
+	}
+	return false
+}
+
+// ShiftOverride lets a user sign in and make changes outside their shift until it expires
+type ShiftOverride struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"userId"`
+	Reason    string    `json:"reason"`
+	GrantedBy string    `json:"grantedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Integration is a partner system, such as an online marketplace, that posts orders
+// to the inbound integration endpoint, signing each request with its secret
+type Integration struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Secret    string    `json:"-"`      // Only returned when the integration is created or its secret rotated
+	SoldBy    string    `json:"soldBy"` // User the sales of the integration are recorded under
+	Active    bool      `json:"active"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Kinds of postings to the accounting system
+const (
+	AccountingSalesJournal = "sales_journal"
+	AccountingPayments     = "payments"
+)
+
+// Statuses of postings to the accounting system
+const (
+	AccountingPosted = "posted"
+	AccountingFailed = "failed"
+)
+
+// AccountingPosting is the state of posting one day's sales journal or payments
+// to the accounting system
+type AccountingPosting struct {
+	Kind        string     `json:"kind"`
+	PostingDate string     `json:"postingDate"` // YYYY-MM-DD
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"lastError,omitempty"`
+	ExternalID  string     `json:"externalId,omitempty"` // ID the accounting system gave the posting
+	Amount      float64    `json:"amount"`
+	PostedAt    *time.Time `json:"postedAt,omitempty"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Reports that can be exported to Google Sheets
+const (
+	SheetsReportDailySales = "daily_sales"
+	SheetsReportLowStock   = "low_stock"
+)
+
+// SheetsReports are the reports that can be exported to Google Sheets
+var SheetsReports = []string{SheetsReportDailySales, SheetsReportLowStock}
+
+// GoogleSheetsExport is the Google Sheets spreadsheet a tenant's reports are
+// exported to. Each report is written to its own tab, replacing what was there.
+type GoogleSheetsExport struct {
+	SpreadsheetID string     `json:"spreadsheetId"`
+	Reports       []string   `json:"reports"` // See SheetsReports
+	UpdatedBy     string     `json:"updatedBy"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	LastExportAt  *time.Time `json:"lastExportAt,omitempty"` // Nil until the first export
+	LastError     string     `json:"lastError,omitempty"`    // Why the last export failed, empty when it succeeded
+}
+
+// CalendarFeed is a user's iCal subscription to their follow-ups. The feed URL
+// carries a token so phone calendars can fetch it; only its hash is stored.
+type CalendarFeed struct {
+	UserID        string     `json:"userId"`
+	TokenHash     string     `json:"-"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	LastFetchedAt *time.Time `json:"lastFetchedAt,omitempty"` // Nil until a calendar fetches the feed
+}
+
+// RefreshToken is a token exchanged for a new access token. Every exchange revokes it
+// and issues its replacement in the same family, which ends when it is reused,
+// expires or its user logs out. Only the token's hash is stored.
+type RefreshToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	FamilyID   string     `json:"familyId"` // Shared by the tokens rotated from the same sign-in
+	TokenHash  string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`  // Nil while the token can be exchanged
+	ReplacedBy string     `json:"replacedBy,omitempty"` // ID of the token it was exchanged for
+}
+
+// PasswordResetToken is a one-time token emailed to a user who forgot their password,
+// exchanged for a new password before it expires. Only the token's hash is stored.
+type PasswordResetToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"` // Set once used, or once a newer token was requested
+}
+
+// Kinds of data that can be exported
+const (
+	ExportSales       = "sales"
+	ExportCabs        = "cabs"
+	ExportAccessories = "accessories"
+	ExportMaterials   = "materials"
+	ExportCustomers   = "customers"
+	// ExportCustomerOccasions are the customers' upcoming birthdays and anniversaries
+	ExportCustomerOccasions = "customer_occasions"
+)
+
+// ExportFilters are the filters each kind of export accepts, named like the fields
+// of the filter its repository takes
+var ExportFilters = map[string][]string{
+	ExportSales:             {"customer_id", "sold_by", "start_date", "end_date"},
+	ExportCabs:              {"make", "unit_color", "status", "search"},
+	ExportAccessories:       {},
+	ExportMaterials:         {"search", "category", "supplier", "status"},
+	ExportCustomers:         {},
+	ExportCustomerOccasions: {"days", "occasion"},
+}
+
+// Statuses of an export job
+const (
+	ExportStatusQueued    = "queued"
+	ExportStatusRunning   = "running"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// ExportJob is an export built in the background. Its CSV file can be downloaded
+// once it is completed, until it expires and is deleted.
+type ExportJob struct {
+	ID          string            `json:"id"`
+	Type        string            `json:"type"` // One of the Export* kinds
+	Filters     map[string]string `json:"filters"`
+	Status      string            `json:"status"`
+	Progress    int               `json:"progress"` // Percentage done, 100 once completed
+	Rows        int               `json:"rows"`     // Rows written so far, excluding the header
+	FileName    string            `json:"fileName,omitempty"`
+	Size        int               `json:"size,omitempty"`  // Size of the file in bytes
+	Error       string            `json:"error,omitempty"` // Why the export failed
+	RequestedBy string            `json:"requestedBy"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	StartedAt   *time.Time        `json:"startedAt,omitempty"`
+	CompletedAt *time.Time        `json:"completedAt,omitempty"`
+	ExpiresAt   time.Time         `json:"expiresAt"` // When the job and its file are deleted
+	StorageKey  string            `json:"-"`         // Where the file is kept in file storage; empty when it is in the database
+}
+
+// StockRow is the current stock of one cab, accessory or material, as streamed by
+// the inventory export. Materials have no make or price.
+type StockRow struct {
+	ID       int
+	Name     string
+	Make     string
+	Quantity int
+	Price    *float64 // Nil for materials
+	Status   string
+}
+
+// Kinds of problems the data integrity check looks for
+const (
+	IntegrityOrphanSaleItem   = "orphan_sale_item"      // Sale item whose sale does not exist
+	IntegrityTotalMismatch    = "sale_total_mismatch"   // Sale whose total is not the sum of its items
+	IntegrityNegativeQuantity = "negative_quantity"     // Cab, accessory or material with negative stock
+	IntegrityMissingCustomer  = "sale_missing_customer" // Sale whose customer does not exist or was deleted
+)
+
+// SaleTotalMismatch is a sale whose total differs from the sum of its items' subtotals
+type SaleTotalMismatch struct {
+	SaleID     string  `json:"saleId"`
+	TotalPrice float64 `json:"totalPrice"`
+	ItemsTotal float64 `json:"itemsTotal"`
+}
+
+// NegativeStock is a cab, accessory or material whose quantity is below zero
+type NegativeStock struct {
+	ItemType string `json:"itemType"` // cab, accessory or material
+	ItemID   int    `json:"itemId"`
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// SaleCustomerRef is a sale referencing a customer that is not in the customer list
+type SaleCustomerRef struct {
+	SaleID     string `json:"saleId"`
+	CustomerID string `json:"customerId"`
+	Deleted    bool   `json:"deleted"` // The customer is in the trash rather than gone for good
+}
+
+// IntegrityIssue is one problem found by the data integrity check
+type IntegrityIssue struct {
+	Kind       string `json:"kind"`       // See the Integrity kinds
+	EntityType string `json:"entityType"` // sale_item, sale, cab, accessory or material
+	EntityID   string `json:"entityId"`
+	Details    string `json:"details"`
+	Fixable    bool   `json:"fixable"` // The check can repair it without losing information
+	Fixed      bool   `json:"fixed"`
+}
+
+// IntegrityReport is the outcome of a data integrity check of one tenant
+type IntegrityReport struct {
+	CheckedAt time.Time        `json:"checkedAt"`
+	Counts    map[string]int   `json:"counts"` // Issues found per kind
+	Fixed     int              `json:"fixed"`
+	Issues    []IntegrityIssue `json:"issues"`
+}
+
+// Statuses of a price change held for approval
+const (
+	PriceChangePending  = "pending"
+	PriceChangeApproved = "approved"
+	PriceChangeRejected = "rejected"
+)
+
+// PriceChange is a change of the price of a cab or accessory by more than
+// PRICE_APPROVAL_THRESHOLD_PERCENT, held until an admin other than the requester
+// approves it. The item keeps its old price until then.
+type PriceChange struct {
+	ID          string     `json:"id"`
+	ItemType    string     `json:"itemType"` // cab or accessory
+	ItemID      int        `json:"itemId"`
+	ItemName    string     `json:"itemName"`
+	OldPrice    float64    `json:"oldPrice"` // Price when the change was requested
+	NewPrice    float64    `json:"newPrice"`
+	Status      string     `json:"status"` // pending, approved or rejected
+	RequestedBy string     `json:"requestedBy"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	ReviewedBy  string     `json:"reviewedBy,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewedAt,omitempty"`
+	Note        string     `json:"note,omitempty"` // Reason given by the reviewer
+}
+
+// Kinds of anomalies flagged by the nightly anomaly scan and the payment reconciliation
+const (
+	AnomalyPriceOutlier     = "price_outlier"     // Item sold far from the price it usually sells at
+	AnomalyStockAdjustment  = "stock_adjustment"  // Unusually large change of an item's quantity by hand
+	AnomalyAfterHoursVoid   = "after_hours_void"  // Sale deleted outside business hours
+	AnomalyPaymentOverage   = "payment_overage"   // Sale whose payments add up to more than its total
+	AnomalyPaymentShortfall = "payment_shortfall" // Sale whose payments do not cover its total
+)
+
+// Statuses of an anomaly in the review queue
+const (
+	AnomalyOpen         = "open"
+	AnomalyAcknowledged = "acknowledged"
+	AnomalyDismissed    = "dismissed"
+)
+
+// Anomaly is something unusual in the sales, stock movements or payments, held for an
+// admin to acknowledge or dismiss. SourceID is the sale item, activity log entry or
+// payment discrepancy it was found in; each source is flagged once per kind.
+type Anomaly struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"` // See the Anomaly kinds
+	SourceID   string     `json:"sourceId"`
+	EntityType string     `json:"entityType"` // sale, cab, accessory or material
+	EntityID   string     `json:"entityId"`
+	Details    string     `json:"details"`
+	OccurredAt time.Time  `json:"occurredAt"`
+	Status     string     `json:"status"` // open, acknowledged or dismissed
+	DetectedAt time.Time  `json:"detectedAt"`
+	ReviewedBy string     `json:"reviewedBy,omitempty"`
+	ReviewedAt *time.Time `json:"reviewedAt,omitempty"`
+	Note       string     `json:"note,omitempty"` // Reason given by the reviewer
+}
+
+// Methods of paying for a sale
+const (
+	PaymentCash         = "cash"
+	PaymentCard         = "card"
+	PaymentBankTransfer = "bank_transfer"
+	PaymentCheck        = "check"
+)
+
+// ValidPaymentMethod reports whether method is one of the payment methods
+func ValidPaymentMethod(method string) bool {
+	switch method {
+	case PaymentCash, PaymentCard, PaymentBankTransfer, PaymentCheck:
+		return true
+	}
+	return false
+}
+
+// SalePayment is money received for a sale. Sales are paid in full when they are
+// recorded, so the payments of a sale should add up to its total, and may never
+// exceed it.
+type SalePayment struct {
+	ID         string    `json:"id"`
+	SaleID     string    `json:"saleId"`
+	Amount     float64   `json:"amount"`
+	Method     string    `json:"method"`              // cash, card, bank_transfer or check
+	Reference  string    `json:"reference,omitempty"` // Card slip, transfer or check number
+	ReceivedBy string    `json:"receivedBy"`
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+// PaymentMismatch is a sale whose payments do not add up to its total
+type PaymentMismatch struct {
+	SaleID     string
+	SaleDate   string // YYYY-MM-DD
+	TotalPrice float64
+	Paid       float64 // Sum of the sale's payments
+}
+
+// SoldPrice is the unit price a cab, accessory or material was sold at
+type SoldPrice struct {
+	SaleItemID string
+	SaleID     string
+	SaleDate   string // YYYY-MM-DD
+	ItemType   string // cab, accessory or material
+	ItemID     string
+	UnitPrice  float64
+	SoldAt     time.Time // When the item was recorded
+}
+
+// ChangePercent returns by how many percent the change moves the price
+func (p PriceChange) ChangePercent() float64 {
+	if p.OldPrice == 0 {
+		return 0
+	}
+	return math.Abs(p.NewPrice-p.OldPrice) / p.OldPrice * 100
+}
+
+// Statuses of a change request
+const (
+	ChangeRequestPending  = "pending"
+	ChangeRequestApplied  = "applied"
+	ChangeRequestRejected = "rejected"
+)
+
+// ChangeRequest is an edit of a cab, accessory or material proposed by someone who
+// may not edit inventory directly. It changes nothing until a reviewer applies it.
+type ChangeRequest struct {
+	ID          string        `json:"id"`
+	EntityType  string        `json:"entityType"` // cab, accessory or material
+	EntityID    int           `json:"entityId"`
+	EntityName  string        `json:"entityName"`
+	Changes     []FieldChange `json:"changes"` // Proposed values, with the values they replace
+	Reason      string        `json:"reason,omitempty"`
+	Status      string        `json:"status"` // pending, applied or rejected
+	RequestedBy string        `json:"requestedBy"`
+	RequestedAt time.Time     `json:"requestedAt"`
+	ReviewedBy  string        `json:"reviewedBy,omitempty"`
+	ReviewedAt  *time.Time    `json:"reviewedAt,omitempty"`
+	Note        string        `json:"note,omitempty"` // Reason given by the reviewer
+}
+
+// ChangeRequestFilter narrows the list of change requests; empty fields match every request
+type ChangeRequestFilter struct {
+	Status      string
+	RequestedBy string
+}
+
+// ItemImage is a photo in the gallery of a cab, accessory or material. The file itself
+// is served separately, at URL.
+type ItemImage struct {
+	ID          string             `json:"id"`
+	ItemType    string             `json:"itemType"` // cab, accessory or material
+	ItemID      int                `json:"itemId"`
+	FileName    string             `json:"fileName"`
+	ContentType string             `json:"contentType"`
+	Size        int                `json:"size"`        // In bytes
+	ContentHash string             `json:"contentHash"` // Hex SHA-256 of the file, to find the same photo on other items
+	Position    int                `json:"position"`    // Order in the gallery, from 0
+	IsPrimary   bool               `json:"isPrimary"`   // The photo shown first; every gallery has one
+	URL         string             `json:"url"`
+	Variants    []ItemImageVariant `json:"variants,omitempty"` // Smaller sizes made so far, smallest first
+	UploadedBy  string             `json:"uploadedBy"`
+	UploadedAt  time.Time          `json:"uploadedAt"`
+}
+
+// ItemImageVariant is a smaller copy of a gallery photo, made in the background after
+// the photo is uploaded
+type ItemImageVariant struct {
+	ImageID     string `json:"imageId"`
+	Size        string `json:"size"` // thumb, small or medium
+	ContentType string `json:"contentType"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	FileSize    int    `json:"fileSize"` // In bytes
+	URL         string `json:"url"`
+}
+
+// Kinds of records imported from the legacy yard system's spreadsheets
+const (
+	LegacyCustomers   = "customers"
+	LegacyCabs        = "cabs"
+	LegacyAccessories = "accessories"
+	LegacyMaterials   = "materials"
+	LegacySales       = "sales"
+)
+
+// LegacyImportKinds lists the kinds of legacy records in the order they must be
+// imported, as historical sales refer to the customers and items imported before them
+var LegacyImportKinds = []string{LegacyCustomers, LegacyCabs, LegacyAccessories, LegacyMaterials, LegacySales}
+
+// Statuses of a legacy import run
+const (
+	LegacyImportRunning   = "running"
+	LegacyImportCompleted = "completed"
+	LegacyImportFailed    = "failed"
+)
+
+// LegacyImportRun is the import of one legacy spreadsheet. Its rows are imported in
+// batches and NextRow is saved after each one, so a run that stopped can be resumed
+// with the same file.
+type LegacyImportRun struct {
+	ID        string            `json:"id"`
+	Kind      string            `json:"kind"` // One of the Legacy* kinds
+	FileName  string            `json:"fileName,omitempty"`
+	Checksum  string            `json:"checksum"` // Hex SHA-256 of the file; resuming requires the same file
+	Mapping   map[string]string `json:"mapping"`  // Column header of each field
+	Status    string            `json:"status"`
+	TotalRows int               `json:"totalRows"`
+	NextRow   int               `json:"nextRow"`  // Data rows processed so far
+	Inserted  int               `json:"inserted"` // Rows imported as new records
+	Matched   int               `json:"matched"`  // Rows linked to a record that already existed
+	Skipped   int               `json:"skipped"`  // Rows imported by an earlier run
+	Rejected  int               `json:"rejected"`
+	Error     string            `json:"error,omitempty"` // Why the run stopped
+	StartedBy string            `json:"startedBy"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// Statuses of the LTO registration of a sold cab unit. The back office submits the
+// paperwork, then the LTO releases the Official Receipt and Certificate of
+// Registration (OR/CR) for the customer.
+const (
+	RegistrationPending   = "pending"   // Waiting for the documents to be submitted
+	RegistrationSubmitted = "submitted" // Documents submitted to the LTO
+	RegistrationReleased  = "released"  // OR/CR released
+	RegistrationCancelled = "cancelled" // Not registered through the yard, e.g. the sale was returned
+)
+
+// registrationTransitions lists the statuses each registration status can move to.
+// Submitted paperwork the LTO sent back is pending again; released registrations are final.
+var registrationTransitions = map[string][]string{
+	RegistrationPending:   {RegistrationSubmitted, RegistrationCancelled},
+	RegistrationSubmitted: {RegistrationPending, RegistrationReleased, RegistrationCancelled},
+	RegistrationReleased:  {},
+	RegistrationCancelled: {RegistrationPending},
+}
+
+// CabRegistration tracks the LTO registration paperwork of one cab unit sold in a sale.
+// Dates are formatted as YYYY-MM-DD, like sale dates.
+type CabRegistration struct {
+	ID            string    `json:"id"`
+	SaleID        string    `json:"saleId"`
+	CabID         int       `json:"cabId"`
+	CustomerID    string    `json:"customerId"`
+	Status        string    `json:"status"`
+	DueDate       string    `json:"dueDate"`                 // When the OR/CR should be released by
+	SubmittedDate string    `json:"submittedDate,omitempty"` // When the documents were submitted
+	ReleasedDate  string    `json:"releasedDate,omitempty"`  // When the OR/CR was released
+	ORNumber      string    `json:"orNumber,omitempty"`      // Official Receipt number
+	CRNumber      string    `json:"crNumber,omitempty"`      // Certificate of Registration number
+	PlateNumber   string    `json:"plateNumber,omitempty"`
+	Notes         string    `json:"notes,omitempty"`
+	UpdatedBy     string    `json:"updatedBy"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// ValidRegistrationStatus reports whether status is a known registration status
+func ValidRegistrationStatus(status string) bool {
+	_, ok := registrationTransitions[status]
+	return ok
+}
+
+// CanTransition reports whether the registration may move to status
+func (r CabRegistration) CanTransition(status string) bool {
+	for _, next := range registrationTransitions[r.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Overdue reports whether the OR/CR is still not released after the due date. today
+// is formatted as YYYY-MM-DD.
+func (r CabRegistration) Overdue(today string) bool {
+	open := r.Status == RegistrationPending || r.Status == RegistrationSubmitted
+	return open && r.DueDate < today
+}
+
+// RegistrationFilter narrows the registrations listed. Zero values match every registration.
+type RegistrationFilter struct {
+	Statuses  []string
+	SaleID    string
+	CabID     int
+	DueBefore string // Only registrations due before this date, YYYY-MM-DD
+}
+
+// Coverages of the insurance policies sold or arranged with a sale
+const (
+	InsuranceCTPL          = "ctpl"          // Compulsory Third Party Liability, required to register a vehicle
+	InsuranceComprehensive = "comprehensive" // Own damage and theft, with or without third party liability
+	InsuranceOther         = "other"
+)
+
+// ValidInsuranceCoverage reports whether coverage is a known insurance coverage
+func ValidInsuranceCoverage(coverage string) bool {
+	return coverage == InsuranceCTPL || coverage == InsuranceComprehensive || coverage == InsuranceOther
+}
+
+// InsurancePolicy is an insurance policy sold or arranged for a customer with a sale.
+// Dates are formatted as YYYY-MM-DD, like sale dates. A renewal is recorded as a new
+// policy naming the policy it renews.
+type InsurancePolicy struct {
+	ID           string    `json:"id"`
+	SaleID       string    `json:"saleId"`
+	CustomerID   string    `json:"customerId"`
+	Provider     string    `json:"provider"`
+	PolicyNumber string    `json:"policyNumber"`
+	Coverage     string    `json:"coverage"`
+	Premium      float64   `json:"premium"`
+	StartDate    string    `json:"startDate"`
+	ExpiryDate   string    `json:"expiryDate"`
+	RenewalOf    string    `json:"renewalOf,omitempty"` // The policy this one renews
+	Notes        string    `json:"notes,omitempty"`
+	CreatedBy    string    `json:"createdBy"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// InsurancePolicyFilter narrows the insurance policies listed. Zero values match every policy.
+type InsurancePolicyFilter struct {
+	SaleID        string
+	CustomerID    string
+	PolicyNumber  string
+	ExpiresFrom   string // Only policies expiring on or after this date, YYYY-MM-DD
+	ExpiresBefore string // Only policies expiring before this date, YYYY-MM-DD
+	RenewalsOnly  bool   // Only policies renewing another
+}
+
+// Entities given display numbers from the numbering formats admins set
+const (
+	NumberedSale     = "sale"
+	NumberedCustomer = "customer"
+	NumberedCab      = "cab"
+)
+
+// ValidNumberedEntity reports whether entityType can be given display numbers
+func ValidNumberedEntity(entityType string) bool {
+	return entityType == NumberedSale || entityType == NumberedCustomer || entityType == NumberedCab
+}
+
+// Placeholders of a numbering format. The sequence placeholder takes an optional
+// width, {seq:5}, to pad the number with zeros.
+const (
+	numberYear      = "{YYYY}"
+	numberShortYear = "{YY}"
+	numberMonth     = "{MM}"
+	numberDay       = "{DD}"
+	numberBranch    = "{branch}"
+	numberSequence  = "{seq"
+)
+
+// Longest numbering format, and widest sequence
+const (
+	MaxNumberFormatLength = 50
+	maxSequenceWidth      = 12
+)
+
+// NumberFormat is how the display numbers of an entity type are generated, e.g.
+// SALE-{YYYY}-{seq:5}. Every combination of the date and branch placeholders has its
+// own sequence, so SALE-{YYYY}-{seq} starts again from 1 each year.
+type NumberFormat struct {
+	EntityType string    `json:"entityType"`
+	Format     string    `json:"format"`
+	UpdatedBy  string    `json:"updatedBy"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// DisplayNumber is the number generated for a record, shown alongside its ID
+type DisplayNumber struct {
+	EntityType string    `json:"entityType"`
+	EntityID   string    `json:"entityId"`
+	Number     string    `json:"number"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ValidateNumberFormat checks that a numbering format has exactly one sequence
+// placeholder and no unknown placeholders
+func ValidateNumberFormat(format string) error {
+	if strings.TrimSpace(format) == "" {
+		return fmt.Errorf("format is required")
+	}
+	if len(format) > MaxNumberFormatLength {
+		return fmt.Errorf("format cannot be longer than %d characters", MaxNumberFormatLength)
+	}
+	sequences := 0
+	for rest := format; rest != ""; {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			break
+		}
+		if rest[open] == '}' {
+			return fmt.Errorf("unmatched } in format")
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return fmt.Errorf("unmatched { in format")
+		}
+		placeholder := rest[open : open+end+1]
+		switch placeholder {
+		case numberYear, numberShortYear, numberMonth, numberDay, numberBranch:
+		default:
+			if _, ok := sequenceWidth(placeholder); !ok {
+				return fmt.Errorf("unknown placeholder %s; use {YYYY}, {YY}, {MM}, {DD}, {branch}, {seq} or {seq:N}", placeholder)
+			}
+			sequences++
+		}
+		rest = rest[open+end+1:]
+	}
+	if sequences != 1 {
+		return fmt.Errorf("format must have exactly one {seq} placeholder")
+	}
+	return nil
+}
+
+// sequenceWidth parses a sequence placeholder, {seq} or {seq:N}, returning the width
+// its numbers are padded to
+func sequenceWidth(placeholder string) (int, bool) {
+	if placeholder == numberSequence+"}" {
+		return 0, true
+	}
+	raw, ok := strings.CutPrefix(placeholder, numberSequence+":")
+	if !ok || !strings.HasSuffix(raw, "}") {
+		return 0, false
+	}
+	width, err := strconv.Atoi(strings.TrimSuffix(raw, "}"))
+	if err != nil || width < 1 || width > maxSequenceWidth {
+		return 0, false
+	}
+	return width, true
+}
+
+// Scope fills in the date and branch placeholders of the format, leaving the
+// sequence placeholder. Numbers of the same scope share a sequence.
+func (f NumberFormat) Scope(at time.Time, branch string) string {
+	branch = strings.NewReplacer("{", "", "}", "").Replace(branch)
+	return strings.NewReplacer(
+		numberYear, at.Format("2006"),
+		numberShortYear, at.Format("06"),
+		numberMonth, at.Format("01"),
+		numberDay, at.Format("02"),
+		numberBranch, branch,
+	).Replace(f.Format)
+}
+
+// FormatSequence fills in the sequence placeholder of a scope with a number, padded
+// with zeros to the placeholder's width
+func FormatSequence(scope string, sequence int) string {
+	open := strings.Index(scope, numberSequence)
+	if open < 0 {
+		return scope
+	}
+	end := strings.IndexByte(scope[open:], '}')
+	if end < 0 {
+		return scope
+	}
+	width, _ := sequenceWidth(scope[open : open+end+1])
+	return scope[:open] + fmt.Sprintf("%0*d", width, sequence) + scope[open+end+1:]
+}
+
+// States of a supervised background worker
+const (
+	WorkerRunning    = "running"
+	WorkerRestarting = "restarting" // Crashed and waiting to be started again
+	WorkerStopped    = "stopped"
+)
+
+// WorkerStatus is the state of a background worker, such as a job or a queue,
+// run by the server's supervisor
+type WorkerStatus struct {
+	Name        string     `json:"name" example:"Export purge job"`
+	State       string     `json:"state" enums:"running,restarting,stopped"`
+	StartedAt   time.Time  `json:"startedAt"` // When it was last started
+	Restarts    int        `json:"restarts"`  // Times it was started again after crashing
+	LastPanic   string     `json:"lastPanic,omitempty"`
+	LastPanicAt *time.Time `json:"lastPanicAt,omitempty"`
+}
+
+// Operations of an inventory batch
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchChange is an operation of an inventory batch once checked, as the repositories
+// apply it: Item is created, replaces item ID, or item ID is deleted
+type BatchChange[T any] struct {
+	Op   string
+	ID   int
+	Item T // The item to create, or the new state of the one to update
+}
+
+// LogLevelStatus is the level the server logs at, and when it reverts to its
+// configured level after an admin changed it
+type LogLevelStatus struct {
+	Level        string     `json:"level" enums:"debug,info,warn,error"`
+	DefaultLevel string     `json:"defaultLevel" enums:"debug,info,warn,error"` // Set by LOG_LEVEL
+	RevertsAt    *time.Time `json:"revertsAt,omitempty"`                        // Nil unless changed at runtime
+	ChangedBy    string     `json:"changedBy,omitempty"`
 }
@@ -49,3 +49,36 @@ type UserUpdateRequest struct {
 type UserPasswordUpdateRequest struct {
 	NewPassword string `json:"newPassword" example:"newsecurepassword123"`
 }
+
+// UserInviteEntry is a single invitation within a bulk invite request.
+type UserInviteEntry struct {
+	Email    string `json:"email" example:"jane.doe@example.com"`
+	FullName string `json:"fullName,omitempty" example:"Jane Doe"`
+	Role     string `json:"role" example:"staff" enums:"staff,admin"`
+}
+
+// UserInviteRequest defines the shape of the request body for inviting users in bulk.
+type UserInviteRequest struct {
+	Invites []UserInviteEntry `json:"invites"`
+}
+
+// UserInviteAcceptRequest defines the shape of the request body used to accept an invitation.
+// Username and FullName are optional and override the values set when the invite was created.
+type UserInviteAcceptRequest struct {
+	Token    string `json:"token" example:"one-time-setup-token"`
+	Password string `json:"password" example:"securepassword123"`
+	Username string `json:"username,omitempty" example:"janedoe"`
+	FullName string `json:"fullName,omitempty" example:"Jane Doe"`
+}
+
+// ForgotPasswordRequest defines the shape of the request body used to ask for a password reset link.
+type ForgotPasswordRequest struct {
+	Email string `json:"email" example:"jane@example.com"`
+}
+
+// ResetPasswordRequest defines the shape of the request body used to set a new password
+// with the token of a reset link.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" example:"one-time-reset-token"`
+	Password string `json:"password" example:"securepassword123"`
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"oop/internal/models"
 	"oop/internal/config"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Helper function to determine status based on quantity
@@ -13,7 +17,7 @@ func determineStatus(quantity int) models.AccessoryStatus {
 	switch {
 	case quantity == 0:
 		return models.StatusOutOfStock
-	case quantity <= 2:
+	case quantity <= LowStockQuantity:
 		return models.StatusLowStock
 	case quantity <= 5:
 		return models.StatusInStock
@@ -25,29 +29,45 @@ func determineStatus(quantity int) models.AccessoryStatus {
 // AccessoryRepository defines methods for working with accessories
 type AccessoryRepository interface {
 	GetAll(ctx context.Context) ([]models.Accessory, error)
+	// GetPaginated returns one page of the accessories matching the filter, ordered
+	// by ID, along with the total number of matches. Pages start at 1.
+	GetPaginated(ctx context.Context, page, limit int, filter models.AccessoryFilter) ([]models.Accessory, int64, error)
 	GetByID(ctx context.Context, id int) (models.Accessory, error)
 	Create(ctx context.Context, input models.NewAccessoryInput) (int, error)
 	Update(ctx context.Context, id int, input models.UpdateAccessoryInput) (models.Accessory, error)
 	Delete(ctx context.Context, id int) error
+	// Restore brings back a deleted accessory.
+	Restore(ctx context.Context, id int) error
+	// GetDeleted returns the deleted accessories with when they were deleted, most
+	// recently deleted first.
+	GetDeleted(ctx context.Context) ([]models.Accessory, error)
+	// ApplyBatch creates, updates and deletes accessories in one transaction, all or
+	// none. Created and updated accessories get the status of their quantity and their
+	// timestamps, and created ones their ID; the change that failed is reported as a
+	// *BatchError.
+	ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Accessory]) error
 }
 
 // AccessoryRepositoryImpl is a SQL implementation of AccessoryRepository
 type AccessoryRepositoryImpl struct {
-	DB *sql.DB
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
 }
 
-// NewAccessoryRepository creates a new accessory repository
+// NewAccessoryRepository creates a new accessory repository for the default tenant
 func NewAccessoryRepository(db *sql.DB) AccessoryRepository {
 	return &AccessoryRepositoryImpl{
-		DB: db,
+		DB:       db,
+		TenantID: models.DefaultTenantID,
 	}
 }
 
 // GetAll retrieves all accessories from the database
 func (r *AccessoryRepositoryImpl) GetAll(ctx context.Context) ([]models.Accessory, error) {
 	query := `
-		SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+		SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 		FROM accessories
+		WHERE tenant_id = ? AND deleted_at IS NULL
 		ORDER BY id ASC
 	`
 
@@ -57,17 +77,81 @@ func (r *AccessoryRepositoryImpl) GetAll(ctx context.Context) ([]models.Accessor
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.QueryContext(ctx)
+	rows, err := stmt.QueryContext(ctx, r.TenantID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var accessories []models.Accessory
+	if err := scanAccessories(rows, &accessories); err != nil {
+		return nil, err
+	}
+	return accessories, nil
+}
+
+// GetPaginated retrieves one page of the accessories with optional filtering
+func (r *AccessoryRepositoryImpl) GetPaginated(ctx context.Context, page, limit int, filter models.AccessoryFilter) ([]models.Accessory, int64, error) {
+	where, args := r.accessoriesFilter(filter)
+
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM accessories`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count accessories: %w", err)
+	}
+
+	query := `SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
+		FROM accessories` + where + ` ORDER BY id ASC LIMIT ? OFFSET ?`
+	rows, err := r.DB.QueryContext(ctx, query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query accessories: %w", err)
+	}
+	defer rows.Close()
+
+	accessories := []models.Accessory{}
+	if err := scanAccessories(rows, &accessories); err != nil {
+		return nil, 0, err
+	}
+	return accessories, total, nil
+}
+
+// accessoriesFilter returns the WHERE clause and its arguments for an accessory
+// filter. A numeric search term matches the ID, any other the name. Filter values
+// only reach the query as arguments.
+func (r *AccessoryRepositoryImpl) accessoriesFilter(filter models.AccessoryFilter) (string, []interface{}) {
+	conditions := []string{"tenant_id = ?", "deleted_at IS NULL"}
+	args := []interface{}{r.TenantID}
+
+	if filter.Make != "" {
+		conditions = append(conditions, "make = ?")
+		args = append(args, filter.Make)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.UnitColor != "" {
+		conditions = append(conditions, "unit_color = ?")
+		args = append(args, filter.UnitColor)
+	}
+	if filter.Search != "" {
+		if id, err := strconv.Atoi(filter.Search); err == nil {
+			conditions = append(conditions, "id = ?")
+			args = append(args, id)
+		} else {
+			conditions = append(conditions, "LOWER(name) LIKE LOWER(?)")
+			args = append(args, "%"+filter.Search+"%")
+		}
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanAccessories appends the accessories read from rows
+func scanAccessories(rows *sql.Rows, accessories *[]models.Accessory) error {
 	for rows.Next() {
 		var a models.Accessory
 		var makeStr, colorStr, statusStr string
 		var imageSQL sql.NullString
+		var minPrice, maxPrice sql.NullFloat64
 
 		err := rows.Scan(
 			&a.ID,
@@ -75,6 +159,8 @@ func (r *AccessoryRepositoryImpl) GetAll(ctx context.Context) ([]models.Accessor
 			&makeStr,
 			&a.Quantity,
 			&a.Price,
+			&minPrice,
+			&maxPrice,
 			&statusStr,
 			&colorStr,
 			&imageSQL,
@@ -82,12 +168,13 @@ func (r *AccessoryRepositoryImpl) GetAll(ctx context.Context) ([]models.Accessor
 			&a.UpdatedAt,
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		a.Make = models.AccessoryMake(makeStr)
 		a.UnitColor = models.AccessoryColor(colorStr)
 		a.Status = models.AccessoryStatus(statusStr)
+		a.MinPrice, a.MaxPrice = nullPrice(minPrice), nullPrice(maxPrice)
 		
 		// Handle NULL image values with default image
 		if imageSQL.Valid && imageSQL.String != "" {
@@ -96,22 +183,18 @@ func (r *AccessoryRepositoryImpl) GetAll(ctx context.Context) ([]models.Accessor
 			a.Image = config.DefaultImageURL
 		}
 
-		accessories = append(accessories, a)
+		*accessories = append(*accessories, a)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return accessories, nil
+	return rows.Err()
 }
 
 // GetByID retrieves an accessory by its ID
 func (r *AccessoryRepositoryImpl) GetByID(ctx context.Context, id int) (models.Accessory, error) {
 	query := `
-		SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+		SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 		FROM accessories
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
 	`
 
 	stmt, err := r.DB.PrepareContext(ctx, query)
@@ -123,13 +206,16 @@ func (r *AccessoryRepositoryImpl) GetByID(ctx context.Context, id int) (models.A
 	var a models.Accessory
 	var makeStr, colorStr, statusStr string
 	var imageSQL sql.NullString
+	var minPrice, maxPrice sql.NullFloat64
 
-	err = stmt.QueryRowContext(ctx, id).Scan(
+	err = stmt.QueryRowContext(ctx, id, r.TenantID).Scan(
 		&a.ID,
 		&a.Name,
 		&makeStr,
 		&a.Quantity,
 		&a.Price,
+		&minPrice,
+		&maxPrice,
 		&statusStr,
 		&colorStr,
 		&imageSQL,
@@ -147,6 +233,7 @@ func (r *AccessoryRepositoryImpl) GetByID(ctx context.Context, id int) (models.A
 	a.Make = models.AccessoryMake(makeStr)
 	a.UnitColor = models.AccessoryColor(colorStr)
 	a.Status = models.AccessoryStatus(statusStr)
+	a.MinPrice, a.MaxPrice = nullPrice(minPrice), nullPrice(maxPrice)
 	
 	// Handle NULL image values with default image
 	if imageSQL.Valid && imageSQL.String != "" {
@@ -160,11 +247,14 @@ func (r *AccessoryRepositoryImpl) GetByID(ctx context.Context, id int) (models.A
 
 // Create inserts a new accessory into the database and returns its ID.
 func (r *AccessoryRepositoryImpl) Create(ctx context.Context, input models.NewAccessoryInput) (int, error) {
+	if input.Quantity < 0 {
+		return 0, negativeQuantity("accessory", input.Quantity)
+	}
 	status := determineStatus(input.Quantity)
 
 	query := `
-		INSERT INTO accessories (name, make, quantity, price, status, unit_color, image, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		INSERT INTO accessories (tenant_id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`
 
 	stmt, err := r.DB.PrepareContext(ctx, query)
@@ -182,17 +272,20 @@ func (r *AccessoryRepositoryImpl) Create(ctx context.Context, input models.NewAc
 
 	res, err := stmt.ExecContext(
 		ctx,
+		r.TenantID,
 		input.Name,
 		string(input.Make),
 		input.Quantity,
 		input.Price,
+		models.PriceBound(input.MinPrice),
+		models.PriceBound(input.MaxPrice),
 		string(status),
 		string(input.UnitColor),
 		imageValue,
 	)
 
 	if err != nil {
-		return 0, err
+		return 0, stockError(err)
 	}
 
 	id, err := res.LastInsertId()
@@ -205,6 +298,10 @@ func (r *AccessoryRepositoryImpl) Create(ctx context.Context, input models.NewAc
 
 // Update modifies an existing accessory in the database
 func (r *AccessoryRepositoryImpl) Update(ctx context.Context, id int, input models.UpdateAccessoryInput) (models.Accessory, error) {
+	if input.Quantity != nil && *input.Quantity < 0 {
+		return models.Accessory{}, negativeQuantity("accessory", *input.Quantity)
+	}
+
 	// First, get the current accessory
 	accessory, err := r.GetByID(ctx, id)
 	if err != nil {
@@ -225,6 +322,12 @@ func (r *AccessoryRepositoryImpl) Update(ctx context.Context, id int, input mode
 	if input.Price != nil {
 		accessory.Price = *input.Price
 	}
+	if input.MinPrice != nil {
+		accessory.MinPrice = models.PriceBound(input.MinPrice)
+	}
+	if input.MaxPrice != nil {
+		accessory.MaxPrice = models.PriceBound(input.MaxPrice)
+	}
 	if input.UnitColor != nil {
 		accessory.UnitColor = *input.UnitColor
 	}
@@ -239,8 +342,8 @@ func (r *AccessoryRepositoryImpl) Update(ctx context.Context, id int, input mode
 
 	updateQuery := `
 		UPDATE accessories
-		SET name = ?, make = ?, quantity = ?, price = ?, status = ?, unit_color = ?, image = ?, updated_at = NOW()
-		WHERE id = ?
+		SET name = ?, make = ?, quantity = ?, price = ?, min_price = ?, max_price = ?, status = ?, unit_color = ?, image = ?, updated_at = NOW()
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
 	`
 
 	stmt, err := r.DB.PrepareContext(ctx, updateQuery)
@@ -262,14 +365,17 @@ func (r *AccessoryRepositoryImpl) Update(ctx context.Context, id int, input mode
 		string(accessory.Make),
 		accessory.Quantity,
 		accessory.Price,
+		accessory.MinPrice,
+		accessory.MaxPrice,
 		string(accessory.Status),
 		string(accessory.UnitColor),
 		imageValue,
 		id,
+		r.TenantID,
 	)
 
 	if err != nil {
-		return models.Accessory{}, err
+		return models.Accessory{}, stockError(err)
 	}
 
 	// Since RETURNING is not used, we might want to re-fetch the accessory to get the updated_at time set by NOW().
@@ -286,9 +392,9 @@ func (r *AccessoryRepositoryImpl) Update(ctx context.Context, id int, input mode
 	return updatedAccessory, nil
 }
 
-// Delete removes an accessory from the database
+// Delete soft-deletes an accessory, so it can be restored
 func (r *AccessoryRepositoryImpl) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM accessories WHERE id = ?`
+	query := `UPDATE accessories SET deleted_at = NOW() WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 
 	stmt, err := r.DB.PrepareContext(ctx, query)
 	if err != nil {
@@ -296,7 +402,7 @@ func (r *AccessoryRepositoryImpl) Delete(ctx context.Context, id int) error {
 	}
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, id)
+	result, err := stmt.ExecContext(ctx, id, r.TenantID)
 	if err != nil {
 		return err
 	}
@@ -312,3 +418,131 @@ func (r *AccessoryRepositoryImpl) Delete(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// Restore clears the deletion of an accessory
+func (r *AccessoryRepositoryImpl) Restore(ctx context.Context, id int) error {
+	query := `UPDATE accessories SET deleted_at = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+
+	result, err := r.DB.ExecContext(ctx, query, id, r.TenantID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted accessory not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetDeleted retrieves the deleted accessories
+func (r *AccessoryRepositoryImpl) GetDeleted(ctx context.Context) ([]models.Accessory, error) {
+	query := `
+		SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at, deleted_at
+		FROM accessories
+		WHERE tenant_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id
+	`
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted accessories: %w", err)
+	}
+	defer rows.Close()
+
+	accessories := []models.Accessory{}
+	for rows.Next() {
+		var a models.Accessory
+		var imageSQL sql.NullString
+		var minPrice, maxPrice sql.NullFloat64
+		var deletedAt time.Time
+		if err := rows.Scan(&a.ID, &a.Name, &a.Make, &a.Quantity, &a.Price, &minPrice, &maxPrice, &a.Status, &a.UnitColor,
+			&imageSQL, &a.CreatedAt, &a.UpdatedAt, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted accessory: %w", err)
+		}
+		a.MinPrice, a.MaxPrice = nullPrice(minPrice), nullPrice(maxPrice)
+		a.Image = config.DefaultImageURL
+		if imageSQL.Valid && imageSQL.String != "" {
+			a.Image = imageSQL.String
+		}
+		a.DeletedAt = &deletedAt
+		accessories = append(accessories, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted accessories: %w", err)
+	}
+	return accessories, nil
+}
+
+// ApplyBatch applies the changes of an accessory batch in one transaction, locking
+// each updated accessory so the batch cannot overwrite a change made while it runs
+func (r *AccessoryRepositoryImpl) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Accessory]) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range changes {
+		if err := r.applyChange(ctx, tx, &changes[i], now); err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit accessory batch: %w", err)
+	}
+	return nil
+}
+
+// applyChange applies one change of an accessory batch within tx
+func (r *AccessoryRepositoryImpl) applyChange(ctx context.Context, tx *sql.Tx, change *models.BatchChange[models.Accessory], now time.Time) error {
+	if change.Op == models.BatchDelete {
+		return deleteBatchItem(ctx, tx, "accessories", "accessory", change.ID, r.TenantID, now)
+	}
+
+	accessory := &change.Item
+	if accessory.Quantity < 0 {
+		return negativeQuantity("accessory", accessory.Quantity)
+	}
+	accessory.Status = determineStatus(accessory.Quantity)
+	accessory.MinPrice, accessory.MaxPrice = models.PriceBound(accessory.MinPrice), models.PriceBound(accessory.MaxPrice)
+	if accessory.Image == "" {
+		accessory.Image = config.DefaultImageURL
+	}
+
+	if change.Op == models.BatchCreate {
+		res, err := tx.ExecContext(ctx, `INSERT INTO accessories (tenant_id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.TenantID, accessory.Name, string(accessory.Make), accessory.Quantity, accessory.Price, accessory.MinPrice, accessory.MaxPrice,
+			string(accessory.Status), string(accessory.UnitColor), imageColumn(accessory.Image), now, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert accessory %q: %w", accessory.Name, stockError(err))
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get ID of accessory %q: %w", accessory.Name, err)
+		}
+		accessory.ID, accessory.CreatedAt, accessory.UpdatedAt = int(id), now, now
+		return nil
+	}
+
+	createdAt, err := lockBatchItem(ctx, tx, "accessories", "accessory", change.ID, r.TenantID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE accessories
+		SET name = ?, make = ?, quantity = ?, price = ?, min_price = ?, max_price = ?, status = ?, unit_color = ?, image = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?`,
+		accessory.Name, string(accessory.Make), accessory.Quantity, accessory.Price, accessory.MinPrice, accessory.MaxPrice,
+		string(accessory.Status), string(accessory.UnitColor), imageColumn(accessory.Image), now, change.ID, r.TenantID); err != nil {
+		return fmt.Errorf("failed to update accessory %d: %w", change.ID, stockError(err))
+	}
+	accessory.ID, accessory.CreatedAt, accessory.UpdatedAt = change.ID, createdAt, now
+	return nil
+}
@@ -21,22 +21,23 @@ func TestAccessoryRepository_GetAll(t *testing.T) {
 	defer db.Close()
 
 	// Create columns for the mock result
-	columns := []string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}
+	columns := []string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}
 
 	// Create expected time values
 	now := time.Now()
 
 	// Create expected rows
 	rows := sqlmock.NewRows(columns).
-		AddRow(1, "Steering Wheel", "OEM", 10, 5000.0, "In Stock", "Black", "image1.jpg", now, now).
-		AddRow(2, "Sport Seats", "Aftermarket", 0, 12000.0, "Out of Stock", "Silver", "image2.jpg", now, now)
+		AddRow(1, "Steering Wheel", "OEM", 10, 5000.0, nil, nil, "In Stock", "Black", "image1.jpg", now, now).
+		AddRow(2, "Sport Seats", "Aftermarket", 0, 12000.0, nil, nil, "Out of Stock", "Silver", "image2.jpg", now, now)
 
 	// Set up expected query and result
 	mock.ExpectPrepare(regexp.QuoteMeta(`
-		SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+		SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 		FROM accessories
+		WHERE tenant_id = ? AND deleted_at IS NULL
 		ORDER BY id ASC
-	`)).ExpectQuery().WillReturnRows(rows)
+	`)).ExpectQuery().WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 
 	// Create repository with mock DB
 	repo := NewAccessoryRepository(db)
@@ -61,6 +62,31 @@ func TestAccessoryRepository_GetAll(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAccessoryRepository_GetPaginated(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewAccessoryRepository(db)
+
+	where := " WHERE tenant_id = ? AND deleted_at IS NULL AND make = ? AND LOWER(name) LIKE LOWER(?)"
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM accessories" + where)).
+		WithArgs(models.DefaultTenantID, "OEM", "%mirror%").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(3))
+	now := time.Now()
+	mock.ExpectQuery(regexp.QuoteMeta(where + " ORDER BY id ASC LIMIT ? OFFSET ?")).
+		WithArgs(models.DefaultTenantID, "OEM", "%mirror%", 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}).
+			AddRow(5, "Side Mirror", "OEM", 4, 800.0, nil, nil, "In Stock", "Black", nil, now, now))
+
+	accessories, total, err := repo.GetPaginated(context.Background(), 2, 2, models.AccessoryFilter{Make: "OEM", Search: "mirror"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	if assert.Len(t, accessories, 1) {
+		assert.Equal(t, "Side Mirror", accessories[0].Name)
+		assert.NotEmpty(t, accessories[0].Image, "the default image fills in a missing one")
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestAccessoryRepository_GetByID(t *testing.T) {
 	// Create a new SQL mock
 	db, mock, err := sqlmock.New()
@@ -70,7 +96,7 @@ func TestAccessoryRepository_GetByID(t *testing.T) {
 	defer db.Close()
 
 	// Create columns for the mock result
-	columns := []string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}
+	columns := []string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}
 
 	// Create expected time values
 	now := time.Now()
@@ -78,13 +104,13 @@ func TestAccessoryRepository_GetByID(t *testing.T) {
 	// Test case 1: Accessory exists
 	t.Run("Accessory exists", func(t *testing.T) {
 		rows := sqlmock.NewRows(columns).
-			AddRow(1, "Steering Wheel", "OEM", 10, 5000.0, "In Stock", "Black", "image1.jpg", now, now)
+			AddRow(1, "Steering Wheel", "OEM", 10, 5000.0, nil, nil, "In Stock", "Black", "image1.jpg", now, now)
 
 		mock.ExpectPrepare(regexp.QuoteMeta(`
-			SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+			SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 			FROM accessories
-			WHERE id = ?
-		`)).ExpectQuery().WithArgs(1).WillReturnRows(rows)
+			WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
+		`)).ExpectQuery().WithArgs(1, models.DefaultTenantID).WillReturnRows(rows)
 
 		repo := NewAccessoryRepository(db)
 		accessory, err := repo.GetByID(context.Background(), 1)
@@ -102,10 +128,10 @@ func TestAccessoryRepository_GetByID(t *testing.T) {
 	// Test case 2: Accessory does not exist
 	t.Run("Accessory does not exist", func(t *testing.T) {
 		mock.ExpectPrepare(regexp.QuoteMeta(`
-			SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+			SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 			FROM accessories
-			WHERE id = ?
-		`)).ExpectQuery().WithArgs(99).WillReturnError(sql.ErrNoRows)
+			WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
+		`)).ExpectQuery().WithArgs(99, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 
 		repo := NewAccessoryRepository(db)
 		_, err := repo.GetByID(context.Background(), 99)
@@ -138,13 +164,16 @@ func TestAccessoryRepository_Create(t *testing.T) {
 
 	// Setup expected query and result
 	mock.ExpectPrepare(regexp.QuoteMeta(`
-		INSERT INTO accessories (name, make, quantity, price, status, unit_color, image, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
+		INSERT INTO accessories (tenant_id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), NOW())
 	`)).ExpectExec().WithArgs(
+		models.DefaultTenantID,
 		input.Name,
 		string(input.Make),
 		input.Quantity,
 		input.Price,
+		nil, // No price guard
+		nil,
 		string(models.StatusInStock), // Status is calculated based on quantity
 		string(input.UnitColor),
 		input.Image,
@@ -179,6 +208,7 @@ func TestAccessoryRepository_Update(t *testing.T) {
 	name := "Updated Steering Wheel"
 	quantity := 2
 	price := 5500.0
+	minPrice := 5000.0
 
 	// Create expected time values
 	now := time.Now()
@@ -188,42 +218,46 @@ func TestAccessoryRepository_Update(t *testing.T) {
 		Name:     &name,
 		Quantity: &quantity,
 		Price:    &price,
+		MinPrice: &minPrice,
 	}
 
 	// Setup mock for GetByID (first step in the update process)
 	mock.ExpectPrepare(regexp.QuoteMeta(`
-		SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+		SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 		FROM accessories
-		WHERE id = ?
-	`)).ExpectQuery().WithArgs(id).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}).
-			AddRow(id, "Steering Wheel", "OEM", 10, 5000.0, "In Stock", "Black", "image1.jpg", now, now),
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
+	`)).ExpectQuery().WithArgs(id, models.DefaultTenantID).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}).
+			AddRow(id, "Steering Wheel", "OEM", 10, 5000.0, nil, nil, "In Stock", "Black", "image1.jpg", now, now),
 	)
 
 	// Setup mock for update query
 	mock.ExpectPrepare(regexp.QuoteMeta(`
 		UPDATE accessories
-		SET name = ?, make = ?, quantity = ?, price = ?, status = ?, unit_color = ?, image = ?, updated_at = NOW()
-		WHERE id = ?
+		SET name = ?, make = ?, quantity = ?, price = ?, min_price = ?, max_price = ?, status = ?, unit_color = ?, image = ?, updated_at = NOW()
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
 	`)).ExpectExec().WithArgs(
 		name,                          // updated name
 		string(models.MakeOEM),        // unchanged make
 		quantity,                      // updated quantity
 		price,                         // updated price
+		minPrice,                      // new minimum price
+		nil,                           // still no maximum price
 		string(models.StatusLowStock), // status updated based on quantity
 		string(models.ColorBlack),     // unchanged color
 		"image1.jpg",                  // unchanged image
 		id,
+		models.DefaultTenantID,
 	).WillReturnResult(sqlmock.NewResult(0, 1)) // No new ID, 1 row affected
 
 	// Setup mock for GetByID again (to fetch the updated accessory)
 	mock.ExpectPrepare(regexp.QuoteMeta(`
-		SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at
+		SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at
 		FROM accessories
-		WHERE id = ?
-	`)).ExpectQuery().WithArgs(id).WillReturnRows(
-		sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}).
-			AddRow(id, name, "OEM", quantity, price, "Low Stock", "Black", "image1.jpg", now, now),
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
+	`)).ExpectQuery().WithArgs(id, models.DefaultTenantID).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}).
+			AddRow(id, name, "OEM", quantity, price, nil, nil, "Low Stock", "Black", "image1.jpg", now, now),
 	)
 
 	// Create repository with mock DB
@@ -261,9 +295,9 @@ func TestAccessoryRepository_Delete(t *testing.T) {
 		id := 1
 
 		mock.ExpectPrepare(regexp.QuoteMeta(`
-			DELETE FROM accessories
-			WHERE id = ?
-		`)).ExpectExec().WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+			UPDATE accessories SET deleted_at = NOW()
+			WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
+		`)).ExpectExec().WithArgs(id, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
 
 		repo := NewAccessoryRepository(db)
 		err := repo.Delete(context.Background(), id)
@@ -276,9 +310,9 @@ func TestAccessoryRepository_Delete(t *testing.T) {
 		id := 999
 
 		mock.ExpectPrepare(regexp.QuoteMeta(`
-			DELETE FROM accessories
-			WHERE id = ?
-		`)).ExpectExec().WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0))
+			UPDATE accessories SET deleted_at = NOW()
+			WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
+		`)).ExpectExec().WithArgs(id, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
 
 		repo := NewAccessoryRepository(db)
 		err := repo.Delete(context.Background(), id)
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+)
+
+// AccountingPostingRepository defines the interface for the state of postings to the accounting system.
+type AccountingPostingRepository interface {
+	// Get returns the posting of a kind for a day.
+	Get(kind, date string) (*models.AccountingPosting, error)
+	// Save stores a posting, replacing the one of the same kind and day.
+	Save(posting *models.AccountingPosting) error
+	// List returns up to limit postings, latest day first, only those with status when it is set.
+	List(status string, limit int) ([]models.AccountingPosting, error)
+	// GetRetryable returns the failed postings with fewer than maxAttempts attempts, oldest day first.
+	GetRetryable(maxAttempts int) ([]models.AccountingPosting, error)
+}
+
+// accountingPostingRepository implements the AccountingPostingRepository interface.
+type accountingPostingRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewAccountingPostingRepository creates a new instance of accountingPostingRepository for the default tenant.
+func NewAccountingPostingRepository(db *sql.DB) AccountingPostingRepository {
+	return &accountingPostingRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const accountingPostingColumns = `kind, DATE_FORMAT(posting_date, '%Y-%m-%d'), status, attempts, COALESCE(last_error, ''), COALESCE(external_id, ''), amount, posted_at, updated_at`
+
+// scanAccountingPosting reads a posting selected with accountingPostingColumns
+func scanAccountingPosting(row interface{ Scan(...interface{}) error }) (models.AccountingPosting, error) {
+	var posting models.AccountingPosting
+	var postedAt sql.NullTime
+	err := row.Scan(&posting.Kind, &posting.PostingDate, &posting.Status, &posting.Attempts, &posting.LastError,
+		&posting.ExternalID, &posting.Amount, &postedAt, &posting.UpdatedAt)
+	if postedAt.Valid {
+		posting.PostedAt = &postedAt.Time
+	}
+	return posting, err
+}
+
+// Get retrieves the posting of a kind for a day.
+func (r *accountingPostingRepository) Get(kind, date string) (*models.AccountingPosting, error) {
+	query := `SELECT ` + accountingPostingColumns + ` FROM accounting_postings WHERE tenant_id = ? AND kind = ? AND posting_date = ?`
+
+	posting, err := scanAccountingPosting(r.DB.QueryRow(query, r.TenantID, kind, date))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("accounting posting not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get accounting posting: %w", err)
+	}
+	return &posting, nil
+}
+
+// Save stores a posting.
+func (r *accountingPostingRepository) Save(posting *models.AccountingPosting) error {
+	query := `
+		INSERT INTO accounting_postings (tenant_id, kind, posting_date, status, attempts, last_error, external_id, amount, posted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status), attempts = VALUES(attempts), last_error = VALUES(last_error),
+			external_id = VALUES(external_id), amount = VALUES(amount), posted_at = VALUES(posted_at), updated_at = VALUES(updated_at)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, posting.Kind, posting.PostingDate, posting.Status, posting.Attempts, posting.LastError,
+		posting.ExternalID, posting.Amount, posting.PostedAt, posting.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save accounting posting: %w", err)
+	}
+	return nil
+}
+
+// List retrieves up to limit postings, latest day first.
+func (r *accountingPostingRepository) List(status string, limit int) ([]models.AccountingPosting, error) {
+	query := `SELECT ` + accountingPostingColumns + ` FROM accounting_postings WHERE tenant_id = ?`
+	args := []interface{}{r.TenantID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY posting_date DESC, kind LIMIT ?`
+	args = append(args, limit)
+
+	return r.queryPostings(query, args...)
+}
+
+// GetRetryable retrieves the failed postings that have attempts left, oldest day first.
+func (r *accountingPostingRepository) GetRetryable(maxAttempts int) ([]models.AccountingPosting, error) {
+	query := `SELECT ` + accountingPostingColumns + ` FROM accounting_postings
+		WHERE tenant_id = ? AND status = ? AND attempts < ? ORDER BY posting_date, kind`
+	return r.queryPostings(query, r.TenantID, models.AccountingFailed, maxAttempts)
+}
+
+func (r *accountingPostingRepository) queryPostings(query string, args ...interface{}) ([]models.AccountingPosting, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounting postings: %w", err)
+	}
+	defer rows.Close()
+
+	postings := []models.AccountingPosting{}
+	for rows.Next() {
+		posting, err := scanAccountingPosting(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan accounting posting row: %w", err)
+		}
+		postings = append(postings, posting)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accounting posting rows: %w", err)
+	}
+	return postings, nil
+}
@@ -0,0 +1,80 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accountingPostingSelect = `SELECT kind, DATE_FORMAT(posting_date, '%Y-%m-%d'), status, attempts, COALESCE(last_error, ''), COALESCE(external_id, ''), amount, posted_at, updated_at FROM accounting_postings`
+
+var accountingPostingRows = []string{"kind", "posting_date", "status", "attempts", "last_error", "external_id", "amount", "posted_at", "updated_at"}
+
+func newMockAccountingPostingRepo(t *testing.T) (repositories.AccountingPostingRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewAccountingPostingRepository(db), mock
+}
+
+func TestSaveAndGetAccountingPosting(t *testing.T) {
+	repo, mock := newMockAccountingPostingRepo(t)
+	now := time.Now()
+	posting := &models.AccountingPosting{Kind: models.AccountingSalesJournal, PostingDate: "2025-03-01", Status: models.AccountingPosted,
+		Attempts: 1, ExternalID: "JE-1", Amount: 1120, PostedAt: &now, UpdatedAt: now}
+
+	mock.ExpectExec(`
+		INSERT INTO accounting_postings (tenant_id, kind, posting_date, status, attempts, last_error, external_id, amount, posted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?)
+		ON DUPLICATE KEY UPDATE status = VALUES(status), attempts = VALUES(attempts), last_error = VALUES(last_error),
+			external_id = VALUES(external_id), amount = VALUES(amount), posted_at = VALUES(posted_at), updated_at = VALUES(updated_at)
+	`).WithArgs(models.DefaultTenantID, models.AccountingSalesJournal, "2025-03-01", models.AccountingPosted, 1, "", "JE-1", 1120.0, &now, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Save(posting))
+
+	mock.ExpectQuery(accountingPostingSelect+` WHERE tenant_id = ? AND kind = ? AND posting_date = ?`).
+		WithArgs(models.DefaultTenantID, models.AccountingSalesJournal, "2025-03-01").
+		WillReturnRows(sqlmock.NewRows(accountingPostingRows).
+			AddRow(models.AccountingSalesJournal, "2025-03-01", models.AccountingPosted, 1, "", "JE-1", 1120.0, now, now))
+	found, err := repo.Get(models.AccountingSalesJournal, "2025-03-01")
+	require.NoError(t, err)
+	assert.Equal(t, "JE-1", found.ExternalID)
+	require.NotNil(t, found.PostedAt)
+
+	mock.ExpectQuery(accountingPostingSelect+` WHERE tenant_id = ? AND kind = ? AND posting_date = ?`).
+		WithArgs(models.DefaultTenantID, models.AccountingPayments, "2025-03-01").WillReturnError(sql.ErrNoRows)
+	_, err = repo.Get(models.AccountingPayments, "2025-03-01")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestListAccountingPostings(t *testing.T) {
+	repo, mock := newMockAccountingPostingRepo(t)
+	now := time.Now()
+
+	mock.ExpectQuery(accountingPostingSelect+` WHERE tenant_id = ? AND status = ? ORDER BY posting_date DESC, kind LIMIT ?`).
+		WithArgs(models.DefaultTenantID, models.AccountingFailed, 10).
+		WillReturnRows(sqlmock.NewRows(accountingPostingRows).
+			AddRow(models.AccountingPayments, "2025-03-01", models.AccountingFailed, 2, "accounting system answered 503", "", 500.0, nil, now))
+	postings, err := repo.List(models.AccountingFailed, 10)
+	require.NoError(t, err)
+	require.Len(t, postings, 1)
+	assert.Nil(t, postings[0].PostedAt)
+	assert.Equal(t, 2, postings[0].Attempts)
+
+	mock.ExpectQuery(accountingPostingSelect+` WHERE tenant_id = ? AND status = ? AND attempts < ? ORDER BY posting_date, kind`).
+		WithArgs(models.DefaultTenantID, models.AccountingFailed, 5).
+		WillReturnRows(sqlmock.NewRows(accountingPostingRows))
+	postings, err = repo.GetRetryable(5)
+	require.NoError(t, err)
+	assert.Empty(t, postings)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
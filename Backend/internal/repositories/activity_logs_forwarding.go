@@ -0,0 +1,31 @@
+package repositories
+
+import "oop/internal/models"
+
+// LogForwarder receives every activity log entry after it is stored, e.g. to ship it
+// to a SIEM. Forward must not block.
+type LogForwarder interface {
+	Forward(tenantID string, entry models.ActivityLog)
+}
+
+// forwardingLogsRepository passes the entries a logs repository creates to a forwarder
+type forwardingLogsRepository struct {
+	LogsRepositoryInterface
+	forwarder LogForwarder
+	tenantID  string
+}
+
+// WithLogForwarding wraps the logs repository of a tenant so every entry created
+// through it, including those written by the audit recorder, is also forwarded
+func WithLogForwarding(repo LogsRepositoryInterface, forwarder LogForwarder, tenantID string) LogsRepositoryInterface {
+	return &forwardingLogsRepository{LogsRepositoryInterface: repo, forwarder: forwarder, tenantID: tenantID}
+}
+
+// Create stores an entry and forwards it once it has its ID and hash
+func (r *forwardingLogsRepository) Create(log *models.ActivityLog) error {
+	if err := r.LogsRepositoryInterface.Create(log); err != nil {
+		return err
+	}
+	r.forwarder.Forward(r.tenantID, *log)
+	return nil
+}
@@ -0,0 +1,39 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingForwarder struct {
+	tenantIDs []string
+	entries   []models.ActivityLog
+}
+
+func (f *recordingForwarder) Forward(tenantID string, entry models.ActivityLog) {
+	f.tenantIDs = append(f.tenantIDs, tenantID)
+	f.entries = append(f.entries, entry)
+}
+
+func TestWithLogForwarding(t *testing.T) {
+	forwarder := &recordingForwarder{}
+	repo := repositories.WithLogForwarding(memory.NewLogsRepository(), forwarder, "acme")
+
+	entry := &models.ActivityLog{User: "admin", Action: "UPDATE", Status: "SUCCESS"}
+	require.NoError(t, repo.Create(entry))
+
+	require.Len(t, forwarder.entries, 1)
+	assert.Equal(t, []string{"acme"}, forwarder.tenantIDs)
+	assert.Equal(t, entry.ID, forwarder.entries[0].ID, "entries are forwarded once stored")
+	assert.NotEmpty(t, forwarder.entries[0].ID)
+
+	stored, err := repo.GetByID(entry.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE", stored.Action, "reads go to the wrapped repository")
+}
@@ -2,10 +2,13 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"oop/internal/models"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,19 +17,24 @@ import (
 // LogsRepositoryInterface defines the interface for activity log database operations
 type LogsRepositoryInterface interface {
 	Create(log *models.ActivityLog) error
+	GetByID(id string) (*models.ActivityLog, error)
 	GetLogs(page, limit int) ([]models.ActivityLog, int64, error)
 	GetBasedOnFilter(page, limit int, user, action, status string, startDate, endDate *time.Time) ([]models.ActivityLog, int64, error)
+	VerifyChain() (*models.AuditChainReport, error)
 }
 
 // LogsRepository handles database operations related to users
 type LogsRepository struct {
-	dbClient *sql.DB // Changed from *DatabaseClient to *sql.DB assuming it's a standard SQL database client
+	dbClient *sql.DB    // Changed from *DatabaseClient to *sql.DB assuming it's a standard SQL database client
+	tenantID string     // Every query is scoped to this tenant; each tenant has its own hash chain
+	chainMu  sync.Mutex // Serializes inserts within this process so each entry links to the latest hash
 }
 
-// NewLogsRepository creates a new LogsRepository instance
+// NewLogsRepository creates a new LogsRepository instance for the default tenant
 func NewLogsRepository(dbClient *sql.DB) LogsRepositoryInterface {
 	return &LogsRepository{
 		dbClient: dbClient,
+		tenantID: models.DefaultTenantID,
 	}
 }
 
@@ -41,17 +49,90 @@ func (r *LogsRepository) Create(logEntry *models.ActivityLog) error {
 	if logEntry.Timestamp.IsZero() {
 		logEntry.Timestamp = now
 	}
+	// The column stores whole seconds; truncate so the hashed value matches what is read back
+	logEntry.Timestamp = logEntry.Timestamp.Truncate(time.Second)
 
-	query := `INSERT INTO activity_logs (id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at)
-	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	_, err := r.dbClient.Exec(query, logEntry.ID, logEntry.Timestamp, logEntry.User, logEntry.Action, logEntry.Details, logEntry.Status, logEntry.IsSystemAction, logEntry.CreatedAt, logEntry.UpdatedAt)
+	// Entity and change columns are optional; store NULL when the log is not tied to an entity
+	var entityType, entityID, changes sql.NullString
+	if logEntry.EntityType != "" {
+		entityType = sql.NullString{String: logEntry.EntityType, Valid: true}
+	}
+	if logEntry.EntityID != "" {
+		entityID = sql.NullString{String: logEntry.EntityID, Valid: true}
+	}
+	if len(logEntry.Changes) > 0 {
+		changesJSON, err := json.Marshal(logEntry.Changes)
+		if err != nil {
+			return fmt.Errorf("could not encode activity log changes: %w", err)
+		}
+		changes = sql.NullString{String: string(changesJSON), Valid: true}
+	}
+
+	r.chainMu.Lock()
+	defer r.chainMu.Unlock()
+
+	tx, err := r.dbClient.Begin()
+	if err != nil {
+		log.Printf("Error starting activity log transaction: %v", err)
+		return fmt.Errorf("could not create activity log: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	// Lock the latest chained entry so concurrent writers cannot link to the same hash
+	var prevHash sql.NullString
+	err = tx.QueryRow(`SELECT hash FROM activity_logs WHERE tenant_id = ? AND hash IS NOT NULL ORDER BY seq DESC LIMIT 1 FOR UPDATE`, r.tenantID).Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Error reading previous activity log hash: %v", err)
+		return fmt.Errorf("could not create activity log: %w", err)
+	}
+	logEntry.PrevHash = prevHash.String
+	logEntry.Hash = computeActivityLogHash(logEntry)
+
+	query := `INSERT INTO activity_logs (id, tenant_id, timestamp, user_id, action_type, details, status, is_system_action, entity_type, entity_id, changes, hash, prev_hash, created_at, updated_at)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, logEntry.ID, r.tenantID, logEntry.Timestamp, logEntry.User, logEntry.Action, logEntry.Details, logEntry.Status, logEntry.IsSystemAction, entityType, entityID, changes, logEntry.Hash, prevHash, logEntry.CreatedAt, logEntry.UpdatedAt)
 	if err != nil {
 		log.Printf("Error creating activity log: %v", err)
 		return fmt.Errorf("could not create activity log: %w", err)
 	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing activity log: %v", err)
+		return fmt.Errorf("could not create activity log: %w", err)
+	}
 	return nil
 }
 
+// GetByID retrieves a single activity log including its recorded field changes.
+func (r *LogsRepository) GetByID(id string) (*models.ActivityLog, error) {
+	query := `SELECT id, timestamp, user_id, action_type, details, status, is_system_action, entity_type, entity_id, changes, hash, prev_hash, created_at, updated_at
+	          FROM activity_logs WHERE id = ? AND tenant_id = ?`
+
+	var l models.ActivityLog
+	var entityType, entityID, changes, hash, prevHash sql.NullString
+	err := r.dbClient.QueryRow(query, id, r.tenantID).Scan(&l.ID, &l.Timestamp, &l.User, &l.Action, &l.Details, &l.Status, &l.IsSystemAction, &entityType, &entityID, &changes, &hash, &prevHash, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("activity log with ID %s not found: %w", id, err)
+		}
+		log.Printf("Error getting activity log %s: %v", id, err)
+		return nil, fmt.Errorf("could not get activity log: %w", err)
+	}
+
+	l.EntityType = entityType.String
+	l.EntityID = entityID.String
+	l.Hash = hash.String
+	l.PrevHash = prevHash.String
+	if changes.Valid && changes.String != "" {
+		if err := json.Unmarshal([]byte(changes.String), &l.Changes); err != nil {
+			log.Printf("Error decoding changes for activity log %s: %v", id, err)
+			return nil, fmt.Errorf("could not decode activity log changes: %w", err)
+		}
+	}
+
+	return &l, nil
+}
+
 // GetLogs retrieves paginated activity logs.
 func (r *LogsRepository) GetLogs(page, limit int) ([]models.ActivityLog, int64, error) {
 	if page < 1 {
@@ -63,17 +144,17 @@ func (r *LogsRepository) GetLogs(page, limit int) ([]models.ActivityLog, int64,
 	offset := (page - 1) * limit
 
 	query := `SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at
-	          FROM activity_logs ORDER BY timestamp DESC LIMIT ? OFFSET ?`
-	countQuery := `SELECT COUNT(*) FROM activity_logs`
+	          FROM activity_logs WHERE tenant_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?`
+	countQuery := `SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?`
 
 	var total int64
-	err := r.dbClient.QueryRow(countQuery).Scan(&total)
+	err := r.dbClient.QueryRow(countQuery, r.tenantID).Scan(&total)
 	if err != nil {
 		log.Printf("Error counting activity logs: %v", err)
 		return nil, 0, fmt.Errorf("could not count activity logs: %w", err)
 	}
 
-	rows, err := r.dbClient.Query(query, limit, offset)
+	rows, err := r.dbClient.Query(query, r.tenantID, limit, offset)
 	if err != nil {
 		log.Printf("Error querying activity logs: %v", err)
 		return nil, 0, fmt.Errorf("could not query activity logs: %w", err)
@@ -110,11 +191,11 @@ func (r *LogsRepository) GetBasedOnFilter(page, limit int, user, action, status
 
 	var queryBuilder strings.Builder
 	var countQueryBuilder strings.Builder
-	args := []interface{}{}
-	paramIndex := 1
+	args := []interface{}{r.tenantID}
+	paramIndex := 2
 
-	queryBuilder.WriteString("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1")
-	countQueryBuilder.WriteString("SELECT COUNT(*) FROM activity_logs WHERE 1=1")
+	queryBuilder.WriteString("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ?")
+	countQueryBuilder.WriteString("SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?")
 
 	addCondition := func(field, value string) {
 		if value != "" {
@@ -1,10 +1,12 @@
 package repositories
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"oop/internal/models"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+const (
+	insertActivityLogQuery   = "INSERT INTO activity_logs (id, tenant_id, timestamp, user_id, action_type, details, status, is_system_action, entity_type, entity_id, changes, hash, prev_hash, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	lastActivityLogHashQuery = "SELECT hash FROM activity_logs WHERE tenant_id = ? AND hash IS NOT NULL ORDER BY seq DESC LIMIT 1 FOR UPDATE"
+)
+
 func TestCreateActivityLog(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -30,19 +37,25 @@ func TestCreateActivityLog(t *testing.T) {
 	}
 
 	t.Run("SuccessfulCreate", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO activity_logs (id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")).
-			WithArgs(sqlmock.AnyArg(), logEntry.Timestamp, logEntry.User, logEntry.Action, logEntry.Details, logEntry.Status, logEntry.IsSystemAction, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lastActivityLogHashQuery)).WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(regexp.QuoteMeta(insertActivityLogQuery)).
+			WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, now.Truncate(time.Second), logEntry.User, logEntry.Action, logEntry.Details, logEntry.Status, logEntry.IsSystemAction, nil, nil, nil, sqlmock.AnyArg(), nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		err := repo.Create(logEntry)
 		assert.NoError(t, err)
 		assert.NotEmpty(t, logEntry.ID)
 		assert.WithinDuration(t, now, logEntry.CreatedAt, time.Second)
 		assert.WithinDuration(t, now, logEntry.UpdatedAt, time.Second)
+		assert.Len(t, logEntry.Hash, 64)
+		assert.Empty(t, logEntry.PrevHash, "first entry starts the chain")
 	})
 
 	t.Run("SuccessfulCreate_WithExistingID", func(t *testing.T) {
 		existingID := "existing-uuid"
+		prevHash := strings.Repeat("a", 64)
 		logEntryWithID := &models.ActivityLog{
 			ID:             existingID,
 			User:           "testuser2",
@@ -52,18 +65,27 @@ func TestCreateActivityLog(t *testing.T) {
 			IsSystemAction: false,
 			Timestamp:      now,
 		}
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO activity_logs (id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")).
-			WithArgs(existingID, logEntryWithID.Timestamp, logEntryWithID.User, logEntryWithID.Action, logEntryWithID.Details, logEntryWithID.Status, logEntryWithID.IsSystemAction, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lastActivityLogHashQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(prevHash))
+		mock.ExpectExec(regexp.QuoteMeta(insertActivityLogQuery)).
+			WithArgs(existingID, models.DefaultTenantID, now.Truncate(time.Second), logEntryWithID.User, logEntryWithID.Action, logEntryWithID.Details, logEntryWithID.Status, logEntryWithID.IsSystemAction, nil, nil, nil, sqlmock.AnyArg(), prevHash, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
 		err := repo.Create(logEntryWithID)
 		assert.NoError(t, err)
 		assert.Equal(t, existingID, logEntryWithID.ID)
+		assert.Equal(t, prevHash, logEntryWithID.PrevHash)
+		assert.Equal(t, computeActivityLogHash(logEntryWithID), logEntryWithID.Hash)
 	})
 
 	t.Run("DatabaseError", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO activity_logs (id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta(lastActivityLogHashQuery)).WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(regexp.QuoteMeta(insertActivityLogQuery)).
 			WillReturnError(fmt.Errorf("db error"))
+		mock.ExpectRollback()
 
 		err := repo.Create(logEntry)
 		assert.Error(t, err)
@@ -89,10 +111,10 @@ func TestGetLogs(t *testing.T) {
 			AddRow(expectedLogs[0].ID, expectedLogs[0].Timestamp, expectedLogs[0].User, expectedLogs[0].Action, "", "", false, expectedLogs[0].CreatedAt, expectedLogs[0].UpdatedAt).
 			AddRow(expectedLogs[1].ID, expectedLogs[1].Timestamp, expectedLogs[1].User, expectedLogs[1].Action, "", "", false, expectedLogs[1].CreatedAt, expectedLogs[1].UpdatedAt)
 
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?`)).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
-			WithArgs(10, 0).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
+			WithArgs(models.DefaultTenantID, 10, 0).
 			WillReturnRows(rows)
 
 		logs, total, err := repo.GetLogs(1, 10)
@@ -103,10 +125,10 @@ func TestGetLogs(t *testing.T) {
 	})
 
 	t.Run("NoLogsFound", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?`)).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
-			WithArgs(10, 0).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
+			WithArgs(models.DefaultTenantID, 10, 0).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "created_at", "updated_at"}))
 
 		logs, total, err := repo.GetLogs(1, 10)
@@ -116,7 +138,7 @@ func TestGetLogs(t *testing.T) {
 	})
 
 	t.Run("CountQueryError", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?`)).
 			WillReturnError(fmt.Errorf("count db error"))
 
 		_, _, err := repo.GetLogs(1, 10)
@@ -124,9 +146,9 @@ func TestGetLogs(t *testing.T) {
 	})
 
 	t.Run("MainQueryError", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?`)).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1)) // Assume count is fine
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
 			WillReturnError(fmt.Errorf("main query db error"))
 
 		_, _, err := repo.GetLogs(1, 10)
@@ -137,9 +159,9 @@ func TestGetLogs(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "timestamp"}). // Mismatching columns to cause scan error
 									AddRow("uuid1", now)
 
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs`)).
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ?`)).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? ORDER BY timestamp DESC LIMIT ? OFFSET ?")).
 			WillReturnRows(rows)
 
 		_, _, err := repo.GetLogs(1, 10)
@@ -174,14 +196,14 @@ func TestGetBasedOnFilter(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "created_at", "updated_at"}).
 			AddRow(defaultLog.ID, defaultLog.Timestamp, defaultLog.User, defaultLog.Action, defaultLog.Details, defaultLog.Status, defaultLog.IsSystemAction, defaultLog.CreatedAt, defaultLog.UpdatedAt)
 
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?) AND LOWER(action_type) LIKE LOWER(?) AND LOWER(status) LIKE LOWER(?) AND timestamp >= ? AND timestamp <= ?"
-		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?) AND LOWER(action_type) LIKE LOWER(?) AND LOWER(status) LIKE LOWER(?) AND timestamp >= ? AND timestamp <= ? ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?) AND LOWER(action_type) LIKE LOWER(?) AND LOWER(status) LIKE LOWER(?) AND timestamp >= ? AND timestamp <= ?"
+		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?) AND LOWER(action_type) LIKE LOWER(?) AND LOWER(status) LIKE LOWER(?) AND timestamp >= ? AND timestamp <= ? ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs("%test_user%", "%TEST_ACTION%", "%SUCCESS%", AnyTime{}, AnyTime{}).
+			WithArgs(models.DefaultTenantID, "%test_user%", "%TEST_ACTION%", "%SUCCESS%", AnyTime{}, AnyTime{}).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-			WithArgs("%test_user%", "%TEST_ACTION%", "%SUCCESS%", AnyTime{}, AnyTime{}, 10, 0).
+			WithArgs(models.DefaultTenantID, "%test_user%", "%TEST_ACTION%", "%SUCCESS%", AnyTime{}, AnyTime{}, 10, 0).
 			WillReturnRows(rows)
 
 		logs, total, err := repo.GetBasedOnFilter(1, 10, "test_user", "TEST_ACTION", "SUCCESS", &startDate, &endDate)
@@ -195,14 +217,14 @@ func TestGetBasedOnFilter(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "created_at", "updated_at"}).
 			AddRow(defaultLog.ID, defaultLog.Timestamp, defaultLog.User, defaultLog.Action, defaultLog.Details, defaultLog.Status, defaultLog.IsSystemAction, defaultLog.CreatedAt, defaultLog.UpdatedAt)
 
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?)"
-		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?)"
+		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs("%test_user%").
+			WithArgs(models.DefaultTenantID, "%test_user%").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-			WithArgs("%test_user%", 10, 0).
+			WithArgs(models.DefaultTenantID, "%test_user%", 10, 0).
 			WillReturnRows(rows)
 
 		logs, total, err := repo.GetBasedOnFilter(1, 10, "test_user", "", "", nil, nil)
@@ -216,14 +238,14 @@ func TestGetBasedOnFilter(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "created_at", "updated_at"}).
 			AddRow(defaultLog.ID, defaultLog.Timestamp, defaultLog.User, defaultLog.Action, defaultLog.Details, defaultLog.Status, defaultLog.IsSystemAction, defaultLog.CreatedAt, defaultLog.UpdatedAt)
 
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND timestamp >= ? AND timestamp <= ?"
-		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1 AND timestamp >= ? AND timestamp <= ? ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND timestamp >= ? AND timestamp <= ?"
+		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? AND timestamp >= ? AND timestamp <= ? ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs(AnyTime{}, AnyTime{}).
+			WithArgs(models.DefaultTenantID, AnyTime{}, AnyTime{}).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-			WithArgs(AnyTime{}, AnyTime{}, 10, 0).
+			WithArgs(models.DefaultTenantID, AnyTime{}, AnyTime{}, 10, 0).
 			WillReturnRows(rows)
 
 		logs, total, err := repo.GetBasedOnFilter(1, 10, "", "", "", &startDate, &endDate)
@@ -234,14 +256,14 @@ func TestGetBasedOnFilter(t *testing.T) {
 	})
 
 	t.Run("NoResultsFound", func(t *testing.T) {
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?)"
-		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?)"
+		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs("%nonexistent%").
+			WithArgs(models.DefaultTenantID, "%nonexistent%").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-			WithArgs("%nonexistent%", 10, 0).
+			WithArgs(models.DefaultTenantID, "%nonexistent%", 10, 0).
 			WillReturnRows(sqlmock.NewRows([]string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "created_at", "updated_at"}))
 
 		logs, total, err := repo.GetBasedOnFilter(1, 10, "nonexistent", "", "", nil, nil)
@@ -251,9 +273,9 @@ func TestGetBasedOnFilter(t *testing.T) {
 	})
 
 	t.Run("CountQueryError_WithFilter", func(t *testing.T) {
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?)"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?)"
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs("%test_user%").
+			WithArgs(models.DefaultTenantID, "%test_user%").
 			WillReturnError(fmt.Errorf("filter count db error"))
 
 		_, _, err := repo.GetBasedOnFilter(1, 10, "test_user", "", "", nil, nil)
@@ -261,14 +283,14 @@ func TestGetBasedOnFilter(t *testing.T) {
 	})
 
 	t.Run("MainQueryError_WithFilter", func(t *testing.T) {
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?)"
-		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?)"
+		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs("%test_user%").
+			WithArgs(models.DefaultTenantID, "%test_user%").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1)) // Assume count is fine
 		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-			WithArgs("%test_user%", 10, 0).
+			WithArgs(models.DefaultTenantID, "%test_user%", 10, 0).
 			WillReturnError(fmt.Errorf("filter main query db error"))
 
 		_, _, err := repo.GetBasedOnFilter(1, 10, "test_user", "", "", nil, nil)
@@ -279,14 +301,14 @@ func TestGetBasedOnFilter(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "timestamp"}). // Mismatch columns
 									AddRow("uuid1", now)
 
-		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?)"
-		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE 1=1 AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
+		expectedCountQuery := "SELECT COUNT(*) FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?)"
+		expectedQuery := "SELECT id, timestamp, user_id, action_type, details, status, is_system_action, created_at, updated_at FROM activity_logs WHERE tenant_id = ? AND LOWER(user_id) LIKE LOWER(?) ORDER BY timestamp DESC LIMIT ? OFFSET ?"
 
 		mock.ExpectQuery(regexp.QuoteMeta(expectedCountQuery)).
-			WithArgs("%test_user%").
+			WithArgs(models.DefaultTenantID, "%test_user%").
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 		mock.ExpectQuery(regexp.QuoteMeta(expectedQuery)).
-			WithArgs("%test_user%", 10, 0).
+			WithArgs(models.DefaultTenantID, "%test_user%", 10, 0).
 			WillReturnRows(rows)
 
 		_, _, err := repo.GetBasedOnFilter(1, 10, "test_user", "", "", nil, nil)
@@ -295,3 +317,185 @@ func TestGetBasedOnFilter(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestCreateActivityLogWithChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLogsRepository(db)
+	logEntry := &models.ActivityLog{
+		User:       "admin-id",
+		Action:     "UPDATE_CUSTOMER",
+		Status:     "SUCCESS",
+		EntityType: "customer",
+		EntityID:   "cust-1",
+		Changes:    []models.FieldChange{{Field: "phone", Before: "111", After: "222"}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(lastActivityLogHashQuery)).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(regexp.QuoteMeta(insertActivityLogQuery)).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, AnyTime{}, "admin-id", "UPDATE_CUSTOMER", "", "SUCCESS", false, "customer", "cust-1", `[{"field":"phone","before":"111","after":"222"}]`, sqlmock.AnyArg(), nil, AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Create(logEntry))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetActivityLogByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewLogsRepository(db)
+	now := time.Now()
+	query := regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, entity_type, entity_id, changes, hash, prev_hash, created_at, updated_at FROM activity_logs WHERE id = ? AND tenant_id = ?")
+	columns := []string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "entity_type", "entity_id", "changes", "hash", "prev_hash", "created_at", "updated_at"}
+
+	t.Run("WithChanges", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow("uuid1", now, "admin-id", "UPDATE_CUSTOMER", "Updated customer", "SUCCESS", false, "customer", "cust-1", `[{"field":"phone","before":"111","after":"222"}]`, "hash1", "", now, now)
+		mock.ExpectQuery(query).WithArgs("uuid1", models.DefaultTenantID).WillReturnRows(rows)
+
+		logEntry, err := repo.GetByID("uuid1")
+		assert.NoError(t, err)
+		assert.Equal(t, "customer", logEntry.EntityType)
+		assert.Equal(t, "cust-1", logEntry.EntityID)
+		assert.Equal(t, []models.FieldChange{{Field: "phone", Before: "111", After: "222"}}, logEntry.Changes)
+	})
+
+	t.Run("WithoutChanges", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow("uuid2", now, "user1", "LOGIN", "", "SUCCESS", false, nil, nil, nil, nil, nil, now, now)
+		mock.ExpectQuery(query).WithArgs("uuid2", models.DefaultTenantID).WillReturnRows(rows)
+
+		logEntry, err := repo.GetByID("uuid2")
+		assert.NoError(t, err)
+		assert.Empty(t, logEntry.EntityType)
+		assert.Nil(t, logEntry.Changes)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("missing", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+		logEntry, err := repo.GetByID("missing")
+		assert.Nil(t, logEntry)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVerifyActivityLogChain(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT id, timestamp, user_id, action_type, details, status, is_system_action, entity_type, entity_id, changes, hash, prev_hash FROM activity_logs WHERE tenant_id = ? ORDER BY seq ASC")
+	columns := []string{"id", "timestamp", "user_id", "action_type", "details", "status", "is_system_action", "entity_type", "entity_id", "changes", "hash", "prev_hash"}
+	now := time.Now().Truncate(time.Second)
+
+	// buildChain links the entries the same way Create does
+	buildChain := func() []*models.ActivityLog {
+		entries := []*models.ActivityLog{
+			{ID: "log-1", Timestamp: now, User: "user1", Action: "LOGIN", Status: "SUCCESS"},
+			{ID: "log-2", Timestamp: now, User: "admin", Action: "UPDATE_CUSTOMER", Status: "SUCCESS", EntityType: "customer", EntityID: "cust-1",
+				Changes: []models.FieldChange{{Field: "phone", Before: "111", After: "222"}}},
+			{ID: "log-3", Timestamp: now, User: "user1", Action: "LOGOUT", Status: "SUCCESS"},
+		}
+		prev := ""
+		for _, e := range entries {
+			e.PrevHash = prev
+			e.Hash = computeActivityLogHash(e)
+			prev = e.Hash
+		}
+		return entries
+	}
+
+	addRow := func(rows *sqlmock.Rows, e *models.ActivityLog) {
+		var entityType, entityID, changes, prevHash driver.Value
+		if e.EntityType != "" {
+			entityType, entityID = e.EntityType, e.EntityID
+		}
+		if len(e.Changes) > 0 {
+			// Simulate the database reformatting the JSON column
+			changes = `[{"after": "222", "before": "111", "field": "phone"}]`
+		}
+		if e.PrevHash != "" {
+			prevHash = e.PrevHash
+		}
+		rows.AddRow(e.ID, e.Timestamp, e.User, e.Action, e.Details, e.Status, e.IsSystemAction, entityType, entityID, changes, e.Hash, prevHash)
+	}
+
+	t.Run("ValidChainWithLegacyEntries", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewLogsRepository(db)
+
+		rows := sqlmock.NewRows(columns).
+			AddRow("legacy-1", now, "user1", "LOGIN", "", "SUCCESS", false, nil, nil, nil, nil, nil)
+		for _, e := range buildChain() {
+			addRow(rows, e)
+		}
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		report, err := repo.VerifyChain()
+		assert.NoError(t, err)
+		assert.True(t, report.Valid)
+		assert.Equal(t, 3, report.CheckedEntries)
+		assert.Equal(t, 1, report.UnchainedEntries)
+		assert.Empty(t, report.FirstTamperedID)
+	})
+
+	t.Run("ModifiedEntry", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewLogsRepository(db)
+
+		entries := buildChain()
+		entries[1].User = "someone-else"
+		rows := sqlmock.NewRows(columns)
+		for _, e := range entries {
+			addRow(rows, e)
+		}
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		report, err := repo.VerifyChain()
+		assert.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, "log-2", report.FirstTamperedID)
+		assert.Contains(t, report.Reason, "do not match")
+	})
+
+	t.Run("RemovedEntry", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewLogsRepository(db)
+
+		entries := buildChain()
+		rows := sqlmock.NewRows(columns)
+		addRow(rows, entries[0])
+		addRow(rows, entries[2])
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		report, err := repo.VerifyChain()
+		assert.NoError(t, err)
+		assert.False(t, report.Valid)
+		assert.Equal(t, "log-3", report.FirstTamperedID)
+		assert.Contains(t, report.Reason, "removed")
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewLogsRepository(db)
+
+		mock.ExpectQuery(query).WillReturnError(fmt.Errorf("db error"))
+
+		report, err := repo.VerifyChain()
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	})
+}
@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnnouncementRepository defines the interface for announcement data operations.
+type AnnouncementRepository interface {
+	Create(announcement *models.Announcement) error
+	GetByID(id string) (*models.Announcement, error)
+	Update(announcement *models.Announcement) error
+	Delete(id string) error
+	// GetAll returns every announcement, including scheduled and expired ones, newest first.
+	GetAll() ([]models.Announcement, error)
+	// GetActive returns the announcements displayed at the given time, newest first,
+	// with Acknowledged set for userID.
+	GetActive(at time.Time, userID string) ([]models.Announcement, error)
+	// Acknowledge records that a user read an announcement. Acknowledging twice keeps the first time.
+	Acknowledge(announcementID, userID string) error
+	GetAcknowledgments(announcementID string) ([]models.AnnouncementAck, error)
+}
+
+// announcementRepository implements the AnnouncementRepository interface.
+type announcementRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewAnnouncementRepository creates a new instance of announcementRepository for the default tenant.
+func NewAnnouncementRepository(db *sql.DB) AnnouncementRepository {
+	return &announcementRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Create stores a new announcement.
+func (r *announcementRepository) Create(announcement *models.Announcement) error {
+	if announcement.ID == "" {
+		announcement.ID = uuid.New().String()
+	}
+	now := time.Now()
+	announcement.CreatedAt = now
+	announcement.UpdatedAt = now
+
+	query := `
+		INSERT INTO announcements (id, tenant_id, title, body, severity, starts_at, ends_at, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, announcement.ID, r.TenantID, announcement.Title, announcement.Body, announcement.Severity,
+		announcement.StartsAt, announcement.EndsAt, announcement.CreatedBy, announcement.CreatedAt, announcement.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an announcement by its ID.
+func (r *announcementRepository) GetByID(id string) (*models.Announcement, error) {
+	query := `
+		SELECT id, title, body, severity, starts_at, ends_at, created_by, created_at, updated_at, FALSE
+		FROM announcements
+		WHERE id = ? AND tenant_id = ?
+	`
+	announcement, err := scanAnnouncement(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("announcement not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+
+	return announcement, nil
+}
+
+// Update saves the content and display window of an announcement.
+func (r *announcementRepository) Update(announcement *models.Announcement) error {
+	announcement.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE announcements SET title = ?, body = ?, severity = ?, starts_at = ?, ends_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.DB.Exec(query, announcement.Title, announcement.Body, announcement.Severity, announcement.StartsAt,
+		announcement.EndsAt, announcement.UpdatedAt, announcement.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update announcement: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("announcement not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Delete removes an announcement and its acknowledgments.
+func (r *announcementRepository) Delete(id string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM announcement_acks WHERE announcement_id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to delete announcement acknowledgments: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM announcements WHERE id = ? AND tenant_id = ?`, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete announcement: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("announcement not found: %w", sql.ErrNoRows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetAll retrieves every announcement of the tenant, newest first.
+func (r *announcementRepository) GetAll() ([]models.Announcement, error) {
+	query := `
+		SELECT id, title, body, severity, starts_at, ends_at, created_by, created_at, updated_at, FALSE
+		FROM announcements
+		WHERE tenant_id = ?
+		ORDER BY starts_at DESC
+	`
+	return r.queryAnnouncements(query, r.TenantID)
+}
+
+// GetActive retrieves the announcements displayed at the given time, newest first.
+func (r *announcementRepository) GetActive(at time.Time, userID string) ([]models.Announcement, error) {
+	query := `
+		SELECT a.id, a.title, a.body, a.severity, a.starts_at, a.ends_at, a.created_by, a.created_at, a.updated_at,
+			ack.user_id IS NOT NULL
+		FROM announcements a
+		LEFT JOIN announcement_acks ack ON ack.announcement_id = a.id AND ack.user_id = ? AND ack.tenant_id = a.tenant_id
+		WHERE a.tenant_id = ? AND a.starts_at <= ? AND (a.ends_at IS NULL OR a.ends_at > ?)
+		ORDER BY a.starts_at DESC
+	`
+	return r.queryAnnouncements(query, userID, r.TenantID, at, at)
+}
+
+func (r *announcementRepository) queryAnnouncements(query string, args ...interface{}) ([]models.Announcement, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %w", err)
+	}
+	defer rows.Close()
+
+	announcements := []models.Announcement{}
+	for rows.Next() {
+		announcement, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement row: %w", err)
+		}
+		announcements = append(announcements, *announcement)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating announcement rows: %w", err)
+	}
+
+	return announcements, nil
+}
+
+// Acknowledge records that a user read an announcement.
+func (r *announcementRepository) Acknowledge(announcementID, userID string) error {
+	query := `
+		INSERT IGNORE INTO announcement_acks (announcement_id, tenant_id, user_id, acknowledged_at)
+		VALUES (?, ?, ?, ?)
+	`
+	if _, err := r.DB.Exec(query, announcementID, r.TenantID, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to acknowledge announcement: %w", err)
+	}
+	return nil
+}
+
+// GetAcknowledgments retrieves who acknowledged an announcement, earliest first.
+func (r *announcementRepository) GetAcknowledgments(announcementID string) ([]models.AnnouncementAck, error) {
+	query := `
+		SELECT announcement_id, user_id, acknowledged_at
+		FROM announcement_acks
+		WHERE announcement_id = ? AND tenant_id = ?
+		ORDER BY acknowledged_at ASC
+	`
+	rows, err := r.DB.Query(query, announcementID, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcement acknowledgments: %w", err)
+	}
+	defer rows.Close()
+
+	acks := []models.AnnouncementAck{}
+	for rows.Next() {
+		var ack models.AnnouncementAck
+		if err := rows.Scan(&ack.AnnouncementID, &ack.UserID, &ack.AcknowledgedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan acknowledgment row: %w", err)
+		}
+		acks = append(acks, ack)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating acknowledgment rows: %w", err)
+	}
+
+	return acks, nil
+}
+
+func scanAnnouncement(row rowScanner) (*models.Announcement, error) {
+	var announcement models.Announcement
+	var endsAt sql.NullTime
+
+	err := row.Scan(
+		&announcement.ID,
+		&announcement.Title,
+		&announcement.Body,
+		&announcement.Severity,
+		&announcement.StartsAt,
+		&endsAt,
+		&announcement.CreatedBy,
+		&announcement.CreatedAt,
+		&announcement.UpdatedAt,
+		&announcement.Acknowledged,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if endsAt.Valid {
+		announcement.EndsAt = &endsAt.Time
+	}
+
+	return &announcement, nil
+}
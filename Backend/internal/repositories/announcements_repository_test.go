@@ -0,0 +1,78 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var announcementColumns = []string{"id", "title", "body", "severity", "starts_at", "ends_at", "created_by", "created_at", "updated_at", "acknowledged"}
+
+func newMockAnnouncementRepo(t *testing.T) (repositories.AnnouncementRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewAnnouncementRepository(db), mock
+}
+
+func TestGetActiveAnnouncements(t *testing.T) {
+	repo, mock := newMockAnnouncementRepo(t)
+	now := time.Now()
+	query := `
+		SELECT a.id, a.title, a.body, a.severity, a.starts_at, a.ends_at, a.created_by, a.created_at, a.updated_at,
+			ack.user_id IS NOT NULL
+		FROM announcements a
+		LEFT JOIN announcement_acks ack ON ack.announcement_id = a.id AND ack.user_id = ? AND ack.tenant_id = a.tenant_id
+		WHERE a.tenant_id = ? AND a.starts_at <= ? AND (a.ends_at IS NULL OR a.ends_at > ?)
+		ORDER BY a.starts_at DESC
+	`
+	rows := sqlmock.NewRows(announcementColumns).
+		AddRow("ann-1", "Policy", "Read me", models.AnnouncementSeverityWarning, now, nil, "admin-1", now, now, true).
+		AddRow("ann-2", "Closing", "At 3pm", models.AnnouncementSeverityInfo, now, now.Add(time.Hour), "admin-1", now, now, false)
+	mock.ExpectQuery(query).WithArgs("user-1", models.DefaultTenantID, now, now).WillReturnRows(rows)
+
+	announcements, err := repo.GetActive(now, "user-1")
+
+	require.NoError(t, err)
+	require.Len(t, announcements, 2)
+	assert.True(t, announcements[0].Acknowledged)
+	assert.Nil(t, announcements[0].EndsAt)
+	assert.False(t, announcements[1].Acknowledged)
+	assert.NotNil(t, announcements[1].EndsAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcknowledgeAnnouncement(t *testing.T) {
+	repo, mock := newMockAnnouncementRepo(t)
+	query := `
+		INSERT IGNORE INTO announcement_acks (announcement_id, tenant_id, user_id, acknowledged_at)
+		VALUES (?, ?, ?, ?)
+	`
+	mock.ExpectExec(query).WithArgs("ann-1", models.DefaultTenantID, "user-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.Acknowledge("ann-1", "user-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteAnnouncementNotFound(t *testing.T) {
+	repo, mock := newMockAnnouncementRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM announcement_acks WHERE announcement_id = ? AND tenant_id = ?").
+		WithArgs("missing", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM announcements WHERE id = ? AND tenant_id = ?").
+		WithArgs("missing", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Delete("missing")
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,236 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnomalyRepository defines the interface for the review queue of unusual sales and
+// stock movements, and for reading the records they are found in.
+type AnomalyRepository interface {
+	// SoldPrices returns the prices cabs, accessories and materials were sold at
+	// between two sale dates (inclusive, YYYY-MM-DD).
+	SoldPrices(startDate, endDate string) ([]models.SoldPrice, error)
+	// Activity returns the activity log entries with one of the actions recorded from
+	// from up to but not including to, with their field changes, oldest first.
+	Activity(actions []string, from, to time.Time) ([]models.ActivityLog, error)
+	// Add stores new open anomalies, skipping those whose source was already flagged
+	// for the same kind, and returns the ones it stored.
+	Add(anomalies []models.Anomaly) ([]models.Anomaly, error)
+	// GetByID returns an anomaly, or an error wrapping sql.ErrNoRows when it does not exist.
+	GetByID(id string) (*models.Anomaly, error)
+	// GetAll returns the anomalies with the status and kind, either of which may be
+	// empty to match all, most recent first.
+	GetAll(status, kind string) ([]models.Anomaly, error)
+	// Review acknowledges or dismisses an open anomaly, or returns an error wrapping
+	// sql.ErrNoRows when it is not open, so an anomaly is only reviewed once.
+	Review(id, status, reviewedBy, note string) (*models.Anomaly, error)
+}
+
+// anomalyRepository implements the AnomalyRepository interface.
+type anomalyRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewAnomalyRepository creates a new instance of anomalyRepository for the default tenant.
+func NewAnomalyRepository(db *sql.DB) AnomalyRepository {
+	return &anomalyRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const anomalyColumns = `id, kind, source_id, entity_type, entity_id, details, occurred_at, status, detected_at, reviewed_by, reviewed_at, note`
+
+// SoldPrices retrieves the unit prices of the items sold between two sale dates.
+func (r *anomalyRepository) SoldPrices(startDate, endDate string) ([]models.SoldPrice, error) {
+	query := `SELECT si.id, si.sale_id, DATE_FORMAT(s.sale_date, '%Y-%m-%d'), si.item_type,
+			COALESCE(CAST(CASE si.item_type WHEN 'cab' THEN si.multi_cab_id WHEN 'accessory' THEN si.accessory_id ELSE si.material_id END AS CHAR), ''),
+			si.unit_price, si.created_at
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		WHERE si.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ?
+		ORDER BY s.sale_date, si.created_at`
+	rows, err := r.DB.Query(query, r.TenantID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sold prices: %w", err)
+	}
+	defer rows.Close()
+
+	prices := []models.SoldPrice{}
+	for rows.Next() {
+		var price models.SoldPrice
+		if err := rows.Scan(&price.SaleItemID, &price.SaleID, &price.SaleDate, &price.ItemType, &price.ItemID, &price.UnitPrice, &price.SoldAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sold price: %w", err)
+		}
+		prices = append(prices, price)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sold prices: %w", err)
+	}
+	return prices, nil
+}
+
+// Activity retrieves the activity log entries with the actions in a time range.
+func (r *anomalyRepository) Activity(actions []string, from, to time.Time) ([]models.ActivityLog, error) {
+	if len(actions) == 0 {
+		return []models.ActivityLog{}, nil
+	}
+	query := `SELECT id, timestamp, user_id, action_type, details, status, entity_type, entity_id, changes
+		FROM activity_logs
+		WHERE tenant_id = ? AND action_type IN (?` + strings.Repeat(", ?", len(actions)-1) + `) AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp`
+	args := []interface{}{r.TenantID}
+	for _, action := range actions {
+		args = append(args, action)
+	}
+	args = append(args, from, to)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.ActivityLog{}
+	for rows.Next() {
+		var entry models.ActivityLog
+		var entityType, entityID, changes sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.Timestamp, &entry.User, &entry.Action, &entry.Details, &entry.Status, &entityType, &entityID, &changes); err != nil {
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		entry.EntityType, entry.EntityID = entityType.String, entityID.String
+		if changes.Valid && changes.String != "" {
+			if err := json.Unmarshal([]byte(changes.String), &entry.Changes); err != nil {
+				return nil, fmt.Errorf("could not decode changes of activity log %s: %w", entry.ID, err)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating activity: %w", err)
+	}
+	return entries, nil
+}
+
+// Add stores the anomalies whose source was not flagged for their kind yet.
+func (r *anomalyRepository) Add(anomalies []models.Anomaly) ([]models.Anomaly, error) {
+	query := `INSERT IGNORE INTO anomalies (id, tenant_id, kind, source_id, entity_type, entity_id, details, occurred_at, status, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	added := []models.Anomaly{}
+	for _, anomaly := range anomalies {
+		anomaly.ID = uuid.New().String()
+		anomaly.Status = models.AnomalyOpen
+		anomaly.DetectedAt = time.Now()
+		result, err := r.DB.Exec(query, anomaly.ID, r.TenantID, anomaly.Kind, anomaly.SourceID, anomaly.EntityType, anomaly.EntityID,
+			anomaly.Details, anomaly.OccurredAt, anomaly.Status, anomaly.DetectedAt)
+		if err != nil {
+			return added, fmt.Errorf("failed to add %s anomaly of %s: %w", anomaly.Kind, anomaly.SourceID, err)
+		}
+		// No row is inserted when the source was already flagged
+		if inserted, err := result.RowsAffected(); err != nil {
+			return added, fmt.Errorf("failed to get affected rows: %w", err)
+		} else if inserted > 0 {
+			added = append(added, anomaly)
+		}
+	}
+	return added, nil
+}
+
+// GetByID retrieves an anomaly by its ID.
+func (r *anomalyRepository) GetByID(id string) (*models.Anomaly, error) {
+	query := `SELECT ` + anomalyColumns + ` FROM anomalies WHERE id = ? AND tenant_id = ?`
+	anomaly, err := scanAnomaly(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("anomaly not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get anomaly: %w", err)
+	}
+	return anomaly, nil
+}
+
+// GetAll retrieves the anomalies with a status and kind, or all of them.
+func (r *anomalyRepository) GetAll(status, kind string) ([]models.Anomaly, error) {
+	query := `SELECT ` + anomalyColumns + ` FROM anomalies WHERE tenant_id = ?`
+	args := []interface{}{r.TenantID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY occurred_at DESC, id`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	anomalies := []models.Anomaly{}
+	for rows.Next() {
+		anomaly, err := scanAnomaly(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan anomaly row: %w", err)
+		}
+		anomalies = append(anomalies, *anomaly)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating anomaly rows: %w", err)
+	}
+	return anomalies, nil
+}
+
+// Review acknowledges or dismisses an anomaly that is still open.
+func (r *anomalyRepository) Review(id, status, reviewedBy, note string) (*models.Anomaly, error) {
+	query := `UPDATE anomalies SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`
+	result, err := r.DB.Exec(query, status, reviewedBy, time.Now(), note, id, r.TenantID, models.AnomalyOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review anomaly: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("open anomaly not found: %w", sql.ErrNoRows)
+	}
+	return r.GetByID(id)
+}
+
+func scanAnomaly(row rowScanner) (*models.Anomaly, error) {
+	var anomaly models.Anomaly
+	var reviewedBy, note sql.NullString
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&anomaly.ID,
+		&anomaly.Kind,
+		&anomaly.SourceID,
+		&anomaly.EntityType,
+		&anomaly.EntityID,
+		&anomaly.Details,
+		&anomaly.OccurredAt,
+		&anomaly.Status,
+		&anomaly.DetectedAt,
+		&reviewedBy,
+		&reviewedAt,
+		&note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	anomaly.ReviewedBy, anomaly.Note = reviewedBy.String, note.String
+	if reviewedAt.Valid {
+		anomaly.ReviewedAt = &reviewedAt.Time
+	}
+	return &anomaly, nil
+}
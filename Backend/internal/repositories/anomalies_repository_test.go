@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAnomaliesSkipsFlaggedSources(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewAnomalyRepository(db)
+
+	insert := regexp.QuoteMeta("INSERT IGNORE INTO anomalies (id, tenant_id, kind, source_id, entity_type, entity_id, details, occurred_at, status, detected_at)")
+	mock.ExpectExec(insert).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, models.AnomalyAfterHoursVoid, "log-1", "sale", "sale-1", "Sale deleted at 23:00", sqlmock.AnyArg(), models.AnomalyOpen, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insert).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, models.AnomalyAfterHoursVoid, "log-2", "sale", "sale-2", "Sale deleted at 23:30", sqlmock.AnyArg(), models.AnomalyOpen, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	added, err := repo.Add([]models.Anomaly{
+		{Kind: models.AnomalyAfterHoursVoid, SourceID: "log-1", EntityType: "sale", EntityID: "sale-1", Details: "Sale deleted at 23:00", OccurredAt: time.Now()},
+		{Kind: models.AnomalyAfterHoursVoid, SourceID: "log-2", EntityType: "sale", EntityID: "sale-2", Details: "Sale deleted at 23:30", OccurredAt: time.Now()},
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 1, "the source flagged before is skipped")
+	assert.Equal(t, "log-2", added[0].SourceID)
+	assert.Equal(t, models.AnomalyOpen, added[0].Status)
+	assert.NotEmpty(t, added[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewAnomalyOnlyOnce(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewAnomalyRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE anomalies SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?")).
+		WithArgs(models.AnomalyDismissed, "admin-1", sqlmock.AnyArg(), "", "anomaly-1", models.DefaultTenantID, models.AnomalyOpen).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err := repo.Review("anomaly-1", models.AnomalyDismissed, "admin-1", "")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "an anomaly that is not open cannot be reviewed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAnomalyActivity(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewAnomalyRepository(db)
+	from := time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 1)
+
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE tenant_id = ? AND action_type IN (?, ?) AND timestamp >= ? AND timestamp < ?")).
+		WithArgs(models.DefaultTenantID, "UPDATE_CAB", "DELETE_SALE", from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "timestamp", "user_id", "action_type", "details", "status", "entity_type", "entity_id", "changes"}).
+			AddRow("log-1", from.Add(9*time.Hour), "admin-1", "UPDATE_CAB", "Updated cab 1", "SUCCESS", "cab", "1", `[{"field":"quantity","before":50,"after":5}]`).
+			AddRow("log-2", from.Add(23*time.Hour), "staff-1", "DELETE_SALE", "Deleted sale sale-1", "SUCCESS", "sale", "sale-1", nil))
+
+	entries, err := repo.Activity([]string{"UPDATE_CAB", "DELETE_SALE"}, from, to)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Len(t, entries[0].Changes, 1)
+	assert.Equal(t, "quantity", entries[0].Changes[0].Field)
+	assert.Equal(t, 5.0, entries[0].Changes[0].After)
+	assert.Empty(t, entries[1].Changes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
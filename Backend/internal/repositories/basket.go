@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"sort"
+
+	"oop/internal/models"
+)
+
+// AccessoryPair is an ordered pair of accessory IDs sold in the same sale
+type AccessoryPair [2]int
+
+// BuildBasketPairs turns sale counts into basket pairs in both directions, ordered by
+// confidence, then support, then accessory IDs. itemSales counts the sales containing
+// each accessory and pairSales the sales containing both accessories of each pair.
+func BuildBasketPairs(totalSales int, itemSales map[int]int, pairSales map[AccessoryPair]int) []models.BasketPair {
+	pairs := make([]models.BasketPair, 0, len(pairSales))
+	if totalSales == 0 {
+		return pairs
+	}
+	for pair, together := range pairSales {
+		if itemSales[pair[0]] == 0 {
+			continue
+		}
+		pairs = append(pairs, models.BasketPair{
+			AccessoryID:       pair[0],
+			PairedAccessoryID: pair[1],
+			SalesTogether:     together,
+			Support:           float64(together) / float64(totalSales),
+			Confidence:        float64(together) / float64(itemSales[pair[0]]),
+		})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		a, b := pairs[i], pairs[j]
+		switch {
+		case a.Confidence != b.Confidence:
+			return a.Confidence > b.Confidence
+		case a.Support != b.Support:
+			return a.Support > b.Support
+		case a.AccessoryID != b.AccessoryID:
+			return a.AccessoryID < b.AccessoryID
+		}
+		return a.PairedAccessoryID < b.PairedAccessoryID
+	})
+	return pairs
+}
@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"oop/internal/config"
+)
+
+// BatchError is the error of an inventory batch that was rolled back, naming the
+// change that failed. It wraps ErrNegativeStock or sql.ErrNoRows when the change
+// would take a quantity below zero or its item does not exist.
+type BatchError struct {
+	Index int // Of the change in the batch
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("change %d of batch: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// lockBatchItem locks the row of an item a batch updates and returns when it was
+// created, failing with sql.ErrNoRows when it does not exist or is deleted
+func lockBatchItem(ctx context.Context, tx *sql.Tx, table, itemType string, id int, tenantID string) (time.Time, error) {
+	var createdAt time.Time
+	err := tx.QueryRowContext(ctx, "SELECT created_at FROM "+table+" WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL FOR UPDATE", id, tenantID).Scan(&createdAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, fmt.Errorf("%s with ID %d not found: %w", itemType, id, sql.ErrNoRows)
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to lock %s %d: %w", itemType, id, err)
+	}
+	return createdAt, nil
+}
+
+// deleteBatchItem soft-deletes an item of a batch, failing with sql.ErrNoRows when it
+// does not exist or is already deleted
+func deleteBatchItem(ctx context.Context, tx *sql.Tx, table, itemType string, id int, tenantID string, now time.Time) error {
+	res, err := tx.ExecContext(ctx, "UPDATE "+table+" SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL", now, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s %d: %w", itemType, id, err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to delete %s %d: %w", itemType, id, err)
+	} else if affected == 0 {
+		return fmt.Errorf("%s with ID %d not found: %w", itemType, id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// imageColumn is the value stored for an item's image: NULL for none or the default
+func imageColumn(image string) interface{} {
+	if image == "" || image == config.DefaultImageURL {
+		return nil
+	}
+	return image
+}
@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CabRegistrationRepository defines the interface for the LTO registration paperwork
+// of sold cab units.
+type CabRegistrationRepository interface {
+	Create(registration *models.CabRegistration) error
+	// GetByID returns an error wrapping sql.ErrNoRows when the registration does not exist.
+	GetByID(id string) (*models.CabRegistration, error)
+	// Update saves every editable field of a registration, including its status.
+	Update(registration *models.CabRegistration) error
+	// GetAll returns the registrations matching the filter, soonest due first.
+	GetAll(filter models.RegistrationFilter) ([]models.CabRegistration, error)
+}
+
+// cabRegistrationRepository implements the CabRegistrationRepository interface.
+type cabRegistrationRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewCabRegistrationRepository creates a new instance of cabRegistrationRepository for the default tenant.
+func NewCabRegistrationRepository(db *sql.DB) CabRegistrationRepository {
+	return &cabRegistrationRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const cabRegistrationColumns = `id, sale_id, cab_id, customer_id, status, due_date, submitted_date, released_date, or_number, cr_number, plate_number, notes, updated_by, created_at, updated_at`
+
+// Create stores a new registration.
+func (r *cabRegistrationRepository) Create(registration *models.CabRegistration) error {
+	if registration.ID == "" {
+		registration.ID = uuid.New().String()
+	}
+	now := time.Now()
+	registration.CreatedAt = now
+	registration.UpdatedAt = now
+
+	query := `
+		INSERT INTO cab_registrations (id, tenant_id, sale_id, cab_id, customer_id, status, due_date, submitted_date, released_date,
+			or_number, cr_number, plate_number, notes, updated_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, registration.ID, r.TenantID, registration.SaleID, registration.CabID, registration.CustomerID,
+		registration.Status, registration.DueDate, registration.SubmittedDate, registration.ReleasedDate, registration.ORNumber,
+		registration.CRNumber, registration.PlateNumber, registration.Notes, registration.UpdatedBy, registration.CreatedAt, registration.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create cab registration: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a registration by its ID.
+func (r *cabRegistrationRepository) GetByID(id string) (*models.CabRegistration, error) {
+	query := `SELECT ` + cabRegistrationColumns + ` FROM cab_registrations WHERE id = ? AND tenant_id = ?`
+
+	registration, err := scanCabRegistration(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("cab registration not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get cab registration: %w", err)
+	}
+
+	return registration, nil
+}
+
+// Update saves every editable field of a registration.
+func (r *cabRegistrationRepository) Update(registration *models.CabRegistration) error {
+	registration.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE cab_registrations SET status = ?, due_date = ?, submitted_date = ?, released_date = ?, or_number = ?, cr_number = ?,
+			plate_number = ?, notes = ?, updated_by = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.DB.Exec(query, registration.Status, registration.DueDate, registration.SubmittedDate, registration.ReleasedDate,
+		registration.ORNumber, registration.CRNumber, registration.PlateNumber, registration.Notes, registration.UpdatedBy,
+		registration.UpdatedAt, registration.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update cab registration: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cab registration not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetAll retrieves the registrations matching the filter.
+func (r *cabRegistrationRepository) GetAll(filter models.RegistrationFilter) ([]models.CabRegistration, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{r.TenantID}
+
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status IN (?"+strings.Repeat(", ?", len(filter.Statuses)-1)+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.SaleID != "" {
+		conditions = append(conditions, "sale_id = ?")
+		args = append(args, filter.SaleID)
+	}
+	if filter.CabID != 0 {
+		conditions = append(conditions, "cab_id = ?")
+		args = append(args, filter.CabID)
+	}
+	if filter.DueBefore != "" {
+		conditions = append(conditions, "due_date < ?")
+		args = append(args, filter.DueBefore)
+	}
+
+	query := `SELECT ` + cabRegistrationColumns + ` FROM cab_registrations WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY due_date ASC, created_at ASC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cab registrations: %w", err)
+	}
+	defer rows.Close()
+
+	registrations := []models.CabRegistration{}
+	for rows.Next() {
+		registration, err := scanCabRegistration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cab registration row: %w", err)
+		}
+		registrations = append(registrations, *registration)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cab registration rows: %w", err)
+	}
+
+	return registrations, nil
+}
+
+func scanCabRegistration(row rowScanner) (*models.CabRegistration, error) {
+	var registration models.CabRegistration
+	err := row.Scan(
+		&registration.ID,
+		&registration.SaleID,
+		&registration.CabID,
+		&registration.CustomerID,
+		&registration.Status,
+		&registration.DueDate,
+		&registration.SubmittedDate,
+		&registration.ReleasedDate,
+		&registration.ORNumber,
+		&registration.CRNumber,
+		&registration.PlateNumber,
+		&registration.Notes,
+		&registration.UpdatedBy,
+		&registration.CreatedAt,
+		&registration.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &registration, nil
+}
@@ -0,0 +1,97 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cabRegistrationColumns = []string{"id", "sale_id", "cab_id", "customer_id", "status", "due_date", "submitted_date", "released_date", "or_number", "cr_number", "plate_number", "notes", "updated_by", "created_at", "updated_at"}
+
+const cabRegistrationSelect = "SELECT id, sale_id, cab_id, customer_id, status, due_date, submitted_date, released_date, or_number, cr_number, plate_number, notes, updated_by, created_at, updated_at FROM cab_registrations"
+
+func newMockCabRegistrationRepo(t *testing.T) (repositories.CabRegistrationRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewCabRegistrationRepository(db), mock
+}
+
+func TestCreateCabRegistration(t *testing.T) {
+	repo, mock := newMockCabRegistrationRepo(t)
+	mock.ExpectExec(`
+		INSERT INTO cab_registrations (id, tenant_id, sale_id, cab_id, customer_id, status, due_date, submitted_date, released_date,
+			or_number, cr_number, plate_number, notes, updated_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "sale-1", 7, "customer-1", models.RegistrationPending, "2026-11-15", "", "",
+			"", "", "", "", "staff-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	registration := &models.CabRegistration{SaleID: "sale-1", CabID: 7, CustomerID: "customer-1", Status: models.RegistrationPending, DueDate: "2026-11-15", UpdatedBy: "staff-1"}
+	require.NoError(t, repo.Create(registration))
+
+	assert.NotEmpty(t, registration.ID)
+	assert.False(t, registration.CreatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllCabRegistrationsFilters(t *testing.T) {
+	repo, mock := newMockCabRegistrationRepo(t)
+	now := time.Now()
+
+	query := cabRegistrationSelect + " WHERE tenant_id = ? AND status IN (?, ?) AND sale_id = ? AND cab_id = ? AND due_date < ?" +
+		" ORDER BY due_date ASC, created_at ASC"
+	rows := sqlmock.NewRows(cabRegistrationColumns).
+		AddRow("reg-1", "sale-1", 7, "customer-1", models.RegistrationSubmitted, "2026-10-10", "2026-10-01", "", "", "", "", "", "staff-1", now, now)
+	mock.ExpectQuery(query).
+		WithArgs(models.DefaultTenantID, models.RegistrationPending, models.RegistrationSubmitted, "sale-1", 7, "2026-10-16").
+		WillReturnRows(rows)
+
+	registrations, err := repo.GetAll(models.RegistrationFilter{
+		Statuses:  []string{models.RegistrationPending, models.RegistrationSubmitted},
+		SaleID:    "sale-1",
+		CabID:     7,
+		DueBefore: "2026-10-16",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, registrations, 1)
+	assert.Equal(t, "2026-10-01", registrations[0].SubmittedDate)
+	assert.True(t, registrations[0].Overdue("2026-10-16"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCabRegistrationByIDNotFound(t *testing.T) {
+	repo, mock := newMockCabRegistrationRepo(t)
+	mock.ExpectQuery(cabRegistrationSelect+" WHERE id = ? AND tenant_id = ?").
+		WithArgs("missing", models.DefaultTenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	registration, err := repo.GetByID("missing")
+
+	assert.Nil(t, registration)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateCabRegistrationNotFound(t *testing.T) {
+	repo, mock := newMockCabRegistrationRepo(t)
+	mock.ExpectExec(`
+		UPDATE cab_registrations SET status = ?, due_date = ?, submitted_date = ?, released_date = ?, or_number = ?, cr_number = ?,
+			plate_number = ?, notes = ?, updated_by = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Update(&models.CabRegistration{ID: "missing", Status: models.RegistrationPending, DueDate: "2026-11-15"})
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
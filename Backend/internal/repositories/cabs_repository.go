@@ -1,64 +1,76 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"oop/internal/models"
 	"oop/internal/config"
+	"slices"
 	"strings"
 	"time"
 )
 
 // CabsRepository defines the interface for cab data operations.
 type CabsRepository interface {
-	GetCabs(filters map[string]interface{}) ([]models.MultiCab, error)
-	GetCabByID(id int) (*models.MultiCab, error)
-	AddCab(cab models.MultiCab) (*models.MultiCab, error)
-	UpdateCab(id int, cab models.MultiCab) (*models.MultiCab, error)
-	DeleteCab(id int) error
+	// GetCabs returns the cabs matching the filter, newest first unless sorted by
+	// one of models.CabSortFields. A positive limit returns that many cabs, skipping
+	// the offset.
+	GetCabs(ctx context.Context, filter models.CabFilter) ([]models.MultiCab, error)
+	// CountCabs returns how many cabs match the filter, ignoring sorting and paging.
+	CountCabs(ctx context.Context, filter models.CabFilter) (int64, error)
+	GetCabByID(ctx context.Context, id int) (*models.MultiCab, error)
+	AddCab(ctx context.Context, cab models.MultiCab) (*models.MultiCab, error)
+	UpdateCab(ctx context.Context, id int, cab models.MultiCab) (*models.MultiCab, error)
+	// DeleteCab soft-deletes a cab: it is hidden from every lookup but kept for the
+	// sales that reference it.
+	DeleteCab(ctx context.Context, id int) error
+	// RestoreCab brings back a deleted cab, or returns an error wrapping sql.ErrNoRows.
+	RestoreCab(ctx context.Context, id int) error
+	// GetDeletedCabs returns the deleted cabs with when they were deleted, most recently
+	// deleted first.
+	GetDeletedCabs(ctx context.Context) ([]models.MultiCab, error)
+	// ApplyBatch creates, updates and deletes cabs in one transaction, all or none.
+	// Created cabs get their ID, and created and updated ones their timestamps; the
+	// change that failed is reported as a *BatchError.
+	ApplyBatch(ctx context.Context, changes []models.BatchChange[models.MultiCab]) error
 }
 
 // cabsRepository is a database implementation of CabsRepository.
 type cabsRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
 }
 
-// NewCabsRepository creates a new instance of the database repository.
+// NewCabsRepository creates a new instance of the database repository for the default tenant.
 func NewCabsRepository(db *sql.DB) CabsRepository {
-	return &cabsRepository{DB: db}
+	return &cabsRepository{DB: db, TenantID: models.DefaultTenantID}
 }
 
 // GetCabs retrieves a list of cabs, applying filters if provided.
-func (r *cabsRepository) GetCabs(filters map[string]interface{}) ([]models.MultiCab, error) {
-	query := `SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1`
-	var args []interface{}
-
-	// Apply filters to query
-	if makeFilter, ok := filters["make"].(string); ok && makeFilter != "" {
-		query += " AND make = ?"
-		args = append(args, makeFilter)
-	}
-
-	if colorFilter, ok := filters["unit_color"].(string); ok && colorFilter != "" {
-		query += " AND unit_color = ?"
-		args = append(args, colorFilter)
-	}
-
-	if statusFilter, ok := filters["status"].(string); ok && statusFilter != "" {
-		query += " AND status = ?"
-		args = append(args, statusFilter)
+func (r *cabsRepository) GetCabs(ctx context.Context, filter models.CabFilter) ([]models.MultiCab, error) {
+	where, args := r.cabsFilter(filter)
+	query := `SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs` + where
+
+	// Newest first unless asked otherwise; ties are broken by ID so pages do not overlap.
+	// Only known column names are put in the query.
+	if slices.Contains(models.CabSortFields, filter.SortBy) {
+		dir := "ASC"
+		if strings.EqualFold(filter.SortDir, "desc") {
+			dir = "DESC"
+		}
+		query += " ORDER BY " + filter.SortBy + " " + dir + ", id " + dir
+	} else {
+		query += " ORDER BY created_at DESC"
 	}
 
-	if searchFilter, ok := filters["search"].(string); ok && searchFilter != "" {
-		query += " AND (name LIKE ? OR make LIKE ?)"
-		searchTerm := "%" + searchFilter + "%"
-		args = append(args, searchTerm, searchTerm)
+	if filter.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, filter.Limit, max(filter.Offset, 0))
 	}
 
-	query += " ORDER BY created_at DESC"
-
-	rows, err := r.DB.Query(query, args...)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Printf("Error querying cabs: %v\nQuery: %s\nArgs: %v", err, query, args)
 		return nil, err
@@ -70,6 +82,7 @@ func (r *cabsRepository) GetCabs(filters map[string]interface{}) ([]models.Multi
 		var cab models.MultiCab
 		var createdAt, updatedAt time.Time
 		var imageSQL sql.NullString
+		var minPrice, maxPrice sql.NullFloat64
 
 		if err := rows.Scan(
 			&cab.ID,
@@ -77,6 +90,8 @@ func (r *cabsRepository) GetCabs(filters map[string]interface{}) ([]models.Multi
 			&cab.Make,
 			&cab.Quantity,
 			&cab.Price,
+			&minPrice,
+			&maxPrice,
 			&cab.Status,
 			&cab.UnitColor,
 			&imageSQL,
@@ -89,6 +104,7 @@ func (r *cabsRepository) GetCabs(filters map[string]interface{}) ([]models.Multi
 
 		cab.CreatedAt = createdAt
 		cab.UpdatedAt = updatedAt
+		cab.MinPrice, cab.MaxPrice = nullPrice(minPrice), nullPrice(maxPrice)
 		
 		// Handle NULL image values with default image
 		if imageSQL.Valid && imageSQL.String != "" {
@@ -108,14 +124,51 @@ func (r *cabsRepository) GetCabs(filters map[string]interface{}) ([]models.Multi
 	return cabs, nil
 }
 
+// CountCabs counts the cabs matching the filter.
+func (r *cabsRepository) CountCabs(ctx context.Context, filter models.CabFilter) (int64, error) {
+	where, args := r.cabsFilter(filter)
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM multicabs`+where, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("failed to count cabs: %w", err)
+	}
+	return total, nil
+}
+
+// cabsFilter returns the WHERE clause and its arguments for the make, unit color,
+// status and search of a cab filter. Filter values only reach the query as arguments.
+func (r *cabsRepository) cabsFilter(filter models.CabFilter) (string, []interface{}) {
+	conditions := []string{"tenant_id = ?", "deleted_at IS NULL"}
+	args := []interface{}{r.TenantID}
+
+	if filter.Make != "" {
+		conditions = append(conditions, "make = ?")
+		args = append(args, filter.Make)
+	}
+	if filter.UnitColor != "" {
+		conditions = append(conditions, "unit_color = ?")
+		args = append(args, filter.UnitColor)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, "(name LIKE ? OR make LIKE ?)")
+		searchTerm := "%" + filter.Search + "%"
+		args = append(args, searchTerm, searchTerm)
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
 // GetCabByID retrieves a single cab by its ID.
-func (r *cabsRepository) GetCabByID(id int) (*models.MultiCab, error) {
-	query := `SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = ?`
-	row := r.DB.QueryRow(query, id)
+func (r *cabsRepository) GetCabByID(ctx context.Context, id int) (*models.MultiCab, error) {
+	query := `SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+	row := r.DB.QueryRowContext(ctx, query, id, r.TenantID)
 
 	var cab models.MultiCab
 	var createdAt, updatedAt time.Time
 	var imageSQL sql.NullString
+	var minPrice, maxPrice sql.NullFloat64
 
 	if err := row.Scan(
 		&cab.ID,
@@ -123,6 +176,8 @@ func (r *cabsRepository) GetCabByID(id int) (*models.MultiCab, error) {
 		&cab.Make,
 		&cab.Quantity,
 		&cab.Price,
+		&minPrice,
+		&maxPrice,
 		&cab.Status,
 		&cab.UnitColor,
 		&imageSQL,
@@ -138,6 +193,7 @@ func (r *cabsRepository) GetCabByID(id int) (*models.MultiCab, error) {
 
 	cab.CreatedAt = createdAt
 	cab.UpdatedAt = updatedAt
+	cab.MinPrice, cab.MaxPrice = nullPrice(minPrice), nullPrice(maxPrice)
 	
 	// Handle NULL image values with default image
 	if imageSQL.Valid && imageSQL.String != "" {
@@ -150,16 +206,20 @@ func (r *cabsRepository) GetCabByID(id int) (*models.MultiCab, error) {
 }
 
 // AddCab adds a new cab to the repository.
-func (r *cabsRepository) AddCab(cab models.MultiCab) (*models.MultiCab, error) {
+func (r *cabsRepository) AddCab(ctx context.Context, cab models.MultiCab) (*models.MultiCab, error) {
 	// Basic validation
 	if cab.Name == "" || cab.Make == "" || cab.UnitColor == "" {
 		return nil, fmt.Errorf("cab name, make, and color cannot be empty")
 	}
+	if cab.Quantity < 0 {
+		return nil, negativeQuantity("cab", cab.Quantity)
+	}
 
-	query := `INSERT INTO multicabs (name, make, quantity, price, status, unit_color, image, created_at, updated_at) 
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO multicabs (tenant_id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at) 
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	now := time.Now()
+	cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
 	
 	var imageValue interface{}
 	if cab.Image == "" || cab.Image == config.DefaultImageURL {
@@ -168,12 +228,15 @@ func (r *cabsRepository) AddCab(cab models.MultiCab) (*models.MultiCab, error) {
 		imageValue = cab.Image
 	}
 	
-	result, err := r.DB.Exec(
+	result, err := r.DB.ExecContext(ctx, 
 		query,
+		r.TenantID,
 		cab.Name,
 		cab.Make,
 		cab.Quantity,
 		cab.Price,
+		cab.MinPrice,
+		cab.MaxPrice,
 		cab.Status,
 		cab.UnitColor,
 		imageValue,
@@ -183,7 +246,7 @@ func (r *cabsRepository) AddCab(cab models.MultiCab) (*models.MultiCab, error) {
 
 	if err != nil {
 		log.Printf("Error adding cab: %v", err)
-		return nil, err
+		return nil, stockError(err)
 	}
 
 	// Get the auto-generated ID
@@ -207,9 +270,13 @@ func (r *cabsRepository) AddCab(cab models.MultiCab) (*models.MultiCab, error) {
 }
 
 // UpdateCab updates an existing cab in the repository.
-func (r *cabsRepository) UpdateCab(id int, cab models.MultiCab) (*models.MultiCab, error) {
+func (r *cabsRepository) UpdateCab(ctx context.Context, id int, cab models.MultiCab) (*models.MultiCab, error) {
+	if cab.Quantity < 0 {
+		return nil, negativeQuantity("cab", cab.Quantity)
+	}
+
 	// Check if the cab exists
-	existingCab, err := r.GetCabByID(id)
+	existingCab, err := r.GetCabByID(ctx, id)
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "not found") {
 			return nil, fmt.Errorf("cab with ID %d not found for update", id)
@@ -219,10 +286,11 @@ func (r *cabsRepository) UpdateCab(id int, cab models.MultiCab) (*models.MultiCa
 
 	// Prepare the update query
 	query := `UPDATE multicabs 
-              SET name = ?, make = ?, quantity = ?, price = ?, status = ?, unit_color = ?, image = ?, updated_at = ? 
-              WHERE id = ?`
+              SET name = ?, make = ?, quantity = ?, price = ?, min_price = ?, max_price = ?, status = ?, unit_color = ?, image = ?, updated_at = ? 
+              WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 
 	now := time.Now()
+	cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
 	
 	var imageValue interface{}
 	if cab.Image == "" || cab.Image == config.DefaultImageURL {
@@ -231,22 +299,25 @@ func (r *cabsRepository) UpdateCab(id int, cab models.MultiCab) (*models.MultiCa
 		imageValue = cab.Image
 	}
 	
-	_, err = r.DB.Exec(
+	_, err = r.DB.ExecContext(ctx, 
 		query,
 		cab.Name,
 		cab.Make,
 		cab.Quantity,
 		cab.Price,
+		cab.MinPrice,
+		cab.MaxPrice,
 		cab.Status,
 		cab.UnitColor,
 		imageValue,
 		now,
 		id,
+		r.TenantID,
 	)
 
 	if err != nil {
 		log.Printf("Error updating cab ID %d: %v", id, err)
-		return nil, err
+		return nil, stockError(err)
 	}
 
 	// Update the cab with the current data
@@ -262,23 +333,145 @@ func (r *cabsRepository) UpdateCab(id int, cab models.MultiCab) (*models.MultiCa
 	return &cab, nil
 }
 
-// DeleteCab removes a cab from the repository by its ID.
-func (r *cabsRepository) DeleteCab(id int) error {
-	// Check if the cab exists
-	_, err := r.GetCabByID(id)
+// DeleteCab soft-deletes a cab by its ID, so it can be restored.
+func (r *cabsRepository) DeleteCab(ctx context.Context, id int) error {
+	query := `UPDATE multicabs SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+	result, err := r.DB.ExecContext(ctx, query, time.Now(), id, r.TenantID)
 	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "not found") {
-			return fmt.Errorf("cab with ID %d not found for deletion", id)
-		}
+		log.Printf("Error deleting cab ID %d: %v", id, err)
 		return err
 	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for cab ID %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("cab with ID %d not found for deletion", id)
+	}
+	return nil
+}
+
+// RestoreCab clears the deletion of a cab.
+func (r *cabsRepository) RestoreCab(ctx context.Context, id int) error {
+	query := `UPDATE multicabs SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+	result, err := r.DB.ExecContext(ctx, query, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to restore cab ID %d: %w", id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for cab ID %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted cab with ID %d not found: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
 
-	query := `DELETE FROM multicabs WHERE id = ?`
-	_, err = r.DB.Exec(query, id)
+// GetDeletedCabs retrieves the deleted cabs.
+func (r *cabsRepository) GetDeletedCabs(ctx context.Context) ([]models.MultiCab, error) {
+	query := `SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at, deleted_at
+		FROM multicabs WHERE tenant_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID)
 	if err != nil {
-		log.Printf("Error deleting cab ID %d: %v", id, err)
-		return err
+		return nil, fmt.Errorf("failed to query deleted cabs: %w", err)
+	}
+	defer rows.Close()
+
+	cabs := []models.MultiCab{}
+	for rows.Next() {
+		var cab models.MultiCab
+		var imageSQL sql.NullString
+		var minPrice, maxPrice sql.NullFloat64
+		var deletedAt time.Time
+		if err := rows.Scan(&cab.ID, &cab.Name, &cab.Make, &cab.Quantity, &cab.Price, &minPrice, &maxPrice, &cab.Status,
+			&cab.UnitColor, &imageSQL, &cab.CreatedAt, &cab.UpdatedAt, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted cab: %w", err)
+		}
+		cab.MinPrice, cab.MaxPrice = nullPrice(minPrice), nullPrice(maxPrice)
+		cab.Image = config.DefaultImageURL
+		if imageSQL.Valid && imageSQL.String != "" {
+			cab.Image = imageSQL.String
+		}
+		cab.DeletedAt = &deletedAt
+		cabs = append(cabs, cab)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted cabs: %w", err)
+	}
+	return cabs, nil
+}
+
+// ApplyBatch applies the changes of a cab batch in one transaction, locking each
+// updated cab so the batch cannot overwrite a change made while it runs
+func (r *cabsRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.MultiCab]) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range changes {
+		if err := r.applyChange(ctx, tx, &changes[i], now); err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit cab batch: %w", err)
+	}
+	return nil
+}
+
+// applyChange applies one change of a cab batch within tx
+func (r *cabsRepository) applyChange(ctx context.Context, tx *sql.Tx, change *models.BatchChange[models.MultiCab], now time.Time) error {
+	if change.Op == models.BatchDelete {
+		return deleteBatchItem(ctx, tx, "multicabs", "cab", change.ID, r.TenantID, now)
+	}
+
+	cab := &change.Item
+	if cab.Quantity < 0 {
+		return negativeQuantity("cab", cab.Quantity)
+	}
+	cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
+	if cab.Image == "" {
+		cab.Image = config.DefaultImageURL
+	}
+
+	if change.Op == models.BatchCreate {
+		res, err := tx.ExecContext(ctx, `INSERT INTO multicabs (tenant_id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.TenantID, cab.Name, cab.Make, cab.Quantity, cab.Price, cab.MinPrice, cab.MaxPrice, cab.Status, cab.UnitColor, imageColumn(cab.Image), now, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert cab %q: %w", cab.Name, stockError(err))
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get ID of cab %q: %w", cab.Name, err)
+		}
+		cab.ID, cab.CreatedAt, cab.UpdatedAt = int(id), now, now
+		return nil
+	}
+
+	createdAt, err := lockBatchItem(ctx, tx, "multicabs", "cab", change.ID, r.TenantID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE multicabs
+		SET name = ?, make = ?, quantity = ?, price = ?, min_price = ?, max_price = ?, status = ?, unit_color = ?, image = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?`,
+		cab.Name, cab.Make, cab.Quantity, cab.Price, cab.MinPrice, cab.MaxPrice, cab.Status, cab.UnitColor, imageColumn(cab.Image), now, change.ID, r.TenantID); err != nil {
+		return fmt.Errorf("failed to update cab %d: %w", change.ID, stockError(err))
+	}
+	cab.ID, cab.CreatedAt, cab.UpdatedAt = change.ID, createdAt, now
 	return nil
 }
+
+// nullPrice converts a nullable price column, such as a bound of a price guard
+func nullPrice(price sql.NullFloat64) *float64 {
+	if !price.Valid {
+		return nil
+	}
+	return &price.Float64
+}
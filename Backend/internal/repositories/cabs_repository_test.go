@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"oop/internal/models"
@@ -26,15 +27,15 @@ func TestGetCabs_NoFilters(t *testing.T) {
 		{ID: 2, Name: "911 GT3", Make: "Porsche", Quantity: 2, Price: 15000000, Status: "In Stock", UnitColor: "White", Image: "911.jpg", CreatedAt: now, UpdatedAt: now},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}).
-		AddRow(expectedCabs[0].ID, expectedCabs[0].Name, expectedCabs[0].Make, expectedCabs[0].Quantity, expectedCabs[0].Price, expectedCabs[0].Status, expectedCabs[0].UnitColor, expectedCabs[0].Image, expectedCabs[0].CreatedAt, expectedCabs[0].UpdatedAt).
-		AddRow(expectedCabs[1].ID, expectedCabs[1].Name, expectedCabs[1].Make, expectedCabs[1].Quantity, expectedCabs[1].Price, expectedCabs[1].Status, expectedCabs[1].UnitColor, expectedCabs[1].Image, expectedCabs[1].CreatedAt, expectedCabs[1].UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}).
+		AddRow(expectedCabs[0].ID, expectedCabs[0].Name, expectedCabs[0].Make, expectedCabs[0].Quantity, expectedCabs[0].Price, nil, nil, expectedCabs[0].Status, expectedCabs[0].UnitColor, expectedCabs[0].Image, expectedCabs[0].CreatedAt, expectedCabs[0].UpdatedAt).
+		AddRow(expectedCabs[1].ID, expectedCabs[1].Name, expectedCabs[1].Make, expectedCabs[1].Quantity, expectedCabs[1].Price, nil, nil, expectedCabs[1].Status, expectedCabs[1].UnitColor, expectedCabs[1].Image, expectedCabs[1].CreatedAt, expectedCabs[1].UpdatedAt)
 
 	// Base query without filters
-	query := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 ORDER BY created_at DESC"
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+	query := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 
-	cabs, err := repo.GetCabs(nil)
+	cabs, err := repo.GetCabs(context.Background(), models.CabFilter{})
 	require.NoError(t, err)
 	assert.Equal(t, expectedCabs, cabs)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -52,19 +53,19 @@ func TestGetCabs_WithFilters(t *testing.T) {
 	cabMustang := models.MultiCab{ID: 3, Name: "Mustang", Make: "Ford", Quantity: 5, Price: 5500000, Status: "Available", UnitColor: "Red", Image: "mustang.jpg", CreatedAt: now, UpdatedAt: now}
 	cabRX7 := models.MultiCab{ID: 1, Name: "RX‑7", Make: "Mazda", Quantity: 4, Price: 7000000, Status: "In Stock", UnitColor: "Blue", Image: "rx7.jpg", CreatedAt: now, UpdatedAt: now}
 
-	cols := []string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}
+	cols := []string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}
 
 	// Filter by Make
 	t.Run("Filter by Make", func(t *testing.T) {
 		rowsMake := sqlmock.NewRows(cols).
-			AddRow(cabPorsche911.ID, cabPorsche911.Name, cabPorsche911.Make, cabPorsche911.Quantity, cabPorsche911.Price, cabPorsche911.Status, cabPorsche911.UnitColor, cabPorsche911.Image, cabPorsche911.CreatedAt, cabPorsche911.UpdatedAt).
-			AddRow(cabPorscheCayenne.ID, cabPorscheCayenne.Name, cabPorscheCayenne.Make, cabPorscheCayenne.Quantity, cabPorscheCayenne.Price, cabPorscheCayenne.Status, cabPorscheCayenne.UnitColor, cabPorscheCayenne.Image, cabPorscheCayenne.CreatedAt, cabPorscheCayenne.UpdatedAt)
+			AddRow(cabPorsche911.ID, cabPorsche911.Name, cabPorsche911.Make, cabPorsche911.Quantity, cabPorsche911.Price, nil, nil, cabPorsche911.Status, cabPorsche911.UnitColor, cabPorsche911.Image, cabPorsche911.CreatedAt, cabPorsche911.UpdatedAt).
+			AddRow(cabPorscheCayenne.ID, cabPorscheCayenne.Name, cabPorscheCayenne.Make, cabPorscheCayenne.Quantity, cabPorscheCayenne.Price, nil, nil, cabPorscheCayenne.Status, cabPorscheCayenne.UnitColor, cabPorscheCayenne.Image, cabPorscheCayenne.CreatedAt, cabPorscheCayenne.UpdatedAt)
 
-		queryMake := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND make = \\? ORDER BY created_at DESC"
-		mock.ExpectQuery(queryMake).WithArgs("Porsche").WillReturnRows(rowsMake)
+		queryMake := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND make = \\? ORDER BY created_at DESC"
+		mock.ExpectQuery(queryMake).WithArgs(models.DefaultTenantID, "Porsche").WillReturnRows(rowsMake)
 
-		filtersMake := map[string]interface{}{"make": "Porsche"}
-		cabsMake, errMake := repo.GetCabs(filtersMake)
+		filtersMake := models.CabFilter{Make: "Porsche"}
+		cabsMake, errMake := repo.GetCabs(context.Background(), filtersMake)
 		require.NoError(t, errMake)
 		assert.Len(t, cabsMake, 2)
 		assert.Equal(t, []models.MultiCab{cabPorsche911, cabPorscheCayenne}, cabsMake)
@@ -74,13 +75,13 @@ func TestGetCabs_WithFilters(t *testing.T) {
 	// Filter by Status
 	t.Run("Filter by Status", func(t *testing.T) {
 		rowsStatus := sqlmock.NewRows(cols).
-			AddRow(cabMustang.ID, cabMustang.Name, cabMustang.Make, cabMustang.Quantity, cabMustang.Price, cabMustang.Status, cabMustang.UnitColor, cabMustang.Image, cabMustang.CreatedAt, cabMustang.UpdatedAt)
+			AddRow(cabMustang.ID, cabMustang.Name, cabMustang.Make, cabMustang.Quantity, cabMustang.Price, nil, nil, cabMustang.Status, cabMustang.UnitColor, cabMustang.Image, cabMustang.CreatedAt, cabMustang.UpdatedAt)
 
-		queryStatus := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND status = \\? ORDER BY created_at DESC"
-		mock.ExpectQuery(queryStatus).WithArgs("Available").WillReturnRows(rowsStatus)
+		queryStatus := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND status = \\? ORDER BY created_at DESC"
+		mock.ExpectQuery(queryStatus).WithArgs(models.DefaultTenantID, "Available").WillReturnRows(rowsStatus)
 
-		filtersStatus := map[string]interface{}{"status": "Available"}
-		cabsStatus, errStatus := repo.GetCabs(filtersStatus)
+		filtersStatus := models.CabFilter{Status: "Available"}
+		cabsStatus, errStatus := repo.GetCabs(context.Background(), filtersStatus)
 		require.NoError(t, errStatus)
 		assert.Len(t, cabsStatus, 1)
 		assert.Equal(t, []models.MultiCab{cabMustang}, cabsStatus)
@@ -90,14 +91,14 @@ func TestGetCabs_WithFilters(t *testing.T) {
 	// Filter by Search (Name)
 	t.Run("Filter by Search Name", func(t *testing.T) {
 		rowsSearchName := sqlmock.NewRows(cols).
-			AddRow(cabRX7.ID, cabRX7.Name, cabRX7.Make, cabRX7.Quantity, cabRX7.Price, cabRX7.Status, cabRX7.UnitColor, cabRX7.Image, cabRX7.CreatedAt, cabRX7.UpdatedAt)
+			AddRow(cabRX7.ID, cabRX7.Name, cabRX7.Make, cabRX7.Quantity, cabRX7.Price, nil, nil, cabRX7.Status, cabRX7.UnitColor, cabRX7.Image, cabRX7.CreatedAt, cabRX7.UpdatedAt)
 
-		querySearchName := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND \\(name LIKE \\? OR make LIKE \\?\\) ORDER BY created_at DESC"
+		querySearchName := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND \\(name LIKE \\? OR make LIKE \\?\\) ORDER BY created_at DESC"
 		searchTerm := "%RX%"
-		mock.ExpectQuery(querySearchName).WithArgs(searchTerm, searchTerm).WillReturnRows(rowsSearchName)
+		mock.ExpectQuery(querySearchName).WithArgs(models.DefaultTenantID, searchTerm, searchTerm).WillReturnRows(rowsSearchName)
 
-		filtersSearchName := map[string]interface{}{"search": "RX"}
-		cabsSearchName, errSearchName := repo.GetCabs(filtersSearchName)
+		filtersSearchName := models.CabFilter{Search: "RX"}
+		cabsSearchName, errSearchName := repo.GetCabs(context.Background(), filtersSearchName)
 		require.NoError(t, errSearchName)
 		assert.Len(t, cabsSearchName, 1)
 		assert.Equal(t, []models.MultiCab{cabRX7}, cabsSearchName)
@@ -107,14 +108,14 @@ func TestGetCabs_WithFilters(t *testing.T) {
 	// Filter by Search (Make)
 	t.Run("Filter by Search Make", func(t *testing.T) {
 		rowsSearchMake := sqlmock.NewRows(cols).
-			AddRow(cabMustang.ID, cabMustang.Name, cabMustang.Make, cabMustang.Quantity, cabMustang.Price, cabMustang.Status, cabMustang.UnitColor, cabMustang.Image, cabMustang.CreatedAt, cabMustang.UpdatedAt)
+			AddRow(cabMustang.ID, cabMustang.Name, cabMustang.Make, cabMustang.Quantity, cabMustang.Price, nil, nil, cabMustang.Status, cabMustang.UnitColor, cabMustang.Image, cabMustang.CreatedAt, cabMustang.UpdatedAt)
 
-		querySearchMake := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND \\(name LIKE \\? OR make LIKE \\?\\) ORDER BY created_at DESC"
+		querySearchMake := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND \\(name LIKE \\? OR make LIKE \\?\\) ORDER BY created_at DESC"
 		searchTerm := "%ford%"
-		mock.ExpectQuery(querySearchMake).WithArgs(searchTerm, searchTerm).WillReturnRows(rowsSearchMake)
+		mock.ExpectQuery(querySearchMake).WithArgs(models.DefaultTenantID, searchTerm, searchTerm).WillReturnRows(rowsSearchMake)
 
-		filtersSearchMake := map[string]interface{}{"search": "ford"}
-		cabsSearchMake, errSearchMake := repo.GetCabs(filtersSearchMake)
+		filtersSearchMake := models.CabFilter{Search: "ford"}
+		cabsSearchMake, errSearchMake := repo.GetCabs(context.Background(), filtersSearchMake)
 		require.NoError(t, errSearchMake)
 		assert.Len(t, cabsSearchMake, 1)
 		assert.Equal(t, []models.MultiCab{cabMustang}, cabsSearchMake)
@@ -124,13 +125,13 @@ func TestGetCabs_WithFilters(t *testing.T) {
 	// Combined Filters
 	t.Run("Combined Filters", func(t *testing.T) {
 		rowsCombined := sqlmock.NewRows(cols).
-			AddRow(cabPorsche911.ID, cabPorsche911.Name, cabPorsche911.Make, cabPorsche911.Quantity, cabPorsche911.Price, cabPorsche911.Status, cabPorsche911.UnitColor, cabPorsche911.Image, cabPorsche911.CreatedAt, cabPorsche911.UpdatedAt)
+			AddRow(cabPorsche911.ID, cabPorsche911.Name, cabPorsche911.Make, cabPorsche911.Quantity, cabPorsche911.Price, nil, nil, cabPorsche911.Status, cabPorsche911.UnitColor, cabPorsche911.Image, cabPorsche911.CreatedAt, cabPorsche911.UpdatedAt)
 
-		queryCombined := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND make = \\? AND status = \\? ORDER BY created_at DESC"
-		mock.ExpectQuery(queryCombined).WithArgs("Porsche", "In Stock").WillReturnRows(rowsCombined)
+		queryCombined := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND make = \\? AND status = \\? ORDER BY created_at DESC"
+		mock.ExpectQuery(queryCombined).WithArgs(models.DefaultTenantID, "Porsche", "In Stock").WillReturnRows(rowsCombined)
 
-		filtersCombined := map[string]interface{}{"make": "Porsche", "status": "In Stock"}
-		cabsCombined, errCombined := repo.GetCabs(filtersCombined)
+		filtersCombined := models.CabFilter{Make: "Porsche", Status: "In Stock"}
+		cabsCombined, errCombined := repo.GetCabs(context.Background(), filtersCombined)
 		require.NoError(t, errCombined)
 		assert.Len(t, cabsCombined, 1)
 		assert.Equal(t, []models.MultiCab{cabPorsche911}, cabsCombined)
@@ -141,11 +142,11 @@ func TestGetCabs_WithFilters(t *testing.T) {
 	t.Run("No Results", func(t *testing.T) {
 		rowsNone := sqlmock.NewRows(cols) // No rows added
 
-		queryNone := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND make = \\? ORDER BY created_at DESC"
-		mock.ExpectQuery(queryNone).WithArgs("Ferrari").WillReturnRows(rowsNone)
+		queryNone := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND make = \\? ORDER BY created_at DESC"
+		mock.ExpectQuery(queryNone).WithArgs(models.DefaultTenantID, "Ferrari").WillReturnRows(rowsNone)
 
-		filtersNone := map[string]interface{}{"make": "Ferrari"}
-		cabsNone, errNone := repo.GetCabs(filtersNone)
+		filtersNone := models.CabFilter{Make: "Ferrari"}
+		cabsNone, errNone := repo.GetCabs(context.Background(), filtersNone)
 		require.NoError(t, errNone)
 		assert.Len(t, cabsNone, 0)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -153,17 +154,60 @@ func TestGetCabs_WithFilters(t *testing.T) {
 
 	// Query Error
 	t.Run("Query Error", func(t *testing.T) {
-		queryErr := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE 1=1 AND make = \\? ORDER BY created_at DESC"
-		mock.ExpectQuery(queryErr).WithArgs("ErrorCase").WillReturnError(sql.ErrConnDone)
+		queryErr := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NULL AND make = \\? ORDER BY created_at DESC"
+		mock.ExpectQuery(queryErr).WithArgs(models.DefaultTenantID, "ErrorCase").WillReturnError(sql.ErrConnDone)
 
-		filtersErr := map[string]interface{}{"make": "ErrorCase"}
-		cabsErr, err := repo.GetCabs(filtersErr)
+		filtersErr := models.CabFilter{Make: "ErrorCase"}
+		cabsErr, err := repo.GetCabs(context.Background(), filtersErr)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Nil(t, cabsErr)
 		// No mock.ExpectationsWereMet() here as the mock connection is intentionally "closed"
 	})
 }
 
+func TestGetCabs_SortedPage(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewCabsRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"})
+	query := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = ? AND deleted_at IS NULL AND make = ? ORDER BY price DESC, id DESC LIMIT ? OFFSET ?"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID, "Mazda", 10, 20).WillReturnRows(rows)
+
+	_, err := repo.GetCabs(context.Background(), models.CabFilter{Make: "Mazda", SortBy: "price", SortDir: "desc", Limit: 10, Offset: 20})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCabs_UnknownSortFieldKeepsDefaultOrder(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewCabsRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"})
+	query := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
+
+	_, err := repo.GetCabs(context.Background(), models.CabFilter{SortBy: "price; DROP TABLE multicabs"})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountCabs(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewCabsRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM multicabs WHERE tenant_id = ? AND deleted_at IS NULL AND status = ?")).
+		WithArgs(models.DefaultTenantID, "In Stock").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	total, err := repo.CountCabs(context.Background(), models.CabFilter{Status: "In Stock", Limit: 5, Offset: 5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetCabByID_Exists(t *testing.T) {
 	db, mock := NewMockDB(t)
 	defer db.Close()
@@ -172,14 +216,14 @@ func TestGetCabByID_Exists(t *testing.T) {
 	now := time.Now()
 	expectedCab := &models.MultiCab{ID: 1, Name: "RX‑7", Make: "Mazda", Quantity: 4, Price: 7000000, Status: "In Stock", UnitColor: "Blue", Image: "rx7.jpg", CreatedAt: now, UpdatedAt: now}
 
-	rows := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}).
-		AddRow(expectedCab.ID, expectedCab.Name, expectedCab.Make, expectedCab.Quantity, expectedCab.Price, expectedCab.Status, expectedCab.UnitColor, expectedCab.Image, expectedCab.CreatedAt, expectedCab.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}).
+		AddRow(expectedCab.ID, expectedCab.Name, expectedCab.Make, expectedCab.Quantity, expectedCab.Price, nil, nil, expectedCab.Status, expectedCab.UnitColor, expectedCab.Image, expectedCab.CreatedAt, expectedCab.UpdatedAt)
 
-	query := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\?"
-	mock.ExpectQuery(query).WithArgs(expectedCab.ID).WillReturnRows(rows)
+	query := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\? AND tenant_id = \\?"
+	mock.ExpectQuery(query).WithArgs(expectedCab.ID, models.DefaultTenantID).WillReturnRows(rows)
 
 	id := 1
-	cab, err := repo.GetCabByID(id)
+	cab, err := repo.GetCabByID(context.Background(), id)
 	require.NoError(t, err)
 	require.NotNil(t, cab)
 	assert.Equal(t, expectedCab, cab)
@@ -191,12 +235,12 @@ func TestGetCabByID_NotExists(t *testing.T) {
 	defer db.Close()
 	repo := NewCabsRepository(db)
 
-	query := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\?"
+	query := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\? AND tenant_id = \\?"
 	IDNotFound := 99
-	mock.ExpectQuery(query).WithArgs(IDNotFound).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(query).WithArgs(IDNotFound, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 
 	id := 99
-	cab, err := repo.GetCabByID(id)
+	cab, err := repo.GetCabByID(context.Background(), id)
 	require.Error(t, err, "Should return error for non-existent ID")
 	assert.Nil(t, cab, "Cab should be nil for non-existent ID")
 	// Check error message specific to the DB implementation
@@ -214,17 +258,19 @@ func TestAddCab_Success(t *testing.T) {
 		Make:      "Test Make",
 		Quantity:  10,
 		Price:     500000,
+		MinPrice:  floatPtr(400000),
+		MaxPrice:  floatPtr(0), // Not checked, stored as NULL
 		Status:    "Available",
 		UnitColor: "Green",
 		Image:     "test.jpg",
 	}
 
-	insertQuery := "INSERT INTO multicabs \\(name, make, quantity, price, status, unit_color, image, created_at, updated_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)"
+	insertQuery := "INSERT INTO multicabs \\(tenant_id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)"
 	mock.ExpectExec(insertQuery).
-		WithArgs(newCabData.Name, newCabData.Make, newCabData.Quantity, newCabData.Price, newCabData.Status, newCabData.UnitColor, newCabData.Image, sqlmock.AnyArg(), sqlmock.AnyArg()). // Use AnyArg for timestamps
-		WillReturnResult(sqlmock.NewResult(8, 1))                                                                                                                                         // Expecting ID 8, 1 row affected
+		WithArgs(models.DefaultTenantID, newCabData.Name, newCabData.Make, newCabData.Quantity, newCabData.Price, 400000.0, nil, newCabData.Status, newCabData.UnitColor, newCabData.Image, sqlmock.AnyArg(), sqlmock.AnyArg()). // Use AnyArg for timestamps
+		WillReturnResult(sqlmock.NewResult(8, 1))                                                                                                                                                                                // Expecting ID 8, 1 row affected
 
-	addedCab, err := repo.AddCab(newCabData)
+	addedCab, err := repo.AddCab(context.Background(), newCabData)
 	require.NoError(t, err)
 	require.NotNil(t, addedCab)
 
@@ -235,6 +281,9 @@ func TestAddCab_Success(t *testing.T) {
 	assert.Equal(t, newCabData.Make, addedCab.Make)
 	assert.Equal(t, newCabData.Quantity, addedCab.Quantity)
 	assert.Equal(t, newCabData.Price, addedCab.Price)
+	require.NotNil(t, addedCab.MinPrice)
+	assert.Equal(t, 400000.0, *addedCab.MinPrice)
+	assert.Nil(t, addedCab.MaxPrice)
 	assert.Equal(t, newCabData.Status, addedCab.Status)
 	assert.Equal(t, newCabData.UnitColor, addedCab.UnitColor)
 	assert.Equal(t, newCabData.Image, addedCab.Image)
@@ -258,7 +307,7 @@ func TestAddCab_ValidationError(t *testing.T) {
 		Make:      "Test Make",
 		UnitColor: "Red", // Assuming UnitColor is required by DB repo validation
 	}
-	addedCab, err := repo.AddCab(invalidCabData)
+	addedCab, err := repo.AddCab(context.Background(), invalidCabData)
 	require.Error(t, err, "Should return error for invalid data")
 	assert.Nil(t, addedCab)
 	// Check for the specific validation error message from the repository
@@ -277,10 +326,10 @@ func TestUpdateCab_Success(t *testing.T) {
 	now := time.Now()
 	// We need to mock the initial GetCabByID call within UpdateCab
 	originalCab := &models.MultiCab{ID: idToUpdate, Name: "RX‑7", Make: "Mazda", Quantity: 4, Price: 7000000, Status: "In Stock", UnitColor: "Blue", Image: "rx7.jpg", CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour)}
-	getByIDQuery := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\?"
-	rowsGet := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}).
-		AddRow(originalCab.ID, originalCab.Name, originalCab.Make, originalCab.Quantity, originalCab.Price, originalCab.Status, originalCab.UnitColor, originalCab.Image, originalCab.CreatedAt, originalCab.UpdatedAt)
-	mock.ExpectQuery(getByIDQuery).WithArgs(idToUpdate).WillReturnRows(rowsGet)
+	getByIDQuery := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\? AND tenant_id = \\?"
+	rowsGet := sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at"}).
+		AddRow(originalCab.ID, originalCab.Name, originalCab.Make, originalCab.Quantity, originalCab.Price, nil, nil, originalCab.Status, originalCab.UnitColor, originalCab.Image, originalCab.CreatedAt, originalCab.UpdatedAt)
+	mock.ExpectQuery(getByIDQuery).WithArgs(idToUpdate, models.DefaultTenantID).WillReturnRows(rowsGet)
 
 	updateData := models.MultiCab{
 		Name:      "RX-7 Updated",
@@ -294,12 +343,12 @@ func TestUpdateCab_Success(t *testing.T) {
 	}
 
 	// Mock the UPDATE execution
-	updateQuery := "UPDATE multicabs SET name = \\?, make = \\?, quantity = \\?, price = \\?, status = \\?, unit_color = \\?, image = \\?, updated_at = \\? WHERE id = \\?"
+	updateQuery := "UPDATE multicabs SET name = \\?, make = \\?, quantity = \\?, price = \\?, min_price = \\?, max_price = \\?, status = \\?, unit_color = \\?, image = \\?, updated_at = \\? WHERE id = \\? AND tenant_id = \\?"
 	mock.ExpectExec(updateQuery).
-		WithArgs(updateData.Name, updateData.Make, updateData.Quantity, updateData.Price, updateData.Status, updateData.UnitColor, updateData.Image, sqlmock.AnyArg(), idToUpdate).
+		WithArgs(updateData.Name, updateData.Make, updateData.Quantity, updateData.Price, nil, nil, updateData.Status, updateData.UnitColor, updateData.Image, sqlmock.AnyArg(), idToUpdate, models.DefaultTenantID).
 		WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
 
-	updatedCab, err := repo.UpdateCab(idToUpdate, updateData)
+	updatedCab, err := repo.UpdateCab(context.Background(), idToUpdate, updateData)
 	require.NoError(t, err)
 	require.NotNil(t, updatedCab)
 
@@ -329,11 +378,11 @@ func TestUpdateCab_NotExists(t *testing.T) {
 
 	id := 99
 	// Mock the GetCabByID call which should return not found
-	getByIDQuery := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\?"
-	mock.ExpectQuery(getByIDQuery).WithArgs(id).WillReturnError(sql.ErrNoRows)
+	getByIDQuery := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\? AND tenant_id = \\?"
+	mock.ExpectQuery(getByIDQuery).WithArgs(id, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 
 	updateData := models.MultiCab{Name: "Does not matter"}
-	updatedCab, err := repo.UpdateCab(id, updateData)
+	updatedCab, err := repo.UpdateCab(context.Background(), id, updateData)
 	require.Error(t, err, "Should return error for non-existent ID")
 	assert.Nil(t, updatedCab)
 	// Check error message specific to the DB implementation's update check
@@ -348,25 +397,14 @@ func TestDeleteCab_Success(t *testing.T) {
 
 	idToDelete := 1
 
-	// Mock the GetCabByID check before deletion
-	getByIDQuery := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\\\?"
-	// Return data for all columns expected by GetCabByID's Scan
-	cols := []string{"id", "name", "make", "quantity", "price", "status", "unit_color", "image", "created_at", "updated_at"}
-	rowsGet := sqlmock.NewRows(cols).
-		AddRow(idToDelete, "Dummy Name", "Dummy Make", 0, 0.0, "Dummy Status", "Dummy Color", "dummy.jpg", time.Now(), time.Now()) // Provide dummy values
-	mock.ExpectQuery(getByIDQuery).WithArgs(idToDelete).WillReturnRows(rowsGet)
-
-	// Mock the DELETE execution
-	deleteQuery := "DELETE FROM multicabs WHERE id = \\\\?"
-	mock.ExpectExec(deleteQuery).WithArgs(idToDelete).WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
+	// Deleting a cab only marks it deleted so it can be restored from the trash
+	deleteQuery := "UPDATE multicabs SET deleted_at = \\? WHERE id = \\? AND tenant_id = \\? AND deleted_at IS NULL"
+	mock.ExpectExec(deleteQuery).WithArgs(sqlmock.AnyArg(), idToDelete, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
 
-	// Perform deletion
-	errDelete := repo.DeleteCab(idToDelete)
+	errDelete := repo.DeleteCab(context.Background(), idToDelete)
 	require.NoError(t, errDelete, "Should successfully delete the cab")
 
 	assert.NoError(t, mock.ExpectationsWereMet())
-
-	// Verification of deletion would require separate mock expectations if needed.
 }
 
 func TestDeleteCab_NotExists(t *testing.T) {
@@ -375,16 +413,53 @@ func TestDeleteCab_NotExists(t *testing.T) {
 	repo := NewCabsRepository(db)
 
 	id := 99
-	// Mock the GetCabByID check which should return not found
-	getByIDQuery := "SELECT id, name, make, quantity, price, status, unit_color, image, created_at, updated_at FROM multicabs WHERE id = \\?"
-	mock.ExpectQuery(getByIDQuery).WithArgs(id).WillReturnError(sql.ErrNoRows)
-
-	// DELETE query should not be executed if GetByID fails
+	deleteQuery := "UPDATE multicabs SET deleted_at = \\? WHERE id = \\? AND tenant_id = \\? AND deleted_at IS NULL"
+	mock.ExpectExec(deleteQuery).WithArgs(sqlmock.AnyArg(), id, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
 
-	err := repo.DeleteCab(id)
+	err := repo.DeleteCab(context.Background(), id)
 	require.Error(t, err, "Should return error for non-existent ID")
-	// Check error message specific to the DB implementation's delete check
 	assert.Contains(t, err.Error(), fmt.Sprintf("cab with ID %d not found for deletion", id))
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestRestoreCab(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewCabsRepository(db)
+
+	restoreQuery := "UPDATE multicabs SET deleted_at = NULL, deleted_by = NULL WHERE id = \\? AND tenant_id = \\? AND deleted_at IS NOT NULL"
+	mock.ExpectExec(restoreQuery).WithArgs(1, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.RestoreCab(context.Background(), 1))
+
+	mock.ExpectExec(restoreQuery).WithArgs(2, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	err := repo.RestoreCab(context.Background(), 2)
+	assert.ErrorIs(t, err, sql.ErrNoRows, "a cab that is not deleted cannot be restored")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDeletedCabs(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewCabsRepository(db)
+
+	deletedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	query := "SELECT id, name, make, quantity, price, min_price, max_price, status, unit_color, image, created_at, updated_at, deleted_at\\s+FROM multicabs WHERE tenant_id = \\? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id"
+	cols := []string{"id", "name", "make", "quantity", "price", "min_price", "max_price", "status", "unit_color", "image", "created_at", "updated_at", "deleted_at"}
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID).WillReturnRows(sqlmock.NewRows(cols).
+		AddRow(3, "Scrapped", "Suzuki", 0, 1000.0, nil, nil, "Out of Stock", "Red", "", time.Now(), time.Now(), deletedAt))
+
+	cabs, err := repo.GetDeletedCabs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, cabs, 1)
+	assert.Equal(t, "Scrapped", cabs[0].Name)
+	require.NotNil(t, cabs[0].DeletedAt)
+	assert.True(t, cabs[0].DeletedAt.Equal(deletedAt))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
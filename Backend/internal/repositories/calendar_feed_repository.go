@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// CalendarFeedRepository defines the interface for the iCal feed subscriptions of users.
+type CalendarFeedRepository interface {
+	// GetByUser returns the user's feed, or an error wrapping sql.ErrNoRows when they have none.
+	GetByUser(userID string) (*models.CalendarFeed, error)
+	// GetByTokenHash returns the feed with the token hash, or an error wrapping sql.ErrNoRows.
+	GetByTokenHash(tokenHash string) (*models.CalendarFeed, error)
+	// Save replaces the user's feed, so the token of the previous one stops working.
+	Save(feed *models.CalendarFeed) error
+	// RecordFetch stores when the user's feed was last fetched.
+	RecordFetch(userID string, at time.Time) error
+	// Delete removes the user's feed.
+	Delete(userID string) error
+}
+
+// calendarFeedRepository implements the CalendarFeedRepository interface.
+type calendarFeedRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewCalendarFeedRepository creates a new instance of calendarFeedRepository for the default tenant.
+func NewCalendarFeedRepository(db *sql.DB) CalendarFeedRepository {
+	return &calendarFeedRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const calendarFeedColumns = `user_id, token_hash, created_at, last_fetched_at`
+
+// GetByUser retrieves the user's feed.
+func (r *calendarFeedRepository) GetByUser(userID string) (*models.CalendarFeed, error) {
+	query := `SELECT ` + calendarFeedColumns + ` FROM calendar_feeds WHERE tenant_id = ? AND user_id = ?`
+	return r.get(query, r.TenantID, userID)
+}
+
+// GetByTokenHash retrieves the feed a token belongs to.
+func (r *calendarFeedRepository) GetByTokenHash(tokenHash string) (*models.CalendarFeed, error) {
+	query := `SELECT ` + calendarFeedColumns + ` FROM calendar_feeds WHERE tenant_id = ? AND token_hash = ?`
+	return r.get(query, r.TenantID, tokenHash)
+}
+
+func (r *calendarFeedRepository) get(query string, args ...interface{}) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	var lastFetchedAt sql.NullTime
+	err := r.DB.QueryRow(query, args...).Scan(&feed.UserID, &feed.TokenHash, &feed.CreatedAt, &lastFetchedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("calendar feed not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+	if lastFetchedAt.Valid {
+		feed.LastFetchedAt = &lastFetchedAt.Time
+	}
+	return &feed, nil
+}
+
+// Save stores the feed, replacing the user's previous one.
+func (r *calendarFeedRepository) Save(feed *models.CalendarFeed) error {
+	query := `
+		INSERT INTO calendar_feeds (tenant_id, user_id, token_hash, created_at, last_fetched_at)
+		VALUES (?, ?, ?, ?, NULL)
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_at = VALUES(created_at), last_fetched_at = NULL
+	`
+	if _, err := r.DB.Exec(query, r.TenantID, feed.UserID, feed.TokenHash, feed.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+	return nil
+}
+
+// RecordFetch stores when the feed was last fetched.
+func (r *calendarFeedRepository) RecordFetch(userID string, at time.Time) error {
+	query := `UPDATE calendar_feeds SET last_fetched_at = ? WHERE tenant_id = ? AND user_id = ?`
+	if _, err := r.DB.Exec(query, at, r.TenantID, userID); err != nil {
+		return fmt.Errorf("failed to record calendar feed fetch: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the user's feed.
+func (r *calendarFeedRepository) Delete(userID string) error {
+	query := `DELETE FROM calendar_feeds WHERE tenant_id = ? AND user_id = ?`
+	result, err := r.DB.Exec(query, r.TenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected == 0 {
+		return fmt.Errorf("calendar feed not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
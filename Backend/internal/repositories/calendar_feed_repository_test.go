@@ -0,0 +1,68 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockCalendarFeedRepo(t *testing.T) (repositories.CalendarFeedRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewCalendarFeedRepository(db), mock
+}
+
+func TestGetCalendarFeedByTokenHash(t *testing.T) {
+	repo, mock := newMockCalendarFeedRepo(t)
+	createdAt := time.Now()
+	mock.ExpectQuery(`SELECT user_id, token_hash, created_at, last_fetched_at FROM calendar_feeds WHERE tenant_id = ? AND token_hash = ?`).
+		WithArgs(models.DefaultTenantID, "hash-1").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "token_hash", "created_at", "last_fetched_at"}).
+			AddRow("staff-1", "hash-1", createdAt, nil))
+	mock.ExpectQuery(`SELECT user_id, token_hash, created_at, last_fetched_at FROM calendar_feeds WHERE tenant_id = ? AND user_id = ?`).
+		WithArgs(models.DefaultTenantID, "staff-2").WillReturnError(sql.ErrNoRows)
+
+	feed, err := repo.GetByTokenHash("hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "staff-1", feed.UserID)
+	assert.Nil(t, feed.LastFetchedAt, "never fetched")
+
+	_, err = repo.GetByUser("staff-2")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveCalendarFeed(t *testing.T) {
+	repo, mock := newMockCalendarFeedRepo(t)
+	createdAt := time.Now()
+	mock.ExpectExec(`
+		INSERT INTO calendar_feeds (tenant_id, user_id, token_hash, created_at, last_fetched_at)
+		VALUES (?, ?, ?, ?, NULL)
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash), created_at = VALUES(created_at), last_fetched_at = NULL
+	`).WithArgs(models.DefaultTenantID, "staff-1", "hash-1", createdAt).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE calendar_feeds SET last_fetched_at = ? WHERE tenant_id = ? AND user_id = ?`).
+		WithArgs(createdAt, models.DefaultTenantID, "staff-1").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Save(&models.CalendarFeed{UserID: "staff-1", TokenHash: "hash-1", CreatedAt: createdAt}))
+	require.NoError(t, repo.RecordFetch("staff-1", createdAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCalendarFeedNotFound(t *testing.T) {
+	repo, mock := newMockCalendarFeedRepo(t)
+	mock.ExpectExec(`DELETE FROM calendar_feeds WHERE tenant_id = ? AND user_id = ?`).WithArgs(models.DefaultTenantID, "staff-1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete("staff-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ChangeRequestRepository defines the interface for proposed edits of cabs,
+// accessories and materials awaiting review.
+type ChangeRequestRepository interface {
+	// Create stores a new pending change request.
+	Create(request *models.ChangeRequest) error
+	GetByID(id string) (*models.ChangeRequest, error)
+	// GetAll returns the requests matching the filter, most recently requested first.
+	GetAll(filter models.ChangeRequestFilter) ([]models.ChangeRequest, error)
+	// Review marks a pending request applied or rejected, or returns an error wrapping
+	// sql.ErrNoRows when it is not pending, so a request is only reviewed once.
+	Review(id, status, reviewedBy, note string) (*models.ChangeRequest, error)
+}
+
+// changeRequestRepository implements the ChangeRequestRepository interface.
+type changeRequestRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewChangeRequestRepository creates a new instance of changeRequestRepository for the default tenant.
+func NewChangeRequestRepository(db *sql.DB) ChangeRequestRepository {
+	return &changeRequestRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const changeRequestColumns = `id, entity_type, entity_id, entity_name, changes, reason, status, requested_by, requested_at, reviewed_by, reviewed_at, note`
+
+// Create stores a new pending change request.
+func (r *changeRequestRepository) Create(request *models.ChangeRequest) error {
+	if request.ID == "" {
+		request.ID = uuid.New().String()
+	}
+	request.Status = models.ChangeRequestPending
+	request.RequestedAt = time.Now()
+
+	changes, err := json.Marshal(request.Changes)
+	if err != nil {
+		return fmt.Errorf("could not encode change request changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO change_requests (id, tenant_id, entity_type, entity_id, entity_name, changes, reason, status, requested_by, requested_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.DB.Exec(query, request.ID, r.TenantID, request.EntityType, request.EntityID, request.EntityName,
+		string(changes), request.Reason, request.Status, request.RequestedBy, request.RequestedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create change request: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a change request by its ID.
+func (r *changeRequestRepository) GetByID(id string) (*models.ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM change_requests WHERE id = ? AND tenant_id = ?`
+
+	request, err := scanChangeRequest(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("change request not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get change request: %w", err)
+	}
+	return request, nil
+}
+
+// GetAll retrieves the change requests matching the filter.
+func (r *changeRequestRepository) GetAll(filter models.ChangeRequestFilter) ([]models.ChangeRequest, error) {
+	query := `SELECT ` + changeRequestColumns + ` FROM change_requests WHERE tenant_id = ?`
+	args := []interface{}{r.TenantID}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	if filter.RequestedBy != "" {
+		query += ` AND requested_by = ?`
+		args = append(args, filter.RequestedBy)
+	}
+	query += ` ORDER BY requested_at DESC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []models.ChangeRequest{}
+	for rows.Next() {
+		request, err := scanChangeRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan change request row: %w", err)
+		}
+		requests = append(requests, *request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating change request rows: %w", err)
+	}
+	return requests, nil
+}
+
+// Review marks a request that is still pending applied or rejected.
+func (r *changeRequestRepository) Review(id, status, reviewedBy, note string) (*models.ChangeRequest, error) {
+	query := `UPDATE change_requests SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`
+	result, err := r.DB.Exec(query, status, reviewedBy, time.Now(), note, id, r.TenantID, models.ChangeRequestPending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review change request: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("pending change request not found: %w", sql.ErrNoRows)
+	}
+	return r.GetByID(id)
+}
+
+func scanChangeRequest(row rowScanner) (*models.ChangeRequest, error) {
+	var request models.ChangeRequest
+	var changes string
+	var reason, reviewedBy, note sql.NullString
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&request.ID,
+		&request.EntityType,
+		&request.EntityID,
+		&request.EntityName,
+		&changes,
+		&reason,
+		&request.Status,
+		&request.RequestedBy,
+		&request.RequestedAt,
+		&reviewedBy,
+		&reviewedAt,
+		&note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(changes), &request.Changes); err != nil {
+		return nil, fmt.Errorf("could not decode change request changes: %w", err)
+	}
+	request.Reason, request.ReviewedBy, request.Note = reason.String, reviewedBy.String, note.String
+	if reviewedAt.Valid {
+		request.ReviewedAt = &reviewedAt.Time
+	}
+	return &request, nil
+}
@@ -0,0 +1,87 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var changeRequestColumns = []string{"id", "entity_type", "entity_id", "entity_name", "changes", "reason", "status",
+	"requested_by", "requested_at", "reviewed_by", "reviewed_at", "note"}
+
+func newMockChangeRequestRepo(t *testing.T) (repositories.ChangeRequestRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewChangeRequestRepository(db), mock
+}
+
+func TestCreateChangeRequest(t *testing.T) {
+	repo, mock := newMockChangeRequestRepo(t)
+	mock.ExpectExec(`
+		INSERT INTO change_requests (id, tenant_id, entity_type, entity_id, entity_name, changes, reason, status, requested_by, requested_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "material", 2, "Plywood", `[{"field":"quantity","before":10,"after":12}]`,
+		"Recount", "pending", "staff-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	request := &models.ChangeRequest{EntityType: "material", EntityID: 2, EntityName: "Plywood", Reason: "Recount", RequestedBy: "staff-1",
+		Changes: []models.FieldChange{{Field: "quantity", Before: 10, After: 12}}}
+	require.NoError(t, repo.Create(request))
+	assert.NotEmpty(t, request.ID)
+	assert.Equal(t, models.ChangeRequestPending, request.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllChangeRequests(t *testing.T) {
+	repo, mock := newMockChangeRequestRepo(t)
+	requestedAt := time.Now()
+	mock.ExpectQuery(`SELECT id, entity_type, entity_id, entity_name, changes, reason, status, requested_by, requested_at, reviewed_by, reviewed_at, note FROM change_requests WHERE tenant_id = ? AND status = ? AND requested_by = ? ORDER BY requested_at DESC`).
+		WithArgs(models.DefaultTenantID, "pending", "staff-1").
+		WillReturnRows(sqlmock.NewRows(changeRequestColumns).
+			AddRow("request-1", "cab", 1, "RX-7", `[{"field":"status","before":"Available","after":"Reserved"}]`, nil, "pending", "staff-1", requestedAt, nil, nil, nil))
+
+	requests, err := repo.GetAll(models.ChangeRequestFilter{Status: models.ChangeRequestPending, RequestedBy: "staff-1"})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].Changes, 1)
+	assert.Equal(t, "status", requests[0].Changes[0].Field)
+	assert.Equal(t, "Reserved", requests[0].Changes[0].After)
+	assert.Empty(t, requests[0].Reason)
+	assert.Nil(t, requests[0].ReviewedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewChangeRequest(t *testing.T) {
+	repo, mock := newMockChangeRequestRepo(t)
+	reviewedAt := time.Now()
+	mock.ExpectExec(`UPDATE change_requests SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`).
+		WithArgs("applied", "admin-1", sqlmock.AnyArg(), "", "request-1", models.DefaultTenantID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, entity_type, entity_id, entity_name, changes, reason, status, requested_by, requested_at, reviewed_by, reviewed_at, note FROM change_requests WHERE id = ? AND tenant_id = ?`).
+		WithArgs("request-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows(changeRequestColumns).
+			AddRow("request-1", "cab", 1, "RX-7", `[]`, "Sold out", "applied", "staff-1", reviewedAt, "admin-1", reviewedAt, nil))
+	mock.ExpectExec(`UPDATE change_requests SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`).
+		WithArgs("rejected", "admin-2", sqlmock.AnyArg(), "", "request-1", models.DefaultTenantID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	request, err := repo.Review("request-1", models.ChangeRequestApplied, "admin-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeRequestApplied, request.Status)
+	require.NotNil(t, request.ReviewedAt)
+
+	_, err = repo.Review("request-1", models.ChangeRequestRejected, "admin-2", "")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "a request is reviewed once")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
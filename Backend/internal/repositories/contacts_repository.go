@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -12,26 +13,33 @@ import (
 
 // CustomerRepository defines the interface for customer data operations.
 type CustomerRepository interface {
-	CreateCustomer(customer *models.Customer) (*models.Customer, error)
-	GetCustomerByID(id string) (*models.Customer, error)
-	GetAllCustomers() ([]*models.Customer, error)
-	UpdateCustomer(customer *models.Customer) (*models.Customer, error)
-	DeleteCustomer(id string) error
-	GetCustomerByEmail(email string) (*models.Customer, error)
+	CreateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error)
+	GetCustomerByID(ctx context.Context, id string) (*models.Customer, error)
+	GetAllCustomers(ctx context.Context) ([]*models.Customer, error)
+	// GetPaginated returns one page of the customers whose name, email or phone
+	// contains the search term (all of them when it is empty), newest first, along
+	// with the total number of matches. Pages start at 1.
+	GetPaginated(ctx context.Context, page, limit int, search string) ([]*models.Customer, int64, error)
+	UpdateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error)
+	DeleteCustomer(ctx context.Context, id string) error
+	// RestoreCustomer brings back a deleted customer.
+	RestoreCustomer(ctx context.Context, id string) error
+	GetCustomerByEmail(ctx context.Context, email string) (*models.Customer, error)
 }
 
 // customerRepository implements the CustomerRepository interface.
 type customerRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
 }
 
-// NewCustomerRepository creates a new instance of customerRepository.
+// NewCustomerRepository creates a new instance of customerRepository for the default tenant.
 func NewCustomerRepository(db *sql.DB) CustomerRepository {
-	return &customerRepository{DB: db}
+	return &customerRepository{DB: db, TenantID: models.DefaultTenantID}
 }
 
 // CreateCustomer adds a new customer to the database.
-func (r *customerRepository) CreateCustomer(customer *models.Customer) (*models.Customer, error) {
+func (r *customerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
 	if customer.ID == "" {
 		customer.ID = uuid.New().String()
 	}
@@ -42,16 +50,23 @@ func (r *customerRepository) CreateCustomer(customer *models.Customer) (*models.
 	customer.UpdatedAt = now
 
 	query := `
-		INSERT INTO customers (id, full_name, email, phone, address, date_registered, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO customers (id, tenant_id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := r.DB.Exec(
+	_, err := r.DB.ExecContext(ctx,
 		query,
 		customer.ID,
+		r.TenantID,
 		customer.FullName,
 		customer.Email,
 		customer.Phone,
 		customer.Address,
+		customer.Province,
+		customer.City,
+		customer.Barangay,
+		customer.Latitude,
+		customer.Longitude,
+		customer.DateOfBirth,
 		customer.DateRegistered,
 		customer.CreatedAt,
 		customer.UpdatedAt,
@@ -65,13 +80,13 @@ func (r *customerRepository) CreateCustomer(customer *models.Customer) (*models.
 }
 
 // GetCustomerByID retrieves a customer by their ID.
-func (r *customerRepository) GetCustomerByID(id string) (*models.Customer, error) {
+func (r *customerRepository) GetCustomerByID(ctx context.Context, id string) (*models.Customer, error) {
 	query := `
-		SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at
+		SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at
 		FROM customers
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
 	`
-	row := r.DB.QueryRow(query, id)
+	row := r.DB.QueryRowContext(ctx, query, id, r.TenantID)
 
 	var customer models.Customer
 	err := row.Scan(
@@ -80,6 +95,12 @@ func (r *customerRepository) GetCustomerByID(id string) (*models.Customer, error
 		&customer.Email,
 		&customer.Phone,
 		&customer.Address,
+		&customer.Province,
+		&customer.City,
+		&customer.Barangay,
+		&customer.Latitude,
+		&customer.Longitude,
+		&customer.DateOfBirth,
 		&customer.DateRegistered,
 		&customer.CreatedAt,
 		&customer.UpdatedAt,
@@ -96,13 +117,13 @@ func (r *customerRepository) GetCustomerByID(id string) (*models.Customer, error
 }
 
 // GetCustomerByEmail retrieves a customer by their email.
-func (r *customerRepository) GetCustomerByEmail(email string) (*models.Customer, error) {
+func (r *customerRepository) GetCustomerByEmail(ctx context.Context, email string) (*models.Customer, error) {
 	query := `
-		SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at
+		SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at
 		FROM customers
-		WHERE email = ?
+		WHERE email = ? AND tenant_id = ? AND deleted_at IS NULL
 	`
-	row := r.DB.QueryRow(query, email)
+	row := r.DB.QueryRowContext(ctx, query, email, r.TenantID)
 
 	var customer models.Customer
 	err := row.Scan(
@@ -111,6 +132,12 @@ func (r *customerRepository) GetCustomerByEmail(email string) (*models.Customer,
 		&customer.Email,
 		&customer.Phone,
 		&customer.Address,
+		&customer.Province,
+		&customer.City,
+		&customer.Barangay,
+		&customer.Latitude,
+		&customer.Longitude,
+		&customer.DateOfBirth,
 		&customer.DateRegistered,
 		&customer.CreatedAt,
 		&customer.UpdatedAt,
@@ -127,13 +154,14 @@ func (r *customerRepository) GetCustomerByEmail(email string) (*models.Customer,
 }
 
 // GetAllCustomers retrieves all customers from the database.
-func (r *customerRepository) GetAllCustomers() ([]*models.Customer, error) {
+func (r *customerRepository) GetAllCustomers(ctx context.Context) ([]*models.Customer, error) {
 	query := `
-		SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at
+		SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at
 		FROM customers
+		WHERE tenant_id = ? AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
-	rows, err := r.DB.Query(query)
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query customers: %w", err)
 	}
@@ -148,6 +176,12 @@ func (r *customerRepository) GetAllCustomers() ([]*models.Customer, error) {
 			&customer.Email,
 			&customer.Phone,
 			&customer.Address,
+			&customer.Province,
+			&customer.City,
+			&customer.Barangay,
+			&customer.Latitude,
+			&customer.Longitude,
+			&customer.DateOfBirth,
 			&customer.DateRegistered,
 			&customer.CreatedAt,
 			&customer.UpdatedAt,
@@ -165,23 +199,69 @@ func (r *customerRepository) GetAllCustomers() ([]*models.Customer, error) {
 	return customers, nil
 }
 
+// GetPaginated retrieves one page of the customers matching a search term.
+func (r *customerRepository) GetPaginated(ctx context.Context, page, limit int, search string) ([]*models.Customer, int64, error) {
+	where := ` WHERE tenant_id = ? AND deleted_at IS NULL`
+	args := []interface{}{r.TenantID}
+	if search != "" {
+		where += ` AND (LOWER(full_name) LIKE LOWER(?) OR LOWER(email) LIKE LOWER(?) OR phone LIKE ?)`
+		likeTerm := "%" + search + "%"
+		args = append(args, likeTerm, likeTerm, likeTerm)
+	}
+
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM customers`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count customers: %w", err)
+	}
+
+	query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at
+		FROM customers` + where + ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	rows, err := r.DB.QueryContext(ctx, query, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query customers: %w", err)
+	}
+	defer rows.Close()
+
+	customers := []*models.Customer{}
+	for rows.Next() {
+		var customer models.Customer
+		if err := rows.Scan(&customer.ID, &customer.FullName, &customer.Email, &customer.Phone, &customer.Address,
+			&customer.Province, &customer.City, &customer.Barangay, &customer.Latitude, &customer.Longitude,
+			&customer.DateOfBirth, &customer.DateRegistered, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan customer row: %w", err)
+		}
+		customers = append(customers, &customer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating customer rows: %w", err)
+	}
+	return customers, total, nil
+}
+
 // UpdateCustomer updates an existing customer in the database.
-func (r *customerRepository) UpdateCustomer(customer *models.Customer) (*models.Customer, error) {
+func (r *customerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
 	customer.UpdatedAt = time.Now()
 
 	query := `
 		UPDATE customers
-		SET full_name = ?, email = ?, phone = ?, address = ?, updated_at = ?
-		WHERE id = ?
+		SET full_name = ?, email = ?, phone = ?, address = ?, province = ?, city = ?, barangay = ?, latitude = ?, longitude = ?, date_of_birth = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL
 	`
-	result, err := r.DB.Exec(
+	result, err := r.DB.ExecContext(ctx,
 		query,
 		customer.FullName,
 		customer.Email,
 		customer.Phone,
 		customer.Address,
+		customer.Province,
+		customer.City,
+		customer.Barangay,
+		customer.Latitude,
+		customer.Longitude,
+		customer.DateOfBirth,
 		customer.UpdatedAt,
 		customer.ID,
+		r.TenantID,
 	)
 
 	if err != nil {
@@ -200,10 +280,10 @@ func (r *customerRepository) UpdateCustomer(customer *models.Customer) (*models.
 	return customer, nil
 }
 
-// DeleteCustomer removes a customer from the database by their ID.
-func (r *customerRepository) DeleteCustomer(id string) error {
-	query := `DELETE FROM customers WHERE id = ?`
-	result, err := r.DB.Exec(query, id)
+// DeleteCustomer soft-deletes a customer by their ID, so they can be restored.
+func (r *customerRepository) DeleteCustomer(ctx context.Context, id string) error {
+	query := `UPDATE customers SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+	result, err := r.DB.ExecContext(ctx, query, time.Now(), id, r.TenantID)
 	if err != nil {
 		return fmt.Errorf("failed to delete customer with ID %s: %w", id, err)
 	}
@@ -219,3 +299,23 @@ func (r *customerRepository) DeleteCustomer(id string) error {
 
 	return nil
 }
+
+// RestoreCustomer clears the deletion of a customer.
+func (r *customerRepository) RestoreCustomer(ctx context.Context, id string) error {
+	query := `UPDATE customers SET deleted_at = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+	result, err := r.DB.ExecContext(ctx, query, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to restore customer with ID %s: %w", id, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for customer ID %s: %w", id, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted customer with ID %s not found: %w", id, sql.ErrNoRows)
+	}
+
+	return nil
+}
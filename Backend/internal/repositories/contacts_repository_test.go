@@ -1,6 +1,7 @@
 package repositories_test
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -44,8 +45,8 @@ func TestCreateCustomer(t *testing.T) {
 				Address:   "123 Test St",
 			},
 			mockSetup: func(mock sqlmock.Sqlmock, customer *models.Customer) {
-				mock.ExpectExec("INSERT INTO customers (id, full_name, email, phone, address, date_registered, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").
-					WithArgs(sqlmock.AnyArg(), customer.FullName, customer.Email, customer.Phone, customer.Address, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				mock.ExpectExec("INSERT INTO customers (id, tenant_id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").
+					WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, customer.FullName, customer.Email, customer.Phone, customer.Address, customer.Province, customer.City, customer.Barangay, customer.Latitude, customer.Longitude, customer.DateOfBirth, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			expectError: false,
@@ -60,8 +61,8 @@ func TestCreateCustomer(t *testing.T) {
 				Address:   "456 Test Ave",
 			},
 			mockSetup: func(mock sqlmock.Sqlmock, customer *models.Customer) {
-				mock.ExpectExec("INSERT INTO customers (id, full_name, email, phone, address, date_registered, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").
-					WithArgs(customer.ID, customer.FullName, customer.Email, customer.Phone, customer.Address, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				mock.ExpectExec("INSERT INTO customers (id, tenant_id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").
+					WithArgs(customer.ID, models.DefaultTenantID, customer.FullName, customer.Email, customer.Phone, customer.Address, customer.Province, customer.City, customer.Barangay, customer.Latitude, customer.Longitude, customer.DateOfBirth, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 					WillReturnResult(sqlmock.NewResult(1, 1))
 			},
 			expectError: false,
@@ -73,8 +74,8 @@ func TestCreateCustomer(t *testing.T) {
 				Email:    "error@example.com",
 			},
 			mockSetup: func(mock sqlmock.Sqlmock, customer *models.Customer) {
-				mock.ExpectExec("INSERT INTO customers (id, full_name, email, phone, address, date_registered, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)").
-					WithArgs(sqlmock.AnyArg(), customer.FullName, customer.Email, customer.Phone, customer.Address, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+				mock.ExpectExec("INSERT INTO customers (id, tenant_id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").
+					WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, customer.FullName, customer.Email, customer.Phone, customer.Address, customer.Province, customer.City, customer.Barangay, customer.Latitude, customer.Longitude, customer.DateOfBirth, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 					WillReturnError(errors.New("db error"))
 			},
 			expectError:   true,
@@ -85,7 +86,7 @@ func TestCreateCustomer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup(mock, tt.customer)
-			createdCustomer, err := repo.CreateCustomer(tt.customer)
+			createdCustomer, err := repo.CreateCustomer(context.Background(), tt.customer)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -123,19 +124,19 @@ func TestGetCustomerByID(t *testing.T) {
 		{
 			name: "Success",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers WHERE id = ?`
-				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "date_registered", "created_at", "updated_at"}).
-					AddRow(customerID, "Test User", "get@example.com", "111", "Addr1", time.Now(), time.Now(), time.Now())
-				mock.ExpectQuery(query).WithArgs(customerID).WillReturnRows(rows)
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "province", "city", "barangay", "latitude", "longitude", "date_of_birth", "date_registered", "created_at", "updated_at"}).
+					AddRow(customerID, "Test User", "get@example.com", "111", "Addr1", "Metro Manila", "Makati", "Poblacion", 14.5547, 121.0244, "1990-04-12", time.Now(), time.Now(), time.Now())
+				mock.ExpectQuery(query).WithArgs(customerID, models.DefaultTenantID).WillReturnRows(rows)
 			},
-			expectCustomer: &models.Customer{ID: customerID, FullName: "Test User", Email: "get@example.com", Phone: "111", Address: "Addr1"},
+			expectCustomer: &models.Customer{ID: customerID, FullName: "Test User", Email: "get@example.com", Phone: "111", Address: "Addr1", City: "Makati", DateOfBirth: "1990-04-12"},
 			expectError:    false,
 		},
 		{
 			name: "Not Found",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers WHERE id = ?`
-				mock.ExpectQuery(query).WithArgs(customerID).WillReturnError(sql.ErrNoRows)
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+				mock.ExpectQuery(query).WithArgs(customerID, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 			},
 			expectError:   true,
 			errorContains: fmt.Sprintf("customer with ID %s not found", customerID),
@@ -143,9 +144,9 @@ func TestGetCustomerByID(t *testing.T) {
 		{
 			name: "Scan Error",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers WHERE id = ?`
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 				rows := sqlmock.NewRows([]string{"id", "full_name"}).AddRow(customerID, "Test User") // Mismatched columns
-				mock.ExpectQuery(query).WithArgs(customerID).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(customerID, models.DefaultTenantID).WillReturnRows(rows)
 			},
 			expectError:   true,
 			errorContains: fmt.Sprintf("failed to get customer by ID %s", customerID),
@@ -155,7 +156,7 @@ func TestGetCustomerByID(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup(mock)
-			customer, err := repo.GetCustomerByID(customerID)
+			customer, err := repo.GetCustomerByID(context.Background(), customerID)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -166,6 +167,9 @@ func TestGetCustomerByID(t *testing.T) {
 				require.NotNil(t, customer)
 				assert.Equal(t, tt.expectCustomer.ID, customer.ID)
 				assert.Equal(t, tt.expectCustomer.FullName, customer.FullName)
+				assert.Equal(t, tt.expectCustomer.City, customer.City)
+				require.NotNil(t, customer.Latitude)
+				assert.Equal(t, 14.5547, *customer.Latitude)
 				assert.False(t, customer.DateRegistered.IsZero())
 				assert.False(t, customer.CreatedAt.IsZero())
 				assert.False(t, customer.UpdatedAt.IsZero())
@@ -189,10 +193,10 @@ func TestGetCustomerByEmail(t *testing.T) {
 		{
 			name: "Success",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers WHERE email = ?`
-				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "date_registered", "created_at", "updated_at"}).
-					AddRow(uuid.New().String(), "Email User", customerEmail, "222", "Addr2", time.Now(), time.Now(), time.Now())
-				mock.ExpectQuery(query).WithArgs(customerEmail).WillReturnRows(rows)
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE email = ? AND tenant_id = ? AND deleted_at IS NULL`
+				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "province", "city", "barangay", "latitude", "longitude", "date_of_birth", "date_registered", "created_at", "updated_at"}).
+					AddRow(uuid.New().String(), "Email User", customerEmail, "222", "Addr2", "", "", "", nil, nil, "", time.Now(), time.Now(), time.Now())
+				mock.ExpectQuery(query).WithArgs(customerEmail, models.DefaultTenantID).WillReturnRows(rows)
 			},
 			expectCustomer: &models.Customer{FullName: "Email User", Email: customerEmail, Phone: "222", Address: "Addr2"},
 			expectError:    false,
@@ -200,8 +204,8 @@ func TestGetCustomerByEmail(t *testing.T) {
 		{
 			name: "Not Found",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers WHERE email = ?`
-				mock.ExpectQuery(query).WithArgs(customerEmail).WillReturnError(sql.ErrNoRows)
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE email = ? AND tenant_id = ? AND deleted_at IS NULL`
+				mock.ExpectQuery(query).WithArgs(customerEmail, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 			},
 			expectError:   true,
 			errorContains: fmt.Sprintf("customer with email %s not found", customerEmail),
@@ -211,7 +215,7 @@ func TestGetCustomerByEmail(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup(mock)
-			customer, err := repo.GetCustomerByEmail(customerEmail)
+			customer, err := repo.GetCustomerByEmail(context.Background(), customerEmail)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -241,11 +245,11 @@ func TestGetAllCustomers(t *testing.T) {
 		{
 			name: "Success - multiple customers",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers ORDER BY created_at DESC`
-				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "date_registered", "created_at", "updated_at"}).
-					AddRow(uuid.New().String(), "User 1", "u1@example.com", "", "", time.Now(), time.Now(), time.Now()).
-					AddRow(uuid.New().String(), "User 2", "u2@example.com", "", "", time.Now(), time.Now(), time.Now())
-				mock.ExpectQuery(query).WillReturnRows(rows)
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC`
+				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "province", "city", "barangay", "latitude", "longitude", "date_of_birth", "date_registered", "created_at", "updated_at"}).
+					AddRow(uuid.New().String(), "User 1", "u1@example.com", "", "", "", "", "", nil, nil, "", time.Now(), time.Now(), time.Now()).
+					AddRow(uuid.New().String(), "User 2", "u2@example.com", "", "", "", "", "", nil, nil, "", time.Now(), time.Now(), time.Now())
+				mock.ExpectQuery(query).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 			},
 			expectCount: 2,
 			expectError: false,
@@ -253,9 +257,9 @@ func TestGetAllCustomers(t *testing.T) {
 		{
 			name: "Success - no customers",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers ORDER BY created_at DESC`
-				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "date_registered", "created_at", "updated_at"})
-				mock.ExpectQuery(query).WillReturnRows(rows)
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC`
+				rows := sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "province", "city", "barangay", "latitude", "longitude", "date_of_birth", "date_registered", "created_at", "updated_at"})
+				mock.ExpectQuery(query).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 			},
 			expectCount: 0,
 			expectError: false,
@@ -263,8 +267,8 @@ func TestGetAllCustomers(t *testing.T) {
 		{
 			name: "Error - query fails",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers ORDER BY created_at DESC`
-				mock.ExpectQuery(query).WillReturnError(errors.New("db query error"))
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC`
+				mock.ExpectQuery(query).WithArgs(models.DefaultTenantID).WillReturnError(errors.New("db query error"))
 			},
 			expectError:   true,
 			errorContains: "failed to query customers: db query error",
@@ -272,9 +276,9 @@ func TestGetAllCustomers(t *testing.T) {
 		{
 			name: "Error - scan fails",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `SELECT id, full_name, email, phone, address, date_registered, created_at, updated_at FROM customers ORDER BY created_at DESC`
+				query := `SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC`
 				rows := sqlmock.NewRows([]string{"id", "full_name"}).AddRow(uuid.New().String(), "User 1") // Mismatched columns
-				mock.ExpectQuery(query).WillReturnRows(rows)
+				mock.ExpectQuery(query).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 			},
 			expectError:   true,
 			errorContains: "failed to scan customer row",
@@ -284,7 +288,7 @@ func TestGetAllCustomers(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup(mock)
-			customers, err := repo.GetAllCustomers()
+			customers, err := repo.GetAllCustomers(context.Background())
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -318,9 +322,9 @@ func TestUpdateCustomer(t *testing.T) {
 		{
 			name: "Success",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `UPDATE customers SET full_name = ?, email = ?, phone = ?, address = ?, updated_at = ? WHERE id = ?`
+				query := `UPDATE customers SET full_name = ?, email = ?, phone = ?, address = ?, province = ?, city = ?, barangay = ?, latitude = ?, longitude = ?, date_of_birth = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 				mock.ExpectExec(query).
-					WithArgs(customerToUpdate.FullName, customerToUpdate.Email, customerToUpdate.Phone, customerToUpdate.Address, sqlmock.AnyArg(), customerToUpdate.ID).
+					WithArgs(customerToUpdate.FullName, customerToUpdate.Email, customerToUpdate.Phone, customerToUpdate.Address, customerToUpdate.Province, customerToUpdate.City, customerToUpdate.Barangay, customerToUpdate.Latitude, customerToUpdate.Longitude, customerToUpdate.DateOfBirth, sqlmock.AnyArg(), customerToUpdate.ID, models.DefaultTenantID).
 					WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			expectError: false,
@@ -328,9 +332,9 @@ func TestUpdateCustomer(t *testing.T) {
 		{
 			name: "Not Found",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `UPDATE customers SET full_name = ?, email = ?, phone = ?, address = ?, updated_at = ? WHERE id = ?`
+				query := `UPDATE customers SET full_name = ?, email = ?, phone = ?, address = ?, province = ?, city = ?, barangay = ?, latitude = ?, longitude = ?, date_of_birth = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 				mock.ExpectExec(query).
-					WithArgs(customerToUpdate.FullName, customerToUpdate.Email, customerToUpdate.Phone, customerToUpdate.Address, sqlmock.AnyArg(), customerToUpdate.ID).
+					WithArgs(customerToUpdate.FullName, customerToUpdate.Email, customerToUpdate.Phone, customerToUpdate.Address, customerToUpdate.Province, customerToUpdate.City, customerToUpdate.Barangay, customerToUpdate.Latitude, customerToUpdate.Longitude, customerToUpdate.DateOfBirth, sqlmock.AnyArg(), customerToUpdate.ID, models.DefaultTenantID).
 					WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
 			},
 			expectError:   true,
@@ -339,9 +343,9 @@ func TestUpdateCustomer(t *testing.T) {
 		{
 			name: "DB Error",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `UPDATE customers SET full_name = ?, email = ?, phone = ?, address = ?, updated_at = ? WHERE id = ?`
+				query := `UPDATE customers SET full_name = ?, email = ?, phone = ?, address = ?, province = ?, city = ?, barangay = ?, latitude = ?, longitude = ?, date_of_birth = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 				mock.ExpectExec(query).
-					WithArgs(customerToUpdate.FullName, customerToUpdate.Email, customerToUpdate.Phone, customerToUpdate.Address, sqlmock.AnyArg(), customerToUpdate.ID).
+					WithArgs(customerToUpdate.FullName, customerToUpdate.Email, customerToUpdate.Phone, customerToUpdate.Address, customerToUpdate.Province, customerToUpdate.City, customerToUpdate.Barangay, customerToUpdate.Latitude, customerToUpdate.Longitude, customerToUpdate.DateOfBirth, sqlmock.AnyArg(), customerToUpdate.ID, models.DefaultTenantID).
 					WillReturnError(errors.New("db update error"))
 			},
 			expectError:   true,
@@ -352,7 +356,7 @@ func TestUpdateCustomer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup(mock)
-			updatedCustomer, err := repo.UpdateCustomer(customerToUpdate)
+			updatedCustomer, err := repo.UpdateCustomer(context.Background(), customerToUpdate)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -383,16 +387,16 @@ func TestDeleteCustomer(t *testing.T) {
 		{
 			name: "Success",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `DELETE FROM customers WHERE id = ?`
-				mock.ExpectExec(query).WithArgs(customerID).WillReturnResult(sqlmock.NewResult(0, 1))
+				query := `UPDATE customers SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+				mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), customerID, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			expectError: false,
 		},
 		{
 			name: "Not Found",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `DELETE FROM customers WHERE id = ?`
-				mock.ExpectExec(query).WithArgs(customerID).WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
+				query := `UPDATE customers SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+				mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), customerID, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
 			},
 			expectError:   true,
 			errorContains: fmt.Sprintf("customer with ID %s not found for deletion", customerID),
@@ -400,8 +404,8 @@ func TestDeleteCustomer(t *testing.T) {
 		{
 			name: "DB Error",
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				query := `DELETE FROM customers WHERE id = ?`
-				mock.ExpectExec(query).WithArgs(customerID).WillReturnError(errors.New("db delete error"))
+				query := `UPDATE customers SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+				mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), customerID, models.DefaultTenantID).WillReturnError(errors.New("db delete error"))
 			},
 			expectError:   true,
 			errorContains: fmt.Sprintf("failed to delete customer with ID %s: db delete error", customerID),
@@ -411,7 +415,7 @@ func TestDeleteCustomer(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup(mock)
-			err := repo.DeleteCustomer(customerID)
+			err := repo.DeleteCustomer(context.Background(), customerID)
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -423,3 +427,25 @@ func TestDeleteCustomer(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPaginatedCustomers(t *testing.T) {
+	repo, mock := newMockCustomerRepo(t)
+	where := ` WHERE tenant_id = ? AND deleted_at IS NULL AND (LOWER(full_name) LIKE LOWER(?) OR LOWER(email) LIKE LOWER(?) OR phone LIKE ?)`
+
+	mock.ExpectQuery(`SELECT COUNT(*) FROM customers`+where).
+		WithArgs(models.DefaultTenantID, "%juan%", "%juan%", "%juan%").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(11))
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at
+		FROM customers`+where+` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`).
+		WithArgs(models.DefaultTenantID, "%juan%", "%juan%", "%juan%", 10, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "province", "city", "barangay", "latitude", "longitude", "date_of_birth", "date_registered", "created_at", "updated_at"}).
+			AddRow("uuid-11", "Juan Dela Cruz", "juan@example.com", "+639171234567", "Manila", "", "", "", nil, nil, "1988-02-29", now, now, now))
+
+	customers, total, err := repo.GetPaginated(context.Background(), 2, 10, "juan")
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), total)
+	require.Len(t, customers, 1)
+	assert.Equal(t, "uuid-11", customers[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
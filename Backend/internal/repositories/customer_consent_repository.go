@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+)
+
+// CustomerConsentRepository defines the interface for the marketing consent and
+// do-not-contact flags of customers.
+type CustomerConsentRepository interface {
+	// Get returns the customer's flags; customers without any recorded have neither
+	// consented nor asked not to be contacted.
+	Get(customerID string) (*models.CustomerConsent, error)
+	// GetAll returns the recorded flags of every customer.
+	GetAll() ([]models.CustomerConsent, error)
+	// Save replaces the customer's flags.
+	Save(consent *models.CustomerConsent) error
+}
+
+// customerConsentRepository implements the CustomerConsentRepository interface.
+type customerConsentRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewCustomerConsentRepository creates a new instance of customerConsentRepository for the default tenant.
+func NewCustomerConsentRepository(db *sql.DB) CustomerConsentRepository {
+	return &customerConsentRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const customerConsentColumns = `customer_id, marketing_consent, marketing_consent_at, marketing_consent_source, do_not_contact, do_not_contact_at, do_not_contact_source, updated_by`
+
+// scanCustomerConsent reads a row of customerConsentColumns
+func scanCustomerConsent(row interface{ Scan(...interface{}) error }) (models.CustomerConsent, error) {
+	var consent models.CustomerConsent
+	var consentAt, doNotContactAt sql.NullTime
+	if err := row.Scan(&consent.CustomerID, &consent.MarketingConsent, &consentAt, &consent.MarketingConsentSource,
+		&consent.DoNotContact, &doNotContactAt, &consent.DoNotContactSource, &consent.UpdatedBy); err != nil {
+		return consent, err
+	}
+	if consentAt.Valid {
+		consent.MarketingConsentAt = &consentAt.Time
+	}
+	if doNotContactAt.Valid {
+		consent.DoNotContactAt = &doNotContactAt.Time
+	}
+	return consent, nil
+}
+
+// Get retrieves the customer's flags.
+func (r *customerConsentRepository) Get(customerID string) (*models.CustomerConsent, error) {
+	query := `SELECT ` + customerConsentColumns + ` FROM customer_consents WHERE tenant_id = ? AND customer_id = ?`
+	consent, err := scanCustomerConsent(r.DB.QueryRow(query, r.TenantID, customerID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &models.CustomerConsent{CustomerID: customerID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consent of customer %s: %w", customerID, err)
+	}
+	return &consent, nil
+}
+
+// GetAll retrieves the recorded flags of every customer.
+func (r *customerConsentRepository) GetAll() ([]models.CustomerConsent, error) {
+	query := `SELECT ` + customerConsentColumns + ` FROM customer_consents WHERE tenant_id = ?`
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customer consents: %w", err)
+	}
+	defer rows.Close()
+
+	consents := []models.CustomerConsent{}
+	for rows.Next() {
+		consent, err := scanCustomerConsent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan customer consent row: %w", err)
+		}
+		consents = append(consents, consent)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating customer consent rows: %w", err)
+	}
+	return consents, nil
+}
+
+// Save stores the customer's flags, replacing any recorded before.
+func (r *customerConsentRepository) Save(consent *models.CustomerConsent) error {
+	query := `
+		INSERT INTO customer_consents (tenant_id, ` + customerConsentColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE marketing_consent = VALUES(marketing_consent), marketing_consent_at = VALUES(marketing_consent_at),
+			marketing_consent_source = VALUES(marketing_consent_source), do_not_contact = VALUES(do_not_contact),
+			do_not_contact_at = VALUES(do_not_contact_at), do_not_contact_source = VALUES(do_not_contact_source), updated_by = VALUES(updated_by)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, consent.CustomerID, consent.MarketingConsent, consent.MarketingConsentAt, consent.MarketingConsentSource,
+		consent.DoNotContact, consent.DoNotContactAt, consent.DoNotContactSource, consent.UpdatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to save consent of customer %s: %w", consent.CustomerID, err)
+	}
+	return nil
+}
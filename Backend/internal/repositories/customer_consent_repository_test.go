@@ -0,0 +1,62 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const customerConsentColumns = `customer_id, marketing_consent, marketing_consent_at, marketing_consent_source, do_not_contact, do_not_contact_at, do_not_contact_source, updated_by`
+
+func newMockCustomerConsentRepo(t *testing.T) (repositories.CustomerConsentRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewCustomerConsentRepository(db), mock
+}
+
+func TestGetCustomerConsent(t *testing.T) {
+	repo, mock := newMockCustomerConsentRepo(t)
+	query := "SELECT " + customerConsentColumns + " FROM customer_consents WHERE tenant_id = ? AND customer_id = ?"
+	now := time.Now()
+
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "cust-1").
+		WillReturnRows(sqlmock.NewRows([]string{"customer_id", "marketing_consent", "marketing_consent_at", "marketing_consent_source", "do_not_contact", "do_not_contact_at", "do_not_contact_source", "updated_by"}).
+			AddRow("cust-1", true, now, "in_store_form", false, nil, "", "user-1"))
+	consent, err := repo.Get("cust-1")
+	require.NoError(t, err)
+	assert.True(t, consent.MarketingConsent)
+	require.NotNil(t, consent.MarketingConsentAt)
+	assert.Nil(t, consent.DoNotContactAt)
+
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "cust-2").WillReturnError(sql.ErrNoRows)
+	consent, err = repo.Get("cust-2")
+	require.NoError(t, err, "customers without recorded flags have not consented")
+	assert.Equal(t, &models.CustomerConsent{CustomerID: "cust-2"}, consent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveCustomerConsent(t *testing.T) {
+	repo, mock := newMockCustomerConsentRepo(t)
+	now := time.Now()
+	consent := &models.CustomerConsent{CustomerID: "cust-1", DoNotContact: true, DoNotContactAt: &now, DoNotContactSource: "phone_call", UpdatedBy: "user-1"}
+
+	mock.ExpectExec(`
+		INSERT INTO customer_consents (tenant_id, `+customerConsentColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE marketing_consent = VALUES(marketing_consent), marketing_consent_at = VALUES(marketing_consent_at),
+			marketing_consent_source = VALUES(marketing_consent_source), do_not_contact = VALUES(do_not_contact),
+			do_not_contact_at = VALUES(do_not_contact_at), do_not_contact_source = VALUES(do_not_contact_source), updated_by = VALUES(updated_by)
+	`).WithArgs(models.DefaultTenantID, "cust-1", false, nil, "", true, &now, "phone_call", "user-1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	require.NoError(t, repo.Save(consent))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,18 +5,29 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"log/slog"
 	"oop/internal/config"
+	"sync/atomic"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
 // DatabaseClient represents a client for interacting with the database.
 type DatabaseClient struct {
-	DB *sql.DB
+	DB    *sql.DB
+	Files FileStore // Keeps the contents of photos and attachments when set; nil keeps them in the database
+
+	down atomic.Bool // Set while the last Check failed
 }
 
+// maxConnectBackoff caps the wait between two startup attempts to reach the database
+const maxConnectBackoff = time.Minute
+
 // NewDatabaseClient creates a new DatabaseClient and establishes a database connection
 // using the provided database configuration. It returns a pointer to the client and an error.
+// The database is pinged up to config.ConnectAttempts times, backing off between
+// attempts, so the server can start before MySQL is ready.
 func NewDatabaseClient(config config.DatabaseConfig) (*DatabaseClient, error) {
 	// Enable parsing of MySQL TIMESTAMP fields into time.Time and set charset to utf8mb4
 	connStr := fmt.Sprintf(
@@ -28,14 +39,64 @@ func NewDatabaseClient(config config.DatabaseConfig) (*DatabaseClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Connections dropped by the server, such as when MySQL restarts or closes idle
+	// ones, are replaced by the pool; recycling them early keeps stale ones out of it
+	db.SetConnMaxLifetime(5 * time.Minute)
+	db.SetConnMaxIdleTime(time.Minute)
 
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %v", err)
+	if err := waitForDatabase(db.PingContext, config.ConnectAttempts, config.ConnectBackoff); err != nil {
+		db.Close()
+		return nil, err
 	}
 
 	return &DatabaseClient{DB: db}, nil
 }
 
+// waitForDatabase pings the database until it answers, at most attempts times,
+// waiting backoff after the first failure and doubling it after every next one
+func waitForDatabase(ping func(ctx context.Context) error, attempts int, backoff time.Duration) error {
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := ping(ctx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("failed to ping database after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("Database not reachable (attempt %d of %d), retrying in %s: %v", attempt, attempts, backoff, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// Check pings the database and records whether it answered, for Healthy. Losing
+// and regaining the connection are logged; requests made while it is lost fail
+// without stopping the server, and the pool reconnects once the database is back.
+func (c *DatabaseClient) Check(ctx context.Context) error {
+	started := time.Now()
+	err := c.DB.PingContext(ctx)
+	stats := c.DB.Stats()
+	slog.DebugContext(ctx, "Database checked", "took", time.Since(started), "error", err,
+		"open", stats.OpenConnections, "inUse", stats.InUse, "idle", stats.Idle,
+		"waitCount", stats.WaitCount, "waited", stats.WaitDuration)
+	wasDown := c.down.Swap(err != nil)
+	switch {
+	case err != nil && !wasDown:
+		slog.ErrorContext(ctx, "Database connection lost, serving in degraded mode", "error", err)
+	case err == nil && wasDown:
+		log.Println("Database connection restored.")
+	}
+	return err
+}
+
+// Healthy reports whether the database answered the last Check; it is healthy until checked
+func (c *DatabaseClient) Healthy() bool {
+	return !c.down.Load()
+}
+
 // Close closes the database connection held by the DatabaseClient.
 // It accepts a context for timeout control and returns an error if closing fails.
 func (c *DatabaseClient) Close(ctx context.Context) error {
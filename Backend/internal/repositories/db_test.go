@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForDatabase(t *testing.T) {
+	t.Run("retries until the database answers", func(t *testing.T) {
+		pings := 0
+		err := waitForDatabase(func(ctx context.Context) error {
+			pings++
+			if pings < 3 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}, 5, time.Millisecond)
+		require.NoError(t, err)
+		assert.Equal(t, 3, pings)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		pings := 0
+		err := waitForDatabase(func(ctx context.Context) error {
+			pings++
+			return errors.New("connection refused")
+		}, 3, time.Millisecond)
+		assert.ErrorContains(t, err, "after 3 attempts: connection refused")
+		assert.Equal(t, 3, pings)
+	})
+
+	t.Run("pings at least once", func(t *testing.T) {
+		pings := 0
+		err := waitForDatabase(func(ctx context.Context) error {
+			pings++
+			return errors.New("connection refused")
+		}, 0, time.Millisecond)
+		assert.Error(t, err)
+		assert.Equal(t, 1, pings)
+	})
+}
+
+func TestDatabaseClientCheck(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer db.Close()
+	client := &DatabaseClient{DB: db}
+	assert.True(t, client.Healthy(), "healthy until checked")
+
+	mock.ExpectPing().WillReturnError(errors.New("connection reset"))
+	assert.Error(t, client.Check(context.Background()))
+	assert.False(t, client.Healthy())
+
+	mock.ExpectPing()
+	assert.NoError(t, client.Check(context.Background()))
+	assert.True(t, client.Healthy(), "the server recovers once the database is back")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DepositRepository defines the interface for bank deposits of register cash.
+type DepositRepository interface {
+	// Create stores a deposit with the links to its register sessions.
+	Create(deposit *models.BankDeposit) error
+	GetByID(id string) (*models.BankDeposit, error)
+	// GetAll returns the deposits matching the filter, latest deposit date first.
+	GetAll(filter models.DepositFilter) ([]models.BankDeposit, error)
+	// Delete removes a deposit, leaving its sessions undeposited.
+	Delete(id string) error
+	// DepositedSessions returns the deposit ID of each of the sessions that was deposited.
+	DepositedSessions(sessionIDs []string) (map[string]string, error)
+}
+
+// depositRepository implements the DepositRepository interface.
+type depositRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewDepositRepository creates a new instance of depositRepository for the default tenant.
+func NewDepositRepository(db *sql.DB) DepositRepository {
+	return &depositRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const depositColumns = `id, bank, reference, amount, deposit_date, expected_amount, difference, recorded_by, notes, created_at`
+
+// Create stores a deposit with the links to its register sessions.
+func (r *depositRepository) Create(deposit *models.BankDeposit) error {
+	if deposit.ID == "" {
+		deposit.ID = uuid.New().String()
+	}
+	deposit.CreatedAt = time.Now()
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO bank_deposits (id, tenant_id, bank, reference, amount, deposit_date, expected_amount, difference, recorded_by, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = tx.Exec(query, deposit.ID, r.TenantID, deposit.Bank, deposit.Reference, deposit.Amount, deposit.DepositDate,
+		deposit.ExpectedAmount, deposit.Difference, deposit.RecordedBy, deposit.Notes, deposit.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create bank deposit: %w", err)
+	}
+
+	for _, sessionID := range deposit.SessionIDs {
+		_, err := tx.Exec(`INSERT INTO bank_deposit_sessions (tenant_id, deposit_id, session_id) VALUES (?, ?, ?)`,
+			r.TenantID, deposit.ID, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to link register session %s to bank deposit: %w", sessionID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a deposit by its ID.
+func (r *depositRepository) GetByID(id string) (*models.BankDeposit, error) {
+	query := `SELECT ` + depositColumns + ` FROM bank_deposits WHERE id = ? AND tenant_id = ?`
+
+	deposit, err := scanDeposit(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("bank deposit not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get bank deposit: %w", err)
+	}
+
+	deposits := []models.BankDeposit{*deposit}
+	if err := r.loadSessionIDs(deposits); err != nil {
+		return nil, err
+	}
+	return &deposits[0], nil
+}
+
+// GetAll retrieves the deposits matching the filter.
+func (r *depositRepository) GetAll(filter models.DepositFilter) ([]models.BankDeposit, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{r.TenantID}
+
+	if filter.StartDate != "" {
+		conditions = append(conditions, "deposit_date >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		conditions = append(conditions, "deposit_date <= ?")
+		args = append(args, filter.EndDate)
+	}
+	if filter.SessionID != "" {
+		conditions = append(conditions, "id IN (SELECT deposit_id FROM bank_deposit_sessions WHERE tenant_id = ? AND session_id = ?)")
+		args = append(args, r.TenantID, filter.SessionID)
+	}
+
+	query := `SELECT ` + depositColumns + ` FROM bank_deposits WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY deposit_date DESC, created_at DESC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bank deposits: %w", err)
+	}
+	defer rows.Close()
+
+	deposits := []models.BankDeposit{}
+	for rows.Next() {
+		deposit, err := scanDeposit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bank deposit row: %w", err)
+		}
+		deposits = append(deposits, *deposit)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating bank deposit rows: %w", err)
+	}
+
+	if err := r.loadSessionIDs(deposits); err != nil {
+		return nil, err
+	}
+	return deposits, nil
+}
+
+// loadSessionIDs fills in the register sessions of the deposits
+func (r *depositRepository) loadSessionIDs(deposits []models.BankDeposit) error {
+	if len(deposits) == 0 {
+		return nil
+	}
+
+	index := make(map[string]int, len(deposits))
+	args := []interface{}{r.TenantID}
+	for i := range deposits {
+		index[deposits[i].ID] = i
+		deposits[i].SessionIDs = []string{}
+		args = append(args, deposits[i].ID)
+	}
+
+	query := `SELECT deposit_id, session_id FROM bank_deposit_sessions WHERE tenant_id = ? AND deposit_id IN (?` +
+		strings.Repeat(", ?", len(deposits)-1) + `) ORDER BY session_id`
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query bank deposit sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var depositID, sessionID string
+		if err := rows.Scan(&depositID, &sessionID); err != nil {
+			return fmt.Errorf("failed to scan bank deposit session row: %w", err)
+		}
+		if i, ok := index[depositID]; ok {
+			deposits[i].SessionIDs = append(deposits[i].SessionIDs, sessionID)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating bank deposit session rows: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a deposit and its links to register sessions.
+func (r *depositRepository) Delete(id string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM bank_deposit_sessions WHERE deposit_id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to unlink bank deposit sessions: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM bank_deposits WHERE id = ? AND tenant_id = ?`, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete bank deposit: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("bank deposit not found: %w", sql.ErrNoRows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DepositedSessions returns the deposit ID of each of the sessions that was deposited.
+func (r *depositRepository) DepositedSessions(sessionIDs []string) (map[string]string, error) {
+	deposited := make(map[string]string)
+	if len(sessionIDs) == 0 {
+		return deposited, nil
+	}
+
+	args := []interface{}{r.TenantID}
+	for _, sessionID := range sessionIDs {
+		args = append(args, sessionID)
+	}
+	query := `SELECT session_id, deposit_id FROM bank_deposit_sessions WHERE tenant_id = ? AND session_id IN (?` +
+		strings.Repeat(", ?", len(sessionIDs)-1) + `)`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deposited sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sessionID, depositID string
+		if err := rows.Scan(&sessionID, &depositID); err != nil {
+			return nil, fmt.Errorf("failed to scan deposited session row: %w", err)
+		}
+		deposited[sessionID] = depositID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deposited session rows: %w", err)
+	}
+	return deposited, nil
+}
+
+func scanDeposit(row rowScanner) (*models.BankDeposit, error) {
+	var deposit models.BankDeposit
+	err := row.Scan(
+		&deposit.ID,
+		&deposit.Bank,
+		&deposit.Reference,
+		&deposit.Amount,
+		&deposit.DepositDate,
+		&deposit.ExpectedAmount,
+		&deposit.Difference,
+		&deposit.RecordedBy,
+		&deposit.Notes,
+		&deposit.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &deposit, nil
+}
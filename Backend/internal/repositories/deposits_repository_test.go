@@ -0,0 +1,92 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockDepositRepo(t *testing.T) (repositories.DepositRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewDepositRepository(db), mock
+}
+
+func TestCreateDeposit(t *testing.T) {
+	repo, mock := newMockDepositRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`
+		INSERT INTO bank_deposits (id, tenant_id, bank, reference, amount, deposit_date, expected_amount, difference, recorded_by, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "BDO", "DS-1", 1000.0, "2025-03-12", 1000.0, 0.0, "staff-1", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, sessionID := range []string{"reg-1", "reg-2"} {
+		mock.ExpectExec(`INSERT INTO bank_deposit_sessions (tenant_id, deposit_id, session_id) VALUES (?, ?, ?)`).
+			WithArgs(models.DefaultTenantID, sqlmock.AnyArg(), sessionID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	deposit := &models.BankDeposit{Bank: "BDO", Reference: "DS-1", Amount: 1000, DepositDate: "2025-03-12", SessionIDs: []string{"reg-1", "reg-2"}, ExpectedAmount: 1000, RecordedBy: "staff-1"}
+	require.NoError(t, repo.Create(deposit))
+	assert.NotEmpty(t, deposit.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllDepositsBySession(t *testing.T) {
+	repo, mock := newMockDepositRepo(t)
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, bank, reference, amount, deposit_date, expected_amount, difference, recorded_by, notes, created_at FROM bank_deposits WHERE tenant_id = ? AND id IN (SELECT deposit_id FROM bank_deposit_sessions WHERE tenant_id = ? AND session_id = ?) ORDER BY deposit_date DESC, created_at DESC`).
+		WithArgs(models.DefaultTenantID, models.DefaultTenantID, "reg-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "bank", "reference", "amount", "deposit_date", "expected_amount", "difference", "recorded_by", "notes", "created_at"}).
+			AddRow("dep-1", "BDO", "DS-1", 1000.0, "2025-03-12", 1000.0, 0.0, "staff-1", "", now))
+	mock.ExpectQuery(`SELECT deposit_id, session_id FROM bank_deposit_sessions WHERE tenant_id = ? AND deposit_id IN (?) ORDER BY session_id`).
+		WithArgs(models.DefaultTenantID, "dep-1").
+		WillReturnRows(sqlmock.NewRows([]string{"deposit_id", "session_id"}).AddRow("dep-1", "reg-1").AddRow("dep-1", "reg-2"))
+
+	deposits, err := repo.GetAll(models.DepositFilter{SessionID: "reg-1"})
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	assert.Equal(t, []string{"reg-1", "reg-2"}, deposits[0].SessionIDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDepositedSessions(t *testing.T) {
+	repo, mock := newMockDepositRepo(t)
+	mock.ExpectQuery(`SELECT session_id, deposit_id FROM bank_deposit_sessions WHERE tenant_id = ? AND session_id IN (?, ?)`).
+		WithArgs(models.DefaultTenantID, "reg-1", "reg-2").
+		WillReturnRows(sqlmock.NewRows([]string{"session_id", "deposit_id"}).AddRow("reg-2", "dep-1"))
+
+	deposited, err := repo.DepositedSessions([]string{"reg-1", "reg-2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"reg-2": "dep-1"}, deposited)
+
+	deposited, err = repo.DepositedSessions(nil)
+	require.NoError(t, err)
+	assert.Empty(t, deposited, "no query without sessions")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUndepositedRegisterSessions(t *testing.T) {
+	repo, mock := newMockRegisterSessionRepo(t)
+	before := time.Date(2025, 3, 12, 15, 0, 0, 0, time.Local)
+	mock.ExpectQuery(`SELECT id, opened_by, opened_at, opening_float, status, closed_by, closed_at, denominations, counted_cash, expected_cash, variance, sales_total, sales_count, notes FROM register_sessions s
+		LEFT JOIN bank_deposit_sessions d ON d.tenant_id = s.tenant_id AND d.session_id = s.id
+		WHERE s.tenant_id = ? AND s.status = ? AND s.closed_at < ? AND d.session_id IS NULL
+		ORDER BY s.closed_at ASC`).
+		WithArgs(models.DefaultTenantID, models.RegisterSessionClosed, before).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "opened_by", "opened_at", "opening_float", "status", "closed_by", "closed_at", "denominations", "counted_cash", "expected_cash", "variance", "sales_total", "sales_count", "notes"}))
+
+	sessions, err := repo.GetUndeposited(before)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+)
+
+// DisplayNumberRepository defines the interface for the numbering formats of each
+// entity type, their sequences and the display numbers given to records.
+type DisplayNumberRepository interface {
+	GetFormats() ([]models.NumberFormat, error)
+	// GetFormat returns an error wrapping sql.ErrNoRows when the entity type has no format.
+	GetFormat(entityType string) (*models.NumberFormat, error)
+	// SaveFormat sets the format of an entity type, replacing the one it had.
+	SaveFormat(format *models.NumberFormat) error
+	DeleteFormat(entityType string) error
+	// Assign takes the next number of the scope's sequence and gives it to the record,
+	// in one transaction so concurrent records get different numbers. A record that
+	// already has a number keeps it.
+	Assign(entityType, entityID, scope string) (*models.DisplayNumber, error)
+	// GetNumbers returns the display numbers of the records that have one, by ID.
+	GetNumbers(entityType string, entityIDs []string) (map[string]string, error)
+	// Find returns the records given a display number, of any entity type.
+	Find(number string) ([]models.DisplayNumber, error)
+}
+
+// displayNumberRepository implements the DisplayNumberRepository interface.
+type displayNumberRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewDisplayNumberRepository creates a new instance of displayNumberRepository for the default tenant.
+func NewDisplayNumberRepository(db *sql.DB) DisplayNumberRepository {
+	return &displayNumberRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// GetFormats retrieves the numbering formats of every entity type that has one.
+func (r *displayNumberRepository) GetFormats() ([]models.NumberFormat, error) {
+	rows, err := r.DB.Query(`SELECT entity_type, format, updated_by, updated_at FROM number_formats WHERE tenant_id = ? ORDER BY entity_type`, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query number formats: %w", err)
+	}
+	defer rows.Close()
+
+	formats := []models.NumberFormat{}
+	for rows.Next() {
+		var format models.NumberFormat
+		if err := rows.Scan(&format.EntityType, &format.Format, &format.UpdatedBy, &format.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan number format row: %w", err)
+		}
+		formats = append(formats, format)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating number format rows: %w", err)
+	}
+
+	return formats, nil
+}
+
+// GetFormat retrieves the numbering format of an entity type.
+func (r *displayNumberRepository) GetFormat(entityType string) (*models.NumberFormat, error) {
+	var format models.NumberFormat
+	err := r.DB.QueryRow(`SELECT entity_type, format, updated_by, updated_at FROM number_formats WHERE tenant_id = ? AND entity_type = ?`,
+		r.TenantID, entityType).Scan(&format.EntityType, &format.Format, &format.UpdatedBy, &format.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("number format not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get number format: %w", err)
+	}
+	return &format, nil
+}
+
+// SaveFormat sets the numbering format of an entity type.
+func (r *displayNumberRepository) SaveFormat(format *models.NumberFormat) error {
+	format.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO number_formats (tenant_id, entity_type, format, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE format = VALUES(format), updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`
+	if _, err := r.DB.Exec(query, r.TenantID, format.EntityType, format.Format, format.UpdatedBy, format.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save number format: %w", err)
+	}
+	return nil
+}
+
+// DeleteFormat removes the numbering format of an entity type. Its sequences and the
+// numbers already given are kept.
+func (r *displayNumberRepository) DeleteFormat(entityType string) error {
+	result, err := r.DB.Exec(`DELETE FROM number_formats WHERE tenant_id = ? AND entity_type = ?`, r.TenantID, entityType)
+	if err != nil {
+		return fmt.Errorf("failed to delete number format: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("number format not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Assign gives a record the next number of the scope's sequence. Incrementing the
+// sequence locks its row until the number is stored.
+func (r *displayNumberRepository) Assign(entityType, entityID, scope string) (*models.DisplayNumber, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	assigned := &models.DisplayNumber{EntityType: entityType, EntityID: entityID}
+	err = tx.QueryRow(`SELECT number, created_at FROM display_numbers WHERE tenant_id = ? AND entity_type = ? AND entity_id = ?`,
+		r.TenantID, entityType, entityID).Scan(&assigned.Number, &assigned.CreatedAt)
+	if err == nil {
+		return assigned, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get display number: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO number_sequences (tenant_id, entity_type, scope, last_value) VALUES (?, ?, ?, 1)
+		ON DUPLICATE KEY UPDATE last_value = last_value + 1
+	`, r.TenantID, entityType, scope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to advance number sequence: %w", err)
+	}
+	var sequence int
+	err = tx.QueryRow(`SELECT last_value FROM number_sequences WHERE tenant_id = ? AND entity_type = ? AND scope = ?`,
+		r.TenantID, entityType, scope).Scan(&sequence)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get number sequence: %w", err)
+	}
+
+	assigned.Number = models.FormatSequence(scope, sequence)
+	assigned.CreatedAt = time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO display_numbers (tenant_id, entity_type, entity_id, number, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, r.TenantID, entityType, entityID, assigned.Number, assigned.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store display number: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return assigned, nil
+}
+
+// GetNumbers retrieves the display numbers of records by ID.
+func (r *displayNumberRepository) GetNumbers(entityType string, entityIDs []string) (map[string]string, error) {
+	numbers := make(map[string]string, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return numbers, nil
+	}
+
+	args := []interface{}{r.TenantID, entityType}
+	for _, id := range entityIDs {
+		args = append(args, id)
+	}
+	query := `SELECT entity_id, number FROM display_numbers WHERE tenant_id = ? AND entity_type = ? AND entity_id IN (?` +
+		strings.Repeat(", ?", len(entityIDs)-1) + `)`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query display numbers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, number string
+		if err := rows.Scan(&id, &number); err != nil {
+			return nil, fmt.Errorf("failed to scan display number row: %w", err)
+		}
+		numbers[id] = number
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating display number rows: %w", err)
+	}
+
+	return numbers, nil
+}
+
+// Find retrieves the records given a display number.
+func (r *displayNumberRepository) Find(number string) ([]models.DisplayNumber, error) {
+	rows, err := r.DB.Query(`SELECT entity_type, entity_id, number, created_at FROM display_numbers WHERE tenant_id = ? AND number = ? ORDER BY entity_type`,
+		r.TenantID, number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query display numbers: %w", err)
+	}
+	defer rows.Close()
+
+	found := []models.DisplayNumber{}
+	for rows.Next() {
+		var assigned models.DisplayNumber
+		if err := rows.Scan(&assigned.EntityType, &assigned.EntityID, &assigned.Number, &assigned.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan display number row: %w", err)
+		}
+		found = append(found, assigned)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating display number rows: %w", err)
+	}
+
+	return found, nil
+}
@@ -0,0 +1,91 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockDisplayNumberRepo(t *testing.T) (repositories.DisplayNumberRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewDisplayNumberRepository(db), mock
+}
+
+func TestAssignDisplayNumber(t *testing.T) {
+	repo, mock := newMockDisplayNumberRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT number, created_at FROM display_numbers WHERE tenant_id = ? AND entity_type = ? AND entity_id = ?`).
+		WithArgs(models.DefaultTenantID, models.NumberedSale, "sale-1").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`
+		INSERT INTO number_sequences (tenant_id, entity_type, scope, last_value) VALUES (?, ?, ?, 1)
+		ON DUPLICATE KEY UPDATE last_value = last_value + 1
+	`).
+		WithArgs(models.DefaultTenantID, models.NumberedSale, "SALE-2026-{seq:5}").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`SELECT last_value FROM number_sequences WHERE tenant_id = ? AND entity_type = ? AND scope = ?`).
+		WithArgs(models.DefaultTenantID, models.NumberedSale, "SALE-2026-{seq:5}").
+		WillReturnRows(sqlmock.NewRows([]string{"last_value"}).AddRow(42))
+	mock.ExpectExec(`
+		INSERT INTO display_numbers (tenant_id, entity_type, entity_id, number, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`).
+		WithArgs(models.DefaultTenantID, models.NumberedSale, "sale-1", "SALE-2026-00042", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assigned, err := repo.Assign(models.NumberedSale, "sale-1", "SALE-2026-{seq:5}")
+
+	require.NoError(t, err)
+	assert.Equal(t, "SALE-2026-00042", assigned.Number)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignDisplayNumberKeepsExisting(t *testing.T) {
+	repo, mock := newMockDisplayNumberRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT number, created_at FROM display_numbers WHERE tenant_id = ? AND entity_type = ? AND entity_id = ?`).
+		WithArgs(models.DefaultTenantID, models.NumberedSale, "sale-1").
+		WillReturnRows(sqlmock.NewRows([]string{"number", "created_at"}).AddRow("SALE-2026-00007", time.Now()))
+	mock.ExpectRollback()
+
+	assigned, err := repo.Assign(models.NumberedSale, "sale-1", "SALE-2026-{seq:5}")
+
+	require.NoError(t, err)
+	assert.Equal(t, "SALE-2026-00007", assigned.Number)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDisplayNumbers(t *testing.T) {
+	repo, mock := newMockDisplayNumberRepo(t)
+	mock.ExpectQuery(`SELECT entity_id, number FROM display_numbers WHERE tenant_id = ? AND entity_type = ? AND entity_id IN (?, ?)`).
+		WithArgs(models.DefaultTenantID, models.NumberedCab, "1", "2").
+		WillReturnRows(sqlmock.NewRows([]string{"entity_id", "number"}).AddRow("2", "CAB-MAIN-1"))
+
+	numbers, err := repo.GetNumbers(models.NumberedCab, []string{"1", "2"})
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"2": "CAB-MAIN-1"}, numbers)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteNumberFormatNotFound(t *testing.T) {
+	repo, mock := newMockDisplayNumberRepo(t)
+	mock.ExpectExec(`DELETE FROM number_formats WHERE tenant_id = ? AND entity_type = ?`).
+		WithArgs(models.DefaultTenantID, models.NumberedCab).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.DeleteFormat(models.NumberedCab)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// DocumentTemplateRepository defines the interface for the branding printed on receipts and statements.
+type DocumentTemplateRepository interface {
+	// Get returns the tenant's template, or an empty template when none was saved.
+	Get() (models.DocumentTemplate, error)
+	// Save replaces the text of the template, keeping the logo.
+	Save(template models.DocumentTemplate) error
+	// GetLogo returns the logo image, or an error wrapping sql.ErrNoRows when there is none.
+	GetLogo() (models.DocumentLogo, error)
+	// SaveLogo replaces the logo image.
+	SaveLogo(logo models.DocumentLogo, updatedBy string, updatedAt time.Time) error
+	// DeleteLogo removes the logo image.
+	DeleteLogo(updatedBy string, updatedAt time.Time) error
+}
+
+// documentTemplateRepository implements the DocumentTemplateRepository interface.
+type documentTemplateRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewDocumentTemplateRepository creates a new instance of documentTemplateRepository for the default tenant.
+func NewDocumentTemplateRepository(db *sql.DB) DocumentTemplateRepository {
+	return &documentTemplateRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Get retrieves the tenant's template.
+func (r *documentTemplateRepository) Get() (models.DocumentTemplate, error) {
+	query := `
+		SELECT company_name, address, header_text, footer_text, terms, logo IS NOT NULL, updated_by, updated_at
+		FROM document_templates
+		WHERE tenant_id = ?
+	`
+	var template models.DocumentTemplate
+	var updatedAt time.Time
+	err := r.DB.QueryRow(query, r.TenantID).Scan(
+		&template.CompanyName, &template.Address, &template.HeaderText, &template.FooterText,
+		&template.Terms, &template.HasLogo, &template.UpdatedBy, &updatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DocumentTemplate{}, nil
+	}
+	if err != nil {
+		return models.DocumentTemplate{}, fmt.Errorf("failed to get document template: %w", err)
+	}
+	template.UpdatedAt = &updatedAt
+
+	return template, nil
+}
+
+// Save stores the text of the template, creating the tenant's row if needed.
+func (r *documentTemplateRepository) Save(template models.DocumentTemplate) error {
+	query := `
+		INSERT INTO document_templates (tenant_id, company_name, address, header_text, footer_text, terms, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE company_name = VALUES(company_name), address = VALUES(address),
+			header_text = VALUES(header_text), footer_text = VALUES(footer_text), terms = VALUES(terms),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, template.CompanyName, template.Address, template.HeaderText,
+		template.FooterText, template.Terms, template.UpdatedBy, template.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save document template: %w", err)
+	}
+
+	return nil
+}
+
+// GetLogo retrieves the logo image.
+func (r *documentTemplateRepository) GetLogo() (models.DocumentLogo, error) {
+	query := `SELECT logo_content_type, logo FROM document_templates WHERE tenant_id = ? AND logo IS NOT NULL`
+	var logo models.DocumentLogo
+	err := r.DB.QueryRow(query, r.TenantID).Scan(&logo.ContentType, &logo.Data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DocumentLogo{}, fmt.Errorf("document logo not found: %w", sql.ErrNoRows)
+	}
+	if err != nil {
+		return models.DocumentLogo{}, fmt.Errorf("failed to get document logo: %w", err)
+	}
+
+	return logo, nil
+}
+
+// SaveLogo stores the logo image, creating the tenant's row if needed.
+func (r *documentTemplateRepository) SaveLogo(logo models.DocumentLogo, updatedBy string, updatedAt time.Time) error {
+	query := `
+		INSERT INTO document_templates (tenant_id, logo, logo_content_type, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE logo = VALUES(logo), logo_content_type = VALUES(logo_content_type),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, logo.Data, logo.ContentType, updatedBy, updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save document logo: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLogo clears the logo image. Deleting a missing logo is not an error.
+func (r *documentTemplateRepository) DeleteLogo(updatedBy string, updatedAt time.Time) error {
+	query := `
+		UPDATE document_templates SET logo = NULL, logo_content_type = NULL, updated_by = ?, updated_at = ?
+		WHERE tenant_id = ? AND logo IS NOT NULL
+	`
+	_, err := r.DB.Exec(query, updatedBy, updatedAt, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete document logo: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockDocumentTemplateRepo(t *testing.T) (repositories.DocumentTemplateRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewDocumentTemplateRepository(db), mock
+}
+
+const getDocumentTemplateQuery = `
+		SELECT company_name, address, header_text, footer_text, terms, logo IS NOT NULL, updated_by, updated_at
+		FROM document_templates
+		WHERE tenant_id = ?
+	`
+
+func TestGetDocumentTemplate(t *testing.T) {
+	repo, mock := newMockDocumentTemplateRepo(t)
+	updatedAt := time.Now()
+	mock.ExpectQuery(getDocumentTemplateQuery).WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"company_name", "address", "header_text", "footer_text", "terms", "has_logo", "updated_by", "updated_at"}).
+			AddRow("Surplus Motors", "Cebu City", "Official Receipt", "Thank you!", "No returns", true, "admin-1", updatedAt))
+
+	template, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, models.DocumentTemplate{
+		CompanyName: "Surplus Motors",
+		Address:     "Cebu City",
+		HeaderText:  "Official Receipt",
+		FooterText:  "Thank you!",
+		Terms:       "No returns",
+		HasLogo:     true,
+		UpdatedBy:   "admin-1",
+		UpdatedAt:   &updatedAt,
+	}, template)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDocumentTemplateNotSaved(t *testing.T) {
+	repo, mock := newMockDocumentTemplateRepo(t)
+	mock.ExpectQuery(getDocumentTemplateQuery).WithArgs(models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	template, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, models.DocumentTemplate{}, template)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveDocumentTemplate(t *testing.T) {
+	repo, mock := newMockDocumentTemplateRepo(t)
+	updatedAt := time.Now()
+	mock.ExpectExec(`
+		INSERT INTO document_templates (tenant_id, company_name, address, header_text, footer_text, terms, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE company_name = VALUES(company_name), address = VALUES(address),
+			header_text = VALUES(header_text), footer_text = VALUES(footer_text), terms = VALUES(terms),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`).WithArgs(models.DefaultTenantID, "Surplus Motors", "Cebu City", "", "Thank you!", "", "admin-1", updatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Save(models.DocumentTemplate{CompanyName: "Surplus Motors", Address: "Cebu City", FooterText: "Thank you!", UpdatedBy: "admin-1", UpdatedAt: &updatedAt})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDocumentLogoMissing(t *testing.T) {
+	repo, mock := newMockDocumentTemplateRepo(t)
+	mock.ExpectQuery(`SELECT logo_content_type, logo FROM document_templates WHERE tenant_id = ? AND logo IS NOT NULL`).
+		WithArgs(models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetLogo()
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveDocumentLogo(t *testing.T) {
+	repo, mock := newMockDocumentTemplateRepo(t)
+	updatedAt := time.Now()
+	data := []byte("\x89PNG\r\n\x1a\n")
+	mock.ExpectExec(`
+		INSERT INTO document_templates (tenant_id, logo, logo_content_type, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE logo = VALUES(logo), logo_content_type = VALUES(logo_content_type),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`).WithArgs(models.DefaultTenantID, data, "image/png", "admin-1", updatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.SaveLogo(models.DocumentLogo{ContentType: "image/png", Data: data}, "admin-1", updatedAt)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"oop/internal/models"
+)
+
+// ErrNotDuplicateSale is returned when a sale voided as a duplicate is not one of the
+// sale it supposedly duplicates, or is that sale itself
+var ErrNotDuplicateSale = errors.New("sale is not a duplicate")
+
+// duplicateSaleItems returns the items of a sale in a fixed order, for comparing the
+// items of two sales
+func duplicateSaleItems(items []models.SaleItem) []models.DuplicateSaleItem {
+	result := make([]models.DuplicateSaleItem, 0, len(items))
+	for _, item := range items {
+		result = append(result, models.DuplicateSaleItem{ItemType: item.ItemType(), ItemID: item.Item.ID(), Quantity: item.Quantity, UnitPrice: item.UnitPrice})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		switch {
+		case a.ItemType != b.ItemType:
+			return a.ItemType < b.ItemType
+		case a.ItemID != b.ItemID:
+			return a.ItemID < b.ItemID
+		case a.Quantity != b.Quantity:
+			return a.Quantity < b.Quantity
+		}
+		return a.UnitPrice < b.UnitPrice
+	})
+	return result
+}
+
+// duplicateSaleKey identifies what a sale and its copies have in common: the customer,
+// the sale date, the total and the items, prices compared to the centavo
+func duplicateSaleKey(sale models.Sale, items []models.DuplicateSaleItem) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%s|%s|%.2f", sale.CustomerID, sale.SaleDate, sale.TotalPrice)
+	for _, item := range items {
+		fmt.Fprintf(&key, "|%s:%s:%d:%.2f", item.ItemType, item.ItemID, item.Quantity, item.UnitPrice)
+	}
+	return key.String()
+}
+
+// IsDuplicateSale reports whether sale is a duplicate of original: a different sale for
+// the same customer on the same day with the same total and items
+func IsDuplicateSale(sale, original models.Sale, saleItems, originalItems []models.SaleItem) bool {
+	return sale.ID != original.ID &&
+		duplicateSaleKey(sale, duplicateSaleItems(saleItems)) == duplicateSaleKey(original, duplicateSaleItems(originalItems))
+}
+
+// GroupDuplicateSales groups the sales that are duplicates of each other, given the
+// items of each sale by sale ID. Groups are ordered newest sale date first, then by
+// customer, and the sales of a group oldest first, so the first is the presumed
+// original. Sales without a duplicate are left out.
+func GroupDuplicateSales(sales []models.Sale, items map[string][]models.SaleItem) []models.DuplicateSaleGroup {
+	byKey := map[string]*models.DuplicateSaleGroup{}
+	for _, sale := range sales {
+		saleItems := duplicateSaleItems(items[sale.ID])
+		key := duplicateSaleKey(sale, saleItems)
+		group, ok := byKey[key]
+		if !ok {
+			group = &models.DuplicateSaleGroup{CustomerID: sale.CustomerID, SaleDate: sale.SaleDate, TotalPrice: sale.TotalPrice, Items: saleItems}
+			byKey[key] = group
+		}
+		group.Sales = append(group.Sales, sale)
+	}
+
+	groups := []models.DuplicateSaleGroup{}
+	for _, group := range byKey {
+		if len(group.Sales) < 2 {
+			continue
+		}
+		sort.Slice(group.Sales, func(i, j int) bool {
+			a, b := group.Sales[i], group.Sales[j]
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		})
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		switch {
+		case a.SaleDate != b.SaleDate:
+			return a.SaleDate > b.SaleDate
+		case a.CustomerID != b.CustomerID:
+			return a.CustomerID < b.CustomerID
+		}
+		return a.Sales[0].ID < b.Sales[0].ID
+	})
+	return groups
+}
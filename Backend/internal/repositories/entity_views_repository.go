@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oop/internal/models"
+)
+
+// EntityViewRepository defines the interface for the records users recently opened.
+type EntityViewRepository interface {
+	// RecordView stores a view, replacing the user's previous view of the same record.
+	RecordView(view models.EntityView) error
+	// GetRecent returns a user's latest views, most recent first. An empty entityType matches every type.
+	GetRecent(userID, entityType string, limit int) ([]models.EntityView, error)
+}
+
+// entityViewRepository implements the EntityViewRepository interface.
+type entityViewRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewEntityViewRepository creates a new instance of entityViewRepository for the default tenant.
+func NewEntityViewRepository(db *sql.DB) EntityViewRepository {
+	return &entityViewRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// RecordView stores a view, keeping one row per user and record.
+func (r *entityViewRepository) RecordView(view models.EntityView) error {
+	query := `
+		INSERT INTO entity_views (tenant_id, user_id, entity_type, entity_id, viewed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE viewed_at = VALUES(viewed_at)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, view.UserID, view.EntityType, view.EntityID, view.ViewedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record view: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecent retrieves a user's latest views, most recent first.
+func (r *entityViewRepository) GetRecent(userID, entityType string, limit int) ([]models.EntityView, error) {
+	query := `
+		SELECT user_id, entity_type, entity_id, viewed_at
+		FROM entity_views
+		WHERE tenant_id = ? AND user_id = ?
+	`
+	args := []interface{}{r.TenantID, userID}
+	if entityType != "" {
+		query += " AND entity_type = ?"
+		args = append(args, entityType)
+	}
+	query += " ORDER BY viewed_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query views: %w", err)
+	}
+	defer rows.Close()
+
+	views := []models.EntityView{}
+	for rows.Next() {
+		var view models.EntityView
+		if err := rows.Scan(&view.UserID, &view.EntityType, &view.EntityID, &view.ViewedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan view row: %w", err)
+		}
+		views = append(views, view)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating view rows: %w", err)
+	}
+
+	return views, nil
+}
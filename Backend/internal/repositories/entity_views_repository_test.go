@@ -0,0 +1,55 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockEntityViewRepo(t *testing.T) (repositories.EntityViewRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewEntityViewRepository(db), mock
+}
+
+func TestRecordView(t *testing.T) {
+	repo, mock := newMockEntityViewRepo(t)
+	viewedAt := time.Now()
+	mock.ExpectExec(`
+		INSERT INTO entity_views (tenant_id, user_id, entity_type, entity_id, viewed_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE viewed_at = VALUES(viewed_at)
+	`).WithArgs(models.DefaultTenantID, "user-1", models.ViewEntityCab, "7", viewedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.RecordView(models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCab, EntityID: "7", ViewedAt: viewedAt})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetRecentViewsByType(t *testing.T) {
+	repo, mock := newMockEntityViewRepo(t)
+	viewedAt := time.Now()
+	mock.ExpectQuery(`
+		SELECT user_id, entity_type, entity_id, viewed_at
+		FROM entity_views
+		WHERE tenant_id = ? AND user_id = ?
+	 AND entity_type = ? ORDER BY viewed_at DESC LIMIT ?`).WithArgs(models.DefaultTenantID, "user-1", models.ViewEntityCustomer, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "entity_type", "entity_id", "viewed_at"}).
+			AddRow("user-1", models.ViewEntityCustomer, "c-1", viewedAt))
+
+	views, err := repo.GetRecent("user-1", models.ViewEntityCustomer, 20)
+
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, "c-1", views[0].EntityID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,357 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpenseRepository defines the interface for yard expenses and their attachments.
+type ExpenseRepository interface {
+	Create(expense *models.Expense) error
+	// GetByID returns an expense with its attachments, without their contents.
+	GetByID(id string) (*models.Expense, error)
+	// Update saves every editable field of an expense, including its review.
+	Update(expense *models.Expense) error
+	// Delete removes an expense and its attachments.
+	Delete(id string) error
+	// GetAll returns the expenses matching the filter, latest expense date first.
+	// Attachments are not loaded.
+	GetAll(filter models.ExpenseFilter) ([]models.Expense, error)
+	// AddAttachment stores a file attached to an expense.
+	AddAttachment(attachment *models.ExpenseAttachment, data []byte) error
+	// GetAttachment returns an attachment of an expense with its contents.
+	GetAttachment(expenseID, id string) (*models.ExpenseAttachment, []byte, error)
+	DeleteAttachment(expenseID, id string) error
+}
+
+// expenseRepository implements the ExpenseRepository interface.
+type expenseRepository struct {
+	DB       *sql.DB
+	TenantID string    // Every query is scoped to this tenant
+	Files    FileStore // Keeps the contents of attachments when set
+}
+
+// NewExpenseRepository creates a new instance of expenseRepository for the default tenant.
+func NewExpenseRepository(db *sql.DB) ExpenseRepository {
+	return &expenseRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const expenseColumns = `id, category, description, vendor, amount, expense_date, status, submitted_by, reviewed_by, reviewed_at, review_note, created_at, updated_at`
+
+const expenseAttachmentColumns = `id, expense_id, file_name, content_type, size, storage_key, uploaded_by, uploaded_at`
+
+// Create stores a new expense.
+func (r *expenseRepository) Create(expense *models.Expense) error {
+	if expense.ID == "" {
+		expense.ID = uuid.New().String()
+	}
+	now := time.Now()
+	expense.CreatedAt = now
+	expense.UpdatedAt = now
+
+	query := `
+		INSERT INTO expenses (id, tenant_id, category, description, vendor, amount, expense_date, status, submitted_by, reviewed_by, reviewed_at, review_note, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, expense.ID, r.TenantID, expense.Category, expense.Description, expense.Vendor, expense.Amount,
+		expense.ExpenseDate, expense.Status, expense.SubmittedBy, expense.ReviewedBy, expense.ReviewedAt, expense.ReviewNote,
+		expense.CreatedAt, expense.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create expense: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an expense and its attachments by its ID.
+func (r *expenseRepository) GetByID(id string) (*models.Expense, error) {
+	query := `SELECT ` + expenseColumns + ` FROM expenses WHERE id = ? AND tenant_id = ?`
+
+	expense, err := scanExpense(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("expense not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	rows, err := r.DB.Query(`SELECT `+expenseAttachmentColumns+` FROM expense_attachments
+		WHERE expense_id = ? AND tenant_id = ? ORDER BY uploaded_at ASC`, id, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expense attachments: %w", err)
+	}
+	defer rows.Close()
+
+	expense.Attachments = []models.ExpenseAttachment{}
+	for rows.Next() {
+		attachment, err := scanExpenseAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expense attachment row: %w", err)
+		}
+		expense.Attachments = append(expense.Attachments, *attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expense attachment rows: %w", err)
+	}
+
+	return expense, nil
+}
+
+// Update saves every editable field of an expense.
+func (r *expenseRepository) Update(expense *models.Expense) error {
+	expense.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE expenses SET category = ?, description = ?, vendor = ?, amount = ?, expense_date = ?, status = ?,
+			reviewed_by = ?, reviewed_at = ?, review_note = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.DB.Exec(query, expense.Category, expense.Description, expense.Vendor, expense.Amount, expense.ExpenseDate,
+		expense.Status, expense.ReviewedBy, expense.ReviewedAt, expense.ReviewNote, expense.UpdatedAt, expense.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update expense: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("expense not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Delete removes an expense and its attachments.
+func (r *expenseRepository) Delete(id string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var keys []string
+	if r.Files != nil {
+		keys, err = queryStorageKeys(tx, `SELECT storage_key FROM expense_attachments WHERE expense_id = ? AND tenant_id = ?`, id, r.TenantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM expense_attachments WHERE expense_id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to delete expense attachments: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM expenses WHERE id = ? AND tenant_id = ?`, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete expense: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("expense not found: %w", sql.ErrNoRows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	removeFiles(r.Files, keys...)
+	return nil
+}
+
+// GetAll retrieves the expenses matching the filter.
+func (r *expenseRepository) GetAll(filter models.ExpenseFilter) ([]models.Expense, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{r.TenantID}
+
+	if filter.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, filter.Category)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.SubmittedBy != "" {
+		conditions = append(conditions, "submitted_by = ?")
+		args = append(args, filter.SubmittedBy)
+	}
+	if filter.StartDate != "" {
+		conditions = append(conditions, "expense_date >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		conditions = append(conditions, "expense_date <= ?")
+		args = append(args, filter.EndDate)
+	}
+
+	query := `SELECT ` + expenseColumns + ` FROM expenses WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY expense_date DESC, created_at DESC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expenses: %w", err)
+	}
+	defer rows.Close()
+
+	expenses := []models.Expense{}
+	for rows.Next() {
+		expense, err := scanExpense(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expense row: %w", err)
+		}
+		expenses = append(expenses, *expense)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expense rows: %w", err)
+	}
+
+	return expenses, nil
+}
+
+// AddAttachment stores a file attached to an expense.
+func (r *expenseRepository) AddAttachment(attachment *models.ExpenseAttachment, data []byte) error {
+	if attachment.ID == "" {
+		attachment.ID = uuid.New().String()
+	}
+	attachment.Size = len(data)
+
+	storageKey, stored, err := putFile(r.Files, fileKey(r.TenantID, "expense-attachments", attachment.ID), attachment.ContentType, data)
+	if err != nil {
+		return err
+	}
+	attachment.StorageKey = storageKey
+
+	query := `
+		INSERT INTO expense_attachments (id, tenant_id, expense_id, file_name, content_type, size, storage_key, data, uploaded_by, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.DB.Exec(query, attachment.ID, r.TenantID, attachment.ExpenseID, attachment.FileName, attachment.ContentType,
+		attachment.Size, storageKey, stored, attachment.UploadedBy, attachment.UploadedAt)
+	if err != nil {
+		removeFiles(r.Files, storageKey)
+		return fmt.Errorf("failed to add expense attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetAttachment retrieves an attachment of an expense with its contents.
+func (r *expenseRepository) GetAttachment(expenseID, id string) (*models.ExpenseAttachment, []byte, error) {
+	query := `SELECT ` + expenseAttachmentColumns + `, data FROM expense_attachments WHERE id = ? AND expense_id = ? AND tenant_id = ?`
+
+	var attachment models.ExpenseAttachment
+	var data []byte
+	err := r.DB.QueryRow(query, id, expenseID, r.TenantID).Scan(
+		&attachment.ID,
+		&attachment.ExpenseID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.UploadedBy,
+		&attachment.UploadedAt,
+		&data,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("expense attachment not found: %w", err)
+		}
+		return nil, nil, fmt.Errorf("failed to get expense attachment: %w", err)
+	}
+
+	data, err = readFile(r.Files, attachment.StorageKey, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &attachment, data, nil
+}
+
+// DeleteAttachment removes an attachment of an expense.
+func (r *expenseRepository) DeleteAttachment(expenseID, id string) error {
+	var storageKey string
+	if r.Files != nil {
+		err := r.DB.QueryRow(`SELECT storage_key FROM expense_attachments WHERE id = ? AND expense_id = ? AND tenant_id = ?`,
+			id, expenseID, r.TenantID).Scan(&storageKey)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("expense attachment not found: %w", err)
+			}
+			return fmt.Errorf("failed to get expense attachment: %w", err)
+		}
+	}
+
+	result, err := r.DB.Exec(`DELETE FROM expense_attachments WHERE id = ? AND expense_id = ? AND tenant_id = ?`, id, expenseID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete expense attachment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("expense attachment not found: %w", sql.ErrNoRows)
+	}
+
+	removeFiles(r.Files, storageKey)
+	return nil
+}
+
+func scanExpense(row rowScanner) (*models.Expense, error) {
+	var expense models.Expense
+	var reviewedAt sql.NullTime
+
+	err := row.Scan(
+		&expense.ID,
+		&expense.Category,
+		&expense.Description,
+		&expense.Vendor,
+		&expense.Amount,
+		&expense.ExpenseDate,
+		&expense.Status,
+		&expense.SubmittedBy,
+		&expense.ReviewedBy,
+		&reviewedAt,
+		&expense.ReviewNote,
+		&expense.CreatedAt,
+		&expense.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if reviewedAt.Valid {
+		expense.ReviewedAt = &reviewedAt.Time
+	}
+	return &expense, nil
+}
+
+func scanExpenseAttachment(row rowScanner) (*models.ExpenseAttachment, error) {
+	var attachment models.ExpenseAttachment
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.ExpenseID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.UploadedBy,
+		&attachment.UploadedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
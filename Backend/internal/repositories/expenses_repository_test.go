@@ -0,0 +1,98 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockExpenseRepo(t *testing.T) (repositories.ExpenseRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewExpenseRepository(db), mock
+}
+
+var expenseColumnNames = []string{"id", "category", "description", "vendor", "amount", "expense_date", "status", "submitted_by", "reviewed_by", "reviewed_at", "review_note", "created_at", "updated_at"}
+
+func TestGetExpenseByID(t *testing.T) {
+	repo, mock := newMockExpenseRepo(t)
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, category, description, vendor, amount, expense_date, status, submitted_by, reviewed_by, reviewed_at, review_note, created_at, updated_at FROM expenses WHERE id = ? AND tenant_id = ?`).
+		WithArgs("exp-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows(expenseColumnNames).
+			AddRow("exp-1", "fuel", "Diesel", "Petron", 1500.0, "2025-03-01", "approved", "staff-1", "admin-1", now, "", now, now))
+	mock.ExpectQuery(`SELECT id, expense_id, file_name, content_type, size, storage_key, uploaded_by, uploaded_at FROM expense_attachments
+		WHERE expense_id = ? AND tenant_id = ? ORDER BY uploaded_at ASC`).
+		WithArgs("exp-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "expense_id", "file_name", "content_type", "size", "storage_key", "uploaded_by", "uploaded_at"}).
+			AddRow("att-1", "exp-1", "receipt.pdf", "application/pdf", 2048, "", "staff-1", now))
+
+	expense, err := repo.GetByID("exp-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Petron", expense.Vendor)
+	require.NotNil(t, expense.ReviewedAt)
+	require.Len(t, expense.Attachments, 1)
+	assert.Equal(t, 2048, expense.Attachments[0].Size)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetExpenseByIDNotFound(t *testing.T) {
+	repo, mock := newMockExpenseRepo(t)
+	mock.ExpectQuery(`SELECT id, category, description, vendor, amount, expense_date, status, submitted_by, reviewed_by, reviewed_at, review_note, created_at, updated_at FROM expenses WHERE id = ? AND tenant_id = ?`).
+		WithArgs("missing", models.DefaultTenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetByID("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllExpensesFilter(t *testing.T) {
+	repo, mock := newMockExpenseRepo(t)
+	mock.ExpectQuery(`SELECT id, category, description, vendor, amount, expense_date, status, submitted_by, reviewed_by, reviewed_at, review_note, created_at, updated_at FROM expenses WHERE tenant_id = ? AND status = ? AND expense_date >= ? AND expense_date <= ? ORDER BY expense_date DESC, created_at DESC`).
+		WithArgs(models.DefaultTenantID, models.ExpenseApproved, "2025-03-01", "2025-03-31").
+		WillReturnRows(sqlmock.NewRows(expenseColumnNames))
+
+	expenses, err := repo.GetAll(models.ExpenseFilter{Status: models.ExpenseApproved, StartDate: "2025-03-01", EndDate: "2025-03-31"})
+	require.NoError(t, err)
+	assert.Empty(t, expenses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteExpense(t *testing.T) {
+	repo, mock := newMockExpenseRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM expense_attachments WHERE expense_id = ? AND tenant_id = ?`).
+		WithArgs("exp-1", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM expenses WHERE id = ? AND tenant_id = ?`).
+		WithArgs("exp-1", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Delete("exp-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteExpenseNotFound(t *testing.T) {
+	repo, mock := newMockExpenseRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM expense_attachments WHERE expense_id = ? AND tenant_id = ?`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM expenses WHERE id = ? AND tenant_id = ?`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Delete("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// ExportJobRepository defines the interface for exports built in the background.
+type ExportJobRepository interface {
+	// Create stores a queued job.
+	Create(job *models.ExportJob) error
+	// GetByID returns a job without its file, or an error wrapping sql.ErrNoRows.
+	GetByID(id string) (*models.ExportJob, error)
+	// GetByUser returns the jobs a user requested, newest first.
+	GetByUser(userID string) ([]models.ExportJob, error)
+	// Start marks a job as running.
+	Start(id string, at time.Time) error
+	// UpdateProgress records how far a running job got.
+	UpdateProgress(id string, progress, rows int) error
+	// Complete stores the file of a job and marks it completed. The job's FileName,
+	// Rows, CompletedAt and ExpiresAt are saved with it.
+	Complete(job *models.ExportJob, data []byte) error
+	// Fail marks a job as failed with the reason.
+	Fail(id, message string, at time.Time) error
+	// GetFile returns the file of a completed job.
+	GetFile(id string) ([]byte, error)
+	// DeleteExpired deletes the jobs that expired before the time, with their files,
+	// and returns how many were deleted.
+	DeleteExpired(before time.Time) (int, error)
+}
+
+// exportJobRepository implements the ExportJobRepository interface.
+type exportJobRepository struct {
+	DB       *sql.DB
+	TenantID string    // Every query is scoped to this tenant
+	Files    FileStore // Keeps the files of exports when set
+}
+
+// NewExportJobRepository creates a new instance of exportJobRepository for the default tenant.
+func NewExportJobRepository(db *sql.DB) ExportJobRepository {
+	return &exportJobRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const exportJobColumns = `id, type, filters, status, progress, row_count, file_name, size, error, requested_by, created_at, started_at, completed_at, expires_at, storage_key`
+
+// Create stores a new job.
+func (r *exportJobRepository) Create(job *models.ExportJob) error {
+	filters, err := json.Marshal(job.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to encode export filters: %w", err)
+	}
+	query := `
+		INSERT INTO export_jobs (id, tenant_id, type, filters, status, progress, row_count, error, requested_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, 0, 0, '', ?, ?, ?)
+	`
+	if _, err := r.DB.Exec(query, job.ID, r.TenantID, job.Type, string(filters), job.Status, job.RequestedBy, job.CreatedAt, job.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create export job: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a job.
+func (r *exportJobRepository) GetByID(id string) (*models.ExportJob, error) {
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE id = ? AND tenant_id = ?`
+	job, err := scanExportJob(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("export job not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get export job: %w", err)
+	}
+	return job, nil
+}
+
+// GetByUser retrieves the jobs a user requested.
+func (r *exportJobRepository) GetByUser(userID string) ([]models.ExportJob, error) {
+	query := `SELECT ` + exportJobColumns + ` FROM export_jobs WHERE tenant_id = ? AND requested_by = ? ORDER BY created_at DESC`
+	rows, err := r.DB.Query(query, r.TenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list export jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []models.ExportJob{}
+	for rows.Next() {
+		job, err := scanExportJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan export job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating export jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func scanExportJob(row interface{ Scan(...interface{}) error }) (*models.ExportJob, error) {
+	var job models.ExportJob
+	var filters string
+	var startedAt, completedAt sql.NullTime
+	err := row.Scan(&job.ID, &job.Type, &filters, &job.Status, &job.Progress, &job.Rows, &job.FileName, &job.Size, &job.Error,
+		&job.RequestedBy, &job.CreatedAt, &startedAt, &completedAt, &job.ExpiresAt, &job.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(filters), &job.Filters); err != nil {
+		return nil, fmt.Errorf("failed to decode export filters: %w", err)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	return &job, nil
+}
+
+// Start marks a job as running.
+func (r *exportJobRepository) Start(id string, at time.Time) error {
+	query := `UPDATE export_jobs SET status = ?, started_at = ? WHERE id = ? AND tenant_id = ?`
+	return r.update(query, models.ExportStatusRunning, at, id, r.TenantID)
+}
+
+// UpdateProgress records the progress of a running job. Updates that change nothing
+// are not an error.
+func (r *exportJobRepository) UpdateProgress(id string, progress, rows int) error {
+	query := `UPDATE export_jobs SET progress = ?, row_count = ? WHERE id = ? AND tenant_id = ?`
+	if _, err := r.DB.Exec(query, progress, rows, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	return nil
+}
+
+// Complete stores the file and marks the job completed.
+func (r *exportJobRepository) Complete(job *models.ExportJob, data []byte) error {
+	storageKey, stored, err := putFile(r.Files, fileKey(r.TenantID, "exports", job.ID), "text/csv", data)
+	if err != nil {
+		return err
+	}
+	query := `
+		UPDATE export_jobs SET status = ?, progress = 100, row_count = ?, file_name = ?, size = ?, completed_at = ?, expires_at = ?, storage_key = ?, data = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	if err := r.update(query, models.ExportStatusCompleted, job.Rows, job.FileName, len(data), job.CompletedAt, job.ExpiresAt, storageKey, stored, job.ID, r.TenantID); err != nil {
+		removeFiles(r.Files, storageKey)
+		return err
+	}
+	return nil
+}
+
+// Fail marks a job as failed.
+func (r *exportJobRepository) Fail(id, message string, at time.Time) error {
+	query := `UPDATE export_jobs SET status = ?, error = ?, completed_at = ? WHERE id = ? AND tenant_id = ?`
+	return r.update(query, models.ExportStatusFailed, message, at, id, r.TenantID)
+}
+
+func (r *exportJobRepository) update(query string, args ...interface{}) error {
+	result, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update export job: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected == 0 {
+		return fmt.Errorf("export job not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// GetFile retrieves the file of a completed job.
+func (r *exportJobRepository) GetFile(id string) ([]byte, error) {
+	query := `SELECT storage_key, data FROM export_jobs WHERE id = ? AND tenant_id = ? AND status = ?`
+	var storageKey string
+	var data []byte
+	if err := r.DB.QueryRow(query, id, r.TenantID, models.ExportStatusCompleted).Scan(&storageKey, &data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("export file not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get export file: %w", err)
+	}
+	return readFile(r.Files, storageKey, data)
+}
+
+// DeleteExpired deletes the expired jobs and their files.
+func (r *exportJobRepository) DeleteExpired(before time.Time) (int, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var keys []string
+	if r.Files != nil {
+		keys, err = queryStorageKeys(tx, `SELECT storage_key FROM export_jobs WHERE tenant_id = ? AND expires_at < ?`, r.TenantID, before)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM export_jobs WHERE tenant_id = ? AND expires_at < ?`, r.TenantID, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired export jobs: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	removeFiles(r.Files, keys...)
+	return int(deleted), nil
+}
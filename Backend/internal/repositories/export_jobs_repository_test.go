@@ -0,0 +1,78 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockExportJobRepo(t *testing.T) (repositories.ExportJobRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewExportJobRepository(db), mock
+}
+
+func TestGetExportJobByID(t *testing.T) {
+	repo, mock := newMockExportJobRepo(t)
+	now := time.Now()
+	query := `SELECT id, type, filters, status, progress, row_count, file_name, size, error, requested_by, created_at, started_at, completed_at, expires_at, storage_key FROM export_jobs WHERE id = ? AND tenant_id = ?`
+	mock.ExpectQuery(query).WithArgs("job-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "filters", "status", "progress", "row_count", "file_name", "size", "error",
+			"requested_by", "created_at", "started_at", "completed_at", "expires_at", "storage_key"}).
+			AddRow("job-1", models.ExportSales, `{"start_date":"2025-01-01"}`, models.ExportStatusRunning, 40, 200, "", 0, "",
+				"staff-1", now, now, nil, now.Add(time.Hour), ""))
+	mock.ExpectQuery(query).WithArgs("job-2", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	job, err := repo.GetByID("job-1")
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-01", job.Filters["start_date"])
+	assert.Equal(t, 40, job.Progress)
+	assert.NotNil(t, job.StartedAt)
+	assert.Nil(t, job.CompletedAt)
+
+	_, err = repo.GetByID("job-2")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCompleteExportJob(t *testing.T) {
+	repo, mock := newMockExportJobRepo(t)
+	now := time.Now()
+	job := &models.ExportJob{ID: "job-1", Rows: 2, FileName: "sales.csv", CompletedAt: &now, ExpiresAt: now.Add(time.Hour)}
+	data := []byte("id\n1\n2\n")
+	mock.ExpectExec(`
+		UPDATE export_jobs SET status = ?, progress = 100, row_count = ?, file_name = ?, size = ?, completed_at = ?, expires_at = ?, storage_key = ?, data = ?
+		WHERE id = ? AND tenant_id = ?
+	`).WithArgs(models.ExportStatusCompleted, 2, "sales.csv", len(data), &now, job.ExpiresAt, "", data, "job-1", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE export_jobs SET status = ?, error = ?, completed_at = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(models.ExportStatusFailed, "Failed to build the export", now, "job-2", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.Complete(job, data))
+	assert.True(t, errors.Is(repo.Fail("job-2", "Failed to build the export", now), sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteExpiredExportJobs(t *testing.T) {
+	repo, mock := newMockExportJobRepo(t)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM export_jobs WHERE tenant_id = ? AND expires_at < ?`).
+		WithArgs(models.DefaultTenantID, now).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	deleted, err := repo.DeleteExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// FavoriteRepository defines the interface for the inventory items users starred.
+type FavoriteRepository interface {
+	// Save stars an item for a user, or updates whether they are notified when it is already starred.
+	Save(favorite *models.Favorite) error
+	Remove(userID, itemType string, itemID int) error
+	GetByUser(userID string) ([]models.Favorite, error)
+	// GetWatchers returns the IDs of the users notified when the item changes.
+	GetWatchers(itemType string, itemID int) ([]string, error)
+}
+
+// favoriteRepository implements the FavoriteRepository interface.
+type favoriteRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewFavoriteRepository creates a new instance of favoriteRepository for the default tenant.
+func NewFavoriteRepository(db *sql.DB) FavoriteRepository {
+	return &favoriteRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Save stars an item, keeping the original star time when it was already starred.
+func (r *favoriteRepository) Save(favorite *models.Favorite) error {
+	favorite.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO user_favorites (tenant_id, user_id, item_type, item_id, notify, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE notify = VALUES(notify)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, favorite.UserID, favorite.ItemType, favorite.ItemID, favorite.Notify, favorite.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save favorite: %w", err)
+	}
+
+	return nil
+}
+
+// Remove unstars an item.
+func (r *favoriteRepository) Remove(userID, itemType string, itemID int) error {
+	query := `DELETE FROM user_favorites WHERE tenant_id = ? AND user_id = ? AND item_type = ? AND item_id = ?`
+	result, err := r.DB.Exec(query, r.TenantID, userID, itemType, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to remove favorite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("favorite not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetByUser retrieves the items a user starred, most recent first.
+func (r *favoriteRepository) GetByUser(userID string) ([]models.Favorite, error) {
+	query := `
+		SELECT user_id, item_type, item_id, notify, created_at
+		FROM user_favorites
+		WHERE tenant_id = ? AND user_id = ?
+		ORDER BY created_at DESC
+	`
+	rows, err := r.DB.Query(query, r.TenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query favorites: %w", err)
+	}
+	defer rows.Close()
+
+	favorites := []models.Favorite{}
+	for rows.Next() {
+		var favorite models.Favorite
+		if err := rows.Scan(&favorite.UserID, &favorite.ItemType, &favorite.ItemID, &favorite.Notify, &favorite.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan favorite row: %w", err)
+		}
+		favorites = append(favorites, favorite)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating favorite rows: %w", err)
+	}
+
+	return favorites, nil
+}
+
+// GetWatchers retrieves the users who starred an item with notifications on.
+func (r *favoriteRepository) GetWatchers(itemType string, itemID int) ([]string, error) {
+	query := `
+		SELECT user_id
+		FROM user_favorites
+		WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND notify = TRUE
+	`
+	rows, err := r.DB.Query(query, r.TenantID, itemType, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchers: %w", err)
+	}
+	defer rows.Close()
+
+	userIDs := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan watcher row: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watcher rows: %w", err)
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,63 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockFavoriteRepo(t *testing.T) (repositories.FavoriteRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewFavoriteRepository(db), mock
+}
+
+func TestSaveFavorite(t *testing.T) {
+	repo, mock := newMockFavoriteRepo(t)
+	mock.ExpectExec(`
+		INSERT INTO user_favorites (tenant_id, user_id, item_type, item_id, notify, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE notify = VALUES(notify)
+	`).WithArgs(models.DefaultTenantID, "user-1", models.FavoriteItemCab, 7, true, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Save(&models.Favorite{UserID: "user-1", ItemType: models.FavoriteItemCab, ItemID: 7, Notify: true})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFavoriteWatchers(t *testing.T) {
+	repo, mock := newMockFavoriteRepo(t)
+	mock.ExpectQuery(`
+		SELECT user_id
+		FROM user_favorites
+		WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND notify = TRUE
+	`).WithArgs(models.DefaultTenantID, models.FavoriteItemAccessory, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1").AddRow("user-2"))
+
+	watchers, err := repo.GetWatchers(models.FavoriteItemAccessory, 3)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, watchers)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRemoveFavoriteNotFound(t *testing.T) {
+	repo, mock := newMockFavoriteRepo(t)
+	mock.ExpectExec("DELETE FROM user_favorites WHERE tenant_id = ? AND user_id = ? AND item_type = ? AND item_id = ?").
+		WithArgs(models.DefaultTenantID, "user-1", models.FavoriteItemCab, 7).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Remove("user-1", models.FavoriteItemCab, 7)
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// FileStore keeps the contents of uploaded photos and attachments outside the
+// database, on disk or in an object store. Repositories without one keep contents in
+// the data column of their rows; rows whose contents are in the store record the key
+// in their storage_key column instead.
+type FileStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// fileKey returns the key of a file of a tenant, e.g. tenant-1/item-images/<id>
+func fileKey(tenantID string, parts ...string) string {
+	return tenantID + "/" + strings.Join(parts, "/")
+}
+
+// putFile stores data under key when files is set. It returns the storage key to
+// record with the row and what to write to its data column.
+func putFile(files FileStore, key, contentType string, data []byte) (string, []byte, error) {
+	if files == nil {
+		return "", data, nil
+	}
+	if err := files.Put(context.Background(), key, contentType, data); err != nil {
+		return "", nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	return key, []byte{}, nil
+}
+
+// readFile returns the contents of a row: the file at storageKey, or its data column
+// when the row has no storage key
+func readFile(files FileStore, storageKey string, data []byte) ([]byte, error) {
+	if storageKey == "" {
+		return data, nil
+	}
+	if files == nil {
+		return nil, fmt.Errorf("file %s is kept in file storage, which is not configured", storageKey)
+	}
+	data, err := files.Get(context.Background(), storageKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored file: %w", err)
+	}
+	return data, nil
+}
+
+// removeFiles deletes stored files once their rows are gone. Failures are logged
+// rather than returned, as the rows cannot be brought back; the file is left orphaned.
+func removeFiles(files FileStore, keys ...string) {
+	if files == nil {
+		return
+	}
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := files.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting stored file %s: %v", key, err)
+		}
+	}
+}
+
+// queryStorageKeys returns the non-empty storage keys selected by query, so the files
+// can be removed once the transaction deleting their rows commits
+func queryStorageKeys(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query storage keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan storage key: %w", err)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating storage keys: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,117 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryFileStore is a FileStore keeping files in a map
+type memoryFileStore map[string][]byte
+
+func (s memoryFileStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	s[key] = data
+	return nil
+}
+
+func (s memoryFileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, ok := s[key]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return data, nil
+}
+
+func (s memoryFileStore) Delete(ctx context.Context, key string) error {
+	delete(s, key)
+	return nil
+}
+
+func TestItemImagesInFileStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	files := memoryFileStore{}
+	repo := repositories.ForTenant(&repositories.DatabaseClient{DB: db, Files: files}, "tenant-1").Images
+	uploadedAt := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT(*), COALESCE(MAX(position) + 1, 0) FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs("tenant-1", "cab", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(0, 0))
+	mock.ExpectExec(`
+		INSERT INTO item_images (id, tenant_id, item_type, item_id, file_name, content_type, size, content_hash, storage_key, data, position, is_primary, uploaded_by, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).WithArgs("image-1", "tenant-1", "cab", 1, "front.jpg", "image/jpeg", 3, repositories.ItemImageHash([]byte("jpg")),
+		"tenant-1/item-images/image-1/original", []byte{}, 0, true, "staff-1", uploadedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	image := &models.ItemImage{ID: "image-1", ItemType: "cab", ItemID: 1, FileName: "front.jpg", ContentType: "image/jpeg", UploadedBy: "staff-1", UploadedAt: uploadedAt}
+	require.NoError(t, repo.Add(image, []byte("jpg")))
+	assert.Equal(t, []byte("jpg"), files["tenant-1/item-images/image-1/original"], "the contents go to the file store")
+
+	mock.ExpectQuery(`SELECT id, item_type, item_id, file_name, content_type, size, content_hash, position, is_primary, uploaded_by, uploaded_at, storage_key, data FROM item_images WHERE id = ? AND tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs("image-1", "tenant-1", "cab", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_type", "item_id", "file_name", "content_type", "size", "content_hash", "position", "is_primary", "uploaded_by", "uploaded_at", "storage_key", "data"}).
+			AddRow("image-1", "cab", 1, "front.jpg", "image/jpeg", 3, "", 0, true, "staff-1", uploadedAt, "tenant-1/item-images/image-1/original", []byte{}))
+	_, data, err := repo.GetFile("cab", 1, "image-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("jpg"), data)
+
+	files["tenant-1/item-images/image-1/thumb"] = []byte("thumb")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT position, is_primary FROM item_images WHERE id = ? AND tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs("image-1", "tenant-1", "cab", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "is_primary"}).AddRow(0, false))
+	mock.ExpectQuery(`SELECT storage_key FROM item_images WHERE id = ? AND tenant_id = ?
+			UNION ALL SELECT storage_key FROM item_image_variants WHERE image_id = ? AND tenant_id = ?`).
+		WithArgs("image-1", "tenant-1", "image-1", "tenant-1").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("tenant-1/item-images/image-1/original").AddRow("tenant-1/item-images/image-1/thumb"))
+	mock.ExpectExec(`DELETE FROM item_image_variants WHERE image_id = ? AND tenant_id = ?`).
+		WithArgs("image-1", "tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM item_images WHERE id = ? AND tenant_id = ?`).
+		WithArgs("image-1", "tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE item_images SET position = position - 1 WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND position > ?`).
+		WithArgs("tenant-1", "cab", 1, 0).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Delete("cab", 1, "image-1"))
+	assert.Empty(t, files, "the photo and its variants are removed from the file store")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpenseAttachmentInFileStore(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	files := memoryFileStore{}
+	repo := repositories.ForTenant(&repositories.DatabaseClient{DB: db, Files: files}, "tenant-1").Expenses
+	uploadedAt := time.Now()
+
+	mock.ExpectExec(`
+		INSERT INTO expense_attachments (id, tenant_id, expense_id, file_name, content_type, size, storage_key, data, uploaded_by, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).WithArgs("att-1", "tenant-1", "exp-1", "receipt.pdf", "application/pdf", 4, "tenant-1/expense-attachments/att-1", []byte{}, "staff-1", uploadedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	attachment := &models.ExpenseAttachment{ID: "att-1", ExpenseID: "exp-1", FileName: "receipt.pdf", ContentType: "application/pdf", UploadedBy: "staff-1", UploadedAt: uploadedAt}
+	require.NoError(t, repo.AddAttachment(attachment, []byte("%PDF")))
+	assert.Equal(t, "tenant-1/expense-attachments/att-1", attachment.StorageKey)
+
+	mock.ExpectQuery(`SELECT storage_key FROM expense_attachments WHERE id = ? AND expense_id = ? AND tenant_id = ?`).
+		WithArgs("att-1", "exp-1", "tenant-1").
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow("tenant-1/expense-attachments/att-1"))
+	mock.ExpectExec(`DELETE FROM expense_attachments WHERE id = ? AND expense_id = ? AND tenant_id = ?`).
+		WithArgs("att-1", "exp-1", "tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.DeleteAttachment("exp-1", "att-1"))
+	assert.Empty(t, files)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
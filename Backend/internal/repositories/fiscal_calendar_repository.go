@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// FiscalCalendarRepository defines the interface for the fiscal year used by reports.
+type FiscalCalendarRepository interface {
+	// Get returns the tenant's fiscal calendar, or the calendar year when none was saved.
+	Get() (models.FiscalCalendar, error)
+	// Save replaces the tenant's fiscal calendar.
+	Save(calendar models.FiscalCalendar) error
+}
+
+// fiscalCalendarRepository implements the FiscalCalendarRepository interface.
+type fiscalCalendarRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewFiscalCalendarRepository creates a new instance of fiscalCalendarRepository for the default tenant.
+func NewFiscalCalendarRepository(db *sql.DB) FiscalCalendarRepository {
+	return &fiscalCalendarRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Get retrieves the tenant's fiscal calendar.
+func (r *fiscalCalendarRepository) Get() (models.FiscalCalendar, error) {
+	query := `SELECT start_month, named_after_end, updated_by, updated_at FROM fiscal_calendars WHERE tenant_id = ?`
+	calendar := models.FiscalCalendar{StartMonth: int(time.January)}
+	var updatedAt time.Time
+	err := r.DB.QueryRow(query, r.TenantID).Scan(&calendar.StartMonth, &calendar.NamedAfterEnd, &calendar.UpdatedBy, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.FiscalCalendar{StartMonth: int(time.January)}, nil
+	}
+	if err != nil {
+		return models.FiscalCalendar{}, fmt.Errorf("failed to get fiscal calendar: %w", err)
+	}
+	calendar.UpdatedAt = &updatedAt
+
+	return calendar, nil
+}
+
+// Save stores the fiscal calendar, creating the tenant's row if needed.
+func (r *fiscalCalendarRepository) Save(calendar models.FiscalCalendar) error {
+	query := `
+		INSERT INTO fiscal_calendars (tenant_id, start_month, named_after_end, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE start_month = VALUES(start_month), named_after_end = VALUES(named_after_end),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, calendar.StartMonth, calendar.NamedAfterEnd, calendar.UpdatedBy, calendar.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save fiscal calendar: %w", err)
+	}
+
+	return nil
+}
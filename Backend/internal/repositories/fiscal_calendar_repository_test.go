@@ -0,0 +1,63 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockFiscalCalendarRepo(t *testing.T) (repositories.FiscalCalendarRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewFiscalCalendarRepository(db), mock
+}
+
+const getFiscalCalendarQuery = `SELECT start_month, named_after_end, updated_by, updated_at FROM fiscal_calendars WHERE tenant_id = ?`
+
+func TestGetFiscalCalendar(t *testing.T) {
+	repo, mock := newMockFiscalCalendarRepo(t)
+	updatedAt := time.Now()
+	mock.ExpectQuery(getFiscalCalendarQuery).WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"start_month", "named_after_end", "updated_by", "updated_at"}).
+			AddRow(7, true, "admin-1", updatedAt))
+
+	calendar, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, models.FiscalCalendar{StartMonth: 7, NamedAfterEnd: true, UpdatedBy: "admin-1", UpdatedAt: &updatedAt}, calendar)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFiscalCalendarNotSaved(t *testing.T) {
+	repo, mock := newMockFiscalCalendarRepo(t)
+	mock.ExpectQuery(getFiscalCalendarQuery).WithArgs(models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	calendar, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, models.FiscalCalendar{StartMonth: 1}, calendar, "the calendar year")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveFiscalCalendar(t *testing.T) {
+	repo, mock := newMockFiscalCalendarRepo(t)
+	updatedAt := time.Now()
+	mock.ExpectExec(`
+		INSERT INTO fiscal_calendars (tenant_id, start_month, named_after_end, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE start_month = VALUES(start_month), named_after_end = VALUES(named_after_end),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`).WithArgs(models.DefaultTenantID, 4, false, "admin-1", &updatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Save(models.FiscalCalendar{StartMonth: 4, UpdatedBy: "admin-1", UpdatedAt: &updatedAt})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+)
+
+// GoogleSheetsExportRepository defines the interface for the spreadsheet a tenant's reports are exported to.
+type GoogleSheetsExportRepository interface {
+	// Get returns the tenant's export, or an error wrapping sql.ErrNoRows when none is set up.
+	Get() (*models.GoogleSheetsExport, error)
+	// Save replaces the spreadsheet and reports of the tenant's export, keeping how the last export went.
+	Save(export *models.GoogleSheetsExport) error
+	// RecordExport stores when the last export ran and why it failed, empty when it succeeded.
+	RecordExport(at time.Time, exportErr string) error
+	// Delete stops exporting the tenant's reports.
+	Delete() error
+}
+
+// googleSheetsExportRepository implements the GoogleSheetsExportRepository interface.
+type googleSheetsExportRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewGoogleSheetsExportRepository creates a new instance of googleSheetsExportRepository for the default tenant.
+func NewGoogleSheetsExportRepository(db *sql.DB) GoogleSheetsExportRepository {
+	return &googleSheetsExportRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Get retrieves the tenant's export.
+func (r *googleSheetsExportRepository) Get() (*models.GoogleSheetsExport, error) {
+	query := `SELECT spreadsheet_id, reports, updated_by, updated_at, last_export_at, COALESCE(last_error, '')
+		FROM google_sheets_exports WHERE tenant_id = ?`
+
+	var export models.GoogleSheetsExport
+	var reports string
+	var lastExportAt sql.NullTime
+	err := r.DB.QueryRow(query, r.TenantID).Scan(&export.SpreadsheetID, &reports, &export.UpdatedBy, &export.UpdatedAt, &lastExportAt, &export.LastError)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("google sheets export not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get google sheets export: %w", err)
+	}
+	export.Reports = strings.Split(reports, ",")
+	if lastExportAt.Valid {
+		export.LastExportAt = &lastExportAt.Time
+	}
+	return &export, nil
+}
+
+// Save stores the export, creating the tenant's row if needed.
+func (r *googleSheetsExportRepository) Save(export *models.GoogleSheetsExport) error {
+	query := `
+		INSERT INTO google_sheets_exports (tenant_id, spreadsheet_id, reports, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE spreadsheet_id = VALUES(spreadsheet_id), reports = VALUES(reports),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`
+	_, err := r.DB.Exec(query, r.TenantID, export.SpreadsheetID, strings.Join(export.Reports, ","), export.UpdatedBy, export.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save google sheets export: %w", err)
+	}
+	return nil
+}
+
+// RecordExport stores the outcome of the last export.
+func (r *googleSheetsExportRepository) RecordExport(at time.Time, exportErr string) error {
+	query := `UPDATE google_sheets_exports SET last_export_at = ?, last_error = NULLIF(?, '') WHERE tenant_id = ?`
+	if _, err := r.DB.Exec(query, at, exportErr, r.TenantID); err != nil {
+		return fmt.Errorf("failed to record google sheets export: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the tenant's export.
+func (r *googleSheetsExportRepository) Delete() error {
+	query := `DELETE FROM google_sheets_exports WHERE tenant_id = ?`
+	result, err := r.DB.Exec(query, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete google sheets export: %w", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected == 0 {
+		return fmt.Errorf("google sheets export not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const getGoogleSheetsExportQuery = `SELECT spreadsheet_id, reports, updated_by, updated_at, last_export_at, COALESCE(last_error, '')
+		FROM google_sheets_exports WHERE tenant_id = ?`
+
+func newMockGoogleSheetsExportRepo(t *testing.T) (repositories.GoogleSheetsExportRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewGoogleSheetsExportRepository(db), mock
+}
+
+func TestGetGoogleSheetsExport(t *testing.T) {
+	repo, mock := newMockGoogleSheetsExportRepo(t)
+	updatedAt := time.Now()
+	mock.ExpectQuery(getGoogleSheetsExportQuery).WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"spreadsheet_id", "reports", "updated_by", "updated_at", "last_export_at", "last_error"}).
+			AddRow("sheet-1", "daily_sales,low_stock", "admin-1", updatedAt, nil, ""))
+
+	export, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.SheetsReportDailySales, models.SheetsReportLowStock}, export.Reports)
+	assert.Nil(t, export.LastExportAt, "never exported")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetGoogleSheetsExportNotSetUp(t *testing.T) {
+	repo, mock := newMockGoogleSheetsExportRepo(t)
+	mock.ExpectQuery(getGoogleSheetsExportQuery).WithArgs(models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.Get()
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveGoogleSheetsExport(t *testing.T) {
+	repo, mock := newMockGoogleSheetsExportRepo(t)
+	updatedAt := time.Now()
+	mock.ExpectExec(`
+		INSERT INTO google_sheets_exports (tenant_id, spreadsheet_id, reports, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE spreadsheet_id = VALUES(spreadsheet_id), reports = VALUES(reports),
+			updated_by = VALUES(updated_by), updated_at = VALUES(updated_at)
+	`).WithArgs(models.DefaultTenantID, "sheet-1", "low_stock", "admin-1", updatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE google_sheets_exports SET last_export_at = ?, last_error = NULLIF(?, '') WHERE tenant_id = ?`).
+		WithArgs(updatedAt, "", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Save(&models.GoogleSheetsExport{SpreadsheetID: "sheet-1", Reports: []string{models.SheetsReportLowStock}, UpdatedBy: "admin-1", UpdatedAt: updatedAt}))
+	require.NoError(t, repo.RecordExport(updatedAt, ""))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteGoogleSheetsExportNotSetUp(t *testing.T) {
+	repo, mock := newMockGoogleSheetsExportRepo(t)
+	mock.ExpectExec(`DELETE FROM google_sheets_exports WHERE tenant_id = ?`).WithArgs(models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete()
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,199 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InsurancePolicyRepository defines the interface for the insurance policies sold or
+// arranged with sales.
+type InsurancePolicyRepository interface {
+	Create(policy *models.InsurancePolicy) error
+	// GetByID returns an error wrapping sql.ErrNoRows when the policy does not exist.
+	GetByID(id string) (*models.InsurancePolicy, error)
+	// Update saves every editable field of a policy.
+	Update(policy *models.InsurancePolicy) error
+	Delete(id string) error
+	// GetAll returns the policies matching the filter, soonest expiring first.
+	GetAll(filter models.InsurancePolicyFilter) ([]models.InsurancePolicy, error)
+}
+
+// insurancePolicyRepository implements the InsurancePolicyRepository interface.
+type insurancePolicyRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewInsurancePolicyRepository creates a new instance of insurancePolicyRepository for the default tenant.
+func NewInsurancePolicyRepository(db *sql.DB) InsurancePolicyRepository {
+	return &insurancePolicyRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const insurancePolicyColumns = `id, sale_id, customer_id, provider, policy_number, coverage, premium, start_date, expiry_date, renewal_of, notes, created_by, created_at, updated_at`
+
+// Create stores a new policy.
+func (r *insurancePolicyRepository) Create(policy *models.InsurancePolicy) error {
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	query := `
+		INSERT INTO insurance_policies (id, tenant_id, sale_id, customer_id, provider, policy_number, coverage, premium,
+			start_date, expiry_date, renewal_of, notes, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, policy.ID, r.TenantID, policy.SaleID, policy.CustomerID, policy.Provider, policy.PolicyNumber,
+		policy.Coverage, policy.Premium, policy.StartDate, policy.ExpiryDate, policy.RenewalOf, policy.Notes, policy.CreatedBy,
+		policy.CreatedAt, policy.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create insurance policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a policy by its ID.
+func (r *insurancePolicyRepository) GetByID(id string) (*models.InsurancePolicy, error) {
+	query := `SELECT ` + insurancePolicyColumns + ` FROM insurance_policies WHERE id = ? AND tenant_id = ?`
+
+	policy, err := scanInsurancePolicy(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("insurance policy not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get insurance policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Update saves every editable field of a policy.
+func (r *insurancePolicyRepository) Update(policy *models.InsurancePolicy) error {
+	policy.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE insurance_policies SET provider = ?, policy_number = ?, coverage = ?, premium = ?, start_date = ?, expiry_date = ?,
+			notes = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.DB.Exec(query, policy.Provider, policy.PolicyNumber, policy.Coverage, policy.Premium, policy.StartDate,
+		policy.ExpiryDate, policy.Notes, policy.UpdatedAt, policy.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update insurance policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("insurance policy not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Delete removes a policy.
+func (r *insurancePolicyRepository) Delete(id string) error {
+	result, err := r.DB.Exec(`DELETE FROM insurance_policies WHERE id = ? AND tenant_id = ?`, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete insurance policy: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("insurance policy not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetAll retrieves the policies matching the filter.
+func (r *insurancePolicyRepository) GetAll(filter models.InsurancePolicyFilter) ([]models.InsurancePolicy, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{r.TenantID}
+
+	if filter.SaleID != "" {
+		conditions = append(conditions, "sale_id = ?")
+		args = append(args, filter.SaleID)
+	}
+	if filter.CustomerID != "" {
+		conditions = append(conditions, "customer_id = ?")
+		args = append(args, filter.CustomerID)
+	}
+	if filter.PolicyNumber != "" {
+		conditions = append(conditions, "policy_number = ?")
+		args = append(args, filter.PolicyNumber)
+	}
+	if filter.ExpiresFrom != "" {
+		conditions = append(conditions, "expiry_date >= ?")
+		args = append(args, filter.ExpiresFrom)
+	}
+	if filter.ExpiresBefore != "" {
+		conditions = append(conditions, "expiry_date < ?")
+		args = append(args, filter.ExpiresBefore)
+	}
+	if filter.RenewalsOnly {
+		conditions = append(conditions, "renewal_of <> ''")
+	}
+
+	query := `SELECT ` + insurancePolicyColumns + ` FROM insurance_policies WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY expiry_date ASC, created_at ASC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query insurance policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []models.InsurancePolicy{}
+	for rows.Next() {
+		policy, err := scanInsurancePolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan insurance policy row: %w", err)
+		}
+		policies = append(policies, *policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating insurance policy rows: %w", err)
+	}
+
+	return policies, nil
+}
+
+func scanInsurancePolicy(row rowScanner) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+	err := row.Scan(
+		&policy.ID,
+		&policy.SaleID,
+		&policy.CustomerID,
+		&policy.Provider,
+		&policy.PolicyNumber,
+		&policy.Coverage,
+		&policy.Premium,
+		&policy.StartDate,
+		&policy.ExpiryDate,
+		&policy.RenewalOf,
+		&policy.Notes,
+		&policy.CreatedBy,
+		&policy.CreatedAt,
+		&policy.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
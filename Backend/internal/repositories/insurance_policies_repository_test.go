@@ -0,0 +1,90 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var insurancePolicyColumns = []string{"id", "sale_id", "customer_id", "provider", "policy_number", "coverage", "premium", "start_date", "expiry_date", "renewal_of", "notes", "created_by", "created_at", "updated_at"}
+
+const insurancePolicySelect = "SELECT id, sale_id, customer_id, provider, policy_number, coverage, premium, start_date, expiry_date, renewal_of, notes, created_by, created_at, updated_at FROM insurance_policies"
+
+func newMockInsurancePolicyRepo(t *testing.T) (repositories.InsurancePolicyRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewInsurancePolicyRepository(db), mock
+}
+
+func TestCreateInsurancePolicy(t *testing.T) {
+	repo, mock := newMockInsurancePolicyRepo(t)
+	mock.ExpectExec(`
+		INSERT INTO insurance_policies (id, tenant_id, sale_id, customer_id, provider, policy_number, coverage, premium,
+			start_date, expiry_date, renewal_of, notes, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "sale-1", "customer-1", "Malayan Insurance", "CTPL-1", models.InsuranceCTPL, 1200.0,
+			"2026-10-16", "2027-10-16", "", "", "staff-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	policy := &models.InsurancePolicy{SaleID: "sale-1", CustomerID: "customer-1", Provider: "Malayan Insurance", PolicyNumber: "CTPL-1",
+		Coverage: models.InsuranceCTPL, Premium: 1200, StartDate: "2026-10-16", ExpiryDate: "2027-10-16", CreatedBy: "staff-1"}
+	require.NoError(t, repo.Create(policy))
+
+	assert.NotEmpty(t, policy.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllInsurancePoliciesFilters(t *testing.T) {
+	repo, mock := newMockInsurancePolicyRepo(t)
+	now := time.Now()
+
+	query := insurancePolicySelect + " WHERE tenant_id = ? AND customer_id = ? AND expiry_date >= ? AND expiry_date < ? AND renewal_of <> ''" +
+		" ORDER BY expiry_date ASC, created_at ASC"
+	rows := sqlmock.NewRows(insurancePolicyColumns).
+		AddRow("policy-2", "sale-1", "customer-1", "FPG Insurance", "COMP-2", models.InsuranceComprehensive, 8900.0, "2026-11-05", "2027-11-05", "policy-1", "", "staff-1", now, now)
+	mock.ExpectQuery(query).
+		WithArgs(models.DefaultTenantID, "customer-1", "2026-10-16", "2027-12-01").
+		WillReturnRows(rows)
+
+	policies, err := repo.GetAll(models.InsurancePolicyFilter{CustomerID: "customer-1", ExpiresFrom: "2026-10-16", ExpiresBefore: "2027-12-01", RenewalsOnly: true})
+
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Equal(t, "policy-1", policies[0].RenewalOf)
+	assert.Equal(t, 8900.0, policies[0].Premium)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetInsurancePolicyByIDNotFound(t *testing.T) {
+	repo, mock := newMockInsurancePolicyRepo(t)
+	mock.ExpectQuery(insurancePolicySelect+" WHERE id = ? AND tenant_id = ?").
+		WithArgs("missing", models.DefaultTenantID).
+		WillReturnError(sql.ErrNoRows)
+
+	policy, err := repo.GetByID("missing")
+
+	assert.Nil(t, policy)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteInsurancePolicyNotFound(t *testing.T) {
+	repo, mock := newMockInsurancePolicyRepo(t)
+	mock.ExpectExec("DELETE FROM insurance_policies WHERE id = ? AND tenant_id = ?").
+		WithArgs("missing", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete("missing")
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
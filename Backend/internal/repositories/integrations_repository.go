@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IntegrationRepository defines the interface for the partner systems that post to the
+// inbound integration endpoint, the nonces of their requests and the orders they sent.
+type IntegrationRepository interface {
+	Create(integration *models.Integration) error
+	GetByID(id string) (*models.Integration, error)
+	// GetAll returns every integration, newest first.
+	GetAll() ([]models.Integration, error)
+	// SetSecret replaces the secret of an integration.
+	SetSecret(id, secret string) error
+	// Deactivate stops an integration from being accepted, keeping its orders.
+	Deactivate(id string) error
+	// UseNonce records the nonce of a request, forgetting nonces received before
+	// forgetBefore. It reports false when the nonce was already used.
+	UseNonce(integrationID, nonce string, at, forgetBefore time.Time) (bool, error)
+	// GetOrderSale returns the sale an order of an integration was converted into.
+	GetOrderSale(integrationID, externalID string) (string, error)
+	// SaveOrder records the sale an order was converted into.
+	SaveOrder(integrationID, externalID, saleID string) error
+}
+
+// integrationRepository implements the IntegrationRepository interface.
+type integrationRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewIntegrationRepository creates a new instance of integrationRepository for the default tenant.
+func NewIntegrationRepository(db *sql.DB) IntegrationRepository {
+	return &integrationRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const integrationColumns = `id, name, secret, sold_by, active, created_by, created_at, updated_at`
+
+// Create stores a new integration.
+func (r *integrationRepository) Create(integration *models.Integration) error {
+	if integration.ID == "" {
+		integration.ID = uuid.New().String()
+	}
+	integration.Active = true
+	integration.CreatedAt = time.Now()
+	integration.UpdatedAt = integration.CreatedAt
+
+	query := `
+		INSERT INTO integrations (id, tenant_id, name, secret, sold_by, active, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, integration.ID, r.TenantID, integration.Name, integration.Secret, integration.SoldBy,
+		integration.Active, integration.CreatedBy, integration.CreatedAt, integration.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create integration: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an integration by its ID.
+func (r *integrationRepository) GetByID(id string) (*models.Integration, error) {
+	query := `SELECT ` + integrationColumns + ` FROM integrations WHERE id = ? AND tenant_id = ?`
+
+	var integration models.Integration
+	err := r.DB.QueryRow(query, id, r.TenantID).Scan(&integration.ID, &integration.Name, &integration.Secret, &integration.SoldBy,
+		&integration.Active, &integration.CreatedBy, &integration.CreatedAt, &integration.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("integration not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get integration: %w", err)
+	}
+	return &integration, nil
+}
+
+// GetAll retrieves every integration, newest first.
+func (r *integrationRepository) GetAll() ([]models.Integration, error) {
+	query := `SELECT ` + integrationColumns + ` FROM integrations WHERE tenant_id = ? ORDER BY created_at DESC`
+
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query integrations: %w", err)
+	}
+	defer rows.Close()
+
+	integrations := []models.Integration{}
+	for rows.Next() {
+		var integration models.Integration
+		if err := rows.Scan(&integration.ID, &integration.Name, &integration.Secret, &integration.SoldBy,
+			&integration.Active, &integration.CreatedBy, &integration.CreatedAt, &integration.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan integration row: %w", err)
+		}
+		integrations = append(integrations, integration)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating integration rows: %w", err)
+	}
+	return integrations, nil
+}
+
+// SetSecret replaces the secret of an integration.
+func (r *integrationRepository) SetSecret(id, secret string) error {
+	query := `UPDATE integrations SET secret = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`
+	return r.execIntegration(query, "update the secret of", id, secret, time.Now(), id, r.TenantID)
+}
+
+// Deactivate stops an integration from being accepted.
+func (r *integrationRepository) Deactivate(id string) error {
+	query := `UPDATE integrations SET active = FALSE, updated_at = ? WHERE id = ? AND tenant_id = ?`
+	return r.execIntegration(query, "deactivate", id, time.Now(), id, r.TenantID)
+}
+
+// execIntegration runs a statement on one integration, failing when it does not exist
+func (r *integrationRepository) execIntegration(query, action, id string, args ...interface{}) error {
+	result, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to %s integration %s: %w", action, id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("integration not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// UseNonce records the nonce of a request, reporting false when it was already used.
+func (r *integrationRepository) UseNonce(integrationID, nonce string, at, forgetBefore time.Time) (bool, error) {
+	if _, err := r.DB.Exec(`DELETE FROM integration_nonces WHERE tenant_id = ? AND received_at < ?`, r.TenantID, forgetBefore); err != nil {
+		return false, fmt.Errorf("failed to forget old integration nonces: %w", err)
+	}
+
+	query := `
+		INSERT IGNORE INTO integration_nonces (tenant_id, integration_id, nonce, received_at)
+		VALUES (?, ?, ?, ?)
+	`
+	result, err := r.DB.Exec(query, r.TenantID, integrationID, nonce, at)
+	if err != nil {
+		return false, fmt.Errorf("failed to record integration nonce: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return rowsAffected == 1, nil
+}
+
+// GetOrderSale retrieves the sale an order was converted into.
+func (r *integrationRepository) GetOrderSale(integrationID, externalID string) (string, error) {
+	query := `SELECT sale_id FROM integration_orders WHERE tenant_id = ? AND integration_id = ? AND external_id = ?`
+
+	var saleID string
+	if err := r.DB.QueryRow(query, r.TenantID, integrationID, externalID).Scan(&saleID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("integration order not found: %w", err)
+		}
+		return "", fmt.Errorf("failed to get integration order: %w", err)
+	}
+	return saleID, nil
+}
+
+// SaveOrder records the sale an order was converted into.
+func (r *integrationRepository) SaveOrder(integrationID, externalID, saleID string) error {
+	query := `
+		INSERT INTO integration_orders (tenant_id, integration_id, external_id, sale_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	if _, err := r.DB.Exec(query, r.TenantID, integrationID, externalID, saleID, time.Now()); err != nil {
+		return fmt.Errorf("failed to record integration order: %w", err)
+	}
+	return nil
+}
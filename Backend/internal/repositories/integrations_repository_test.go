@@ -0,0 +1,102 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockIntegrationRepo(t *testing.T) (repositories.IntegrationRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewIntegrationRepository(db), mock
+}
+
+func TestCreateAndGetIntegration(t *testing.T) {
+	repo, mock := newMockIntegrationRepo(t)
+
+	mock.ExpectExec(`
+		INSERT INTO integrations (id, tenant_id, name, secret, sold_by, active, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "Marketplace", "secret", "user-1", true, "admin-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	integration := &models.Integration{Name: "Marketplace", Secret: "secret", SoldBy: "user-1", CreatedBy: "admin-1"}
+	require.NoError(t, repo.Create(integration))
+	assert.NotEmpty(t, integration.ID)
+	assert.True(t, integration.Active)
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT id, name, secret, sold_by, active, created_by, created_at, updated_at FROM integrations WHERE id = ? AND tenant_id = ?`).
+		WithArgs(integration.ID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "secret", "sold_by", "active", "created_by", "created_at", "updated_at"}).
+			AddRow(integration.ID, "Marketplace", "secret", "user-1", true, "admin-1", now, now))
+	found, err := repo.GetByID(integration.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", found.Secret)
+
+	mock.ExpectQuery(`SELECT id, name, secret, sold_by, active, created_by, created_at, updated_at FROM integrations WHERE id = ? AND tenant_id = ?`).
+		WithArgs("missing", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+	_, err = repo.GetByID("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	mock.ExpectExec(`UPDATE integrations SET active = FALSE, updated_at = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(sqlmock.AnyArg(), "missing", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	err = repo.Deactivate("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUseIntegrationNonce(t *testing.T) {
+	repo, mock := newMockIntegrationRepo(t)
+	at := time.Now()
+	forgetBefore := at.Add(-10 * time.Minute)
+
+	for _, rows := range []int64{1, 0} {
+		mock.ExpectExec(`DELETE FROM integration_nonces WHERE tenant_id = ? AND received_at < ?`).
+			WithArgs(models.DefaultTenantID, forgetBefore).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`
+		INSERT IGNORE INTO integration_nonces (tenant_id, integration_id, nonce, received_at)
+		VALUES (?, ?, ?, ?)
+	`).WithArgs(models.DefaultTenantID, "integration-1", "nonce-1", at).WillReturnResult(sqlmock.NewResult(0, rows))
+	}
+
+	fresh, err := repo.UseNonce("integration-1", "nonce-1", at, forgetBefore)
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, err = repo.UseNonce("integration-1", "nonce-1", at, forgetBefore)
+	require.NoError(t, err)
+	assert.False(t, fresh, "a nonce is only accepted once")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIntegrationOrders(t *testing.T) {
+	repo, mock := newMockIntegrationRepo(t)
+
+	mock.ExpectQuery(`SELECT sale_id FROM integration_orders WHERE tenant_id = ? AND integration_id = ? AND external_id = ?`).
+		WithArgs(models.DefaultTenantID, "integration-1", "order-1").WillReturnError(sql.ErrNoRows)
+	_, err := repo.GetOrderSale("integration-1", "order-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	mock.ExpectExec(`
+		INSERT INTO integration_orders (tenant_id, integration_id, external_id, sale_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`).WithArgs(models.DefaultTenantID, "integration-1", "order-1", "sale-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SaveOrder("integration-1", "order-1", "sale-1"))
+
+	mock.ExpectQuery(`SELECT sale_id FROM integration_orders WHERE tenant_id = ? AND integration_id = ? AND external_id = ?`).
+		WithArgs(models.DefaultTenantID, "integration-1", "order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"sale_id"}).AddRow("sale-1"))
+	saleID, err := repo.GetOrderSale("integration-1", "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sale-1", saleID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
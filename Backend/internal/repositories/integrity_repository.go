@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+)
+
+// totalTolerance is how far a sale's total may be from the sum of its items before
+// they count as different, so rounding to centavos is not reported
+const totalTolerance = 0.005
+
+// IntegrityRepository defines the interface for finding the records of a tenant that
+// are inconsistent with each other.
+type IntegrityRepository interface {
+	// OrphanSaleItems returns the sale items whose sale does not exist.
+	OrphanSaleItems() ([]models.SaleItem, error)
+	// SaleTotalMismatches returns the sales with items whose total is not the sum of
+	// the items' subtotals. Sales without items are not checked.
+	SaleTotalMismatches() ([]models.SaleTotalMismatch, error)
+	// NegativeStock returns the cabs, accessories and materials with a negative quantity.
+	NegativeStock() ([]models.NegativeStock, error)
+	// SalesWithMissingCustomers returns the sales whose customer does not exist or was deleted.
+	SalesWithMissingCustomers() ([]models.SaleCustomerRef, error)
+	// DeleteOrphanSaleItems deletes the sale items with the given IDs that still have
+	// no sale, and returns how many were deleted.
+	DeleteOrphanSaleItems(ids []string) (int, error)
+	// ZeroNegativeStock sets the quantity of a cab, accessory or material to zero if
+	// it is below zero, or returns an error wrapping sql.ErrNoRows if it is not.
+	ZeroNegativeStock(itemType string, itemID int) error
+}
+
+// stockTables are the tables of the item types whose stock is counted
+var stockTables = map[string]string{
+	models.InventoryCab:       "multicabs",
+	models.InventoryAccessory: "accessories",
+	models.InventoryMaterial:  "materials",
+}
+
+// integrityRepository implements the IntegrityRepository interface.
+type integrityRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewIntegrityRepository creates a new instance of integrityRepository for the default tenant.
+func NewIntegrityRepository(db *sql.DB) IntegrityRepository {
+	return &integrityRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// OrphanSaleItems retrieves the sale items whose sale does not exist.
+func (r *integrityRepository) OrphanSaleItems() ([]models.SaleItem, error) {
+	query := `SELECT si.id, si.sale_id, si.item_type, si.multi_cab_id, si.accessory_id, si.material_id, si.quantity, si.unit_price, si.subtotal, si.created_at, si.updated_at
+		FROM sale_items si
+		LEFT JOIN sales s ON s.id = si.sale_id AND s.tenant_id = si.tenant_id
+		WHERE si.tenant_id = ? AND s.id IS NULL
+		ORDER BY si.created_at`
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphan sale items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.SaleItem{}
+	for rows.Next() {
+		var item models.SaleItem
+		var itemType, multiCabID, accessoryID, materialID string
+		if err := rows.Scan(&item.ID, &item.SaleID, &itemType, &multiCabID, &accessoryID, &materialID,
+			&item.Quantity, &item.UnitPrice, &item.Subtotal, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan orphan sale item: %w", err)
+		}
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// SaleTotalMismatches retrieves the sales whose total differs from their items.
+func (r *integrityRepository) SaleTotalMismatches() ([]models.SaleTotalMismatch, error) {
+	query := `SELECT s.id, s.total_price, SUM(si.subtotal) AS items_total
+		FROM sales s
+		JOIN sale_items si ON si.sale_id = s.id AND si.tenant_id = s.tenant_id
+		WHERE s.tenant_id = ?
+		GROUP BY s.id, s.total_price
+		HAVING ABS(s.total_price - SUM(si.subtotal)) > ?
+		ORDER BY s.id`
+	rows, err := r.DB.Query(query, r.TenantID, totalTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sale totals: %w", err)
+	}
+	defer rows.Close()
+
+	mismatches := []models.SaleTotalMismatch{}
+	for rows.Next() {
+		var mismatch models.SaleTotalMismatch
+		if err := rows.Scan(&mismatch.SaleID, &mismatch.TotalPrice, &mismatch.ItemsTotal); err != nil {
+			return nil, fmt.Errorf("failed to scan sale total: %w", err)
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	return mismatches, rows.Err()
+}
+
+// NegativeStock retrieves the cabs, accessories and materials with a negative
+// quantity, including deleted ones, which can be restored.
+func (r *integrityRepository) NegativeStock() ([]models.NegativeStock, error) {
+	query := `SELECT 'cab', id, name, quantity FROM multicabs WHERE tenant_id = ? AND quantity < 0
+		UNION ALL SELECT 'accessory', id, name, quantity FROM accessories WHERE tenant_id = ? AND quantity < 0
+		UNION ALL SELECT 'material', id, name, quantity FROM materials WHERE tenant_id = ? AND quantity < 0`
+	rows, err := r.DB.Query(query, r.TenantID, r.TenantID, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query negative stock: %w", err)
+	}
+	defer rows.Close()
+
+	stock := []models.NegativeStock{}
+	for rows.Next() {
+		var item models.NegativeStock
+		if err := rows.Scan(&item.ItemType, &item.ItemID, &item.Name, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan negative stock: %w", err)
+		}
+		stock = append(stock, item)
+	}
+	return stock, rows.Err()
+}
+
+// SalesWithMissingCustomers retrieves the sales whose customer is gone or deleted.
+func (r *integrityRepository) SalesWithMissingCustomers() ([]models.SaleCustomerRef, error) {
+	query := `SELECT s.id, s.customer_id, c.deleted_at
+		FROM sales s
+		LEFT JOIN customers c ON c.id = s.customer_id AND c.tenant_id = s.tenant_id
+		WHERE s.tenant_id = ? AND s.customer_id <> '' AND (c.id IS NULL OR c.deleted_at IS NOT NULL)
+		ORDER BY s.id`
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sale customers: %w", err)
+	}
+	defer rows.Close()
+
+	refs := []models.SaleCustomerRef{}
+	for rows.Next() {
+		var ref models.SaleCustomerRef
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&ref.SaleID, &ref.CustomerID, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sale customer: %w", err)
+		}
+		ref.Deleted = deletedAt.Valid
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// DeleteOrphanSaleItems deletes the given sale items, unless their sale has appeared since.
+func (r *integrityRepository) DeleteOrphanSaleItems(ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{r.TenantID}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, r.TenantID)
+	query := `DELETE FROM sale_items WHERE tenant_id = ? AND id IN (?` + strings.Repeat(", ?", len(ids)-1) + `)
+		AND sale_id NOT IN (SELECT id FROM sales WHERE tenant_id = ?)`
+	result, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphan sale items: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete orphan sale items: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// ZeroNegativeStock sets a negative quantity to zero.
+func (r *integrityRepository) ZeroNegativeStock(itemType string, itemID int) error {
+	table, ok := stockTables[itemType]
+	if !ok {
+		return fmt.Errorf("unknown item type %q", itemType)
+	}
+	query := `UPDATE ` + table + ` SET quantity = 0, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity < 0`
+	args := []interface{}{time.Now(), itemID, r.TenantID}
+	if itemType == models.InventoryAccessory {
+		// Accessories store the status that follows from their quantity
+		query = `UPDATE accessories SET quantity = 0, status = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity < 0`
+		args = append([]interface{}{string(models.StatusOutOfStock)}, args...)
+	}
+
+	result, err := r.DB.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to zero the quantity of %s %d: %w", itemType, itemID, err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to zero the quantity of %s %d: %w", itemType, itemID, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%s %d has no negative quantity: %w", itemType, itemID, sql.ErrNoRows)
+	}
+	return nil
+}
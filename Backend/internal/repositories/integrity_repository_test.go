@@ -0,0 +1,120 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockIntegrityRepo(t *testing.T) (repositories.IntegrityRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewIntegrityRepository(db), mock
+}
+
+func TestOrphanSaleItems(t *testing.T) {
+	repo, mock := newMockIntegrityRepo(t)
+	now := time.Now()
+	mock.ExpectQuery(`SELECT si.id, si.sale_id, si.item_type, si.multi_cab_id, si.accessory_id, si.material_id, si.quantity, si.unit_price, si.subtotal, si.created_at, si.updated_at
+		FROM sale_items si
+		LEFT JOIN sales s ON s.id = si.sale_id AND s.tenant_id = si.tenant_id
+		WHERE si.tenant_id = ? AND s.id IS NULL
+		ORDER BY si.created_at`).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sale_id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price", "subtotal", "created_at", "updated_at"}).
+			AddRow("item-1", "sale-gone", "cab", "1", "", "", 1, 100.0, 100.0, now, now))
+
+	items, err := repo.OrphanSaleItems()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "sale-gone", items[0].SaleID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaleTotalMismatchesAndMissingCustomers(t *testing.T) {
+	repo, mock := newMockIntegrityRepo(t)
+	mock.ExpectQuery(`SELECT s.id, s.total_price, SUM(si.subtotal) AS items_total
+		FROM sales s
+		JOIN sale_items si ON si.sale_id = s.id AND si.tenant_id = s.tenant_id
+		WHERE s.tenant_id = ?
+		GROUP BY s.id, s.total_price
+		HAVING ABS(s.total_price - SUM(si.subtotal)) > ?
+		ORDER BY s.id`).
+		WithArgs(models.DefaultTenantID, 0.005).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "total_price", "items_total"}).AddRow("sale-1", 500.0, 400.0))
+	mock.ExpectQuery(`SELECT s.id, s.customer_id, c.deleted_at
+		FROM sales s
+		LEFT JOIN customers c ON c.id = s.customer_id AND c.tenant_id = s.tenant_id
+		WHERE s.tenant_id = ? AND s.customer_id <> '' AND (c.id IS NULL OR c.deleted_at IS NOT NULL)
+		ORDER BY s.id`).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "deleted_at"}).
+			AddRow("sale-1", "customer-1", time.Now()).
+			AddRow("sale-2", "customer-gone", nil))
+
+	mismatches, err := repo.SaleTotalMismatches()
+	require.NoError(t, err)
+	assert.Equal(t, []models.SaleTotalMismatch{{SaleID: "sale-1", TotalPrice: 500, ItemsTotal: 400}}, mismatches)
+
+	refs, err := repo.SalesWithMissingCustomers()
+	require.NoError(t, err)
+	assert.Equal(t, []models.SaleCustomerRef{
+		{SaleID: "sale-1", CustomerID: "customer-1", Deleted: true},
+		{SaleID: "sale-2", CustomerID: "customer-gone"},
+	}, refs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNegativeStock(t *testing.T) {
+	repo, mock := newMockIntegrityRepo(t)
+	mock.ExpectQuery(`SELECT 'cab', id, name, quantity FROM multicabs WHERE tenant_id = ? AND quantity < 0
+		UNION ALL SELECT 'accessory', id, name, quantity FROM accessories WHERE tenant_id = ? AND quantity < 0
+		UNION ALL SELECT 'material', id, name, quantity FROM materials WHERE tenant_id = ? AND quantity < 0`).
+		WithArgs(models.DefaultTenantID, models.DefaultTenantID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "name", "quantity"}).AddRow("material", 3, "Paint", -2))
+
+	stock, err := repo.NegativeStock()
+	require.NoError(t, err)
+	assert.Equal(t, []models.NegativeStock{{ItemType: models.InventoryMaterial, ItemID: 3, Name: "Paint", Quantity: -2}}, stock)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteOrphanSaleItems(t *testing.T) {
+	repo, mock := newMockIntegrityRepo(t)
+	mock.ExpectExec(`DELETE FROM sale_items WHERE tenant_id = ? AND id IN (?, ?)
+		AND sale_id NOT IN (SELECT id FROM sales WHERE tenant_id = ?)`).
+		WithArgs(models.DefaultTenantID, "item-1", "item-2", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	deleted, err := repo.DeleteOrphanSaleItems([]string{"item-1", "item-2"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	deleted, err = repo.DeleteOrphanSaleItems(nil)
+	require.NoError(t, err)
+	assert.Zero(t, deleted, "nothing to delete runs no statement")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestZeroNegativeStock(t *testing.T) {
+	repo, mock := newMockIntegrityRepo(t)
+	mock.ExpectExec(`UPDATE materials SET quantity = 0, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity < 0`).
+		WithArgs(sqlmock.AnyArg(), 3, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE accessories SET quantity = 0, status = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity < 0`).
+		WithArgs(string(models.StatusOutOfStock), sqlmock.AnyArg(), 4, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.ZeroNegativeStock(models.InventoryMaterial, 3))
+	err := repo.ZeroNegativeStock(models.InventoryAccessory, 4)
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "no longer negative")
+	assert.Error(t, repo.ZeroNegativeStock("customer", 1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
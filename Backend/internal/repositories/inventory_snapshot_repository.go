@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+)
+
+// InventorySnapshotRepository defines the interface for end-of-month inventory snapshots.
+type InventorySnapshotRepository interface {
+	// Save stores a month's snapshot, replacing one taken before for the same month.
+	Save(snapshot *models.InventorySnapshot) error
+	// Get returns a month's snapshot with its items by type, then item ID.
+	Get(month string) (*models.InventorySnapshot, error)
+}
+
+// inventorySnapshotRepository implements the InventorySnapshotRepository interface.
+type inventorySnapshotRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewInventorySnapshotRepository creates a new instance of inventorySnapshotRepository for the default tenant.
+func NewInventorySnapshotRepository(db *sql.DB) InventorySnapshotRepository {
+	return &inventorySnapshotRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Save stores a snapshot and its items in one transaction.
+func (r *inventorySnapshotRepository) Save(snapshot *models.InventorySnapshot) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM inventory_snapshot_items WHERE tenant_id = ? AND month = ?`, r.TenantID, snapshot.Month)
+	if err != nil {
+		return fmt.Errorf("failed to clear inventory snapshot items: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO inventory_snapshots (tenant_id, month, taken_at, total_quantity, total_value)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE taken_at = VALUES(taken_at), total_quantity = VALUES(total_quantity), total_value = VALUES(total_value)
+	`, r.TenantID, snapshot.Month, snapshot.TakenAt, snapshot.TotalQuantity, snapshot.TotalValue)
+	if err != nil {
+		return fmt.Errorf("failed to save inventory snapshot: %w", err)
+	}
+
+	for _, item := range snapshot.Items {
+		_, err = tx.Exec(`
+			INSERT INTO inventory_snapshot_items (tenant_id, month, item_type, item_id, name, quantity, unit_price, value)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.TenantID, snapshot.Month, item.ItemType, item.ItemID, item.Name, item.Quantity, item.UnitPrice, item.Value)
+		if err != nil {
+			return fmt.Errorf("failed to save inventory snapshot item: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a month's snapshot.
+func (r *inventorySnapshotRepository) Get(month string) (*models.InventorySnapshot, error) {
+	snapshot := models.InventorySnapshot{Month: month, Items: []models.InventorySnapshotItem{}}
+	err := r.DB.QueryRow(`SELECT taken_at, total_quantity, total_value FROM inventory_snapshots WHERE tenant_id = ? AND month = ?`,
+		r.TenantID, month).Scan(&snapshot.TakenAt, &snapshot.TotalQuantity, &snapshot.TotalValue)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("inventory snapshot not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get inventory snapshot: %w", err)
+	}
+
+	rows, err := r.DB.Query(`
+		SELECT item_type, item_id, name, quantity, unit_price, value
+		FROM inventory_snapshot_items WHERE tenant_id = ? AND month = ?
+		ORDER BY item_type, item_id
+	`, r.TenantID, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inventory snapshot items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.InventorySnapshotItem
+		if err := rows.Scan(&item.ItemType, &item.ItemID, &item.Name, &item.Quantity, &item.UnitPrice, &item.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan inventory snapshot item row: %w", err)
+		}
+		snapshot.Items = append(snapshot.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inventory snapshot item rows: %w", err)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,94 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockInventorySnapshotRepo(t *testing.T) (repositories.InventorySnapshotRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewInventorySnapshotRepository(db), mock
+}
+
+func TestSaveInventorySnapshot(t *testing.T) {
+	repo, mock := newMockInventorySnapshotRepo(t)
+	takenAt := time.Now()
+	snapshot := &models.InventorySnapshot{
+		Month:         "2025-03",
+		TakenAt:       takenAt,
+		TotalQuantity: 10,
+		TotalValue:    3000,
+		Items: []models.InventorySnapshotItem{
+			{ItemType: models.InventoryAccessory, ItemID: 4, Name: "Side mirror", Quantity: 6, UnitPrice: 500, Value: 3000},
+			{ItemType: models.InventoryMaterial, ItemID: 2, Name: "Paint", Quantity: 4},
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM inventory_snapshot_items WHERE tenant_id = ? AND month = ?`).
+		WithArgs(models.DefaultTenantID, "2025-03").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`
+		INSERT INTO inventory_snapshots (tenant_id, month, taken_at, total_quantity, total_value)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE taken_at = VALUES(taken_at), total_quantity = VALUES(total_quantity), total_value = VALUES(total_value)
+	`).WithArgs(models.DefaultTenantID, "2025-03", takenAt, 10, 3000.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	insertItem := `
+			INSERT INTO inventory_snapshot_items (tenant_id, month, item_type, item_id, name, quantity, unit_price, value)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`
+	mock.ExpectExec(insertItem).WithArgs(models.DefaultTenantID, "2025-03", models.InventoryAccessory, 4, "Side mirror", 6, 500.0, 3000.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WithArgs(models.DefaultTenantID, "2025-03", models.InventoryMaterial, 2, "Paint", 4, 0.0, 0.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Save(snapshot))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetInventorySnapshot(t *testing.T) {
+	repo, mock := newMockInventorySnapshotRepo(t)
+	takenAt := time.Now()
+	mock.ExpectQuery(`SELECT taken_at, total_quantity, total_value FROM inventory_snapshots WHERE tenant_id = ? AND month = ?`).
+		WithArgs(models.DefaultTenantID, "2025-03").
+		WillReturnRows(sqlmock.NewRows([]string{"taken_at", "total_quantity", "total_value"}).AddRow(takenAt, 3, 450000.0))
+	mock.ExpectQuery(`
+		SELECT item_type, item_id, name, quantity, unit_price, value
+		FROM inventory_snapshot_items WHERE tenant_id = ? AND month = ?
+		ORDER BY item_type, item_id
+	`).WithArgs(models.DefaultTenantID, "2025-03").
+		WillReturnRows(sqlmock.NewRows([]string{"item_type", "item_id", "name", "quantity", "unit_price", "value"}).
+			AddRow(models.InventoryCab, 1, "Scrum", 3, 150000.0, 450000.0))
+
+	snapshot, err := repo.Get("2025-03")
+	require.NoError(t, err)
+	assert.Equal(t, &models.InventorySnapshot{
+		Month:         "2025-03",
+		TakenAt:       takenAt,
+		TotalQuantity: 3,
+		TotalValue:    450000,
+		Items:         []models.InventorySnapshotItem{{ItemType: models.InventoryCab, ItemID: 1, Name: "Scrum", Quantity: 3, UnitPrice: 150000, Value: 450000}},
+	}, snapshot)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetInventorySnapshotNotTaken(t *testing.T) {
+	repo, mock := newMockInventorySnapshotRepo(t)
+	mock.ExpectQuery(`SELECT taken_at, total_quantity, total_value FROM inventory_snapshots WHERE tenant_id = ? AND month = ?`).
+		WithArgs(models.DefaultTenantID, "2025-02").WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.Get("2025-02")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
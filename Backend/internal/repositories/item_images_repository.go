@@ -0,0 +1,480 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ItemImageRepository defines the interface for the photo galleries of cabs,
+// accessories and materials.
+type ItemImageRepository interface {
+	// Add stores a photo at the end of an item's gallery, setting its size and content
+	// hash. The first photo of a gallery becomes its primary photo.
+	Add(image *models.ItemImage, data []byte) error
+	// GetAll returns the photos of an item in gallery order with the variants made of
+	// them so far, without their contents.
+	GetAll(itemType string, itemID int) ([]models.ItemImage, error)
+	// GetFile returns a photo of an item with its contents.
+	GetFile(itemType string, itemID int, id string) (*models.ItemImage, []byte, error)
+	// Reorder puts the photos of an item in the order of ids, which must list each of
+	// them once.
+	Reorder(itemType string, itemID int, ids []string) error
+	SetPrimary(itemType string, itemID int, id string) error
+	// Delete removes a photo of an item and its variants. When it was the primary
+	// photo, the first of the remaining ones becomes primary.
+	Delete(itemType string, itemID int, id string) error
+	// SaveVariant stores a smaller copy of a photo, replacing the one of the same size.
+	// It does nothing when the photo has been deleted in the meantime.
+	SaveVariant(variant models.ItemImageVariant, data []byte) error
+	// GetVariant returns a variant of a photo of an item with its contents.
+	GetVariant(itemType string, itemID int, id, size string) (*models.ItemImageVariant, []byte, error)
+	// FindByHash returns the photos with a content hash in any gallery, oldest first.
+	FindByHash(hash string) ([]models.ItemImage, error)
+	// GetDuplicates returns the photos whose content hash is shared by more than one
+	// item, grouped by hash and oldest first within a group.
+	GetDuplicates() ([]models.ItemImage, error)
+}
+
+// ItemImageHash returns the content hash of a photo
+func ItemImageHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// itemImageRepository implements the ItemImageRepository interface.
+type itemImageRepository struct {
+	DB       *sql.DB
+	TenantID string    // Every query is scoped to this tenant
+	Files    FileStore // Keeps the contents of photos and variants when set
+}
+
+// NewItemImageRepository creates a new instance of itemImageRepository for the default tenant.
+func NewItemImageRepository(db *sql.DB) ItemImageRepository {
+	return &itemImageRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const itemImageColumns = `id, item_type, item_id, file_name, content_type, size, content_hash, position, is_primary, uploaded_by, uploaded_at`
+
+// Add stores a photo at the end of an item's gallery.
+func (r *itemImageRepository) Add(image *models.ItemImage, data []byte) error {
+	if image.ID == "" {
+		image.ID = uuid.New().String()
+	}
+	image.Size = len(data)
+	image.ContentHash = ItemImageHash(data)
+
+	storageKey, stored, err := putFile(r.Files, fileKey(r.TenantID, "item-images", image.ID, "original"), image.ContentType, data)
+	if err != nil {
+		return err
+	}
+	if err := r.insert(image, storageKey, stored); err != nil {
+		removeFiles(r.Files, storageKey)
+		return err
+	}
+	return nil
+}
+
+// insert adds the row of a photo at the end of its item's gallery.
+func (r *itemImageRepository) insert(image *models.ItemImage, storageKey string, data []byte) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	err = tx.QueryRow(`SELECT COUNT(*), COALESCE(MAX(position) + 1, 0) FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ?`,
+		r.TenantID, image.ItemType, image.ItemID).Scan(&count, &image.Position)
+	if err != nil {
+		return fmt.Errorf("failed to count item images: %w", err)
+	}
+	image.IsPrimary = count == 0
+
+	query := `
+		INSERT INTO item_images (id, tenant_id, item_type, item_id, file_name, content_type, size, content_hash, storage_key, data, position, is_primary, uploaded_by, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = tx.Exec(query, image.ID, r.TenantID, image.ItemType, image.ItemID, image.FileName, image.ContentType,
+		image.Size, image.ContentHash, storageKey, data, image.Position, image.IsPrimary, image.UploadedBy, image.UploadedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add item image: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetAll retrieves the photos of an item in gallery order.
+func (r *itemImageRepository) GetAll(itemType string, itemID int) ([]models.ItemImage, error) {
+	query := `SELECT ` + itemImageColumns + ` FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ? ORDER BY position`
+
+	images, err := r.queryImages(query, r.TenantID, itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return images, nil
+	}
+
+	variants, err := r.getVariants(itemType, itemID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range images {
+		images[i].Variants = variants[images[i].ID]
+	}
+	return images, nil
+}
+
+// getVariants retrieves the variants of the photos of an item by photo id, smallest first.
+func (r *itemImageRepository) getVariants(itemType string, itemID int) (map[string][]models.ItemImageVariant, error) {
+	query := `
+		SELECT v.image_id, v.size, v.content_type, v.width, v.height, v.file_size
+		FROM item_image_variants v
+		JOIN item_images i ON i.id = v.image_id AND i.tenant_id = v.tenant_id
+		WHERE v.tenant_id = ? AND i.item_type = ? AND i.item_id = ?
+		ORDER BY v.width * v.height
+	`
+	rows, err := r.DB.Query(query, r.TenantID, itemType, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item image variants: %w", err)
+	}
+	defer rows.Close()
+
+	variants := map[string][]models.ItemImageVariant{}
+	for rows.Next() {
+		var variant models.ItemImageVariant
+		if err := rows.Scan(&variant.ImageID, &variant.Size, &variant.ContentType, &variant.Width, &variant.Height, &variant.FileSize); err != nil {
+			return nil, fmt.Errorf("failed to scan item image variant row: %w", err)
+		}
+		variants[variant.ImageID] = append(variants[variant.ImageID], variant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating item image variant rows: %w", err)
+	}
+	return variants, nil
+}
+
+// GetFile retrieves a photo of an item with its contents.
+func (r *itemImageRepository) GetFile(itemType string, itemID int, id string) (*models.ItemImage, []byte, error) {
+	query := `SELECT ` + itemImageColumns + `, storage_key, data FROM item_images WHERE id = ? AND tenant_id = ? AND item_type = ? AND item_id = ?`
+
+	var image models.ItemImage
+	var storageKey string
+	var data []byte
+	err := r.DB.QueryRow(query, id, r.TenantID, itemType, itemID).Scan(
+		&image.ID,
+		&image.ItemType,
+		&image.ItemID,
+		&image.FileName,
+		&image.ContentType,
+		&image.Size,
+		&image.ContentHash,
+		&image.Position,
+		&image.IsPrimary,
+		&image.UploadedBy,
+		&image.UploadedAt,
+		&storageKey,
+		&data,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("item image not found: %w", err)
+		}
+		return nil, nil, fmt.Errorf("failed to get item image: %w", err)
+	}
+
+	data, err = readFile(r.Files, storageKey, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &image, data, nil
+}
+
+// Reorder puts the photos of an item in the order of ids.
+func (r *itemImageRepository) Reorder(itemType string, itemID int, ids []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Rows updated to the position they had already do not count as affected, so the
+	// order is checked against the gallery first
+	rows, err := tx.Query(`SELECT id FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ?`, r.TenantID, itemType, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to query item images: %w", err)
+	}
+	gallery := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan item image row: %w", err)
+		}
+		gallery[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating item image rows: %w", err)
+	}
+	if err := checkGalleryOrder(gallery, ids); err != nil {
+		return err
+	}
+
+	for position, id := range ids {
+		_, err := tx.Exec(`UPDATE item_images SET position = ? WHERE id = ? AND tenant_id = ?`, position, id, r.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to reorder item images: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// checkGalleryOrder returns an error wrapping sql.ErrNoRows unless ids lists each
+// photo of a gallery once
+func checkGalleryOrder(gallery map[string]bool, ids []string) error {
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !gallery[id] || seen[id] {
+			return fmt.Errorf("item image %s is not in the gallery or is listed twice: %w", id, sql.ErrNoRows)
+		}
+		seen[id] = true
+	}
+	if len(ids) != len(gallery) {
+		return fmt.Errorf("the order must list each of the %d images once: %w", len(gallery), sql.ErrNoRows)
+	}
+	return nil
+}
+
+// SetPrimary makes a photo the primary photo of its item.
+func (r *itemImageRepository) SetPrimary(itemType string, itemID int, id string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM item_images WHERE id = ? AND tenant_id = ? AND item_type = ? AND item_id = ?`,
+		id, r.TenantID, itemType, itemID).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("item image not found: %w", err)
+		}
+		return fmt.Errorf("failed to get item image: %w", err)
+	}
+
+	_, err = tx.Exec(`UPDATE item_images SET is_primary = (id = ?) WHERE tenant_id = ? AND item_type = ? AND item_id = ?`,
+		id, r.TenantID, itemType, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to set primary item image: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a photo of an item, closing the gap it leaves in the gallery order.
+func (r *itemImageRepository) Delete(itemType string, itemID int, id string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var position int
+	var primary bool
+	err = tx.QueryRow(`SELECT position, is_primary FROM item_images WHERE id = ? AND tenant_id = ? AND item_type = ? AND item_id = ?`,
+		id, r.TenantID, itemType, itemID).Scan(&position, &primary)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("item image not found: %w", err)
+		}
+		return fmt.Errorf("failed to get item image: %w", err)
+	}
+
+	var keys []string
+	if r.Files != nil {
+		keys, err = queryStorageKeys(tx, `SELECT storage_key FROM item_images WHERE id = ? AND tenant_id = ?
+			UNION ALL SELECT storage_key FROM item_image_variants WHERE image_id = ? AND tenant_id = ?`, id, r.TenantID, id, r.TenantID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM item_image_variants WHERE image_id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to delete item image variants: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM item_images WHERE id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to delete item image: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE item_images SET position = position - 1 WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND position > ?`,
+		r.TenantID, itemType, itemID, position)
+	if err != nil {
+		return fmt.Errorf("failed to reorder item images: %w", err)
+	}
+	if primary {
+		_, err = tx.Exec(`UPDATE item_images SET is_primary = TRUE WHERE tenant_id = ? AND item_type = ? AND item_id = ? ORDER BY position LIMIT 1`,
+			r.TenantID, itemType, itemID)
+		if err != nil {
+			return fmt.Errorf("failed to set primary item image: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	removeFiles(r.Files, keys...)
+	return nil
+}
+
+// SaveVariant stores a smaller copy of a photo, replacing the one of the same size.
+func (r *itemImageRepository) SaveVariant(variant models.ItemImageVariant, data []byte) error {
+	variant.FileSize = len(data)
+
+	storageKey, stored, err := putFile(r.Files, fileKey(r.TenantID, "item-images", variant.ImageID, variant.Size), variant.ContentType, data)
+	if err != nil {
+		return err
+	}
+
+	// Selecting from item_images skips variants of photos deleted while they were made
+	query := `
+		INSERT INTO item_image_variants (image_id, tenant_id, size, content_type, width, height, file_size, storage_key, data)
+		SELECT id, tenant_id, ?, ?, ?, ?, ?, ?, ? FROM item_images WHERE id = ? AND tenant_id = ?
+		ON DUPLICATE KEY UPDATE content_type = VALUES(content_type), width = VALUES(width), height = VALUES(height),
+			file_size = VALUES(file_size), storage_key = VALUES(storage_key), data = VALUES(data)
+	`
+	_, err = r.DB.Exec(query, variant.Size, variant.ContentType, variant.Width, variant.Height, variant.FileSize, storageKey, stored,
+		variant.ImageID, r.TenantID)
+	if err != nil {
+		removeFiles(r.Files, storageKey)
+		return fmt.Errorf("failed to save item image variant: %w", err)
+	}
+
+	// A stored variant of a deleted photo is removed again. Whether the photo is gone
+	// is checked rather than the affected rows, which are 0 for an unchanged variant.
+	if storageKey != "" {
+		var exists int
+		err := r.DB.QueryRow(`SELECT 1 FROM item_images WHERE id = ? AND tenant_id = ?`, variant.ImageID, r.TenantID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			removeFiles(r.Files, storageKey)
+		}
+	}
+	return nil
+}
+
+// GetVariant retrieves a variant of a photo of an item with its contents.
+func (r *itemImageRepository) GetVariant(itemType string, itemID int, id, size string) (*models.ItemImageVariant, []byte, error) {
+	query := `
+		SELECT v.image_id, v.size, v.content_type, v.width, v.height, v.file_size, v.storage_key, v.data
+		FROM item_image_variants v
+		JOIN item_images i ON i.id = v.image_id AND i.tenant_id = v.tenant_id
+		WHERE v.image_id = ? AND v.size = ? AND v.tenant_id = ? AND i.item_type = ? AND i.item_id = ?
+	`
+	var variant models.ItemImageVariant
+	var storageKey string
+	var data []byte
+	err := r.DB.QueryRow(query, id, size, r.TenantID, itemType, itemID).Scan(
+		&variant.ImageID,
+		&variant.Size,
+		&variant.ContentType,
+		&variant.Width,
+		&variant.Height,
+		&variant.FileSize,
+		&storageKey,
+		&data,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("item image variant not found: %w", err)
+		}
+		return nil, nil, fmt.Errorf("failed to get item image variant: %w", err)
+	}
+
+	data, err = readFile(r.Files, storageKey, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &variant, data, nil
+}
+
+// FindByHash retrieves the photos with a content hash in any gallery, oldest first.
+func (r *itemImageRepository) FindByHash(hash string) ([]models.ItemImage, error) {
+	query := `SELECT ` + itemImageColumns + ` FROM item_images WHERE tenant_id = ? AND content_hash = ? ORDER BY uploaded_at`
+	return r.queryImages(query, r.TenantID, hash)
+}
+
+// GetDuplicates retrieves the photos whose content hash is shared by more than one item.
+func (r *itemImageRepository) GetDuplicates() ([]models.ItemImage, error) {
+	query := `
+		SELECT ` + itemImageColumns + ` FROM item_images
+		WHERE tenant_id = ? AND content_hash IN (
+			SELECT content_hash FROM item_images
+			WHERE tenant_id = ? AND content_hash <> ''
+			GROUP BY content_hash
+			HAVING COUNT(DISTINCT item_type, item_id) > 1
+		)
+		ORDER BY content_hash, uploaded_at
+	`
+	return r.queryImages(query, r.TenantID, r.TenantID)
+}
+
+// queryImages runs a query selecting itemImageColumns and scans its rows.
+func (r *itemImageRepository) queryImages(query string, args ...interface{}) ([]models.ItemImage, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item images: %w", err)
+	}
+	defer rows.Close()
+
+	images := []models.ItemImage{}
+	for rows.Next() {
+		image, err := scanItemImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan item image row: %w", err)
+		}
+		images = append(images, *image)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating item image rows: %w", err)
+	}
+	return images, nil
+}
+
+func scanItemImage(row rowScanner) (*models.ItemImage, error) {
+	var image models.ItemImage
+	err := row.Scan(
+		&image.ID,
+		&image.ItemType,
+		&image.ItemID,
+		&image.FileName,
+		&image.ContentType,
+		&image.Size,
+		&image.ContentHash,
+		&image.Position,
+		&image.IsPrimary,
+		&image.UploadedBy,
+		&image.UploadedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
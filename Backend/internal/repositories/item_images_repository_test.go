@@ -0,0 +1,127 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockItemImageRepo(t *testing.T) (repositories.ItemImageRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewItemImageRepository(db), mock
+}
+
+func TestAddItemImage(t *testing.T) {
+	repo, mock := newMockItemImageRepo(t)
+	uploadedAt := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT(*), COALESCE(MAX(position) + 1, 0) FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs(models.DefaultTenantID, "cab", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "position"}).AddRow(2, 2))
+	mock.ExpectExec(`
+		INSERT INTO item_images (id, tenant_id, item_type, item_id, file_name, content_type, size, content_hash, storage_key, data, position, is_primary, uploaded_by, uploaded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "cab", 1, "side.jpg", "image/jpeg", 3, repositories.ItemImageHash([]byte("jpg")), "", []byte("jpg"), 2, false, "staff-1", uploadedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	image := &models.ItemImage{ItemType: "cab", ItemID: 1, FileName: "side.jpg", ContentType: "image/jpeg", UploadedBy: "staff-1", UploadedAt: uploadedAt}
+	require.NoError(t, repo.Add(image, []byte("jpg")))
+	assert.NotEmpty(t, image.ID)
+	assert.Equal(t, 2, image.Position)
+	assert.False(t, image.IsPrimary, "the gallery has a primary photo already")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReorderItemImages(t *testing.T) {
+	repo, mock := newMockItemImageRepo(t)
+	gallery := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"id"}).AddRow("image-1").AddRow("image-2") }
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs(models.DefaultTenantID, "accessory", 4).WillReturnRows(gallery())
+	mock.ExpectExec(`UPDATE item_images SET position = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(0, "image-2", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE item_images SET position = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(1, "image-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM item_images WHERE tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs(models.DefaultTenantID, "accessory", 4).WillReturnRows(gallery())
+	mock.ExpectRollback()
+
+	require.NoError(t, repo.Reorder("accessory", 4, []string{"image-2", "image-1"}))
+	err := repo.Reorder("accessory", 4, []string{"image-2", "image-2"})
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "every photo is listed once")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeletePrimaryItemImage(t *testing.T) {
+	repo, mock := newMockItemImageRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT position, is_primary FROM item_images WHERE id = ? AND tenant_id = ? AND item_type = ? AND item_id = ?`).
+		WithArgs("image-1", models.DefaultTenantID, "material", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "is_primary"}).AddRow(0, true))
+	mock.ExpectExec(`DELETE FROM item_image_variants WHERE image_id = ? AND tenant_id = ?`).
+		WithArgs("image-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM item_images WHERE id = ? AND tenant_id = ?`).
+		WithArgs("image-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE item_images SET position = position - 1 WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND position > ?`).
+		WithArgs(models.DefaultTenantID, "material", 2, 0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE item_images SET is_primary = TRUE WHERE tenant_id = ? AND item_type = ? AND item_id = ? ORDER BY position LIMIT 1`).
+		WithArgs(models.DefaultTenantID, "material", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Delete("material", 2, "image-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveItemImageVariant(t *testing.T) {
+	repo, mock := newMockItemImageRepo(t)
+	mock.ExpectExec(`
+		INSERT INTO item_image_variants (image_id, tenant_id, size, content_type, width, height, file_size, storage_key, data)
+		SELECT id, tenant_id, ?, ?, ?, ?, ?, ?, ? FROM item_images WHERE id = ? AND tenant_id = ?
+		ON DUPLICATE KEY UPDATE content_type = VALUES(content_type), width = VALUES(width), height = VALUES(height),
+			file_size = VALUES(file_size), storage_key = VALUES(storage_key), data = VALUES(data)
+	`).WithArgs("thumb", "image/jpeg", 160, 120, 4, "", []byte("jpeg"), "image-1", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	variant := models.ItemImageVariant{ImageID: "image-1", Size: "thumb", ContentType: "image/jpeg", Width: 160, Height: 120}
+	require.NoError(t, repo.SaveVariant(variant, []byte("jpeg")))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDuplicateItemImages(t *testing.T) {
+	repo, mock := newMockItemImageRepo(t)
+	uploadedAt := time.Now()
+	hash := repositories.ItemImageHash([]byte("jpg"))
+	mock.ExpectQuery(`
+		SELECT id, item_type, item_id, file_name, content_type, size, content_hash, position, is_primary, uploaded_by, uploaded_at FROM item_images
+		WHERE tenant_id = ? AND content_hash IN (
+			SELECT content_hash FROM item_images
+			WHERE tenant_id = ? AND content_hash <> ''
+			GROUP BY content_hash
+			HAVING COUNT(DISTINCT item_type, item_id) > 1
+		)
+		ORDER BY content_hash, uploaded_at
+	`).WithArgs(models.DefaultTenantID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_type", "item_id", "file_name", "content_type", "size", "content_hash", "position", "is_primary", "uploaded_by", "uploaded_at"}).
+			AddRow("image-1", "cab", 1, "front.jpg", "image/jpeg", 3, hash, 0, true, "staff-1", uploadedAt).
+			AddRow("image-2", "cab", 2, "front.jpg", "image/jpeg", 3, hash, 0, true, "staff-1", uploadedAt))
+
+	images, err := repo.GetDuplicates()
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, hash, images[1].ContentHash)
+	assert.Equal(t, 2, images[1].ItemID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
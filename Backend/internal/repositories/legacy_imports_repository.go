@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LegacyImportRepository defines the interface for the imports of legacy spreadsheets
+// and the records their rows were imported as.
+type LegacyImportRepository interface {
+	// CreateRun stores a new run, assigning its ID and timestamps.
+	CreateRun(run *models.LegacyImportRun) error
+	// UpdateRun saves the status and progress of a run, setting its UpdatedAt.
+	UpdateRun(run *models.LegacyImportRun) error
+	// GetRun returns a run, or an error wrapping sql.ErrNoRows.
+	GetRun(id string) (*models.LegacyImportRun, error)
+	// GetRuns returns every run, newest first.
+	GetRuns() ([]models.LegacyImportRun, error)
+	// GetRecords returns the ID of the record each legacy key of a kind was imported as.
+	GetRecords(kind string) (map[string]string, error)
+	// AddRecord records the record a legacy row was imported as.
+	AddRecord(kind, legacyKey, entityID string) error
+}
+
+// legacyImportRepository implements the LegacyImportRepository interface.
+type legacyImportRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewLegacyImportRepository creates a new instance of legacyImportRepository for the default tenant.
+func NewLegacyImportRepository(db *sql.DB) LegacyImportRepository {
+	return &legacyImportRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const legacyImportRunColumns = `id, kind, file_name, checksum, mapping, status, total_rows, next_row, inserted, matched, skipped, rejected, error, started_by, created_at, updated_at`
+
+// CreateRun inserts a run.
+func (r *legacyImportRepository) CreateRun(run *models.LegacyImportRun) error {
+	mapping, err := json.Marshal(run.Mapping)
+	if err != nil {
+		return fmt.Errorf("failed to encode legacy import mapping: %w", err)
+	}
+	run.ID = uuid.New().String()
+	run.CreatedAt = time.Now()
+	run.UpdatedAt = run.CreatedAt
+	query := `
+		INSERT INTO legacy_import_runs (id, tenant_id, kind, file_name, checksum, mapping, status, total_rows, next_row, inserted, matched, skipped, rejected, error, started_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = r.DB.Exec(query, run.ID, r.TenantID, run.Kind, run.FileName, run.Checksum, string(mapping), run.Status, run.TotalRows,
+		run.NextRow, run.Inserted, run.Matched, run.Skipped, run.Rejected, run.Error, run.StartedBy, run.CreatedAt, run.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create legacy import run: %w", err)
+	}
+	return nil
+}
+
+// UpdateRun saves the progress of a run.
+func (r *legacyImportRepository) UpdateRun(run *models.LegacyImportRun) error {
+	run.UpdatedAt = time.Now()
+	query := `
+		UPDATE legacy_import_runs SET status = ?, next_row = ?, inserted = ?, matched = ?, skipped = ?, rejected = ?, error = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.DB.Exec(query, run.Status, run.NextRow, run.Inserted, run.Matched, run.Skipped, run.Rejected, run.Error, run.UpdatedAt, run.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update legacy import run: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("legacy import run not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// GetRun retrieves a run.
+func (r *legacyImportRepository) GetRun(id string) (*models.LegacyImportRun, error) {
+	query := `SELECT ` + legacyImportRunColumns + ` FROM legacy_import_runs WHERE id = ? AND tenant_id = ?`
+	run, err := scanLegacyImportRun(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("legacy import run not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get legacy import run: %w", err)
+	}
+	return run, nil
+}
+
+// GetRuns retrieves every run.
+func (r *legacyImportRepository) GetRuns() ([]models.LegacyImportRun, error) {
+	query := `SELECT ` + legacyImportRunColumns + ` FROM legacy_import_runs WHERE tenant_id = ? ORDER BY created_at DESC, id`
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list legacy import runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := []models.LegacyImportRun{}
+	for rows.Next() {
+		run, err := scanLegacyImportRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan legacy import run: %w", err)
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating legacy import runs: %w", err)
+	}
+	return runs, nil
+}
+
+func scanLegacyImportRun(row interface{ Scan(...interface{}) error }) (*models.LegacyImportRun, error) {
+	var run models.LegacyImportRun
+	var mapping string
+	err := row.Scan(&run.ID, &run.Kind, &run.FileName, &run.Checksum, &mapping, &run.Status, &run.TotalRows, &run.NextRow,
+		&run.Inserted, &run.Matched, &run.Skipped, &run.Rejected, &run.Error, &run.StartedBy, &run.CreatedAt, &run.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(mapping), &run.Mapping); err != nil {
+		return nil, fmt.Errorf("failed to decode legacy import mapping: %w", err)
+	}
+	return &run, nil
+}
+
+// GetRecords retrieves the records the legacy rows of a kind were imported as.
+func (r *legacyImportRepository) GetRecords(kind string) (map[string]string, error) {
+	rows, err := r.DB.Query(`SELECT legacy_key, entity_id FROM legacy_import_records WHERE tenant_id = ? AND kind = ?`, r.TenantID, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query legacy import records: %w", err)
+	}
+	defer rows.Close()
+
+	records := map[string]string{}
+	for rows.Next() {
+		var legacyKey, entityID string
+		if err := rows.Scan(&legacyKey, &entityID); err != nil {
+			return nil, fmt.Errorf("failed to scan legacy import record: %w", err)
+		}
+		records[legacyKey] = entityID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating legacy import records: %w", err)
+	}
+	return records, nil
+}
+
+// AddRecord inserts a legacy import record.
+func (r *legacyImportRepository) AddRecord(kind, legacyKey, entityID string) error {
+	_, err := r.DB.Exec(`INSERT INTO legacy_import_records (tenant_id, kind, legacy_key, entity_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+		r.TenantID, kind, legacyKey, entityID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record legacy %s %q: %w", kind, legacyKey, err)
+	}
+	return nil
+}
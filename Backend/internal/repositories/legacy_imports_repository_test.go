@@ -0,0 +1,79 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockLegacyImportRepo(t *testing.T) (repositories.LegacyImportRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewLegacyImportRepository(db), mock
+}
+
+func TestGetLegacyImportRun(t *testing.T) {
+	repo, mock := newMockLegacyImportRepo(t)
+	now := time.Now()
+	query := `SELECT id, kind, file_name, checksum, mapping, status, total_rows, next_row, inserted, matched, skipped, rejected, error, started_by, created_at, updated_at FROM legacy_import_runs WHERE id = ? AND tenant_id = ?`
+	mock.ExpectQuery(query).WithArgs("run-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "file_name", "checksum", "mapping", "status", "total_rows", "next_row",
+			"inserted", "matched", "skipped", "rejected", "error", "started_by", "created_at", "updated_at"}).
+			AddRow("run-1", models.LegacyCabs, "units.csv", "abc", `{"name":"Unit"}`, models.LegacyImportFailed, 300, 200,
+				150, 10, 0, 40, "Failed to import line 202", "admin-1", now, now))
+	mock.ExpectQuery(query).WithArgs("run-2", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	run, err := repo.GetRun("run-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Unit", run.Mapping["name"])
+	assert.Equal(t, 200, run.NextRow)
+	assert.Equal(t, models.LegacyImportFailed, run.Status)
+
+	_, err = repo.GetRun("run-2")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateLegacyImportRun(t *testing.T) {
+	repo, mock := newMockLegacyImportRepo(t)
+	query := `
+		UPDATE legacy_import_runs SET status = ?, next_row = ?, inserted = ?, matched = ?, skipped = ?, rejected = ?, error = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	mock.ExpectExec(query).WithArgs(models.LegacyImportCompleted, 300, 250, 10, 0, 40, "", sqlmock.AnyArg(), "run-1", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WithArgs(models.LegacyImportRunning, 0, 0, 0, 0, 0, "", sqlmock.AnyArg(), "run-2", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	run := &models.LegacyImportRun{ID: "run-1", Status: models.LegacyImportCompleted, NextRow: 300, Inserted: 250, Matched: 10, Rejected: 40}
+	require.NoError(t, repo.UpdateRun(run))
+	assert.False(t, run.UpdatedAt.IsZero())
+	err := repo.UpdateRun(&models.LegacyImportRun{ID: "run-2", Status: models.LegacyImportRunning})
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLegacyImportRecords(t *testing.T) {
+	repo, mock := newMockLegacyImportRepo(t)
+	mock.ExpectExec(`INSERT INTO legacy_import_records (tenant_id, kind, legacy_key, entity_id, created_at) VALUES (?, ?, ?, ?, ?)`).
+		WithArgs(models.DefaultTenantID, models.LegacyCustomers, "C-001", "customer-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT legacy_key, entity_id FROM legacy_import_records WHERE tenant_id = ? AND kind = ?`).
+		WithArgs(models.DefaultTenantID, models.LegacyCustomers).
+		WillReturnRows(sqlmock.NewRows([]string{"legacy_key", "entity_id"}).AddRow("C-001", "customer-1").AddRow("C-002", "customer-2"))
+
+	require.NoError(t, repo.AddRecord(models.LegacyCustomers, "C-001", "customer-1"))
+	records, err := repo.GetRecords(models.LegacyCustomers)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"C-001": "customer-1", "C-002": "customer-2"}, records)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
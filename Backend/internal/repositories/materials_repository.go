@@ -1,9 +1,12 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"oop/internal/models"
@@ -12,57 +15,48 @@ import (
 
 // MaterialRepository defines the interface for material database operations
 type MaterialRepository interface {
-	GetAll(searchTerm string, category string, supplier string, status string) ([]models.Material, error)
-	GetByID(id int) (*models.Material, error)
-	Create(material *models.Material) (int, error)
-	Update(material *models.Material) error
-	Delete(id int) error
-	GetPaginated(page, limit int, searchTerm, category, supplier, status string) ([]models.Material, int64, error)
+	// GetAll returns the materials matching the filter, newest first.
+	GetAll(ctx context.Context, filter models.MaterialFilter) ([]models.Material, error)
+	GetByID(ctx context.Context, id int) (*models.Material, error)
+	Create(ctx context.Context, material *models.Material) (int, error)
+	Update(ctx context.Context, material *models.Material) error
+	Delete(ctx context.Context, id int) error
+	// Restore brings back a deleted material.
+	Restore(ctx context.Context, id int) error
+	// GetDeleted returns the deleted materials with when they were deleted, most
+	// recently deleted first.
+	GetDeleted(ctx context.Context) ([]models.Material, error)
+	// GetPaginated returns one page of the materials matching the filter, newest
+	// first, along with the total number of matches. Pages start at 1.
+	GetPaginated(ctx context.Context, page, limit int, filter models.MaterialFilter) ([]models.Material, int64, error)
+	// BulkImport inserts the materials without an ID and updates the name, category,
+	// supplier, quantity and status of those with one, all or none. Inserted
+	// materials get their ID; updating a material that does not exist is an error.
+	BulkImport(ctx context.Context, materials []models.Material) error
+	// ApplyBatch creates, updates and deletes materials in one transaction, all or
+	// none. Created materials get their ID, and created and updated ones their
+	// timestamps; the change that failed is reported as a *BatchError.
+	ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Material]) error
 }
 
 // materialRepository implements the MaterialRepository interface
 type materialRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
 }
 
-// NewMaterialRepository creates a new instance of materialRepository
+// NewMaterialRepository creates a new instance of materialRepository for the default tenant
 func NewMaterialRepository(db *sql.DB) MaterialRepository {
-	return &materialRepository{DB: db}
+	return &materialRepository{DB: db, TenantID: models.DefaultTenantID}
 }
 
 // GetAll retrieves all materials from the database, with optional filtering
-func (r *materialRepository) GetAll(searchTerm string, category string, supplier string, status string) ([]models.Material, error) {
-	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1`
-	args := []interface{}{}
-
-	if searchTerm != "" {
-		// First try to convert searchTerm to integer for direct ID comparison
-		if id, err := strconv.Atoi(searchTerm); err == nil {
-			query += " AND id = ?"
-			args = append(args, id)
-		} else {
-			// If not an integer, search in text fields with case-insensitive comparison
-			query += " AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?))"
-			likeTerm := "%" + searchTerm + "%"
-			args = append(args, likeTerm, likeTerm, likeTerm)
-		}
-	}
-	if category != "" {
-		query += " AND LOWER(category) = LOWER(?)"
-		args = append(args, category)
-	}
-	if supplier != "" {
-		query += " AND LOWER(supplier) = LOWER(?)"
-		args = append(args, supplier)
-	}
-	if status != "" {
-		query += " AND LOWER(status) = LOWER(?)"
-		args = append(args, status)
-	}
-
+func (r *materialRepository) GetAll(ctx context.Context, filter models.MaterialFilter) ([]models.Material, error) {
+	where, args := r.materialsFilter(filter)
+	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials` + where
 	query += " ORDER BY created_at DESC"
 
-	rows, err := r.DB.Query(query, args...)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		log.Printf("Error querying materials: %v\nQuery: %s\nArgs: %v", err, query, args)
 		return nil, err
@@ -98,9 +92,9 @@ func (r *materialRepository) GetAll(searchTerm string, category string, supplier
 }
 
 // GetByID retrieves a single material by its ID
-func (r *materialRepository) GetByID(id int) (*models.Material, error) {
-	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE id = ?`
-	row := r.DB.QueryRow(query, id)
+func (r *materialRepository) GetByID(ctx context.Context, id int) (*models.Material, error) {
+	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+	row := r.DB.QueryRowContext(ctx, query, id, r.TenantID)
 
 	var m models.Material
 	var imageSQL sql.NullString
@@ -124,8 +118,11 @@ func (r *materialRepository) GetByID(id int) (*models.Material, error) {
 }
 
 // Create inserts a new material into the database
-func (r *materialRepository) Create(material *models.Material) (int, error) {
-	query := `INSERT INTO materials (name, category, supplier, quantity, status, image, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+func (r *materialRepository) Create(ctx context.Context, material *models.Material) (int, error) {
+	if material.Quantity < 0 {
+		return 0, negativeQuantity("material", material.Quantity)
+	}
+	query := `INSERT INTO materials (tenant_id, name, category, supplier, quantity, status, image, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 	now := time.Now()
 	
 	var imageValue interface{}
@@ -135,10 +132,10 @@ func (r *materialRepository) Create(material *models.Material) (int, error) {
 		imageValue = material.Image
 	}
 	
-	res, err := r.DB.Exec(query, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, imageValue, now, now)
+	res, err := r.DB.ExecContext(ctx, query, r.TenantID, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, imageValue, now, now)
 	if err != nil {
 		log.Printf("Error creating material: %v", err)
-		return 0, err
+		return 0, stockError(err)
 	}
 
 	id, err := res.LastInsertId()
@@ -151,8 +148,11 @@ func (r *materialRepository) Create(material *models.Material) (int, error) {
 }
 
 // Update modifies an existing material in the database
-func (r *materialRepository) Update(material *models.Material) error {
-	query := `UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, image = ?, updated_at = ? WHERE id = ?`
+func (r *materialRepository) Update(ctx context.Context, material *models.Material) error {
+	if material.Quantity < 0 {
+		return negativeQuantity("material", material.Quantity)
+	}
+	query := `UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, image = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 	now := time.Now()
 	
 	var imageValue interface{}
@@ -162,18 +162,18 @@ func (r *materialRepository) Update(material *models.Material) error {
 		imageValue = material.Image
 	}
 	
-	_, err := r.DB.Exec(query, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, imageValue, now, material.ID)
+	_, err := r.DB.ExecContext(ctx, query, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, imageValue, now, material.ID, r.TenantID)
 	if err != nil {
 		log.Printf("Error updating material ID %d: %v", material.ID, err)
-		return err
+		return stockError(err)
 	}
 	return nil
 }
 
-// Delete removes a material from the database by its ID
-func (r *materialRepository) Delete(id int) error {
-	query := `DELETE FROM materials WHERE id = ?`
-	_, err := r.DB.Exec(query, id)
+// Delete soft-deletes a material by its ID, so it can be restored
+func (r *materialRepository) Delete(ctx context.Context, id int) error {
+	query := `UPDATE materials SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
+	_, err := r.DB.ExecContext(ctx, query, time.Now(), id, r.TenantID)
 	if err != nil {
 		log.Printf("Error deleting material ID %d: %v", id, err)
 		return err
@@ -181,61 +181,72 @@ func (r *materialRepository) Delete(id int) error {
 	return nil
 }
 
-// GetPaginated retrieves paginated materials with optional filtering
-func (r *materialRepository) GetPaginated(page, limit int, searchTerm, category, supplier, status string) ([]models.Material, int64, error) {
-	offset := (page - 1) * limit
-	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1`
-	countQuery := `SELECT COUNT(*) FROM materials WHERE 1=1`
-	args := []interface{}{}
-	countArgs := []interface{}{}
-
-	if searchTerm != "" {
-		// First try to convert searchTerm to integer for direct ID comparison
-		if id, err := strconv.Atoi(searchTerm); err == nil {
-			query += " AND id = ?"
-			countQuery += " AND id = ?"
-			args = append(args, id)
-			countArgs = append(countArgs, id)
-		} else {
-			// If not an integer, search in text fields with case-insensitive comparison
-			query += " AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?))"
-			countQuery += " AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?))"
-			likeTerm := "%" + searchTerm + "%"
-			args = append(args, likeTerm, likeTerm, likeTerm)
-			countArgs = append(countArgs, likeTerm, likeTerm, likeTerm)
-		}
+// Restore clears the deletion of a material
+func (r *materialRepository) Restore(ctx context.Context, id int) error {
+	query := `UPDATE materials SET deleted_at = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+	result, err := r.DB.ExecContext(ctx, query, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to restore material ID %d: %w", id, err)
 	}
-	if category != "" {
-		query += " AND LOWER(category) = LOWER(?)"
-		countQuery += " AND LOWER(category) = LOWER(?)"
-		args = append(args, category)
-		countArgs = append(countArgs, category)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for material ID %d: %w", id, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted material with ID %d not found: %w", id, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// GetDeleted retrieves the deleted materials
+func (r *materialRepository) GetDeleted(ctx context.Context) ([]models.Material, error) {
+	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at, deleted_at FROM materials WHERE tenant_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id`
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deleted materials: %w", err)
 	}
-	if supplier != "" {
-		query += " AND LOWER(supplier) = LOWER(?)"
-		countQuery += " AND LOWER(supplier) = LOWER(?)"
-		args = append(args, supplier)
-		countArgs = append(countArgs, supplier)
+	defer rows.Close()
+
+	materials := []models.Material{}
+	for rows.Next() {
+		var m models.Material
+		var imageSQL sql.NullString
+		var deletedAt time.Time
+		if err := rows.Scan(&m.ID, &m.Name, &m.Category, &m.Supplier, &m.Quantity, &m.Status, &imageSQL, &m.CreatedAt, &m.UpdatedAt, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted material: %w", err)
+		}
+		m.Image = config.DefaultImageURL
+		if imageSQL.Valid && imageSQL.String != "" {
+			m.Image = imageSQL.String
+		}
+		m.DeletedAt = &deletedAt
+		materials = append(materials, m)
 	}
-	if status != "" {
-		query += " AND LOWER(status) = LOWER(?)"
-		countQuery += " AND LOWER(status) = LOWER(?)"
-		args = append(args, status)
-		countArgs = append(countArgs, status)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deleted materials: %w", err)
 	}
+	return materials, nil
+}
+
+// GetPaginated retrieves paginated materials with optional filtering
+func (r *materialRepository) GetPaginated(ctx context.Context, page, limit int, filter models.MaterialFilter) ([]models.Material, int64, error) {
+	offset := (page - 1) * limit
+	where, countArgs := r.materialsFilter(filter)
+	query := `SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials` + where
+	countQuery := `SELECT COUNT(*) FROM materials` + where
 
 	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	args := append(append([]interface{}{}, countArgs...), limit, offset)
 
 	// Get total count
 	var total int64
-	err := r.DB.QueryRow(countQuery, countArgs...).Scan(&total)
+	err := r.DB.QueryRowContext(ctx, countQuery, countArgs...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	rows, err := r.DB.Query(query, args...)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -252,3 +263,157 @@ func (r *materialRepository) GetPaginated(page, limit int, searchTerm, category,
 
 	return materials, total, nil
 }
+
+// materialsFilter returns the WHERE clause and its arguments for a material filter.
+// A numeric search term matches the ID; any other matches the name, category or
+// supplier, regardless of case. Filter values only reach the query as arguments.
+func (r *materialRepository) materialsFilter(filter models.MaterialFilter) (string, []interface{}) {
+	conditions := []string{"tenant_id = ?", "deleted_at IS NULL"}
+	args := []interface{}{r.TenantID}
+
+	if filter.Search != "" {
+		if id, err := strconv.Atoi(filter.Search); err == nil {
+			conditions = append(conditions, "id = ?")
+			args = append(args, id)
+		} else {
+			conditions = append(conditions, "(LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?))")
+			likeTerm := "%" + filter.Search + "%"
+			args = append(args, likeTerm, likeTerm, likeTerm)
+		}
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "LOWER(category) = LOWER(?)")
+		args = append(args, filter.Category)
+	}
+	if filter.Supplier != "" {
+		conditions = append(conditions, "LOWER(supplier) = LOWER(?)")
+		args = append(args, filter.Supplier)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "LOWER(status) = LOWER(?)")
+		args = append(args, filter.Status)
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// BulkImport inserts and updates materials in one transaction, so a failed row leaves
+// every material as it was. Images are not imported: new materials get the default
+// image and updated ones keep theirs.
+func (r *materialRepository) BulkImport(ctx context.Context, materials []models.Material) error {
+	for _, material := range materials {
+		if material.Quantity < 0 {
+			return negativeQuantity("material", material.Quantity)
+		}
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO materials (tenant_id, name, category, supplier, quantity, status, image, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, NULL, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare material insert: %w", err)
+	}
+	defer insert.Close()
+	update, err := tx.PrepareContext(ctx, `UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare material update: %w", err)
+	}
+	defer update.Close()
+
+	now := time.Now()
+	for i := range materials {
+		material := &materials[i]
+		if material.ID == 0 {
+			res, err := insert.ExecContext(ctx, r.TenantID, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, now, now)
+			if err != nil {
+				return fmt.Errorf("failed to insert material %q: %w", material.Name, stockError(err))
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get ID of material %q: %w", material.Name, err)
+			}
+			material.ID = int(id)
+			continue
+		}
+
+		res, err := update.ExecContext(ctx, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, now, material.ID, r.TenantID)
+		if err != nil {
+			return fmt.Errorf("failed to update material ID %d: %w", material.ID, stockError(err))
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to update material ID %d: %w", material.ID, err)
+		} else if affected == 0 {
+			return fmt.Errorf("material ID %d not found: %w", material.ID, sql.ErrNoRows)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit material import: %w", err)
+	}
+	return nil
+}
+
+// ApplyBatch applies the changes of a material batch in one transaction, locking each
+// updated material so the batch cannot overwrite a change made while it runs
+func (r *materialRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Material]) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for i := range changes {
+		if err := r.applyChange(ctx, tx, &changes[i], now); err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit material batch: %w", err)
+	}
+	return nil
+}
+
+// applyChange applies one change of a material batch within tx
+func (r *materialRepository) applyChange(ctx context.Context, tx *sql.Tx, change *models.BatchChange[models.Material], now time.Time) error {
+	if change.Op == models.BatchDelete {
+		return deleteBatchItem(ctx, tx, "materials", "material", change.ID, r.TenantID, now)
+	}
+
+	material := &change.Item
+	if material.Quantity < 0 {
+		return negativeQuantity("material", material.Quantity)
+	}
+	if material.Image == "" {
+		material.Image = config.DefaultImageURL
+	}
+
+	if change.Op == models.BatchCreate {
+		res, err := tx.ExecContext(ctx, `INSERT INTO materials (tenant_id, name, category, supplier, quantity, status, image, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.TenantID, material.Name, material.Category, material.Supplier, material.Quantity, material.Status, imageColumn(material.Image), now, now)
+		if err != nil {
+			return fmt.Errorf("failed to insert material %q: %w", material.Name, stockError(err))
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get ID of material %q: %w", material.Name, err)
+		}
+		material.ID, material.CreatedAt, material.UpdatedAt = int(id), now, now
+		return nil
+	}
+
+	createdAt, err := lockBatchItem(ctx, tx, "materials", "material", change.ID, r.TenantID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, image = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`,
+		material.Name, material.Category, material.Supplier, material.Quantity, material.Status, imageColumn(material.Image), now, change.ID, r.TenantID); err != nil {
+		return fmt.Errorf("failed to update material %d: %w", change.ID, stockError(err))
+	}
+	material.ID, material.CreatedAt, material.UpdatedAt = change.ID, createdAt, now
+	return nil
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"regexp"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"oop/internal/models"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,10 +38,10 @@ func TestGetAllMaterials(t *testing.T) {
 			AddRow(expectedMaterials[0].ID, expectedMaterials[0].Name, expectedMaterials[0].Category, expectedMaterials[0].Supplier, expectedMaterials[0].Quantity, expectedMaterials[0].Status, expectedMaterials[0].Image, expectedMaterials[0].CreatedAt, expectedMaterials[0].UpdatedAt).
 			AddRow(expectedMaterials[1].ID, expectedMaterials[1].Name, expectedMaterials[1].Category, expectedMaterials[1].Supplier, expectedMaterials[1].Quantity, expectedMaterials[1].Status, expectedMaterials[1].Image, expectedMaterials[1].CreatedAt, expectedMaterials[1].UpdatedAt)
 
-		query := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+		query := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 
-		materials, err := repo.GetAll("", "", "", "")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{})
 		assert.NoError(t, err)
 		assert.Equal(t, expectedMaterials, materials)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -49,10 +51,10 @@ func TestGetAllMaterials(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name", "category", "supplier", "quantity", "status", "image", "created_at", "updated_at"}).
 			AddRow(expectedMaterials[0].ID, expectedMaterials[0].Name, expectedMaterials[0].Category, expectedMaterials[0].Supplier, expectedMaterials[0].Quantity, expectedMaterials[0].Status, expectedMaterials[0].Image, expectedMaterials[0].CreatedAt, expectedMaterials[0].UpdatedAt)
 
-		querySearch := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?)) ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(querySearch)).WithArgs("%term%", "%term%", "%term%").WillReturnRows(rows)
+		querySearch := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?)) ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(querySearch)).WithArgs(models.DefaultTenantID, "%term%", "%term%", "%term%").WillReturnRows(rows)
 
-		materials, err := repo.GetAll("term", "", "", "")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{Search: "term"})
 		assert.NoError(t, err)
 		assert.Equal(t, []models.Material{expectedMaterials[0]}, materials)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -62,10 +64,10 @@ func TestGetAllMaterials(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name", "category", "supplier", "quantity", "status", "image", "created_at", "updated_at"}).
 			AddRow(expectedMaterials[0].ID, expectedMaterials[0].Name, expectedMaterials[0].Category, expectedMaterials[0].Supplier, expectedMaterials[0].Quantity, expectedMaterials[0].Status, expectedMaterials[0].Image, expectedMaterials[0].CreatedAt, expectedMaterials[0].UpdatedAt)
 
-		queryCategory := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 AND LOWER(category) = LOWER(?) ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(queryCategory)).WithArgs("Cat A").WillReturnRows(rows)
+		queryCategory := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL AND LOWER(category) = LOWER(?) ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(queryCategory)).WithArgs(models.DefaultTenantID, "Cat A").WillReturnRows(rows)
 
-		materials, err := repo.GetAll("", "Cat A", "", "")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{Category: "Cat A"})
 		assert.NoError(t, err)
 		assert.Equal(t, []models.Material{expectedMaterials[0]}, materials)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -75,10 +77,10 @@ func TestGetAllMaterials(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name", "category", "supplier", "quantity", "status", "image", "created_at", "updated_at"}).
 			AddRow(expectedMaterials[0].ID, expectedMaterials[0].Name, expectedMaterials[0].Category, expectedMaterials[0].Supplier, expectedMaterials[0].Quantity, expectedMaterials[0].Status, expectedMaterials[0].Image, expectedMaterials[0].CreatedAt, expectedMaterials[0].UpdatedAt)
 
-		querySupplier := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 AND LOWER(supplier) = LOWER(?) ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(querySupplier)).WithArgs("Sup 1").WillReturnRows(rows)
+		querySupplier := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL AND LOWER(supplier) = LOWER(?) ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(querySupplier)).WithArgs(models.DefaultTenantID, "Sup 1").WillReturnRows(rows)
 
-		materials, err := repo.GetAll("", "", "Sup 1", "")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{Supplier: "Sup 1"})
 		assert.NoError(t, err)
 		assert.Equal(t, []models.Material{expectedMaterials[0]}, materials)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -88,10 +90,10 @@ func TestGetAllMaterials(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name", "category", "supplier", "quantity", "status", "image", "created_at", "updated_at"}).
 			AddRow(expectedMaterials[0].ID, expectedMaterials[0].Name, expectedMaterials[0].Category, expectedMaterials[0].Supplier, expectedMaterials[0].Quantity, expectedMaterials[0].Status, expectedMaterials[0].Image, expectedMaterials[0].CreatedAt, expectedMaterials[0].UpdatedAt)
 
-		queryStatus := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 AND LOWER(status) = LOWER(?) ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(queryStatus)).WithArgs("Active").WillReturnRows(rows)
+		queryStatus := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL AND LOWER(status) = LOWER(?) ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(queryStatus)).WithArgs(models.DefaultTenantID, "Active").WillReturnRows(rows)
 
-		materials, err := repo.GetAll("", "", "", "Active")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{Status: "Active"})
 		assert.NoError(t, err)
 		assert.Equal(t, []models.Material{expectedMaterials[0]}, materials)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -101,20 +103,20 @@ func TestGetAllMaterials(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name", "category", "supplier", "quantity", "status", "image", "created_at", "updated_at"}).
 			AddRow(expectedMaterials[0].ID, expectedMaterials[0].Name, expectedMaterials[0].Category, expectedMaterials[0].Supplier, expectedMaterials[0].Quantity, expectedMaterials[0].Status, expectedMaterials[0].Image, expectedMaterials[0].CreatedAt, expectedMaterials[0].UpdatedAt)
 
-		queryAll := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?)) AND LOWER(category) = LOWER(?) AND LOWER(supplier) = LOWER(?) AND LOWER(status) = LOWER(?) ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(queryAll)).WithArgs("%term%", "%term%", "%term%", "Cat A", "Sup 1", "Active").WillReturnRows(rows)
+		queryAll := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL AND (LOWER(name) LIKE LOWER(?) OR LOWER(category) LIKE LOWER(?) OR LOWER(supplier) LIKE LOWER(?)) AND LOWER(category) = LOWER(?) AND LOWER(supplier) = LOWER(?) AND LOWER(status) = LOWER(?) ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(queryAll)).WithArgs(models.DefaultTenantID, "%term%", "%term%", "%term%", "Cat A", "Sup 1", "Active").WillReturnRows(rows)
 
-		materials, err := repo.GetAll("term", "Cat A", "Sup 1", "Active")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{Search: "term", Category: "Cat A", Supplier: "Sup 1", Status: "Active"})
 		assert.NoError(t, err)
 		assert.Equal(t, []models.Material{expectedMaterials[0]}, materials)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Query Error", func(t *testing.T) {
-		query := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE 1=1 ORDER BY created_at DESC"
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnError(sql.ErrConnDone)
+		query := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC"
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID).WillReturnError(sql.ErrConnDone)
 
-		materials, err := repo.GetAll("", "", "", "")
+		materials, err := repo.GetAll(context.Background(), models.MaterialFilter{})
 		assert.ErrorIs(t, err, sql.ErrConnDone) 
 		assert.Nil(t, materials)
 	})
@@ -128,29 +130,29 @@ func TestGetMaterialByID(t *testing.T) {
 	now := time.Now()
 	expectedMaterial := &models.Material{ID: 1, Name: "Material 1", Category: "Cat A", Supplier: "Sup 1", Quantity: 10, Status: "Active", Image: "img1.jpg", CreatedAt: now, UpdatedAt: now}
 
-	query := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE id = ?"
+	query := "SELECT id, name, category, supplier, quantity, status, image, created_at, updated_at FROM materials WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL"
 
 	t.Run("Found", func(t *testing.T) {
 		rows := sqlmock.NewRows([]string{"id", "name", "category", "supplier", "quantity", "status", "image", "created_at", "updated_at"}).
 			AddRow(expectedMaterial.ID, expectedMaterial.Name, expectedMaterial.Category, expectedMaterial.Supplier, expectedMaterial.Quantity, expectedMaterial.Status, expectedMaterial.Image, expectedMaterial.CreatedAt, expectedMaterial.UpdatedAt)
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1).WillReturnRows(rows)
-		material, err := repo.GetByID(1)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(1, models.DefaultTenantID).WillReturnRows(rows)
+		material, err := repo.GetByID(context.Background(), 1)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedMaterial, material)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Not Found", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(2).WillReturnError(sql.ErrNoRows)
-		material, err := repo.GetByID(2)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(2, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+		material, err := repo.GetByID(context.Background(), 2)
 		assert.NoError(t, err) // GetByID itself doesn't return sql.ErrNoRows directly, it returns nil material
 		assert.Nil(t, material)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Query Error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(3).WillReturnError(sql.ErrConnDone)
-		material, err := repo.GetByID(3)
+		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(3, models.DefaultTenantID).WillReturnError(sql.ErrConnDone)
+		material, err := repo.GetByID(context.Background(), 3)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Nil(t, material)
 		// No mock.ExpectationsWereMet() here
@@ -171,14 +173,14 @@ func TestCreateMaterial(t *testing.T) {
 		Image:    "new.jpg",
 	}
 
-	query := "INSERT INTO materials (name, category, supplier, quantity, status, image, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	query := "INSERT INTO materials (tenant_id, name, category, supplier, quantity, status, image, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 	t.Run("Success", func(t *testing.T) {
 		mock.ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(newMaterial.Name, newMaterial.Category, newMaterial.Supplier, newMaterial.Quantity, newMaterial.Status, newMaterial.Image, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(models.DefaultTenantID, newMaterial.Name, newMaterial.Category, newMaterial.Supplier, newMaterial.Quantity, newMaterial.Status, newMaterial.Image, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		id, err := repo.Create(newMaterial)
+		id, err := repo.Create(context.Background(), newMaterial)
 		assert.NoError(t, err)
 		assert.Equal(t, 1, id)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -186,10 +188,10 @@ func TestCreateMaterial(t *testing.T) {
 
 	t.Run("Exec Error", func(t *testing.T) {
 		mock.ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(newMaterial.Name, newMaterial.Category, newMaterial.Supplier, newMaterial.Quantity, newMaterial.Status, newMaterial.Image, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(models.DefaultTenantID, newMaterial.Name, newMaterial.Category, newMaterial.Supplier, newMaterial.Quantity, newMaterial.Status, newMaterial.Image, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnError(sql.ErrConnDone)
 
-		id, err := repo.Create(newMaterial)
+		id, err := repo.Create(context.Background(), newMaterial)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Equal(t, 0, id)
 		// No mock.ExpectationsWereMet() here
@@ -198,10 +200,10 @@ func TestCreateMaterial(t *testing.T) {
 	t.Run("Result Error", func(t *testing.T) {
 		expectedErr := sql.ErrNoRows // Simulate driver not supporting LastInsertId or other result errors
 		mock.ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(newMaterial.Name, newMaterial.Category, newMaterial.Supplier, newMaterial.Quantity, newMaterial.Status, newMaterial.Image, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(models.DefaultTenantID, newMaterial.Name, newMaterial.Category, newMaterial.Supplier, newMaterial.Quantity, newMaterial.Status, newMaterial.Image, sqlmock.AnyArg(), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewErrorResult(expectedErr))
 
-		id, err := repo.Create(newMaterial)
+		id, err := repo.Create(context.Background(), newMaterial)
 		assert.ErrorIs(t, err, expectedErr)
 		assert.Equal(t, 0, id)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -223,34 +225,52 @@ func TestUpdateMaterial(t *testing.T) {
 		Image:    "updated.jpg",
 	}
 
-	query := "UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, image = ?, updated_at = ? WHERE id = ?"
+	query := "UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, image = ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL"
 
 	t.Run("Success", func(t *testing.T) {
 		mock.ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID).
+			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID, models.DefaultTenantID).
 			WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
 
-		err := repo.Update(updatedMaterial)
+		err := repo.Update(context.Background(), updatedMaterial)
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("Negative Quantity", func(t *testing.T) {
+		negative := *updatedMaterial
+		negative.Quantity = -1
+		err := repo.Update(context.Background(), &negative)
+		assert.ErrorIs(t, err, ErrNegativeStock)
+		assert.NoError(t, mock.ExpectationsWereMet(), "refused without a query")
+	})
+
+	t.Run("Check Constraint Violated", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta(query)).
+			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID, models.DefaultTenantID).
+			WillReturnError(&mysql.MySQLError{Number: 3819, Message: "Check constraint 'materials_quantity_non_negative' is violated."})
+
+		err := repo.Update(context.Background(), updatedMaterial)
+		assert.ErrorIs(t, err, ErrNegativeStock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("Exec Error", func(t *testing.T) {
 		mock.ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID).
+			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID, models.DefaultTenantID).
 			WillReturnError(sql.ErrConnDone)
 
-		err := repo.Update(updatedMaterial)
+		err := repo.Update(context.Background(), updatedMaterial)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		// No mock.ExpectationsWereMet() here
 	})
 
 	t.Run("No Rows Affected", func(t *testing.T) {
 		mock.ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID).
+			WithArgs(updatedMaterial.Name, updatedMaterial.Category, updatedMaterial.Supplier, updatedMaterial.Quantity, updatedMaterial.Status, updatedMaterial.Image, sqlmock.AnyArg(), updatedMaterial.ID, models.DefaultTenantID).
 			WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
 
-		err := repo.Update(updatedMaterial)
+		err := repo.Update(context.Background(), updatedMaterial)
 		assert.NoError(t, err) // Update itself doesn't error on 0 rows affected, usually
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -262,29 +282,160 @@ func TestDeleteMaterial(t *testing.T) {
 	repo := NewMaterialRepository(db)
 
 	materialID := 1
-	query := "DELETE FROM materials WHERE id = ?"
+	query := "UPDATE materials SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL"
 
 	t.Run("Success", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(materialID).WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(sqlmock.AnyArg(), materialID, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected
 
-		err := repo.Delete(materialID)
+		err := repo.Delete(context.Background(), materialID)
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Exec Error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(materialID).WillReturnError(sql.ErrConnDone)
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(sqlmock.AnyArg(), materialID, models.DefaultTenantID).WillReturnError(sql.ErrConnDone)
 
-		err := repo.Delete(materialID)
+		err := repo.Delete(context.Background(), materialID)
 		assert.ErrorIs(t, err, sql.ErrConnDone)
 		// No mock.ExpectationsWereMet() here
 	})
 
 	t.Run("No Rows Affected", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(materialID).WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
+		mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(sqlmock.AnyArg(), materialID, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
 
-		err := repo.Delete(materialID)
+		err := repo.Delete(context.Background(), materialID)
 		assert.NoError(t, err) // Delete itself doesn't error on 0 rows affected
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestBulkImportMaterials(t *testing.T) {
+	insert := regexp.QuoteMeta("INSERT INTO materials (tenant_id, name, category, supplier, quantity, status, image, created_at, updated_at)")
+	update := regexp.QuoteMeta("UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, updated_at = ?")
+	materials := func() []models.Material {
+		return []models.Material{
+			{Name: "Rivets", Category: "Hardware", Supplier: "Steel Co.", Quantity: 100, Status: "In Stock"},
+			{ID: 7, Name: "Plywood", Category: "Lumber", Supplier: "Timber Inc.", Quantity: 25, Status: "In Stock"},
+		}
+	}
+
+	t.Run("Inserts and updates", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewMaterialRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(insert)
+		mock.ExpectPrepare(update)
+		mock.ExpectExec(insert).
+			WithArgs(models.DefaultTenantID, "Rivets", "Hardware", "Steel Co.", 100, "In Stock", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectExec(update).
+			WithArgs("Plywood", "Lumber", "Timber Inc.", 25, "In Stock", sqlmock.AnyArg(), 7, models.DefaultTenantID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		imported := materials()
+		assert.NoError(t, repo.BulkImport(context.Background(), imported))
+		assert.Equal(t, 12, imported[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing Material Rolls Back", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewMaterialRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectPrepare(insert)
+		mock.ExpectPrepare(update)
+		mock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := repo.BulkImport(context.Background(), materials())
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Negative Quantity", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewMaterialRepository(db)
+
+		imported := materials()
+		imported[1].Quantity = -1
+		assert.ErrorIs(t, repo.BulkImport(context.Background(), imported), ErrNegativeStock)
+		assert.NoError(t, mock.ExpectationsWereMet(), "refused without a query")
+	})
+}
+
+func TestApplyMaterialBatch(t *testing.T) {
+	insert := regexp.QuoteMeta("INSERT INTO materials (tenant_id, name, category, supplier, quantity, status, image, created_at, updated_at)")
+	lock := regexp.QuoteMeta("SELECT created_at FROM materials WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL FOR UPDATE")
+	update := regexp.QuoteMeta("UPDATE materials SET name = ?, category = ?, supplier = ?, quantity = ?, status = ?, image = ?, updated_at = ?")
+	remove := regexp.QuoteMeta("UPDATE materials SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL")
+	changes := func() []models.BatchChange[models.Material] {
+		return []models.BatchChange[models.Material]{
+			{Op: models.BatchCreate, Item: models.Material{Name: "Rivets", Category: "Hardware", Supplier: "Steel Co.", Quantity: 100, Status: "In Stock"}},
+			{Op: models.BatchUpdate, ID: 7, Item: models.Material{Name: "Plywood", Category: "Lumber", Supplier: "Timber Inc.", Quantity: 25, Status: "In Stock"}},
+			{Op: models.BatchDelete, ID: 9},
+		}
+	}
+
+	t.Run("Applies every change", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewMaterialRepository(db)
+		created := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).
+			WithArgs(models.DefaultTenantID, "Rivets", "Hardware", "Steel Co.", 100, "In Stock", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectQuery(lock).WithArgs(7, models.DefaultTenantID).WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(created))
+		mock.ExpectExec(update).
+			WithArgs("Plywood", "Lumber", "Timber Inc.", 25, "In Stock", nil, sqlmock.AnyArg(), 7, models.DefaultTenantID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(remove).WithArgs(sqlmock.AnyArg(), 9, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		applied := changes()
+		assert.NoError(t, repo.ApplyBatch(context.Background(), applied))
+		assert.Equal(t, 12, applied[0].Item.ID)
+		assert.Equal(t, created, applied[1].Item.CreatedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Missing Material Rolls Back", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewMaterialRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(insert).WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectQuery(lock).WithArgs(7, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := repo.ApplyBatch(context.Background(), changes())
+		var batchErr *BatchError
+		assert.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 1, batchErr.Index)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Negative Quantity", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewMaterialRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		applied := changes()
+		applied[0].Item.Quantity = -1
+		assert.ErrorIs(t, repo.ApplyBatch(context.Background(), applied), ErrNegativeStock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,286 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.AccessoryRepository = (*AccessoryRepository)(nil)
+
+// AccessoryRepository is an in-memory implementation of repositories.AccessoryRepository
+type AccessoryRepository struct {
+	mu          sync.RWMutex
+	accessories map[int]models.Accessory
+	deleted     map[int]trashed[models.Accessory] // Soft-deleted accessories, until restored or purged
+	nextID      int
+}
+
+// NewAccessoryRepository creates an empty in-memory accessory repository
+func NewAccessoryRepository() *AccessoryRepository {
+	return &AccessoryRepository{accessories: make(map[int]models.Accessory), deleted: make(map[int]trashed[models.Accessory]), nextID: 1}
+}
+
+// accessoryStatus mirrors the stock thresholds of the database implementation
+func accessoryStatus(quantity int) models.AccessoryStatus {
+	switch {
+	case quantity == 0:
+		return models.StatusOutOfStock
+	case quantity <= repositories.LowStockQuantity:
+		return models.StatusLowStock
+	case quantity <= 5:
+		return models.StatusInStock
+	default:
+		return models.StatusAvailable
+	}
+}
+
+// GetAll returns every accessory ordered by ID
+func (r *AccessoryRepository) GetAll(ctx context.Context) ([]models.Accessory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var accessories []models.Accessory
+	for _, accessory := range r.accessories {
+		accessories = append(accessories, withDefaultAccessoryImage(accessory))
+	}
+	sort.Slice(accessories, func(i, j int) bool { return accessories[i].ID < accessories[j].ID })
+	return accessories, nil
+}
+
+// GetPaginated returns one page of the filtered accessories, ordered by ID, along
+// with the total number of matches
+func (r *AccessoryRepository) GetPaginated(ctx context.Context, page, limit int, filter models.AccessoryFilter) ([]models.Accessory, int64, error) {
+	all, _ := r.GetAll(ctx)
+	accessories := []models.Accessory{}
+	for _, accessory := range all {
+		if matchesAccessory(accessory, filter) {
+			accessories = append(accessories, accessory)
+		}
+	}
+	total := int64(len(accessories))
+
+	offset := (page - 1) * limit
+	if offset < 0 || offset >= len(accessories) {
+		return []models.Accessory{}, total, nil
+	}
+	end := offset + limit
+	if end > len(accessories) {
+		end = len(accessories)
+	}
+	return accessories[offset:end], total, nil
+}
+
+// matchesAccessory applies an accessory filter the way the database implementation does
+func matchesAccessory(accessory models.Accessory, filter models.AccessoryFilter) bool {
+	if filter.Make != "" && string(accessory.Make) != filter.Make {
+		return false
+	}
+	if filter.Status != "" && string(accessory.Status) != filter.Status {
+		return false
+	}
+	if filter.UnitColor != "" && string(accessory.UnitColor) != filter.UnitColor {
+		return false
+	}
+	if filter.Search != "" {
+		if id, err := strconv.Atoi(filter.Search); err == nil {
+			return accessory.ID == id
+		}
+		return containsFold(accessory.Name, filter.Search)
+	}
+	return true
+}
+
+// GetByID retrieves a single accessory by its ID
+func (r *AccessoryRepository) GetByID(ctx context.Context, id int) (models.Accessory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	accessory, ok := r.accessories[id]
+	if !ok {
+		return models.Accessory{}, errors.New("accessory not found")
+	}
+	return withDefaultAccessoryImage(accessory), nil
+}
+
+// Create stores a new accessory, deriving its status from the quantity, and returns its ID
+func (r *AccessoryRepository) Create(ctx context.Context, input models.NewAccessoryInput) (int, error) {
+	if input.Quantity < 0 {
+		return 0, negativeQuantity("accessory", input.Quantity)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	accessory := models.Accessory{
+		ID:        r.nextID,
+		Name:      input.Name,
+		Make:      input.Make,
+		Quantity:  input.Quantity,
+		Price:     input.Price,
+		MinPrice:  models.PriceBound(input.MinPrice),
+		MaxPrice:  models.PriceBound(input.MaxPrice),
+		Status:    accessoryStatus(input.Quantity),
+		UnitColor: input.UnitColor,
+		Image:     input.Image,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.nextID++
+	r.accessories[accessory.ID] = accessory
+	return accessory.ID, nil
+}
+
+// Update applies the provided fields to an existing accessory
+func (r *AccessoryRepository) Update(ctx context.Context, id int, input models.UpdateAccessoryInput) (models.Accessory, error) {
+	if input.Quantity != nil && *input.Quantity < 0 {
+		return models.Accessory{}, negativeQuantity("accessory", *input.Quantity)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accessory, ok := r.accessories[id]
+	if !ok {
+		return models.Accessory{}, errors.New("accessory not found")
+	}
+
+	if input.Name != nil {
+		accessory.Name = *input.Name
+	}
+	if input.Make != nil {
+		accessory.Make = *input.Make
+	}
+	if input.Quantity != nil {
+		accessory.Quantity = *input.Quantity
+		accessory.Status = accessoryStatus(*input.Quantity)
+	}
+	if input.Price != nil {
+		accessory.Price = *input.Price
+	}
+	if input.MinPrice != nil {
+		accessory.MinPrice = models.PriceBound(input.MinPrice)
+	}
+	if input.MaxPrice != nil {
+		accessory.MaxPrice = models.PriceBound(input.MaxPrice)
+	}
+	if input.UnitColor != nil {
+		accessory.UnitColor = *input.UnitColor
+	}
+	if input.Image != nil {
+		if *input.Image == "null" {
+			accessory.Image = ""
+		} else {
+			accessory.Image = *input.Image
+		}
+	}
+	accessory.UpdatedAt = time.Now()
+	r.accessories[id] = accessory
+
+	return withDefaultAccessoryImage(accessory), nil
+}
+
+// Delete soft-deletes an accessory, keeping it for Restore
+func (r *AccessoryRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accessory, ok := r.accessories[id]
+	if !ok {
+		return errors.New("accessory not found")
+	}
+	r.deleted[id] = trashed[models.Accessory]{record: accessory, deletedAt: time.Now()}
+	delete(r.accessories, id)
+	return nil
+}
+
+// Restore brings back a deleted accessory
+func (r *AccessoryRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	accessory, ok := r.deleted[id]
+	if !ok {
+		return fmt.Errorf("deleted accessory not found: %w", sql.ErrNoRows)
+	}
+	r.accessories[id] = accessory.record
+	delete(r.deleted, id)
+	return nil
+}
+
+// GetDeleted returns the deleted accessories, most recently deleted first
+func (r *AccessoryRepository) GetDeleted(ctx context.Context) ([]models.Accessory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return deletedRecords(r.deleted, func(accessory *models.Accessory, deletedAt time.Time) {
+		*accessory = withDefaultAccessoryImage(*accessory)
+		accessory.DeletedAt = &deletedAt
+	}), nil
+}
+
+// adjustQuantity adds delta to the stock of an accessory and updates its status; used when
+// accessories are sold with a cab or their sale called off.
+func (r *AccessoryRepository) adjustQuantity(id int, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if accessory, ok := r.accessories[id]; ok {
+		accessory.Quantity += delta
+		accessory.Status = accessoryStatus(accessory.Quantity)
+		accessory.UpdatedAt = time.Now()
+		r.accessories[id] = accessory
+	}
+}
+
+// withDefaultAccessoryImage fills in the placeholder image for accessories without one
+func withDefaultAccessoryImage(accessory models.Accessory) models.Accessory {
+	if accessory.Image == "" {
+		accessory.Image = config.DefaultImageURL
+	}
+	return accessory
+}
+
+// ApplyBatch creates, updates and deletes accessories, all or none; created and
+// updated accessories get the status of their quantity
+func (r *AccessoryRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Accessory]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := checkBatch("accessory", changes, r.accessories, func(accessory models.Accessory) int { return accessory.Quantity }); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range changes {
+		change := &changes[i]
+		if change.Op == models.BatchDelete {
+			r.deleted[change.ID] = trashed[models.Accessory]{record: r.accessories[change.ID], deletedAt: now}
+			delete(r.accessories, change.ID)
+			continue
+		}
+
+		accessory := &change.Item
+		accessory.Status = accessoryStatus(accessory.Quantity)
+		accessory.MinPrice, accessory.MaxPrice = models.PriceBound(accessory.MinPrice), models.PriceBound(accessory.MaxPrice)
+		accessory.CreatedAt, accessory.UpdatedAt = now, now
+		if change.Op == models.BatchCreate {
+			accessory.ID = r.nextID
+			r.nextID++
+		} else {
+			accessory.ID, accessory.CreatedAt = change.ID, r.accessories[change.ID].CreatedAt
+		}
+		r.accessories[accessory.ID] = *accessory
+		*accessory = withDefaultAccessoryImage(*accessory)
+	}
+	return nil
+}
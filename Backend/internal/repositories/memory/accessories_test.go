@@ -0,0 +1,95 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessoryRepositoryStatus(t *testing.T) {
+	tests := []struct {
+		quantity int
+		want     models.AccessoryStatus
+	}{
+		{0, models.StatusOutOfStock},
+		{2, models.StatusLowStock},
+		{5, models.StatusInStock},
+		{6, models.StatusAvailable},
+	}
+
+	repo := memory.NewAccessoryRepository()
+	ctx := context.Background()
+	for _, tt := range tests {
+		id, err := repo.Create(ctx, models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: tt.quantity, UnitColor: models.ColorBlack})
+		require.NoError(t, err)
+		accessory, err := repo.GetByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, accessory.Status, "quantity %d", tt.quantity)
+	}
+
+	accessories, err := repo.GetAll(ctx)
+	require.NoError(t, err)
+	if assert.Len(t, accessories, 4) {
+		assert.Equal(t, 1, accessories[0].ID, "ordered by ID")
+	}
+}
+
+func TestAccessoryRepositoryUpdate(t *testing.T) {
+	repo := memory.NewAccessoryRepository()
+	ctx := context.Background()
+	id, err := repo.Create(ctx, models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 8, Price: 4500, UnitColor: models.ColorBlack, Image: "https://example.com/rack.png"})
+	require.NoError(t, err)
+
+	quantity := 1
+	image := "null"
+	updated, err := repo.Update(ctx, id, models.UpdateAccessoryInput{Quantity: &quantity, Image: &image})
+	require.NoError(t, err)
+	assert.Equal(t, "Roof Rack", updated.Name)
+	assert.Equal(t, models.StatusLowStock, updated.Status)
+	assert.Equal(t, config.DefaultImageURL, updated.Image)
+
+	_, err = repo.Update(ctx, 99, models.UpdateAccessoryInput{})
+	assert.EqualError(t, err, "accessory not found")
+
+	require.NoError(t, repo.Delete(ctx, id))
+	assert.EqualError(t, repo.Delete(ctx, id), "accessory not found")
+	_, err = repo.GetByID(ctx, id)
+	assert.EqualError(t, err, "accessory not found")
+}
+
+func TestAccessoryRepositoryGetPaginated(t *testing.T) {
+	repo := memory.NewAccessoryRepository()
+	ctx := context.Background()
+	for _, input := range []models.NewAccessoryInput{
+		{Name: "Side Mirror", Make: models.MakeOEM, Quantity: 4, UnitColor: models.ColorBlack},
+		{Name: "Rear Mirror", Make: models.MakeOEM, Quantity: 8, UnitColor: models.ColorSilver},
+		{Name: "Mirror Cover", Make: models.MakeAftermarket, Quantity: 2, UnitColor: models.ColorBlack},
+		{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 1, UnitColor: models.ColorBlack},
+	} {
+		_, err := repo.Create(ctx, input)
+		require.NoError(t, err)
+	}
+
+	page, total, err := repo.GetPaginated(ctx, 2, 1, models.AccessoryFilter{Make: string(models.MakeOEM), Search: "MIRROR"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "Rear Mirror", page[0].Name, "ordered by ID")
+	}
+
+	page, total, err = repo.GetPaginated(ctx, 1, 10, models.AccessoryFilter{Search: "4"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total, "a numeric search term matches the ID")
+	assert.Equal(t, "Roof Rack", page[0].Name)
+
+	page, total, err = repo.GetPaginated(ctx, 3, 2, models.AccessoryFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Empty(t, page, "past the last page")
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.AccountingPostingRepository = (*AccountingPostingRepository)(nil)
+
+// accountingPostingKey identifies the posting of a kind for a day
+type accountingPostingKey struct {
+	kind string
+	date string
+}
+
+// AccountingPostingRepository is an in-memory implementation of repositories.AccountingPostingRepository
+type AccountingPostingRepository struct {
+	mu       sync.Mutex
+	postings map[accountingPostingKey]models.AccountingPosting
+}
+
+// NewAccountingPostingRepository creates an empty in-memory accounting posting repository
+func NewAccountingPostingRepository() *AccountingPostingRepository {
+	return &AccountingPostingRepository{postings: make(map[accountingPostingKey]models.AccountingPosting)}
+}
+
+// Get returns a copy of the posting of a kind for a day
+func (r *AccountingPostingRepository) Get(kind, date string) (*models.AccountingPosting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	posting, ok := r.postings[accountingPostingKey{kind, date}]
+	if !ok {
+		return nil, fmt.Errorf("accounting posting not found: %w", sql.ErrNoRows)
+	}
+	return &posting, nil
+}
+
+// Save stores a copy of a posting, replacing the one of the same kind and day
+func (r *AccountingPostingRepository) Save(posting *models.AccountingPosting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.postings[accountingPostingKey{posting.Kind, posting.PostingDate}] = *posting
+	return nil
+}
+
+// List returns up to limit postings, latest day first
+func (r *AccountingPostingRepository) List(status string, limit int) ([]models.AccountingPosting, error) {
+	postings := r.filter(func(posting models.AccountingPosting) bool {
+		return status == "" || posting.Status == status
+	})
+	sort.Slice(postings, func(i, j int) bool {
+		if postings[i].PostingDate != postings[j].PostingDate {
+			return postings[i].PostingDate > postings[j].PostingDate
+		}
+		return postings[i].Kind < postings[j].Kind
+	})
+	if len(postings) > limit {
+		postings = postings[:limit]
+	}
+	return postings, nil
+}
+
+// GetRetryable returns the failed postings with attempts left, oldest day first
+func (r *AccountingPostingRepository) GetRetryable(maxAttempts int) ([]models.AccountingPosting, error) {
+	postings := r.filter(func(posting models.AccountingPosting) bool {
+		return posting.Status == models.AccountingFailed && posting.Attempts < maxAttempts
+	})
+	sort.Slice(postings, func(i, j int) bool {
+		if postings[i].PostingDate != postings[j].PostingDate {
+			return postings[i].PostingDate < postings[j].PostingDate
+		}
+		return postings[i].Kind < postings[j].Kind
+	})
+	return postings, nil
+}
+
+func (r *AccountingPostingRepository) filter(keep func(models.AccountingPosting) bool) []models.AccountingPosting {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	postings := []models.AccountingPosting{}
+	for _, posting := range r.postings {
+		if keep(posting) {
+			postings = append(postings, posting)
+		}
+	}
+	return postings
+}
@@ -0,0 +1,38 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountingPostingRepository(t *testing.T) {
+	store := memory.NewStore()
+	for _, posting := range []models.AccountingPosting{
+		{Kind: models.AccountingSalesJournal, PostingDate: "2025-03-01", Status: models.AccountingPosted, Attempts: 1},
+		{Kind: models.AccountingPayments, PostingDate: "2025-03-01", Status: models.AccountingFailed, Attempts: 5},
+		{Kind: models.AccountingPayments, PostingDate: "2025-03-02", Status: models.AccountingFailed, Attempts: 1},
+		{Kind: models.AccountingSalesJournal, PostingDate: "2025-02-28", Status: models.AccountingFailed, Attempts: 2},
+	} {
+		require.NoError(t, store.Accounting.Save(&posting))
+	}
+
+	_, err := store.Accounting.Get(models.AccountingSalesJournal, "2025-03-02")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	postings, err := store.Accounting.List("", 2)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+	assert.Equal(t, "2025-03-02", postings[0].PostingDate, "latest day first")
+
+	retryable, err := store.Accounting.GetRetryable(5)
+	require.NoError(t, err)
+	require.Len(t, retryable, 2, "postings out of attempts are not retried")
+	assert.Equal(t, "2025-02-28", retryable[0].PostingDate, "oldest day first")
+}
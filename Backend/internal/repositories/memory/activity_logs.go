@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.LogsRepositoryInterface = (*LogsRepository)(nil)
+
+// LogsRepository is an in-memory implementation of repositories.LogsRepositoryInterface.
+// Entries cannot be modified once stored, so they are not hash-chained.
+type LogsRepository struct {
+	mu   sync.RWMutex
+	logs []models.ActivityLog
+}
+
+// NewLogsRepository creates an empty in-memory activity log repository
+func NewLogsRepository() *LogsRepository {
+	return &LogsRepository{}
+}
+
+// Create appends a new activity log entry
+func (r *LogsRepository) Create(logEntry *models.ActivityLog) error {
+	if logEntry.ID == "" {
+		logEntry.ID = uuid.New().String()
+	}
+	now := time.Now()
+	logEntry.CreatedAt = now
+	logEntry.UpdatedAt = now
+	if logEntry.Timestamp.IsZero() {
+		logEntry.Timestamp = now
+	}
+	logEntry.Timestamp = logEntry.Timestamp.Truncate(time.Second)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, *logEntry)
+	return nil
+}
+
+// GetByID retrieves a single activity log entry, including its field changes
+func (r *LogsRepository) GetByID(id string) (*models.ActivityLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, entry := range r.logs {
+		if entry.ID == id {
+			return &entry, nil
+		}
+	}
+	return nil, fmt.Errorf("activity log with ID %s not found", id)
+}
+
+// GetLogs returns one page of activity logs, newest first
+func (r *LogsRepository) GetLogs(page, limit int) ([]models.ActivityLog, int64, error) {
+	return r.GetBasedOnFilter(page, limit, "", "", "", nil, nil)
+}
+
+// GetBasedOnFilter returns one page of the activity logs matching the filters, newest first.
+// User, action and status match case-insensitively on a substring.
+func (r *LogsRepository) GetBasedOnFilter(page, limit int, user, action, status string, startDate, endDate *time.Time) ([]models.ActivityLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 10
+	}
+
+	r.mu.RLock()
+	matched := []models.ActivityLog{}
+	// Walk backwards so entries logged within the same second stay newest first after sorting
+	for i := len(r.logs) - 1; i >= 0; i-- {
+		entry := r.logs[i]
+		if user != "" && !containsFold(entry.User, user) {
+			continue
+		}
+		if action != "" && !containsFold(entry.Action, action) {
+			continue
+		}
+		if status != "" && !containsFold(entry.Status, status) {
+			continue
+		}
+		if startDate != nil && entry.Timestamp.Before(*startDate) {
+			continue
+		}
+		if endDate != nil && entry.Timestamp.After(*endDate) {
+			continue
+		}
+		// Like the database implementation, lists leave out the field changes
+		entry.Changes = nil
+		matched = append(matched, entry)
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].Timestamp.After(matched[j].Timestamp) })
+
+	total := int64(len(matched))
+	offset := (page - 1) * limit
+	if offset >= len(matched) {
+		return []models.ActivityLog{}, total, nil
+	}
+	end := offset + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], total, nil
+}
+
+// VerifyChain always reports a valid chain, since in-memory entries cannot be tampered with
+func (r *LogsRepository) VerifyChain() (*models.AuditChainReport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return &models.AuditChainReport{Valid: true, CheckedEntries: len(r.logs)}, nil
+}
@@ -0,0 +1,144 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.AnnouncementRepository = (*AnnouncementRepository)(nil)
+
+// AnnouncementRepository is an in-memory implementation of repositories.AnnouncementRepository
+type AnnouncementRepository struct {
+	mu            sync.RWMutex
+	announcements map[string]models.Announcement
+	acks          map[string]map[string]time.Time // Announcement ID -> user ID -> acknowledged at
+}
+
+// NewAnnouncementRepository creates an empty in-memory announcement repository
+func NewAnnouncementRepository() *AnnouncementRepository {
+	return &AnnouncementRepository{
+		announcements: make(map[string]models.Announcement),
+		acks:          make(map[string]map[string]time.Time),
+	}
+}
+
+// Create stores a new announcement
+func (r *AnnouncementRepository) Create(announcement *models.Announcement) error {
+	if announcement.ID == "" {
+		announcement.ID = uuid.New().String()
+	}
+	now := time.Now()
+	announcement.CreatedAt = now
+	announcement.UpdatedAt = now
+	announcement.Acknowledged = false
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.announcements[announcement.ID] = *announcement
+	return nil
+}
+
+// GetByID retrieves an announcement by its ID
+func (r *AnnouncementRepository) GetByID(id string) (*models.Announcement, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	announcement, ok := r.announcements[id]
+	if !ok {
+		return nil, fmt.Errorf("announcement not found: %w", sql.ErrNoRows)
+	}
+	return &announcement, nil
+}
+
+// Update saves the content and display window of an announcement
+func (r *AnnouncementRepository) Update(announcement *models.Announcement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.announcements[announcement.ID]
+	if !ok {
+		return fmt.Errorf("announcement not found: %w", sql.ErrNoRows)
+	}
+	announcement.CreatedBy = existing.CreatedBy
+	announcement.CreatedAt = existing.CreatedAt
+	announcement.UpdatedAt = time.Now()
+	announcement.Acknowledged = false
+	r.announcements[announcement.ID] = *announcement
+	return nil
+}
+
+// Delete removes an announcement and its acknowledgments
+func (r *AnnouncementRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.announcements[id]; !ok {
+		return fmt.Errorf("announcement not found: %w", sql.ErrNoRows)
+	}
+	delete(r.announcements, id)
+	delete(r.acks, id)
+	return nil
+}
+
+// GetAll returns every announcement, newest first
+func (r *AnnouncementRepository) GetAll() ([]models.Announcement, error) {
+	return r.filter(func(models.Announcement) bool { return true }, ""), nil
+}
+
+// GetActive returns the announcements displayed at the given time, newest first
+func (r *AnnouncementRepository) GetActive(at time.Time, userID string) ([]models.Announcement, error) {
+	return r.filter(func(a models.Announcement) bool { return a.ActiveAt(at) }, userID), nil
+}
+
+func (r *AnnouncementRepository) filter(keep func(models.Announcement) bool, userID string) []models.Announcement {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	announcements := []models.Announcement{}
+	for _, announcement := range r.announcements {
+		if !keep(announcement) {
+			continue
+		}
+		if userID != "" {
+			_, announcement.Acknowledged = r.acks[announcement.ID][userID]
+		}
+		announcements = append(announcements, announcement)
+	}
+	sort.Slice(announcements, func(i, j int) bool { return announcements[i].StartsAt.After(announcements[j].StartsAt) })
+	return announcements
+}
+
+// Acknowledge records that a user read an announcement, keeping the first time
+func (r *AnnouncementRepository) Acknowledge(announcementID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.acks[announcementID] == nil {
+		r.acks[announcementID] = make(map[string]time.Time)
+	}
+	if _, ok := r.acks[announcementID][userID]; !ok {
+		r.acks[announcementID][userID] = time.Now()
+	}
+	return nil
+}
+
+// GetAcknowledgments returns who acknowledged an announcement, earliest first
+func (r *AnnouncementRepository) GetAcknowledgments(announcementID string) ([]models.AnnouncementAck, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	acks := []models.AnnouncementAck{}
+	for userID, at := range r.acks[announcementID] {
+		acks = append(acks, models.AnnouncementAck{AnnouncementID: announcementID, UserID: userID, AcknowledgedAt: at})
+	}
+	sort.Slice(acks, func(i, j int) bool { return acks[i].AcknowledgedAt.Before(acks[j].AcknowledgedAt) })
+	return acks, nil
+}
@@ -0,0 +1,61 @@
+package memory_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnouncementRepository(t *testing.T) {
+	repo := memory.NewAnnouncementRepository()
+	now := time.Now()
+	ends := now.Add(time.Hour)
+
+	current := &models.Announcement{Title: "Current", Body: "Now", Severity: models.AnnouncementSeverityInfo, StartsAt: now.Add(-time.Minute), EndsAt: &ends}
+	scheduled := &models.Announcement{Title: "Scheduled", Body: "Soon", Severity: models.AnnouncementSeverityInfo, StartsAt: now.Add(time.Hour)}
+	require.NoError(t, repo.Create(current))
+	require.NoError(t, repo.Create(scheduled))
+	assert.NotEmpty(t, current.ID)
+
+	active, err := repo.GetActive(now, "user-1")
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, current.ID, active[0].ID)
+	assert.False(t, active[0].Acknowledged)
+
+	active, err = repo.GetActive(ends, "user-1")
+	require.NoError(t, err)
+	assert.Len(t, active, 1, "the current announcement ends as the scheduled one starts")
+	assert.Equal(t, scheduled.ID, active[0].ID)
+
+	require.NoError(t, repo.Acknowledge(current.ID, "user-1"))
+	require.NoError(t, repo.Acknowledge(current.ID, "user-1"))
+	active, err = repo.GetActive(now, "user-1")
+	require.NoError(t, err)
+	assert.True(t, active[0].Acknowledged)
+	active, err = repo.GetActive(now, "user-2")
+	require.NoError(t, err)
+	assert.False(t, active[0].Acknowledged, "acknowledgments are per user")
+
+	acks, err := repo.GetAcknowledgments(current.ID)
+	require.NoError(t, err)
+	assert.Len(t, acks, 1)
+
+	all, err := repo.GetAll()
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, repo.Delete(current.ID))
+	_, err = repo.GetByID(current.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	acks, err = repo.GetAcknowledgments(current.ID)
+	require.NoError(t, err)
+	assert.Empty(t, acks)
+	assert.ErrorIs(t, repo.Delete(current.ID), sql.ErrNoRows)
+}
@@ -0,0 +1,153 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.AnomalyRepository = (*AnomalyRepository)(nil)
+
+// AnomalyRepository is an in-memory implementation of repositories.AnomalyRepository
+// reading the records of the sales and activity log repositories
+type AnomalyRepository struct {
+	mu        sync.RWMutex
+	anomalies map[string]models.Anomaly
+	sales     *SalesRepository
+	logs      *LogsRepository
+}
+
+// NewAnomalyRepository creates an empty anomaly review queue over the given repositories
+func NewAnomalyRepository(sales *SalesRepository, logs *LogsRepository) *AnomalyRepository {
+	return &AnomalyRepository{anomalies: make(map[string]models.Anomaly), sales: sales, logs: logs}
+}
+
+// SoldPrices returns the unit prices of the items sold between two sale dates
+func (r *AnomalyRepository) SoldPrices(startDate, endDate string) ([]models.SoldPrice, error) {
+	r.sales.mu.RLock()
+	defer r.sales.mu.RUnlock()
+
+	prices := []models.SoldPrice{}
+	for _, sale := range r.sales.sales {
+		if sale.SaleDate < startDate || sale.SaleDate > endDate {
+			continue
+		}
+		for _, item := range r.sales.items[sale.ID] {
+			prices = append(prices, models.SoldPrice{
+				SaleItemID: item.ID,
+				SaleID:     sale.ID,
+				SaleDate:   sale.SaleDate,
+				ItemType:   item.ItemType(),
+				ItemID:     item.Item.ID(),
+				UnitPrice:  item.UnitPrice,
+				SoldAt:     item.CreatedAt,
+			})
+		}
+	}
+	sort.Slice(prices, func(i, j int) bool {
+		if prices[i].SaleDate != prices[j].SaleDate {
+			return prices[i].SaleDate < prices[j].SaleDate
+		}
+		return prices[i].SoldAt.Before(prices[j].SoldAt)
+	})
+	return prices, nil
+}
+
+// Activity returns the activity log entries with the actions in a time range, oldest first
+func (r *AnomalyRepository) Activity(actions []string, from, to time.Time) ([]models.ActivityLog, error) {
+	r.logs.mu.RLock()
+	defer r.logs.mu.RUnlock()
+
+	entries := []models.ActivityLog{}
+	for _, entry := range r.logs.logs {
+		if slices.Contains(actions, entry.Action) && !entry.Timestamp.Before(from) && entry.Timestamp.Before(to) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	return entries, nil
+}
+
+// Add stores the anomalies whose source was not flagged for their kind yet
+func (r *AnomalyRepository) Add(anomalies []models.Anomaly) ([]models.Anomaly, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added := []models.Anomaly{}
+	for _, anomaly := range anomalies {
+		if r.flagged(anomaly.Kind, anomaly.SourceID) {
+			continue
+		}
+		anomaly.ID = uuid.New().String()
+		anomaly.Status = models.AnomalyOpen
+		anomaly.DetectedAt = time.Now()
+		r.anomalies[anomaly.ID] = anomaly
+		added = append(added, anomaly)
+	}
+	return added, nil
+}
+
+// flagged reports whether a source was flagged for a kind. The caller must hold the lock.
+func (r *AnomalyRepository) flagged(kind, sourceID string) bool {
+	for _, anomaly := range r.anomalies {
+		if anomaly.Kind == kind && anomaly.SourceID == sourceID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetByID returns a copy of an anomaly
+func (r *AnomalyRepository) GetByID(id string) (*models.Anomaly, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	anomaly, ok := r.anomalies[id]
+	if !ok {
+		return nil, fmt.Errorf("anomaly not found: %w", sql.ErrNoRows)
+	}
+	return &anomaly, nil
+}
+
+// GetAll returns the anomalies with a status and kind, or all of them, most recent first
+func (r *AnomalyRepository) GetAll(status, kind string) ([]models.Anomaly, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	anomalies := []models.Anomaly{}
+	for _, anomaly := range r.anomalies {
+		if (status == "" || anomaly.Status == status) && (kind == "" || anomaly.Kind == kind) {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		if !anomalies[i].OccurredAt.Equal(anomalies[j].OccurredAt) {
+			return anomalies[i].OccurredAt.After(anomalies[j].OccurredAt)
+		}
+		return anomalies[i].ID < anomalies[j].ID
+	})
+	return anomalies, nil
+}
+
+// Review acknowledges or dismisses an anomaly that is still open
+func (r *AnomalyRepository) Review(id, status, reviewedBy, note string) (*models.Anomaly, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	anomaly, ok := r.anomalies[id]
+	if !ok || anomaly.Status != models.AnomalyOpen {
+		return nil, fmt.Errorf("open anomaly not found: %w", sql.ErrNoRows)
+	}
+	now := time.Now()
+	anomaly.Status, anomaly.ReviewedBy, anomaly.ReviewedAt, anomaly.Note = status, reviewedBy, &now, note
+	r.anomalies[anomaly.ID] = anomaly
+	return &anomaly, nil
+}
@@ -0,0 +1,78 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnomalyRepository(t *testing.T) {
+	store := memory.NewStore()
+	repo := store.Anomalies
+	now := time.Now()
+	anomaly := func(kind, sourceID string, occurredAt time.Time) models.Anomaly {
+		return models.Anomaly{Kind: kind, SourceID: sourceID, EntityType: "sale", EntityID: sourceID, Details: "Flagged " + sourceID, OccurredAt: occurredAt}
+	}
+
+	added, err := repo.Add([]models.Anomaly{
+		anomaly(models.AnomalyPriceOutlier, "item-1", now.Add(-time.Hour)),
+		anomaly(models.AnomalyAfterHoursVoid, "log-1", now),
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+
+	// The same source is flagged once per kind
+	added, err = repo.Add([]models.Anomaly{
+		anomaly(models.AnomalyPriceOutlier, "item-1", now),
+		anomaly(models.AnomalyStockAdjustment, "item-1", now.Add(-2*time.Hour)),
+	})
+	require.NoError(t, err)
+	require.Len(t, added, 1)
+	assert.Equal(t, models.AnomalyStockAdjustment, added[0].Kind)
+
+	all, err := repo.GetAll("", "")
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "log-1", all[0].SourceID, "most recent first")
+
+	reviewed, err := repo.Review(all[0].ID, models.AnomalyAcknowledged, "admin-1", "Checked")
+	require.NoError(t, err)
+	assert.Equal(t, models.AnomalyAcknowledged, reviewed.Status)
+	require.NotNil(t, reviewed.ReviewedAt)
+	_, err = repo.Review(all[0].ID, models.AnomalyDismissed, "admin-1", "")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "an anomaly is reviewed once")
+	_, err = repo.GetByID("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	open, err := repo.GetAll(models.AnomalyOpen, "")
+	require.NoError(t, err)
+	assert.Len(t, open, 2)
+	outliers, err := repo.GetAll(models.AnomalyOpen, models.AnomalyPriceOutlier)
+	require.NoError(t, err)
+	assert.Len(t, outliers, 1)
+}
+
+func TestAnomalyRepositorySoldPrices(t *testing.T) {
+	store := memory.NewStore()
+	for _, saleDate := range []string{"2026-10-01", "2026-10-15", "2026-10-20"} {
+		saleID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "staff-1", SaleDate: saleDate, TotalPrice: 200})
+		require.NoError(t, err)
+		_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: saleID, Item: models.IntRef(models.RefAccessory, 7), Quantity: 2, UnitPrice: 100, Subtotal: 200})
+		require.NoError(t, err)
+	}
+
+	prices, err := store.Anomalies.SoldPrices("2026-10-01", "2026-10-15")
+	require.NoError(t, err)
+	require.Len(t, prices, 2)
+	assert.Equal(t, "2026-10-01", prices[0].SaleDate)
+	assert.Equal(t, "7", prices[0].ItemID)
+	assert.Equal(t, 100.0, prices[0].UnitPrice)
+}
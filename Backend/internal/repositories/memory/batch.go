@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+// checkBatch returns a *repositories.BatchError for the first change of a batch that
+// cannot be applied to items: one setting a quantity below zero, or updating or
+// deleting an item that does not exist or that an earlier change deleted. The caller
+// must hold the lock.
+func checkBatch[T any](itemType string, changes []models.BatchChange[T], items map[int]T, quantity func(T) int) error {
+	gone := make(map[int]bool)
+	for i, change := range changes {
+		var err error
+		if change.Op != models.BatchDelete && quantity(change.Item) < 0 {
+			err = negativeQuantity(itemType, quantity(change.Item))
+		} else if change.Op != models.BatchCreate {
+			if _, ok := items[change.ID]; !ok || gone[change.ID] {
+				err = fmt.Errorf("%s with ID %d not found: %w", itemType, change.ID, sql.ErrNoRows)
+			}
+			gone[change.ID] = change.Op == models.BatchDelete
+		}
+		if err != nil {
+			return &repositories.BatchError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
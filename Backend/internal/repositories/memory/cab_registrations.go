@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.CabRegistrationRepository = (*CabRegistrationRepository)(nil)
+
+// CabRegistrationRepository is an in-memory implementation of repositories.CabRegistrationRepository
+type CabRegistrationRepository struct {
+	mu            sync.RWMutex
+	registrations map[string]models.CabRegistration
+}
+
+// NewCabRegistrationRepository creates an empty in-memory cab registration repository
+func NewCabRegistrationRepository() *CabRegistrationRepository {
+	return &CabRegistrationRepository{registrations: make(map[string]models.CabRegistration)}
+}
+
+// Create stores a new registration
+func (r *CabRegistrationRepository) Create(registration *models.CabRegistration) error {
+	if registration.ID == "" {
+		registration.ID = uuid.New().String()
+	}
+	now := time.Now()
+	registration.CreatedAt = now
+	registration.UpdatedAt = now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.registrations[registration.ID] = *registration
+	return nil
+}
+
+// GetByID retrieves a registration by its ID
+func (r *CabRegistrationRepository) GetByID(id string) (*models.CabRegistration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	registration, ok := r.registrations[id]
+	if !ok {
+		return nil, fmt.Errorf("cab registration not found: %w", sql.ErrNoRows)
+	}
+	return &registration, nil
+}
+
+// Update saves every editable field of a registration
+func (r *CabRegistrationRepository) Update(registration *models.CabRegistration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.registrations[registration.ID]
+	if !ok {
+		return fmt.Errorf("cab registration not found: %w", sql.ErrNoRows)
+	}
+	registration.SaleID = existing.SaleID
+	registration.CabID = existing.CabID
+	registration.CustomerID = existing.CustomerID
+	registration.CreatedAt = existing.CreatedAt
+	registration.UpdatedAt = time.Now()
+	r.registrations[registration.ID] = *registration
+	return nil
+}
+
+// GetAll returns the registrations matching the filter, soonest due first
+func (r *CabRegistrationRepository) GetAll(filter models.RegistrationFilter) ([]models.CabRegistration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	registrations := []models.CabRegistration{}
+	for _, registration := range r.registrations {
+		if matchesRegistrationFilter(registration, filter) {
+			registrations = append(registrations, registration)
+		}
+	}
+	sort.Slice(registrations, func(i, j int) bool {
+		a, b := registrations[i], registrations[j]
+		if a.DueDate != b.DueDate {
+			return a.DueDate < b.DueDate
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return registrations, nil
+}
+
+func matchesRegistrationFilter(registration models.CabRegistration, filter models.RegistrationFilter) bool {
+	if filter.SaleID != "" && registration.SaleID != filter.SaleID {
+		return false
+	}
+	if filter.CabID != 0 && registration.CabID != filter.CabID {
+		return false
+	}
+	if filter.DueBefore != "" && registration.DueDate >= filter.DueBefore {
+		return false
+	}
+	if len(filter.Statuses) == 0 {
+		return true
+	}
+	for _, status := range filter.Statuses {
+		if registration.Status == status {
+			return true
+		}
+	}
+	return false
+}
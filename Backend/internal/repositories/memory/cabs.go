@@ -0,0 +1,273 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.CabsRepository = (*CabsRepository)(nil)
+
+// CabsRepository is an in-memory implementation of repositories.CabsRepository
+type CabsRepository struct {
+	mu      sync.RWMutex
+	cabs    map[int]models.MultiCab
+	deleted map[int]trashed[models.MultiCab] // Soft-deleted cabs, until restored or purged
+	nextID  int
+}
+
+// NewCabsRepository creates an empty in-memory cab repository
+func NewCabsRepository() *CabsRepository {
+	return &CabsRepository{cabs: make(map[int]models.MultiCab), deleted: make(map[int]trashed[models.MultiCab]), nextID: 1}
+}
+
+// GetCabs returns the cabs matching the filter, newest first unless it sorts them
+// otherwise, limited to its limit after its offset when the limit is positive
+func (r *CabsRepository) GetCabs(ctx context.Context, filter models.CabFilter) ([]models.MultiCab, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cabs := r.matchingCabs(filter)
+	less, ok := cabLess[filter.SortBy]
+	if !ok {
+		sort.Slice(cabs, func(i, j int) bool {
+			if cabs[i].CreatedAt.Equal(cabs[j].CreatedAt) {
+				return cabs[i].ID > cabs[j].ID
+			}
+			return cabs[i].CreatedAt.After(cabs[j].CreatedAt)
+		})
+	} else {
+		desc := strings.EqualFold(filter.SortDir, "desc")
+		sort.Slice(cabs, func(i, j int) bool {
+			a, b := cabs[i], cabs[j]
+			if desc {
+				a, b = b, a
+			}
+			if less(a, b) {
+				return true
+			}
+			if less(b, a) {
+				return false
+			}
+			return a.ID < b.ID
+		})
+	}
+
+	if filter.Limit > 0 {
+		offset := min(max(filter.Offset, 0), len(cabs))
+		cabs = cabs[offset:min(offset+filter.Limit, len(cabs))]
+	}
+	return cabs, nil
+}
+
+// CountCabs counts the cabs matching the filter
+func (r *CabsRepository) CountCabs(ctx context.Context, filter models.CabFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.matchingCabs(filter))), nil
+}
+
+// cabLess orders cabs by each of models.CabSortFields
+var cabLess = map[string]func(a, b models.MultiCab) bool{
+	"id":         func(a, b models.MultiCab) bool { return a.ID < b.ID },
+	"name":       func(a, b models.MultiCab) bool { return a.Name < b.Name },
+	"make":       func(a, b models.MultiCab) bool { return a.Make < b.Make },
+	"quantity":   func(a, b models.MultiCab) bool { return a.Quantity < b.Quantity },
+	"price":      func(a, b models.MultiCab) bool { return a.Price < b.Price },
+	"status":     func(a, b models.MultiCab) bool { return a.Status < b.Status },
+	"unit_color": func(a, b models.MultiCab) bool { return a.UnitColor < b.UnitColor },
+	"created_at": func(a, b models.MultiCab) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"updated_at": func(a, b models.MultiCab) bool { return a.UpdatedAt.Before(b.UpdatedAt) },
+}
+
+// matchingCabs returns the cabs matching the make, unit color, status and search of
+// a filter. The caller must hold the lock.
+func (r *CabsRepository) matchingCabs(filter models.CabFilter) []models.MultiCab {
+	var cabs []models.MultiCab
+	for _, cab := range r.cabs {
+		if filter.Make != "" && cab.Make != filter.Make {
+			continue
+		}
+		if filter.UnitColor != "" && cab.UnitColor != filter.UnitColor {
+			continue
+		}
+		if filter.Status != "" && cab.Status != filter.Status {
+			continue
+		}
+		if filter.Search != "" && !containsFold(cab.Name, filter.Search) && !containsFold(cab.Make, filter.Search) {
+			continue
+		}
+		cabs = append(cabs, withDefaultCabImage(cab))
+	}
+	return cabs
+}
+
+// GetCabByID retrieves a single cab by its ID
+func (r *CabsRepository) GetCabByID(ctx context.Context, id int) (*models.MultiCab, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cab, ok := r.cabs[id]
+	if !ok {
+		return nil, fmt.Errorf("cab with ID %d not found", id)
+	}
+	cab = withDefaultCabImage(cab)
+	return &cab, nil
+}
+
+// AddCab stores a new cab and assigns it the next ID
+func (r *CabsRepository) AddCab(ctx context.Context, cab models.MultiCab) (*models.MultiCab, error) {
+	if cab.Name == "" || cab.Make == "" || cab.UnitColor == "" {
+		return nil, fmt.Errorf("cab name, make, and color cannot be empty")
+	}
+	if cab.Quantity < 0 {
+		return nil, negativeQuantity("cab", cab.Quantity)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	cab.ID = r.nextID
+	cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
+	cab.CreatedAt = now
+	cab.UpdatedAt = now
+	r.nextID++
+	r.cabs[cab.ID] = cab
+
+	cab = withDefaultCabImage(cab)
+	return &cab, nil
+}
+
+// UpdateCab replaces the fields of an existing cab
+func (r *CabsRepository) UpdateCab(ctx context.Context, id int, cab models.MultiCab) (*models.MultiCab, error) {
+	if cab.Quantity < 0 {
+		return nil, negativeQuantity("cab", cab.Quantity)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.cabs[id]
+	if !ok {
+		return nil, fmt.Errorf("cab with ID %d not found for update", id)
+	}
+
+	cab.ID = id
+	cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
+	cab.CreatedAt = existing.CreatedAt
+	cab.UpdatedAt = time.Now()
+	r.cabs[id] = cab
+
+	cab = withDefaultCabImage(cab)
+	return &cab, nil
+}
+
+// DeleteCab soft-deletes a cab, keeping it for RestoreCab
+func (r *CabsRepository) DeleteCab(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cab, ok := r.cabs[id]
+	if !ok {
+		return fmt.Errorf("cab with ID %d not found for deletion", id)
+	}
+	r.deleted[id] = trashed[models.MultiCab]{record: cab, deletedAt: time.Now()}
+	delete(r.cabs, id)
+	return nil
+}
+
+// RestoreCab brings back a deleted cab
+func (r *CabsRepository) RestoreCab(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cab, ok := r.deleted[id]
+	if !ok {
+		return fmt.Errorf("deleted cab with ID %d not found: %w", id, sql.ErrNoRows)
+	}
+	r.cabs[id] = cab.record
+	delete(r.deleted, id)
+	return nil
+}
+
+// GetDeletedCabs returns the deleted cabs, most recently deleted first
+func (r *CabsRepository) GetDeletedCabs(ctx context.Context) ([]models.MultiCab, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return deletedRecords(r.deleted, func(cab *models.MultiCab, deletedAt time.Time) {
+		*cab = withDefaultCabImage(*cab)
+		cab.DeletedAt = &deletedAt
+	}), nil
+}
+
+// adjustQuantity adds delta to the stock of a cab and updates its status; used when a cab is sold or its sale called off
+func (r *CabsRepository) adjustQuantity(id int, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cab, ok := r.cabs[id]; ok {
+		cab.Quantity += delta
+		if delta < 0 {
+			cab.Status = repositories.CabStatusAfterSale(cab.Quantity, cab.Status)
+		} else {
+			cab.Status = repositories.CabStatusAfterRestock(cab.Quantity, cab.Status)
+		}
+		cab.UpdatedAt = time.Now()
+		r.cabs[id] = cab
+	}
+}
+
+// withDefaultCabImage fills in the placeholder image like the database implementation does for NULL images
+func withDefaultCabImage(cab models.MultiCab) models.MultiCab {
+	if cab.Image == "" {
+		cab.Image = config.DefaultImageURL
+	}
+	return cab
+}
+
+// containsFold reports whether substr is within s, ignoring case like MySQL's default collation
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// ApplyBatch creates, updates and deletes cabs, all or none
+func (r *CabsRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.MultiCab]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := checkBatch("cab", changes, r.cabs, func(cab models.MultiCab) int { return cab.Quantity }); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range changes {
+		change := &changes[i]
+		if change.Op == models.BatchDelete {
+			r.deleted[change.ID] = trashed[models.MultiCab]{record: r.cabs[change.ID], deletedAt: now}
+			delete(r.cabs, change.ID)
+			continue
+		}
+
+		cab := &change.Item
+		cab.MinPrice, cab.MaxPrice = models.PriceBound(cab.MinPrice), models.PriceBound(cab.MaxPrice)
+		cab.CreatedAt, cab.UpdatedAt = now, now
+		if change.Op == models.BatchCreate {
+			cab.ID = r.nextID
+			r.nextID++
+		} else {
+			cab.ID, cab.CreatedAt = change.ID, r.cabs[change.ID].CreatedAt
+		}
+		r.cabs[cab.ID] = *cab
+		*cab = withDefaultCabImage(*cab)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCabsRepositoryGetCabs(t *testing.T) {
+	repo := memory.NewCabsRepository()
+	for _, cab := range []models.MultiCab{
+		{Name: "Scrum Wagon", Make: "Mazda", Price: 300, Status: "In Stock", UnitColor: "White"},
+		{Name: "Carry Truck", Make: "Toyota", Price: 100, Status: "Low Stock", UnitColor: "Silver"},
+		{Name: "Bongo", Make: "Mazda", Price: 200, Status: "Low Stock", UnitColor: "Silver"},
+	} {
+		_, err := repo.AddCab(context.Background(), cab)
+		require.NoError(t, err)
+	}
+
+	names := func(cabs []models.MultiCab) []string {
+		result := []string{}
+		for _, cab := range cabs {
+			result = append(result, cab.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		filter models.CabFilter
+		want   []string
+	}{
+		{"No filters, newest first", models.CabFilter{}, []string{"Bongo", "Carry Truck", "Scrum Wagon"}},
+		{"Make", models.CabFilter{Make: "Mazda"}, []string{"Bongo", "Scrum Wagon"}},
+		{"Color and status", models.CabFilter{UnitColor: "Silver", Status: "Low Stock"}, []string{"Bongo", "Carry Truck"}},
+		{"Search ignores case", models.CabFilter{Search: "toyo"}, []string{"Carry Truck"}},
+		{"No match", models.CabFilter{Make: "Ford"}, []string{}},
+		{"Sorted by price", models.CabFilter{SortBy: "price"}, []string{"Carry Truck", "Bongo", "Scrum Wagon"}},
+		{"Page sorted by name descending", models.CabFilter{SortBy: "name", SortDir: "desc", Limit: 1, Offset: 1}, []string{"Carry Truck"}},
+		{"Page past the end", models.CabFilter{Limit: 2, Offset: 5}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cabs, err := repo.GetCabs(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, names(cabs))
+		})
+	}
+
+	total, err := repo.CountCabs(context.Background(), models.CabFilter{Make: "Mazda", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total, "the count ignores paging")
+}
+
+func TestCabsRepositoryCRUD(t *testing.T) {
+	repo := memory.NewCabsRepository()
+
+	_, err := repo.AddCab(context.Background(), models.MultiCab{Name: "Scrum Wagon"})
+	assert.EqualError(t, err, "cab name, make, and color cannot be empty")
+
+	cab, err := repo.AddCab(context.Background(), models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 3, Price: 185000})
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.ID)
+	assert.Equal(t, config.DefaultImageURL, cab.Image)
+
+	cab.Quantity = 2
+	updated, err := repo.UpdateCab(context.Background(), cab.ID, *cab)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Quantity)
+	assert.Equal(t, cab.CreatedAt, updated.CreatedAt)
+
+	require.NoError(t, repo.DeleteCab(context.Background(), cab.ID))
+
+	_, err = repo.GetCabByID(context.Background(), cab.ID)
+	assert.EqualError(t, err, "cab with ID 1 not found")
+	_, err = repo.UpdateCab(context.Background(), cab.ID, *cab)
+	assert.EqualError(t, err, "cab with ID 1 not found for update")
+	assert.EqualError(t, repo.DeleteCab(context.Background(), cab.ID), "cab with ID 1 not found for deletion")
+}
+
+func TestCabsRepositorySoftDelete(t *testing.T) {
+	repo := memory.NewCabsRepository()
+	cab, err := repo.AddCab(context.Background(), models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 3, Price: 185000})
+	require.NoError(t, err)
+	assert.ErrorIs(t, repo.RestoreCab(context.Background(), cab.ID), sql.ErrNoRows, "only deleted cabs can be restored")
+
+	require.NoError(t, repo.DeleteCab(context.Background(), cab.ID))
+	cabs, err := repo.GetCabs(context.Background(), models.CabFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, cabs)
+	deleted, err := repo.GetDeletedCabs(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, 3, deleted[0].Quantity)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	require.NoError(t, repo.RestoreCab(context.Background(), cab.ID))
+	restored, err := repo.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Quantity)
+	assert.Nil(t, restored.DeletedAt)
+	deleted, err = repo.GetDeletedCabs(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+}
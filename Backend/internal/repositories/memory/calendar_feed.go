@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.CalendarFeedRepository = (*CalendarFeedRepository)(nil)
+
+// CalendarFeedRepository is an in-memory implementation of repositories.CalendarFeedRepository
+type CalendarFeedRepository struct {
+	mu    sync.RWMutex
+	feeds map[string]models.CalendarFeed // User ID -> feed
+}
+
+// NewCalendarFeedRepository creates an empty in-memory calendar feed repository
+func NewCalendarFeedRepository() *CalendarFeedRepository {
+	return &CalendarFeedRepository{feeds: make(map[string]models.CalendarFeed)}
+}
+
+// GetByUser returns a copy of the user's feed
+func (r *CalendarFeedRepository) GetByUser(userID string) (*models.CalendarFeed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	feed, ok := r.feeds[userID]
+	if !ok {
+		return nil, fmt.Errorf("calendar feed not found: %w", sql.ErrNoRows)
+	}
+	return &feed, nil
+}
+
+// GetByTokenHash returns a copy of the feed with the token hash
+func (r *CalendarFeedRepository) GetByTokenHash(tokenHash string) (*models.CalendarFeed, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, feed := range r.feeds {
+		if feed.TokenHash == tokenHash {
+			return &feed, nil
+		}
+	}
+	return nil, fmt.Errorf("calendar feed not found: %w", sql.ErrNoRows)
+}
+
+// Save replaces the user's feed
+func (r *CalendarFeedRepository) Save(feed *models.CalendarFeed) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.feeds[feed.UserID] = models.CalendarFeed{UserID: feed.UserID, TokenHash: feed.TokenHash, CreatedAt: feed.CreatedAt}
+	return nil
+}
+
+// RecordFetch stores when the user's feed was last fetched
+func (r *CalendarFeedRepository) RecordFetch(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if feed, ok := r.feeds[userID]; ok {
+		feed.LastFetchedAt = &at
+		r.feeds[userID] = feed
+	}
+	return nil
+}
+
+// Delete removes the user's feed
+func (r *CalendarFeedRepository) Delete(userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.feeds[userID]; !ok {
+		return fmt.Errorf("calendar feed not found: %w", sql.ErrNoRows)
+	}
+	delete(r.feeds, userID)
+	return nil
+}
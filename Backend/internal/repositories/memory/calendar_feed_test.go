@@ -0,0 +1,38 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarFeedRepository(t *testing.T) {
+	repo := memory.NewCalendarFeedRepository()
+
+	_, err := repo.GetByUser("staff-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	require.NoError(t, repo.Save(&models.CalendarFeed{UserID: "staff-1", TokenHash: "hash-1", CreatedAt: time.Now()}))
+	require.NoError(t, repo.RecordFetch("staff-1", time.Now()))
+	feed, err := repo.GetByTokenHash("hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "staff-1", feed.UserID)
+	assert.NotNil(t, feed.LastFetchedAt)
+
+	require.NoError(t, repo.Save(&models.CalendarFeed{UserID: "staff-1", TokenHash: "hash-2", CreatedAt: time.Now()}))
+	_, err = repo.GetByTokenHash("hash-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "a new feed replaces the old token")
+	feed, err = repo.GetByUser("staff-1")
+	require.NoError(t, err)
+	assert.Nil(t, feed.LastFetchedAt, "the new feed has not been fetched yet")
+
+	require.NoError(t, repo.Delete("staff-1"))
+	assert.True(t, errors.Is(repo.Delete("staff-1"), sql.ErrNoRows))
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.ChangeRequestRepository = (*ChangeRequestRepository)(nil)
+
+// ChangeRequestRepository is an in-memory implementation of repositories.ChangeRequestRepository
+type ChangeRequestRepository struct {
+	mu       sync.RWMutex
+	requests map[string]models.ChangeRequest
+}
+
+// NewChangeRequestRepository creates an empty in-memory change request repository
+func NewChangeRequestRepository() *ChangeRequestRepository {
+	return &ChangeRequestRepository{requests: make(map[string]models.ChangeRequest)}
+}
+
+// copyChangeRequest returns a copy of a request that shares no slice with it
+func copyChangeRequest(request models.ChangeRequest) models.ChangeRequest {
+	request.Changes = append([]models.FieldChange(nil), request.Changes...)
+	return request
+}
+
+// Create stores a new pending change request
+func (r *ChangeRequestRepository) Create(request *models.ChangeRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if request.ID == "" {
+		request.ID = uuid.New().String()
+	}
+	request.Status = models.ChangeRequestPending
+	request.RequestedAt = time.Now()
+	r.requests[request.ID] = copyChangeRequest(*request)
+	return nil
+}
+
+// GetByID returns a copy of a change request
+func (r *ChangeRequestRepository) GetByID(id string) (*models.ChangeRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	request, ok := r.requests[id]
+	if !ok {
+		return nil, fmt.Errorf("change request not found: %w", sql.ErrNoRows)
+	}
+	request = copyChangeRequest(request)
+	return &request, nil
+}
+
+// GetAll returns the requests matching the filter, most recently requested first
+func (r *ChangeRequestRepository) GetAll(filter models.ChangeRequestFilter) ([]models.ChangeRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	requests := []models.ChangeRequest{}
+	for _, request := range r.requests {
+		if filter.Status != "" && request.Status != filter.Status {
+			continue
+		}
+		if filter.RequestedBy != "" && request.RequestedBy != filter.RequestedBy {
+			continue
+		}
+		requests = append(requests, copyChangeRequest(request))
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].RequestedAt.After(requests[j].RequestedAt) })
+	return requests, nil
+}
+
+// Review marks a request that is still pending applied or rejected
+func (r *ChangeRequestRepository) Review(id, status, reviewedBy, note string) (*models.ChangeRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request, ok := r.requests[id]
+	if !ok || request.Status != models.ChangeRequestPending {
+		return nil, fmt.Errorf("pending change request not found: %w", sql.ErrNoRows)
+	}
+	now := time.Now()
+	request.Status, request.ReviewedBy, request.ReviewedAt, request.Note = status, reviewedBy, &now, note
+	r.requests[request.ID] = request
+	request = copyChangeRequest(request)
+	return &request, nil
+}
@@ -0,0 +1,47 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeRequestRepository(t *testing.T) {
+	repo := memory.NewChangeRequestRepository()
+
+	_, err := repo.GetByID("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	request := &models.ChangeRequest{EntityType: models.InventoryCab, EntityID: 1, EntityName: "RX-7", RequestedBy: "staff-1",
+		Changes: []models.FieldChange{{Field: "status", Before: "Available", After: "Reserved"}}}
+	require.NoError(t, repo.Create(request))
+	require.NoError(t, repo.Create(&models.ChangeRequest{EntityType: models.InventoryMaterial, EntityID: 2, RequestedBy: "staff-2"}))
+	assert.Equal(t, models.ChangeRequestPending, request.Status)
+
+	request.Changes[0].After = "Sold"
+	saved, err := repo.GetByID(request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Reserved", saved.Changes[0].After, "stored requests share nothing with the caller")
+
+	reviewed, err := repo.Review(request.ID, models.ChangeRequestApplied, "admin-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "admin-1", reviewed.ReviewedBy)
+	assert.NotNil(t, reviewed.ReviewedAt)
+	_, err = repo.Review(request.ID, models.ChangeRequestRejected, "admin-2", "")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "reviewed once")
+
+	requests, err := repo.GetAll(models.ChangeRequestFilter{Status: models.ChangeRequestPending})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "staff-2", requests[0].RequestedBy)
+	requests, err = repo.GetAll(models.ChangeRequestFilter{RequestedBy: "staff-1"})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, request.ID, requests[0].ID)
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.CustomerConsentRepository = (*CustomerConsentRepository)(nil)
+
+// CustomerConsentRepository is an in-memory implementation of repositories.CustomerConsentRepository
+type CustomerConsentRepository struct {
+	mu       sync.RWMutex
+	consents map[string]models.CustomerConsent // Customer ID -> flags
+}
+
+// NewCustomerConsentRepository creates an empty in-memory customer consent repository
+func NewCustomerConsentRepository() *CustomerConsentRepository {
+	return &CustomerConsentRepository{consents: make(map[string]models.CustomerConsent)}
+}
+
+// Get returns a copy of the customer's flags, or empty flags when none are recorded
+func (r *CustomerConsentRepository) Get(customerID string) (*models.CustomerConsent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	consent, ok := r.consents[customerID]
+	if !ok {
+		return &models.CustomerConsent{CustomerID: customerID}, nil
+	}
+	return &consent, nil
+}
+
+// GetAll returns copies of the recorded flags, ordered by customer ID
+func (r *CustomerConsentRepository) GetAll() ([]models.CustomerConsent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	consents := make([]models.CustomerConsent, 0, len(r.consents))
+	for _, consent := range r.consents {
+		consents = append(consents, consent)
+	}
+	sort.Slice(consents, func(i, j int) bool { return consents[i].CustomerID < consents[j].CustomerID })
+	return consents, nil
+}
+
+// Save replaces the customer's flags
+func (r *CustomerConsentRepository) Save(consent *models.CustomerConsent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.consents[consent.CustomerID] = *consent
+	return nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.CustomerRepository = (*CustomerRepository)(nil)
+
+// CustomerRepository is an in-memory implementation of repositories.CustomerRepository
+type CustomerRepository struct {
+	mu        sync.RWMutex
+	customers map[string]models.Customer
+	deleted   map[string]trashed[models.Customer] // Soft-deleted customers, until restored or purged
+}
+
+// NewCustomerRepository creates an empty in-memory customer repository
+func NewCustomerRepository() *CustomerRepository {
+	return &CustomerRepository{customers: make(map[string]models.Customer), deleted: make(map[string]trashed[models.Customer])}
+}
+
+// CreateCustomer stores a new customer
+func (r *CustomerRepository) CreateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
+	if customer.ID == "" {
+		customer.ID = uuid.New().String()
+	}
+	now := time.Now()
+	customer.DateRegistered = now
+	customer.CreatedAt = now
+	customer.UpdatedAt = now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.customers {
+		if existing.Email == customer.Email {
+			return nil, fmt.Errorf("could not create customer: duplicate email %s", customer.Email)
+		}
+	}
+	r.customers[customer.ID] = *customer
+
+	created := *customer
+	return &created, nil
+}
+
+// GetCustomerByID retrieves a customer by their ID
+func (r *CustomerRepository) GetCustomerByID(ctx context.Context, id string) (*models.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	customer, ok := r.customers[id]
+	if !ok {
+		return nil, fmt.Errorf("customer with ID %s not found", id)
+	}
+	return &customer, nil
+}
+
+// GetCustomerByEmail retrieves a customer by their email address
+func (r *CustomerRepository) GetCustomerByEmail(ctx context.Context, email string) (*models.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, customer := range r.customers {
+		if customer.Email == email {
+			return &customer, nil
+		}
+	}
+	return nil, fmt.Errorf("customer with email %s not found", email)
+}
+
+// GetAllCustomers returns every customer, newest first
+func (r *CustomerRepository) GetAllCustomers(ctx context.Context) ([]*models.Customer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	customers := make([]*models.Customer, 0, len(r.customers))
+	for _, customer := range r.customers {
+		customer := customer
+		customers = append(customers, &customer)
+	}
+	sort.Slice(customers, func(i, j int) bool {
+		if customers[i].CreatedAt.Equal(customers[j].CreatedAt) {
+			return customers[i].ID > customers[j].ID
+		}
+		return customers[i].CreatedAt.After(customers[j].CreatedAt)
+	})
+	return customers, nil
+}
+
+// GetPaginated returns one page of the customers whose name, email or phone contains
+// the search term, newest first, along with the total number of matches
+func (r *CustomerRepository) GetPaginated(ctx context.Context, page, limit int, search string) ([]*models.Customer, int64, error) {
+	all, _ := r.GetAllCustomers(ctx)
+	customers := []*models.Customer{}
+	for _, customer := range all {
+		if search == "" || containsFold(customer.FullName, search) || containsFold(customer.Email, search) || strings.Contains(customer.Phone, search) {
+			customers = append(customers, customer)
+		}
+	}
+	total := int64(len(customers))
+
+	offset := (page - 1) * limit
+	if offset < 0 || offset >= len(customers) {
+		return []*models.Customer{}, total, nil
+	}
+	end := offset + limit
+	if end > len(customers) {
+		end = len(customers)
+	}
+	return customers[offset:end], total, nil
+}
+
+// UpdateCustomer stores the contact details of an existing customer
+func (r *CustomerRepository) UpdateCustomer(ctx context.Context, customer *models.Customer) (*models.Customer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.customers[customer.ID]
+	if !ok {
+		return nil, fmt.Errorf("customer with ID %s not found for update", customer.ID)
+	}
+
+	existing.FullName = customer.FullName
+	existing.Email = customer.Email
+	existing.Phone = customer.Phone
+	existing.Address = customer.Address
+	existing.Province = customer.Province
+	existing.City = customer.City
+	existing.Barangay = customer.Barangay
+	existing.Latitude = customer.Latitude
+	existing.Longitude = customer.Longitude
+	existing.DateOfBirth = customer.DateOfBirth
+	existing.UpdatedAt = time.Now()
+	r.customers[customer.ID] = existing
+
+	updated := existing
+	return &updated, nil
+}
+
+// DeleteCustomer soft-deletes a customer, keeping them for RestoreCustomer
+func (r *CustomerRepository) DeleteCustomer(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customer, ok := r.customers[id]
+	if !ok {
+		return fmt.Errorf("customer with ID %s not found for deletion", id)
+	}
+	r.deleted[customer.ID] = trashed[models.Customer]{record: customer, deletedAt: time.Now()} // Keyed by the stored ID; id may point into a request buffer
+	delete(r.customers, id)
+	return nil
+}
+
+// RestoreCustomer brings back a deleted customer
+func (r *CustomerRepository) RestoreCustomer(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	customer, ok := r.deleted[id]
+	if !ok {
+		return fmt.Errorf("deleted customer with ID %s not found: %w", id, sql.ErrNoRows)
+	}
+	r.customers[customer.record.ID] = customer.record
+	delete(r.deleted, id)
+	return nil
+}
@@ -0,0 +1,84 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerRepositoryCRUD(t *testing.T) {
+	repo := memory.NewCustomerRepository()
+
+	created, err := repo.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "+639171234567"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.False(t, created.DateRegistered.IsZero())
+
+	_, err = repo.CreateCustomer(context.Background(), &models.Customer{FullName: "Other Juan", Email: "juan@example.com"})
+	assert.Error(t, err, "duplicate email")
+
+	byEmail, err := repo.GetCustomerByEmail(context.Background(), "juan@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, byEmail.ID)
+
+	// Returned customers are copies; changing one must not change the stored record
+	byEmail.FullName = "Changed"
+	stored, err := repo.GetCustomerByID(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Juan Dela Cruz", stored.FullName)
+
+	stored.Address = "123 Rizal St"
+	updated, err := repo.UpdateCustomer(context.Background(), stored)
+	require.NoError(t, err)
+	assert.Equal(t, "123 Rizal St", updated.Address)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+
+	require.NoError(t, repo.DeleteCustomer(context.Background(), created.ID))
+	customers, err := repo.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, customers)
+}
+
+func TestCustomerRepositoryNotFound(t *testing.T) {
+	repo := memory.NewCustomerRepository()
+
+	_, err := repo.GetCustomerByID(context.Background(), "c-1")
+	assert.EqualError(t, err, "customer with ID c-1 not found")
+	_, err = repo.GetCustomerByEmail(context.Background(), "nobody@example.com")
+	assert.EqualError(t, err, "customer with email nobody@example.com not found")
+	_, err = repo.UpdateCustomer(context.Background(), &models.Customer{ID: "c-1"})
+	assert.EqualError(t, err, "customer with ID c-1 not found for update")
+	assert.EqualError(t, repo.DeleteCustomer(context.Background(), "c-1"), "customer with ID c-1 not found for deletion")
+}
+
+func TestCustomerRepositoryGetPaginated(t *testing.T) {
+	repo := memory.NewCustomerRepository()
+	for _, customer := range []*models.Customer{
+		{FullName: "Juan Dela Cruz", Email: "juan@example.com", Phone: "+639171234567"},
+		{FullName: "Maria Santos", Email: "maria@example.com", Phone: "+639181234567"},
+		{FullName: "Jose Rizal", Email: "jose@juanmail.ph", Phone: "+639191234567"},
+	} {
+		_, err := repo.CreateCustomer(context.Background(), customer)
+		require.NoError(t, err)
+	}
+
+	customers, total, err := repo.GetPaginated(context.Background(), 1, 10, "JUAN")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total, "names and emails are searched")
+	assert.Len(t, customers, 2)
+
+	customers, total, err = repo.GetPaginated(context.Background(), 1, 10, "918")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total, "phone numbers are searched")
+	assert.Equal(t, "Maria Santos", customers[0].FullName)
+
+	customers, total, err = repo.GetPaginated(context.Background(), 2, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, customers, 1)
+}
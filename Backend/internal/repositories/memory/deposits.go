@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.DepositRepository = (*DepositRepository)(nil)
+
+// DepositRepository is an in-memory implementation of repositories.DepositRepository
+type DepositRepository struct {
+	mu       sync.RWMutex
+	deposits map[string]models.BankDeposit
+	sessions map[string]string // Deposit ID by register session ID
+}
+
+// NewDepositRepository creates an empty in-memory deposit repository
+func NewDepositRepository() *DepositRepository {
+	return &DepositRepository{deposits: make(map[string]models.BankDeposit), sessions: make(map[string]string)}
+}
+
+// Create stores a deposit with the links to its register sessions. Like the unique
+// key of the database, it fails when a session was already deposited.
+func (r *DepositRepository) Create(deposit *models.BankDeposit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, sessionID := range deposit.SessionIDs {
+		if _, ok := r.sessions[sessionID]; ok {
+			return fmt.Errorf("failed to link register session %s to bank deposit: already deposited", sessionID)
+		}
+	}
+
+	if deposit.ID == "" {
+		deposit.ID = uuid.New().String()
+	}
+	deposit.CreatedAt = time.Now()
+	for _, sessionID := range deposit.SessionIDs {
+		r.sessions[sessionID] = deposit.ID
+	}
+	r.deposits[deposit.ID] = cloneDeposit(*deposit)
+	return nil
+}
+
+// GetByID returns a copy of a deposit
+func (r *DepositRepository) GetByID(id string) (*models.BankDeposit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deposit, ok := r.deposits[id]
+	if !ok {
+		return nil, fmt.Errorf("bank deposit not found: %w", sql.ErrNoRows)
+	}
+	deposit = cloneDeposit(deposit)
+	return &deposit, nil
+}
+
+// GetAll returns copies of the deposits matching the filter, latest deposit date first
+func (r *DepositRepository) GetAll(filter models.DepositFilter) ([]models.BankDeposit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deposits := []models.BankDeposit{}
+	for _, deposit := range r.deposits {
+		switch {
+		case filter.StartDate != "" && deposit.DepositDate < filter.StartDate:
+			continue
+		case filter.EndDate != "" && deposit.DepositDate > filter.EndDate:
+			continue
+		case filter.SessionID != "" && r.sessions[filter.SessionID] != deposit.ID:
+			continue
+		}
+		deposits = append(deposits, cloneDeposit(deposit))
+	}
+	sort.Slice(deposits, func(i, j int) bool {
+		if deposits[i].DepositDate != deposits[j].DepositDate {
+			return deposits[i].DepositDate > deposits[j].DepositDate
+		}
+		return deposits[i].CreatedAt.After(deposits[j].CreatedAt)
+	})
+	return deposits, nil
+}
+
+// Delete removes a deposit, leaving its sessions undeposited
+func (r *DepositRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deposit, ok := r.deposits[id]
+	if !ok {
+		return fmt.Errorf("bank deposit not found: %w", sql.ErrNoRows)
+	}
+	for _, sessionID := range deposit.SessionIDs {
+		delete(r.sessions, sessionID)
+	}
+	delete(r.deposits, id)
+	return nil
+}
+
+// DepositedSessions returns the deposit ID of each of the sessions that was deposited
+func (r *DepositRepository) DepositedSessions(sessionIDs []string) (map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deposited := make(map[string]string)
+	for _, sessionID := range sessionIDs {
+		if depositID, ok := r.sessions[sessionID]; ok {
+			deposited[sessionID] = depositID
+		}
+	}
+	return deposited, nil
+}
+
+// cloneDeposit copies the session IDs of a deposit, so callers cannot change stored deposits
+func cloneDeposit(deposit models.BankDeposit) models.BankDeposit {
+	deposit.SessionIDs = append([]string{}, deposit.SessionIDs...)
+	sort.Strings(deposit.SessionIDs)
+	return deposit
+}
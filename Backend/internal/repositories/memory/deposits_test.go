@@ -0,0 +1,55 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositRepository(t *testing.T) {
+	store := memory.NewStore()
+	closedAt := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+	var sessionIDs []string
+	for i := 0; i < 3; i++ {
+		session := &models.RegisterSession{OpenedBy: "staff-1", OpenedAt: closedAt.Add(-time.Hour)}
+		require.NoError(t, store.Registers.Open(session))
+		at := closedAt.AddDate(0, 0, i)
+		session.ClosedAt = &at
+		require.NoError(t, store.Registers.Close(session))
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+
+	deposit := &models.BankDeposit{Bank: "BDO", Reference: "DS-1", Amount: 1000, DepositDate: "2025-03-12", SessionIDs: []string{sessionIDs[1], sessionIDs[0]}}
+	require.NoError(t, store.Deposits.Create(deposit))
+	assert.Error(t, store.Deposits.Create(&models.BankDeposit{SessionIDs: []string{sessionIDs[0]}}), "a session is deposited once")
+
+	deposited, err := store.Deposits.DepositedSessions(sessionIDs)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{sessionIDs[0]: deposit.ID, sessionIDs[1]: deposit.ID}, deposited)
+
+	undeposited, err := store.Registers.GetUndeposited(closedAt.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	require.Len(t, undeposited, 1)
+	assert.Equal(t, sessionIDs[2], undeposited[0].ID)
+
+	deposits, err := store.Deposits.GetAll(models.DepositFilter{SessionID: sessionIDs[1]})
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	assert.Len(t, deposits[0].SessionIDs, 2)
+	deposits, err = store.Deposits.GetAll(models.DepositFilter{StartDate: "2025-03-13"})
+	require.NoError(t, err)
+	assert.Empty(t, deposits)
+
+	require.NoError(t, store.Deposits.Delete(deposit.ID))
+	assert.True(t, errors.Is(store.Deposits.Delete(deposit.ID), sql.ErrNoRows))
+	undeposited, err = store.Registers.GetUndeposited(closedAt.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	assert.Len(t, undeposited, 3, "deleting a deposit releases its sessions")
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.DisplayNumberRepository = (*DisplayNumberRepository)(nil)
+
+// DisplayNumberRepository is an in-memory implementation of repositories.DisplayNumberRepository
+type DisplayNumberRepository struct {
+	mu        sync.Mutex
+	formats   map[string]models.NumberFormat
+	sequences map[string]int                  // Last number given, by entity type and scope
+	numbers   map[string]models.DisplayNumber // By entity type and ID
+}
+
+// NewDisplayNumberRepository creates an empty in-memory display number repository
+func NewDisplayNumberRepository() *DisplayNumberRepository {
+	return &DisplayNumberRepository{
+		formats:   make(map[string]models.NumberFormat),
+		sequences: make(map[string]int),
+		numbers:   make(map[string]models.DisplayNumber),
+	}
+}
+
+// GetFormats returns the numbering formats of every entity type that has one
+func (r *DisplayNumberRepository) GetFormats() ([]models.NumberFormat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	formats := make([]models.NumberFormat, 0, len(r.formats))
+	for _, format := range r.formats {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool { return formats[i].EntityType < formats[j].EntityType })
+	return formats, nil
+}
+
+// GetFormat retrieves the numbering format of an entity type
+func (r *DisplayNumberRepository) GetFormat(entityType string) (*models.NumberFormat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	format, ok := r.formats[entityType]
+	if !ok {
+		return nil, fmt.Errorf("number format not found: %w", sql.ErrNoRows)
+	}
+	return &format, nil
+}
+
+// SaveFormat sets the numbering format of an entity type
+func (r *DisplayNumberRepository) SaveFormat(format *models.NumberFormat) error {
+	format.UpdatedAt = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formats[format.EntityType] = *format
+	return nil
+}
+
+// DeleteFormat removes the numbering format of an entity type
+func (r *DisplayNumberRepository) DeleteFormat(entityType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.formats[entityType]; !ok {
+		return fmt.Errorf("number format not found: %w", sql.ErrNoRows)
+	}
+	delete(r.formats, entityType)
+	return nil
+}
+
+// Assign gives a record the next number of the scope's sequence
+func (r *DisplayNumberRepository) Assign(entityType, entityID, scope string) (*models.DisplayNumber, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := entityType + "/" + entityID
+	if assigned, ok := r.numbers[key]; ok {
+		return &assigned, nil
+	}
+	sequenceKey := entityType + "/" + scope
+	r.sequences[sequenceKey]++
+	assigned := models.DisplayNumber{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Number:     models.FormatSequence(scope, r.sequences[sequenceKey]),
+		CreatedAt:  time.Now(),
+	}
+	r.numbers[key] = assigned
+	return &assigned, nil
+}
+
+// GetNumbers returns the display numbers of records by ID
+func (r *DisplayNumberRepository) GetNumbers(entityType string, entityIDs []string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	numbers := make(map[string]string, len(entityIDs))
+	for _, id := range entityIDs {
+		if assigned, ok := r.numbers[entityType+"/"+id]; ok {
+			numbers[id] = assigned.Number
+		}
+	}
+	return numbers, nil
+}
+
+// Find returns the records given a display number
+func (r *DisplayNumberRepository) Find(number string) ([]models.DisplayNumber, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := []models.DisplayNumber{}
+	for _, assigned := range r.numbers {
+		if assigned.Number == number {
+			found = append(found, assigned)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].EntityType < found[j].EntityType })
+	return found, nil
+}
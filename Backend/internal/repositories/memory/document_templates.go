@@ -0,0 +1,84 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.DocumentTemplateRepository = (*DocumentTemplateRepository)(nil)
+
+// DocumentTemplateRepository is an in-memory implementation of repositories.DocumentTemplateRepository
+type DocumentTemplateRepository struct {
+	mu       sync.RWMutex
+	template models.DocumentTemplate
+	logo     *models.DocumentLogo
+}
+
+// NewDocumentTemplateRepository creates an in-memory template repository with no template saved
+func NewDocumentTemplateRepository() *DocumentTemplateRepository {
+	return &DocumentTemplateRepository{}
+}
+
+// Get returns the template, or an empty template when none was saved
+func (r *DocumentTemplateRepository) Get() (models.DocumentTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template := r.template
+	template.HasLogo = r.logo != nil
+	return template, nil
+}
+
+// Save replaces the text of the template, keeping the logo
+func (r *DocumentTemplateRepository) Save(template models.DocumentTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	template.HasLogo = false
+	r.template = template
+	return nil
+}
+
+// GetLogo returns a copy of the logo image
+func (r *DocumentTemplateRepository) GetLogo() (models.DocumentLogo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.logo == nil {
+		return models.DocumentLogo{}, fmt.Errorf("document logo not found: %w", sql.ErrNoRows)
+	}
+	return models.DocumentLogo{ContentType: r.logo.ContentType, Data: append([]byte(nil), r.logo.Data...)}, nil
+}
+
+// SaveLogo replaces the logo image
+func (r *DocumentTemplateRepository) SaveLogo(logo models.DocumentLogo, updatedBy string, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logo = &models.DocumentLogo{ContentType: logo.ContentType, Data: append([]byte(nil), logo.Data...)}
+	r.touch(updatedBy, updatedAt)
+	return nil
+}
+
+// DeleteLogo removes the logo image
+func (r *DocumentTemplateRepository) DeleteLogo(updatedBy string, updatedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.logo != nil {
+		r.logo = nil
+		r.touch(updatedBy, updatedAt)
+	}
+	return nil
+}
+
+// touch records who last changed the template; the caller holds the lock
+func (r *DocumentTemplateRepository) touch(updatedBy string, updatedAt time.Time) {
+	r.template.UpdatedBy = updatedBy
+	r.template.UpdatedAt = &updatedAt
+}
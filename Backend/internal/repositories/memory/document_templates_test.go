@@ -0,0 +1,44 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentTemplateRepository(t *testing.T) {
+	repo := memory.NewDocumentTemplateRepository()
+
+	template, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, models.DocumentTemplate{}, template, "nothing is saved yet")
+	_, err = repo.GetLogo()
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	savedAt := time.Now()
+	require.NoError(t, repo.SaveLogo(models.DocumentLogo{ContentType: "image/png", Data: []byte("png")}, "admin-1", savedAt))
+	require.NoError(t, repo.Save(models.DocumentTemplate{CompanyName: "Surplus Motors", UpdatedBy: "admin-2", UpdatedAt: &savedAt}))
+
+	template, err = repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "Surplus Motors", template.CompanyName)
+	assert.True(t, template.HasLogo, "saving the text keeps the logo")
+	assert.Equal(t, "admin-2", template.UpdatedBy)
+
+	logo, err := repo.GetLogo()
+	require.NoError(t, err)
+	assert.Equal(t, models.DocumentLogo{ContentType: "image/png", Data: []byte("png")}, logo)
+
+	require.NoError(t, repo.DeleteLogo("admin-1", savedAt))
+	template, err = repo.Get()
+	require.NoError(t, err)
+	assert.False(t, template.HasLogo)
+	assert.Equal(t, "Surplus Motors", template.CompanyName)
+}
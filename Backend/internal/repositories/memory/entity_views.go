@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.EntityViewRepository = (*EntityViewRepository)(nil)
+
+// EntityViewRepository is an in-memory implementation of repositories.EntityViewRepository
+type EntityViewRepository struct {
+	mu    sync.RWMutex
+	views map[entityViewKey]models.EntityView
+}
+
+type entityViewKey struct {
+	userID     string
+	entityType string
+	entityID   string
+}
+
+// NewEntityViewRepository creates an empty in-memory view repository
+func NewEntityViewRepository() *EntityViewRepository {
+	return &EntityViewRepository{views: make(map[entityViewKey]models.EntityView)}
+}
+
+// RecordView stores a view, replacing the user's previous view of the same record
+func (r *EntityViewRepository) RecordView(view models.EntityView) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.views[entityViewKey{view.UserID, view.EntityType, view.EntityID}] = view
+	return nil
+}
+
+// GetRecent returns a user's latest views, most recent first
+func (r *EntityViewRepository) GetRecent(userID, entityType string, limit int) ([]models.EntityView, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	views := []models.EntityView{}
+	for key, view := range r.views {
+		if key.userID == userID && (entityType == "" || key.entityType == entityType) {
+			views = append(views, view)
+		}
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].ViewedAt.After(views[j].ViewedAt) })
+	if len(views) > limit {
+		views = views[:limit]
+	}
+	return views, nil
+}
@@ -0,0 +1,37 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityViewRepository(t *testing.T) {
+	repo := memory.NewEntityViewRepository()
+	start := time.Now()
+
+	require.NoError(t, repo.RecordView(models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCab, EntityID: "1", ViewedAt: start}))
+	require.NoError(t, repo.RecordView(models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCustomer, EntityID: "c-1", ViewedAt: start.Add(time.Minute)}))
+	require.NoError(t, repo.RecordView(models.EntityView{UserID: "user-2", EntityType: models.ViewEntityCab, EntityID: "2", ViewedAt: start}))
+	require.NoError(t, repo.RecordView(models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCab, EntityID: "1", ViewedAt: start.Add(2 * time.Minute)}))
+
+	views, err := repo.GetRecent("user-1", "", 10)
+	require.NoError(t, err)
+	require.Len(t, views, 2, "viewing a record again replaces the earlier view")
+	assert.Equal(t, "1", views[0].EntityID)
+	assert.Equal(t, "c-1", views[1].EntityID)
+
+	views, err = repo.GetRecent("user-1", models.ViewEntityCustomer, 10)
+	require.NoError(t, err)
+	require.Len(t, views, 1)
+	assert.Equal(t, "c-1", views[0].EntityID)
+
+	views, err = repo.GetRecent("user-1", "", 1)
+	require.NoError(t, err)
+	assert.Len(t, views, 1)
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.ExpenseRepository = (*ExpenseRepository)(nil)
+
+// storedAttachment is an expense attachment with its contents
+type storedAttachment struct {
+	attachment models.ExpenseAttachment
+	data       []byte
+}
+
+// ExpenseRepository is an in-memory implementation of repositories.ExpenseRepository
+type ExpenseRepository struct {
+	mu          sync.RWMutex
+	expenses    map[string]models.Expense
+	attachments map[string]storedAttachment
+}
+
+// NewExpenseRepository creates an empty in-memory expense repository
+func NewExpenseRepository() *ExpenseRepository {
+	return &ExpenseRepository{
+		expenses:    make(map[string]models.Expense),
+		attachments: make(map[string]storedAttachment),
+	}
+}
+
+// Create stores a new expense
+func (r *ExpenseRepository) Create(expense *models.Expense) error {
+	if expense.ID == "" {
+		expense.ID = uuid.New().String()
+	}
+	now := time.Now()
+	expense.CreatedAt = now
+	expense.UpdatedAt = now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.expenses[expense.ID] = cloneExpense(*expense)
+	return nil
+}
+
+// GetByID returns a copy of an expense with its attachments, without their contents
+func (r *ExpenseRepository) GetByID(id string) (*models.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expense, ok := r.expenses[id]
+	if !ok {
+		return nil, fmt.Errorf("expense not found: %w", sql.ErrNoRows)
+	}
+	expense = cloneExpense(expense)
+
+	expense.Attachments = []models.ExpenseAttachment{}
+	for _, stored := range r.attachments {
+		if stored.attachment.ExpenseID == id {
+			expense.Attachments = append(expense.Attachments, stored.attachment)
+		}
+	}
+	sort.Slice(expense.Attachments, func(i, j int) bool {
+		return expense.Attachments[i].UploadedAt.Before(expense.Attachments[j].UploadedAt)
+	})
+	return &expense, nil
+}
+
+// Update saves every editable field of an expense
+func (r *ExpenseRepository) Update(expense *models.Expense) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.expenses[expense.ID]
+	if !ok {
+		return fmt.Errorf("expense not found: %w", sql.ErrNoRows)
+	}
+	expense.SubmittedBy = existing.SubmittedBy
+	expense.CreatedAt = existing.CreatedAt
+	expense.UpdatedAt = time.Now()
+	r.expenses[expense.ID] = cloneExpense(*expense)
+	return nil
+}
+
+// Delete removes an expense and its attachments
+func (r *ExpenseRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.expenses[id]; !ok {
+		return fmt.Errorf("expense not found: %w", sql.ErrNoRows)
+	}
+	delete(r.expenses, id)
+	for attachmentID, stored := range r.attachments {
+		if stored.attachment.ExpenseID == id {
+			delete(r.attachments, attachmentID)
+		}
+	}
+	return nil
+}
+
+// GetAll returns copies of the expenses matching the filter, latest expense date first
+func (r *ExpenseRepository) GetAll(filter models.ExpenseFilter) ([]models.Expense, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expenses := []models.Expense{}
+	for _, expense := range r.expenses {
+		if matchesExpenseFilter(expense, filter) {
+			expenses = append(expenses, cloneExpense(expense))
+		}
+	}
+	sort.Slice(expenses, func(i, j int) bool {
+		if expenses[i].ExpenseDate != expenses[j].ExpenseDate {
+			return expenses[i].ExpenseDate > expenses[j].ExpenseDate
+		}
+		return expenses[i].CreatedAt.After(expenses[j].CreatedAt)
+	})
+	return expenses, nil
+}
+
+func matchesExpenseFilter(expense models.Expense, filter models.ExpenseFilter) bool {
+	switch {
+	case filter.Category != "" && expense.Category != filter.Category:
+		return false
+	case filter.Status != "" && expense.Status != filter.Status:
+		return false
+	case filter.SubmittedBy != "" && expense.SubmittedBy != filter.SubmittedBy:
+		return false
+	case filter.StartDate != "" && expense.ExpenseDate < filter.StartDate:
+		return false
+	case filter.EndDate != "" && expense.ExpenseDate > filter.EndDate:
+		return false
+	}
+	return true
+}
+
+// AddAttachment stores a copy of a file attached to an expense
+func (r *ExpenseRepository) AddAttachment(attachment *models.ExpenseAttachment, data []byte) error {
+	if attachment.ID == "" {
+		attachment.ID = uuid.New().String()
+	}
+	attachment.Size = len(data)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attachments[attachment.ID] = storedAttachment{attachment: *attachment, data: append([]byte(nil), data...)}
+	return nil
+}
+
+// GetAttachment returns an attachment of an expense with a copy of its contents
+func (r *ExpenseRepository) GetAttachment(expenseID, id string) (*models.ExpenseAttachment, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.attachments[id]
+	if !ok || stored.attachment.ExpenseID != expenseID {
+		return nil, nil, fmt.Errorf("expense attachment not found: %w", sql.ErrNoRows)
+	}
+	attachment := stored.attachment
+	return &attachment, append([]byte(nil), stored.data...), nil
+}
+
+// DeleteAttachment removes an attachment of an expense
+func (r *ExpenseRepository) DeleteAttachment(expenseID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.attachments[id]
+	if !ok || stored.attachment.ExpenseID != expenseID {
+		return fmt.Errorf("expense attachment not found: %w", sql.ErrNoRows)
+	}
+	delete(r.attachments, id)
+	return nil
+}
+
+// cloneExpense copies the pointers of an expense and drops its attachments, which are stored separately
+func cloneExpense(expense models.Expense) models.Expense {
+	if expense.ReviewedAt != nil {
+		reviewedAt := *expense.ReviewedAt
+		expense.ReviewedAt = &reviewedAt
+	}
+	expense.Attachments = nil
+	return expense
+}
@@ -0,0 +1,62 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpenseRepository(t *testing.T) {
+	repo := memory.NewExpenseRepository()
+
+	fuel := &models.Expense{Category: models.ExpenseFuel, Description: "Diesel", Amount: 1500, ExpenseDate: "2025-03-01", Status: models.ExpensePending, SubmittedBy: "staff-1"}
+	power := &models.Expense{Category: models.ExpenseUtilities, Description: "Electricity", Amount: 4200, ExpenseDate: "2025-03-10", Status: models.ExpenseApproved, SubmittedBy: "staff-2"}
+	require.NoError(t, repo.Create(fuel))
+	require.NoError(t, repo.Create(power))
+	assert.NotEmpty(t, fuel.ID)
+
+	expenses, err := repo.GetAll(models.ExpenseFilter{StartDate: "2025-03-01", EndDate: "2025-03-31"})
+	require.NoError(t, err)
+	require.Len(t, expenses, 2)
+	assert.Equal(t, power.ID, expenses[0].ID, "latest expense date first")
+
+	expenses, err = repo.GetAll(models.ExpenseFilter{Status: models.ExpensePending, SubmittedBy: "staff-1"})
+	require.NoError(t, err)
+	require.Len(t, expenses, 1)
+	assert.Equal(t, fuel.ID, expenses[0].ID)
+
+	expenses, err = repo.GetAll(models.ExpenseFilter{EndDate: "2025-02-28"})
+	require.NoError(t, err)
+	assert.Empty(t, expenses)
+
+	attachment := &models.ExpenseAttachment{ExpenseID: fuel.ID, FileName: "receipt.pdf", ContentType: "application/pdf", UploadedAt: time.Now()}
+	data := []byte("%PDF-1.4")
+	require.NoError(t, repo.AddAttachment(attachment, data))
+	assert.Equal(t, len(data), attachment.Size)
+	data[0] = 'X' // The stored contents are a copy
+
+	got, err := repo.GetByID(fuel.ID)
+	require.NoError(t, err)
+	require.Len(t, got.Attachments, 1)
+	assert.Equal(t, "receipt.pdf", got.Attachments[0].FileName)
+
+	_, contents, err := repo.GetAttachment(fuel.ID, attachment.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("%PDF-1.4"), contents)
+	_, _, err = repo.GetAttachment(power.ID, attachment.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "attachments belong to one expense")
+
+	require.NoError(t, repo.Delete(fuel.ID))
+	_, _, err = repo.GetAttachment(fuel.ID, attachment.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "deleting an expense removes its attachments")
+	_, err = repo.GetByID(fuel.ID)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(repo.Delete(fuel.ID), sql.ErrNoRows))
+}
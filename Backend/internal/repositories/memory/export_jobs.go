@@ -0,0 +1,160 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.ExportJobRepository = (*ExportJobRepository)(nil)
+
+// storedExportJob is an export job with the contents of its file
+type storedExportJob struct {
+	job  models.ExportJob
+	data []byte
+}
+
+// ExportJobRepository is an in-memory implementation of repositories.ExportJobRepository
+type ExportJobRepository struct {
+	mu   sync.RWMutex
+	jobs map[string]*storedExportJob
+}
+
+// NewExportJobRepository creates an empty in-memory export job repository
+func NewExportJobRepository() *ExportJobRepository {
+	return &ExportJobRepository{jobs: make(map[string]*storedExportJob)}
+}
+
+// copyExportJob returns a copy of a job that shares nothing with the stored one
+func copyExportJob(job models.ExportJob) models.ExportJob {
+	job.Filters = maps.Clone(job.Filters)
+	return job
+}
+
+// Create stores a copy of the job
+func (r *ExportJobRepository) Create(job *models.ExportJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.jobs[job.ID] = &storedExportJob{job: copyExportJob(*job)}
+	return nil
+}
+
+// GetByID returns a copy of the job
+func (r *ExportJobRepository) GetByID(id string) (*models.ExportJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("export job not found: %w", sql.ErrNoRows)
+	}
+	job := copyExportJob(stored.job)
+	return &job, nil
+}
+
+// GetByUser returns copies of the jobs a user requested, newest first
+func (r *ExportJobRepository) GetByUser(userID string) ([]models.ExportJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	jobs := []models.ExportJob{}
+	for _, stored := range r.jobs {
+		if stored.job.RequestedBy == userID {
+			jobs = append(jobs, copyExportJob(stored.job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs, nil
+}
+
+// Start marks a job as running
+func (r *ExportJobRepository) Start(id string, at time.Time) error {
+	return r.update(id, func(job *models.ExportJob) {
+		job.Status = models.ExportStatusRunning
+		job.StartedAt = &at
+	})
+}
+
+// UpdateProgress records the progress of a running job
+func (r *ExportJobRepository) UpdateProgress(id string, progress, rows int) error {
+	return r.update(id, func(job *models.ExportJob) {
+		job.Progress, job.Rows = progress, rows
+	})
+}
+
+// Complete stores a copy of the file and marks the job completed
+func (r *ExportJobRepository) Complete(job *models.ExportJob, data []byte) error {
+	if err := r.update(job.ID, func(stored *models.ExportJob) {
+		stored.Status = models.ExportStatusCompleted
+		stored.Progress = 100
+		stored.Rows = job.Rows
+		stored.FileName = job.FileName
+		stored.Size = len(data)
+		stored.CompletedAt = job.CompletedAt
+		stored.ExpiresAt = job.ExpiresAt
+	}); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if stored, ok := r.jobs[job.ID]; ok {
+		stored.data = append([]byte(nil), data...)
+	}
+	return nil
+}
+
+// Fail marks a job as failed
+func (r *ExportJobRepository) Fail(id, message string, at time.Time) error {
+	return r.update(id, func(job *models.ExportJob) {
+		job.Status = models.ExportStatusFailed
+		job.Error = message
+		job.CompletedAt = &at
+	})
+}
+
+func (r *ExportJobRepository) update(id string, apply func(job *models.ExportJob)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.jobs[id]
+	if !ok {
+		return fmt.Errorf("export job not found: %w", sql.ErrNoRows)
+	}
+	apply(&stored.job)
+	return nil
+}
+
+// GetFile returns a copy of the file of a completed job
+func (r *ExportJobRepository) GetFile(id string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.jobs[id]
+	if !ok || stored.job.Status != models.ExportStatusCompleted {
+		return nil, fmt.Errorf("export file not found: %w", sql.ErrNoRows)
+	}
+	return append([]byte(nil), stored.data...), nil
+}
+
+// DeleteExpired deletes the jobs that expired before the time
+func (r *ExportJobRepository) DeleteExpired(before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, stored := range r.jobs {
+		if stored.job.ExpiresAt.Before(before) {
+			delete(r.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,63 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportJobRepository(t *testing.T) {
+	repo := memory.NewExportJobRepository()
+	now := time.Now()
+	job := func(id, userID string, createdAt time.Time) *models.ExportJob {
+		return &models.ExportJob{ID: id, Type: models.ExportSales, Filters: map[string]string{}, Status: models.ExportStatusQueued,
+			RequestedBy: userID, CreatedAt: createdAt, ExpiresAt: createdAt.Add(time.Hour)}
+	}
+
+	require.NoError(t, repo.Create(job("old", "staff-1", now.Add(-2*time.Hour))))
+	require.NoError(t, repo.Create(job("new", "staff-1", now)))
+	require.NoError(t, repo.Create(job("other", "staff-2", now)))
+
+	_, err := repo.GetFile("new")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "a queued job has no file")
+
+	require.NoError(t, repo.Start("new", now))
+	require.NoError(t, repo.UpdateProgress("new", 50, 10))
+	completed := job("new", "staff-1", now)
+	completed.Rows, completed.FileName, completed.CompletedAt = 20, "sales.csv", &now
+	require.NoError(t, repo.Complete(completed, []byte("id\n1\n")))
+
+	stored, err := repo.GetByID("new")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportStatusCompleted, stored.Status)
+	assert.Equal(t, 100, stored.Progress)
+	assert.Equal(t, 20, stored.Rows)
+	assert.Equal(t, 5, stored.Size)
+	data, err := repo.GetFile("new")
+	require.NoError(t, err)
+	assert.Equal(t, "id\n1\n", string(data))
+
+	require.NoError(t, repo.Fail("other", "Failed to build the export", now))
+	other, err := repo.GetByID("other")
+	require.NoError(t, err)
+	assert.Equal(t, models.ExportStatusFailed, other.Status)
+
+	jobs, err := repo.GetByUser("staff-1")
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "new", jobs[0].ID, "newest first")
+
+	deleted, err := repo.DeleteExpired(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = repo.GetByID("old")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(repo.Start("old", now), sql.ErrNoRows))
+}
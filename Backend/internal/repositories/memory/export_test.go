@@ -0,0 +1,26 @@
+package memory
+
+// SetQuantity writes the quantity of a cab, accessory or material past the checks of
+// the repositories, like a row written before the database refused negative stock
+func (s *Store) SetQuantity(itemType string, id, quantity int) {
+	switch itemType {
+	case "cab":
+		s.Cabs.mu.Lock()
+		defer s.Cabs.mu.Unlock()
+		cab := s.Cabs.cabs[id]
+		cab.Quantity = quantity
+		s.Cabs.cabs[id] = cab
+	case "accessory":
+		s.Accessories.mu.Lock()
+		defer s.Accessories.mu.Unlock()
+		accessory := s.Accessories.accessories[id]
+		accessory.Quantity = quantity
+		s.Accessories.accessories[id] = accessory
+	case "material":
+		s.Materials.mu.Lock()
+		defer s.Materials.mu.Unlock()
+		material := s.Materials.materials[id]
+		material.Quantity = quantity
+		s.Materials.materials[id] = material
+	}
+}
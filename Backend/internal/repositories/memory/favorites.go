@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.FavoriteRepository = (*FavoriteRepository)(nil)
+
+// FavoriteRepository is an in-memory implementation of repositories.FavoriteRepository
+type FavoriteRepository struct {
+	mu        sync.RWMutex
+	favorites map[favoriteKey]models.Favorite
+}
+
+type favoriteKey struct {
+	userID   string
+	itemType string
+	itemID   int
+}
+
+// NewFavoriteRepository creates an empty in-memory favorite repository
+func NewFavoriteRepository() *FavoriteRepository {
+	return &FavoriteRepository{favorites: make(map[favoriteKey]models.Favorite)}
+}
+
+// Save stars an item, keeping the original star time when it was already starred
+func (r *FavoriteRepository) Save(favorite *models.Favorite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := favoriteKey{favorite.UserID, favorite.ItemType, favorite.ItemID}
+	favorite.CreatedAt = time.Now()
+	if existing, ok := r.favorites[key]; ok {
+		favorite.CreatedAt = existing.CreatedAt
+	}
+	r.favorites[key] = *favorite
+	return nil
+}
+
+// Remove unstars an item
+func (r *FavoriteRepository) Remove(userID, itemType string, itemID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := favoriteKey{userID, itemType, itemID}
+	if _, ok := r.favorites[key]; !ok {
+		return fmt.Errorf("favorite not found: %w", sql.ErrNoRows)
+	}
+	delete(r.favorites, key)
+	return nil
+}
+
+// GetByUser returns the items a user starred, most recent first
+func (r *FavoriteRepository) GetByUser(userID string) ([]models.Favorite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	favorites := []models.Favorite{}
+	for key, favorite := range r.favorites {
+		if key.userID == userID {
+			favorites = append(favorites, favorite)
+		}
+	}
+	sort.Slice(favorites, func(i, j int) bool { return favorites[i].CreatedAt.After(favorites[j].CreatedAt) })
+	return favorites, nil
+}
+
+// GetWatchers returns the users who starred an item with notifications on
+func (r *FavoriteRepository) GetWatchers(itemType string, itemID int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userIDs := []string{}
+	for key, favorite := range r.favorites {
+		if key.itemType == itemType && key.itemID == itemID && favorite.Notify {
+			userIDs = append(userIDs, key.userID)
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
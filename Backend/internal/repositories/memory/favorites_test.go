@@ -0,0 +1,40 @@
+package memory_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavoriteRepository(t *testing.T) {
+	repo := memory.NewFavoriteRepository()
+
+	first := &models.Favorite{UserID: "user-1", ItemType: models.FavoriteItemCab, ItemID: 1, Notify: true}
+	require.NoError(t, repo.Save(first))
+	require.NoError(t, repo.Save(&models.Favorite{UserID: "user-2", ItemType: models.FavoriteItemCab, ItemID: 1, Notify: true}))
+	require.NoError(t, repo.Save(&models.Favorite{UserID: "user-1", ItemType: models.FavoriteItemAccessory, ItemID: 1, Notify: true}))
+
+	watchers, err := repo.GetWatchers(models.FavoriteItemCab, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-1", "user-2"}, watchers)
+
+	muted := &models.Favorite{UserID: "user-1", ItemType: models.FavoriteItemCab, ItemID: 1, Notify: false}
+	require.NoError(t, repo.Save(muted))
+	assert.Equal(t, first.CreatedAt, muted.CreatedAt, "saving again keeps the star time")
+
+	watchers, err = repo.GetWatchers(models.FavoriteItemCab, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user-2"}, watchers)
+
+	favorites, err := repo.GetByUser("user-1")
+	require.NoError(t, err)
+	assert.Len(t, favorites, 2)
+
+	require.NoError(t, repo.Remove("user-1", models.FavoriteItemCab, 1))
+	assert.ErrorIs(t, repo.Remove("user-1", models.FavoriteItemCab, 1), sql.ErrNoRows)
+}
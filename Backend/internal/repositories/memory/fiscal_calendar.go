@@ -0,0 +1,39 @@
+package memory
+
+import (
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.FiscalCalendarRepository = (*FiscalCalendarRepository)(nil)
+
+// FiscalCalendarRepository is an in-memory implementation of repositories.FiscalCalendarRepository
+type FiscalCalendarRepository struct {
+	mu       sync.RWMutex
+	calendar models.FiscalCalendar
+}
+
+// NewFiscalCalendarRepository creates an in-memory fiscal calendar repository using the calendar year
+func NewFiscalCalendarRepository() *FiscalCalendarRepository {
+	return &FiscalCalendarRepository{calendar: models.FiscalCalendar{StartMonth: int(time.January)}}
+}
+
+// Get returns the fiscal calendar
+func (r *FiscalCalendarRepository) Get() (models.FiscalCalendar, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.calendar, nil
+}
+
+// Save replaces the fiscal calendar
+func (r *FiscalCalendarRepository) Save(calendar models.FiscalCalendar) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calendar = calendar
+	return nil
+}
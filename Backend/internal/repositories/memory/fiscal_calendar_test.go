@@ -0,0 +1,31 @@
+package memory_test
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiscalCalendarRepository(t *testing.T) {
+	repo := memory.NewFiscalCalendarRepository()
+
+	calendar, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, time.January, calendar.FirstMonth(), "the calendar year until one is saved")
+
+	require.NoError(t, repo.Save(models.FiscalCalendar{StartMonth: 7, NamedAfterEnd: true, UpdatedBy: "admin-1"}))
+	calendar, err = repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "admin-1", calendar.UpdatedBy)
+
+	march := time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local)
+	yearStart := calendar.YearStart(march)
+	assert.Equal(t, time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local), yearStart)
+	assert.Equal(t, 2025, calendar.YearName(yearStart), "named after the year it ends in")
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local), calendar.YearStart(time.Date(2025, 7, 1, 0, 0, 0, 0, time.Local)))
+}
@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"oop/internal/models"
+)
+
+// Credentials of the fixture accounts created by Seed
+const (
+	FixtureAdminEmail    = "admin@example.com"
+	FixtureAdminPassword = "admin123"
+	FixtureStaffEmail    = "staff@example.com"
+	FixtureStaffPassword = "staff123"
+)
+
+// NewSeededStore creates a store filled with the fixture data
+func NewSeededStore() (*Store, error) {
+	store := NewStore()
+	if err := store.Seed(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Seed fills the store with a small, consistent data set: an admin and a staff account,
+// a few customers, cabs, accessories and materials, and one recorded cab sale.
+func (s *Store) Seed() error {
+	users := []models.User{
+		{Username: "admin", FullName: "Demo Admin", Email: FixtureAdminEmail, Password: FixtureAdminPassword, Role: "admin"},
+		{Username: "staff", FullName: "Demo Staff", Email: FixtureStaffEmail, Password: FixtureStaffPassword, Role: "staff"},
+	}
+	for i := range users {
+		if err := s.Users.Create(context.Background(), &users[i]); err != nil {
+			return fmt.Errorf("could not seed user %s: %w", users[i].Email, err)
+		}
+	}
+
+	customers := []models.Customer{
+		{ID: "0b8f6c1e-3f1a-4d2b-9c6e-1a2b3c4d5e01", FullName: "Juan Dela Cruz", Email: "juan.delacruz@example.com", Phone: "+639171234567", Address: "123 Rizal St, Cebu City"},
+		{ID: "0b8f6c1e-3f1a-4d2b-9c6e-1a2b3c4d5e02", FullName: "Maria Santos", Email: "maria.santos@example.com", Phone: "+639181234567", Address: "45 Osmeña Blvd, Cebu City"},
+		{ID: "0b8f6c1e-3f1a-4d2b-9c6e-1a2b3c4d5e03", FullName: "Pedro Reyes", Email: "pedro.reyes@example.com", Phone: "+639191234567", Address: "7 Mabini St, Mandaue City"},
+	}
+	for i := range customers {
+		if _, err := s.Customers.CreateCustomer(context.Background(), &customers[i]); err != nil {
+			return fmt.Errorf("could not seed customer %s: %w", customers[i].FullName, err)
+		}
+	}
+
+	cabs := []models.MultiCab{
+		{Name: "Scrum Wagon", Make: "Mazda", Quantity: 6, Price: 185000, Status: "In Stock", UnitColor: "White"},
+		{Name: "Carry Truck", Make: "Toyota", Quantity: 2, Price: 210000, Status: "Low Stock", UnitColor: "Silver"},
+		{Name: "Vanette", Make: "Nissan", Quantity: 4, Price: 198000, Status: "In Stock", UnitColor: "Blue"},
+		{Name: "Every Van", Make: "Ford", Quantity: 0, Price: 175000, Status: "Out of Stock", UnitColor: "Red"},
+	}
+	for _, cab := range cabs {
+		if _, err := s.Cabs.AddCab(context.Background(), cab); err != nil {
+			return fmt.Errorf("could not seed cab %s: %w", cab.Name, err)
+		}
+	}
+
+	accessories := []models.NewAccessoryInput{
+		{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 8, Price: 4500, UnitColor: models.ColorBlack},
+		{Name: "Side Mirror", Make: models.MakeGeneric, Quantity: 4, Price: 1200, UnitColor: models.ColorChrome},
+		{Name: "Seat Covers", Make: models.MakeAftermarket, Quantity: 2, Price: 2800, UnitColor: models.ColorSilver},
+		{Name: "Mud Flaps", Make: models.MakeCustom, Quantity: 0, Price: 650, UnitColor: models.ColorBlack},
+	}
+	for _, accessory := range accessories {
+		if _, err := s.Accessories.Create(context.Background(), accessory); err != nil {
+			return fmt.Errorf("could not seed accessory %s: %w", accessory.Name, err)
+		}
+	}
+
+	materials := []models.Material{
+		{Name: "Steel Sheet", Category: "Building", Supplier: "Steel Co.", Quantity: 40, Status: "In Stock"},
+		{Name: "Plywood", Category: "Lumber", Supplier: "Wood Works", Quantity: 5, Status: "Low Stock"},
+		{Name: "Wiring Harness", Category: "Electrical", Supplier: "Construction Supplies Inc.", Quantity: 12, Status: "In Stock"},
+		{Name: "Bolts and Nuts", Category: "Hardware", Supplier: "Steel Co.", Quantity: 0, Status: "Out of Stock"},
+	}
+	for i := range materials {
+		if _, err := s.Materials.Create(context.Background(), &materials[i]); err != nil {
+			return fmt.Errorf("could not seed material %s: %w", materials[i].Name, err)
+		}
+	}
+
+	// One sale so reports and the customer history are not empty
+	staff, err := s.Users.GetByEmail(context.Background(), FixtureStaffEmail)
+	if err != nil {
+		return fmt.Errorf("could not seed sale: %w", err)
+	}
+	roofRack := models.AccessoryForSale{ID: 1, Name: "Roof Rack", Price: 4500, Quantity: 1, UnitPrice: 4500}
+	if _, err := s.Sales.SellCab(context.Background(), 1, customers[0].ID, 1, staff.Id, "", []models.AccessoryForSale{roofRack}); err != nil {
+		return fmt.Errorf("could not seed sale: %w", err)
+	}
+
+	return nil
+}
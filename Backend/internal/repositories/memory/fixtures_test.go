@@ -0,0 +1,53 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSeededStore(t *testing.T) {
+	store, err := memory.NewSeededStore()
+	require.NoError(t, err)
+
+	admin, err := store.Users.VerifyPassword(context.Background(), memory.FixtureAdminEmail, memory.FixtureAdminPassword)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", admin.Role)
+	assert.True(t, admin.IsActive)
+
+	_, err = store.Users.VerifyPassword(context.Background(), "staff", "wrong-password")
+	assert.EqualError(t, err, "invalid password")
+
+	customers, err := store.Customers.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, customers, 3)
+
+	materials, err := store.Materials.GetAll(context.Background(), models.MaterialFilter{Category: "building"})
+	require.NoError(t, err)
+	if assert.Len(t, materials, 1) {
+		assert.Equal(t, "Steel Sheet", materials[0].Name)
+	}
+
+	// The seeded sale took one cab and one roof rack out of stock
+	sales, err := store.Sales.GetCustomerSales(context.Background(), "0b8f6c1e-3f1a-4d2b-9c6e-1a2b3c4d5e01")
+	require.NoError(t, err)
+	if assert.Len(t, sales, 1) {
+		assert.Equal(t, 189500.0, sales[0].TotalPrice)
+		items, err := store.Sales.GetSaleItems(context.Background(), sales[0].ID)
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+	}
+
+	cab, err := store.Cabs.GetCabByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 5, cab.Quantity)
+
+	roofRack, err := store.Accessories.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 7, roofRack.Quantity)
+}
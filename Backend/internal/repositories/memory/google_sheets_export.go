@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.GoogleSheetsExportRepository = (*GoogleSheetsExportRepository)(nil)
+
+// GoogleSheetsExportRepository is an in-memory implementation of repositories.GoogleSheetsExportRepository
+type GoogleSheetsExportRepository struct {
+	mu     sync.RWMutex
+	export *models.GoogleSheetsExport
+}
+
+// NewGoogleSheetsExportRepository creates an in-memory google sheets export repository with no export set up
+func NewGoogleSheetsExportRepository() *GoogleSheetsExportRepository {
+	return &GoogleSheetsExportRepository{}
+}
+
+// Get returns a copy of the export
+func (r *GoogleSheetsExportRepository) Get() (*models.GoogleSheetsExport, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.export == nil {
+		return nil, fmt.Errorf("google sheets export not found: %w", sql.ErrNoRows)
+	}
+	export := *r.export
+	export.Reports = slices.Clone(r.export.Reports)
+	return &export, nil
+}
+
+// Save replaces the spreadsheet and reports of the export
+func (r *GoogleSheetsExportRepository) Save(export *models.GoogleSheetsExport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saved := models.GoogleSheetsExport{
+		SpreadsheetID: export.SpreadsheetID,
+		Reports:       slices.Clone(export.Reports),
+		UpdatedBy:     export.UpdatedBy,
+		UpdatedAt:     export.UpdatedAt,
+	}
+	if r.export != nil {
+		saved.LastExportAt, saved.LastError = r.export.LastExportAt, r.export.LastError
+	}
+	r.export = &saved
+	return nil
+}
+
+// RecordExport stores the outcome of the last export
+func (r *GoogleSheetsExportRepository) RecordExport(at time.Time, exportErr string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.export != nil {
+		r.export.LastExportAt, r.export.LastError = &at, exportErr
+	}
+	return nil
+}
+
+// Delete removes the export
+func (r *GoogleSheetsExportRepository) Delete() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.export == nil {
+		return fmt.Errorf("google sheets export not found: %w", sql.ErrNoRows)
+	}
+	r.export = nil
+	return nil
+}
@@ -0,0 +1,35 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoogleSheetsExportRepository(t *testing.T) {
+	repo := memory.NewGoogleSheetsExportRepository()
+
+	_, err := repo.Get()
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "nothing is exported until an export is set up")
+
+	require.NoError(t, repo.Save(&models.GoogleSheetsExport{SpreadsheetID: "sheet-1", Reports: []string{models.SheetsReportDailySales}, UpdatedBy: "admin-1"}))
+	exportedAt := time.Date(2025, 3, 1, 6, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.RecordExport(exportedAt, "permission denied"))
+
+	require.NoError(t, repo.Save(&models.GoogleSheetsExport{SpreadsheetID: "sheet-2", Reports: models.SheetsReports, UpdatedBy: "admin-2"}))
+	export, err := repo.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "sheet-2", export.SpreadsheetID)
+	assert.Equal(t, "permission denied", export.LastError, "saving keeps how the last export went")
+	assert.Equal(t, exportedAt, *export.LastExportAt)
+
+	require.NoError(t, repo.Delete())
+	assert.True(t, errors.Is(repo.Delete(), sql.ErrNoRows))
+}
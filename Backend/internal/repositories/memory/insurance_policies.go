@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.InsurancePolicyRepository = (*InsurancePolicyRepository)(nil)
+
+// InsurancePolicyRepository is an in-memory implementation of repositories.InsurancePolicyRepository
+type InsurancePolicyRepository struct {
+	mu       sync.RWMutex
+	policies map[string]models.InsurancePolicy
+}
+
+// NewInsurancePolicyRepository creates an empty in-memory insurance policy repository
+func NewInsurancePolicyRepository() *InsurancePolicyRepository {
+	return &InsurancePolicyRepository{policies: make(map[string]models.InsurancePolicy)}
+}
+
+// Create stores a new policy
+func (r *InsurancePolicyRepository) Create(policy *models.InsurancePolicy) error {
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+// GetByID retrieves a policy by its ID
+func (r *InsurancePolicyRepository) GetByID(id string) (*models.InsurancePolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policy, ok := r.policies[id]
+	if !ok {
+		return nil, fmt.Errorf("insurance policy not found: %w", sql.ErrNoRows)
+	}
+	return &policy, nil
+}
+
+// Update saves every editable field of a policy
+func (r *InsurancePolicyRepository) Update(policy *models.InsurancePolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.policies[policy.ID]
+	if !ok {
+		return fmt.Errorf("insurance policy not found: %w", sql.ErrNoRows)
+	}
+	policy.SaleID = existing.SaleID
+	policy.CustomerID = existing.CustomerID
+	policy.RenewalOf = existing.RenewalOf
+	policy.CreatedBy = existing.CreatedBy
+	policy.CreatedAt = existing.CreatedAt
+	policy.UpdatedAt = time.Now()
+	r.policies[policy.ID] = *policy
+	return nil
+}
+
+// Delete removes a policy
+func (r *InsurancePolicyRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.policies[id]; !ok {
+		return fmt.Errorf("insurance policy not found: %w", sql.ErrNoRows)
+	}
+	delete(r.policies, id)
+	return nil
+}
+
+// GetAll returns the policies matching the filter, soonest expiring first
+func (r *InsurancePolicyRepository) GetAll(filter models.InsurancePolicyFilter) ([]models.InsurancePolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	policies := []models.InsurancePolicy{}
+	for _, policy := range r.policies {
+		if matchesInsurancePolicyFilter(policy, filter) {
+			policies = append(policies, policy)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		a, b := policies[i], policies[j]
+		if a.ExpiryDate != b.ExpiryDate {
+			return a.ExpiryDate < b.ExpiryDate
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+	return policies, nil
+}
+
+func matchesInsurancePolicyFilter(policy models.InsurancePolicy, filter models.InsurancePolicyFilter) bool {
+	switch {
+	case filter.SaleID != "" && policy.SaleID != filter.SaleID:
+		return false
+	case filter.CustomerID != "" && policy.CustomerID != filter.CustomerID:
+		return false
+	case filter.PolicyNumber != "" && policy.PolicyNumber != filter.PolicyNumber:
+		return false
+	case filter.ExpiresFrom != "" && policy.ExpiryDate < filter.ExpiresFrom:
+		return false
+	case filter.ExpiresBefore != "" && policy.ExpiryDate >= filter.ExpiresBefore:
+		return false
+	case filter.RenewalsOnly && policy.RenewalOf == "":
+		return false
+	}
+	return true
+}
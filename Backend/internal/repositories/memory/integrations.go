@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.IntegrationRepository = (*IntegrationRepository)(nil)
+
+// integrationKey identifies a nonce or an order of an integration
+type integrationKey struct {
+	integrationID string
+	key           string
+}
+
+// IntegrationRepository is an in-memory implementation of repositories.IntegrationRepository
+type IntegrationRepository struct {
+	mu           sync.Mutex
+	integrations map[string]models.Integration
+	nonces       map[integrationKey]time.Time
+	orders       map[integrationKey]string // Sale IDs
+}
+
+// NewIntegrationRepository creates an empty in-memory integration repository
+func NewIntegrationRepository() *IntegrationRepository {
+	return &IntegrationRepository{
+		integrations: make(map[string]models.Integration),
+		nonces:       make(map[integrationKey]time.Time),
+		orders:       make(map[integrationKey]string),
+	}
+}
+
+// Create stores a new integration
+func (r *IntegrationRepository) Create(integration *models.Integration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if integration.ID == "" {
+		integration.ID = uuid.New().String()
+	}
+	integration.Active = true
+	integration.CreatedAt = time.Now()
+	integration.UpdatedAt = integration.CreatedAt
+	r.integrations[integration.ID] = *integration
+	return nil
+}
+
+// GetByID returns a copy of an integration
+func (r *IntegrationRepository) GetByID(id string) (*models.Integration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	integration, ok := r.integrations[id]
+	if !ok {
+		return nil, fmt.Errorf("integration not found: %w", sql.ErrNoRows)
+	}
+	return &integration, nil
+}
+
+// GetAll returns every integration, newest first
+func (r *IntegrationRepository) GetAll() ([]models.Integration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := make([]models.Integration, 0, len(r.integrations))
+	for _, integration := range r.integrations {
+		all = append(all, integration)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.After(all[j].CreatedAt) })
+	return all, nil
+}
+
+// SetSecret replaces the secret of an integration
+func (r *IntegrationRepository) SetSecret(id, secret string) error {
+	return r.update(id, func(integration *models.Integration) { integration.Secret = secret })
+}
+
+// Deactivate stops an integration from being accepted
+func (r *IntegrationRepository) Deactivate(id string) error {
+	return r.update(id, func(integration *models.Integration) { integration.Active = false })
+}
+
+func (r *IntegrationRepository) update(id string, change func(*models.Integration)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	integration, ok := r.integrations[id]
+	if !ok {
+		return fmt.Errorf("integration not found: %w", sql.ErrNoRows)
+	}
+	change(&integration)
+	integration.UpdatedAt = time.Now()
+	r.integrations[integration.ID] = integration
+	return nil
+}
+
+// UseNonce records the nonce of a request, reporting false when it was already used
+func (r *IntegrationRepository) UseNonce(integrationID, nonce string, at, forgetBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, receivedAt := range r.nonces {
+		if receivedAt.Before(forgetBefore) {
+			delete(r.nonces, key)
+		}
+	}
+	key := integrationKey{integrationID: integrationID, key: nonce}
+	if _, used := r.nonces[key]; used {
+		return false, nil
+	}
+	r.nonces[key] = at
+	return true, nil
+}
+
+// GetOrderSale returns the sale an order was converted into
+func (r *IntegrationRepository) GetOrderSale(integrationID, externalID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	saleID, ok := r.orders[integrationKey{integrationID: integrationID, key: externalID}]
+	if !ok {
+		return "", fmt.Errorf("integration order not found: %w", sql.ErrNoRows)
+	}
+	return saleID, nil
+}
+
+// SaveOrder records the sale an order was converted into
+func (r *IntegrationRepository) SaveOrder(integrationID, externalID, saleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := integrationKey{integrationID: integrationID, key: externalID}
+	if _, ok := r.orders[key]; ok {
+		return fmt.Errorf("order %s of integration %s was already recorded", externalID, integrationID)
+	}
+	r.orders[key] = saleID
+	return nil
+}
@@ -0,0 +1,52 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrationRepository(t *testing.T) {
+	store := memory.NewStore()
+	integration := &models.Integration{Name: "Marketplace", Secret: "old", SoldBy: "user-1"}
+	require.NoError(t, store.Integrations.Create(integration))
+	require.NoError(t, store.Integrations.SetSecret(integration.ID, "new"))
+	assert.True(t, errors.Is(store.Integrations.SetSecret("missing", "new"), sql.ErrNoRows))
+
+	found, err := store.Integrations.GetByID(integration.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new", found.Secret)
+	assert.True(t, found.Active)
+
+	require.NoError(t, store.Integrations.Deactivate(integration.ID))
+	all, err := store.Integrations.GetAll()
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.False(t, all[0].Active)
+
+	now := time.Now()
+	fresh, err := store.Integrations.UseNonce(integration.ID, "nonce", now.Add(-time.Hour), now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, fresh)
+	fresh, err = store.Integrations.UseNonce(integration.ID, "nonce", now, now.Add(-2*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, fresh, "a nonce is only accepted once")
+	fresh, err = store.Integrations.UseNonce(integration.ID, "nonce", now, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.True(t, fresh, "forgotten nonces are accepted again")
+
+	_, err = store.Integrations.GetOrderSale(integration.ID, "order-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	require.NoError(t, store.Integrations.SaveOrder(integration.ID, "order-1", "sale-1"))
+	assert.Error(t, store.Integrations.SaveOrder(integration.ID, "order-1", "sale-2"))
+	saleID, err := store.Integrations.GetOrderSale(integration.ID, "order-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sale-1", saleID)
+}
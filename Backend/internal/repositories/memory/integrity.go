@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.IntegrityRepository = (*IntegrityRepository)(nil)
+
+// totalTolerance is how far a sale's total may be from the sum of its items before
+// they count as different
+const totalTolerance = 0.005
+
+// IntegrityRepository is an in-memory implementation of repositories.IntegrityRepository
+// over the records of the sales, customer and inventory repositories
+type IntegrityRepository struct {
+	sales       *SalesRepository
+	customers   *CustomerRepository
+	cabs        *CabsRepository
+	accessories *AccessoryRepository
+	materials   *MaterialRepository
+}
+
+// NewIntegrityRepository creates an integrity check over the given repositories
+func NewIntegrityRepository(sales *SalesRepository, customers *CustomerRepository, cabs *CabsRepository, accessories *AccessoryRepository, materials *MaterialRepository) *IntegrityRepository {
+	return &IntegrityRepository{sales: sales, customers: customers, cabs: cabs, accessories: accessories, materials: materials}
+}
+
+// OrphanSaleItems returns the sale items whose sale does not exist, oldest first
+func (r *IntegrityRepository) OrphanSaleItems() ([]models.SaleItem, error) {
+	r.sales.mu.RLock()
+	defer r.sales.mu.RUnlock()
+
+	items := []models.SaleItem{}
+	for saleID, saleItems := range r.sales.items {
+		if _, ok := r.sales.sales[saleID]; !ok {
+			items = append(items, saleItems...)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	return items, nil
+}
+
+// SaleTotalMismatches returns the sales with items whose total is not the sum of the
+// items' subtotals
+func (r *IntegrityRepository) SaleTotalMismatches() ([]models.SaleTotalMismatch, error) {
+	r.sales.mu.RLock()
+	defer r.sales.mu.RUnlock()
+
+	mismatches := []models.SaleTotalMismatch{}
+	for id, sale := range r.sales.sales {
+		items := r.sales.items[id]
+		if len(items) == 0 {
+			continue
+		}
+		var itemsTotal float64
+		for _, item := range items {
+			itemsTotal += item.Subtotal
+		}
+		if math.Abs(sale.TotalPrice-itemsTotal) > totalTolerance {
+			mismatches = append(mismatches, models.SaleTotalMismatch{SaleID: id, TotalPrice: sale.TotalPrice, ItemsTotal: itemsTotal})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool { return mismatches[i].SaleID < mismatches[j].SaleID })
+	return mismatches, nil
+}
+
+// NegativeStock returns the cabs, accessories and materials with a negative quantity,
+// including deleted ones
+func (r *IntegrityRepository) NegativeStock() ([]models.NegativeStock, error) {
+	stock := []models.NegativeStock{}
+
+	r.cabs.mu.RLock()
+	for _, cab := range r.cabs.cabs {
+		if cab.Quantity < 0 {
+			stock = append(stock, models.NegativeStock{ItemType: models.InventoryCab, ItemID: cab.ID, Name: cab.Name, Quantity: cab.Quantity})
+		}
+	}
+	for _, item := range r.cabs.deleted {
+		if item.record.Quantity < 0 {
+			stock = append(stock, models.NegativeStock{ItemType: models.InventoryCab, ItemID: item.record.ID, Name: item.record.Name, Quantity: item.record.Quantity})
+		}
+	}
+	r.cabs.mu.RUnlock()
+
+	r.accessories.mu.RLock()
+	for _, accessory := range r.accessories.accessories {
+		if accessory.Quantity < 0 {
+			stock = append(stock, models.NegativeStock{ItemType: models.InventoryAccessory, ItemID: accessory.ID, Name: accessory.Name, Quantity: accessory.Quantity})
+		}
+	}
+	for _, item := range r.accessories.deleted {
+		if item.record.Quantity < 0 {
+			stock = append(stock, models.NegativeStock{ItemType: models.InventoryAccessory, ItemID: item.record.ID, Name: item.record.Name, Quantity: item.record.Quantity})
+		}
+	}
+	r.accessories.mu.RUnlock()
+
+	r.materials.mu.RLock()
+	for _, material := range r.materials.materials {
+		if material.Quantity < 0 {
+			stock = append(stock, models.NegativeStock{ItemType: models.InventoryMaterial, ItemID: material.ID, Name: material.Name, Quantity: material.Quantity})
+		}
+	}
+	for _, item := range r.materials.deleted {
+		if item.record.Quantity < 0 {
+			stock = append(stock, models.NegativeStock{ItemType: models.InventoryMaterial, ItemID: item.record.ID, Name: item.record.Name, Quantity: item.record.Quantity})
+		}
+	}
+	r.materials.mu.RUnlock()
+
+	sort.Slice(stock, func(i, j int) bool {
+		if stock[i].ItemType != stock[j].ItemType {
+			return stock[i].ItemType < stock[j].ItemType
+		}
+		return stock[i].ItemID < stock[j].ItemID
+	})
+	return stock, nil
+}
+
+// SalesWithMissingCustomers returns the sales whose customer does not exist or was deleted
+func (r *IntegrityRepository) SalesWithMissingCustomers() ([]models.SaleCustomerRef, error) {
+	r.sales.mu.RLock()
+	defer r.sales.mu.RUnlock()
+	r.customers.mu.RLock()
+	defer r.customers.mu.RUnlock()
+
+	refs := []models.SaleCustomerRef{}
+	for id, sale := range r.sales.sales {
+		if sale.CustomerID == "" {
+			continue
+		}
+		if _, ok := r.customers.customers[sale.CustomerID]; ok {
+			continue
+		}
+		_, deleted := r.customers.deleted[sale.CustomerID]
+		refs = append(refs, models.SaleCustomerRef{SaleID: id, CustomerID: sale.CustomerID, Deleted: deleted})
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].SaleID < refs[j].SaleID })
+	return refs, nil
+}
+
+// DeleteOrphanSaleItems deletes the given sale items that still have no sale
+func (r *IntegrityRepository) DeleteOrphanSaleItems(ids []string) (int, error) {
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+
+	r.sales.mu.Lock()
+	defer r.sales.mu.Unlock()
+
+	deleted := 0
+	for saleID, items := range r.sales.items {
+		if _, ok := r.sales.sales[saleID]; ok {
+			continue
+		}
+		kept := items[:0]
+		for _, item := range items {
+			if remove[item.ID] {
+				deleted++
+				continue
+			}
+			kept = append(kept, item)
+		}
+		if len(kept) == 0 {
+			delete(r.sales.items, saleID)
+		} else {
+			r.sales.items[saleID] = kept
+		}
+	}
+	return deleted, nil
+}
+
+// ZeroNegativeStock sets a negative quantity to zero, including of deleted records
+func (r *IntegrityRepository) ZeroNegativeStock(itemType string, itemID int) error {
+	notNegative := fmt.Errorf("%s %d has no negative quantity: %w", itemType, itemID, sql.ErrNoRows)
+	now := time.Now()
+	switch itemType {
+	case models.InventoryCab:
+		r.cabs.mu.Lock()
+		defer r.cabs.mu.Unlock()
+		if cab, ok := r.cabs.cabs[itemID]; ok && cab.Quantity < 0 {
+			cab.Quantity, cab.UpdatedAt = 0, now
+			r.cabs.cabs[itemID] = cab
+		} else if item, ok := r.cabs.deleted[itemID]; ok && item.record.Quantity < 0 {
+			item.record.Quantity, item.record.UpdatedAt = 0, now
+			r.cabs.deleted[itemID] = item
+		} else {
+			return notNegative
+		}
+	case models.InventoryAccessory:
+		r.accessories.mu.Lock()
+		defer r.accessories.mu.Unlock()
+		if accessory, ok := r.accessories.accessories[itemID]; ok && accessory.Quantity < 0 {
+			accessory.Quantity, accessory.Status, accessory.UpdatedAt = 0, accessoryStatus(0), now
+			r.accessories.accessories[itemID] = accessory
+		} else if item, ok := r.accessories.deleted[itemID]; ok && item.record.Quantity < 0 {
+			item.record.Quantity, item.record.Status, item.record.UpdatedAt = 0, accessoryStatus(0), now
+			r.accessories.deleted[itemID] = item
+		} else {
+			return notNegative
+		}
+	case models.InventoryMaterial:
+		r.materials.mu.Lock()
+		defer r.materials.mu.Unlock()
+		if material, ok := r.materials.materials[itemID]; ok && material.Quantity < 0 {
+			material.Quantity, material.UpdatedAt = 0, now
+			r.materials.materials[itemID] = material
+		} else if item, ok := r.materials.deleted[itemID]; ok && item.record.Quantity < 0 {
+			item.record.Quantity, item.record.UpdatedAt = 0, now
+			r.materials.deleted[itemID] = item
+		} else {
+			return notNegative
+		}
+	default:
+		return fmt.Errorf("unknown item type %q", itemType)
+	}
+	return nil
+}
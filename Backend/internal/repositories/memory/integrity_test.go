@@ -0,0 +1,120 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrityRepository(t *testing.T) {
+	store := memory.NewStore()
+	kept, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	trashed, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Maria Clara", Email: "maria@example.com"})
+	require.NoError(t, err)
+	require.NoError(t, store.Customers.DeleteCustomer(context.Background(), trashed.ID))
+
+	balanced, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: kept.ID, TotalPrice: 300})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: balanced, Item: models.IntRef(models.RefCab, 1), Quantity: 1, UnitPrice: 300, Subtotal: 300})
+	require.NoError(t, err)
+	unbalanced, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: trashed.ID, TotalPrice: 500})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: unbalanced, Item: models.IntRef(models.RefAccessory, 1), Quantity: 2, UnitPrice: 200, Subtotal: 400})
+	require.NoError(t, err)
+	gone, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "customer-gone", TotalPrice: 100})
+	require.NoError(t, err)
+	orphan, err := store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: "sale-gone", Item: models.IntRef(models.RefCab, 1), Quantity: 1, Subtotal: 100})
+	require.NoError(t, err)
+
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Quantity: 1})
+	require.NoError(t, err)
+	store.SetQuantity(models.InventoryMaterial, materialID, -2)
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Red", Quantity: 1})
+	require.NoError(t, err)
+	store.SetQuantity(models.InventoryCab, cab.ID, -1)
+	_, err = store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Quantity: 1})
+	require.NoError(t, err)
+
+	orphans, err := store.Integrity.OrphanSaleItems()
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, orphan, orphans[0].ID)
+
+	mismatches, err := store.Integrity.SaleTotalMismatches()
+	require.NoError(t, err)
+	assert.Equal(t, []models.SaleTotalMismatch{{SaleID: unbalanced, TotalPrice: 500, ItemsTotal: 400}}, mismatches,
+		"the sale without items is not checked")
+
+	stock, err := store.Integrity.NegativeStock()
+	require.NoError(t, err)
+	require.Len(t, stock, 2)
+	assert.Equal(t, models.NegativeStock{ItemType: models.InventoryCab, ItemID: cab.ID, Name: "RX-7", Quantity: -1}, stock[0])
+	assert.Equal(t, "Paint", stock[1].Name)
+
+	refs, err := store.Integrity.SalesWithMissingCustomers()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []models.SaleCustomerRef{
+		{SaleID: unbalanced, CustomerID: trashed.ID, Deleted: true},
+		{SaleID: gone, CustomerID: "customer-gone"},
+	}, refs)
+
+	deleted, err := store.Integrity.DeleteOrphanSaleItems([]string{orphan, "not-an-orphan"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	orphans, err = store.Integrity.OrphanSaleItems()
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+	items, err := store.Sales.GetSaleItems(context.Background(), balanced)
+	require.NoError(t, err)
+	assert.Len(t, items, 1, "items of existing sales are kept")
+
+	require.NoError(t, store.Integrity.ZeroNegativeStock(models.InventoryCab, cab.ID))
+	zeroed, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, zeroed.Quantity)
+	assert.True(t, errors.Is(store.Integrity.ZeroNegativeStock(models.InventoryCab, cab.ID), sql.ErrNoRows), "already zero")
+	require.NoError(t, store.Materials.Delete(context.Background(), materialID))
+	require.NoError(t, store.Integrity.ZeroNegativeStock(models.InventoryMaterial, materialID), "deleted materials are repaired too")
+	stock, err = store.Integrity.NegativeStock()
+	require.NoError(t, err)
+	assert.Empty(t, stock)
+}
+
+func TestInventoryRefusesNegativeStock(t *testing.T) {
+	store := memory.NewStore()
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Red", Quantity: 2})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side Mirror", Make: "Toyota", Quantity: 1, Price: 500, UnitColor: "Black"})
+	require.NoError(t, err)
+
+	_, err = store.Sales.SellCab(context.Background(), cab.ID, "customer-1", 3, "staff-1", "", nil)
+	assert.True(t, errors.Is(err, repositories.ErrNegativeStock))
+	_, err = store.Sales.SellCab(context.Background(), cab.ID, "customer-1", 1, "staff-1", "", []models.AccessoryForSale{{ID: accessoryID, Quantity: 2, Price: 500}})
+	assert.True(t, errors.Is(err, repositories.ErrNegativeStock))
+	sales, err := store.Sales.GetAll(context.Background(), models.SalesFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, sales, "refused sales are not recorded")
+
+	_, err = store.Cabs.UpdateCab(context.Background(), cab.ID, models.MultiCab{Name: "RX-7", Make: "Mazda", UnitColor: "Red", Quantity: -1})
+	assert.True(t, errors.Is(err, repositories.ErrNegativeStock))
+	quantity := -1
+	_, err = store.Accessories.Update(context.Background(), accessoryID, models.UpdateAccessoryInput{Quantity: &quantity})
+	assert.True(t, errors.Is(err, repositories.ErrNegativeStock))
+	_, err = store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Quantity: -1})
+	assert.True(t, errors.Is(err, repositories.ErrNegativeStock))
+
+	_, err = store.Sales.SellCab(context.Background(), cab.ID, "customer-1", 2, "staff-1", "", []models.AccessoryForSale{{ID: accessoryID, Quantity: 1, Price: 500}})
+	require.NoError(t, err)
+	sold, err := store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sold.Quantity, "the last units can be sold")
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.InventorySnapshotRepository = (*InventorySnapshotRepository)(nil)
+
+// InventorySnapshotRepository is an in-memory implementation of repositories.InventorySnapshotRepository
+type InventorySnapshotRepository struct {
+	mu        sync.Mutex
+	snapshots map[string]models.InventorySnapshot // By month
+}
+
+// NewInventorySnapshotRepository creates an empty in-memory inventory snapshot repository
+func NewInventorySnapshotRepository() *InventorySnapshotRepository {
+	return &InventorySnapshotRepository{snapshots: make(map[string]models.InventorySnapshot)}
+}
+
+// Save stores a copy of a snapshot, replacing the month's previous one
+func (r *InventorySnapshotRepository) Save(snapshot *models.InventorySnapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *snapshot
+	stored.Items = append([]models.InventorySnapshotItem{}, snapshot.Items...)
+	sort.Slice(stored.Items, func(i, j int) bool {
+		if stored.Items[i].ItemType != stored.Items[j].ItemType {
+			return stored.Items[i].ItemType < stored.Items[j].ItemType
+		}
+		return stored.Items[i].ItemID < stored.Items[j].ItemID
+	})
+	r.snapshots[snapshot.Month] = stored
+	return nil
+}
+
+// Get returns a copy of a month's snapshot
+func (r *InventorySnapshotRepository) Get(month string) (*models.InventorySnapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot, ok := r.snapshots[month]
+	if !ok {
+		return nil, fmt.Errorf("inventory snapshot not found: %w", sql.ErrNoRows)
+	}
+	snapshot.Items = append([]models.InventorySnapshotItem{}, snapshot.Items...)
+	return &snapshot, nil
+}
@@ -0,0 +1,35 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventorySnapshotRepository(t *testing.T) {
+	repo := memory.NewInventorySnapshotRepository()
+
+	_, err := repo.Get("2025-03")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	snapshot := &models.InventorySnapshot{Month: "2025-03", TakenAt: time.Now(), Items: []models.InventorySnapshotItem{
+		{ItemType: models.InventoryMaterial, ItemID: 1, Quantity: 5},
+		{ItemType: models.InventoryCab, ItemID: 9, Quantity: 1},
+		{ItemType: models.InventoryCab, ItemID: 2, Quantity: 2},
+	}}
+	require.NoError(t, repo.Save(snapshot))
+	snapshot.Items[0].Quantity = 99 // The repository keeps its own copy
+
+	stored, err := repo.Get("2025-03")
+	require.NoError(t, err)
+	require.Len(t, stored.Items, 3)
+	assert.Equal(t, []int{2, 9, 1}, []int{stored.Items[0].ItemID, stored.Items[1].ItemID, stored.Items[2].ItemID}, "by type, then item ID")
+	assert.Equal(t, 5, stored.Items[2].Quantity)
+}
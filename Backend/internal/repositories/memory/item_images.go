@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.ItemImageRepository = (*ItemImageRepository)(nil)
+
+// storedItemImage is a gallery photo with its contents
+type storedItemImage struct {
+	image    models.ItemImage
+	data     []byte
+	variants map[string]storedItemImageVariant // By size
+}
+
+// storedItemImageVariant is a smaller copy of a photo with its contents
+type storedItemImageVariant struct {
+	variant models.ItemImageVariant
+	data    []byte
+}
+
+// ItemImageRepository is an in-memory implementation of repositories.ItemImageRepository
+type ItemImageRepository struct {
+	mu     sync.RWMutex
+	images map[string]storedItemImage
+}
+
+// NewItemImageRepository creates an empty in-memory item image repository
+func NewItemImageRepository() *ItemImageRepository {
+	return &ItemImageRepository{images: make(map[string]storedItemImage)}
+}
+
+// gallery returns the ids of an item's photos in gallery order. The caller holds the lock.
+func (r *ItemImageRepository) gallery(itemType string, itemID int) []string {
+	ids := []string{}
+	for id, stored := range r.images {
+		if stored.image.ItemType == itemType && stored.image.ItemID == itemID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return r.images[ids[i]].image.Position < r.images[ids[j]].image.Position })
+	return ids
+}
+
+// find returns a photo of an item. The caller holds the lock.
+func (r *ItemImageRepository) find(itemType string, itemID int, id string) (storedItemImage, error) {
+	stored, ok := r.images[id]
+	if !ok || stored.image.ItemType != itemType || stored.image.ItemID != itemID {
+		return stored, fmt.Errorf("item image not found: %w", sql.ErrNoRows)
+	}
+	return stored, nil
+}
+
+// Add stores a copy of a photo at the end of an item's gallery
+func (r *ItemImageRepository) Add(image *models.ItemImage, data []byte) error {
+	if image.ID == "" {
+		image.ID = uuid.New().String()
+	}
+	image.Size = len(data)
+	image.ContentHash = repositories.ItemImageHash(data)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	gallery := r.gallery(image.ItemType, image.ItemID)
+	image.Position = len(gallery)
+	image.IsPrimary = len(gallery) == 0
+	r.images[image.ID] = storedItemImage{image: *image, data: append([]byte(nil), data...)}
+	return nil
+}
+
+// GetAll returns the photos of an item in gallery order
+func (r *ItemImageRepository) GetAll(itemType string, itemID int) ([]models.ItemImage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	images := []models.ItemImage{}
+	for _, id := range r.gallery(itemType, itemID) {
+		stored := r.images[id]
+		image := stored.image
+		for _, variant := range stored.variants {
+			image.Variants = append(image.Variants, variant.variant)
+		}
+		sort.Slice(image.Variants, func(i, j int) bool {
+			return image.Variants[i].Width*image.Variants[i].Height < image.Variants[j].Width*image.Variants[j].Height
+		})
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// GetFile returns a photo of an item with a copy of its contents
+func (r *ItemImageRepository) GetFile(itemType string, itemID int, id string) (*models.ItemImage, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, err := r.find(itemType, itemID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	image := stored.image
+	return &image, append([]byte(nil), stored.data...), nil
+}
+
+// Reorder puts the photos of an item in the order of ids
+func (r *ItemImageRepository) Reorder(itemType string, itemID int, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(ids) != len(r.gallery(itemType, itemID)) {
+		return fmt.Errorf("the order must list each image once: %w", sql.ErrNoRows)
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, err := r.find(itemType, itemID, id); err != nil || seen[id] {
+			return fmt.Errorf("item image %s is not in the gallery or is listed twice: %w", id, sql.ErrNoRows)
+		}
+		seen[id] = true
+	}
+	for position, id := range ids {
+		stored := r.images[id]
+		stored.image.Position = position
+		r.images[id] = stored
+	}
+	return nil
+}
+
+// SetPrimary makes a photo the primary photo of its item
+func (r *ItemImageRepository) SetPrimary(itemType string, itemID int, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.find(itemType, itemID, id); err != nil {
+		return err
+	}
+	for _, other := range r.gallery(itemType, itemID) {
+		stored := r.images[other]
+		stored.image.IsPrimary = other == id
+		r.images[other] = stored
+	}
+	return nil
+}
+
+// Delete removes a photo of an item, closing the gap it leaves in the gallery order
+func (r *ItemImageRepository) Delete(itemType string, itemID int, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted, err := r.find(itemType, itemID, id)
+	if err != nil {
+		return err
+	}
+	delete(r.images, id)
+	for position, other := range r.gallery(itemType, itemID) {
+		stored := r.images[other]
+		stored.image.Position = position
+		if deleted.image.IsPrimary && position == 0 {
+			stored.image.IsPrimary = true
+		}
+		r.images[other] = stored
+	}
+	return nil
+}
+
+// SaveVariant stores a copy of a smaller version of a photo, unless the photo is gone
+func (r *ItemImageRepository) SaveVariant(variant models.ItemImageVariant, data []byte) error {
+	variant.FileSize = len(data)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.images[variant.ImageID]
+	if !ok {
+		return nil
+	}
+	if stored.variants == nil {
+		stored.variants = map[string]storedItemImageVariant{}
+	}
+	stored.variants[variant.Size] = storedItemImageVariant{variant: variant, data: append([]byte(nil), data...)}
+	r.images[variant.ImageID] = stored
+	return nil
+}
+
+// GetVariant returns a variant of a photo of an item with a copy of its contents
+func (r *ItemImageRepository) GetVariant(itemType string, itemID int, id, size string) (*models.ItemImageVariant, []byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, err := r.find(itemType, itemID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	variant, ok := stored.variants[size]
+	if !ok {
+		return nil, nil, fmt.Errorf("item image variant not found: %w", sql.ErrNoRows)
+	}
+	found := variant.variant
+	return &found, append([]byte(nil), variant.data...), nil
+}
+
+// FindByHash returns the photos with a content hash in any gallery, oldest first
+func (r *ItemImageRepository) FindByHash(hash string) ([]models.ItemImage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	images := []models.ItemImage{}
+	for _, stored := range r.images {
+		if stored.image.ContentHash == hash {
+			images = append(images, stored.image)
+		}
+	}
+	sortByUpload(images)
+	return images, nil
+}
+
+// GetDuplicates returns the photos whose content hash is shared by more than one item
+func (r *ItemImageRepository) GetDuplicates() ([]models.ItemImage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := map[string]map[string]bool{} // Items of each hash
+	for _, stored := range r.images {
+		hash := stored.image.ContentHash
+		if hash == "" {
+			continue
+		}
+		if items[hash] == nil {
+			items[hash] = map[string]bool{}
+		}
+		items[hash][fmt.Sprintf("%s/%d", stored.image.ItemType, stored.image.ItemID)] = true
+	}
+	images := []models.ItemImage{}
+	for _, stored := range r.images {
+		if len(items[stored.image.ContentHash]) > 1 {
+			images = append(images, stored.image)
+		}
+	}
+	sortByUpload(images)
+	sort.SliceStable(images, func(i, j int) bool { return images[i].ContentHash < images[j].ContentHash })
+	return images, nil
+}
+
+// sortByUpload sorts photos oldest first
+func sortByUpload(images []models.ItemImage) {
+	sort.Slice(images, func(i, j int) bool {
+		if !images[i].UploadedAt.Equal(images[j].UploadedAt) {
+			return images[i].UploadedAt.Before(images[j].UploadedAt)
+		}
+		return images[i].ID < images[j].ID
+	})
+}
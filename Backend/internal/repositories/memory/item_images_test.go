@@ -0,0 +1,99 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemImageRepository(t *testing.T) {
+	repo := memory.NewItemImageRepository()
+
+	var ids []string
+	for _, name := range []string{"front.jpg", "side.jpg", "back.jpg"} {
+		image := &models.ItemImage{ItemType: models.InventoryCab, ItemID: 1, FileName: name, ContentType: "image/jpeg"}
+		require.NoError(t, repo.Add(image, []byte(name)))
+		ids = append(ids, image.ID)
+	}
+	require.NoError(t, repo.Add(&models.ItemImage{ItemType: models.InventoryAccessory, ItemID: 1, FileName: "rack.jpg"}, []byte("rack")))
+
+	images, err := repo.GetAll(models.InventoryCab, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+	assert.True(t, images[0].IsPrimary, "the first photo is primary")
+	assert.Equal(t, 2, images[2].Position)
+
+	assert.True(t, errors.Is(repo.Reorder(models.InventoryCab, 1, ids[:2]), sql.ErrNoRows), "every photo is listed")
+	require.NoError(t, repo.Reorder(models.InventoryCab, 1, []string{ids[2], ids[0], ids[1]}))
+	require.NoError(t, repo.SetPrimary(models.InventoryCab, 1, ids[2]))
+	assert.True(t, errors.Is(repo.SetPrimary(models.InventoryAccessory, 1, ids[2]), sql.ErrNoRows), "photos belong to one item")
+
+	require.NoError(t, repo.Delete(models.InventoryCab, 1, ids[2]))
+	images, err = repo.GetAll(models.InventoryCab, 1)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, ids[0], images[0].ID)
+	assert.Equal(t, 0, images[0].Position)
+	assert.True(t, images[0].IsPrimary, "the first remaining photo becomes primary")
+	assert.False(t, images[1].IsPrimary)
+
+	image, data, err := repo.GetFile(models.InventoryCab, 1, ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, "side.jpg", image.FileName)
+	assert.Equal(t, []byte("side.jpg"), data)
+}
+
+func TestItemImageVariants(t *testing.T) {
+	repo := memory.NewItemImageRepository()
+	image := &models.ItemImage{ItemType: models.InventoryMaterial, ItemID: 3, FileName: "sheet.png", ContentType: "image/png"}
+	require.NoError(t, repo.Add(image, []byte("png")))
+
+	require.NoError(t, repo.SaveVariant(models.ItemImageVariant{ImageID: image.ID, Size: "small", ContentType: "image/jpeg", Width: 480, Height: 360}, []byte("small")))
+	require.NoError(t, repo.SaveVariant(models.ItemImageVariant{ImageID: image.ID, Size: "thumb", ContentType: "image/jpeg", Width: 160, Height: 120}, []byte("thumb")))
+	require.NoError(t, repo.SaveVariant(models.ItemImageVariant{ImageID: "deleted", Size: "thumb"}, []byte("thumb")), "variants of deleted photos are dropped")
+
+	images, err := repo.GetAll(models.InventoryMaterial, 3)
+	require.NoError(t, err)
+	require.Len(t, images[0].Variants, 2)
+	assert.Equal(t, "thumb", images[0].Variants[0].Size, "variants are listed smallest first")
+
+	variant, data, err := repo.GetVariant(models.InventoryMaterial, 3, image.ID, "thumb")
+	require.NoError(t, err)
+	assert.Equal(t, 5, variant.FileSize)
+	assert.Equal(t, []byte("thumb"), data)
+	_, _, err = repo.GetVariant(models.InventoryMaterial, 3, image.ID, "medium")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	require.NoError(t, repo.Delete(models.InventoryMaterial, 3, image.ID))
+	_, _, err = repo.GetVariant(models.InventoryMaterial, 3, image.ID, "thumb")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "variants go with their photo")
+}
+
+func TestDuplicateItemImages(t *testing.T) {
+	repo := memory.NewItemImageRepository()
+	first := &models.ItemImage{ItemType: models.InventoryCab, ItemID: 1, FileName: "front.jpg", UploadedAt: time.Now().Add(-time.Hour)}
+	require.NoError(t, repo.Add(first, []byte("front")))
+	require.NoError(t, repo.Add(&models.ItemImage{ItemType: models.InventoryCab, ItemID: 1, FileName: "again.jpg", UploadedAt: time.Now()}, []byte("front")))
+
+	duplicates, err := repo.GetDuplicates()
+	require.NoError(t, err)
+	assert.Empty(t, duplicates, "the same photo twice on one item is not shared")
+
+	require.NoError(t, repo.Add(&models.ItemImage{ItemType: models.InventoryCab, ItemID: 2, FileName: "copy.jpg", UploadedAt: time.Now()}, []byte("front")))
+	require.NoError(t, repo.Add(&models.ItemImage{ItemType: models.InventoryCab, ItemID: 2, FileName: "back.jpg", UploadedAt: time.Now()}, []byte("back")))
+	duplicates, err = repo.GetDuplicates()
+	require.NoError(t, err)
+	require.Len(t, duplicates, 3)
+	assert.Equal(t, first.ID, duplicates[0].ID, "oldest first")
+
+	matches, err := repo.FindByHash(first.ContentHash)
+	require.NoError(t, err)
+	assert.Len(t, matches, 3)
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.LegacyImportRepository = (*LegacyImportRepository)(nil)
+
+// LegacyImportRepository is an in-memory implementation of repositories.LegacyImportRepository
+type LegacyImportRepository struct {
+	mu      sync.Mutex
+	runs    map[string]models.LegacyImportRun
+	records map[string]map[string]string // By kind, then legacy key
+}
+
+// NewLegacyImportRepository creates an empty in-memory legacy import repository
+func NewLegacyImportRepository() *LegacyImportRepository {
+	return &LegacyImportRepository{runs: make(map[string]models.LegacyImportRun), records: make(map[string]map[string]string)}
+}
+
+// copyLegacyImportRun copies a run, so callers never share its mapping with the store
+func copyLegacyImportRun(run models.LegacyImportRun) models.LegacyImportRun {
+	mapping := make(map[string]string, len(run.Mapping))
+	for field, column := range run.Mapping {
+		mapping[field] = column
+	}
+	run.Mapping = mapping
+	return run
+}
+
+// CreateRun stores a new run
+func (r *LegacyImportRepository) CreateRun(run *models.LegacyImportRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run.ID = uuid.New().String()
+	run.CreatedAt = time.Now()
+	run.UpdatedAt = run.CreatedAt
+	r.runs[run.ID] = copyLegacyImportRun(*run)
+	return nil
+}
+
+// UpdateRun saves the status and progress of a run
+func (r *LegacyImportRepository) UpdateRun(run *models.LegacyImportRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.runs[run.ID]
+	if !ok {
+		return fmt.Errorf("legacy import run not found: %w", sql.ErrNoRows)
+	}
+	run.UpdatedAt = time.Now()
+	stored.Status = run.Status
+	stored.NextRow = run.NextRow
+	stored.Inserted = run.Inserted
+	stored.Matched = run.Matched
+	stored.Skipped = run.Skipped
+	stored.Rejected = run.Rejected
+	stored.Error = run.Error
+	stored.UpdatedAt = run.UpdatedAt
+	r.runs[run.ID] = stored
+	return nil
+}
+
+// GetRun retrieves a run
+func (r *LegacyImportRepository) GetRun(id string) (*models.LegacyImportRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	run, ok := r.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("legacy import run not found: %w", sql.ErrNoRows)
+	}
+	run = copyLegacyImportRun(run)
+	return &run, nil
+}
+
+// GetRuns retrieves every run, newest first
+func (r *LegacyImportRepository) GetRuns() ([]models.LegacyImportRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	runs := make([]models.LegacyImportRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, copyLegacyImportRun(run))
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+			return runs[i].CreatedAt.After(runs[j].CreatedAt)
+		}
+		return runs[i].ID < runs[j].ID
+	})
+	return runs, nil
+}
+
+// GetRecords retrieves the records the legacy rows of a kind were imported as
+func (r *LegacyImportRepository) GetRecords(kind string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records := make(map[string]string, len(r.records[kind]))
+	for legacyKey, entityID := range r.records[kind] {
+		records[legacyKey] = entityID
+	}
+	return records, nil
+}
+
+// AddRecord records the record a legacy row was imported as. A key may only be
+// recorded once per kind, like the primary key of the database table.
+func (r *LegacyImportRepository) AddRecord(kind, legacyKey, entityID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.records[kind] == nil {
+		r.records[kind] = make(map[string]string)
+	}
+	if _, ok := r.records[kind][legacyKey]; ok {
+		return fmt.Errorf("failed to record legacy %s %q: duplicate key", kind, legacyKey)
+	}
+	r.records[kind][legacyKey] = entityID
+	return nil
+}
@@ -0,0 +1,48 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyImportRepository(t *testing.T) {
+	repo := memory.NewLegacyImportRepository()
+
+	first := &models.LegacyImportRun{Kind: models.LegacyCustomers, Checksum: "abc", Mapping: map[string]string{"full_name": "Name"},
+		Status: models.LegacyImportRunning, TotalRows: 3, StartedBy: "admin-1"}
+	require.NoError(t, repo.CreateRun(first))
+	require.NotEmpty(t, first.ID)
+	second := &models.LegacyImportRun{Kind: models.LegacyCabs, Checksum: "def", Mapping: map[string]string{}, Status: models.LegacyImportRunning}
+	require.NoError(t, repo.CreateRun(second))
+
+	first.Mapping["full_name"] = "Changed"
+	first.Status, first.NextRow, first.Inserted = models.LegacyImportCompleted, 3, 2
+	require.NoError(t, repo.UpdateRun(first))
+	stored, err := repo.GetRun(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.LegacyImportCompleted, stored.Status)
+	assert.Equal(t, 2, stored.Inserted)
+	assert.Equal(t, "Name", stored.Mapping["full_name"], "the mapping is saved when the run is created")
+
+	_, err = repo.GetRun("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(repo.UpdateRun(&models.LegacyImportRun{ID: "missing"}), sql.ErrNoRows))
+
+	runs, err := repo.GetRuns()
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+
+	require.NoError(t, repo.AddRecord(models.LegacyCustomers, "C-001", "customer-1"))
+	assert.Error(t, repo.AddRecord(models.LegacyCustomers, "C-001", "customer-2"), "keys are unique per kind")
+	require.NoError(t, repo.AddRecord(models.LegacyCabs, "C-001", "7"))
+	records, err := repo.GetRecords(models.LegacyCustomers)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"C-001": "customer-1"}, records)
+}
@@ -0,0 +1,261 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.MaterialRepository = (*MaterialRepository)(nil)
+
+// MaterialRepository is an in-memory implementation of repositories.MaterialRepository
+type MaterialRepository struct {
+	mu        sync.RWMutex
+	materials map[int]models.Material
+	deleted   map[int]trashed[models.Material] // Soft-deleted materials, until restored or purged
+	nextID    int
+}
+
+// NewMaterialRepository creates an empty in-memory material repository
+func NewMaterialRepository() *MaterialRepository {
+	return &MaterialRepository{materials: make(map[int]models.Material), deleted: make(map[int]trashed[models.Material]), nextID: 1}
+}
+
+// GetAll returns the materials matching the filters, newest first.
+// A numeric search term matches the ID; any other term matches name, category or supplier.
+func (r *MaterialRepository) GetAll(ctx context.Context, filter models.MaterialFilter) ([]models.Material, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	materials := []models.Material{}
+	for _, material := range r.materials {
+		if matchesMaterial(material, filter) {
+			materials = append(materials, withDefaultMaterialImage(material))
+		}
+	}
+	sort.Slice(materials, func(i, j int) bool {
+		if materials[i].CreatedAt.Equal(materials[j].CreatedAt) {
+			return materials[i].ID > materials[j].ID
+		}
+		return materials[i].CreatedAt.After(materials[j].CreatedAt)
+	})
+	return materials, nil
+}
+
+// GetPaginated returns one page of the filtered materials along with the total number of matches
+func (r *MaterialRepository) GetPaginated(ctx context.Context, page, limit int, filter models.MaterialFilter) ([]models.Material, int64, error) {
+	materials, _ := r.GetAll(ctx, filter)
+	total := int64(len(materials))
+
+	offset := (page - 1) * limit
+	if offset < 0 || offset >= len(materials) {
+		return []models.Material{}, total, nil
+	}
+	end := offset + limit
+	if end > len(materials) {
+		end = len(materials)
+	}
+	return materials[offset:end], total, nil
+}
+
+// GetByID retrieves a material by its ID. Like the database implementation, a missing
+// material is reported as nil without an error.
+func (r *MaterialRepository) GetByID(ctx context.Context, id int) (*models.Material, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	material, ok := r.materials[id]
+	if !ok {
+		return nil, nil
+	}
+	material = withDefaultMaterialImage(material)
+	return &material, nil
+}
+
+// Create stores a new material and returns its ID
+func (r *MaterialRepository) Create(ctx context.Context, material *models.Material) (int, error) {
+	if material.Quantity < 0 {
+		return 0, negativeQuantity("material", material.Quantity)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	stored := *material
+	stored.ID = r.nextID
+	stored.CreatedAt = now
+	stored.UpdatedAt = now
+	r.nextID++
+	r.materials[stored.ID] = stored
+	return stored.ID, nil
+}
+
+// Update replaces the fields of an existing material; unknown IDs are ignored
+func (r *MaterialRepository) Update(ctx context.Context, material *models.Material) error {
+	if material.Quantity < 0 {
+		return negativeQuantity("material", material.Quantity)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.materials[material.ID]
+	if !ok {
+		return nil
+	}
+	stored := *material
+	stored.CreatedAt = existing.CreatedAt
+	stored.UpdatedAt = time.Now()
+	r.materials[material.ID] = stored
+	return nil
+}
+
+// Delete soft-deletes a material, keeping it for Restore; unknown IDs are ignored
+func (r *MaterialRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if material, ok := r.materials[id]; ok {
+		r.deleted[id] = trashed[models.Material]{record: material, deletedAt: time.Now()}
+		delete(r.materials, id)
+	}
+	return nil
+}
+
+// Restore brings back a deleted material
+func (r *MaterialRepository) Restore(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	material, ok := r.deleted[id]
+	if !ok {
+		return fmt.Errorf("deleted material with ID %d not found: %w", id, sql.ErrNoRows)
+	}
+	r.materials[id] = material.record
+	delete(r.deleted, id)
+	return nil
+}
+
+// GetDeleted returns the deleted materials, most recently deleted first
+func (r *MaterialRepository) GetDeleted(ctx context.Context) ([]models.Material, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return deletedRecords(r.deleted, func(material *models.Material, deletedAt time.Time) {
+		*material = withDefaultMaterialImage(*material)
+		material.DeletedAt = &deletedAt
+	}), nil
+}
+
+// BulkImport inserts the materials without an ID and updates those with one, all or
+// none; new materials get the default image and updated ones keep theirs
+func (r *MaterialRepository) BulkImport(ctx context.Context, materials []models.Material) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, material := range materials {
+		if material.Quantity < 0 {
+			return negativeQuantity("material", material.Quantity)
+		}
+		if _, ok := r.materials[material.ID]; material.ID != 0 && !ok {
+			return fmt.Errorf("material ID %d not found: %w", material.ID, sql.ErrNoRows)
+		}
+	}
+
+	now := time.Now()
+	for i := range materials {
+		material := &materials[i]
+		if material.ID == 0 {
+			material.ID = r.nextID
+			r.nextID++
+			stored := *material
+			stored.Image = config.DefaultImageURL
+			stored.CreatedAt = now
+			stored.UpdatedAt = now
+			r.materials[stored.ID] = stored
+			continue
+		}
+
+		stored := r.materials[material.ID]
+		stored.Name = material.Name
+		stored.Category = material.Category
+		stored.Supplier = material.Supplier
+		stored.Quantity = material.Quantity
+		stored.Status = material.Status
+		stored.UpdatedAt = now
+		r.materials[material.ID] = stored
+	}
+	return nil
+}
+
+// ApplyBatch creates, updates and deletes materials, all or none
+func (r *MaterialRepository) ApplyBatch(ctx context.Context, changes []models.BatchChange[models.Material]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := checkBatch("material", changes, r.materials, func(material models.Material) int { return material.Quantity }); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range changes {
+		change := &changes[i]
+		if change.Op == models.BatchDelete {
+			r.deleted[change.ID] = trashed[models.Material]{record: r.materials[change.ID], deletedAt: now}
+			delete(r.materials, change.ID)
+			continue
+		}
+
+		material := &change.Item
+		material.CreatedAt, material.UpdatedAt = now, now
+		if change.Op == models.BatchCreate {
+			material.ID = r.nextID
+			r.nextID++
+		} else {
+			material.ID, material.CreatedAt = change.ID, r.materials[change.ID].CreatedAt
+		}
+		r.materials[material.ID] = *material
+		*material = withDefaultMaterialImage(*material)
+	}
+	return nil
+}
+
+// matchesMaterial applies the search and filter rules of the database implementation
+func matchesMaterial(material models.Material, filter models.MaterialFilter) bool {
+	if filter.Search != "" {
+		if id, err := strconv.Atoi(filter.Search); err == nil {
+			if material.ID != id {
+				return false
+			}
+		} else if !containsFold(material.Name, filter.Search) && !containsFold(material.Category, filter.Search) && !containsFold(material.Supplier, filter.Search) {
+			return false
+		}
+	}
+	if filter.Category != "" && !strings.EqualFold(material.Category, filter.Category) {
+		return false
+	}
+	if filter.Supplier != "" && !strings.EqualFold(material.Supplier, filter.Supplier) {
+		return false
+	}
+	if filter.Status != "" && !strings.EqualFold(material.Status, filter.Status) {
+		return false
+	}
+	return true
+}
+
+// withDefaultMaterialImage fills in the placeholder image for materials without one
+func withDefaultMaterialImage(material models.Material) models.Material {
+	if material.Image == "" {
+		material.Image = config.DefaultImageURL
+	}
+	return material
+}
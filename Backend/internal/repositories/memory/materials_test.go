@@ -0,0 +1,160 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedMaterials(t *testing.T) *memory.MaterialRepository {
+	repo := memory.NewMaterialRepository()
+	for _, material := range []models.Material{
+		{Name: "Steel Sheet", Category: "Building", Supplier: "Steel Co.", Quantity: 40, Status: "In Stock"},
+		{Name: "Plywood", Category: "Lumber", Supplier: "Wood Works", Quantity: 5, Status: "Low Stock"},
+		{Name: "Steel Bolts", Category: "Hardware", Supplier: "Steel Co.", Quantity: 0, Status: "Out of Stock"},
+	} {
+		material := material
+		_, err := repo.Create(context.Background(), &material)
+		require.NoError(t, err)
+	}
+	return repo
+}
+
+func TestMaterialRepositoryGetAll(t *testing.T) {
+	repo := seedMaterials(t)
+
+	tests := []struct {
+		name   string
+		filter models.MaterialFilter
+		want   []string
+	}{
+		{"No filters, newest first", models.MaterialFilter{}, []string{"Steel Bolts", "Plywood", "Steel Sheet"}},
+		{"Numeric search matches the ID", models.MaterialFilter{Search: "2"}, []string{"Plywood"}},
+		{"Text search ignores case", models.MaterialFilter{Search: "STEEL"}, []string{"Steel Bolts", "Steel Sheet"}},
+		{"Filters ignore case", models.MaterialFilter{Supplier: "steel co.", Status: "in stock"}, []string{"Steel Sheet"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			materials, err := repo.GetAll(context.Background(), tt.filter)
+			require.NoError(t, err)
+			names := []string{}
+			for _, material := range materials {
+				names = append(names, material.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestMaterialRepositoryGetPaginated(t *testing.T) {
+	repo := seedMaterials(t)
+
+	page, total, err := repo.GetPaginated(context.Background(), 2, 2, models.MaterialFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "Steel Sheet", page[0].Name)
+	}
+
+	page, total, err = repo.GetPaginated(context.Background(), 3, 2, models.MaterialFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Empty(t, page)
+}
+
+func TestMaterialRepositoryUpdateDelete(t *testing.T) {
+	repo := seedMaterials(t)
+
+	material, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	material.Quantity = 38
+	require.NoError(t, repo.Update(context.Background(), material))
+
+	updated, err := repo.GetByID(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 38, updated.Quantity)
+	assert.Equal(t, material.CreatedAt, updated.CreatedAt)
+
+	require.NoError(t, repo.Delete(context.Background(), 1))
+	missing, err := repo.GetByID(context.Background(), 1)
+	assert.NoError(t, err, "a missing material is not an error")
+	assert.Nil(t, missing)
+}
+
+func TestMaterialRepositoryBulkImport(t *testing.T) {
+	repo := seedMaterials(t)
+	plywood, err := repo.GetAll(context.Background(), models.MaterialFilter{Search: "Plywood"})
+	require.NoError(t, err)
+	require.Len(t, plywood, 1)
+
+	// Updating a material that does not exist imports nothing
+	err = repo.BulkImport(context.Background(), []models.Material{
+		{Name: "Rivets", Category: "Hardware", Supplier: "Steel Co.", Quantity: 100, Status: "In Stock"},
+		{ID: 99, Name: "Gone", Category: "Hardware", Supplier: "Steel Co.", Quantity: 1, Status: "Low Stock"},
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	all, err := repo.GetAll(context.Background(), models.MaterialFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	materials := []models.Material{
+		{Name: "Rivets", Category: "Hardware", Supplier: "Steel Co.", Quantity: 100, Status: "In Stock"},
+		{ID: plywood[0].ID, Name: "Plywood", Category: "Lumber", Supplier: "Timber Inc.", Quantity: 25, Status: "In Stock"},
+	}
+	require.NoError(t, repo.BulkImport(context.Background(), materials))
+	assert.NotZero(t, materials[0].ID)
+
+	rivets, err := repo.GetByID(context.Background(), materials[0].ID)
+	require.NoError(t, err)
+	require.NotNil(t, rivets)
+	assert.Equal(t, 100, rivets.Quantity)
+	assert.NotEmpty(t, rivets.Image, "new materials get the default image")
+	updated, err := repo.GetByID(context.Background(), plywood[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Timber Inc.", updated.Supplier)
+	assert.Equal(t, 25, updated.Quantity)
+	assert.Equal(t, plywood[0].CreatedAt, updated.CreatedAt)
+}
+
+func TestMaterialRepositoryApplyBatch(t *testing.T) {
+	repo := seedMaterials(t)
+	plywood, err := repo.GetAll(context.Background(), models.MaterialFilter{Search: "Plywood"})
+	require.NoError(t, err)
+	require.Len(t, plywood, 1)
+	id := plywood[0].ID
+
+	// Updating a material deleted earlier in the batch applies nothing
+	err = repo.ApplyBatch(context.Background(), []models.BatchChange[models.Material]{
+		{Op: models.BatchCreate, Item: models.Material{Name: "Rivets", Category: "Hardware", Supplier: "Steel Co.", Quantity: 100, Status: "In Stock"}},
+		{Op: models.BatchDelete, ID: id},
+		{Op: models.BatchUpdate, ID: id, Item: models.Material{Name: "Plywood", Category: "Lumber", Supplier: "Wood Works", Quantity: 9, Status: "In Stock"}},
+	})
+	var batchErr *repositories.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 2, batchErr.Index)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	all, err := repo.GetAll(context.Background(), models.MaterialFilter{})
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	changes := []models.BatchChange[models.Material]{
+		{Op: models.BatchCreate, Item: models.Material{Name: "Rivets", Category: "Hardware", Supplier: "Steel Co.", Quantity: 100, Status: "In Stock"}},
+		{Op: models.BatchUpdate, ID: id, Item: models.Material{Name: "Plywood", Category: "Lumber", Supplier: "Timber Inc.", Quantity: 25, Status: "In Stock"}},
+	}
+	require.NoError(t, repo.ApplyBatch(context.Background(), changes))
+	assert.NotZero(t, changes[0].Item.ID)
+	assert.NotEmpty(t, changes[0].Item.Image, "new materials get the default image")
+	assert.Equal(t, plywood[0].CreatedAt, changes[1].Item.CreatedAt)
+
+	updated, err := repo.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, "Timber Inc.", updated.Supplier)
+	assert.Equal(t, 25, updated.Quantity)
+}
@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.PasswordResetRepository = (*PasswordResetRepository)(nil)
+
+// PasswordResetRepository is an in-memory implementation of repositories.PasswordResetRepository
+type PasswordResetRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]models.PasswordResetToken // Token ID -> token
+}
+
+// NewPasswordResetRepository creates an empty in-memory password reset repository
+func NewPasswordResetRepository() *PasswordResetRepository {
+	return &PasswordResetRepository{tokens: make(map[string]models.PasswordResetToken)}
+}
+
+// Create spends the user's unused tokens and stores a copy of the new one
+func (r *PasswordResetRepository) Create(token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, earlier := range r.tokens {
+		if earlier.UserID == token.UserID && earlier.UsedAt == nil {
+			spentAt := token.CreatedAt
+			earlier.UsedAt = &spentAt
+			r.tokens[id] = earlier
+		}
+	}
+	stored := *token
+	stored.UsedAt = nil
+	r.tokens[token.ID] = stored
+	return nil
+}
+
+// GetByTokenHash returns a copy of the token with the hash
+func (r *PasswordResetRepository) GetByTokenHash(tokenHash string) (*models.PasswordResetToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, fmt.Errorf("password reset token not found: %w", sql.ErrNoRows)
+}
+
+// Use marks a token as used, once
+func (r *PasswordResetRepository) Use(id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UsedAt != nil {
+		return fmt.Errorf("password reset token already used: %w", sql.ErrNoRows)
+	}
+	token.UsedAt = &at
+	r.tokens[token.ID] = token
+	return nil
+}
+
+// LastCreatedAt returns when the user's newest token was created
+func (r *PasswordResetRepository) LastCreatedAt(userID string) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var last time.Time
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.CreatedAt.After(last) {
+			last = token.CreatedAt
+		}
+	}
+	return last, nil
+}
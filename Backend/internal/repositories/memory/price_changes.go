@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.PriceChangeRepository = (*PriceChangeRepository)(nil)
+
+// PriceChangeRepository is an in-memory implementation of repositories.PriceChangeRepository
+type PriceChangeRepository struct {
+	mu      sync.RWMutex
+	changes map[string]models.PriceChange
+}
+
+// NewPriceChangeRepository creates an empty in-memory price change repository
+func NewPriceChangeRepository() *PriceChangeRepository {
+	return &PriceChangeRepository{changes: make(map[string]models.PriceChange)}
+}
+
+// Create stores a new pending price change
+func (r *PriceChangeRepository) Create(change *models.PriceChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if change.ID == "" {
+		change.ID = uuid.New().String()
+	}
+	change.Status = models.PriceChangePending
+	change.RequestedAt = time.Now()
+	r.changes[change.ID] = *change
+	return nil
+}
+
+// GetByID returns a copy of a price change
+func (r *PriceChangeRepository) GetByID(id string) (*models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	change, ok := r.changes[id]
+	if !ok {
+		return nil, fmt.Errorf("price change not found: %w", sql.ErrNoRows)
+	}
+	return &change, nil
+}
+
+// GetAll returns the price changes with a status, or all of them, most recently requested first
+func (r *PriceChangeRepository) GetAll(status string) ([]models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := []models.PriceChange{}
+	for _, change := range r.changes {
+		if status == "" || change.Status == status {
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].RequestedAt.After(changes[j].RequestedAt) })
+	return changes, nil
+}
+
+// GetPending returns the pending change of an item
+func (r *PriceChangeRepository) GetPending(itemType string, itemID int) (*models.PriceChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, change := range r.changes {
+		if change.ItemType == itemType && change.ItemID == itemID && change.Status == models.PriceChangePending {
+			return &change, nil
+		}
+	}
+	return nil, fmt.Errorf("price change not found: %w", sql.ErrNoRows)
+}
+
+// Review approves or rejects a change that is still pending
+func (r *PriceChangeRepository) Review(id, status, reviewedBy, note string) (*models.PriceChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change, ok := r.changes[id]
+	if !ok || change.Status != models.PriceChangePending {
+		return nil, fmt.Errorf("pending price change not found: %w", sql.ErrNoRows)
+	}
+	now := time.Now()
+	change.Status, change.ReviewedBy, change.ReviewedAt, change.Note = status, reviewedBy, &now, note
+	r.changes[change.ID] = change
+	return &change, nil
+}
@@ -0,0 +1,43 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceChangeRepository(t *testing.T) {
+	repo := memory.NewPriceChangeRepository()
+
+	_, err := repo.GetPending(models.InventoryCab, 1)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	change := &models.PriceChange{ItemType: models.InventoryCab, ItemID: 1, ItemName: "RX-7", OldPrice: 700000, NewPrice: 900000, RequestedBy: "staff-1"}
+	require.NoError(t, repo.Create(change))
+	assert.Equal(t, models.PriceChangePending, change.Status)
+	pending, err := repo.GetPending(models.InventoryCab, 1)
+	require.NoError(t, err)
+	assert.Equal(t, change.ID, pending.ID)
+
+	reviewed, err := repo.Review(change.ID, models.PriceChangeApproved, "admin-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, "admin-1", reviewed.ReviewedBy)
+	assert.NotNil(t, reviewed.ReviewedAt)
+	_, err = repo.Review(change.ID, models.PriceChangeRejected, "admin-2", "")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "reviewed once")
+
+	_, err = repo.GetPending(models.InventoryCab, 1)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	changes, err := repo.GetAll(models.PriceChangePending)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+	changes, err = repo.GetAll("")
+	require.NoError(t, err)
+	assert.Len(t, changes, 1)
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.ReceiptSeriesRepository = (*ReceiptSeriesRepository)(nil)
+
+// ReceiptSeriesRepository is an in-memory implementation of repositories.ReceiptSeriesRepository
+type ReceiptSeriesRepository struct {
+	mu       sync.Mutex
+	series   map[string]models.ReceiptSeries
+	receipts map[string]models.OfficialReceipt // By sale ID
+}
+
+// NewReceiptSeriesRepository creates an empty in-memory receipt series repository
+func NewReceiptSeriesRepository() *ReceiptSeriesRepository {
+	return &ReceiptSeriesRepository{series: make(map[string]models.ReceiptSeries), receipts: make(map[string]models.OfficialReceipt)}
+}
+
+// Create stores a new series, starting at its first number
+func (r *ReceiptSeriesRepository) Create(series *models.ReceiptSeries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if series.ID == "" {
+		series.ID = uuid.New().String()
+	}
+	series.NextNumber = series.StartNumber
+	series.CreatedAt = time.Now()
+	series.UpdatedAt = series.CreatedAt
+	r.series[series.ID] = *series
+	return nil
+}
+
+// GetByID returns a copy of a series
+func (r *ReceiptSeriesRepository) GetByID(id string) (*models.ReceiptSeries, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	series, ok := r.series[id]
+	if !ok {
+		return nil, fmt.Errorf("receipt series not found: %w", sql.ErrNoRows)
+	}
+	return &series, nil
+}
+
+// GetAll returns the series of a branch, or of every branch, by branch then start number
+func (r *ReceiptSeriesRepository) GetAll(branch string) ([]models.ReceiptSeries, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := []models.ReceiptSeries{}
+	for _, series := range r.series {
+		if branch == "" || series.Branch == branch {
+			all = append(all, series)
+		}
+	}
+	sortReceiptSeries(all)
+	return all, nil
+}
+
+// Update saves whether a series is active and its warning level
+func (r *ReceiptSeriesRepository) Update(series *models.ReceiptSeries) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.series[series.ID]
+	if !ok {
+		return fmt.Errorf("receipt series not found: %w", sql.ErrNoRows)
+	}
+	series.UpdatedAt = time.Now()
+	stored.Active = series.Active
+	stored.WarnRemaining = series.WarnRemaining
+	stored.UpdatedAt = series.UpdatedAt
+	r.series[series.ID] = stored
+	return nil
+}
+
+// Issue allocates the next OR number of the branch's first active series with
+// numbers left to a sale. Like the keys of the database, it fails when the sale
+// already has one.
+func (r *ReceiptSeriesRepository) Issue(branch, saleID, issuedBy string) (*models.OfficialReceipt, *models.ReceiptSeries, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.receipts[saleID]; ok {
+		return nil, nil, fmt.Errorf("failed to issue official receipt: sale %s already has one", saleID)
+	}
+
+	candidates := []models.ReceiptSeries{}
+	for _, series := range r.series {
+		if series.Branch == branch && series.Active && series.Remaining() > 0 {
+			candidates = append(candidates, series)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("no receipt series with numbers left for branch %s: %w", branch, sql.ErrNoRows)
+	}
+	sortReceiptSeries(candidates)
+	series := candidates[0]
+
+	receipt := models.OfficialReceipt{
+		SaleID:   saleID,
+		SeriesID: series.ID,
+		Branch:   series.Branch,
+		Number:   series.NextNumber,
+		ORNumber: series.FormatNumber(series.NextNumber),
+		IssuedBy: issuedBy,
+		IssuedAt: time.Now(),
+	}
+	series.NextNumber++
+	series.UpdatedAt = receipt.IssuedAt
+	r.series[series.ID] = series
+	r.receipts[saleID] = receipt
+	return &receipt, &series, nil
+}
+
+// GetIssued returns the OR number issued to a sale
+func (r *ReceiptSeriesRepository) GetIssued(saleID string) (*models.OfficialReceipt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	receipt, ok := r.receipts[saleID]
+	if !ok {
+		return nil, fmt.Errorf("official receipt not found: %w", sql.ErrNoRows)
+	}
+	return &receipt, nil
+}
+
+// sortReceiptSeries orders series by branch, then start number
+func sortReceiptSeries(all []models.ReceiptSeries) {
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Branch != all[j].Branch {
+			return all[i].Branch < all[j].Branch
+		}
+		return all[i].StartNumber < all[j].StartNumber
+	})
+}
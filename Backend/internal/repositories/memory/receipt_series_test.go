@@ -0,0 +1,49 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptSeriesRepository(t *testing.T) {
+	store := memory.NewStore()
+	later := &models.ReceiptSeries{Branch: "MNL", StartNumber: 101, EndNumber: 200, Active: true}
+	first := &models.ReceiptSeries{Branch: "MNL", StartNumber: 1, EndNumber: 2, Active: true}
+	other := &models.ReceiptSeries{Branch: "CEB", StartNumber: 1, EndNumber: 100, Active: true}
+	for _, series := range []*models.ReceiptSeries{later, first, other} {
+		require.NoError(t, store.Receipts.Create(series))
+		assert.Equal(t, series.StartNumber, series.NextNumber)
+	}
+
+	var numbers []string
+	for _, saleID := range []string{"sale-1", "sale-2", "sale-3"} {
+		receipt, _, err := store.Receipts.Issue("MNL", saleID, "staff-1")
+		require.NoError(t, err)
+		numbers = append(numbers, receipt.ORNumber)
+	}
+	assert.Equal(t, []string{"1", "2", "101"}, numbers, "the lowest series is used up first")
+
+	_, _, err := store.Receipts.Issue("MNL", "sale-1", "staff-1")
+	assert.Error(t, err, "a sale gets one number")
+	issued, err := store.Receipts.GetIssued("sale-2")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, issued.SeriesID)
+
+	later.Active = false
+	require.NoError(t, store.Receipts.Update(later))
+	_, _, err = store.Receipts.Issue("MNL", "sale-4", "staff-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "inactive series issue no numbers")
+
+	all, err := store.Receipts.GetAll("MNL")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, first.ID, all[0].ID)
+	assert.Equal(t, 0, all[0].Remaining())
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.RefreshTokenRepository = (*RefreshTokenRepository)(nil)
+
+// RefreshTokenRepository is an in-memory implementation of repositories.RefreshTokenRepository
+type RefreshTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]models.RefreshToken // Token ID -> token
+}
+
+// NewRefreshTokenRepository creates an empty in-memory refresh token repository
+func NewRefreshTokenRepository() *RefreshTokenRepository {
+	return &RefreshTokenRepository{tokens: make(map[string]models.RefreshToken)}
+}
+
+// Create stores a copy of the token
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *token
+	stored.RevokedAt, stored.ReplacedBy = nil, ""
+	r.tokens[token.ID] = stored
+	return nil
+}
+
+// GetByTokenHash returns a copy of the token with the hash
+func (r *RefreshTokenRepository) GetByTokenHash(tokenHash string) (*models.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			return &token, nil
+		}
+	}
+	return nil, fmt.Errorf("refresh token not found: %w", sql.ErrNoRows)
+}
+
+// Rotate revokes the old token and stores its replacement
+func (r *RefreshTokenRepository) Rotate(oldID string, next *models.RefreshToken, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.tokens[oldID]
+	if !ok || old.RevokedAt != nil {
+		return fmt.Errorf("refresh token already revoked: %w", sql.ErrNoRows)
+	}
+	old.RevokedAt, old.ReplacedBy = &at, next.ID
+	r.tokens[oldID] = old
+
+	stored := *next
+	stored.RevokedAt, stored.ReplacedBy = nil, ""
+	r.tokens[next.ID] = stored
+	return nil
+}
+
+// RevokeFamily revokes the live tokens of a session
+func (r *RefreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	r.revoke(at, func(token models.RefreshToken) bool { return token.FamilyID == familyID })
+	return nil
+}
+
+// RevokeUser revokes the live tokens of every session of a user
+func (r *RefreshTokenRepository) RevokeUser(userID string, at time.Time) error {
+	r.revoke(at, func(token models.RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+func (r *RefreshTokenRepository) revoke(at time.Time, match func(models.RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, token := range r.tokens {
+		if token.RevokedAt == nil && match(token) {
+			token.RevokedAt = &at
+			r.tokens[id] = token
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshTokenRepository(t *testing.T) {
+	repo := memory.NewRefreshTokenRepository()
+	now := time.Now()
+	token := func(id, userID, familyID string) *models.RefreshToken {
+		return &models.RefreshToken{ID: id, UserID: userID, FamilyID: familyID, TokenHash: "hash-" + id, ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	}
+
+	require.NoError(t, repo.Create(token("1", "staff-1", "family-1")))
+	require.NoError(t, repo.Create(token("other", "staff-2", "family-2")))
+	require.NoError(t, repo.Rotate("1", token("2", "staff-1", "family-1"), now))
+
+	old, err := repo.GetByTokenHash("hash-1")
+	require.NoError(t, err)
+	require.NotNil(t, old.RevokedAt)
+	assert.Equal(t, "2", old.ReplacedBy)
+	assert.True(t, errors.Is(repo.Rotate("1", token("3", "staff-1", "family-1"), now), sql.ErrNoRows), "a revoked token is not rotated again")
+
+	require.NoError(t, repo.RevokeFamily("family-1", now))
+	current, err := repo.GetByTokenHash("hash-2")
+	require.NoError(t, err)
+	assert.NotNil(t, current.RevokedAt)
+	other, err := repo.GetByTokenHash("hash-other")
+	require.NoError(t, err)
+	assert.Nil(t, other.RevokedAt, "other sessions are untouched")
+
+	require.NoError(t, repo.RevokeUser("staff-2", now))
+	other, err = repo.GetByTokenHash("hash-other")
+	require.NoError(t, err)
+	assert.NotNil(t, other.RevokedAt)
+
+	_, err = repo.GetByTokenHash("hash-unknown")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.RegisterSessionRepository = (*RegisterSessionRepository)(nil)
+
+// RegisterSessionRepository is an in-memory implementation of repositories.RegisterSessionRepository
+type RegisterSessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]models.RegisterSession
+	deposits *DepositRepository // Optional; without it no session counts as deposited
+}
+
+// NewRegisterSessionRepository creates an empty in-memory register session repository
+func NewRegisterSessionRepository() *RegisterSessionRepository {
+	return &RegisterSessionRepository{sessions: make(map[string]models.RegisterSession)}
+}
+
+// Open stores a new open session
+func (r *RegisterSessionRepository) Open(session *models.RegisterSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if session.ID == "" {
+		session.ID = uuid.New().String()
+	}
+	session.Status = models.RegisterSessionOpen
+	r.sessions[session.ID] = cloneRegisterSession(*session)
+	return nil
+}
+
+// GetByID returns a copy of a session
+func (r *RegisterSessionRepository) GetByID(id string) (*models.RegisterSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("register session not found: %w", sql.ErrNoRows)
+	}
+	session = cloneRegisterSession(session)
+	return &session, nil
+}
+
+// GetOpen returns a copy of the user's latest open session
+func (r *RegisterSessionRepository) GetOpen(userID string) (*models.RegisterSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *models.RegisterSession
+	for _, session := range r.sessions {
+		if session.OpenedBy != userID || session.Status != models.RegisterSessionOpen {
+			continue
+		}
+		if latest == nil || session.OpenedAt.After(latest.OpenedAt) {
+			session := cloneRegisterSession(session)
+			latest = &session
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("register session not found: %w", sql.ErrNoRows)
+	}
+	return latest, nil
+}
+
+// Close saves the count of an open session
+func (r *RegisterSessionRepository) Close(session *models.RegisterSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.sessions[session.ID]
+	if !ok || existing.Status != models.RegisterSessionOpen {
+		return fmt.Errorf("register session not open: %w", sql.ErrNoRows)
+	}
+	session.Status = models.RegisterSessionClosed
+	r.sessions[session.ID] = cloneRegisterSession(*session)
+	return nil
+}
+
+// GetClosedBetween returns copies of the sessions closed in [start, end), earliest first
+func (r *RegisterSessionRepository) GetClosedBetween(start, end time.Time) ([]models.RegisterSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []models.RegisterSession{}
+	for _, session := range r.sessions {
+		if session.Status != models.RegisterSessionClosed || session.ClosedAt == nil {
+			continue
+		}
+		if session.ClosedAt.Before(start) || !session.ClosedAt.Before(end) {
+			continue
+		}
+		sessions = append(sessions, cloneRegisterSession(session))
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ClosedAt.Before(*sessions[j].ClosedAt) })
+	return sessions, nil
+}
+
+// GetUndeposited returns copies of the sessions closed before closedBefore that no deposit covers, earliest first
+func (r *RegisterSessionRepository) GetUndeposited(closedBefore time.Time) ([]models.RegisterSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []models.RegisterSession{}
+	ids := []string{}
+	for _, session := range r.sessions {
+		if session.Status == models.RegisterSessionClosed && session.ClosedAt != nil && session.ClosedAt.Before(closedBefore) {
+			sessions = append(sessions, cloneRegisterSession(session))
+			ids = append(ids, session.ID)
+		}
+	}
+
+	if r.deposits != nil {
+		deposited, err := r.deposits.DepositedSessions(ids)
+		if err != nil {
+			return nil, err
+		}
+		undeposited := sessions[:0]
+		for _, session := range sessions {
+			if _, ok := deposited[session.ID]; !ok {
+				undeposited = append(undeposited, session)
+			}
+		}
+		sessions = undeposited
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ClosedAt.Before(*sessions[j].ClosedAt) })
+	return sessions, nil
+}
+
+// cloneRegisterSession copies the slices and pointers of a session, so callers cannot change stored sessions
+func cloneRegisterSession(session models.RegisterSession) models.RegisterSession {
+	if session.ClosedAt != nil {
+		closedAt := *session.ClosedAt
+		session.ClosedAt = &closedAt
+	}
+	session.Denominations = append([]models.DenominationCount(nil), session.Denominations...)
+	return session
+}
@@ -0,0 +1,53 @@
+package memory_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterSessionRepository(t *testing.T) {
+	repo := memory.NewRegisterSessionRepository()
+	openedAt := time.Date(2025, 3, 12, 8, 0, 0, 0, time.UTC)
+
+	_, err := repo.GetOpen("staff-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	session := &models.RegisterSession{OpenedBy: "staff-1", OpenedAt: openedAt, OpeningFloat: 1000}
+	require.NoError(t, repo.Open(session))
+	assert.NotEmpty(t, session.ID)
+
+	open, err := repo.GetOpen("staff-1")
+	require.NoError(t, err)
+	assert.Equal(t, session.ID, open.ID)
+
+	closedAt := openedAt.Add(9 * time.Hour)
+	open.ClosedAt = &closedAt
+	open.Denominations = []models.DenominationCount{{Kind: models.CashBill, Value: 1000, Count: 1, Subtotal: 1000}}
+	open.CountedCash = 1000
+	require.NoError(t, repo.Close(open))
+	assert.True(t, errors.Is(repo.Close(open), sql.ErrNoRows), "a session closes once")
+
+	_, err = repo.GetOpen("staff-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	// Changing the returned session does not change the stored one
+	open.Denominations[0].Count = 99
+
+	closed, err := repo.GetClosedBetween(openedAt, closedAt.Add(time.Second))
+	require.NoError(t, err)
+	require.Len(t, closed, 1)
+	assert.Equal(t, models.RegisterSessionClosed, closed[0].Status)
+	assert.Equal(t, 1, closed[0].Denominations[0].Count)
+
+	closed, err = repo.GetClosedBetween(openedAt, closedAt)
+	require.NoError(t, err)
+	assert.Empty(t, closed, "the end of the range is excluded")
+}
@@ -0,0 +1,130 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.SalePaymentRepository = (*SalePaymentRepository)(nil)
+
+// SalePaymentRepository is an in-memory implementation of repositories.SalePaymentRepository
+// checking payments against the totals of the sales repository
+type SalePaymentRepository struct {
+	mu           sync.RWMutex
+	payments     map[string][]models.SalePayment // By sale ID
+	installments map[string]bool                 // IDs of the sales paid in installments
+	sales        *SalesRepository
+}
+
+// NewSalePaymentRepository creates an empty payment repository over the given sales
+func NewSalePaymentRepository(sales *SalesRepository) *SalePaymentRepository {
+	return &SalePaymentRepository{payments: make(map[string][]models.SalePayment), installments: make(map[string]bool), sales: sales}
+}
+
+// Add records a payment unless it would take the sale's payments above its total
+func (r *SalePaymentRepository) Add(payment *models.SalePayment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.sales.mu.RLock()
+	sale, ok := r.sales.sales[payment.SaleID]
+	r.sales.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("sale %s not found: %w", payment.SaleID, sql.ErrNoRows)
+	}
+	paid := r.paid(payment.SaleID)
+	if paid+payment.Amount > sale.TotalPrice+repositories.PaymentTolerance {
+		return fmt.Errorf("payment of %.2f with %.2f of %.2f already paid: %w", payment.Amount, paid, sale.TotalPrice, repositories.ErrPaymentExceedsTotal)
+	}
+
+	if payment.ID == "" {
+		payment.ID = uuid.New().String()
+	}
+	if payment.ReceivedAt.IsZero() {
+		payment.ReceivedAt = time.Now()
+	}
+	r.payments[payment.SaleID] = append(r.payments[payment.SaleID], *payment)
+	return nil
+}
+
+// GetBySale returns the payments of a sale, oldest first
+func (r *SalePaymentRepository) GetBySale(saleID string) ([]models.SalePayment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	payments := append([]models.SalePayment{}, r.payments[saleID]...)
+	sort.SliceStable(payments, func(i, j int) bool { return payments[i].ReceivedAt.Before(payments[j].ReceivedAt) })
+	return payments, nil
+}
+
+// Paid returns the sum of the payments of a sale
+func (r *SalePaymentRepository) Paid(saleID string) (float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.paid(saleID), nil
+}
+
+// PaidBySales returns the sums of the payments of the given sales that have any
+func (r *SalePaymentRepository) PaidBySales(saleIDs []string) (map[string]float64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	paid := make(map[string]float64, len(saleIDs))
+	for _, id := range saleIDs {
+		if len(r.payments[id]) > 0 {
+			paid[id] = r.paid(id)
+		}
+	}
+	return paid, nil
+}
+
+// MarkInstallment records that a sale is paid in installments
+func (r *SalePaymentRepository) MarkInstallment(saleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.installments[saleID] = true
+	return nil
+}
+
+// Mismatches returns the sales whose payments do not add up to their total, oldest
+// first, leaving out the balances of installment sales and of sales not counted
+func (r *SalePaymentRepository) Mismatches() ([]models.PaymentMismatch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.sales.mu.RLock()
+	defer r.sales.mu.RUnlock()
+
+	mismatches := []models.PaymentMismatch{}
+	for _, sale := range r.sales.sales {
+		paid := r.paid(sale.ID)
+		overpaid := paid-sale.TotalPrice > repositories.PaymentTolerance
+		short := sale.TotalPrice-paid > repositories.PaymentTolerance && !r.installments[sale.ID] && sale.Counted()
+		if overpaid || short {
+			mismatches = append(mismatches, models.PaymentMismatch{SaleID: sale.ID, SaleDate: sale.SaleDate, TotalPrice: sale.TotalPrice, Paid: paid})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].SaleDate == mismatches[j].SaleDate {
+			return mismatches[i].SaleID < mismatches[j].SaleID
+		}
+		return mismatches[i].SaleDate < mismatches[j].SaleDate
+	})
+	return mismatches, nil
+}
+
+// paid sums the payments of a sale; the caller holds the lock
+func (r *SalePaymentRepository) paid(saleID string) float64 {
+	paid := 0.0
+	for _, payment := range r.payments[saleID] {
+		paid += payment.Amount
+	}
+	return paid
+}
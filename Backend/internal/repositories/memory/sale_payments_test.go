@@ -0,0 +1,72 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSalePaymentRepository(t *testing.T) {
+	store := memory.NewStore()
+	repo := store.Payments
+
+	paidID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c1", SoldBy: "u1", SaleDate: "2026-10-01", TotalPrice: 1000})
+	require.NoError(t, err)
+	shortID, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c1", SoldBy: "u1", SaleDate: "2026-10-02", TotalPrice: 500})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Add(&models.SalePayment{SaleID: paidID, Amount: 600, Method: models.PaymentCash, ReceivedBy: "u1"}))
+	require.NoError(t, repo.Add(&models.SalePayment{SaleID: paidID, Amount: 400, Method: models.PaymentCard, ReceivedBy: "u1"}))
+	require.NoError(t, repo.Add(&models.SalePayment{SaleID: shortID, Amount: 200, Method: models.PaymentCash, ReceivedBy: "u1"}))
+
+	// Payments never exceed the total
+	err = repo.Add(&models.SalePayment{SaleID: paidID, Amount: 0.01, Method: models.PaymentCash})
+	assert.True(t, errors.Is(err, repositories.ErrPaymentExceedsTotal))
+	err = repo.Add(&models.SalePayment{SaleID: "missing", Amount: 1, Method: models.PaymentCash})
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	payments, err := repo.GetBySale(paidID)
+	require.NoError(t, err)
+	require.Len(t, payments, 2)
+	assert.NotEmpty(t, payments[0].ID)
+	paid, err := repo.Paid(paidID)
+	require.NoError(t, err)
+	assert.InDelta(t, 1000, paid, 0.001)
+
+	// Only the sale paid less than its total is a mismatch, until its total drops
+	mismatches, err := repo.Mismatches()
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, models.PaymentMismatch{SaleID: shortID, SaleDate: "2026-10-02", TotalPrice: 500, Paid: 200}, mismatches[0])
+
+	sale, err := store.Sales.GetByID(context.Background(), paidID)
+	require.NoError(t, err)
+	sale.TotalPrice = 900
+	require.NoError(t, store.Sales.Update(context.Background(), sale))
+	mismatches, err = repo.Mismatches()
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, paidID, mismatches[0].SaleID, "oldest first")
+	assert.InDelta(t, 1000, mismatches[0].Paid, 0.001)
+
+	// The balance of an installment sale is not a mismatch, an overage still is
+	require.NoError(t, repo.MarkInstallment(shortID))
+	require.NoError(t, repo.MarkInstallment(paidID))
+	mismatches, err = repo.Mismatches()
+	require.NoError(t, err)
+	require.Len(t, mismatches, 1)
+	assert.Equal(t, paidID, mismatches[0].SaleID)
+
+	sums, err := repo.PaidBySales([]string{paidID, shortID, "missing"})
+	require.NoError(t, err)
+	assert.Len(t, sums, 2, "sales without payments are left out")
+	assert.InDelta(t, 200, sums[shortID], 0.001)
+}
@@ -0,0 +1,816 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.SalesRepository = (*SalesRepository)(nil)
+
+// SalesRepository is an in-memory implementation of repositories.SalesRepository.
+// Selling a cab decrements the stock held by the cab and accessory repositories it was created with.
+type SalesRepository struct {
+	mu          sync.RWMutex
+	sales       map[string]models.Sale
+	items       map[string][]models.SaleItem
+	cabs        *CabsRepository
+	accessories *AccessoryRepository
+	voids       []models.DuplicateSaleVoid
+	refunds     map[string][]models.SaleRefund // By sale ID
+	lastID      int64
+}
+
+// NewSalesRepository creates an empty in-memory sales repository backed by the given inventory
+func NewSalesRepository(cabs *CabsRepository, accessories *AccessoryRepository) *SalesRepository {
+	return &SalesRepository{
+		sales:       make(map[string]models.Sale),
+		items:       make(map[string][]models.SaleItem),
+		refunds:     make(map[string][]models.SaleRefund),
+		cabs:        cabs,
+		accessories: accessories,
+	}
+}
+
+// GetAll returns the sales matching the filter, newest first
+func (r *SalesRepository) GetAll(ctx context.Context, filter models.SalesFilter) ([]models.Sale, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sales []models.Sale
+	for _, sale := range r.sales {
+		if filter.CustomerID != "" && sale.CustomerID != filter.CustomerID {
+			continue
+		}
+		if filter.SoldBy != "" && sale.SoldBy != filter.SoldBy {
+			continue
+		}
+		if filter.Status != "" && sale.Status != filter.Status {
+			continue
+		}
+		if filter.StartDate != "" && sale.SaleDate < filter.StartDate {
+			continue
+		}
+		if filter.EndDate != "" && sale.SaleDate > filter.EndDate {
+			continue
+		}
+		sales = append(sales, sale)
+	}
+	sort.Slice(sales, func(i, j int) bool {
+		if sales[i].CreatedAt.Equal(sales[j].CreatedAt) {
+			return sales[i].ID > sales[j].ID
+		}
+		return sales[i].CreatedAt.After(sales[j].CreatedAt)
+	})
+	return sales, nil
+}
+
+// GetPaginated returns one page of the filtered sales along with the total number of matches
+func (r *SalesRepository) GetPaginated(ctx context.Context, page, limit int, filter models.SalesFilter) ([]models.Sale, int64, error) {
+	sales, _ := r.GetAll(ctx, filter)
+	total := int64(len(sales))
+
+	offset := (page - 1) * limit
+	if offset < 0 || offset >= len(sales) {
+		return []models.Sale{}, total, nil
+	}
+	end := offset + limit
+	if end > len(sales) {
+		end = len(sales)
+	}
+	return sales[offset:end], total, nil
+}
+
+// GetByID retrieves a single sale by its ID
+func (r *SalesRepository) GetByID(ctx context.Context, id string) (*models.Sale, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sale, ok := r.sales[id]
+	if !ok {
+		return nil, fmt.Errorf("sale with ID %s not found", id)
+	}
+	return &sale, nil
+}
+
+// GetCustomerSales returns all sales of a customer
+func (r *SalesRepository) GetCustomerSales(ctx context.Context, customerID string) ([]models.Sale, error) {
+	return r.GetAll(ctx, models.SalesFilter{CustomerID: customerID})
+}
+
+// GetCustomerSaleItems returns the items of a customer's sales in the order they
+// were added, named after the cabs and accessories sold. Materials are not held
+// here and are left unnamed.
+func (r *SalesRepository) GetCustomerSaleItems(ctx context.Context, customerID string) ([]models.SaleItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := []models.SaleItem{}
+	for saleID, saleItems := range r.items {
+		if r.sales[saleID].CustomerID != customerID {
+			continue
+		}
+		for _, item := range saleItems {
+			switch item.ItemType() {
+			case models.RefCab:
+				if cab, err := r.cabs.GetCabByID(context.Background(), item.Item.IntID()); err == nil {
+					item.Name = cab.Name
+				}
+			case models.RefAccessory:
+				if accessory, err := r.accessories.GetByID(context.Background(), item.Item.IntID()); err == nil {
+					item.Name = accessory.Name
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].CreatedAt.Equal(items[j].CreatedAt) {
+			return items[i].ID < items[j].ID
+		}
+		return items[i].CreatedAt.Before(items[j].CreatedAt)
+	})
+	return items, nil
+}
+
+// Create stores a new sale and returns its ID
+func (r *SalesRepository) Create(ctx context.Context, sale *models.Sale) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if sale.ID == "" {
+		sale.ID = r.newID("sale")
+	}
+	if sale.Status == "" {
+		sale.Status = models.SaleConfirmed
+	}
+	sale.StockTaken = false // Nothing was taken out of stock
+	now := time.Now()
+	sale.CreatedAt = now
+	sale.UpdatedAt = now
+	r.sales[sale.ID] = *sale
+	return sale.ID, nil
+}
+
+// Update replaces the fields of an existing sale but its status
+func (r *SalesRepository) Update(ctx context.Context, sale *models.Sale) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.sales[sale.ID]
+	if !ok {
+		return fmt.Errorf("sale with ID %s not found for update", sale.ID)
+	}
+	sale.Status = existing.Status
+	sale.StockTaken = existing.StockTaken
+	sale.CreatedAt = existing.CreatedAt
+	sale.UpdatedAt = time.Now()
+	r.sales[sale.ID] = *sale
+	return nil
+}
+
+// Delete removes a sale together with its items
+func (r *SalesRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.sales[id]; !ok {
+		return fmt.Errorf("sale with ID %s not found for deletion", id)
+	}
+	delete(r.sales, id)
+	delete(r.items, id)
+	return nil
+}
+
+// SetStatus moves a sale along its workflow, putting its cabs and accessories back
+// in stock when that calls off a sale that took them
+func (r *SalesRepository) SetStatus(ctx context.Context, id, status string) error {
+	r.mu.Lock()
+	sale, ok := r.sales[id]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("sale with ID %s not found: %w", id, sql.ErrNoRows)
+	}
+	if !sale.CanTransition(status) {
+		r.mu.Unlock()
+		return fmt.Errorf("sale %s from %s to %s: %w", id, sale.CurrentStatus(), status, repositories.ErrSaleTransition)
+	}
+	restock := sale.RestocksOn(status)
+	sale.Status = status
+	sale.UpdatedAt = time.Now()
+	r.sales[id] = sale
+	var items []models.SaleItem
+	if restock {
+		items = r.unreturned(id)
+	}
+	r.mu.Unlock()
+
+	r.putBack(items)
+	return nil
+}
+
+// unreturned returns the items of a sale with the quantities refunds have not returned
+func (r *SalesRepository) unreturned(saleID string) []models.SaleItem {
+	refunded := r.refunded(saleID)
+	var items []models.SaleItem
+	for _, item := range r.items[saleID] {
+		if item.Quantity -= refunded[item.ID]; item.Quantity > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// refunded sums the units of each item of a sale that refunds returned, by sale item ID
+func (r *SalesRepository) refunded(saleID string) map[string]int {
+	refunded := make(map[string]int)
+	for _, refund := range r.refunds[saleID] {
+		for _, item := range refund.Items {
+			refunded[item.SaleItemID] += item.Quantity
+		}
+	}
+	return refunded
+}
+
+// putBack adds the quantities of the cab and accessory items to their stock
+func (r *SalesRepository) putBack(items []models.SaleItem) {
+	for _, item := range items {
+		switch item.Item.Type() {
+		case models.RefCab:
+			r.cabs.adjustQuantity(item.Item.IntID(), item.Quantity)
+		case models.RefAccessory:
+			r.accessories.adjustQuantity(item.Item.IntID(), item.Quantity)
+		}
+	}
+}
+
+// Refund records a refund of a confirmed or completed sale, puts the cabs and
+// accessories returned back in stock when the sale took them and refunds the sale
+// once nothing is left to return
+func (r *SalesRepository) Refund(ctx context.Context, refund *models.SaleRefund) error {
+	r.mu.Lock()
+	sale, ok := r.sales[refund.SaleID]
+	if !ok {
+		r.mu.Unlock()
+		return fmt.Errorf("sale with ID %s not found: %w", refund.SaleID, sql.ErrNoRows)
+	}
+	if !sale.CanTransition(models.SaleRefunded) {
+		r.mu.Unlock()
+		return fmt.Errorf("refund of %s sale %s: %w", sale.CurrentStatus(), refund.SaleID, repositories.ErrSaleTransition)
+	}
+	restock, all, err := repositories.PlanRefund(refund, r.items[refund.SaleID], r.refunded(refund.SaleID))
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	if refund.ID == "" {
+		refund.ID = r.newID("refund")
+	}
+	refund.RefundedAt = time.Now()
+	stored := *refund
+	stored.Items = slices.Clone(refund.Items)
+	r.refunds[refund.SaleID] = append(r.refunds[refund.SaleID], stored)
+	if all {
+		sale.Status = models.SaleRefunded
+		sale.UpdatedAt = refund.RefundedAt
+		r.sales[refund.SaleID] = sale
+	}
+	r.mu.Unlock()
+
+	if sale.StockTaken {
+		r.putBack(restock)
+	}
+	return nil
+}
+
+// GetRefunds returns the refunds of a sale, oldest first
+func (r *SalesRepository) GetRefunds(ctx context.Context, saleID string) ([]models.SaleRefund, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	refunds := make([]models.SaleRefund, 0, len(r.refunds[saleID]))
+	for _, refund := range r.refunds[saleID] {
+		refund.Items = slices.Clone(refund.Items)
+		refunds = append(refunds, refund)
+	}
+	return refunds, nil
+}
+
+// GetSaleItems returns the items of a sale
+func (r *SalesRepository) GetSaleItems(ctx context.Context, saleID string) ([]models.SaleItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var items []models.SaleItem
+	items = append(items, r.items[saleID]...)
+	return items, nil
+}
+
+// CreateSaleItem stores a new sale item and returns its ID
+func (r *SalesRepository) CreateSaleItem(ctx context.Context, item *models.SaleItem) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item.ID == "" {
+		item.ID = r.newID("item")
+	}
+	now := time.Now()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	r.items[item.SaleID] = append(r.items[item.SaleID], *item)
+	return item.ID, nil
+}
+
+// Sell stores a sale with its items, taking the cabs and accessories sold out of
+// stock when asked to, unless fewer units are in stock than are sold
+func (r *SalesRepository) Sell(ctx context.Context, sale *models.Sale, items []models.SaleItem, takeStock bool) error {
+	// The lock is held from checking stock to taking it, so two sales cannot both take the last unit
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if takeStock {
+		// Refuse the sale rather than take stock below zero, like the guarded updates of the database
+		cabs, accessories := make(map[int]int), make(map[int]int)
+		for _, item := range items {
+			switch item.Item.Type() {
+			case models.RefCab:
+				cabs[item.Item.IntID()] += item.Quantity
+			case models.RefAccessory:
+				accessories[item.Item.IntID()] += item.Quantity
+			}
+		}
+		for id, units := range cabs {
+			if cab, err := r.cabs.GetCabByID(ctx, id); err != nil || cab.Quantity < units {
+				return insufficientStock("cab", id, units)
+			}
+		}
+		for id, units := range accessories {
+			if accessory, err := r.accessories.GetByID(ctx, id); err != nil || accessory.Quantity < units {
+				return insufficientStock("accessory", id, units)
+			}
+		}
+	}
+
+	if sale.ID == "" {
+		sale.ID = r.newID("sale")
+	}
+	if sale.Status == "" {
+		sale.Status = models.SaleConfirmed
+	}
+	sale.StockTaken = takeStock
+	now := time.Now()
+	sale.CreatedAt, sale.UpdatedAt = now, now
+	for i := range items {
+		if items[i].ID == "" {
+			items[i].ID = r.newID("item")
+		}
+		items[i].SaleID, items[i].CreatedAt, items[i].UpdatedAt = sale.ID, now, now
+	}
+	r.sales[sale.ID] = *sale
+	r.items[sale.ID] = slices.Clone(items)
+
+	if takeStock {
+		for _, item := range items {
+			switch item.Item.Type() {
+			case models.RefCab:
+				r.cabs.adjustQuantity(item.Item.IntID(), -item.Quantity)
+			case models.RefAccessory:
+				r.accessories.adjustQuantity(item.Item.IntID(), -item.Quantity)
+			}
+		}
+	}
+	return nil
+}
+
+// SellCab records the sale of a cab with optional accessories and takes them out of stock
+func (r *SalesRepository) SellCab(ctx context.Context, cabID int, customerID string, quantity int, soldBy, taxType string, accessories []models.AccessoryForSale) (*models.Sale, error) {
+	// The lock is held from checking stock to taking it, as in Sell
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cab, err := r.cabs.GetCabByID(ctx, cabID)
+	if err != nil {
+		return nil, fmt.Errorf("cab with ID %d not found", cabID)
+	}
+
+	// Refuse the sale rather than take stock below zero, like the guarded updates of the database
+	if cab.Quantity < quantity {
+		return nil, insufficientStock("cab", cabID, quantity)
+	}
+	wanted := make(map[int]int)
+	for _, acc := range accessories {
+		wanted[acc.ID] += acc.Quantity
+	}
+	for id, units := range wanted {
+		if accessory, err := r.accessories.GetByID(ctx, id); err != nil || accessory.Quantity < units {
+			return nil, insufficientStock("accessory", id, units)
+		}
+	}
+
+	cabTotal := cab.Price * float64(quantity)
+	totalPrice := cabTotal
+	for _, acc := range accessories {
+		totalPrice += acc.Price * float64(acc.Quantity)
+	}
+
+	now := time.Now()
+	sale := models.Sale{
+		ID:         r.newID("sale"),
+		CustomerID: customerID,
+		SoldBy:     soldBy,
+		SaleDate:   now.Format("2006-01-02"),
+		TotalPrice: totalPrice,
+		TaxType:    taxType,
+		Status:     models.SaleConfirmed,
+		StockTaken: true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	sale.ApplyTax()
+	r.sales[sale.ID] = sale
+
+	items := []models.SaleItem{{
+		ID:        r.newID("item"),
+		SaleID:    sale.ID,
+		Item:      models.IntRef(models.RefCab, cabID),
+		Quantity:  quantity,
+		UnitPrice: cab.Price,
+		Subtotal:  cabTotal,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	for _, acc := range accessories {
+		items = append(items, models.SaleItem{
+			ID:        r.newID("item"),
+			SaleID:    sale.ID,
+			Item:      models.IntRef(models.RefAccessory, acc.ID),
+			Quantity:  acc.Quantity,
+			UnitPrice: acc.Price,
+			Subtotal:  acc.Price * float64(acc.Quantity),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	r.items[sale.ID] = items
+
+	for _, acc := range accessories {
+		r.accessories.adjustQuantity(acc.ID, -acc.Quantity)
+	}
+	r.cabs.adjustQuantity(cabID, -quantity)
+
+	return &sale, nil
+}
+
+// newID returns a unique ID in the timestamp-based format of the database implementation.
+// The caller must hold the write lock.
+func (r *SalesRepository) newID(prefix string) string {
+	next := time.Now().UnixNano()
+	if next <= r.lastID {
+		next = r.lastID + 1
+	}
+	r.lastID = next
+	return fmt.Sprintf("%s_%d", prefix, next)
+}
+
+// GetLeaderboard ranks sellers by their confirmed and completed sales between two sale
+// dates, by revenue, units sold, number of sales and user ID
+func (r *SalesRepository) GetLeaderboard(ctx context.Context, startDate, endDate string) ([]models.LeaderboardEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	bySeller := make(map[string]*models.LeaderboardEntry)
+	for _, sale := range r.sales {
+		if sale.SaleDate < startDate || sale.SaleDate > endDate || !sale.Counted() {
+			continue
+		}
+		entry, ok := bySeller[sale.SoldBy]
+		if !ok {
+			entry = &models.LeaderboardEntry{UserID: sale.SoldBy}
+			bySeller[sale.SoldBy] = entry
+		}
+		entry.Revenue += sale.TotalPrice
+		entry.SalesCount++
+		for _, item := range r.items[sale.ID] {
+			entry.UnitsSold += item.Quantity
+		}
+	}
+
+	entries := make([]models.LeaderboardEntry, 0, len(bySeller))
+	for _, entry := range bySeller {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		switch {
+		case a.Revenue != b.Revenue:
+			return a.Revenue > b.Revenue
+		case a.UnitsSold != b.UnitsSold:
+			return a.UnitsSold > b.UnitsSold
+		case a.SalesCount != b.SalesCount:
+			return a.SalesCount > b.SalesCount
+		}
+		return a.UserID < b.UserID
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries, nil
+}
+
+// GetBasketPairs counts how often accessories are sold together across the confirmed
+// and completed sales
+func (r *SalesRepository) GetBasketPairs(ctx context.Context) ([]models.BasketPair, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	itemSales := make(map[int]int)
+	pairSales := make(map[repositories.AccessoryPair]int)
+	totalSales := 0
+	for saleID, sale := range r.sales {
+		if !sale.Counted() {
+			continue
+		}
+		totalSales++
+		seen := make(map[int]bool)
+		accessories := []int{}
+		for _, item := range r.items[saleID] {
+			id := item.Item.IntID()
+			if item.ItemType() != models.RefAccessory || id == 0 || seen[id] {
+				continue
+			}
+			seen[id] = true
+			accessories = append(accessories, id)
+		}
+		for _, a := range accessories {
+			itemSales[a]++
+			for _, b := range accessories {
+				if a != b {
+					pairSales[repositories.AccessoryPair{a, b}]++
+				}
+			}
+		}
+	}
+	return repositories.BuildBasketPairs(totalSales, itemSales, pairSales), nil
+}
+
+// GetPivot totals a measure of the items of the confirmed and completed sales by two dimensions
+func (r *SalesRepository) GetPivot(ctx context.Context, rows, cols, measure, startDate, endDate string) ([]models.PivotCell, error) {
+	if !slices.Contains(models.PivotDimensions, rows) || !slices.Contains(models.PivotDimensions, cols) || !slices.Contains(models.PivotMeasures, measure) {
+		return nil, fmt.Errorf("unknown pivot dimension or measure: %s, %s, %s", rows, cols, measure)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	totals := make(map[[2]string]float64)
+	counted := make(map[[2]string]map[string]bool)
+	for _, sale := range r.sales {
+		if sale.SaleDate < startDate || sale.SaleDate > endDate || !sale.Counted() {
+			continue
+		}
+		for _, item := range r.items[sale.ID] {
+			key := [2]string{r.pivotValue(rows, sale, item), r.pivotValue(cols, sale, item)}
+			switch measure {
+			case models.PivotRevenue:
+				totals[key] += item.Subtotal
+			case models.PivotUnits:
+				totals[key] += float64(item.Quantity)
+			case models.PivotSales:
+				if counted[key] == nil {
+					counted[key] = make(map[string]bool)
+				}
+				if !counted[key][sale.ID] {
+					counted[key][sale.ID] = true
+					totals[key]++
+				}
+			}
+		}
+	}
+
+	cells := make([]models.PivotCell, 0, len(totals))
+	for key, value := range totals {
+		cells = append(cells, models.PivotCell{Row: key[0], Col: key[1], Value: value})
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Row != cells[j].Row {
+			return cells[i].Row < cells[j].Row
+		}
+		return cells[i].Col < cells[j].Col
+	})
+	return cells, nil
+}
+
+// GetSalesSummary totals the confirmed and completed sales between two sale dates by
+// day, week or month and ranks their best-selling cabs and accessories
+func (r *SalesRepository) GetSalesSummary(ctx context.Context, groupBy, startDate, endDate string, top int) (*models.SalesSummary, error) {
+	if groupBy != models.SummaryDay && groupBy != models.SummaryWeek && groupBy != models.SummaryMonth {
+		return nil, fmt.Errorf("unknown sales summary grouping: %s", groupBy)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	periods := make(map[string]*models.SalesSummaryPeriod)
+	sellers := map[string]map[string]*models.TopSeller{"cab": {}, "accessory": {}}
+	for _, sale := range r.sales {
+		if sale.SaleDate < startDate || sale.SaleDate > endDate || !sale.Counted() {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", sale.SaleDate)
+		if err != nil {
+			continue
+		}
+		switch groupBy {
+		case models.SummaryWeek:
+			day = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		case models.SummaryMonth:
+			day = day.AddDate(0, 0, 1-day.Day())
+		}
+		start := day.Format("2006-01-02")
+		period, ok := periods[start]
+		if !ok {
+			period = &models.SalesSummaryPeriod{StartDate: start}
+			periods[start] = period
+		}
+		period.SalesCount++
+		period.Revenue += sale.TotalPrice
+
+		for _, item := range r.items[sale.ID] {
+			period.UnitsSold += item.Quantity
+			itemID := item.Item.ID()
+			bySeller, ok := sellers[item.ItemType()]
+			if !ok || itemID == "" {
+				continue
+			}
+			seller, ok := bySeller[itemID]
+			if !ok {
+				seller = r.topSeller(item.ItemType(), itemID)
+				bySeller[itemID] = seller
+			}
+			seller.UnitsSold += item.Quantity
+			seller.Revenue += item.Subtotal
+		}
+	}
+
+	summary := &models.SalesSummary{Periods: make([]models.SalesSummaryPeriod, 0, len(periods))}
+	for _, period := range periods {
+		summary.Periods = append(summary.Periods, *period)
+	}
+	sort.Slice(summary.Periods, func(i, j int) bool { return summary.Periods[i].StartDate < summary.Periods[j].StartDate })
+	summary.TopCabs = rankSellers(sellers["cab"], top)
+	summary.TopAccessories = rankSellers(sellers["accessory"], top)
+	return summary, nil
+}
+
+// topSeller starts the ranking entry of a cab or accessory with its name and make
+func (r *SalesRepository) topSeller(itemType, itemID string) *models.TopSeller {
+	id, _ := strconv.Atoi(itemID)
+	seller := &models.TopSeller{ItemID: id}
+	switch itemType {
+	case "cab":
+		if cab, err := r.cabs.GetCabByID(context.Background(), id); err == nil {
+			seller.Name, seller.Make = cab.Name, cab.Make
+		}
+	case "accessory":
+		if accessory, err := r.accessories.GetByID(context.Background(), id); err == nil {
+			seller.Name, seller.Make = accessory.Name, string(accessory.Make)
+		}
+	}
+	return seller
+}
+
+// rankSellers orders items by units sold, then revenue, then ID, keeping the top ones
+func rankSellers(bySeller map[string]*models.TopSeller, top int) []models.TopSeller {
+	sellers := make([]models.TopSeller, 0, len(bySeller))
+	for _, seller := range bySeller {
+		sellers = append(sellers, *seller)
+	}
+	sort.Slice(sellers, func(i, j int) bool {
+		a, b := sellers[i], sellers[j]
+		switch {
+		case a.UnitsSold != b.UnitsSold:
+			return a.UnitsSold > b.UnitsSold
+		case a.Revenue != b.Revenue:
+			return a.Revenue > b.Revenue
+		}
+		return a.ItemID < b.ItemID
+	})
+	if len(sellers) > top {
+		sellers = sellers[:top]
+	}
+	return sellers
+}
+
+// pivotValue returns the value of a pivot dimension for a sale item
+func (r *SalesRepository) pivotValue(dimension string, sale models.Sale, item models.SaleItem) string {
+	switch dimension {
+	case models.PivotMake:
+		switch item.ItemType() {
+		case models.RefCab:
+			if id := item.Item.IntID(); id != 0 {
+				if cab, err := r.cabs.GetCabByID(context.Background(), id); err == nil {
+					return cab.Make
+				}
+			}
+		case models.RefAccessory:
+			if id := item.Item.IntID(); id != 0 {
+				if accessory, err := r.accessories.GetByID(context.Background(), id); err == nil {
+					return string(accessory.Make)
+				}
+			}
+		}
+		return ""
+	case models.PivotItemType:
+		return item.ItemType()
+	case models.PivotMonth:
+		return sale.SaleDate[:min(len(sale.SaleDate), 7)]
+	case models.PivotYear:
+		return sale.SaleDate[:min(len(sale.SaleDate), 4)]
+	case models.PivotSoldBy:
+		return sale.SoldBy
+	}
+	return sale.TaxType
+}
+
+// GetPossibleDuplicates groups the sales between two sale dates that are duplicates of each other
+func (r *SalesRepository) GetPossibleDuplicates(ctx context.Context, startDate, endDate string) ([]models.DuplicateSaleGroup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sales []models.Sale
+	for _, sale := range r.sales {
+		if sale.SaleDate >= startDate && sale.SaleDate <= endDate && !sale.Final() {
+			sales = append(sales, sale)
+		}
+	}
+	return repositories.GroupDuplicateSales(sales, r.items), nil
+}
+
+// VoidDuplicate deletes a sale and its items as a duplicate of another sale and records the void
+func (r *SalesRepository) VoidDuplicate(ctx context.Context, id, duplicateOf, voidedBy string) (*models.DuplicateSaleVoid, error) {
+	r.mu.Lock()
+	sale, ok := r.sales[id]
+	if !ok {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("sale with ID %s not found: %w", id, sql.ErrNoRows)
+	}
+	original, ok := r.sales[duplicateOf]
+	if !ok {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("sale with ID %s not found: %w", duplicateOf, sql.ErrNoRows)
+	}
+	for _, s := range []models.Sale{sale, original} {
+		if s.Final() {
+			r.mu.Unlock()
+			return nil, fmt.Errorf("void of sale %s as a duplicate of %s sale %s: %w", id, s.CurrentStatus(), s.ID, repositories.ErrSaleTransition)
+		}
+	}
+	if !repositories.IsDuplicateSale(sale, original, r.items[id], r.items[duplicateOf]) {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("sale %s of sale %s: %w", id, duplicateOf, repositories.ErrNotDuplicateSale)
+	}
+
+	void := models.DuplicateSaleVoid{
+		SaleID:      sale.ID,
+		DuplicateOf: original.ID,
+		CustomerID:  sale.CustomerID,
+		SaleDate:    sale.SaleDate,
+		TotalPrice:  sale.TotalPrice,
+		SoldBy:      sale.SoldBy,
+		VoidedBy:    voidedBy,
+		VoidedAt:    time.Now(),
+	}
+	var restock []models.SaleItem
+	if sale.RestocksOn(models.SaleCancelled) {
+		restock = r.unreturned(id)
+	}
+	r.voids = append(r.voids, void)
+	delete(r.sales, id)
+	delete(r.items, id)
+	r.mu.Unlock()
+
+	r.putBack(restock)
+	return &void, nil
+}
+
+// GetDuplicateVoids returns the voids of sales dated between two sale dates, most recently voided first
+func (r *SalesRepository) GetDuplicateVoids(ctx context.Context, startDate, endDate string) ([]models.DuplicateSaleVoid, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	voids := []models.DuplicateSaleVoid{}
+	for i := len(r.voids) - 1; i >= 0; i-- {
+		if r.voids[i].SaleDate >= startDate && r.voids[i].SaleDate <= endDate {
+			voids = append(voids, r.voids[i])
+		}
+	}
+	return voids, nil
+}
@@ -0,0 +1,600 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+	"testing"
+
+	"oop/internal/handlers"
+	"oop/internal/models"
+	"oop/internal/repositories"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ handlers.SaleRepository = (*memory.SalesRepository)(nil)
+
+func TestSalesRepositoryGetAll(t *testing.T) {
+	store := memory.NewStore()
+	for _, sale := range []models.Sale{
+		{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 100},
+		{CustomerID: "c-2", SoldBy: "u-1", SaleDate: "2025-02-10", TotalPrice: 200},
+		{CustomerID: "c-1", SoldBy: "u-2", SaleDate: "2025-03-10", TotalPrice: 300},
+	} {
+		sale := sale
+		_, err := store.Sales.Create(context.Background(), &sale)
+		require.NoError(t, err)
+	}
+
+	totals := func(sales []models.Sale) []float64 {
+		result := []float64{}
+		for _, sale := range sales {
+			result = append(result, sale.TotalPrice)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		filter models.SalesFilter
+		want   []float64
+	}{
+		{"No filters, newest first", models.SalesFilter{}, []float64{300, 200, 100}},
+		{"Customer", models.SalesFilter{CustomerID: "c-1"}, []float64{300, 100}},
+		{"Seller", models.SalesFilter{SoldBy: "u-1"}, []float64{200, 100}},
+		{"Date range", models.SalesFilter{StartDate: "2025-02-01", EndDate: "2025-03-10"}, []float64{300, 200}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sales, err := store.Sales.GetAll(context.Background(), tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, totals(sales))
+		})
+	}
+}
+
+func TestSalesRepositoryGetPaginated(t *testing.T) {
+	store := memory.NewStore()
+	for _, date := range []string{"2025-01-10", "2025-02-10", "2025-03-10"} {
+		_, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SaleDate: date, TotalPrice: 100})
+		require.NoError(t, err)
+	}
+
+	sales, total, err := store.Sales.GetPaginated(context.Background(), 1, 2, models.SalesFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, sales, 2)
+	assert.Equal(t, "2025-03-10", sales[0].SaleDate, "newest first")
+
+	sales, total, err = store.Sales.GetPaginated(context.Background(), 2, 2, models.SalesFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, sales, 1)
+	assert.Equal(t, "2025-01-10", sales[0].SaleDate)
+
+	sales, total, err = store.Sales.GetPaginated(context.Background(), 3, 2, models.SalesFilter{StartDate: "2025-02-01"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Empty(t, sales, "past the last page")
+}
+
+func TestSalesRepositoryCRUD(t *testing.T) {
+	store := memory.NewStore()
+
+	sale := &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 100}
+	id, err := store.Sales.Create(context.Background(), sale)
+	require.NoError(t, err)
+	assert.Contains(t, id, "sale_")
+
+	_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: id, Item: models.IntRef(models.RefMaterial, 3), Quantity: 2, UnitPrice: 50, Subtotal: 100})
+	require.NoError(t, err)
+
+	sale.TotalPrice = 120
+	require.NoError(t, store.Sales.Update(context.Background(), sale))
+	stored, err := store.Sales.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 120.0, stored.TotalPrice)
+
+	require.NoError(t, store.Sales.Delete(context.Background(), id))
+	items, err := store.Sales.GetSaleItems(context.Background(), id)
+	require.NoError(t, err)
+	assert.Empty(t, items, "items are deleted with the sale")
+
+	_, err = store.Sales.GetByID(context.Background(), id)
+	assert.EqualError(t, err, "sale with ID "+id+" not found")
+	assert.EqualError(t, store.Sales.Update(context.Background(), sale), "sale with ID "+id+" not found for update")
+	assert.EqualError(t, store.Sales.Delete(context.Background(), id), "sale with ID "+id+" not found for deletion")
+}
+
+func TestSalesRepositorySellCab(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 5, Price: 1000})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 4, Price: 100, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+
+	sale, err := store.Sales.SellCab(context.Background(), cab.ID, "c-1", 2, "u-1", "", []models.AccessoryForSale{{ID: accessoryID, Price: 100, Quantity: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, 2300.0, sale.TotalPrice)
+
+	items, err := store.Sales.GetSaleItems(context.Background(), sale.ID)
+	require.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, "cab", items[0].ItemType())
+		assert.Equal(t, "accessory", items[1].ItemType())
+		assert.NotEqual(t, items[0].ID, items[1].ID)
+	}
+
+	cab, err = store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, cab.Quantity)
+	accessory, err := store.Accessories.GetByID(ctx, accessoryID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, accessory.Quantity)
+	assert.Equal(t, models.StatusLowStock, accessory.Status)
+
+	_, err = store.Sales.SellCab(context.Background(), cab.ID, "c-1", 3, "u-1", "", nil)
+	require.NoError(t, err)
+	cab, err = store.Cabs.GetCabByID(context.Background(), cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, string(models.StatusOutOfStock), cab.Status, "selling the last cab takes it out of stock")
+}
+
+func TestSalesRepositorySellCabUnknownCab(t *testing.T) {
+	store := memory.NewStore()
+
+	_, err := store.Sales.SellCab(context.Background(), 42, "c-1", 1, "u-1", "", []models.AccessoryForSale{})
+	assert.EqualError(t, err, "cab with ID 42 not found")
+}
+
+func TestSalesRepositorySell(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 2, Price: 1000})
+	require.NoError(t, err)
+	items := func(quantity int) []models.SaleItem {
+		return []models.SaleItem{{Item: models.IntRef(models.RefCab, cab.ID), Quantity: quantity, UnitPrice: 1000, Subtotal: 1000 * float64(quantity)}}
+	}
+
+	err = store.Sales.Sell(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 3000}, items(3), true)
+	assert.ErrorIs(t, err, repositories.ErrNegativeStock)
+	sales, err := store.Sales.GetAll(ctx, models.SalesFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, sales, "nothing is recorded")
+
+	sale := &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 2000}
+	require.NoError(t, store.Sales.Sell(ctx, sale, items(2), true))
+	assert.Equal(t, models.SaleConfirmed, sale.Status)
+	stored, err := store.Sales.GetSaleItems(ctx, sale.ID)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, sale.ID, stored[0].SaleID)
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cab.Quantity)
+
+	// A sale recorded without taking stock leaves it alone, even when none is left
+	require.NoError(t, store.Sales.Sell(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2024-06-01", TotalPrice: 1000}, items(1), false))
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cab.Quantity)
+}
+
+func TestSalesRepositorySellConcurrently(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 1, Price: 1000})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			items := []models.SaleItem{{Item: models.IntRef(models.RefCab, cab.ID), Quantity: 1, UnitPrice: 1000, Subtotal: 1000}}
+			errs <- store.Sales.Sell(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 1000}, items, true)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	sold := 0
+	for err := range errs {
+		if err == nil {
+			sold++
+		} else {
+			assert.ErrorIs(t, err, repositories.ErrNegativeStock)
+		}
+	}
+	assert.Equal(t, 1, sold, "only one sale takes the last unit")
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cab.Quantity)
+}
+
+func TestSalesRepositorySetStatus(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 1, Price: 1000})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 2, Price: 100, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+
+	sale, err := store.Sales.SellCab(ctx, cab.ID, "c-1", 1, "u-1", "", []models.AccessoryForSale{{ID: accessoryID, Price: 100, Quantity: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, models.SaleConfirmed, sale.Status)
+
+	require.NoError(t, store.Sales.SetStatus(ctx, sale.ID, models.SaleCompleted))
+	require.NoError(t, store.Sales.SetStatus(ctx, sale.ID, models.SaleRefunded))
+	stored, err := store.Sales.GetByID(ctx, sale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SaleRefunded, stored.Status)
+
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.Quantity, "refunding the sale puts the cab back")
+	assert.Equal(t, string(models.StatusLowStock), cab.Status)
+	accessory, err := store.Accessories.GetByID(ctx, accessoryID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, accessory.Quantity)
+
+	assert.ErrorIs(t, store.Sales.SetStatus(ctx, sale.ID, models.SaleCancelled), repositories.ErrSaleTransition, "refunded sales are final")
+	assert.ErrorIs(t, store.Sales.SetStatus(ctx, "sale_404", models.SaleCompleted), sql.ErrNoRows)
+
+	// Drafts took nothing out of stock, so cancelling one puts nothing back
+	draftID, err := store.Sales.Create(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 1000, Status: models.SaleDraft})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(ctx, &models.SaleItem{SaleID: draftID, Item: models.IntRef(models.RefCab, cab.ID), Quantity: 1, UnitPrice: 1000, Subtotal: 1000})
+	require.NoError(t, err)
+	require.NoError(t, store.Sales.SetStatus(ctx, draftID, models.SaleCancelled))
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.Quantity)
+
+	// Neither did a confirmed sale recorded without taking stock
+	confirmedID, err := store.Sales.Create(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-11", TotalPrice: 1000, StockTaken: true})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(ctx, &models.SaleItem{SaleID: confirmedID, Item: models.IntRef(models.RefCab, cab.ID), Quantity: 1, UnitPrice: 1000, Subtotal: 1000})
+	require.NoError(t, err)
+	require.NoError(t, store.Sales.SetStatus(ctx, confirmedID, models.SaleCancelled))
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.Quantity, "the cab was never taken out of stock")
+}
+
+func TestSalesRepositoryRefund(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 1, Price: 1000})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 2, Price: 100, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+
+	sale, err := store.Sales.SellCab(ctx, cab.ID, "c-1", 1, "u-1", "", []models.AccessoryForSale{{ID: accessoryID, Price: 100, Quantity: 2}})
+	require.NoError(t, err)
+	require.NoError(t, store.Sales.SetStatus(ctx, sale.ID, models.SaleCompleted))
+
+	items, err := store.Sales.GetSaleItems(ctx, sale.ID)
+	require.NoError(t, err)
+	var accessoryItem models.SaleItem
+	for _, item := range items {
+		if item.Item.Type() == models.RefAccessory {
+			accessoryItem = item
+		}
+	}
+
+	// Returning one roof rack leaves the sale completed
+	refund := &models.SaleRefund{SaleID: sale.ID, Items: []models.SaleRefundItem{{SaleItemID: accessoryItem.ID, Quantity: 1}}, RefundedBy: "u-2"}
+	require.NoError(t, store.Sales.Refund(ctx, refund))
+	assert.Equal(t, 100.0, refund.Amount)
+	accessory, err := store.Accessories.GetByID(ctx, accessoryID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, accessory.Quantity)
+	stored, err := store.Sales.GetByID(ctx, sale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SaleCompleted, stored.Status)
+
+	err = store.Sales.Refund(ctx, &models.SaleRefund{SaleID: sale.ID, Items: []models.SaleRefundItem{{SaleItemID: accessoryItem.ID, Quantity: 2}}})
+	assert.ErrorIs(t, err, repositories.ErrRefundQuantity, "only one roof rack is left to return")
+
+	// Returning the rest refunds the sale
+	require.NoError(t, store.Sales.Refund(ctx, &models.SaleRefund{SaleID: sale.ID, RefundedBy: "u-2"}))
+	stored, err = store.Sales.GetByID(ctx, sale.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SaleRefunded, stored.Status)
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.Quantity)
+	accessory, err = store.Accessories.GetByID(ctx, accessoryID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, accessory.Quantity)
+
+	refunds, err := store.Sales.GetRefunds(ctx, sale.ID)
+	require.NoError(t, err)
+	require.Len(t, refunds, 2)
+	assert.Len(t, refunds[0].Items, 1)
+	assert.Len(t, refunds[1].Items, 2)
+	assert.Equal(t, 1200.0, refunds[0].Amount+refunds[1].Amount)
+}
+
+func TestSalesRepositoryRefundConfirmed(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 1, Price: 1000})
+	require.NoError(t, err)
+
+	// A cab sale is confirmed when recorded and can be refunded before it is completed
+	sale, err := store.Sales.SellCab(ctx, cab.ID, "c-1", 1, "u-1", "", nil)
+	require.NoError(t, err)
+	require.NoError(t, store.Sales.Refund(ctx, &models.SaleRefund{SaleID: sale.ID, RefundedBy: "u-2"}))
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.Quantity)
+
+	// A sale recorded without taking stock puts nothing back
+	saleID, err := store.Sales.Create(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 1000})
+	require.NoError(t, err)
+	_, err = store.Sales.CreateSaleItem(ctx, &models.SaleItem{SaleID: saleID, Item: models.IntRef(models.RefCab, cab.ID), Quantity: 1, UnitPrice: 1000, Subtotal: 1000})
+	require.NoError(t, err)
+	require.NoError(t, store.Sales.Refund(ctx, &models.SaleRefund{SaleID: saleID, RefundedBy: "u-2"}))
+	stored, err := store.Sales.GetByID(ctx, saleID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SaleRefunded, stored.Status)
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cab.Quantity)
+
+	// Drafts are cancelled, not refunded
+	draftID, err := store.Sales.Create(ctx, &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: "2025-01-10", TotalPrice: 1000, Status: models.SaleDraft})
+	require.NoError(t, err)
+	assert.ErrorIs(t, store.Sales.Refund(ctx, &models.SaleRefund{SaleID: draftID}), repositories.ErrSaleTransition)
+}
+
+func TestSalesRepositoryGetCustomerSaleItems(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Quantity: 2, Price: 500})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(ctx, models.NewAccessoryInput{Name: "Roof Rack", Make: models.MakeOEM, Quantity: 2, Price: 100, UnitColor: models.ColorBlack})
+	require.NoError(t, err)
+
+	_, err = store.Sales.SellCab(ctx, cab.ID, "c-1", 1, "u-1", "", []models.AccessoryForSale{{ID: accessoryID, Price: 100, Quantity: 1}})
+	require.NoError(t, err)
+	_, err = store.Sales.SellCab(ctx, cab.ID, "c-2", 1, "u-1", "", nil)
+	require.NoError(t, err)
+
+	items, err := store.Sales.GetCustomerSaleItems(ctx, "c-1")
+	require.NoError(t, err)
+	var names []string
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	assert.ElementsMatch(t, []string{"Carry", "Roof Rack"}, names, "only the items bought by the customer")
+
+	items, err = store.Sales.GetCustomerSaleItems(ctx, "c-3")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestSalesRepositoryGetLeaderboard(t *testing.T) {
+	store := memory.NewStore()
+	for _, sale := range []struct {
+		soldBy string
+		date   string
+		total  float64
+		units  int
+		status string
+	}{
+		{"u-1", "2025-03-03", 500, 1, models.SaleConfirmed},
+		{"u-2", "2025-03-04", 300, 2, models.SaleCompleted},
+		{"u-2", "2025-03-09", 200, 1, ""},
+		{"u-3", "2025-03-05", 500, 3, models.SaleConfirmed},
+		{"u-4", "2025-03-10", 900, 1, models.SaleConfirmed}, // Outside the range
+		{"u-1", "2025-03-06", 900, 1, models.SaleDraft},     // Never made
+		{"u-3", "2025-03-06", 900, 1, models.SaleCancelled}, // Undone
+	} {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{SoldBy: sale.soldBy, SaleDate: sale.date, TotalPrice: sale.total, Status: sale.status})
+		require.NoError(t, err)
+		_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: id, Item: models.IntRef(models.RefAccessory, 1), Quantity: sale.units})
+		require.NoError(t, err)
+	}
+
+	entries, err := store.Sales.GetLeaderboard(context.Background(), "2025-03-03", "2025-03-09")
+	require.NoError(t, err)
+	assert.Equal(t, []models.LeaderboardEntry{
+		{Rank: 1, UserID: "u-2", Revenue: 500, UnitsSold: 3, SalesCount: 2},
+		{Rank: 2, UserID: "u-3", Revenue: 500, UnitsSold: 3, SalesCount: 1},
+		{Rank: 3, UserID: "u-1", Revenue: 500, UnitsSold: 1, SalesCount: 1},
+	}, entries)
+}
+
+func TestSalesRepositoryGetBasketPairs(t *testing.T) {
+	store := memory.NewStore()
+	for _, accessories := range [][]string{
+		{"1", "2", "1"}, // An accessory listed twice counts once
+		{"1", "2"},
+		{"1", "3"},
+		{},
+	} {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{SoldBy: "u-1", SaleDate: "2025-03-03", TotalPrice: 100})
+		require.NoError(t, err)
+		_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: id, Item: models.IntRef(models.RefCab, 7), Quantity: 1})
+		require.NoError(t, err)
+		for _, accessoryID := range accessories {
+			_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: id, Item: models.NewRef(models.RefAccessory, accessoryID), Quantity: 1})
+			require.NoError(t, err)
+		}
+	}
+
+	pairs, err := store.Sales.GetBasketPairs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []models.BasketPair{
+		{AccessoryID: 2, PairedAccessoryID: 1, SalesTogether: 2, Support: 0.5, Confidence: 1},
+		{AccessoryID: 3, PairedAccessoryID: 1, SalesTogether: 1, Support: 0.25, Confidence: 1},
+		{AccessoryID: 1, PairedAccessoryID: 2, SalesTogether: 2, Support: 0.5, Confidence: 2.0 / 3},
+		{AccessoryID: 1, PairedAccessoryID: 3, SalesTogether: 1, Support: 0.25, Confidence: 1.0 / 3},
+	}, pairs)
+}
+
+func TestSalesRepositoryGetPivot(t *testing.T) {
+	store := memory.NewStore()
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Quantity: 5})
+	require.NoError(t, err)
+	cabID := strconv.Itoa(cab.ID)
+	for _, sale := range []struct {
+		date  string
+		items []models.SaleItem
+	}{
+		{"2025-03-03", []models.SaleItem{{Item: models.NewRef(models.RefCab, cabID), Quantity: 1, Subtotal: 500}, {Item: models.IntRef(models.RefMaterial, 4), Quantity: 2, Subtotal: 40}}},
+		{"2025-03-20", []models.SaleItem{{Item: models.NewRef(models.RefCab, cabID), Quantity: 2, Subtotal: 1000}}},
+		{"2025-04-02", []models.SaleItem{{Item: models.NewRef(models.RefCab, cabID), Quantity: 1, Subtotal: 450}}},
+		{"2025-05-01", []models.SaleItem{{Item: models.NewRef(models.RefCab, cabID), Quantity: 1, Subtotal: 450}}}, // Outside the range
+	} {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{SoldBy: "u-1", SaleDate: sale.date})
+		require.NoError(t, err)
+		for _, item := range sale.items {
+			item.SaleID = id
+			_, err = store.Sales.CreateSaleItem(context.Background(), &item)
+			require.NoError(t, err)
+		}
+	}
+
+	cells, err := store.Sales.GetPivot(context.Background(), models.PivotMake, models.PivotMonth, models.PivotSales, "2025-03-01", "2025-04-30")
+	require.NoError(t, err)
+	assert.Equal(t, []models.PivotCell{
+		{Row: "", Col: "2025-03", Value: 1},
+		{Row: "Suzuki", Col: "2025-03", Value: 2},
+		{Row: "Suzuki", Col: "2025-04", Value: 1},
+	}, cells)
+
+	cells, err = store.Sales.GetPivot(context.Background(), models.PivotItemType, models.PivotYear, models.PivotRevenue, "2025-03-01", "2025-04-30")
+	require.NoError(t, err)
+	assert.Equal(t, []models.PivotCell{
+		{Row: "cab", Col: "2025", Value: 1950},
+		{Row: "material", Col: "2025", Value: 40},
+	}, cells)
+
+	_, err = store.Sales.GetPivot(context.Background(), "customer", models.PivotMonth, models.PivotSales, "2025-03-01", "2025-04-30")
+	assert.Error(t, err)
+}
+
+func TestSalesRepositoryGetSalesSummary(t *testing.T) {
+	store := memory.NewStore()
+	cab, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Carry", Make: "Suzuki", UnitColor: "White", Quantity: 5})
+	require.NoError(t, err)
+	cabID := strconv.Itoa(cab.ID)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side Mirror", Make: "Toyota", Quantity: 9, Price: 100, UnitColor: "Black"})
+	require.NoError(t, err)
+	for _, sale := range []struct {
+		date  string
+		total float64
+		items []models.SaleItem
+	}{
+		{"2025-03-03", 500, []models.SaleItem{{Item: models.NewRef(models.RefCab, cabID), Quantity: 1, Subtotal: 500}}},
+		{"2025-03-09", 300, []models.SaleItem{{Item: models.IntRef(models.RefAccessory, accessoryID), Quantity: 3, Subtotal: 300}}},
+		{"2025-03-10", 1000, []models.SaleItem{{Item: models.NewRef(models.RefCab, cabID), Quantity: 2, Subtotal: 1000}, {Item: models.IntRef(models.RefCab, 99), Quantity: 3, Subtotal: 800}}},
+		{"2025-03-20", 40, nil}, // Outside the range
+	} {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{SoldBy: "u-1", SaleDate: sale.date, TotalPrice: sale.total})
+		require.NoError(t, err)
+		for _, item := range sale.items {
+			item.SaleID = id
+			_, err = store.Sales.CreateSaleItem(context.Background(), &item)
+			require.NoError(t, err)
+		}
+	}
+
+	summary, err := store.Sales.GetSalesSummary(context.Background(), models.SummaryWeek, "2025-03-01", "2025-03-16", 1)
+	require.NoError(t, err)
+	assert.Equal(t, []models.SalesSummaryPeriod{
+		{StartDate: "2025-03-03", SalesCount: 2, Revenue: 800, UnitsSold: 4},
+		{StartDate: "2025-03-10", SalesCount: 1, Revenue: 1000, UnitsSold: 5},
+	}, summary.Periods)
+	assert.Equal(t, []models.TopSeller{{ItemID: cab.ID, Name: "Carry", Make: "Suzuki", UnitsSold: 3, Revenue: 1500}}, summary.TopCabs, "ties on units go to the higher revenue")
+	assert.Equal(t, []models.TopSeller{{ItemID: accessoryID, Name: "Side Mirror", Make: "Toyota", UnitsSold: 3, Revenue: 300}}, summary.TopAccessories)
+
+	_, err = store.Sales.GetSalesSummary(context.Background(), "quarter", "2025-03-01", "2025-03-16", 1)
+	assert.Error(t, err)
+}
+
+func TestSalesRepositoryVoidDuplicate(t *testing.T) {
+	store := memory.NewStore()
+	addSale := func(date string, quantity int) string {
+		id, err := store.Sales.Create(context.Background(), &models.Sale{CustomerID: "c-1", SoldBy: "u-1", SaleDate: date, TotalPrice: 900})
+		require.NoError(t, err)
+		_, err = store.Sales.CreateSaleItem(context.Background(), &models.SaleItem{SaleID: id, Item: models.IntRef(models.RefAccessory, 3), Quantity: quantity, UnitPrice: 900 / float64(quantity)})
+		require.NoError(t, err)
+		return id
+	}
+	original := addSale("2025-03-04", 3)
+	duplicate := addSale("2025-03-04", 3)
+	otherItems := addSale("2025-03-04", 1)
+	otherDay := addSale("2025-03-05", 3)
+
+	groups, err := store.Sales.GetPossibleDuplicates(context.Background(), "2025-03-01", "2025-03-31")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "2025-03-04", groups[0].SaleDate)
+	require.Len(t, groups[0].Sales, 2)
+	assert.Equal(t, original, groups[0].Sales[0].ID)
+	assert.Equal(t, duplicate, groups[0].Sales[1].ID)
+
+	_, err = store.Sales.VoidDuplicate(context.Background(), otherItems, original, "admin-1")
+	assert.ErrorIs(t, err, repositories.ErrNotDuplicateSale)
+	_, err = store.Sales.VoidDuplicate(context.Background(), otherDay, original, "admin-1")
+	assert.ErrorIs(t, err, repositories.ErrNotDuplicateSale)
+	_, err = store.Sales.VoidDuplicate(context.Background(), original, original, "admin-1")
+	assert.ErrorIs(t, err, repositories.ErrNotDuplicateSale, "a sale is not a duplicate of itself")
+	_, err = store.Sales.VoidDuplicate(context.Background(), "sale-99", original, "admin-1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	cancelled := addSale("2025-03-04", 3)
+	require.NoError(t, store.Sales.SetStatus(context.Background(), cancelled, models.SaleCancelled))
+	_, err = store.Sales.VoidDuplicate(context.Background(), cancelled, original, "admin-1")
+	assert.ErrorIs(t, err, repositories.ErrSaleTransition, "cancelled sales cannot be voided")
+
+	void, err := store.Sales.VoidDuplicate(context.Background(), duplicate, original, "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.DuplicateSaleVoid{SaleID: duplicate, DuplicateOf: original, CustomerID: "c-1", SaleDate: "2025-03-04", TotalPrice: 900, SoldBy: "u-1", VoidedBy: "admin-1", VoidedAt: void.VoidedAt}, *void)
+	_, err = store.Sales.GetByID(context.Background(), duplicate)
+	assert.Error(t, err, "the duplicate is deleted")
+	items, err := store.Sales.GetSaleItems(context.Background(), duplicate)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	groups, err = store.Sales.GetPossibleDuplicates(context.Background(), "2025-03-01", "2025-03-31")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+	voids, err := store.Sales.GetDuplicateVoids(context.Background(), "2025-03-01", "2025-03-31")
+	require.NoError(t, err)
+	assert.Equal(t, []models.DuplicateSaleVoid{*void}, voids)
+	voids, err = store.Sales.GetDuplicateVoids(context.Background(), "2025-04-01", "2025-04-30")
+	require.NoError(t, err)
+	assert.Empty(t, voids)
+}
+
+func TestSalesRepositoryVoidDuplicateRestocks(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	cab, err := store.Cabs.AddCab(ctx, models.MultiCab{Name: "Scrum Wagon", Make: "Mazda", UnitColor: "White", Quantity: 3, Price: 1000})
+	require.NoError(t, err)
+
+	original, err := store.Sales.SellCab(ctx, cab.ID, "c-1", 1, "u-1", "", nil)
+	require.NoError(t, err)
+	duplicate, err := store.Sales.SellCab(ctx, cab.ID, "c-1", 1, "u-1", "", nil)
+	require.NoError(t, err)
+
+	_, err = store.Sales.VoidDuplicate(ctx, duplicate.ID, original.ID, "admin-1")
+	require.NoError(t, err)
+	cab, err = store.Cabs.GetCabByID(ctx, cab.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cab.Quantity, "the cab of the voided sale goes back in stock")
+}
@@ -0,0 +1,149 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.ShiftRepository = (*ShiftRepository)(nil)
+
+// ShiftRepository is an in-memory implementation of repositories.ShiftRepository over
+// the users of a user repository
+type ShiftRepository struct {
+	users *UserRepository
+
+	mu        sync.Mutex
+	schedules []models.ShiftSchedule
+	branches  map[string]string // By user ID
+	overrides []models.ShiftOverride
+}
+
+// NewShiftRepository creates a repository for the shifts of the given users
+func NewShiftRepository(users *UserRepository) *ShiftRepository {
+	return &ShiftRepository{users: users, branches: make(map[string]string)}
+}
+
+// copySchedule copies a schedule so callers cannot change the stored days
+func copySchedule(schedule models.ShiftSchedule) models.ShiftSchedule {
+	schedule.Days = append([]int{}, schedule.Days...)
+	return schedule
+}
+
+// GetSchedules returns the shift schedules ordered by role, branch and start time
+func (r *ShiftRepository) GetSchedules() ([]models.ShiftSchedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schedules := make([]models.ShiftSchedule, 0, len(r.schedules))
+	for _, schedule := range r.schedules {
+		schedules = append(schedules, copySchedule(schedule))
+	}
+	sort.SliceStable(schedules, func(i, j int) bool {
+		a, b := schedules[i], schedules[j]
+		switch {
+		case a.Role != b.Role:
+			return a.Role < b.Role
+		case a.Branch != b.Branch:
+			return a.Branch < b.Branch
+		}
+		return a.Start < b.Start
+	})
+	return schedules, nil
+}
+
+// ReplaceSchedules replaces every shift schedule, assigning their IDs
+func (r *ShiftRepository) ReplaceSchedules(schedules []models.ShiftSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schedules = make([]models.ShiftSchedule, 0, len(schedules))
+	for i := range schedules {
+		schedules[i].ID = uuid.New().String()
+		r.schedules = append(r.schedules, copySchedule(schedules[i]))
+	}
+	return nil
+}
+
+// userExists reports whether the user repository has a user
+func (r *ShiftRepository) userExists(userID string) bool {
+	r.users.mu.RLock()
+	defer r.users.mu.RUnlock()
+	_, ok := r.users.users[userID]
+	return ok
+}
+
+// GetBranch returns the shift branch of a user
+func (r *ShiftRepository) GetBranch(userID string) (string, error) {
+	if !r.userExists(userID) {
+		return "", fmt.Errorf("user %s not found: %w", userID, sql.ErrNoRows)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.branches[userID], nil
+}
+
+// SetBranch sets the shift branch of a user; "" clears it
+func (r *ShiftRepository) SetBranch(userID, branch string) error {
+	if !r.userExists(userID) {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if branch == "" {
+		delete(r.branches, userID)
+	} else {
+		r.branches[userID] = branch
+	}
+	return nil
+}
+
+// AddOverride records an override, assigning its ID and creation time
+func (r *ShiftRepository) AddOverride(override *models.ShiftOverride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	override.ID = uuid.New().String()
+	override.CreatedAt = time.Now()
+	r.overrides = append(r.overrides, *override)
+	return nil
+}
+
+// ActiveOverride returns the override of a user in effect at that expires last
+func (r *ShiftRepository) ActiveOverride(userID string, at time.Time) (*models.ShiftOverride, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var active *models.ShiftOverride
+	for _, override := range r.overrides {
+		if override.UserID != userID || !override.ExpiresAt.After(at) {
+			continue
+		}
+		if active == nil || override.ExpiresAt.After(active.ExpiresAt) {
+			found := override
+			active = &found
+		}
+	}
+	return active, nil
+}
+
+// ActiveOverrides returns the overrides in effect at, expiring soonest first
+func (r *ShiftRepository) ActiveOverrides(at time.Time) ([]models.ShiftOverride, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	active := []models.ShiftOverride{}
+	for _, override := range r.overrides {
+		if override.ExpiresAt.After(at) {
+			active = append(active, override)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool { return active[i].ExpiresAt.Before(active[j].ExpiresAt) })
+	return active, nil
+}
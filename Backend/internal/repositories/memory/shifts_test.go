@@ -0,0 +1,58 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShiftRepository(t *testing.T) {
+	store := memory.NewStore()
+	user := &models.User{Username: "clerk", Email: "clerk@example.com", Password: "secret", Role: "staff", IsActive: true}
+	require.NoError(t, store.Users.Create(context.Background(), user))
+
+	require.NoError(t, store.Shifts.ReplaceSchedules([]models.ShiftSchedule{
+		{Role: "staff", Branch: "CEBU", Start: "22:00", End: "06:00"},
+		{Role: "staff", Days: []int{1}, Start: "08:00", End: "17:00"},
+	}))
+	schedules, err := store.Shifts.GetSchedules()
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+	assert.Equal(t, "", schedules[0].Branch, "ordered by role, then branch")
+	schedules[0].Days[0] = 7
+	again, err := store.Shifts.GetSchedules()
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, again[0].Days, "stored schedules cannot be changed through results")
+
+	require.NoError(t, store.Shifts.SetBranch(user.Id, "CEBU"))
+	branch, err := store.Shifts.GetBranch(user.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "CEBU", branch)
+	assert.True(t, errors.Is(store.Shifts.SetBranch("missing", "CEBU"), sql.ErrNoRows))
+	_, err = store.Shifts.GetBranch("missing")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+
+	now := time.Now()
+	for _, hours := range []int{-1, 1, 3} {
+		require.NoError(t, store.Shifts.AddOverride(&models.ShiftOverride{UserID: user.Id, Reason: "Audit", GrantedBy: "admin-1", ExpiresAt: now.Add(time.Duration(hours) * time.Hour)}))
+	}
+	active, err := store.Shifts.ActiveOverride(user.Id, now)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.WithinDuration(t, now.Add(3*time.Hour), active.ExpiresAt, time.Second, "the override expiring last")
+	overrides, err := store.Shifts.ActiveOverrides(now)
+	require.NoError(t, err)
+	require.Len(t, overrides, 2, "expired overrides are left out")
+	assert.True(t, overrides[0].ExpiresAt.Before(overrides[1].ExpiresAt))
+	active, err = store.Shifts.ActiveOverride("someone-else", now)
+	require.NoError(t, err)
+	assert.Nil(t, active)
+}
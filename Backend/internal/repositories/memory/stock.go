@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"fmt"
+
+	"oop/internal/repositories"
+)
+
+// negativeQuantity is the error for setting an item's quantity below zero, which the
+// database refuses with a CHECK constraint
+func negativeQuantity(itemType string, quantity int) error {
+	return fmt.Errorf("%s quantity of %d: %w", itemType, quantity, repositories.ErrNegativeStock)
+}
+
+// insufficientStock is the error for taking more units of an item than are in stock
+func insufficientStock(itemType string, id, requested int) error {
+	return fmt.Errorf("%s %d has fewer than %d in stock: %w", itemType, id, requested, repositories.ErrNegativeStock)
+}
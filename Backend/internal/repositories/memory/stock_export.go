@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.StockExportRepository = (*StockExportRepository)(nil)
+
+// StockExportRepository is an in-memory implementation of repositories.StockExportRepository
+// reading the cab, accessory and material repositories
+type StockExportRepository struct {
+	cabs        *CabsRepository
+	accessories *AccessoryRepository
+	materials   *MaterialRepository
+}
+
+// NewStockExportRepository creates a stock export over the given repositories
+func NewStockExportRepository(cabs *CabsRepository, accessories *AccessoryRepository, materials *MaterialRepository) *StockExportRepository {
+	return &StockExportRepository{cabs: cabs, accessories: accessories, materials: materials}
+}
+
+// EachStock calls fn with the stock of each item of the type, ordered by ID
+func (r *StockExportRepository) EachStock(itemType string, fn func(models.StockRow) error) error {
+	var rows []models.StockRow
+	switch itemType {
+	case models.ExportCabs:
+		r.cabs.mu.RLock()
+		for _, cab := range r.cabs.cabs {
+			price := cab.Price
+			rows = append(rows, models.StockRow{ID: cab.ID, Name: cab.Name, Make: cab.Make, Quantity: cab.Quantity, Price: &price, Status: cab.Status})
+		}
+		r.cabs.mu.RUnlock()
+	case models.ExportAccessories:
+		r.accessories.mu.RLock()
+		for _, accessory := range r.accessories.accessories {
+			price := accessory.Price
+			rows = append(rows, models.StockRow{ID: accessory.ID, Name: accessory.Name, Make: string(accessory.Make), Quantity: accessory.Quantity,
+				Price: &price, Status: string(accessory.Status)})
+		}
+		r.accessories.mu.RUnlock()
+	case models.ExportMaterials:
+		r.materials.mu.RLock()
+		for _, material := range r.materials.materials {
+			rows = append(rows, models.StockRow{ID: material.ID, Name: material.Name, Quantity: material.Quantity, Status: material.Status})
+		}
+		r.materials.mu.RUnlock()
+	default:
+		return fmt.Errorf("unknown inventory type %q", itemType)
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].ID < rows[j].ID })
+	for _, row := range rows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStockExportRepository(t *testing.T) {
+	store := memory.NewStore()
+	for _, name := range []string{"Carry", "Scrum", "Every"} {
+		_, err := store.Cabs.AddCab(context.Background(), models.MultiCab{Name: name, Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 2, Price: 1000})
+		require.NoError(t, err)
+	}
+
+	var rows []models.StockRow
+	require.NoError(t, store.Stock.EachStock(models.ExportCabs, func(row models.StockRow) error {
+		rows = append(rows, row)
+		return nil
+	}))
+	require.Len(t, rows, 3)
+	assert.Equal(t, "Carry", rows[0].Name, "ordered by ID")
+	require.NotNil(t, rows[0].Price)
+	assert.Equal(t, 1000.0, *rows[0].Price)
+
+	assert.Error(t, store.Stock.EachStock("customers", func(models.StockRow) error { return nil }))
+}
@@ -0,0 +1,157 @@
+// Package memory provides in-memory implementations of the repositories, used by mock
+// mode and by tests that exercise handlers without a database or sqlmock expectations.
+// They mirror the behavior of the database implementations, including the error
+// messages handlers match on, but nothing is persisted.
+package memory
+
+import "oop/internal/models"
+
+// Store holds one in-memory repository per table. The sales repository shares the
+// cab and accessory repositories so selling a cab takes it out of stock, the
+// register session repository shares the deposit repository to find undeposited
+// sessions. The trash lists the records deleted from the customer, cab, accessory
+// and material repositories, and the dormancy repository tracks the sign-ins of the users.
+type Store struct {
+	Users         *UserRepository
+	Customers     *CustomerRepository
+	Cabs          *CabsRepository
+	Accessories   *AccessoryRepository
+	Materials     *MaterialRepository
+	Sales         *SalesRepository
+	Logs          *LogsRepository
+	Invites       *UserInviteRepository
+	Announcements *AnnouncementRepository
+	Tasks         *TaskRepository
+	Favorites     *FavoriteRepository
+	Views         *EntityViewRepository
+	Documents     *DocumentTemplateRepository
+	Registers     *RegisterSessionRepository
+	Expenses      *ExpenseRepository
+	Deposits      *DepositRepository
+	Receipts      *ReceiptSeriesRepository
+	Fiscal        *FiscalCalendarRepository
+	Snapshots     *InventorySnapshotRepository
+	Trash         *TrashRepository
+	Dormancy      *UserDormancyRepository
+	Integrations  *IntegrationRepository
+	Accounting    *AccountingPostingRepository
+	Sheets        *GoogleSheetsExportRepository
+	Calendars     *CalendarFeedRepository
+	Integrity     *IntegrityRepository
+	PriceChanges  *PriceChangeRepository
+	Changes       *ChangeRequestRepository
+	Images        *ItemImageRepository
+	RefreshTokens *RefreshTokenRepository
+	Exports       *ExportJobRepository
+	Anomalies     *AnomalyRepository
+	Stock         *StockExportRepository
+	Payments      *SalePaymentRepository
+	Shifts        *ShiftRepository
+	LegacyImports *LegacyImportRepository
+	Resets        *PasswordResetRepository
+	Consents      *CustomerConsentRepository
+	Registrations *CabRegistrationRepository
+	Insurance     *InsurancePolicyRepository
+	Numbers       *DisplayNumberRepository
+}
+
+// NewStore creates a store with empty repositories
+func NewStore() *Store {
+	users := NewUserRepository()
+	cabs := NewCabsRepository()
+	accessories := NewAccessoryRepository()
+	customers := NewCustomerRepository()
+	materials := NewMaterialRepository()
+	deposits := NewDepositRepository()
+	registers := NewRegisterSessionRepository()
+	registers.deposits = deposits
+	sales := NewSalesRepository(cabs, accessories)
+	logs := NewLogsRepository()
+
+	return &Store{
+		Users:         users,
+		Customers:     customers,
+		Cabs:          cabs,
+		Accessories:   accessories,
+		Materials:     materials,
+		Sales:         sales,
+		Logs:          logs,
+		Invites:       NewUserInviteRepository(),
+		Announcements: NewAnnouncementRepository(),
+		Tasks:         NewTaskRepository(),
+		Favorites:     NewFavoriteRepository(),
+		Views:         NewEntityViewRepository(),
+		Documents:     NewDocumentTemplateRepository(),
+		Registers:     registers,
+		Expenses:      NewExpenseRepository(),
+		Deposits:      deposits,
+		Receipts:      NewReceiptSeriesRepository(),
+		Fiscal:        NewFiscalCalendarRepository(),
+		Snapshots:     NewInventorySnapshotRepository(),
+		Trash:         NewTrashRepository(customers, cabs, accessories, materials),
+		Dormancy:      NewUserDormancyRepository(users),
+		Integrations:  NewIntegrationRepository(),
+		Accounting:    NewAccountingPostingRepository(),
+		Sheets:        NewGoogleSheetsExportRepository(),
+		Calendars:     NewCalendarFeedRepository(),
+		Integrity:     NewIntegrityRepository(sales, customers, cabs, accessories, materials),
+		PriceChanges:  NewPriceChangeRepository(),
+		Changes:       NewChangeRequestRepository(),
+		Images:        NewItemImageRepository(),
+		RefreshTokens: NewRefreshTokenRepository(),
+		Exports:       NewExportJobRepository(),
+		Anomalies:     NewAnomalyRepository(sales, logs),
+		Stock:         NewStockExportRepository(cabs, accessories, materials),
+		Payments:      NewSalePaymentRepository(sales),
+		Shifts:        NewShiftRepository(users),
+		LegacyImports: NewLegacyImportRepository(),
+		Resets:        NewPasswordResetRepository(),
+		Consents:      NewCustomerConsentRepository(),
+		Registrations: NewCabRegistrationRepository(),
+		Insurance:     NewInsurancePolicyRepository(),
+		Numbers:       NewDisplayNumberRepository(),
+	}
+}
+
+// usage counts the records in the store
+func (s *Store) usage() models.TenantUsage {
+	var usage models.TenantUsage
+
+	s.Users.mu.RLock()
+	usage.Users = len(s.Users.users)
+	for _, user := range s.Users.users {
+		if user.IsActive {
+			usage.ActiveUsers++
+		}
+	}
+	s.Users.mu.RUnlock()
+
+	s.Customers.mu.RLock()
+	usage.Customers = len(s.Customers.customers)
+	s.Customers.mu.RUnlock()
+
+	s.Cabs.mu.RLock()
+	usage.Cabs = len(s.Cabs.cabs)
+	s.Cabs.mu.RUnlock()
+
+	s.Accessories.mu.RLock()
+	usage.Accessories = len(s.Accessories.accessories)
+	s.Accessories.mu.RUnlock()
+
+	s.Materials.mu.RLock()
+	usage.Materials = len(s.Materials.materials)
+	s.Materials.mu.RUnlock()
+
+	s.Sales.mu.RLock()
+	usage.Sales = len(s.Sales.sales)
+	for _, sale := range s.Sales.sales {
+		usage.SalesTotal += sale.TotalPrice
+		if usage.LastSaleAt == nil || sale.CreatedAt.After(*usage.LastSaleAt) {
+			createdAt := sale.CreatedAt
+			usage.LastSaleAt = &createdAt
+		}
+	}
+	s.Sales.mu.RUnlock()
+
+	return usage
+}
@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.TaskRepository = (*TaskRepository)(nil)
+
+// TaskRepository is an in-memory implementation of repositories.TaskRepository
+type TaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[string]models.Task
+}
+
+// NewTaskRepository creates an empty in-memory task repository
+func NewTaskRepository() *TaskRepository {
+	return &TaskRepository{tasks: make(map[string]models.Task)}
+}
+
+// Create stores a new task
+func (r *TaskRepository) Create(task *models.Task) error {
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	now := time.Now()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks[task.ID] = *task
+	return nil
+}
+
+// GetByID retrieves a task by its ID
+func (r *TaskRepository) GetByID(id string) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, ok := r.tasks[id]
+	if !ok {
+		return nil, fmt.Errorf("task not found: %w", sql.ErrNoRows)
+	}
+	return &task, nil
+}
+
+// Update saves every editable field of a task
+func (r *TaskRepository) Update(task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tasks[task.ID]
+	if !ok {
+		return fmt.Errorf("task not found: %w", sql.ErrNoRows)
+	}
+	task.CreatedBy = existing.CreatedBy
+	task.CreatedAt = existing.CreatedAt
+	task.UpdatedAt = time.Now()
+	r.tasks[task.ID] = *task
+	return nil
+}
+
+// Delete removes a task
+func (r *TaskRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[id]; !ok {
+		return fmt.Errorf("task not found: %w", sql.ErrNoRows)
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+// GetAll returns the tasks matching the filter, soonest due first and tasks without a due date last
+func (r *TaskRepository) GetAll(filter models.TaskFilter) ([]models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := []models.Task{}
+	for _, task := range r.tasks {
+		if matchesTaskFilter(task, filter) {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		switch {
+		case a.DueDate == nil && b.DueDate == nil:
+			return a.CreatedAt.Before(b.CreatedAt)
+		case a.DueDate == nil || b.DueDate == nil:
+			return b.DueDate == nil
+		case !a.DueDate.Equal(*b.DueDate):
+			return a.DueDate.Before(*b.DueDate)
+		default:
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	})
+	return tasks, nil
+}
+
+func matchesTaskFilter(task models.Task, filter models.TaskFilter) bool {
+	if filter.AssigneeID != "" && task.AssigneeID != filter.AssigneeID {
+		return false
+	}
+	if filter.EntityType != "" && task.EntityType != filter.EntityType {
+		return false
+	}
+	if filter.EntityID != "" && task.EntityID != filter.EntityID {
+		return false
+	}
+	if filter.DueBefore != nil && (task.DueDate == nil || !task.DueDate.Before(*filter.DueBefore)) {
+		return false
+	}
+	if len(filter.Statuses) == 0 {
+		return true
+	}
+	for _, status := range filter.Statuses {
+		if task.Status == status {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package memory_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskRepository(t *testing.T) {
+	repo := memory.NewTaskRepository()
+	now := time.Now()
+	tomorrow := now.Add(24 * time.Hour)
+	yesterday := now.Add(-24 * time.Hour)
+
+	undated := &models.Task{Title: "Undated", AssigneeID: "user-1", Status: models.TaskStatusOpen}
+	later := &models.Task{Title: "Later", AssigneeID: "user-1", Status: models.TaskStatusOpen, DueDate: &tomorrow,
+		EntityType: models.TaskEntityCustomer, EntityID: "customer-1"}
+	late := &models.Task{Title: "Late", AssigneeID: "user-2", Status: models.TaskStatusInProgress, DueDate: &yesterday}
+	for _, task := range []*models.Task{undated, later, late} {
+		require.NoError(t, repo.Create(task))
+	}
+
+	all, err := repo.GetAll(models.TaskFilter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, []string{"Late", "Later", "Undated"}, []string{all[0].Title, all[1].Title, all[2].Title})
+
+	assigned, err := repo.GetAll(models.TaskFilter{AssigneeID: "user-1", Statuses: []string{models.TaskStatusOpen}})
+	require.NoError(t, err)
+	assert.Len(t, assigned, 2)
+
+	linked, err := repo.GetAll(models.TaskFilter{EntityType: models.TaskEntityCustomer, EntityID: "customer-1"})
+	require.NoError(t, err)
+	require.Len(t, linked, 1)
+	assert.Equal(t, later.ID, linked[0].ID)
+
+	overdue, err := repo.GetAll(models.TaskFilter{DueBefore: &now})
+	require.NoError(t, err)
+	require.Len(t, overdue, 1)
+	assert.Equal(t, late.ID, overdue[0].ID)
+
+	late.Status = models.TaskStatusDone
+	require.NoError(t, repo.Update(late))
+	stored, err := repo.GetByID(late.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.TaskStatusDone, stored.Status)
+
+	require.NoError(t, repo.Delete(late.ID))
+	_, err = repo.GetByID(late.ID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.ErrorIs(t, repo.Update(late), sql.ErrNoRows)
+}
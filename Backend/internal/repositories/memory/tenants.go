@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.TenantRepository = (*TenantRepository)(nil)
+
+// TenantRepository is an in-memory implementation of repositories.TenantRepository.
+// It also owns the store of every tenant, which is where usage is counted.
+type TenantRepository struct {
+	mu       sync.RWMutex
+	tenants  map[string]models.Tenant
+	stores   map[string]*Store
+	features map[string]map[string]bool
+}
+
+// NewTenantRepository creates an in-memory tenant repository holding only the
+// default tenant, matching the row the migration inserts
+func NewTenantRepository() *TenantRepository {
+	now := time.Now()
+	return &TenantRepository{tenants: map[string]models.Tenant{
+		models.DefaultTenantID: {
+			ID:        models.DefaultTenantID,
+			Slug:      models.DefaultTenantID,
+			Name:      "Default",
+			IsActive:  true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+	}, stores: map[string]*Store{}, features: map[string]map[string]bool{}}
+}
+
+// Store returns the store holding a tenant's data, creating an empty one on first use
+func (r *TenantRepository) Store(tenantID string) *Store {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	store, ok := r.stores[tenantID]
+	if !ok {
+		store = NewStore()
+		r.stores[tenantID] = store
+	}
+	return store
+}
+
+// SetStore replaces the store of a tenant, e.g. with one seeded with fixtures
+func (r *TenantRepository) SetStore(tenantID string, store *Store) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stores[tenantID] = store
+}
+
+// Create stores a new tenant. Slugs are unique, as in the database.
+func (r *TenantRepository) Create(tenant *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.tenants {
+		if existing.Slug == tenant.Slug {
+			return fmt.Errorf("failed to create tenant: slug %s already exists", tenant.Slug)
+		}
+	}
+
+	if tenant.ID == "" {
+		tenant.ID = uuid.New().String()
+	}
+	now := time.Now()
+	tenant.CreatedAt = now
+	tenant.UpdatedAt = now
+	r.tenants[tenant.ID] = *tenant
+	return nil
+}
+
+// GetByID retrieves a tenant by its ID
+func (r *TenantRepository) GetByID(id string) (*models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return nil, fmt.Errorf("tenant not found: %w", sql.ErrNoRows)
+	}
+	return &tenant, nil
+}
+
+// GetBySlug retrieves a tenant by the subdomain it is served on
+func (r *TenantRepository) GetBySlug(slug string) (*models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tenant := range r.tenants {
+		if tenant.Slug == slug {
+			return &tenant, nil
+		}
+	}
+	return nil, fmt.Errorf("tenant not found: %w", sql.ErrNoRows)
+}
+
+// GetAll returns all tenants, oldest first
+func (r *TenantRepository) GetAll() ([]models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]models.Tenant, 0, len(r.tenants))
+	for _, tenant := range r.tenants {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		if tenants[i].CreatedAt.Equal(tenants[j].CreatedAt) {
+			return tenants[i].ID == models.DefaultTenantID
+		}
+		return tenants[i].CreatedAt.Before(tenants[j].CreatedAt)
+	})
+	return tenants, nil
+}
+
+// Update saves the name and active state of a tenant
+func (r *TenantRepository) Update(tenant *models.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.tenants[tenant.ID]
+	if !ok {
+		return fmt.Errorf("tenant not found: %w", sql.ErrNoRows)
+	}
+	existing.Name = tenant.Name
+	existing.IsActive = tenant.IsActive
+	existing.UpdatedAt = time.Now()
+	r.tenants[tenant.ID] = existing
+	*tenant = existing
+	return nil
+}
+
+// Delete removes a tenant and its feature flags. Its store is kept, as the database keeps the rows.
+func (r *TenantRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[id]; !ok {
+		return fmt.Errorf("tenant not found: %w", sql.ErrNoRows)
+	}
+	delete(r.tenants, id)
+	delete(r.features, id)
+	return nil
+}
+
+// GetUsage counts the records in a tenant's store
+func (r *TenantRepository) GetUsage(id string) (*models.TenantUsage, error) {
+	usage := r.Store(id).usage()
+	usage.TenantID = id
+	return &usage, nil
+}
+
+// GetFeatures returns the feature flags stored for a tenant
+func (r *TenantRepository) GetFeatures(id string) (map[string]bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	features := map[string]bool{}
+	for feature, enabled := range r.features[id] {
+		features[feature] = enabled
+	}
+	return features, nil
+}
+
+// SetFeature stores a feature flag of a tenant
+func (r *TenantRepository) SetFeature(id, feature string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.features[id] == nil {
+		r.features[id] = map[string]bool{}
+	}
+	r.features[id][feature] = enabled
+	return nil
+}
@@ -0,0 +1,63 @@
+package memory_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRepository(t *testing.T) {
+	repo := memory.NewTenantRepository()
+
+	defaultTenant, err := repo.GetByID(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.True(t, defaultTenant.IsActive)
+
+	tenant := &models.Tenant{Slug: "acme", Name: "Acme Motors", IsActive: true}
+	require.NoError(t, repo.Create(tenant))
+	assert.NotEmpty(t, tenant.ID)
+
+	assert.Error(t, repo.Create(&models.Tenant{Slug: "acme", Name: "Other"}), "duplicate slug")
+
+	bySlug, err := repo.GetBySlug("acme")
+	require.NoError(t, err)
+	assert.Equal(t, tenant.ID, bySlug.ID)
+
+	_, err = repo.GetBySlug("missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	tenants, err := repo.GetAll()
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+	assert.Equal(t, models.DefaultTenantID, tenants[0].ID)
+}
+
+func TestTenantRepositoryUsageAndFeatures(t *testing.T) {
+	repo := memory.NewTenantRepository()
+	seeded, err := memory.NewSeededStore()
+	require.NoError(t, err)
+	repo.SetStore(models.DefaultTenantID, seeded)
+
+	usage, err := repo.GetUsage(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, usage.Users)
+	assert.Equal(t, 1, usage.Sales)
+	assert.NotNil(t, usage.LastSaleAt)
+
+	empty, err := repo.GetUsage("other")
+	require.NoError(t, err)
+	assert.Zero(t, empty.Users)
+
+	require.NoError(t, repo.SetFeature("other", "sso", false))
+	features, err := repo.GetFeatures("other")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"sso": false}, features)
+	features, err = repo.GetFeatures(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Empty(t, features)
+}
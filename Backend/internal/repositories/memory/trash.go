@@ -0,0 +1,239 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.TrashRepository = (*TrashRepository)(nil)
+
+// trashed is a soft-deleted record with when and by whom it was deleted
+type trashed[T any] struct {
+	record    T
+	deletedAt time.Time
+	deletedBy string
+}
+
+// deletedRecords returns the deleted records with IDs, most recently deleted first,
+// after finish fills in when each was deleted. The caller must hold the lock.
+func deletedRecords[T any](deleted map[int]trashed[T], finish func(record *T, deletedAt time.Time)) []T {
+	ids := make([]int, 0, len(deleted))
+	for id := range deleted {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := deleted[ids[i]], deleted[ids[j]]
+		if !a.deletedAt.Equal(b.deletedAt) {
+			return a.deletedAt.After(b.deletedAt)
+		}
+		return ids[i] < ids[j]
+	})
+
+	records := make([]T, 0, len(ids))
+	for _, id := range ids {
+		record := deleted[id].record
+		finish(&record, deleted[id].deletedAt)
+		records = append(records, record)
+	}
+	return records
+}
+
+// TrashRepository is an in-memory implementation of repositories.TrashRepository over
+// the deleted records of the customer, cab, accessory and material repositories
+type TrashRepository struct {
+	customers   *CustomerRepository
+	cabs        *CabsRepository
+	accessories *AccessoryRepository
+	materials   *MaterialRepository
+}
+
+// NewTrashRepository creates a trash over the deleted records of the given repositories
+func NewTrashRepository(customers *CustomerRepository, cabs *CabsRepository, accessories *AccessoryRepository, materials *MaterialRepository) *TrashRepository {
+	return &TrashRepository{customers: customers, cabs: cabs, accessories: accessories, materials: materials}
+}
+
+// SetDeletedBy records who deleted a record
+func (r *TrashRepository) SetDeletedBy(entityType, entityID, userID string) error {
+	switch entityType {
+	case models.TrashCustomer:
+		r.customers.mu.Lock()
+		defer r.customers.mu.Unlock()
+		if item, ok := r.customers.deleted[entityID]; ok {
+			item.deletedBy = userID
+			r.customers.deleted[entityID] = item
+		}
+	case models.TrashCab:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		r.cabs.mu.Lock()
+		defer r.cabs.mu.Unlock()
+		if item, ok := r.cabs.deleted[id]; ok {
+			item.deletedBy = userID
+			r.cabs.deleted[id] = item
+		}
+	case models.TrashAccessory:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		r.accessories.mu.Lock()
+		defer r.accessories.mu.Unlock()
+		if item, ok := r.accessories.deleted[id]; ok {
+			item.deletedBy = userID
+			r.accessories.deleted[id] = item
+		}
+	case models.TrashMaterial:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		r.materials.mu.Lock()
+		defer r.materials.mu.Unlock()
+		if item, ok := r.materials.deleted[id]; ok {
+			item.deletedBy = userID
+			r.materials.deleted[id] = item
+		}
+	default:
+		return fmt.Errorf("%q records are not kept in the trash", entityType)
+	}
+	return nil
+}
+
+// List returns the deleted records of a type, or of every type, most recently deleted first
+func (r *TrashRepository) List(entityType string, limit int) ([]models.TrashItem, error) {
+	switch entityType {
+	case "", models.TrashCustomer, models.TrashCab, models.TrashAccessory, models.TrashMaterial:
+	default:
+		return nil, fmt.Errorf("%q records are not kept in the trash", entityType)
+	}
+
+	items := []models.TrashItem{}
+	if entityType == "" || entityType == models.TrashCustomer {
+		r.customers.mu.RLock()
+		for id, item := range r.customers.deleted {
+			items = append(items, models.TrashItem{EntityType: models.TrashCustomer, EntityID: id, Name: item.record.FullName, DeletedAt: item.deletedAt, DeletedBy: item.deletedBy})
+		}
+		r.customers.mu.RUnlock()
+	}
+	if entityType == "" || entityType == models.TrashCab {
+		r.cabs.mu.RLock()
+		for id, item := range r.cabs.deleted {
+			items = append(items, models.TrashItem{EntityType: models.TrashCab, EntityID: strconv.Itoa(id), Name: item.record.Name, DeletedAt: item.deletedAt, DeletedBy: item.deletedBy})
+		}
+		r.cabs.mu.RUnlock()
+	}
+	if entityType == "" || entityType == models.TrashAccessory {
+		r.accessories.mu.RLock()
+		for id, item := range r.accessories.deleted {
+			items = append(items, models.TrashItem{EntityType: models.TrashAccessory, EntityID: strconv.Itoa(id), Name: item.record.Name, DeletedAt: item.deletedAt, DeletedBy: item.deletedBy})
+		}
+		r.accessories.mu.RUnlock()
+	}
+	if entityType == "" || entityType == models.TrashMaterial {
+		r.materials.mu.RLock()
+		for id, item := range r.materials.deleted {
+			items = append(items, models.TrashItem{EntityType: models.TrashMaterial, EntityID: strconv.Itoa(id), Name: item.record.Name, DeletedAt: item.deletedAt, DeletedBy: item.deletedBy})
+		}
+		r.materials.mu.RUnlock()
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// Restore clears the deletion of a record
+func (r *TrashRepository) Restore(entityType, entityID string) error {
+	switch entityType {
+	case models.TrashCustomer:
+		return r.customers.RestoreCustomer(context.Background(), entityID)
+	case models.TrashCab:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		return r.cabs.RestoreCab(context.Background(), id)
+	case models.TrashAccessory:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		return r.accessories.Restore(context.Background(), id)
+	case models.TrashMaterial:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		return r.materials.Restore(context.Background(), id)
+	}
+	return fmt.Errorf("%q records are not kept in the trash", entityType)
+}
+
+// Purge forgets a deleted record
+func (r *TrashRepository) Purge(entityType, entityID string) error {
+	switch entityType {
+	case models.TrashCustomer:
+		r.customers.mu.Lock()
+		defer r.customers.mu.Unlock()
+		if _, ok := r.customers.deleted[entityID]; ok {
+			delete(r.customers.deleted, entityID)
+			return nil
+		}
+	case models.TrashCab:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		r.cabs.mu.Lock()
+		defer r.cabs.mu.Unlock()
+		if _, ok := r.cabs.deleted[id]; ok {
+			delete(r.cabs.deleted, id)
+			return nil
+		}
+	case models.TrashAccessory:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		r.accessories.mu.Lock()
+		defer r.accessories.mu.Unlock()
+		if _, ok := r.accessories.deleted[id]; ok {
+			delete(r.accessories.deleted, id)
+			return nil
+		}
+	case models.TrashMaterial:
+		id, err := trashIntID(entityType, entityID)
+		if err != nil {
+			return err
+		}
+		r.materials.mu.Lock()
+		defer r.materials.mu.Unlock()
+		if _, ok := r.materials.deleted[id]; ok {
+			delete(r.materials.deleted, id)
+			return nil
+		}
+	default:
+		return fmt.Errorf("%q records are not kept in the trash", entityType)
+	}
+	return fmt.Errorf("deleted %s %s not found: %w", entityType, entityID, sql.ErrNoRows)
+}
+
+// trashIntID parses the ID of a cab, accessory or material. IDs that are not numbers
+// match no record, like in the database.
+func trashIntID(entityType, entityID string) (int, error) {
+	id, err := strconv.Atoi(entityID)
+	if err != nil {
+		return 0, fmt.Errorf("deleted %s %s not found: %w", entityType, entityID, sql.ErrNoRows)
+	}
+	return id, nil
+}
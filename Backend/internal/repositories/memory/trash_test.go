@@ -0,0 +1,57 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashRepository(t *testing.T) {
+	store := memory.NewStore()
+	customer, err := store.Customers.CreateCustomer(context.Background(), &models.Customer{FullName: "Juan Dela Cruz", Email: "juan@example.com"})
+	require.NoError(t, err)
+	materialID, err := store.Materials.Create(context.Background(), &models.Material{Name: "Paint", Quantity: 3})
+	require.NoError(t, err)
+	accessoryID, err := store.Accessories.Create(context.Background(), models.NewAccessoryInput{Name: "Side Mirror", Make: "Toyota", Quantity: 2, Price: 500, UnitColor: "Black"})
+	require.NoError(t, err)
+
+	require.NoError(t, store.Customers.DeleteCustomer(context.Background(), customer.ID))
+	require.NoError(t, store.Trash.SetDeletedBy(models.TrashCustomer, customer.ID, "user-1"))
+	require.NoError(t, store.Materials.Delete(context.Background(), materialID))
+	require.NoError(t, store.Accessories.Delete(context.Background(), accessoryID))
+
+	items, err := store.Trash.List("", 10)
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.Equal(t, models.TrashAccessory, items[0].EntityType, "most recently deleted first")
+	assert.Equal(t, "Juan Dela Cruz", items[2].Name)
+	assert.Equal(t, "user-1", items[2].DeletedBy)
+
+	items, err = store.Trash.List(models.TrashMaterial, 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, strconv.Itoa(materialID), items[0].EntityID)
+	items, err = store.Trash.List("", 1)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	require.NoError(t, store.Trash.Restore(models.TrashCustomer, customer.ID))
+	_, err = store.Customers.GetCustomerByID(context.Background(), customer.ID)
+	assert.NoError(t, err)
+	require.NoError(t, store.Trash.Purge(models.TrashMaterial, strconv.Itoa(materialID)))
+	assert.True(t, errors.Is(store.Trash.Restore(models.TrashMaterial, strconv.Itoa(materialID)), sql.ErrNoRows), "purged records are gone")
+	assert.True(t, errors.Is(store.Trash.Purge(models.TrashCustomer, customer.ID), sql.ErrNoRows), "restored records are not in the trash")
+
+	items, err = store.Trash.List("", 10)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, strconv.Itoa(accessoryID), items[0].EntityID)
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+)
+
+var _ repositories.UserDormancyRepository = (*UserDormancyRepository)(nil)
+
+// UserDormancyRepository is an in-memory implementation of
+// repositories.UserDormancyRepository over the users of a user repository
+type UserDormancyRepository struct {
+	users *UserRepository
+
+	mu          sync.Mutex
+	lastLogin   map[string]time.Time
+	reactivated map[string]time.Time
+	exempt      map[string]bool
+}
+
+// NewUserDormancyRepository creates a repository tracking the sign-ins of the given users
+func NewUserDormancyRepository(users *UserRepository) *UserDormancyRepository {
+	return &UserDormancyRepository{users: users, lastLogin: make(map[string]time.Time), reactivated: make(map[string]time.Time), exempt: make(map[string]bool)}
+}
+
+// RecordLogin stores when a user signed in
+func (r *UserDormancyRepository) RecordLogin(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastLogin[userID] = at
+	return nil
+}
+
+// RecordReactivation stores when a user was reactivated
+func (r *UserDormancyRepository) RecordReactivation(userID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reactivated[userID] = at
+	return nil
+}
+
+// SetExempt sets whether a user is exempt from the dormant account policy
+func (r *UserDormancyRepository) SetExempt(userID string, exempt bool) error {
+	r.users.mu.RLock()
+	user, ok := r.users.users[userID]
+	r.users.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.exempt[user.Id] = exempt
+	return nil
+}
+
+// FindDormant returns the active users inactive since cutoff, least recently active first
+func (r *UserDormancyRepository) FindDormant(cutoff time.Time) ([]models.UserDormancy, error) {
+	r.users.mu.RLock()
+	defer r.users.mu.RUnlock()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	dormant := []models.UserDormancy{}
+	for _, user := range r.users.users {
+		if !user.IsActive {
+			continue
+		}
+		activity := models.UserDormancy{
+			UserID:    user.Id,
+			Username:  user.Username,
+			Email:     user.Email,
+			Role:      user.Role,
+			CreatedAt: user.CreatedAt,
+			Exempt:    r.exempt[user.Id],
+		}
+		if at, ok := r.lastLogin[user.Id]; ok {
+			activity.LastLoginAt = &at
+		}
+		if at, ok := r.reactivated[user.Id]; ok {
+			activity.ReactivatedAt = &at
+		}
+		if activity.LastActiveAt().Before(cutoff) {
+			dormant = append(dormant, activity)
+		}
+	}
+	sort.Slice(dormant, func(i, j int) bool { return dormant[i].LastActiveAt().Before(dormant[j].LastActiveAt()) })
+	return dormant, nil
+}
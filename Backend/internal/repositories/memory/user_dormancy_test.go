@@ -0,0 +1,42 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserDormancyRepository(t *testing.T) {
+	store := memory.NewStore()
+	newUser := func(username string) *models.User {
+		user := &models.User{Username: username, Email: username + "@example.com", Password: "secret", Role: "staff", IsActive: true}
+		require.NoError(t, store.Users.Create(context.Background(), user))
+		return user
+	}
+	never := newUser("never")
+	recent := newUser("recent")
+	reactivated := newUser("reactivated")
+	require.NoError(t, store.Users.DeactivateUser(context.Background(), newUser("inactive").Id))
+
+	cutoff := time.Now().Add(time.Minute)
+	require.NoError(t, store.Dormancy.RecordLogin(recent.Id, cutoff.Add(time.Hour)))
+	require.NoError(t, store.Dormancy.RecordLogin(reactivated.Id, cutoff.AddDate(-1, 0, 0)))
+	require.NoError(t, store.Dormancy.RecordReactivation(reactivated.Id, cutoff.Add(time.Hour)))
+	require.NoError(t, store.Dormancy.SetExempt(never.Id, true))
+	assert.True(t, errors.Is(store.Dormancy.SetExempt("missing", true), sql.ErrNoRows))
+
+	dormant, err := store.Dormancy.FindDormant(cutoff)
+	require.NoError(t, err)
+	require.Len(t, dormant, 1, "recent logins, reactivations and inactive accounts are left out")
+	assert.Equal(t, never.Id, dormant[0].UserID)
+	assert.Nil(t, dormant[0].LastLoginAt)
+	assert.True(t, dormant[0].Exempt)
+}
@@ -0,0 +1,82 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/google/uuid"
+)
+
+var _ repositories.UserInviteRepository = (*UserInviteRepository)(nil)
+
+// UserInviteRepository is an in-memory implementation of repositories.UserInviteRepository
+type UserInviteRepository struct {
+	mu      sync.RWMutex
+	invites map[string]models.UserInvite
+}
+
+// NewUserInviteRepository creates an empty in-memory invite repository
+func NewUserInviteRepository() *UserInviteRepository {
+	return &UserInviteRepository{invites: make(map[string]models.UserInvite)}
+}
+
+// Create stores a new invitation
+func (r *UserInviteRepository) Create(invite *models.UserInvite) error {
+	if invite.ID == "" {
+		invite.ID = uuid.New().String()
+	}
+	invite.CreatedAt = time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invites[invite.ID] = *invite
+	return nil
+}
+
+// GetPending returns all invitations that have not been accepted yet, newest first
+func (r *UserInviteRepository) GetPending() ([]models.UserInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invites := []models.UserInvite{}
+	for _, invite := range r.invites {
+		if invite.AcceptedAt == nil {
+			invites = append(invites, invite)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].CreatedAt.After(invites[j].CreatedAt) })
+	return invites, nil
+}
+
+// GetByTokenHash retrieves an invitation by the hash of its setup token
+func (r *UserInviteRepository) GetByTokenHash(tokenHash string) (*models.UserInvite, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, invite := range r.invites {
+		if invite.TokenHash == tokenHash {
+			return &invite, nil
+		}
+	}
+	return nil, fmt.Errorf("invite not found")
+}
+
+// MarkAccepted records that an invitation has been used. An invitation can only be accepted once.
+func (r *UserInviteRepository) MarkAccepted(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invite, ok := r.invites[id]
+	if !ok || invite.AcceptedAt != nil {
+		return fmt.Errorf("invite not found or already accepted")
+	}
+	now := time.Now()
+	invite.AcceptedAt = &now
+	r.invites[invite.ID] = invite
+	return nil
+}
@@ -0,0 +1,221 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserRepository is an in-memory implementation of handlers.UserRepository.
+// Passwords are bcrypt-hashed like in the database implementation.
+type UserRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+// NewUserRepository creates an empty in-memory user repository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: make(map[string]models.User)}
+}
+
+// Create stores a new user, hashing its password
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	if user.Id == "" {
+		user.Id = uuid.New().String()
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	if user.Role == "" {
+		user.Role = "user"
+	}
+	user.IsActive = true
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return fmt.Errorf("failed to create user: duplicate email %s", user.Email)
+		}
+	}
+
+	stored := *user
+	stored.Password = string(hashedPassword)
+	r.users[user.Id] = stored
+	return nil
+}
+
+// GetByID retrieves a user by their ID
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	return &user, nil
+}
+
+// GetByEmail retrieves a user by their email address
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Email == email })
+}
+
+// GetByUsername retrieves a user by their username
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.find(func(u models.User) bool { return u.Username == username })
+}
+
+// find returns the first user matching the predicate
+func (r *UserRepository) find(match func(models.User) bool) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if match(user) {
+			return &user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found: %w", sql.ErrNoRows)
+}
+
+// Update stores the profile fields of an existing user; the password is left unchanged
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.users[user.Id]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+
+	user.UpdatedAt = time.Now()
+	existing.Username = user.Username
+	existing.FullName = user.FullName
+	existing.Email = user.Email
+	existing.Role = user.Role
+	existing.IsActive = user.IsActive
+	existing.UpdatedAt = user.UpdatedAt
+	r.users[user.Id] = existing
+	return nil
+}
+
+// UpdatePassword hashes and stores a new password
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID string, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return fmt.Errorf("user not found")
+	}
+	user.Password = string(hashedPassword)
+	user.UpdatedAt = time.Now()
+	r.users[userID] = user
+	return nil
+}
+
+// Delete removes a user
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return fmt.Errorf("user not found")
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// GetAll returns every user, newest first
+func (r *UserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]*models.User, 0, len(r.users))
+	for _, user := range r.users {
+		user := user
+		users = append(users, &user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+	return users, nil
+}
+
+// VerifyPassword checks the password of the user with the given email or username
+func (r *UserRepository) VerifyPassword(ctx context.Context, identifier, password string) (*models.User, error) {
+	user, err := r.GetByEmail(ctx, identifier)
+	if err != nil {
+		user, err = r.GetByUsername(ctx, identifier)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, fmt.Errorf("invalid password")
+	}
+	return user, nil
+}
+
+// ActivateUser sets a user's status to active
+func (r *UserRepository) ActivateUser(ctx context.Context, id string) error {
+	return r.setActive(id, true)
+}
+
+// DeactivateUser sets a user's status to inactive
+func (r *UserRepository) DeactivateUser(ctx context.Context, id string) error {
+	return r.setActive(id, false)
+}
+
+// setActive updates the active flag; unknown IDs are ignored like the UPDATE in the database implementation
+func (r *UserRepository) setActive(id string, active bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, ok := r.users[id]; ok {
+		user.IsActive = active
+		user.UpdatedAt = time.Now()
+		r.users[user.Id] = user
+	}
+	return nil
+}
+
+// EmailExists checks if an email is already registered
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	return err == nil, nil
+}
+
+// UsernameExists checks if a username is already taken
+func (r *UserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	_, err := r.GetByUsername(ctx, username)
+	return err == nil, nil
+}
+
+// FindByEmailOrUsernameConstantTime finds a user by email or username
+func (r *UserRepository) FindByEmailOrUsernameConstantTime(ctx context.Context, identifier string) (*models.User, error) {
+	return r.find(func(u models.User) bool {
+		return u.Email == identifier || (u.Username != "" && u.Username == identifier)
+	})
+}
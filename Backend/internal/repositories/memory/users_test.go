@@ -0,0 +1,98 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"oop/internal/handlers"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _ handlers.UserRepository = (*memory.UserRepository)(nil)
+
+func TestUserRepositoryCreate(t *testing.T) {
+	repo := memory.NewUserRepository()
+
+	user := &models.User{Username: "jane", FullName: "Jane Doe", Email: "jane@example.com", Password: "secret123"}
+	require.NoError(t, repo.Create(context.Background(), user))
+	assert.NotEmpty(t, user.Id)
+	assert.Equal(t, "user", user.Role)
+	assert.True(t, user.IsActive)
+
+	stored, err := repo.GetByID(context.Background(), user.Id)
+	require.NoError(t, err)
+	assert.NotEqual(t, "secret123", stored.Password, "password must be stored hashed")
+
+	err = repo.Create(context.Background(), &models.User{Username: "jane2", Email: "jane@example.com", Password: "secret123"})
+	assert.Error(t, err, "duplicate email")
+
+	exists, err := repo.EmailExists(context.Background(), "jane@example.com")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = repo.UsernameExists(context.Background(), "nobody")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestUserRepositoryVerifyPassword(t *testing.T) {
+	repo := memory.NewUserRepository()
+	require.NoError(t, repo.Create(context.Background(), &models.User{Username: "jane", Email: "jane@example.com", Password: "secret123"}))
+
+	tests := []struct {
+		name       string
+		identifier string
+		password   string
+		wantErr    string
+	}{
+		{"By email", "jane@example.com", "secret123", ""},
+		{"By username", "jane", "secret123", ""},
+		{"Wrong password", "jane", "wrong", "invalid password"},
+		{"Unknown user", "john", "secret123", "user not found: sql: no rows in result set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := repo.VerifyPassword(context.Background(), tt.identifier, tt.password)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "jane@example.com", user.Email)
+		})
+	}
+}
+
+func TestUserRepositoryUpdate(t *testing.T) {
+	repo := memory.NewUserRepository()
+	user := &models.User{Username: "jane", Email: "jane@example.com", Password: "secret123"}
+	require.NoError(t, repo.Create(context.Background(), user))
+
+	user.FullName = "Jane Smith"
+	user.Role = "staff"
+	require.NoError(t, repo.Update(context.Background(), user))
+
+	require.NoError(t, repo.UpdatePassword(context.Background(), user.Id, "newsecret456"))
+	_, err := repo.VerifyPassword(context.Background(), "jane", "newsecret456")
+	assert.NoError(t, err)
+
+	require.NoError(t, repo.DeactivateUser(context.Background(), user.Id))
+	stored, err := repo.GetByID(context.Background(), user.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Smith", stored.FullName)
+	assert.Equal(t, "staff", stored.Role)
+	assert.False(t, stored.IsActive)
+
+	assert.EqualError(t, repo.Update(context.Background(), &models.User{Id: "missing"}), "user not found")
+	assert.EqualError(t, repo.UpdatePassword(context.Background(), "missing", "x"), "user not found")
+	assert.EqualError(t, repo.Delete(context.Background(), "missing"), "user not found")
+
+	require.NoError(t, repo.Delete(context.Background(), user.Id))
+	_, err = repo.GetByEmail(context.Background(), "jane@example.com")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.EqualError(t, err, "user not found: sql: no rows in result set")
+}
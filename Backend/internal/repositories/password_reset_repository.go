@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// PasswordResetRepository defines the interface for the one-time tokens of password reset links.
+type PasswordResetRepository interface {
+	// Create stores a token, spending the user's earlier unused tokens so only the
+	// newest link works.
+	Create(token *models.PasswordResetToken) error
+	// GetByTokenHash returns the token with the hash, or an error wrapping sql.ErrNoRows.
+	GetByTokenHash(tokenHash string) (*models.PasswordResetToken, error)
+	// Use records that a token was used. It returns an error wrapping sql.ErrNoRows when
+	// the token was already used, so two requests with the same token cannot both succeed.
+	Use(id string, at time.Time) error
+	// LastCreatedAt returns when the user's newest token was created, or the zero time
+	// when they never asked for one.
+	LastCreatedAt(userID string) (time.Time, error)
+}
+
+// passwordResetRepository implements the PasswordResetRepository interface.
+type passwordResetRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewPasswordResetRepository creates a new instance of passwordResetRepository for the default tenant.
+func NewPasswordResetRepository(db *sql.DB) PasswordResetRepository {
+	return &passwordResetRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Create spends the user's unused tokens and stores the new one in one transaction.
+func (r *passwordResetRepository) Create(token *models.PasswordResetToken) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE password_reset_tokens SET used_at = ? WHERE tenant_id = ? AND user_id = ? AND used_at IS NULL`
+	if _, err := tx.Exec(query, token.CreatedAt, r.TenantID, token.UserID); err != nil {
+		return fmt.Errorf("failed to spend earlier password reset tokens: %w", err)
+	}
+
+	query = `INSERT INTO password_reset_tokens (id, tenant_id, user_id, token_hash, expires_at, created_at, used_at) VALUES (?, ?, ?, ?, ?, ?, NULL)`
+	if _, err := tx.Exec(query, token.ID, r.TenantID, token.UserID, token.TokenHash, token.ExpiresAt, token.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetByTokenHash retrieves the token a hash belongs to, whether or not it was used.
+func (r *passwordResetRepository) GetByTokenHash(tokenHash string) (*models.PasswordResetToken, error) {
+	query := `SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_tokens WHERE tenant_id = ? AND token_hash = ?`
+	var token models.PasswordResetToken
+	var usedAt sql.NullTime
+	err := r.DB.QueryRow(query, r.TenantID, tokenHash).Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt, &token.CreatedAt, &usedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("password reset token not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get password reset token: %w", err)
+	}
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+	return &token, nil
+}
+
+// Use marks a token as used, once.
+func (r *passwordResetRepository) Use(id string, at time.Time) error {
+	query := `UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND tenant_id = ? AND used_at IS NULL`
+	result, err := r.DB.Exec(query, at, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to use password reset token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to use password reset token: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("password reset token already used: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// LastCreatedAt returns when the user's newest token was created.
+func (r *passwordResetRepository) LastCreatedAt(userID string) (time.Time, error) {
+	query := `SELECT MAX(created_at) FROM password_reset_tokens WHERE tenant_id = ? AND user_id = ?`
+	var last sql.NullTime
+	if err := r.DB.QueryRow(query, r.TenantID, userID).Scan(&last); err != nil {
+		return time.Time{}, fmt.Errorf("failed to get the last password reset token: %w", err)
+	}
+	return last.Time, nil
+}
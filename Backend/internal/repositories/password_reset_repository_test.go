@@ -0,0 +1,83 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockPasswordResetRepo(t *testing.T) (repositories.PasswordResetRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewPasswordResetRepository(db), mock
+}
+
+func TestCreatePasswordResetToken(t *testing.T) {
+	repo, mock := newMockPasswordResetRepo(t)
+	now := time.Now()
+	token := &models.PasswordResetToken{ID: "reset-1", UserID: "user-1", TokenHash: "hash", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE password_reset_tokens SET used_at = ? WHERE tenant_id = ? AND user_id = ? AND used_at IS NULL").
+		WithArgs(now, models.DefaultTenantID, "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO password_reset_tokens (id, tenant_id, user_id, token_hash, expires_at, created_at, used_at) VALUES (?, ?, ?, ?, ?, ?, NULL)").
+		WithArgs("reset-1", models.DefaultTenantID, "user-1", "hash", token.ExpiresAt, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Create(token))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPasswordResetTokenByHash(t *testing.T) {
+	repo, mock := newMockPasswordResetRepo(t)
+	now := time.Now()
+	query := "SELECT id, user_id, token_hash, expires_at, created_at, used_at FROM password_reset_tokens WHERE tenant_id = ? AND token_hash = ?"
+
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "hash").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "token_hash", "expires_at", "created_at", "used_at"}).
+			AddRow("reset-1", "user-1", "hash", now.Add(time.Hour), now, now))
+	token, err := repo.GetByTokenHash("hash")
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", token.UserID)
+	require.NotNil(t, token.UsedAt)
+
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "missing").WillReturnError(sql.ErrNoRows)
+	_, err = repo.GetByTokenHash("missing")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsePasswordResetToken(t *testing.T) {
+	repo, mock := newMockPasswordResetRepo(t)
+	now := time.Now()
+	query := "UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND tenant_id = ? AND used_at IS NULL"
+
+	mock.ExpectExec(query).WithArgs(now, "reset-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Use("reset-1", now))
+
+	mock.ExpectExec(query).WithArgs(now, "reset-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.Use("reset-1", now), sql.ErrNoRows, "a token is used once")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPasswordResetLastCreatedAt(t *testing.T) {
+	repo, mock := newMockPasswordResetRepo(t)
+	query := "SELECT MAX(created_at) FROM password_reset_tokens WHERE tenant_id = ? AND user_id = ?"
+
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"MAX(created_at)"}).AddRow(nil))
+	last, err := repo.LastCreatedAt("user-1")
+	require.NoError(t, err)
+	assert.True(t, last.IsZero(), "users who never asked for a reset")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceChangeRepository defines the interface for the price changes of cabs and
+// accessories held for approval.
+type PriceChangeRepository interface {
+	// Create stores a new pending price change.
+	Create(change *models.PriceChange) error
+	GetByID(id string) (*models.PriceChange, error)
+	// GetAll returns the changes with the status, or every change when status is
+	// empty, most recently requested first.
+	GetAll(status string) ([]models.PriceChange, error)
+	// GetPending returns the pending change of an item, or an error wrapping
+	// sql.ErrNoRows when it has none.
+	GetPending(itemType string, itemID int) (*models.PriceChange, error)
+	// Review approves or rejects a pending change, or returns an error wrapping
+	// sql.ErrNoRows when it is not pending, so a change is only reviewed once.
+	Review(id, status, reviewedBy, note string) (*models.PriceChange, error)
+}
+
+// priceChangeRepository implements the PriceChangeRepository interface.
+type priceChangeRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewPriceChangeRepository creates a new instance of priceChangeRepository for the default tenant.
+func NewPriceChangeRepository(db *sql.DB) PriceChangeRepository {
+	return &priceChangeRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const priceChangeColumns = `id, item_type, item_id, item_name, old_price, new_price, status, requested_by, requested_at, reviewed_by, reviewed_at, note`
+
+// Create stores a new pending price change.
+func (r *priceChangeRepository) Create(change *models.PriceChange) error {
+	if change.ID == "" {
+		change.ID = uuid.New().String()
+	}
+	change.Status = models.PriceChangePending
+	change.RequestedAt = time.Now()
+
+	query := `
+		INSERT INTO price_changes (id, tenant_id, item_type, item_id, item_name, old_price, new_price, status, requested_by, requested_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, change.ID, r.TenantID, change.ItemType, change.ItemID, change.ItemName,
+		change.OldPrice, change.NewPrice, change.Status, change.RequestedBy, change.RequestedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create price change: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a price change by its ID.
+func (r *priceChangeRepository) GetByID(id string) (*models.PriceChange, error) {
+	query := `SELECT ` + priceChangeColumns + ` FROM price_changes WHERE id = ? AND tenant_id = ?`
+	return r.get(query, id, r.TenantID)
+}
+
+// GetPending retrieves the pending change of an item.
+func (r *priceChangeRepository) GetPending(itemType string, itemID int) (*models.PriceChange, error) {
+	query := `SELECT ` + priceChangeColumns + ` FROM price_changes
+		WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND status = ?
+		ORDER BY requested_at DESC LIMIT 1`
+	return r.get(query, r.TenantID, itemType, itemID, models.PriceChangePending)
+}
+
+func (r *priceChangeRepository) get(query string, args ...interface{}) (*models.PriceChange, error) {
+	change, err := scanPriceChange(r.DB.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("price change not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get price change: %w", err)
+	}
+	return change, nil
+}
+
+// GetAll retrieves the price changes with a status, or all of them.
+func (r *priceChangeRepository) GetAll(status string) ([]models.PriceChange, error) {
+	query := `SELECT ` + priceChangeColumns + ` FROM price_changes WHERE tenant_id = ?`
+	args := []interface{}{r.TenantID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY requested_at DESC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query price changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []models.PriceChange{}
+	for rows.Next() {
+		change, err := scanPriceChange(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan price change row: %w", err)
+		}
+		changes = append(changes, *change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating price change rows: %w", err)
+	}
+	return changes, nil
+}
+
+// Review approves or rejects a change that is still pending.
+func (r *priceChangeRepository) Review(id, status, reviewedBy, note string) (*models.PriceChange, error) {
+	query := `UPDATE price_changes SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`
+	result, err := r.DB.Exec(query, status, reviewedBy, time.Now(), note, id, r.TenantID, models.PriceChangePending)
+	if err != nil {
+		return nil, fmt.Errorf("failed to review price change: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, fmt.Errorf("pending price change not found: %w", sql.ErrNoRows)
+	}
+	return r.GetByID(id)
+}
+
+func scanPriceChange(row rowScanner) (*models.PriceChange, error) {
+	var change models.PriceChange
+	var reviewedBy, note sql.NullString
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&change.ID,
+		&change.ItemType,
+		&change.ItemID,
+		&change.ItemName,
+		&change.OldPrice,
+		&change.NewPrice,
+		&change.Status,
+		&change.RequestedBy,
+		&change.RequestedAt,
+		&reviewedBy,
+		&reviewedAt,
+		&note,
+	)
+	if err != nil {
+		return nil, err
+	}
+	change.ReviewedBy, change.Note = reviewedBy.String, note.String
+	if reviewedAt.Valid {
+		change.ReviewedAt = &reviewedAt.Time
+	}
+	return &change, nil
+}
@@ -0,0 +1,92 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var priceChangeColumns = []string{"id", "item_type", "item_id", "item_name", "old_price", "new_price", "status",
+	"requested_by", "requested_at", "reviewed_by", "reviewed_at", "note"}
+
+func newMockPriceChangeRepo(t *testing.T) (repositories.PriceChangeRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewPriceChangeRepository(db), mock
+}
+
+func TestCreatePriceChange(t *testing.T) {
+	repo, mock := newMockPriceChangeRepo(t)
+	mock.ExpectExec(`
+		INSERT INTO price_changes (id, tenant_id, item_type, item_id, item_name, old_price, new_price, status, requested_by, requested_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "cab", 1, "RX-7", 700000.0, 900000.0, "pending", "staff-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	change := &models.PriceChange{ItemType: "cab", ItemID: 1, ItemName: "RX-7", OldPrice: 700000, NewPrice: 900000, RequestedBy: "staff-1"}
+	require.NoError(t, repo.Create(change))
+	assert.NotEmpty(t, change.ID)
+	assert.Equal(t, models.PriceChangePending, change.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPendingPriceChange(t *testing.T) {
+	repo, mock := newMockPriceChangeRepo(t)
+	requestedAt := time.Now()
+	mock.ExpectQuery(`SELECT id, item_type, item_id, item_name, old_price, new_price, status, requested_by, requested_at, reviewed_by, reviewed_at, note FROM price_changes
+		WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND status = ?
+		ORDER BY requested_at DESC LIMIT 1`).
+		WithArgs(models.DefaultTenantID, "accessory", 4, "pending").
+		WillReturnRows(sqlmock.NewRows(priceChangeColumns).
+			AddRow("change-1", "accessory", 4, "Roof Rack", 4500.0, 9000.0, "pending", "staff-1", requestedAt, nil, nil, nil))
+	mock.ExpectQuery(`SELECT id, item_type, item_id, item_name, old_price, new_price, status, requested_by, requested_at, reviewed_by, reviewed_at, note FROM price_changes
+		WHERE tenant_id = ? AND item_type = ? AND item_id = ? AND status = ?
+		ORDER BY requested_at DESC LIMIT 1`).
+		WithArgs(models.DefaultTenantID, "cab", 1, "pending").WillReturnError(sql.ErrNoRows)
+
+	change, err := repo.GetPending("accessory", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "change-1", change.ID)
+	assert.Equal(t, 9000.0, change.NewPrice)
+	assert.Nil(t, change.ReviewedAt)
+
+	_, err = repo.GetPending("cab", 1)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReviewPriceChange(t *testing.T) {
+	repo, mock := newMockPriceChangeRepo(t)
+	reviewedAt := time.Now()
+	mock.ExpectExec(`UPDATE price_changes SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`).
+		WithArgs("rejected", "admin-1", sqlmock.AnyArg(), "Typo", "change-1", models.DefaultTenantID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, item_type, item_id, item_name, old_price, new_price, status, requested_by, requested_at, reviewed_by, reviewed_at, note FROM price_changes WHERE id = ? AND tenant_id = ?`).
+		WithArgs("change-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows(priceChangeColumns).
+			AddRow("change-1", "cab", 1, "RX-7", 700000.0, 70.0, "rejected", "staff-1", reviewedAt, "admin-1", reviewedAt, "Typo"))
+	mock.ExpectExec(`UPDATE price_changes SET status = ?, reviewed_by = ?, reviewed_at = ?, note = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?`).
+		WithArgs("approved", "admin-2", sqlmock.AnyArg(), "", "change-1", models.DefaultTenantID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	change, err := repo.Review("change-1", models.PriceChangeRejected, "admin-1", "Typo")
+	require.NoError(t, err)
+	assert.Equal(t, "admin-1", change.ReviewedBy)
+	require.NotNil(t, change.ReviewedAt)
+	assert.Equal(t, "Typo", change.Note)
+
+	_, err = repo.Review("change-1", models.PriceChangeApproved, "admin-2", "")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "already reviewed")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReceiptSeriesRepository defines the interface for official receipt series and the
+// OR numbers issued from them.
+type ReceiptSeriesRepository interface {
+	Create(series *models.ReceiptSeries) error
+	GetByID(id string) (*models.ReceiptSeries, error)
+	// GetAll returns the series of a branch, or of every branch when branch is empty,
+	// by branch then start number.
+	GetAll(branch string) ([]models.ReceiptSeries, error)
+	// Update saves whether a series is active and its warning level.
+	Update(series *models.ReceiptSeries) error
+	// Issue allocates the next number of the branch's active series with numbers
+	// left, lowest start number first, to a sale. It returns the receipt and the
+	// series after the allocation.
+	Issue(branch, saleID, issuedBy string) (*models.OfficialReceipt, *models.ReceiptSeries, error)
+	// GetIssued returns the OR number issued to a sale.
+	GetIssued(saleID string) (*models.OfficialReceipt, error)
+}
+
+// receiptSeriesRepository implements the ReceiptSeriesRepository interface.
+type receiptSeriesRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewReceiptSeriesRepository creates a new instance of receiptSeriesRepository for the default tenant.
+func NewReceiptSeriesRepository(db *sql.DB) ReceiptSeriesRepository {
+	return &receiptSeriesRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const receiptSeriesColumns = `id, branch, prefix, start_number, end_number, next_number, warn_remaining, active, created_by, created_at, updated_at`
+
+// Create stores a new series, starting at its first number.
+func (r *receiptSeriesRepository) Create(series *models.ReceiptSeries) error {
+	if series.ID == "" {
+		series.ID = uuid.New().String()
+	}
+	series.NextNumber = series.StartNumber
+	series.CreatedAt = time.Now()
+	series.UpdatedAt = series.CreatedAt
+
+	query := `
+		INSERT INTO receipt_series (id, tenant_id, branch, prefix, start_number, end_number, next_number, warn_remaining, active, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, series.ID, r.TenantID, series.Branch, series.Prefix, series.StartNumber, series.EndNumber,
+		series.NextNumber, series.WarnRemaining, series.Active, series.CreatedBy, series.CreatedAt, series.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create receipt series: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a series by its ID.
+func (r *receiptSeriesRepository) GetByID(id string) (*models.ReceiptSeries, error) {
+	query := `SELECT ` + receiptSeriesColumns + ` FROM receipt_series WHERE id = ? AND tenant_id = ?`
+
+	series, err := scanReceiptSeries(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("receipt series not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get receipt series: %w", err)
+	}
+	return series, nil
+}
+
+// GetAll retrieves the series of a branch, or of every branch.
+func (r *receiptSeriesRepository) GetAll(branch string) ([]models.ReceiptSeries, error) {
+	query := `SELECT ` + receiptSeriesColumns + ` FROM receipt_series WHERE tenant_id = ?`
+	args := []interface{}{r.TenantID}
+	if branch != "" {
+		query += ` AND branch = ?`
+		args = append(args, branch)
+	}
+	query += ` ORDER BY branch, start_number`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query receipt series: %w", err)
+	}
+	defer rows.Close()
+
+	all := []models.ReceiptSeries{}
+	for rows.Next() {
+		series, err := scanReceiptSeries(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan receipt series row: %w", err)
+		}
+		all = append(all, *series)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating receipt series rows: %w", err)
+	}
+	return all, nil
+}
+
+// Update saves whether a series is active and its warning level.
+func (r *receiptSeriesRepository) Update(series *models.ReceiptSeries) error {
+	series.UpdatedAt = time.Now()
+
+	query := `UPDATE receipt_series SET active = ?, warn_remaining = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`
+	result, err := r.DB.Exec(query, series.Active, series.WarnRemaining, series.UpdatedAt, series.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update receipt series: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("receipt series not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// Issue allocates the next OR number of the branch to a sale. The series row is
+// locked until the receipt is stored, so concurrent sales get different numbers.
+func (r *receiptSeriesRepository) Issue(branch, saleID, issuedBy string) (*models.OfficialReceipt, *models.ReceiptSeries, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT ` + receiptSeriesColumns + ` FROM receipt_series
+		WHERE tenant_id = ? AND branch = ? AND active = TRUE AND next_number <= end_number
+		ORDER BY start_number LIMIT 1 FOR UPDATE`
+	series, err := scanReceiptSeries(tx.QueryRow(query, r.TenantID, branch))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("no receipt series with numbers left for branch %s: %w", branch, err)
+		}
+		return nil, nil, fmt.Errorf("failed to get receipt series: %w", err)
+	}
+
+	receipt := &models.OfficialReceipt{
+		SaleID:   saleID,
+		SeriesID: series.ID,
+		Branch:   series.Branch,
+		Number:   series.NextNumber,
+		ORNumber: series.FormatNumber(series.NextNumber),
+		IssuedBy: issuedBy,
+		IssuedAt: time.Now(),
+	}
+	series.NextNumber++
+	series.UpdatedAt = receipt.IssuedAt
+
+	_, err = tx.Exec(`UPDATE receipt_series SET next_number = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`,
+		series.NextNumber, series.UpdatedAt, series.ID, r.TenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to advance receipt series: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO official_receipts (tenant_id, sale_id, series_id, branch, number, or_number, issued_by, issued_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.TenantID, receipt.SaleID, receipt.SeriesID, receipt.Branch, receipt.Number, receipt.ORNumber, receipt.IssuedBy, receipt.IssuedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to issue official receipt: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return receipt, series, nil
+}
+
+// GetIssued retrieves the OR number issued to a sale.
+func (r *receiptSeriesRepository) GetIssued(saleID string) (*models.OfficialReceipt, error) {
+	query := `
+		SELECT sale_id, series_id, branch, number, or_number, issued_by, issued_at
+		FROM official_receipts WHERE sale_id = ? AND tenant_id = ?
+	`
+	var receipt models.OfficialReceipt
+	err := r.DB.QueryRow(query, saleID, r.TenantID).Scan(
+		&receipt.SaleID,
+		&receipt.SeriesID,
+		&receipt.Branch,
+		&receipt.Number,
+		&receipt.ORNumber,
+		&receipt.IssuedBy,
+		&receipt.IssuedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("official receipt not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get official receipt: %w", err)
+	}
+	return &receipt, nil
+}
+
+func scanReceiptSeries(row rowScanner) (*models.ReceiptSeries, error) {
+	var series models.ReceiptSeries
+	err := row.Scan(
+		&series.ID,
+		&series.Branch,
+		&series.Prefix,
+		&series.StartNumber,
+		&series.EndNumber,
+		&series.NextNumber,
+		&series.WarnRemaining,
+		&series.Active,
+		&series.CreatedBy,
+		&series.CreatedAt,
+		&series.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
@@ -0,0 +1,64 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockReceiptSeriesRepo(t *testing.T) (repositories.ReceiptSeriesRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewReceiptSeriesRepository(db), mock
+}
+
+const issueSeriesQuery = `SELECT id, branch, prefix, start_number, end_number, next_number, warn_remaining, active, created_by, created_at, updated_at FROM receipt_series
+		WHERE tenant_id = ? AND branch = ? AND active = TRUE AND next_number <= end_number
+		ORDER BY start_number LIMIT 1 FOR UPDATE`
+
+func TestIssueOfficialReceipt(t *testing.T) {
+	repo, mock := newMockReceiptSeriesRepo(t)
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(issueSeriesQuery).
+		WithArgs(models.DefaultTenantID, "MNL").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "branch", "prefix", "start_number", "end_number", "next_number", "warn_remaining", "active", "created_by", "created_at", "updated_at"}).
+			AddRow("series-1", "MNL", "A-", 1, 5000, 42, 50, true, "admin-1", now, now))
+	mock.ExpectExec(`UPDATE receipt_series SET next_number = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(43, sqlmock.AnyArg(), "series-1", models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`
+		INSERT INTO official_receipts (tenant_id, sale_id, series_id, branch, number, or_number, issued_by, issued_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`).
+		WithArgs(models.DefaultTenantID, "sale-1", "series-1", "MNL", 42, "A-0042", "staff-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	receipt, series, err := repo.Issue("MNL", "sale-1", "staff-1")
+	require.NoError(t, err)
+	assert.Equal(t, "A-0042", receipt.ORNumber)
+	assert.Equal(t, 43, series.NextNumber)
+	assert.Equal(t, 4958, series.Remaining())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIssueOfficialReceiptExhausted(t *testing.T) {
+	repo, mock := newMockReceiptSeriesRepo(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(issueSeriesQuery).WithArgs(models.DefaultTenantID, "MNL").WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, _, err := repo.Issue("MNL", "sale-1", "staff-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// RefreshTokenRepository defines the interface for the refresh tokens of user sessions.
+type RefreshTokenRepository interface {
+	// Create stores a token starting or continuing a session.
+	Create(token *models.RefreshToken) error
+	// GetByTokenHash returns the token with the hash, or an error wrapping sql.ErrNoRows.
+	GetByTokenHash(tokenHash string) (*models.RefreshToken, error)
+	// Rotate revokes the token with oldID and stores next as its replacement. It returns an
+	// error wrapping sql.ErrNoRows when the old token was already revoked, so two requests
+	// exchanging the same token cannot both succeed.
+	Rotate(oldID string, next *models.RefreshToken, at time.Time) error
+	// RevokeFamily revokes the tokens of a session that are not revoked yet.
+	RevokeFamily(familyID string, at time.Time) error
+	// RevokeUser revokes the tokens of every session of a user.
+	RevokeUser(userID string, at time.Time) error
+}
+
+// refreshTokenRepository implements the RefreshTokenRepository interface.
+type refreshTokenRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewRefreshTokenRepository creates a new instance of refreshTokenRepository for the default tenant.
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const refreshTokenColumns = `id, user_id, family_id, token_hash, expires_at, created_at, revoked_at, replaced_by`
+
+const insertRefreshToken = `
+	INSERT INTO refresh_tokens (id, tenant_id, user_id, family_id, token_hash, expires_at, created_at, revoked_at, replaced_by)
+	VALUES (?, ?, ?, ?, ?, ?, ?, NULL, '')
+`
+
+// Create stores a new token.
+func (r *refreshTokenRepository) Create(token *models.RefreshToken) error {
+	_, err := r.DB.Exec(insertRefreshToken, token.ID, r.TenantID, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt, token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetByTokenHash retrieves the token a hash belongs to, whether or not it was revoked.
+func (r *refreshTokenRepository) GetByTokenHash(tokenHash string) (*models.RefreshToken, error) {
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE tenant_id = ? AND token_hash = ?`
+	var token models.RefreshToken
+	var revokedAt sql.NullTime
+	err := r.DB.QueryRow(query, r.TenantID, tokenHash).Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash,
+		&token.ExpiresAt, &token.CreatedAt, &revokedAt, &token.ReplacedBy)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("refresh token not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
+
+// Rotate revokes the old token and stores its replacement in one transaction.
+func (r *refreshTokenRepository) Rotate(oldID string, next *models.RefreshToken, at time.Time) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE refresh_tokens SET revoked_at = ?, replaced_by = ? WHERE id = ? AND tenant_id = ? AND revoked_at IS NULL`
+	result, err := tx.Exec(query, at, next.ID, oldID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("refresh token already revoked: %w", sql.ErrNoRows)
+	}
+
+	_, err = tx.Exec(insertRefreshToken, next.ID, r.TenantID, next.UserID, next.FamilyID, next.TokenHash, next.ExpiresAt, next.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RevokeFamily revokes the live tokens of a session.
+func (r *refreshTokenRepository) RevokeFamily(familyID string, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = ? WHERE tenant_id = ? AND family_id = ? AND revoked_at IS NULL`
+	if _, err := r.DB.Exec(query, at, r.TenantID, familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUser revokes the live tokens of every session of a user.
+func (r *refreshTokenRepository) RevokeUser(userID string, at time.Time) error {
+	query := `UPDATE refresh_tokens SET revoked_at = ? WHERE tenant_id = ? AND user_id = ? AND revoked_at IS NULL`
+	if _, err := r.DB.Exec(query, at, r.TenantID, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
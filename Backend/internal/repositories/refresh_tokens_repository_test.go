@@ -0,0 +1,87 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockRefreshTokenRepo(t *testing.T) (repositories.RefreshTokenRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewRefreshTokenRepository(db), mock
+}
+
+const insertRefreshTokenQuery = `
+	INSERT INTO refresh_tokens (id, tenant_id, user_id, family_id, token_hash, expires_at, created_at, revoked_at, replaced_by)
+	VALUES (?, ?, ?, ?, ?, ?, ?, NULL, '')
+`
+
+func TestGetRefreshTokenByTokenHash(t *testing.T) {
+	repo, mock := newMockRefreshTokenRepo(t)
+	now := time.Now()
+	query := `SELECT id, user_id, family_id, token_hash, expires_at, created_at, revoked_at, replaced_by FROM refresh_tokens WHERE tenant_id = ? AND token_hash = ?`
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "hash-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "family_id", "token_hash", "expires_at", "created_at", "revoked_at", "replaced_by"}).
+			AddRow("token-1", "staff-1", "family-1", "hash-1", now.Add(time.Hour), now, now, "token-2"))
+	mock.ExpectQuery(query).WithArgs(models.DefaultTenantID, "hash-2").WillReturnError(sql.ErrNoRows)
+
+	token, err := repo.GetByTokenHash("hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "family-1", token.FamilyID)
+	require.NotNil(t, token.RevokedAt)
+	assert.Equal(t, "token-2", token.ReplacedBy)
+
+	_, err = repo.GetByTokenHash("hash-2")
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	repo, mock := newMockRefreshTokenRepo(t)
+	now := time.Now()
+	next := &models.RefreshToken{ID: "token-2", UserID: "staff-1", FamilyID: "family-1", TokenHash: "hash-2", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	revoke := `UPDATE refresh_tokens SET revoked_at = ?, replaced_by = ? WHERE id = ? AND tenant_id = ? AND revoked_at IS NULL`
+
+	mock.ExpectBegin()
+	mock.ExpectExec(revoke).WithArgs(now, "token-2", "token-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertRefreshTokenQuery).
+		WithArgs("token-2", models.DefaultTenantID, "staff-1", "family-1", "hash-2", next.ExpiresAt, now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	require.NoError(t, repo.Rotate("token-1", next, now))
+
+	// A token revoked by a concurrent exchange is not rotated twice
+	mock.ExpectBegin()
+	mock.ExpectExec(revoke).WithArgs(now, "token-2", "token-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err := repo.Rotate("token-1", next, now)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevokeRefreshTokens(t *testing.T) {
+	repo, mock := newMockRefreshTokenRepo(t)
+	now := time.Now()
+	mock.ExpectExec(insertRefreshTokenQuery).
+		WithArgs("token-1", models.DefaultTenantID, "staff-1", "family-1", "hash-1", now.Add(time.Hour), now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = ? WHERE tenant_id = ? AND family_id = ? AND revoked_at IS NULL`).
+		WithArgs(now, models.DefaultTenantID, "family-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = ? WHERE tenant_id = ? AND user_id = ? AND revoked_at IS NULL`).
+		WithArgs(now, models.DefaultTenantID, "staff-1").WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, repo.Create(&models.RefreshToken{ID: "token-1", UserID: "staff-1", FamilyID: "family-1", TokenHash: "hash-1", ExpiresAt: now.Add(time.Hour), CreatedAt: now}))
+	require.NoError(t, repo.RevokeFamily("family-1", now))
+	require.NoError(t, repo.RevokeUser("staff-1", now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
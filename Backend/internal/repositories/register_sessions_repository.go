@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegisterSessionRepository defines the interface for cash register sessions.
+type RegisterSessionRepository interface {
+	// Open stores a new open session.
+	Open(session *models.RegisterSession) error
+	GetByID(id string) (*models.RegisterSession, error)
+	// GetOpen returns the user's open session, or an error wrapping sql.ErrNoRows when there is none.
+	GetOpen(userID string) (*models.RegisterSession, error)
+	// Close saves the count of an open session. It returns an error wrapping
+	// sql.ErrNoRows when the session is not open anymore.
+	Close(session *models.RegisterSession) error
+	// GetClosedBetween returns the sessions closed in [start, end), earliest first.
+	GetClosedBetween(start, end time.Time) ([]models.RegisterSession, error)
+	// GetUndeposited returns the sessions closed before closedBefore that are not
+	// linked to a bank deposit, earliest first.
+	GetUndeposited(closedBefore time.Time) ([]models.RegisterSession, error)
+}
+
+// registerSessionRepository implements the RegisterSessionRepository interface.
+type registerSessionRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewRegisterSessionRepository creates a new instance of registerSessionRepository for the default tenant.
+func NewRegisterSessionRepository(db *sql.DB) RegisterSessionRepository {
+	return &registerSessionRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const registerSessionColumns = `id, opened_by, opened_at, opening_float, status, closed_by, closed_at, denominations, counted_cash, expected_cash, variance, sales_total, sales_count, notes`
+
+// Open stores a new open session.
+func (r *registerSessionRepository) Open(session *models.RegisterSession) error {
+	if session.ID == "" {
+		session.ID = uuid.New().String()
+	}
+	session.Status = models.RegisterSessionOpen
+
+	query := `INSERT INTO register_sessions (id, tenant_id, opened_by, opened_at, opening_float, status) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.DB.Exec(query, session.ID, r.TenantID, session.OpenedBy, session.OpenedAt, session.OpeningFloat, session.Status)
+	if err != nil {
+		return fmt.Errorf("failed to open register session: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a session by its ID.
+func (r *registerSessionRepository) GetByID(id string) (*models.RegisterSession, error) {
+	query := `SELECT ` + registerSessionColumns + ` FROM register_sessions WHERE id = ? AND tenant_id = ?`
+	return r.getOne(query, id, r.TenantID)
+}
+
+// GetOpen retrieves the user's open session.
+func (r *registerSessionRepository) GetOpen(userID string) (*models.RegisterSession, error) {
+	query := `SELECT ` + registerSessionColumns + ` FROM register_sessions WHERE tenant_id = ? AND opened_by = ? AND status = ? ORDER BY opened_at DESC LIMIT 1`
+	return r.getOne(query, r.TenantID, userID, models.RegisterSessionOpen)
+}
+
+func (r *registerSessionRepository) getOne(query string, args ...interface{}) (*models.RegisterSession, error) {
+	session, err := scanRegisterSession(r.DB.QueryRow(query, args...))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("register session not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get register session: %w", err)
+	}
+
+	return session, nil
+}
+
+// Close saves the count of an open session.
+func (r *registerSessionRepository) Close(session *models.RegisterSession) error {
+	denominations, err := json.Marshal(session.Denominations)
+	if err != nil {
+		return fmt.Errorf("could not encode denominations: %w", err)
+	}
+	session.Status = models.RegisterSessionClosed
+
+	query := `
+		UPDATE register_sessions SET status = ?, closed_by = ?, closed_at = ?, denominations = ?, counted_cash = ?,
+			expected_cash = ?, variance = ?, sales_total = ?, sales_count = ?, notes = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?
+	`
+	result, err := r.DB.Exec(query, session.Status, session.ClosedBy, session.ClosedAt, string(denominations), session.CountedCash,
+		session.ExpectedCash, session.Variance, session.SalesTotal, session.SalesCount, session.Notes,
+		session.ID, r.TenantID, models.RegisterSessionOpen)
+	if err != nil {
+		return fmt.Errorf("failed to close register session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("register session not open: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetClosedBetween retrieves the sessions closed in [start, end).
+func (r *registerSessionRepository) GetClosedBetween(start, end time.Time) ([]models.RegisterSession, error) {
+	query := `SELECT ` + registerSessionColumns + ` FROM register_sessions
+		WHERE tenant_id = ? AND status = ? AND closed_at >= ? AND closed_at < ?
+		ORDER BY closed_at ASC`
+
+	return r.querySessions(query, r.TenantID, models.RegisterSessionClosed, start, end)
+}
+
+// GetUndeposited retrieves the closed sessions not linked to a bank deposit.
+func (r *registerSessionRepository) GetUndeposited(closedBefore time.Time) ([]models.RegisterSession, error) {
+	query := `SELECT ` + registerSessionColumns + ` FROM register_sessions s
+		LEFT JOIN bank_deposit_sessions d ON d.tenant_id = s.tenant_id AND d.session_id = s.id
+		WHERE s.tenant_id = ? AND s.status = ? AND s.closed_at < ? AND d.session_id IS NULL
+		ORDER BY s.closed_at ASC`
+
+	return r.querySessions(query, r.TenantID, models.RegisterSessionClosed, closedBefore)
+}
+
+func (r *registerSessionRepository) querySessions(query string, args ...interface{}) ([]models.RegisterSession, error) {
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query register sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.RegisterSession{}
+	for rows.Next() {
+		session, err := scanRegisterSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan register session row: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating register session rows: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func scanRegisterSession(row rowScanner) (*models.RegisterSession, error) {
+	var session models.RegisterSession
+	var closedAt sql.NullTime
+	var denominations sql.NullString
+
+	err := row.Scan(
+		&session.ID,
+		&session.OpenedBy,
+		&session.OpenedAt,
+		&session.OpeningFloat,
+		&session.Status,
+		&session.ClosedBy,
+		&closedAt,
+		&denominations,
+		&session.CountedCash,
+		&session.ExpectedCash,
+		&session.Variance,
+		&session.SalesTotal,
+		&session.SalesCount,
+		&session.Notes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if closedAt.Valid {
+		session.ClosedAt = &closedAt.Time
+	}
+	if denominations.Valid && denominations.String != "" {
+		if err := json.Unmarshal([]byte(denominations.String), &session.Denominations); err != nil {
+			return nil, fmt.Errorf("could not decode denominations: %w", err)
+		}
+	}
+	return &session, nil
+}
@@ -0,0 +1,92 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockRegisterSessionRepo(t *testing.T) (repositories.RegisterSessionRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewRegisterSessionRepository(db), mock
+}
+
+const closeRegisterSessionQuery = `
+		UPDATE register_sessions SET status = ?, closed_by = ?, closed_at = ?, denominations = ?, counted_cash = ?,
+			expected_cash = ?, variance = ?, sales_total = ?, sales_count = ?, notes = ?
+		WHERE id = ? AND tenant_id = ? AND status = ?
+	`
+
+func TestOpenRegisterSession(t *testing.T) {
+	repo, mock := newMockRegisterSessionRepo(t)
+	openedAt := time.Now()
+	mock.ExpectExec(`INSERT INTO register_sessions (id, tenant_id, opened_by, opened_at, opening_float, status) VALUES (?, ?, ?, ?, ?, ?)`).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "staff-1", openedAt, 1000.0, models.RegisterSessionOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	session := &models.RegisterSession{OpenedBy: "staff-1", OpenedAt: openedAt, OpeningFloat: 1000}
+	require.NoError(t, repo.Open(session))
+	assert.NotEmpty(t, session.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCloseRegisterSession(t *testing.T) {
+	repo, mock := newMockRegisterSessionRepo(t)
+	closedAt := time.Now()
+	session := &models.RegisterSession{
+		ID:            "reg-1",
+		ClosedBy:      "staff-1",
+		ClosedAt:      &closedAt,
+		Denominations: []models.DenominationCount{{Kind: models.CashCoin, Value: 0.25, Count: 4, Subtotal: 1}},
+		CountedCash:   1,
+		ExpectedCash:  2,
+		Variance:      -1,
+	}
+	mock.ExpectExec(closeRegisterSessionQuery).
+		WithArgs(models.RegisterSessionClosed, "staff-1", &closedAt, `[{"kind":"coin","value":0.25,"count":4,"subtotal":1}]`, 1.0,
+			2.0, -1.0, 0.0, 0, "", "reg-1", models.DefaultTenantID, models.RegisterSessionOpen).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Close(session))
+	assert.Equal(t, models.RegisterSessionClosed, session.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCloseRegisterSessionAlreadyClosed(t *testing.T) {
+	repo, mock := newMockRegisterSessionRepo(t)
+	closedAt := time.Now()
+	mock.ExpectExec(closeRegisterSessionQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Close(&models.RegisterSession{ID: "reg-1", ClosedAt: &closedAt})
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetClosedRegisterSessions(t *testing.T) {
+	repo, mock := newMockRegisterSessionRepo(t)
+	start := time.Date(2025, 3, 12, 0, 0, 0, 0, time.Local)
+	closedAt := start.Add(18 * time.Hour)
+	mock.ExpectQuery(`SELECT id, opened_by, opened_at, opening_float, status, closed_by, closed_at, denominations, counted_cash, expected_cash, variance, sales_total, sales_count, notes FROM register_sessions
+		WHERE tenant_id = ? AND status = ? AND closed_at >= ? AND closed_at < ?
+		ORDER BY closed_at ASC`).
+		WithArgs(models.DefaultTenantID, models.RegisterSessionClosed, start, start.AddDate(0, 0, 1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "opened_by", "opened_at", "opening_float", "status", "closed_by", "closed_at", "denominations", "counted_cash", "expected_cash", "variance", "sales_total", "sales_count", "notes"}).
+			AddRow("reg-1", "staff-1", start.Add(8*time.Hour), 1000.0, "closed", "staff-1", closedAt, `[{"kind":"bill","value":1000,"count":2,"subtotal":2000}]`, 2000.0, 2000.0, 0.0, 1000.0, 1, ""))
+
+	sessions, err := repo.GetClosedBetween(start, start.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, &closedAt, sessions[0].ClosedAt)
+	assert.Equal(t, []models.DenominationCount{{Kind: models.CashBill, Value: 1000, Count: 2, Subtotal: 2000}}, sessions[0].Denominations)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
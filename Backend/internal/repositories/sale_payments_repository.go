@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrPaymentExceedsTotal is returned when a payment would take the payments of a sale
+// above its total
+var ErrPaymentExceedsTotal = errors.New("payments cannot exceed the sale total")
+
+// PaymentTolerance is how far apart payments and a sale total may be and still be
+// considered equal, to absorb rounding to the cent
+const PaymentTolerance = 0.005
+
+// SalePaymentRepository defines the interface for the payments received for sales.
+type SalePaymentRepository interface {
+	// Add records a payment, returning an error wrapping sql.ErrNoRows when the sale
+	// does not exist, or ErrPaymentExceedsTotal when the sale's payments would add
+	// up to more than its total.
+	Add(payment *models.SalePayment) error
+	// GetBySale returns the payments of a sale, oldest first.
+	GetBySale(saleID string) ([]models.SalePayment, error)
+	// Paid returns the sum of the payments of a sale.
+	Paid(saleID string) (float64, error)
+	// PaidBySales returns the sum of the payments of each of the given sales, by sale
+	// ID. Sales without payments are left out.
+	PaidBySales(saleIDs []string) (map[string]float64, error)
+	// MarkInstallment records that a sale is paid in installments, so a balance left
+	// to pay is not a mismatch.
+	MarkInstallment(saleID string) error
+	// Mismatches returns the sales whose payments do not add up to their total,
+	// oldest first. Sales paid in installments are only returned when paid more
+	// than their total, and sales not counted as made, such as drafts, when paid
+	// anything.
+	Mismatches() ([]models.PaymentMismatch, error)
+}
+
+// salePaymentRepository implements the SalePaymentRepository interface.
+type salePaymentRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewSalePaymentRepository creates a new instance of salePaymentRepository for the default tenant.
+func NewSalePaymentRepository(db *sql.DB) SalePaymentRepository {
+	return &salePaymentRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Add records a payment in a transaction that locks the sale, so concurrent payments
+// cannot together exceed its total.
+func (r *salePaymentRepository) Add(payment *models.SalePayment) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total float64
+	err = tx.QueryRow(`SELECT total_price FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE`, payment.SaleID, r.TenantID).Scan(&total)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("sale %s not found: %w", payment.SaleID, err)
+		}
+		return fmt.Errorf("failed to get sale total: %w", err)
+	}
+	var paid float64
+	if err := tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM sale_payments WHERE tenant_id = ? AND sale_id = ?`, r.TenantID, payment.SaleID).Scan(&paid); err != nil {
+		return fmt.Errorf("failed to sum payments: %w", err)
+	}
+	if paid+payment.Amount > total+PaymentTolerance {
+		return fmt.Errorf("payment of %.2f with %.2f of %.2f already paid: %w", payment.Amount, paid, total, ErrPaymentExceedsTotal)
+	}
+
+	if payment.ID == "" {
+		payment.ID = uuid.New().String()
+	}
+	if payment.ReceivedAt.IsZero() {
+		payment.ReceivedAt = time.Now()
+	}
+	_, err = tx.Exec(`INSERT INTO sale_payments (id, tenant_id, sale_id, amount, method, reference, received_by, received_at)
+		VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		payment.ID, r.TenantID, payment.SaleID, payment.Amount, payment.Method, payment.Reference, payment.ReceivedBy, payment.ReceivedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add payment: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetBySale retrieves the payments of a sale.
+func (r *salePaymentRepository) GetBySale(saleID string) ([]models.SalePayment, error) {
+	rows, err := r.DB.Query(`SELECT id, sale_id, amount, method, COALESCE(reference, ''), received_by, received_at
+		FROM sale_payments WHERE tenant_id = ? AND sale_id = ? ORDER BY received_at, id`, r.TenantID, saleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payments: %w", err)
+	}
+	defer rows.Close()
+
+	payments := []models.SalePayment{}
+	for rows.Next() {
+		var payment models.SalePayment
+		if err := rows.Scan(&payment.ID, &payment.SaleID, &payment.Amount, &payment.Method, &payment.Reference, &payment.ReceivedBy, &payment.ReceivedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payments: %w", err)
+	}
+	return payments, nil
+}
+
+// Paid retrieves the sum of the payments of a sale.
+func (r *salePaymentRepository) Paid(saleID string) (float64, error) {
+	var paid float64
+	if err := r.DB.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM sale_payments WHERE tenant_id = ? AND sale_id = ?`, r.TenantID, saleID).Scan(&paid); err != nil {
+		return 0, fmt.Errorf("failed to sum payments: %w", err)
+	}
+	return paid, nil
+}
+
+// PaidBySales retrieves the sums of the payments of several sales in one query.
+func (r *salePaymentRepository) PaidBySales(saleIDs []string) (map[string]float64, error) {
+	paid := make(map[string]float64, len(saleIDs))
+	if len(saleIDs) == 0 {
+		return paid, nil
+	}
+	args := []interface{}{r.TenantID}
+	for _, id := range saleIDs {
+		args = append(args, id)
+	}
+	query := `SELECT sale_id, SUM(amount) FROM sale_payments WHERE tenant_id = ? AND sale_id IN (?` +
+		strings.Repeat(", ?", len(saleIDs)-1) + `) GROUP BY sale_id`
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum payments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var saleID string
+		var amount float64
+		if err := rows.Scan(&saleID, &amount); err != nil {
+			return nil, fmt.Errorf("failed to scan payment sum: %w", err)
+		}
+		paid[saleID] = amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment sums: %w", err)
+	}
+	return paid, nil
+}
+
+// MarkInstallment records that a sale is paid in installments. Marking a sale twice
+// is not an error.
+func (r *salePaymentRepository) MarkInstallment(saleID string) error {
+	_, err := r.DB.Exec(`INSERT IGNORE INTO installment_sales (tenant_id, sale_id, created_at) VALUES (?, ?, ?)`,
+		r.TenantID, saleID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark installment sale: %w", err)
+	}
+	return nil
+}
+
+// Mismatches retrieves the sales whose payments are more or less than their total,
+// leaving out the balances of installment sales and of sales that are not counted.
+func (r *salePaymentRepository) Mismatches() ([]models.PaymentMismatch, error) {
+	query := `SELECT s.id, DATE_FORMAT(s.sale_date, '%Y-%m-%d'), s.total_price, COALESCE(SUM(p.amount), 0) AS paid
+		FROM sales s
+		LEFT JOIN sale_payments p ON p.tenant_id = s.tenant_id AND p.sale_id = s.id
+		LEFT JOIN installment_sales i ON i.tenant_id = s.tenant_id AND i.sale_id = s.id
+		WHERE s.tenant_id = ?
+		GROUP BY s.id, s.sale_date, s.total_price, s.status, i.sale_id
+		HAVING COALESCE(SUM(p.amount), 0) - s.total_price > ?
+			OR (i.sale_id IS NULL AND s.` + countedSale + ` AND s.total_price - COALESCE(SUM(p.amount), 0) > ?)
+		ORDER BY s.sale_date, s.id`
+	rows, err := r.DB.Query(query, r.TenantID, PaymentTolerance, PaymentTolerance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payment mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	mismatches := []models.PaymentMismatch{}
+	for rows.Next() {
+		var mismatch models.PaymentMismatch
+		if err := rows.Scan(&mismatch.SaleID, &mismatch.SaleDate, &mismatch.TotalPrice, &mismatch.Paid); err != nil {
+			return nil, fmt.Errorf("failed to scan payment mismatch: %w", err)
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payment mismatches: %w", err)
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"regexp"
+	"testing"
+
+	"oop/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSalePayment(t *testing.T) {
+	lockSale := regexp.QuoteMeta("SELECT total_price FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE")
+	sumPaid := regexp.QuoteMeta("SELECT COALESCE(SUM(amount), 0) FROM sale_payments WHERE tenant_id = ? AND sale_id = ?")
+
+	t.Run("Within total", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewSalePaymentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSale).WithArgs("sale-1", models.DefaultTenantID).
+			WillReturnRows(sqlmock.NewRows([]string{"total_price"}).AddRow(1000.0))
+		mock.ExpectQuery(sumPaid).WithArgs(models.DefaultTenantID, "sale-1").
+			WillReturnRows(sqlmock.NewRows([]string{"paid"}).AddRow(600.0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_payments")).
+			WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "sale-1", 400.0, models.PaymentCard, "slip-7", "user-1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		payment := &models.SalePayment{SaleID: "sale-1", Amount: 400, Method: models.PaymentCard, Reference: "slip-7", ReceivedBy: "user-1"}
+		require.NoError(t, repo.Add(payment))
+		assert.NotEmpty(t, payment.ID)
+		assert.False(t, payment.ReceivedAt.IsZero())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Exceeds total", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewSalePaymentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSale).WithArgs("sale-1", models.DefaultTenantID).
+			WillReturnRows(sqlmock.NewRows([]string{"total_price"}).AddRow(1000.0))
+		mock.ExpectQuery(sumPaid).WithArgs(models.DefaultTenantID, "sale-1").
+			WillReturnRows(sqlmock.NewRows([]string{"paid"}).AddRow(1000.0))
+		mock.ExpectRollback()
+
+		err := repo.Add(&models.SalePayment{SaleID: "sale-1", Amount: 1, Method: models.PaymentCash})
+		assert.True(t, errors.Is(err, ErrPaymentExceedsTotal))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Sale not found", func(t *testing.T) {
+		db, mock := NewMockDB(t)
+		defer db.Close()
+		repo := NewSalePaymentRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockSale).WithArgs("missing", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		err := repo.Add(&models.SalePayment{SaleID: "missing", Amount: 1, Method: models.PaymentCash})
+		assert.True(t, errors.Is(err, sql.ErrNoRows))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSalePaymentMismatches(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalePaymentRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN installment_sales i ON i.tenant_id = s.tenant_id AND i.sale_id = s.id")+
+		`.*`+regexp.QuoteMeta("OR (i.sale_id IS NULL AND s.status IN ('confirmed', 'completed') AND")).
+		WithArgs(models.DefaultTenantID, PaymentTolerance, PaymentTolerance).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sale_date", "total_price", "paid"}).
+			AddRow("sale-1", "2026-10-01", 1000.0, 0.0).
+			AddRow("sale-2", "2026-10-02", 500.0, 650.0))
+
+	mismatches, err := repo.Mismatches()
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+	assert.Equal(t, models.PaymentMismatch{SaleID: "sale-2", SaleDate: "2026-10-02", TotalPrice: 500, Paid: 650}, mismatches[1])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSalePaymentPaidBySales(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalePaymentRepository(db)
+
+	paid, err := repo.PaidBySales(nil)
+	require.NoError(t, err)
+	assert.Empty(t, paid, "no query without sales")
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT sale_id, SUM(amount) FROM sale_payments WHERE tenant_id = ? AND sale_id IN (?, ?) GROUP BY sale_id")).
+		WithArgs(models.DefaultTenantID, "sale-1", "sale-2").
+		WillReturnRows(sqlmock.NewRows([]string{"sale_id", "paid"}).AddRow("sale-1", 300.0))
+
+	paid, err = repo.PaidBySales([]string{"sale-1", "sale-2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"sale-1": 300}, paid)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMarkInstallmentSale(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalePaymentRepository(db)
+
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO installment_sales (tenant_id, sale_id, created_at) VALUES (?, ?, ?)")).
+		WithArgs(models.DefaultTenantID, "sale-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkInstallment("sale-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"regexp"
 	"testing"
@@ -21,38 +23,61 @@ func TestGetAll_NoFilters(t *testing.T) {
 
 	now := time.Now()
 	expected := []models.Sale{
-		{ID: "s1", CustomerID: "cust1", SoldBy: "user1", SaleDate: "2025-05-09", TotalPrice: 100.0, CreatedAt: now, UpdatedAt: now},
-		{ID: "s2", CustomerID: "cust2", SoldBy: "user2", SaleDate: "2025-05-08", TotalPrice: 200.0, CreatedAt: now, UpdatedAt: now},
+		{ID: "s1", CustomerID: "cust1", SoldBy: "user1", SaleDate: "2025-05-09", TotalPrice: 112.0, TaxType: models.TaxVatable, VATAmount: 12.0, Status: models.SaleConfirmed, CreatedAt: now, UpdatedAt: now},
+		{ID: "s2", CustomerID: "cust2", SoldBy: "user2", SaleDate: "2025-05-08", TotalPrice: 200.0, TaxType: models.TaxExempt, Status: models.SaleCompleted, CreatedAt: now, UpdatedAt: now},
 	}
 
-	rows := sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "created_at", "updated_at"}).
-		AddRow(expected[0].ID, expected[0].CustomerID, expected[0].SoldBy, expected[0].SaleDate, expected[0].TotalPrice, expected[0].CreatedAt, expected[0].UpdatedAt).
-		AddRow(expected[1].ID, expected[1].CustomerID, expected[1].SoldBy, expected[1].SaleDate, expected[1].TotalPrice, expected[1].CreatedAt, expected[1].UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "status", "created_at", "updated_at"}).
+		AddRow(expected[0].ID, expected[0].CustomerID, expected[0].SoldBy, expected[0].SaleDate, expected[0].TotalPrice, expected[0].TaxType, expected[0].VATAmount, expected[0].Status, expected[0].CreatedAt, expected[0].UpdatedAt).
+		AddRow(expected[1].ID, expected[1].CustomerID, expected[1].SoldBy, expected[1].SaleDate, expected[1].TotalPrice, expected[1].TaxType, expected[1].VATAmount, expected[1].Status, expected[1].CreatedAt, expected[1].UpdatedAt)
 
-	query := "SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE 1=1 ORDER BY created_at DESC"
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WillReturnRows(rows)
+	query := "SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE tenant_id = ? ORDER BY created_at DESC"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID).WillReturnRows(rows)
 
-	sales, err := repo.GetAll(nil)
+	sales, err := repo.GetAll(context.Background(), models.SalesFilter{})
 	require.NoError(t, err)
 	assert.Equal(t, expected, sales)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestGetPaginated_WithFilters(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	now := time.Now()
+	filter := models.SalesFilter{CustomerID: "cust1", StartDate: "2025-05-01"}
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM sales WHERE tenant_id = ? AND customer_id = ? AND sale_date >= ?")).
+		WithArgs(models.DefaultTenantID, "cust1", "2025-05-01").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	query := "SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE tenant_id = ? AND customer_id = ? AND sale_date >= ? ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(models.DefaultTenantID, "cust1", "2025-05-01", 5, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "status", "created_at", "updated_at"}).
+			AddRow("s11", "cust1", "user1", "2025-05-09", 112.0, models.TaxVatable, 12.0, models.SaleConfirmed, now, now))
+
+	sales, total, err := repo.GetPaginated(context.Background(), 3, 5, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), total)
+	require.Len(t, sales, 1)
+	assert.Equal(t, "s11", sales[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestGetByID_Exists(t *testing.T) {
 	db, mock := NewMockDB(t)
 	defer db.Close()
 	repo := NewSalesRepository(db)
 
 	now := time.Now()
-	expected := &models.Sale{ID: "s1", CustomerID: "cust1", SoldBy: "user1", SaleDate: "2025-05-09", TotalPrice: 150.0, CreatedAt: now, UpdatedAt: now}
+	expected := &models.Sale{ID: "s1", CustomerID: "cust1", SoldBy: "user1", SaleDate: "2025-05-09", TotalPrice: 150.0, TaxType: models.TaxZeroRated, Status: models.SaleDraft, CreatedAt: now, UpdatedAt: now}
 
-	rows := sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "created_at", "updated_at"}).
-		AddRow(expected.ID, expected.CustomerID, expected.SoldBy, expected.SaleDate, expected.TotalPrice, expected.CreatedAt, expected.UpdatedAt)
+	rows := sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "status", "created_at", "updated_at"}).
+		AddRow(expected.ID, expected.CustomerID, expected.SoldBy, expected.SaleDate, expected.TotalPrice, expected.TaxType, expected.VATAmount, expected.Status, expected.CreatedAt, expected.UpdatedAt)
 
-	query := "SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE id = ?"
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(expected.ID).WillReturnRows(rows)
+	query := "SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE id = ? AND tenant_id = ?"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(expected.ID, models.DefaultTenantID).WillReturnRows(rows)
 
-	sale, err := repo.GetByID(expected.ID)
+	sale, err := repo.GetByID(context.Background(), expected.ID)
 	require.NoError(t, err)
 	require.NotNil(t, sale)
 	assert.Equal(t, expected, sale)
@@ -65,10 +90,10 @@ func TestGetByID_NotExists(t *testing.T) {
 	repo := NewSalesRepository(db)
 
 	id := "notfound"
-	query := "SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE id = ?"
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id).WillReturnError(sql.ErrNoRows)
+	query := "SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE id = ? AND tenant_id = ?"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(id, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 
-	sale, err := repo.GetByID(id)
+	sale, err := repo.GetByID(context.Background(), id)
 	require.Error(t, err)
 	assert.Nil(t, sale)
 	assert.Contains(t, err.Error(), fmt.Sprintf("sale with ID %s not found", id))
@@ -80,13 +105,13 @@ func TestCreate_Success(t *testing.T) {
 	defer db.Close()
 	repo := NewSalesRepository(db)
 
-	s := &models.Sale{ID: "testid", CustomerID: "cust1", SoldBy: "user1", SaleDate: "2025-05-10", TotalPrice: 75.5}
-	query := "INSERT INTO sales (id, customer_id, sold_by, sale_date, total_price, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	s := &models.Sale{ID: "testid", CustomerID: "cust1", SoldBy: "user1", SaleDate: "2025-05-10", TotalPrice: 75.5, TaxType: models.TaxVatable, VATAmount: 8.09}
+	query := "INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	mock.ExpectExec(regexp.QuoteMeta(query)).
-		WithArgs(s.ID, s.CustomerID, s.SoldBy, s.SaleDate, s.TotalPrice, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(s.ID, models.DefaultTenantID, s.CustomerID, s.SoldBy, s.SaleDate, s.TotalPrice, s.TaxType, s.VATAmount, models.SaleConfirmed, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	id, err := repo.Create(s)
+	id, err := repo.Create(context.Background(), s)
 	require.NoError(t, err)
 	assert.Equal(t, s.ID, id)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -97,21 +122,21 @@ func TestUpdate_Success(t *testing.T) {
 	defer db.Close()
 	repo := NewSalesRepository(db)
 
-	s := &models.Sale{ID: "s1", CustomerID: "cust2", SoldBy: "user2", SaleDate: "2025-05-11", TotalPrice: 120.0}
+	s := &models.Sale{ID: "s1", CustomerID: "cust2", SoldBy: "user2", SaleDate: "2025-05-11", TotalPrice: 120.0, TaxType: models.TaxExempt}
 	// Mock existing sale lookup
-	getQuery := "SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE id = ?"
+	getQuery := "SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE id = ? AND tenant_id = ?"
 	now := time.Now().Add(-time.Hour)
-	rowsGet := sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "created_at", "updated_at"}).
-		AddRow(s.ID, s.CustomerID, s.SoldBy, s.SaleDate, s.TotalPrice, now, now)
-	mock.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(s.ID).WillReturnRows(rowsGet)
+	rowsGet := sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "status", "created_at", "updated_at"}).
+		AddRow(s.ID, s.CustomerID, s.SoldBy, s.SaleDate, s.TotalPrice, models.TaxVatable, 12.86, models.SaleConfirmed, now, now)
+	mock.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(s.ID, models.DefaultTenantID).WillReturnRows(rowsGet)
 
 	// Mock update
-	updateQuery := "UPDATE sales SET customer_id = ?, sold_by = ?, sale_date = ?, total_price = ?, updated_at = ? WHERE id = ?"
+	updateQuery := "UPDATE sales SET customer_id = ?, sold_by = ?, sale_date = ?, total_price = ?, tax_type = ?, vat_amount = ?, updated_at = ? WHERE id = ? AND tenant_id = ?"
 	mock.ExpectExec(regexp.QuoteMeta(updateQuery)).
-		WithArgs(s.CustomerID, s.SoldBy, s.SaleDate, s.TotalPrice, sqlmock.AnyArg(), s.ID).
+		WithArgs(s.CustomerID, s.SoldBy, s.SaleDate, s.TotalPrice, s.TaxType, 0.0, sqlmock.AnyArg(), s.ID, models.DefaultTenantID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	err := repo.Update(s)
+	err := repo.Update(context.Background(), s)
 	require.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -122,10 +147,10 @@ func TestUpdate_NotExists(t *testing.T) {
 	repo := NewSalesRepository(db)
 
 	s := &models.Sale{ID: "notexists"}
-	getQuery := "SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE id = ?"
-	mock.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(s.ID).WillReturnError(sql.ErrNoRows)
+	getQuery := "SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE id = ? AND tenant_id = ?"
+	mock.ExpectQuery(regexp.QuoteMeta(getQuery)).WithArgs(s.ID, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 
-	err := repo.Update(s)
+	err := repo.Update(context.Background(), s)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), fmt.Sprintf("sale with ID %s not found for update", s.ID))
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -140,11 +165,11 @@ func TestDelete_Success(t *testing.T) {
 
 	// Mock transaction
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sales WHERE id = ?")).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected for main sale delete
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sale_items WHERE sale_id = ?")).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 2)) // 2 items deleted
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sales WHERE id = ? AND tenant_id = ?")).WithArgs(id, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1)) // 1 row affected for main sale delete
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sale_items WHERE sale_id = ? AND tenant_id = ?")).WithArgs(id, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 2)) // 2 items deleted
 	mock.ExpectCommit()
 
-	err := repo.Delete(id)
+	err := repo.Delete(context.Background(), id)
 	require.NoError(t, err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -158,11 +183,11 @@ func TestDelete_NotExists(t *testing.T) {
 
 	mock.ExpectBegin()
 	// Expect deletion attempt on 'sales' table, but it won't find the row.
-	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sales WHERE id = ?")).WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sales WHERE id = ? AND tenant_id = ?")).WithArgs(id, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0)) // 0 rows affected
 	// No ExpectExec for sale_items as it shouldn't be reached if the sale doesn't exist.
 	// The deferred Rollback will be called implicitly if Commit isn't reached.
 
-	err := repo.Delete(id)
+	err := repo.Delete(context.Background(), id)
 	require.Error(t, err)
 	assert.EqualError(t, err, fmt.Sprintf("sale with ID %s not found for deletion", id)) // Check for the specific error message
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -176,16 +201,39 @@ func TestGetSaleItems(t *testing.T) {
 	saleID := "sitems"
 	now := time.Now()
 	expected := []models.SaleItem{
-		{ID: "i1", SaleID: saleID, ItemType: "cab", MultiCabID: "10", AccessoryID: "", MaterialID: "", Quantity: 1, UnitPrice: 100.0, Subtotal: 100.0, CreatedAt: now, UpdatedAt: now},
+		{ID: "i1", SaleID: saleID, Item: models.IntRef(models.RefCab, 10), Quantity: 1, UnitPrice: 100.0, Subtotal: 100.0, CreatedAt: now, UpdatedAt: now},
 	}
 
 	rows := sqlmock.NewRows([]string{"id", "sale_id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price", "subtotal", "created_at", "updated_at"}).
-		AddRow(expected[0].ID, expected[0].SaleID, expected[0].ItemType, expected[0].MultiCabID, expected[0].AccessoryID, expected[0].MaterialID, expected[0].Quantity, expected[0].UnitPrice, expected[0].Subtotal, expected[0].CreatedAt, expected[0].UpdatedAt)
+		AddRow(expected[0].ID, expected[0].SaleID, "cab", "10", "", "", expected[0].Quantity, expected[0].UnitPrice, expected[0].Subtotal, expected[0].CreatedAt, expected[0].UpdatedAt)
 
-	query := "SELECT id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at FROM sale_items WHERE sale_id = ?"
-	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(saleID).WillReturnRows(rows)
+	query := "SELECT id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at FROM sale_items WHERE sale_id = ? AND tenant_id = ?"
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(saleID, models.DefaultTenantID).WillReturnRows(rows)
 
-	items, err := repo.GetSaleItems(saleID)
+	items, err := repo.GetSaleItems(context.Background(), saleID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, items)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetCustomerSaleItems(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	now := time.Now()
+	expected := []models.SaleItem{
+		{ID: "i1", SaleID: "s1", Item: models.IntRef(models.RefCab, 10), Quantity: 1, UnitPrice: 500, Subtotal: 500, CreatedAt: now, UpdatedAt: now, Name: "Carry"},
+		{ID: "i2", SaleID: "s2", Item: models.NewRef(models.RefMaterial, "m-4"), Quantity: 3, UnitPrice: 20, Subtotal: 60, CreatedAt: now, UpdatedAt: now, Name: "Paint"},
+	}
+	rows := sqlmock.NewRows([]string{"id", "sale_id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price", "subtotal", "created_at", "updated_at", "name"}).
+		AddRow("i1", "s1", "cab", "10", "", "", 1, 500.0, 500.0, now, now, "Carry").
+		AddRow("i2", "s2", "material", "", "", "m-4", 3, 20.0, 60.0, now, now, "Paint")
+	mock.ExpectQuery(`SELECT si.id, .* FROM sale_items si JOIN sales s .* LEFT JOIN materials mt .* WHERE si.tenant_id = \? AND s.customer_id = \?`).
+		WithArgs(models.DefaultTenantID, "c1").
+		WillReturnRows(rows)
+
+	items, err := repo.GetCustomerSaleItems(context.Background(), "c1")
 	require.NoError(t, err)
 	assert.Equal(t, expected, items)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -196,13 +244,13 @@ func TestCreateSaleItem(t *testing.T) {
 	defer db.Close()
 	repo := NewSalesRepository(db)
 
-	item := &models.SaleItem{ID: "item1", SaleID: "sale1", ItemType: "cab", MultiCabID: "5", AccessoryID: "", MaterialID: "", Quantity: 2, UnitPrice: 300.0, Subtotal: 600.0}
-	query := "INSERT INTO sale_items (id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	item := &models.SaleItem{ID: "item1", SaleID: "sale1", Item: models.IntRef(models.RefCab, 5), Quantity: 2, UnitPrice: 300.0, Subtotal: 600.0}
+	query := "INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 	mock.ExpectExec(regexp.QuoteMeta(query)).
-		WithArgs(item.ID, item.SaleID, item.ItemType, item.MultiCabID, item.AccessoryID, item.MaterialID, item.Quantity, item.UnitPrice, item.Subtotal, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(item.ID, models.DefaultTenantID, item.SaleID, "cab", "5", "", "", item.Quantity, item.UnitPrice, item.Subtotal, sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
-	id, err := repo.CreateSaleItem(item)
+	id, err := repo.CreateSaleItem(context.Background(), item)
 	require.NoError(t, err)
 	assert.Equal(t, item.ID, id)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -221,17 +269,21 @@ func TestSellCab_NoAccessories(t *testing.T) {
 	mock.ExpectBegin()
 	// Mock cab lookup
 	cabPrice := 500.0
-	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price FROM multicabs WHERE id = ?")).WithArgs(cabID).
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(cabID, models.DefaultTenantID).
 		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(cabID, "Test", cabPrice))
 	// Mock create sale
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sales (id, customer_id, sold_by, sale_date, total_price, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)")).WithArgs(sqlmock.AnyArg(), customer, user, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, customer, user, sqlmock.AnyArg(), sqlmock.AnyArg(), models.TaxVatable, 107.14, models.SaleConfirmed, true, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
 	// Mock cab sale item insert
-	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_items (id, sale_id, item_type, multi_cab_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)")).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "cab", cabID, quantity, cabPrice, cabPrice*float64(quantity), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, sqlmock.AnyArg(), "cab", cabID, quantity, cabPrice, cabPrice*float64(quantity), sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
 	// Mock update cab inventory
-	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ?")).WithArgs(quantity, sqlmock.AnyArg(), cabID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?")).WithArgs(quantity, sqlmock.AnyArg(), cabID, models.DefaultTenantID, quantity).WillReturnResult(sqlmock.NewResult(0, 1))
+	// One cab is left, so it becomes low on stock
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(cabID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(1, "In Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET status = ? WHERE id = ? AND tenant_id = ?")).WithArgs("Low Stock", cabID, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	sale, err := repo.SellCab(cabID, customer, quantity, user, nil)
+	sale, err := repo.SellCab(context.Background(), cabID, customer, quantity, user, "", nil)
 	require.NoError(t, err)
 	assert.Equal(t, customer, sale.CustomerID)
 	assert.Equal(t, user, sale.SoldBy)
@@ -239,5 +291,534 @@ func TestSellCab_NoAccessories(t *testing.T) {
 	assert.WithinDuration(t, time.Now(), sale.UpdatedAt, 5*time.Second)
 	expectedTotal := cabPrice * float64(quantity)
 	assert.Equal(t, expectedTotal, sale.TotalPrice)
+	assert.Equal(t, models.TaxVatable, sale.TaxType)
+	assert.Equal(t, 107.14, sale.VATAmount, "12% VAT included in the total")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSellCab_InsufficientStock(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	cabID := 10
+	quantity := 3
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(cabID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(cabID, "Test", 500.0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Fewer than three units left, so the guarded update matches no row
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?")).WithArgs(quantity, sqlmock.AnyArg(), cabID, models.DefaultTenantID, quantity).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err := repo.SellCab(context.Background(), cabID, "cust", quantity, "user", "", nil)
+	assert.True(t, errors.Is(err, ErrNegativeStock))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSellCab_SellsOutAccessory(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	cabID, accessoryID := 10, 4
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, price FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(cabID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(cabID, "Test", 500.0))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "cust", "user", sqlmock.AnyArg(), 700.0, models.TaxExempt, 0.0, models.SaleConfirmed, true, sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_items (id, tenant_id, sale_id, item_type, accessory_id, quantity, unit_price, subtotal, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?")).WithArgs(2, sqlmock.AnyArg(), accessoryID, models.DefaultTenantID, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	// The last two accessories are sold, so the accessory is out of stock
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM accessories WHERE id = ? AND tenant_id = ?")).WithArgs(accessoryID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(0, "Low Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET status = ? WHERE id = ? AND tenant_id = ?")).WithArgs("Out of Stock", accessoryID, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?")).WithArgs(1, sqlmock.AnyArg(), cabID, models.DefaultTenantID, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	// Plenty of cabs are left, so their status is kept
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(cabID, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(8, "Available"))
+	mock.ExpectCommit()
+
+	sale, err := repo.SellCab(context.Background(), cabID, "cust", 1, "user", models.TaxExempt, []models.AccessoryForSale{{ID: accessoryID, Price: 100, Quantity: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, models.TaxExempt, sale.TaxType)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSell(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	insertSale := regexp.QuoteMeta("INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at)")
+	insertItem := regexp.QuoteMeta("INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at)")
+	items := func() []models.SaleItem {
+		return []models.SaleItem{
+			{Item: models.IntRef(models.RefCab, 7), Quantity: 1, UnitPrice: 1000, Subtotal: 1000},
+			{Item: models.IntRef(models.RefAccessory, 4), Quantity: 2, UnitPrice: 50, Subtotal: 100},
+		}
+	}
+
+	// The sale, its items and taking them out of stock go in one transaction
+	mock.ExpectBegin()
+	mock.ExpectExec(insertSale).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "cust", "user", "2025-03-04", 1100.0, models.TaxExempt, 0.0, models.SaleConfirmed, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, sqlmock.AnyArg(), "cab", "7", "", "", 1, 1000.0, 1000.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, sqlmock.AnyArg(), "accessory", "", "4", "", 2, 50.0, 100.0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?")).
+		WithArgs(1, sqlmock.AnyArg(), 7, models.DefaultTenantID, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(7, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(4, "Available"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?")).
+		WithArgs(2, sqlmock.AnyArg(), 4, models.DefaultTenantID, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM accessories WHERE id = ? AND tenant_id = ?")).WithArgs(4, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(0, "Low Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET status = ? WHERE id = ? AND tenant_id = ?")).WithArgs("Out of Stock", 4, models.DefaultTenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	sale := &models.Sale{CustomerID: "cust", SoldBy: "user", SaleDate: "2025-03-04", TotalPrice: 1100, TaxType: models.TaxExempt}
+	sold := items()
+	require.NoError(t, repo.Sell(context.Background(), sale, sold, true))
+	assert.NotEmpty(t, sale.ID)
+	assert.True(t, sale.StockTaken)
+	assert.NotEqual(t, sold[0].ID, sold[1].ID)
+	assert.Equal(t, sale.ID, sold[1].SaleID)
+
+	// Fewer cabs in stock than are sold rolls everything back
+	mock.ExpectBegin()
+	mock.ExpectExec(insertSale).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity - ?")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := repo.Sell(context.Background(), &models.Sale{CustomerID: "cust", SoldBy: "user", SaleDate: "2025-03-04", TotalPrice: 1100}, items(), true)
+	assert.ErrorIs(t, err, ErrNegativeStock)
+
+	// Without taking stock only the sale and its items are recorded
+	mock.ExpectBegin()
+	mock.ExpectExec(insertSale).WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "cust", "user", "2025-03-04", 1100.0, "", 0.0, models.SaleConfirmed, false, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertItem).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Sell(context.Background(), &models.Sale{CustomerID: "cust", SoldBy: "user", SaleDate: "2025-03-04", TotalPrice: 1100}, items(), false))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetSaleStatus(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	// Cancelling a confirmed sale that took stock puts its cab and accessory back
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status, stock_taken FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleConfirmed, true))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sales SET status = ?, updated_at = ? WHERE id = ? AND tenant_id = ?")).
+		WithArgs(models.SaleCancelled, sqlmock.AnyArg(), "sale-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"item_type", "multi_cab_id", "accessory_id", "quantity"}).
+			AddRow("cab", "7", "", 1).
+			AddRow("accessory", "", "4", 2).
+			AddRow("material", "", "", 5))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity + ?, updated_at = ? WHERE id = ? AND tenant_id = ?")).
+		WithArgs(1, sqlmock.AnyArg(), 7, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(7, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(1, "Out of Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET status = ? WHERE id = ? AND tenant_id = ?")).WithArgs("Low Stock", 7, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET quantity = quantity + ?, updated_at = ? WHERE id = ? AND tenant_id = ?")).
+		WithArgs(2, sqlmock.AnyArg(), 4, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM accessories WHERE id = ? AND tenant_id = ?")).WithArgs(4, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(2, "Out of Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET status = ? WHERE id = ? AND tenant_id = ?")).WithArgs("Low Stock", 4, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetStatus(context.Background(), "sale-1", models.SaleCancelled))
+
+	// Completing a sale leaves stock alone
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-2", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleConfirmed, true))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sales SET status = ?")).
+		WithArgs(models.SaleCompleted, sqlmock.AnyArg(), "sale-2", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetStatus(context.Background(), "sale-2", models.SaleCompleted))
+
+	// Cancelling a confirmed sale that took no stock leaves stock alone
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-3", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleConfirmed, false))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sales SET status = ?")).
+		WithArgs(models.SaleCancelled, sqlmock.AnyArg(), "sale-3", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.SetStatus(context.Background(), "sale-3", models.SaleCancelled))
+
+	// A cancelled sale is final
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleCancelled, true))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.SetStatus(context.Background(), "sale-1", models.SaleConfirmed), ErrSaleTransition)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-9", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.SetStatus(context.Background(), "sale-9", models.SaleCompleted), sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRefundSale(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	// Returning one of two accessories of a completed sale puts it back in stock and
+	// leaves the sale completed
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT status, stock_taken FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleCompleted, true))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ? AND tenant_id = ?")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price"}).
+			AddRow("item-1", "cab", "7", "", "", 1, 1000.0).
+			AddRow("item-2", "accessory", "", "4", "", 2, 49.99))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT sale_item_id, SUM(quantity) FROM sale_refund_items WHERE tenant_id = ? AND sale_id = ? GROUP BY sale_item_id")).
+		WithArgs(models.DefaultTenantID, "sale-1").WillReturnRows(sqlmock.NewRows([]string{"sale_item_id", "quantity"}))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refunds (id, tenant_id, sale_id, amount, reason, refunded_by, refunded_at)")).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "sale-1", 49.99, "Damaged", "user-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refund_items (tenant_id, refund_id, sale_id, sale_item_id, quantity, amount)")).
+		WithArgs(models.DefaultTenantID, sqlmock.AnyArg(), "sale-1", "item-2", 1, 49.99).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET quantity = quantity + ?, updated_at = ? WHERE id = ? AND tenant_id = ?")).
+		WithArgs(1, sqlmock.AnyArg(), 4, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM accessories WHERE id = ? AND tenant_id = ?")).WithArgs(4, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(1, "Out of Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET status = ? WHERE id = ? AND tenant_id = ?")).WithArgs("Low Stock", 4, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	refund := &models.SaleRefund{SaleID: "sale-1", Items: []models.SaleRefundItem{{SaleItemID: "item-2", Quantity: 1}}, Reason: "Damaged", RefundedBy: "user-1"}
+	require.NoError(t, repo.Refund(context.Background(), refund))
+	assert.NotEmpty(t, refund.ID)
+	assert.Equal(t, 49.99, refund.Amount)
+	assert.Equal(t, models.IntRef(models.RefAccessory, 4), refund.Items[0].Item)
+
+	// Returning the rest refunds the sale
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleCompleted, true))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-1", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price"}).
+			AddRow("item-1", "cab", "7", "", "", 1, 1000.0).
+			AddRow("item-2", "accessory", "", "4", "", 2, 49.99))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_refund_items")).WithArgs(models.DefaultTenantID, "sale-1").
+		WillReturnRows(sqlmock.NewRows([]string{"sale_item_id", "quantity"}).AddRow("item-2", 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refunds")).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "sale-1", 1049.99, "", "user-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refund_items")).
+		WithArgs(models.DefaultTenantID, sqlmock.AnyArg(), "sale-1", "item-1", 1, 1000.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refund_items")).
+		WithArgs(models.DefaultTenantID, sqlmock.AnyArg(), "sale-1", "item-2", 1, 49.99).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity + ?")).
+		WithArgs(1, sqlmock.AnyArg(), 7, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM multicabs")).WithArgs(7, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(1, "Low Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE accessories SET quantity = quantity + ?")).
+		WithArgs(1, sqlmock.AnyArg(), 4, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM accessories")).WithArgs(4, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(2, "Low Stock"))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sales SET status = ?, updated_at = ? WHERE id = ? AND tenant_id = ?")).
+		WithArgs(models.SaleRefunded, sqlmock.AnyArg(), "sale-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Refund(context.Background(), &models.SaleRefund{SaleID: "sale-1", RefundedBy: "user-1"}))
+
+	// More than is left to return is refused
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-2", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleCompleted, true))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-2", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price"}).
+			AddRow("item-3", "accessory", "", "4", "", 2, 49.99))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_refund_items")).WithArgs(models.DefaultTenantID, "sale-2").
+		WillReturnRows(sqlmock.NewRows([]string{"sale_item_id", "quantity"}).AddRow("item-3", 1))
+	mock.ExpectRollback()
+
+	err := repo.Refund(context.Background(), &models.SaleRefund{SaleID: "sale-2", Items: []models.SaleRefundItem{{SaleItemID: "item-3", Quantity: 2}}})
+	assert.ErrorIs(t, err, ErrRefundQuantity)
+
+	// Returning the items of a confirmed sale that took no stock refunds it without
+	// putting anything back
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-4", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleConfirmed, false))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-4", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price"}).
+			AddRow("item-4", "accessory", "", "4", "", 1, 49.99))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_refund_items")).WithArgs(models.DefaultTenantID, "sale-4").
+		WillReturnRows(sqlmock.NewRows([]string{"sale_item_id", "quantity"}))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refunds")).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "sale-4", 49.99, "", "user-1", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO sale_refund_items")).
+		WithArgs(models.DefaultTenantID, sqlmock.AnyArg(), "sale-4", "item-4", 1, 49.99).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sales SET status = ?")).
+		WithArgs(models.SaleRefunded, sqlmock.AnyArg(), "sale-4", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	require.NoError(t, repo.Refund(context.Background(), &models.SaleRefund{SaleID: "sale-4", RefundedBy: "user-1"}))
+
+	// Drafts cannot be refunded
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-3", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "stock_taken"}).AddRow(models.SaleDraft, false))
+	mock.ExpectRollback()
+
+	assert.ErrorIs(t, repo.Refund(context.Background(), &models.SaleRefund{SaleID: "sale-3"}), ErrSaleTransition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	rows := sqlmock.NewRows([]string{"sold_by", "revenue", "units", "sales"}).
+		AddRow("user2", 700.0, 3, 2).
+		AddRow("user1", 500.0, 1, 1)
+	query := `
+		SELECT s.sold_by, SUM(s.total_price), COALESCE(SUM(items.units), 0), COUNT(*)
+		FROM sales s
+		LEFT JOIN (
+			SELECT sale_id, SUM(quantity) AS units FROM sale_items WHERE tenant_id = ? GROUP BY sale_id
+		) items ON items.sale_id = s.id
+		WHERE s.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ? AND s.status IN ('confirmed', 'completed')
+		GROUP BY s.sold_by
+		ORDER BY SUM(s.total_price) DESC, COALESCE(SUM(items.units), 0) DESC, COUNT(*) DESC, s.sold_by ASC
+	`
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(models.DefaultTenantID, models.DefaultTenantID, "2025-05-01", "2025-05-31").
+		WillReturnRows(rows)
+
+	entries, err := repo.GetLeaderboard(context.Background(), "2025-05-01", "2025-05-31")
+	require.NoError(t, err)
+	assert.Equal(t, []models.LeaderboardEntry{
+		{Rank: 1, UserID: "user2", Revenue: 700, UnitsSold: 3, SalesCount: 2},
+		{Rank: 2, UserID: "user1", Revenue: 500, UnitsSold: 1, SalesCount: 1},
+	}, entries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBasketPairs(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM sales WHERE tenant_id = ? AND status IN ('confirmed', 'completed')`)).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(4))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT si.accessory_id, COUNT(DISTINCT si.sale_id)
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		WHERE si.tenant_id = ? AND si.item_type = 'accessory' AND si.accessory_id IS NOT NULL AND s.status IN ('confirmed', 'completed')
+		GROUP BY si.accessory_id
+	`)).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"accessory_id", "sales"}).AddRow(1, 2).AddRow(2, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT a.accessory_id, b.accessory_id, COUNT(DISTINCT a.sale_id)
+		FROM sale_items a
+		JOIN sale_items b ON b.tenant_id = a.tenant_id AND b.sale_id = a.sale_id AND b.accessory_id <> a.accessory_id
+		JOIN sales s ON s.tenant_id = a.tenant_id AND s.id = a.sale_id
+		WHERE a.tenant_id = ? AND a.item_type = 'accessory' AND b.item_type = 'accessory' AND s.status IN ('confirmed', 'completed')
+		GROUP BY a.accessory_id, b.accessory_id
+	`)).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b", "sales"}).AddRow(1, 2, 1).AddRow(2, 1, 1))
+
+	pairs, err := repo.GetBasketPairs(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []models.BasketPair{
+		{AccessoryID: 2, PairedAccessoryID: 1, SalesTogether: 1, Support: 0.25, Confidence: 1},
+		{AccessoryID: 1, PairedAccessoryID: 2, SalesTogether: 1, Support: 0.25, Confidence: 0.5},
+	}, pairs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPivot(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	query := `
+		SELECT COALESCE(m.make, a.make, '') AS pivot_row, DATE_FORMAT(s.sale_date, '%Y-%m') AS pivot_col, SUM(si.quantity)
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		LEFT JOIN multicabs m ON si.item_type = 'cab' AND m.tenant_id = si.tenant_id AND m.id = si.multi_cab_id
+		LEFT JOIN accessories a ON si.item_type = 'accessory' AND a.tenant_id = si.tenant_id AND a.id = si.accessory_id
+		WHERE si.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ? AND s.status IN ('confirmed', 'completed')
+		GROUP BY pivot_row, pivot_col
+		ORDER BY pivot_row, pivot_col
+	`
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(models.DefaultTenantID, "2025-01-01", "2025-06-30").
+		WillReturnRows(sqlmock.NewRows([]string{"pivot_row", "pivot_col", "units"}).AddRow("Suzuki", "2025-02", 3).AddRow("Suzuki", "2025-03", 1))
+
+	cells, err := repo.GetPivot(context.Background(), models.PivotMake, models.PivotMonth, models.PivotUnits, "2025-01-01", "2025-06-30")
+	require.NoError(t, err)
+	assert.Equal(t, []models.PivotCell{{Row: "Suzuki", Col: "2025-02", Value: 3}, {Row: "Suzuki", Col: "2025-03", Value: 1}}, cells)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = repo.GetPivot(context.Background(), "s.id; DROP TABLE sales", models.PivotMonth, models.PivotUnits, "2025-01-01", "2025-06-30")
+	assert.Error(t, err, "dimensions outside the allowed set never reach the database")
+}
+
+func TestGetSalesSummary(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	periods := `
+		SELECT DATE_FORMAT(s.sale_date, '%Y-%m-01') AS period_start, COUNT(*), SUM(s.total_price), COALESCE(SUM(items.units), 0)
+		FROM sales s
+		LEFT JOIN (
+			SELECT sale_id, SUM(quantity) AS units FROM sale_items WHERE tenant_id = ? GROUP BY sale_id
+		) items ON items.sale_id = s.id
+		WHERE s.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ? AND s.status IN ('confirmed', 'completed')
+		GROUP BY period_start
+		ORDER BY period_start
+	`
+	mock.ExpectQuery(regexp.QuoteMeta(periods)).
+		WithArgs(models.DefaultTenantID, models.DefaultTenantID, "2025-01-01", "2025-03-31").
+		WillReturnRows(sqlmock.NewRows([]string{"period_start", "count", "revenue", "units"}).AddRow("2025-01-01", 2, 1500.0, 3).AddRow("2025-03-01", 1, 80.0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT si.multi_cab_id, COALESCE(i.name, ''), COALESCE(i.make, ''), SUM(si.quantity), SUM(si.subtotal)")).
+		WithArgs(models.DefaultTenantID, "cab", "2025-01-01", "2025-03-31", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "make", "units", "revenue"}).AddRow(7, "Carry", "Suzuki", 2, 1400.0))
+	mock.ExpectQuery(regexp.QuoteMeta("LEFT JOIN accessories i ON i.tenant_id = si.tenant_id AND i.id = si.accessory_id")+
+		`.*`+regexp.QuoteMeta("AND s.status IN ('confirmed', 'completed')")).
+		WithArgs(models.DefaultTenantID, "accessory", "2025-01-01", "2025-03-31", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "make", "units", "revenue"}))
+
+	summary, err := repo.GetSalesSummary(context.Background(), models.SummaryMonth, "2025-01-01", "2025-03-31", 3)
+	require.NoError(t, err)
+	assert.Equal(t, &models.SalesSummary{
+		Periods: []models.SalesSummaryPeriod{
+			{StartDate: "2025-01-01", SalesCount: 2, Revenue: 1500, UnitsSold: 3},
+			{StartDate: "2025-03-01", SalesCount: 1, Revenue: 80},
+		},
+		TopCabs:        []models.TopSeller{{ItemID: 7, Name: "Carry", Make: "Suzuki", UnitsSold: 2, Revenue: 1400}},
+		TopAccessories: []models.TopSeller{},
+	}, summary)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = repo.GetSalesSummary(context.Background(), "quarter", "2025-01-01", "2025-03-31", 3)
+	assert.Error(t, err)
+}
+
+func TestGetPossibleDuplicates(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	created := time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC)
+	saleColumns := []string{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "status", "created_at", "updated_at"}
+	mock.ExpectQuery(regexp.QuoteMeta("HAVING COUNT(*) > 1")).
+		WithArgs(models.DefaultTenantID, "2025-03-01", "2025-03-31", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows(saleColumns).
+			AddRow("sale-2", "cust-1", "staff-1", "2025-03-04", 1500.0, models.TaxVatable, 160.71, models.SaleConfirmed, created.Add(time.Minute), created.Add(time.Minute)).
+			AddRow("sale-1", "cust-1", "staff-1", "2025-03-04", 1500.0, models.TaxVatable, 160.71, models.SaleConfirmed, created, created).
+			AddRow("sale-3", "cust-1", "staff-1", "2025-03-04", 1500.0, models.TaxVatable, 160.71, models.SaleConfirmed, created, created))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items si")).
+		WithArgs(models.DefaultTenantID, "2025-03-01", "2025-03-31", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"sale_id", "item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price"}).
+			AddRow("sale-1", "accessory", "", "4", "", 3, 500.0).
+			AddRow("sale-2", "accessory", "", "4", "", 3, 500.0).
+			AddRow("sale-3", "accessory", "", "5", "", 3, 500.0))
+
+	groups, err := repo.GetPossibleDuplicates(context.Background(), "2025-03-01", "2025-03-31")
+	require.NoError(t, err)
+	require.Len(t, groups, 1, "sale-3 has the same total but other items")
+	assert.Equal(t, []models.DuplicateSaleItem{{ItemType: "accessory", ItemID: "4", Quantity: 3, UnitPrice: 500}}, groups[0].Items)
+	require.Len(t, groups[0].Sales, 2)
+	assert.Equal(t, "sale-1", groups[0].Sales[0].ID, "the oldest sale comes first")
+	assert.Equal(t, "sale-2", groups[0].Sales[1].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestVoidDuplicate(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewSalesRepository(db)
+
+	created := time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC)
+	saleRow := func(id string, total float64, status string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "status", "stock_taken", "created_at", "updated_at"}).
+			AddRow(id, "cust-1", "staff-1", "2025-03-04", total, models.TaxVatable, 0.0, status, true, created, created)
+	}
+	itemRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"item_type", "multi_cab_id", "accessory_id", "material_id", "quantity", "unit_price"}).
+			AddRow("cab", "7", "", "", 1, 1500.0)
+	}
+
+	// The voided sale took its cab out of stock, so the cab goes back
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-2", models.DefaultTenantID).WillReturnRows(saleRow("sale-2", 1500, models.SaleConfirmed))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-2", models.DefaultTenantID).WillReturnRows(itemRows())
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).WillReturnRows(saleRow("sale-1", 1500, models.SaleCompleted))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-1", models.DefaultTenantID).WillReturnRows(itemRows())
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO duplicate_sale_voids")).
+		WithArgs("sale-2", models.DefaultTenantID, "sale-1", "cust-1", "2025-03-04", 1500.0, "staff-1", "admin-1", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-2", models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"item_type", "multi_cab_id", "accessory_id", "quantity"}).AddRow("cab", "7", "", 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE multicabs SET quantity = quantity + ?, updated_at = ? WHERE id = ? AND tenant_id = ?")).
+		WithArgs(1, sqlmock.AnyArg(), 7, models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT quantity, status FROM multicabs WHERE id = ? AND tenant_id = ?")).WithArgs(7, models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity", "status"}).AddRow(3, "Available"))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sale_items WHERE sale_id = ?")).WithArgs("sale-2", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM sales WHERE id = ?")).WithArgs("sale-2", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	void, err := repo.VoidDuplicate(context.Background(), "sale-2", "sale-1", "admin-1")
+	require.NoError(t, err)
+	assert.Equal(t, "sale-1", void.DuplicateOf)
+	assert.Equal(t, "admin-1", void.VoidedBy)
+
+	// A sale with another total is not a duplicate, and nothing is deleted
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-3", models.DefaultTenantID).WillReturnRows(saleRow("sale-3", 900, models.SaleConfirmed))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-3", models.DefaultTenantID).WillReturnRows(itemRows())
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).WillReturnRows(saleRow("sale-1", 1500, models.SaleConfirmed))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-1", models.DefaultTenantID).WillReturnRows(itemRows())
+	mock.ExpectRollback()
+
+	_, err = repo.VoidDuplicate(context.Background(), "sale-3", "sale-1", "admin-1")
+	assert.ErrorIs(t, err, ErrNotDuplicateSale)
+
+	// A cancelled sale already put its items back, so it cannot be voided
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-4", models.DefaultTenantID).WillReturnRows(saleRow("sale-4", 1500, models.SaleCancelled))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-4", models.DefaultTenantID).WillReturnRows(itemRows())
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-1", models.DefaultTenantID).WillReturnRows(saleRow("sale-1", 1500, models.SaleConfirmed))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM sale_items WHERE sale_id = ?")).WithArgs("sale-1", models.DefaultTenantID).WillReturnRows(itemRows())
+	mock.ExpectRollback()
+
+	_, err = repo.VoidDuplicate(context.Background(), "sale-4", "sale-1", "admin-1")
+	assert.ErrorIs(t, err, ErrSaleTransition)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FOR UPDATE")).WithArgs("sale-9", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err = repo.VoidDuplicate(context.Background(), "sale-9", "sale-1", "admin-1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
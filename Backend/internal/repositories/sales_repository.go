@@ -1,74 +1,237 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"oop/internal/models"
 	"strings"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // SalesRepository defines the interface for sales data operations
 type SalesRepository interface {
-	GetAll(filters map[string]interface{}) ([]models.Sale, error)
-	GetByID(id string) (*models.Sale, error)
-	GetCustomerSales(customerID string) ([]models.Sale, error)
-	Create(sale *models.Sale) (string, error)
-	Update(sale *models.Sale) error
-	Delete(id string) error
-	GetSaleItems(saleID string) ([]models.SaleItem, error)
-	CreateSaleItem(item *models.SaleItem) (string, error)
-	SellCab(cabID int, customerID string, quantity int, soldBy string, accessories []models.AccessoryForSale) (*models.Sale, error)
+	// GetAll returns the sales matching the filter, newest first.
+	GetAll(ctx context.Context, filter models.SalesFilter) ([]models.Sale, error)
+	// GetPaginated returns one page of the sales matching the filter, newest first,
+	// along with the total number of matches. Pages start at 1.
+	GetPaginated(ctx context.Context, page, limit int, filter models.SalesFilter) ([]models.Sale, int64, error)
+	GetByID(ctx context.Context, id string) (*models.Sale, error)
+	GetCustomerSales(ctx context.Context, customerID string) ([]models.Sale, error)
+	// GetCustomerSaleItems returns the items of every sale of a customer with the
+	// name of the cab, accessory or material sold, in the order they were added.
+	GetCustomerSaleItems(ctx context.Context, customerID string) ([]models.SaleItem, error)
+	// Create records a sale without taking anything out of stock, so calling it
+	// off does not restock its items.
+	Create(ctx context.Context, sale *models.Sale) (string, error)
+	Update(ctx context.Context, sale *models.Sale) error
+	Delete(ctx context.Context, id string) error
+	// SetStatus moves a sale to status and, when that cancels or refunds a confirmed
+	// or completed sale that took stock, puts its cabs and accessories back in stock,
+	// in one transaction. It fails with sql.ErrNoRows when the sale does not exist and with
+	// ErrSaleTransition when it cannot move from its current status to status.
+	SetStatus(ctx context.Context, id, status string) error
+	// Refund records the return of items of a confirmed or completed sale, as
+	// planned by PlanRefund, puts the cabs and accessories returned back in stock
+	// when the sale took them out of stock and, when nothing is left to return,
+	// marks the sale refunded, in one transaction. It fails with sql.ErrNoRows when
+	// the sale does not exist, with ErrSaleTransition when it is neither confirmed
+	// nor completed and with ErrRefundQuantity when more is returned than is left to
+	// return.
+	Refund(ctx context.Context, refund *models.SaleRefund) error
+	// GetRefunds returns the refunds of a sale, oldest first.
+	GetRefunds(ctx context.Context, saleID string) ([]models.SaleRefund, error)
+	GetSaleItems(ctx context.Context, saleID string) ([]models.SaleItem, error)
+	CreateSaleItem(ctx context.Context, item *models.SaleItem) (string, error)
+	// SellCab records the sale of a cab with optional accessories and takes them out
+	// of stock in one transaction, updating their status. It fails with
+	// ErrNegativeStock when fewer units are in stock than are sold. An empty tax type
+	// is vatable.
+	SellCab(ctx context.Context, cabID int, customerID string, quantity int, soldBy, taxType string, accessories []models.AccessoryForSale) (*models.Sale, error)
+	// Sell records a sale with its items in one transaction, filling in their IDs.
+	// With takeStock it takes the cabs and accessories sold out of stock, updating
+	// their status, and fails with ErrNegativeStock when fewer units are in stock
+	// than are sold; without, stock is left alone, as for sales made before their
+	// items were counted. Nothing is recorded when it fails.
+	Sell(ctx context.Context, sale *models.Sale, items []models.SaleItem, takeStock bool) error
+	// GetLeaderboard ranks the staff who sold between two sale dates (inclusive,
+	// YYYY-MM-DD) by revenue, then units sold, then number of sales, then user ID.
+	// Like every report, it only counts confirmed and completed sales.
+	GetLeaderboard(ctx context.Context, startDate, endDate string) ([]models.LeaderboardEntry, error)
+	// GetBasketPairs returns every pair of accessories sold together, in both
+	// directions, with their support and confidence across all confirmed and
+	// completed sales.
+	GetBasketPairs(ctx context.Context) ([]models.BasketPair, error)
+	// GetPivot totals measure over the items of the sales between two sale dates
+	// (inclusive, YYYY-MM-DD), grouped by the rows and cols dimensions. Both must be
+	// models.PivotDimensions and measure one of models.PivotMeasures. Only non-empty
+	// cells are returned, ordered by row then column.
+	GetPivot(ctx context.Context, rows, cols, measure, startDate, endDate string) ([]models.PivotCell, error)
+	// GetSalesSummary totals the sales between two sale dates (inclusive, YYYY-MM-DD)
+	// by day, week or month, one of the models.Summary* groupings, and ranks the top
+	// cabs and accessories by units sold, then revenue, then ID.
+	GetSalesSummary(ctx context.Context, groupBy, startDate, endDate string, top int) (*models.SalesSummary, error)
+	// GetPossibleDuplicates groups the sales between two sale dates (inclusive,
+	// YYYY-MM-DD) that have the same customer, day, total and items, as grouped by
+	// GroupDuplicateSales. Cancelled and refunded sales are left out.
+	GetPossibleDuplicates(ctx context.Context, startDate, endDate string) ([]models.DuplicateSaleGroup, error)
+	// VoidDuplicate deletes a sale and its items as a duplicate of another sale,
+	// puts the cabs and accessories the sale took out of stock back and records the
+	// void, in one transaction. It fails with sql.ErrNoRows when either sale does not
+	// exist, with ErrSaleTransition when either was cancelled or refunded and with
+	// ErrNotDuplicateSale when they are not duplicates.
+	VoidDuplicate(ctx context.Context, id, duplicateOf, voidedBy string) (*models.DuplicateSaleVoid, error)
+	// GetDuplicateVoids returns the voids of sales dated between two sale dates
+	// (inclusive, YYYY-MM-DD), most recently voided first.
+	GetDuplicateVoids(ctx context.Context, startDate, endDate string) ([]models.DuplicateSaleVoid, error)
+}
+
+// ErrSaleTransition is returned when a sale is moved to a status its current status
+// cannot move to
+var ErrSaleTransition = errors.New("sale status transition not allowed")
+
+// ErrRefundQuantity is returned when a refund returns more of an item than is left
+// to return, or an item the sale does not have
+var ErrRefundQuantity = errors.New("refund quantity exceeds the quantity left to return")
+
+// PlanRefund fills in the items and amounts of a refund of a sale with items, of
+// which earlier refunds returned the units in refunded, by sale item ID. A refund
+// without items returns every unit left. It returns the items to put back in stock
+// and whether nothing is left to return after the refund.
+func PlanRefund(refund *models.SaleRefund, items []models.SaleItem, refunded map[string]int) ([]models.SaleItem, bool, error) {
+	sold := make(map[string]models.SaleItem, len(items))
+	left := make(map[string]int, len(items))
+	for _, item := range items {
+		sold[item.ID] = item
+		left[item.ID] = item.Quantity - refunded[item.ID]
+	}
+	if len(refund.Items) == 0 {
+		for _, item := range items {
+			if left[item.ID] > 0 {
+				refund.Items = append(refund.Items, models.SaleRefundItem{SaleItemID: item.ID, Quantity: left[item.ID]})
+			}
+		}
+		if len(refund.Items) == 0 {
+			return nil, false, fmt.Errorf("sale %s has nothing left to return: %w", refund.SaleID, ErrRefundQuantity)
+		}
+	}
+
+	restock := make([]models.SaleItem, 0, len(refund.Items))
+	refund.Amount = 0
+	for i, line := range refund.Items {
+		item, ok := sold[line.SaleItemID]
+		if !ok || line.Quantity <= 0 || line.Quantity > left[line.SaleItemID] {
+			return nil, false, fmt.Errorf("return of %d of item %s of sale %s with %d left: %w",
+				line.Quantity, line.SaleItemID, refund.SaleID, left[line.SaleItemID], ErrRefundQuantity)
+		}
+		left[line.SaleItemID] -= line.Quantity
+		refund.Items[i].Item = item.Item
+		refund.Items[i].Amount = math.Round(float64(line.Quantity)*item.UnitPrice*100) / 100
+		refund.Amount += refund.Items[i].Amount
+		restock = append(restock, models.SaleItem{ID: item.ID, SaleID: item.SaleID, Item: item.Item, Quantity: line.Quantity})
+	}
+	refund.Amount = math.Round(refund.Amount*100) / 100
+
+	for _, quantity := range left {
+		if quantity > 0 {
+			return restock, false, nil
+		}
+	}
+	return restock, true, nil
 }
 
 // salesRepository is a database implementation of SalesRepository
 type salesRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
 }
 
-// NewSalesRepository creates a new instance of the sales repository
+// NewSalesRepository creates a new instance of the sales repository for the default tenant
 func NewSalesRepository(db *sql.DB) SalesRepository {
-	return &salesRepository{DB: db}
+	return &salesRepository{DB: db, TenantID: models.DefaultTenantID}
 }
 
 // GetAll retrieves all sales from the database, with optional filtering
-// TODO: Implement proper filtering based on the filters map
-func (r *salesRepository) GetAll(filters map[string]interface{}) ([]models.Sale, error) {
-	query := `SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE 1=1`
-	args := []interface{}{}
+func (r *salesRepository) GetAll(ctx context.Context, filter models.SalesFilter) ([]models.Sale, error) {
+	where, args := r.salesFilter(filter)
+	query := `SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales` + where
+	query += " ORDER BY created_at DESC"
 
-	// Apply filters
-	if customerID, ok := filters["customer_id"].(string); ok && customerID != "" {
-		query += " AND customer_id = ?"
-		args = append(args, customerID)
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Error querying sales: %v\nQuery: %s\nArgs: %v", err, query, args)
+		return nil, err
 	}
+	defer rows.Close()
 
-	if soldBy, ok := filters["sold_by"].(string); ok && soldBy != "" {
-		query += " AND sold_by = ?"
-		args = append(args, soldBy)
+	var sales []models.Sale
+	if err := scanSales(rows, &sales); err != nil {
+		return nil, err
 	}
+	return sales, nil
+}
 
-	if startDate, ok := filters["start_date"].(string); ok && startDate != "" {
-		query += " AND sale_date >= ?"
-		args = append(args, startDate)
-	}
+// GetPaginated retrieves one page of the sales with optional filtering
+func (r *salesRepository) GetPaginated(ctx context.Context, page, limit int, filter models.SalesFilter) ([]models.Sale, int64, error) {
+	where, args := r.salesFilter(filter)
 
-	if endDate, ok := filters["end_date"].(string); ok && endDate != "" {
-		query += " AND sale_date <= ?"
-		args = append(args, endDate)
+	// Get total count
+	var total int64
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM sales`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count sales: %w", err)
 	}
 
-	query += " ORDER BY created_at DESC"
-
-	rows, err := r.DB.Query(query, args...)
+	query := `SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales` + where
+	query += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
+	rows, err := r.DB.QueryContext(ctx, query, append(args, limit, (page-1)*limit)...)
 	if err != nil {
-		log.Printf("Error querying sales: %v\nQuery: %s\nArgs: %v", err, query, args)
-		return nil, err
+		return nil, 0, fmt.Errorf("failed to query sales: %w", err)
 	}
 	defer rows.Close()
 
-	var sales []models.Sale
+	sales := []models.Sale{}
+	if err := scanSales(rows, &sales); err != nil {
+		return nil, 0, err
+	}
+	return sales, total, nil
+}
+
+// salesFilter returns the WHERE clause and its arguments for a sales filter. Filter
+// values only reach the query as arguments.
+func (r *salesRepository) salesFilter(filter models.SalesFilter) (string, []interface{}) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{r.TenantID}
+
+	if filter.CustomerID != "" {
+		conditions = append(conditions, "customer_id = ?")
+		args = append(args, filter.CustomerID)
+	}
+	if filter.SoldBy != "" {
+		conditions = append(conditions, "sold_by = ?")
+		args = append(args, filter.SoldBy)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.StartDate != "" {
+		conditions = append(conditions, "sale_date >= ?")
+		args = append(args, filter.StartDate)
+	}
+	if filter.EndDate != "" {
+		conditions = append(conditions, "sale_date <= ?")
+		args = append(args, filter.EndDate)
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// scanSales appends the sales read from rows
+func scanSales(rows *sql.Rows, sales *[]models.Sale) error {
 	for rows.Next() {
 		var sale models.Sale
 		var createdAt, updatedAt time.Time
@@ -79,30 +242,32 @@ func (r *salesRepository) GetAll(filters map[string]interface{}) ([]models.Sale,
 			&sale.SoldBy,
 			&sale.SaleDate,
 			&sale.TotalPrice,
+			&sale.TaxType,
+			&sale.VATAmount,
+			&sale.Status,
 			&createdAt,
 			&updatedAt,
 		); err != nil {
 			log.Printf("Error scanning sale row: %v", err)
-			return nil, err
+			return err
 		}
 
 		sale.CreatedAt = createdAt
 		sale.UpdatedAt = updatedAt
-		sales = append(sales, sale)
+		*sales = append(*sales, sale)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		log.Printf("Error iterating sale rows: %v", err)
-		return nil, err
+		return err
 	}
-
-	return sales, nil
+	return nil
 }
 
 // GetByID retrieves a single sale by its ID
-func (r *salesRepository) GetByID(id string) (*models.Sale, error) {
-	query := `SELECT id, customer_id, sold_by, sale_date, total_price, created_at, updated_at FROM sales WHERE id = ?`
-	row := r.DB.QueryRow(query, id)
+func (r *salesRepository) GetByID(ctx context.Context, id string) (*models.Sale, error) {
+	query := `SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at FROM sales WHERE id = ? AND tenant_id = ?`
+	row := r.DB.QueryRowContext(ctx, query, id, r.TenantID)
 
 	var sale models.Sale
 	var createdAt, updatedAt time.Time
@@ -113,6 +278,9 @@ func (r *salesRepository) GetByID(id string) (*models.Sale, error) {
 		&sale.SoldBy,
 		&sale.SaleDate,
 		&sale.TotalPrice,
+		&sale.TaxType,
+		&sale.VATAmount,
+		&sale.Status,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
@@ -129,17 +297,64 @@ func (r *salesRepository) GetByID(id string) (*models.Sale, error) {
 }
 
 // GetCustomerSales retrieves all sales for a specific customer
-func (r *salesRepository) GetCustomerSales(customerID string) ([]models.Sale, error) {
-	filters := map[string]interface{}{
-		"customer_id": customerID,
+func (r *salesRepository) GetCustomerSales(ctx context.Context, customerID string) ([]models.Sale, error) {
+	return r.GetAll(ctx, models.SalesFilter{CustomerID: customerID})
+}
+
+// GetCustomerSaleItems retrieves the items of a customer's sales in one query,
+// joined with the inventory tables for the names of the items sold
+func (r *salesRepository) GetCustomerSaleItems(ctx context.Context, customerID string) ([]models.SaleItem, error) {
+	query := `
+		SELECT si.id, si.sale_id, si.item_type, si.multi_cab_id, si.accessory_id, si.material_id, si.quantity, si.unit_price, si.subtotal, si.created_at, si.updated_at,
+			COALESCE(m.name, a.name, mt.name, '')
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		LEFT JOIN multicabs m ON si.item_type = 'cab' AND m.tenant_id = si.tenant_id AND m.id = si.multi_cab_id
+		LEFT JOIN accessories a ON si.item_type = 'accessory' AND a.tenant_id = si.tenant_id AND a.id = si.accessory_id
+		LEFT JOIN materials mt ON si.item_type = 'material' AND mt.tenant_id = si.tenant_id AND mt.id = si.material_id
+		WHERE si.tenant_id = ? AND s.customer_id = ?
+		ORDER BY si.created_at, si.id
+	`
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sale items of customer %s: %w", customerID, err)
 	}
-	return r.GetAll(filters)
+	defer rows.Close()
+
+	items := []models.SaleItem{}
+	for rows.Next() {
+		var item models.SaleItem
+		var itemType, multiCabID, accessoryID, materialID string
+		if err := rows.Scan(
+			&item.ID,
+			&item.SaleID,
+			&itemType,
+			&multiCabID,
+			&accessoryID,
+			&materialID,
+			&item.Quantity,
+			&item.UnitPrice,
+			&item.Subtotal,
+			&item.CreatedAt,
+			&item.UpdatedAt,
+			&item.Name,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan sale item of customer %s: %w", customerID, err)
+		}
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sale items of customer %s: %w", customerID, err)
+	}
+
+	return items, nil
 }
 
 // Create inserts a new sale record into the database
-func (r *salesRepository) Create(sale *models.Sale) (string, error) {
-	query := `INSERT INTO sales (id, customer_id, sold_by, sale_date, total_price, created_at, updated_at) 
-			VALUES (?, ?, ?, ?, ?, ?, ?)`
+func (r *salesRepository) Create(ctx context.Context, sale *models.Sale) (string, error) {
+	query := `INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, created_at, updated_at) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	// Generate a UUID if not provided
 	if sale.ID == "" {
@@ -148,17 +363,25 @@ func (r *salesRepository) Create(sale *models.Sale) (string, error) {
 		sale.ID = fmt.Sprintf("sale_%d", time.Now().UnixNano())
 	}
 
+	if sale.Status == "" {
+		sale.Status = models.SaleConfirmed
+	}
+
 	now := time.Now()
 	sale.CreatedAt = now
 	sale.UpdatedAt = now
 
-	_, err := r.DB.Exec(
+	_, err := r.DB.ExecContext(ctx,
 		query,
 		sale.ID,
+		r.TenantID,
 		sale.CustomerID,
 		sale.SoldBy,
 		sale.SaleDate,
 		sale.TotalPrice,
+		sale.TaxType,
+		sale.VATAmount,
+		sale.Status,
 		sale.CreatedAt,
 		sale.UpdatedAt,
 	)
@@ -172,9 +395,9 @@ func (r *salesRepository) Create(sale *models.Sale) (string, error) {
 }
 
 // Update modifies an existing sale record in the database
-func (r *salesRepository) Update(sale *models.Sale) error {
+func (r *salesRepository) Update(ctx context.Context, sale *models.Sale) error {
 	// Check if the sale exists
-	_, err := r.GetByID(sale.ID)
+	_, err := r.GetByID(ctx, sale.ID)
 	if err != nil {
 		if strings.Contains(strings.ToLower(err.Error()), "not found") {
 			return fmt.Errorf("sale with ID %s not found for update", sale.ID)
@@ -183,20 +406,23 @@ func (r *salesRepository) Update(sale *models.Sale) error {
 	}
 
 	query := `UPDATE sales 
-			SET customer_id = ?, sold_by = ?, sale_date = ?, total_price = ?, updated_at = ? 
-			WHERE id = ?`
+			SET customer_id = ?, sold_by = ?, sale_date = ?, total_price = ?, tax_type = ?, vat_amount = ?, updated_at = ? 
+			WHERE id = ? AND tenant_id = ?`
 
 	now := time.Now()
 	sale.UpdatedAt = now
 
-	_, err = r.DB.Exec(
+	_, err = r.DB.ExecContext(ctx,
 		query,
 		sale.CustomerID,
 		sale.SoldBy,
 		sale.SaleDate,
 		sale.TotalPrice,
+		sale.TaxType,
+		sale.VATAmount,
 		now,
 		sale.ID,
+		r.TenantID,
 	)
 
 	if err != nil {
@@ -208,8 +434,8 @@ func (r *salesRepository) Update(sale *models.Sale) error {
 }
 
 // Delete removes a sale and its associated items from the database
-func (r *salesRepository) Delete(id string) error {
-	tx, err := r.DB.Begin()
+func (r *salesRepository) Delete(ctx context.Context, id string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("Error beginning transaction for deleting sale ID %s: %v", id, err)
 		return fmt.Errorf("could not start transaction: %w", err)
@@ -217,8 +443,8 @@ func (r *salesRepository) Delete(id string) error {
 	defer tx.Rollback() // Rollback if not committed
 
 	// First, delete the sale record
-	querySale := `DELETE FROM sales WHERE id = ?`
-	result, err := tx.Exec(querySale, id)
+	querySale := `DELETE FROM sales WHERE id = ? AND tenant_id = ?`
+	result, err := tx.ExecContext(ctx, querySale, id, r.TenantID)
 	if err != nil {
 		log.Printf("Error deleting sale ID %s: %v", id, err)
 		return fmt.Errorf("error deleting sale: %w", err)
@@ -234,8 +460,8 @@ func (r *salesRepository) Delete(id string) error {
 	}
 
 	// Then, delete associated sale items (if any)
-	queryItems := `DELETE FROM sale_items WHERE sale_id = ?`
-	_, err = tx.Exec(queryItems, id)
+	queryItems := `DELETE FROM sale_items WHERE sale_id = ? AND tenant_id = ?`
+	_, err = tx.ExecContext(ctx, queryItems, id, r.TenantID)
 	if err != nil {
 		// If deleting items fails, we've already deleted the sale, rollback will handle it.
 		log.Printf("Error deleting sale items for sale ID %s: %v", id, err)
@@ -250,12 +476,252 @@ func (r *salesRepository) Delete(id string) error {
 	return nil
 }
 
+// SetStatus moves a sale along its workflow, restocking its items when a sale that
+// took them out of stock is called off
+func (r *salesRepository) SetStatus(ctx context.Context, id, status string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	sale := models.Sale{ID: id}
+	err = tx.QueryRowContext(ctx, `SELECT status, stock_taken FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE`, id, r.TenantID).Scan(&sale.Status, &sale.StockTaken)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("sale with ID %s not found: %w", id, sql.ErrNoRows)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read status of sale %s: %w", id, err)
+	}
+	if !sale.CanTransition(status) {
+		return fmt.Errorf("sale %s from %s to %s: %w", id, sale.CurrentStatus(), status, ErrSaleTransition)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE sales SET status = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`, status, time.Now(), id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to update status of sale %s: %w", id, err)
+	}
+	if sale.RestocksOn(status) {
+		if err := r.restock(ctx, tx, id); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+// restock puts the cabs and accessories of a sale not returned by refunds back in
+// stock within the transaction calling the sale off. Materials are not stocked by sales.
+func (r *salesRepository) restock(ctx context.Context, tx *sql.Tx, saleID string) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT item_type, COALESCE(multi_cab_id, ''), COALESCE(accessory_id, ''),
+			quantity - (SELECT COALESCE(SUM(r.quantity), 0) FROM sale_refund_items r WHERE r.tenant_id = sale_items.tenant_id AND r.sale_item_id = sale_items.id)
+		FROM sale_items WHERE sale_id = ? AND tenant_id = ?
+	`, saleID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to query items of sale %s: %w", saleID, err)
+	}
+	var items []models.SaleItem
+	for rows.Next() {
+		var itemType, multiCabID, accessoryID string
+		var quantity int
+		if err := rows.Scan(&itemType, &multiCabID, &accessoryID, &quantity); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan item of sale %s: %w", saleID, err)
+		}
+		if quantity > 0 {
+			items = append(items, models.SaleItem{SaleID: saleID, Item: models.SaleItemRef(itemType, multiCabID, accessoryID, ""), Quantity: quantity})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return fmt.Errorf("error iterating items of sale %s: %w", saleID, err)
+	}
+	rows.Close()
+	return r.putBack(ctx, tx, items)
+}
+
+// putBack adds the quantities of the cab and accessory items to their stock within tx
+// and updates their status. Material items are skipped.
+func (r *salesRepository) putBack(ctx context.Context, tx *sql.Tx, items []models.SaleItem) error {
+	for _, item := range items {
+		var table string
+		status := CabStatusAfterRestock
+		switch item.Item.Type() {
+		case models.RefCab:
+			table = "multicabs"
+		case models.RefAccessory:
+			table = "accessories"
+			status = func(quantity int, _ string) string { return string(determineStatus(quantity)) }
+		default:
+			continue
+		}
+		id := item.Item.IntID()
+		if _, err := tx.ExecContext(ctx, "UPDATE "+table+" SET quantity = quantity + ?, updated_at = ? WHERE id = ? AND tenant_id = ?",
+			item.Quantity, time.Now(), id, r.TenantID); err != nil {
+			return fmt.Errorf("failed to restock %s %d: %w", table, id, err)
+		}
+		if err := r.updateStockStatus(ctx, tx, table, id, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Refund records a refund of a confirmed or completed sale in a transaction that
+// locks the sale, so concurrent refunds cannot together return more than was sold
+func (r *salesRepository) Refund(ctx context.Context, refund *models.SaleRefund) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	sale := models.Sale{ID: refund.SaleID}
+	err = tx.QueryRowContext(ctx, `SELECT status, stock_taken FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE`, refund.SaleID, r.TenantID).Scan(&sale.Status, &sale.StockTaken)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("sale with ID %s not found: %w", refund.SaleID, sql.ErrNoRows)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read status of sale %s: %w", refund.SaleID, err)
+	}
+	if !sale.CanTransition(models.SaleRefunded) {
+		return fmt.Errorf("refund of %s sale %s: %w", sale.CurrentStatus(), refund.SaleID, ErrSaleTransition)
+	}
+
+	items, refunded, err := r.refundable(ctx, tx, refund.SaleID)
+	if err != nil {
+		return err
+	}
+	restock, all, err := PlanRefund(refund, items, refunded)
+	if err != nil {
+		return err
+	}
+
+	if refund.ID == "" {
+		refund.ID = uuid.New().String()
+	}
+	refund.RefundedAt = time.Now()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO sale_refunds (id, tenant_id, sale_id, amount, reason, refunded_by, refunded_at)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, ?)`,
+		refund.ID, r.TenantID, refund.SaleID, refund.Amount, refund.Reason, refund.RefundedBy, refund.RefundedAt); err != nil {
+		return fmt.Errorf("failed to record refund of sale %s: %w", refund.SaleID, err)
+	}
+	for _, item := range refund.Items {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO sale_refund_items (tenant_id, refund_id, sale_id, sale_item_id, quantity, amount) VALUES (?, ?, ?, ?, ?, ?)`,
+			r.TenantID, refund.ID, refund.SaleID, item.SaleItemID, item.Quantity, item.Amount); err != nil {
+			return fmt.Errorf("failed to record refund of item %s: %w", item.SaleItemID, err)
+		}
+	}
+	if sale.StockTaken {
+		if err := r.putBack(ctx, tx, restock); err != nil {
+			return err
+		}
+	}
+	if all {
+		if _, err := tx.ExecContext(ctx, `UPDATE sales SET status = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`, models.SaleRefunded, time.Now(), refund.SaleID, r.TenantID); err != nil {
+			return fmt.Errorf("failed to update status of sale %s: %w", refund.SaleID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+// refundable reads the items of a sale and the units of each that earlier refunds
+// returned, by sale item ID, within tx
+func (r *salesRepository) refundable(ctx context.Context, tx *sql.Tx, saleID string) ([]models.SaleItem, map[string]int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, item_type, COALESCE(multi_cab_id, ''), COALESCE(accessory_id, ''), COALESCE(material_id, ''), quantity, unit_price
+		FROM sale_items WHERE sale_id = ? AND tenant_id = ?
+	`, saleID, r.TenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query items of sale %s: %w", saleID, err)
+	}
+	defer rows.Close()
+	var items []models.SaleItem
+	for rows.Next() {
+		item := models.SaleItem{SaleID: saleID}
+		var itemType, multiCabID, accessoryID, materialID string
+		if err := rows.Scan(&item.ID, &itemType, &multiCabID, &accessoryID, &materialID, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan item of sale %s: %w", saleID, err)
+		}
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating items of sale %s: %w", saleID, err)
+	}
+
+	refundedRows, err := tx.QueryContext(ctx, `SELECT sale_item_id, SUM(quantity) FROM sale_refund_items WHERE tenant_id = ? AND sale_id = ? GROUP BY sale_item_id`, r.TenantID, saleID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query refunds of sale %s: %w", saleID, err)
+	}
+	defer refundedRows.Close()
+	refunded := make(map[string]int)
+	for refundedRows.Next() {
+		var itemID string
+		var quantity int
+		if err := refundedRows.Scan(&itemID, &quantity); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan refunds of sale %s: %w", saleID, err)
+		}
+		refunded[itemID] = quantity
+	}
+	if err := refundedRows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating refunds of sale %s: %w", saleID, err)
+	}
+	return items, refunded, nil
+}
+
+// GetRefunds retrieves the refunds of a sale with their items
+func (r *salesRepository) GetRefunds(ctx context.Context, saleID string) ([]models.SaleRefund, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT f.id, f.amount, COALESCE(f.reason, ''), f.refunded_by, f.refunded_at,
+			i.sale_item_id, i.quantity, i.amount, si.item_type, COALESCE(si.multi_cab_id, ''), COALESCE(si.accessory_id, ''), COALESCE(si.material_id, '')
+		FROM sale_refunds f
+		JOIN sale_refund_items i ON i.tenant_id = f.tenant_id AND i.refund_id = f.id
+		JOIN sale_items si ON si.tenant_id = i.tenant_id AND si.id = i.sale_item_id
+		WHERE f.tenant_id = ? AND f.sale_id = ?
+		ORDER BY f.refunded_at, f.id, si.created_at, si.id
+	`, r.TenantID, saleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refunds of sale %s: %w", saleID, err)
+	}
+	defer rows.Close()
+
+	refunds := []models.SaleRefund{}
+	for rows.Next() {
+		refund := models.SaleRefund{SaleID: saleID}
+		var item models.SaleRefundItem
+		var itemType, multiCabID, accessoryID, materialID string
+		if err := rows.Scan(&refund.ID, &refund.Amount, &refund.Reason, &refund.RefundedBy, &refund.RefundedAt,
+			&item.SaleItemID, &item.Quantity, &item.Amount, &itemType, &multiCabID, &accessoryID, &materialID); err != nil {
+			return nil, fmt.Errorf("failed to scan refund of sale %s: %w", saleID, err)
+		}
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
+		if n := len(refunds); n > 0 && refunds[n-1].ID == refund.ID {
+			refunds[n-1].Items = append(refunds[n-1].Items, item)
+			continue
+		}
+		refund.Items = []models.SaleRefundItem{item}
+		refunds = append(refunds, refund)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating refunds of sale %s: %w", saleID, err)
+	}
+	return refunds, nil
+}
+
 // GetSaleItems retrieves all items for a specific sale
-func (r *salesRepository) GetSaleItems(saleID string) ([]models.SaleItem, error) {
+func (r *salesRepository) GetSaleItems(ctx context.Context, saleID string) ([]models.SaleItem, error) {
 	query := `SELECT id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at 
-			FROM sale_items WHERE sale_id = ?`
+			FROM sale_items WHERE sale_id = ? AND tenant_id = ?`
 
-	rows, err := r.DB.Query(query, saleID)
+	rows, err := r.DB.QueryContext(ctx, query, saleID, r.TenantID)
 	if err != nil {
 		log.Printf("Error querying sale items for sale ID %s: %v", saleID, err)
 		return nil, err
@@ -265,15 +731,16 @@ func (r *salesRepository) GetSaleItems(saleID string) ([]models.SaleItem, error)
 	var items []models.SaleItem
 	for rows.Next() {
 		var item models.SaleItem
+		var itemType, multiCabID, accessoryID, materialID string
 		var createdAt, updatedAt time.Time
 
 		if err := rows.Scan(
 			&item.ID,
 			&item.SaleID,
-			&item.ItemType,
-			&item.MultiCabID,
-			&item.AccessoryID,
-			&item.MaterialID,
+			&itemType,
+			&multiCabID,
+			&accessoryID,
+			&materialID,
 			&item.Quantity,
 			&item.UnitPrice,
 			&item.Subtotal,
@@ -284,6 +751,7 @@ func (r *salesRepository) GetSaleItems(saleID string) ([]models.SaleItem, error)
 			return nil, err
 		}
 
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
 		item.CreatedAt = createdAt
 		item.UpdatedAt = updatedAt
 		items = append(items, item)
@@ -298,9 +766,9 @@ func (r *salesRepository) GetSaleItems(saleID string) ([]models.SaleItem, error)
 }
 
 // CreateSaleItem inserts a new sale item into the database
-func (r *salesRepository) CreateSaleItem(item *models.SaleItem) (string, error) {
-	query := `INSERT INTO sale_items (id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+func (r *salesRepository) CreateSaleItem(ctx context.Context, item *models.SaleItem) (string, error) {
+	query := `INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	// Generate a UUID if not provided
 	if item.ID == "" {
@@ -310,15 +778,17 @@ func (r *salesRepository) CreateSaleItem(item *models.SaleItem) (string, error)
 	}
 
 	now := time.Now()
+	multiCabID, accessoryID, materialID := models.SaleItemColumns(item.Item)
 
-	_, err := r.DB.Exec(
+	_, err := r.DB.ExecContext(ctx,
 		query,
 		item.ID,
+		r.TenantID,
 		item.SaleID,
-		item.ItemType,
-		item.MultiCabID,
-		item.AccessoryID,
-		item.MaterialID,
+		item.ItemType(),
+		multiCabID,
+		accessoryID,
+		materialID,
 		item.Quantity,
 		item.UnitPrice,
 		item.Subtotal,
@@ -334,24 +804,109 @@ func (r *salesRepository) CreateSaleItem(item *models.SaleItem) (string, error)
 	return item.ID, nil
 }
 
+// Sell records a sale and its items, and takes them out of stock when asked to, in
+// one transaction
+func (r *salesRepository) Sell(ctx context.Context, sale *models.Sale, items []models.SaleItem, takeStock bool) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	if sale.ID == "" {
+		sale.ID = fmt.Sprintf("sale_%d", time.Now().UnixNano())
+	}
+	if sale.Status == "" {
+		sale.Status = models.SaleConfirmed
+	}
+	sale.StockTaken = takeStock
+	now := time.Now()
+	sale.CreatedAt, sale.UpdatedAt = now, now
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sale.ID, r.TenantID, sale.CustomerID, sale.SoldBy, sale.SaleDate, sale.TotalPrice, sale.TaxType, sale.VATAmount, sale.Status, sale.StockTaken, now, now); err != nil {
+		return fmt.Errorf("failed to create sale: %w", err)
+	}
+
+	for i := range items {
+		item := &items[i]
+		if item.ID == "" {
+			item.ID = fmt.Sprintf("item_%d_%d", now.UnixNano(), i)
+		}
+		item.SaleID, item.CreatedAt, item.UpdatedAt = sale.ID, now, now
+		multiCabID, accessoryID, materialID := models.SaleItemColumns(item.Item)
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, accessory_id, material_id, quantity, unit_price, subtotal, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			item.ID, r.TenantID, sale.ID, item.ItemType(), multiCabID, accessoryID, materialID, item.Quantity, item.UnitPrice, item.Subtotal, now, now); err != nil {
+			return fmt.Errorf("failed to add %s to sale %s: %w", item.Item, sale.ID, err)
+		}
+	}
+	if takeStock {
+		if err := r.takeOut(ctx, tx, items); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return nil
+}
+
+// takeOut subtracts the quantities of the cab and accessory items from their stock
+// within tx, unless fewer units are left than are sold, and updates their status.
+// Material items are skipped.
+func (r *salesRepository) takeOut(ctx context.Context, tx *sql.Tx, items []models.SaleItem) error {
+	for _, item := range items {
+		var table string
+		status := CabStatusAfterSale
+		switch item.Item.Type() {
+		case models.RefCab:
+			table = "multicabs"
+		case models.RefAccessory:
+			table = "accessories"
+			status = func(quantity int, _ string) string { return string(determineStatus(quantity)) }
+		default:
+			continue
+		}
+		id := item.Item.IntID()
+		result, err := tx.ExecContext(ctx, "UPDATE "+table+" SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?",
+			item.Quantity, time.Now(), id, r.TenantID, item.Quantity)
+		if err != nil {
+			return fmt.Errorf("failed to take %s %d out of stock: %w", table, id, stockError(err))
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return err
+		} else if updated == 0 {
+			return insufficientStock(item.Item.Type(), id, item.Quantity)
+		}
+		if err := r.updateStockStatus(ctx, tx, table, id, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SellCab handles the complete process of selling a cab with optional accessories
-func (r *salesRepository) SellCab(cabID int, customerID string, quantity int, soldBy string, accessories []models.AccessoryForSale) (*models.Sale, error) {
+func (r *salesRepository) SellCab(ctx context.Context, cabID int, customerID string, quantity int, soldBy, taxType string, accessories []models.AccessoryForSale) (*models.Sale, error) {
 	// Start a transaction
-	tx, err := r.DB.Begin()
+	tx, err := r.DB.BeginTx(ctx, nil)
 	if err != nil {
 		log.Printf("Error starting transaction for cab sale: %v", err)
 		return nil, err
 	}
 
 	// Get the cab details
-	query := `SELECT id, name, price FROM multicabs WHERE id = ?`
+	query := `SELECT id, name, price FROM multicabs WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL`
 	var cab struct {
 		ID    int
 		Name  string
 		Price float64
 	}
 
-	err = tx.QueryRow(query, cabID).Scan(&cab.ID, &cab.Name, &cab.Price)
+	err = tx.QueryRowContext(ctx, query, cabID, r.TenantID).Scan(&cab.ID, &cab.Name, &cab.Price)
 	if err != nil {
 		tx.Rollback()
 		if err == sql.ErrNoRows {
@@ -370,17 +925,32 @@ func (r *salesRepository) SellCab(cabID int, customerID string, quantity int, so
 	totalPrice := cabTotal + accessoriesTotal
 
 	// Create the sale record
-	saleID := fmt.Sprintf("sale_%d", time.Now().UnixNano())
-	saleDate := time.Now().Format("2006-01-02")
+	sale := &models.Sale{
+		ID:         fmt.Sprintf("sale_%d", time.Now().UnixNano()),
+		CustomerID: customerID,
+		SoldBy:     soldBy,
+		SaleDate:   time.Now().Format("2006-01-02"),
+		TotalPrice: totalPrice,
+		TaxType:    taxType,
+		Status:     models.SaleConfirmed,
+		StockTaken: true,
+	}
+	sale.ApplyTax()
+	saleID := sale.ID
 
-	_, err = tx.Exec(
-		`INSERT INTO sales (id, customer_id, sold_by, sale_date, total_price, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO sales (id, tenant_id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		saleID,
+		r.TenantID,
 		customerID,
 		soldBy,
-		saleDate,
+		sale.SaleDate,
 		totalPrice,
+		sale.TaxType,
+		sale.VATAmount,
+		sale.Status,
+		sale.StockTaken,
 		time.Now(),
 		time.Now(),
 	)
@@ -392,10 +962,11 @@ func (r *salesRepository) SellCab(cabID int, customerID string, quantity int, so
 	}
 
 	// Add the cab as a sale item
-	_, err = tx.Exec(
-		`INSERT INTO sale_items (id, sale_id, item_type, multi_cab_id, quantity, unit_price, subtotal, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO sale_items (id, tenant_id, sale_id, item_type, multi_cab_id, quantity, unit_price, subtotal, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		fmt.Sprintf("item_%d_cab", time.Now().UnixNano()),
+		r.TenantID,
 		saleID,
 		"cab",
 		cabID,
@@ -414,10 +985,11 @@ func (r *salesRepository) SellCab(cabID int, customerID string, quantity int, so
 
 	// Add each accessory as a sale item
 	for _, acc := range accessories {
-		_, err = tx.Exec(
-			`INSERT INTO sale_items (id, sale_id, item_type, accessory_id, quantity, unit_price, subtotal, created_at, updated_at) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO sale_items (id, tenant_id, sale_id, item_type, accessory_id, quantity, unit_price, subtotal, created_at, updated_at) 
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			fmt.Sprintf("item_%d_acc_%d", time.Now().UnixNano(), acc.ID),
+			r.TenantID,
 			saleID,
 			"accessory",
 			acc.ID,
@@ -434,32 +1006,61 @@ func (r *salesRepository) SellCab(cabID int, customerID string, quantity int, so
 			return nil, err
 		}
 
-		// Update the accessory inventory
-		_, err = tx.Exec(
-			"UPDATE accessories SET quantity = quantity - ?, updated_at = ? WHERE id = ?",
+		// Update the accessory inventory, unless fewer units are left than were sold
+		result, err := tx.ExecContext(ctx,
+			"UPDATE accessories SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?",
 			acc.Quantity,
 			time.Now(),
 			acc.ID,
+			r.TenantID,
+			acc.Quantity,
 		)
 
 		if err != nil {
 			tx.Rollback()
 			log.Printf("Error updating accessory inventory: %v", err)
+			return nil, stockError(err)
+		}
+		if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+			tx.Rollback()
+			if err != nil {
+				return nil, err
+			}
+			return nil, insufficientStock("accessory", acc.ID, acc.Quantity)
+		}
+		err = r.updateStockStatus(ctx, tx, "accessories", acc.ID, func(quantity int, _ string) string {
+			return string(determineStatus(quantity))
+		})
+		if err != nil {
+			tx.Rollback()
 			return nil, err
 		}
 	}
 
-	// Update the cab inventory
-	_, err = tx.Exec(
-		"UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ?",
+	// Update the cab inventory, unless fewer units are left than were sold
+	result, err := tx.ExecContext(ctx,
+		"UPDATE multicabs SET quantity = quantity - ?, updated_at = ? WHERE id = ? AND tenant_id = ? AND quantity >= ?",
 		quantity,
 		time.Now(),
 		cabID,
+		r.TenantID,
+		quantity,
 	)
 
 	if err != nil {
 		tx.Rollback()
 		log.Printf("Error updating cab inventory: %v", err)
+		return nil, stockError(err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		tx.Rollback()
+		if err != nil {
+			return nil, err
+		}
+		return nil, insufficientStock("cab", cabID, quantity)
+	}
+	if err := r.updateStockStatus(ctx, tx, "multicabs", cabID, CabStatusAfterSale); err != nil {
+		tx.Rollback()
 		return nil, err
 	}
 
@@ -470,15 +1071,460 @@ func (r *salesRepository) SellCab(cabID int, customerID string, quantity int, so
 	}
 
 	// Return the created sale
-	sale := &models.Sale{
-		ID:         saleID,
-		CustomerID: customerID,
-		SoldBy:     soldBy,
-		SaleDate:   saleDate,
-		TotalPrice: totalPrice,
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-	}
+	sale.CreatedAt = time.Now()
+	sale.UpdatedAt = sale.CreatedAt
 
 	return sale, nil
 }
+
+// updateStockStatus sets the status of a cab or accessory from its quantity left
+// after a sale, as status returns it, within the sale's transaction
+func (r *salesRepository) updateStockStatus(ctx context.Context, tx *sql.Tx, table string, id int, status func(quantity int, current string) string) error {
+	var quantity int
+	var current string
+	err := tx.QueryRowContext(ctx, "SELECT quantity, status FROM "+table+" WHERE id = ? AND tenant_id = ?", id, r.TenantID).Scan(&quantity, &current)
+	if err != nil {
+		return fmt.Errorf("failed to get stock of %s %d: %w", table, id, err)
+	}
+	next := status(quantity, current)
+	if next == current {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE "+table+" SET status = ? WHERE id = ? AND tenant_id = ?", next, id, r.TenantID); err != nil {
+		return fmt.Errorf("failed to update stock status of %s %d: %w", table, id, err)
+	}
+	return nil
+}
+
+// GetLeaderboard aggregates revenue, units and sales per seller between two sale dates,
+// counting only confirmed and completed sales
+func (r *salesRepository) GetLeaderboard(ctx context.Context, startDate, endDate string) ([]models.LeaderboardEntry, error) {
+	query := `
+		SELECT s.sold_by, SUM(s.total_price), COALESCE(SUM(items.units), 0), COUNT(*)
+		FROM sales s
+		LEFT JOIN (
+			SELECT sale_id, SUM(quantity) AS units FROM sale_items WHERE tenant_id = ? GROUP BY sale_id
+		) items ON items.sale_id = s.id
+		WHERE s.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ? AND s.` + countedSale + `
+		GROUP BY s.sold_by
+		ORDER BY SUM(s.total_price) DESC, COALESCE(SUM(items.units), 0) DESC, COUNT(*) DESC, s.sold_by ASC
+	`
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID, r.TenantID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.LeaderboardEntry{}
+	for rows.Next() {
+		entry := models.LeaderboardEntry{Rank: len(entries) + 1}
+		if err := rows.Scan(&entry.UserID, &entry.Revenue, &entry.UnitsSold, &entry.SalesCount); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard row: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leaderboard rows: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetBasketPairs counts how often accessories are sold together across the tenant's
+// confirmed and completed sales
+func (r *salesRepository) GetBasketPairs(ctx context.Context) ([]models.BasketPair, error) {
+	var totalSales int
+	if err := r.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM sales WHERE tenant_id = ? AND `+countedSale, r.TenantID).Scan(&totalSales); err != nil {
+		return nil, fmt.Errorf("failed to count sales: %w", err)
+	}
+
+	itemSales := make(map[int]int)
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT si.accessory_id, COUNT(DISTINCT si.sale_id)
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		WHERE si.tenant_id = ? AND si.item_type = 'accessory' AND si.accessory_id IS NOT NULL AND s.`+countedSale+`
+		GROUP BY si.accessory_id
+	`, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accessory sales: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var accessoryID, count int
+		if err := rows.Scan(&accessoryID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan accessory sales row: %w", err)
+		}
+		itemSales[accessoryID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accessory sales rows: %w", err)
+	}
+
+	pairSales := make(map[AccessoryPair]int)
+	pairRows, err := r.DB.QueryContext(ctx, `
+		SELECT a.accessory_id, b.accessory_id, COUNT(DISTINCT a.sale_id)
+		FROM sale_items a
+		JOIN sale_items b ON b.tenant_id = a.tenant_id AND b.sale_id = a.sale_id AND b.accessory_id <> a.accessory_id
+		JOIN sales s ON s.tenant_id = a.tenant_id AND s.id = a.sale_id
+		WHERE a.tenant_id = ? AND a.item_type = 'accessory' AND b.item_type = 'accessory' AND s.`+countedSale+`
+		GROUP BY a.accessory_id, b.accessory_id
+	`, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accessory pairs: %w", err)
+	}
+	defer pairRows.Close()
+	for pairRows.Next() {
+		var pair AccessoryPair
+		var count int
+		if err := pairRows.Scan(&pair[0], &pair[1], &count); err != nil {
+			return nil, fmt.Errorf("failed to scan accessory pair row: %w", err)
+		}
+		pairSales[pair] = count
+	}
+	if err := pairRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating accessory pair rows: %w", err)
+	}
+
+	return BuildBasketPairs(totalSales, itemSales, pairSales), nil
+}
+
+// pivotColumns and pivotMeasures are the SQL of the pivot's dimensions and measures
+// over sale_items si, sales s, multicabs m and accessories a. Only these ever reach
+// the query.
+var (
+	pivotColumns = map[string]string{
+		models.PivotMake:     "COALESCE(m.make, a.make, '')",
+		models.PivotItemType: "si.item_type",
+		models.PivotMonth:    "DATE_FORMAT(s.sale_date, '%Y-%m')",
+		models.PivotYear:     "DATE_FORMAT(s.sale_date, '%Y')",
+		models.PivotSoldBy:   "s.sold_by",
+		models.PivotTaxType:  "s.tax_type",
+	}
+	pivotMeasures = map[string]string{
+		models.PivotRevenue: "SUM(si.subtotal)",
+		models.PivotUnits:   "SUM(si.quantity)",
+		models.PivotSales:   "COUNT(DISTINCT s.id)",
+	}
+)
+
+// GetPivot totals a measure of the items of the tenant's confirmed and completed sales
+// by two dimensions
+func (r *salesRepository) GetPivot(ctx context.Context, rows, cols, measure, startDate, endDate string) ([]models.PivotCell, error) {
+	rowColumn, rowOK := pivotColumns[rows]
+	colColumn, colOK := pivotColumns[cols]
+	measureColumn, measureOK := pivotMeasures[measure]
+	if !rowOK || !colOK || !measureOK {
+		return nil, fmt.Errorf("unknown pivot dimension or measure: %s, %s, %s", rows, cols, measure)
+	}
+
+	query := `
+		SELECT ` + rowColumn + ` AS pivot_row, ` + colColumn + ` AS pivot_col, ` + measureColumn + `
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		LEFT JOIN multicabs m ON si.item_type = 'cab' AND m.tenant_id = si.tenant_id AND m.id = si.multi_cab_id
+		LEFT JOIN accessories a ON si.item_type = 'accessory' AND a.tenant_id = si.tenant_id AND a.id = si.accessory_id
+		WHERE si.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ? AND s.` + countedSale + `
+		GROUP BY pivot_row, pivot_col
+		ORDER BY pivot_row, pivot_col
+	`
+	result, err := r.DB.QueryContext(ctx, query, r.TenantID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sales pivot: %w", err)
+	}
+	defer result.Close()
+
+	cells := []models.PivotCell{}
+	for result.Next() {
+		var cell models.PivotCell
+		if err := result.Scan(&cell.Row, &cell.Col, &cell.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan sales pivot row: %w", err)
+		}
+		cells = append(cells, cell)
+	}
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sales pivot rows: %w", err)
+	}
+
+	return cells, nil
+}
+
+// summaryPeriodColumns is the SQL of the first day of the period of a sale s, by
+// grouping. Only these ever reach the query.
+var summaryPeriodColumns = map[string]string{
+	models.SummaryDay:   "DATE_FORMAT(s.sale_date, '%Y-%m-%d')",
+	models.SummaryWeek:  "DATE_FORMAT(DATE_SUB(s.sale_date, INTERVAL WEEKDAY(s.sale_date) DAY), '%Y-%m-%d')",
+	models.SummaryMonth: "DATE_FORMAT(s.sale_date, '%Y-%m-01')",
+}
+
+// topSellerQuery ranks the items of one type over sale_items si and sales s, joined
+// with their inventory table for the name and make
+const topSellerQuery = `
+		SELECT si.%[1]s, COALESCE(i.name, ''), COALESCE(i.make, ''), SUM(si.quantity), SUM(si.subtotal)
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		LEFT JOIN %[2]s i ON i.tenant_id = si.tenant_id AND i.id = si.%[1]s
+		WHERE si.tenant_id = ? AND si.item_type = ? AND si.%[1]s IS NOT NULL AND s.sale_date >= ? AND s.sale_date <= ? AND s.` + countedSale + `
+		GROUP BY si.%[1]s, i.name, i.make
+		ORDER BY SUM(si.quantity) DESC, SUM(si.subtotal) DESC, si.%[1]s ASC
+		LIMIT ?
+	`
+
+// GetSalesSummary totals the tenant's confirmed and completed sales by period and ranks
+// their best-selling items
+func (r *salesRepository) GetSalesSummary(ctx context.Context, groupBy, startDate, endDate string, top int) (*models.SalesSummary, error) {
+	periodColumn, ok := summaryPeriodColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown sales summary grouping: %s", groupBy)
+	}
+
+	query := `
+		SELECT ` + periodColumn + ` AS period_start, COUNT(*), SUM(s.total_price), COALESCE(SUM(items.units), 0)
+		FROM sales s
+		LEFT JOIN (
+			SELECT sale_id, SUM(quantity) AS units FROM sale_items WHERE tenant_id = ? GROUP BY sale_id
+		) items ON items.sale_id = s.id
+		WHERE s.tenant_id = ? AND s.sale_date >= ? AND s.sale_date <= ? AND s.` + countedSale + `
+		GROUP BY period_start
+		ORDER BY period_start
+	`
+	rows, err := r.DB.QueryContext(ctx, query, r.TenantID, r.TenantID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sales summary: %w", err)
+	}
+	defer rows.Close()
+
+	summary := &models.SalesSummary{Periods: []models.SalesSummaryPeriod{}}
+	for rows.Next() {
+		var period models.SalesSummaryPeriod
+		if err := rows.Scan(&period.StartDate, &period.SalesCount, &period.Revenue, &period.UnitsSold); err != nil {
+			return nil, fmt.Errorf("failed to scan sales summary row: %w", err)
+		}
+		summary.Periods = append(summary.Periods, period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sales summary rows: %w", err)
+	}
+
+	if summary.TopCabs, err = r.topSellers(ctx, "cab", "multi_cab_id", "multicabs", startDate, endDate, top); err != nil {
+		return nil, err
+	}
+	if summary.TopAccessories, err = r.topSellers(ctx, "accessory", "accessory_id", "accessories", startDate, endDate, top); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// topSellers ranks the items of one type sold between two sale dates
+func (r *salesRepository) topSellers(ctx context.Context, itemType, idColumn, table, startDate, endDate string, top int) ([]models.TopSeller, error) {
+	rows, err := r.DB.QueryContext(ctx, fmt.Sprintf(topSellerQuery, idColumn, table), r.TenantID, itemType, startDate, endDate, top)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top %s sellers: %w", itemType, err)
+	}
+	defer rows.Close()
+
+	sellers := []models.TopSeller{}
+	for rows.Next() {
+		var seller models.TopSeller
+		if err := rows.Scan(&seller.ItemID, &seller.Name, &seller.Make, &seller.UnitsSold, &seller.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan top %s seller: %w", itemType, err)
+		}
+		sellers = append(sellers, seller)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating top %s sellers: %w", itemType, err)
+	}
+	return sellers, nil
+}
+
+// countedSale matches the sales reports count: drafts were never made, and cancelled
+// and refunded sales were undone
+const countedSale = `status IN ('confirmed', 'completed')`
+
+// voidableSale matches the sales that can be voided as duplicates: cancelled and
+// refunded sales are final and already put their items back in stock
+const voidableSale = `status NOT IN ('cancelled', 'refunded')`
+
+// duplicateCandidates selects the customer, day and total of the sales between two
+// sale dates that another sale shares; only those sales can be duplicates
+const duplicateCandidates = `
+		SELECT customer_id, sale_date, total_price FROM sales
+		WHERE tenant_id = ? AND sale_date >= ? AND sale_date <= ? AND ` + voidableSale + `
+		GROUP BY customer_id, sale_date, total_price
+		HAVING COUNT(*) > 1
+	`
+
+// GetPossibleDuplicates loads the sales sharing a customer, day and total with another
+// sale, and their items, then groups those with the same items
+func (r *salesRepository) GetPossibleDuplicates(ctx context.Context, startDate, endDate string) ([]models.DuplicateSaleGroup, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT s.id, s.customer_id, s.sold_by, s.sale_date, s.total_price, s.tax_type, s.vat_amount, s.status, s.created_at, s.updated_at
+		FROM sales s
+		JOIN (`+duplicateCandidates+`) d ON d.customer_id = s.customer_id AND d.sale_date = s.sale_date AND d.total_price = s.total_price
+		WHERE s.tenant_id = ? AND s.`+voidableSale+`
+	`, r.TenantID, startDate, endDate, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query possible duplicate sales: %w", err)
+	}
+	defer rows.Close()
+	var sales []models.Sale
+	if err := scanSales(rows, &sales); err != nil {
+		return nil, fmt.Errorf("failed to scan possible duplicate sales: %w", err)
+	}
+	if len(sales) == 0 {
+		return []models.DuplicateSaleGroup{}, nil
+	}
+
+	itemRows, err := r.DB.QueryContext(ctx, `
+		SELECT si.sale_id, si.item_type, COALESCE(si.multi_cab_id, ''), COALESCE(si.accessory_id, ''), COALESCE(si.material_id, ''), si.quantity, si.unit_price
+		FROM sale_items si
+		JOIN sales s ON s.tenant_id = si.tenant_id AND s.id = si.sale_id
+		JOIN (`+duplicateCandidates+`) d ON d.customer_id = s.customer_id AND d.sale_date = s.sale_date AND d.total_price = s.total_price
+		WHERE si.tenant_id = ? AND s.`+voidableSale+`
+	`, r.TenantID, startDate, endDate, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query items of possible duplicate sales: %w", err)
+	}
+	defer itemRows.Close()
+	items := map[string][]models.SaleItem{}
+	for itemRows.Next() {
+		var item models.SaleItem
+		var itemType, multiCabID, accessoryID, materialID string
+		if err := itemRows.Scan(&item.SaleID, &itemType, &multiCabID, &accessoryID, &materialID, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, fmt.Errorf("failed to scan item of possible duplicate sale: %w", err)
+		}
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
+		items[item.SaleID] = append(items[item.SaleID], item)
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating items of possible duplicate sales: %w", err)
+	}
+
+	return GroupDuplicateSales(sales, items), nil
+}
+
+// VoidDuplicate checks the sale against the one it duplicates, records the void, then
+// deletes the sale and its items. Payments of the voided sale are kept for the record.
+func (r *salesRepository) VoidDuplicate(ctx context.Context, id, duplicateOf, voidedBy string) (*models.DuplicateSaleVoid, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not start transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	sale, saleItems, err := r.saleWithItems(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	original, originalItems, err := r.saleWithItems(ctx, tx, duplicateOf)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range []*models.Sale{sale, original} {
+		if s.Final() {
+			return nil, fmt.Errorf("void of sale %s as a duplicate of %s sale %s: %w", id, s.CurrentStatus(), s.ID, ErrSaleTransition)
+		}
+	}
+	if !IsDuplicateSale(*sale, *original, saleItems, originalItems) {
+		return nil, fmt.Errorf("sale %s of sale %s: %w", id, duplicateOf, ErrNotDuplicateSale)
+	}
+
+	void := &models.DuplicateSaleVoid{
+		SaleID:      sale.ID,
+		DuplicateOf: original.ID,
+		CustomerID:  sale.CustomerID,
+		SaleDate:    sale.SaleDate,
+		TotalPrice:  sale.TotalPrice,
+		SoldBy:      sale.SoldBy,
+		VoidedBy:    voidedBy,
+		VoidedAt:    time.Now(),
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO duplicate_sale_voids (sale_id, tenant_id, duplicate_of, customer_id, sale_date, total_price, sold_by, voided_by, voided_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, void.SaleID, r.TenantID, void.DuplicateOf, void.CustomerID, void.SaleDate, void.TotalPrice, void.SoldBy, void.VoidedBy, void.VoidedAt); err != nil {
+		return nil, fmt.Errorf("failed to record void of sale %s: %w", id, err)
+	}
+	if sale.RestocksOn(models.SaleCancelled) {
+		if err := r.restock(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sale_items WHERE sale_id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return nil, fmt.Errorf("error deleting sale items: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sales WHERE id = ? AND tenant_id = ?`, id, r.TenantID); err != nil {
+		return nil, fmt.Errorf("error deleting sale: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not commit transaction: %w", err)
+	}
+	return void, nil
+}
+
+// saleWithItems reads a sale and its items within a transaction, locking the sale
+func (r *salesRepository) saleWithItems(ctx context.Context, tx *sql.Tx, id string) (*models.Sale, []models.SaleItem, error) {
+	var sale models.Sale
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, customer_id, sold_by, sale_date, total_price, tax_type, vat_amount, status, stock_taken, created_at, updated_at
+		FROM sales WHERE id = ? AND tenant_id = ? FOR UPDATE
+	`, id, r.TenantID).Scan(&sale.ID, &sale.CustomerID, &sale.SoldBy, &sale.SaleDate, &sale.TotalPrice, &sale.TaxType, &sale.VATAmount, &sale.Status, &sale.StockTaken, &sale.CreatedAt, &sale.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, fmt.Errorf("sale with ID %s not found: %w", id, sql.ErrNoRows)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read sale %s: %w", id, err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT item_type, COALESCE(multi_cab_id, ''), COALESCE(accessory_id, ''), COALESCE(material_id, ''), quantity, unit_price
+		FROM sale_items WHERE sale_id = ? AND tenant_id = ?
+	`, id, r.TenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query items of sale %s: %w", id, err)
+	}
+	defer rows.Close()
+	var items []models.SaleItem
+	for rows.Next() {
+		item := models.SaleItem{SaleID: id}
+		var itemType, multiCabID, accessoryID, materialID string
+		if err := rows.Scan(&itemType, &multiCabID, &accessoryID, &materialID, &item.Quantity, &item.UnitPrice); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan item of sale %s: %w", id, err)
+		}
+		item.Item = models.SaleItemRef(itemType, multiCabID, accessoryID, materialID)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating items of sale %s: %w", id, err)
+	}
+	return &sale, items, nil
+}
+
+// GetDuplicateVoids lists the sales voided as duplicates between two sale dates
+func (r *salesRepository) GetDuplicateVoids(ctx context.Context, startDate, endDate string) ([]models.DuplicateSaleVoid, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT sale_id, duplicate_of, customer_id, sale_date, total_price, sold_by, voided_by, voided_at
+		FROM duplicate_sale_voids
+		WHERE tenant_id = ? AND sale_date >= ? AND sale_date <= ?
+		ORDER BY voided_at DESC, sale_id ASC
+	`, r.TenantID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query duplicate sale voids: %w", err)
+	}
+	defer rows.Close()
+
+	voids := []models.DuplicateSaleVoid{}
+	for rows.Next() {
+		var void models.DuplicateSaleVoid
+		if err := rows.Scan(&void.SaleID, &void.DuplicateOf, &void.CustomerID, &void.SaleDate, &void.TotalPrice, &void.SoldBy, &void.VoidedBy, &void.VoidedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate sale void: %w", err)
+		}
+		voids = append(voids, void)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating duplicate sale voids: %w", err)
+	}
+	return voids, nil
+}
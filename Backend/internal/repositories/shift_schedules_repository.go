@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShiftRepository defines the interface for the shift schedules of a tenant, the
+// branches of its users and the overrides letting users work outside their shift.
+type ShiftRepository interface {
+	// GetSchedules returns the shift schedules, ordered by role, branch and start time.
+	GetSchedules() ([]models.ShiftSchedule, error)
+	// ReplaceSchedules replaces every shift schedule with the given ones, assigning
+	// their IDs.
+	ReplaceSchedules(schedules []models.ShiftSchedule) error
+	// GetBranch returns the branch whose schedules apply to a user, "" when none
+	// does, or an error wrapping sql.ErrNoRows when the user does not exist.
+	GetBranch(userID string) (string, error)
+	// SetBranch sets the branch whose schedules apply to a user; "" clears it. It
+	// returns an error wrapping sql.ErrNoRows when the user does not exist.
+	SetBranch(userID, branch string) error
+	// AddOverride records an override, assigning its ID and creation time.
+	AddOverride(override *models.ShiftOverride) error
+	// ActiveOverride returns the override of a user that expires last among those
+	// still in effect at, or nil when there is none.
+	ActiveOverride(userID string, at time.Time) (*models.ShiftOverride, error)
+	// ActiveOverrides returns the overrides in effect at, expiring soonest first.
+	ActiveOverrides(at time.Time) ([]models.ShiftOverride, error)
+}
+
+// shiftRepository implements the ShiftRepository interface.
+type shiftRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewShiftRepository creates a new instance of shiftRepository for the default tenant.
+func NewShiftRepository(db *sql.DB) ShiftRepository {
+	return &shiftRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// formatShiftDays stores the ISO weekdays of a schedule as a comma-separated list
+func formatShiftDays(days []int) string {
+	parts := make([]string, len(days))
+	for i, day := range days {
+		parts[i] = strconv.Itoa(day)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseShiftDays reads the ISO weekdays stored by formatShiftDays
+func parseShiftDays(stored string) ([]int, error) {
+	days := []int{}
+	if stored == "" {
+		return days, nil
+	}
+	for _, part := range strings.Split(stored, ",") {
+		day, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shift day %q", part)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// GetSchedules retrieves the shift schedules.
+func (r *shiftRepository) GetSchedules() ([]models.ShiftSchedule, error) {
+	rows, err := r.DB.Query(`SELECT id, role, branch, days, start_time, end_time
+		FROM shift_schedules WHERE tenant_id = ? ORDER BY role, branch, start_time, id`, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shift schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.ShiftSchedule{}
+	for rows.Next() {
+		var schedule models.ShiftSchedule
+		var days string
+		if err := rows.Scan(&schedule.ID, &schedule.Role, &schedule.Branch, &days, &schedule.Start, &schedule.End); err != nil {
+			return nil, fmt.Errorf("failed to scan shift schedule: %w", err)
+		}
+		if schedule.Days, err = parseShiftDays(days); err != nil {
+			return nil, fmt.Errorf("shift schedule %s: %w", schedule.ID, err)
+		}
+		schedules = append(schedules, schedule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shift schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// ReplaceSchedules replaces the shift schedules in a transaction, so the tenant is
+// never left without schedules halfway.
+func (r *shiftRepository) ReplaceSchedules(schedules []models.ShiftSchedule) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM shift_schedules WHERE tenant_id = ?`, r.TenantID); err != nil {
+		return fmt.Errorf("failed to delete shift schedules: %w", err)
+	}
+	for i := range schedules {
+		schedules[i].ID = uuid.New().String()
+		schedule := schedules[i]
+		_, err := tx.Exec(`INSERT INTO shift_schedules (id, tenant_id, role, branch, days, start_time, end_time) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			schedule.ID, r.TenantID, schedule.Role, schedule.Branch, formatShiftDays(schedule.Days), schedule.Start, schedule.End)
+		if err != nil {
+			return fmt.Errorf("failed to insert shift schedule: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetBranch retrieves the shift branch of a user.
+func (r *shiftRepository) GetBranch(userID string) (string, error) {
+	var branch string
+	err := r.DB.QueryRow(`SELECT COALESCE(shift_branch, '') FROM users WHERE id = ? AND tenant_id = ?`, userID, r.TenantID).Scan(&branch)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("user %s not found: %w", userID, err)
+		}
+		return "", fmt.Errorf("failed to get shift branch of user %s: %w", userID, err)
+	}
+	return branch, nil
+}
+
+// SetBranch sets the shift branch of a user.
+func (r *shiftRepository) SetBranch(userID, branch string) error {
+	result, err := r.DB.Exec(`UPDATE users SET shift_branch = NULLIF(?, '') WHERE id = ? AND tenant_id = ?`, branch, userID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update shift branch of user %s: %w", userID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// AddOverride inserts a shift override.
+func (r *shiftRepository) AddOverride(override *models.ShiftOverride) error {
+	override.ID = uuid.New().String()
+	override.CreatedAt = time.Now()
+	_, err := r.DB.Exec(`INSERT INTO shift_overrides (id, tenant_id, user_id, reason, granted_by, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		override.ID, r.TenantID, override.UserID, override.Reason, override.GrantedBy, override.CreatedAt, override.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to add shift override: %w", err)
+	}
+	return nil
+}
+
+// ActiveOverride retrieves the override of a user in effect at the given time.
+func (r *shiftRepository) ActiveOverride(userID string, at time.Time) (*models.ShiftOverride, error) {
+	var override models.ShiftOverride
+	err := r.DB.QueryRow(`SELECT id, user_id, reason, granted_by, created_at, expires_at
+		FROM shift_overrides WHERE tenant_id = ? AND user_id = ? AND expires_at > ?
+		ORDER BY expires_at DESC LIMIT 1`, r.TenantID, userID, at).
+		Scan(&override.ID, &override.UserID, &override.Reason, &override.GrantedBy, &override.CreatedAt, &override.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get shift override of user %s: %w", userID, err)
+	}
+	return &override, nil
+}
+
+// ActiveOverrides retrieves the overrides in effect at the given time.
+func (r *shiftRepository) ActiveOverrides(at time.Time) ([]models.ShiftOverride, error) {
+	rows, err := r.DB.Query(`SELECT id, user_id, reason, granted_by, created_at, expires_at
+		FROM shift_overrides WHERE tenant_id = ? AND expires_at > ? ORDER BY expires_at, id`, r.TenantID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shift overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []models.ShiftOverride{}
+	for rows.Next() {
+		var override models.ShiftOverride
+		if err := rows.Scan(&override.ID, &override.UserID, &override.Reason, &override.GrantedBy, &override.CreatedAt, &override.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan shift override: %w", err)
+		}
+		overrides = append(overrides, override)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shift overrides: %w", err)
+	}
+	return overrides, nil
+}
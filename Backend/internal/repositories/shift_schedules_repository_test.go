@@ -0,0 +1,98 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockShiftRepo(t *testing.T) (repositories.ShiftRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewShiftRepository(db), mock
+}
+
+func TestShiftRepositoryReplaceAndGetSchedules(t *testing.T) {
+	repo, mock := newMockShiftRepo(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM shift_schedules WHERE tenant_id = ?`).WithArgs(models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`INSERT INTO shift_schedules (id, tenant_id, role, branch, days, start_time, end_time) VALUES (?, ?, ?, ?, ?, ?, ?)`).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "staff", "CEBU", "1,2,3", "08:00", "17:00").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	schedules := []models.ShiftSchedule{{Role: "staff", Branch: "CEBU", Days: []int{1, 2, 3}, Start: "08:00", End: "17:00"}}
+	require.NoError(t, repo.ReplaceSchedules(schedules))
+	assert.NotEmpty(t, schedules[0].ID)
+
+	mock.ExpectQuery(`SELECT id, role, branch, days, start_time, end_time
+		FROM shift_schedules WHERE tenant_id = ? ORDER BY role, branch, start_time, id`).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role", "branch", "days", "start_time", "end_time"}).
+			AddRow("s-1", "staff", "", "", "08:00", "17:00").
+			AddRow("s-2", "staff", "CEBU", "1,2,3", "22:00", "06:00"))
+	got, err := repo.GetSchedules()
+	require.NoError(t, err)
+	assert.Equal(t, []models.ShiftSchedule{
+		{ID: "s-1", Role: "staff", Days: []int{}, Start: "08:00", End: "17:00"},
+		{ID: "s-2", Role: "staff", Branch: "CEBU", Days: []int{1, 2, 3}, Start: "22:00", End: "06:00"},
+	}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShiftRepositoryBranch(t *testing.T) {
+	repo, mock := newMockShiftRepo(t)
+
+	mock.ExpectExec(`UPDATE users SET shift_branch = NULLIF(?, '') WHERE id = ? AND tenant_id = ?`).
+		WithArgs("CEBU", "u-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetBranch("u-1", "CEBU"))
+	mock.ExpectExec(`UPDATE users SET shift_branch = NULLIF(?, '') WHERE id = ? AND tenant_id = ?`).
+		WithArgs("", "missing", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.True(t, errors.Is(repo.SetBranch("missing", ""), sql.ErrNoRows))
+
+	mock.ExpectQuery(`SELECT COALESCE(shift_branch, '') FROM users WHERE id = ? AND tenant_id = ?`).
+		WithArgs("u-1", models.DefaultTenantID).WillReturnRows(sqlmock.NewRows([]string{"shift_branch"}).AddRow("CEBU"))
+	branch, err := repo.GetBranch("u-1")
+	require.NoError(t, err)
+	assert.Equal(t, "CEBU", branch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShiftRepositoryOverrides(t *testing.T) {
+	repo, mock := newMockShiftRepo(t)
+	now := time.Date(2025, 3, 12, 20, 0, 0, 0, time.UTC)
+
+	override := &models.ShiftOverride{UserID: "u-1", Reason: "Inventory count", GrantedBy: "admin-1", ExpiresAt: now.Add(2 * time.Hour)}
+	mock.ExpectExec(`INSERT INTO shift_overrides (id, tenant_id, user_id, reason, granted_by, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`).
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "u-1", "Inventory count", "admin-1", sqlmock.AnyArg(), override.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.AddOverride(override))
+	assert.NotEmpty(t, override.ID)
+
+	mock.ExpectQuery(`SELECT id, user_id, reason, granted_by, created_at, expires_at
+		FROM shift_overrides WHERE tenant_id = ? AND user_id = ? AND expires_at > ?
+		ORDER BY expires_at DESC LIMIT 1`).
+		WithArgs(models.DefaultTenantID, "u-2", now).WillReturnError(sql.ErrNoRows)
+	active, err := repo.ActiveOverride("u-2", now)
+	require.NoError(t, err)
+	assert.Nil(t, active, "users without an override have none in effect")
+
+	mock.ExpectQuery(`SELECT id, user_id, reason, granted_by, created_at, expires_at
+		FROM shift_overrides WHERE tenant_id = ? AND expires_at > ? ORDER BY expires_at, id`).
+		WithArgs(models.DefaultTenantID, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "reason", "granted_by", "created_at", "expires_at"}).
+			AddRow(override.ID, "u-1", "Inventory count", "admin-1", now, override.ExpiresAt))
+	overrides, err := repo.ActiveOverrides(now)
+	require.NoError(t, err)
+	require.Len(t, overrides, 1)
+	assert.Equal(t, "u-1", overrides[0].UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"oop/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrNegativeStock is returned when a change would take the quantity of a cab,
+// accessory or material below zero
+var ErrNegativeStock = errors.New("quantity cannot go below zero")
+
+// mysqlCheckViolated is the MySQL error number of a violated CHECK constraint
+const mysqlCheckViolated = 3819
+
+// negativeQuantity is the error for setting an item's quantity below zero
+func negativeQuantity(itemType string, quantity int) error {
+	return fmt.Errorf("%s quantity of %d: %w", itemType, quantity, ErrNegativeStock)
+}
+
+// LowStockQuantity is the quantity at or below which an item is low on stock
+const LowStockQuantity = 2
+
+// CabStatusAfterSale returns the status of a cab with quantity units left after a
+// sale. Selling the last units marks it Out of Stock, and selling down to
+// LowStockQuantity marks it Low Stock; otherwise its status is kept, as cab statuses
+// are set by staff.
+func CabStatusAfterSale(quantity int, current string) string {
+	switch {
+	case quantity == 0:
+		return string(models.StatusOutOfStock)
+	case quantity <= LowStockQuantity:
+		return string(models.StatusLowStock)
+	default:
+		return current
+	}
+}
+
+// CabStatusAfterRestock returns the status of a cab with quantity units after units
+// of a called-off sale were put back in stock. Cabs that sales marked Out of Stock
+// or Low Stock get the status of their quantity; others keep the status staff set.
+func CabStatusAfterRestock(quantity int, current string) string {
+	if current != string(models.StatusOutOfStock) && current != string(models.StatusLowStock) {
+		return current
+	}
+	switch {
+	case quantity == 0:
+		return string(models.StatusOutOfStock)
+	case quantity <= LowStockQuantity:
+		return string(models.StatusLowStock)
+	default:
+		return string(models.StatusInStock)
+	}
+}
+
+// insufficientStock is the error for taking more units of an item than are in stock
+func insufficientStock(itemType string, id, requested int) error {
+	return fmt.Errorf("%s %d has fewer than %d in stock: %w", itemType, id, requested, ErrNegativeStock)
+}
+
+// stockError maps a violated quantity CHECK constraint (migration 025) to
+// ErrNegativeStock and returns other errors as they are
+func stockError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlCheckViolated {
+		return fmt.Errorf("%s: %w", mysqlErr.Message, ErrNegativeStock)
+	}
+	return err
+}
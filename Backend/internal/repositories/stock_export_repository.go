@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oop/internal/models"
+)
+
+// StockExportRepository defines the interface for reading the current stock of an
+// inventory table without loading the whole table into memory.
+type StockExportRepository interface {
+	// EachStock calls fn with the stock of each cab, accessory or material (itemType
+	// is models.ExportCabs, ExportAccessories or ExportMaterials), ordered by ID,
+	// reading one row at a time. It stops at the first error fn returns.
+	EachStock(itemType string, fn func(models.StockRow) error) error
+}
+
+// stockExportRepository implements the StockExportRepository interface.
+type stockExportRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewStockExportRepository creates a new instance of stockExportRepository for the default tenant.
+func NewStockExportRepository(db *sql.DB) StockExportRepository {
+	return &stockExportRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// stockQueries selects the stock of each inventory table; deleted accessories and
+// materials are left out.
+var stockQueries = map[string]string{
+	models.ExportCabs:        `SELECT id, name, make, quantity, price, status FROM multicabs WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY id`,
+	models.ExportAccessories: `SELECT id, name, make, quantity, price, status FROM accessories WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY id`,
+	models.ExportMaterials:   `SELECT id, name, '', quantity, NULL, status FROM materials WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY id`,
+}
+
+// EachStock streams the stock of an inventory table row by row.
+func (r *stockExportRepository) EachStock(itemType string, fn func(models.StockRow) error) error {
+	query, ok := stockQueries[itemType]
+	if !ok {
+		return fmt.Errorf("unknown inventory type %q", itemType)
+	}
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to query %s stock: %w", itemType, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.StockRow
+		var price sql.NullFloat64
+		if err := rows.Scan(&row.ID, &row.Name, &row.Make, &row.Quantity, &price, &row.Status); err != nil {
+			return fmt.Errorf("failed to scan %s stock: %w", itemType, err)
+		}
+		if price.Valid {
+			row.Price = &price.Float64
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating %s stock: %w", itemType, err)
+	}
+	return nil
+}
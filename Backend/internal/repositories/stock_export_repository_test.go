@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"oop/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEachStock(t *testing.T) {
+	db, mock := NewMockDB(t)
+	defer db.Close()
+	repo := NewStockExportRepository(db)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT id, name, '', quantity, NULL, status FROM materials WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY id`)).
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "make", "quantity", "price", "status"}).
+			AddRow(1, "Paint", "", 12, nil, "In Stock").
+			AddRow(2, "Bolts", "", 300, nil, "In Stock").
+			AddRow(3, "Sealant", "", 0, nil, "Out of Stock"))
+
+	// Rows are handed over one at a time, and an error stops the export
+	var names []string
+	stop := errors.New("client went away")
+	err := repo.EachStock(models.ExportMaterials, func(row models.StockRow) error {
+		assert.Nil(t, row.Price)
+		names = append(names, row.Name)
+		if len(names) == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, []string{"Paint", "Bolts"}, names)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	err = repo.EachStock(models.ExportCustomers, func(models.StockRow) error { return nil })
+	require.Error(t, err)
+}
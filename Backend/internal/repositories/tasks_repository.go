@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskRepository defines the interface for task data operations.
+type TaskRepository interface {
+	Create(task *models.Task) error
+	GetByID(id string) (*models.Task, error)
+	Update(task *models.Task) error
+	Delete(id string) error
+	// GetAll returns the tasks matching the filter, soonest due first and tasks without
+	// a due date last.
+	GetAll(filter models.TaskFilter) ([]models.Task, error)
+}
+
+// taskRepository implements the TaskRepository interface.
+type taskRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewTaskRepository creates a new instance of taskRepository for the default tenant.
+func NewTaskRepository(db *sql.DB) TaskRepository {
+	return &taskRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+const taskColumns = `id, title, description, assignee_id, created_by, due_date, status, entity_type, entity_id, completed_at, created_at, updated_at`
+
+// Create stores a new task.
+func (r *taskRepository) Create(task *models.Task) error {
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	now := time.Now()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+
+	query := `
+		INSERT INTO tasks (id, tenant_id, title, description, assignee_id, created_by, due_date, status, entity_type, entity_id, completed_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, task.ID, r.TenantID, task.Title, task.Description, task.AssigneeID, task.CreatedBy, task.DueDate,
+		task.Status, task.EntityType, task.EntityID, task.CompletedAt, task.CreatedAt, task.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a task by its ID.
+func (r *taskRepository) GetByID(id string) (*models.Task, error) {
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ? AND tenant_id = ?`
+
+	task, err := scanTask(r.DB.QueryRow(query, id, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("task not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+
+	return task, nil
+}
+
+// Update saves every editable field of a task, including its status.
+func (r *taskRepository) Update(task *models.Task) error {
+	task.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE tasks SET title = ?, description = ?, assignee_id = ?, due_date = ?, status = ?, entity_type = ?, entity_id = ?,
+			completed_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`
+	result, err := r.DB.Exec(query, task.Title, task.Description, task.AssigneeID, task.DueDate, task.Status, task.EntityType,
+		task.EntityID, task.CompletedAt, task.UpdatedAt, task.ID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("task not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Delete removes a task.
+func (r *taskRepository) Delete(id string) error {
+	result, err := r.DB.Exec(`DELETE FROM tasks WHERE id = ? AND tenant_id = ?`, id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("task not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetAll retrieves the tasks matching the filter.
+func (r *taskRepository) GetAll(filter models.TaskFilter) ([]models.Task, error) {
+	conditions := []string{"tenant_id = ?"}
+	args := []interface{}{r.TenantID}
+
+	if filter.AssigneeID != "" {
+		conditions = append(conditions, "assignee_id = ?")
+		args = append(args, filter.AssigneeID)
+	}
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status IN (?"+strings.Repeat(", ?", len(filter.Statuses)-1)+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.DueBefore != nil {
+		conditions = append(conditions, "due_date < ?")
+		args = append(args, *filter.DueBefore)
+	}
+
+	query := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + strings.Join(conditions, " AND ") +
+		` ORDER BY due_date IS NULL, due_date ASC, created_at ASC`
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task row: %w", err)
+		}
+		tasks = append(tasks, *task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating task rows: %w", err)
+	}
+
+	return tasks, nil
+}
+
+func scanTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	var dueDate, completedAt sql.NullTime
+
+	err := row.Scan(
+		&task.ID,
+		&task.Title,
+		&task.Description,
+		&task.AssigneeID,
+		&task.CreatedBy,
+		&dueDate,
+		&task.Status,
+		&task.EntityType,
+		&task.EntityID,
+		&completedAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dueDate.Valid {
+		task.DueDate = &dueDate.Time
+	}
+	if completedAt.Valid {
+		task.CompletedAt = &completedAt.Time
+	}
+	return &task, nil
+}
@@ -0,0 +1,79 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var taskColumns = []string{"id", "title", "description", "assignee_id", "created_by", "due_date", "status", "entity_type", "entity_id", "completed_at", "created_at", "updated_at"}
+
+const taskSelect = "SELECT id, title, description, assignee_id, created_by, due_date, status, entity_type, entity_id, completed_at, created_at, updated_at FROM tasks"
+
+func newMockTaskRepo(t *testing.T) (repositories.TaskRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewTaskRepository(db), mock
+}
+
+func TestGetAllTasksFilters(t *testing.T) {
+	repo, mock := newMockTaskRepo(t)
+	now := time.Now()
+
+	query := taskSelect + " WHERE tenant_id = ? AND assignee_id = ? AND status IN (?, ?) AND due_date < ?" +
+		" ORDER BY due_date IS NULL, due_date ASC, created_at ASC"
+	rows := sqlmock.NewRows(taskColumns).
+		AddRow("task-1", "Call about balance", "", "user-1", "admin-1", now.Add(-time.Hour), models.TaskStatusOpen,
+			models.TaskEntityCustomer, "customer-1", nil, now, now)
+	mock.ExpectQuery(query).
+		WithArgs(models.DefaultTenantID, "user-1", models.TaskStatusOpen, models.TaskStatusInProgress, now).
+		WillReturnRows(rows)
+
+	tasks, err := repo.GetAll(models.TaskFilter{
+		AssigneeID: "user-1",
+		Statuses:   []string{models.TaskStatusOpen, models.TaskStatusInProgress},
+		DueBefore:  &now,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.NotNil(t, tasks[0].DueDate)
+	assert.Nil(t, tasks[0].CompletedAt)
+	assert.Equal(t, "customer-1", tasks[0].EntityID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTaskByID(t *testing.T) {
+	repo, mock := newMockTaskRepo(t)
+	query := taskSelect + " WHERE id = ? AND tenant_id = ?"
+
+	mock.ExpectQuery(query).WithArgs("missing", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+	task, err := repo.GetByID("missing")
+
+	assert.Nil(t, task)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTaskNotFound(t *testing.T) {
+	repo, mock := newMockTaskRepo(t)
+	mock.ExpectExec(`
+		UPDATE tasks SET title = ?, description = ?, assignee_id = ?, due_date = ?, status = ?, entity_type = ?, entity_id = ?,
+			completed_at = ?, updated_at = ?
+		WHERE id = ? AND tenant_id = ?
+	`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Update(&models.Task{ID: "missing", Title: "Call", Status: models.TaskStatusOpen})
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
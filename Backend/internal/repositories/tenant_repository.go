@@ -0,0 +1,247 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantRepository defines the interface for tenant data operations.
+// Tenants are the only rows that are not themselves scoped to a tenant.
+type TenantRepository interface {
+	Create(tenant *models.Tenant) error
+	GetByID(id string) (*models.Tenant, error)
+	GetBySlug(slug string) (*models.Tenant, error)
+	GetAll() ([]models.Tenant, error)
+	Update(tenant *models.Tenant) error
+	Delete(id string) error
+	GetUsage(id string) (*models.TenantUsage, error)
+	// GetFeatures returns the feature flags stored for a tenant. Features without a
+	// stored value are absent, so callers apply their own defaults.
+	GetFeatures(id string) (map[string]bool, error)
+	SetFeature(id, feature string, enabled bool) error
+}
+
+// tenantRepository implements the TenantRepository interface.
+type tenantRepository struct {
+	DB *sql.DB
+}
+
+// NewTenantRepository creates a new instance of tenantRepository.
+func NewTenantRepository(db *sql.DB) TenantRepository {
+	return &tenantRepository{DB: db}
+}
+
+// Create stores a new tenant.
+func (r *tenantRepository) Create(tenant *models.Tenant) error {
+	if tenant.ID == "" {
+		tenant.ID = uuid.New().String()
+	}
+	now := time.Now()
+	tenant.CreatedAt = now
+	tenant.UpdatedAt = now
+
+	query := `
+		INSERT INTO tenants (id, slug, name, is_active, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(query, tenant.ID, tenant.Slug, tenant.Name, tenant.IsActive, tenant.CreatedAt, tenant.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a tenant by its ID.
+func (r *tenantRepository) GetByID(id string) (*models.Tenant, error) {
+	query := `
+		SELECT id, slug, name, is_active, created_at, updated_at
+		FROM tenants
+		WHERE id = ?
+	`
+	return r.getOne(query, id)
+}
+
+// GetBySlug retrieves a tenant by the subdomain it is served on.
+func (r *tenantRepository) GetBySlug(slug string) (*models.Tenant, error) {
+	query := `
+		SELECT id, slug, name, is_active, created_at, updated_at
+		FROM tenants
+		WHERE slug = ?
+	`
+	return r.getOne(query, slug)
+}
+
+func (r *tenantRepository) getOne(query string, arg string) (*models.Tenant, error) {
+	tenant, err := scanTenant(r.DB.QueryRow(query, arg))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("tenant not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return tenant, nil
+}
+
+// GetAll retrieves all tenants, oldest first so the default tenant comes first.
+func (r *tenantRepository) GetAll() ([]models.Tenant, error) {
+	query := `
+		SELECT id, slug, name, is_active, created_at, updated_at
+		FROM tenants
+		ORDER BY created_at ASC
+	`
+	rows, err := r.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []models.Tenant{}
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant row: %w", err)
+		}
+		tenants = append(tenants, *tenant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant rows: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// Update saves the name and active state of a tenant. The slug never changes, so
+// subdomains and issued tokens keep pointing at the same tenant.
+func (r *tenantRepository) Update(tenant *models.Tenant) error {
+	tenant.UpdatedAt = time.Now()
+
+	query := `UPDATE tenants SET name = ?, is_active = ?, updated_at = ? WHERE id = ?`
+	result, err := r.DB.Exec(query, tenant.Name, tenant.IsActive, tenant.UpdatedAt, tenant.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("tenant not found: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Delete removes a tenant and its feature flags. Rows of the tenant's data are kept,
+// but they can no longer be reached because requests for the tenant are rejected.
+func (r *tenantRepository) Delete(id string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM tenant_features WHERE tenant_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete tenant features: %w", err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM tenants WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("tenant not found: %w", sql.ErrNoRows)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetUsage counts the records a tenant holds across the business tables.
+func (r *tenantRepository) GetUsage(id string) (*models.TenantUsage, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM users WHERE tenant_id = ?),
+			(SELECT COUNT(*) FROM users WHERE tenant_id = ? AND is_active = TRUE),
+			(SELECT COUNT(*) FROM customers WHERE tenant_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM multicabs WHERE tenant_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM accessories WHERE tenant_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM materials WHERE tenant_id = ? AND deleted_at IS NULL),
+			(SELECT COUNT(*) FROM sales WHERE tenant_id = ?),
+			(SELECT COALESCE(SUM(total_price), 0) FROM sales WHERE tenant_id = ?),
+			(SELECT MAX(created_at) FROM sales WHERE tenant_id = ?)
+	`
+	usage := models.TenantUsage{TenantID: id}
+	var lastSaleAt sql.NullTime
+	err := r.DB.QueryRow(query, id, id, id, id, id, id, id, id, id).Scan(
+		&usage.Users, &usage.ActiveUsers, &usage.Customers, &usage.Cabs, &usage.Accessories,
+		&usage.Materials, &usage.Sales, &usage.SalesTotal, &lastSaleAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant usage: %w", err)
+	}
+	if lastSaleAt.Valid {
+		usage.LastSaleAt = &lastSaleAt.Time
+	}
+
+	return &usage, nil
+}
+
+// GetFeatures retrieves the feature flags stored for a tenant.
+func (r *tenantRepository) GetFeatures(id string) (map[string]bool, error) {
+	rows, err := r.DB.Query(`SELECT feature, enabled FROM tenant_features WHERE tenant_id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tenant features: %w", err)
+	}
+	defer rows.Close()
+
+	features := map[string]bool{}
+	for rows.Next() {
+		var feature string
+		var enabled bool
+		if err := rows.Scan(&feature, &enabled); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant feature row: %w", err)
+		}
+		features[feature] = enabled
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenant feature rows: %w", err)
+	}
+
+	return features, nil
+}
+
+// SetFeature stores a feature flag of a tenant, replacing any previous value.
+func (r *tenantRepository) SetFeature(id, feature string, enabled bool) error {
+	query := `
+		INSERT INTO tenant_features (tenant_id, feature, enabled, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), updated_at = VALUES(updated_at)
+	`
+	if _, err := r.DB.Exec(query, id, feature, enabled, time.Now()); err != nil {
+		return fmt.Errorf("failed to set tenant feature: %w", err)
+	}
+	return nil
+}
+
+func scanTenant(row rowScanner) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := row.Scan(&tenant.ID, &tenant.Slug, &tenant.Name, &tenant.IsActive, &tenant.CreatedAt, &tenant.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
@@ -0,0 +1,174 @@
+package repositories_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tenantColumns = []string{"id", "slug", "name", "is_active", "created_at", "updated_at"}
+
+func newMockTenantRepo(t *testing.T) (repositories.TenantRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewTenantRepository(db), mock
+}
+
+func TestCreateTenant(t *testing.T) {
+	repo, mock := newMockTenantRepo(t)
+
+	tenant := &models.Tenant{Slug: "acme", Name: "Acme Motors", IsActive: true}
+	mock.ExpectExec("INSERT INTO tenants (id, slug, name, is_active, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)").
+		WithArgs(sqlmock.AnyArg(), "acme", "Acme Motors", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(tenant)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tenant.ID)
+	assert.False(t, tenant.CreatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTenantBySlug(t *testing.T) {
+	repo, mock := newMockTenantRepo(t)
+	query := "SELECT id, slug, name, is_active, created_at, updated_at FROM tenants WHERE slug = ?"
+	now := time.Now()
+
+	t.Run("Found", func(t *testing.T) {
+		rows := sqlmock.NewRows(tenantColumns).AddRow("tenant-1", "acme", "Acme Motors", true, now, now)
+		mock.ExpectQuery(query).WithArgs("acme").WillReturnRows(rows)
+
+		tenant, err := repo.GetBySlug("acme")
+		require.NoError(t, err)
+		assert.Equal(t, "tenant-1", tenant.ID)
+		assert.True(t, tenant.IsActive)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs("missing").WillReturnError(sql.ErrNoRows)
+
+		tenant, err := repo.GetBySlug("missing")
+		assert.Nil(t, tenant)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.Contains(t, err.Error(), "tenant not found")
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetAllTenants(t *testing.T) {
+	repo, mock := newMockTenantRepo(t)
+	now := time.Now()
+
+	rows := sqlmock.NewRows(tenantColumns).
+		AddRow(models.DefaultTenantID, models.DefaultTenantID, "Default", true, now, now).
+		AddRow("tenant-1", "acme", "Acme Motors", false, now, now)
+	mock.ExpectQuery("SELECT id, slug, name, is_active, created_at, updated_at FROM tenants ORDER BY created_at ASC").
+		WillReturnRows(rows)
+
+	tenants, err := repo.GetAll()
+
+	require.NoError(t, err)
+	require.Len(t, tenants, 2)
+	assert.Equal(t, models.DefaultTenantID, tenants[0].ID)
+	assert.False(t, tenants[1].IsActive)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestForTenantScopesQueries(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+
+	repos := repositories.ForTenant(&repositories.DatabaseClient{DB: db}, "tenant-1")
+
+	mock.ExpectQuery("SELECT id, full_name, email, phone, address, province, city, barangay, latitude, longitude, date_of_birth, date_registered, created_at, updated_at FROM customers WHERE tenant_id = ? AND deleted_at IS NULL ORDER BY created_at DESC").
+		WithArgs("tenant-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "email", "phone", "address", "province", "city", "barangay", "latitude", "longitude", "date_of_birth", "date_registered", "created_at", "updated_at"}))
+	mock.ExpectExec("UPDATE materials SET deleted_at = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NULL").
+		WithArgs(sqlmock.AnyArg(), 7, "tenant-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	customers, err := repos.Customers.GetAllCustomers(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, customers)
+	assert.NoError(t, repos.Materials.Delete(context.Background(), 7))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTenantNotFound(t *testing.T) {
+	repo, mock := newMockTenantRepo(t)
+
+	mock.ExpectExec("UPDATE tenants SET name = ?, is_active = ?, updated_at = ? WHERE id = ?").
+		WithArgs("Acme", false, sqlmock.AnyArg(), "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Update(&models.Tenant{ID: "missing", Name: "Acme"})
+
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteTenant(t *testing.T) {
+	repo, mock := newMockTenantRepo(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM tenant_features WHERE tenant_id = ?").WithArgs("tenant-1").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM tenants WHERE id = ?").WithArgs("tenant-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, repo.Delete("tenant-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTenantUsage(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err, "Failed to create sqlmock")
+	defer db.Close()
+	repo := repositories.NewTenantRepository(db)
+
+	lastSale := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"users", "active_users", "customers", "cabs", "accessories", "materials", "sales", "sales_total", "last_sale_at"}).
+		AddRow(3, 2, 10, 4, 7, 12, 5, 925000.0, lastSale)
+	mock.ExpectQuery(`SELECT MAX\(created_at\) FROM sales WHERE tenant_id = \?`).
+		WithArgs("tenant-1", "tenant-1", "tenant-1", "tenant-1", "tenant-1", "tenant-1", "tenant-1", "tenant-1", "tenant-1").
+		WillReturnRows(rows)
+
+	usage, err := repo.GetUsage("tenant-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", usage.TenantID)
+	assert.Equal(t, 2, usage.ActiveUsers)
+	assert.Equal(t, 925000.0, usage.SalesTotal)
+	require.NotNil(t, usage.LastSaleAt)
+	assert.True(t, lastSale.Equal(*usage.LastSaleAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTenantFeatures(t *testing.T) {
+	repo, mock := newMockTenantRepo(t)
+
+	mock.ExpectExec("INSERT INTO tenant_features (tenant_id, feature, enabled, updated_at) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE enabled = VALUES(enabled), updated_at = VALUES(updated_at)").
+		WithArgs("tenant-1", "sso", false, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	require.NoError(t, repo.SetFeature("tenant-1", "sso", false))
+
+	mock.ExpectQuery("SELECT feature, enabled FROM tenant_features WHERE tenant_id = ?").
+		WithArgs("tenant-1").
+		WillReturnRows(sqlmock.NewRows([]string{"feature", "enabled"}).AddRow("sso", false))
+	features, err := repo.GetFeatures("tenant-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"sso": false}, features)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
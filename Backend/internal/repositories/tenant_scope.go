@@ -0,0 +1,100 @@
+package repositories
+
+// TenantRepositories are the SQL repositories of one tenant.
+//
+// Tenant isolation is enforced here rather than in handlers: every repository is bound
+// to a tenant when it is created, and every statement it runs filters on, or stamps
+// new rows with, that tenant_id. The server only creates repositories through
+// ForTenant, so a handler has no way to reach another tenant's rows. The
+// single-tenant constructors (NewCabsRepository and so on) bind the default tenant.
+type TenantRepositories struct {
+	Users         *UserRepository
+	Materials     MaterialRepository
+	Accessories   AccessoryRepository
+	Customers     CustomerRepository
+	Cabs          CabsRepository
+	Sales         SalesRepository
+	Logs          LogsRepositoryInterface
+	Invites       UserInviteRepository
+	Announcements AnnouncementRepository
+	Tasks         TaskRepository
+	Favorites     FavoriteRepository
+	Views         EntityViewRepository
+	Documents     DocumentTemplateRepository
+	Registers     RegisterSessionRepository
+	Expenses      ExpenseRepository
+	Deposits      DepositRepository
+	Receipts      ReceiptSeriesRepository
+	Fiscal        FiscalCalendarRepository
+	Snapshots     InventorySnapshotRepository
+	Trash         TrashRepository
+	Dormancy      UserDormancyRepository
+	Integrations  IntegrationRepository
+	Accounting    AccountingPostingRepository
+	Sheets        GoogleSheetsExportRepository
+	Calendars     CalendarFeedRepository
+	Integrity     IntegrityRepository
+	PriceChanges  PriceChangeRepository
+	Changes       ChangeRequestRepository
+	Images        ItemImageRepository
+	RefreshTokens RefreshTokenRepository
+	Exports       ExportJobRepository
+	Anomalies     AnomalyRepository
+	Stock         StockExportRepository
+	Payments      SalePaymentRepository
+	Shifts        ShiftRepository
+	LegacyImports LegacyImportRepository
+	Resets        PasswordResetRepository
+	Consents      CustomerConsentRepository
+	Registrations CabRegistrationRepository
+	Insurance     InsurancePolicyRepository
+	Numbers       DisplayNumberRepository
+}
+
+// ForTenant creates the SQL repositories scoped to tenantID
+func ForTenant(dbClient *DatabaseClient, tenantID string) TenantRepositories {
+	db := dbClient.DB
+	return TenantRepositories{
+		Users:         &UserRepository{dbClient: dbClient, tenantID: tenantID},
+		Materials:     &materialRepository{DB: db, TenantID: tenantID},
+		Accessories:   &AccessoryRepositoryImpl{DB: db, TenantID: tenantID},
+		Customers:     &customerRepository{DB: db, TenantID: tenantID},
+		Cabs:          &cabsRepository{DB: db, TenantID: tenantID},
+		Sales:         &salesRepository{DB: db, TenantID: tenantID},
+		Logs:          &LogsRepository{dbClient: db, tenantID: tenantID},
+		Invites:       &userInviteRepository{DB: db, TenantID: tenantID},
+		Announcements: &announcementRepository{DB: db, TenantID: tenantID},
+		Tasks:         &taskRepository{DB: db, TenantID: tenantID},
+		Favorites:     &favoriteRepository{DB: db, TenantID: tenantID},
+		Views:         &entityViewRepository{DB: db, TenantID: tenantID},
+		Documents:     &documentTemplateRepository{DB: db, TenantID: tenantID},
+		Registers:     &registerSessionRepository{DB: db, TenantID: tenantID},
+		Expenses:      &expenseRepository{DB: db, TenantID: tenantID, Files: dbClient.Files},
+		Deposits:      &depositRepository{DB: db, TenantID: tenantID},
+		Receipts:      &receiptSeriesRepository{DB: db, TenantID: tenantID},
+		Fiscal:        &fiscalCalendarRepository{DB: db, TenantID: tenantID},
+		Snapshots:     &inventorySnapshotRepository{DB: db, TenantID: tenantID},
+		Trash:         &trashRepository{DB: db, TenantID: tenantID},
+		Dormancy:      &userDormancyRepository{DB: db, TenantID: tenantID},
+		Integrations:  &integrationRepository{DB: db, TenantID: tenantID},
+		Accounting:    &accountingPostingRepository{DB: db, TenantID: tenantID},
+		Sheets:        &googleSheetsExportRepository{DB: db, TenantID: tenantID},
+		Calendars:     &calendarFeedRepository{DB: db, TenantID: tenantID},
+		Integrity:     &integrityRepository{DB: db, TenantID: tenantID},
+		PriceChanges:  &priceChangeRepository{DB: db, TenantID: tenantID},
+		Changes:       &changeRequestRepository{DB: db, TenantID: tenantID},
+		Images:        &itemImageRepository{DB: db, TenantID: tenantID, Files: dbClient.Files},
+		RefreshTokens: &refreshTokenRepository{DB: db, TenantID: tenantID},
+		Exports:       &exportJobRepository{DB: db, TenantID: tenantID, Files: dbClient.Files},
+		Anomalies:     &anomalyRepository{DB: db, TenantID: tenantID},
+		Stock:         &stockExportRepository{DB: db, TenantID: tenantID},
+		Payments:      &salePaymentRepository{DB: db, TenantID: tenantID},
+		Shifts:        &shiftRepository{DB: db, TenantID: tenantID},
+		LegacyImports: &legacyImportRepository{DB: db, TenantID: tenantID},
+		Resets:        &passwordResetRepository{DB: db, TenantID: tenantID},
+		Consents:      &customerConsentRepository{DB: db, TenantID: tenantID},
+		Registrations: &cabRegistrationRepository{DB: db, TenantID: tenantID},
+		Insurance:     &insurancePolicyRepository{DB: db, TenantID: tenantID},
+		Numbers:       &displayNumberRepository{DB: db, TenantID: tenantID},
+	}
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oop/internal/models"
+	"strings"
+)
+
+// TrashRepository defines the interface for the soft-deleted customers, cabs,
+// accessories and materials of a tenant.
+type TrashRepository interface {
+	// SetDeletedBy records who deleted a record that was just soft-deleted.
+	SetDeletedBy(entityType, entityID, userID string) error
+	// List returns the deleted records of a type, or of every type when entityType is
+	// empty, most recently deleted first.
+	List(entityType string, limit int) ([]models.TrashItem, error)
+	// Restore clears the deletion of a record.
+	Restore(entityType, entityID string) error
+	// Purge removes a deleted record for good.
+	Purge(entityType, entityID string) error
+}
+
+// trashTable is where the deleted records of one type are kept
+type trashTable struct {
+	table      string
+	nameColumn string
+}
+
+// trashTables are the tables of the record types that are soft-deleted
+var trashTables = map[string]trashTable{
+	models.TrashCustomer:  {table: "customers", nameColumn: "full_name"},
+	models.TrashCab:       {table: "multicabs", nameColumn: "name"},
+	models.TrashAccessory: {table: "accessories", nameColumn: "name"},
+	models.TrashMaterial:  {table: "materials", nameColumn: "name"},
+}
+
+// trashTypes lists the record types in a stable order
+var trashTypes = []string{models.TrashCustomer, models.TrashCab, models.TrashAccessory, models.TrashMaterial}
+
+// trashRepository implements the TrashRepository interface.
+type trashRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewTrashRepository creates a new instance of trashRepository for the default tenant.
+func NewTrashRepository(db *sql.DB) TrashRepository {
+	return &trashRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// tableOf returns the table of a record type
+func tableOf(entityType string) (trashTable, error) {
+	table, ok := trashTables[entityType]
+	if !ok {
+		return trashTable{}, fmt.Errorf("%q records are not kept in the trash", entityType)
+	}
+	return table, nil
+}
+
+// SetDeletedBy records who deleted a record.
+func (r *trashRepository) SetDeletedBy(entityType, entityID, userID string) error {
+	table, err := tableOf(entityType)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE ` + table.table + ` SET deleted_by = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+	if _, err := r.DB.Exec(query, userID, entityID, r.TenantID); err != nil {
+		return fmt.Errorf("failed to record who deleted %s %s: %w", entityType, entityID, err)
+	}
+	return nil
+}
+
+// List retrieves deleted records across the trashed tables.
+func (r *trashRepository) List(entityType string, limit int) ([]models.TrashItem, error) {
+	types := trashTypes
+	if entityType != "" {
+		if _, err := tableOf(entityType); err != nil {
+			return nil, err
+		}
+		types = []string{entityType}
+	}
+
+	selects := make([]string, 0, len(types))
+	args := make([]interface{}, 0, len(types)+1)
+	for _, t := range types {
+		table := trashTables[t]
+		selects = append(selects, `SELECT '`+t+`' AS entity_type, CAST(id AS CHAR) AS entity_id, `+table.nameColumn+` AS name, deleted_at, COALESCE(deleted_by, '') AS deleted_by
+			FROM `+table.table+` WHERE tenant_id = ? AND deleted_at IS NOT NULL`)
+		args = append(args, r.TenantID)
+	}
+	query := strings.Join(selects, ` UNION ALL `) + ` ORDER BY deleted_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := r.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		if err := rows.Scan(&item.EntityType, &item.EntityID, &item.Name, &item.DeletedAt, &item.DeletedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan trash row: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating trash rows: %w", err)
+	}
+	return items, nil
+}
+
+// Restore clears the deletion of a record.
+func (r *trashRepository) Restore(entityType, entityID string) error {
+	table, err := tableOf(entityType)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE ` + table.table + ` SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+	return r.execDeleted(query, "restore", entityType, entityID)
+}
+
+// Purge removes a deleted record. Records still referenced, such as customers with
+// sales, cannot be purged.
+func (r *trashRepository) Purge(entityType, entityID string) error {
+	table, err := tableOf(entityType)
+	if err != nil {
+		return err
+	}
+
+	query := `DELETE FROM ` + table.table + ` WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`
+	return r.execDeleted(query, "purge", entityType, entityID)
+}
+
+// execDeleted runs a statement on one deleted record, failing when it is not in the trash
+func (r *trashRepository) execDeleted(query, action, entityType, entityID string) error {
+	result, err := r.DB.Exec(query, entityID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to %s %s %s: %w", action, entityType, entityID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("deleted %s %s not found: %w", entityType, entityID, sql.ErrNoRows)
+	}
+	return nil
+}
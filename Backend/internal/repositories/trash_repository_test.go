@@ -0,0 +1,81 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockTrashRepo(t *testing.T) (repositories.TrashRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewTrashRepository(db), mock
+}
+
+func TestListTrash(t *testing.T) {
+	repo, mock := newMockTrashRepo(t)
+	deletedAt := time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT 'customer' AS entity_type, CAST(id AS CHAR) AS entity_id, full_name AS name, deleted_at, COALESCE(deleted_by, '') AS deleted_by FROM customers WHERE tenant_id = ? AND deleted_at IS NOT NULL`+
+		` UNION ALL SELECT 'cab' AS entity_type, CAST(id AS CHAR) AS entity_id, name AS name, deleted_at, COALESCE(deleted_by, '') AS deleted_by FROM multicabs WHERE tenant_id = ? AND deleted_at IS NOT NULL`+
+		` UNION ALL SELECT 'accessory' AS entity_type, CAST(id AS CHAR) AS entity_id, name AS name, deleted_at, COALESCE(deleted_by, '') AS deleted_by FROM accessories WHERE tenant_id = ? AND deleted_at IS NOT NULL`+
+		` UNION ALL SELECT 'material' AS entity_type, CAST(id AS CHAR) AS entity_id, name AS name, deleted_at, COALESCE(deleted_by, '') AS deleted_by FROM materials WHERE tenant_id = ? AND deleted_at IS NOT NULL`+
+		` ORDER BY deleted_at DESC LIMIT ?`).
+		WithArgs(models.DefaultTenantID, models.DefaultTenantID, models.DefaultTenantID, models.DefaultTenantID, 50).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_type", "entity_id", "name", "deleted_at", "deleted_by"}).
+			AddRow("material", "7", "Paint", deletedAt, "user-1").
+			AddRow("customer", "c-1", "Juan Dela Cruz", deletedAt.Add(-time.Hour), ""))
+
+	items, err := repo.List("", 50)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, models.TrashItem{EntityType: models.TrashMaterial, EntityID: "7", Name: "Paint", DeletedAt: deletedAt, DeletedBy: "user-1"}, items[0])
+
+	mock.ExpectQuery(`SELECT 'customer' AS entity_type, CAST(id AS CHAR) AS entity_id, full_name AS name, deleted_at, COALESCE(deleted_by, '') AS deleted_by FROM customers WHERE tenant_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT ?`).
+		WithArgs(models.DefaultTenantID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"entity_type", "entity_id", "name", "deleted_at", "deleted_by"}))
+	items, err = repo.List(models.TrashCustomer, 10)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	_, err = repo.List("sale", 10)
+	assert.Error(t, err, "sales are not soft-deleted")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreAndPurgeTrash(t *testing.T) {
+	repo, mock := newMockTrashRepo(t)
+
+	mock.ExpectExec(`UPDATE accessories SET deleted_by = ? WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`).
+		WithArgs("user-1", "4", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetDeletedBy(models.TrashAccessory, "4", "user-1"))
+
+	mock.ExpectExec(`UPDATE accessories SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`).
+		WithArgs("4", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Restore(models.TrashAccessory, "4"))
+
+	mock.ExpectExec(`DELETE FROM customers WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`).
+		WithArgs("c-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	err := repo.Purge(models.TrashCustomer, "c-1")
+	assert.True(t, errors.Is(err, sql.ErrNoRows), "only deleted records are purged")
+
+	mock.ExpectExec(`DELETE FROM materials WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`).
+		WithArgs("7", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Purge(models.TrashMaterial, "7"))
+
+	mock.ExpectExec(`UPDATE multicabs SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND tenant_id = ? AND deleted_at IS NOT NULL`).
+		WithArgs("3", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Restore(models.TrashCab, "3"))
+
+	assert.Error(t, repo.Purge("sale", "1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
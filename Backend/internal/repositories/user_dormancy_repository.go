@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"oop/internal/models"
+	"time"
+)
+
+// UserDormancyRepository defines the interface for the sign-in activity of users and
+// their exemptions from the dormant account policy.
+type UserDormancyRepository interface {
+	// RecordLogin stores when a user signed in.
+	RecordLogin(userID string, at time.Time) error
+	// RecordReactivation stores when a user was reactivated, which restarts the
+	// count of days without a sign-in.
+	RecordReactivation(userID string, at time.Time) error
+	// SetExempt sets whether a user is exempt from the dormant account policy.
+	SetExempt(userID string, exempt bool) error
+	// FindDormant returns the active users who have not signed in or been reactivated
+	// since cutoff, and were created before it, least recently active first.
+	// Exempt users are included so callers can report them.
+	FindDormant(cutoff time.Time) ([]models.UserDormancy, error)
+}
+
+// userDormancyRepository implements the UserDormancyRepository interface.
+type userDormancyRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewUserDormancyRepository creates a new instance of userDormancyRepository for the default tenant.
+func NewUserDormancyRepository(db *sql.DB) UserDormancyRepository {
+	return &userDormancyRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// RecordLogin stores when a user signed in.
+func (r *userDormancyRepository) RecordLogin(userID string, at time.Time) error {
+	_, err := r.DB.Exec(`UPDATE users SET last_login_at = ? WHERE id = ? AND tenant_id = ?`, at, userID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to record login of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// RecordReactivation stores when a user was reactivated.
+func (r *userDormancyRepository) RecordReactivation(userID string, at time.Time) error {
+	_, err := r.DB.Exec(`UPDATE users SET reactivated_at = ? WHERE id = ? AND tenant_id = ?`, at, userID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to record reactivation of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// SetExempt sets whether a user is exempt from the dormant account policy.
+func (r *userDormancyRepository) SetExempt(userID string, exempt bool) error {
+	result, err := r.DB.Exec(`UPDATE users SET dormancy_exempt = ? WHERE id = ? AND tenant_id = ?`, exempt, userID, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update dormancy exemption of user %s: %w", userID, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// FindDormant retrieves the active users inactive since cutoff.
+func (r *userDormancyRepository) FindDormant(cutoff time.Time) ([]models.UserDormancy, error) {
+	// GREATEST is NULL when any argument is, so both dates fall back to created_at
+	query := `
+		SELECT id, username, email, role, created_at, last_login_at, reactivated_at, dormancy_exempt
+		FROM users
+		WHERE tenant_id = ? AND is_active = TRUE
+			AND GREATEST(COALESCE(last_login_at, created_at), COALESCE(reactivated_at, created_at)) < ?
+		ORDER BY GREATEST(COALESCE(last_login_at, created_at), COALESCE(reactivated_at, created_at)) ASC
+	`
+	rows, err := r.DB.Query(query, r.TenantID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dormant users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.UserDormancy{}
+	for rows.Next() {
+		var user models.UserDormancy
+		var lastLogin, reactivated sql.NullTime
+		if err := rows.Scan(&user.UserID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &lastLogin, &reactivated, &user.Exempt); err != nil {
+			return nil, fmt.Errorf("failed to scan dormant user row: %w", err)
+		}
+		if lastLogin.Valid {
+			user.LastLoginAt = &lastLogin.Time
+		}
+		if reactivated.Valid {
+			user.ReactivatedAt = &reactivated.Time
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating dormant user rows: %w", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,74 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockUserDormancyRepo(t *testing.T) (repositories.UserDormancyRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewUserDormancyRepository(db), mock
+}
+
+func TestRecordLoginAndExemption(t *testing.T) {
+	repo, mock := newMockUserDormancyRepo(t)
+	at := time.Now()
+
+	mock.ExpectExec(`UPDATE users SET last_login_at = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(at, "user-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.RecordLogin("user-1", at))
+
+	mock.ExpectExec(`UPDATE users SET reactivated_at = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(at, "user-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.RecordReactivation("user-1", at))
+
+	mock.ExpectExec(`UPDATE users SET dormancy_exempt = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(true, "user-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SetExempt("user-1", true))
+
+	mock.ExpectExec(`UPDATE users SET dormancy_exempt = ? WHERE id = ? AND tenant_id = ?`).
+		WithArgs(false, "missing", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+	err := repo.SetExempt("missing", false)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindDormantUsers(t *testing.T) {
+	repo, mock := newMockUserDormancyRepo(t)
+	cutoff := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := cutoff.AddDate(-1, 0, 0)
+	lastLogin := cutoff.AddDate(0, -2, 0)
+
+	mock.ExpectQuery(`
+		SELECT id, username, email, role, created_at, last_login_at, reactivated_at, dormancy_exempt
+		FROM users
+		WHERE tenant_id = ? AND is_active = TRUE
+			AND GREATEST(COALESCE(last_login_at, created_at), COALESCE(reactivated_at, created_at)) < ?
+		ORDER BY GREATEST(COALESCE(last_login_at, created_at), COALESCE(reactivated_at, created_at)) ASC
+	`).WithArgs(models.DefaultTenantID, cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "created_at", "last_login_at", "reactivated_at", "dormancy_exempt"}).
+			AddRow("user-1", "never", "never@example.com", "staff", created, nil, nil, false).
+			AddRow("user-2", "away", "away@example.com", "staff", created, lastLogin, created.AddDate(0, 1, 0), true))
+
+	users, err := repo.FindDormant(cutoff)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Nil(t, users[0].LastLoginAt)
+	assert.Equal(t, created, users[0].LastActiveAt())
+	require.NotNil(t, users[1].LastLoginAt)
+	require.NotNil(t, users[1].ReactivatedAt)
+	assert.Equal(t, lastLogin, users[1].LastActiveAt(), "the later of the last login and the reactivation")
+	assert.True(t, users[1].Exempt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
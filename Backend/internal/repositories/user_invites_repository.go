@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"oop/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserInviteRepository defines the interface for user invitation data operations.
+type UserInviteRepository interface {
+	Create(invite *models.UserInvite) error
+	GetPending() ([]models.UserInvite, error)
+	GetByTokenHash(tokenHash string) (*models.UserInvite, error)
+	MarkAccepted(id string) error
+}
+
+// userInviteRepository implements the UserInviteRepository interface.
+type userInviteRepository struct {
+	DB       *sql.DB
+	TenantID string // Every query is scoped to this tenant
+}
+
+// NewUserInviteRepository creates a new instance of userInviteRepository for the default tenant.
+func NewUserInviteRepository(db *sql.DB) UserInviteRepository {
+	return &userInviteRepository{DB: db, TenantID: models.DefaultTenantID}
+}
+
+// Create stores a new invitation.
+func (r *userInviteRepository) Create(invite *models.UserInvite) error {
+	if invite.ID == "" {
+		invite.ID = uuid.New().String()
+	}
+	invite.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO user_invites (id, tenant_id, user_id, email, role, token_hash, invited_by, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := r.DB.Exec(
+		query,
+		invite.ID,
+		r.TenantID,
+		invite.UserID,
+		invite.Email,
+		invite.Role,
+		invite.TokenHash,
+		invite.InvitedBy,
+		invite.ExpiresAt,
+		invite.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user invite: %w", err)
+	}
+
+	return nil
+}
+
+// GetPending retrieves all invitations that have not been accepted yet, including expired ones.
+func (r *userInviteRepository) GetPending() ([]models.UserInvite, error) {
+	query := `
+		SELECT id, user_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+		FROM user_invites
+		WHERE accepted_at IS NULL AND tenant_id = ?
+		ORDER BY created_at DESC
+	`
+	rows, err := r.DB.Query(query, r.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending invites: %w", err)
+	}
+	defer rows.Close()
+
+	invites := []models.UserInvite{}
+	for rows.Next() {
+		invite, err := scanUserInvite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invite row: %w", err)
+		}
+		invites = append(invites, *invite)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating invite rows: %w", err)
+	}
+
+	return invites, nil
+}
+
+// GetByTokenHash retrieves an invitation by the hash of its setup token.
+func (r *userInviteRepository) GetByTokenHash(tokenHash string) (*models.UserInvite, error) {
+	query := `
+		SELECT id, user_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at
+		FROM user_invites
+		WHERE token_hash = ? AND tenant_id = ?
+	`
+	invite, err := scanUserInvite(r.DB.QueryRow(query, tokenHash, r.TenantID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("invite not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	return invite, nil
+}
+
+// MarkAccepted records that an invitation has been used. An invitation can only be accepted once.
+func (r *userInviteRepository) MarkAccepted(id string) error {
+	query := `UPDATE user_invites SET accepted_at = ? WHERE id = ? AND tenant_id = ? AND accepted_at IS NULL`
+	result, err := r.DB.Exec(query, time.Now(), id, r.TenantID)
+	if err != nil {
+		return fmt.Errorf("failed to accept invite: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("invite not found or already accepted")
+	}
+
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUserInvite(row rowScanner) (*models.UserInvite, error) {
+	var invite models.UserInvite
+	var acceptedAt sql.NullTime
+
+	err := row.Scan(
+		&invite.ID,
+		&invite.UserID,
+		&invite.Email,
+		&invite.Role,
+		&invite.TokenHash,
+		&invite.InvitedBy,
+		&invite.ExpiresAt,
+		&acceptedAt,
+		&invite.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if acceptedAt.Valid {
+		invite.AcceptedAt = &acceptedAt.Time
+	}
+
+	return &invite, nil
+}
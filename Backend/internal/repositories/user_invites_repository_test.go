@@ -0,0 +1,122 @@
+package repositories_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userInviteColumns = []string{"id", "user_id", "email", "role", "token_hash", "invited_by", "expires_at", "accepted_at", "created_at"}
+
+func newMockUserInviteRepo(t *testing.T) (repositories.UserInviteRepository, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err, "Failed to create sqlmock")
+	t.Cleanup(func() { db.Close() })
+	return repositories.NewUserInviteRepository(db), mock
+}
+
+func TestCreateUserInvite(t *testing.T) {
+	repo, mock := newMockUserInviteRepo(t)
+
+	invite := &models.UserInvite{
+		UserID:    "user-1",
+		Email:     "jane@example.com",
+		Role:      "staff",
+		TokenHash: "hash",
+		InvitedBy: "admin-1",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	mock.ExpectExec("INSERT INTO user_invites (id, tenant_id, user_id, email, role, token_hash, invited_by, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)").
+		WithArgs(sqlmock.AnyArg(), models.DefaultTenantID, "user-1", "jane@example.com", "staff", "hash", "admin-1", invite.ExpiresAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(invite)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, invite.ID)
+	assert.False(t, invite.CreatedAt.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetPendingUserInvites(t *testing.T) {
+	repo, mock := newMockUserInviteRepo(t)
+	now := time.Now()
+
+	rows := sqlmock.NewRows(userInviteColumns).
+		AddRow("inv-1", "user-1", "jane@example.com", "staff", "hash1", "admin-1", now.Add(time.Hour), nil, now).
+		AddRow("inv-2", "user-2", "john@example.com", "admin", "hash2", "admin-1", now.Add(-time.Hour), nil, now)
+
+	mock.ExpectQuery("SELECT id, user_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at FROM user_invites WHERE accepted_at IS NULL AND tenant_id = ? ORDER BY created_at DESC").
+		WithArgs(models.DefaultTenantID).
+		WillReturnRows(rows)
+
+	invites, err := repo.GetPending()
+
+	assert.NoError(t, err)
+	assert.Len(t, invites, 2)
+	assert.Equal(t, "jane@example.com", invites[0].Email)
+	assert.Nil(t, invites[0].AcceptedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetUserInviteByTokenHash(t *testing.T) {
+	query := "SELECT id, user_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at FROM user_invites WHERE token_hash = ? AND tenant_id = ?"
+
+	t.Run("Found and accepted", func(t *testing.T) {
+		repo, mock := newMockUserInviteRepo(t)
+		now := time.Now()
+		rows := sqlmock.NewRows(userInviteColumns).
+			AddRow("inv-1", "user-1", "jane@example.com", "staff", "hash1", "admin-1", now.Add(time.Hour), now, now)
+		mock.ExpectQuery(query).WithArgs("hash1", models.DefaultTenantID).WillReturnRows(rows)
+
+		invite, err := repo.GetByTokenHash("hash1")
+
+		assert.NoError(t, err)
+		require.NotNil(t, invite)
+		assert.Equal(t, "inv-1", invite.ID)
+		assert.NotNil(t, invite.AcceptedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not found", func(t *testing.T) {
+		repo, mock := newMockUserInviteRepo(t)
+		mock.ExpectQuery(query).WithArgs("missing", models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+
+		invite, err := repo.GetByTokenHash("missing")
+
+		assert.Nil(t, invite)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMarkUserInviteAccepted(t *testing.T) {
+	query := "UPDATE user_invites SET accepted_at = ? WHERE id = ? AND tenant_id = ? AND accepted_at IS NULL"
+
+	t.Run("Success", func(t *testing.T) {
+		repo, mock := newMockUserInviteRepo(t)
+		mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), "inv-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.MarkAccepted("inv-1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Already accepted", func(t *testing.T) {
+		repo, mock := newMockUserInviteRepo(t)
+		mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), "inv-1", models.DefaultTenantID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.MarkAccepted("inv-1")
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "already accepted")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -15,17 +16,19 @@ import (
 // UserRepository handles database operations related to users
 type UserRepository struct {
 	dbClient *DatabaseClient
+	tenantID string // Every query is scoped to this tenant
 }
 
-// NewUserRepository creates a new UserRepository instance
+// NewUserRepository creates a new UserRepository instance for the default tenant
 func NewUserRepository(dbClient *DatabaseClient) *UserRepository {
 	return &UserRepository{
 		dbClient: dbClient,
+		tenantID: models.DefaultTenantID,
 	}
 }
 
 // Create adds a new user to the database
-func (r *UserRepository) Create(user *models.User) error {
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	// Generate a new UUID for the user if not provided
 	if user.Id == "" {
 		user.Id = uuid.New().String()
@@ -52,12 +55,13 @@ func (r *UserRepository) Create(user *models.User) error {
 
 	// Insert the user into the database
 	query := `
-		INSERT INTO users (id, username, full_name, email, password_hash, role, created_at, updated_at, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO users (id, tenant_id, username, full_name, email, password_hash, role, created_at, updated_at, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err = r.dbClient.DB.Exec(
+	_, err = r.dbClient.DB.ExecContext(ctx,
 		query,
 		user.Id,
+		r.tenantID,
 		user.Username,
 		user.FullName,
 		user.Email,
@@ -76,13 +80,13 @@ func (r *UserRepository) Create(user *models.User) error {
 }
 
 // GetByID retrieves a user by their ID
-func (r *UserRepository) GetByID(id string) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	query := `
 		SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active
 		FROM users
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ?
 	`
-	row := r.dbClient.DB.QueryRow(query, id)
+	row := r.dbClient.DB.QueryRowContext(ctx, query, id, r.tenantID)
 
 	var user models.User
 	err := row.Scan(
@@ -108,13 +112,13 @@ func (r *UserRepository) GetByID(id string) (*models.User, error) {
 }
 
 // GetByEmail retrieves a user by their email address
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
 		SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active
 		FROM users
-		WHERE email = ?
+		WHERE email = ? AND tenant_id = ?
 	`
-	row := r.dbClient.DB.QueryRow(query, email)
+	row := r.dbClient.DB.QueryRowContext(ctx, query, email, r.tenantID)
 
 	var user models.User
 	err := row.Scan(
@@ -140,16 +144,16 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 // Update updates an existing user in the database
-func (r *UserRepository) Update(user *models.User) error {
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	// Update the timestamp
 	user.UpdatedAt = time.Now()
 
 	query := `
 		UPDATE users
 		SET username = ?, full_name = ?, email = ?, role = ?, updated_at = ?, is_active = ?
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ?
 	`
-	result, err := r.dbClient.DB.Exec(
+	result, err := r.dbClient.DB.ExecContext(ctx,
 		query,
 		user.Username,
 		user.FullName,
@@ -158,6 +162,7 @@ func (r *UserRepository) Update(user *models.User) error {
 		user.UpdatedAt,
 		user.IsActive,
 		user.Id,
+		r.tenantID,
 	)
 
 	if err != nil {
@@ -177,7 +182,7 @@ func (r *UserRepository) Update(user *models.User) error {
 }
 
 // UpdatePassword updates a user's password
-func (r *UserRepository) UpdatePassword(userID string, newPassword string) error {
+func (r *UserRepository) UpdatePassword(ctx context.Context, userID string, newPassword string) error {
 	// Hash the new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
@@ -190,13 +195,14 @@ func (r *UserRepository) UpdatePassword(userID string, newPassword string) error
 	query := `
 		UPDATE users
 		SET password_hash = ?, updated_at = ?
-		WHERE id = ?
+		WHERE id = ? AND tenant_id = ?
 	`
-	result, err := r.dbClient.DB.Exec(
+	result, err := r.dbClient.DB.ExecContext(ctx,
 		query,
 		string(hashedPassword),
 		updatedAt,
 		userID,
+		r.tenantID,
 	)
 
 	if err != nil {
@@ -216,9 +222,9 @@ func (r *UserRepository) UpdatePassword(userID string, newPassword string) error
 }
 
 // Delete removes a user from the database
-func (r *UserRepository) Delete(id string) error {
-	query := `DELETE FROM users WHERE id = ?`
-	result, err := r.dbClient.DB.Exec(query, id)
+func (r *UserRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM users WHERE id = ? AND tenant_id = ?`
+	result, err := r.dbClient.DB.ExecContext(ctx, query, id, r.tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -236,14 +242,15 @@ func (r *UserRepository) Delete(id string) error {
 }
 
 // GetAll retrieves all users from the database
-func (r *UserRepository) GetAll() ([]*models.User, error) {
+func (r *UserRepository) GetAll(ctx context.Context) ([]*models.User, error) {
 	log.Println("Getting all users")
 	query := `
 		SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active
 		FROM users
+		WHERE tenant_id = ?
 		ORDER BY created_at DESC
 	`
-	rows, err := r.dbClient.DB.Query(query)
+	rows, err := r.dbClient.DB.QueryContext(ctx, query, r.tenantID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
 	}
@@ -277,13 +284,13 @@ func (r *UserRepository) GetAll() ([]*models.User, error) {
 }
 
 // GetByUsername retrieves a user by their username
-func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	query := `
 		SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active
 		FROM users
-		WHERE username = ?
+		WHERE username = ? AND tenant_id = ?
 	`
-	row := r.dbClient.DB.QueryRow(query, username)
+	row := r.dbClient.DB.QueryRowContext(ctx, query, username, r.tenantID)
 
 	var user models.User
 	err := row.Scan(
@@ -309,10 +316,10 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 }
 
 // UsernameExists checks if a username already exists in the database
-func (r *UserRepository) UsernameExists(username string) (bool, error) {
+func (r *UserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)`
-	err := r.dbClient.DB.QueryRow(query, username).Scan(&exists)
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE username = ? AND tenant_id = ?)`
+	err := r.dbClient.DB.QueryRowContext(ctx, query, username, r.tenantID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if username exists: %w", err)
 	}
@@ -321,12 +328,12 @@ func (r *UserRepository) UsernameExists(username string) (bool, error) {
 
 // VerifyPassword checks if the provided password matches the stored hash
 // Note: This method does not check if the user is active - that's the responsibility of the handler
-func (r *UserRepository) VerifyPassword(identifier, password string) (*models.User, error) {
+func (r *UserRepository) VerifyPassword(ctx context.Context, identifier, password string) (*models.User, error) {
 	// Try to find user by email first, then by username
-	user, err := r.GetByEmail(identifier)
+	user, err := r.GetByEmail(ctx, identifier)
 	if err != nil {
 		// If not found by email, try username
-		user, err = r.GetByUsername(identifier)
+		user, err = r.GetByUsername(ctx, identifier)
 		if err != nil {
 			return nil, err
 		}
@@ -345,9 +352,9 @@ func (r *UserRepository) VerifyPassword(identifier, password string) (*models.Us
 }
 
 // ActivateUser sets a user's status to active
-func (r *UserRepository) ActivateUser(id string) error {
-	query := `UPDATE users SET is_active = ?, updated_at = ? WHERE id = ?`
-	_, err := r.dbClient.DB.Exec(query, true, time.Now(), id)
+func (r *UserRepository) ActivateUser(ctx context.Context, id string) error {
+	query := `UPDATE users SET is_active = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`
+	_, err := r.dbClient.DB.ExecContext(ctx, query, true, time.Now(), id, r.tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to activate user: %w", err)
 	}
@@ -356,9 +363,9 @@ func (r *UserRepository) ActivateUser(id string) error {
 }
 
 // DeactivateUser sets a user's status to inactive
-func (r *UserRepository) DeactivateUser(id string) error {
-	query := `UPDATE users SET is_active = ?, updated_at = ? WHERE id = ?`
-	_, err := r.dbClient.DB.Exec(query, false, time.Now(), id)
+func (r *UserRepository) DeactivateUser(ctx context.Context, id string) error {
+	query := `UPDATE users SET is_active = ?, updated_at = ? WHERE id = ? AND tenant_id = ?`
+	_, err := r.dbClient.DB.ExecContext(ctx, query, false, time.Now(), id, r.tenantID)
 	if err != nil {
 		return fmt.Errorf("failed to deactivate user: %w", err)
 	}
@@ -367,10 +374,10 @@ func (r *UserRepository) DeactivateUser(id string) error {
 }
 
 // EmailExists checks if an email already exists in the database
-func (r *UserRepository) EmailExists(email string) (bool, error) {
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)`
-	err := r.dbClient.DB.QueryRow(query, email).Scan(&exists)
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND tenant_id = ?)`
+	err := r.dbClient.DB.QueryRowContext(ctx, query, email, r.tenantID).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if email exists: %w", err)
 	}
@@ -379,18 +386,18 @@ func (r *UserRepository) EmailExists(email string) (bool, error) {
 
 // FindByEmailOrUsernameConstantTime attempts to find a user by email or username
 // in a way that takes a consistent amount of time.
-func (r *UserRepository) FindByEmailOrUsernameConstantTime(identifier string) (*models.User, error) {
+func (r *UserRepository) FindByEmailOrUsernameConstantTime(ctx context.Context, identifier string) (*models.User, error) {
 	// Always query for both email and username to maintain consistent timing.
 	// Use COALESCE to handle NULL usernames safely
 	query := `
 		SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active
 		FROM users
-		WHERE email = ? OR (username IS NOT NULL AND username = ?)
+		WHERE (email = ? OR (username IS NOT NULL AND username = ?)) AND tenant_id = ?
 		LIMIT 1
 	`
 
 	var user models.User
-	err := r.dbClient.DB.QueryRow(query, identifier, identifier).Scan(
+	err := r.dbClient.DB.QueryRowContext(ctx, query, identifier, identifier, r.tenantID).Scan(
 		&user.Id,
 		&user.Username,
 		&user.FullName,
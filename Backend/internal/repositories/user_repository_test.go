@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
 	"oop/internal/models"
 	"testing"
@@ -22,6 +23,7 @@ func TestUserRepository_Create(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Create a test user
@@ -34,9 +36,10 @@ func TestUserRepository_Create(t *testing.T) {
 	}
 
 	// Set up the expected SQL query and result
-	mock.ExpectExec("INSERT INTO users (id, username, full_name, email, password_hash, role, created_at, updated_at, is_active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)").
+	mock.ExpectExec("INSERT INTO users (id, tenant_id, username, full_name, email, password_hash, role, created_at, updated_at, is_active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)").
 		WithArgs(
 			user.Id,
+			models.DefaultTenantID,
 			user.Username,
 			user.FullName,
 			user.Email,
@@ -49,7 +52,7 @@ func TestUserRepository_Create(t *testing.T) {
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	// Call the function being tested
-	err = repo.Create(user)
+	err = repo.Create(context.Background(), user)
 
 	// Assert that no errors occurred
 	assert.NoError(t, err)
@@ -71,6 +74,7 @@ func TestUserRepository_GetByID(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
@@ -91,12 +95,12 @@ func TestUserRepository_GetByID(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id", "username", "full_name", "email", "password_hash", "role", "created_at", "updated_at", "is_active"}).
 		AddRow(expectedUser.Id, expectedUser.Username, expectedUser.FullName, expectedUser.Email, expectedUser.Password, expectedUser.Role, expectedUser.CreatedAt, expectedUser.UpdatedAt, expectedUser.IsActive)
 
-	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE id = ?").
-		WithArgs(userID).
+	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE id = ? AND tenant_id = ?").
+		WithArgs(userID, models.DefaultTenantID).
 		WillReturnRows(rows)
 
 	// Call the function being tested
-	user, err := repo.GetByID(userID)
+	user, err := repo.GetByID(context.Background(), userID)
 
 	// Assert that no errors occurred and the user matches the expected user
 	assert.NoError(t, err)
@@ -119,6 +123,7 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
@@ -139,12 +144,12 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	rows := sqlmock.NewRows([]string{"id", "username", "full_name", "email", "password_hash", "role", "created_at", "updated_at", "is_active"}).
 		AddRow(expectedUser.Id, expectedUser.Username, expectedUser.FullName, expectedUser.Email, expectedUser.Password, expectedUser.Role, expectedUser.CreatedAt, expectedUser.UpdatedAt, expectedUser.IsActive)
 
-	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE email = ?").
-		WithArgs(email).
+	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE email = ? AND tenant_id = ?").
+		WithArgs(email, models.DefaultTenantID).
 		WillReturnRows(rows)
 
 	// Call the function being tested
-	user, err := repo.GetByEmail(email)
+	user, err := repo.GetByEmail(context.Background(), email)
 
 	// Assert that no errors occurred and the user matches the expected user
 	assert.NoError(t, err)
@@ -167,6 +172,7 @@ func TestUserRepository_Update(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Create a test user
@@ -179,12 +185,12 @@ func TestUserRepository_Update(t *testing.T) {
 	}
 
 	// Set up the expected SQL query and result
-	mock.ExpectExec("UPDATE users SET username = ?, full_name = ?, email = ?, role = ?, updated_at = ?, is_active = ? WHERE id = ?").
-		WithArgs(user.Username, user.FullName, user.Email, user.Role, sqlmock.AnyArg(), user.IsActive, user.Id).
+	mock.ExpectExec("UPDATE users SET username = ?, full_name = ?, email = ?, role = ?, updated_at = ?, is_active = ? WHERE id = ? AND tenant_id = ?").
+		WithArgs(user.Username, user.FullName, user.Email, user.Role, sqlmock.AnyArg(), user.IsActive, user.Id, models.DefaultTenantID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Call the function being tested
-	err = repo.Update(user)
+	err = repo.Update(context.Background(), user)
 
 	// Assert that no errors occurred
 	assert.NoError(t, err)
@@ -206,6 +212,7 @@ func TestUserRepository_UpdatePassword(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
@@ -213,16 +220,17 @@ func TestUserRepository_UpdatePassword(t *testing.T) {
 	newPassword := "new_password"
 
 	// Set up the expected SQL query and result
-	mock.ExpectExec("UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?").
+	mock.ExpectExec("UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ? AND tenant_id = ?").
 		WithArgs(
 			sqlmock.AnyArg(), // Hashed password
 			sqlmock.AnyArg(), // UpdatedAt timestamp
 			userID,
+			models.DefaultTenantID,
 		).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Call the function being tested
-	err = repo.UpdatePassword(userID, newPassword)
+	err = repo.UpdatePassword(context.Background(), userID, newPassword)
 
 	// Assert that no errors occurred
 	assert.NoError(t, err)
@@ -244,18 +252,19 @@ func TestUserRepository_Delete(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
 	userID := "test-id"
 
 	// Set up the expected SQL query and result
-	mock.ExpectExec("DELETE FROM users WHERE id = ?").
-		WithArgs(userID).
+	mock.ExpectExec("DELETE FROM users WHERE id = ? AND tenant_id = ?").
+		WithArgs(userID, models.DefaultTenantID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Call the function being tested
-	err = repo.Delete(userID)
+	err = repo.Delete(context.Background(), userID)
 
 	// Assert that no errors occurred
 	assert.NoError(t, err)
@@ -277,6 +286,7 @@ func TestUserRepository_GetAll(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
@@ -308,11 +318,12 @@ func TestUserRepository_GetAll(t *testing.T) {
 		AddRow(user1.Id, user1.Username, user1.FullName, user1.Email, user1.Password, user1.Role, user1.CreatedAt, user1.UpdatedAt, user1.IsActive).
 		AddRow(user2.Id, user2.Username, user2.FullName, user2.Email, user2.Password, user2.Role, user2.CreatedAt, user2.UpdatedAt, user2.IsActive)
 
-	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users ORDER BY created_at DESC").
+	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE tenant_id = ? ORDER BY created_at DESC").
+		WithArgs(models.DefaultTenantID).
 		WillReturnRows(rows)
 
 	// Call the function being tested
-	users, err := repo.GetAll()
+	users, err := repo.GetAll(context.Background())
 
 	// Assert that no errors occurred and the users match the expected users
 	assert.NoError(t, err)
@@ -338,8 +349,8 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 	hashedCorrectPassword := string(hashedCorrectPasswordBytes)
 
 	userColumnNames := []string{"id", "username", "full_name", "email", "password_hash", "role", "created_at", "updated_at", "is_active"}
-	queryByEmail := "SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE email = ?"
-	queryByUsername := "SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE username = ?"
+	queryByEmail := "SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE email = ? AND tenant_id = ?"
+	queryByUsername := "SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE username = ? AND tenant_id = ?"
 
 	baseUser := &models.User{
 		Id:        "test-id-common",
@@ -357,7 +368,7 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 		}
 		defer db.Close()
-		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}}
+		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}, tenantID: models.DefaultTenantID}
 
 		userToReturn := *baseUser // copy
 		userToReturn.Email = emailIdentifier
@@ -365,9 +376,9 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 
 		rows := sqlmock.NewRows(userColumnNames).
 			AddRow(userToReturn.Id, userToReturn.Username, userToReturn.FullName, userToReturn.Email, userToReturn.Password, userToReturn.Role, userToReturn.CreatedAt, userToReturn.UpdatedAt, userToReturn.IsActive)
-		mock.ExpectQuery(queryByEmail).WithArgs(emailIdentifier).WillReturnRows(rows)
+		mock.ExpectQuery(queryByEmail).WithArgs(emailIdentifier, models.DefaultTenantID).WillReturnRows(rows)
 
-		user, err := repo.VerifyPassword(emailIdentifier, correctPassword)
+		user, err := repo.VerifyPassword(context.Background(), emailIdentifier, correctPassword)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
@@ -384,7 +395,7 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 		}
 		defer db.Close()
-		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}}
+		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}, tenantID: models.DefaultTenantID}
 
 		userToReturn := *baseUser // copy
 		userToReturn.Email = emailIdentifier
@@ -392,9 +403,9 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 
 		rows := sqlmock.NewRows(userColumnNames).
 			AddRow(userToReturn.Id, userToReturn.Username, userToReturn.FullName, userToReturn.Email, userToReturn.Password, userToReturn.Role, userToReturn.CreatedAt, userToReturn.UpdatedAt, userToReturn.IsActive)
-		mock.ExpectQuery(queryByEmail).WithArgs(emailIdentifier).WillReturnRows(rows)
+		mock.ExpectQuery(queryByEmail).WithArgs(emailIdentifier, models.DefaultTenantID).WillReturnRows(rows)
 
-		user, err := repo.VerifyPassword(emailIdentifier, incorrectPassword)
+		user, err := repo.VerifyPassword(context.Background(), emailIdentifier, incorrectPassword)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -410,18 +421,18 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 		}
 		defer db.Close()
-		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}}
+		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}, tenantID: models.DefaultTenantID}
 
 		userToReturn := *baseUser // copy
 		userToReturn.Username = usernameIdentifier
 		userToReturn.Email = "someother@example.com" // Ensure all fields are plausible
 
-		mock.ExpectQuery(queryByEmail).WithArgs(usernameIdentifier).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(queryByEmail).WithArgs(usernameIdentifier, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 		rows := sqlmock.NewRows(userColumnNames).
 			AddRow(userToReturn.Id, userToReturn.Username, userToReturn.FullName, userToReturn.Email, userToReturn.Password, userToReturn.Role, userToReturn.CreatedAt, userToReturn.UpdatedAt, userToReturn.IsActive)
-		mock.ExpectQuery(queryByUsername).WithArgs(usernameIdentifier).WillReturnRows(rows)
+		mock.ExpectQuery(queryByUsername).WithArgs(usernameIdentifier, models.DefaultTenantID).WillReturnRows(rows)
 
-		user, err := repo.VerifyPassword(usernameIdentifier, correctPassword)
+		user, err := repo.VerifyPassword(context.Background(), usernameIdentifier, correctPassword)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, user)
@@ -438,18 +449,18 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 		}
 		defer db.Close()
-		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}}
+		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}, tenantID: models.DefaultTenantID}
 
 		userToReturn := *baseUser // copy
 		userToReturn.Username = usernameIdentifier
 		userToReturn.Email = "someother@example.com"
 
-		mock.ExpectQuery(queryByEmail).WithArgs(usernameIdentifier).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(queryByEmail).WithArgs(usernameIdentifier, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 		rows := sqlmock.NewRows(userColumnNames).
 			AddRow(userToReturn.Id, userToReturn.Username, userToReturn.FullName, userToReturn.Email, userToReturn.Password, userToReturn.Role, userToReturn.CreatedAt, userToReturn.UpdatedAt, userToReturn.IsActive)
-		mock.ExpectQuery(queryByUsername).WithArgs(usernameIdentifier).WillReturnRows(rows)
+		mock.ExpectQuery(queryByUsername).WithArgs(usernameIdentifier, models.DefaultTenantID).WillReturnRows(rows)
 
-		user, err := repo.VerifyPassword(usernameIdentifier, incorrectPassword)
+		user, err := repo.VerifyPassword(context.Background(), usernameIdentifier, incorrectPassword)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -465,13 +476,13 @@ func TestUserRepository_VerifyPassword(t *testing.T) {
 			t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 		}
 		defer db.Close()
-		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}}
+		repo := &UserRepository{dbClient: &DatabaseClient{DB: db}, tenantID: models.DefaultTenantID}
 
 		nonExistentIdentifier := "nonexistentuser"
-		mock.ExpectQuery(queryByEmail).WithArgs(nonExistentIdentifier).WillReturnError(sql.ErrNoRows)
-		mock.ExpectQuery(queryByUsername).WithArgs(nonExistentIdentifier).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(queryByEmail).WithArgs(nonExistentIdentifier, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(queryByUsername).WithArgs(nonExistentIdentifier, models.DefaultTenantID).WillReturnError(sql.ErrNoRows)
 
-		user, err := repo.VerifyPassword(nonExistentIdentifier, correctPassword)
+		user, err := repo.VerifyPassword(context.Background(), nonExistentIdentifier, correctPassword)
 
 		assert.Error(t, err)
 		assert.Nil(t, user)
@@ -493,18 +504,19 @@ func TestUserRepository_ActivateUser(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
 	userID := "test-id"
 
 	// Set up the expected SQL query and result
-	mock.ExpectExec("UPDATE users SET is_active = ?, updated_at = ? WHERE id = ?").
-		WithArgs(true, sqlmock.AnyArg(), userID).
+	mock.ExpectExec("UPDATE users SET is_active = ?, updated_at = ? WHERE id = ? AND tenant_id = ?").
+		WithArgs(true, sqlmock.AnyArg(), userID, models.DefaultTenantID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Call the function being tested
-	err = repo.ActivateUser(userID)
+	err = repo.ActivateUser(context.Background(), userID)
 
 	// Assert that no errors occurred
 	assert.NoError(t, err)
@@ -526,18 +538,19 @@ func TestUserRepository_DeactivateUser(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
 	userID := "test-id"
 
 	// Set up the expected SQL query and result
-	mock.ExpectExec("UPDATE users SET is_active = ?, updated_at = ? WHERE id = ?").
-		WithArgs(false, sqlmock.AnyArg(), userID).
+	mock.ExpectExec("UPDATE users SET is_active = ?, updated_at = ? WHERE id = ? AND tenant_id = ?").
+		WithArgs(false, sqlmock.AnyArg(), userID, models.DefaultTenantID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
 
 	// Call the function being tested
-	err = repo.DeactivateUser(userID)
+	err = repo.DeactivateUser(context.Background(), userID)
 
 	// Assert that no errors occurred
 	assert.NoError(t, err)
@@ -559,18 +572,19 @@ func TestUserRepository_EmailExists(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
 	email := "test@example.com"
 
 	// Test when email exists
-	mock.ExpectQuery("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)").
-		WithArgs(email).
+	mock.ExpectQuery("SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND tenant_id = ?)").
+		WithArgs(email, models.DefaultTenantID).
 		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 
 	// Call the function being tested
-	exists, err := repo.EmailExists(email)
+	exists, err := repo.EmailExists(context.Background(), email)
 
 	// Assert that no errors occurred and the email exists
 	assert.NoError(t, err)
@@ -591,14 +605,15 @@ func TestUserRepository_EmailExists(t *testing.T) {
 	// Create a new repository with the new mock database
 	repo2 := &UserRepository{
 		dbClient: &DatabaseClient{DB: db2},
+		tenantID: models.DefaultTenantID,
 	}
 
-	mock2.ExpectQuery("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)").
-		WithArgs(email).
+	mock2.ExpectQuery("SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND tenant_id = ?)").
+		WithArgs(email, models.DefaultTenantID).
 		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
 
 	// Call the function being tested
-	exists, err = repo2.EmailExists(email)
+	exists, err = repo2.EmailExists(context.Background(), email)
 
 	// Assert that no errors occurred and the email does not exist
 	assert.NoError(t, err)
@@ -621,18 +636,19 @@ func TestUserRepository_GetByID_NotFound(t *testing.T) {
 	// Create a new repository with the mock database
 	repo := &UserRepository{
 		dbClient: &DatabaseClient{DB: db},
+		tenantID: models.DefaultTenantID,
 	}
 
 	// Set up test data
 	userID := "non-existent-id"
 
 	// Set up the expected SQL query to return no rows
-	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE id = ?").
-		WithArgs(userID).
+	mock.ExpectQuery("SELECT id, username, full_name, email, password_hash, role, created_at, updated_at, is_active FROM users WHERE id = ? AND tenant_id = ?").
+		WithArgs(userID, models.DefaultTenantID).
 		WillReturnError(sql.ErrNoRows)
 
 	// Call the function being tested
-	user, err := repo.GetByID(userID)
+	user, err := repo.GetByID(context.Background(), userID)
 
 	// Assert that an error occurred and the user is nil
 	assert.Error(t, err)
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+
+	"oop/internal/config"
+	"oop/internal/models"
+)
+
+// JournalLine is a debit or credit to an account
+type JournalLine struct {
+	Account     string  `json:"account"`
+	Description string  `json:"description"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+}
+
+// SalesJournal is the journal entry of one day's sales
+type SalesJournal struct {
+	TenantID  string        `json:"tenantId"`
+	Reference string        `json:"reference"` // Unique per tenant and day, e.g. sales-2025-03-01
+	Date      string        `json:"date"`
+	Lines     []JournalLine `json:"lines"`
+}
+
+// Total is the amount debited, which equals the amount credited
+func (j SalesJournal) Total() float64 {
+	total := 0.0
+	for _, line := range j.Lines {
+		total += line.Debit
+	}
+	return roundCents(total)
+}
+
+// AccountingPayment is a payment received for a sale
+type AccountingPayment struct {
+	SaleID     string  `json:"saleId"`
+	CustomerID string  `json:"customerId"`
+	Amount     float64 `json:"amount"`
+}
+
+// PaymentBatch is the payments received on one day
+type PaymentBatch struct {
+	TenantID  string              `json:"tenantId"`
+	Reference string              `json:"reference"` // Unique per tenant and day, e.g. payments-2025-03-01
+	Date      string              `json:"date"`
+	Payments  []AccountingPayment `json:"payments"`
+}
+
+// Total is the sum of the payments
+func (b PaymentBatch) Total() float64 {
+	total := 0.0
+	for _, payment := range b.Payments {
+		total += payment.Amount
+	}
+	return roundCents(total)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// AccountingExporter posts sales journals and payments to an accounting system, such
+// as QuickBooks or Xero. Each returns the ID the accounting system gave the posting.
+// Postings carry a reference that is unique per tenant and day, so an exporter can
+// have the accounting system ignore one that is retried after it was recorded.
+type AccountingExporter interface {
+	PostSalesJournal(ctx context.Context, journal SalesJournal) (string, error)
+	PostPayments(ctx context.Context, batch PaymentBatch) (string, error)
+}
+
+// NewAccountingExporter creates the exporter of the configured accounting system, or nil when none is configured
+func NewAccountingExporter(cfg config.AccountingConfig) AccountingExporter {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &httpAccountingExporter{url: cfg.URL, token: cfg.Token, client: &http.Client{}}
+}
+
+// NewSalesJournal builds the journal entry of a day's confirmed and completed sales:
+// the total is debited to cash, and credited to sales net of VAT and to output VAT
+func NewSalesJournal(tenantID, date string, sales []models.Sale, accounts config.AccountingAccounts) SalesJournal {
+	total, vat := 0.0, 0.0
+	for _, sale := range sales {
+		if !sale.Counted() {
+			continue
+		}
+		total += sale.TotalPrice
+		vat += sale.VATAmount
+	}
+	total, vat = roundCents(total), roundCents(vat)
+
+	journal := SalesJournal{TenantID: tenantID, Reference: "sales-" + date, Date: date}
+	journal.Lines = append(journal.Lines,
+		JournalLine{Account: accounts.Cash, Description: fmt.Sprintf("Sales of %s", date), Debit: total},
+		JournalLine{Account: accounts.Sales, Description: fmt.Sprintf("Sales of %s, net of VAT", date), Credit: roundCents(total - vat)},
+	)
+	if vat > 0 {
+		journal.Lines = append(journal.Lines, JournalLine{Account: accounts.VAT, Description: fmt.Sprintf("Output VAT of %s", date), Credit: vat})
+	}
+	return journal
+}
+
+// NewPaymentBatch builds the payments received on a day. Sales are paid in full when
+// they are confirmed, so each confirmed or completed sale of the day is a payment.
+func NewPaymentBatch(tenantID, date string, sales []models.Sale) PaymentBatch {
+	batch := PaymentBatch{TenantID: tenantID, Reference: "payments-" + date, Date: date, Payments: make([]AccountingPayment, 0, len(sales))}
+	for _, sale := range sales {
+		if !sale.Counted() {
+			continue
+		}
+		batch.Payments = append(batch.Payments, AccountingPayment{SaleID: sale.ID, CustomerID: sale.CustomerID, Amount: sale.TotalPrice})
+	}
+	return batch
+}
+
+// httpAccountingExporter posts journals to {url}/journals and payments to {url}/payments
+// as JSON, with the reference as the Idempotency-Key header, and reads the ID of the
+// posting from the "id" field of the answer
+type httpAccountingExporter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (e *httpAccountingExporter) PostSalesJournal(ctx context.Context, journal SalesJournal) (string, error) {
+	return e.post(ctx, "/journals", journal.TenantID+"/"+journal.Reference, journal)
+}
+
+func (e *httpAccountingExporter) PostPayments(ctx context.Context, batch PaymentBatch) (string, error) {
+	return e.post(ctx, "/payments", batch.TenantID+"/"+batch.Reference, batch)
+}
+
+func (e *httpAccountingExporter) post(ctx context.Context, path, idempotencyKey string, payload interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode posting: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url+path, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create accounting request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach accounting system: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("accounting system answered %s", resp.Status)
+	}
+
+	var answer struct {
+		ID string `json:"id"`
+	}
+	// The ID is informational; an answer without one still means the posting was recorded
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer)
+	return answer.ID, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/config"
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAccountingAccounts = config.AccountingAccounts{Cash: "1000", Sales: "4000", VAT: "2100"}
+
+func TestNewSalesJournalBalances(t *testing.T) {
+	sales := []models.Sale{
+		{ID: "s1", TotalPrice: 1120, TaxType: models.TaxVatable},
+		{ID: "s2", TotalPrice: 500, TaxType: models.TaxExempt},
+	}
+	for i := range sales {
+		sales[i].ApplyTax()
+	}
+
+	journal := NewSalesJournal("acme", "2025-03-01", sales, testAccountingAccounts)
+	assert.Equal(t, "sales-2025-03-01", journal.Reference)
+	require.Len(t, journal.Lines, 3)
+	assert.Equal(t, JournalLine{Account: "1000", Description: "Sales of 2025-03-01", Debit: 1620}, journal.Lines[0])
+	assert.Equal(t, 1500.0, journal.Lines[1].Credit, "sales are credited net of VAT")
+	assert.Equal(t, 120.0, journal.Lines[2].Credit)
+	assert.Equal(t, 1620.0, journal.Total())
+
+	exempt := NewSalesJournal("acme", "2025-03-01", sales[1:], testAccountingAccounts)
+	assert.Len(t, exempt.Lines, 2, "no VAT line without VAT")
+
+	batch := NewPaymentBatch("acme", "2025-03-01", sales)
+	assert.Equal(t, "payments-2025-03-01", batch.Reference)
+	assert.Len(t, batch.Payments, 2)
+	assert.Equal(t, 1620.0, batch.Total())
+}
+
+func TestHTTPAccountingExporter(t *testing.T) {
+	var paths, keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/api/journals":
+			json.NewEncoder(w).Encode(map[string]string{"id": "JE-1"})
+		case "/api/payments":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	exporter := NewAccountingExporter(config.AccountingConfig{URL: server.URL + "/api", Token: "token"})
+	require.NotNil(t, exporter)
+
+	id, err := exporter.PostSalesJournal(context.Background(), SalesJournal{TenantID: "acme", Reference: "sales-2025-03-01"})
+	require.NoError(t, err)
+	assert.Equal(t, "JE-1", id)
+	_, err = exporter.PostPayments(context.Background(), PaymentBatch{TenantID: "acme", Reference: "payments-2025-03-01"})
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"/api/journals", "/api/payments"}, paths)
+	assert.Equal(t, []string{"acme/sales-2025-03-01", "acme/payments-2025-03-01"}, keys, "retries can be recognized by their key")
+	assert.Nil(t, NewAccountingExporter(config.AccountingConfig{}), "no exporter without a URL")
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"oop/internal/models"
+)
+
+// MaskedValue replaces the value of sensitive fields in recorded changes
+const MaskedValue = "***"
+
+// sensitiveFieldMarkers identify fields whose values must never be written to the activity log
+var sensitiveFieldMarkers = []string{"password", "token", "secret", "hash"}
+
+// personalDiffFields hold personal data of customers, masked so the activity log keeps
+// no copy of it: entries cannot be rewritten once chained, so a copy would outlive
+// anonymizing the customer
+var personalDiffFields = map[string]bool{
+	"fullname":    true,
+	"email":       true,
+	"phone":       true,
+	"address":     true,
+	"barangay":    true,
+	"latitude":    true,
+	"longitude":   true,
+	"dateofbirth": true,
+}
+
+// ignoredDiffFields change on every write, or are generated by the server, and carry
+// no audit value
+var ignoredDiffFields = map[string]bool{
+	"updatedat":     true,
+	"updated_at":    true,
+	"createdat":     true,
+	"created_at":    true,
+	"displaynumber": true,
+}
+
+// isSensitiveField reports whether a field's values should be masked
+func isSensitiveField(field string) bool {
+	lower := strings.ToLower(field)
+	if personalDiffFields[lower] {
+		return true
+	}
+	for _, marker := range sensitiveFieldMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFieldMap converts a value to its JSON field representation so the diff
+// uses the same field names the API exposes.
+func toFieldMap(v interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
+
+// DiffFields compares two versions of an entity and returns the fields that changed,
+// sorted by field name. Values of sensitive fields and personal data are masked, and
+// fields hidden from JSON (such as password hashes) are never included.
+func DiffFields(before, after interface{}) []models.FieldChange {
+	beforeFields := toFieldMap(before)
+	afterFields := toFieldMap(after)
+
+	names := make([]string, 0, len(beforeFields)+len(afterFields))
+	seen := make(map[string]bool)
+	for _, fields := range []map[string]interface{}{beforeFields, afterFields} {
+		for name := range fields {
+			if !seen[name] && !ignoredDiffFields[strings.ToLower(name)] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	changes := []models.FieldChange{}
+	for _, name := range names {
+		oldValue, newValue := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if isSensitiveField(name) {
+			oldValue, newValue = MaskedValue, MaskedValue
+		}
+		changes = append(changes, models.FieldChange{Field: name, Before: oldValue, After: newValue})
+	}
+
+	return changes
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+)
+
+// chatSendTimeout limits a single attempt at posting a message
+const chatSendTimeout = 10 * time.Second
+
+// telegramAPI is where the Telegram bot API is served
+const telegramAPI = "https://api.telegram.org"
+
+// ChatField is a labelled value of a chat message
+type ChatField struct {
+	Label string
+	Value string
+}
+
+// ChatMessage is a notification as it is posted to a chat channel. Each channel
+// formats it in its own markup.
+type ChatMessage struct {
+	Title  string
+	Fields []ChatField
+}
+
+// NewChatMessage describes a notification of a tenant for a chat channel. Big sales
+// and stock-outs are spelled out; other notifications list their data.
+func NewChatMessage(tenantID string, notification models.Notification) ChatMessage {
+	var message ChatMessage
+	switch data := notification.Data.(type) {
+	case models.BigSaleEvent:
+		message = ChatMessage{Title: "Big sale", Fields: []ChatField{
+			{"Total", fmt.Sprintf("PHP %.2f", data.TotalPrice)},
+			{"Sale", data.SaleID},
+			{"Customer", data.CustomerID},
+			{"Sold by", data.SoldBy},
+		}}
+	case models.StockOutEvent:
+		message = ChatMessage{Title: "Out of stock", Fields: []ChatField{
+			{"Item", fmt.Sprintf("%s #%d %s", data.ItemType, data.ItemID, data.ItemName)},
+		}}
+	default:
+		title := strings.ReplaceAll(notification.Type, "_", " ")
+		if title != "" {
+			title = strings.ToUpper(title[:1]) + title[1:]
+		}
+		details, err := json.Marshal(notification.Data)
+		if err != nil {
+			details = []byte(fmt.Sprint(notification.Data))
+		}
+		message = ChatMessage{Title: title, Fields: []ChatField{{"Details", string(details)}}}
+	}
+	if tenantID != models.DefaultTenantID {
+		message.Fields = append(message.Fields, ChatField{"Tenant", tenantID})
+	}
+	return message
+}
+
+// ChatChannel posts messages to a chat service
+type ChatChannel interface {
+	Send(ctx context.Context, message ChatMessage) error
+}
+
+// NewChatChannel creates the channel of a chat service configuration
+func NewChatChannel(cfg config.ChatChannelConfig) ChatChannel {
+	client := &http.Client{}
+	switch cfg.Kind {
+	case config.ChatSlack:
+		return &slackChannel{webhookURL: cfg.WebhookURL, client: client}
+	case config.ChatTelegram:
+		return &telegramChannel{apiURL: telegramAPI, botToken: cfg.BotToken, chatID: cfg.ChatID, client: client}
+	}
+	return nil
+}
+
+// ChatNotifier posts the notifications of the configured types to a chat channel
+// from a background worker. A failed post is retried with exponential backoff up to
+// MaxRetries times, then dropped; while the channel is slow or down up to QueueSize
+// messages wait and newer ones are dropped rather than slowing down requests.
+type ChatNotifier struct {
+	name    string
+	channel ChatChannel
+	events  map[string]bool
+	cfg     config.ChatConfig
+	backoff func(attempt int) time.Duration
+
+	queue   chan ChatMessage
+	dropped atomic.Int64
+	stop    chan struct{}
+	done    <-chan struct{}
+	once    sync.Once
+}
+
+// NewChatNotifier creates a notifier posting the events of cfg to channel and starts its worker under workers
+func NewChatNotifier(workers *Supervisor, channel ChatChannel, channelCfg config.ChatChannelConfig, cfg config.ChatConfig) *ChatNotifier {
+	n := &ChatNotifier{
+		name:    channelCfg.Kind,
+		channel: channel,
+		events:  make(map[string]bool),
+		cfg:     cfg,
+		backoff: exponentialBackoff,
+		queue:   make(chan ChatMessage, cfg.QueueSize),
+		stop:    make(chan struct{}),
+	}
+	for _, event := range channelCfg.Events {
+		n.events[event] = true
+	}
+	n.done = workers.Go(channelCfg.Kind+" chat notifier", n.stop, n.run)
+	return n
+}
+
+// Notify queues a notification of a configured type without blocking
+func (n *ChatNotifier) Notify(tenantID string, notification models.Notification) {
+	if !n.events[notification.Type] {
+		return
+	}
+	select {
+	case <-n.stop:
+		return
+	default:
+	}
+
+	select {
+	case n.queue <- NewChatMessage(tenantID, notification):
+	default:
+		n.dropped.Add(1)
+	}
+}
+
+// Close stops accepting notifications and waits until the queued ones are posted or ctx is done
+func (n *ChatNotifier) Close(ctx context.Context) error {
+	n.once.Do(func() { close(n.stop) })
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s notifier did not finish posting: %w", n.name, ctx.Err())
+	}
+}
+
+func (n *ChatNotifier) run() {
+	for {
+		select {
+		case message := <-n.queue:
+			n.deliver(message)
+		case <-n.stop:
+			for {
+				select {
+				case message := <-n.queue:
+					n.deliver(message)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts a message, retrying failures. After shutdown has begun it retries
+// without waiting so Close is not held up by the backoff.
+func (n *ChatNotifier) deliver(message ChatMessage) {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), chatSendTimeout)
+		err := n.channel.Send(ctx, message)
+		cancel()
+		if err == nil {
+			if dropped := n.dropped.Swap(0); dropped > 0 {
+				log.Printf("%s notification queue was full, dropped %d messages", n.name, dropped)
+			}
+			return
+		}
+
+		if attempt >= n.cfg.MaxRetries {
+			log.Printf("Error posting %q to %s, giving up after %d attempts: %v", message.Title, n.name, attempt+1, err)
+			return
+		}
+		log.Printf("Error posting %q to %s (attempt %d): %v", message.Title, n.name, attempt+1, err)
+
+		select {
+		case <-time.After(n.backoff(attempt)):
+		case <-n.stop:
+		}
+	}
+}
+
+// postJSON posts payload as JSON and fails unless the answer is a 2xx
+func postJSON(ctx context.Context, client *http.Client, endpoint string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Webhook URLs and bot tokens are secrets, so keep the URL out of errors that end up in the log
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to reach chat service: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("chat service answered %s", resp.Status)
+	}
+	return nil
+}
+
+// slackChannel posts to a Slack incoming webhook, in Slack's mrkdwn
+type slackChannel struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// slackEscaper escapes the characters Slack treats as control characters in text
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func (s *slackChannel) Send(ctx context.Context, message ChatMessage) error {
+	var text strings.Builder
+	text.WriteString(":rotating_light: *" + slackEscaper.Replace(message.Title) + "*")
+	for _, field := range message.Fields {
+		text.WriteString("\n*" + slackEscaper.Replace(field.Label) + ":* " + slackEscaper.Replace(field.Value))
+	}
+	return postJSON(ctx, s.client, s.webhookURL, map[string]string{"text": text.String()})
+}
+
+// telegramChannel sends messages as a Telegram bot, in Telegram's HTML
+type telegramChannel struct {
+	apiURL   string
+	botToken string
+	chatID   string
+	client   *http.Client
+}
+
+func (t *telegramChannel) Send(ctx context.Context, message ChatMessage) error {
+	var text strings.Builder
+	text.WriteString("<b>" + html.EscapeString(message.Title) + "</b>")
+	for _, field := range message.Fields {
+		text.WriteString("\n<b>" + html.EscapeString(field.Label) + ":</b> " + html.EscapeString(field.Value))
+	}
+	payload := map[string]interface{}{
+		"chat_id":                  t.chatID,
+		"text":                     text.String(),
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+	return postJSON(ctx, t.client, t.apiURL+"/bot"+t.botToken+"/sendMessage", payload)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChatChannel records posted messages and fails the first failures attempts
+type fakeChatChannel struct {
+	mu       sync.Mutex
+	messages []ChatMessage
+	attempts int
+	failures int
+}
+
+func (ch *fakeChatChannel) Send(ctx context.Context, message ChatMessage) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.attempts++
+	if ch.attempts <= ch.failures {
+		return errors.New("chat service unavailable")
+	}
+	ch.messages = append(ch.messages, message)
+	return nil
+}
+
+func (ch *fakeChatChannel) posted() []ChatMessage {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	return ch.messages
+}
+
+func newTestChatNotifier(channel ChatChannel) *ChatNotifier {
+	notifier := NewChatNotifier(nil, channel,
+		config.ChatChannelConfig{Kind: config.ChatSlack, Events: []string{"big_sale", "stock_out"}},
+		config.ChatConfig{QueueSize: 10, MaxRetries: 2})
+	notifier.backoff = func(int) time.Duration { return time.Millisecond }
+	return notifier
+}
+
+var testBigSale = models.Notification{Type: "big_sale", Data: models.BigSaleEvent{
+	SaleID: "sale-1", CustomerID: "customer-1", SoldBy: "user-1", TotalPrice: 250000, Threshold: 100000,
+}}
+
+func TestChatNotifierPostsConfiguredEvents(t *testing.T) {
+	channel := &fakeChatChannel{}
+	notifier := newTestChatNotifier(channel)
+	hub := NewNotificationHub()
+	hub.AddNotifier(notifier)
+
+	hub.Publish(models.DefaultTenantID, testBigSale)
+	hub.Publish("acme", models.Notification{Type: "stock_out", Data: models.StockOutEvent{ItemType: "cab", ItemID: 7, ItemName: "Suzuki Carry"}})
+	hub.Publish(models.DefaultTenantID, models.Notification{Type: "watchlist", Data: "ignored"})
+	require.NoError(t, notifier.Close(context.Background()))
+
+	messages := channel.posted()
+	require.Len(t, messages, 2, "only the configured events are posted")
+	assert.Equal(t, "Big sale", messages[0].Title)
+	assert.Contains(t, messages[0].Fields, ChatField{"Total", "PHP 250000.00"})
+	assert.NotContains(t, messages[0].Fields, ChatField{"Tenant", models.DefaultTenantID})
+	assert.Equal(t, "Out of stock", messages[1].Title)
+	assert.Equal(t, []ChatField{{"Item", "cab #7 Suzuki Carry"}, {"Tenant", "acme"}}, messages[1].Fields)
+
+	hub.Publish(models.DefaultTenantID, testBigSale)
+	assert.Len(t, channel.posted(), 2, "notifications are refused once closed")
+}
+
+func TestChatNotifierRetries(t *testing.T) {
+	channel := &fakeChatChannel{failures: 2}
+	notifier := newTestChatNotifier(channel)
+	notifier.Notify(models.DefaultTenantID, testBigSale)
+	require.NoError(t, notifier.Close(context.Background()))
+	assert.Len(t, channel.posted(), 1, "posted on the third attempt")
+
+	channel = &fakeChatChannel{failures: 3}
+	notifier = newTestChatNotifier(channel)
+	notifier.Notify(models.DefaultTenantID, testBigSale)
+	require.NoError(t, notifier.Close(context.Background()))
+	assert.Empty(t, channel.posted(), "dropped after the retries are used up")
+	assert.Equal(t, 3, channel.attempts)
+}
+
+func TestNewChatMessageOfOtherNotifications(t *testing.T) {
+	message := NewChatMessage(models.DefaultTenantID, models.Notification{
+		Type: "receipt_series_low",
+		Data: map[string]int{"remaining": 12},
+	})
+	assert.Equal(t, "Receipt series low", message.Title)
+	assert.Equal(t, []ChatField{{"Details", `{"remaining":12}`}}, message.Fields)
+}
+
+func TestSlackChannel(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/T000/B000/secret", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	channel := NewChatChannel(config.ChatChannelConfig{Kind: config.ChatSlack, WebhookURL: server.URL + "/services/T000/B000/secret"})
+	err := channel.Send(context.Background(), ChatMessage{Title: "Out of stock", Fields: []ChatField{{"Item", "Seat <cover> & mat"}}})
+	require.NoError(t, err)
+	assert.Equal(t, ":rotating_light: *Out of stock*\n*Item:* Seat &lt;cover&gt; &amp; mat", payload["text"])
+}
+
+func TestTelegramChannel(t *testing.T) {
+	var payload map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:token/sendMessage", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := &telegramChannel{apiURL: server.URL, botToken: "123:token", chatID: "-100200", client: server.Client()}
+	err := channel.Send(context.Background(), ChatMessage{Title: "Big sale", Fields: []ChatField{{"Customer", "Dela Cruz & Sons"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "-100200", payload["chat_id"])
+	assert.Equal(t, "HTML", payload["parse_mode"])
+	assert.Equal(t, "<b>Big sale</b>\n<b>Customer:</b> Dela Cruz &amp; Sons", payload["text"])
+
+	status = http.StatusUnauthorized
+	err = channel.Send(context.Background(), ChatMessage{Title: "Big sale"})
+	assert.ErrorContains(t, err, "401")
+
+	server.Close()
+	err = channel.Send(context.Background(), ChatMessage{Title: "Big sale"})
+	require.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "123:token"), "the bot token is kept out of errors")
+}
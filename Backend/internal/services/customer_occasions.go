@@ -0,0 +1,76 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"oop/internal/models"
+)
+
+// occasionDateLayout formats dates of birth and occasion dates
+const occasionDateLayout = "2006-01-02"
+
+// CustomerOccasions returns the birthdays and registration anniversaries of customers
+// falling within days days from from, today included, soonest first. occasion is
+// models.OccasionBirthday, models.OccasionAnniversary or "" for both. Customers born on
+// February 29 are greeted on February 28 in other years; anniversaries start a year after
+// registering.
+func CustomerOccasions(customers []*models.Customer, from time.Time, days int, occasion string) []models.CustomerOccasion {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+
+	occasions := []models.CustomerOccasion{}
+	add := func(customer *models.Customer, kind string, since time.Time) {
+		next := occasionIn(since, from.Year())
+		if next.Before(from) {
+			next = occasionIn(since, from.Year()+1)
+		}
+		daysAway := int(next.Sub(from).Hours()+12) / 24 // Rounded over daylight saving changes
+		years := next.Year() - since.Year()
+		if daysAway >= days || (kind == models.OccasionAnniversary && years < 1) {
+			return
+		}
+		occasions = append(occasions, models.CustomerOccasion{
+			CustomerID: customer.ID,
+			FullName:   customer.FullName,
+			Occasion:   kind,
+			Date:       next.Format(occasionDateLayout),
+			DaysAway:   daysAway,
+			Years:      years,
+		})
+	}
+
+	for _, customer := range customers {
+		if occasion != models.OccasionAnniversary && customer.DateOfBirth != "" {
+			if born, err := time.ParseInLocation(occasionDateLayout, customer.DateOfBirth, time.Local); err == nil {
+				add(customer, models.OccasionBirthday, born)
+			}
+		}
+		if occasion != models.OccasionBirthday && !customer.DateRegistered.IsZero() {
+			add(customer, models.OccasionAnniversary, customer.DateRegistered.In(time.Local))
+		}
+	}
+
+	sort.Slice(occasions, func(i, j int) bool {
+		a, b := occasions[i], occasions[j]
+		switch {
+		case a.Date != b.Date:
+			return a.Date < b.Date
+		case a.FullName != b.FullName:
+			return a.FullName < b.FullName
+		case a.CustomerID != b.CustomerID:
+			return a.CustomerID < b.CustomerID
+		}
+		return a.Occasion < b.Occasion
+	})
+	return occasions
+}
+
+// occasionIn returns the date an occasion first on date falls on in year, February 29
+// falling on February 28 outside leap years
+func occasionIn(date time.Time, year int) time.Time {
+	day := date.Day()
+	if date.Month() == time.February && day == 29 && time.Date(year, time.February, 29, 0, 0, 0, 0, time.Local).Month() != time.February {
+		day = 28
+	}
+	return time.Date(year, date.Month(), day, 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomerOccasions(t *testing.T) {
+	registered := time.Date(2023, time.March, 20, 15, 30, 0, 0, time.Local)
+	customers := []*models.Customer{
+		{ID: "c-1", FullName: "Maria Santos", DateOfBirth: "1990-03-14", DateRegistered: registered},
+		{ID: "c-2", FullName: "Juan Dela Cruz", DateOfBirth: "1988-02-29", DateRegistered: time.Date(2025, time.March, 1, 0, 0, 0, 0, time.Local)},
+		{ID: "c-3", FullName: "Ana Reyes", DateOfBirth: "1975-03-12"},
+		{ID: "c-4", FullName: "No Birthday", DateRegistered: time.Date(2024, time.March, 13, 0, 0, 0, 0, time.Local)},
+	}
+	from := time.Date(2025, time.March, 12, 17, 0, 0, 0, time.Local)
+
+	occasions := CustomerOccasions(customers, from, 5, "")
+	require.Len(t, occasions, 3, "birthdays and anniversaries after the window, or in their first year, are left out")
+	assert.Equal(t, models.CustomerOccasion{CustomerID: "c-3", FullName: "Ana Reyes", Occasion: models.OccasionBirthday, Date: "2025-03-12", DaysAway: 0, Years: 50}, occasions[0])
+	assert.Equal(t, models.CustomerOccasion{CustomerID: "c-4", FullName: "No Birthday", Occasion: models.OccasionAnniversary, Date: "2025-03-13", DaysAway: 1, Years: 1}, occasions[1])
+	assert.Equal(t, "c-1", occasions[2].CustomerID)
+	assert.Equal(t, 35, occasions[2].Years)
+
+	occasions = CustomerOccasions(customers, from, 366, models.OccasionBirthday)
+	require.Len(t, occasions, 3)
+	assert.Equal(t, "2026-02-28", occasions[2].Date, "February 29 birthdays fall on February 28 outside leap years")
+	assert.Equal(t, 38, occasions[2].Years)
+
+	occasions = CustomerOccasions(customers, from, 366, models.OccasionAnniversary)
+	require.Len(t, occasions, 3)
+	assert.Equal(t, "2026-03-01", occasions[2].Date, "the first anniversary of registering")
+	for _, occasion := range occasions {
+		assert.Equal(t, models.OccasionAnniversary, occasion.Occasion)
+	}
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"math"
+
+	"oop/internal/config"
+)
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// DeliveryEstimate is the distance from the delivery origin to a destination and the
+// fee of delivering there
+type DeliveryEstimate struct {
+	StraightLineKm float64
+	RoadKm         float64 // Straight-line distance times the road factor
+	Fee            float64
+}
+
+// EstimateDelivery works out the distance and fee of a delivery from the configured
+// origin to the coordinates, rounded to tenths of kilometers and to cents
+func EstimateDelivery(cfg config.DeliveryConfig, latitude, longitude float64) DeliveryEstimate {
+	straight := HaversineKm(cfg.OriginLatitude, cfg.OriginLongitude, latitude, longitude)
+	road := math.Round(straight*cfg.RoadFactor*10) / 10
+	return DeliveryEstimate{
+		StraightLineKm: math.Round(straight*10) / 10,
+		RoadKm:         road,
+		Fee:            math.Round((cfg.BaseFee+road*cfg.FeePerKm)*100) / 100,
+	}
+}
+
+// HaversineKm returns the great-circle distance between two points in kilometers
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+	dLat, dLon := toRadians(lat2-lat1), toRadians(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
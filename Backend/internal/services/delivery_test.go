@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"oop/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPHLocationsResolve(t *testing.T) {
+	locations, err := LoadPHLocations("")
+	require.NoError(t, err)
+
+	place, err := locations.Resolve("ncr", "city of las pinas", "  Talon   Uno ")
+	require.NoError(t, err, "aliases, case, ñ and a City of prefix are folded")
+	assert.Equal(t, "Metro Manila", place.Province)
+	assert.Equal(t, "Las Piñas", place.City)
+	assert.Equal(t, "Talon Uno", place.Barangay, "barangays of cities listing none are kept as given")
+	assert.InDelta(t, 14.4445, place.Latitude, 1e-9)
+
+	place, err = locations.Resolve("Metro Manila", "Quezon", "")
+	require.NoError(t, err)
+	assert.Equal(t, "Quezon City", place.City)
+
+	_, err = locations.Resolve("Atlantis", "Makati", "")
+	assert.ErrorIs(t, err, ErrUnknownLocation)
+	_, err = locations.Resolve("Cavite", "Makati", "")
+	assert.ErrorIs(t, err, ErrUnknownLocation, "the city must be in the province")
+
+	custom := NewPHLocations([]PHProvince{{Name: "Cebu", Cities: []PHCity{{Name: "Cebu City", Barangays: []string{"Lahug", "Mabolo"}}}}})
+	place, err = custom.Resolve("cebu", "Cebu", "lahug")
+	require.NoError(t, err)
+	assert.Equal(t, "Lahug", place.Barangay)
+	_, err = custom.Resolve("Cebu", "Cebu", "Talamban")
+	assert.ErrorIs(t, err, ErrUnknownLocation, "barangays of cities listing them are validated")
+}
+
+func TestEstimateDelivery(t *testing.T) {
+	assert.InDelta(t, 111.19, HaversineKm(0, 0, 1, 0), 0.01, "a degree of latitude")
+
+	cfg := config.DeliveryConfig{OriginLatitude: 14.5995, OriginLongitude: 120.9842, HasOrigin: true, RoadFactor: 1.3, BaseFee: 500, FeePerKm: 20}
+	estimate := EstimateDelivery(cfg, 14.5995, 120.9842)
+	assert.Equal(t, DeliveryEstimate{Fee: 500}, estimate, "deliveries to the origin cost the base fee")
+
+	// Manila to Baguio
+	estimate = EstimateDelivery(cfg, 16.4023, 120.596)
+	assert.Equal(t, 204.7, estimate.StraightLineKm)
+	assert.Equal(t, 266.2, estimate.RoadKm)
+	assert.Equal(t, 5824.0, estimate.Fee)
+}
+
+func TestNominatimGeocoder(t *testing.T) {
+	var query, userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, userAgent = r.URL.Query().Get("q"), r.UserAgent()
+		if r.URL.Query().Get("countrycodes") != "ph" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if query == "Nowhere, Philippines" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"lat":"14.5547","lon":"121.0244"}]`))
+	}))
+	defer server.Close()
+
+	geocoder := NewNominatimGeocoder(server.URL+"/", "cortes-test")
+	lat, lon, err := geocoder.Geocode(context.Background(), GeocodeQuery{Street: "12 Ayala Ave", City: "Makati", Province: "Metro Manila"})
+	require.NoError(t, err)
+	assert.Equal(t, "12 Ayala Ave, Makati, Metro Manila, Philippines", query)
+	assert.Equal(t, "cortes-test", userAgent)
+	assert.Equal(t, 14.5547, lat)
+	assert.Equal(t, 121.0244, lon)
+
+	_, _, err = geocoder.Geocode(context.Background(), GeocodeQuery{Street: "Nowhere"})
+	assert.Error(t, err)
+}
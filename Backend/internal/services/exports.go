@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+)
+
+// exportQueueSize is how many exports can wait for a worker before further ones are
+// refused
+const exportQueueSize = 64
+
+// exportProgressEvery is how many rows are written between progress reports
+const exportProgressEvery = 500
+
+// ExportQueue runs exports in the background on a fixed number of workers, so large
+// exports do not hold a request open until they finish
+type ExportQueue struct {
+	queue chan func()
+	stop  chan struct{}
+	done  []<-chan struct{} // One per worker
+	once  sync.Once
+}
+
+// NewExportQueue creates a queue and starts its workers under supervisor
+func NewExportQueue(supervisor *Supervisor, workers int) *ExportQueue {
+	q := &ExportQueue{
+		queue: make(chan func(), exportQueueSize),
+		stop:  make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		q.done = append(q.done, supervisor.Go(fmt.Sprintf("Export worker %d", i+1), q.stop, q.run))
+	}
+	return q
+}
+
+// Queue schedules an export to run without blocking. It reports whether the export
+// was queued; a nil queue or a full one queues nothing.
+func (q *ExportQueue) Queue(export func()) bool {
+	if q == nil {
+		return false
+	}
+	select {
+	case <-q.stop:
+		return false
+	default:
+	}
+
+	select {
+	case q.queue <- export:
+		return true
+	default:
+		log.Printf("Export queue full, refusing export")
+		return false
+	}
+}
+
+// Close stops accepting exports and returns once the queued ones have run
+func (q *ExportQueue) Close() {
+	q.once.Do(func() { close(q.stop) })
+	for _, done := range q.done {
+		<-done
+	}
+}
+
+func (q *ExportQueue) run() {
+	for {
+		select {
+		case export := <-q.queue:
+			export()
+		case <-q.stop:
+			for {
+				select {
+				case export := <-q.queue:
+					export()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// WriteExportCSV writes the rows of an export, header first, as CSV. progress is
+// called with the number of rows written after every few hundred rows and once at
+// the end.
+func WriteExportCSV(rows [][]string, progress func(written int)) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for i, row := range rows {
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+		if i > 0 && i%exportProgressEvery == 0 && progress != nil {
+			progress(i)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	if progress != nil && len(rows) > 0 {
+		progress(len(rows) - 1)
+	}
+	return buf.Bytes(), nil
+}
+
+func exportMoney(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SalesExport returns the rows of a sales export
+func SalesExport(sales []models.Sale) [][]string {
+	rows := [][]string{{"id", "customer_id", "sold_by", "sale_date", "total_price", "tax_type", "vat_amount", "created_at"}}
+	for _, sale := range sales {
+		rows = append(rows, []string{sale.ID, sale.CustomerID, sale.SoldBy, sale.SaleDate, exportMoney(sale.TotalPrice),
+			sale.TaxType, exportMoney(sale.VATAmount), exportTime(sale.CreatedAt)})
+	}
+	return rows
+}
+
+// CabsExport returns the rows of a multicab inventory export
+func CabsExport(cabs []models.MultiCab) [][]string {
+	rows := [][]string{{"id", "name", "make", "unit_color", "quantity", "price", "status", "created_at"}}
+	for _, cab := range cabs {
+		rows = append(rows, []string{strconv.Itoa(cab.ID), cab.Name, cab.Make, cab.UnitColor, strconv.Itoa(cab.Quantity),
+			exportMoney(cab.Price), cab.Status, exportTime(cab.CreatedAt)})
+	}
+	return rows
+}
+
+// AccessoriesExport returns the rows of an accessory inventory export
+func AccessoriesExport(accessories []models.Accessory) [][]string {
+	rows := [][]string{{"id", "name", "make", "unit_color", "quantity", "price", "status", "created_at"}}
+	for _, accessory := range accessories {
+		rows = append(rows, []string{strconv.Itoa(accessory.ID), accessory.Name, string(accessory.Make), string(accessory.UnitColor),
+			strconv.Itoa(accessory.Quantity), exportMoney(accessory.Price), string(accessory.Status), exportTime(accessory.CreatedAt)})
+	}
+	return rows
+}
+
+// MaterialsExport returns the rows of a material inventory export
+func MaterialsExport(materials []models.Material) [][]string {
+	rows := [][]string{{"id", "name", "category", "supplier", "quantity", "status", "created_at"}}
+	for _, material := range materials {
+		rows = append(rows, []string{strconv.Itoa(material.ID), material.Name, material.Category, material.Supplier,
+			strconv.Itoa(material.Quantity), material.Status, exportTime(material.CreatedAt)})
+	}
+	return rows
+}
+
+// StockExportHeader is the header row of a streamed inventory stock export
+var StockExportHeader = []string{"name", "make", "quantity", "price", "status"}
+
+// StockExportRow returns the row of one item of a streamed inventory stock export.
+// Materials have no make or price, so those columns are left empty.
+func StockExportRow(row models.StockRow) []string {
+	price := ""
+	if row.Price != nil {
+		price = exportMoney(*row.Price)
+	}
+	return []string{row.Name, row.Make, strconv.Itoa(row.Quantity), price, row.Status}
+}
+
+// CustomersExport returns the rows of a customer export. With mask, email addresses,
+// phone numbers and dates of birth are masked as they are in API responses.
+func CustomersExport(customers []*models.Customer, mask bool) [][]string {
+	rows := [][]string{{"id", "full_name", "email", "phone", "address", "barangay", "city", "province", "date_of_birth", "date_registered"}}
+	for _, customer := range customers {
+		email, phone, born := customer.Email, customer.Phone, customer.DateOfBirth
+		if mask {
+			email, phone, born = MaskEmail(email), MaskPhone(phone), MaskDateOfBirth(born)
+		}
+		rows = append(rows, []string{customer.ID, customer.FullName, email, phone, customer.Address, customer.Barangay, customer.City, customer.Province, born, exportTime(customer.DateRegistered)})
+	}
+	return rows
+}
+
+// CustomerOccasionsExport returns the rows of an export of upcoming birthdays and
+// anniversaries, with the contact details of the customers, by ID, to greet them
+// through. With mask, they are masked as they are in API responses.
+func CustomerOccasionsExport(occasions []models.CustomerOccasion, customers map[string]*models.Customer, mask bool) [][]string {
+	rows := [][]string{{"date", "occasion", "years", "customer_id", "full_name", "email", "phone"}}
+	for _, occasion := range occasions {
+		var email, phone string
+		if customer, ok := customers[occasion.CustomerID]; ok {
+			email, phone = customer.Email, customer.Phone
+		}
+		if mask {
+			email, phone = MaskEmail(email), MaskPhone(phone)
+		}
+		rows = append(rows, []string{occasion.Date, occasion.Occasion, strconv.Itoa(occasion.Years), occasion.CustomerID, occasion.FullName, email, phone})
+	}
+	return rows
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GeocodeQuery is the address to locate
+type GeocodeQuery struct {
+	Street   string
+	Barangay string
+	City     string
+	Province string
+}
+
+// String joins the parts of the address that are set
+func (q GeocodeQuery) String() string {
+	var parts []string
+	for _, part := range []string{q.Street, q.Barangay, q.City, q.Province} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(append(parts, "Philippines"), ", ")
+}
+
+// Geocoder finds the coordinates of an address. Implementations are pluggable so the
+// provider can be swapped without touching the handlers.
+type Geocoder interface {
+	Geocode(ctx context.Context, query GeocodeQuery) (latitude, longitude float64, err error)
+}
+
+// NominatimGeocoder locates addresses with the search API of OpenStreetMap's
+// Nominatim, or of a server offering the same API
+type NominatimGeocoder struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// NewNominatimGeocoder creates a geocoder searching baseURL, identifying itself with userAgent
+func NewNominatimGeocoder(baseURL, userAgent string) *NominatimGeocoder {
+	return &NominatimGeocoder{baseURL: strings.TrimRight(baseURL, "/"), userAgent: userAgent, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Geocode returns the coordinates of the best match in the Philippines
+func (g *NominatimGeocoder) Geocode(ctx context.Context, query GeocodeQuery) (float64, float64, error) {
+	params := url.Values{"q": {query.String()}, "format": {"jsonv2"}, "limit": {"1"}, "countrycodes": {"ph"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build geocoding request: %w", err)
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("geocoding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("geocoder answered %s", resp.Status)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return 0, 0, fmt.Errorf("failed to decode geocoding response: %w", err)
+	}
+	if len(results) == 0 {
+		return 0, 0, fmt.Errorf("no match for %q", query.String())
+	}
+	latitude, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q: %w", results[0].Lat, err)
+	}
+	longitude, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q: %w", results[0].Lon, err)
+	}
+	return latitude, longitude, nil
+}
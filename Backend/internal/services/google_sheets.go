@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	googleSheetsAPI   = "https://sheets.googleapis.com/v4/spreadsheets"
+	googleSheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+)
+
+// SheetsReportTabs are the tabs each report is written to
+var SheetsReportTabs = map[string]string{
+	models.SheetsReportDailySales: "Daily Sales",
+	models.SheetsReportLowStock:   "Low Stock",
+}
+
+// SheetsWriter writes rows to the tabs of a Google Sheets spreadsheet
+type SheetsWriter interface {
+	// ServiceAccount is the email a spreadsheet is shared with so it can be written to
+	ServiceAccount() string
+	// WriteSheet replaces the contents of a tab with rows, adding the tab when it is missing
+	WriteSheet(ctx context.Context, spreadsheetID, tab string, rows [][]interface{}) error
+}
+
+// DailySalesSheet is the daily sales report: the number, total and VAT of the confirmed
+// and completed sales of each day from the first day to the last, oldest first,
+// including days without sales
+func DailySalesSheet(sales []models.Sale, first, last time.Time) [][]interface{} {
+	type dayTotals struct {
+		count      int
+		total, vat float64
+	}
+	byDay := make(map[string]*dayTotals)
+	for _, sale := range sales {
+		if !sale.Counted() {
+			continue
+		}
+		day := byDay[sale.SaleDate]
+		if day == nil {
+			day = &dayTotals{}
+			byDay[sale.SaleDate] = day
+		}
+		day.count++
+		day.total += sale.TotalPrice
+		day.vat += sale.VATAmount
+	}
+
+	rows := [][]interface{}{{"Date", "Sales", "Total", "VAT", "Net of VAT"}}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		totals := dayTotals{}
+		if byDay[date] != nil {
+			totals = *byDay[date]
+		}
+		rows = append(rows, []interface{}{date, totals.count, roundCents(totals.total), roundCents(totals.vat), roundCents(totals.total - totals.vat)})
+	}
+	return rows
+}
+
+// LowStockSheet is the low stock report: the cabs, accessories and materials that
+// are low on or out of stock, fewest left first
+func LowStockSheet(cabs []models.MultiCab, accessories []models.Accessory, materials []models.Material) [][]interface{} {
+	type item struct {
+		kind     string
+		id       int
+		name     string
+		quantity int
+		status   string
+	}
+	var items []item
+	lowStock := func(status string) bool {
+		return status == string(models.StatusLowStock) || status == string(models.StatusOutOfStock)
+	}
+	for _, cab := range cabs {
+		if lowStock(cab.Status) {
+			items = append(items, item{"Cab", cab.ID, cab.Name, cab.Quantity, cab.Status})
+		}
+	}
+	for _, accessory := range accessories {
+		if lowStock(string(accessory.Status)) {
+			items = append(items, item{"Accessory", accessory.ID, accessory.Name, accessory.Quantity, string(accessory.Status)})
+		}
+	}
+	for _, material := range materials {
+		if lowStock(material.Status) {
+			items = append(items, item{"Material", material.ID, material.Name, material.Quantity, material.Status})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].quantity < items[j].quantity })
+
+	rows := [][]interface{}{{"Type", "ID", "Name", "Quantity", "Status"}}
+	for _, it := range items {
+		rows = append(rows, []interface{}{it.kind, it.id, it.name, it.quantity, it.status})
+	}
+	return rows
+}
+
+// googleServiceAccount is the part of a service account JSON key the writer uses
+type googleServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// GoogleSheetsWriter writes to spreadsheets as a Google service account, which must
+// have been given edit access to each spreadsheet
+type GoogleSheetsWriter struct {
+	email    string
+	key      *rsa.PrivateKey
+	tokenURL string
+	apiURL   string
+	client   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewGoogleSheetsWriter creates a writer using the configured service account key, or
+// returns nil when none is configured
+func NewGoogleSheetsWriter(cfg config.GoogleSheetsConfig) (SheetsWriter, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	data, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read google service account key: %w", err)
+	}
+	return newGoogleSheetsWriter(data, googleSheetsAPI)
+}
+
+func newGoogleSheetsWriter(credentials []byte, apiURL string) (*GoogleSheetsWriter, error) {
+	var account googleServiceAccount
+	if err := json.Unmarshal(credentials, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse google service account key: %w", err)
+	}
+	if account.ClientEmail == "" || account.TokenURI == "" {
+		return nil, fmt.Errorf("google service account key has no client_email or token_uri")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse google service account private key: %w", err)
+	}
+	return &GoogleSheetsWriter{
+		email:    account.ClientEmail,
+		key:      key,
+		tokenURL: account.TokenURI,
+		apiURL:   apiURL,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// ServiceAccount is the email of the service account
+func (w *GoogleSheetsWriter) ServiceAccount() string {
+	return w.email
+}
+
+// WriteSheet clears the tab and writes rows from its first cell. Values are entered as
+// if typed, so dates and numbers can be formatted and charted in the spreadsheet.
+func (w *GoogleSheetsWriter) WriteSheet(ctx context.Context, spreadsheetID, tab string, rows [][]interface{}) error {
+	base := w.apiURL + "/" + url.PathEscape(spreadsheetID)
+
+	var spreadsheet struct {
+		Sheets []struct {
+			Properties struct {
+				Title string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	if err := w.call(ctx, http.MethodGet, base+"?fields=sheets.properties.title", nil, &spreadsheet); err != nil {
+		return err
+	}
+	exists := false
+	for _, sheet := range spreadsheet.Sheets {
+		exists = exists || sheet.Properties.Title == tab
+	}
+	if !exists {
+		addSheet := map[string]interface{}{"requests": []interface{}{
+			map[string]interface{}{"addSheet": map[string]interface{}{"properties": map[string]string{"title": tab}}},
+		}}
+		if err := w.call(ctx, http.MethodPost, base+":batchUpdate", addSheet, nil); err != nil {
+			return err
+		}
+	}
+
+	// Quote the tab, doubling quotes in its name, so names with spaces form a valid range
+	tabRange := "'" + strings.ReplaceAll(tab, "'", "''") + "'"
+	if err := w.call(ctx, http.MethodPost, base+"/values/"+url.PathEscape(tabRange)+":clear", map[string]string{}, nil); err != nil {
+		return err
+	}
+	values := map[string]interface{}{"range": tabRange + "!A1", "majorDimension": "ROWS", "values": rows}
+	return w.call(ctx, http.MethodPut, base+"/values/"+url.PathEscape(tabRange+"!A1")+"?valueInputOption=USER_ENTERED", values, nil)
+}
+
+// call sends payload as JSON to the Sheets API and decodes the answer into out, when set
+func (w *GoogleSheetsWriter) call(ctx context.Context, method, endpoint string, payload, out interface{}) error {
+	token, err := w.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode google sheets request: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create google sheets request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach google sheets: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("google sheets answered %s: %s", resp.Status, googleErrorMessage(resp.Body))
+	}
+	if out != nil {
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode google sheets answer: %w", err)
+		}
+	}
+	return nil
+}
+
+// googleErrorMessage reads the message of a Google API error answer, such as the
+// permission error of a spreadsheet that was not shared with the service account
+func googleErrorMessage(body io.Reader) string {
+	var answer struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(body, 1<<16)).Decode(&answer); err != nil || answer.Error.Message == "" {
+		return "no details"
+	}
+	return answer.Error.Message
+}
+
+// token returns an access token of the service account, exchanging a signed
+// assertion for a new one shortly before the current one expires
+func (w *GoogleSheetsWriter) token(ctx context.Context) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if w.accessToken != "" && now.Before(w.expiresAt.Add(-time.Minute)) {
+		return w.accessToken, nil
+	}
+
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   w.email,
+		"scope": googleSheetsScope,
+		"aud":   w.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(w.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign google service account assertion: %w", err)
+	}
+
+	form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {assertion}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create google token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach google token endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("google token endpoint answered %s", resp.Status)
+	}
+
+	var answer struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&answer); err != nil || answer.AccessToken == "" {
+		return "", fmt.Errorf("google token endpoint returned no access token")
+	}
+	w.accessToken = answer.AccessToken
+	w.expiresAt = now.Add(time.Duration(answer.ExpiresIn) * time.Second)
+	return w.accessToken, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailySalesSheet(t *testing.T) {
+	sales := []models.Sale{
+		{SaleDate: "2025-03-01", TotalPrice: 1120, VATAmount: 120},
+		{SaleDate: "2025-03-01", TotalPrice: 560, VATAmount: 60},
+		{SaleDate: "2025-03-03", TotalPrice: 100},
+	}
+	rows := DailySalesSheet(sales, time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local), time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local))
+
+	require.Len(t, rows, 4)
+	assert.Equal(t, []interface{}{"2025-03-01", 2, 1680.0, 180.0, 1500.0}, rows[1])
+	assert.Equal(t, []interface{}{"2025-03-02", 0, 0.0, 0.0, 0.0}, rows[2], "days without sales are listed")
+	assert.Equal(t, "2025-03-03", rows[3][0])
+}
+
+func TestLowStockSheet(t *testing.T) {
+	rows := LowStockSheet(
+		[]models.MultiCab{{ID: 1, Name: "RX-7", Quantity: 2, Status: string(models.StatusLowStock)}, {ID: 2, Quantity: 9, Status: string(models.StatusAvailable)}},
+		[]models.Accessory{{ID: 3, Name: "Mirror", Quantity: 0, Status: models.StatusOutOfStock}},
+		[]models.Material{{ID: 4, Name: "Bolts", Quantity: 1, Status: string(models.StatusLowStock)}},
+	)
+
+	require.Len(t, rows, 4)
+	assert.Equal(t, []interface{}{"Accessory", 3, "Mirror", 0, "Out of Stock"}, rows[1], "fewest left first")
+	assert.Equal(t, "Material", rows[2][0])
+	assert.Equal(t, "Cab", rows[3][0])
+}
+
+// fakeGoogle serves the token endpoint and the part of the Sheets API the writer uses
+type fakeGoogle struct {
+	key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	tokens   int
+	tabs     []string
+	requests []string
+	written  [][]interface{}
+}
+
+func (g *fakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if r.URL.Path == "/token" {
+		_, err := jwt.Parse(r.FormValue("assertion"), func(*jwt.Token) (interface{}, error) { return &g.key.PublicKey, nil })
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		g.tokens++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token-1", "expires_in": 3600})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer token-1" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	g.requests = append(g.requests, r.Method+" "+r.URL.EscapedPath())
+	switch {
+	case r.URL.Path == "/spreadsheets/missing":
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":{"code":403,"message":"The caller does not have permission"}}`))
+	case r.Method == http.MethodGet:
+		sheets := []interface{}{}
+		for _, tab := range g.tabs {
+			sheets = append(sheets, map[string]interface{}{"properties": map[string]string{"title": tab}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"sheets": sheets})
+	case r.URL.Path == "/spreadsheets/sheet-1:batchUpdate":
+		g.tabs = append(g.tabs, "Daily Sales")
+		_, _ = w.Write([]byte(`{}`))
+	case r.Method == http.MethodPut:
+		var body struct {
+			Values [][]interface{} `json:"values"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		g.written = body.Values
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		_, _ = w.Write([]byte(`{}`))
+	}
+}
+
+func newTestSheetsWriter(t *testing.T) (*GoogleSheetsWriter, *fakeGoogle) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	google := &fakeGoogle{key: key}
+	server := httptest.NewServer(google)
+	t.Cleanup(server.Close)
+
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	credentials, err := json.Marshal(googleServiceAccount{ClientEmail: "reports@project.iam.gserviceaccount.com", PrivateKey: string(privateKey), TokenURI: server.URL + "/token"})
+	require.NoError(t, err)
+	writer, err := newGoogleSheetsWriter(credentials, server.URL+"/spreadsheets")
+	require.NoError(t, err)
+	return writer, google
+}
+
+func TestGoogleSheetsWriterWritesTab(t *testing.T) {
+	writer, google := newTestSheetsWriter(t)
+	assert.Equal(t, "reports@project.iam.gserviceaccount.com", writer.ServiceAccount())
+
+	rows := [][]interface{}{{"Date", "Sales"}, {"2025-03-01", 2}}
+	require.NoError(t, writer.WriteSheet(context.Background(), "sheet-1", "Daily Sales", rows))
+	require.NoError(t, writer.WriteSheet(context.Background(), "sheet-1", "Daily Sales", rows))
+
+	assert.Equal(t, 1, google.tokens, "the access token is reused until it expires")
+	assert.Equal(t, []string{
+		"GET /spreadsheets/sheet-1",
+		"POST /spreadsheets/sheet-1:batchUpdate",
+		"POST /spreadsheets/sheet-1/values/%27Daily%20Sales%27:clear",
+		"PUT /spreadsheets/sheet-1/values/%27Daily%20Sales%27%21A1",
+		"GET /spreadsheets/sheet-1",
+		"POST /spreadsheets/sheet-1/values/%27Daily%20Sales%27:clear",
+		"PUT /spreadsheets/sheet-1/values/%27Daily%20Sales%27%21A1",
+	}, google.requests, "the tab is only added when missing")
+	assert.Equal(t, []interface{}{"2025-03-01", 2.0}, google.written[1])
+}
+
+func TestGoogleSheetsWriterReportsPermissionErrors(t *testing.T) {
+	writer, _ := newTestSheetsWriter(t)
+
+	err := writer.WriteSheet(context.Background(), "missing", "Low Stock", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "The caller does not have permission")
+}
+
+func TestNewGoogleSheetsWriterRejectsInvalidKeys(t *testing.T) {
+	_, err := newGoogleSheetsWriter([]byte(`{"client_email":"a@b.c","token_uri":"https://oauth2.googleapis.com/token","private_key":"not a key"}`), googleSheetsAPI)
+	assert.Error(t, err)
+}
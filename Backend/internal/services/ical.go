@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icalLineOctets is the longest a content line may be before it is folded (RFC 5545 3.1)
+const icalLineOctets = 75
+
+// icalTimeFormat is a UTC date-time as iCalendar writes it
+const icalTimeFormat = "20060102T150405Z"
+
+// CalendarEvent is one event of an iCal feed
+type CalendarEvent struct {
+	UID         string // Stays the same across fetches so calendars update the event in place
+	Summary     string
+	Description string
+	Start       time.Time
+	Duration    time.Duration
+	Updated     time.Time
+}
+
+// icalEscaper escapes the characters that are special in iCalendar text values
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// RenderICal lays out events as an iCalendar feed that calendar apps can subscribe to.
+// refresh suggests how often they fetch it again.
+func RenderICal(name string, refresh time.Duration, events []CalendarEvent) string {
+	var b strings.Builder
+	line := func(content string) {
+		writeICalLine(&b, content)
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Surplus Sales Management System//Calendar//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + icalEscaper.Replace(name))
+	line("REFRESH-INTERVAL;VALUE=DURATION:" + icalDuration(refresh))
+	line("X-PUBLISHED-TTL:" + icalDuration(refresh))
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + event.Updated.UTC().Format(icalTimeFormat))
+		line("LAST-MODIFIED:" + event.Updated.UTC().Format(icalTimeFormat))
+		line("DTSTART:" + event.Start.UTC().Format(icalTimeFormat))
+		line("DTEND:" + event.Start.Add(event.Duration).UTC().Format(icalTimeFormat))
+		line("SUMMARY:" + icalEscaper.Replace(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION:" + icalEscaper.Replace(event.Description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// writeICalLine ends a content line with CRLF, folding it into continuation lines
+// that start with a space when it is too long. Folds never split a UTF-8 character.
+func writeICalLine(b *strings.Builder, content string) {
+	limit := icalLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		b.WriteString(content[:cut])
+		b.WriteString("\r\n ")
+		content = content[cut:]
+		limit = icalLineOctets - 1 // The leading space counts toward the continuation line
+	}
+	b.WriteString(content)
+	b.WriteString("\r\n")
+}
+
+// icalDuration writes a duration of whole minutes, e.g. PT1H or PT1H30M
+func icalDuration(d time.Duration) string {
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	switch {
+	case minutes == 0 && hours > 0:
+		return fmt.Sprintf("PT%dH", hours)
+	case hours == 0:
+		return fmt.Sprintf("PT%dM", minutes)
+	}
+	return fmt.Sprintf("PT%dH%dM", hours, minutes)
+}
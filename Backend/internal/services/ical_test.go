@@ -0,0 +1,45 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderICal(t *testing.T) {
+	start := time.Date(2025, 3, 3, 17, 0, 0, 0, time.FixedZone("PST", 8*60*60))
+	feed := RenderICal("Follow-ups", 90*time.Minute, []CalendarEvent{{
+		UID:         "task-1@example.com",
+		Summary:     "Call Juan; ask about the balance, then log it",
+		Description: "Line one\nLine two",
+		Start:       start,
+		Duration:    30 * time.Minute,
+		Updated:     start,
+	}})
+
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+	assert.Contains(t, feed, "REFRESH-INTERVAL;VALUE=DURATION:PT1H30M\r\n")
+	assert.Contains(t, feed, "DTSTART:20250303T090000Z\r\nDTEND:20250303T093000Z\r\n", "times are written in UTC")
+	assert.Contains(t, feed, `SUMMARY:Call Juan\; ask about the balance\, then log it`)
+	assert.Contains(t, feed, `DESCRIPTION:Line one\nLine two`)
+}
+
+func TestRenderICalFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("Ñ", 60) // Two octets each
+	feed := RenderICal("Follow-ups", time.Hour, []CalendarEvent{{UID: "task-1", Summary: summary}})
+
+	for _, line := range strings.Split(strings.TrimSuffix(feed, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	unfolded := strings.ReplaceAll(feed, "\r\n ", "")
+	assert.Contains(t, unfolded, "SUMMARY:"+summary+"\r\n", "folds do not split characters")
+}
+
+func TestICalDuration(t *testing.T) {
+	assert.Equal(t, "PT1H", icalDuration(time.Hour))
+	assert.Equal(t, "PT30M", icalDuration(30*time.Minute))
+	assert.Equal(t, "PT25H5M", icalDuration(25*time.Hour+5*time.Minute))
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"sync"
+
+	"oop/internal/models"
+)
+
+// Sizes of the variants made of uploaded photos. A photo is served in its original
+// size when it is no larger than the size asked for.
+const (
+	ImageSizeThumb    = "thumb"
+	ImageSizeSmall    = "small"
+	ImageSizeMedium   = "medium"
+	ImageSizeOriginal = "original"
+)
+
+// ImageVariantSizes are the longest sides of the variants, in pixels
+var ImageVariantSizes = map[string]int{ImageSizeThumb: 160, ImageSizeSmall: 480, ImageSizeMedium: 1024}
+
+// imageVariantQuality is the JPEG quality of the variants
+const imageVariantQuality = 80
+
+// imageVariantQueueSize is how many photos can wait for their variants before further
+// ones are skipped. Each holds the uploaded file, so the queue is kept short.
+const imageVariantQueueSize = 16
+
+// ImageVariant is a resized copy of a photo with its contents
+type ImageVariant struct {
+	models.ItemImageVariant
+	Data []byte
+}
+
+// ImageVariantStore saves the variants of a photo
+type ImageVariantStore interface {
+	SaveVariant(variant models.ItemImageVariant, data []byte) error
+}
+
+// ImageVariantQueue makes the smaller variants of uploaded photos in the background,
+// so uploads return as soon as the original is saved. Variants are best effort: when
+// the queue is full or a photo cannot be decoded, the original is served instead.
+type ImageVariantQueue struct {
+	queue chan imageVariantJob
+	stop  chan struct{}
+	done  <-chan struct{}
+	once  sync.Once
+}
+
+type imageVariantJob struct {
+	store ImageVariantStore // The repository of the tenant the photo belongs to
+	image models.ItemImage
+	data  []byte
+}
+
+// NewImageVariantQueue creates a queue and starts its worker under workers
+func NewImageVariantQueue(workers *Supervisor) *ImageVariantQueue {
+	q := &ImageVariantQueue{
+		queue: make(chan imageVariantJob, imageVariantQueueSize),
+		stop:  make(chan struct{}),
+	}
+	q.done = workers.Go("Image variant queue", q.stop, q.run)
+	return q
+}
+
+// Queue schedules the variants of a photo to be made and saved to store without
+// blocking. It reports whether the photo was queued.
+func (q *ImageVariantQueue) Queue(store ImageVariantStore, image models.ItemImage, data []byte) bool {
+	if q == nil {
+		return false
+	}
+	select {
+	case <-q.stop:
+		return false
+	default:
+	}
+
+	select {
+	case q.queue <- imageVariantJob{store: store, image: image, data: data}:
+		return true
+	default:
+		log.Printf("Image variant queue full, serving photo %s of %s %d in its original size", image.ID, image.ItemType, image.ItemID)
+		return false
+	}
+}
+
+// Close stops accepting photos and returns once the queued ones are processed
+func (q *ImageVariantQueue) Close() {
+	q.once.Do(func() { close(q.stop) })
+	<-q.done
+}
+
+func (q *ImageVariantQueue) run() {
+	for {
+		select {
+		case job := <-q.queue:
+			q.process(job)
+		case <-q.stop:
+			for {
+				select {
+				case job := <-q.queue:
+					q.process(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (q *ImageVariantQueue) process(job imageVariantJob) {
+	variants, err := MakeImageVariants(job.data)
+	if err != nil {
+		log.Printf("Error making variants of photo %s of %s %d: %v", job.image.ID, job.image.ItemType, job.image.ItemID, err)
+		return
+	}
+	for _, variant := range variants {
+		variant.ImageID = job.image.ID
+		if err := job.store.SaveVariant(variant.ItemImageVariant, variant.Data); err != nil {
+			log.Printf("Error saving the %s variant of photo %s: %v", variant.Size, job.image.ID, err)
+		}
+	}
+}
+
+// MakeImageVariants decodes a JPEG or PNG photo and returns the variants smaller than
+// it, without their ImageID. Photos with transparency stay PNG; the others are
+// encoded as JPEG, as the standard library has no WebP encoder.
+func MakeImageVariants(data []byte) ([]ImageVariant, error) {
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode photo: %w", err)
+	}
+	transparent := format == "png" && !isOpaque(src)
+
+	variants := []ImageVariant{}
+	for _, size := range []string{ImageSizeThumb, ImageSizeSmall, ImageSizeMedium} {
+		width, height, ok := fitWithin(src.Bounds().Dx(), src.Bounds().Dy(), ImageVariantSizes[size])
+		if !ok {
+			continue
+		}
+		resized := downscale(src, width, height)
+
+		variant := ImageVariant{ItemImageVariant: models.ItemImageVariant{Size: size, Width: width, Height: height}}
+		var buf bytes.Buffer
+		if transparent {
+			variant.ContentType = "image/png"
+			err = png.Encode(&buf, resized)
+		} else {
+			variant.ContentType = "image/jpeg"
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: imageVariantQuality})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not encode %s variant: %w", size, err)
+		}
+		variant.Data = buf.Bytes()
+		variant.FileSize = len(variant.Data)
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// fitWithin scales width and height down so the longest side is limit. It reports
+// false when the image already fits, as variants are never enlarged.
+func fitWithin(width, height, limit int) (int, int, bool) {
+	longest := width
+	if height > longest {
+		longest = height
+	}
+	if longest <= limit {
+		return width, height, false
+	}
+	scaledWidth := width * limit / longest
+	scaledHeight := height * limit / longest
+	if scaledWidth < 1 {
+		scaledWidth = 1
+	}
+	if scaledHeight < 1 {
+		scaledHeight = 1
+	}
+	return scaledWidth, scaledHeight, true
+}
+
+// isOpaque reports whether every pixel of an image is fully opaque
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// downscale shrinks an image to width by height, averaging the source pixels that
+// fall into each destination pixel
+func downscale(src image.Image, width, height int) *image.NRGBA {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := bounds.Min.Y + (y+1)*bounds.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := bounds.Min.X + (x+1)*bounds.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			// Colors are summed premultiplied so transparent pixels do not darken edges
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			if a == 0 {
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xff / a),
+				G: uint8(g * 0xff / a),
+				B: uint8(b * 0xff / a),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
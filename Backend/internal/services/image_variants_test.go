@@ -0,0 +1,49 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestMakeImageVariants(t *testing.T) {
+	// A transparent logo stays PNG so its background is not filled in
+	logo := image.NewNRGBA(image.Rect(0, 0, 2000, 500))
+	logo.Set(10, 10, color.NRGBA{R: 255, A: 255})
+	variants, err := MakeImageVariants(encodePNG(t, logo))
+	require.NoError(t, err)
+	require.Len(t, variants, 3)
+	for i, size := range []string{ImageSizeThumb, ImageSizeSmall, ImageSizeMedium} {
+		assert.Equal(t, size, variants[i].Size)
+		assert.Equal(t, "image/png", variants[i].ContentType)
+		assert.Equal(t, ImageVariantSizes[size], variants[i].Width)
+		assert.Equal(t, ImageVariantSizes[size]/4, variants[i].Height, "the aspect ratio is kept")
+		assert.Equal(t, len(variants[i].Data), variants[i].FileSize)
+	}
+
+	// Opaque photos become JPEG, and are never enlarged
+	photo := image.NewRGBA(image.Rect(0, 0, 300, 400))
+	for i := range photo.Pix {
+		photo.Pix[i] = 0xff
+	}
+	variants, err = MakeImageVariants(encodePNG(t, photo))
+	require.NoError(t, err)
+	require.Len(t, variants, 1)
+	assert.Equal(t, "image/jpeg", variants[0].ContentType)
+	assert.Equal(t, 120, variants[0].Width)
+	assert.Equal(t, 160, variants[0].Height)
+
+	_, err = MakeImageVariants([]byte("RIFF....WEBP"))
+	assert.Error(t, err, "WebP photos cannot be decoded")
+}
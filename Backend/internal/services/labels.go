@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// LabelSheet is the layout of a sheet of adhesive labels, in PDF points (1/72 inch)
+type LabelSheet struct {
+	PageWidth, PageHeight   float64
+	Columns, Rows           int
+	LabelWidth, LabelHeight float64
+	MarginLeft, MarginTop   float64 // From the top left corner of the page to the first label
+	PitchX, PitchY          float64 // From one label to the next, gaps included
+}
+
+// PerSheet is the number of labels on a sheet
+func (s LabelSheet) PerSheet() int {
+	return s.Columns * s.Rows
+}
+
+// Label sheets the labels are laid out for
+const (
+	LabelSheetLetter = "letter" // 30 labels of 2.625" x 1" on US Letter, as Avery 5160
+	LabelSheetA4     = "a4"     // 21 labels of 63.5mm x 38.1mm on A4, as Avery L7160
+)
+
+// LabelSheets are the supported label sheets by name
+var LabelSheets = map[string]LabelSheet{
+	LabelSheetLetter: {PageWidth: 612, PageHeight: 792, Columns: 3, Rows: 10, LabelWidth: 189, LabelHeight: 72,
+		MarginLeft: 13.5, MarginTop: 36, PitchX: 198, PitchY: 72},
+	LabelSheetA4: {PageWidth: 595.28, PageHeight: 841.89, Columns: 3, Rows: 7, LabelWidth: 180, LabelHeight: 108,
+		MarginLeft: 20.4, MarginTop: 42.9, PitchX: 187.2, PitchY: 108},
+}
+
+// InventoryLabel is the label of one cab, accessory or material
+type InventoryLabel struct {
+	Name     string
+	Price    *float64 // Left off when nil, as materials have no price
+	Barcode  string   // Code 39 value, see LabelBarcode
+	Location string   // Where the item is kept, if known
+}
+
+// labelBarcodePrefixes start the barcode of each inventory type
+var labelBarcodePrefixes = map[string]string{"cab": "CAB", "accessory": "ACC", "material": "MAT"}
+
+// LabelBarcode returns the barcode value of an inventory item, e.g. CAB-12
+func LabelBarcode(itemType string, id int) string {
+	return fmt.Sprintf("%s-%d", labelBarcodePrefixes[itemType], id)
+}
+
+// code39Patterns are the bars and spaces of each Code 39 character, alternating from
+// a bar, with 1 for a wide element
+var code39Patterns = map[rune]string{
+	'0': "000110100", '1': "100100001", '2': "001100001", '3': "101100000", '4': "000110001",
+	'5': "100110000", '6': "001110000", '7': "000100101", '8': "100100100", '9': "001100100",
+	'A': "100001001", 'B': "001001001", 'C': "101001000", 'D': "000011001", 'E': "100011000",
+	'F': "001011000", 'G': "000001101", 'H': "100001100", 'I': "001001100", 'J': "000011100",
+	'K': "100000011", 'L': "001000011", 'M': "101000010", 'N': "000010011", 'O': "100010010",
+	'P': "001010010", 'Q': "000000111", 'R': "100000110", 'S': "001000110", 'T': "000010110",
+	'U': "110000001", 'V': "011000001", 'W': "111000000", 'X': "010010001", 'Y': "110010000",
+	'Z': "011010000", '-': "010000101", '.': "110000100", ' ': "011000100", '*': "010010100",
+}
+
+// code39WideRatio is how many narrow elements a wide one spans
+const code39WideRatio = 3
+
+// code39Elements returns the widths of the bars and spaces encoding value, in narrow
+// elements, with the start and stop characters and a narrow gap between characters
+func code39Elements(value string) ([]int, error) {
+	elements := []int{}
+	for i, r := range "*" + value + "*" {
+		pattern, ok := code39Patterns[r]
+		if !ok || (r == '*' && i > 0 && i < len(value)+1) {
+			return nil, fmt.Errorf("%q cannot be encoded in a Code 39 barcode", r)
+		}
+		if i > 0 {
+			elements = append(elements, 1)
+		}
+		for _, wide := range pattern {
+			if wide == '1' {
+				elements = append(elements, code39WideRatio)
+			} else {
+				elements = append(elements, 1)
+			}
+		}
+	}
+	return elements, nil
+}
+
+// Inside a label, in points
+const (
+	labelPadding      = 6
+	labelNameSize     = 9
+	labelDetailSize   = 8
+	labelCaptionSize  = 6
+	labelMaxBarNarrow = 1.0  // Narrow bar width; longer barcodes are narrowed to fit
+	labelMinBarNarrow = 0.55 // Narrowest bar handheld scanners still read reliably
+)
+
+// RenderLabelsPDF lays out labels on as many pages of sheet as they need, left to
+// right and top to bottom, and returns them as a PDF document
+func RenderLabelsPDF(sheet LabelSheet, labels []InventoryLabel) ([]byte, error) {
+	if sheet.PerSheet() == 0 {
+		return nil, fmt.Errorf("label sheet has no labels")
+	}
+
+	pages := []string{}
+	for start := 0; start < len(labels); start += sheet.PerSheet() {
+		end := start + sheet.PerSheet()
+		if end > len(labels) {
+			end = len(labels)
+		}
+		var content strings.Builder
+		for i, label := range labels[start:end] {
+			x := sheet.MarginLeft + float64(i%sheet.Columns)*sheet.PitchX
+			y := sheet.PageHeight - sheet.MarginTop - float64(i/sheet.Columns)*sheet.PitchY - sheet.LabelHeight
+			if err := writeLabel(&content, sheet, x, y, label); err != nil {
+				return nil, err
+			}
+		}
+		pages = append(pages, content.String())
+	}
+	if len(pages) == 0 {
+		pages = append(pages, "")
+	}
+	return writePDF(sheet.PageWidth, sheet.PageHeight, pages), nil
+}
+
+// writeLabel draws a label whose bottom left corner is at x, y: the name, the price and
+// location below it, and the barcode with its value at the bottom
+func writeLabel(b *strings.Builder, sheet LabelSheet, x, y float64, label InventoryLabel) error {
+	elements, err := code39Elements(label.Barcode)
+	if err != nil {
+		return err
+	}
+
+	width := sheet.LabelWidth - 2*labelPadding
+	left := x + labelPadding
+	top := y + sheet.LabelHeight - labelPadding
+
+	nameLine := top - labelNameSize
+	writeText(b, "F2", labelNameSize, left, nameLine, fitText(asciiOnly(label.Name), labelNameSize, width))
+
+	detailLine := nameLine - labelDetailSize - 3
+	price := ""
+	if label.Price != nil {
+		price = "PHP " + FormatAmount(*label.Price)
+		writeText(b, "F2", labelDetailSize, left, detailLine, price)
+	}
+	if label.Location != "" {
+		room := width - textWidth(price, labelDetailSize) - labelDetailSize
+		location := fitText(asciiOnly(label.Location), labelDetailSize, room)
+		writeText(b, "F1", labelDetailSize, left+width-textWidth(location, labelDetailSize), detailLine, location)
+	}
+
+	// The barcode fills the rest of the label above its caption
+	modules := 0
+	for _, element := range elements {
+		modules += element
+	}
+	narrow := width / float64(modules)
+	if narrow > labelMaxBarNarrow {
+		narrow = labelMaxBarNarrow
+	}
+	if narrow < labelMinBarNarrow {
+		return fmt.Errorf("barcode %s is too long for the label", label.Barcode)
+	}
+	barsBottom := y + labelPadding + labelCaptionSize + 2
+	barsHeight := detailLine - 4 - barsBottom
+	barX := left + (width-narrow*float64(modules))/2
+	b.WriteString("0 g\n")
+	for i, element := range elements {
+		if i%2 == 0 { // Bars and spaces alternate, starting with a bar
+			fmt.Fprintf(b, "%.2f %.2f %.2f %.2f re f\n", barX, barsBottom, narrow*float64(element), barsHeight)
+		}
+		barX += narrow * float64(element)
+	}
+	writeText(b, "F1", labelCaptionSize, left+(width-textWidth(label.Barcode, labelCaptionSize))/2, y+labelPadding, label.Barcode)
+	return nil
+}
+
+// pdfEscaper escapes the characters that are special in PDF strings
+var pdfEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+
+// writeText draws ASCII text with its baseline starting at x, y
+func writeText(b *strings.Builder, font string, size, x, y float64, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(b, "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscaper.Replace(text))
+}
+
+// textWidth estimates the width of ASCII text in Helvetica, erring on the wide side
+func textWidth(text string, size float64) float64 {
+	width := 0.0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(" .,:;'!|iljtfI()[]", r):
+			width += 0.33
+		case strings.ContainsRune("mwMW@", r):
+			width += 0.95
+		case r >= 'A' && r <= 'Z':
+			width += 0.73
+		default:
+			width += 0.6
+		}
+	}
+	return width * size
+}
+
+// fitText shortens text with an ellipsis until it fits in width
+func fitText(text string, size, width float64) string {
+	if textWidth(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	if len(runes) == 0 {
+		return ""
+	}
+	return strings.TrimRight(string(runes), " ") + "..."
+}
+
+// writePDF assembles a PDF document with one page of the size per content stream,
+// using the standard Helvetica fonts so nothing has to be embedded
+func writePDF(width, height float64, pages []string) []byte {
+	var b bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page is followed by its content
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	b.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, content := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			width, height, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
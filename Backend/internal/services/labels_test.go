@@ -0,0 +1,69 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode39Elements(t *testing.T) {
+	elements, err := code39Elements("C-1")
+	require.NoError(t, err)
+	// Five characters with start and stop, nine elements each and a gap between them
+	assert.Len(t, elements, 5*9+4)
+	wide := 0
+	for _, element := range elements[:9] {
+		if element == code39WideRatio {
+			wide++
+		}
+	}
+	assert.Equal(t, 3, wide, "three of the nine elements are wide")
+
+	_, err = code39Elements("cab-1")
+	assert.Error(t, err, "lowercase is not encodable")
+	_, err = code39Elements("A*B")
+	assert.Error(t, err, "* only starts and stops a barcode")
+}
+
+func TestRenderLabelsPDF(t *testing.T) {
+	price := 700000.0
+	labels := make([]InventoryLabel, 31)
+	for i := range labels {
+		labels[i] = InventoryLabel{Name: "RX-7 (Blue)", Price: &price, Barcode: LabelBarcode("cab", 12), Location: "Yard B"}
+	}
+	labels[30] = InventoryLabel{Name: "A very long material name that cannot fit on one label", Barcode: LabelBarcode("material", 3)}
+
+	pdf, err := RenderLabelsPDF(LabelSheets[LabelSheetLetter], labels)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	document := string(pdf)
+	assert.Contains(t, document, "/Count 2", "31 labels take two Letter sheets")
+	assert.Contains(t, document, `(RX-7 \(Blue\)) Tj`)
+	assert.Contains(t, document, "(PHP 700,000.00) Tj")
+	assert.Contains(t, document, "(CAB-12) Tj")
+	assert.Contains(t, document, "(Yard B) Tj")
+	assert.Contains(t, document, "...) Tj", "long names are shortened")
+	assert.Equal(t, 1, strings.Count(document, "(MAT-3) Tj"))
+
+	// Each object listed in the cross-reference table starts at its offset
+	xref := strings.LastIndex(document, "xref\n")
+	require.Positive(t, xref)
+	for i, line := range strings.Split(document[xref:], "\n")[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		var offset int
+		_, err := fmt.Sscan(line, &offset)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(document[offset:], strconv.Itoa(i+1)+" 0 obj"), "object %d", i+1)
+	}
+
+	_, err = RenderLabelsPDF(LabelSheets[LabelSheetA4], []InventoryLabel{{Name: "Bad", Barcode: "cab-1"}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+)
+
+// LogLevel is the level the server logs at. It starts at the level LOG_LEVEL sets,
+// and admins can change it for a while, such as to debug logging while diagnosing an
+// incident, without restarting the server; it reverts on its own once the time is up.
+// Messages of the standard log package are logged at the level their prefix gives, see
+// stdLogLevel. The level is kept in memory, so a change only applies to the instance
+// that made it and is lost on restart.
+type LogLevel struct {
+	level *slog.LevelVar
+	base  slog.Level
+	now   func() time.Time
+
+	mu        sync.Mutex
+	revertsAt time.Time // Zero while at the base level
+	changedBy string
+	timer     *time.Timer
+}
+
+// NewLogLevel creates a log level starting at base
+func NewLogLevel(base slog.Level) *LogLevel {
+	level := new(slog.LevelVar)
+	level.Set(base)
+	return &LogLevel{level: level, base: base, now: time.Now}
+}
+
+// Install makes the slog and standard log packages write to w, dropping messages
+// below the level
+func (l *LogLevel) Install(w io.Writer) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: l.level})))
+	log.SetOutput(stdLog{}) // Replaces the bridge SetDefault installs, which logs everything at info
+	log.SetFlags(0)         // slog adds the time
+}
+
+// Set changes the level until ttl has passed, replacing any earlier change, and
+// returns when it reverts
+func (l *LogLevel) Set(level slog.Level, ttl time.Duration, changedBy string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+	l.level.Set(level)
+	l.revertsAt = l.now().Add(ttl)
+	l.changedBy = changedBy
+	l.timer = time.AfterFunc(ttl, l.expire)
+	slog.Warn("Log level changed", "level", levelName(level), "by", changedBy, "until", l.revertsAt.Format(time.RFC3339))
+	return l.revertsAt
+}
+
+// Reset reverts to the base level now
+func (l *LogLevel) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.revert()
+}
+
+// Close stops the timer of a change, leaving the level as it is
+func (l *LogLevel) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.timer != nil {
+		l.timer.Stop()
+	}
+}
+
+// Status returns the current level, the base level and when the current one reverts
+func (l *LogLevel) Status() models.LogLevelStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	status := models.LogLevelStatus{Level: levelName(l.level.Level()), DefaultLevel: levelName(l.base), ChangedBy: l.changedBy}
+	if !l.revertsAt.IsZero() {
+		revertsAt := l.revertsAt
+		status.RevertsAt = &revertsAt
+	}
+	return status
+}
+
+// expire reverts to the base level once the change is due to, unless a later change
+// replaced it after its timer fired
+func (l *LogLevel) expire() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.revertsAt.IsZero() && !l.now().Before(l.revertsAt) {
+		l.revert()
+	}
+}
+
+// revert restores the base level; l.mu must be held
+func (l *LogLevel) revert() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if l.revertsAt.IsZero() {
+		return
+	}
+	l.level.Set(l.base)
+	l.revertsAt, l.changedBy = time.Time{}, ""
+	slog.Warn("Log level reverted", "level", levelName(l.base))
+}
+
+// stdLog passes the messages of the standard log package on to slog
+type stdLog struct{}
+
+func (stdLog) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	slog.Log(context.Background(), stdLogLevel(msg), msg)
+	return len(p), nil
+}
+
+// stdLogLevel is the level of a message of the standard log package, which has none
+// of its own: error for those starting with Error or Failed, warn for those starting
+// with Warning and info for the rest
+func stdLogLevel(msg string) slog.Level {
+	switch {
+	case strings.HasPrefix(msg, "Error"), strings.HasPrefix(msg, "Failed"):
+		return slog.LevelError
+	case strings.HasPrefix(msg, "Warning"):
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// levelName is the name of a level as LOG_LEVEL takes it, such as debug
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevelRevertsAfterTTL(t *testing.T) {
+	l := NewLogLevel(slog.LevelInfo)
+	assert.Equal(t, "info", l.Status().Level)
+
+	revertsAt := l.Set(slog.LevelDebug, 20*time.Millisecond, "admin-1")
+	status := l.Status()
+	assert.Equal(t, "debug", status.Level)
+	assert.Equal(t, "info", status.DefaultLevel)
+	assert.Equal(t, "admin-1", status.ChangedBy)
+	require.NotNil(t, status.RevertsAt)
+	assert.Equal(t, revertsAt, *status.RevertsAt)
+
+	require.Eventually(t, func() bool { return l.Status().Level == "info" }, time.Second, time.Millisecond)
+	status = l.Status()
+	assert.Nil(t, status.RevertsAt)
+	assert.Empty(t, status.ChangedBy)
+}
+
+func TestLogLevelLaterChangeReplacesEarlier(t *testing.T) {
+	l := NewLogLevel(slog.LevelWarn)
+	l.Set(slog.LevelDebug, 10*time.Millisecond, "admin-1")
+	l.Set(slog.LevelError, time.Hour, "admin-2")
+	defer l.Close()
+
+	time.Sleep(30 * time.Millisecond)
+	status := l.Status()
+	assert.Equal(t, "error", status.Level)
+	assert.Equal(t, "admin-2", status.ChangedBy)
+
+	l.Reset()
+	assert.Equal(t, "warn", l.Status().Level)
+}
+
+func TestLogLevelFiltersMessages(t *testing.T) {
+	defer func(logger *slog.Logger, w io.Writer, flags int) {
+		slog.SetDefault(logger)
+		log.SetOutput(w)
+		log.SetFlags(flags)
+	}(slog.Default(), log.Writer(), log.Flags())
+
+	var out bytes.Buffer
+	l := NewLogLevel(slog.LevelInfo)
+	l.Install(&out)
+
+	slog.Debug("hidden detail")
+	log.Printf("standard message")
+	assert.NotContains(t, out.String(), "hidden detail")
+	assert.Contains(t, out.String(), "standard message")
+
+	l.Set(slog.LevelDebug, time.Hour, "admin-1")
+	defer l.Close()
+	slog.DebugContext(context.Background(), "shown detail")
+	assert.Contains(t, out.String(), "shown detail")
+}
+
+func TestLogLevelStandardLogPrefixes(t *testing.T) {
+	defer func(logger *slog.Logger, w io.Writer, flags int) {
+		slog.SetDefault(logger)
+		log.SetOutput(w)
+		log.SetFlags(flags)
+	}(slog.Default(), log.Writer(), log.Flags())
+
+	var out bytes.Buffer
+	l := NewLogLevel(slog.LevelError)
+	l.Install(&out)
+
+	log.Printf("Error saving sale: %v", "deadlock")
+	log.Printf("Warning: quota almost spent")
+	log.Printf("User admin-1 applied roster provisioning")
+	assert.Contains(t, out.String(), `level=ERROR msg="Error saving sale: deadlock"`)
+	assert.NotContains(t, out.String(), "quota almost spent")
+	assert.NotContains(t, out.String(), "roster provisioning")
+
+	l.Set(slog.LevelWarn, time.Hour, "admin-1")
+	defer l.Close()
+	log.Printf("Warning: quota almost spent")
+	assert.Contains(t, out.String(), `level=WARN msg="Warning: quota almost spent"`)
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+)
+
+// Limits of a single delivery attempt and of the wait between attempts
+const (
+	logSendTimeout = 10 * time.Second
+	maxLogBackoff  = 30 * time.Second
+)
+
+// ShippedLog is an activity log entry as it is sent to the collector
+type ShippedLog struct {
+	TenantID string `json:"tenantId"`
+	models.ActivityLog
+}
+
+// LogSink delivers a batch of entries to a collector
+type LogSink interface {
+	Send(ctx context.Context, batch []ShippedLog) error
+}
+
+// NewLogSink creates the sink of the configured collector, or nil when none is configured
+func NewLogSink(cfg config.SIEMConfig) LogSink {
+	switch {
+	case cfg.HTTPURL != "":
+		return &httpLogSink{url: cfg.HTTPURL, token: cfg.HTTPToken, client: &http.Client{}}
+	case cfg.SyslogAddress != "":
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "-"
+		}
+		return &syslogLogSink{network: cfg.SyslogNetwork, address: cfg.SyslogAddress, hostname: hostname}
+	}
+	return nil
+}
+
+// LogShipper forwards activity log entries to a SIEM collector from a background
+// worker, in batches of up to BatchSize sent at least every FlushInterval. A failed
+// batch is retried with exponential backoff up to MaxRetries times, then dropped.
+// While the collector is slow or down, entries wait in a queue of QueueSize; once it
+// is full new entries are dropped rather than slowing down requests, and the number
+// dropped is logged when delivery resumes. Entries stay in the activity log either way.
+type LogShipper struct {
+	sink    LogSink
+	cfg     config.SIEMConfig
+	backoff func(attempt int) time.Duration
+
+	queue   chan ShippedLog
+	dropped atomic.Int64
+	stop    chan struct{}
+	done    <-chan struct{}
+	once    sync.Once
+}
+
+// NewLogShipper creates a shipper delivering to sink and starts its worker under workers
+func NewLogShipper(workers *Supervisor, sink LogSink, cfg config.SIEMConfig) *LogShipper {
+	s := &LogShipper{
+		sink:    sink,
+		cfg:     cfg,
+		backoff: exponentialBackoff,
+		queue:   make(chan ShippedLog, cfg.QueueSize),
+		stop:    make(chan struct{}),
+	}
+	s.done = workers.Go("SIEM log shipper", s.stop, s.run)
+	return s
+}
+
+// exponentialBackoff waits 1s, 2s, 4s and so on between attempts, up to maxLogBackoff
+func exponentialBackoff(attempt int) time.Duration {
+	if attempt >= 5 {
+		return maxLogBackoff
+	}
+	return min(time.Second<<attempt, maxLogBackoff)
+}
+
+// Forward queues an entry without blocking, dropping it when the queue is full
+func (s *LogShipper) Forward(tenantID string, entry models.ActivityLog) {
+	select {
+	case <-s.stop:
+		return
+	default:
+	}
+
+	select {
+	case s.queue <- ShippedLog{TenantID: tenantID, ActivityLog: entry}:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Close stops accepting entries and waits until the queued ones are delivered or
+// ctx is done
+func (s *LogShipper) Close(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("log shipper did not finish delivering: %w", ctx.Err())
+	}
+}
+
+func (s *LogShipper) run() {
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]ShippedLog, 0, s.cfg.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			s.deliver(batch)
+			batch = make([]ShippedLog, 0, s.cfg.BatchSize)
+		}
+	}
+
+	for {
+		select {
+		case entry := <-s.queue:
+			batch = append(batch, entry)
+			if len(batch) >= s.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			for {
+				select {
+				case entry := <-s.queue:
+					batch = append(batch, entry)
+					if len(batch) >= s.cfg.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a batch, retrying failures. After shutdown has begun it retries
+// without waiting so Close is not held up by the backoff.
+func (s *LogShipper) deliver(batch []ShippedLog) {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), logSendTimeout)
+		err := s.sink.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			if dropped := s.dropped.Swap(0); dropped > 0 {
+				log.Printf("SIEM queue was full, dropped %d activity log entries", dropped)
+			}
+			return
+		}
+
+		if attempt >= s.cfg.MaxRetries {
+			log.Printf("Error shipping %d activity log entries to SIEM, giving up after %d attempts: %v", len(batch), attempt+1, err)
+			return
+		}
+		log.Printf("Error shipping %d activity log entries to SIEM (attempt %d): %v", len(batch), attempt+1, err)
+
+		select {
+		case <-time.After(s.backoff(attempt)):
+		case <-s.stop:
+		}
+	}
+}
+
+// httpLogSink posts each batch to a collector as a JSON array
+type httpLogSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s *httpLogSink) Send(ctx context.Context, batch []ShippedLog) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode log batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create collector request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach collector: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+// syslogLogSink sends each entry as an RFC 5424 message with a JSON body. Over TCP
+// messages are framed with octet counting (RFC 6587).
+type syslogLogSink struct {
+	network  string
+	address  string
+	hostname string
+}
+
+// Syslog priority parts: entries are logged under local0, failures as warnings
+const (
+	syslogFacilityLocal0 = 16
+	syslogSeverityWarn   = 4
+	syslogSeverityInfo   = 6
+)
+
+func (s *syslogLogSink) Send(ctx context.Context, batch []ShippedLog) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return fmt.Errorf("failed to reach syslog collector: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	for _, entry := range batch {
+		message, err := s.format(entry)
+		if err != nil {
+			return err
+		}
+		if s.network == "tcp" {
+			message = fmt.Sprintf("%d %s", len(message), message)
+		}
+		if _, err := conn.Write([]byte(message)); err != nil {
+			return fmt.Errorf("failed to write to syslog collector: %w", err)
+		}
+	}
+	return nil
+}
+
+// format renders an entry as an RFC 5424 message
+func (s *syslogLogSink) format(entry ShippedLog) (string, error) {
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode log entry: %w", err)
+	}
+
+	severity := syslogSeverityInfo
+	if !strings.EqualFold(entry.Status, "success") {
+		severity = syslogSeverityWarn
+	}
+	timestamp := entry.Timestamp
+	if timestamp.IsZero() {
+		timestamp = entry.CreatedAt
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s surplus-sales - activity - %s",
+		syslogFacilityLocal0*8+severity, timestamp.UTC().Format(time.RFC3339Nano), s.hostname, body), nil
+}
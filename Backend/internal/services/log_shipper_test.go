@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLogSink records delivered batches and fails the first failures attempts
+type fakeLogSink struct {
+	mu       sync.Mutex
+	batches  [][]ShippedLog
+	attempts int
+	failures int
+	block    chan struct{} // When set, Send waits on it
+}
+
+func (s *fakeLogSink) Send(ctx context.Context, batch []ShippedLog) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts++
+	if s.attempts <= s.failures {
+		return errors.New("collector unavailable")
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *fakeLogSink) delivered() [][]ShippedLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches
+}
+
+func testSIEMConfig() config.SIEMConfig {
+	return config.SIEMConfig{BatchSize: 3, FlushInterval: time.Hour, QueueSize: 10, MaxRetries: 2}
+}
+
+func newTestShipper(sink LogSink, cfg config.SIEMConfig) *LogShipper {
+	shipper := NewLogShipper(nil, sink, cfg)
+	shipper.backoff = func(int) time.Duration { return time.Millisecond }
+	return shipper
+}
+
+func TestLogShipperBatches(t *testing.T) {
+	sink := &fakeLogSink{}
+	shipper := newTestShipper(sink, testSIEMConfig())
+
+	for _, id := range []string{"1", "2", "3", "4"} {
+		shipper.Forward("acme", models.ActivityLog{ID: id})
+	}
+	assert.Eventually(t, func() bool { return len(sink.delivered()) == 1 }, time.Second, time.Millisecond, "a full batch is sent right away")
+
+	require.NoError(t, shipper.Close(context.Background()))
+	batches := sink.delivered()
+	require.Len(t, batches, 2, "the rest is flushed on close")
+	assert.Len(t, batches[0], 3)
+	assert.Equal(t, "4", batches[1][0].ID)
+	assert.Equal(t, "acme", batches[1][0].TenantID)
+
+	shipper.Forward("acme", models.ActivityLog{ID: "5"})
+	assert.Len(t, sink.delivered(), 2, "entries are refused once closed")
+}
+
+func TestLogShipperRetries(t *testing.T) {
+	sink := &fakeLogSink{failures: 2}
+	shipper := newTestShipper(sink, testSIEMConfig())
+	shipper.Forward("acme", models.ActivityLog{ID: "1"})
+	require.NoError(t, shipper.Close(context.Background()))
+	assert.Len(t, sink.delivered(), 1, "delivered on the third attempt")
+
+	sink = &fakeLogSink{failures: 3}
+	shipper = newTestShipper(sink, testSIEMConfig())
+	shipper.Forward("acme", models.ActivityLog{ID: "1"})
+	require.NoError(t, shipper.Close(context.Background()))
+	assert.Empty(t, sink.delivered(), "dropped after the retries are used up")
+	assert.Equal(t, 3, sink.attempts)
+}
+
+func TestLogShipperBackpressure(t *testing.T) {
+	sink := &fakeLogSink{block: make(chan struct{})}
+	cfg := testSIEMConfig()
+	cfg.BatchSize = 1
+	cfg.QueueSize = 2
+	shipper := newTestShipper(sink, cfg)
+
+	shipper.Forward("acme", models.ActivityLog{ID: "1"}) // Picked up by the worker, which blocks sending it
+	assert.Eventually(t, func() bool { return len(shipper.queue) == 0 }, time.Second, time.Millisecond)
+	for _, id := range []string{"2", "3", "4", "5"} {
+		shipper.Forward("acme", models.ActivityLog{ID: id})
+	}
+	assert.Equal(t, int64(2), shipper.dropped.Load(), "entries beyond the queue are dropped")
+
+	close(sink.block)
+	require.NoError(t, shipper.Close(context.Background()))
+	assert.Len(t, sink.delivered(), 3)
+	assert.Equal(t, int64(0), shipper.dropped.Load(), "the drop count is reported once delivery resumes")
+}
+
+func TestHTTPLogSink(t *testing.T) {
+	var received []ShippedLog
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sink := NewLogSink(config.SIEMConfig{HTTPURL: server.URL, HTTPToken: "secret"})
+	err := sink.Send(context.Background(), []ShippedLog{{TenantID: "acme", ActivityLog: models.ActivityLog{ID: "1", Action: "UPDATE"}}})
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	assert.Equal(t, "acme", received[0].TenantID)
+	assert.Equal(t, "UPDATE", received[0].Action)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	err = NewLogSink(config.SIEMConfig{HTTPURL: failing.URL}).Send(context.Background(), []ShippedLog{{}})
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestSyslogLogSinkTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		frame, _ := io.ReadAll(conn) // The sink closes the connection after each batch
+		lines <- string(frame)
+	}()
+
+	sink := NewLogSink(config.SIEMConfig{SyslogNetwork: "tcp", SyslogAddress: listener.Addr().String()})
+	timestamp := time.Date(2026, 5, 4, 9, 30, 0, 0, time.UTC)
+	entry := ShippedLog{TenantID: "acme", ActivityLog: models.ActivityLog{ID: "1", Status: "FAILED", Timestamp: timestamp}}
+	require.NoError(t, sink.Send(context.Background(), []ShippedLog{entry}))
+
+	select {
+	case frame := <-lines:
+		length, message, ok := strings.Cut(frame, " ")
+		require.True(t, ok)
+		assert.Equal(t, strconv.Itoa(len(message)), length, "frames are prefixed with their length")
+		assert.True(t, strings.HasPrefix(message, "<132>1 2026-05-04T09:30:00Z "), "local0 warning for failures: %s", message)
+		assert.Contains(t, message, `"tenantId":"acme"`)
+	case <-time.After(time.Second):
+		t.Fatal("no syslog message received")
+	}
+}
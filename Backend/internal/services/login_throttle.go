@@ -0,0 +1,131 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"oop/internal/config"
+)
+
+// loginFailures counts the failed sign-ins of one account or address
+type loginFailures struct {
+	count       int
+	lastFailed  time.Time
+	lockedUntil time.Time
+}
+
+// LoginThrottle counts failed sign-ins per account and per address of each tenant,
+// locking an account, or blocking an address, for the lockout once too many failed.
+// Failures older than the lockout are forgotten. It is kept in memory, so it starts
+// empty when the server restarts and is per instance.
+type LoginThrottle struct {
+	config config.LoginThrottleConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]*loginFailures // By tenant and account or address
+}
+
+// NewLoginThrottle creates a throttle with the given limits
+func NewLoginThrottle(cfg config.LoginThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{config: cfg, now: time.Now, failures: make(map[string]*loginFailures)}
+}
+
+// AccountLocked returns how long an account stays locked, 0 when it is not. A nil
+// throttle locks nothing.
+func (t *LoginThrottle) AccountLocked(tenantID, account string) time.Duration {
+	if t == nil || t.config.MaxFailures == 0 {
+		return 0
+	}
+	return t.lockedFor(accountKey(tenantID, account))
+}
+
+// AddressBlocked returns how long sign-ins from an address stay blocked, 0 when they
+// are not
+func (t *LoginThrottle) AddressBlocked(tenantID, ip string) time.Duration {
+	if t == nil || t.config.IPMaxFailures == 0 {
+		return 0
+	}
+	return t.lockedFor(addressKey(tenantID, ip))
+}
+
+// Fail records a failed sign-in to an account from an address. It returns how long
+// the account is now locked, 0 unless this failure locked it.
+func (t *LoginThrottle) Fail(tenantID, account, ip string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+	t.fail(addressKey(tenantID, ip), t.config.IPMaxFailures, now)
+	if t.fail(accountKey(tenantID, account), t.config.MaxFailures, now) {
+		return t.config.Lockout
+	}
+	return 0
+}
+
+// Succeed forgets the failed sign-ins of an account once its owner signed in
+func (t *LoginThrottle) Succeed(tenantID, account string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, accountKey(tenantID, account))
+}
+
+// fail counts a failure under key, locking it once max failures were counted; a max
+// of 0 counts nothing. It reports whether the key got locked.
+func (t *LoginThrottle) fail(key string, max int, now time.Time) bool {
+	if max == 0 {
+		return false
+	}
+	failures, ok := t.failures[key]
+	if !ok {
+		failures = &loginFailures{}
+		t.failures[key] = failures
+	}
+	failures.count++
+	failures.lastFailed = now
+	if failures.count < max {
+		return false
+	}
+	failures.count = 0
+	failures.lockedUntil = now.Add(t.config.Lockout)
+	return true
+}
+
+// lockedFor returns how long key stays locked
+func (t *LoginThrottle) lockedFor(key string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	failures, ok := t.failures[key]
+	if !ok {
+		return 0
+	}
+	if wait := failures.lockedUntil.Sub(t.now()); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// prune forgets the failures older than the lockout of keys no longer locked
+func (t *LoginThrottle) prune(now time.Time) {
+	for key, failures := range t.failures {
+		if now.Sub(failures.lastFailed) >= t.config.Lockout && !now.Before(failures.lockedUntil) {
+			delete(t.failures, key)
+		}
+	}
+}
+
+func accountKey(tenantID, account string) string {
+	return tenantID + "\x00account:" + account
+}
+
+func addressKey(tenantID, ip string) string {
+	return tenantID + "\x00ip:" + ip
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	throttle := NewLoginThrottle(config.LoginThrottleConfig{MaxFailures: 3, IPMaxFailures: 5, Lockout: 15 * time.Minute})
+	throttle.now = func() time.Time { return now }
+
+	assert.Zero(t, throttle.Fail("acme", "user-1", "10.0.0.1"))
+	assert.Zero(t, throttle.Fail("acme", "user-1", "10.0.0.1"))
+	assert.Zero(t, throttle.AccountLocked("acme", "user-1"))
+	assert.Equal(t, 15*time.Minute, throttle.Fail("acme", "user-1", "10.0.0.1"), "the third failure locks the account")
+	assert.Equal(t, 15*time.Minute, throttle.AccountLocked("acme", "user-1"))
+	assert.Zero(t, throttle.AccountLocked("other", "user-1"), "tenants are tracked separately")
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, 5*time.Minute, throttle.AccountLocked("acme", "user-1"))
+	now = now.Add(5 * time.Minute)
+	assert.Zero(t, throttle.AccountLocked("acme", "user-1"), "the lock wears off")
+
+	// Failures spread over more than the lockout are forgotten
+	throttle.Fail("acme", "user-2", "10.0.0.2")
+	throttle.Fail("acme", "user-2", "10.0.0.2")
+	now = now.Add(16 * time.Minute)
+	assert.Zero(t, throttle.Fail("acme", "user-2", "10.0.0.2"))
+
+	// Signing in forgets the account's failures
+	throttle.Fail("acme", "user-2", "10.0.0.2")
+	throttle.Succeed("acme", "user-2")
+	assert.Zero(t, throttle.Fail("acme", "user-2", "10.0.0.2"))
+
+	// Failures from one address count across accounts
+	for i, account := range []string{"a", "b", "c", "d"} {
+		assert.Zero(t, throttle.AddressBlocked("acme", "10.0.0.3"), i)
+		throttle.Fail("acme", account, "10.0.0.3")
+	}
+	throttle.Fail("acme", "e", "10.0.0.3")
+	assert.Equal(t, 15*time.Minute, throttle.AddressBlocked("acme", "10.0.0.3"))
+	assert.Zero(t, throttle.AccountLocked("acme", "e"))
+
+	var disabled *LoginThrottle
+	assert.Zero(t, disabled.Fail("acme", "user-1", "10.0.0.1"), "a nil throttle locks nothing")
+	assert.Zero(t, disabled.AccountLocked("acme", "user-1"))
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+
+	"oop/internal/config"
+)
+
+// Mailer sends plain-text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// NewMailer returns an SMTP mailer when SMTP is configured, otherwise a mailer
+// that only logs messages so local development works without a mail server.
+func NewMailer(cfg config.MailerConfig) Mailer {
+	if !cfg.Enabled() {
+		return &LogMailer{}
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+// SMTPMailer delivers emails through an SMTP server
+type SMTPMailer struct {
+	cfg config.MailerConfig
+}
+
+// Send delivers a single plain-text email
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	addr := fmt.Sprintf("%s:%d", m.cfg.Host, m.cfg.Port)
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	msg := buildMessage(m.cfg.From, to, subject, body)
+	if err := smtp.SendMail(addr, auth, m.cfg.From, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to, err)
+	}
+	return nil
+}
+
+// LogMailer writes emails to the application log instead of sending them
+type LogMailer struct{}
+
+// Send logs the email
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Printf("Email (SMTP not configured) to=%s subject=%q\n%s", to, subject, body)
+	return nil
+}
+
+// headerSanitizer strips line breaks so header values cannot inject extra headers
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// buildMessage assembles an RFC 5322 message with CRLF line endings
+func buildMessage(from, to, subject, body string) string {
+	var sb strings.Builder
+	sb.WriteString("From: " + headerSanitizer.Replace(from) + "\r\n")
+	sb.WriteString("To: " + headerSanitizer.Replace(to) + "\r\n")
+	sb.WriteString("Subject: " + headerSanitizer.Replace(subject) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return sb.String()
+}
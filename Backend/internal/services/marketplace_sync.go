@@ -0,0 +1,269 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+)
+
+// marketplacePushTimeout limits a single push to the marketplace
+const marketplacePushTimeout = 30 * time.Second
+
+// Item types of marketplace listings
+const (
+	ListingCab       = "cab"
+	ListingAccessory = "accessory"
+)
+
+// MarketplaceListing is the availability and price of an inventory item as it is
+// pushed to a marketplace
+type MarketplaceListing struct {
+	SKU       string  `json:"sku"`      // Item type and ID, e.g. cab-12
+	ItemType  string  `json:"itemType"` // cab or accessory
+	ItemID    int     `json:"itemId"`
+	Name      string  `json:"name,omitempty"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	Available bool    `json:"available"` // False once sold out or deleted
+}
+
+func newListing(itemType string, itemID int, name string, price float64, quantity int) MarketplaceListing {
+	return MarketplaceListing{
+		SKU:       fmt.Sprintf("%s-%d", itemType, itemID),
+		ItemType:  itemType,
+		ItemID:    itemID,
+		Name:      name,
+		Price:     price,
+		Quantity:  quantity,
+		Available: quantity > 0,
+	}
+}
+
+// CabListing is the listing of a cab
+func CabListing(cab models.MultiCab) MarketplaceListing {
+	return newListing(ListingCab, cab.ID, cab.Name, cab.Price, cab.Quantity)
+}
+
+// AccessoryListing is the listing of an accessory
+func AccessoryListing(accessory models.Accessory) MarketplaceListing {
+	return newListing(ListingAccessory, accessory.ID, accessory.Name, accessory.Price, accessory.Quantity)
+}
+
+// RemovedListing is the listing of a deleted item, which is no longer available
+func RemovedListing(itemType string, itemID int) MarketplaceListing {
+	return newListing(itemType, itemID, "", 0, 0)
+}
+
+// MarketplaceBatch is a set of listings of one tenant. A full batch holds every
+// item of the tenant, so the marketplace should delist the items it leaves out.
+type MarketplaceBatch struct {
+	TenantID string               `json:"tenantId"`
+	Full     bool                 `json:"full"`
+	Listings []MarketplaceListing `json:"listings"`
+}
+
+// MarketplaceAdapter pushes listings to a marketplace or e-commerce API. An adapter
+// for another marketplace translates batches into that marketplace's API.
+type MarketplaceAdapter interface {
+	Push(ctx context.Context, batch MarketplaceBatch) error
+}
+
+// NewMarketplaceAdapter creates the adapter of the configured marketplace, or nil when none is configured
+func NewMarketplaceAdapter(cfg config.MarketplaceConfig) MarketplaceAdapter {
+	if !cfg.Enabled() {
+		return nil
+	}
+	switch cfg.Adapter {
+	case config.MarketplaceAdapterREST:
+		return &restMarketplaceAdapter{url: cfg.URL, token: cfg.Token, client: &http.Client{}}
+	}
+	return nil
+}
+
+// MarketplaceSync pushes inventory availability and prices to a marketplace. Changed
+// items are collected and pushed from a background worker every Interval, only
+// their latest state; a failed push is retried with exponential backoff up to
+// MaxRetries times, then dropped until the nightly full sync. Up to QueueSize items
+// wait while the marketplace is slow or down; changes to other items are dropped
+// rather than slowing down requests. A nil *MarketplaceSync is valid and pushes
+// nothing, so inventory handlers work without one.
+type MarketplaceSync struct {
+	adapter MarketplaceAdapter
+	cfg     config.MarketplaceConfig
+	backoff func(attempt int) time.Duration
+
+	mu      sync.Mutex
+	pending map[string]map[string]MarketplaceListing // Tenant ID, then SKU
+	queued  int
+	dropped atomic.Int64
+
+	stop chan struct{}
+	done <-chan struct{}
+	once sync.Once
+}
+
+// NewMarketplaceSync creates a sync pushing through adapter and starts its worker under workers
+func NewMarketplaceSync(workers *Supervisor, adapter MarketplaceAdapter, cfg config.MarketplaceConfig) *MarketplaceSync {
+	s := &MarketplaceSync{
+		adapter: adapter,
+		cfg:     cfg,
+		backoff: exponentialBackoff,
+		pending: make(map[string]map[string]MarketplaceListing),
+		stop:    make(chan struct{}),
+	}
+	s.done = workers.Go("Marketplace sync", s.stop, s.run)
+	return s
+}
+
+// ItemChanged queues the current listing of an item of a tenant without blocking
+func (s *MarketplaceSync) ItemChanged(tenantID string, listing MarketplaceListing) {
+	if s == nil {
+		return
+	}
+	select {
+	case <-s.stop:
+		return
+	default:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	listings := s.pending[tenantID]
+	if listings == nil {
+		listings = make(map[string]MarketplaceListing)
+		s.pending[tenantID] = listings
+	}
+	if _, queued := listings[listing.SKU]; !queued {
+		if s.queued >= s.cfg.QueueSize {
+			s.dropped.Add(1)
+			return
+		}
+		s.queued++
+	}
+	listings[listing.SKU] = listing
+}
+
+// FullSync pushes every listing of a tenant, so the marketplace catches up with
+// changes that were dropped or made outside the inventory routes
+func (s *MarketplaceSync) FullSync(tenantID string, listings []MarketplaceListing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), marketplacePushTimeout)
+	defer cancel()
+	if err := s.adapter.Push(ctx, MarketplaceBatch{TenantID: tenantID, Full: true, Listings: listings}); err != nil {
+		return fmt.Errorf("failed to push %d listings of tenant %s: %w", len(listings), tenantID, err)
+	}
+	return nil
+}
+
+// Close stops accepting changes and waits until the queued ones are pushed or ctx is done
+func (s *MarketplaceSync) Close(ctx context.Context) error {
+	s.once.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("marketplace sync did not finish pushing: %w", ctx.Err())
+	}
+}
+
+func (s *MarketplaceSync) run() {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush()
+		case <-s.stop:
+			s.flush()
+			return
+		}
+	}
+}
+
+// flush pushes the queued listings, one batch per tenant
+func (s *MarketplaceSync) flush() {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[string]map[string]MarketplaceListing)
+	s.queued = 0
+	s.mu.Unlock()
+
+	for tenantID, bySKU := range pending {
+		listings := make([]MarketplaceListing, 0, len(bySKU))
+		for _, listing := range bySKU {
+			listings = append(listings, listing)
+		}
+		sort.Slice(listings, func(i, j int) bool { return listings[i].SKU < listings[j].SKU })
+		s.deliver(MarketplaceBatch{TenantID: tenantID, Listings: listings})
+	}
+}
+
+// deliver pushes a batch, retrying failures. After shutdown has begun it retries
+// without waiting so Close is not held up by the backoff.
+func (s *MarketplaceSync) deliver(batch MarketplaceBatch) {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), marketplacePushTimeout)
+		err := s.adapter.Push(ctx, batch)
+		cancel()
+		if err == nil {
+			if dropped := s.dropped.Swap(0); dropped > 0 {
+				log.Printf("Marketplace sync queue was full, dropped %d changes until the nightly full sync", dropped)
+			}
+			return
+		}
+
+		if attempt >= s.cfg.MaxRetries {
+			log.Printf("Error pushing %d listings of tenant %s to the marketplace, giving up after %d attempts: %v", len(batch.Listings), batch.TenantID, attempt+1, err)
+			return
+		}
+		log.Printf("Error pushing %d listings of tenant %s to the marketplace (attempt %d): %v", len(batch.Listings), batch.TenantID, attempt+1, err)
+
+		select {
+		case <-time.After(s.backoff(attempt)):
+		case <-s.stop:
+		}
+	}
+}
+
+// restMarketplaceAdapter posts each batch as JSON to a generic REST endpoint
+type restMarketplaceAdapter struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (a *restMarketplaceAdapter) Push(ctx context.Context, batch MarketplaceBatch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to encode listings: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create marketplace request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach marketplace: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("marketplace answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"oop/internal/config"
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMarketplace records pushed batches and fails the first failures attempts
+type fakeMarketplace struct {
+	mu       sync.Mutex
+	batches  []MarketplaceBatch
+	attempts int
+	failures int
+}
+
+func (m *fakeMarketplace) Push(ctx context.Context, batch MarketplaceBatch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts++
+	if m.attempts <= m.failures {
+		return errors.New("marketplace unavailable")
+	}
+	m.batches = append(m.batches, batch)
+	return nil
+}
+
+func (m *fakeMarketplace) pushed() []MarketplaceBatch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.batches
+}
+
+func newTestMarketplaceSync(adapter MarketplaceAdapter, queueSize int) *MarketplaceSync {
+	syncer := NewMarketplaceSync(nil, adapter, config.MarketplaceConfig{Interval: time.Hour, QueueSize: queueSize, MaxRetries: 2})
+	syncer.backoff = func(int) time.Duration { return time.Millisecond }
+	return syncer
+}
+
+func TestMarketplaceSyncPushesLatestListings(t *testing.T) {
+	marketplace := &fakeMarketplace{failures: 1}
+	syncer := newTestMarketplaceSync(marketplace, 2)
+
+	syncer.ItemChanged("acme", CabListing(models.MultiCab{ID: 1, Name: "Scrum", Price: 100, Quantity: 2}))
+	syncer.ItemChanged("acme", CabListing(models.MultiCab{ID: 1, Name: "Scrum", Price: 120, Quantity: 0}))
+	syncer.ItemChanged("acme", RemovedListing(ListingAccessory, 7))
+	syncer.ItemChanged("acme", CabListing(models.MultiCab{ID: 2, Quantity: 1})) // Queue is full
+
+	require.NoError(t, syncer.Close(context.Background()))
+	batches := marketplace.pushed()
+	require.Len(t, batches, 1, "changes are pushed on close, after a retry")
+	assert.Equal(t, "acme", batches[0].TenantID)
+	assert.False(t, batches[0].Full)
+	require.Len(t, batches[0].Listings, 2)
+	assert.Equal(t, "accessory-7", batches[0].Listings[0].SKU)
+	assert.False(t, batches[0].Listings[0].Available)
+	assert.Equal(t, "cab-1", batches[0].Listings[1].SKU)
+	assert.Equal(t, 120.0, batches[0].Listings[1].Price, "only the latest state is pushed")
+	assert.False(t, batches[0].Listings[1].Available, "sold out items are not available")
+
+	syncer.ItemChanged("acme", CabListing(models.MultiCab{ID: 3, Quantity: 1}))
+	assert.Len(t, marketplace.pushed(), 1, "changes are refused once closed")
+
+	var disabled *MarketplaceSync
+	disabled.ItemChanged("acme", RemovedListing(ListingCab, 1)) // A nil sync pushes nothing
+}
+
+func TestRESTMarketplaceAdapter(t *testing.T) {
+	var received MarketplaceBatch
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if received.TenantID == "down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	adapter := NewMarketplaceAdapter(config.MarketplaceConfig{Adapter: config.MarketplaceAdapterREST, URL: server.URL, Token: "token"})
+	require.NotNil(t, adapter)
+	syncer := newTestMarketplaceSync(adapter, 10)
+	defer syncer.Close(context.Background())
+
+	listings := []MarketplaceListing{AccessoryListing(models.Accessory{ID: 4, Name: "Roof rack", Price: 50, Quantity: 3})}
+	require.NoError(t, syncer.FullSync("acme", listings))
+	assert.Equal(t, "Bearer token", authorization)
+	assert.True(t, received.Full)
+	assert.Equal(t, listings, received.Listings)
+
+	assert.Error(t, syncer.FullSync("down", listings))
+	assert.Nil(t, NewMarketplaceAdapter(config.MarketplaceConfig{}), "no adapter without a URL")
+}
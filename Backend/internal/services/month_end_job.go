@@ -0,0 +1,71 @@
+package services
+
+import (
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// MonthEndJob runs a task once for every month that ends while the server is up.
+// It checks every interval, and when the server starts, and hands the task the
+// month before the current one (YYYY-MM) until the task succeeds for it. Tasks
+// should skip work already done, since a restart runs them again.
+type MonthEndJob struct {
+	task     func(month string) error
+	interval time.Duration
+	now      func() time.Time
+
+	lastMonth string // The last month the task succeeded for
+	stop      chan struct{}
+	done      <-chan struct{}
+	once      sync.Once
+}
+
+// NewMonthEndJob creates a job and starts it under workers
+func NewMonthEndJob(workers *Supervisor, task func(month string) error, interval time.Duration) *MonthEndJob {
+	j := newMonthEndJob(task, interval, time.Now)
+	j.done = workers.Go("Month-end job", j.stop, j.run)
+	return j
+}
+
+func newMonthEndJob(task func(month string) error, interval time.Duration, now func() time.Time) *MonthEndJob {
+	return &MonthEndJob{task: task, interval: interval, now: now, stop: make(chan struct{})}
+}
+
+// Close stops the job, waiting for a running task to finish
+func (j *MonthEndJob) Close() {
+	j.once.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+func (j *MonthEndJob) run() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.check()
+	for {
+		select {
+		case <-ticker.C:
+			j.check()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// check runs the task for the month that ended last, unless it already succeeded
+func (j *MonthEndJob) check() {
+	now := j.now()
+	month := now.AddDate(0, 0, -now.Day()).Format("2006-01")
+	if month == j.lastMonth {
+		return
+	}
+	slog.Debug("Job started", "job", "Month-end job", "month", month)
+	if err := j.task(month); err != nil {
+		log.Printf("Month-end job for %s failed, retrying in %s: %v", month, j.interval, err)
+		return
+	}
+	slog.Debug("Job finished", "job", "Month-end job", "month", month)
+	j.lastMonth = month
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMonthEndJobRunsOncePerMonth(t *testing.T) {
+	now := time.Date(2025, 3, 1, 0, 5, 0, 0, time.Local)
+	var months []string
+	fail := true
+	job := newMonthEndJob(func(month string) error {
+		months = append(months, month)
+		if fail {
+			return errors.New("database is down")
+		}
+		return nil
+	}, time.Hour, func() time.Time { return now })
+
+	job.check()
+	fail = false
+	job.check() // Retried after a failure
+	job.check()
+	now = time.Date(2025, 3, 31, 23, 59, 0, 0, time.Local)
+	job.check()
+	now = time.Date(2025, 4, 1, 1, 0, 0, 0, time.Local)
+	job.check()
+
+	assert.Equal(t, []string{"2025-02", "2025-02", "2025-03"}, months)
+}
+
+func TestMonthEndJobRunsOnStartAndStopsOnClose(t *testing.T) {
+	ran := make(chan string, 1)
+	job := NewMonthEndJob(nil, func(month string) error {
+		ran <- month
+		return nil
+	}, time.Hour)
+
+	select {
+	case month := <-ran:
+		assert.Equal(t, time.Now().AddDate(0, 0, -time.Now().Day()).Format("2006-01"), month)
+	case <-time.After(time.Second):
+		require.Fail(t, "the job did not run when started")
+	}
+	job.Close()
+	job.Close() // Closing again is harmless
+}
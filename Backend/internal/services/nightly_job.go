@@ -0,0 +1,72 @@
+package services
+
+import (
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// NightlyJob runs a task once a day, after a given local hour. It checks every
+// interval, and when the server starts, and runs the task again at the next check
+// until it succeeds for the day.
+type NightlyJob struct {
+	name     string
+	task     func() error
+	hour     int
+	interval time.Duration
+	now      func() time.Time
+
+	lastDay string // The last day the task succeeded on
+	stop    chan struct{}
+	done    <-chan struct{}
+	once    sync.Once
+}
+
+// NewNightlyJob creates a job and starts it under workers
+func NewNightlyJob(workers *Supervisor, name string, task func() error, hour int, interval time.Duration) *NightlyJob {
+	j := newNightlyJob(name, task, hour, interval, time.Now)
+	j.done = workers.Go(name, j.stop, j.run)
+	return j
+}
+
+func newNightlyJob(name string, task func() error, hour int, interval time.Duration, now func() time.Time) *NightlyJob {
+	return &NightlyJob{name: name, task: task, hour: hour, interval: interval, now: now, stop: make(chan struct{})}
+}
+
+// Close stops the job, waiting for a running task to finish
+func (j *NightlyJob) Close() {
+	j.once.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+func (j *NightlyJob) run() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.check()
+	for {
+		select {
+		case <-ticker.C:
+			j.check()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// check runs the task once its hour has come, unless it already succeeded today
+func (j *NightlyJob) check() {
+	now := j.now()
+	day := now.Format("2006-01-02")
+	if day == j.lastDay || now.Hour() < j.hour {
+		return
+	}
+	slog.Debug("Job started", "job", j.name, "day", day)
+	if err := j.task(); err != nil {
+		log.Printf("%s for %s failed, retrying in %s: %v", j.name, day, j.interval, err)
+		return
+	}
+	slog.Debug("Job finished", "job", j.name, "day", day)
+	j.lastDay = day
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNightlyJobRunsOncePerDayAfterItsHour(t *testing.T) {
+	now := time.Date(2025, 3, 1, 1, 30, 0, 0, time.Local)
+	var days []string
+	fail := true
+	job := newNightlyJob("Test job", func() error {
+		days = append(days, now.Format("2006-01-02"))
+		if fail {
+			return errors.New("marketplace is down")
+		}
+		return nil
+	}, 2, time.Hour, func() time.Time { return now })
+
+	job.check() // Before its hour
+	now = time.Date(2025, 3, 1, 2, 0, 0, 0, time.Local)
+	job.check()
+	fail = false
+	job.check() // Retried after a failure
+	job.check()
+	now = time.Date(2025, 3, 2, 23, 0, 0, 0, time.Local)
+	job.check()
+
+	assert.Equal(t, []string{"2025-03-01", "2025-03-01", "2025-03-02"}, days)
+}
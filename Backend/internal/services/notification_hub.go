@@ -0,0 +1,92 @@
+package services
+
+import (
+	"oop/internal/models"
+	"sync"
+)
+
+// notificationBuffer is how many notifications a slow subscriber can fall behind
+// before further ones are dropped for it
+const notificationBuffer = 16
+
+// Notifier delivers the notifications published to a hub somewhere besides the
+// notification stream, such as a chat channel. Notify is called for every published
+// notification, so it must return quickly and ignore the types it does not deliver.
+type Notifier interface {
+	Notify(tenantID string, notification models.Notification)
+}
+
+// NotificationHub fans notifications out to the stream subscribers of each tenant
+// and to its notifiers. Delivery is best effort: subscribers that are not connected
+// when a notification is published never see it, so clients load the current state
+// when they connect.
+type NotificationHub struct {
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[string]map[int]subscriber // Tenant ID -> subscription ID -> subscriber
+	notifiers   []Notifier
+}
+
+type subscriber struct {
+	userID string
+	ch     chan models.Notification
+}
+
+// NewNotificationHub creates a hub without subscribers
+func NewNotificationHub() *NotificationHub {
+	return &NotificationHub{subscribers: make(map[string]map[int]subscriber)}
+}
+
+// Subscribe returns a channel receiving the notifications a user gets in a tenant and
+// a function that ends the subscription and closes the channel
+func (h *NotificationHub) Subscribe(tenantID, userID string) (<-chan models.Notification, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	id := h.nextID
+	ch := make(chan models.Notification, notificationBuffer)
+	if h.subscribers[tenantID] == nil {
+		h.subscribers[tenantID] = make(map[int]subscriber)
+	}
+	h.subscribers[tenantID][id] = subscriber{userID: userID, ch: ch}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers[tenantID], id)
+			close(ch)
+		})
+	}
+}
+
+// AddNotifier has every notification published from now on also delivered by n
+func (h *NotificationHub) AddNotifier(n Notifier) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.notifiers = append(h.notifiers, n)
+}
+
+// Publish sends a notification to the current subscribers of a tenant without
+// blocking: to every subscriber, or only to its recipients when it names any. The
+// notifiers receive every notification.
+func (h *NotificationHub) Publish(tenantID string, notification models.Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, sub := range h.subscribers[tenantID] {
+		if !notification.SentTo(sub.userID) {
+			continue
+		}
+		select {
+		case sub.ch <- notification:
+		default: // The subscriber is not keeping up; drop rather than stall the publisher
+		}
+	}
+	for _, n := range h.notifiers {
+		n.Notify(tenantID, notification)
+	}
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"oop/internal/config"
+)
+
+// objectStorageTimeout limits a single request to an object store
+const objectStorageTimeout = 60 * time.Second
+
+// unsignedPayload stands in for the body hash of signed URLs, whose body is unknown
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// objectSigner holds what differs between the AWS Signature Version 4 used by S3
+// and the GOOG4 variant Cloud Storage uses with HMAC keys
+type objectSigner struct {
+	algorithm    string // e.g. AWS4-HMAC-SHA256
+	keyPrefix    string // Prepended to the secret key to derive the signing key
+	service      string
+	terminator   string // Last element of the credential scope
+	headerPrefix string // Prefix of the date and content hash headers and query parameters
+}
+
+var (
+	s3Signer  = objectSigner{algorithm: "AWS4-HMAC-SHA256", keyPrefix: "AWS4", service: "s3", terminator: "aws4_request", headerPrefix: "x-amz-"}
+	gcsSigner = objectSigner{algorithm: "GOOG4-HMAC-SHA256", keyPrefix: "GOOG4", service: "storage", terminator: "goog4_request", headerPrefix: "x-goog-"}
+)
+
+// ObjectStorage keeps files in a bucket of an S3-compatible object store or of
+// Google Cloud Storage. Requests are signed with HMAC keys, so no provider SDK is
+// needed; download links are presigned URLs of the bucket.
+type ObjectStorage struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	pathStyle bool
+	accessKey string
+	secretKey string
+	signer    objectSigner
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewS3Storage creates a driver for the S3 bucket in cfg
+func NewS3Storage(cfg config.StorageConfig) *ObjectStorage {
+	return newObjectStorage(cfg, s3Signer)
+}
+
+// NewGCSStorage creates a driver for the Cloud Storage bucket in cfg. The access and
+// secret keys are HMAC keys of a service account.
+func NewGCSStorage(cfg config.StorageConfig) *ObjectStorage {
+	return newObjectStorage(cfg, gcsSigner)
+}
+
+func newObjectStorage(cfg config.StorageConfig, signer objectSigner) *ObjectStorage {
+	// The endpoint is validated when the configuration is loaded
+	endpoint, _ := url.Parse(cfg.Endpoint)
+	return &ObjectStorage{
+		endpoint:  endpoint,
+		bucket:    cfg.Bucket,
+		region:    cfg.Region,
+		pathStyle: cfg.PathStyle,
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		signer:    signer,
+		client:    &http.Client{Timeout: objectStorageTimeout},
+		now:       time.Now,
+	}
+}
+
+// objectURL returns the URL of key in the bucket. Its path is encoded the way it is
+// signed, which escapes more characters than net/url does.
+func (s *ObjectStorage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	base := strings.TrimRight(u.Path, "/")
+	if s.pathStyle {
+		base += "/" + s.bucket
+	} else {
+		u.Host = s.bucket + "." + u.Host
+	}
+	u.Path = base + "/" + key
+
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	u.RawPath = strings.Join(segments, "/")
+	return &u
+}
+
+// Put uploads a file, replacing the one stored under key
+func (s *ObjectStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if err := checkStorageKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the file stored under key
+func (s *ObjectStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := checkStorageKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored file: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the file stored under key
+func (s *ObjectStorage) Delete(ctx context.Context, key string) error {
+	if err := checkStorageKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, "", nil)
+	if err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// do sends a signed request for key. Responses other than 2xx are returned as
+// errors, wrapping ErrObjectNotFound for 404.
+func (s *ObjectStorage) do(ctx context.Context, method, key, contentType string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	s.sign(req, data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("storage request failed: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return nil, fmt.Errorf("storage responded %d to %s %s: %s", resp.StatusCode, method, key, strings.TrimSpace(string(body)))
+}
+
+// sign adds the date, content hash and Authorization headers to req
+func (s *ObjectStorage) sign(req *http.Request, data []byte) {
+	now := s.now().UTC()
+	stamp := now.Format("20060102T150405Z")
+	payloadHash := sha256Hex(data)
+	req.Header.Set(s.signer.headerPrefix+"date", stamp)
+	req.Header.Set(s.signer.headerPrefix+"content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, s.signer.headerPrefix) {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	signedHeaders, canonicalHeaders := canonicalizeHeaders(headers)
+
+	scope := s.scope(now)
+	canonical := strings.Join([]string{req.Method, req.URL.EscapedPath(), canonicalQuery(req.URL.Query()), canonicalHeaders, signedHeaders, payloadHash}, "\n")
+	signature := s.signature(now, stamp, scope, canonical)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.signer.algorithm, s.accessKey, scope, signedHeaders, signature))
+}
+
+// SignedURL returns a presigned GET URL of key. The download name is passed in the
+// response-content-disposition parameter, which both S3 and Cloud Storage honor.
+func (s *ObjectStorage) SignedURL(key, fileName string, expiry time.Duration) (string, error) {
+	if err := checkStorageKey(key); err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	stamp := now.Format("20060102T150405Z")
+	scope := s.scope(now)
+
+	u := s.objectURL(key)
+	prefix := queryPrefix(s.signer.headerPrefix)
+	query := url.Values{
+		prefix + "Algorithm":     {s.signer.algorithm},
+		prefix + "Credential":    {s.accessKey + "/" + scope},
+		prefix + "Date":          {stamp},
+		prefix + "Expires":       {strconv.Itoa(int(expiry.Seconds()))},
+		prefix + "SignedHeaders": {"host"},
+	}
+	if fileName != "" {
+		query.Set("response-content-disposition", ContentDisposition(fileName))
+	}
+	canonical := strings.Join([]string{http.MethodGet, u.EscapedPath(), canonicalQuery(query), "host:" + u.Host + "\n", "host", unsignedPayload}, "\n")
+	u.RawQuery = canonicalQuery(query) + "&" + prefix + "Signature=" + s.signature(now, stamp, scope, canonical)
+	return u.String(), nil
+}
+
+// scope is the credential scope of requests signed at t
+func (s *ObjectStorage) scope(t time.Time) string {
+	return strings.Join([]string{t.Format("20060102"), s.region, s.signer.service, s.signer.terminator}, "/")
+}
+
+// signature signs a canonical request with the key derived for the scope's day
+func (s *ObjectStorage) signature(t time.Time, stamp, scope, canonical string) string {
+	stringToSign := strings.Join([]string{s.signer.algorithm, stamp, scope, sha256Hex([]byte(canonical))}, "\n")
+	key := hmacSHA256([]byte(s.signer.keyPrefix+s.secretKey), t.Format("20060102"))
+	for _, part := range []string{s.region, s.signer.service, s.signer.terminator} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// queryPrefix turns a header prefix such as x-amz- into the query parameter prefix X-Amz-
+func queryPrefix(headerPrefix string) string {
+	parts := strings.Split(strings.TrimSuffix(headerPrefix, "-"), "-")
+	for i, part := range parts {
+		parts[i] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	return strings.Join(parts, "-") + "-"
+}
+
+// canonicalizeHeaders returns the signed header list and the canonical header block
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var block strings.Builder
+	for _, name := range names {
+		block.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), block.String()
+}
+
+// canonicalQuery encodes query parameters sorted by name, with spaces as %20
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
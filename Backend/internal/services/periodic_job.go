@@ -0,0 +1,59 @@
+package services
+
+import (
+	"log"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PeriodicJob runs a task when the server starts and then every interval, such as
+// a policy that is enforced on a schedule. A failed run is logged and the task is
+// tried again at the next tick.
+type PeriodicJob struct {
+	name     string
+	task     func() error
+	interval time.Duration
+
+	stop chan struct{}
+	done <-chan struct{}
+	once sync.Once
+}
+
+// NewPeriodicJob creates a job and starts it under workers
+func NewPeriodicJob(workers *Supervisor, name string, task func() error, interval time.Duration) *PeriodicJob {
+	j := &PeriodicJob{name: name, task: task, interval: interval, stop: make(chan struct{})}
+	j.done = workers.Go(name, j.stop, j.run)
+	return j
+}
+
+// Close stops the job, waiting for a running task to finish
+func (j *PeriodicJob) Close() {
+	j.once.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+func (j *PeriodicJob) run() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.runTask()
+	for {
+		select {
+		case <-ticker.C:
+			j.runTask()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+func (j *PeriodicJob) runTask() {
+	started := time.Now()
+	slog.Debug("Job started", "job", j.name)
+	if err := j.task(); err != nil {
+		log.Printf("%s failed, retrying in %s: %v", j.name, j.interval, err)
+		return
+	}
+	slog.Debug("Job finished", "job", j.name, "took", time.Since(started))
+}
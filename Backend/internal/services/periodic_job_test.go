@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriodicJobRunsUntilClosed(t *testing.T) {
+	ran := make(chan struct{}, 10)
+	job := NewPeriodicJob(nil, "Test job", func() error {
+		ran <- struct{}{}
+		return errors.New("keeps running after failures")
+	}, 10*time.Millisecond)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("the job ran %d times", i)
+		}
+	}
+	job.Close()
+	job.Close() // Safe to call twice
+
+	for len(ran) > 0 {
+		<-ran
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, ran, "no runs after Close")
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// builtinPHLocations is the location dataset used unless PH_LOCATIONS_FILE is set. It
+// covers the provinces of the main trading areas, without barangays.
+//
+//go:embed data/ph_locations.json
+var builtinPHLocations []byte
+
+// ErrUnknownLocation is returned for provinces, cities and barangays missing from the dataset
+var ErrUnknownLocation = errors.New("unknown location")
+
+// PHLocationFile is the JSON layout of a location dataset
+type PHLocationFile struct {
+	Provinces []PHProvince `json:"provinces"`
+}
+
+// PHProvince is a province, or Metro Manila, and its cities and municipalities
+type PHProvince struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Cities  []PHCity `json:"cities"`
+}
+
+// PHCity is a city or municipality with the coordinates of its center. Barangays are
+// only validated for cities that list them.
+type PHCity struct {
+	Name      string   `json:"name"`
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Barangays []string `json:"barangays,omitempty"`
+}
+
+// PHPlace is a validated location with its names as spelled in the dataset and the
+// coordinates of its city's center
+type PHPlace struct {
+	Province  string
+	City      string
+	Barangay  string
+	Latitude  float64
+	Longitude float64
+}
+
+// PHLocations validates provinces, cities and barangays against a dataset. Names match
+// regardless of case, ñ and a "City" suffix or "City of" prefix.
+type PHLocations struct {
+	provinces []PHProvince
+	byName    map[string]*PHProvince // Normalized name or alias -> province
+}
+
+// LoadPHLocations loads the dataset at path, or the built-in one when path is empty
+func LoadPHLocations(path string) (*PHLocations, error) {
+	raw := builtinPHLocations
+	if path != "" {
+		var err error
+		if raw, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read location dataset: %w", err)
+		}
+	}
+	var file PHLocationFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse location dataset: %w", err)
+	}
+	if len(file.Provinces) == 0 {
+		return nil, fmt.Errorf("location dataset has no provinces")
+	}
+	return NewPHLocations(file.Provinces), nil
+}
+
+// NewPHLocations creates a validator of the given provinces
+func NewPHLocations(provinces []PHProvince) *PHLocations {
+	sorted := append([]PHProvince(nil), provinces...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	l := &PHLocations{provinces: sorted, byName: make(map[string]*PHProvince)}
+	for i := range l.provinces {
+		province := &l.provinces[i]
+		l.byName[normalizeLocation(province.Name)] = province
+		for _, alias := range province.Aliases {
+			l.byName[normalizeLocation(alias)] = province
+		}
+	}
+	return l
+}
+
+// Provinces returns the provinces of the dataset, ordered by name
+func (l *PHLocations) Provinces() []PHProvince {
+	return l.provinces
+}
+
+// Province returns the province with a name or alias
+func (l *PHLocations) Province(name string) (*PHProvince, error) {
+	province, ok := l.byName[normalizeLocation(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: province %q", ErrUnknownLocation, strings.TrimSpace(name))
+	}
+	return province, nil
+}
+
+// Resolve validates a province, a city of it and optionally a barangay of the city,
+// returning them as spelled in the dataset with the city's coordinates. Barangays of
+// cities listing none are kept as given.
+func (l *PHLocations) Resolve(provinceName, cityName, barangay string) (PHPlace, error) {
+	province, err := l.Province(provinceName)
+	if err != nil {
+		return PHPlace{}, err
+	}
+	var city *PHCity
+	for i := range province.Cities {
+		if normalizeLocation(province.Cities[i].Name) == normalizeLocation(cityName) {
+			city = &province.Cities[i]
+			break
+		}
+	}
+	if city == nil {
+		return PHPlace{}, fmt.Errorf("%w: city %q in %s", ErrUnknownLocation, strings.TrimSpace(cityName), province.Name)
+	}
+
+	place := PHPlace{Province: province.Name, City: city.Name, Barangay: strings.Join(strings.Fields(barangay), " "), Latitude: city.Latitude, Longitude: city.Longitude}
+	if place.Barangay == "" || len(city.Barangays) == 0 {
+		return place, nil
+	}
+	for _, name := range city.Barangays {
+		if normalizeLocation(name) == normalizeLocation(barangay) {
+			place.Barangay = name
+			return place, nil
+		}
+	}
+	return PHPlace{}, fmt.Errorf("%w: barangay %q in %s", ErrUnknownLocation, place.Barangay, city.Name)
+}
+
+// normalizeLocation folds the spelling differences of place names
+func normalizeLocation(name string) string {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	name = strings.ReplaceAll(name, "ñ", "n")
+	name = strings.TrimPrefix(name, "city of ")
+	return strings.TrimSuffix(name, " city")
+}
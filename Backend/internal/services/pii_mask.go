@@ -0,0 +1,51 @@
+package services
+
+import "strings"
+
+// PIIMask replaces the hidden part of a masked value
+const PIIMask = "•••"
+
+// MaskEmail keeps the first character of the local part and the domain,
+// e.g. "juan@example.com" becomes "j•••@example.com".
+func MaskEmail(email string) string {
+	if email == "" {
+		return ""
+	}
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return PIIMask
+	}
+	return string([]rune(local)[:1]) + PIIMask + "@" + domain
+}
+
+// MaskPhone keeps the international prefix and the last four digits,
+// e.g. "+639171234567" becomes "+63•••4567".
+func MaskPhone(phone string) string {
+	digits := make([]rune, 0, len(phone))
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) == 0 {
+		return ""
+	}
+	if len(digits) <= 4 {
+		return PIIMask
+	}
+
+	prefix := ""
+	if strings.HasPrefix(strings.TrimSpace(phone), "+") && len(digits) > 6 {
+		prefix = "+" + string(digits[:2])
+	}
+	return prefix + PIIMask + string(digits[len(digits)-4:])
+}
+
+// MaskDateOfBirth hides the year of a YYYY-MM-DD date of birth, keeping the day for
+// greetings, e.g. "1990-04-12" becomes "•••-04-12".
+func MaskDateOfBirth(date string) string {
+	if len(date) != len("2006-01-02") {
+		return ""
+	}
+	return PIIMask + date[4:]
+}
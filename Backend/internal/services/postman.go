@@ -0,0 +1,273 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PostmanSchema is the collection format produced by BuildPostmanCollection
+const PostmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanCollection is a Postman v2.1 collection
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanInfo describes a collection
+type PostmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem is either a folder (Item set) or a single request (Request set)
+type PostmanItem struct {
+	Name    string          `json:"name"`
+	Item    []PostmanItem   `json:"item,omitempty"`
+	Request *PostmanRequest `json:"request,omitempty"`
+}
+
+// PostmanRequest is a single request in a collection
+type PostmanRequest struct {
+	Method      string            `json:"method"`
+	Description string            `json:"description,omitempty"`
+	Header      []PostmanVariable `json:"header"`
+	URL         PostmanURL        `json:"url"`
+	Body        *PostmanBody      `json:"body,omitempty"`
+}
+
+// PostmanURL is the target of a request. Path parameters use the :name syntax.
+type PostmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []PostmanVariable `json:"query,omitempty"`
+	Variable []PostmanVariable `json:"variable,omitempty"`
+}
+
+// PostmanBody is a raw request body
+type PostmanBody struct {
+	Mode    string                 `json:"mode"`
+	Raw     string                 `json:"raw"`
+	Options map[string]interface{} `json:"options,omitempty"`
+}
+
+// PostmanVariable is a key/value pair used for variables, headers and query parameters
+type PostmanVariable struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type swaggerSpec struct {
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+	} `json:"info"`
+	Host        string                                `json:"host"`
+	BasePath    string                                `json:"basePath"`
+	Schemes     []string                              `json:"schemes"`
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]swaggerSchema              `json:"definitions"`
+}
+
+type swaggerOperation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description"`
+	Tags        []string              `json:"tags"`
+	Parameters  []swaggerParameter    `json:"parameters"`
+	Security    []map[string][]string `json:"security"`
+}
+
+type swaggerParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description"`
+	Required    bool           `json:"required"`
+	Schema      *swaggerSchema `json:"schema"`
+}
+
+type swaggerSchema struct {
+	Ref        string                   `json:"$ref"`
+	Type       string                   `json:"type"`
+	Properties map[string]swaggerSchema `json:"properties"`
+}
+
+// swaggerMethods are the operation keys of a path item, in the order requests are listed
+var swaggerMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// BuildPostmanCollection converts a Swagger 2.0 spec into a Postman collection with
+// one folder per tag. Requests target the {{baseUrl}} variable, which defaults to
+// origin followed by the spec's base path; an empty origin falls back to the spec's host.
+// Secured requests send "Bearer {{token}}" in the Authorization header.
+func BuildPostmanCollection(specJSON []byte, origin string) (*PostmanCollection, error) {
+	var spec swaggerSpec
+	if err := json.Unmarshal(specJSON, &spec); err != nil {
+		return nil, fmt.Errorf("could not parse API spec: %w", err)
+	}
+
+	if origin == "" {
+		scheme := "http"
+		if len(spec.Schemes) > 0 {
+			scheme = spec.Schemes[0]
+		}
+		origin = scheme + "://" + spec.Host
+	}
+
+	collection := &PostmanCollection{
+		Info: PostmanInfo{
+			Name:        spec.Info.Title,
+			Description: spec.Info.Description,
+			Version:     spec.Info.Version,
+			Schema:      PostmanSchema,
+		},
+		Item: []PostmanItem{},
+		Variable: []PostmanVariable{
+			{Key: "baseUrl", Value: strings.TrimRight(origin, "/") + spec.BasePath},
+			{Key: "token", Value: "", Description: "JWT returned by POST /users/login"},
+		},
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	folders := map[string]*PostmanItem{}
+	for _, path := range paths {
+		for _, method := range swaggerMethods {
+			raw, ok := spec.Paths[path][method]
+			if !ok {
+				continue
+			}
+			var op swaggerOperation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("could not parse %s %s: %w", strings.ToUpper(method), path, err)
+			}
+
+			tag := "Other"
+			if len(op.Tags) > 0 {
+				tag = op.Tags[0]
+			}
+			folder, ok := folders[tag]
+			if !ok {
+				folder = &PostmanItem{Name: tag}
+				folders[tag] = folder
+			}
+			folder.Item = append(folder.Item, buildPostmanItem(&spec, path, method, &op))
+		}
+	}
+
+	tags := make([]string, 0, len(folders))
+	for tag := range folders {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		collection.Item = append(collection.Item, *folders[tag])
+	}
+
+	return collection, nil
+}
+
+// buildPostmanItem converts a single Swagger operation into a Postman request
+func buildPostmanItem(spec *swaggerSpec, path, method string, op *swaggerOperation) PostmanItem {
+	name := op.Summary
+	if name == "" {
+		name = strings.ToUpper(method) + " " + path
+	}
+
+	request := &PostmanRequest{
+		Method:      strings.ToUpper(method),
+		Description: op.Description,
+		Header:      []PostmanVariable{},
+		URL:         PostmanURL{Host: []string{"{{baseUrl}}"}, Path: []string{}},
+	}
+	if len(op.Security) > 0 {
+		request.Header = append(request.Header, PostmanVariable{Key: "Authorization", Value: "Bearer {{token}}"})
+	}
+
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segment = ":" + strings.Trim(segment, "{}")
+		}
+		request.URL.Path = append(request.URL.Path, segment)
+	}
+
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			request.URL.Variable = append(request.URL.Variable, PostmanVariable{Key: param.Name, Description: param.Description})
+		case "query":
+			// Optional query parameters are listed but left disabled
+			request.URL.Query = append(request.URL.Query, PostmanVariable{Key: param.Name, Description: param.Description, Disabled: !param.Required})
+		case "header":
+			request.Header = append(request.Header, PostmanVariable{Key: param.Name, Description: param.Description})
+		case "body":
+			sample, _ := json.MarshalIndent(sampleValue(spec, param.Schema, 0), "", "  ")
+			request.Header = append(request.Header, PostmanVariable{Key: "Content-Type", Value: "application/json"})
+			request.Body = &PostmanBody{
+				Mode:    "raw",
+				Raw:     string(sample),
+				Options: map[string]interface{}{"raw": map[string]string{"language": "json"}},
+			}
+		}
+	}
+
+	request.URL.Raw = "{{baseUrl}}/" + strings.Join(request.URL.Path, "/")
+	var enabled []string
+	for _, q := range request.URL.Query {
+		if !q.Disabled {
+			enabled = append(enabled, q.Key+"=")
+		}
+	}
+	if len(enabled) > 0 {
+		request.URL.Raw += "?" + strings.Join(enabled, "&")
+	}
+
+	return PostmanItem{Name: name, Request: request}
+}
+
+// sampleValue builds a placeholder JSON value for a schema so request bodies
+// show every field. Nested references are expanded a few levels deep.
+func sampleValue(spec *swaggerSpec, schema *swaggerSchema, depth int) interface{} {
+	if schema == nil || depth > 3 {
+		return map[string]interface{}{}
+	}
+	if schema.Ref != "" {
+		definition, ok := spec.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+		if !ok {
+			return map[string]interface{}{}
+		}
+		return sampleValue(spec, &definition, depth+1)
+	}
+
+	switch schema.Type {
+	case "string":
+		return ""
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "array":
+		return []interface{}{}
+	}
+
+	fields := map[string]interface{}{}
+	for name, property := range schema.Properties {
+		property := property
+		fields[name] = sampleValue(spec, &property, depth+1)
+	}
+	return fields
+}
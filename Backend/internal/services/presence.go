@@ -0,0 +1,74 @@
+package services
+
+import (
+	"oop/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Presence remembers when each user of each tenant last made an authenticated request
+// or sent a heartbeat, and at which branch, to tell who is online. It is kept in
+// memory, so it starts empty when the server restarts and is per instance.
+type Presence struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]map[string]models.UserPresence // By tenant, then user ID
+}
+
+// NewPresence creates a tracker listing users as online for window after they were last seen
+func NewPresence(window time.Duration) *Presence {
+	return &Presence{window: window, now: time.Now, tenants: make(map[string]map[string]models.UserPresence)}
+}
+
+// Window returns for how long after they were last seen users are online
+func (p *Presence) Window() time.Duration {
+	return p.window
+}
+
+// Seen records that a user is active now. A non-empty branch replaces the one the
+// user was last seen at; an empty one keeps it.
+func (p *Presence) Seen(tenantID, userID, role, branch string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	users, ok := p.tenants[tenantID]
+	if !ok {
+		users = make(map[string]models.UserPresence)
+		p.tenants[tenantID] = users
+	}
+	presence := users[userID]
+	presence.UserID = userID
+	presence.Role = role
+	if branch != "" {
+		presence.Branch = branch
+	}
+	presence.LastSeenAt = p.now()
+	users[userID] = presence
+}
+
+// Online returns the users of a tenant seen within the window, most recently seen
+// first. Users seen before the window are forgotten, along with their branch.
+func (p *Presence) Online(tenantID string) []models.UserPresence {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	since := p.now().Add(-p.window)
+	online := []models.UserPresence{}
+	for userID, presence := range p.tenants[tenantID] {
+		if presence.LastSeenAt.Before(since) {
+			delete(p.tenants[tenantID], userID)
+			continue
+		}
+		online = append(online, presence)
+	}
+	sort.Slice(online, func(i, j int) bool {
+		if online[i].LastSeenAt.Equal(online[j].LastSeenAt) {
+			return online[i].UserID < online[j].UserID
+		}
+		return online[i].LastSeenAt.After(online[j].LastSeenAt)
+	})
+	return online
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresence(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	presence := NewPresence(5 * time.Minute)
+	presence.now = func() time.Time { return now }
+
+	presence.Seen("acme", "user-1", "staff", "MNL")
+	presence.Seen("acme", "user-2", "admin", "")
+	presence.Seen("other", "user-9", "staff", "CEB")
+
+	now = now.Add(2 * time.Minute)
+	presence.Seen("acme", "user-1", "staff", "") // A request keeps the branch of the last heartbeat
+
+	online := presence.Online("acme")
+	require.Len(t, online, 2, "tenants are tracked separately")
+	assert.Equal(t, "user-1", online[0].UserID, "most recently seen first")
+	assert.Equal(t, "MNL", online[0].Branch)
+	assert.Equal(t, now, online[0].LastSeenAt)
+	assert.Equal(t, "", online[1].Branch)
+
+	now = now.Add(4 * time.Minute)
+	online = presence.Online("acme")
+	require.Len(t, online, 1, "user-2 was last seen 6 minutes ago")
+	assert.Equal(t, "user-1", online[0].UserID)
+
+	// Users seen again after going offline are online again
+	presence.Seen("acme", "user-2", "admin", "")
+	online = presence.Online("acme")
+	require.Len(t, online, 2)
+	assert.Equal(t, "user-2", online[0].UserID)
+	assert.Empty(t, online[0].Branch)
+}
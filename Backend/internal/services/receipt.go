@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Logos may be JPEG
+	_ "image/png"  // or PNG
+	"log"
+	"math"
+	"strings"
+
+	"oop/internal/models"
+)
+
+// Size of 58mm thermal paper in the default font
+const (
+	ThermalReceiptChars = 32  // Characters per line
+	ThermalReceiptDots  = 384 // Printable dots per line
+)
+
+// Receipt is a sale laid out for printing
+type Receipt struct {
+	Template models.DocumentTemplate
+	Logo     []byte // PNG or JPEG image; printed by ESC/POS output only
+	SaleID   string
+	ORNumber string // Official receipt number issued to the sale, if any
+	SaleDate string
+	Customer string
+	Lines    []ReceiptLine
+	Total    float64
+	TaxType  string  // One of the models.Tax* constants; printed by PDF output only
+	VAT      float64 // VAT included in Total when TaxType is models.TaxVatable
+}
+
+// ReceiptLine is one item of a receipt
+type ReceiptLine struct {
+	Description string
+	Quantity    int
+	UnitPrice   float64
+	Subtotal    float64
+}
+
+// receiptRow is one printed row with its styling. Plain text output only
+// honors the alignment.
+type receiptRow struct {
+	text     string
+	centered bool
+	bold     bool
+	tall     bool // Double height
+}
+
+// RenderTextReceipt lays out a receipt as plain text for thermal paper
+func RenderTextReceipt(r Receipt) string {
+	var b strings.Builder
+	for _, row := range receiptRows(r) {
+		text := row.text
+		if row.centered {
+			text = center(text, ThermalReceiptChars)
+		}
+		b.WriteString(strings.TrimRight(text, " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ESC/POS commands
+var (
+	escposInit      = []byte{0x1b, '@'}
+	escposLeft      = []byte{0x1b, 'a', 0}
+	escposCenter    = []byte{0x1b, 'a', 1}
+	escposBoldOn    = []byte{0x1b, 'E', 1}
+	escposBoldOff   = []byte{0x1b, 'E', 0}
+	escposTallOn    = []byte{0x1d, '!', 0x01}
+	escposTallOff   = []byte{0x1d, '!', 0x00}
+	escposFeedLines = []byte{0x1b, 'd', 4}
+	escposCut       = []byte{0x1d, 'V', 'B', 0} // Feed to the cutter and cut partially
+)
+
+// RenderESCPOSReceipt renders a receipt as an ESC/POS byte stream for 58mm
+// thermal printers, with the logo printed as a raster image above the header
+func RenderESCPOSReceipt(r Receipt) []byte {
+	var b bytes.Buffer
+	b.Write(escposInit)
+
+	if len(r.Logo) > 0 {
+		raster, err := escposRaster(r.Logo, ThermalReceiptDots)
+		if err != nil {
+			log.Printf("Skipping receipt logo: %v", err)
+		} else {
+			b.Write(escposCenter)
+			b.Write(raster)
+		}
+	}
+
+	for _, row := range receiptRows(r) {
+		if row.centered {
+			b.Write(escposCenter)
+		} else {
+			b.Write(escposLeft)
+		}
+		if row.bold {
+			b.Write(escposBoldOn)
+		}
+		if row.tall {
+			b.Write(escposTallOn)
+		}
+		b.WriteString(asciiOnly(row.text))
+		b.WriteByte('\n')
+		if row.tall {
+			b.Write(escposTallOff)
+		}
+		if row.bold {
+			b.Write(escposBoldOff)
+		}
+	}
+
+	b.Write(escposLeft)
+	b.Write(escposFeedLines)
+	b.Write(escposCut)
+	return b.Bytes()
+}
+
+// receiptRows lays out the branding, sale details, items and total
+func receiptRows(r Receipt) []receiptRow {
+	width := ThermalReceiptChars
+	rule := receiptRow{text: strings.Repeat("-", width)}
+	rows := []receiptRow{}
+	addWrapped := func(text string, centered, bold bool) {
+		for _, line := range wrapText(text, width) {
+			rows = append(rows, receiptRow{text: line, centered: centered, bold: bold})
+		}
+	}
+
+	for _, line := range wrapText(strings.ToUpper(r.Template.CompanyName), width) {
+		rows = append(rows, receiptRow{text: line, centered: true, bold: true, tall: true})
+	}
+	addWrapped(r.Template.Address, true, false)
+	addWrapped(r.Template.HeaderText, true, false)
+	if len(rows) > 0 {
+		rows = append(rows, rule)
+	}
+
+	if r.ORNumber != "" {
+		rows = append(rows, receiptRow{text: "OR No. " + r.ORNumber, bold: true})
+	}
+	addWrapped("Sale: "+r.SaleID, false, false)
+	addWrapped("Date: "+r.SaleDate, false, false)
+	if r.Customer != "" {
+		addWrapped("Customer: "+r.Customer, false, false)
+	}
+	rows = append(rows, rule)
+
+	for _, line := range r.Lines {
+		addWrapped(line.Description, false, false)
+		rows = append(rows, receiptRow{text: columns(fmt.Sprintf("  %d x %s", line.Quantity, FormatAmount(line.UnitPrice)), FormatAmount(line.Subtotal), width)})
+	}
+	rows = append(rows, rule)
+	rows = append(rows, receiptRow{text: columns("TOTAL", FormatAmount(r.Total), width), bold: true})
+
+	if r.Template.FooterText != "" || r.Template.Terms != "" {
+		rows = append(rows, rule)
+	}
+	addWrapped(r.Template.FooterText, true, false)
+	addWrapped(r.Template.Terms, false, false)
+	return rows
+}
+
+// FormatAmount formats a peso amount with thousands separators, e.g. 1,500.00
+func FormatAmount(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := fmt.Sprintf("%d", cents/100)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("%s%s.%02d", sign, whole, cents%100)
+}
+
+// columns puts left and right on one line of width, dropping the gap when they do not fit
+func columns(left, right string, width int) string {
+	gap := width - len([]rune(left)) - len([]rune(right))
+	if gap < 1 {
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+// center pads text to sit in the middle of width
+func center(text string, width int) string {
+	padding := (width - len([]rune(text))) / 2
+	if padding <= 0 {
+		return text
+	}
+	return strings.Repeat(" ", padding) + text
+}
+
+// wrapText breaks text into lines of at most width characters, between words
+// where possible. Blank lines of the input are kept.
+func wrapText(text string, width int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	lines := []string{}
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := []rune{}
+		for _, word := range strings.Fields(paragraph) {
+			runes := []rune(word)
+			for len(runes) > width {
+				if len(line) > 0 {
+					lines = append(lines, string(line))
+					line = line[:0]
+				}
+				lines = append(lines, string(runes[:width]))
+				runes = runes[width:]
+			}
+			if len(line) > 0 && len(line)+1+len(runes) > width {
+				lines = append(lines, string(line))
+				line = line[:0]
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			line = append(line, runes...)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// asciiReplacements spell out the characters thermal printers commonly lack
+var asciiReplacements = map[rune]string{
+	'ñ': "n", 'Ñ': "N", '₱': "P", '“': "\"", '”': "\"", '‘': "'", '’': "'", '–': "-", '—': "-", '•': "*",
+}
+
+// asciiOnly makes text printable in the printer's default code page
+func asciiOnly(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case asciiReplacements[r] != "":
+			b.WriteString(asciiReplacements[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// escposRaster converts an image to a GS v 0 raster command at most maxDots
+// wide and tall. Transparent pixels print as paper; darker pixels print black.
+func escposRaster(data []byte, maxDots int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode logo: %w", err)
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, fmt.Errorf("logo is empty")
+	}
+
+	scale := math.Min(1, math.Min(float64(maxDots)/float64(bounds.Dx()), float64(maxDots)/float64(bounds.Dy())))
+	width := int(math.Max(1, math.Round(float64(bounds.Dx())*scale)))
+	height := int(math.Max(1, math.Round(float64(bounds.Dy())*scale)))
+	rowBytes := (width + 7) / 8
+
+	raster := []byte{0x1d, 'v', '0', 0, byte(rowBytes), byte(rowBytes >> 8), byte(height), byte(height >> 8)}
+	for y := 0; y < height; y++ {
+		row := make([]byte, rowBytes)
+		for x := 0; x < width; x++ {
+			src := img.At(bounds.Min.X+x*bounds.Dx()/width, bounds.Min.Y+y*bounds.Dy()/height)
+			r, g, b, a := src.RGBA()
+			// Composite over white paper before thresholding
+			luminance := (299*r + 587*g + 114*b) / 1000
+			if luminance+(0xffff-a) < 0x8000 {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		raster = append(raster, row...)
+	}
+	return raster, nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"oop/internal/models"
+)
+
+// ReceiptPage is the paper a PDF receipt is laid out on, in PDF points (1/72 inch)
+type ReceiptPage struct {
+	Width, Height float64
+	Margin        float64 // On every side
+}
+
+// Papers PDF receipts are laid out for
+const (
+	ReceiptPageA5         = "a5"          // 148mm x 210mm
+	ReceiptPageHalfLetter = "half-letter" // 5.5" x 8.5", a US Letter sheet cut in two
+)
+
+// ReceiptPages are the supported receipt papers by name
+var ReceiptPages = map[string]ReceiptPage{
+	ReceiptPageA5:         {Width: 419.53, Height: 595.28, Margin: 28},
+	ReceiptPageHalfLetter: {Width: 396, Height: 612, Margin: 28},
+}
+
+// Type sizes and spacing of a PDF receipt, in points
+const (
+	receiptCompanySize = 14
+	receiptTitleSize   = 11
+	receiptTextSize    = 9
+	receiptSmallSize   = 7
+	receiptLineGap     = 4 // Between the baselines of two rows, on top of the type size
+)
+
+// Columns of the items table, as fractions of the printable width: the right edges
+// of the quantity, unit price and amount columns. The description fills the rest.
+const (
+	receiptQuantityRight  = 0.58
+	receiptUnitPriceRight = 0.79
+	receiptAmountRight    = 1.0
+)
+
+// receiptTaxLabels name the sales of each tax type in the totals
+var receiptTaxLabels = map[string]string{
+	models.TaxVatable:   "VATable sales",
+	models.TaxExempt:    "VAT-exempt sales",
+	models.TaxZeroRated: "Zero-rated sales",
+}
+
+// pdfReceipt lays out a receipt top to bottom, starting a new page when a row does
+// not fit on the current one
+type pdfReceipt struct {
+	page    ReceiptPage
+	pages   []string
+	content strings.Builder
+	y       float64 // Baseline of the last row drawn
+}
+
+// RenderPDFReceipt lays out a receipt on as many pages of page as it needs: the
+// branding of the document template, the sale details, the items with their unit
+// prices, the totals with the tax, then the footer and terms. The logo is left out.
+func RenderPDFReceipt(page ReceiptPage, r Receipt) []byte {
+	p := &pdfReceipt{page: page, y: page.Height - page.Margin}
+	width := page.Width - 2*page.Margin
+
+	for _, line := range wrapPDFText(strings.ToUpper(r.Template.CompanyName), receiptCompanySize, width) {
+		p.centered("F2", receiptCompanySize, line)
+	}
+	for _, text := range []string{r.Template.Address, r.Template.HeaderText} {
+		for _, line := range wrapPDFText(text, receiptTextSize, width) {
+			p.centered("F1", receiptTextSize, line)
+		}
+	}
+	if r.Template.CompanyName != "" || r.Template.Address != "" || r.Template.HeaderText != "" {
+		p.rule(1)
+	}
+
+	p.row(receiptTitleSize)
+	writeText(&p.content, "F2", receiptTitleSize, page.Margin, p.y, "SALES RECEIPT")
+	if r.ORNumber != "" {
+		p.right("F2", receiptTitleSize, width, "OR No. "+asciiOnly(r.ORNumber))
+	}
+	p.detail("Sale", r.SaleID, width)
+	p.detail("Date", r.SaleDate, width)
+	if r.Customer != "" {
+		p.detail("Customer", r.Customer, width)
+	}
+
+	p.row(receiptTextSize)
+	p.rule(0.5)
+	p.tableRow("F2", width, "Description", "Qty", "Unit price", "Amount")
+	p.rule(0.5)
+	descriptionWidth := width*receiptQuantityRight - textWidth("0000", receiptTextSize) - receiptTextSize
+	for _, line := range r.Lines {
+		description := wrapPDFText(line.Description, receiptTextSize, descriptionWidth)
+		if len(description) == 0 {
+			description = []string{""}
+		}
+		p.tableRow("F1", width, description[0], fmt.Sprintf("%d", line.Quantity), FormatAmount(line.UnitPrice), FormatAmount(line.Subtotal))
+		for _, more := range description[1:] {
+			p.row(receiptTextSize)
+			writeText(&p.content, "F1", receiptTextSize, page.Margin, p.y, more)
+		}
+	}
+	p.rule(0.5)
+
+	switch r.TaxType {
+	case models.TaxVatable:
+		p.total("F1", receiptTextSize, width, receiptTaxLabels[r.TaxType], r.Total-r.VAT)
+		p.total("F1", receiptTextSize, width, fmt.Sprintf("VAT (%g%%)", models.VATRate*100), r.VAT)
+	case models.TaxExempt, models.TaxZeroRated:
+		p.total("F1", receiptTextSize, width, receiptTaxLabels[r.TaxType], r.Total)
+	}
+	p.total("F2", receiptTitleSize, width, "TOTAL PHP", r.Total)
+
+	if r.Template.FooterText != "" || r.Template.Terms != "" {
+		p.row(receiptTextSize)
+	}
+	for _, line := range wrapPDFText(r.Template.FooterText, receiptTextSize, width) {
+		p.centered("F1", receiptTextSize, line)
+	}
+	for _, line := range wrapPDFText(r.Template.Terms, receiptSmallSize, width) {
+		p.row(receiptSmallSize)
+		writeText(&p.content, "F1", receiptSmallSize, page.Margin, p.y, line)
+	}
+
+	p.pages = append(p.pages, p.content.String())
+	return writePDF(page.Width, page.Height, p.pages)
+}
+
+// row moves down to the baseline of a row of type size, on a new page when the
+// row would run into the bottom margin
+func (p *pdfReceipt) row(size float64) {
+	p.y -= size + receiptLineGap
+	if p.y < p.page.Margin {
+		p.pages = append(p.pages, p.content.String())
+		p.content.Reset()
+		p.y = p.page.Height - p.page.Margin - size
+	}
+}
+
+// centered draws a row of text in the middle of the page
+func (p *pdfReceipt) centered(font string, size float64, text string) {
+	p.row(size)
+	writeText(&p.content, font, size, (p.page.Width-textWidth(text, size))/2, p.y, text)
+}
+
+// right draws text on the current row against the right margin
+func (p *pdfReceipt) right(font string, size, width float64, text string) {
+	writeText(&p.content, font, size, p.page.Margin+width-textWidth(text, size), p.y, text)
+}
+
+// detail draws a row with a bold label and its value
+func (p *pdfReceipt) detail(label, value string, width float64) {
+	p.row(receiptTextSize)
+	label += ":"
+	writeText(&p.content, "F2", receiptTextSize, p.page.Margin, p.y, label)
+	indent := textWidth(label, receiptTextSize) + receiptTextSize/2
+	writeText(&p.content, "F1", receiptTextSize, p.page.Margin+indent, p.y, fitText(asciiOnly(value), receiptTextSize, width-indent))
+}
+
+// tableRow draws a row of the items table with the numbers right-aligned in their columns
+func (p *pdfReceipt) tableRow(font string, width float64, description, quantity, unitPrice, amount string) {
+	p.row(receiptTextSize)
+	writeText(&p.content, font, receiptTextSize, p.page.Margin, p.y, description)
+	for _, cell := range []struct {
+		right float64
+		text  string
+	}{{receiptQuantityRight, quantity}, {receiptUnitPriceRight, unitPrice}, {receiptAmountRight, amount}} {
+		writeText(&p.content, font, receiptTextSize, p.page.Margin+width*cell.right-textWidth(cell.text, receiptTextSize), p.y, cell.text)
+	}
+}
+
+// total draws a row of the totals, labelled under the unit prices with the amount
+// in the amount column
+func (p *pdfReceipt) total(font string, size, width float64, label string, amount float64) {
+	p.row(size)
+	writeText(&p.content, font, size, p.page.Margin+width*receiptUnitPriceRight-textWidth(label, size), p.y, label)
+	p.right(font, size, width, FormatAmount(amount))
+}
+
+// rule draws a horizontal line across the page just below the current row
+func (p *pdfReceipt) rule(lineWidth float64) {
+	y := p.y - receiptLineGap/2
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", lineWidth, p.page.Margin, y, p.page.Width-p.page.Margin, y)
+}
+
+// wrapPDFText breaks text into printable lines that fit in width at type size
+func wrapPDFText(text string, size, width float64) []string {
+	chars := int(width / textWidth("n", size)) // Lines wider than the average are shortened below
+	lines := []string{}
+	for _, line := range wrapText(asciiOnly(text), max(chars, 1)) {
+		lines = append(lines, fitText(line, size, width))
+	}
+	return lines
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReceipt() Receipt {
+	return Receipt{
+		Template: models.DocumentTemplate{
+			CompanyName: "Surplus Motors",
+			Address:     "88 Osmeña Blvd, Cebu City",
+			FooterText:  "Thank you!",
+			Terms:       "Items sold are not returnable after seven days from purchase.",
+		},
+		SaleID:   "sale_42",
+		SaleDate: "2025-03-03",
+		Customer: "Juan Dela Cruz",
+		Lines: []ReceiptLine{
+			{Description: "Suzuki Multicab Scrum 4x4", Quantity: 1, UnitPrice: 250000, Subtotal: 250000},
+			{Description: "Roof rack", Quantity: 2, UnitPrice: 1500, Subtotal: 3000},
+		},
+		Total: 253000,
+	}
+}
+
+func TestRenderTextReceipt(t *testing.T) {
+	want := strings.Join([]string{
+		"         SURPLUS MOTORS",
+		"   88 Osmeña Blvd, Cebu City",
+		"--------------------------------",
+		"Sale: sale_42",
+		"Date: 2025-03-03",
+		"Customer: Juan Dela Cruz",
+		"--------------------------------",
+		"Suzuki Multicab Scrum 4x4",
+		"  1 x 250,000.00      250,000.00",
+		"Roof rack",
+		"  2 x 1,500.00          3,000.00",
+		"--------------------------------",
+		"TOTAL                 253,000.00",
+		"--------------------------------",
+		"           Thank you!",
+		"Items sold are not returnable",
+		"after seven days from purchase.",
+	}, "\n") + "\n"
+
+	assert.Equal(t, want, RenderTextReceipt(testReceipt()))
+}
+
+func TestRenderTextReceiptWithoutTemplate(t *testing.T) {
+	receipt := testReceipt()
+	receipt.Template = models.DocumentTemplate{}
+
+	text := RenderTextReceipt(receipt)
+	assert.True(t, strings.HasPrefix(text, "Sale: sale_42\n"), "no branding rows without a template")
+	assert.True(t, strings.HasSuffix(text, "TOTAL                 253,000.00\n"))
+}
+
+func TestRenderTextReceiptORNumber(t *testing.T) {
+	receipt := testReceipt()
+	receipt.Template = models.DocumentTemplate{}
+	receipt.ORNumber = "OR-000123"
+
+	assert.True(t, strings.HasPrefix(RenderTextReceipt(receipt), "OR No. OR-000123\nSale: sale_42\n"))
+}
+
+func TestRenderESCPOSReceipt(t *testing.T) {
+	stream := RenderESCPOSReceipt(testReceipt())
+
+	assert.True(t, bytes.HasPrefix(stream, escposInit))
+	assert.True(t, bytes.HasSuffix(stream, escposCut))
+	assert.Contains(t, string(stream), string(escposTallOn)+"SURPLUS MOTORS\n"+string(escposTallOff))
+	assert.Contains(t, string(stream), "88 Osmena Blvd, Cebu City\n", "text is limited to ASCII")
+	assert.Contains(t, string(stream), string(escposBoldOn)+"TOTAL                 253,000.00\n")
+}
+
+func TestRenderESCPOSReceiptLogo(t *testing.T) {
+	// A 20x2 logo, black on the left half and transparent on the right
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 10; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	receipt := testReceipt()
+	receipt.Logo = buf.Bytes()
+	stream := RenderESCPOSReceipt(receipt)
+
+	// 3 bytes per row, 2 rows
+	raster := []byte{0x1d, 'v', '0', 0, 3, 0, 2, 0, 0xff, 0xc0, 0x00, 0xff, 0xc0, 0x00}
+	assert.True(t, bytes.HasPrefix(stream, append(append(append([]byte{}, escposInit...), escposCenter...), raster...)))
+
+	// Logos that cannot be decoded are left out
+	receipt.Logo = []byte("not an image")
+	assert.Equal(t, RenderESCPOSReceipt(testReceipt()), RenderESCPOSReceipt(receipt))
+}
+
+func TestEscposRasterScalesToPaper(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 768, 100))))
+
+	raster, err := escposRaster(buf.Bytes(), ThermalReceiptDots)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x1d, 'v', '0', 0, 48, 0, 50, 0}, raster[:8], "384 dots (48 bytes) wide, 50 rows")
+	assert.Len(t, raster, 8+48*50)
+}
+
+func TestFormatAmount(t *testing.T) {
+	for amount, want := range map[float64]string{
+		0:          "0.00",
+		999.5:      "999.50",
+		1500:       "1,500.00",
+		1234567.89: "1,234,567.89",
+		-2500.1:    "-2,500.10",
+	} {
+		assert.Equal(t, want, FormatAmount(amount))
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	assert.Equal(t, []string{"Roof rack and", "side mirrors"}, wrapText("Roof rack and side mirrors", 13))
+	assert.Equal(t, []string{"ABCDE", "FGH", "tail"}, wrapText("ABCDEFGH tail", 5), "long words are broken")
+	assert.Equal(t, []string{"Line one", "", "Line three"}, wrapText("Line one\n\nLine three", 32))
+	assert.Nil(t, wrapText("   ", 32))
+}
+
+func TestRenderPDFReceipt(t *testing.T) {
+	receipt := testReceipt()
+	receipt.ORNumber = "OR-000123"
+	receipt.TaxType = models.TaxVatable
+	receipt.VAT = 27107.14
+
+	pdf := RenderPDFReceipt(ReceiptPages[ReceiptPageA5], receipt)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	document := string(pdf)
+	assert.Contains(t, document, "/Count 1")
+	assert.Contains(t, document, "/MediaBox [0 0 419.53 595.28]")
+	for _, text := range []string{
+		"SURPLUS MOTORS", "88 Osmena Blvd, Cebu City", "SALES RECEIPT", "OR No. OR-000123", "sale_42", "Juan Dela Cruz",
+		"Suzuki Multicab Scrum 4x4", "Roof rack", "1,500.00", "3,000.00",
+		"VATable sales", "225,892.86", "VAT \\(12%\\)", "27,107.14", "TOTAL PHP", "253,000.00", "Thank you!",
+	} {
+		assert.Contains(t, document, "("+text+") Tj", text)
+	}
+}
+
+func TestRenderPDFReceiptPages(t *testing.T) {
+	receipt := testReceipt()
+	receipt.TaxType = models.TaxExempt
+	receipt.Lines = nil
+	for i := 0; i < 60; i++ {
+		receipt.Lines = append(receipt.Lines, ReceiptLine{Description: "Side mirror", Quantity: 1, UnitPrice: 500, Subtotal: 500})
+	}
+
+	document := string(RenderPDFReceipt(ReceiptPages[ReceiptPageHalfLetter], receipt))
+	assert.Contains(t, document, "/Count 2", "the items run over onto a second page")
+	assert.Equal(t, 60, strings.Count(document, "(Side mirror) Tj"))
+	assert.Contains(t, document, "(VAT-exempt sales) Tj")
+	assert.NotContains(t, document, "(VATable sales) Tj")
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyRequest(t *testing.T) {
+	secret, err := NewSigningSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"externalId":"MKT-1"}`)
+	signature := SignRequest(secret, timestamp, "nonce-1", body)
+
+	assert.NoError(t, VerifyRequest(secret, timestamp, "nonce-1", signature, body, now))
+	assert.NoError(t, VerifyRequest(secret, timestamp, "nonce-1", "sha256="+signature, body, now), "the sha256= prefix is optional")
+
+	assert.ErrorIs(t, VerifyRequest(secret, timestamp, "nonce-2", signature, body, now), ErrSignatureMismatch, "the nonce is signed")
+	assert.ErrorIs(t, VerifyRequest(secret, timestamp, "nonce-1", signature, []byte(`{"externalId":"MKT-2"}`), now), ErrSignatureMismatch)
+	assert.ErrorIs(t, VerifyRequest("other-secret", timestamp, "nonce-1", signature, body, now), ErrSignatureMismatch)
+	assert.ErrorIs(t, VerifyRequest(secret, timestamp, "nonce-1", "not-hex", body, now), ErrSignatureMismatch)
+
+	assert.ErrorIs(t, VerifyRequest(secret, timestamp, "nonce-1", signature, body, now.Add(SignatureTolerance+time.Second)), ErrSignatureTimestamp)
+	assert.ErrorIs(t, VerifyRequest(secret, timestamp, "nonce-1", signature, body, now.Add(-SignatureTolerance-time.Second)), ErrSignatureTimestamp)
+	assert.ErrorIs(t, VerifyRequest(secret, "", "nonce-1", signature, body, now), ErrSignatureTimestamp)
+}
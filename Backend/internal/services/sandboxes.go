@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+)
+
+// SandboxTenants finds the tenants sandboxes belong to
+type SandboxTenants interface {
+	GetByID(id string) (*models.Tenant, error)
+	GetBySlug(slug string) (*models.Tenant, error)
+}
+
+// Sandboxes keeps the training sandbox of every tenant: a tenant of its own, seeded
+// with the fixture data, where new hires practice sales and inventory changes without
+// touching real records. Sandboxes are kept in memory, whatever the tenant's own data
+// is stored in, so they are per instance and reseeded on restart.
+//
+// Sandboxes also finds tenants by ID and slug, resolving sandbox IDs to a tenant that
+// is active while the tenant it belongs to is, so it can stand in for the tenant
+// repository when resolving requests. Sandboxes have no slug of their own.
+type Sandboxes struct {
+	tenants SandboxTenants
+	now     func() time.Time
+
+	// OnReset is called with the sandbox tenant's ID after it was reset, so the
+	// repositories and app built for the old data can be dropped
+	OnReset func(sandboxID string)
+
+	mu        sync.Mutex
+	sandboxes map[string]*sandbox // By the ID of the tenant they belong to
+}
+
+// sandbox is the data of one tenant's sandbox
+type sandbox struct {
+	store    *memory.Store
+	seededAt time.Time
+	users    map[string]models.User // Users who entered, recreated on every reset
+}
+
+// NewSandboxes creates the sandboxes of the tenants found in tenants
+func NewSandboxes(tenants SandboxTenants) *Sandboxes {
+	return &Sandboxes{tenants: tenants, now: time.Now, sandboxes: make(map[string]*sandbox)}
+}
+
+// GetByID returns a tenant, or the sandbox of a tenant when id is a sandbox ID
+func (s *Sandboxes) GetByID(id string) (*models.Tenant, error) {
+	parentID, ok := models.SandboxParent(id)
+	if !ok {
+		return s.tenants.GetByID(id)
+	}
+	parent, err := s.tenants.GetByID(parentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, isSandbox := models.SandboxParent(parent.ID); isSandbox {
+		return nil, fmt.Errorf("sandbox %s has no sandbox: %w", parent.ID, sql.ErrNoRows)
+	}
+	return &models.Tenant{
+		ID:        id,
+		Name:      parent.Name + " (sandbox)",
+		IsActive:  parent.IsActive,
+		CreatedAt: parent.CreatedAt,
+		UpdatedAt: parent.UpdatedAt,
+	}, nil
+}
+
+// GetBySlug returns the tenant served on a subdomain
+func (s *Sandboxes) GetBySlug(slug string) (*models.Tenant, error) {
+	return s.tenants.GetBySlug(slug)
+}
+
+// Store returns the data of a tenant's sandbox, seeding it on first use
+func (s *Sandboxes) Store(tenantID string) (*memory.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	box, err := s.sandbox(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return box.store, nil
+}
+
+// SeededAt returns when a tenant's sandbox was last seeded, or false when it has not
+// been used since the server started
+func (s *Sandboxes) SeededAt(tenantID string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	box, ok := s.sandboxes[tenantID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return box.seededAt, true
+}
+
+// Enter gives a user of a tenant an account in its sandbox with the same ID, name
+// and role, so a token issued for the sandbox identifies them there. The account
+// has a random password: users only reach the sandbox through such tokens.
+func (s *Sandboxes) Enter(tenantID string, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	box, err := s.sandbox(tenantID)
+	if err != nil {
+		return err
+	}
+	if err := mirrorUser(box.store, *user); err != nil {
+		return err
+	}
+	box.users[user.Id] = *user
+	return nil
+}
+
+// Reset replaces the data of a tenant's sandbox with freshly seeded fixtures, keeping
+// the accounts of the users who entered it, and returns when it was seeded
+func (s *Sandboxes) Reset(tenantID string) (time.Time, error) {
+	s.mu.Lock()
+	box, err := s.newSandbox(tenantID)
+	if err != nil {
+		s.mu.Unlock()
+		return time.Time{}, err
+	}
+	if old, ok := s.sandboxes[tenantID]; ok {
+		for _, user := range old.users {
+			if err := mirrorUser(box.store, user); err != nil {
+				s.mu.Unlock()
+				return time.Time{}, err
+			}
+			box.users[user.Id] = user
+		}
+	}
+	s.sandboxes[tenantID] = box
+	s.mu.Unlock()
+
+	if s.OnReset != nil {
+		s.OnReset(models.SandboxTenantID(tenantID))
+	}
+	return box.seededAt, nil
+}
+
+// sandbox returns the sandbox of a tenant, seeding it on first use. s.mu must be held.
+func (s *Sandboxes) sandbox(tenantID string) (*sandbox, error) {
+	if box, ok := s.sandboxes[tenantID]; ok {
+		return box, nil
+	}
+	box, err := s.newSandbox(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	s.sandboxes[tenantID] = box
+	return box, nil
+}
+
+// newSandbox seeds a sandbox for a tenant
+func (s *Sandboxes) newSandbox(tenantID string) (*sandbox, error) {
+	store, err := memory.NewSeededStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed sandbox of tenant %s: %w", tenantID, err)
+	}
+	return &sandbox{store: store, seededAt: s.now(), users: make(map[string]models.User)}, nil
+}
+
+// mirrorUser creates or updates the sandbox account of a user. The user's email is
+// kept unless a fixture account already has it.
+func mirrorUser(store *memory.Store, user models.User) error {
+	if existing, err := store.Users.GetByID(context.Background(), user.Id); err == nil {
+		existing.Username = user.Username
+		existing.FullName = user.FullName
+		existing.Role = user.Role
+		existing.IsActive = true
+		return store.Users.Update(context.Background(), existing)
+	}
+
+	taken, err := store.Users.EmailExists(context.Background(), user.Email)
+	if err != nil {
+		return err
+	}
+	if taken {
+		user.Email = user.Id + "@sandbox.invalid"
+	}
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Errorf("failed to generate sandbox password: %w", err)
+	}
+	user.Password = hex.EncodeToString(raw)
+	if err := store.Users.Create(context.Background(), &user); err != nil {
+		return fmt.Errorf("failed to create sandbox account of user %s: %w", user.Id, err)
+	}
+	return nil
+}
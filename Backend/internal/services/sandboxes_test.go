@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxesGetByID(t *testing.T) {
+	tenants := memory.NewTenantRepository()
+	acme := &models.Tenant{Slug: "acme", Name: "Acme", IsActive: true}
+	require.NoError(t, tenants.Create(acme))
+	sandboxes := NewSandboxes(tenants)
+
+	tenant, err := sandboxes.GetByID(acme.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", tenant.Name)
+
+	tenant, err = sandboxes.GetByID(models.SandboxTenantID(acme.ID))
+	require.NoError(t, err)
+	assert.Equal(t, "sandbox-"+acme.ID, tenant.ID)
+	assert.Equal(t, "Acme (sandbox)", tenant.Name)
+	assert.True(t, tenant.IsActive)
+	assert.Empty(t, tenant.Slug, "sandboxes are not served on a subdomain")
+
+	acme.IsActive = false
+	require.NoError(t, tenants.Update(acme))
+	tenant, err = sandboxes.GetByID(models.SandboxTenantID(acme.ID))
+	require.NoError(t, err)
+	assert.False(t, tenant.IsActive, "a sandbox is inactive with its tenant")
+
+	_, err = sandboxes.GetByID(models.SandboxTenantID("missing"))
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = sandboxes.GetByID(models.SandboxTenantID(models.SandboxTenantID(acme.ID)))
+	assert.ErrorIs(t, err, sql.ErrNoRows, "sandboxes have no sandbox")
+}
+
+func TestSandboxesReset(t *testing.T) {
+	sandboxes := NewSandboxes(memory.NewTenantRepository())
+	var resetIDs []string
+	sandboxes.OnReset = func(sandboxID string) { resetIDs = append(resetIDs, sandboxID) }
+
+	_, ok := sandboxes.SeededAt(models.DefaultTenantID)
+	assert.False(t, ok, "sandboxes are seeded on first use")
+
+	trainee := &models.User{Id: "user-1", Username: "trainee", Email: "trainee@example.com", Role: "staff"}
+	require.NoError(t, sandboxes.Enter(models.DefaultTenantID, trainee))
+	store, err := sandboxes.Store(models.DefaultTenantID)
+	require.NoError(t, err)
+	_, ok = sandboxes.SeededAt(models.DefaultTenantID)
+	assert.True(t, ok)
+	require.NoError(t, store.Customers.DeleteCustomer(context.Background(), "0b8f6c1e-3f1a-4d2b-9c6e-1a2b3c4d5e01"))
+
+	_, err = sandboxes.Reset(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sandbox-default"}, resetIDs)
+
+	reseeded, err := sandboxes.Store(models.DefaultTenantID)
+	require.NoError(t, err)
+	assert.NotSame(t, store, reseeded)
+	customers, err := reseeded.Customers.GetAllCustomers(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, customers, 3)
+	user, err := reseeded.Users.GetByID(context.Background(), "user-1")
+	require.NoError(t, err, "users who entered keep their account")
+	assert.Equal(t, "trainee@example.com", user.Email)
+	assert.Equal(t, "staff", user.Role)
+
+	other, err := sandboxes.Store("acme")
+	require.NoError(t, err)
+	_, err = other.Users.GetByID(context.Background(), "user-1")
+	assert.Error(t, err, "each tenant has its own sandbox")
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"oop/internal/config"
+)
+
+// ErrObjectNotFound is returned when no file is stored under a key
+var ErrObjectNotFound = errors.New("stored file not found")
+
+// ErrSignedURLInvalid is returned for download links that were tampered with or have expired
+var ErrSignedURLInvalid = errors.New("download link is invalid or has expired")
+
+// Storage keeps the contents of uploaded files under slash-separated keys such as
+// tenant-1/item-images/<id>. Deleting a key that holds nothing is not an error.
+type Storage interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// SignedURL returns a link the file can be downloaded from without signing in
+	// until expiry has passed. When fileName is set, the file is downloaded under
+	// that name.
+	SignedURL(key, fileName string, expiry time.Duration) (string, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalFilesPath is where the download links of the local driver are served
+const LocalFilesPath = "/api/files"
+
+// NewStorage creates the storage driver selected by cfg. It returns nil when files
+// are kept in the database. jwtSecret signs the local driver's download links unless
+// a signing secret is configured.
+func NewStorage(cfg config.StorageConfig, jwtSecret []byte) (Storage, error) {
+	switch cfg.Driver {
+	case config.StorageLocal:
+		secret := []byte(cfg.SigningSecret)
+		if len(secret) == 0 {
+			secret = jwtSecret
+		}
+		return NewLocalStorage(cfg.LocalDir, LocalFilesPath, secret)
+	case config.StorageS3:
+		return NewS3Storage(cfg), nil
+	case config.StorageGCS:
+		return NewGCSStorage(cfg), nil
+	default:
+		return nil, nil
+	}
+}
+
+// checkStorageKey rejects keys that are empty or could escape their prefix
+func checkStorageKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid storage key %q", key)
+		}
+	}
+	return nil
+}
+
+// LocalStorage keeps files in a directory on the server's disk. Its download links
+// point at this server, which checks their signature before serving the file.
+type LocalStorage struct {
+	dir     string
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+// NewLocalStorage creates the directory files are kept in when it is missing.
+// Download links start with baseURL and are signed with secret.
+func NewLocalStorage(dir, baseURL string, secret []byte) (*LocalStorage, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("local storage needs a secret to sign download links")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStorage{dir: dir, baseURL: strings.TrimRight(baseURL, "/"), secret: secret, now: time.Now}, nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if err := checkStorageKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes a file, replacing the one stored under key. The file is written next
+// to its final name first, so readers never see it half written.
+func (s *LocalStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create storage directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	return nil
+}
+
+// Get reads the file stored under key
+func (s *LocalStorage) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stored file: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the file stored under key
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete stored file: %w", err)
+	}
+	return nil
+}
+
+// SignedURL returns a link to this server's files route for key
+func (s *LocalStorage) SignedURL(key, fileName string, expiry time.Duration) (string, error) {
+	if err := checkStorageKey(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(s.now().Add(expiry).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.signature(key, fileName, expires)}}
+	if fileName != "" {
+		query.Set("name", fileName)
+	}
+	return s.baseURL + "/" + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// Verify checks the expiry and signature of a download link for key and the file
+// name it was signed with
+func (s *LocalStorage) Verify(key, fileName, expires, signature string) error {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > unix {
+		return ErrSignedURLInvalid
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, fileName, expires))) {
+		return ErrSignedURLInvalid
+	}
+	return nil
+}
+
+func (s *LocalStorage) signature(key, fileName, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + fileName + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ContentDisposition returns the Content-Disposition value downloading a file as
+// fileName. Quotes and line breaks are dropped from the name.
+func ContentDisposition(fileName string) string {
+	fileName = strings.NewReplacer(`"`, "", "\r", "", "\n", "").Replace(fileName)
+	return `attachment; filename="` + fileName + `"`
+}
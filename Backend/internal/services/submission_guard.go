@@ -0,0 +1,104 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// SubmittedResponse is the response a create request was answered with
+type SubmittedResponse struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// SubmissionGuard catches accidental double submissions of create requests, such as
+// a form posted twice by a double click or a retry after a slow response. Requests
+// are identified by a fingerprint the caller computes from the client, endpoint and
+// payload. An identical request within the window is answered with the response of
+// the first one instead of creating the record again; one arriving while the first
+// is still running waits for it. Fingerprints are kept in memory, so the check is per
+// instance.
+type SubmissionGuard struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu          sync.Mutex
+	submissions map[string]*submission
+}
+
+type submission struct {
+	startedAt time.Time
+	done      chan struct{} // Closed once the first request has been answered
+	response  *SubmittedResponse
+}
+
+// NewSubmissionGuard creates a guard treating identical requests within window as
+// duplicates. A window of 0 disables it.
+func NewSubmissionGuard(window time.Duration) *SubmissionGuard {
+	return &SubmissionGuard{window: window, now: time.Now, submissions: make(map[string]*submission)}
+}
+
+// Enabled reports whether duplicates are being caught. A nil guard catches none.
+func (g *SubmissionGuard) Enabled() bool {
+	return g != nil && g.window > 0
+}
+
+// Begin registers a request with the given fingerprint. When it duplicates one
+// answered within the window, Begin returns that response and true, and the request
+// should not be processed. Otherwise the caller processes the request and must call
+// Finish with the same fingerprint.
+func (g *SubmissionGuard) Begin(fingerprint string) (*SubmittedResponse, bool) {
+	for {
+		g.mu.Lock()
+		now := g.now()
+		g.prune(now)
+
+		previous, ok := g.submissions[fingerprint]
+		if !ok {
+			g.submissions[fingerprint] = &submission{startedAt: now, done: make(chan struct{})}
+			g.mu.Unlock()
+			return nil, false
+		}
+		g.mu.Unlock()
+
+		<-previous.done
+		if previous.response != nil {
+			return previous.response, true
+		}
+		// The first request failed and was forgotten, so this one is processed
+		// in its place
+	}
+}
+
+// Finish records the response of a request registered with Begin and releases the
+// duplicates waiting for it. A nil response, for a request that failed, forgets the
+// fingerprint so the request can be retried.
+func (g *SubmissionGuard) Finish(fingerprint string, response *SubmittedResponse) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	current, ok := g.submissions[fingerprint]
+	if !ok {
+		return
+	}
+	current.response = response
+	if response == nil {
+		delete(g.submissions, fingerprint)
+	}
+	close(current.done)
+}
+
+// prune forgets answered requests older than the window. Requests still running
+// are kept so their duplicates keep waiting for them.
+func (g *SubmissionGuard) prune(now time.Time) {
+	for fingerprint, s := range g.submissions {
+		select {
+		case <-s.done:
+			if now.Sub(s.startedAt) >= g.window {
+				delete(g.submissions, fingerprint)
+			}
+		default:
+		}
+	}
+}
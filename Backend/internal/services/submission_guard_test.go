@@ -0,0 +1,84 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGuard(window time.Duration, now *time.Time) *SubmissionGuard {
+	guard := NewSubmissionGuard(window)
+	guard.now = func() time.Time { return *now }
+	return guard
+}
+
+func TestSubmissionGuardReplaysWithinWindow(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 30, 0, 0, time.UTC)
+	guard := newTestGuard(10*time.Second, &now)
+	created := &SubmittedResponse{Status: 201, ContentType: "application/json", Body: []byte(`{"id":"c-1"}`)}
+
+	_, duplicate := guard.Begin("user:1|POST|/api/customers|abc")
+	require.False(t, duplicate)
+	guard.Finish("user:1|POST|/api/customers|abc", created)
+
+	now = now.Add(9 * time.Second)
+	previous, duplicate := guard.Begin("user:1|POST|/api/customers|abc")
+	assert.True(t, duplicate)
+	assert.Equal(t, created, previous)
+
+	_, duplicate = guard.Begin("user:2|POST|/api/customers|abc")
+	assert.False(t, duplicate, "other clients are not affected")
+	guard.Finish("user:2|POST|/api/customers|abc", created)
+
+	now = now.Add(time.Second)
+	_, duplicate = guard.Begin("user:1|POST|/api/customers|abc")
+	assert.False(t, duplicate, "the window has passed")
+}
+
+func TestSubmissionGuardForgetsFailures(t *testing.T) {
+	now := time.Now()
+	guard := newTestGuard(10*time.Second, &now)
+
+	_, duplicate := guard.Begin("key")
+	require.False(t, duplicate)
+	guard.Finish("key", nil)
+
+	_, duplicate = guard.Begin("key")
+	assert.False(t, duplicate, "a failed request can be sent again")
+}
+
+func TestSubmissionGuardWaitsForRunningRequest(t *testing.T) {
+	guard := NewSubmissionGuard(10 * time.Second)
+	created := &SubmittedResponse{Status: 201, Body: []byte(`{"id":1}`)}
+
+	_, duplicate := guard.Begin("key")
+	require.False(t, duplicate)
+
+	var wg sync.WaitGroup
+	results := make([]*SubmittedResponse, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = guard.Begin("key")
+		}(i)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	guard.Finish("key", created)
+	wg.Wait()
+
+	for _, result := range results {
+		assert.Equal(t, created, result, "duplicates get the response of the first request")
+	}
+}
+
+func TestSubmissionGuardDisabled(t *testing.T) {
+	assert.False(t, NewSubmissionGuard(0).Enabled())
+	var guard *SubmissionGuard
+	assert.False(t, guard.Enabled())
+	assert.True(t, NewSubmissionGuard(time.Second).Enabled())
+}
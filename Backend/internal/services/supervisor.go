@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"oop/internal/models"
+)
+
+// Supervisor runs the server's background workers, the loops of its jobs and queues,
+// so one that panics does not take the server down: the panic is logged with its
+// stack and the worker is started again after a backoff that grows with each crash,
+// up to 30 seconds.
+// A nil *Supervisor is valid and runs workers unsupervised, as tests do.
+type Supervisor struct {
+	backoff func(restarts int) time.Duration
+
+	mu      sync.Mutex
+	workers []*models.WorkerStatus // In the order they were started
+	running sync.WaitGroup
+}
+
+// NewSupervisor creates a supervisor with no workers
+func NewSupervisor() *Supervisor {
+	return &Supervisor{backoff: exponentialBackoff}
+}
+
+// Go runs work in a goroutine under name and returns a channel closed once it has
+// returned. work should return once stop is closed. A crashed worker is started
+// again unless stop is closed by then, in which case what it held is lost.
+func (s *Supervisor) Go(name string, stop <-chan struct{}, work func()) <-chan struct{} {
+	done := make(chan struct{})
+	if s == nil {
+		go func() {
+			defer close(done)
+			work()
+		}()
+		return done
+	}
+
+	status := &models.WorkerStatus{Name: name, State: models.WorkerRunning, StartedAt: time.Now()}
+	s.mu.Lock()
+	s.workers = append(s.workers, status)
+	s.mu.Unlock()
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer close(done)
+		for {
+			if s.runOnce(status, work) {
+				s.setState(status, models.WorkerStopped)
+				return
+			}
+
+			s.mu.Lock()
+			wait := s.backoff(status.Restarts)
+			s.mu.Unlock()
+			log.Printf("Worker %s crashed, restarting in %s", name, wait)
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				timer.Stop()
+				log.Printf("Worker %s crashed while the server was stopping and was not restarted", name)
+				s.setState(status, models.WorkerStopped)
+				return
+			}
+
+			s.mu.Lock()
+			status.State = models.WorkerRunning
+			status.StartedAt = time.Now()
+			status.Restarts++
+			s.mu.Unlock()
+		}
+	}()
+	return done
+}
+
+// runOnce runs work, reporting false when it panicked
+func (s *Supervisor) runOnce(status *models.WorkerStatus, work func()) (returned bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Worker %s panicked: %v\n%s", status.Name, r, debug.Stack())
+			now := time.Now()
+			s.mu.Lock()
+			status.State = models.WorkerRestarting
+			status.LastPanic = fmt.Sprint(r)
+			status.LastPanicAt = &now
+			s.mu.Unlock()
+		}
+	}()
+	work()
+	return true
+}
+
+func (s *Supervisor) setState(status *models.WorkerStatus, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status.State = state
+}
+
+// Workers returns the status of every worker, in the order they were started
+func (s *Supervisor) Workers() []models.WorkerStatus {
+	if s == nil {
+		return []models.WorkerStatus{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	workers := make([]models.WorkerStatus, len(s.workers))
+	for i, status := range s.workers {
+		workers[i] = *status
+	}
+	return workers
+}
+
+// Wait returns once every worker has returned, or fails naming those still running
+// when ctx is done. Workers are stopped by closing their jobs and queues; this only
+// makes sure none is left behind before the database is closed.
+func (s *Supervisor) Wait(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		var running []string
+		for _, status := range s.Workers() {
+			if status.State != models.WorkerStopped {
+				running = append(running, status.Name)
+			}
+		}
+		return fmt.Errorf("workers still running: %s: %w", strings.Join(running, ", "), ctx.Err())
+	}
+}
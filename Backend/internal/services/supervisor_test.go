@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisorRestartsCrashedWorkers(t *testing.T) {
+	s := NewSupervisor()
+	s.backoff = func(int) time.Duration { return time.Millisecond }
+
+	var runs atomic.Int32
+	stop := make(chan struct{})
+	done := s.Go("Flaky worker", stop, func() {
+		if runs.Add(1) < 3 {
+			panic("boom")
+		}
+		<-stop
+	})
+
+	require.Eventually(t, func() bool {
+		workers := s.Workers()
+		return workers[0].Restarts == 2 && workers[0].State == models.WorkerRunning
+	}, time.Second, time.Millisecond)
+	worker := s.Workers()[0]
+	assert.Equal(t, "Flaky worker", worker.Name)
+	assert.Equal(t, "boom", worker.LastPanic)
+	assert.NotNil(t, worker.LastPanicAt)
+
+	close(stop)
+	<-done
+	assert.Equal(t, models.WorkerStopped, s.Workers()[0].State)
+	assert.NoError(t, s.Wait(context.Background()))
+}
+
+func TestSupervisorDoesNotRestartWhenStopping(t *testing.T) {
+	s := NewSupervisor()
+	s.backoff = func(int) time.Duration { return time.Hour }
+
+	stop := make(chan struct{})
+	done := s.Go("Crashing worker", stop, func() { panic("boom") })
+	require.Eventually(t, func() bool {
+		return s.Workers()[0].State == models.WorkerRestarting
+	}, time.Second, time.Millisecond)
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the worker waited out its backoff after being stopped")
+	}
+	assert.Equal(t, models.WorkerStopped, s.Workers()[0].State)
+	assert.Equal(t, 0, s.Workers()[0].Restarts)
+}
+
+func TestSupervisorWaitNamesRunningWorkers(t *testing.T) {
+	s := NewSupervisor()
+	stop := make(chan struct{})
+	defer close(stop)
+	s.Go("Stuck worker", stop, func() { <-stop })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Wait(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Stuck worker")
+}
+
+func TestNilSupervisorRunsWorkers(t *testing.T) {
+	var s *Supervisor
+	done := s.Go("Unsupervised worker", nil, func() {})
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the worker did not run")
+	}
+	assert.Empty(t, s.Workers())
+	assert.NoError(t, s.Wait(context.Background()))
+}
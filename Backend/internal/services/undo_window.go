@@ -0,0 +1,86 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UndoTicket identifies a deleted record that can still be restored
+type UndoTicket struct {
+	TenantID   string
+	EntityType string
+	EntityID   string
+	DeletedBy  string // Empty when the delete route is not authenticated
+	ExpiresAt  time.Time
+}
+
+// UndoWindow hands out tokens for undoing deletes. A token restores the record it
+// was issued for until the window has passed. Tokens are kept in memory, so they
+// only work on the instance that issued them and are lost on restart; the record
+// itself stays soft-deleted either way.
+type UndoWindow struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	tickets map[string]UndoTicket
+}
+
+// NewUndoWindow creates an undo window of the given length. A window of 0 disables it.
+func NewUndoWindow(window time.Duration) *UndoWindow {
+	return &UndoWindow{window: window, now: time.Now, tickets: make(map[string]UndoTicket)}
+}
+
+// Enabled reports whether undo tokens are issued. A nil window issues none.
+func (w *UndoWindow) Enabled() bool {
+	return w != nil && w.window > 0
+}
+
+// Issue stores a ticket, setting its expiry, and returns its token
+func (w *UndoWindow) Issue(ticket UndoTicket) (string, UndoTicket, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", ticket, fmt.Errorf("failed to generate undo token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	w.prune(now)
+	ticket.ExpiresAt = now.Add(w.window)
+	w.tickets[token] = ticket
+	return token, ticket, nil
+}
+
+// Get returns the ticket of a token, unless it is unknown or has expired
+func (w *UndoWindow) Get(token string) (UndoTicket, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ticket, ok := w.tickets[token]
+	if !ok || !w.now().Before(ticket.ExpiresAt) {
+		return UndoTicket{}, false
+	}
+	return ticket, true
+}
+
+// Revoke forgets a token once it has been used
+func (w *UndoWindow) Revoke(token string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.tickets, token)
+}
+
+// prune forgets expired tickets
+func (w *UndoWindow) prune(now time.Time) {
+	for token, ticket := range w.tickets {
+		if !now.Before(ticket.ExpiresAt) {
+			delete(w.tickets, token)
+		}
+	}
+}
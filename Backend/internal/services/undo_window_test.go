@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoWindowExpiresTokens(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 30, 0, 0, time.UTC)
+	window := NewUndoWindow(5 * time.Minute)
+	window.now = func() time.Time { return now }
+	require.True(t, window.Enabled())
+
+	token, issued, err := window.Issue(UndoTicket{TenantID: "default", EntityType: "customer", EntityID: "c-1", DeletedBy: "user-1"})
+	require.NoError(t, err)
+	assert.Len(t, token, 32)
+	assert.Equal(t, now.Add(5*time.Minute), issued.ExpiresAt)
+
+	other, _, err := window.Issue(UndoTicket{TenantID: "default", EntityType: "material", EntityID: "7"})
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other, "every delete gets its own token")
+
+	now = now.Add(4 * time.Minute)
+	ticket, ok := window.Get(token)
+	require.True(t, ok)
+	assert.Equal(t, "c-1", ticket.EntityID)
+
+	window.Revoke(token)
+	_, ok = window.Get(token)
+	assert.False(t, ok, "a token is used once")
+
+	now = now.Add(time.Minute)
+	_, ok = window.Get(other)
+	assert.False(t, ok, "the window has passed")
+	_, ok = window.Get("unknown")
+	assert.False(t, ok)
+}
+
+func TestUndoWindowDisabled(t *testing.T) {
+	assert.False(t, NewUndoWindow(0).Enabled())
+	var window *UndoWindow
+	assert.False(t, window.Enabled())
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"oop/internal/config"
+	"oop/internal/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// QuotaVerdict is the outcome of metering a request
+type QuotaVerdict int
+
+// Quota verdicts returned by UsageMeter.Admit
+const (
+	QuotaAllowed          QuotaVerdict = iota
+	QuotaRateLimited                   // Too many requests this minute
+	QuotaTooLarge                      // The request body is over the size limit
+	QuotaUploadsExhausted              // The tenant's upload bytes for the day are used up
+)
+
+// UsageMeter counts API requests and uploaded bytes per tenant and client, and
+// enforces the quotas of a QuotaConfig. Counters are kept in memory, so they start
+// from zero when the server restarts and are per instance.
+type UsageMeter struct {
+	quotas config.QuotaConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	tenants map[string]*tenantMeter
+}
+
+// meter holds the counters shared by tenants and clients
+type meter struct {
+	day         string    // UTC day the daily counters belong to
+	window      time.Time // Start of the current rate limit minute
+	windowCount int
+	usage       models.RequestUsage
+}
+
+type tenantMeter struct {
+	meter
+	clients map[string]*clientMeter
+}
+
+type clientMeter struct {
+	meter
+	lastSeen time.Time
+}
+
+// NewUsageMeter creates a meter enforcing quotas
+func NewUsageMeter(quotas config.QuotaConfig) *UsageMeter {
+	return &UsageMeter{quotas: quotas, now: time.Now, tenants: make(map[string]*tenantMeter)}
+}
+
+// Quotas returns the limits the meter enforces
+func (m *UsageMeter) Quotas() config.QuotaConfig {
+	return m.quotas
+}
+
+// Admit meters a request of a client and decides whether it may proceed. Rejected
+// requests are counted as rejected but not as requests. When the verdict is
+// QuotaRateLimited, retryAfter is the time until the next minute starts.
+func (m *UsageMeter) Admit(tenantID, client string, bodyBytes int) (verdict QuotaVerdict, retryAfter time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UTC()
+	tenant := m.tenant(tenantID)
+	caller, ok := tenant.clients[client]
+	if !ok {
+		caller = &clientMeter{}
+		tenant.clients[client] = caller
+	}
+	caller.lastSeen = now
+	tenant.roll(now)
+	caller.roll(now)
+
+	switch {
+	case m.quotas.MaxRequestBytes > 0 && bodyBytes > m.quotas.MaxRequestBytes:
+		verdict = QuotaTooLarge
+	case m.quotas.UploadBytesPerDay > 0 && bodyBytes > 0 &&
+		tenant.usage.UploadBytesToday+int64(bodyBytes) > int64(m.quotas.UploadBytesPerDay):
+		verdict = QuotaUploadsExhausted
+	case m.quotas.TenantRequestsPerMinute > 0 && tenant.windowCount >= m.quotas.TenantRequestsPerMinute,
+		m.quotas.ClientRequestsPerMinute > 0 && caller.windowCount >= m.quotas.ClientRequestsPerMinute:
+		verdict = QuotaRateLimited
+		retryAfter = tenant.window.Add(time.Minute).Sub(now)
+	}
+
+	if verdict != QuotaAllowed {
+		tenant.usage.RejectedToday++
+		caller.usage.RejectedToday++
+		return verdict, retryAfter
+	}
+
+	tenant.count(bodyBytes)
+	caller.count(bodyBytes)
+	return QuotaAllowed, 0
+}
+
+// RateLimitStatus describes the per-minute rate limit a client is closest to reaching
+type RateLimitStatus struct {
+	Limit     int           // Requests allowed in the window
+	Remaining int           // Requests left in the window
+	Reset     time.Time     // When the window ends and the allowance is restored
+	ResetIn   time.Duration // How long until Reset, by the meter's clock
+}
+
+// RateLimit returns the status of the tighter of the tenant and client per-minute
+// limits for a client, counting the requests admitted so far. It returns false when
+// neither limit is configured.
+func (m *UsageMeter) RateLimit(tenantID, client string) (RateLimitStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UTC()
+	tenant := m.tenant(tenantID)
+	tenant.roll(now)
+
+	var status RateLimitStatus
+	found := false
+	consider := func(limit, used int) {
+		if limit <= 0 {
+			return
+		}
+		remaining := max(limit-used, 0)
+		if !found || remaining < status.Remaining {
+			status = RateLimitStatus{Limit: limit, Remaining: remaining}
+			found = true
+		}
+	}
+
+	consider(m.quotas.TenantRequestsPerMinute, tenant.windowCount)
+	clientUsed := 0
+	if caller, ok := tenant.clients[client]; ok {
+		caller.roll(now)
+		clientUsed = caller.windowCount
+	}
+	consider(m.quotas.ClientRequestsPerMinute, clientUsed)
+
+	status.Reset = now.Truncate(time.Minute).Add(time.Minute)
+	status.ResetIn = status.Reset.Sub(now)
+	return status, found
+}
+
+// Usage returns the traffic of a tenant and of each of its clients, busiest client first
+func (m *UsageMeter) Usage(tenantID string) (models.RequestUsage, []models.ClientUsage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().UTC()
+	tenant := m.tenant(tenantID)
+	tenant.roll(now)
+
+	clients := make([]models.ClientUsage, 0, len(tenant.clients))
+	for name, client := range tenant.clients {
+		client.roll(now)
+		clients = append(clients, models.ClientUsage{Client: name, RequestUsage: client.usage, LastSeenAt: client.lastSeen})
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].RequestsToday == clients[j].RequestsToday {
+			return clients[i].Client < clients[j].Client
+		}
+		return clients[i].RequestsToday > clients[j].RequestsToday
+	})
+
+	return tenant.usage, clients
+}
+
+// tenant returns the meter of a tenant, creating it on first use. m.mu must be held.
+func (m *UsageMeter) tenant(tenantID string) *tenantMeter {
+	tenant, ok := m.tenants[tenantID]
+	if !ok {
+		tenant = &tenantMeter{clients: make(map[string]*clientMeter)}
+		m.tenants[tenantID] = tenant
+	}
+	return tenant
+}
+
+// roll starts a new rate limit window each minute and resets the daily counters each UTC day
+func (c *meter) roll(now time.Time) {
+	if window := now.Truncate(time.Minute); !window.Equal(c.window) {
+		c.window = window
+		c.windowCount = 0
+	}
+	if day := now.Format("2006-01-02"); day != c.day {
+		c.day = day
+		c.usage.RequestsToday = 0
+		c.usage.UploadBytesToday = 0
+		c.usage.RejectedToday = 0
+	}
+}
+
+// count records an admitted request
+func (c *meter) count(bodyBytes int) {
+	c.windowCount++
+	c.usage.RequestsToday++
+	c.usage.RequestsTotal++
+	c.usage.UploadBytesToday += int64(bodyBytes)
+	c.usage.UploadBytesTotal += int64(bodyBytes)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"oop/internal/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMeter(quotas config.QuotaConfig, now *time.Time) *UsageMeter {
+	meter := NewUsageMeter(quotas)
+	meter.now = func() time.Time { return *now }
+	return meter
+}
+
+func TestUsageMeterRateLimits(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 30, 15, 0, time.UTC)
+	meter := newTestMeter(config.QuotaConfig{TenantRequestsPerMinute: 3, ClientRequestsPerMinute: 2}, &now)
+
+	verdict, _ := meter.Admit("acme", "user:1", 0)
+	assert.Equal(t, QuotaAllowed, verdict)
+	verdict, _ = meter.Admit("acme", "user:1", 0)
+	assert.Equal(t, QuotaAllowed, verdict)
+
+	verdict, retryAfter := meter.Admit("acme", "user:1", 0)
+	assert.Equal(t, QuotaRateLimited, verdict, "client limit")
+	assert.Equal(t, 45*time.Second, retryAfter)
+
+	verdict, _ = meter.Admit("acme", "user:2", 0)
+	assert.Equal(t, QuotaAllowed, verdict, "other clients keep their own allowance")
+	verdict, _ = meter.Admit("acme", "user:3", 0)
+	assert.Equal(t, QuotaRateLimited, verdict, "tenant limit")
+
+	verdict, _ = meter.Admit("other", "user:9", 0)
+	assert.Equal(t, QuotaAllowed, verdict, "tenants are metered separately")
+
+	now = now.Add(time.Minute)
+	verdict, _ = meter.Admit("acme", "user:1", 0)
+	assert.Equal(t, QuotaAllowed, verdict, "a new minute resets the window")
+
+	requests, clients := meter.Usage("acme")
+	assert.Equal(t, int64(4), requests.RequestsToday)
+	assert.Equal(t, int64(2), requests.RejectedToday)
+	if assert.Len(t, clients, 3) {
+		assert.Equal(t, "user:1", clients[0].Client)
+		assert.Equal(t, int64(3), clients[0].RequestsToday)
+	}
+}
+
+func TestUsageMeterUploadQuotas(t *testing.T) {
+	now := time.Date(2026, 5, 4, 23, 59, 0, 0, time.UTC)
+	meter := newTestMeter(config.QuotaConfig{MaxRequestBytes: 1000, UploadBytesPerDay: 1500}, &now)
+
+	verdict, _ := meter.Admit("acme", "user:1", 1001)
+	assert.Equal(t, QuotaTooLarge, verdict)
+
+	verdict, _ = meter.Admit("acme", "user:1", 900)
+	assert.Equal(t, QuotaAllowed, verdict)
+	verdict, _ = meter.Admit("acme", "user:1", 900)
+	assert.Equal(t, QuotaUploadsExhausted, verdict)
+	verdict, _ = meter.Admit("acme", "user:1", 0)
+	assert.Equal(t, QuotaAllowed, verdict, "requests without a body still pass")
+
+	now = now.Add(2 * time.Minute)
+	verdict, _ = meter.Admit("acme", "user:1", 900)
+	assert.Equal(t, QuotaAllowed, verdict, "the allowance resets at midnight UTC")
+
+	requests, _ := meter.Usage("acme")
+	assert.Equal(t, int64(900), requests.UploadBytesToday)
+	assert.Equal(t, int64(1800), requests.UploadBytesTotal)
+}
+
+func TestUsageMeterRateLimitStatus(t *testing.T) {
+	now := time.Date(2026, 5, 4, 9, 30, 15, 0, time.UTC)
+	meter := newTestMeter(config.QuotaConfig{TenantRequestsPerMinute: 5, ClientRequestsPerMinute: 3}, &now)
+
+	meter.Admit("acme", "user:1", 0)
+	status, ok := meter.RateLimit("acme", "user:1")
+	assert.True(t, ok)
+	assert.Equal(t, RateLimitStatus{Limit: 3, Remaining: 2, Reset: time.Date(2026, 5, 4, 9, 31, 0, 0, time.UTC), ResetIn: 45 * time.Second}, status, "the client limit is tighter")
+
+	meter.Admit("acme", "user:2", 0)
+	meter.Admit("acme", "user:2", 0)
+	meter.Admit("acme", "user:2", 0)
+	status, _ = meter.RateLimit("acme", "user:1")
+	assert.Equal(t, 5, status.Limit, "the tenant limit is now tighter")
+	assert.Equal(t, 1, status.Remaining)
+
+	meter.Admit("acme", "user:1", 0)
+	meter.Admit("acme", "user:1", 0)
+	status, _ = meter.RateLimit("acme", "user:1")
+	assert.Equal(t, 0, status.Remaining, "never negative once rejected")
+
+	now = now.Add(time.Minute)
+	status, _ = meter.RateLimit("acme", "user:1")
+	assert.Equal(t, 3, status.Remaining, "a new minute restores the allowance")
+
+	_, ok = newTestMeter(config.QuotaConfig{MaxRequestBytes: 1000}, &now).RateLimit("acme", "user:1")
+	assert.False(t, ok, "no per-minute limit is configured")
+}
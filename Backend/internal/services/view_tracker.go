@@ -0,0 +1,88 @@
+package services
+
+import (
+	"log"
+	"oop/internal/models"
+	"sync"
+)
+
+// viewQueueSize is how many views can wait to be written before further ones are dropped
+const viewQueueSize = 256
+
+// ViewStore saves the records users opened
+type ViewStore interface {
+	RecordView(view models.EntityView) error
+}
+
+// ViewTracker writes entity views in the background so recording them never slows
+// down the request that showed the record. Views are best effort: when the queue is
+// full, or a write fails, the view is dropped and only logged.
+type ViewTracker struct {
+	queue chan viewJob
+	stop  chan struct{}
+	done  <-chan struct{}
+	once  sync.Once
+}
+
+type viewJob struct {
+	store ViewStore // The repository of the tenant the view belongs to
+	view  models.EntityView
+}
+
+// NewViewTracker creates a tracker and starts its writer under workers
+func NewViewTracker(workers *Supervisor) *ViewTracker {
+	t := &ViewTracker{
+		queue: make(chan viewJob, viewQueueSize),
+		stop:  make(chan struct{}),
+	}
+	t.done = workers.Go("View tracker", t.stop, t.run)
+	return t
+}
+
+// Track queues a view to be saved to store without blocking. It reports whether the
+// view was queued.
+func (t *ViewTracker) Track(store ViewStore, view models.EntityView) bool {
+	select {
+	case <-t.stop:
+		return false
+	default:
+	}
+
+	select {
+	case t.queue <- viewJob{store: store, view: view}:
+		return true
+	default:
+		log.Printf("View queue full, dropping view of %s %s", view.EntityType, view.EntityID)
+		return false
+	}
+}
+
+// Close stops accepting views and returns once the queued ones are written
+func (t *ViewTracker) Close() {
+	t.once.Do(func() { close(t.stop) })
+	<-t.done
+}
+
+func (t *ViewTracker) run() {
+	for {
+		select {
+		case job := <-t.queue:
+			t.write(job)
+		case <-t.stop:
+			for {
+				select {
+				case job := <-t.queue:
+					t.write(job)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *ViewTracker) write(job viewJob) {
+	if err := job.store.RecordView(job.view); err != nil {
+		log.Printf("Error recording view of %s %s by user %s: %v", job.view.EntityType, job.view.EntityID, job.view.UserID, err)
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"oop/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingViewStore struct {
+	mu    sync.Mutex
+	views []models.EntityView
+	err   error
+}
+
+func (s *recordingViewStore) RecordView(view models.EntityView) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.views = append(s.views, view)
+	return s.err
+}
+
+func TestViewTrackerWritesQueuedViewsOnClose(t *testing.T) {
+	tracker := NewViewTracker(nil)
+	store := &recordingViewStore{}
+	failing := &recordingViewStore{err: errors.New("database is down")}
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, tracker.Track(store, models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCab, EntityID: "1"}))
+	}
+	assert.True(t, tracker.Track(failing, models.EntityView{UserID: "user-1", EntityType: models.ViewEntitySale, EntityID: "s-1"}), "failed writes do not stop the tracker")
+	assert.True(t, tracker.Track(store, models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCab, EntityID: "2"}))
+	tracker.Close()
+
+	assert.Len(t, store.views, 11)
+	assert.False(t, tracker.Track(store, models.EntityView{UserID: "user-1", EntityType: models.ViewEntityCab, EntityID: "3"}), "views are refused once closed")
+	tracker.Close() // Closing again is harmless
+}
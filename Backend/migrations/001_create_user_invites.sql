@@ -0,0 +1,15 @@
+-- Pending account invitations created through POST /api/users/invite.
+-- Only a SHA-256 hash of the one-time setup token is stored.
+CREATE TABLE IF NOT EXISTS user_invites (
+    id          VARCHAR(36)  NOT NULL PRIMARY KEY,
+    user_id     VARCHAR(36)  NOT NULL,
+    email       VARCHAR(255) NOT NULL,
+    role        VARCHAR(32)  NOT NULL,
+    token_hash  CHAR(64)     NOT NULL UNIQUE,
+    invited_by  VARCHAR(36)  NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    accepted_at DATETIME     NULL,
+    created_at  DATETIME     NOT NULL,
+    INDEX idx_user_invites_user_id (user_id),
+    INDEX idx_user_invites_accepted_at (accepted_at)
+);
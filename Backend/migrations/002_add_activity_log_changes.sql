@@ -0,0 +1,7 @@
+-- Field-level before/after diffs for activity log entries
+ALTER TABLE activity_logs
+    ADD COLUMN entity_type VARCHAR(50) NULL AFTER is_system_action,
+    ADD COLUMN entity_id VARCHAR(64) NULL AFTER entity_type,
+    ADD COLUMN changes JSON NULL AFTER entity_id;
+
+CREATE INDEX idx_activity_logs_entity ON activity_logs (entity_type, entity_id);
@@ -0,0 +1,7 @@
+-- Tamper-evident hash chain for activity logs.
+-- seq gives the chain a strict insertion order; entries written before this
+-- migration keep NULL hashes and are reported as unchained by the verifier.
+ALTER TABLE activity_logs
+    ADD COLUMN seq BIGINT NOT NULL AUTO_INCREMENT UNIQUE FIRST,
+    ADD COLUMN hash CHAR(64) NULL AFTER changes,
+    ADD COLUMN prev_hash CHAR(64) NULL AFTER hash;
@@ -0,0 +1,28 @@
+-- Per-tenant data isolation.
+-- Every business table gets a tenant_id; existing rows belong to the default
+-- tenant, which is served when no subdomain or tenant token applies.
+CREATE TABLE IF NOT EXISTS tenants (
+    id         VARCHAR(36)  NOT NULL PRIMARY KEY,
+    slug       VARCHAR(63)  NOT NULL UNIQUE,
+    name       VARCHAR(255) NOT NULL,
+    is_active  BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_at DATETIME     NOT NULL,
+    updated_at DATETIME     NOT NULL
+);
+
+INSERT INTO tenants (id, slug, name, is_active, created_at, updated_at)
+VALUES ('default', 'default', 'Default', TRUE, NOW(), NOW());
+
+ALTER TABLE users         ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_users_tenant_id (tenant_id);
+ALTER TABLE user_invites  ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_user_invites_tenant_id (tenant_id);
+ALTER TABLE customers     ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_customers_tenant_id (tenant_id);
+ALTER TABLE multicabs     ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_multicabs_tenant_id (tenant_id);
+ALTER TABLE accessories   ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_accessories_tenant_id (tenant_id);
+ALTER TABLE materials     ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_materials_tenant_id (tenant_id);
+ALTER TABLE sales         ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_sales_tenant_id (tenant_id);
+ALTER TABLE sale_items    ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_sale_items_tenant_id (tenant_id);
+ALTER TABLE activity_logs ADD COLUMN tenant_id VARCHAR(36) NOT NULL DEFAULT 'default', ADD INDEX idx_activity_logs_tenant_id (tenant_id);
+
+-- If users.email or users.username carry a UNIQUE key, replace it with one that
+-- includes tenant_id so the same person can hold accounts in several tenants, e.g.
+--   ALTER TABLE users DROP INDEX email, ADD UNIQUE KEY uq_users_tenant_email (tenant_id, email);
@@ -0,0 +1,9 @@
+-- Feature flags switched per tenant from the super-admin console.
+-- Features without a row use the default defined in the application.
+CREATE TABLE IF NOT EXISTS tenant_features (
+    tenant_id  VARCHAR(36) NOT NULL,
+    feature    VARCHAR(64) NOT NULL,
+    enabled    BOOLEAN     NOT NULL,
+    updated_at DATETIME    NOT NULL,
+    PRIMARY KEY (tenant_id, feature)
+);
@@ -0,0 +1,24 @@
+-- Announcements admins broadcast to staff, displayed between starts_at and ends_at.
+-- A NULL ends_at keeps the announcement displayed until it is deleted.
+CREATE TABLE IF NOT EXISTS announcements (
+    id         VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id  VARCHAR(36)  NOT NULL,
+    title      VARCHAR(200) NOT NULL,
+    body       TEXT         NOT NULL,
+    severity   VARCHAR(16)  NOT NULL DEFAULT 'info',
+    starts_at  DATETIME     NOT NULL,
+    ends_at    DATETIME     NULL,
+    created_by VARCHAR(36)  NOT NULL,
+    created_at DATETIME     NOT NULL,
+    updated_at DATETIME     NOT NULL,
+    INDEX idx_announcements_tenant_starts (tenant_id, starts_at)
+);
+
+-- Which users read which announcements, keeping the first time each acknowledged.
+CREATE TABLE IF NOT EXISTS announcement_acks (
+    announcement_id VARCHAR(36) NOT NULL,
+    user_id         VARCHAR(36) NOT NULL,
+    tenant_id       VARCHAR(36) NOT NULL,
+    acknowledged_at DATETIME    NOT NULL,
+    PRIMARY KEY (announcement_id, user_id)
+);
@@ -0,0 +1,19 @@
+-- Follow-up tasks assigned to staff, optionally linked to a customer, sale or cab.
+-- entity_type and entity_id are empty when a task is not linked.
+CREATE TABLE IF NOT EXISTS tasks (
+    id           VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)  NOT NULL,
+    title        VARCHAR(200) NOT NULL,
+    description  TEXT         NOT NULL,
+    assignee_id  VARCHAR(36)  NOT NULL,
+    created_by   VARCHAR(36)  NOT NULL,
+    due_date     DATETIME     NULL,
+    status       VARCHAR(16)  NOT NULL DEFAULT 'open',
+    entity_type  VARCHAR(16)  NOT NULL DEFAULT '',
+    entity_id    VARCHAR(36)  NOT NULL DEFAULT '',
+    completed_at DATETIME     NULL,
+    created_at   DATETIME     NOT NULL,
+    updated_at   DATETIME     NOT NULL,
+    INDEX idx_tasks_assignee (tenant_id, assignee_id, status),
+    INDEX idx_tasks_entity (tenant_id, entity_type, entity_id)
+);
@@ -0,0 +1,12 @@
+-- Cabs and accessories users starred. Users with notify set are sent watchlist
+-- notifications when the item's price changes, it is restocked or it is sold.
+CREATE TABLE IF NOT EXISTS user_favorites (
+    tenant_id  VARCHAR(36) NOT NULL,
+    user_id    VARCHAR(36) NOT NULL,
+    item_type  VARCHAR(16) NOT NULL,
+    item_id    INT         NOT NULL,
+    notify     BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at DATETIME    NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, item_type, item_id),
+    INDEX idx_user_favorites_item (tenant_id, item_type, item_id)
+);
@@ -0,0 +1,11 @@
+-- The last time each user opened a cab, accessory, customer or sale, for the
+-- recently viewed list. Viewing a record again moves it to the top.
+CREATE TABLE IF NOT EXISTS entity_views (
+    tenant_id   VARCHAR(36) NOT NULL,
+    user_id     VARCHAR(36) NOT NULL,
+    entity_type VARCHAR(16) NOT NULL,
+    entity_id   VARCHAR(36) NOT NULL,
+    viewed_at   DATETIME    NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, entity_type, entity_id),
+    INDEX idx_entity_views_recent (tenant_id, user_id, viewed_at)
+);
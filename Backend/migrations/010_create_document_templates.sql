@@ -0,0 +1,14 @@
+-- The branding printed on each tenant's receipts and statements: company details,
+-- header and footer text, terms and the logo image. One row per tenant.
+CREATE TABLE IF NOT EXISTS document_templates (
+    tenant_id         VARCHAR(36)   NOT NULL PRIMARY KEY,
+    company_name      VARCHAR(200)  NOT NULL DEFAULT '',
+    address           VARCHAR(500)  NOT NULL DEFAULT '',
+    header_text       VARCHAR(1000) NOT NULL DEFAULT '',
+    footer_text       VARCHAR(1000) NOT NULL DEFAULT '',
+    terms             VARCHAR(4000) NOT NULL DEFAULT '',
+    logo              MEDIUMBLOB    NULL,
+    logo_content_type VARCHAR(32)   NULL,
+    updated_by        VARCHAR(36)   NOT NULL,
+    updated_at        DATETIME      NOT NULL
+);
@@ -0,0 +1,21 @@
+-- Cash register sessions, from opening the drawer with a float to counting it at
+-- close. denominations holds the bills and coins counted, as a JSON array.
+CREATE TABLE IF NOT EXISTS register_sessions (
+    id            VARCHAR(36)   NOT NULL PRIMARY KEY,
+    tenant_id     VARCHAR(36)   NOT NULL,
+    opened_by     VARCHAR(36)   NOT NULL,
+    opened_at     DATETIME      NOT NULL,
+    opening_float DECIMAL(12,2) NOT NULL DEFAULT 0,
+    status        VARCHAR(16)   NOT NULL DEFAULT 'open',
+    closed_by     VARCHAR(36)   NOT NULL DEFAULT '',
+    closed_at     DATETIME      NULL,
+    denominations JSON          NULL,
+    counted_cash  DECIMAL(12,2) NOT NULL DEFAULT 0,
+    expected_cash DECIMAL(12,2) NOT NULL DEFAULT 0,
+    variance      DECIMAL(12,2) NOT NULL DEFAULT 0,
+    sales_total   DECIMAL(12,2) NOT NULL DEFAULT 0,
+    sales_count   INT           NOT NULL DEFAULT 0,
+    notes         VARCHAR(1000) NOT NULL DEFAULT '',
+    INDEX idx_register_sessions_user (tenant_id, opened_by, status),
+    INDEX idx_register_sessions_closed (tenant_id, closed_at)
+);
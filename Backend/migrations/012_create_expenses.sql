@@ -0,0 +1,34 @@
+-- Yard expenses such as fuel, repairs and utilities, reviewed by an admin before
+-- they count in reports. expense_date is stored like sale dates, as YYYY-MM-DD.
+CREATE TABLE IF NOT EXISTS expenses (
+    id           VARCHAR(36)   NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)   NOT NULL,
+    category     VARCHAR(32)   NOT NULL,
+    description  VARCHAR(500)  NOT NULL,
+    vendor       VARCHAR(200)  NOT NULL DEFAULT '',
+    amount       DECIMAL(12,2) NOT NULL,
+    expense_date VARCHAR(10)   NOT NULL,
+    status       VARCHAR(16)   NOT NULL DEFAULT 'pending',
+    submitted_by VARCHAR(36)   NOT NULL,
+    reviewed_by  VARCHAR(36)   NOT NULL DEFAULT '',
+    reviewed_at  DATETIME      NULL,
+    review_note  VARCHAR(500)  NOT NULL DEFAULT '',
+    created_at   DATETIME      NOT NULL,
+    updated_at   DATETIME      NOT NULL,
+    INDEX idx_expenses_date (tenant_id, expense_date),
+    INDEX idx_expenses_status (tenant_id, status)
+);
+
+-- Receipts and invoices attached to expenses, stored with their contents.
+CREATE TABLE IF NOT EXISTS expense_attachments (
+    id           VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)  NOT NULL,
+    expense_id   VARCHAR(36)  NOT NULL,
+    file_name    VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64)  NOT NULL,
+    size         INT          NOT NULL,
+    data         MEDIUMBLOB   NOT NULL,
+    uploaded_by  VARCHAR(36)  NOT NULL,
+    uploaded_at  DATETIME     NOT NULL,
+    INDEX idx_expense_attachments_expense (tenant_id, expense_id)
+);
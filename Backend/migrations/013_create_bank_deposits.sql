@@ -0,0 +1,24 @@
+-- Bank deposits of register cash. bank_deposit_sessions links each deposit to the
+-- register sessions it covers; the unique key keeps a session from being deposited twice.
+CREATE TABLE IF NOT EXISTS bank_deposits (
+    id              VARCHAR(36)   NOT NULL PRIMARY KEY,
+    tenant_id       VARCHAR(36)   NOT NULL,
+    bank            VARCHAR(100)  NOT NULL,
+    reference       VARCHAR(100)  NOT NULL,
+    amount          DECIMAL(12,2) NOT NULL,
+    deposit_date    VARCHAR(10)   NOT NULL,
+    expected_amount DECIMAL(12,2) NOT NULL,
+    difference      DECIMAL(12,2) NOT NULL,
+    recorded_by     VARCHAR(36)   NOT NULL,
+    notes           VARCHAR(1000) NOT NULL DEFAULT '',
+    created_at      DATETIME      NOT NULL,
+    INDEX idx_bank_deposits_date (tenant_id, deposit_date)
+);
+
+CREATE TABLE IF NOT EXISTS bank_deposit_sessions (
+    tenant_id  VARCHAR(36) NOT NULL,
+    deposit_id VARCHAR(36) NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (tenant_id, session_id),
+    INDEX idx_bank_deposit_sessions_deposit (tenant_id, deposit_id)
+);
@@ -0,0 +1,9 @@
+-- Tax type of each sale for BIR filing, and the VAT included in its total price.
+-- Existing sales were all vatable at 12%.
+ALTER TABLE sales
+    ADD COLUMN tax_type VARCHAR(16) NOT NULL DEFAULT 'vatable' AFTER total_price,
+    ADD COLUMN vat_amount DECIMAL(12,2) NOT NULL DEFAULT 0 AFTER tax_type;
+
+UPDATE sales SET vat_amount = ROUND(total_price * 12 / 112, 2) WHERE tax_type = 'vatable';
+
+CREATE INDEX idx_sales_tax ON sales (tenant_id, sale_date, tax_type);
@@ -0,0 +1,32 @@
+-- Official receipt (OR) series registered with the BIR per branch, and the OR
+-- numbers issued to sales from them. next_number passes end_number once a series
+-- is used up; the keys of official_receipts keep a sale from getting two numbers
+-- and a number from being issued twice.
+CREATE TABLE IF NOT EXISTS receipt_series (
+    id             VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id      VARCHAR(36)  NOT NULL,
+    branch         VARCHAR(50)  NOT NULL,
+    prefix         VARCHAR(20)  NOT NULL DEFAULT '',
+    start_number   INT          NOT NULL,
+    end_number     INT          NOT NULL,
+    next_number    INT          NOT NULL,
+    warn_remaining INT          NOT NULL DEFAULT 0,
+    active         BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_by     VARCHAR(36)  NOT NULL,
+    created_at     DATETIME     NOT NULL,
+    updated_at     DATETIME     NOT NULL,
+    INDEX idx_receipt_series_branch (tenant_id, branch, active, start_number)
+);
+
+CREATE TABLE IF NOT EXISTS official_receipts (
+    tenant_id  VARCHAR(36) NOT NULL,
+    sale_id    VARCHAR(64) NOT NULL,
+    series_id  VARCHAR(36) NOT NULL,
+    branch     VARCHAR(50) NOT NULL,
+    number     INT         NOT NULL,
+    or_number  VARCHAR(50) NOT NULL,
+    issued_by  VARCHAR(36) NOT NULL,
+    issued_at  DATETIME    NOT NULL,
+    PRIMARY KEY (tenant_id, sale_id),
+    UNIQUE KEY uq_official_receipts_number (tenant_id, series_id, number)
+);
@@ -0,0 +1,9 @@
+-- The month each tenant's fiscal year starts in, for reports grouped by fiscal
+-- periods. One row per tenant; tenants without one use the calendar year.
+CREATE TABLE IF NOT EXISTS fiscal_calendars (
+    tenant_id       VARCHAR(36) NOT NULL PRIMARY KEY,
+    start_month     TINYINT     NOT NULL DEFAULT 1,
+    named_after_end BOOLEAN     NOT NULL DEFAULT FALSE,
+    updated_by      VARCHAR(36) NOT NULL,
+    updated_at      DATETIME    NOT NULL
+);
@@ -0,0 +1,23 @@
+-- End-of-month inventory positions, kept so finance can reconcile a month after
+-- stock has moved. Snapshots are taken by the month-end job; the items are the
+-- cabs, accessories and materials in stock when it ran, valued at their price then.
+CREATE TABLE IF NOT EXISTS inventory_snapshots (
+    tenant_id      VARCHAR(36)   NOT NULL,
+    month          CHAR(7)       NOT NULL,
+    taken_at       DATETIME      NOT NULL,
+    total_quantity INT           NOT NULL,
+    total_value    DECIMAL(14,2) NOT NULL,
+    PRIMARY KEY (tenant_id, month)
+);
+
+CREATE TABLE IF NOT EXISTS inventory_snapshot_items (
+    tenant_id  VARCHAR(36)   NOT NULL,
+    month      CHAR(7)       NOT NULL,
+    item_type  VARCHAR(20)   NOT NULL,
+    item_id    INT           NOT NULL,
+    name       VARCHAR(255)  NOT NULL,
+    quantity   INT           NOT NULL,
+    unit_price DECIMAL(12,2) NOT NULL,
+    value      DECIMAL(14,2) NOT NULL,
+    PRIMARY KEY (tenant_id, month, item_type, item_id)
+);
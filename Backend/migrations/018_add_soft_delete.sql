@@ -0,0 +1,9 @@
+-- Deleted customers, accessories and materials are kept, marked with when they were
+-- deleted, so a delete can be undone. Queries skip rows with deleted_at set.
+ALTER TABLE customers ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE accessories ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE materials ADD COLUMN deleted_at DATETIME NULL;
+
+CREATE INDEX idx_customers_deleted ON customers (tenant_id, deleted_at);
+CREATE INDEX idx_accessories_deleted ON accessories (tenant_id, deleted_at);
+CREATE INDEX idx_materials_deleted ON materials (tenant_id, deleted_at);
@@ -0,0 +1,4 @@
+-- Who deleted each customer, accessory and material, shown in the trash.
+ALTER TABLE customers ADD COLUMN deleted_by VARCHAR(36) NULL;
+ALTER TABLE accessories ADD COLUMN deleted_by VARCHAR(36) NULL;
+ALTER TABLE materials ADD COLUMN deleted_by VARCHAR(36) NULL;
@@ -0,0 +1,9 @@
+-- When each user last signed in or was reactivated, and whether the dormant account
+-- policy skips them. Users who never signed in count as inactive since their account
+-- was created.
+ALTER TABLE users
+    ADD COLUMN last_login_at DATETIME NULL,
+    ADD COLUMN reactivated_at DATETIME NULL,
+    ADD COLUMN dormancy_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_users_dormancy ON users (tenant_id, is_active, last_login_at);
@@ -0,0 +1,34 @@
+-- Partner systems, such as online marketplaces, that post signed payloads to
+-- /api/integrations/inbound. The secret signs their requests, so it is stored as is.
+CREATE TABLE IF NOT EXISTS integrations (
+    id         VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id  VARCHAR(36)  NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    secret     VARCHAR(128) NOT NULL,
+    sold_by    VARCHAR(36)  NOT NULL, -- User the sales of the integration are recorded under
+    active     BOOLEAN      NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(36)  NOT NULL,
+    created_at DATETIME     NOT NULL,
+    updated_at DATETIME     NOT NULL,
+    INDEX idx_integrations_tenant (tenant_id, created_at)
+);
+
+-- Nonces of recently accepted requests; a nonce is only accepted once per integration
+CREATE TABLE IF NOT EXISTS integration_nonces (
+    tenant_id      VARCHAR(36)  NOT NULL,
+    integration_id VARCHAR(36)  NOT NULL,
+    nonce          VARCHAR(100) NOT NULL,
+    received_at    DATETIME     NOT NULL,
+    PRIMARY KEY (tenant_id, integration_id, nonce),
+    INDEX idx_integration_nonces_received (received_at)
+);
+
+-- The sale each partner order was converted into, so a resent order is not sold twice
+CREATE TABLE IF NOT EXISTS integration_orders (
+    tenant_id      VARCHAR(36)  NOT NULL,
+    integration_id VARCHAR(36)  NOT NULL,
+    external_id    VARCHAR(100) NOT NULL,
+    sale_id        VARCHAR(64)  NOT NULL,
+    created_at     DATETIME     NOT NULL,
+    PRIMARY KEY (tenant_id, integration_id, external_id)
+);
@@ -0,0 +1,17 @@
+-- What was posted to the accounting system: one row per tenant, kind (the day's
+-- sales journal or its payments) and day. Failed postings are retried by the
+-- accounting sync job until they succeed or run out of attempts.
+CREATE TABLE IF NOT EXISTS accounting_postings (
+    tenant_id    VARCHAR(36)   NOT NULL,
+    kind         VARCHAR(20)   NOT NULL,
+    posting_date DATE          NOT NULL,
+    status       VARCHAR(10)   NOT NULL,
+    attempts     INT           NOT NULL DEFAULT 0,
+    last_error   TEXT          NULL,
+    external_id  VARCHAR(255)  NULL,
+    amount       DECIMAL(14,2) NOT NULL DEFAULT 0,
+    posted_at    DATETIME      NULL,
+    updated_at   DATETIME      NOT NULL,
+    PRIMARY KEY (tenant_id, kind, posting_date),
+    INDEX idx_accounting_postings_status (tenant_id, status)
+);
@@ -0,0 +1,11 @@
+-- The Google Sheets spreadsheet each tenant's reports are exported to, and how the
+-- last export went. Reports is a comma-separated list, e.g. daily_sales,low_stock.
+CREATE TABLE IF NOT EXISTS google_sheets_exports (
+    tenant_id      VARCHAR(36)  NOT NULL PRIMARY KEY,
+    spreadsheet_id VARCHAR(100) NOT NULL,
+    reports        VARCHAR(255) NOT NULL,
+    updated_by     VARCHAR(36)  NOT NULL,
+    updated_at     DATETIME     NOT NULL,
+    last_export_at DATETIME     NULL,
+    last_error     TEXT         NULL
+);
@@ -0,0 +1,10 @@
+-- iCal feed subscriptions created through POST /api/users/me/calendar, one per user.
+-- Only a SHA-256 hash of the feed token is stored.
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    tenant_id       VARCHAR(36) NOT NULL,
+    user_id         VARCHAR(36) NOT NULL,
+    token_hash      CHAR(64)    NOT NULL UNIQUE,
+    created_at      DATETIME    NOT NULL,
+    last_fetched_at DATETIME    NULL,
+    PRIMARY KEY (tenant_id, user_id)
+);
@@ -0,0 +1,7 @@
+-- Quantities of cabs, accessories and materials can no longer go below zero. Enforced
+-- by MySQL 8.0.16 and later; the repositories refuse such writes as well.
+-- The ALTERs fail while negative rows exist: zero them first with
+-- POST /api/admin/negative-stock/repair, which records each correction.
+ALTER TABLE multicabs ADD CONSTRAINT multicabs_quantity_non_negative CHECK (quantity >= 0);
+ALTER TABLE accessories ADD CONSTRAINT accessories_quantity_non_negative CHECK (quantity >= 0);
+ALTER TABLE materials ADD CONSTRAINT materials_quantity_non_negative CHECK (quantity >= 0);
@@ -0,0 +1,8 @@
+-- Optional range of prices each cab and accessory may be priced and sold at, to
+-- catch typos such as 70 for 700,000. NULL leaves that side unchecked.
+ALTER TABLE multicabs
+    ADD COLUMN min_price DECIMAL(12,2) NULL AFTER price,
+    ADD COLUMN max_price DECIMAL(12,2) NULL AFTER min_price;
+ALTER TABLE accessories
+    ADD COLUMN min_price DECIMAL(12,2) NULL AFTER price,
+    ADD COLUMN max_price DECIMAL(12,2) NULL AFTER min_price;
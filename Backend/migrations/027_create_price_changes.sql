@@ -0,0 +1,19 @@
+-- Price changes of cabs and accessories larger than PRICE_APPROVAL_THRESHOLD_PERCENT,
+-- held until an admin approves or rejects them through /api/price-changes.
+CREATE TABLE IF NOT EXISTS price_changes (
+    id           VARCHAR(36)   NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)   NOT NULL,
+    item_type    VARCHAR(20)   NOT NULL,
+    item_id      INT           NOT NULL,
+    item_name    VARCHAR(255)  NOT NULL,
+    old_price    DECIMAL(12,2) NOT NULL,
+    new_price    DECIMAL(12,2) NOT NULL,
+    status       VARCHAR(20)   NOT NULL DEFAULT 'pending',
+    requested_by VARCHAR(36)   NOT NULL,
+    requested_at DATETIME      NOT NULL,
+    reviewed_by  VARCHAR(36)   NULL,
+    reviewed_at  DATETIME      NULL,
+    note         VARCHAR(500)  NULL,
+    INDEX idx_price_changes_status (tenant_id, status, requested_at),
+    INDEX idx_price_changes_item (tenant_id, item_type, item_id, status)
+);
@@ -0,0 +1,19 @@
+-- Edits of cabs, accessories and materials proposed through /api/change-requests by
+-- users without the inventory.write permission, applied once a reviewer accepts them.
+CREATE TABLE IF NOT EXISTS change_requests (
+    id           VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)  NOT NULL,
+    entity_type  VARCHAR(20)  NOT NULL,
+    entity_id    INT          NOT NULL,
+    entity_name  VARCHAR(255) NOT NULL,
+    changes      JSON         NOT NULL,
+    reason       VARCHAR(500) NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    requested_by VARCHAR(36)  NOT NULL,
+    requested_at DATETIME     NOT NULL,
+    reviewed_by  VARCHAR(36)  NULL,
+    reviewed_at  DATETIME     NULL,
+    note         VARCHAR(500) NULL,
+    INDEX idx_change_requests_status (tenant_id, status, requested_at),
+    INDEX idx_change_requests_requester (tenant_id, requested_by, requested_at)
+);
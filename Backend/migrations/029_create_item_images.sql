@@ -0,0 +1,17 @@
+-- Photo galleries of cabs, accessories and materials, in display order. Exactly one
+-- photo of each gallery is primary; the image column of the item is left as it was.
+CREATE TABLE IF NOT EXISTS item_images (
+    id           VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)  NOT NULL,
+    item_type    VARCHAR(20)  NOT NULL,
+    item_id      INT          NOT NULL,
+    file_name    VARCHAR(255) NOT NULL,
+    content_type VARCHAR(64)  NOT NULL,
+    size         INT          NOT NULL,
+    data         MEDIUMBLOB   NOT NULL,
+    position     INT          NOT NULL,
+    is_primary   BOOLEAN      NOT NULL DEFAULT FALSE,
+    uploaded_by  VARCHAR(36)  NOT NULL,
+    uploaded_at  DATETIME     NOT NULL,
+    INDEX idx_item_images_item (tenant_id, item_type, item_id, position)
+);
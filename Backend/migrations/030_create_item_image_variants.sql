@@ -0,0 +1,14 @@
+-- Smaller copies of gallery photos, made in the background after each upload. A
+-- photo without a variant of a size is served in its original size instead.
+CREATE TABLE IF NOT EXISTS item_image_variants (
+    image_id     VARCHAR(36) NOT NULL,
+    tenant_id    VARCHAR(36) NOT NULL,
+    size         VARCHAR(16) NOT NULL,
+    content_type VARCHAR(64) NOT NULL,
+    width        INT         NOT NULL,
+    height       INT         NOT NULL,
+    file_size    INT         NOT NULL,
+    data         MEDIUMBLOB  NOT NULL,
+    PRIMARY KEY (image_id, size),
+    INDEX idx_item_image_variants_tenant (tenant_id, image_id)
+);
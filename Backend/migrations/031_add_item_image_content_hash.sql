@@ -0,0 +1,4 @@
+-- SHA-256 of each gallery photo, to spot the same photo attached to several items.
+-- Photos uploaded before this migration keep an empty hash and are not compared.
+ALTER TABLE item_images ADD COLUMN content_hash CHAR(64) NOT NULL DEFAULT '' AFTER size;
+CREATE INDEX idx_item_images_content_hash ON item_images (tenant_id, content_hash);
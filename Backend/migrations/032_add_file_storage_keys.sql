@@ -0,0 +1,6 @@
+-- Where the contents of photos and attachments are kept when a storage driver is
+-- configured. Rows with an empty storage_key keep their contents in data, so rows
+-- written before a driver was set are still served.
+ALTER TABLE item_images ADD COLUMN storage_key VARCHAR(255) NOT NULL DEFAULT '' AFTER content_hash;
+ALTER TABLE item_image_variants ADD COLUMN storage_key VARCHAR(255) NOT NULL DEFAULT '' AFTER file_size;
+ALTER TABLE expense_attachments ADD COLUMN storage_key VARCHAR(255) NOT NULL DEFAULT '' AFTER size;
@@ -0,0 +1,16 @@
+-- Refresh tokens issued on sign-in and exchanged through POST /api/users/refresh.
+-- Each exchange revokes the token and issues its replacement in the same family, so
+-- reusing a revoked token revokes the whole family. Only a SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id   VARCHAR(36) NOT NULL,
+    user_id     VARCHAR(36) NOT NULL,
+    family_id   VARCHAR(36) NOT NULL,
+    token_hash  CHAR(64)    NOT NULL UNIQUE,
+    expires_at  DATETIME    NOT NULL,
+    created_at  DATETIME    NOT NULL,
+    revoked_at  DATETIME    NULL,
+    replaced_by VARCHAR(36) NOT NULL DEFAULT '',
+    INDEX idx_refresh_tokens_family (tenant_id, family_id),
+    INDEX idx_refresh_tokens_user (tenant_id, user_id)
+);
@@ -0,0 +1,24 @@
+-- Exports requested through POST /api/exports and built in the background. The CSV
+-- is kept in data, or in file storage under storage_key when a storage driver is
+-- configured, until expires_at; expired exports are deleted with their files.
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id           VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id    VARCHAR(36)  NOT NULL,
+    type         VARCHAR(20)  NOT NULL,
+    filters      TEXT         NOT NULL,
+    status       VARCHAR(20)  NOT NULL,
+    progress     INT          NOT NULL DEFAULT 0,
+    row_count    INT          NOT NULL DEFAULT 0,
+    file_name    VARCHAR(255) NOT NULL DEFAULT '',
+    size         INT          NOT NULL DEFAULT 0,
+    error        TEXT         NOT NULL,
+    requested_by VARCHAR(36)  NOT NULL,
+    created_at   DATETIME     NOT NULL,
+    started_at   DATETIME     NULL,
+    completed_at DATETIME     NULL,
+    expires_at   DATETIME     NOT NULL,
+    storage_key  VARCHAR(255) NOT NULL DEFAULT '',
+    data         LONGBLOB     NULL,
+    INDEX idx_export_jobs_user (tenant_id, requested_by, created_at),
+    INDEX idx_export_jobs_expiry (expires_at)
+);
@@ -0,0 +1,23 @@
+-- Unusual sales and stock movements flagged by the nightly anomaly scan, held for an
+-- admin to acknowledge or dismiss through /api/anomalies. A sale item or activity log
+-- entry is flagged once per kind.
+CREATE TABLE IF NOT EXISTS anomalies (
+    id          VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id   VARCHAR(36)  NOT NULL,
+    kind        VARCHAR(30)  NOT NULL,
+    source_id   VARCHAR(64)  NOT NULL,
+    entity_type VARCHAR(50)  NOT NULL,
+    entity_id   VARCHAR(64)  NOT NULL,
+    details     VARCHAR(500) NOT NULL,
+    occurred_at DATETIME     NOT NULL,
+    status      VARCHAR(20)  NOT NULL DEFAULT 'open',
+    detected_at DATETIME     NOT NULL,
+    reviewed_by VARCHAR(36)  NULL,
+    reviewed_at DATETIME     NULL,
+    note        VARCHAR(500) NULL,
+    UNIQUE KEY uq_anomalies_source (tenant_id, kind, source_id),
+    INDEX idx_anomalies_status (tenant_id, status, occurred_at)
+);
+
+-- Finding stock adjustments and deleted sales reads the activity log by action and time
+CREATE INDEX idx_activity_logs_action_time ON activity_logs (tenant_id, action_type, timestamp);
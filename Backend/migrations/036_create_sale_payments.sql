@@ -0,0 +1,22 @@
+-- Money received for each sale. Sales are paid in full when they are recorded, so the
+-- payments of a sale add up to its total; the reconciliation job flags the sales where
+-- they do not. Payments of a deleted sale are kept for the record.
+CREATE TABLE IF NOT EXISTS sale_payments (
+    id          VARCHAR(36)   NOT NULL PRIMARY KEY,
+    tenant_id   VARCHAR(36)   NOT NULL,
+    sale_id     VARCHAR(36)   NOT NULL,
+    amount      DECIMAL(12,2) NOT NULL,
+    method      VARCHAR(20)   NOT NULL,
+    reference   VARCHAR(100)  NULL,
+    received_by VARCHAR(36)   NOT NULL,
+    received_at DATETIME      NOT NULL,
+    INDEX idx_sale_payments_sale (tenant_id, sale_id),
+    CONSTRAINT chk_sale_payments_amount CHECK (amount > 0)
+);
+
+-- Record the payment of every sale made before payments were recorded
+INSERT INTO sale_payments (id, tenant_id, sale_id, amount, method, reference, received_by, received_at)
+SELECT UUID(), s.tenant_id, s.id, s.total_price, 'cash', NULL, s.sold_by, s.created_at
+FROM sales s
+WHERE s.total_price > 0
+  AND NOT EXISTS (SELECT 1 FROM sale_payments p WHERE p.tenant_id = s.tenant_id AND p.sale_id = s.id);
@@ -0,0 +1,29 @@
+-- Shift hours during which users of a role may sign in and make changes, by branch.
+-- A tenant without schedules is not restricted. Days are ISO weekdays separated by
+-- commas, such as 1,2,3,4,5, or empty for every day; times are HH:MM in server time.
+CREATE TABLE IF NOT EXISTS shift_schedules (
+    id         VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id  VARCHAR(36) NOT NULL,
+    role       VARCHAR(20) NOT NULL,
+    branch     VARCHAR(50) NOT NULL DEFAULT '',
+    days       VARCHAR(20) NOT NULL DEFAULT '',
+    start_time CHAR(5)     NOT NULL,
+    end_time   CHAR(5)     NOT NULL,
+    INDEX idx_shift_schedules_tenant (tenant_id, role, branch)
+);
+
+-- Branch whose shift schedules apply to each user; NULL for the schedules of their role
+ALTER TABLE users
+    ADD COLUMN shift_branch VARCHAR(50) NULL;
+
+-- Time-limited permissions admins grant users to work outside their shift
+CREATE TABLE IF NOT EXISTS shift_overrides (
+    id         VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id  VARCHAR(36)  NOT NULL,
+    user_id    VARCHAR(36)  NOT NULL,
+    reason     VARCHAR(255) NOT NULL,
+    granted_by VARCHAR(36)  NOT NULL,
+    created_at DATETIME     NOT NULL,
+    expires_at DATETIME     NOT NULL,
+    INDEX idx_shift_overrides_user (tenant_id, user_id, expires_at)
+);
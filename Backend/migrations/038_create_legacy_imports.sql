@@ -0,0 +1,36 @@
+-- Imports of spreadsheets from the legacy yard system, run in batches. next_row is
+-- how many data rows of the file were processed, so a stopped import resumes there
+-- when the same file (by checksum) is uploaded again. mapping is the JSON object of
+-- field names to the spreadsheet's column headers.
+CREATE TABLE IF NOT EXISTS legacy_import_runs (
+    id         VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id  VARCHAR(36)  NOT NULL,
+    kind       VARCHAR(20)  NOT NULL,
+    file_name  VARCHAR(255) NOT NULL DEFAULT '',
+    checksum   CHAR(64)     NOT NULL,
+    mapping    TEXT         NOT NULL,
+    status     VARCHAR(20)  NOT NULL,
+    total_rows INT          NOT NULL DEFAULT 0,
+    next_row   INT          NOT NULL DEFAULT 0,
+    inserted   INT          NOT NULL DEFAULT 0,
+    matched    INT          NOT NULL DEFAULT 0,
+    skipped    INT          NOT NULL DEFAULT 0,
+    rejected   INT          NOT NULL DEFAULT 0,
+    error      TEXT         NOT NULL,
+    started_by VARCHAR(36)  NOT NULL,
+    created_at DATETIME     NOT NULL,
+    updated_at DATETIME     NOT NULL,
+    INDEX idx_legacy_import_runs_tenant (tenant_id, created_at)
+);
+
+-- Record each legacy row was imported as, by the key of the row in the legacy
+-- system. Rows already recorded are skipped when a file is imported again, and
+-- historical sales find their customer and item through it.
+CREATE TABLE IF NOT EXISTS legacy_import_records (
+    tenant_id  VARCHAR(36)  NOT NULL,
+    kind       VARCHAR(20)  NOT NULL,
+    legacy_key VARCHAR(255) NOT NULL,
+    entity_id  VARCHAR(36)  NOT NULL,
+    created_at DATETIME     NOT NULL,
+    PRIMARY KEY (tenant_id, kind, legacy_key)
+);
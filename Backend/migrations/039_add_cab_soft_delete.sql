@@ -0,0 +1,6 @@
+-- Deleted cabs are kept like deleted customers, accessories and materials, so the
+-- sales that reference them keep their history and a delete can be restored.
+ALTER TABLE multicabs ADD COLUMN deleted_at DATETIME NULL;
+ALTER TABLE multicabs ADD COLUMN deleted_by VARCHAR(36) NULL;
+
+CREATE INDEX idx_multicabs_deleted ON multicabs (tenant_id, deleted_at);
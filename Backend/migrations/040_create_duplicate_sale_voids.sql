@@ -0,0 +1,16 @@
+-- Sales voided as duplicates of another sale, entered twice by mistake. The voided
+-- sale and its items are deleted; its customer, date, total and seller are kept here
+-- with the sale it duplicated, so the void can be traced in reports and audits.
+CREATE TABLE IF NOT EXISTS duplicate_sale_voids (
+    sale_id      VARCHAR(36)   NOT NULL,
+    tenant_id    VARCHAR(36)   NOT NULL,
+    duplicate_of VARCHAR(36)   NOT NULL,
+    customer_id  VARCHAR(36)   NOT NULL,
+    sale_date    DATE          NOT NULL,
+    total_price  DECIMAL(12,2) NOT NULL,
+    sold_by      VARCHAR(36)   NOT NULL,
+    voided_by    VARCHAR(36)   NOT NULL,
+    voided_at    DATETIME      NOT NULL,
+    PRIMARY KEY (tenant_id, sale_id),
+    INDEX idx_duplicate_sale_voids_date (tenant_id, sale_date)
+);
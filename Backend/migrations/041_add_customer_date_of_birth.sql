@@ -0,0 +1,3 @@
+-- Customers' dates of birth, for birthday greetings and promotions. Stored like sale
+-- dates, as YYYY-MM-DD, and empty when unknown.
+ALTER TABLE customers ADD COLUMN date_of_birth VARCHAR(10) NOT NULL DEFAULT '' AFTER address;
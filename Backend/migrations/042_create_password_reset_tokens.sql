@@ -0,0 +1,13 @@
+-- One-time tokens of the password reset links emailed through
+-- POST /api/users/forgot-password and used by POST /api/users/reset-password. A token
+-- is spent once used or once a newer one is requested. Only a SHA-256 hash is stored.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id         VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id  VARCHAR(36) NOT NULL,
+    user_id    VARCHAR(36) NOT NULL,
+    token_hash CHAR(64)    NOT NULL UNIQUE,
+    expires_at DATETIME    NOT NULL,
+    created_at DATETIME    NOT NULL,
+    used_at    DATETIME    NULL,
+    INDEX idx_password_reset_tokens_user (tenant_id, user_id)
+);
@@ -0,0 +1,15 @@
+-- Marketing consent and do-not-contact flags of customers, set through
+-- PUT /api/customers/:id/consent with when and how each was last set. Customers without
+-- a row have not consented to marketing.
+CREATE TABLE IF NOT EXISTS customer_consents (
+    tenant_id                VARCHAR(36)  NOT NULL,
+    customer_id              VARCHAR(36)  NOT NULL,
+    marketing_consent        BOOLEAN      NOT NULL DEFAULT FALSE,
+    marketing_consent_at     DATETIME     NULL,
+    marketing_consent_source VARCHAR(100) NOT NULL DEFAULT '',
+    do_not_contact           BOOLEAN      NOT NULL DEFAULT FALSE,
+    do_not_contact_at        DATETIME     NULL,
+    do_not_contact_source    VARCHAR(100) NOT NULL DEFAULT '',
+    updated_by               VARCHAR(36)  NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant_id, customer_id)
+);
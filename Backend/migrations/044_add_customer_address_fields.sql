@@ -0,0 +1,9 @@
+-- Structured customer addresses for delivery planning. address keeps the house number
+-- and street; province, city and barangay are validated against the location dataset,
+-- and latitude and longitude are set when a geocoder located the address.
+ALTER TABLE customers
+    ADD COLUMN province  VARCHAR(100)  NOT NULL DEFAULT '' AFTER address,
+    ADD COLUMN city      VARCHAR(100)  NOT NULL DEFAULT '' AFTER province,
+    ADD COLUMN barangay  VARCHAR(100)  NOT NULL DEFAULT '' AFTER city,
+    ADD COLUMN latitude  DECIMAL(9, 6) NULL AFTER barangay,
+    ADD COLUMN longitude DECIMAL(9, 6) NULL AFTER latitude;
@@ -0,0 +1,23 @@
+-- LTO registration paperwork of each sold cab unit, from submitting the documents to
+-- the release of the OR/CR. Dates are YYYY-MM-DD text, like sale dates, and empty
+-- until they happen.
+CREATE TABLE IF NOT EXISTS cab_registrations (
+    id             VARCHAR(36)  NOT NULL PRIMARY KEY,
+    tenant_id      VARCHAR(36)  NOT NULL,
+    sale_id        VARCHAR(36)  NOT NULL,
+    cab_id         INT          NOT NULL,
+    customer_id    VARCHAR(36)  NOT NULL,
+    status         VARCHAR(20)  NOT NULL,
+    due_date       VARCHAR(10)  NOT NULL,
+    submitted_date VARCHAR(10)  NOT NULL DEFAULT '',
+    released_date  VARCHAR(10)  NOT NULL DEFAULT '',
+    or_number      VARCHAR(50)  NOT NULL DEFAULT '',
+    cr_number      VARCHAR(50)  NOT NULL DEFAULT '',
+    plate_number   VARCHAR(20)  NOT NULL DEFAULT '',
+    notes          TEXT         NULL,
+    updated_by     VARCHAR(36)  NOT NULL DEFAULT '',
+    created_at     DATETIME     NOT NULL,
+    updated_at     DATETIME     NOT NULL,
+    INDEX idx_cab_registrations_sale (tenant_id, sale_id),
+    INDEX idx_cab_registrations_due (tenant_id, status, due_date)
+);
@@ -0,0 +1,22 @@
+-- Insurance policies sold or arranged for customers with a sale. Dates are YYYY-MM-DD
+-- text, like sale dates; renewals name the policy they renew.
+CREATE TABLE IF NOT EXISTS insurance_policies (
+    id            VARCHAR(36)    NOT NULL PRIMARY KEY,
+    tenant_id     VARCHAR(36)    NOT NULL,
+    sale_id       VARCHAR(36)    NOT NULL,
+    customer_id   VARCHAR(36)    NOT NULL,
+    provider      VARCHAR(100)   NOT NULL,
+    policy_number VARCHAR(50)    NOT NULL,
+    coverage      VARCHAR(20)    NOT NULL,
+    premium       DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    start_date    VARCHAR(10)    NOT NULL,
+    expiry_date   VARCHAR(10)    NOT NULL,
+    renewal_of    VARCHAR(36)    NOT NULL DEFAULT '',
+    notes         TEXT           NULL,
+    created_by    VARCHAR(36)    NOT NULL DEFAULT '',
+    created_at    DATETIME       NOT NULL,
+    updated_at    DATETIME       NOT NULL,
+    INDEX idx_insurance_policies_sale (tenant_id, sale_id),
+    INDEX idx_insurance_policies_customer (tenant_id, customer_id),
+    INDEX idx_insurance_policies_expiry (tenant_id, expiry_date)
+);
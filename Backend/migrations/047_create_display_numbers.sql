@@ -0,0 +1,29 @@
+-- Display numbers generated from numbering formats admins set per entity type, e.g.
+-- SALE-{YYYY}-{seq:5}. Each scope, the format with its date and branch filled in, has
+-- its own sequence; the numbers given are kept when a format changes.
+CREATE TABLE IF NOT EXISTS number_formats (
+    tenant_id   VARCHAR(36) NOT NULL,
+    entity_type VARCHAR(20) NOT NULL,
+    format      VARCHAR(50) NOT NULL,
+    updated_by  VARCHAR(36) NOT NULL DEFAULT '',
+    updated_at  DATETIME    NOT NULL,
+    PRIMARY KEY (tenant_id, entity_type)
+);
+
+CREATE TABLE IF NOT EXISTS number_sequences (
+    tenant_id   VARCHAR(36)  NOT NULL,
+    entity_type VARCHAR(20)  NOT NULL,
+    scope       VARCHAR(150) NOT NULL,
+    last_value  BIGINT       NOT NULL,
+    PRIMARY KEY (tenant_id, entity_type, scope)
+);
+
+CREATE TABLE IF NOT EXISTS display_numbers (
+    tenant_id   VARCHAR(36)  NOT NULL,
+    entity_type VARCHAR(20)  NOT NULL,
+    entity_id   VARCHAR(36)  NOT NULL,
+    number      VARCHAR(150) NOT NULL,
+    created_at  DATETIME     NOT NULL,
+    PRIMARY KEY (tenant_id, entity_type, entity_id),
+    INDEX idx_display_numbers_number (tenant_id, number)
+);
@@ -0,0 +1,12 @@
+-- External reference IDs of the items sold, e.g. cab:12 or accessory:4, in the form
+-- the API reports as itemRef. The column is derived from the per-type ID columns,
+-- which stay the stored values, so existing rows need no backfill and older clients
+-- keep working.
+ALTER TABLE sale_items
+    ADD COLUMN item_ref VARCHAR(80) GENERATED ALWAYS AS (CONCAT(item_type, ':', CASE item_type
+        WHEN 'cab' THEN COALESCE(multi_cab_id, '')
+        WHEN 'accessory' THEN COALESCE(accessory_id, '')
+        ELSE COALESCE(material_id, '')
+    END)) STORED;
+
+CREATE INDEX idx_sale_items_item_ref ON sale_items (tenant_id, item_ref);
@@ -0,0 +1,9 @@
+-- Sales sold on installment: a down payment, then the balance in later payments.
+-- Their payments may add up to less than the total without being a shortfall, so the
+-- reconciliation job only flags them when they are paid more than the total.
+CREATE TABLE IF NOT EXISTS installment_sales (
+    tenant_id  VARCHAR(36) NOT NULL,
+    sale_id    VARCHAR(36) NOT NULL,
+    created_at DATETIME    NOT NULL,
+    PRIMARY KEY (tenant_id, sale_id)
+);
@@ -0,0 +1,6 @@
+-- Where each sale is in its workflow: draft, confirmed, completed, cancelled or
+-- refunded. Sales recorded before statuses existed are confirmed.
+ALTER TABLE sales
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'confirmed';
+
+CREATE INDEX idx_sales_status ON sales (tenant_id, status);
@@ -0,0 +1,26 @@
+-- Returns of items of completed sales. The items returned are put back in stock, and
+-- a sale whose items were all returned is refunded. Refunds of a deleted sale are
+-- kept for the record, like its payments.
+CREATE TABLE IF NOT EXISTS sale_refunds (
+    id          VARCHAR(36)   NOT NULL PRIMARY KEY,
+    tenant_id   VARCHAR(36)   NOT NULL,
+    sale_id     VARCHAR(36)   NOT NULL,
+    amount      DECIMAL(12,2) NOT NULL,
+    reason      VARCHAR(255)  NULL,
+    refunded_by VARCHAR(36)   NOT NULL,
+    refunded_at DATETIME      NOT NULL,
+    INDEX idx_sale_refunds_sale (tenant_id, sale_id)
+);
+
+-- Units of each sale item returned by a refund, at the price they were sold at
+CREATE TABLE IF NOT EXISTS sale_refund_items (
+    tenant_id    VARCHAR(36)   NOT NULL,
+    refund_id    VARCHAR(36)   NOT NULL,
+    sale_id      VARCHAR(36)   NOT NULL,
+    sale_item_id VARCHAR(36)   NOT NULL,
+    quantity     INT           NOT NULL,
+    amount       DECIMAL(12,2) NOT NULL,
+    PRIMARY KEY (tenant_id, refund_id, sale_item_id),
+    INDEX idx_sale_refund_items_sale (tenant_id, sale_id),
+    CONSTRAINT chk_sale_refund_items_quantity CHECK (quantity > 0)
+);
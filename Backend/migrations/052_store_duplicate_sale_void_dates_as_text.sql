@@ -0,0 +1,4 @@
+-- Store the sale dates of duplicate sale voids like sale dates, as YYYY-MM-DD text,
+-- so voids read back the same way as the sales they were.
+ALTER TABLE duplicate_sale_voids
+    MODIFY COLUMN sale_date VARCHAR(10) NOT NULL;
@@ -0,0 +1,11 @@
+-- Whether recording a sale took its cabs and accessories out of stock, so cancelling
+-- or refunding it puts them back. Only cab sales took stock before this column
+-- existed; their cab items have IDs ending in _cab.
+ALTER TABLE sales
+    ADD COLUMN stock_taken BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE sales s SET stock_taken = TRUE
+WHERE EXISTS (
+    SELECT 1 FROM sale_items i
+    WHERE i.tenant_id = s.tenant_id AND i.sale_id = s.id AND i.id LIKE 'item\_%\_cab'
+);
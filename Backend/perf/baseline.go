@@ -0,0 +1,126 @@
+package perf
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Budget controls how far results may drift from the baseline before the gate fails
+type Budget struct {
+	Tolerance    float64 `json:"tolerance"`    // Allowed relative increase of the median and p95, e.g. 0.25 for +25%
+	SlackMs      float64 `json:"slackMs"`      // Absolute increase always allowed, so sub-millisecond noise never fails
+	MaxErrorRate float64 `json:"maxErrorRate"` // Highest acceptable fraction of failed requests
+}
+
+// DefaultBudget is used by baselines that don't define their own
+var DefaultBudget = Budget{Tolerance: 0.25, SlackMs: 2, MaxErrorRate: 0.01}
+
+// Baseline is a recorded set of results for one driver and load profile
+type Baseline struct {
+	Driver     string    `json:"driver"`
+	VUs        int       `json:"vus"`
+	Duration   string    `json:"duration"`
+	RecordedAt time.Time `json:"recordedAt"`
+	Notes      string    `json:"notes,omitempty"`
+	Budget     Budget    `json:"budget"`
+	Endpoints  Results   `json:"endpoints"`
+}
+
+// NewBaseline records results produced by a driver with the given options
+func NewBaseline(driver string, opts Options, results Results, notes string) *Baseline {
+	opts = opts.withDefaults()
+	return &Baseline{
+		Driver:     driver,
+		VUs:        opts.VUs,
+		Duration:   opts.Duration.String(),
+		RecordedAt: time.Now().UTC().Truncate(time.Second),
+		Notes:      notes,
+		Budget:     DefaultBudget,
+		Endpoints:  results,
+	}
+}
+
+// LoadBaseline reads a baseline from a JSON file
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("could not parse baseline %s: %w", path, err)
+	}
+	if baseline.Budget == (Budget{}) {
+		baseline.Budget = DefaultBudget
+	}
+	return &baseline, nil
+}
+
+// Save writes the baseline as indented JSON
+func (b *Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Regression describes one metric that exceeded its budget
+type Regression struct {
+	Endpoint string
+	Metric   string
+	Baseline float64
+	Actual   float64
+	Limit    float64
+}
+
+func (r Regression) String() string {
+	if r.Metric == "errorRate" {
+		return fmt.Sprintf("%s: error rate %.2f%% exceeds %.2f%%", r.Endpoint, r.Actual*100, r.Limit*100)
+	}
+	return fmt.Sprintf("%s: %s %.2fms exceeds %.2fms (baseline %.2fms)", r.Endpoint, r.Metric, r.Actual, r.Limit, r.Baseline)
+}
+
+// Compare checks results against the baseline. It fails when the results were produced
+// by another driver, since their numbers are not comparable. Endpoints missing from
+// either side are skipped; a newly measured endpoint has no budget until it is recorded.
+func (b *Baseline) Compare(driver string, results Results) ([]Regression, error) {
+	if driver != b.Driver {
+		return nil, fmt.Errorf("baseline was recorded with the %s driver, results come from %s; record a new baseline for this driver", b.Driver, driver)
+	}
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var regressions []Regression
+	for _, name := range names {
+		actual := results[name]
+		if actual.ErrorRate > b.Budget.MaxErrorRate {
+			regressions = append(regressions, Regression{Endpoint: name, Metric: "errorRate", Actual: actual.ErrorRate, Limit: b.Budget.MaxErrorRate})
+		}
+
+		expected, ok := b.Endpoints[name]
+		if !ok {
+			continue
+		}
+		for _, m := range []struct {
+			metric           string
+			baseline, actual float64
+		}{
+			{"median", expected.Median, actual.Median},
+			{"p95", expected.P95, actual.P95},
+		} {
+			limit := round(m.baseline*(1+b.Budget.Tolerance) + b.Budget.SlackMs)
+			if m.actual > limit {
+				regressions = append(regressions, Regression{Endpoint: name, Metric: m.metric, Baseline: m.baseline, Actual: m.actual, Limit: limit})
+			}
+		}
+	}
+	return regressions, nil
+}
@@ -0,0 +1,190 @@
+package perf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BuiltinDriver generates load with plain HTTP requests from this process. Each endpoint
+// is measured on its own for the full duration, so results are attributable.
+type BuiltinDriver struct{}
+
+// Name identifies the driver in baselines
+func (BuiltinDriver) Name() string { return "builtin" }
+
+// request describes how to call one endpoint
+type request struct {
+	method     string
+	path       string
+	body       []byte
+	wantStatus int
+}
+
+// Run logs in, discovers a cab and a customer to sell to, then measures every endpoint
+func (BuiltinDriver) Run(ctx context.Context, opts Options) (Results, error) {
+	opts = opts.withDefaults()
+	baseURL := strings.TrimRight(opts.BaseURL, "/")
+	client := &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: opts.VUs},
+	}
+
+	token, err := login(ctx, client, baseURL, opts.Email, opts.Password)
+	if err != nil {
+		return nil, err
+	}
+	cabID, customerID, err := discoverSaleTargets(ctx, client, baseURL, token)
+	if err != nil {
+		return nil, err
+	}
+
+	sale, _ := json.Marshal(map[string]interface{}{"customerId": customerID, "quantity": 1})
+	requests := map[string]request{
+		EndpointCabsList:        {http.MethodGet, "/api/cabs", nil, http.StatusOK},
+		EndpointAccessoriesList: {http.MethodGet, "/api/accessories", nil, http.StatusOK},
+		EndpointMaterialsList:   {http.MethodGet, "/api/materials", nil, http.StatusOK},
+		EndpointCustomersList:   {http.MethodGet, "/api/customers", nil, http.StatusOK},
+		EndpointSalesList:       {http.MethodGet, "/api/sales", nil, http.StatusOK},
+		EndpointSaleCreate:      {http.MethodPost, "/api/cabs/" + strconv.Itoa(cabID) + "/sell", sale, http.StatusCreated},
+	}
+
+	results := Results{}
+	for _, name := range Endpoints {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		results[name] = attack(ctx, client, baseURL, token, requests[name], opts)
+	}
+	return results, nil
+}
+
+// attack sends the request from opts.VUs workers until the duration elapses
+func attack(ctx context.Context, client *http.Client, baseURL, token string, req request, opts Options) Stats {
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var (
+		mu        sync.Mutex
+		durations []time.Duration
+		failures  int
+		wg        sync.WaitGroup
+	)
+	for i := 0; i < opts.VUs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				started := time.Now()
+				status, err := send(ctx, client, req.method, baseURL+req.path, token, req.body, nil)
+				elapsed := time.Since(started)
+				if ctx.Err() != nil {
+					return // Requests cut off by the end of the run are not counted
+				}
+
+				mu.Lock()
+				if err != nil || status != req.wantStatus {
+					failures++
+				} else {
+					durations = append(durations, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return summarize(durations, failures)
+}
+
+// login returns a JWT for the configured account
+func login(ctx context.Context, client *http.Client, baseURL, email, password string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"username": email, "password": password})
+	var resp struct {
+		Token string `json:"token"`
+	}
+	status, err := send(ctx, client, http.MethodPost, baseURL+"/api/users/login", "", body, &resp)
+	if err != nil {
+		return "", fmt.Errorf("login: %w", err)
+	}
+	if status != http.StatusOK || resp.Token == "" {
+		return "", fmt.Errorf("login: unexpected status %d", status)
+	}
+	return resp.Token, nil
+}
+
+// discoverSaleTargets picks the cab and customer used by the sale endpoint. Every sale
+// takes a unit out of stock, so the cab with the most stock is sold.
+func discoverSaleTargets(ctx context.Context, client *http.Client, baseURL, token string) (int, string, error) {
+	var cabs []struct {
+		ID       int `json:"id"`
+		Quantity int `json:"quantity"`
+	}
+	if status, err := send(ctx, client, http.MethodGet, baseURL+"/api/cabs", token, nil, &cabs); err != nil || status != http.StatusOK {
+		return 0, "", fmt.Errorf("could not list cabs (status %d): %v", status, err)
+	}
+	if len(cabs) == 0 {
+		return 0, "", fmt.Errorf("no cab to sell; seed the instance first")
+	}
+	cab := cabs[0]
+	for _, other := range cabs[1:] {
+		if other.Quantity > cab.Quantity {
+			cab = other
+		}
+	}
+
+	var customers struct {
+		Customers []struct {
+			ID string `json:"id"`
+		} `json:"customers"`
+	}
+	if status, err := send(ctx, client, http.MethodGet, baseURL+"/api/customers", token, nil, &customers); err != nil || status != http.StatusOK {
+		return 0, "", fmt.Errorf("could not list customers (status %d): %v", status, err)
+	}
+	if len(customers.Customers) == 0 {
+		return 0, "", fmt.Errorf("no customer to sell to; seed the instance first")
+	}
+
+	return cab.ID, customers.Customers[0].ID, nil
+}
+
+// send performs a request and decodes the JSON response into out when it is not nil
+func send(ctx context.Context, client *http.Client, method, url, token string, body []byte, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, err
+		}
+		return resp.StatusCode, nil
+	}
+	// Drain the body so the connection can be reused
+	_, err = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, err
+}
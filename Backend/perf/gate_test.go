@@ -0,0 +1,90 @@
+//go:build perf
+
+package perf
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"oop/internal/repositories/memory"
+
+	"github.com/stretchr/testify/require"
+)
+
+var updateBaseline = flag.Bool("update-baseline", false, "record the measured results as the new baseline")
+
+// TestPerformanceBudget measures a running instance and fails when latencies regress
+// beyond the budget of baseline.json. The sale endpoint writes sales, so point PERF_URL
+// at a MOCK_MODE instance or a disposable environment, never at production.
+//
+// Environment: PERF_URL (required), PERF_DRIVER (k6 or builtin, default k6 when
+// installed), PERF_VUS, PERF_DURATION, PERF_EMAIL and PERF_PASSWORD.
+func TestPerformanceBudget(t *testing.T) {
+	baseURL := os.Getenv("PERF_URL")
+	if baseURL == "" {
+		t.Skip("PERF_URL is not set")
+	}
+
+	opts := Options{
+		BaseURL:  baseURL,
+		Email:    envOr("PERF_EMAIL", memory.FixtureAdminEmail),
+		Password: envOr("PERF_PASSWORD", memory.FixtureAdminPassword),
+	}
+	if vus := os.Getenv("PERF_VUS"); vus != "" {
+		_, err := fmt.Sscan(vus, &opts.VUs)
+		require.NoError(t, err, "invalid PERF_VUS")
+	}
+	if duration := os.Getenv("PERF_DURATION"); duration != "" {
+		d, err := time.ParseDuration(duration)
+		require.NoError(t, err, "invalid PERF_DURATION")
+		opts.Duration = d
+	}
+
+	var driver Driver = BuiltinDriver{}
+	switch os.Getenv("PERF_DRIVER") {
+	case "k6":
+		driver = K6Driver{}
+	case "builtin":
+	case "":
+		if K6Available() {
+			driver = K6Driver{}
+		}
+	default:
+		t.Fatalf("unknown PERF_DRIVER %q, expected k6 or builtin", os.Getenv("PERF_DRIVER"))
+	}
+
+	results, err := driver.Run(context.Background(), opts)
+	require.NoError(t, err)
+	for _, name := range Endpoints {
+		if stats, ok := results[name]; ok {
+			t.Logf("%-17s n=%-6d med=%7.2fms p95=%7.2fms p99=%7.2fms errors=%.2f%%",
+				name, stats.Requests, stats.Median, stats.P95, stats.P99, stats.ErrorRate*100)
+		}
+	}
+
+	if *updateBaseline {
+		notes := os.Getenv("PERF_NOTES")
+		require.NoError(t, NewBaseline(driver.Name(), opts, results, notes).Save("baseline.json"))
+		t.Logf("recorded baseline.json with the %s driver", driver.Name())
+		return
+	}
+
+	baseline, err := LoadBaseline("baseline.json")
+	require.NoError(t, err)
+	regressions, err := baseline.Compare(driver.Name(), results)
+	require.NoError(t, err)
+	for _, r := range regressions {
+		t.Error(r.String())
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,119 @@
+package perf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// K6Driver runs scripts/api.js with k6. The script tags every request with its endpoint
+// name, and this driver reads the per-endpoint submetrics from k6's summary export.
+type K6Driver struct {
+	Binary string // Path to the k6 binary; defaults to "k6" on the PATH
+	Script string // Path to the k6 script; defaults to scripts/api.js next to this file
+}
+
+// Name identifies the driver in baselines
+func (K6Driver) Name() string { return "k6" }
+
+// K6Available reports whether a k6 binary can be found on the PATH
+func K6Available() bool {
+	_, err := exec.LookPath("k6")
+	return err == nil
+}
+
+// Run invokes k6 and parses its summary export
+func (d K6Driver) Run(ctx context.Context, opts Options) (Results, error) {
+	opts = opts.withDefaults()
+
+	binary := d.Binary
+	if binary == "" {
+		binary = "k6"
+	}
+	script := d.Script
+	if script == "" {
+		script = defaultScript()
+	}
+
+	summary, err := os.CreateTemp("", "k6-summary-*.json")
+	if err != nil {
+		return nil, err
+	}
+	summary.Close()
+	defer os.Remove(summary.Name())
+
+	cmd := exec.CommandContext(ctx, binary, "run",
+		"--quiet",
+		"--vus", strconv.Itoa(opts.VUs),
+		"--duration", opts.Duration.String(),
+		"--summary-export", summary.Name(),
+		"--summary-trend-stats", "avg,med,p(95),p(99),max",
+		"-e", "BASE_URL="+opts.BaseURL,
+		"-e", "EMAIL="+opts.Email,
+		"-e", "PASSWORD="+opts.Password,
+		"-e", "TIMEOUT="+opts.Timeout.String(),
+		script,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("k6 run failed: %w: %s", err, stderr.String())
+	}
+
+	data, err := os.ReadFile(summary.Name())
+	if err != nil {
+		return nil, fmt.Errorf("could not read k6 summary: %w", err)
+	}
+	return ParseK6Summary(data)
+}
+
+// defaultScript locates scripts/api.js relative to this source file
+func defaultScript() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		return filepath.Join("perf", "scripts", "api.js")
+	}
+	return filepath.Join(filepath.Dir(file), "scripts", "api.js")
+}
+
+// k6Metric is one entry of k6's --summary-export output. Trends carry the
+// requested percentiles, counters carry count and rates carry value.
+type k6Metric map[string]float64
+
+// ParseK6Summary extracts per-endpoint stats from a k6 --summary-export file.
+// Endpoints the script did not report are left out of the results.
+func ParseK6Summary(data []byte) (Results, error) {
+	var summary struct {
+		Metrics map[string]k6Metric `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("could not parse k6 summary: %w", err)
+	}
+
+	results := Results{}
+	for _, name := range Endpoints {
+		duration, ok := summary.Metrics["http_req_duration{endpoint:"+name+"}"]
+		if !ok {
+			continue
+		}
+		results[name] = Stats{
+			Requests:  int(summary.Metrics["http_reqs{endpoint:"+name+"}"]["count"]),
+			ErrorRate: summary.Metrics["http_req_failed{endpoint:"+name+"}"]["value"],
+			Avg:       round(duration["avg"]),
+			Median:    round(duration["med"]),
+			P95:       round(duration["p(95)"]),
+			P99:       round(duration["p(99)"]),
+			Max:       round(duration["max"]),
+		}
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("k6 summary has no per-endpoint metrics")
+	}
+	return results, nil
+}
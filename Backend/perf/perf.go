@@ -0,0 +1,118 @@
+// Package perf measures the latency of the list and sale endpoints of a running API
+// and compares it with a recorded baseline, so performance regressions fail a build.
+//
+// Load is generated either by k6 (scripts/api.js, invoked from Go) or, when k6 is not
+// installed, by a small built-in HTTP driver. Numbers from the two drivers are not
+// comparable, so a baseline only applies to the driver that recorded it.
+package perf
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Endpoint names used in results and baselines
+const (
+	EndpointCabsList        = "cabs_list"
+	EndpointAccessoriesList = "accessories_list"
+	EndpointMaterialsList   = "materials_list"
+	EndpointCustomersList   = "customers_list"
+	EndpointSalesList       = "sales_list"
+	EndpointSaleCreate      = "sale_create"
+)
+
+// Endpoints lists every measured endpoint, in the order they are exercised
+var Endpoints = []string{
+	EndpointCabsList,
+	EndpointAccessoriesList,
+	EndpointMaterialsList,
+	EndpointCustomersList,
+	EndpointSalesList,
+	EndpointSaleCreate,
+}
+
+// Options configures a load test run
+type Options struct {
+	BaseURL  string        // Origin of the API, without the /api prefix
+	Email    string        // Account used to log in
+	Password string        // Password of that account
+	VUs      int           // Number of concurrent virtual users
+	Duration time.Duration // How long load is applied (per endpoint for the built-in driver)
+	Timeout  time.Duration // Timeout of a single request
+}
+
+// withDefaults fills in unset options
+func (o Options) withDefaults() Options {
+	if o.VUs <= 0 {
+		o.VUs = 10
+	}
+	if o.Duration <= 0 {
+		o.Duration = 10 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	return o
+}
+
+// Stats summarizes the latencies measured for one endpoint, in milliseconds
+type Stats struct {
+	Requests  int     `json:"requests"`
+	ErrorRate float64 `json:"errorRate"` // Fraction of requests that failed or returned an unexpected status
+	Avg       float64 `json:"avgMs"`
+	Median    float64 `json:"medianMs"`
+	P95       float64 `json:"p95Ms"`
+	P99       float64 `json:"p99Ms"`
+	Max       float64 `json:"maxMs"`
+}
+
+// Results maps endpoint names to their measured stats
+type Results map[string]Stats
+
+// Driver generates load against a running API
+type Driver interface {
+	Name() string
+	Run(ctx context.Context, opts Options) (Results, error)
+}
+
+// summarize computes stats from individual request durations
+func summarize(durations []time.Duration, errors int) Stats {
+	stats := Stats{Requests: len(durations) + errors}
+	if stats.Requests > 0 {
+		stats.ErrorRate = float64(errors) / float64(stats.Requests)
+	}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	ms := make([]float64, len(durations))
+	var total float64
+	for i, d := range durations {
+		ms[i] = float64(d) / float64(time.Millisecond)
+		total += ms[i]
+	}
+	sort.Float64s(ms)
+
+	stats.Avg = round(total / float64(len(ms)))
+	stats.Median = round(percentile(ms, 0.50))
+	stats.P95 = round(percentile(ms, 0.95))
+	stats.P99 = round(percentile(ms, 0.99))
+	stats.Max = round(ms[len(ms)-1])
+	return stats
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, q float64) float64 {
+	rank := int(math.Ceil(q * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// round keeps two decimals, which is plenty for millisecond latencies
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
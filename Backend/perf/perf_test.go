@@ -0,0 +1,175 @@
+package perf
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"oop/internal/handlers"
+	"oop/internal/models"
+	"oop/internal/repositories/memory"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestServer serves the measured routes on top of a seeded in-memory store
+func startTestServer(t *testing.T) string {
+	store, err := memory.NewSeededStore()
+	require.NoError(t, err)
+	// Enough stock for every sale of the run
+	_, err = store.Cabs.AddCab(context.Background(), models.MultiCab{Name: "Perf Cab", Make: "Suzuki", UnitColor: "White", Status: "In Stock", Quantity: 1000000, Price: 150000})
+	require.NoError(t, err)
+
+	jwtSecret := []byte("perf-secret")
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	api := app.Group("/api")
+	handlers.NewUserHandler(store.Users, jwtSecret).Routes(api, handlers.NewRouteMiddleware(jwtSecret))
+	handlers.NewCustomerHandler(store.Customers, jwtSecret).Routes(api, handlers.NewRouteMiddleware(jwtSecret))
+	handlers.NewMaterialHandlers(store.Materials, jwtSecret).Routes(api, handlers.NewRouteMiddleware(jwtSecret))
+	handlers.NewSaleHandlers(store.Sales, store.Cabs, store.Accessories, store.Customers, jwtSecret).Routes(api, handlers.NewRouteMiddleware(jwtSecret))
+	api.Get("/cabs", handlers.NewCabsHandlers(store.Cabs).GetCabs)
+	api.Get("/accessories", handlers.NewAccessoriesHandler(store.Accessories).GetAllAccessories)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+
+	return "http://" + ln.Addr().String()
+}
+
+func TestSummarize(t *testing.T) {
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+
+	stats := summarize(durations, 25)
+	assert.Equal(t, 125, stats.Requests)
+	assert.Equal(t, 0.2, stats.ErrorRate)
+	assert.Equal(t, 50.5, stats.Avg)
+	assert.Equal(t, 50.0, stats.Median)
+	assert.Equal(t, 95.0, stats.P95)
+	assert.Equal(t, 99.0, stats.P99)
+	assert.Equal(t, 100.0, stats.Max)
+
+	assert.Equal(t, Stats{Requests: 3, ErrorRate: 1}, summarize(nil, 3))
+}
+
+func TestParseK6Summary(t *testing.T) {
+	summary := `{
+		"metrics": {
+			"http_req_duration": {"avg": 9, "med": 8, "p(95)": 20, "p(99)": 30, "max": 40},
+			"http_req_duration{endpoint:cabs_list}": {"avg": 4.12345, "med": 3.9, "p(95)": 7.25, "p(99)": 9.5, "max": 12.1},
+			"http_reqs{endpoint:cabs_list}": {"count": 1500, "rate": 50},
+			"http_req_failed{endpoint:cabs_list}": {"passes": 3, "fails": 1497, "value": 0.002},
+			"http_req_duration{endpoint:sale_create}": {"avg": 11, "med": 10, "p(95)": 18, "p(99)": 25, "max": 31}
+		}
+	}`
+
+	results, err := ParseK6Summary([]byte(summary))
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, Stats{Requests: 1500, ErrorRate: 0.002, Avg: 4.12, Median: 3.9, P95: 7.25, P99: 9.5, Max: 12.1}, results[EndpointCabsList])
+	assert.Equal(t, 18.0, results[EndpointSaleCreate].P95)
+
+	_, err = ParseK6Summary([]byte(`{"metrics": {"http_req_duration": {"avg": 1}}}`))
+	assert.Error(t, err)
+
+	_, err = ParseK6Summary([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestBaselineCompare(t *testing.T) {
+	baseline := &Baseline{
+		Driver: "k6",
+		Budget: Budget{Tolerance: 0.25, SlackMs: 2, MaxErrorRate: 0.01},
+		Endpoints: Results{
+			EndpointCabsList:   {Median: 4, P95: 8},
+			EndpointSaleCreate: {Median: 10, P95: 20},
+		},
+	}
+
+	t.Run("Within budget", func(t *testing.T) {
+		regressions, err := baseline.Compare("k6", Results{
+			EndpointCabsList:      {Median: 7, P95: 12},    // limits are 7 and 12
+			EndpointMaterialsList: {Median: 500, P95: 900}, // not in the baseline yet
+		})
+		require.NoError(t, err)
+		assert.Empty(t, regressions)
+	})
+
+	t.Run("Over budget", func(t *testing.T) {
+		regressions, err := baseline.Compare("k6", Results{
+			EndpointCabsList:   {Median: 4, P95: 12.5},
+			EndpointSaleCreate: {Median: 15, P95: 20, ErrorRate: 0.05},
+		})
+		require.NoError(t, err)
+		require.Len(t, regressions, 3)
+		assert.Equal(t, Regression{Endpoint: EndpointCabsList, Metric: "p95", Baseline: 8, Actual: 12.5, Limit: 12}, regressions[0])
+		assert.Equal(t, "errorRate", regressions[1].Metric)
+		assert.Equal(t, "median", regressions[2].Metric)
+		assert.Equal(t, "sale_create: median 15.00ms exceeds 14.50ms (baseline 10.00ms)", regressions[2].String())
+	})
+
+	t.Run("Other driver", func(t *testing.T) {
+		_, err := baseline.Compare("builtin", Results{})
+		assert.Error(t, err)
+	})
+}
+
+func TestBaselineSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	opts := Options{VUs: 4, Duration: 5 * time.Second}
+	results := Results{EndpointSalesList: {Requests: 10, Median: 3, P95: 5}}
+
+	require.NoError(t, NewBaseline("builtin", opts, results, "test run").Save(path))
+
+	loaded, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, "builtin", loaded.Driver)
+	assert.Equal(t, 4, loaded.VUs)
+	assert.Equal(t, "5s", loaded.Duration)
+	assert.Equal(t, DefaultBudget, loaded.Budget)
+	assert.Equal(t, results, loaded.Endpoints)
+}
+
+func TestCommittedBaselineParses(t *testing.T) {
+	baseline, err := LoadBaseline("baseline.json")
+	require.NoError(t, err)
+	for _, name := range Endpoints {
+		assert.Contains(t, baseline.Endpoints, name)
+	}
+}
+
+func TestBuiltinDriver(t *testing.T) {
+	url := startTestServer(t)
+
+	results, err := BuiltinDriver{}.Run(context.Background(), Options{
+		BaseURL:  url,
+		Email:    memory.FixtureAdminEmail,
+		Password: memory.FixtureAdminPassword,
+		VUs:      2,
+		Duration: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	for _, name := range Endpoints {
+		stats := results[name]
+		assert.Positive(t, stats.Requests, name)
+		assert.Zero(t, stats.ErrorRate, name)
+		assert.LessOrEqual(t, stats.Median, stats.P95, name)
+		assert.LessOrEqual(t, stats.P95, stats.Max, name)
+	}
+}
+
+func TestBuiltinDriverLoginFailure(t *testing.T) {
+	url := startTestServer(t)
+
+	_, err := BuiltinDriver{}.Run(context.Background(), Options{BaseURL: url, Email: "nobody@example.com", Password: "wrong"})
+	assert.ErrorContains(t, err, "login")
+}
@@ -0,0 +1,70 @@
+// k6 load script for the list and sale endpoints. Run it through the perf package
+// (make perf) so results are compared with perf/baseline.json.
+//
+// Every request is tagged with its endpoint name. The thresholds below never fail;
+// they exist so k6 reports a submetric per endpoint in its summary export.
+import http from 'k6/http';
+import { check, fail } from 'k6';
+
+const BASE_URL = (__ENV.BASE_URL || 'http://localhost:8080').replace(/\/+$/, '');
+const EMAIL = __ENV.EMAIL || 'admin@example.com';
+const PASSWORD = __ENV.PASSWORD || 'admin123';
+const TIMEOUT = __ENV.TIMEOUT || '10s';
+
+const ENDPOINTS = [
+  'cabs_list',
+  'accessories_list',
+  'materials_list',
+  'customers_list',
+  'sales_list',
+  'sale_create',
+];
+
+const thresholds = {};
+for (const name of ENDPOINTS) {
+  thresholds[`http_req_duration{endpoint:${name}}`] = ['max>=0'];
+  thresholds[`http_req_failed{endpoint:${name}}`] = ['rate>=0'];
+  thresholds[`http_reqs{endpoint:${name}}`] = ['count>=0'];
+}
+
+export const options = { thresholds };
+
+export function setup() {
+  const login = http.post(`${BASE_URL}/api/users/login`, JSON.stringify({ username: EMAIL, password: PASSWORD }), {
+    headers: { 'Content-Type': 'application/json' },
+  });
+  if (login.status !== 200) {
+    fail(`login failed with status ${login.status}`);
+  }
+  const token = login.json('token');
+  const params = { headers: { Authorization: `Bearer ${token}` } };
+
+  const cabs = http.get(`${BASE_URL}/api/cabs`, params).json();
+  const customers = http.get(`${BASE_URL}/api/customers`, params).json('customers');
+  if (!cabs || cabs.length === 0 || !customers || customers.length === 0) {
+    fail('the instance needs at least one cab and one customer; seed it first');
+  }
+
+  return { token, cabId: cabs[0].id, customerId: customers[0].id };
+}
+
+export default function (data) {
+  const headers = { Authorization: `Bearer ${data.token}`, 'Content-Type': 'application/json' };
+  const get = (name, path) => {
+    const res = http.get(`${BASE_URL}${path}`, { headers, tags: { endpoint: name }, timeout: TIMEOUT });
+    check(res, { [`${name} status is 200`]: (r) => r.status === 200 });
+  };
+
+  get('cabs_list', '/api/cabs');
+  get('accessories_list', '/api/accessories');
+  get('materials_list', '/api/materials');
+  get('customers_list', '/api/customers');
+  get('sales_list', '/api/sales');
+
+  const sale = http.post(
+    `${BASE_URL}/api/cabs/${data.cabId}/sell`,
+    JSON.stringify({ customerId: data.customerId, quantity: 1 }),
+    { headers, tags: { endpoint: 'sale_create' }, timeout: TIMEOUT, responseCallback: http.expectedStatuses(201) },
+  );
+  check(sale, { 'sale_create status is 201': (r) => r.status === 201 });
+}